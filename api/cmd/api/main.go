@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -16,7 +17,9 @@ import (
 	"github.com/gestaozabele/municipio/internal/auth"
 	"github.com/gestaozabele/municipio/internal/config"
 	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/errtrack"
 	internalhttp "github.com/gestaozabele/municipio/internal/http"
+	"github.com/gestaozabele/municipio/internal/metrics"
 	"github.com/gestaozabele/municipio/internal/repo"
 	"github.com/gestaozabele/municipio/internal/saas"
 	"github.com/gestaozabele/municipio/internal/service"
@@ -36,9 +39,31 @@ func run() error {
 		return fmt.Errorf("config: %w", err)
 	}
 
+	tracker, err := errtrack.New(errtrack.Config{
+		DSN:         cfg.ErrorTracking.DSN,
+		Environment: cfg.ErrorTracking.Environment,
+		Release:     cfg.ErrorTracking.Release,
+		SampleRate:  cfg.ErrorTracking.SampleRate,
+	})
+	switch {
+	case err == nil:
+		log.Logger = log.Logger.Hook(errtrack.Hook{Client: tracker})
+		defer tracker.Close()
+	case !errors.Is(err, errtrack.ErrNotConfigured):
+		return fmt.Errorf("errtrack: %w", err)
+	}
+
 	ctx := context.Background()
 
-	pool, err := db.NewPool(ctx, cfg.DBDSN)
+	pool, err := db.NewPool(ctx, cfg.DBDSN, db.PoolOptions{
+		MaxConns:               int32(cfg.DBPool.MaxConns),
+		MinConns:               int32(cfg.DBPool.MinConns),
+		MaxConnLifetime:        cfg.DBPool.MaxConnLifetime,
+		MaxConnIdleTime:        cfg.DBPool.MaxConnIdleTime,
+		HealthCheckPeriod:      cfg.DBPool.HealthCheckPeriod,
+		StatementCacheMode:     cfg.DBPool.StatementCacheMode,
+		StatementCacheCapacity: cfg.DBPool.StatementCacheCapacity,
+	})
 	if err != nil {
 		return fmt.Errorf("db: %w", err)
 	}
@@ -49,6 +74,7 @@ func run() error {
 		return fmt.Errorf("redis parse: %w", err)
 	}
 	redisClient := redis.NewClient(redisOpts)
+	redisClient.AddHook(metrics.RedisHook{})
 	defer redisClient.Close()
 
 	repository := repo.New(pool)
@@ -56,7 +82,7 @@ func run() error {
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret, cfg.JWTAccessTTL)
 	authService := service.NewAuthService(repository, saasRepo, pool, redisClient, jwtManager, cfg.JWTRefreshTTL)
 
-	handler, err := internalhttp.NewRouter(cfg, pool, redisClient, authService)
+	handler, err := internalhttp.NewRouter(cfg, pool, redisClient, authService, tracker)
 	if err != nil {
 		return fmt.Errorf("router: %w", err)
 	}
@@ -0,0 +1,88 @@
+// Command auditverify confere, sem acesso ao banco, uma exportação da trilha de auditoria
+// (GET /saas/audit/export) contra as âncoras baixadas do armazenamento de objetos.
+//
+//	auditverify [-anchors arquivo.json ...] auditoria.ndjson
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/audit"
+)
+
+type anchorFiles []string
+
+func (a *anchorFiles) String() string     { return strings.Join(*a, ",") }
+func (a *anchorFiles) Set(v string) error { *a = append(*a, v); return nil }
+
+func main() {
+	var anchors anchorFiles
+	flag.Var(&anchors, "anchors", "arquivo de âncora (audit/anchors/*.json); pode repetir")
+	flag.Parse()
+
+	in := io.Reader(os.Stdin)
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	hashes := map[int64]string{}
+	for _, path := range anchors {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			fail(err)
+		}
+		var a audit.Anchor
+		if err := json.Unmarshal(raw, &a); err != nil {
+			fail(fmt.Errorf("%s: %w", path, err))
+		}
+		hashes[a.Seq] = a.Hash
+	}
+
+	verifier := audit.NewVerifier(hashes)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 1<<20), 16<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e audit.Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			fail(fmt.Errorf("linha %d: %w", verifier.Checked+1, err))
+		}
+		if err := verifier.Add(e); err != nil {
+			var chainErr *audit.ChainError
+			if errors.As(err, &chainErr) {
+				fmt.Printf("INVÁLIDA: seq %d: %s (%d registros conferidos antes)\n", chainErr.Seq, chainErr.Reason, verifier.Checked)
+				os.Exit(2)
+			}
+			fail(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fail(err)
+	}
+	if len(hashes) > verifier.Anchored {
+		fmt.Printf("INCOMPLETA: %d de %d âncoras não estão no intervalo exportado\n", len(hashes)-verifier.Anchored, len(hashes))
+		os.Exit(2)
+	}
+	fmt.Printf("OK: %d registros (seq %d a %d), %d âncoras conferidas, último hash %s\n",
+		verifier.Checked, verifier.FirstSeq, verifier.LastSeq, verifier.Anchored, verifier.LastHash)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "auditverify:", err)
+	os.Exit(1)
+}
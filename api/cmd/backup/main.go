@@ -0,0 +1,193 @@
+// Command backup gera dumps lógicos do banco no armazenamento de objetos, aplica a retenção e
+// executa testes de restauração. Pensado para rodar em cron; cada execução fica em backup_runs
+// e aparece em GET /saas/settings/backups.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/backup"
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	_ = godotenv.Load()
+
+	ctx := context.Background()
+
+	dsn := strings.TrimSpace(os.Getenv("DB_DSN"))
+	if dsn == "" {
+		dsn = strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	}
+	if dsn == "" {
+		log.Fatal().Msg("defina DB_DSN ou DATABASE_URL")
+	}
+
+	uploader, err := storage.NewS3Uploader(storage.S3Config{
+		Endpoint:  strings.TrimSpace(os.Getenv("STORAGE_S3_ENDPOINT")),
+		Region:    strings.TrimSpace(os.Getenv("STORAGE_S3_REGION")),
+		Bucket:    firstEnv("BACKUP_S3_BUCKET", "STORAGE_S3_BUCKET"),
+		AccessKey: strings.TrimSpace(os.Getenv("STORAGE_S3_ACCESS_KEY")),
+		SecretKey: strings.TrimSpace(os.Getenv("STORAGE_S3_SECRET_KEY")),
+		// dumps grandes levam mais que o timeout padrão do uploader
+		HTTPClient: &http.Client{Timeout: 30 * time.Minute},
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("storage não configurado")
+	}
+
+	retention, err := time.ParseDuration(envOr("BACKUP_RETENTION", "720h"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("BACKUP_RETENTION inválido")
+	}
+	keepMin, err := strconv.Atoi(envOr("BACKUP_KEEP_MIN", "3"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("BACKUP_KEEP_MIN inválido")
+	}
+
+	pool, err := db.NewPool(ctx, dsn, db.DefaultPoolOptions())
+	if err != nil {
+		log.Fatal().Err(err).Msg("não foi possível conectar ao banco")
+	}
+	defer pool.Close()
+
+	runner := backup.NewRunner(pool, uploader, backup.Config{
+		DSN:       dsn,
+		PgDump:    strings.TrimSpace(os.Getenv("PG_DUMP_BIN")),
+		PgRestore: strings.TrimSpace(os.Getenv("PG_RESTORE_BIN")),
+		DrillDSN:  strings.TrimSpace(os.Getenv("BACKUP_DRILL_DSN")),
+		Retention: retention,
+		KeepMin:   keepMin,
+	}, log.With().Str("component", "backup").Logger())
+
+	cmd := os.Args[1]
+	args := os.Args[2:]
+
+	switch cmd {
+	case "run":
+		if err := runDump(ctx, runner, args); err != nil {
+			log.Fatal().Err(err).Msg("falha no backup")
+		}
+	case "prune":
+		expired, err := runner.Prune(ctx, time.Now())
+		if err != nil {
+			log.Fatal().Err(err).Msg("falha na retenção")
+		}
+		log.Info().Int("expirados", expired).Msg("retenção aplicada")
+	case "drill":
+		if err := runDrill(ctx, runner, args); err != nil {
+			log.Fatal().Err(err).Msg("falha no teste de restauração")
+		}
+	case "status":
+		status, err := backup.LoadStatus(ctx, pool)
+		if err != nil {
+			log.Fatal().Err(err).Msg("falha ao consultar backups")
+		}
+		encoded, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(encoded))
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "backup CLI")
+	fmt.Fprintln(os.Stderr, "uso:")
+	fmt.Fprintln(os.Stderr, "  backup run [--schemas public,outro] [--tenants=false] [--tenant slug]")
+	fmt.Fprintln(os.Stderr, "  backup prune")
+	fmt.Fprintln(os.Stderr, "  backup drill [--escopo schema:public|tenant:slug]")
+	fmt.Fprintln(os.Stderr, "  backup status")
+	fmt.Fprintln(os.Stderr, "variáveis: BACKUP_SCHEMAS, BACKUP_RETENTION (720h), BACKUP_KEEP_MIN (3), BACKUP_DRILL_DSN,")
+	fmt.Fprintln(os.Stderr, "  BACKUP_S3_BUCKET (padrão STORAGE_S3_BUCKET), PG_DUMP_BIN, PG_RESTORE_BIN")
+}
+
+func runDump(ctx context.Context, runner *backup.Runner, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		schemas = fs.String("schemas", envOr("BACKUP_SCHEMAS", "public"), "schemas com dump completo, separados por vírgula")
+		tenants = fs.Bool("tenants", true, "gera também o dump lógico de cada tenant ativo")
+		slug    = fs.String("tenant", "", "gera apenas o dump deste tenant")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *slug != "" {
+		run, err := runner.DumpTenantSlug(ctx, *slug)
+		if err != nil {
+			return err
+		}
+		log.Info().Str("key", *run.ObjectKey).Msg("backup do tenant concluído")
+		return nil
+	}
+
+	var list []string
+	for _, schema := range strings.Split(*schemas, ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			list = append(list, schema)
+		}
+	}
+	if len(list) == 0 && !*tenants {
+		return errors.New("nada a fazer: informe schemas ou habilite tenants")
+	}
+	return runner.RunAll(ctx, list, *tenants)
+}
+
+func runDrill(ctx context.Context, runner *backup.Runner, args []string) error {
+	fs := flag.NewFlagSet("drill", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	escopo := fs.String("escopo", "", "escopo do dump (vazio usa o mais recente)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	run, err := runner.Drill(ctx, *escopo)
+	if err != nil {
+		return err
+	}
+	encoded, _ := json.MarshalIndent(run, "", "  ")
+	fmt.Println(string(encoded))
+	return nil
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/dbmigrate"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	_ = godotenv.Load()
+
+	cmd := os.Args[1]
+	args := os.Args[2:]
+
+	// lint não precisa de banco: roda no CI antes do merge.
+	if cmd == "lint" {
+		if err := runLint(args); err != nil {
+			log.Fatal().Err(err).Msg("migrações reprovadas")
+		}
+		return
+	}
+
+	ctx := context.Background()
+	dsn := strings.TrimSpace(os.Getenv("DB_DSN"))
+	if dsn == "" {
+		dsn = strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	}
+	if dsn == "" {
+		log.Fatal().Msg("defina DB_DSN ou DATABASE_URL")
+	}
+	pool, err := db.NewPool(ctx, dsn, db.PoolOptions{MaxConns: 2})
+	if err != nil {
+		log.Fatal().Err(err).Msg("não foi possível conectar ao banco")
+	}
+	defer pool.Close()
+	runner := dbmigrate.NewRunner(pool, log.Logger)
+
+	switch cmd {
+	case "status":
+		err = runStatus(ctx, runner, args)
+	case "up":
+		err = runUp(ctx, runner, args)
+	case "flag":
+		err = runFlag(ctx, runner, args)
+	default:
+		usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msgf("falha em %s", cmd)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "migrate CLI (travas de zero downtime)")
+	fmt.Fprintln(os.Stderr, "uso:")
+	fmt.Fprintln(os.Stderr, "  migrate lint [--dir migrations]")
+	fmt.Fprintln(os.Stderr, "  migrate status [--dir migrations]")
+	fmt.Fprintln(os.Stderr, "  migrate up [--dir migrations] [--fase expand|contract] [--dry-run] [--linhas-grandes 100000] [--lock-timeout 5s]")
+	fmt.Fprintln(os.Stderr, "  migrate flag list")
+	fmt.Fprintln(os.Stderr, "  migrate flag set --nome coluna_nova --ativa=true [--descricao texto]")
+}
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	dir := fs.String("dir", "migrations", "diretório das migrações")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	migs, err := dbmigrate.LoadDir(*dir)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, mig := range migs {
+		if mig.Version < dbmigrate.GuardrailsDesde {
+			continue
+		}
+		for _, f := range dbmigrate.Lint(mig) {
+			fmt.Println(f.String())
+			total++
+		}
+	}
+	if total > 0 {
+		return fmt.Errorf("%d problema(s); corrija ou libere com -- migrate:allow regra motivo", total)
+	}
+	log.Info().Int("migracoes", len(migs)).Msg("nenhum problema encontrado")
+	return nil
+}
+
+func runStatus(ctx context.Context, runner *dbmigrate.Runner, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	dir := fs.String("dir", "migrations", "diretório das migrações")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	migs, err := dbmigrate.LoadDir(*dir)
+	if err != nil {
+		return err
+	}
+	version, dirty, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pendentes []string
+	for _, mig := range migs {
+		if mig.Version > version {
+			item := fmt.Sprintf("%03d_%s (%s)", mig.Version, mig.Name, mig.Phase)
+			if mig.Flag != "" {
+				item += " flag " + mig.Flag
+			}
+			pendentes = append(pendentes, item)
+		}
+	}
+	encoded, _ := json.MarshalIndent(map[string]any{
+		"versao":     version,
+		"suja":       dirty,
+		"pendentes":  pendentes,
+		"guardrails": dbmigrate.GuardrailsDesde,
+	}, "", "  ")
+	fmt.Println(string(encoded))
+	return nil
+}
+
+func runUp(ctx context.Context, runner *dbmigrate.Runner, args []string) error {
+	fs := flag.NewFlagSet("up", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var (
+		dir         = fs.String("dir", "migrations", "diretório das migrações")
+		fase        = fs.String("fase", dbmigrate.PhaseExpand, "expand (antes do deploy) ou contract (depois)")
+		dryRun      = fs.Bool("dry-run", false, "só confere e lista o que seria aplicado")
+		linhas      = fs.Int64("linhas-grandes", runner.BigRows, "a partir de quantas linhas a tabela conta como grande")
+		lockTimeout = fs.Duration("lock-timeout", runner.LockTimeout, "espera máxima por lock em cada migração")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *lockTimeout <= 0 {
+		return errors.New("lock-timeout deve ser positivo")
+	}
+	runner.BigRows = *linhas
+	runner.LockTimeout = *lockTimeout
+
+	migs, err := dbmigrate.LoadDir(*dir)
+	if err != nil {
+		return err
+	}
+	applied, err := runner.Up(ctx, migs, strings.ToLower(*fase), *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, mig := range applied {
+		prefix := "aplicada"
+		if *dryRun {
+			prefix = "pendente"
+		}
+		fmt.Printf("%s: %03d_%s (%s)\n", prefix, mig.Version, mig.Name, mig.Phase)
+	}
+	if len(applied) == 0 {
+		log.Info().Msg("nada a aplicar")
+	}
+	return nil
+}
+
+func runFlag(ctx context.Context, runner *dbmigrate.Runner, args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errors.New("informe list ou set")
+	}
+	switch args[0] {
+	case "list":
+		flags, err := runner.ListFlags(ctx)
+		if err != nil {
+			return err
+		}
+		encoded, _ := json.MarshalIndent(flags, "", "  ")
+		fmt.Println(string(encoded))
+		return nil
+	case "set":
+		fs := flag.NewFlagSet("flag set", flag.ContinueOnError)
+		fs.SetOutput(os.Stderr)
+		var (
+			nome      = fs.String("nome", "", "nome da flag")
+			ativa     = fs.Bool("ativa", false, "liga (true) ou desliga (false)")
+			descricao = fs.String("descricao", "", "descrição, para o registro")
+		)
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if strings.TrimSpace(*nome) == "" {
+			return errors.New("nome é obrigatório")
+		}
+		if err := runner.SetFlag(ctx, strings.TrimSpace(*nome), *ativa, *descricao); err != nil {
+			return err
+		}
+		log.Info().Str("flag", *nome).Bool("ativa", *ativa).Msg("flag atualizada")
+		return nil
+	default:
+		usage()
+		return fmt.Errorf("subcomando desconhecido: %s", args[0])
+	}
+}
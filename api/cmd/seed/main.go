@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/demo"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	var (
+		slug     = fs.String("tenant", "", "slug do tenant sandbox a popular")
+		wipe     = fs.Bool("wipe", false, "remove os dados sintéticos em vez de gerar")
+		seed     = fs.Int64("seed", 0, "semente do gerador (0 = aleatória)")
+		citizens = fs.Int("citizens", 0, "quantidade de cidadãos")
+		staff    = fs.Int("staff", 0, "quantidade de usuários do backoffice")
+		schools  = fs.Int("schools", 0, "quantidade de escolas")
+		turmas   = fs.Int("turmas", 0, "turmas por escola")
+		students = fs.Int("students", 0, "alunos por turma")
+		days     = fs.Int("days", 0, "dias úteis de aulas com chamada")
+		password = fs.String("staff-password", "", "senha dos usuários gerados (vazio = sem acesso)")
+		force    = fs.Bool("force", false, "permite popular tenant fora do sandbox")
+	)
+	_ = fs.Parse(os.Args[1:])
+
+	if strings.TrimSpace(*slug) == "" {
+		fmt.Fprintln(os.Stderr, "uso: seed --tenant cidade [--wipe] [--citizens 200] [--schools 3] [--turmas 4] [--students 25] [--days 10]")
+		os.Exit(1)
+	}
+
+	_ = godotenv.Load()
+
+	ctx := context.Background()
+
+	dsn := strings.TrimSpace(os.Getenv("DB_DSN"))
+	if dsn == "" {
+		dsn = strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	}
+	if dsn == "" {
+		log.Fatal().Msg("defina DB_DSN ou DATABASE_URL")
+	}
+
+	pool, err := db.NewPool(ctx, dsn, db.DefaultPoolOptions())
+	if err != nil {
+		log.Fatal().Err(err).Msg("não foi possível conectar ao banco")
+	}
+	defer pool.Close()
+
+	tenants := tenant.NewService(tenant.NewRepository(pool))
+	target, err := tenants.GetBySlug(ctx, *slug)
+	if err != nil {
+		log.Fatal().Err(err).Str("tenant", *slug).Msg("tenant não encontrado")
+	}
+	if !target.Sandbox && !*force {
+		log.Fatal().Str("tenant", target.Slug).Msg("tenant não está em sandbox; use --force para continuar")
+	}
+
+	seeder := demo.NewSeeder(pool)
+
+	if *wipe {
+		removed, err := seeder.Wipe(ctx, target.ID)
+		if err != nil {
+			log.Fatal().Err(err).Msg("falha ao remover dados sintéticos")
+		}
+		log.Info().Int64("removidos", removed).Str("tenant", target.Slug).Msg("dados sintéticos removidos")
+		return
+	}
+
+	summary, err := seeder.Seed(ctx, demo.Target{TenantID: target.ID, Slug: target.Slug, Domain: target.Domain}, demo.Options{
+		Seed:             *seed,
+		Citizens:         *citizens,
+		Staff:            *staff,
+		Schools:          *schools,
+		TurmasPerSchool:  *turmas,
+		StudentsPerTurma: *students,
+		Days:             *days,
+		StaffPassword:    *password,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("falha ao gerar dados sintéticos")
+	}
+
+	output, _ := json.MarshalIndent(summary, "", "  ")
+	fmt.Println(string(output))
+}
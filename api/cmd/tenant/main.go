@@ -10,12 +10,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/gestaozabele/municipio/internal/db"
 	"github.com/gestaozabele/municipio/internal/tenant"
+	"github.com/gestaozabele/municipio/internal/tenantmove"
 )
 
 func main() {
@@ -38,7 +41,7 @@ func main() {
 		log.Fatal().Msg("defina DB_DSN ou DATABASE_URL")
 	}
 
-	pool, err := db.NewPool(ctx, dsn)
+	pool, err := db.NewPool(ctx, dsn, db.DefaultPoolOptions())
 	if err != nil {
 		log.Fatal().Err(err).Msg("não foi possível conectar ao banco")
 	}
@@ -59,6 +62,10 @@ func main() {
 		if err := runList(ctx, service); err != nil {
 			log.Fatal().Err(err).Msg("falha ao listar tenants")
 		}
+	case "move":
+		if err := runMove(ctx, pool, args); err != nil {
+			log.Fatal().Err(err).Msg("falha na migração do tenant")
+		}
 	default:
 		usage()
 		os.Exit(1)
@@ -71,6 +78,10 @@ func usage() {
 	fmt.Fprintln(os.Stderr, "  tenant create --slug cidade --name \"Prefeitura\" --domain cidade.urbanbyte.com.br [--settings-file settings.json]")
 	fmt.Fprintln(os.Stderr, "  tenant create --slug cidade --name \"Prefeitura\" --domain cidade.urbanbyte.com.br --settings '{\\\"corPrimaria\\\":\\\"#123456\\\"}'")
 	fmt.Fprintln(os.Stderr, "  tenant list")
+	fmt.Fprintln(os.Stderr, "  tenant move start --slug cidade --destino cluster2 [--excluir tabela1,tabela2] [--por email]")
+	fmt.Fprintln(os.Stderr, "  tenant move copy|verify|switch|cancel --id <migração> [--reset] [--espera 60s]")
+	fmt.Fprintln(os.Stderr, "  tenant move retire --id <migração> --confirmar cidade")
+	fmt.Fprintln(os.Stderr, "  tenant move status [--slug cidade]")
 }
 
 func runCreate(ctx context.Context, service *tenant.Service, args []string) error {
@@ -138,3 +149,120 @@ func runList(ctx context.Context, service *tenant.Service) error {
 	fmt.Println(string(encoded))
 	return nil
 }
+
+// runMove conduz uma migração blue/green do tenant entre clusters (DB_CLUSTERS). A ordem é
+// start → copy → verify → switch → retire; o switch congela o tenant enquanto confere a cópia e
+// troca o roteamento.
+func runMove(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errors.New("informe a etapa da migração")
+	}
+	clusters, err := db.ParseClusters(os.Getenv("DB_CLUSTERS"))
+	if err != nil {
+		return err
+	}
+	// O switch congela o tenant e espera o roteamento em cache nas instâncias da API expirar,
+	// mais uma folga para as requisições em andamento.
+	ttl := 30 * time.Second
+	if raw := strings.TrimSpace(os.Getenv("DB_ROUTING_TTL")); raw != "" {
+		if ttl, err = time.ParseDuration(raw); err != nil {
+			return fmt.Errorf("DB_ROUTING_TTL: %w", err)
+		}
+	}
+
+	step := args[0]
+	fs := flag.NewFlagSet("move "+step, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var (
+		espera    = fs.Duration("espera", ttl+30*time.Second, "quanto o switch aguarda com o tenant congelado antes de verificar")
+		slug      = fs.String("slug", "", "slug do tenant")
+		destino   = fs.String("destino", "", "cluster de destino (nome em DB_CLUSTERS ou primary)")
+		excluir   = fs.String("excluir", "", "tabelas extras que não devem ser copiadas, separadas por vírgula")
+		por       = fs.String("por", "", "responsável pela migração, para o registro")
+		rawID     = fs.String("id", "", "id da migração")
+		reset     = fs.Bool("reset", false, "apaga os dados do tenant no destino antes de copiar")
+		confirmar = fs.String("confirmar", "", "slug do tenant, obrigatório para retire")
+	)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	mover := tenantmove.NewMover(pool, clusters, *espera, log.Logger)
+	defer mover.Close()
+
+	var mig tenantmove.Migration
+	switch step {
+	case "start":
+		if *slug == "" || *destino == "" {
+			return errors.New("slug e destino são obrigatórios")
+		}
+		var tabelas []string
+		for _, t := range strings.Split(*excluir, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tabelas = append(tabelas, t)
+			}
+		}
+		mig, err = mover.Start(ctx, *slug, strings.ToLower(*destino), tabelas, *por)
+	case "status":
+		var tenantID *uuid.UUID
+		if *slug != "" {
+			var id uuid.UUID
+			if err := pool.QueryRow(ctx, `SELECT id FROM tenants WHERE slug = $1`, *slug).Scan(&id); err != nil {
+				return fmt.Errorf("tenant %s: %w", *slug, err)
+			}
+			tenantID = &id
+		}
+		migs, err := tenantmove.List(ctx, pool, tenantID, 20)
+		if err != nil {
+			return err
+		}
+		encoded, _ := json.MarshalIndent(migs, "", "  ")
+		fmt.Println(string(encoded))
+		return nil
+	case "copy", "verify", "switch", "retire", "cancel":
+		id, err := uuid.Parse(*rawID)
+		if err != nil {
+			return errors.New("id da migração inválido")
+		}
+		switch step {
+		case "copy":
+			mig, err = mover.Copy(ctx, id, *reset)
+		case "verify":
+			mig, err = mover.Verify(ctx, id)
+		case "switch":
+			mig, err = mover.Switch(ctx, id)
+		case "cancel":
+			mig, err = mover.Cancel(ctx, id)
+		case "retire":
+			current, getErr := tenantmove.Get(ctx, pool, id)
+			if getErr != nil {
+				return getErr
+			}
+			if *confirmar != current.TenantSlug {
+				return fmt.Errorf("retire apaga os dados em %s; confirme com --confirmar %s", current.Origem, current.TenantSlug)
+			}
+			mig, err = mover.Retire(ctx, id)
+		}
+		if err != nil {
+			// Divergências da verificação ficam no registro; mostrar as tabelas ajuda a decidir
+			// entre repetir a cópia com --reset ou investigar.
+			if errors.Is(err, tenantmove.ErrVerificacao) {
+				if current, getErr := tenantmove.Get(ctx, pool, id); getErr == nil {
+					encoded, _ := json.MarshalIndent(current.Tabelas, "", "  ")
+					fmt.Println(string(encoded))
+				}
+			}
+			return err
+		}
+	default:
+		usage()
+		return fmt.Errorf("etapa desconhecida: %s", step)
+	}
+	if err != nil {
+		return err
+	}
+
+	encoded, _ := json.MarshalIndent(mig, "", "  ")
+	fmt.Println(string(encoded))
+	return nil
+}
@@ -0,0 +1,123 @@
+package address
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrInvalidCEP indica CEP sem 8 dígitos.
+	ErrInvalidCEP = errors.New("CEP inválido")
+	// ErrNotFound indica CEP inexistente nos provedores.
+	ErrNotFound = errors.New("CEP não encontrado")
+)
+
+// Address é um endereço brasileiro no formato usado para cadastro e protocolos.
+type Address struct {
+	CEP         string   `json:"cep"`
+	Logradouro  string   `json:"logradouro"`
+	Numero      string   `json:"numero,omitempty"`
+	Complemento string   `json:"complemento,omitempty"`
+	Bairro      string   `json:"bairro"`
+	Cidade      string   `json:"cidade"`
+	UF          string   `json:"uf"`
+	IBGE        string   `json:"ibge,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	Fonte       string   `json:"fonte,omitempty"`
+}
+
+// NormalizeCEP devolve os 8 dígitos do CEP, aceitando pontos, hífen e espaços.
+func NormalizeCEP(raw string) (string, error) {
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '.' || unicode.IsSpace(r):
+		default:
+			return "", ErrInvalidCEP
+		}
+	}
+	cep := b.String()
+	if len(cep) != 8 || cep == "00000000" {
+		return "", ErrInvalidCEP
+	}
+	return cep, nil
+}
+
+// FormatCEP formata 8 dígitos como 00000-000.
+func FormatCEP(cep string) string {
+	if len(cep) != 8 {
+		return cep
+	}
+	return cep[:5] + "-" + cep[5:]
+}
+
+// abbreviations expande os tipos de logradouro abreviados mais comuns.
+var abbreviations = map[string]string{
+	"r":    "Rua",
+	"av":   "Avenida",
+	"trav": "Travessa",
+	"tv":   "Travessa",
+	"al":   "Alameda",
+	"pc":   "Praça",
+	"pça":  "Praça",
+	"rod":  "Rodovia",
+	"est":  "Estrada",
+	"lgo":  "Largo",
+	"sit":  "Sítio",
+	"faz":  "Fazenda",
+}
+
+// lowerWords ficam minúsculas no meio do nome ("Rua da Paz", "Vila dos Remédios").
+var lowerWords = map[string]struct{}{
+	"da": {}, "das": {}, "de": {}, "do": {}, "dos": {}, "e": {},
+}
+
+// Normalize padroniza o endereço antes de gravar: CEP formatado, UF maiúscula, espaços
+// colapsados, tipo de logradouro por extenso e capitalização de nomes próprios.
+func Normalize(a Address) Address {
+	if cep, err := NormalizeCEP(a.CEP); err == nil {
+		a.CEP = FormatCEP(cep)
+	}
+	a.Logradouro = expandStreetType(titleCase(a.Logradouro))
+	a.Numero = strings.ToUpper(collapse(a.Numero))
+	a.Complemento = collapse(a.Complemento)
+	a.Bairro = titleCase(a.Bairro)
+	a.Cidade = titleCase(a.Cidade)
+	a.UF = strings.ToUpper(collapse(a.UF))
+	return a
+}
+
+func collapse(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func titleCase(value string) string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, word := range words {
+		if _, lower := lowerWords[word]; lower && i > 0 {
+			continue
+		}
+		// Códigos como "BR-230" e "PB-008" ficam em maiúsculas.
+		if strings.ContainsAny(word, "0123456789") {
+			words[i] = strings.ToUpper(word)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+func expandStreetType(street string) string {
+	first, rest, _ := strings.Cut(street, " ")
+	key := strings.ToLower(strings.TrimSuffix(first, "."))
+	if full, ok := abbreviations[key]; ok && rest != "" {
+		return full + " " + rest
+	}
+	return street
+}
@@ -0,0 +1,83 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	got := Normalize(Address{
+		CEP:        "58.540 000",
+		Logradouro: "  av.  JOÃO   da silva ",
+		Numero:     "12a",
+		Bairro:     "centro",
+		Cidade:     "ZABELÊ",
+		UF:         "pb",
+	})
+	want := Address{CEP: "58540-000", Logradouro: "Avenida João da Silva", Numero: "12A", Bairro: "Centro", Cidade: "Zabelê", UF: "PB"}
+	if got != want {
+		t.Fatalf("Normalize = %+v, want %+v", got, want)
+	}
+	if got := Normalize(Address{Logradouro: "rod. br-230"}).Logradouro; got != "Rodovia BR-230" {
+		t.Fatalf("rodovia = %q", got)
+	}
+}
+
+func TestNormalizeCEP(t *testing.T) {
+	if cep, err := NormalizeCEP("01001-000"); err != nil || cep != "01001000" {
+		t.Fatalf("NormalizeCEP = %q, %v", cep, err)
+	}
+	for _, raw := range []string{"0100100", "01001-00a", "00000-000"} {
+		if _, err := NormalizeCEP(raw); !errors.Is(err, ErrInvalidCEP) {
+			t.Errorf("NormalizeCEP(%q) = %v", raw, err)
+		}
+	}
+}
+
+func TestLookupFallsBackToBrasilAPI(t *testing.T) {
+	via := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer via.Close()
+	brasil := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/01001000" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Sé","street":"Praça da Sé",
+			"location":{"coordinates":{"latitude":"-23.5503","longitude":"-46.6342"}}}`))
+	}))
+	defer brasil.Close()
+
+	svc := New(nil, Config{ViaCEPBase: via.URL, BrasilAPIBase: brasil.URL})
+	addr, err := svc.Lookup(context.Background(), "01001-000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Fonte != "brasilapi" || addr.CEP != "01001-000" || addr.Logradouro != "Praça da Sé" || addr.Latitude == nil {
+		t.Fatalf("addr = %+v", addr)
+	}
+
+	if _, err := svc.Lookup(context.Background(), "99999-999"); err == nil {
+		t.Fatal("esperava erro para CEP inexistente com provedor fora do ar")
+	}
+}
+
+func TestLookupNotFound(t *testing.T) {
+	via := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"erro": "true"}`))
+	}))
+	defer via.Close()
+	brasil := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer brasil.Close()
+
+	svc := New(nil, Config{ViaCEPBase: via.URL, BrasilAPIBase: brasil.URL})
+	if _, err := svc.Lookup(context.Background(), "99999999"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
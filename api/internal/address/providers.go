@@ -0,0 +1,130 @@
+package address
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultViaCEPBase    = "https://viacep.com.br/ws"
+	defaultBrasilAPIBase = "https://brasilapi.com.br/api/cep/v2"
+)
+
+type provider interface {
+	name() string
+	lookup(ctx context.Context, cep string) (*Address, error)
+}
+
+type viaCEP struct {
+	client *http.Client
+	base   string
+}
+
+func (p viaCEP) name() string { return "viacep" }
+
+func (p viaCEP) lookup(ctx context.Context, cep string) (*Address, error) {
+	var payload struct {
+		CEP         string `json:"cep"`
+		Logradouro  string `json:"logradouro"`
+		Complemento string `json:"complemento"`
+		Bairro      string `json:"bairro"`
+		Localidade  string `json:"localidade"`
+		UF          string `json:"uf"`
+		IBGE        string `json:"ibge"`
+		// Erro vem como true ou "true" conforme a versão da API.
+		Erro any `json:"erro"`
+	}
+	if err := getJSON(ctx, p.client, p.base+"/"+cep+"/json/", &payload); err != nil {
+		return nil, err
+	}
+	if payload.Erro != nil && fmt.Sprint(payload.Erro) != "false" {
+		return nil, ErrNotFound
+	}
+	return &Address{
+		CEP:         payload.CEP,
+		Logradouro:  payload.Logradouro,
+		Complemento: payload.Complemento,
+		Bairro:      payload.Bairro,
+		Cidade:      payload.Localidade,
+		UF:          payload.UF,
+		IBGE:        payload.IBGE,
+		Fonte:       p.name(),
+	}, nil
+}
+
+type brasilAPI struct {
+	client *http.Client
+	base   string
+}
+
+func (p brasilAPI) name() string { return "brasilapi" }
+
+func (p brasilAPI) lookup(ctx context.Context, cep string) (*Address, error) {
+	var payload struct {
+		CEP          string `json:"cep"`
+		State        string `json:"state"`
+		City         string `json:"city"`
+		Neighborhood string `json:"neighborhood"`
+		Street       string `json:"street"`
+		Location     struct {
+			Coordinates struct {
+				Latitude  json.Number `json:"latitude"`
+				Longitude json.Number `json:"longitude"`
+			} `json:"coordinates"`
+		} `json:"location"`
+	}
+	if err := getJSON(ctx, p.client, p.base+"/"+cep, &payload); err != nil {
+		return nil, err
+	}
+	addr := &Address{
+		CEP:        payload.CEP,
+		Logradouro: payload.Street,
+		Bairro:     payload.Neighborhood,
+		Cidade:     payload.City,
+		UF:         payload.State,
+		Fonte:      p.name(),
+	}
+	if lat, err := payload.Location.Coordinates.Latitude.Float64(); err == nil {
+		addr.Latitude = &lat
+	}
+	if lng, err := payload.Location.Coordinates.Longitude.Float64(); err == nil {
+		addr.Longitude = &lng
+	}
+	return addr, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("resposta inválida: %w", err)
+	}
+	return nil
+}
+
+func trimBase(base, fallback string) string {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		base = fallback
+	}
+	return strings.TrimRight(base, "/")
+}
@@ -0,0 +1,132 @@
+package address
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	cacheKeyPrefix = "cep:"
+	// notFoundMarker guarda CEPs inexistentes por pouco tempo para não martelar os provedores.
+	notFoundMarker = "-"
+	notFoundTTL    = time.Hour
+)
+
+// Config aponta os provedores; bases vazias usam as APIs públicas.
+type Config struct {
+	ViaCEPBase    string
+	BrasilAPIBase string
+	CacheTTL      time.Duration
+	Timeout       time.Duration
+}
+
+// Service consulta CEPs no ViaCEP com BrasilAPI como reserva, com cache no Redis.
+type Service struct {
+	redis     *redis.Client
+	providers []provider
+	ttl       time.Duration
+}
+
+// New cria o serviço; sem Redis as consultas vão sempre aos provedores.
+func New(redisClient *redis.Client, cfg Config) *Service {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ttl := cfg.CacheTTL
+	if ttl <= 0 {
+		ttl = 30 * 24 * time.Hour
+	}
+	client := &http.Client{Timeout: timeout}
+	return &Service{
+		redis: redisClient,
+		providers: []provider{
+			viaCEP{client: client, base: trimBase(cfg.ViaCEPBase, defaultViaCEPBase)},
+			brasilAPI{client: client, base: trimBase(cfg.BrasilAPIBase, defaultBrasilAPIBase)},
+		},
+		ttl: ttl,
+	}
+}
+
+// Lookup devolve o endereço normalizado do CEP. O próximo provedor só é consultado quando
+// o anterior falha; "não encontrado" de um provedor também é conferido no seguinte.
+func (s *Service) Lookup(ctx context.Context, raw string) (*Address, error) {
+	cep, err := NormalizeCEP(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, found, err := s.cached(ctx, cep); err == nil && found {
+		if cached == nil {
+			return nil, ErrNotFound
+		}
+		return cached, nil
+	}
+
+	var errs []error
+	notFound := 0
+	for _, p := range s.providers {
+		addr, err := p.lookup(ctx, cep)
+		if err == nil {
+			normalized := Normalize(*addr)
+			s.store(ctx, cep, &normalized, s.ttl)
+			return &normalized, nil
+		}
+		if errors.Is(err, ErrNotFound) {
+			notFound++
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.name(), err))
+	}
+
+	if notFound == len(s.providers) {
+		s.store(ctx, cep, nil, notFoundTTL)
+		return nil, ErrNotFound
+	}
+	if len(errs) == 0 {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("consulta de CEP indisponível: %w", errors.Join(errs...))
+}
+
+func (s *Service) cached(ctx context.Context, cep string) (*Address, bool, error) {
+	if s.redis == nil {
+		return nil, false, nil
+	}
+	raw, err := s.redis.Get(ctx, cacheKeyPrefix+cep).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if raw == notFoundMarker {
+		return nil, true, nil
+	}
+	var addr Address
+	if err := json.Unmarshal([]byte(raw), &addr); err != nil {
+		return nil, false, err
+	}
+	return &addr, true, nil
+}
+
+func (s *Service) store(ctx context.Context, cep string, addr *Address, ttl time.Duration) {
+	if s.redis == nil {
+		return
+	}
+	value := notFoundMarker
+	if addr != nil {
+		payload, err := json.Marshal(addr)
+		if err != nil {
+			return
+		}
+		value = string(payload)
+	}
+	_ = s.redis.Set(ctx, cacheKeyPrefix+cep, value, ttl).Err()
+}
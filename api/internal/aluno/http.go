@@ -0,0 +1,207 @@
+package aluno
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+type ServiceProvider interface {
+	ListAvaliacoes(ctx context.Context, alunoID uuid.UUID) ([]Avaliacao, error)
+	Iniciar(ctx context.Context, alunoID, avaliacaoID uuid.UUID) (Prova, error)
+	SalvarRespostas(ctx context.Context, alunoID, avaliacaoID uuid.UUID, respostas []Resposta) (Tentativa, error)
+	Entregar(ctx context.Context, alunoID, avaliacaoID uuid.UUID, respostas []Resposta) (Tentativa, error)
+	Resultado(ctx context.Context, alunoID, avaliacaoID uuid.UUID) (Tentativa, error)
+}
+
+// Handler expõe as avaliações online ao aluno autenticado.
+type Handler struct {
+	service ServiceProvider
+}
+
+func NewHandler(service ServiceProvider) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/avaliacoes", h.listAvaliacoes)
+	r.Post("/avaliacoes/{avaliacaoID}/iniciar", h.iniciar)
+	r.Put("/avaliacoes/{avaliacaoID}/respostas", h.salvarRespostas)
+	r.Post("/avaliacoes/{avaliacaoID}/entregar", h.entregar)
+	r.Get("/avaliacoes/{avaliacaoID}/resultado", h.resultado)
+}
+
+func (h *Handler) listAvaliacoes(w http.ResponseWriter, r *http.Request) {
+	alunoID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	avaliacoes, err := h.service.ListAvaliacoes(r.Context(), alunoID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar avaliações", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"avaliacoes": avaliacoes})
+}
+
+func (h *Handler) iniciar(w http.ResponseWriter, r *http.Request) {
+	alunoID, avaliacaoID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	prova, err := h.service.Iniciar(r.Context(), alunoID, avaliacaoID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível iniciar avaliação")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prova)
+}
+
+func (h *Handler) salvarRespostas(w http.ResponseWriter, r *http.Request) {
+	alunoID, avaliacaoID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+	respostas, ok := decodeRespostas(w, r, false)
+	if !ok {
+		return
+	}
+
+	tentativa, err := h.service.SalvarRespostas(r.Context(), alunoID, avaliacaoID, respostas)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível salvar respostas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"tentativa": tentativa})
+}
+
+func (h *Handler) entregar(w http.ResponseWriter, r *http.Request) {
+	alunoID, avaliacaoID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+	respostas, ok := decodeRespostas(w, r, true)
+	if !ok {
+		return
+	}
+
+	tentativa, err := h.service.Entregar(r.Context(), alunoID, avaliacaoID, respostas)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível entregar avaliação")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"tentativa": tentativa})
+}
+
+func (h *Handler) resultado(w http.ResponseWriter, r *http.Request) {
+	alunoID, avaliacaoID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	tentativa, err := h.service.Resultado(r.Context(), alunoID, avaliacaoID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar resultado")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"tentativa": tentativa})
+}
+
+// decodeRespostas lê {"respostas": [...]}; na entrega o corpo pode vir vazio.
+func decodeRespostas(w http.ResponseWriter, r *http.Request, opcional bool) ([]Resposta, bool) {
+	var payload struct {
+		Respostas []Resposta `json:"respostas"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		if opcional && errors.Is(err, io.EOF) {
+			return nil, true
+		}
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return nil, false
+	}
+	return payload.Respostas, true
+}
+
+// parseScope lê o aluno do token e a avaliação da rota.
+func parseScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	alunoID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	avaliacaoID, err := uuid.Parse(chi.URLParam(r, "avaliacaoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "avaliação inválida", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return alunoID, avaliacaoID, true
+}
+
+func writeDomainError(w http.ResponseWriter, err error, fallback string) {
+	var validation ValidationError
+	switch {
+	case errors.As(err, &validation):
+		writeError(w, http.StatusBadRequest, "VALIDATION", validation.Error(), nil)
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "avaliação não encontrada", nil)
+	case errors.Is(err, ErrForaJanela), errors.Is(err, ErrNaoIniciada), errors.Is(err, ErrEntregue), errors.Is(err, ErrPrazoEsgotou):
+		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", fallback, nil)
+	}
+}
+
+func subjectAsUUID(r *http.Request) (uuid.UUID, error) {
+	subject := httpmiddleware.GetSubject(r.Context())
+	return uuid.Parse(subject)
+}
+
+type successEnvelope struct {
+	Data  any `json:"data"`
+	Error any `json:"error"`
+}
+
+type errorEnvelope struct {
+	Data  any        `json:"data"`
+	Error *errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(successEnvelope{Data: data})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorEnvelope{
+		Data: nil,
+		Error: &errorBody{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
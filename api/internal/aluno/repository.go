@@ -0,0 +1,506 @@
+package aluno
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForaJanela   = errors.New("avaliação fora do período de aplicação")
+	ErrNaoIniciada  = errors.New("avaliação não iniciada")
+	ErrEntregue     = errors.New("avaliação já entregue")
+	ErrPrazoEsgotou = errors.New("tempo da avaliação esgotado")
+)
+
+// ValidationError sinaliza resposta malformada (questão de outra avaliação, alternativa
+// inexistente).
+type ValidationError struct{ msg string }
+
+func (e ValidationError) Error() string { return e.msg }
+
+const (
+	dbTimeout = 5 * time.Second
+	// loteEncerramento limita quantas tentativas vencidas são corrigidas por execução do job.
+	loteEncerramento = 100
+)
+
+// Repository guarda as consultas das avaliações online. Todo acesso parte do aluno autenticado e
+// de uma matrícula ativa dele na turma da avaliação.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// conn devolve o pool do cluster da prefeitura da requisição (middleware TenantDatabase) ou o
+// pool padrão fora de requisição.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.db)
+}
+
+// Avaliacao é a visão do aluno sobre uma avaliação online publicada para sua turma.
+type Avaliacao struct {
+	ID             uuid.UUID  `json:"id"`
+	Titulo         string     `json:"titulo"`
+	Disciplina     string     `json:"disciplina"`
+	Turma          string     `json:"turma"`
+	Inicio         *time.Time `json:"inicio,omitempty"`
+	Fim            *time.Time `json:"fim,omitempty"`
+	DuracaoMinutos *int       `json:"duracao_minutos,omitempty"`
+	Questoes       int        `json:"questoes"`
+	Tentativa      *Tentativa `json:"tentativa,omitempty"`
+}
+
+// Tentativa é a participação do aluno; os campos de correção só existem depois da entrega.
+type Tentativa struct {
+	ID         uuid.UUID  `json:"id"`
+	IniciadaEm time.Time  `json:"iniciada_em"`
+	Prazo      *time.Time `json:"prazo,omitempty"`
+	EntregueEm *time.Time `json:"entregue_em,omitempty"`
+	Acertos    *int       `json:"acertos,omitempty"`
+	Objetivas  *int       `json:"objetivas,omitempty"`
+	Pendentes  *int       `json:"pendentes,omitempty"`
+	Nota       *float64   `json:"nota,omitempty"`
+}
+
+// Questao é apresentada ao aluno sem o gabarito.
+type Questao struct {
+	ID           uuid.UUID `json:"id"`
+	Enunciado    string    `json:"enunciado"`
+	Alternativas []string  `json:"alternativas"`
+	correta      *int16
+}
+
+// Resposta é a alternativa marcada (índice a partir de zero); nil limpa a resposta.
+type Resposta struct {
+	QuestaoID   uuid.UUID `json:"questao_id"`
+	Alternativa *int16    `json:"alternativa"`
+}
+
+// Prova é o que o aluno recebe ao iniciar ou retomar a avaliação.
+type Prova struct {
+	Avaliacao Avaliacao  `json:"avaliacao"`
+	Tentativa Tentativa  `json:"tentativa"`
+	Questoes  []Questao  `json:"questoes"`
+	Respostas []Resposta `json:"respostas"`
+}
+
+// aplicacao reúne o necessário para validar janela, corrigir e lançar a nota.
+type aplicacao struct {
+	ID             uuid.UUID
+	TurmaID        uuid.UUID
+	MatriculaID    uuid.UUID
+	TenantID       uuid.UUID
+	Titulo         string
+	Disciplina     string
+	Turma          string
+	Inicio         *time.Time
+	Fim            *time.Time
+	DuracaoMinutos *int
+	AnoLetivo      int
+	CriadaEm       time.Time
+}
+
+func (a aplicacao) visao() Avaliacao {
+	return Avaliacao{
+		ID:             a.ID,
+		Titulo:         a.Titulo,
+		Disciplina:     a.Disciplina,
+		Turma:          a.Turma,
+		Inicio:         a.Inicio,
+		Fim:            a.Fim,
+		DuracaoMinutos: a.DuracaoMinutos,
+	}
+}
+
+const tentativaColumns = `t.id, t.iniciada_em, t.prazo, t.entregue_em, t.acertos, t.objetivas, t.pendentes, t.nota`
+
+func scanTentativa(row pgx.Row) (Tentativa, error) {
+	var t Tentativa
+	err := row.Scan(&t.ID, &t.IniciadaEm, &t.Prazo, &t.EntregueEm, &t.Acertos, &t.Objetivas, &t.Pendentes, &t.Nota)
+	return t, err
+}
+
+// ListAvaliacoes lista as avaliações online publicadas nas turmas em que o aluno está matriculado.
+func (r *Repository) ListAvaliacoes(ctx context.Context, alunoID uuid.UUID) ([]Avaliacao, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT av.id, av.titulo, av.disciplina, tu.nome, av.inicio, av.fim, av.duracao_minutos,
+               (SELECT count(*) FROM aval_questoes q WHERE q.avaliacao_id = av.id)::int,
+               t.id, t.iniciada_em, t.prazo, t.entregue_em, t.acertos, t.objetivas, t.pendentes, t.nota
+        FROM matriculas m
+        JOIN turmas tu ON tu.id = m.turma_id
+        JOIN avaliacoes av ON av.turma_id = m.turma_id AND av.ano_letivo = m.ano_letivo
+        LEFT JOIN aval_tentativas t ON t.avaliacao_id = av.id AND t.matricula_id = m.id
+        WHERE m.aluno_id = $1 AND m.ativo = TRUE AND av.online AND av.status = 'PUBLICADA'
+        ORDER BY COALESCE(av.fim, av.inicio, av.created_at) DESC
+        LIMIT 100
+    `, alunoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Avaliacao, 0)
+	for rows.Next() {
+		var item Avaliacao
+		var tentativaID *uuid.UUID
+		var t Tentativa
+		var iniciada *time.Time
+		if err := rows.Scan(&item.ID, &item.Titulo, &item.Disciplina, &item.Turma, &item.Inicio, &item.Fim, &item.DuracaoMinutos,
+			&item.Questoes, &tentativaID, &iniciada, &t.Prazo, &t.EntregueEm, &t.Acertos, &t.Objetivas, &t.Pendentes, &t.Nota); err != nil {
+			return nil, err
+		}
+		if tentativaID != nil && iniciada != nil {
+			t.ID, t.IniciadaEm = *tentativaID, *iniciada
+			item.Tentativa = &t
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// carregarAplicacao localiza a avaliação online publicada na turma de uma matrícula ativa do aluno.
+func carregarAplicacao(ctx context.Context, tx pgx.Tx, alunoID, avaliacaoID uuid.UUID) (aplicacao, error) {
+	var a aplicacao
+	err := tx.QueryRow(ctx, `
+        SELECT av.id, av.turma_id, m.id, e.tenant_id, av.titulo, av.disciplina, tu.nome,
+               av.inicio, av.fim, av.duracao_minutos, av.ano_letivo, av.created_at
+        FROM avaliacoes av
+        JOIN turmas tu ON tu.id = av.turma_id
+        JOIN escolas e ON e.id = tu.escola_id
+        JOIN matriculas m ON m.turma_id = av.turma_id AND m.ano_letivo = av.ano_letivo
+        WHERE av.id = $1 AND m.aluno_id = $2 AND m.ativo = TRUE AND av.online AND av.status = 'PUBLICADA'
+    `, avaliacaoID, alunoID).Scan(&a.ID, &a.TurmaID, &a.MatriculaID, &a.TenantID, &a.Titulo, &a.Disciplina, &a.Turma,
+		&a.Inicio, &a.Fim, &a.DuracaoMinutos, &a.AnoLetivo, &a.CriadaEm)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+func listQuestoes(ctx context.Context, tx pgx.Tx, avaliacaoID uuid.UUID) ([]Questao, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT id, enunciado, alternativas, correta
+        FROM aval_questoes
+        WHERE avaliacao_id = $1
+        ORDER BY id
+    `, avaliacaoID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Questao, error) {
+		var q Questao
+		err := row.Scan(&q.ID, &q.Enunciado, &q.Alternativas, &q.correta)
+		return q, err
+	})
+}
+
+func listRespostas(ctx context.Context, tx pgx.Tx, avaliacaoID, matriculaID uuid.UUID) ([]Resposta, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT questao_id, alternativa FROM aval_respostas
+        WHERE avaliacao_id = $1 AND matricula_id = $2
+    `, avaliacaoID, matriculaID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Resposta, error) {
+		var resp Resposta
+		err := row.Scan(&resp.QuestaoID, &resp.Alternativa)
+		return resp, err
+	})
+}
+
+// Iniciar abre a tentativa do aluno, ou devolve a já aberta, com as questões sem gabarito e as
+// respostas salvas até agora.
+func (r *Repository) Iniciar(ctx context.Context, alunoID, avaliacaoID uuid.UUID, agora time.Time) (Prova, error) {
+	var prova Prova
+	err := pgx.BeginFunc(ctx, r.conn(ctx), func(tx pgx.Tx) error {
+		a, err := carregarAplicacao(ctx, tx, alunoID, avaliacaoID)
+		if err != nil {
+			return err
+		}
+		tentativa, err := scanTentativa(tx.QueryRow(ctx, `
+            SELECT `+tentativaColumns+` FROM aval_tentativas t
+            WHERE t.avaliacao_id = $1 AND t.matricula_id = $2
+        `, a.ID, a.MatriculaID))
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if err := janelaAberta(a.Inicio, a.Fim, agora); err != nil {
+				return err
+			}
+			tentativa, err = scanTentativa(tx.QueryRow(ctx, `
+                INSERT INTO aval_tentativas AS t (avaliacao_id, matricula_id, iniciada_em, prazo)
+                VALUES ($1, $2, $3, $4)
+                RETURNING `+tentativaColumns, a.ID, a.MatriculaID, agora, calcularPrazo(a.Fim, a.DuracaoMinutos, agora)))
+			if err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case tentativa.EntregueEm != nil:
+			return ErrEntregue
+		}
+
+		questoes, err := listQuestoes(ctx, tx, a.ID)
+		if err != nil {
+			return err
+		}
+		respostas, err := listRespostas(ctx, tx, a.ID, a.MatriculaID)
+		if err != nil {
+			return err
+		}
+		visao := a.visao()
+		visao.Questoes = len(questoes)
+		prova = Prova{Avaliacao: visao, Tentativa: tentativa, Questoes: questoes, Respostas: respostas}
+		return nil
+	})
+	return prova, err
+}
+
+// abrirTentativa carrega e bloqueia a tentativa em andamento.
+func abrirTentativa(ctx context.Context, tx pgx.Tx, a aplicacao) (Tentativa, error) {
+	tentativa, err := scanTentativa(tx.QueryRow(ctx, `
+        SELECT `+tentativaColumns+` FROM aval_tentativas t
+        WHERE t.avaliacao_id = $1 AND t.matricula_id = $2
+        FOR UPDATE
+    `, a.ID, a.MatriculaID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return tentativa, ErrNaoIniciada
+	}
+	if err != nil {
+		return tentativa, err
+	}
+	if tentativa.EntregueEm != nil {
+		return tentativa, ErrEntregue
+	}
+	return tentativa, nil
+}
+
+// gravarRespostas valida cada resposta contra as questões e grava por cima das anteriores.
+func gravarRespostas(ctx context.Context, tx pgx.Tx, a aplicacao, questoes []Questao, respostas []Resposta) error {
+	if len(respostas) == 0 {
+		return nil
+	}
+	porID := make(map[uuid.UUID]Questao, len(questoes))
+	for _, q := range questoes {
+		porID[q.ID] = q
+	}
+	ids := make([]uuid.UUID, 0, len(respostas))
+	alternativas := make([]*int16, 0, len(respostas))
+	for _, resp := range respostas {
+		q, ok := porID[resp.QuestaoID]
+		if !ok {
+			return ValidationError{msg: "questão não pertence à avaliação"}
+		}
+		if resp.Alternativa != nil && (*resp.Alternativa < 0 || int(*resp.Alternativa) >= len(q.Alternativas)) {
+			return ValidationError{msg: fmt.Sprintf("alternativa inválida na questão %s", q.ID)}
+		}
+		ids = append(ids, resp.QuestaoID)
+		alternativas = append(alternativas, resp.Alternativa)
+	}
+	_, err := tx.Exec(ctx, `
+        INSERT INTO aval_respostas (avaliacao_id, matricula_id, questao_id, alternativa)
+        SELECT $1, $2, v.questao_id, v.alternativa
+        FROM unnest($3::uuid[], $4::smallint[]) AS v(questao_id, alternativa)
+        ON CONFLICT (avaliacao_id, matricula_id, questao_id) DO UPDATE SET alternativa = EXCLUDED.alternativa
+    `, a.ID, a.MatriculaID, ids, alternativas)
+	return err
+}
+
+// SalvarRespostas grava respostas parciais dentro do prazo da tentativa.
+func (r *Repository) SalvarRespostas(ctx context.Context, alunoID, avaliacaoID uuid.UUID, respostas []Resposta, agora time.Time) (Tentativa, error) {
+	var tentativa Tentativa
+	err := pgx.BeginFunc(ctx, r.conn(ctx), func(tx pgx.Tx) error {
+		a, err := carregarAplicacao(ctx, tx, alunoID, avaliacaoID)
+		if err != nil {
+			return err
+		}
+		if tentativa, err = abrirTentativa(ctx, tx, a); err != nil {
+			return err
+		}
+		if !dentroDoPrazo(tentativa.Prazo, agora) {
+			return ErrPrazoEsgotou
+		}
+		questoes, err := listQuestoes(ctx, tx, a.ID)
+		if err != nil {
+			return err
+		}
+		return gravarRespostas(ctx, tx, a, questoes, respostas)
+	})
+	return tentativa, err
+}
+
+// Entregar grava as últimas respostas (se ainda no prazo), corrige a tentativa e lança a nota.
+// Depois do prazo a entrega ainda é aceita, mas só com o que já estava salvo.
+func (r *Repository) Entregar(ctx context.Context, alunoID, avaliacaoID uuid.UUID, respostas []Resposta, agora time.Time) (Tentativa, error) {
+	var tentativa Tentativa
+	err := pgx.BeginFunc(ctx, r.conn(ctx), func(tx pgx.Tx) error {
+		a, err := carregarAplicacao(ctx, tx, alunoID, avaliacaoID)
+		if err != nil {
+			return err
+		}
+		if tentativa, err = abrirTentativa(ctx, tx, a); err != nil {
+			return err
+		}
+		questoes, err := listQuestoes(ctx, tx, a.ID)
+		if err != nil {
+			return err
+		}
+		if dentroDoPrazo(tentativa.Prazo, agora) {
+			if err := gravarRespostas(ctx, tx, a, questoes, respostas); err != nil {
+				return err
+			}
+		}
+		tentativa, err = finalizar(ctx, tx, a, tentativa.ID, questoes, agora)
+		return err
+	})
+	return tentativa, err
+}
+
+// Resultado devolve a tentativa entregue do aluno.
+func (r *Repository) Resultado(ctx context.Context, alunoID, avaliacaoID uuid.UUID) (Tentativa, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tentativa, err := scanTentativa(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+tentativaColumns+`
+        FROM aval_tentativas t
+        JOIN matriculas m ON m.id = t.matricula_id
+        WHERE t.avaliacao_id = $1 AND m.aluno_id = $2
+    `, avaliacaoID, alunoID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return tentativa, ErrNotFound
+	}
+	if err != nil {
+		return tentativa, err
+	}
+	if tentativa.EntregueEm == nil {
+		return tentativa, ErrNaoIniciada
+	}
+	return tentativa, nil
+}
+
+// finalizar corrige as questões objetivas, fecha a tentativa e, havendo questões objetivas, grava a
+// nota do aluno no bimestre da avaliação.
+func finalizar(ctx context.Context, tx pgx.Tx, a aplicacao, tentativaID uuid.UUID, questoes []Questao, agora time.Time) (Tentativa, error) {
+	respostas, err := listRespostas(ctx, tx, a.ID, a.MatriculaID)
+	if err != nil {
+		return Tentativa{}, err
+	}
+	c := corrigir(questoes, respostas)
+	tentativa, err := scanTentativa(tx.QueryRow(ctx, `
+        UPDATE aval_tentativas AS t
+        SET entregue_em = $2, acertos = $3, objetivas = $4, pendentes = $5, nota = $6
+        WHERE t.id = $1
+        RETURNING `+tentativaColumns, tentativaID, agora, c.Acertos, c.Objetivas, c.Pendentes, c.Nota))
+	if err != nil {
+		return tentativa, err
+	}
+	if c.Nota == nil {
+		return tentativa, nil
+	}
+
+	var inicioAno, fimAno time.Time
+	err = tx.QueryRow(ctx, `SELECT inicio, fim FROM anos_letivos WHERE tenant_id = $1 AND ano = $2`, a.TenantID, a.AnoLetivo).Scan(&inicioAno, &fimAno)
+	if errors.Is(err, pgx.ErrNoRows) {
+		inicioAno = time.Date(a.AnoLetivo, time.January, 1, 0, 0, 0, 0, time.UTC)
+		fimAno = time.Date(a.AnoLetivo, time.December, 31, 0, 0, 0, 0, time.UTC)
+	} else if err != nil {
+		return tentativa, err
+	}
+	referencia := a.CriadaEm
+	if a.Inicio != nil {
+		referencia = *a.Inicio
+	}
+	_, err = lancarNota(ctx, tx, a, bimestreDaData(inicioAno, fimAno, referencia), *c.Nota)
+	return tentativa, err
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// lancarNota leva a nota da avaliação online ao diário só quando o aluno ainda não tem nota na
+// disciplina e no bimestre: a nota lançada pelo professor prevalece e a da avaliação continua na
+// tentativa. Devolve se a nota foi gravada.
+func lancarNota(ctx context.Context, tx execer, a aplicacao, bimestre int, nota float64) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO notas (turma_id, disciplina, bimestre, matricula_id, nota, obs, ano_letivo)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (ano_letivo, turma_id, disciplina, bimestre, matricula_id) DO NOTHING
+    `, a.TurmaID, a.Disciplina, bimestre, a.MatriculaID, nota, "Avaliação online: "+a.Titulo, a.AnoLetivo)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EncerrarVencidas entrega as tentativas cujo prazo passou sem entrega, com as respostas já
+// salvas. Devolve quantas foram corrigidas. Sob db.Resolver.EachCluster só olha as prefeituras
+// roteadas para o cluster.
+func (r *Repository) EncerrarVencidas(ctx context.Context, agora time.Time) (int, error) {
+	var total int
+	err := pgx.BeginFunc(ctx, r.conn(ctx), func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+            SELECT t.id, av.id, av.turma_id, t.matricula_id, e.tenant_id, av.titulo, av.disciplina, tu.nome,
+                   av.inicio, av.fim, av.duracao_minutos, av.ano_letivo, av.created_at
+            FROM aval_tentativas t
+            JOIN avaliacoes av ON av.id = t.avaliacao_id
+            JOIN turmas tu ON tu.id = av.turma_id
+            JOIN escolas e ON e.id = tu.escola_id
+            WHERE t.entregue_em IS NULL AND t.prazo < $1
+              AND ($3::uuid[] IS NULL OR e.tenant_id = ANY($3))
+            ORDER BY t.prazo
+            LIMIT $2
+            FOR UPDATE OF t SKIP LOCKED
+        `, agora.Add(-toleranciaEntrega), loteEncerramento, db.Tenants(ctx))
+		if err != nil {
+			return err
+		}
+		type vencida struct {
+			tentativaID uuid.UUID
+			aplicacao
+		}
+		vencidas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (vencida, error) {
+			var v vencida
+			err := row.Scan(&v.tentativaID, &v.ID, &v.TurmaID, &v.MatriculaID, &v.TenantID, &v.Titulo, &v.Disciplina, &v.Turma,
+				&v.Inicio, &v.Fim, &v.DuracaoMinutos, &v.AnoLetivo, &v.CriadaEm)
+			return v, err
+		})
+		if err != nil {
+			return err
+		}
+
+		questoesPorAvaliacao := make(map[uuid.UUID][]Questao)
+		for _, v := range vencidas {
+			questoes, ok := questoesPorAvaliacao[v.ID]
+			if !ok {
+				if questoes, err = listQuestoes(ctx, tx, v.ID); err != nil {
+					return err
+				}
+				questoesPorAvaliacao[v.ID] = questoes
+			}
+			if _, err := finalizar(ctx, tx, v.aplicacao, v.tentativaID, questoes, agora); err != nil {
+				return err
+			}
+			total++
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,8 @@
+package aluno
+
+import "github.com/go-chi/chi/v5"
+
+// Mount registra rotas das avaliações online dos alunos.
+func Mount(r chi.Router, handler *Handler) {
+	handler.RegisterRoutes(r)
+}
@@ -0,0 +1,133 @@
+package aluno
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// toleranciaEntrega absorve a latência entre o cronômetro do aplicativo e o servidor; respostas
+// que chegam nesse intervalo depois do prazo ainda são gravadas.
+const toleranciaEntrega = 30 * time.Second
+
+type Service struct {
+	repo   *Repository
+	logger zerolog.Logger
+}
+
+func NewService(repository *Repository, logger zerolog.Logger) *Service {
+	return &Service{repo: repository, logger: logger}
+}
+
+func (s *Service) ListAvaliacoes(ctx context.Context, alunoID uuid.UUID) ([]Avaliacao, error) {
+	return s.repo.ListAvaliacoes(ctx, alunoID)
+}
+
+func (s *Service) Iniciar(ctx context.Context, alunoID, avaliacaoID uuid.UUID) (Prova, error) {
+	return s.repo.Iniciar(ctx, alunoID, avaliacaoID, util.Now())
+}
+
+func (s *Service) SalvarRespostas(ctx context.Context, alunoID, avaliacaoID uuid.UUID, respostas []Resposta) (Tentativa, error) {
+	return s.repo.SalvarRespostas(ctx, alunoID, avaliacaoID, respostas, util.Now())
+}
+
+func (s *Service) Entregar(ctx context.Context, alunoID, avaliacaoID uuid.UUID, respostas []Resposta) (Tentativa, error) {
+	return s.repo.Entregar(ctx, alunoID, avaliacaoID, respostas, util.Now())
+}
+
+func (s *Service) Resultado(ctx context.Context, alunoID, avaliacaoID uuid.UUID) (Tentativa, error) {
+	return s.repo.Resultado(ctx, alunoID, avaliacaoID)
+}
+
+// EncerrarVencidas é o job que entrega tentativas abandonadas depois do prazo.
+func (s *Service) EncerrarVencidas(ctx context.Context) error {
+	total, err := s.repo.EncerrarVencidas(ctx, util.Now())
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		s.logger.Info().Int("tentativas", total).Msg("avaliações online: tentativas vencidas entregues")
+	}
+	return nil
+}
+
+// janelaAberta confere se ainda é possível começar a avaliação.
+func janelaAberta(inicio, fim *time.Time, agora time.Time) error {
+	if inicio != nil && agora.Before(*inicio) {
+		return ErrForaJanela
+	}
+	if fim != nil && !agora.Before(*fim) {
+		return ErrForaJanela
+	}
+	return nil
+}
+
+// calcularPrazo limita a tentativa pela duração da prova e pelo fim da janela, o que vier antes.
+func calcularPrazo(fim *time.Time, duracaoMinutos *int, agora time.Time) *time.Time {
+	var prazo *time.Time
+	if duracaoMinutos != nil && *duracaoMinutos > 0 {
+		limite := agora.Add(time.Duration(*duracaoMinutos) * time.Minute)
+		prazo = &limite
+	}
+	if fim != nil && (prazo == nil || fim.Before(*prazo)) {
+		limite := *fim
+		prazo = &limite
+	}
+	return prazo
+}
+
+func dentroDoPrazo(prazo *time.Time, agora time.Time) bool {
+	return prazo == nil || !agora.After(prazo.Add(toleranciaEntrega))
+}
+
+// Correcao resume a correção automática. Questões sem gabarito (dissertativas) ficam pendentes e
+// fora da nota, que vai de 0 a 10 como as notas lançadas pelo professor.
+type Correcao struct {
+	Acertos   int
+	Objetivas int
+	Pendentes int
+	Nota      *float64
+}
+
+func corrigir(questoes []Questao, respostas []Resposta) Correcao {
+	marcadas := make(map[uuid.UUID]*int16, len(respostas))
+	for _, resp := range respostas {
+		marcadas[resp.QuestaoID] = resp.Alternativa
+	}
+	var c Correcao
+	for _, q := range questoes {
+		if q.correta == nil {
+			c.Pendentes++
+			continue
+		}
+		c.Objetivas++
+		if marcada := marcadas[q.ID]; marcada != nil && *marcada == *q.correta {
+			c.Acertos++
+		}
+	}
+	if c.Objetivas > 0 {
+		nota := math.Round(float64(c.Acertos)/float64(c.Objetivas)*1000) / 100
+		c.Nota = &nota
+	}
+	return c
+}
+
+// bimestreDaData devolve o bimestre do ano letivo em que a data cai, com a mesma divisão em quatro
+// partes iguais usada no boletim. Datas fora do ano letivo vão para o bimestre mais próximo.
+func bimestreDaData(inicio, fim, data time.Time) int {
+	inicio = time.Date(inicio.Year(), inicio.Month(), inicio.Day(), 0, 0, 0, 0, time.UTC)
+	fim = time.Date(fim.Year(), fim.Month(), fim.Day(), 0, 0, 0, 0, time.UTC)
+	data = data.UTC()
+	dias := int(fim.Sub(inicio).Hours()/24) + 1
+	for bimestre := 1; bimestre < 4; bimestre++ {
+		if data.Before(inicio.AddDate(0, 0, dias*bimestre/4)) {
+			return bimestre
+		}
+	}
+	return 4
+}
@@ -0,0 +1,124 @@
+package aluno
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCorrigir(t *testing.T) {
+	um, dois := int16(1), int16(2)
+	q1 := Questao{ID: uuid.New(), Alternativas: []string{"a", "b", "c"}, correta: &um}
+	q2 := Questao{ID: uuid.New(), Alternativas: []string{"a", "b", "c"}, correta: &dois}
+	q3 := Questao{ID: uuid.New(), Alternativas: []string{"a", "b", "c"}, correta: &um}
+	dissertativa := Questao{ID: uuid.New(), Enunciado: "Explique"}
+
+	c := corrigir([]Questao{q1, q2, q3, dissertativa}, []Resposta{
+		{QuestaoID: q1.ID, Alternativa: &um},
+		{QuestaoID: q2.ID, Alternativa: &um},
+		{QuestaoID: dissertativa.ID},
+	})
+	if c.Acertos != 1 || c.Objetivas != 3 || c.Pendentes != 1 {
+		t.Fatalf("correção = %+v", c)
+	}
+	if c.Nota == nil || *c.Nota != 3.33 {
+		t.Fatalf("nota = %v, want 3.33", c.Nota)
+	}
+
+	if c := corrigir([]Questao{dissertativa}, nil); c.Nota != nil {
+		t.Fatalf("prova só dissertativa não deveria ter nota: %v", *c.Nota)
+	}
+}
+
+func TestPrazoEJanela(t *testing.T) {
+	agora := time.Date(2026, 5, 10, 14, 0, 0, 0, time.UTC)
+	inicio := agora.Add(-time.Hour)
+	fim := agora.Add(30 * time.Minute)
+	duracao := 60
+
+	if err := janelaAberta(&inicio, &fim, agora); err != nil {
+		t.Fatalf("janela deveria estar aberta: %v", err)
+	}
+	if err := janelaAberta(&inicio, &fim, fim); err != ErrForaJanela {
+		t.Fatalf("no fim a janela deveria estar fechada: %v", err)
+	}
+	futuro := agora.Add(time.Minute)
+	if err := janelaAberta(&futuro, nil, agora); err != ErrForaJanela {
+		t.Fatalf("antes do início a janela deveria estar fechada: %v", err)
+	}
+
+	if prazo := calcularPrazo(&fim, &duracao, agora); prazo == nil || !prazo.Equal(fim) {
+		t.Fatalf("prazo deveria ser o fim da janela: %v", prazo)
+	}
+	longe := agora.Add(3 * time.Hour)
+	if prazo := calcularPrazo(&longe, &duracao, agora); prazo == nil || !prazo.Equal(agora.Add(time.Hour)) {
+		t.Fatalf("prazo deveria ser a duração: %v", prazo)
+	}
+	if calcularPrazo(nil, nil, agora) != nil {
+		t.Fatal("sem fim nem duração não há prazo")
+	}
+
+	if !dentroDoPrazo(&fim, fim.Add(toleranciaEntrega)) || dentroDoPrazo(&fim, fim.Add(toleranciaEntrega+time.Second)) {
+		t.Fatal("tolerância de entrega incorreta")
+	}
+}
+
+func TestBimestreDaData(t *testing.T) {
+	inicio := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+	fim := time.Date(2026, 12, 18, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		data time.Time
+		want int
+	}{
+		{time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2026, 2, 2, 10, 0, 0, 0, time.UTC), 1},
+		{time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), 2},
+		{time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), 3},
+		{time.Date(2026, 12, 18, 23, 0, 0, 0, time.UTC), 4},
+		{time.Date(2027, 1, 5, 0, 0, 0, 0, time.UTC), 4},
+	}
+	for _, tc := range cases {
+		if got := bimestreDaData(inicio, fim, tc.data); got != tc.want {
+			t.Errorf("bimestreDaData(%s) = %d, want %d", tc.data.Format("2006-01-02"), got, tc.want)
+		}
+	}
+}
+
+// diarioFake guarda notas pela chave única da tabela e aplica a cláusula ON CONFLICT do comando.
+type diarioFake struct {
+	notas map[string]float64
+}
+
+func (d *diarioFake) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	chave := fmt.Sprint(args[6], args[0], args[1], args[2], args[3])
+	if _, existe := d.notas[chave]; existe && !strings.Contains(sql, "DO UPDATE") {
+		return pgconn.NewCommandTag("INSERT 0 0"), nil
+	}
+	d.notas[chave] = args[4].(float64)
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func TestLancarNotaPreservaNotaDoProfessor(t *testing.T) {
+	a := aplicacao{TurmaID: uuid.New(), MatriculaID: uuid.New(), Disciplina: "Matemática", Titulo: "Frações", AnoLetivo: 2026}
+	diario := &diarioFake{notas: map[string]float64{}}
+	chave := fmt.Sprint(a.AnoLetivo, a.TurmaID, a.Disciplina, 2, a.MatriculaID)
+	diario.notas[chave] = 9.5
+
+	lancada, err := lancarNota(context.Background(), diario, a, 2, 4)
+	if err != nil {
+		t.Fatalf("lancarNota: %v", err)
+	}
+	if lancada || diario.notas[chave] != 9.5 {
+		t.Fatalf("nota do professor sobrescrita: lancada=%v nota=%v", lancada, diario.notas[chave])
+	}
+
+	lancada, err = lancarNota(context.Background(), diario, a, 3, 4)
+	if err != nil || !lancada {
+		t.Fatalf("bimestre sem nota deveria receber a da avaliação: lancada=%v err=%v", lancada, err)
+	}
+}
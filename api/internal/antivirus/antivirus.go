@@ -0,0 +1,52 @@
+package antivirus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Veredito normalizado da varredura, independente do motor.
+const (
+	VerdictClean    = "clean"
+	VerdictInfected = "infected"
+)
+
+// ErrNotConfigured indica ausência de motor de varredura.
+var ErrNotConfigured = errors.New("antivirus: scanner não configurado")
+
+// Result descreve o veredito de uma varredura; Signature vem preenchida quando infectado.
+type Result struct {
+	Verdict   string
+	Signature string
+}
+
+// Infected indica se o conteúdo foi sinalizado pelo motor.
+func (r Result) Infected() bool {
+	return r.Verdict == VerdictInfected
+}
+
+// Scanner abstrai o motor de varredura de arquivos enviados.
+type Scanner interface {
+	Scan(ctx context.Context, content []byte) (Result, error)
+}
+
+// Config aponta para o daemon do ClamAV (host:porta ou unix:/caminho).
+type Config struct {
+	Address string
+	Timeout time.Duration
+}
+
+// New devolve o scanner configurado ou ErrNotConfigured.
+func New(cfg Config) (Scanner, error) {
+	address := strings.TrimSpace(cfg.Address)
+	if address == "" {
+		return nil, ErrNotConfigured
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return newClamAV(address, timeout), nil
+}
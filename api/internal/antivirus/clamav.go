@@ -0,0 +1,92 @@
+package antivirus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamChunkSize fica abaixo do StreamMaxLength padrão do clamd por bloco.
+const clamChunkSize = 64 << 10
+
+// clamAV fala o protocolo INSTREAM do clamd.
+type clamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+func newClamAV(address string, timeout time.Duration) *clamAV {
+	network := "tcp"
+	if strings.HasPrefix(address, "unix:") {
+		network = "unix"
+		address = strings.TrimPrefix(address, "unix:")
+	}
+	return &clamAV{network: network, address: address, timeout: timeout}
+}
+
+// Scan envia o conteúdo em blocos prefixados pelo tamanho e interpreta a resposta do clamd.
+func (c *clamAV) Scan(ctx context.Context, content []byte) (Result, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return Result{}, fmt.Errorf("antivirus: conectar ao clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("antivirus: iniciar stream: %w", err)
+	}
+	var size [4]byte
+	for start := 0; start < len(content); start += clamChunkSize {
+		end := start + clamChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+		binary.BigEndian.PutUint32(size[:], uint32(end-start))
+		if _, err := conn.Write(size[:]); err != nil {
+			return Result{}, fmt.Errorf("antivirus: enviar bloco: %w", err)
+		}
+		if _, err := conn.Write(content[start:end]); err != nil {
+			return Result{}, fmt.Errorf("antivirus: enviar bloco: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := conn.Write(size[:]); err != nil {
+		return Result{}, fmt.Errorf("antivirus: encerrar stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return Result{}, fmt.Errorf("antivirus: ler resposta: %w", err)
+	}
+	return parseClamReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseClamReply interpreta "stream: OK", "stream: <assinatura> FOUND" ou "... ERROR".
+func parseClamReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(reply)
+	body := reply
+	if idx := strings.Index(reply, ": "); idx >= 0 {
+		body = reply[idx+2:]
+	}
+	switch {
+	case body == "OK":
+		return Result{Verdict: VerdictClean}, nil
+	case strings.HasSuffix(body, " FOUND"):
+		return Result{Verdict: VerdictInfected, Signature: strings.TrimSuffix(body, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("antivirus: resposta do clamd: %s", reply)
+	}
+}
@@ -0,0 +1,90 @@
+package antivirus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseClamReply(t *testing.T) {
+	cases := []struct {
+		reply     string
+		verdict   string
+		signature string
+		wantErr   bool
+	}{
+		{reply: "stream: OK", verdict: VerdictClean},
+		{reply: "stream: Win.Test.EICAR_HDB-1 FOUND", verdict: VerdictInfected, signature: "Win.Test.EICAR_HDB-1"},
+		{reply: "INSTREAM size limit exceeded. ERROR", wantErr: true},
+	}
+	for _, tc := range cases {
+		result, err := parseClamReply(tc.reply)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: esperava erro", tc.reply)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", tc.reply, err)
+		}
+		if result.Verdict != tc.verdict || result.Signature != tc.signature {
+			t.Fatalf("%q: obtido %+v", tc.reply, result)
+		}
+	}
+}
+
+func TestClamAVScanStreamsChunks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("sem socket local: %v", err)
+	}
+	defer ln.Close()
+
+	content := make([]byte, clamChunkSize+10)
+	received := make(chan int, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		if _, err := reader.ReadBytes(0); err != nil {
+			return
+		}
+		total := 0
+		var size [4]byte
+		for {
+			if _, err := io.ReadFull(reader, size[:]); err != nil {
+				return
+			}
+			n := int(binary.BigEndian.Uint32(size[:]))
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(io.Discard, reader, int64(n)); err != nil {
+				return
+			}
+			total += n
+		}
+		received <- total
+		_, _ = conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+	}()
+
+	scanner := newClamAV(ln.Addr().String(), 2*time.Second)
+	result, err := scanner.Scan(context.Background(), content)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := <-received; got != len(content) {
+		t.Fatalf("clamd recebeu %d bytes, esperado %d", got, len(content))
+	}
+	if !result.Infected() || result.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("resultado inesperado: %+v", result)
+	}
+}
@@ -0,0 +1,459 @@
+// Package assistencia guarda o prontuário das famílias acompanhadas pela assistência social
+// (CRAS/CREAS): composição familiar, inserção em programas e benefícios e registro de visitas.
+// O acesso é restrito aos profissionais cadastrados no módulo e toda leitura ou escrita fica na
+// trilha de auditoria própria.
+package assistencia
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+var (
+	// ErrNotFound indica família, benefício ou profissional inexistente, de outro tenant ou fora do
+	// alcance de quem consulta.
+	ErrNotFound = errors.New("assistencia: registro não encontrado")
+	// ErrForbidden indica usuário sem acesso ao módulo ou à operação.
+	ErrForbidden = errors.New("assistencia: acesso restrito")
+	// ErrDuplicate indica código familiar ou CPF do responsável já cadastrado.
+	ErrDuplicate = errors.New("assistencia: família já cadastrada")
+	// ErrUsuario indica usuário que não pertence a nenhuma secretaria da prefeitura.
+	ErrUsuario = errors.New("assistencia: usuário não pertence à prefeitura")
+)
+
+// Funções dos profissionais no módulo.
+const (
+	FuncaoTecnico     = "tecnico"
+	FuncaoCoordenador = "coordenador"
+)
+
+// Ações registradas na trilha de auditoria.
+const (
+	AcaoListar    = "listar"
+	AcaoConsultar = "consultar"
+	AcaoCriar     = "criar"
+	AcaoAlterar   = "alterar"
+	AcaoBeneficio = "beneficio"
+	AcaoVisita    = "visita"
+	AcaoNegado    = "acesso_negado"
+	AcaoConceder  = "acesso_concedido"
+	AcaoRevogar   = "acesso_revogado"
+	AcaoAuditoria = "auditoria"
+)
+
+// Programas e benefícios acompanhados.
+const (
+	ProgramaBolsaFamilia      = "bolsa_familia"
+	ProgramaBPC               = "bpc"
+	ProgramaBeneficioEventual = "beneficio_eventual"
+	ProgramaPAIF              = "paif"
+	ProgramaSCFV              = "scfv"
+	ProgramaOutro             = "outro"
+)
+
+var (
+	programas          = []string{ProgramaBolsaFamilia, ProgramaBPC, ProgramaBeneficioEventual, ProgramaPAIF, ProgramaSCFV, ProgramaOutro}
+	situacoesFamilia   = []string{"ativa", "desligada"}
+	situacoesBeneficio = []string{"ativo", "suspenso", "encerrado"}
+	tiposVisita        = []string{"domiciliar", "atendimento", "acompanhamento"}
+	referenciaPattern  = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])$`)
+)
+
+// Profissional é um usuário autorizado a trabalhar no módulo.
+type Profissional struct {
+	UsuarioID uuid.UUID `json:"usuario_id"`
+	Nome      string    `json:"nome"`
+	Email     string    `json:"email"`
+	Funcao    string    `json:"funcao"`
+	Unidade   *string   `json:"unidade,omitempty"`
+	Ativo     bool      `json:"ativo"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProfissionalInput concede ou altera o acesso de um usuário.
+type ProfissionalInput struct {
+	UsuarioID uuid.UUID
+	Funcao    string
+	Unidade   *string
+}
+
+// Normalize limpa e valida a entrada.
+func (in *ProfissionalInput) Normalize() error {
+	in.Funcao = strings.ToLower(strings.TrimSpace(in.Funcao))
+	in.Unidade = trimmed(in.Unidade)
+	if in.Funcao != FuncaoTecnico && in.Funcao != FuncaoCoordenador {
+		return errors.New("função deve ser tecnico ou coordenador")
+	}
+	if in.UsuarioID == uuid.Nil {
+		return errors.New("usuario_id obrigatório")
+	}
+	return nil
+}
+
+// Acesso identifica quem opera o módulo em cada requisição e alimenta a auditoria.
+type Acesso struct {
+	UsuarioID   uuid.UUID
+	Coordenador bool
+	IP          string
+	UserAgent   string
+}
+
+// Visivel aplica o sigilo: família sigilosa só aparece para coordenadores e para o técnico de
+// referência.
+func Visivel(a Acesso, sigilosa bool, tecnicoID *uuid.UUID) bool {
+	if !sigilosa || a.Coordenador {
+		return true
+	}
+	return tecnicoID != nil && *tecnicoID == a.UsuarioID
+}
+
+// Familia é o prontuário de uma família acompanhada.
+type Familia struct {
+	ID              uuid.UUID   `json:"id"`
+	Unidade         *string     `json:"unidade,omitempty"`
+	CodigoFamiliar  *string     `json:"codigo_familiar,omitempty"`
+	ResponsavelNome string      `json:"responsavel_nome"`
+	ResponsavelCPF  *string     `json:"responsavel_cpf,omitempty"`
+	ResponsavelNIS  *string     `json:"responsavel_nis,omitempty"`
+	Endereco        *string     `json:"endereco,omitempty"`
+	Bairro          *string     `json:"bairro,omitempty"`
+	Telefone        *string     `json:"telefone,omitempty"`
+	RendaPerCapita  *float64    `json:"renda_per_capita,omitempty"`
+	TecnicoID       *uuid.UUID  `json:"tecnico_id,omitempty"`
+	Sigilosa        bool        `json:"sigilosa"`
+	Situacao        string      `json:"situacao"`
+	Observacoes     *string     `json:"observacoes,omitempty"`
+	Membros         []Membro    `json:"membros"`
+	Beneficios      []Beneficio `json:"beneficios"`
+	Visitas         []Visita    `json:"visitas"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// Resumo é a linha da listagem; CPF e NIS vêm mascarados e o prontuário só abre na consulta.
+type Resumo struct {
+	ID               uuid.UUID  `json:"id"`
+	Unidade          *string    `json:"unidade,omitempty"`
+	CodigoFamiliar   *string    `json:"codigo_familiar,omitempty"`
+	ResponsavelNome  string     `json:"responsavel_nome"`
+	ResponsavelCPF   *string    `json:"responsavel_cpf,omitempty"`
+	ResponsavelNIS   *string    `json:"responsavel_nis,omitempty"`
+	Bairro           *string    `json:"bairro,omitempty"`
+	TecnicoID        *uuid.UUID `json:"tecnico_id,omitempty"`
+	Sigilosa         bool       `json:"sigilosa"`
+	Situacao         string     `json:"situacao"`
+	BeneficiosAtivos []string   `json:"beneficios_ativos"`
+	UltimaVisita     *time.Time `json:"ultima_visita,omitempty"`
+}
+
+// Membro é uma pessoa da composição familiar.
+type Membro struct {
+	ID             uuid.UUID  `json:"id"`
+	Nome           string     `json:"nome"`
+	Parentesco     string     `json:"parentesco"`
+	DataNascimento *time.Time `json:"data_nascimento,omitempty"`
+	NIS            *string    `json:"nis,omitempty"`
+}
+
+// FamiliaInput contém os campos editáveis do prontuário; Membros substitui a composição.
+type FamiliaInput struct {
+	Unidade         *string
+	CodigoFamiliar  *string
+	ResponsavelNome string
+	ResponsavelCPF  *string
+	ResponsavelNIS  *string
+	Endereco        *string
+	Bairro          *string
+	Telefone        *string
+	RendaPerCapita  *float64
+	TecnicoID       *uuid.UUID
+	Sigilosa        bool
+	Situacao        string
+	Observacoes     *string
+	Membros         []Membro
+}
+
+// Normalize limpa e valida a entrada; CPF e NIS ficam só com os dígitos.
+func (in *FamiliaInput) Normalize() error {
+	in.ResponsavelNome = strings.TrimSpace(in.ResponsavelNome)
+	in.Unidade = trimmed(in.Unidade)
+	in.CodigoFamiliar = digitsPtr(in.CodigoFamiliar)
+	in.Endereco = trimmed(in.Endereco)
+	in.Bairro = trimmed(in.Bairro)
+	in.Telefone = trimmed(in.Telefone)
+	in.Observacoes = trimmed(in.Observacoes)
+	in.Situacao = strings.ToLower(strings.TrimSpace(in.Situacao))
+	if in.Situacao == "" {
+		in.Situacao = "ativa"
+	}
+	if in.ResponsavelCPF = trimmed(in.ResponsavelCPF); in.ResponsavelCPF != nil {
+		cpf, err := util.ValidateCPF(*in.ResponsavelCPF)
+		if err != nil {
+			return errors.New("cpf do responsável inválido")
+		}
+		in.ResponsavelCPF = &cpf
+	}
+	var err error
+	if in.ResponsavelNIS, err = normalizeNIS(in.ResponsavelNIS); err != nil {
+		return err
+	}
+	switch {
+	case in.ResponsavelNome == "":
+		return errors.New("nome do responsável obrigatório")
+	case !slices.Contains(situacoesFamilia, in.Situacao):
+		return errors.New("situação inválida")
+	case in.RendaPerCapita != nil && *in.RendaPerCapita < 0:
+		return errors.New("renda per capita inválida")
+	}
+	for i := range in.Membros {
+		m := &in.Membros[i]
+		m.Nome = strings.TrimSpace(m.Nome)
+		m.Parentesco = strings.ToLower(strings.TrimSpace(m.Parentesco))
+		if m.Nome == "" || m.Parentesco == "" {
+			return errors.New("membro exige nome e parentesco")
+		}
+		if m.NIS, err = normalizeNIS(m.NIS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AlteracaoRestrita informa se a alteração mexe no sigilo ou no técnico de referência, decisões
+// reservadas à coordenação.
+func AlteracaoRestrita(atual Familia, in FamiliaInput) bool {
+	return atual.Sigilosa != in.Sigilosa || !sameUUID(atual.TecnicoID, in.TecnicoID)
+}
+
+// CamposAlterados lista os campos do prontuário que a alteração muda, sem os valores, para a
+// auditoria.
+func CamposAlterados(atual Familia, in FamiliaInput) []string {
+	campos := []string{}
+	check := func(nome string, changed bool) {
+		if changed {
+			campos = append(campos, nome)
+		}
+	}
+	check("unidade", !sameString(atual.Unidade, in.Unidade))
+	check("codigo_familiar", !sameString(atual.CodigoFamiliar, in.CodigoFamiliar))
+	check("responsavel_nome", atual.ResponsavelNome != in.ResponsavelNome)
+	check("responsavel_cpf", !sameString(atual.ResponsavelCPF, in.ResponsavelCPF))
+	check("responsavel_nis", !sameString(atual.ResponsavelNIS, in.ResponsavelNIS))
+	check("endereco", !sameString(atual.Endereco, in.Endereco))
+	check("bairro", !sameString(atual.Bairro, in.Bairro))
+	check("telefone", !sameString(atual.Telefone, in.Telefone))
+	check("renda_per_capita", !sameFloat(atual.RendaPerCapita, in.RendaPerCapita))
+	check("tecnico_id", !sameUUID(atual.TecnicoID, in.TecnicoID))
+	check("sigilosa", atual.Sigilosa != in.Sigilosa)
+	check("situacao", atual.Situacao != in.Situacao)
+	check("observacoes", !sameString(atual.Observacoes, in.Observacoes))
+	return campos
+}
+
+// Beneficio é a inserção da família num programa ou benefício.
+type Beneficio struct {
+	ID             uuid.UUID  `json:"id"`
+	FamiliaID      uuid.UUID  `json:"familia_id"`
+	Programa       string     `json:"programa"`
+	Descricao      *string    `json:"descricao,omitempty"`
+	Situacao       string     `json:"situacao"`
+	Inicio         time.Time  `json:"inicio"`
+	Fim            *time.Time `json:"fim,omitempty"`
+	ValorMensal    *float64   `json:"valor_mensal,omitempty"`
+	NISTitular     *string    `json:"nis_titular,omitempty"`
+	CodigoFamiliar *string    `json:"codigo_familiar,omitempty"`
+	Referencia     *string    `json:"referencia,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeneficioInput descreve a inserção. NISTitular, CodigoFamiliar e Referencia (folha AAAA-MM)
+// são os campos de conciliação do Bolsa Família.
+type BeneficioInput struct {
+	Programa       string
+	Descricao      *string
+	Situacao       string
+	Inicio         time.Time
+	Fim            *time.Time
+	ValorMensal    *float64
+	NISTitular     *string
+	CodigoFamiliar *string
+	Referencia     *string
+}
+
+// Normalize limpa e valida a entrada; o Bolsa Família exige o NIS do titular.
+func (in *BeneficioInput) Normalize() error {
+	in.Programa = strings.ToLower(strings.TrimSpace(in.Programa))
+	in.Situacao = strings.ToLower(strings.TrimSpace(in.Situacao))
+	if in.Situacao == "" {
+		in.Situacao = "ativo"
+	}
+	in.Descricao = trimmed(in.Descricao)
+	in.CodigoFamiliar = digitsPtr(in.CodigoFamiliar)
+	in.Referencia = trimmed(in.Referencia)
+	var err error
+	if in.NISTitular, err = normalizeNIS(in.NISTitular); err != nil {
+		return err
+	}
+	switch {
+	case !slices.Contains(programas, in.Programa):
+		return errors.New("programa inválido")
+	case !slices.Contains(situacoesBeneficio, in.Situacao):
+		return errors.New("situação inválida")
+	case in.Inicio.IsZero():
+		return errors.New("início obrigatório")
+	case in.Fim != nil && in.Fim.Before(in.Inicio):
+		return errors.New("fim anterior ao início")
+	case in.ValorMensal != nil && *in.ValorMensal < 0:
+		return errors.New("valor inválido")
+	case in.Referencia != nil && !referenciaPattern.MatchString(*in.Referencia):
+		return errors.New("referência deve estar no formato AAAA-MM")
+	case in.Programa == ProgramaBolsaFamilia && in.NISTitular == nil:
+		return errors.New("bolsa família exige o NIS do titular")
+	case in.Programa == ProgramaOutro && in.Descricao == nil:
+		return errors.New("descreva o programa")
+	}
+	return nil
+}
+
+// Visita é um atendimento, visita domiciliar ou acompanhamento registrado pelo técnico.
+type Visita struct {
+	ID              uuid.UUID  `json:"id"`
+	FamiliaID       uuid.UUID  `json:"familia_id"`
+	Tipo            string     `json:"tipo"`
+	Data            time.Time  `json:"data"`
+	Relato          string     `json:"relato"`
+	Encaminhamentos *string    `json:"encaminhamentos,omitempty"`
+	TecnicoID       *uuid.UUID `json:"tecnico_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// VisitaInput descreve a visita; sem data, vale o dia de hoje.
+type VisitaInput struct {
+	Tipo            string
+	Data            time.Time
+	Relato          string
+	Encaminhamentos *string
+}
+
+// Normalize limpa e valida a entrada.
+func (in *VisitaInput) Normalize() error {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Relato = strings.TrimSpace(in.Relato)
+	in.Encaminhamentos = trimmed(in.Encaminhamentos)
+	if in.Data.IsZero() {
+		in.Data = time.Now()
+	}
+	switch {
+	case !slices.Contains(tiposVisita, in.Tipo):
+		return errors.New("tipo de visita inválido")
+	case in.Relato == "":
+		return errors.New("relato obrigatório")
+	case in.Data.After(time.Now().Add(24 * time.Hour)):
+		return errors.New("data no futuro")
+	}
+	return nil
+}
+
+// Filter restringe a listagem de famílias.
+type Filter struct {
+	Query     string
+	Bairro    string
+	Programa  string
+	Situacao  string
+	TecnicoID *uuid.UUID
+	Limit     int
+}
+
+// Registro é uma linha da trilha de auditoria.
+type Registro struct {
+	ID          uuid.UUID      `json:"id"`
+	UsuarioID   uuid.UUID      `json:"usuario_id"`
+	UsuarioNome *string        `json:"usuario_nome,omitempty"`
+	FamiliaID   *uuid.UUID     `json:"familia_id,omitempty"`
+	Acao        string         `json:"acao"`
+	Detalhe     map[string]any `json:"detalhe"`
+	IP          *string        `json:"ip,omitempty"`
+	UserAgent   *string        `json:"user_agent,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// AuditoriaFilter restringe a consulta à trilha.
+type AuditoriaFilter struct {
+	FamiliaID *uuid.UUID
+	UsuarioID *uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+}
+
+// MaskCPF mostra só os dígitos centrais do CPF (***.456.789-**).
+func MaskCPF(cpf string) string {
+	if len(cpf) != 11 {
+		return "***"
+	}
+	return "***." + cpf[3:6] + "." + cpf[6:9] + "-**"
+}
+
+// MaskNIS mostra só os quatro últimos dígitos do NIS.
+func MaskNIS(nis string) string {
+	if len(nis) < 4 {
+		return "***"
+	}
+	return strings.Repeat("*", len(nis)-4) + nis[len(nis)-4:]
+}
+
+func normalizeNIS(value *string) (*string, error) {
+	nis := digitsPtr(value)
+	if nis != nil && len(*nis) != 11 {
+		return nil, errors.New("NIS deve ter 11 dígitos")
+	}
+	return nis, nil
+}
+
+func digitsPtr(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	var sb strings.Builder
+	for _, r := range *value {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	out := sb.String()
+	return &out
+}
+
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	if v := strings.TrimSpace(*value); v != "" {
+		return &v
+	}
+	return nil
+}
+
+func sameString(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameFloat(a, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameUUID(a, b *uuid.UUID) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
@@ -0,0 +1,106 @@
+package assistencia
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVisivel(t *testing.T) {
+	tecnico := uuid.New()
+	outro := uuid.New()
+	if !Visivel(Acesso{UsuarioID: outro}, false, &tecnico) {
+		t.Fatal("família comum deve ser visível a qualquer profissional")
+	}
+	if Visivel(Acesso{UsuarioID: outro}, true, &tecnico) {
+		t.Fatal("família sigilosa não deve ser visível a outro técnico")
+	}
+	if Visivel(Acesso{UsuarioID: outro}, true, nil) {
+		t.Fatal("família sigilosa sem referência só é visível à coordenação")
+	}
+	if !Visivel(Acesso{UsuarioID: tecnico}, true, &tecnico) || !Visivel(Acesso{UsuarioID: outro, Coordenador: true}, true, &tecnico) {
+		t.Fatal("técnico de referência e coordenação devem ver a família sigilosa")
+	}
+}
+
+func TestMask(t *testing.T) {
+	if got := MaskCPF("52998224725"); got != "***.982.247-**" {
+		t.Errorf("MaskCPF = %q", got)
+	}
+	if got := MaskNIS("12345678901"); got != "*******8901" {
+		t.Errorf("MaskNIS = %q", got)
+	}
+	if MaskCPF("123") != "***" || MaskNIS("12") != "***" {
+		t.Error("valores curtos devem ser totalmente mascarados")
+	}
+}
+
+func TestFamiliaInputNormalize(t *testing.T) {
+	cpf := "529.982.247-25"
+	nis := "123.45678.90-1"
+	codigo := " 0001-234 "
+	in := FamiliaInput{ResponsavelNome: " Maria ", ResponsavelCPF: &cpf, ResponsavelNIS: &nis, CodigoFamiliar: &codigo,
+		Membros: []Membro{{Nome: " João ", Parentesco: " Filho "}}}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if *in.ResponsavelCPF != "52998224725" || *in.ResponsavelNIS != "12345678901" || *in.CodigoFamiliar != "0001234" || in.Situacao != "ativa" {
+		t.Fatalf("entrada não normalizada: %+v", in)
+	}
+	if in.Membros[0].Nome != "João" || in.Membros[0].Parentesco != "filho" {
+		t.Fatalf("membro não normalizado: %+v", in.Membros[0])
+	}
+
+	bad := "111.111.111-11"
+	in = FamiliaInput{ResponsavelNome: "Maria", ResponsavelCPF: &bad}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro de CPF")
+	}
+	curto := "123"
+	in = FamiliaInput{ResponsavelNome: "Maria", Membros: []Membro{{Nome: "Ana", Parentesco: "filha", NIS: &curto}}}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro de NIS")
+	}
+}
+
+func TestBeneficioInputNormalize(t *testing.T) {
+	in := BeneficioInput{Programa: "Bolsa_Familia", Inicio: time.Now()}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("bolsa família sem NIS deve falhar")
+	}
+	nis := "12345678901"
+	ref := "2025-13"
+	in = BeneficioInput{Programa: ProgramaBolsaFamilia, Inicio: time.Now(), NISTitular: &nis, Referencia: &ref}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("referência com mês inválido deve falhar")
+	}
+	ref = "2025-04"
+	in.Referencia = &ref
+	if err := in.Normalize(); err != nil || in.Situacao != "ativo" {
+		t.Fatalf("normalize: %v %+v", err, in)
+	}
+}
+
+func TestCamposAlterados(t *testing.T) {
+	tecnico := uuid.New()
+	bairro := "Centro"
+	atual := Familia{ResponsavelNome: "Maria", Bairro: &bairro, TecnicoID: &tecnico, Situacao: "ativa"}
+	outroBairro := "Alto"
+	in := FamiliaInput{ResponsavelNome: "Maria", Bairro: &outroBairro, TecnicoID: &tecnico, Situacao: "ativa"}
+	if got := CamposAlterados(atual, in); !reflect.DeepEqual(got, []string{"bairro"}) {
+		t.Fatalf("CamposAlterados = %v", got)
+	}
+	if AlteracaoRestrita(atual, in) {
+		t.Fatal("troca de bairro não é alteração restrita")
+	}
+	in.Sigilosa = true
+	if !AlteracaoRestrita(atual, in) {
+		t.Fatal("mudança de sigilo é restrita à coordenação")
+	}
+	in.Sigilosa, in.TecnicoID = false, nil
+	if !AlteracaoRestrita(atual, in) {
+		t.Fatal("troca do técnico de referência é restrita à coordenação")
+	}
+}
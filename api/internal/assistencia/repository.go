@@ -0,0 +1,650 @@
+package assistencia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const familiaColumns = `id, unidade, codigo_familiar, responsavel_nome, responsavel_cpf, responsavel_nis, endereco, bairro,
+        telefone, renda_per_capita::float8, tecnico_id, sigilosa, situacao, observacoes, created_at, updated_at`
+
+const beneficioColumns = `id, familia_id, programa, descricao, situacao, inicio, fim, valor_mensal::float8, nis_titular,
+        codigo_familiar, referencia, created_at, updated_at`
+
+// visibilidade é o filtro SQL equivalente a Visivel; $1 é o usuário e $2 indica coordenador.
+const visibilidade = `(NOT f.sigilosa OR $2::bool OR f.tecnico_id = $1)`
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Repository provê acesso ao módulo de assistência social. Toda operação grava a auditoria na
+// mesma transação: sem registro na trilha, nada é lido nem alterado.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// Profissional devolve o acesso ativo do usuário no módulo ou ErrForbidden.
+func (r *Repository) Profissional(ctx context.Context, tenantID, usuarioID uuid.UUID) (*Profissional, error) {
+	p, err := scanProfissional(r.conn(ctx).QueryRow(ctx, `
+        SELECT p.usuario_id, u.nome, u.email, p.funcao, p.unidade, p.ativo, p.created_at, p.updated_at
+        FROM assistencia_profissionais p
+        JOIN usuarios u ON u.id = p.usuario_id
+        WHERE p.tenant_id = $1 AND p.usuario_id = $2 AND p.ativo AND u.ativo
+    `, tenantID, usuarioID))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrForbidden
+	}
+	return p, err
+}
+
+// PodeGerir informa se o usuário concede acessos: coordenadores do módulo e secretários ou
+// administradores técnicos de alguma secretaria da prefeitura.
+func (r *Repository) PodeGerir(ctx context.Context, tenantID, usuarioID uuid.UUID) (bool, error) {
+	var ok bool
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS (
+                   SELECT 1 FROM assistencia_profissionais
+                   WHERE tenant_id = $1 AND usuario_id = $2 AND ativo AND funcao = 'coordenador'
+               )
+            OR EXISTS (
+                   SELECT 1 FROM usuarios_secretarias us
+                   JOIN secretarias s ON s.id = us.secretaria_id
+                   WHERE s.tenant_id = $1 AND us.usuario_id = $2 AND us.papel IN ('SECRETARIO', 'ADMIN_TEC')
+               )
+    `, tenantID, usuarioID).Scan(&ok)
+	return ok, err
+}
+
+// ListProfissionais lista os acessos concedidos, inclusive revogados.
+func (r *Repository) ListProfissionais(ctx context.Context, tenantID uuid.UUID) ([]Profissional, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT p.usuario_id, u.nome, u.email, p.funcao, p.unidade, p.ativo, p.created_at, p.updated_at
+        FROM assistencia_profissionais p
+        JOIN usuarios u ON u.id = p.usuario_id
+        WHERE p.tenant_id = $1
+        ORDER BY p.ativo DESC, u.nome
+    `, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Profissional, error) {
+		p, err := scanProfissional(row)
+		if err != nil {
+			return Profissional{}, err
+		}
+		return *p, nil
+	})
+}
+
+// SetProfissional concede ou altera o acesso; o usuário precisa atuar em alguma secretaria da
+// prefeitura.
+func (r *Repository) SetProfissional(ctx context.Context, tenantID uuid.UUID, a Acesso, in ProfissionalInput) (*Profissional, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO assistencia_profissionais (tenant_id, usuario_id, funcao, unidade, concedido_por)
+        SELECT $1, $2, $3, $4, $5
+        WHERE EXISTS (
+            SELECT 1 FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE s.tenant_id = $1 AND us.usuario_id = $2
+        )
+        ON CONFLICT (tenant_id, usuario_id) DO UPDATE
+        SET funcao = EXCLUDED.funcao, unidade = EXCLUDED.unidade, ativo = TRUE,
+            concedido_por = EXCLUDED.concedido_por, updated_at = now()
+    `, tenantID, in.UsuarioID, in.Funcao, in.Unidade, a.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrUsuario
+	}
+	if err := registrar(ctx, tx, tenantID, a, nil, AcaoConceder, map[string]any{"usuario_id": in.UsuarioID, "funcao": in.Funcao}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.profissional(ctx, tenantID, in.UsuarioID)
+}
+
+// RevokeProfissional desativa o acesso do usuário, mantendo o histórico.
+func (r *Repository) RevokeProfissional(ctx context.Context, tenantID uuid.UUID, a Acesso, usuarioID uuid.UUID) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE assistencia_profissionais SET ativo = FALSE, updated_at = now()
+        WHERE tenant_id = $1 AND usuario_id = $2 AND ativo
+    `, tenantID, usuarioID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := registrar(ctx, tx, tenantID, a, nil, AcaoRevogar, map[string]any{"usuario_id": usuarioID}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListFamilias lista as famílias visíveis a quem consulta, com documentos mascarados.
+func (r *Repository) ListFamilias(ctx context.Context, tenantID uuid.UUID, a Acesso, filter Filter) ([]Resumo, error) {
+	clauses := []string{"f.tenant_id = $3", visibilidade}
+	args := []any{a.UsuarioID, a.Coordenador, tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	detalhe := map[string]any{}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		add("(f.responsavel_nome ILIKE '%' || $? || '%' OR f.codigo_familiar = $? OR f.responsavel_nis = $? OR f.responsavel_cpf = $?)", q)
+		detalhe["busca"] = true
+	}
+	if filter.Bairro != "" {
+		add("f.bairro ILIKE $?", filter.Bairro)
+		detalhe["bairro"] = filter.Bairro
+	}
+	if filter.Situacao != "" {
+		add("f.situacao = $?", filter.Situacao)
+		detalhe["situacao"] = filter.Situacao
+	}
+	if filter.Programa != "" {
+		add("EXISTS (SELECT 1 FROM assistencia_beneficios b WHERE b.familia_id = f.id AND b.situacao = 'ativo' AND b.programa = $?)", filter.Programa)
+		detalhe["programa"] = filter.Programa
+	}
+	if filter.TecnicoID != nil {
+		add("f.tecnico_id = $?", *filter.TecnicoID)
+		detalhe["tecnico_id"] = *filter.TecnicoID
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args = append(args, limit)
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := registrar(ctx, tx, tenantID, a, nil, AcaoListar, detalhe); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, `
+        SELECT f.id, f.unidade, f.codigo_familiar, f.responsavel_nome, f.responsavel_cpf, f.responsavel_nis, f.bairro,
+               f.tecnico_id, f.sigilosa, f.situacao,
+               COALESCE((SELECT array_agg(DISTINCT b.programa ORDER BY b.programa) FROM assistencia_beneficios b
+                         WHERE b.familia_id = f.id AND b.situacao = 'ativo'), '{}'),
+               (SELECT max(v.data) FROM assistencia_visitas v WHERE v.familia_id = f.id)
+        FROM assistencia_familias f
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY f.responsavel_nome
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	familias, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Resumo, error) {
+		var f Resumo
+		err := row.Scan(&f.ID, &f.Unidade, &f.CodigoFamiliar, &f.ResponsavelNome, &f.ResponsavelCPF, &f.ResponsavelNIS, &f.Bairro,
+			&f.TecnicoID, &f.Sigilosa, &f.Situacao, &f.BeneficiosAtivos, &f.UltimaVisita)
+		if f.ResponsavelCPF != nil {
+			masked := MaskCPF(*f.ResponsavelCPF)
+			f.ResponsavelCPF = &masked
+		}
+		if f.ResponsavelNIS != nil {
+			masked := MaskNIS(*f.ResponsavelNIS)
+			f.ResponsavelNIS = &masked
+		}
+		return f, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return familias, nil
+}
+
+// GetFamilia abre o prontuário completo. Tentativa sobre família sigilosa fora do alcance é
+// registrada como acesso negado e responde como inexistente.
+func (r *Repository) GetFamilia(ctx context.Context, tenantID uuid.UUID, a Acesso, id uuid.UUID) (*Familia, error) {
+	var f *Familia
+	err := r.withFamilia(ctx, tenantID, a, id, false, func(tx pgx.Tx, atual *Familia) error {
+		if err := registrar(ctx, tx, tenantID, a, &id, AcaoConsultar, map[string]any{}); err != nil {
+			return err
+		}
+		if err := loadDetalhes(ctx, tx, atual); err != nil {
+			return err
+		}
+		f = atual
+		return nil
+	})
+	return f, err
+}
+
+// CreateFamilia abre o prontuário. Técnicos ficam como referência da família que cadastram e só a
+// coordenação indica outro técnico.
+func (r *Repository) CreateFamilia(ctx context.Context, tenantID uuid.UUID, a Acesso, in FamiliaInput) (*Familia, error) {
+	if in.TecnicoID == nil && !a.Coordenador {
+		in.TecnicoID = &a.UsuarioID
+	}
+	if !a.Coordenador && *in.TecnicoID != a.UsuarioID {
+		return nil, ErrForbidden
+	}
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.checkTecnico(ctx, tx, tenantID, in.TecnicoID); err != nil {
+		return nil, err
+	}
+	f, err := scanFamilia(tx.QueryRow(ctx, `
+        INSERT INTO assistencia_familias (tenant_id, unidade, codigo_familiar, responsavel_nome, responsavel_cpf, responsavel_nis,
+                                          endereco, bairro, telefone, renda_per_capita, tecnico_id, sigilosa, situacao, observacoes, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+        RETURNING `+familiaColumns,
+		tenantID, in.Unidade, in.CodigoFamiliar, in.ResponsavelNome, in.ResponsavelCPF, in.ResponsavelNIS, in.Endereco, in.Bairro,
+		in.Telefone, in.RendaPerCapita, in.TecnicoID, in.Sigilosa, in.Situacao, in.Observacoes, a.UsuarioID))
+	if err != nil {
+		return nil, familiaError(err)
+	}
+	if f.Membros, err = replaceMembros(ctx, tx, f.ID, in.Membros); err != nil {
+		return nil, err
+	}
+	if err := registrar(ctx, tx, tenantID, a, &f.ID, AcaoCriar, map[string]any{"sigilosa": f.Sigilosa}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	f.Beneficios, f.Visitas = []Beneficio{}, []Visita{}
+	return f, nil
+}
+
+// UpdateFamilia substitui os dados do prontuário e a composição familiar. Sigilo e técnico de
+// referência só mudam pela coordenação.
+func (r *Repository) UpdateFamilia(ctx context.Context, tenantID uuid.UUID, a Acesso, id uuid.UUID, in FamiliaInput) (*Familia, error) {
+	var f *Familia
+	err := r.withFamilia(ctx, tenantID, a, id, true, func(tx pgx.Tx, atual *Familia) error {
+		if AlteracaoRestrita(*atual, in) && !a.Coordenador {
+			return ErrForbidden
+		}
+		if err := r.checkTecnico(ctx, tx, tenantID, in.TecnicoID); err != nil {
+			return err
+		}
+		campos := CamposAlterados(*atual, in)
+		updated, err := scanFamilia(tx.QueryRow(ctx, `
+            UPDATE assistencia_familias
+            SET unidade = $2, codigo_familiar = $3, responsavel_nome = $4, responsavel_cpf = $5, responsavel_nis = $6,
+                endereco = $7, bairro = $8, telefone = $9, renda_per_capita = $10, tecnico_id = $11, sigilosa = $12,
+                situacao = $13, observacoes = $14, updated_at = now()
+            WHERE id = $1
+            RETURNING `+familiaColumns,
+			id, in.Unidade, in.CodigoFamiliar, in.ResponsavelNome, in.ResponsavelCPF, in.ResponsavelNIS, in.Endereco, in.Bairro,
+			in.Telefone, in.RendaPerCapita, in.TecnicoID, in.Sigilosa, in.Situacao, in.Observacoes))
+		if err != nil {
+			return familiaError(err)
+		}
+		if _, err := replaceMembros(ctx, tx, id, in.Membros); err != nil {
+			return err
+		}
+		if err := registrar(ctx, tx, tenantID, a, &id, AcaoAlterar, map[string]any{"campos": campos, "membros": len(in.Membros)}); err != nil {
+			return err
+		}
+		if err := loadDetalhes(ctx, tx, updated); err != nil {
+			return err
+		}
+		f = updated
+		return nil
+	})
+	return f, err
+}
+
+// AddBeneficio registra a inserção da família num programa.
+func (r *Repository) AddBeneficio(ctx context.Context, tenantID uuid.UUID, a Acesso, familiaID uuid.UUID, in BeneficioInput) (*Beneficio, error) {
+	var b *Beneficio
+	err := r.withFamilia(ctx, tenantID, a, familiaID, true, func(tx pgx.Tx, _ *Familia) error {
+		created, err := scanBeneficio(tx.QueryRow(ctx, `
+            INSERT INTO assistencia_beneficios (familia_id, programa, descricao, situacao, inicio, fim, valor_mensal, nis_titular,
+                                                codigo_familiar, referencia, created_by)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+            RETURNING `+beneficioColumns,
+			familiaID, in.Programa, in.Descricao, in.Situacao, in.Inicio, in.Fim, in.ValorMensal, in.NISTitular,
+			in.CodigoFamiliar, in.Referencia, a.UsuarioID))
+		if err != nil {
+			return err
+		}
+		if err := registrar(ctx, tx, tenantID, a, &familiaID, AcaoBeneficio, map[string]any{"beneficio_id": created.ID, "programa": created.Programa, "situacao": created.Situacao}); err != nil {
+			return err
+		}
+		b = created
+		return nil
+	})
+	return b, err
+}
+
+// UpdateBeneficio altera a inserção (suspensão, encerramento, nova folha de referência).
+func (r *Repository) UpdateBeneficio(ctx context.Context, tenantID uuid.UUID, a Acesso, familiaID, beneficioID uuid.UUID, in BeneficioInput) (*Beneficio, error) {
+	var b *Beneficio
+	err := r.withFamilia(ctx, tenantID, a, familiaID, true, func(tx pgx.Tx, _ *Familia) error {
+		updated, err := scanBeneficio(tx.QueryRow(ctx, `
+            UPDATE assistencia_beneficios
+            SET programa = $3, descricao = $4, situacao = $5, inicio = $6, fim = $7, valor_mensal = $8, nis_titular = $9,
+                codigo_familiar = $10, referencia = $11, updated_at = now()
+            WHERE familia_id = $1 AND id = $2
+            RETURNING `+beneficioColumns,
+			familiaID, beneficioID, in.Programa, in.Descricao, in.Situacao, in.Inicio, in.Fim, in.ValorMensal, in.NISTitular,
+			in.CodigoFamiliar, in.Referencia))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := registrar(ctx, tx, tenantID, a, &familiaID, AcaoBeneficio, map[string]any{"beneficio_id": beneficioID, "programa": updated.Programa, "situacao": updated.Situacao}); err != nil {
+			return err
+		}
+		b = updated
+		return nil
+	})
+	return b, err
+}
+
+// AddVisita registra a visita ou o atendimento feito por quem está autenticado.
+func (r *Repository) AddVisita(ctx context.Context, tenantID uuid.UUID, a Acesso, familiaID uuid.UUID, in VisitaInput) (*Visita, error) {
+	var v Visita
+	err := r.withFamilia(ctx, tenantID, a, familiaID, false, func(tx pgx.Tx, _ *Familia) error {
+		if err := tx.QueryRow(ctx, `
+            INSERT INTO assistencia_visitas (familia_id, tipo, data, relato, encaminhamentos, tecnico_id)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id, familia_id, tipo, data, relato, encaminhamentos, tecnico_id, created_at
+        `, familiaID, in.Tipo, in.Data, in.Relato, in.Encaminhamentos, a.UsuarioID).Scan(
+			&v.ID, &v.FamiliaID, &v.Tipo, &v.Data, &v.Relato, &v.Encaminhamentos, &v.TecnicoID, &v.CreatedAt); err != nil {
+			return err
+		}
+		return registrar(ctx, tx, tenantID, a, &familiaID, AcaoVisita, map[string]any{"visita_id": v.ID, "tipo": v.Tipo})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Auditoria consulta a trilha; é reservada à coordenação e a própria consulta fica registrada.
+func (r *Repository) Auditoria(ctx context.Context, tenantID uuid.UUID, a Acesso, filter AuditoriaFilter) ([]Registro, error) {
+	if !a.Coordenador {
+		return nil, ErrForbidden
+	}
+	clauses := []string{"l.tenant_id = $1"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	detalhe := map[string]any{}
+	if filter.FamiliaID != nil {
+		add("l.familia_id = $?", *filter.FamiliaID)
+		detalhe["familia_id"] = *filter.FamiliaID
+	}
+	if filter.UsuarioID != nil {
+		add("l.usuario_id = $?", *filter.UsuarioID)
+		detalhe["usuario_id"] = *filter.UsuarioID
+	}
+	if filter.From != nil {
+		add("l.created_at >= $?", *filter.From)
+	}
+	if filter.To != nil {
+		add("l.created_at < $?", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := registrar(ctx, tx, tenantID, a, filter.FamiliaID, AcaoAuditoria, detalhe); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, `
+        SELECT l.id, l.usuario_id, u.nome, l.familia_id, l.acao, l.detalhe, l.ip, l.user_agent, l.created_at
+        FROM assistencia_auditoria l
+        LEFT JOIN usuarios u ON u.id = l.usuario_id
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY l.created_at DESC
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	registros, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Registro, error) {
+		var (
+			reg Registro
+			raw []byte
+		)
+		if err := row.Scan(&reg.ID, &reg.UsuarioID, &reg.UsuarioNome, &reg.FamiliaID, &reg.Acao, &raw, &reg.IP, &reg.UserAgent, &reg.CreatedAt); err != nil {
+			return Registro{}, err
+		}
+		reg.Detalhe = map[string]any{}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &reg.Detalhe); err != nil {
+				return Registro{}, err
+			}
+		}
+		return reg, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return registros, nil
+}
+
+// withFamilia carrega a família numa transação e aplica o sigilo. A negativa é gravada fora da
+// transação, para sobreviver ao rollback.
+func (r *Repository) withFamilia(ctx context.Context, tenantID uuid.UUID, a Acesso, id uuid.UUID, lock bool, fn func(pgx.Tx, *Familia) error) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + familiaColumns + ` FROM assistencia_familias WHERE tenant_id = $1 AND id = $2`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+	f, err := scanFamilia(tx.QueryRow(ctx, query, tenantID, id))
+	if err != nil {
+		return err
+	}
+	if !Visivel(a, f.Sigilosa, f.TecnicoID) {
+		if err := registrar(ctx, r.conn(ctx), tenantID, a, &id, AcaoNegado, map[string]any{"motivo": "familia_sigilosa"}); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	if err := fn(tx, f); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) profissional(ctx context.Context, tenantID, usuarioID uuid.UUID) (*Profissional, error) {
+	return scanProfissional(r.conn(ctx).QueryRow(ctx, `
+        SELECT p.usuario_id, u.nome, u.email, p.funcao, p.unidade, p.ativo, p.created_at, p.updated_at
+        FROM assistencia_profissionais p
+        JOIN usuarios u ON u.id = p.usuario_id
+        WHERE p.tenant_id = $1 AND p.usuario_id = $2
+    `, tenantID, usuarioID))
+}
+
+// checkTecnico exige que o técnico de referência seja profissional ativo do módulo.
+func (r *Repository) checkTecnico(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, tecnicoID *uuid.UUID) error {
+	if tecnicoID == nil {
+		return nil
+	}
+	var ok bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM assistencia_profissionais WHERE tenant_id = $1 AND usuario_id = $2 AND ativo)
+    `, tenantID, *tecnicoID).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUsuario
+	}
+	return nil
+}
+
+func registrar(ctx context.Context, db execer, tenantID uuid.UUID, a Acesso, familiaID *uuid.UUID, acao string, detalhe map[string]any) error {
+	raw, err := json.Marshal(detalhe)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, `
+        INSERT INTO assistencia_auditoria (tenant_id, usuario_id, familia_id, acao, detalhe, ip, user_agent)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6::text, ''), NULLIF($7::text, ''))
+    `, tenantID, a.UsuarioID, familiaID, acao, raw, a.IP, a.UserAgent)
+	return err
+}
+
+func replaceMembros(ctx context.Context, tx pgx.Tx, familiaID uuid.UUID, membros []Membro) ([]Membro, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM assistencia_membros WHERE familia_id = $1`, familiaID); err != nil {
+		return nil, err
+	}
+	out := make([]Membro, 0, len(membros))
+	for i, m := range membros {
+		if err := tx.QueryRow(ctx, `
+            INSERT INTO assistencia_membros (familia_id, nome, parentesco, data_nascimento, nis, posicao)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id
+        `, familiaID, m.Nome, m.Parentesco, m.DataNascimento, m.NIS, i).Scan(&m.ID); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func loadDetalhes(ctx context.Context, tx pgx.Tx, f *Familia) error {
+	rows, err := tx.Query(ctx, `
+        SELECT id, nome, parentesco, data_nascimento, nis FROM assistencia_membros WHERE familia_id = $1 ORDER BY posicao
+    `, f.ID)
+	if err != nil {
+		return err
+	}
+	if f.Membros, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Membro, error) {
+		var m Membro
+		err := row.Scan(&m.ID, &m.Nome, &m.Parentesco, &m.DataNascimento, &m.NIS)
+		return m, err
+	}); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(ctx, `SELECT `+beneficioColumns+` FROM assistencia_beneficios WHERE familia_id = $1 ORDER BY inicio DESC`, f.ID)
+	if err != nil {
+		return err
+	}
+	if f.Beneficios, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Beneficio, error) {
+		b, err := scanBeneficio(row)
+		if err != nil {
+			return Beneficio{}, err
+		}
+		return *b, nil
+	}); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(ctx, `
+        SELECT id, familia_id, tipo, data, relato, encaminhamentos, tecnico_id, created_at
+        FROM assistencia_visitas WHERE familia_id = $1 ORDER BY data DESC, created_at DESC
+    `, f.ID)
+	if err != nil {
+		return err
+	}
+	f.Visitas, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Visita, error) {
+		var v Visita
+		err := row.Scan(&v.ID, &v.FamiliaID, &v.Tipo, &v.Data, &v.Relato, &v.Encaminhamentos, &v.TecnicoID, &v.CreatedAt)
+		return v, err
+	})
+	return err
+}
+
+func scanProfissional(row pgx.Row) (*Profissional, error) {
+	var p Profissional
+	if err := row.Scan(&p.UsuarioID, &p.Nome, &p.Email, &p.Funcao, &p.Unidade, &p.Ativo, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func scanFamilia(row pgx.Row) (*Familia, error) {
+	var f Familia
+	if err := row.Scan(&f.ID, &f.Unidade, &f.CodigoFamiliar, &f.ResponsavelNome, &f.ResponsavelCPF, &f.ResponsavelNIS, &f.Endereco,
+		&f.Bairro, &f.Telefone, &f.RendaPerCapita, &f.TecnicoID, &f.Sigilosa, &f.Situacao, &f.Observacoes, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	f.Membros, f.Beneficios, f.Visitas = []Membro{}, []Beneficio{}, []Visita{}
+	return &f, nil
+}
+
+func scanBeneficio(row pgx.Row) (*Beneficio, error) {
+	var b Beneficio
+	if err := row.Scan(&b.ID, &b.FamiliaID, &b.Programa, &b.Descricao, &b.Situacao, &b.Inicio, &b.Fim, &b.ValorMensal, &b.NISTitular,
+		&b.CodigoFamiliar, &b.Referencia, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func familiaError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
@@ -0,0 +1,223 @@
+// Package ativo mantém o cadastro de ativos municipais (praças, escolas, postes, veículos) que
+// protocolos e ordens de manutenção referenciam, com etiqueta QR e histórico de serviço.
+package ativo
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica ativo inexistente ou de outro tenant.
+	ErrNotFound = errors.New("ativo: não encontrado")
+	// ErrDuplicate indica código de patrimônio já usado no tenant.
+	ErrDuplicate = errors.New("ativo: código já cadastrado")
+)
+
+// Tipos de ativo.
+const (
+	TipoPraca       = "praca"
+	TipoEscola      = "escola"
+	TipoPoste       = "poste"
+	TipoVeiculo     = "veiculo"
+	TipoPredio      = "predio"
+	TipoVia         = "via"
+	TipoEquipamento = "equipamento"
+	TipoOutro       = "outro"
+)
+
+// Situação do ativo.
+const (
+	StatusAtivo      = "ativo"
+	StatusManutencao = "manutencao"
+	StatusInativo    = "inativo"
+	StatusBaixado    = "baixado"
+)
+
+// Tipos de entrada do histórico. Protocolos vinculados e ordens de serviço do ativo aparecem como
+// HistoricoProtocolo e HistoricoOrdem.
+const (
+	HistoricoManutencao = "manutencao"
+	HistoricoStatus     = "status"
+	HistoricoNota       = "nota"
+	HistoricoProtocolo  = "protocolo"
+	HistoricoOrdem      = "ordem_servico"
+)
+
+// Ativo é um bem municipal cadastrado por uma secretaria.
+type Ativo struct {
+	ID           uuid.UUID      `json:"id"`
+	TenantID     uuid.UUID      `json:"tenant_id"`
+	SecretariaID *uuid.UUID     `json:"secretaria_id,omitempty"`
+	Tipo         string         `json:"tipo"`
+	Codigo       string         `json:"codigo"`
+	Nome         string         `json:"nome"`
+	Descricao    *string        `json:"descricao,omitempty"`
+	Endereco     *string        `json:"endereco,omitempty"`
+	Bairro       *string        `json:"bairro,omitempty"`
+	Latitude     *float64       `json:"latitude,omitempty"`
+	Longitude    *float64       `json:"longitude,omitempty"`
+	Atributos    map[string]any `json:"atributos"`
+	Status       string         `json:"status"`
+	QRToken      string         `json:"qr_token"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// PublicAtivo é o que a etiqueta QR revela a quem a escaneia.
+type PublicAtivo struct {
+	Tipo     string  `json:"tipo"`
+	Codigo   string  `json:"codigo"`
+	Nome     string  `json:"nome"`
+	Endereco *string `json:"endereco,omitempty"`
+	Bairro   *string `json:"bairro,omitempty"`
+	Status   string  `json:"status"`
+}
+
+// Public reduz o ativo aos campos públicos.
+func (a *Ativo) Public() PublicAtivo {
+	return PublicAtivo{Tipo: a.Tipo, Codigo: a.Codigo, Nome: a.Nome, Endereco: a.Endereco, Bairro: a.Bairro, Status: a.Status}
+}
+
+// Input contém os campos editáveis de um ativo.
+type Input struct {
+	SecretariaID *uuid.UUID
+	Tipo         string
+	Codigo       string
+	Nome         string
+	Descricao    *string
+	Endereco     *string
+	Bairro       *string
+	Latitude     *float64
+	Longitude    *float64
+	Atributos    map[string]any
+	Status       string
+	ActorID      *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada.
+func (in *Input) Normalize() error {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Codigo = strings.ToUpper(strings.TrimSpace(in.Codigo))
+	in.Nome = strings.TrimSpace(in.Nome)
+	in.Status = strings.ToLower(strings.TrimSpace(in.Status))
+	in.Descricao = trimmed(in.Descricao)
+	in.Endereco = trimmed(in.Endereco)
+	in.Bairro = trimmed(in.Bairro)
+	if in.Status == "" {
+		in.Status = StatusAtivo
+	}
+	if in.Atributos == nil {
+		in.Atributos = map[string]any{}
+	}
+	switch {
+	case !ValidTipo(in.Tipo):
+		return errors.New("tipo inválido")
+	case in.Codigo == "":
+		return errors.New("código obrigatório")
+	case in.Nome == "":
+		return errors.New("nome obrigatório")
+	case !ValidStatus(in.Status):
+		return errors.New("status inválido")
+	case (in.Latitude == nil) != (in.Longitude == nil):
+		return errors.New("latitude e longitude devem ser informadas juntas")
+	case in.Latitude != nil && (*in.Latitude < -90 || *in.Latitude > 90 || *in.Longitude < -180 || *in.Longitude > 180):
+		return errors.New("localização inválida")
+	}
+	return nil
+}
+
+// ValidTipo informa se o tipo de ativo é conhecido.
+func ValidTipo(tipo string) bool {
+	switch tipo {
+	case TipoPraca, TipoEscola, TipoPoste, TipoVeiculo, TipoPredio, TipoVia, TipoEquipamento, TipoOutro:
+		return true
+	}
+	return false
+}
+
+// ValidStatus informa se a situação do ativo é conhecida.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusAtivo, StatusManutencao, StatusInativo, StatusBaixado:
+		return true
+	}
+	return false
+}
+
+// Filter restringe a listagem de ativos.
+type Filter struct {
+	Tipo         string
+	Status       string
+	SecretariaID *uuid.UUID
+	Bairro       string
+	Query        string
+	Limit        int
+}
+
+// Historico é uma entrada do histórico de serviço do ativo.
+type Historico struct {
+	ID              uuid.UUID  `json:"id"`
+	Tipo            string     `json:"tipo"`
+	Descricao       string     `json:"descricao"`
+	ProtocoloID     *uuid.UUID `json:"protocolo_id,omitempty"`
+	ProtocoloNumero *string    `json:"protocolo_numero,omitempty"`
+	Status          *string    `json:"status,omitempty"`
+	Custo           *float64   `json:"custo,omitempty"`
+	RealizadoEm     *time.Time `json:"realizado_em,omitempty"`
+	AtorID          *uuid.UUID `json:"ator_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// HistoricoInput é uma manutenção ou nota registrada pela secretaria.
+type HistoricoInput struct {
+	Tipo        string
+	Descricao   string
+	ProtocoloID *uuid.UUID
+	Custo       *float64
+	RealizadoEm *time.Time
+	AtorID      *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada; mudanças de status são registradas pelo próprio cadastro.
+func (in *HistoricoInput) Normalize() error {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Descricao = strings.TrimSpace(in.Descricao)
+	if in.Tipo == "" {
+		in.Tipo = HistoricoManutencao
+	}
+	switch {
+	case in.Tipo != HistoricoManutencao && in.Tipo != HistoricoNota:
+		return errors.New("tipo de registro inválido")
+	case in.Descricao == "":
+		return errors.New("descrição obrigatória")
+	case in.Custo != nil && *in.Custo < 0:
+		return errors.New("custo inválido")
+	}
+	return nil
+}
+
+// NewQRToken gera o identificador público impresso na etiqueta.
+func NewQRToken() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*value)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,51 @@
+package ativo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInputNormalize(t *testing.T) {
+	lat := -7.1
+	in := Input{Tipo: " Praca ", Codigo: " pr-001 ", Nome: " Praça Central "}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if in.Tipo != TipoPraca || in.Codigo != "PR-001" || in.Status != StatusAtivo || in.Atributos == nil {
+		t.Fatalf("entrada não normalizada: %+v", in)
+	}
+
+	in = Input{Tipo: "praca", Codigo: "PR-1", Nome: "Praça", Latitude: &lat}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro com latitude sem longitude")
+	}
+	in = Input{Tipo: "ponte", Codigo: "X", Nome: "Ponte"}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro de tipo")
+	}
+}
+
+func TestHistoricoInputNormalize(t *testing.T) {
+	in := HistoricoInput{Descricao: " troca de lâmpada "}
+	if err := in.Normalize(); err != nil || in.Tipo != HistoricoManutencao {
+		t.Fatalf("normalize: %v %+v", err, in)
+	}
+	in = HistoricoInput{Tipo: HistoricoStatus, Descricao: "x"}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("status não pode ser registrado manualmente")
+	}
+}
+
+func TestEtiqueta(t *testing.T) {
+	url := PublicURL("prefeitura.exemplo.gov.br/", "abc")
+	if url != "https://prefeitura.exemplo.gov.br/ativos/abc" {
+		t.Fatalf("url = %s", url)
+	}
+	svg, err := Etiqueta("Prefeitura <Teste>", url, &Ativo{Nome: "Poste", Codigo: "PT-9"})
+	if err != nil {
+		t.Fatalf("etiqueta: %v", err)
+	}
+	if !strings.Contains(svg, "Prefeitura &lt;Teste&gt;") || !strings.Contains(svg, "PT-9") {
+		t.Fatalf("etiqueta sem textos esperados: %s", svg)
+	}
+}
@@ -0,0 +1,44 @@
+package ativo
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/qrcode"
+)
+
+// etiquetaNomeMax limita o nome impresso para caber ao lado do código.
+const etiquetaNomeMax = 32
+
+// PublicURL monta o endereço gravado na etiqueta, aberto no portal do cidadão da prefeitura.
+func PublicURL(domain, token string) string {
+	return "https://" + strings.TrimSuffix(strings.TrimSpace(domain), "/") + "/ativos/" + token
+}
+
+// Etiqueta desenha a etiqueta em SVG (60 x 30 mm) com o QR code à esquerda e, ao lado, a
+// prefeitura, o nome e o código de patrimônio do ativo.
+func Etiqueta(prefeitura, url string, a *Ativo) (string, error) {
+	code, err := qrcode.Encode(url)
+	if err != nil {
+		return "", err
+	}
+	// A área útil tem 30 unidades de altura; o código, com zona de silêncio, ocupa um quadrado.
+	modules := code.Size + 8
+	nome := a.Nome
+	if runes := []rune(nome); len(runes) > etiquetaNomeMax {
+		nome = string(runes[:etiquetaNomeMax-1]) + "…"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="60mm" height="30mm" viewBox="0 0 60 30">`)
+	sb.WriteString(`<rect width="60" height="30" fill="#fff" stroke="#000" stroke-width="0.2"/>`)
+	fmt.Fprintf(&sb, `<g transform="scale(%.4f)" shape-rendering="crispEdges"><path fill="#000" d="%s"/></g>`, 30/float64(modules), code.PathData(4))
+	sb.WriteString(`<g font-family="sans-serif" fill="#000">`)
+	fmt.Fprintf(&sb, `<text x="31" y="7" font-size="2.6">%s</text>`, html.EscapeString(prefeitura))
+	fmt.Fprintf(&sb, `<text x="31" y="14" font-size="3" font-weight="bold">%s</text>`, html.EscapeString(nome))
+	fmt.Fprintf(&sb, `<text x="31" y="21" font-size="4" font-family="monospace">%s</text>`, html.EscapeString(a.Codigo))
+	sb.WriteString(`<text x="31" y="27" font-size="2.2">Escaneie para relatar um problema</text>`)
+	sb.WriteString(`</g></svg>`)
+	return sb.String(), nil
+}
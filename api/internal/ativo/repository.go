@@ -0,0 +1,250 @@
+package ativo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// ErrSecretaria indica secretaria que não pertence à prefeitura do ativo.
+var ErrSecretaria = errors.New("ativo: secretaria não pertence à prefeitura")
+
+const ativoColumns = `id, tenant_id, secretaria_id, tipo, codigo, nome, descricao, endereco, bairro,
+        ST_Y(localizacao::geometry), ST_X(localizacao::geometry), atributos, status, qr_token, created_at, updated_at`
+
+// Repository provê acesso ao cadastro de ativos.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// List lista os ativos do tenant segundo o filtro, por código.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, filter Filter) ([]Ativo, error) {
+	clauses := []string{"tenant_id = $1"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.Tipo != "" {
+		add("tipo = $?", filter.Tipo)
+	}
+	if filter.Status != "" {
+		add("status = $?", filter.Status)
+	}
+	if filter.SecretariaID != nil {
+		add("secretaria_id = $?", *filter.SecretariaID)
+	}
+	if filter.Bairro != "" {
+		add("lower(bairro) = lower($?)", filter.Bairro)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		add("(nome ILIKE $? OR codigo ILIKE $?)", "%"+q+"%")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+ativoColumns+`
+        FROM ativos
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY codigo
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ativos := make([]Ativo, 0)
+	for rows.Next() {
+		a, err := scanAtivo(rows)
+		if err != nil {
+			return nil, err
+		}
+		ativos = append(ativos, *a)
+	}
+	return ativos, rows.Err()
+}
+
+// Get busca o ativo do tenant.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID) (*Ativo, error) {
+	return scanAtivo(r.conn(ctx).QueryRow(ctx, `SELECT `+ativoColumns+` FROM ativos WHERE tenant_id = $1 AND id = $2`, tenantID, id))
+}
+
+// GetByQRToken busca o ativo do tenant pelo identificador da etiqueta.
+func (r *Repository) GetByQRToken(ctx context.Context, tenantID uuid.UUID, token string) (*Ativo, error) {
+	return scanAtivo(r.conn(ctx).QueryRow(ctx, `SELECT `+ativoColumns+` FROM ativos WHERE tenant_id = $1 AND qr_token = $2`, tenantID, token))
+}
+
+// Create cadastra o ativo; a entrada já deve estar normalizada.
+func (r *Repository) Create(ctx context.Context, tenantID uuid.UUID, in Input) (*Ativo, error) {
+	token, err := NewQRToken()
+	if err != nil {
+		return nil, err
+	}
+	atributos, err := json.Marshal(in.Atributos)
+	if err != nil {
+		return nil, err
+	}
+	row := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO ativos (tenant_id, secretaria_id, tipo, codigo, nome, descricao, endereco, bairro, localizacao,
+                            atributos, status, qr_token, created_by)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8,
+               CASE WHEN $9::float8 IS NULL THEN NULL ELSE ST_SetSRID(ST_MakePoint($10, $9), 4326)::geography END,
+               $11, $12, $13, $14
+        WHERE $2::uuid IS NULL OR EXISTS (SELECT 1 FROM secretarias WHERE id = $2 AND tenant_id = $1)
+        RETURNING `+ativoColumns,
+		tenantID, in.SecretariaID, in.Tipo, in.Codigo, in.Nome, in.Descricao, in.Endereco, in.Bairro,
+		in.Latitude, in.Longitude, atributos, in.Status, token, in.ActorID)
+	return writeError(scanAtivo(row))
+}
+
+// Update substitui os campos do ativo; mudanças de situação entram no histórico.
+func (r *Repository) Update(ctx context.Context, tenantID, id uuid.UUID, in Input) (*Ativo, error) {
+	atributos, err := json.Marshal(in.Atributos)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous string
+	if err := tx.QueryRow(ctx, `SELECT status FROM ativos WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, id).Scan(&previous); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	a, err := writeError(scanAtivo(tx.QueryRow(ctx, `
+        UPDATE ativos
+        SET secretaria_id = $3, tipo = $4, codigo = $5, nome = $6, descricao = $7, endereco = $8, bairro = $9,
+            localizacao = CASE WHEN $10::float8 IS NULL THEN NULL ELSE ST_SetSRID(ST_MakePoint($11, $10), 4326)::geography END,
+            atributos = $12, status = $13, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+          AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM secretarias WHERE id = $3 AND tenant_id = $1))
+        RETURNING `+ativoColumns,
+		tenantID, id, in.SecretariaID, in.Tipo, in.Codigo, in.Nome, in.Descricao, in.Endereco, in.Bairro,
+		in.Latitude, in.Longitude, atributos, in.Status)))
+	if err != nil {
+		return nil, err
+	}
+	if previous != a.Status {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO ativo_historico (ativo_id, tipo, descricao, ator_id) VALUES ($1, 'status', $2, $3)
+        `, a.ID, fmt.Sprintf("situação alterada de %s para %s", previous, a.Status), in.ActorID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AddHistorico registra uma manutenção ou nota; o protocolo citado precisa ser do mesmo tenant.
+func (r *Repository) AddHistorico(ctx context.Context, tenantID, ativoID uuid.UUID, in HistoricoInput) (*Historico, error) {
+	h := Historico{Tipo: in.Tipo, Descricao: in.Descricao, ProtocoloID: in.ProtocoloID, Custo: in.Custo, RealizadoEm: in.RealizadoEm, AtorID: in.AtorID}
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO ativo_historico (ativo_id, tipo, descricao, protocolo_id, custo, realizado_em, ator_id)
+        SELECT a.id, $3, $4, $5, $6, $7, $8
+        FROM ativos a
+        WHERE a.tenant_id = $1 AND a.id = $2
+          AND ($5::uuid IS NULL OR EXISTS (SELECT 1 FROM protocolos WHERE id = $5 AND tenant_id = $1))
+        RETURNING id, created_at
+    `, tenantID, ativoID, in.Tipo, in.Descricao, in.ProtocoloID, in.Custo, in.RealizadoEm, in.AtorID).Scan(&h.ID, &h.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Historico devolve o histórico de serviço do ativo, mais recente primeiro: registros da
+// secretaria, protocolos vinculados e ordens de serviço, com a situação atual de cada um. O custo
+// de uma ordem é a soma dos materiais com preço informado.
+func (r *Repository) Historico(ctx context.Context, ativoID uuid.UUID) ([]Historico, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT h.id, h.tipo, h.descricao, h.protocolo_id, p.numero, NULL::text, h.custo::float8, h.realizado_em, h.ator_id, h.created_at
+        FROM ativo_historico h
+        LEFT JOIN protocolos p ON p.id = h.protocolo_id
+        WHERE h.ativo_id = $1
+        UNION ALL
+        SELECT p.id, 'protocolo', c.nome, p.id, p.numero, p.status, NULL, NULL, NULL, p.created_at
+        FROM protocolos p
+        JOIN protocolo_categorias c ON c.id = p.categoria_id
+        WHERE p.ativo_id = $1
+        UNION ALL
+        SELECT o.id, 'ordem_servico', o.numero || ': ' || o.descricao, o.protocolo_id, p.numero, o.status,
+               (SELECT sum(m.quantidade * m.custo_unitario)::float8 FROM ordem_servico_materiais m WHERE m.ordem_id = o.id),
+               o.concluida_em::date, o.assinado_por, o.created_at
+        FROM ordens_servico o
+        JOIN protocolos p ON p.id = o.protocolo_id
+        WHERE o.ativo_id = $1
+        ORDER BY 10 DESC
+        LIMIT 200
+    `, ativoID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Historico, error) {
+		var h Historico
+		err := row.Scan(&h.ID, &h.Tipo, &h.Descricao, &h.ProtocoloID, &h.ProtocoloNumero, &h.Status, &h.Custo, &h.RealizadoEm, &h.AtorID, &h.CreatedAt)
+		return h, err
+	})
+}
+
+func scanAtivo(row pgx.Row) (*Ativo, error) {
+	var (
+		a         Ativo
+		atributos []byte
+	)
+	if err := row.Scan(&a.ID, &a.TenantID, &a.SecretariaID, &a.Tipo, &a.Codigo, &a.Nome, &a.Descricao, &a.Endereco, &a.Bairro,
+		&a.Latitude, &a.Longitude, &atributos, &a.Status, &a.QRToken, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(atributos, &a.Atributos); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// writeError traduz os erros da escrita. Create e Update só deixam de devolver a linha quando a
+// secretaria informada não é da prefeitura (Update confere a existência do ativo antes).
+func writeError(a *Ativo, err error) (*Ativo, error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return nil, ErrDuplicate
+	case errors.Is(err, ErrNotFound):
+		return nil, ErrSecretaria
+	}
+	return a, err
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// Anchorer publica periodicamente o hash do último registro da cadeia no armazenamento de objetos.
+// Quem altera o banco não consegue refazer a cadeia sem divergir das âncoras já publicadas; o bucket
+// deve ter versionamento ou retenção (object lock) para que elas também não sejam regravadas.
+type Anchorer struct {
+	repo     *Repository
+	uploader storage.Uploader
+	logger   zerolog.Logger
+}
+
+func NewAnchorer(repo *Repository, uploader storage.Uploader, logger zerolog.Logger) *Anchorer {
+	return &Anchorer{repo: repo, uploader: uploader, logger: logger}
+}
+
+// RunOnce publica uma âncora se a cadeia cresceu desde a última.
+func (a *Anchorer) RunOnce(ctx context.Context) error {
+	head, err := a.repo.Head(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	last, err := a.repo.LastAnchor(ctx)
+	if err != nil {
+		return err
+	}
+	if last != nil && last.Seq >= head.Seq {
+		return nil
+	}
+
+	anchor := Anchor{
+		Seq:        head.Seq,
+		Hash:       head.Hash,
+		EntryAt:    head.CreatedAt,
+		AnchoredAt: time.Now().UTC(),
+		ObjectKey:  fmt.Sprintf("audit/anchors/%020d.json", head.Seq),
+	}
+	if last != nil {
+		anchor.PrevSeq, anchor.PrevHash = &last.Seq, &last.Hash
+	}
+	body, err := json.MarshalIndent(anchor, "", "  ")
+	if err != nil {
+		return err
+	}
+	uploaded, err := a.uploader.Upload(ctx, storage.UploadInput{
+		Key:         anchor.ObjectKey,
+		Body:        body,
+		ContentType: "application/json",
+	})
+	if err != nil {
+		return fmt.Errorf("publicar âncora: %w", err)
+	}
+	if uploaded != nil {
+		anchor.ObjectURL = uploaded.URL
+	}
+	if err := a.repo.InsertAnchor(ctx, anchor); err != nil {
+		return err
+	}
+	a.logger.Info().Int64("seq", anchor.Seq).Str("key", anchor.ObjectKey).Msg("audit: âncora publicada")
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package audit mantém a trilha imutável das ações de escrita do painel SaaS: quem fez, o quê, em
+// qual entidade, com os valores antes/depois e a origem da requisição. O middleware registra toda
+// requisição de escrita; os handlers acrescentam a entidade e o diff quando os conhecem. Os registros
+// formam uma cadeia de hashes ancorada periodicamente fora do banco, o que torna a adulteração
+// detectável.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound indica registro inexistente na trilha.
+var ErrNotFound = errors.New("audit: registro não encontrado")
+
+// Change é o valor de um campo antes e depois da ação.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Entry é um registro da trilha. Seq, PrevHash e Hash encadeiam o registro ao anterior; registros
+// gravados antes do encadeamento ficam sem eles.
+type Entry struct {
+	ID         uuid.UUID         `json:"id"`
+	Seq        int64             `json:"seq,omitempty"`
+	PrevHash   string            `json:"prev_hash,omitempty"`
+	Hash       string            `json:"hash,omitempty"`
+	ActorID    *uuid.UUID        `json:"actor_id,omitempty"`
+	ActorName  *string           `json:"actor_name,omitempty"`
+	ActorRoles []string          `json:"actor_roles"`
+	Action     string            `json:"action"`
+	Entity     string            `json:"entity"`
+	EntityID   *string           `json:"entity_id,omitempty"`
+	TenantID   *uuid.UUID        `json:"tenant_id,omitempty"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Status     int               `json:"status"`
+	Before     map[string]any    `json:"before,omitempty"`
+	After      map[string]any    `json:"after,omitempty"`
+	Diff       map[string]Change `json:"diff,omitempty"`
+	IP         *string           `json:"ip,omitempty"`
+	UserAgent  *string           `json:"user_agent,omitempty"`
+	RequestID  *string           `json:"request_id,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Filter restringe a consulta à trilha.
+type Filter struct {
+	ActorID  *uuid.UUID
+	TenantID *uuid.UUID
+	Entity   string
+	EntityID string
+	Action   string
+	// Falhas inclui as requisições recusadas (status 4xx/5xx).
+	Falhas bool
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
+
+type pendingKey struct{}
+
+// pending acumula o que o handler informa durante a requisição.
+type pending struct {
+	action   string
+	entity   string
+	entityID string
+	before   any
+	after    any
+	changed  bool
+}
+
+func withPending(ctx context.Context) (context.Context, *pending) {
+	p := &pending{}
+	return context.WithValue(ctx, pendingKey{}, p), p
+}
+
+func pendingFrom(ctx context.Context) *pending {
+	p, _ := ctx.Value(pendingKey{}).(*pending)
+	return p
+}
+
+// Action troca o nome da ação registrada, por padrão o método e a rota ("PUT /saas/tenants/{id}").
+func Action(ctx context.Context, action string) {
+	if p := pendingFrom(ctx); p != nil {
+		p.action = action
+	}
+}
+
+// Entity informa a entidade afetada quando ela não está na rota, como no cadastro.
+func Entity(ctx context.Context, entity, id string) {
+	if p := pendingFrom(ctx); p != nil {
+		p.entity = entity
+		p.entityID = id
+	}
+}
+
+// Changes guarda os valores antes e depois da ação; nil indica criação ou exclusão.
+func Changes(ctx context.Context, before, after any) {
+	if p := pendingFrom(ctx); p != nil {
+		p.before = before
+		p.after = after
+		p.changed = true
+	}
+}
+
+// camposSensiveis são mascarados em before/after, pelo nome do campo.
+var camposSensiveis = []string{"password", "senha", "token", "secret", "segredo", "api_key", "apikey"}
+
+const mascara = "***"
+
+// Snapshot converte o valor para o mapa gravado, mascarando campos sensíveis.
+func Snapshot(value any) (map[string]any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		// Valores que não são objeto ficam sob uma chave só.
+		var scalar any
+		if err := json.Unmarshal(raw, &scalar); err != nil {
+			return nil, err
+		}
+		out = map[string]any{"value": scalar}
+	}
+	redact(out)
+	return out, nil
+}
+
+func redact(values map[string]any) {
+	for key, value := range values {
+		if sensivel(key) {
+			if value != nil && value != "" {
+				values[key] = mascara
+			}
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			redact(v)
+		case []any:
+			for _, item := range v {
+				if m, ok := item.(map[string]any); ok {
+					redact(m)
+				}
+			}
+		}
+	}
+}
+
+func sensivel(key string) bool {
+	key = strings.ToLower(key)
+	for _, campo := range camposSensiveis {
+		if strings.Contains(key, campo) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff lista os campos de primeiro nível que mudaram entre os dois retratos.
+func Diff(before, after map[string]any) map[string]Change {
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	diff := make(map[string]Change)
+	for k := range keys {
+		b, a := before[k], after[k]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		diff[k] = Change{Before: b, After: a}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// DefaultAction monta o nome padrão da ação a partir do método e do padrão da rota.
+func DefaultAction(method, pattern string) string {
+	return method + " " + limparRota(pattern)
+}
+
+// EntityFromRoute deduz a entidade do primeiro segmento da rota depois do prefixo /saas.
+func EntityFromRoute(pattern string) string {
+	rota := strings.TrimPrefix(limparRota(pattern), "/saas")
+	for _, segmento := range strings.Split(rota, "/") {
+		if segmento != "" && !strings.HasPrefix(segmento, "{") {
+			return segmento
+		}
+	}
+	return ""
+}
+
+func limparRota(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "/*")
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
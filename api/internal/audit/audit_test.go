@@ -0,0 +1,59 @@
+package audit
+
+import "testing"
+
+func TestSnapshotMascaraCamposSensiveis(t *testing.T) {
+	snap, err := Snapshot(map[string]any{
+		"name":      "Ana",
+		"api_token": "cf-123",
+		"settings":  map[string]any{"smtp_password": "x", "host": "smtp"},
+		"secret":    "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap["api_token"] != mascara || snap["name"] != "Ana" {
+		t.Fatalf("snapshot = %v", snap)
+	}
+	if nested := snap["settings"].(map[string]any); nested["smtp_password"] != mascara || nested["host"] != "smtp" {
+		t.Fatalf("settings = %v", nested)
+	}
+	if snap["secret"] != "" {
+		t.Fatalf("campo vazio não deveria ser mascarado: %v", snap["secret"])
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := map[string]any{"status": "ativo", "valor": 10.0, "notes": "a"}
+	after := map[string]any{"status": "suspenso", "valor": 10.0, "renewal_date": "2026-01-01"}
+	diff := Diff(before, after)
+	if len(diff) != 3 {
+		t.Fatalf("diff = %v", diff)
+	}
+	if diff["status"].Before != "ativo" || diff["status"].After != "suspenso" {
+		t.Fatalf("status = %+v", diff["status"])
+	}
+	if diff["notes"].After != nil || diff["renewal_date"].Before != nil {
+		t.Fatalf("campos removidos/incluídos = %+v %+v", diff["notes"], diff["renewal_date"])
+	}
+	if Diff(before, before) != nil {
+		t.Fatal("retratos iguais não deveriam gerar diff")
+	}
+}
+
+func TestRotas(t *testing.T) {
+	cases := []struct{ method, pattern, action, entity string }{
+		{"POST", "/saas/tenants", "POST /saas/tenants", "tenants"},
+		{"PUT", "/saas/tenants/{id}/contract/", "PUT /saas/tenants/{id}/contract", "tenants"},
+		{"PUT", "/saas/settings/cloudflare", "PUT /saas/settings/cloudflare", "settings"},
+		{"POST", "/saas/users/*", "POST /saas/users", "users"},
+	}
+	for _, c := range cases {
+		if got := DefaultAction(c.method, c.pattern); got != c.action {
+			t.Errorf("DefaultAction(%q) = %q, want %q", c.pattern, got, c.action)
+		}
+		if got := EntityFromRoute(c.pattern); got != c.entity {
+			t.Errorf("EntityFromRoute(%q) = %q, want %q", c.pattern, got, c.entity)
+		}
+	}
+}
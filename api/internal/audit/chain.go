@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GenesisHash é o hash anterior do primeiro registro da cadeia.
+var GenesisHash = strings.Repeat("0", 64)
+
+// chainRecord fixa os campos e a forma que entram no hash. Quem audita recalcula a cadeia a partir
+// da exportação: hash = hex(sha256(prev_hash + "\n" + JSON deste registro)), com created_at em UTC
+// e precisão de microssegundos, como o Postgres guarda.
+type chainRecord struct {
+	Seq        int64             `json:"seq"`
+	ID         uuid.UUID         `json:"id"`
+	ActorID    *uuid.UUID        `json:"actor_id"`
+	ActorRoles []string          `json:"actor_roles"`
+	Action     string            `json:"action"`
+	Entity     string            `json:"entity"`
+	EntityID   *string           `json:"entity_id"`
+	TenantID   *uuid.UUID        `json:"tenant_id"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Status     int               `json:"status"`
+	Before     map[string]any    `json:"before"`
+	After      map[string]any    `json:"after"`
+	Diff       map[string]Change `json:"diff"`
+	IP         *string           `json:"ip"`
+	UserAgent  *string           `json:"user_agent"`
+	RequestID  *string           `json:"request_id"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+// ChainHash calcula o hash do registro encadeado ao anterior.
+func ChainHash(prevHash string, e Entry) (string, error) {
+	rec := chainRecord{
+		Seq:        e.Seq,
+		ID:         e.ID,
+		ActorID:    e.ActorID,
+		ActorRoles: e.ActorRoles,
+		Action:     e.Action,
+		Entity:     e.Entity,
+		EntityID:   e.EntityID,
+		TenantID:   e.TenantID,
+		Method:     e.Method,
+		Path:       e.Path,
+		Status:     e.Status,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		CreatedAt:  chainTime(e.CreatedAt),
+	}
+	if rec.ActorRoles == nil {
+		rec.ActorRoles = []string{}
+	}
+	// Mapas vazios são gravados como NULL; os dois lados precisam concordar.
+	if len(e.Before) > 0 {
+		rec.Before = e.Before
+	}
+	if len(e.After) > 0 {
+		rec.After = e.After
+	}
+	if len(e.Diff) > 0 {
+		rec.Diff = e.Diff
+	}
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(prevHash+"\n"), raw...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func chainTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// ChainError aponta o primeiro registro em que a cadeia não confere.
+type ChainError struct {
+	Seq    int64  `json:"seq"`
+	Reason string `json:"reason"`
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit: cadeia inválida em seq %d: %s", e.Seq, e.Reason)
+}
+
+// Verifier confere a cadeia registro a registro, em ordem crescente de seq. Começando no meio da
+// cadeia, o prev_hash do primeiro registro é aceito como ponto de partida; as âncoras confirmam
+// que ele também não foi alterado.
+type Verifier struct {
+	Checked  int
+	FirstSeq int64
+	LastSeq  int64
+	LastHash string
+	anchors  map[int64]string
+	Anchored int
+}
+
+// NewVerifier cria o verificador; anchors mapeia seq para o hash publicado na âncora.
+func NewVerifier(anchors map[int64]string) *Verifier {
+	return &Verifier{anchors: anchors}
+}
+
+// Add confere o próximo registro.
+func (v *Verifier) Add(e Entry) error {
+	if e.Seq <= 0 || e.Hash == "" {
+		return &ChainError{Seq: e.Seq, Reason: "registro fora da cadeia"}
+	}
+	switch {
+	case v.Checked == 0 && e.Seq == 1 && e.PrevHash != GenesisHash:
+		return &ChainError{Seq: e.Seq, Reason: "primeiro registro não parte do hash inicial"}
+	case v.Checked > 0 && e.Seq != v.LastSeq+1:
+		return &ChainError{Seq: e.Seq, Reason: fmt.Sprintf("sequência salta de %d para %d", v.LastSeq, e.Seq)}
+	case v.Checked > 0 && e.PrevHash != v.LastHash:
+		return &ChainError{Seq: e.Seq, Reason: "prev_hash não confere com o registro anterior"}
+	}
+	hash, err := ChainHash(e.PrevHash, e)
+	if err != nil {
+		return err
+	}
+	if hash != e.Hash {
+		return &ChainError{Seq: e.Seq, Reason: "conteúdo alterado: hash recalculado difere do gravado"}
+	}
+	if anchored, ok := v.anchors[e.Seq]; ok {
+		if anchored != e.Hash {
+			return &ChainError{Seq: e.Seq, Reason: "hash difere da âncora publicada"}
+		}
+		v.Anchored++
+	}
+	if v.Checked == 0 {
+		v.FirstSeq = e.Seq
+	}
+	v.Checked++
+	v.LastSeq = e.Seq
+	v.LastHash = e.Hash
+	return nil
+}
+
+// Anchor é o resumo da cadeia publicado periodicamente no armazenamento de objetos, fora do banco.
+type Anchor struct {
+	Seq        int64     `json:"seq"`
+	Hash       string    `json:"hash"`
+	EntryAt    time.Time `json:"entry_created_at"`
+	AnchoredAt time.Time `json:"anchored_at"`
+	ObjectKey  string    `json:"object_key"`
+	ObjectURL  string    `json:"object_url,omitempty"`
+	PrevSeq    *int64    `json:"prev_seq,omitempty"`
+	PrevHash   *string   `json:"prev_anchor_hash,omitempty"`
+}
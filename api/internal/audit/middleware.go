@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// insertTimeout limita a gravação feita depois da resposta, que não depende mais do cliente.
+const insertTimeout = 5 * time.Second
+
+// Recorder grava a trilha das requisições de escrita que passam pelo middleware.
+type Recorder struct {
+	repo   *Repository
+	logger zerolog.Logger
+}
+
+func NewRecorder(repo *Repository, logger zerolog.Logger) *Recorder {
+	return &Recorder{repo: repo, logger: logger}
+}
+
+// Middleware registra toda requisição que não seja leitura, inclusive as recusadas, com o status da
+// resposta. Deve vir depois da autenticação, que identifica o autor.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, p := withPending(r.Context())
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		entry := rec.montar(r, p, ww.Status())
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), insertTimeout)
+		defer cancel()
+		if err := rec.repo.Insert(ctx, entry); err != nil {
+			rec.logger.Error().Err(err).Str("action", entry.Action).Str("path", entry.Path).Msg("audit: falha ao gravar registro")
+		}
+	})
+}
+
+func (rec *Recorder) montar(r *http.Request, p *pending, status int) Entry {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	pattern := r.URL.Path
+	var routeID string
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if rp := rctx.RoutePattern(); rp != "" {
+			pattern = rp
+		}
+		routeID = rctx.URLParam("id")
+	}
+
+	entry := Entry{
+		ActorRoles: httpmiddleware.GetRoles(r.Context()),
+		Action:     p.action,
+		Entity:     p.entity,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     status,
+	}
+	if id, err := uuid.Parse(httpmiddleware.GetSubject(r.Context())); err == nil {
+		entry.ActorID = &id
+	}
+	if entry.Action == "" {
+		entry.Action = DefaultAction(r.Method, pattern)
+	}
+	entityID := p.entityID
+	if entry.Entity == "" {
+		entry.Entity = EntityFromRoute(pattern)
+		entityID = routeID
+	}
+	if entityID != "" {
+		entry.EntityID = &entityID
+	}
+	// As rotas /tenants/{id}/... e o cadastro de tenant identificam a prefeitura afetada.
+	if entry.Entity == "tenants" && entry.EntityID != nil {
+		if id, err := uuid.Parse(*entry.EntityID); err == nil {
+			entry.TenantID = &id
+		}
+	}
+	if p.changed {
+		before, err := Snapshot(p.before)
+		if err != nil {
+			rec.logger.Warn().Err(err).Str("action", entry.Action).Msg("audit: estado anterior não serializável")
+		}
+		after, err := Snapshot(p.after)
+		if err != nil {
+			rec.logger.Warn().Err(err).Str("action", entry.Action).Msg("audit: estado posterior não serializável")
+		}
+		entry.Before, entry.After = before, after
+		entry.Diff = Diff(before, after)
+	}
+	if ip := strings.TrimSpace(httpmiddleware.ClientIP(r)); ip != "" {
+		entry.IP = &ip
+	}
+	if ua := r.UserAgent(); ua != "" {
+		entry.UserAgent = &ua
+	}
+	if reqID := chimiddleware.GetReqID(r.Context()); reqID != "" {
+		entry.RequestID = &reqID
+	}
+	return entry
+}
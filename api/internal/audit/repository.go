@@ -0,0 +1,284 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository grava e consulta a trilha; a tabela só aceita inserção.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+const entryColumns = `
+    a.id, COALESCE(a.seq, 0), COALESCE(a.prev_hash, ''), COALESCE(a.hash, ''), a.actor_id, su.name, a.actor_roles,
+    a.action, a.entity, a.entity_id, a.tenant_id, a.method, a.path, a.status, a.before, a.after, a.diff, a.ip,
+    a.user_agent, a.request_id, a.created_at`
+
+// chainLockKey serializa as inserções para que cada registro aponte para o último da cadeia.
+const chainLockKey = 7620001
+
+// Insert grava o registro.
+func (r *Repository) Insert(ctx context.Context, e Entry) error {
+	before, err := jsonOrNil(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := jsonOrNil(e.After)
+	if err != nil {
+		return err
+	}
+	diff, err := jsonOrNil(e.Diff)
+	if err != nil {
+		return err
+	}
+	if e.ActorRoles == nil {
+		e.ActorRoles = []string{}
+	}
+	e.ID = uuid.New()
+	e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, chainLockKey); err != nil {
+			return err
+		}
+		e.PrevHash = GenesisHash
+		err := tx.QueryRow(ctx, `
+            SELECT seq, hash FROM saas_audit_log WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1`).Scan(&e.Seq, &e.PrevHash)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		e.Seq++
+		if e.Hash, err = ChainHash(e.PrevHash, e); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+            INSERT INTO saas_audit_log (id, seq, prev_hash, hash, actor_id, actor_roles, action, entity, entity_id, tenant_id,
+                                        method, path, status, before, after, diff, ip, user_agent, request_id, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+        `, e.ID, e.Seq, e.PrevHash, e.Hash, e.ActorID, e.ActorRoles, e.Action, e.Entity, e.EntityID, e.TenantID,
+			e.Method, e.Path, e.Status, before, after, diff, e.IP, e.UserAgent, e.RequestID, e.CreatedAt)
+		return err
+	})
+}
+
+// chainBatch é o tamanho das páginas lidas ao percorrer a cadeia.
+const chainBatch = 1000
+
+// Chain percorre os registros encadeados com seq no intervalo, em ordem crescente; toSeq zero vai
+// até o fim.
+func (r *Repository) Chain(ctx context.Context, fromSeq, toSeq int64, fn func(Entry) error) error {
+	next := max(fromSeq, 1)
+	for {
+		rows, err := r.pool.Query(ctx, `
+            SELECT `+entryColumns+`
+            FROM saas_audit_log a
+            LEFT JOIN saas_users su ON su.id = a.actor_id
+            WHERE a.seq >= $1 AND ($2 = 0 OR a.seq <= $2)
+            ORDER BY a.seq
+            LIMIT $3`, next, toSeq, chainBatch)
+		if err != nil {
+			return err
+		}
+		entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Entry, error) {
+			return scanEntry(row)
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if len(entries) < chainBatch {
+			return nil
+		}
+		next = entries[len(entries)-1].Seq + 1
+	}
+}
+
+// Head devolve o último registro da cadeia, ou ErrNotFound se ela ainda está vazia.
+func (r *Repository) Head(ctx context.Context) (*Entry, error) {
+	e, err := scanEntry(r.pool.QueryRow(ctx, `
+        SELECT `+entryColumns+`
+        FROM saas_audit_log a
+        LEFT JOIN saas_users su ON su.id = a.actor_id
+        WHERE a.seq IS NOT NULL
+        ORDER BY a.seq DESC
+        LIMIT 1`))
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+const anchorColumns = `seq, hash, entry_created_at, anchored_at, object_key, object_url, prev_seq, prev_hash`
+
+// LastAnchor devolve a âncora mais recente, ou nil se nenhuma foi publicada.
+func (r *Repository) LastAnchor(ctx context.Context) (*Anchor, error) {
+	a, err := scanAnchor(r.pool.QueryRow(ctx, `SELECT `+anchorColumns+` FROM saas_audit_anchors ORDER BY seq DESC LIMIT 1`))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return a, err
+}
+
+// InsertAnchor registra a âncora já publicada no armazenamento de objetos.
+func (r *Repository) InsertAnchor(ctx context.Context, a Anchor) error {
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO saas_audit_anchors (`+anchorColumns+`)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)`,
+		a.Seq, a.Hash, a.EntryAt, a.AnchoredAt, a.ObjectKey, a.ObjectURL, a.PrevSeq, a.PrevHash)
+	return err
+}
+
+// ListAnchors devolve as âncoras com seq no intervalo, em ordem crescente; toSeq zero vai até o fim.
+func (r *Repository) ListAnchors(ctx context.Context, fromSeq, toSeq int64) ([]Anchor, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+anchorColumns+`
+        FROM saas_audit_anchors
+        WHERE seq >= $1 AND ($2 = 0 OR seq <= $2)
+        ORDER BY seq`, fromSeq, toSeq)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Anchor, error) {
+		a, err := scanAnchor(row)
+		if err != nil {
+			return Anchor{}, err
+		}
+		return *a, nil
+	})
+}
+
+func scanAnchor(row pgx.Row) (*Anchor, error) {
+	var (
+		a   Anchor
+		url *string
+	)
+	if err := row.Scan(&a.Seq, &a.Hash, &a.EntryAt, &a.AnchoredAt, &a.ObjectKey, &url, &a.PrevSeq, &a.PrevHash); err != nil {
+		return nil, err
+	}
+	if url != nil {
+		a.ObjectURL = *url
+	}
+	return &a, nil
+}
+
+// List devolve os registros mais recentes primeiro.
+func (r *Repository) List(ctx context.Context, f Filter) ([]Entry, error) {
+	var clauses []string
+	var args []any
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if f.ActorID != nil {
+		add("a.actor_id = $?", *f.ActorID)
+	}
+	if f.TenantID != nil {
+		add("a.tenant_id = $?", *f.TenantID)
+	}
+	if f.Entity != "" {
+		add("a.entity = $?", f.Entity)
+	}
+	if f.EntityID != "" {
+		add("a.entity_id = $?", f.EntityID)
+	}
+	if f.Action != "" {
+		add("a.action ILIKE $?", "%"+f.Action+"%")
+	}
+	if !f.Falhas {
+		clauses = append(clauses, "a.status < 400")
+	}
+	if f.From != nil {
+		add("a.created_at >= $?", *f.From)
+	}
+	if f.To != nil {
+		add("a.created_at < $?", *f.To)
+	}
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	where := ""
+	if len(clauses) > 0 {
+		where = "WHERE " + strings.Join(clauses, " AND ")
+	}
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+entryColumns+`
+        FROM saas_audit_log a
+        LEFT JOIN saas_users su ON su.id = a.actor_id
+        `+where+`
+        ORDER BY a.created_at DESC, a.id
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Entry, error) {
+		return scanEntry(row)
+	})
+}
+
+// Get devolve um registro.
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (*Entry, error) {
+	e, err := scanEntry(r.pool.QueryRow(ctx, `
+        SELECT `+entryColumns+`
+        FROM saas_audit_log a
+        LEFT JOIN saas_users su ON su.id = a.actor_id
+        WHERE a.id = $1
+    `, id))
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func scanEntry(row pgx.Row) (Entry, error) {
+	var (
+		e                   Entry
+		before, after, diff []byte
+	)
+	if err := row.Scan(&e.ID, &e.Seq, &e.PrevHash, &e.Hash, &e.ActorID, &e.ActorName, &e.ActorRoles,
+		&e.Action, &e.Entity, &e.EntityID, &e.TenantID, &e.Method, &e.Path, &e.Status, &before, &after, &diff, &e.IP,
+		&e.UserAgent, &e.RequestID, &e.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Entry{}, ErrNotFound
+		}
+		return Entry{}, err
+	}
+	for _, campo := range []struct {
+		raw []byte
+		dst any
+	}{{before, &e.Before}, {after, &e.After}, {diff, &e.Diff}} {
+		if len(campo.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(campo.raw, campo.dst); err != nil {
+			return Entry{}, err
+		}
+	}
+	return e, nil
+}
+
+func jsonOrNil[T any](value map[string]T) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
@@ -8,9 +8,15 @@ import (
 	"github.com/google/uuid"
 )
 
+// ScopeMFACadastro restringe o token às rotas de cadastro da biometria: é o acesso de quem entrou
+// por senha numa prefeitura que exige MFA e ainda não tem passkey.
+const ScopeMFACadastro = "mfa_cadastro"
+
 // Claims representa as informações presentes em um JWT de acesso.
 type Claims struct {
 	Roles []string `json:"roles"`
+	// Scope vazio é acesso completo; qualquer outro valor limita as rotas aceitas.
+	Scope string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -27,11 +33,17 @@ func NewJWTManager(secret string, accessTTL time.Duration) *JWTManager {
 
 // GenerateAccessToken cria um JWT HS256 com claims padrão.
 func (m *JWTManager) GenerateAccessToken(subject, audience string, roles []string) (string, string, error) {
+	return m.GenerateScopedAccessToken(subject, audience, roles, "")
+}
+
+// GenerateScopedAccessToken cria um JWT cujo uso o middleware limita conforme scope.
+func (m *JWTManager) GenerateScopedAccessToken(subject, audience string, roles []string, scope string) (string, string, error) {
 	now := time.Now().UTC()
 	jti := uuid.NewString()
 
 	claims := Claims{
 		Roles: roles,
+		Scope: scope,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   subject,
 			Audience:  jwt.ClaimStrings{audience},
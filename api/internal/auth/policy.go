@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// MinPasswordLength é o mínimo aceito mesmo sem política da prefeitura.
+	MinPasswordLength = 8
+	// MaxSessionHours limita a sessão configurável da equipe a 30 dias.
+	MaxSessionHours = 720
+)
+
+// Policy reúne as exigências de autenticação que a prefeitura impõe à sua equipe, guardadas em
+// settings.auth do tenant e aplicadas à audiência backoffice.
+type Policy struct {
+	// MFAObrigatorio recusa o login só com senha: a equipe entra por biometria (passkey com
+	// verificação do usuário), que conta como segundo fator.
+	MFAObrigatorio bool `json:"mfa_obrigatorio"`
+	// SSOObrigatorio recusa senha e biometria locais e manda o usuário ao provedor da prefeitura.
+	SSOObrigatorio bool   `json:"sso_obrigatorio"`
+	SSOURL         string `json:"sso_url,omitempty"`
+	// SessaoHoras substitui a validade padrão do refresh token; zero mantém o padrão.
+	SessaoHoras     int  `json:"sessao_horas,omitempty"`
+	SenhaMinTamanho int  `json:"senha_min_tamanho,omitempty"`
+	SenhaComplexa   bool `json:"senha_complexa"`
+}
+
+// ParsePolicy lê settings.auth; JSON vazio ou nulo vira política sem exigências.
+func ParsePolicy(raw []byte) (Policy, error) {
+	var p Policy
+	if len(raw) == 0 || string(raw) == "null" {
+		return p, nil
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// Normalize valida a política definida pelo administrador da prefeitura. O SSO obrigatório é
+// recusado enquanto a API não tiver o retorno do provedor: sem ele toda a equipe, inclusive o
+// administrador que ligou a opção, ficaria sem login.
+func (p *Policy) Normalize() error {
+	p.SSOURL = strings.TrimSpace(p.SSOURL)
+	if p.SSOURL != "" {
+		u, err := url.Parse(p.SSOURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("sso_url deve ser uma URL https do provedor de identidade")
+		}
+	}
+	if p.SSOObrigatorio {
+		return errors.New("sso_obrigatorio ainda não é suportado: o login pelo provedor de identidade não está disponível")
+	}
+	if p.SessaoHoras < 0 || p.SessaoHoras > MaxSessionHours {
+		return fmt.Errorf("sessao_horas deve estar entre 1 e %d", MaxSessionHours)
+	}
+	if p.SenhaMinTamanho != 0 && (p.SenhaMinTamanho < MinPasswordLength || p.SenhaMinTamanho > 128) {
+		return fmt.Errorf("senha_min_tamanho deve estar entre %d e 128", MinPasswordLength)
+	}
+	return nil
+}
+
+// Merge combina as políticas das prefeituras em que o usuário atua, ficando sempre com a exigência
+// mais forte e a sessão mais curta.
+func (p Policy) Merge(other Policy) Policy {
+	merged := p
+	merged.MFAObrigatorio = p.MFAObrigatorio || other.MFAObrigatorio
+	merged.SSOObrigatorio = p.SSOObrigatorio || other.SSOObrigatorio
+	if merged.SSOURL == "" || (!p.SSOObrigatorio && other.SSOObrigatorio) {
+		merged.SSOURL = other.SSOURL
+	}
+	if other.SessaoHoras > 0 && (p.SessaoHoras == 0 || other.SessaoHoras < p.SessaoHoras) {
+		merged.SessaoHoras = other.SessaoHoras
+	}
+	if other.SenhaMinTamanho > merged.SenhaMinTamanho {
+		merged.SenhaMinTamanho = other.SenhaMinTamanho
+	}
+	merged.SenhaComplexa = p.SenhaComplexa || other.SenhaComplexa
+	return merged
+}
+
+// SessionTTL devolve a validade da sessão, ou fallback quando a prefeitura não define.
+func (p Policy) SessionTTL(fallback time.Duration) time.Duration {
+	if p.SessaoHoras > 0 {
+		return time.Duration(p.SessaoHoras) * time.Hour
+	}
+	return fallback
+}
+
+// ValidatePassword confere tamanho mínimo e, se exigido, letras maiúsculas, minúsculas, dígitos e
+// símbolos.
+func (p Policy) ValidatePassword(senha string) error {
+	minimo := max(p.SenhaMinTamanho, MinPasswordLength)
+	if len([]rune(senha)) < minimo {
+		return fmt.Errorf("senha deve ter ao menos %d caracteres", minimo)
+	}
+	if !p.SenhaComplexa {
+		return nil
+	}
+	var upper, lower, digit, symbol bool
+	for _, c := range senha {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			symbol = true
+		}
+	}
+	if !upper || !lower || !digit || !symbol {
+		return errors.New("senha deve combinar letras maiúsculas, minúsculas, números e símbolos")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyMergeKeepsStrictest(t *testing.T) {
+	a := Policy{SessaoHoras: 12, SenhaMinTamanho: 10}
+	b := Policy{MFAObrigatorio: true, SSOObrigatorio: true, SSOURL: "https://idp.exemplo.gov.br", SessaoHoras: 8, SenhaComplexa: true}
+
+	got := a.Merge(b)
+	if !got.MFAObrigatorio || !got.SSOObrigatorio || !got.SenhaComplexa {
+		t.Fatalf("exigências perdidas: %+v", got)
+	}
+	if got.SessaoHoras != 8 || got.SenhaMinTamanho != 10 || got.SSOURL != "https://idp.exemplo.gov.br" {
+		t.Fatalf("merge inesperado: %+v", got)
+	}
+	if ttl := (Policy{}).Merge(a).SessionTTL(time.Hour); ttl != 12*time.Hour {
+		t.Fatalf("ttl = %s", ttl)
+	}
+	if ttl := (Policy{}).SessionTTL(time.Hour); ttl != time.Hour {
+		t.Fatalf("ttl padrão = %s", ttl)
+	}
+}
+
+func TestPolicyValidatePassword(t *testing.T) {
+	p := Policy{SenhaMinTamanho: 10, SenhaComplexa: true}
+	for senha, ok := range map[string]bool{
+		"Curta1!":         false,
+		"semmaiusculas1!": false,
+		"SemSimbolo123":   false,
+		"Completa#2026":   true,
+	} {
+		if err := p.ValidatePassword(senha); (err == nil) != ok {
+			t.Errorf("ValidatePassword(%q) = %v", senha, err)
+		}
+	}
+	if err := (Policy{}).ValidatePassword("simples12"); err != nil {
+		t.Errorf("política vazia deveria aceitar: %v", err)
+	}
+}
+
+func TestPolicyNormalize(t *testing.T) {
+	p := Policy{SSOURL: "http://idp"}
+	if err := p.Normalize(); err == nil {
+		t.Fatal("sso_url sem https deveria falhar")
+	}
+	p = Policy{SSOObrigatorio: true, SSOURL: "https://idp.exemplo.gov.br"}
+	if err := p.Normalize(); err == nil {
+		t.Fatal("sso_obrigatorio sem login pelo provedor deveria falhar")
+	}
+	p = Policy{SessaoHoras: MaxSessionHours + 1}
+	if err := p.Normalize(); err == nil {
+		t.Fatal("sessão acima do limite deveria falhar")
+	}
+	if p, err := ParsePolicy(nil); err != nil || p != (Policy{}) {
+		t.Fatalf("ParsePolicy(nil) = %+v, %v", p, err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package authz define as permissões granulares do backoffice e os papéis que cada prefeitura monta
+// com elas, além dos papéis fixos (secretário, prefeito, administração técnica), que concedem tudo.
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica papel inexistente ou de outro tenant.
+	ErrNotFound = errors.New("authz: papel não encontrado")
+	// ErrCodigoEmUso indica código já usado por outro papel da prefeitura.
+	ErrCodigoEmUso = errors.New("authz: código de papel já existe")
+	// ErrMembroInvalido indica usuário que não pertence à equipe da prefeitura.
+	ErrMembroInvalido = errors.New("authz: usuário não pertence à prefeitura")
+)
+
+// Permissões do backoffice; cada rota da secretaria exige ao menos uma delas.
+const (
+	PermPresencaVer           = "presenca.ver"
+	PermCidadaosUnificar      = "cidadaos.unificar"
+	PermProtocolosVer         = "protocolos.ver"
+	PermProtocolosResponder   = "protocolos.responder"
+	PermProtocolosConfigurar  = "protocolos.configurar"
+	PermAtivosGerenciar       = "ativos.gerenciar"
+	PermEquipesGerenciar      = "equipes.gerenciar"
+	PermOrdensGerenciar       = "ordens.gerenciar"
+	PermEstoqueGerenciar      = "estoque.gerenciar"
+	PermSaudeCampanhas        = "saude.campanhas"
+	PermDocumentosEmitir      = "documentos.emitir"
+	PermSenhasAtender         = "senhas.atender"
+	PermEventosGerenciar      = "eventos.gerenciar"
+	PermAssistenciaAtender    = "assistencia.atender"
+	PermAssistenciaConfigurar = "assistencia.configurar"
+)
+
+// RoleMarker é o papel gravado no token de quem tem algum papel personalizado ou delegação vigente;
+// as permissões em si são consultadas a cada requisição, para que mudanças valham sem novo login.
+const RoleMarker = "EQUIPE"
+
+// Permission descreve uma permissão do catálogo.
+type Permission struct {
+	Codigo    string `json:"codigo"`
+	Modulo    string `json:"modulo"`
+	Descricao string `json:"descricao"`
+}
+
+var catalog = []Permission{
+	{PermPresencaVer, "escolas", "Ver a presença ao vivo dos alunos"},
+	{PermCidadaosUnificar, "cidadaos", "Unificar cadastros duplicados de cidadãos"},
+	{PermProtocolosVer, "protocolos", "Ver protocolos e o mapa de ocorrências"},
+	{PermProtocolosResponder, "protocolos", "Atribuir, transferir e mudar o status de protocolos"},
+	{PermProtocolosConfigurar, "protocolos", "Configurar categorias, filas e regras de SLA"},
+	{PermAtivosGerenciar, "ativos", "Cadastrar e manter o patrimônio"},
+	{PermEquipesGerenciar, "ordens", "Montar as equipes de campo"},
+	{PermOrdensGerenciar, "ordens", "Agendar e executar ordens de serviço"},
+	{PermEstoqueGerenciar, "estoque", "Cadastrar itens e movimentar o estoque"},
+	{PermSaudeCampanhas, "saude", "Gerir campanhas de vacinação e registrar doses"},
+	{PermDocumentosEmitir, "documentos", "Emitir e revogar documentos oficiais"},
+	{PermSenhasAtender, "senhas", "Chamar senhas e configurar as filas de atendimento"},
+	{PermEventosGerenciar, "eventos", "Publicar eventos e fazer o check-in"},
+	{PermAssistenciaAtender, "assistencia", "Atender famílias da assistência social"},
+	{PermAssistenciaConfigurar, "assistencia", "Credenciar profissionais e ver a auditoria da assistência"},
+}
+
+// Catalog devolve o catálogo de permissões na ordem de exibição.
+func Catalog() []Permission {
+	return append([]Permission(nil), catalog...)
+}
+
+// Valid informa se a permissão existe no catálogo.
+func Valid(codigo string) bool {
+	for _, p := range catalog {
+		if p.Codigo == codigo {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAuditor é o papel de secretaria de auditores externos e fiscais do TCE: enxerga tudo o que os
+// papéis fixos de gestão enxergam, mas o token é recusado em qualquer escrita (ver middleware.ReadOnly).
+const RoleAuditor = "AUDITOR"
+
+// papeisFixos são os papéis de secretaria que concedem todas as permissões; ATENDENTE não concede
+// nenhuma e depende de um papel personalizado para entrar no backoffice.
+var papeisFixos = map[string]bool{"SECRETARIO": true, "PREFEITO": true, "ADMIN_TEC": true, RoleAuditor: true}
+
+// FullAccess informa se o papel fixo concede todas as permissões.
+func FullAccess(papel string) bool {
+	return papeisFixos[strings.ToUpper(strings.TrimSpace(papel))]
+}
+
+// Role é um papel personalizado da prefeitura.
+type Role struct {
+	ID         uuid.UUID   `json:"id"`
+	TenantID   uuid.UUID   `json:"tenant_id"`
+	Codigo     string      `json:"codigo"`
+	Nome       string      `json:"nome"`
+	Descricao  *string     `json:"descricao,omitempty"`
+	Permissoes []string    `json:"permissoes"`
+	Membros    []uuid.UUID `json:"membros"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// RoleInput cria ou altera um papel.
+type RoleInput struct {
+	Codigo     string
+	Nome       string
+	Descricao  *string
+	Permissoes []string
+}
+
+var codigoPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,39}$`)
+
+// Normalize valida o papel; sem código, ele é derivado do nome.
+func (in *RoleInput) Normalize() error {
+	in.Nome = strings.TrimSpace(in.Nome)
+	in.Codigo = strings.ToLower(strings.TrimSpace(in.Codigo))
+	if in.Codigo == "" {
+		in.Codigo = Slug(in.Nome)
+	}
+	if in.Descricao != nil {
+		desc := strings.TrimSpace(*in.Descricao)
+		if desc == "" {
+			in.Descricao = nil
+		} else {
+			in.Descricao = &desc
+		}
+	}
+	switch {
+	case in.Nome == "" || len(in.Nome) > 80:
+		return errors.New("nome obrigatório, com até 80 caracteres")
+	case !codigoPattern.MatchString(in.Codigo):
+		return errors.New("código deve ter de 2 a 40 letras minúsculas, números, - ou _")
+	}
+
+	seen := map[string]bool{}
+	permissoes := make([]string, 0, len(in.Permissoes))
+	for _, p := range in.Permissoes {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || seen[p] {
+			continue
+		}
+		if !Valid(p) {
+			return fmt.Errorf("permissão desconhecida: %s", p)
+		}
+		seen[p] = true
+		permissoes = append(permissoes, p)
+	}
+	if len(permissoes) == 0 {
+		return errors.New("informe ao menos uma permissão")
+	}
+	sort.Strings(permissoes)
+	in.Permissoes = permissoes
+	return nil
+}
+
+// Slug deriva o código do papel a partir do nome, sem acentos.
+func Slug(nome string) string {
+	replacer := strings.NewReplacer(
+		"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+	)
+	var b strings.Builder
+	dash := false
+	for _, r := range replacer.Replace(strings.ToLower(strings.TrimSpace(nome))) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimSuffix(slug[:40], "-")
+	}
+	return slug
+}
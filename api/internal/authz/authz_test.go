@@ -0,0 +1,72 @@
+package authz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRoleInputNormalize(t *testing.T) {
+	in := RoleInput{Nome: " Atendimento Protocolos ", Permissoes: []string{" Protocolos.Ver", PermProtocolosResponder, "protocolos.ver"}}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if in.Codigo != "atendimento-protocolos" {
+		t.Fatalf("código derivado = %q", in.Codigo)
+	}
+	if len(in.Permissoes) != 2 || in.Permissoes[0] != PermProtocolosResponder || in.Permissoes[1] != PermProtocolosVer {
+		t.Fatalf("permissões = %v", in.Permissoes)
+	}
+
+	bad := RoleInput{Nome: "Notas", Permissoes: []string{"notas.apagar"}}
+	if err := bad.Normalize(); err == nil {
+		t.Fatal("esperava erro para permissão fora do catálogo")
+	}
+	empty := RoleInput{Nome: "Vazio"}
+	if err := empty.Normalize(); err == nil {
+		t.Fatal("esperava erro para papel sem permissões")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	cases := map[string]string{
+		"Saúde — Vacinação":    "saude-vacinacao",
+		"  Ordens de Serviço ": "ordens-de-servico",
+		"Estoque/Almoxarifado": "estoque-almoxarifado",
+	}
+	for nome, want := range cases {
+		if got := Slug(nome); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", nome, got, want)
+		}
+	}
+}
+
+func TestDelegacaoInputNormalize(t *testing.T) {
+	hoje := time.Date(2026, 7, 10, 15, 0, 0, 0, time.UTC)
+	in := DelegacaoInput{
+		DelegadoID: uuid.New(),
+		Permissoes: []string{PermProtocolosResponder},
+		Inicio:     time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC),
+		Fim:        time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if err := in.Normalize(hoje); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	d := Delegacao{Inicio: in.Inicio, Fim: in.Fim}
+	if !d.Ativa(time.Date(2026, 7, 31, 23, 0, 0, 0, time.UTC)) || d.Ativa(time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("o último dia deve estar incluído no período")
+	}
+
+	vencida := in
+	vencida.Fim = time.Date(2026, 7, 9, 0, 0, 0, 0, time.UTC)
+	vencida.Inicio = vencida.Fim
+	if err := vencida.Normalize(hoje); err == nil {
+		t.Fatal("esperava erro para período encerrado")
+	}
+	longa := in
+	longa.Fim = in.Inicio.AddDate(0, 0, MaxDelegacaoDias)
+	if err := longa.Normalize(hoje); err == nil {
+		t.Fatal("esperava erro para delegação acima do limite")
+	}
+}
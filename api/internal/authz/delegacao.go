@@ -0,0 +1,134 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrDelegacaoNotFound indica delegação inexistente, de outro tenant ou de outro usuário.
+	ErrDelegacaoNotFound = errors.New("authz: delegação não encontrada")
+	// ErrDelegacaoEncerrada indica revogação de delegação já revogada ou vencida.
+	ErrDelegacaoEncerrada = errors.New("authz: delegação já encerrada")
+	// ErrDeleganteInvalido indica que quem delega não é secretário nem prefeito na prefeitura.
+	ErrDeleganteInvalido = errors.New("authz: só secretário ou prefeito pode delegar")
+)
+
+// MaxDelegacaoDias limita a duração de uma delegação; períodos maiores pedem papel personalizado.
+const MaxDelegacaoDias = 90
+
+// Delegacao transfere temporariamente permissões de um secretário para outro usuário da prefeitura.
+type Delegacao struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	DeleganteID uuid.UUID  `json:"delegante_id"`
+	Delegante   string     `json:"delegante"`
+	DelegadoID  uuid.UUID  `json:"delegado_id"`
+	Delegado    string     `json:"delegado"`
+	Permissoes  []string   `json:"permissoes"`
+	Inicio      time.Time  `json:"inicio"`
+	Fim         time.Time  `json:"fim"`
+	Motivo      *string    `json:"motivo,omitempty"`
+	RevogadaEm  *time.Time `json:"revogada_em,omitempty"`
+	Acoes       int        `json:"acoes"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Ativa informa se a delegação vale no dia informado.
+func (d Delegacao) Ativa(dia time.Time) bool {
+	dia = truncDay(dia)
+	return d.RevogadaEm == nil && !dia.Before(truncDay(d.Inicio)) && !dia.After(truncDay(d.Fim))
+}
+
+// DelegacaoInput cria uma delegação.
+type DelegacaoInput struct {
+	DelegadoID uuid.UUID
+	Permissoes []string
+	Inicio     time.Time
+	Fim        time.Time
+	Motivo     *string
+}
+
+// Normalize valida a delegação. O período é de dias inteiros, inclusive nas pontas, e não pode
+// terminar antes de hoje.
+func (in *DelegacaoInput) Normalize(hoje time.Time) error {
+	in.Inicio, in.Fim = truncDay(in.Inicio), truncDay(in.Fim)
+	if in.Motivo != nil {
+		motivo := strings.TrimSpace(*in.Motivo)
+		if motivo == "" {
+			in.Motivo = nil
+		} else {
+			in.Motivo = &motivo
+		}
+	}
+	switch {
+	case in.DelegadoID == uuid.Nil:
+		return errors.New("informe quem recebe a delegação")
+	case in.Inicio.IsZero() || in.Fim.IsZero():
+		return errors.New("início e fim obrigatórios")
+	case in.Fim.Before(in.Inicio):
+		return errors.New("fim deve ser igual ou posterior ao início")
+	case in.Fim.Before(truncDay(hoje)):
+		return errors.New("período já encerrado")
+	case in.Fim.Sub(in.Inicio) >= MaxDelegacaoDias*24*time.Hour:
+		return fmt.Errorf("delegação pode durar no máximo %d dias", MaxDelegacaoDias)
+	}
+
+	seen := map[string]bool{}
+	permissoes := make([]string, 0, len(in.Permissoes))
+	for _, p := range in.Permissoes {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || seen[p] {
+			continue
+		}
+		if !Valid(p) {
+			return fmt.Errorf("permissão desconhecida: %s", p)
+		}
+		seen[p] = true
+		permissoes = append(permissoes, p)
+	}
+	if len(permissoes) == 0 {
+		return errors.New("informe ao menos uma permissão")
+	}
+	sort.Strings(permissoes)
+	in.Permissoes = permissoes
+	return nil
+}
+
+// DelegacaoAcao é uma requisição de escrita feita sob delegação.
+type DelegacaoAcao struct {
+	ID        uuid.UUID `json:"id"`
+	UsuarioID uuid.UUID `json:"usuario_id"`
+	Metodo    string    `json:"metodo"`
+	Rota      string    `json:"rota"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type delegacaoKey struct{}
+
+// WithDelegacao marca o contexto da requisição autorizada por delegação, para que os registros de
+// histórico gravem em nome de quem a ação foi feita.
+func WithDelegacao(ctx context.Context, d *Delegacao) context.Context {
+	return context.WithValue(ctx, delegacaoKey{}, d)
+}
+
+// DelegacaoFrom devolve a delegação que autorizou a requisição, se houver.
+func DelegacaoFrom(ctx context.Context) *Delegacao {
+	d, _ := ctx.Value(delegacaoKey{}).(*Delegacao)
+	return d
+}
+
+func truncDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
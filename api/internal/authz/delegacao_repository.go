@@ -0,0 +1,154 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const delegacaoColumns = `d.id, d.tenant_id, d.delegante_id, COALESCE(ud.nome, ''), d.delegado_id, COALESCE(ur.nome, ''),
+        d.permissoes, d.inicio, d.fim, d.motivo, d.revogada_em,
+        (SELECT count(*) FROM delegacao_acoes a WHERE a.delegacao_id = d.id)::int, d.created_at`
+
+const delegacaoFrom = ` FROM delegacoes d
+        LEFT JOIN usuarios ud ON ud.id = d.delegante_id
+        LEFT JOIN usuarios ur ON ur.id = d.delegado_id`
+
+// hojeSQL é a data corrente no fuso das prefeituras atendidas.
+const hojeSQL = `(now() AT TIME ZONE 'America/Sao_Paulo')::date`
+
+// CreateDelegacao grava a delegação. Quem delega precisa ser secretário ou prefeito na prefeitura e
+// quem recebe precisa ter vínculo com alguma secretaria dela.
+func (r *Repository) CreateDelegacao(ctx context.Context, tenantID, deleganteID uuid.UUID, in DelegacaoInput) (*Delegacao, error) {
+	var delegante, membro bool
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT
+            EXISTS (SELECT 1 FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id
+                    WHERE us.usuario_id = $1 AND s.tenant_id = $3 AND us.papel IN ('SECRETARIO', 'PREFEITO')),
+            EXISTS (SELECT 1 FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id
+                    WHERE us.usuario_id = $2 AND s.tenant_id = $3)`,
+		deleganteID, in.DelegadoID, tenantID).Scan(&delegante, &membro); err != nil {
+		return nil, err
+	}
+	if !delegante {
+		return nil, ErrDeleganteInvalido
+	}
+	if !membro || in.DelegadoID == deleganteID {
+		return nil, ErrMembroInvalido
+	}
+
+	var id uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO delegacoes (tenant_id, delegante_id, delegado_id, permissoes, inicio, fim, motivo)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id`, tenantID, deleganteID, in.DelegadoID, in.Permissoes, in.Inicio, in.Fim, in.Motivo).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetDelegacao(ctx, tenantID, id, deleganteID)
+}
+
+// GetDelegacao carrega uma delegação visível ao usuário, como delegante ou delegado.
+func (r *Repository) GetDelegacao(ctx context.Context, tenantID, id, userID uuid.UUID) (*Delegacao, error) {
+	return scanDelegacao(r.conn(ctx).QueryRow(ctx, `SELECT `+delegacaoColumns+delegacaoFrom+`
+        WHERE d.tenant_id = $1 AND d.id = $2 AND (d.delegante_id = $3 OR d.delegado_id = $3)`, tenantID, id, userID))
+}
+
+// ListDelegacoes lista as delegações dadas e recebidas pelo usuário na prefeitura, das mais
+// recentes às mais antigas.
+func (r *Repository) ListDelegacoes(ctx context.Context, tenantID, userID uuid.UUID) ([]Delegacao, error) {
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+delegacaoColumns+delegacaoFrom+`
+        WHERE d.tenant_id = $1 AND (d.delegante_id = $2 OR d.delegado_id = $2)
+        ORDER BY d.inicio DESC, d.created_at DESC`, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Delegacao, error) {
+		d, err := scanDelegacao(row)
+		if err != nil {
+			return Delegacao{}, err
+		}
+		return *d, nil
+	})
+}
+
+// RevogarDelegacao encerra a delegação antes do fim; só o delegante pode revogar.
+func (r *Repository) RevogarDelegacao(ctx context.Context, tenantID, id, deleganteID uuid.UUID) (*Delegacao, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE delegacoes SET revogada_em = now()
+        WHERE tenant_id = $1 AND id = $2 AND delegante_id = $3 AND revogada_em IS NULL AND fim >= `+hojeSQL,
+		tenantID, id, deleganteID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := r.GetDelegacao(ctx, tenantID, id, deleganteID)
+	if err != nil {
+		return nil, err
+	}
+	if d.DeleganteID != deleganteID {
+		return nil, ErrDelegacaoNotFound
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrDelegacaoEncerrada
+	}
+	return d, nil
+}
+
+// ActiveDelegacao devolve uma delegação vigente hoje que conceda ao usuário alguma das permissões,
+// ou nil. Como em HasAnyPermission, a prefeitura é conferida na resolução do escopo da rota.
+func (r *Repository) ActiveDelegacao(ctx context.Context, userID uuid.UUID, perms []string) (*Delegacao, error) {
+	d, err := scanDelegacao(r.conn(ctx).QueryRow(ctx, `SELECT `+delegacaoColumns+delegacaoFrom+`
+        WHERE d.delegado_id = $1 AND d.permissoes && $2::text[] AND d.revogada_em IS NULL
+          AND `+hojeSQL+` BETWEEN d.inicio AND d.fim
+          AND ($3::uuid[] IS NULL OR d.tenant_id = ANY($3))
+        ORDER BY d.fim
+        LIMIT 1`, userID, perms, db.Tenants(ctx)))
+	if errors.Is(err, ErrDelegacaoNotFound) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// RecordDelegacaoAcao registra uma requisição de escrita feita sob a delegação.
+func (r *Repository) RecordDelegacaoAcao(ctx context.Context, delegacaoID, userID uuid.UUID, metodo, rota string, status int) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+        INSERT INTO delegacao_acoes (delegacao_id, usuario_id, metodo, rota, status)
+        VALUES ($1, $2, $3, $4, $5)`, delegacaoID, userID, metodo, rota, status)
+	return err
+}
+
+// ListDelegacaoAcoes lista o que foi feito sob a delegação, visível ao delegante e ao delegado.
+func (r *Repository) ListDelegacaoAcoes(ctx context.Context, tenantID, id, userID uuid.UUID) ([]DelegacaoAcao, error) {
+	if _, err := r.GetDelegacao(ctx, tenantID, id, userID); err != nil {
+		return nil, err
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT id, usuario_id, metodo, rota, status, created_at
+        FROM delegacao_acoes
+        WHERE delegacao_id = $1
+        ORDER BY created_at DESC
+        LIMIT 500`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (DelegacaoAcao, error) {
+		var a DelegacaoAcao
+		err := row.Scan(&a.ID, &a.UsuarioID, &a.Metodo, &a.Rota, &a.Status, &a.CreatedAt)
+		return a, err
+	})
+}
+
+func scanDelegacao(row pgx.Row) (*Delegacao, error) {
+	var d Delegacao
+	if err := row.Scan(&d.ID, &d.TenantID, &d.DeleganteID, &d.Delegante, &d.DelegadoID, &d.Delegado, &d.Permissoes,
+		&d.Inicio, &d.Fim, &d.Motivo, &d.RevogadaEm, &d.Acoes, &d.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDelegacaoNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,213 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const roleColumns = `r.id, r.tenant_id, r.codigo, r.nome, r.descricao, r.permissoes,
+        COALESCE((SELECT array_agg(m.usuario_id ORDER BY m.created_at) FROM backoffice_role_membros m WHERE m.role_id = r.id), '{}'),
+        r.created_at, r.updated_at`
+
+// Repository persiste os papéis personalizados e responde às consultas de permissão.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// List lista os papéis da prefeitura em ordem alfabética.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID) ([]Role, error) {
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+roleColumns+` FROM backoffice_roles r WHERE r.tenant_id = $1 ORDER BY r.nome`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Role, error) {
+		role, err := scanRole(row)
+		if err != nil {
+			return Role{}, err
+		}
+		return *role, nil
+	})
+}
+
+// Get carrega um papel da prefeitura com os membros.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID) (*Role, error) {
+	return scanRole(r.conn(ctx).QueryRow(ctx, `SELECT `+roleColumns+` FROM backoffice_roles r WHERE r.tenant_id = $1 AND r.id = $2`, tenantID, id))
+}
+
+// Create grava um papel novo.
+func (r *Repository) Create(ctx context.Context, tenantID uuid.UUID, in RoleInput, createdBy uuid.UUID) (*Role, error) {
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO backoffice_roles (tenant_id, codigo, nome, descricao, permissoes, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id`, tenantID, in.Codigo, in.Nome, in.Descricao, in.Permissoes, createdBy).Scan(&id)
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Update altera nome, código, descrição e permissões; vale na próxima requisição dos membros.
+func (r *Repository) Update(ctx context.Context, tenantID, id uuid.UUID, in RoleInput) (*Role, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE backoffice_roles
+        SET codigo = $3, nome = $4, descricao = $5, permissoes = $6, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2`, tenantID, id, in.Codigo, in.Nome, in.Descricao, in.Permissoes)
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Delete remove o papel e os vínculos dos membros.
+func (r *Repository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM backoffice_roles WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetMembros troca os membros do papel. Só entram usuários com vínculo em alguma secretaria da
+// prefeitura, inclusive atendentes, que passam a acessar o backoffice pelas permissões do papel.
+func (r *Repository) SetMembros(ctx context.Context, tenantID, id uuid.UUID, membros []uuid.UUID) (*Role, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := tx.QueryRow(ctx, `SELECT id FROM backoffice_roles WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+		tenantID, id).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if membros == nil {
+		membros = []uuid.UUID{}
+	}
+
+	var fora int
+	if err := tx.QueryRow(ctx, `
+        SELECT count(*) FROM unnest($2::uuid[]) AS u(id)
+        WHERE NOT EXISTS (
+            SELECT 1 FROM usuarios_secretarias us
+            JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE us.usuario_id = u.id AND s.tenant_id = $1
+        )`, tenantID, membros).Scan(&fora); err != nil {
+		return nil, err
+	}
+	if fora > 0 {
+		return nil, ErrMembroInvalido
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM backoffice_role_membros WHERE role_id = $1 AND NOT (usuario_id = ANY($2::uuid[]))`, id, membros); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO backoffice_role_membros (role_id, usuario_id)
+        SELECT $1, u FROM unnest($2::uuid[]) AS u
+        ON CONFLICT DO NOTHING`, id, membros); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// HasAnyPermission informa se algum papel personalizado do usuário, em qualquer prefeitura, concede
+// uma das permissões. A prefeitura em si é conferida depois, na resolução do escopo da rota. Dentro
+// de db.Resolver.EachCluster só valem as prefeituras roteadas para o cluster (db.Tenants).
+func (r *Repository) HasAnyPermission(ctx context.Context, userID uuid.UUID, perms []string) (bool, error) {
+	var ok bool
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM backoffice_role_membros m
+            JOIN backoffice_roles r ON r.id = m.role_id
+            WHERE m.usuario_id = $1 AND r.permissoes && $2::text[]
+              AND ($3::uuid[] IS NULL OR r.tenant_id = ANY($3))
+        )`, userID, perms, db.Tenants(ctx)).Scan(&ok)
+	return ok, err
+}
+
+// Permissions devolve as permissões do usuário na prefeitura: todas, se ele tiver papel fixo de
+// gestão em alguma secretaria dela, ou a união dos papéis personalizados e das delegações vigentes.
+func (r *Repository) Permissions(ctx context.Context, tenantID, userID uuid.UUID) ([]string, error) {
+	var full bool
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM usuarios_secretarias us
+            JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE us.usuario_id = $1 AND s.tenant_id = $2 AND us.papel IN ('SECRETARIO', 'PREFEITO', 'ADMIN_TEC', 'AUDITOR')
+        )`, userID, tenantID).Scan(&full); err != nil {
+		return nil, err
+	}
+	if full {
+		perms := make([]string, 0, len(catalog))
+		for _, p := range catalog {
+			perms = append(perms, p.Codigo)
+		}
+		return perms, nil
+	}
+
+	var perms []string
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT COALESCE(array_agg(DISTINCT p ORDER BY p), '{}')
+        FROM (
+            SELECT unnest(r.permissoes) AS p
+            FROM backoffice_role_membros m
+            JOIN backoffice_roles r ON r.id = m.role_id
+            WHERE m.usuario_id = $1 AND r.tenant_id = $2
+            UNION ALL
+            SELECT unnest(d.permissoes)
+            FROM delegacoes d
+            WHERE d.delegado_id = $1 AND d.tenant_id = $2 AND d.revogada_em IS NULL
+              AND `+hojeSQL+` BETWEEN d.inicio AND d.fim
+        ) AS concedidas`, userID, tenantID).Scan(&perms)
+	return perms, err
+}
+
+func mapWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCodigoEmUso
+	}
+	return err
+}
+
+func scanRole(row pgx.Row) (*Role, error) {
+	var role Role
+	if err := row.Scan(&role.ID, &role.TenantID, &role.Codigo, &role.Nome, &role.Descricao, &role.Permissoes,
+		&role.Membros, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
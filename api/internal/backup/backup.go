@@ -0,0 +1,398 @@
+// Package backup orquestra dumps lógicos do banco para o armazenamento de objetos, a retenção
+// desses arquivos e os testes de restauração. Cada execução fica registrada em backup_runs.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/storage"
+	"github.com/gestaozabele/municipio/internal/tenantmove"
+)
+
+const (
+	TipoDump         = "dump"
+	TipoRestoreDrill = "restore_drill"
+
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusExpired = "expired"
+)
+
+// Config reúne o que o orquestrador precisa além do pool e do storage.
+type Config struct {
+	// DSN é repassado ao pg_dump; deve apontar para o mesmo banco do pool.
+	DSN       string
+	PgDump    string
+	PgRestore string
+	// DrillDSN é um banco descartável onde o teste de restauração aplica o dump de schema.
+	// Vazio, o teste só confere checksum e o índice do arquivo.
+	DrillDSN string
+	// Retention é a idade a partir da qual um dump pode ser expirado; KeepMin dumps mais
+	// recentes de cada escopo são mantidos mesmo se mais antigos.
+	Retention time.Duration
+	KeepMin   int
+}
+
+// Run é uma execução registrada em backup_runs.
+type Run struct {
+	ID         uuid.UUID      `json:"id"`
+	Tipo       string         `json:"tipo"`
+	Escopo     string         `json:"escopo"`
+	Status     string         `json:"status"`
+	ObjectKey  *string        `json:"object_key,omitempty"`
+	Bytes      *int64         `json:"bytes,omitempty"`
+	SHA256     *string        `json:"sha256,omitempty"`
+	BackupID   *uuid.UUID     `json:"backup_id,omitempty"`
+	Erro       *string        `json:"erro,omitempty"`
+	Detalhes   map[string]any `json:"detalhes"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// Runner executa dumps, retenção e testes de restauração.
+type Runner struct {
+	pool     *pgxpool.Pool
+	uploader storage.Uploader
+	cfg      Config
+	logger   zerolog.Logger
+}
+
+// NewRunner cria o orquestrador; uploader deve ser um storage real.
+func NewRunner(pool *pgxpool.Pool, uploader storage.Uploader, cfg Config, logger zerolog.Logger) *Runner {
+	if cfg.PgDump == "" {
+		cfg.PgDump = "pg_dump"
+	}
+	if cfg.PgRestore == "" {
+		cfg.PgRestore = "pg_restore"
+	}
+	return &Runner{pool: pool, uploader: uploader, cfg: cfg, logger: logger}
+}
+
+// EscopoSchema e EscopoTenant identificam o alvo de um dump em backup_runs.
+func EscopoSchema(schema string) string { return "schema:" + schema }
+func EscopoTenant(slug string) string   { return "tenant:" + slug }
+
+// RunAll gera o dump de cada schema e, se tenants for verdadeiro, o de cada tenant ativo. A
+// falha de um escopo não interrompe os demais.
+func (r *Runner) RunAll(ctx context.Context, schemas []string, tenants bool) error {
+	var failed, total int
+	for _, schema := range schemas {
+		total++
+		if _, err := r.DumpSchema(ctx, schema); err != nil {
+			failed++
+			r.logger.Warn().Err(err).Str("schema", schema).Msg("backup: dump de schema falhou")
+		}
+	}
+	if tenants {
+		targets, err := r.activeTenants(ctx)
+		if err != nil {
+			return err
+		}
+		for _, t := range targets {
+			total++
+			if _, err := r.DumpTenant(ctx, t.id, t.slug); err != nil {
+				failed++
+				r.logger.Warn().Err(err).Str("tenant", t.slug).Msg("backup: dump de tenant falhou")
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("backup: %d de %d escopos falharam", failed, total)
+	}
+	return nil
+}
+
+// DumpTenantSlug gera o dump de um único tenant.
+func (r *Runner) DumpTenantSlug(ctx context.Context, slug string) (Run, error) {
+	var id uuid.UUID
+	if err := r.pool.QueryRow(ctx, `SELECT id FROM tenants WHERE slug = $1`, slug).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Run{}, fmt.Errorf("backup: tenant %s não encontrado", slug)
+		}
+		return Run{}, err
+	}
+	return r.DumpTenant(ctx, id, slug)
+}
+
+// DumpSchema executa pg_dump em formato custom restrito ao schema e envia o arquivo ao storage. A
+// saída vai para um arquivo temporário, lido direto pelo upload, para que o dump de um schema
+// grande não passe inteiro pela memória; a senha segue em PGPASSWORD, fora da linha de comando.
+func (r *Runner) DumpSchema(ctx context.Context, schema string) (Run, error) {
+	run, err := r.start(ctx, TipoDump, EscopoSchema(schema), nil)
+	if err != nil {
+		return run, err
+	}
+
+	arquivo, err := os.CreateTemp("", "backup-*.dump")
+	if err != nil {
+		return r.fail(ctx, run, err)
+	}
+	defer os.Remove(arquivo.Name())
+	defer arquivo.Close()
+
+	dsn, env := credenciais(r.cfg.DSN)
+	hash := sha256.New()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.cfg.PgDump, "--format=custom", "--no-owner", "--no-privileges",
+		"--schema="+schema, "--dbname="+dsn)
+	cmd.Env = env
+	cmd.Stdout = io.MultiWriter(arquivo, hash)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return r.fail(ctx, run, fmt.Errorf("pg_dump: %w: %s", err, ultimaLinha(stderr.String())))
+	}
+	size, err := arquivo.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return r.fail(ctx, run, err)
+	}
+	if _, err := arquivo.Seek(0, io.SeekStart); err != nil {
+		return r.fail(ctx, run, err)
+	}
+
+	key := fmt.Sprintf("backups/schema/%s/%s.dump", schema, run.StartedAt.UTC().Format("20060102T150405Z"))
+	return r.concluir(ctx, run, storage.UploadInput{Key: key, Stream: arquivo, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))},
+		map[string]any{"formato": "pg_dump custom", "schema": schema})
+}
+
+// credenciais tira a senha do DSN repassado às ferramentas do Postgres e a devolve no ambiente do
+// processo (PGPASSWORD), onde outros usuários da máquina não a veem como veriam em ps.
+func credenciais(dsn string) (string, []string) {
+	env := os.Environ()
+	senha := ""
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn, env
+		}
+		if u.User != nil {
+			senha, _ = u.User.Password()
+			u.User = url.User(u.User.Username())
+		}
+		q := u.Query()
+		if q.Has("password") {
+			senha = q.Get("password")
+			q.Del("password")
+			u.RawQuery = q.Encode()
+		}
+		dsn = u.String()
+	} else {
+		campos := strings.Fields(dsn)
+		mantidos := campos[:0]
+		for i := 0; i < len(campos); i++ {
+			valor, ok := strings.CutPrefix(campos[i], "password=")
+			if !ok {
+				mantidos = append(mantidos, campos[i])
+				continue
+			}
+			// Senha entre aspas simples pode conter espaços.
+			if strings.HasPrefix(valor, "'") {
+				for (len(valor) == 1 || !strings.HasSuffix(valor, "'")) && i+1 < len(campos) {
+					i++
+					valor += " " + campos[i]
+				}
+				valor = strings.TrimSuffix(strings.TrimPrefix(valor, "'"), "'")
+			}
+			senha = valor
+		}
+		dsn = strings.Join(mantidos, " ")
+	}
+	if senha != "" {
+		env = append(env, "PGPASSWORD="+senha)
+	}
+	return dsn, env
+}
+
+// DumpTenant exporta, numa transação somente leitura, as linhas do tenant no mesmo plano usado
+// para mover tenants entre clusters: tabelas com tenant_id, as filhas delas por chave estrangeira e
+// as mães compartilhadas (alunos, usuarios), com as mães antes das filhas. O resultado é um script
+// SQL com blocos COPY comprimido em gzip, que pode ser aplicado com psql sobre um banco com o mesmo
+// schema; linhas compartilhadas que o banco já tenha são mantidas.
+func (r *Runner) DumpTenant(ctx context.Context, tenantID uuid.UUID, slug string) (Run, error) {
+	run, err := r.start(ctx, TipoDump, EscopoTenant(slug), nil)
+	if err != nil {
+		return run, err
+	}
+
+	body, linhas, err := r.copyTenant(ctx, tenantID, slug)
+	if err != nil {
+		return r.fail(ctx, run, err)
+	}
+
+	key := fmt.Sprintf("backups/tenant/%s/%s.sql.gz", slug, run.StartedAt.UTC().Format("20060102T150405Z"))
+	return r.finish(ctx, run, key, body, map[string]any{"formato": "sql copy gzip", "tenant_id": tenantID, "linhas": linhas})
+}
+
+// foraDoBackup mantém no dump também as tabelas do plano de controle com tenant_id (contratos,
+// faturas, administradores); só o controle de migrações e os próprios backups ficam de fora.
+func foraDoBackup(t pgx.Identifier) bool {
+	nome := t[len(t)-1]
+	return nome == "schema_migrations" || nome == "backup_runs"
+}
+
+func (r *Runner) copyTenant(ctx context.Context, tenantID uuid.UUID, slug string) ([]byte, map[string]int64, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	plano, err := tenantmove.DescobrirPlano(ctx, tx, tenantID, foraDoBackup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	fmt.Fprintf(gz, "-- backup do tenant %s (%s) gerado em %s\n", slug, tenantID, time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(gz, "SET client_encoding = 'UTF8';\n\n")
+
+	linhas := make(map[string]int64, len(plano))
+	for _, alvo := range plano {
+		nome := alvo.Nome()
+		cols, err := tenantmove.Colunas(ctx, tx, alvo)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Linhas compartilhadas passam por uma tabela temporária para não colidir com as que o banco
+		// restaurado já tenha.
+		destino := nome
+		if alvo.Compartilhada {
+			destino = pgx.Identifier{"restauro_" + alvo.Tabela[len(alvo.Tabela)-1]}.Sanitize()
+			fmt.Fprintf(gz, "%s%s\n", marcaCompartilhada, nome)
+			fmt.Fprintf(gz, "CREATE TEMP TABLE %s (LIKE %s);\n", destino, nome)
+		} else {
+			fmt.Fprintf(gz, "%s%s\n", marcaTabela, nome)
+		}
+		fmt.Fprintf(gz, "COPY %s (%s) FROM stdin;\n", destino, cols)
+		tag, err := tx.Conn().PgConn().CopyTo(ctx, gz, fmt.Sprintf("COPY (SELECT %s FROM %s WHERE %s) TO STDOUT", cols, nome, alvo.Filtro))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", nome, err)
+		}
+		fmt.Fprintf(gz, "\\.\n")
+		if alvo.Compartilhada {
+			fmt.Fprintf(gz, "INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING;\n", nome, cols, cols, destino)
+			fmt.Fprintf(gz, "DROP TABLE %s;\n", destino)
+		}
+		fmt.Fprintf(gz, "\n")
+		linhas[nome] = tag.RowsAffected()
+	}
+	if err := gz.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), linhas, nil
+}
+
+// marcaTabela e marcaCompartilhada abrem o bloco de cada tabela no script do tenant; o teste de
+// restauração lê delas a tabela real, já que o COPY de uma compartilhada vai para a temporária.
+const (
+	marcaTabela        = "-- tabela "
+	marcaCompartilhada = "-- tabela compartilhada "
+)
+
+type tenantTarget struct {
+	id   uuid.UUID
+	slug string
+}
+
+func (r *Runner) activeTenants(ctx context.Context) ([]tenantTarget, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, slug FROM tenants WHERE status = 'active' ORDER BY slug`)
+	if err != nil {
+		return nil, fmt.Errorf("backup: listar tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []tenantTarget
+	for rows.Next() {
+		var t tenantTarget
+		if err := rows.Scan(&t.id, &t.slug); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+func (r *Runner) start(ctx context.Context, tipo, escopo string, backupID *uuid.UUID) (Run, error) {
+	run := Run{Tipo: tipo, Escopo: escopo, Status: StatusRunning, BackupID: backupID, Detalhes: map[string]any{}}
+	err := r.pool.QueryRow(ctx, `
+        INSERT INTO backup_runs (tipo, escopo, backup_id) VALUES ($1, $2, $3)
+        RETURNING id, started_at
+    `, tipo, escopo, backupID).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return run, fmt.Errorf("backup: registrar execução: %w", err)
+	}
+	return run, nil
+}
+
+// finish envia o arquivo ao storage e marca a execução como bem-sucedida.
+func (r *Runner) finish(ctx context.Context, run Run, key string, body []byte, detalhes map[string]any) (Run, error) {
+	sum := sha256.Sum256(body)
+	return r.concluir(ctx, run, storage.UploadInput{Key: key, Body: body, Size: int64(len(body)), SHA256: hex.EncodeToString(sum[:])}, detalhes)
+}
+
+// concluir é o finish de um upload já montado; input traz Size e SHA256 também quando vem de Body.
+func (r *Runner) concluir(ctx context.Context, run Run, input storage.UploadInput, detalhes map[string]any) (Run, error) {
+	if input.Size == 0 {
+		return r.fail(ctx, run, errors.New("dump vazio"))
+	}
+	input.ContentType = "application/octet-stream"
+	input.CacheControl = "private, no-store"
+	if _, err := r.uploader.Upload(ctx, input); err != nil {
+		return r.fail(ctx, run, fmt.Errorf("upload: %w", err))
+	}
+
+	key, size, digest := input.Key, input.Size, input.SHA256
+	run.Status = StatusSuccess
+	run.ObjectKey = &key
+	run.Bytes = &size
+	run.SHA256 = &digest
+	run.Detalhes = detalhes
+	err := r.pool.QueryRow(ctx, `
+        UPDATE backup_runs
+        SET status = 'success', object_key = $2, bytes = $3, sha256 = $4, detalhes = $5, finished_at = now()
+        WHERE id = $1
+        RETURNING finished_at
+    `, run.ID, key, size, digest, detalhes).Scan(&run.FinishedAt)
+	if err != nil {
+		return run, fmt.Errorf("backup: concluir execução: %w", err)
+	}
+	r.logger.Info().Str("escopo", run.Escopo).Str("key", key).Int64("bytes", size).Msg("backup: dump enviado")
+	return run, nil
+}
+
+// fail registra o erro na execução e o devolve ao chamador.
+func (r *Runner) fail(ctx context.Context, run Run, cause error) (Run, error) {
+	msg := cause.Error()
+	run.Status = StatusFailed
+	run.Erro = &msg
+	if _, err := r.pool.Exec(ctx, `
+        UPDATE backup_runs SET status = 'failed', erro = $2, detalhes = $3, finished_at = now() WHERE id = $1
+    `, run.ID, msg, run.Detalhes); err != nil {
+		r.logger.Warn().Err(err).Str("run", run.ID.String()).Msg("backup: não foi possível registrar falha")
+	}
+	return run, fmt.Errorf("backup %s: %w", run.Escopo, cause)
+}
+
+// ultimaLinha resume o stderr das ferramentas do Postgres à mensagem final.
+func ultimaLinha(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestSelecionarExpiradosPreservaMinimoPorEscopo(t *testing.T) {
+	now := time.Date(2026, 3, 31, 3, 0, 0, 0, time.UTC)
+	dia := 24 * time.Hour
+	runs := []Run{
+		{Escopo: "schema:public", StartedAt: now.Add(-40 * dia)},
+		{Escopo: "schema:public", StartedAt: now.Add(-1 * dia)},
+		{Escopo: "schema:public", StartedAt: now.Add(-35 * dia)},
+		{Escopo: "tenant:zabele", StartedAt: now.Add(-50 * dia)},
+		{Escopo: "tenant:zabele", StartedAt: now.Add(-45 * dia)},
+	}
+
+	got := selecionarExpirados(runs, now, 30*dia, 2)
+	if len(got) != 1 {
+		t.Fatalf("esperava 1 expirado, veio %d", len(got))
+	}
+	if got[0].Escopo != "schema:public" || !got[0].StartedAt.Equal(now.Add(-40*dia)) {
+		t.Fatalf("expirado inesperado: %+v", got[0])
+	}
+
+	if got := selecionarExpirados(runs, now, 0, 0); got != nil {
+		t.Fatalf("retenção zero não deveria expirar nada: %+v", got)
+	}
+}
+
+func TestVerificarDumpTenant(t *testing.T) {
+	script := "-- backup\n" +
+		"COPY \"public\".\"tenants\" FROM stdin;\n1\tzabele\n\\.\n\n" +
+		"COPY \"public\".\"alunos\" FROM stdin;\na\nb\n\\.\n\n"
+	body := gzipar(t, script)
+
+	detalhes := map[string]any{"linhas": map[string]any{`"public"."tenants"`: float64(1), `"public"."alunos"`: float64(2)}}
+	linhas, err := verificarDumpTenant(body, detalhes)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if linhas[`"public"."alunos"`] != 2 {
+		t.Fatalf("contagem inesperada: %v", linhas)
+	}
+
+	detalhes["linhas"].(map[string]any)[`"public"."alunos"`] = float64(3)
+	if _, err := verificarDumpTenant(body, detalhes); err == nil {
+		t.Fatal("esperava divergência de contagem")
+	}
+
+	if _, err := verificarDumpTenant(gzipar(t, "COPY \"public\".\"alunos\" FROM stdin;\na\n"), nil); err == nil {
+		t.Fatal("esperava erro de bloco não terminado")
+	}
+}
+
+type aplicadorFake struct {
+	execs  []string
+	copies map[string]string
+}
+
+func (f *aplicadorFake) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	f.execs = append(f.execs, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (f *aplicadorFake) CopyFrom(_ context.Context, r io.Reader, sql string) (pgconn.CommandTag, error) {
+	dados, _ := io.ReadAll(r)
+	f.copies[sql] = string(dados)
+	return pgconn.NewCommandTag(fmt.Sprintf("COPY %d", strings.Count(string(dados), "\n"))), nil
+}
+
+func TestRestaurarDumpTenant(t *testing.T) {
+	script := "-- backup\nSET client_encoding = 'UTF8';\n\n" +
+		"-- tabela \"public\".\"tenants\"\n" +
+		"COPY \"public\".\"tenants\" (\"id\", \"slug\") FROM stdin;\n1\tzabele\n\\.\n\n" +
+		"-- tabela compartilhada \"public\".\"alunos\"\n" +
+		"CREATE TEMP TABLE \"restauro_alunos\" (LIKE \"public\".\"alunos\");\n" +
+		"COPY \"restauro_alunos\" (\"id\", \"nome\") FROM stdin;\na\tAna\nb\tBia\n\\.\n" +
+		"INSERT INTO \"public\".\"alunos\" (\"id\", \"nome\") SELECT \"id\", \"nome\" FROM \"restauro_alunos\" ON CONFLICT DO NOTHING;\n" +
+		"DROP TABLE \"restauro_alunos\";\n\n"
+	body := gzipar(t, script)
+
+	// As contagens registradas são pela tabela real, não pela temporária.
+	detalhes := map[string]any{"linhas": map[string]any{`"public"."tenants"`: float64(1), `"public"."alunos"`: float64(2)}}
+	if _, err := verificarDumpTenant(body, detalhes); err != nil {
+		t.Fatalf("verificar: %v", err)
+	}
+
+	fake := &aplicadorFake{copies: map[string]string{}}
+	linhas, err := restaurarDumpTenant(context.Background(), fake, body)
+	if err != nil {
+		t.Fatalf("restaurar: %v", err)
+	}
+	if fake.execs[0] != `TRUNCATE "public"."tenants", "public"."alunos" CASCADE` {
+		t.Fatalf("esvaziamento = %q", fake.execs[0])
+	}
+	if len(fake.execs) != 5 || !strings.HasPrefix(fake.execs[3], `INSERT INTO "public"."alunos"`) {
+		t.Fatalf("comandos = %q", fake.execs)
+	}
+	if got := fake.copies[`COPY "restauro_alunos" ("id", "nome") FROM stdin;`]; got != "a\tAna\nb\tBia\n" {
+		t.Fatalf("dados copiados = %q", got)
+	}
+	if linhas[`"public"."alunos"`] != 2 || linhas[`"public"."tenants"`] != 1 {
+		t.Fatalf("linhas = %v", linhas)
+	}
+
+	if _, err := restaurarDumpTenant(context.Background(), fake, gzipar(t, "COPY \"public\".\"alunos\" FROM stdin;\na\n\\.\n")); err == nil {
+		t.Fatal("dump sem marcação aceito")
+	}
+}
+
+func gzipar(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCredenciaisTiraSenhaDaLinhaDeComando(t *testing.T) {
+	t.Setenv("PGPASSWORD", "")
+	casos := []struct {
+		dsn, semSenha, senha string
+	}{
+		{"postgres://app:s3cr3t@db:5432/municipio?sslmode=require", "postgres://app@db:5432/municipio?sslmode=require", "s3cr3t"},
+		{"postgres://app@db/municipio?password=s3cr3t", "postgres://app@db/municipio", "s3cr3t"},
+		{"host=db user=app password='com espaço' dbname=municipio", "host=db user=app dbname=municipio", "com espaço"},
+		{"host=db user=app dbname=municipio", "host=db user=app dbname=municipio", ""},
+	}
+	for _, c := range casos {
+		dsn, env := credenciais(c.dsn)
+		if dsn != c.semSenha {
+			t.Errorf("credenciais(%q) dsn = %q, want %q", c.dsn, dsn, c.semSenha)
+		}
+		senha := ""
+		for _, kv := range env {
+			if v, ok := strings.CutPrefix(kv, "PGPASSWORD="); ok {
+				senha = v
+			}
+		}
+		if senha != c.senha {
+			t.Errorf("credenciais(%q) PGPASSWORD = %q, want %q", c.dsn, senha, c.senha)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// Drill baixa o dump bem-sucedido mais recente do escopo (ou de qualquer escopo, se vazio),
+// confere o checksum e valida o conteúdo. Dumps de schema passam por pg_restore --list e, com
+// DrillDSN configurado, são restaurados por completo no banco descartável; dumps de tenant têm
+// os blocos COPY conferidos contra as contagens registradas no backup e, com DrillDSN, são
+// aplicados com as chaves estrangeiras ligadas numa transação desfeita ao final.
+func (r *Runner) Drill(ctx context.Context, escopo string) (Run, error) {
+	downloader, ok := r.uploader.(storage.Downloader)
+	if !ok {
+		return Run{}, errors.New("backup: storage não permite baixar objetos")
+	}
+
+	backup, err := r.latestDump(ctx, escopo)
+	if err != nil {
+		return Run{}, err
+	}
+
+	run, err := r.start(ctx, TipoRestoreDrill, backup.Escopo, &backup.ID)
+	if err != nil {
+		return run, err
+	}
+	inicio := time.Now()
+
+	body, err := downloader.Download(ctx, *backup.ObjectKey)
+	if err != nil {
+		return r.fail(ctx, run, fmt.Errorf("download: %w", err))
+	}
+	sum := sha256.Sum256(body)
+	if backup.SHA256 != nil && hex.EncodeToString(sum[:]) != *backup.SHA256 {
+		return r.fail(ctx, run, errors.New("checksum diverge do registrado no backup"))
+	}
+	run.Detalhes["checksum_ok"] = true
+	run.Detalhes["object_key"] = *backup.ObjectKey
+
+	if strings.HasSuffix(*backup.ObjectKey, ".sql.gz") {
+		linhas, err := verificarDumpTenant(body, backup.Detalhes)
+		if err != nil {
+			return r.fail(ctx, run, err)
+		}
+		run.Detalhes["tabelas"] = len(linhas)
+		if err := r.restoreTenant(ctx, body, run.Detalhes); err != nil {
+			return r.fail(ctx, run, err)
+		}
+	} else {
+		if err := r.restoreSchema(ctx, body, run.Detalhes); err != nil {
+			return r.fail(ctx, run, err)
+		}
+	}
+	run.Detalhes["duracao_ms"] = time.Since(inicio).Milliseconds()
+
+	run.Status = StatusSuccess
+	err = r.pool.QueryRow(ctx, `
+        UPDATE backup_runs SET status = 'success', detalhes = $2, finished_at = now()
+        WHERE id = $1
+        RETURNING finished_at
+    `, run.ID, run.Detalhes).Scan(&run.FinishedAt)
+	if err != nil {
+		return run, fmt.Errorf("backup: concluir teste de restauração: %w", err)
+	}
+	r.logger.Info().Str("escopo", run.Escopo).Str("backup", backup.ID.String()).Msg("backup: teste de restauração concluído")
+	return run, nil
+}
+
+func (r *Runner) latestDump(ctx context.Context, escopo string) (Run, error) {
+	var backup Run
+	err := r.pool.QueryRow(ctx, `
+        SELECT id, escopo, object_key, sha256, detalhes, started_at
+        FROM backup_runs
+        WHERE tipo = 'dump' AND status = 'success' AND ($1 = '' OR escopo = $1)
+        ORDER BY started_at DESC
+        LIMIT 1
+    `, escopo).Scan(&backup.ID, &backup.Escopo, &backup.ObjectKey, &backup.SHA256, &backup.Detalhes, &backup.StartedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return backup, errors.New("backup: nenhum dump disponível para o teste de restauração")
+	}
+	return backup, err
+}
+
+// restoreSchema lista o índice do arquivo e, se houver banco de teste, restaura nele.
+func (r *Runner) restoreSchema(ctx context.Context, body []byte, detalhes map[string]any) error {
+	var stdout, stderr bytes.Buffer
+	list := exec.CommandContext(ctx, r.cfg.PgRestore, "--list")
+	list.Stdin = bytes.NewReader(body)
+	list.Stdout = &stdout
+	list.Stderr = &stderr
+	if err := list.Run(); err != nil {
+		return fmt.Errorf("pg_restore --list: %w: %s", err, ultimaLinha(stderr.String()))
+	}
+	var tabelas, dados int
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, ";"):
+		case strings.Contains(line, " TABLE DATA "):
+			dados++
+		case strings.Contains(line, " TABLE "):
+			tabelas++
+		}
+	}
+	if tabelas == 0 {
+		return errors.New("dump sem tabelas")
+	}
+	detalhes["tabelas"] = tabelas
+	detalhes["tabelas_com_dados"] = dados
+
+	if r.cfg.DrillDSN == "" {
+		detalhes["restaurado"] = false
+		return nil
+	}
+	stderr.Reset()
+	dsn, env := credenciais(r.cfg.DrillDSN)
+	restore := exec.CommandContext(ctx, r.cfg.PgRestore, "--clean", "--if-exists", "--no-owner", "--no-privileges",
+		"--exit-on-error", "--dbname="+dsn)
+	restore.Env = env
+	restore.Stdin = bytes.NewReader(body)
+	restore.Stderr = &stderr
+	if err := restore.Run(); err != nil {
+		return fmt.Errorf("pg_restore: %w: %s", err, ultimaLinha(stderr.String()))
+	}
+	detalhes["restaurado"] = true
+	return nil
+}
+
+// restoreTenant aplica o dump do tenant no banco de teste, que precisa ter o schema atual (o teste
+// de um dump de schema deixa o banco assim). Tudo roda numa transação desfeita no fim, então o
+// banco de teste não muda.
+func (r *Runner) restoreTenant(ctx context.Context, body []byte, detalhes map[string]any) error {
+	if r.cfg.DrillDSN == "" {
+		detalhes["restaurado"] = false
+		return nil
+	}
+	conn, err := pgx.Connect(ctx, r.cfg.DrillDSN)
+	if err != nil {
+		return fmt.Errorf("banco de teste: %w", err)
+	}
+	defer conn.Close(ctx)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	linhas, err := restaurarDumpTenant(ctx, txAplicador{tx}, body)
+	if err != nil {
+		return fmt.Errorf("restaurar: %w", err)
+	}
+	var total int64
+	for _, n := range linhas {
+		total += n
+	}
+	detalhes["restaurado"] = true
+	detalhes["linhas_restauradas"] = total
+	return nil
+}
+
+// aplicador é o que restaurarDumpTenant precisa da conexão; separado para os testes.
+type aplicador interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, r io.Reader, sql string) (pgconn.CommandTag, error)
+}
+
+type txAplicador struct{ pgx.Tx }
+
+func (t txAplicador) CopyFrom(ctx context.Context, r io.Reader, sql string) (pgconn.CommandTag, error) {
+	return t.Conn().PgConn().CopyFrom(ctx, r, sql)
+}
+
+// restaurarDumpTenant esvazia as tabelas do dump (com CASCADE, para que nenhuma linha antiga
+// satisfaça as chaves estrangeiras) e executa o script: blocos COPY pelo protocolo de cópia e os
+// demais comandos, todos de uma linha, por Exec. Devolve as linhas copiadas por tabela.
+func restaurarDumpTenant(ctx context.Context, a aplicador, body []byte) (map[string]int64, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	script, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	lines := strings.Split(string(script), "\n")
+
+	var tabelas []string
+	for _, line := range lines {
+		if nome, _, ok := marcaDeTabela(line); ok {
+			tabelas = append(tabelas, nome)
+		}
+	}
+	if len(tabelas) == 0 {
+		return nil, errors.New("dump sem marcação de tabelas; gere um backup novo")
+	}
+	if _, err := a.Exec(ctx, "TRUNCATE "+strings.Join(tabelas, ", ")+" CASCADE"); err != nil {
+		return nil, fmt.Errorf("esvaziar tabelas: %w", err)
+	}
+
+	linhas := map[string]int64{}
+	tabela := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if nome, _, ok := marcaDeTabela(line); ok {
+			tabela = nome
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		if !strings.HasPrefix(line, "COPY ") {
+			if _, err := a.Exec(ctx, line); err != nil {
+				return nil, fmt.Errorf("%s: %w", tabela, err)
+			}
+			continue
+		}
+		var dados strings.Builder
+		for i++; i < len(lines) && lines[i] != `\.`; i++ {
+			dados.WriteString(lines[i])
+			dados.WriteByte('\n')
+		}
+		if i == len(lines) {
+			return nil, fmt.Errorf("bloco COPY de %s não terminado", tabela)
+		}
+		tag, err := a.CopyFrom(ctx, strings.NewReader(dados.String()), line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tabela, err)
+		}
+		linhas[tabela] += tag.RowsAffected()
+	}
+	return linhas, nil
+}
+
+// marcaDeTabela reconhece a linha que abre o bloco de uma tabela no script do tenant.
+func marcaDeTabela(line string) (nome string, compartilhada bool, ok bool) {
+	if rest, found := strings.CutPrefix(line, marcaCompartilhada); found {
+		return rest, true, true
+	}
+	if rest, found := strings.CutPrefix(line, marcaTabela); found {
+		return rest, false, true
+	}
+	return "", false, false
+}
+
+// verificarDumpTenant descomprime o script, exige que todo bloco COPY termine em "\." e compara
+// as linhas de cada tabela com as contagens gravadas no dump (detalhes.linhas), quando houver.
+func verificarDumpTenant(body []byte, detalhes map[string]any) (map[string]int64, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	defer gz.Close()
+
+	// Dumps novos marcam a tabela real antes de cada bloco; nos antigos ela vem do próprio COPY.
+	linhas := map[string]int64{}
+	tabela, marcada := "", ""
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case tabela != "" && line == `\.`:
+			tabela = ""
+		case tabela != "":
+			linhas[tabela]++
+		case strings.HasPrefix(line, marcaTabela):
+			marcada, _, _ = marcaDeTabela(line)
+		case strings.HasPrefix(line, "COPY ") && strings.HasSuffix(line, " FROM stdin;"):
+			tabela = strings.TrimSuffix(strings.TrimPrefix(line, "COPY "), " FROM stdin;")
+			if i := strings.Index(tabela, " ("); i >= 0 {
+				tabela = tabela[:i]
+			}
+			if marcada != "" {
+				tabela, marcada = marcada, ""
+			}
+			linhas[tabela] += 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	if tabela != "" {
+		return nil, fmt.Errorf("bloco COPY de %s não terminado", tabela)
+	}
+	if len(linhas) == 0 {
+		return nil, errors.New("dump sem blocos COPY")
+	}
+
+	esperado, _ := detalhes["linhas"].(map[string]any)
+	for nome, v := range esperado {
+		n, _ := v.(float64)
+		if linhas[nome] != int64(n) {
+			return nil, fmt.Errorf("%s: %d linhas no arquivo, %d registradas no backup", nome, linhas[nome], int64(n))
+		}
+	}
+	return linhas, nil
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// Prune expira os dumps fora da janela de retenção. Quando o storage sabe remover objetos o
+// arquivo é apagado; caso contrário só o registro muda e a remoção fica com as regras de ciclo
+// de vida do bucket (prefixo backups/).
+func (r *Runner) Prune(ctx context.Context, now time.Time) (int, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT id, escopo, object_key, started_at
+        FROM backup_runs
+        WHERE tipo = 'dump' AND status = 'success'
+    `)
+	if err != nil {
+		return 0, fmt.Errorf("backup: listar dumps: %w", err)
+	}
+	var runs []Run
+	for rows.Next() {
+		run := Run{Tipo: TipoDump, Status: StatusSuccess}
+		if err := rows.Scan(&run.ID, &run.Escopo, &run.ObjectKey, &run.StartedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		runs = append(runs, run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	deleter, canDelete := r.uploader.(storage.Deleter)
+	var expired int
+	for _, run := range selecionarExpirados(runs, now, r.cfg.Retention, r.cfg.KeepMin) {
+		if canDelete && run.ObjectKey != nil {
+			if err := deleter.Delete(ctx, *run.ObjectKey); err != nil {
+				r.logger.Warn().Err(err).Str("key", *run.ObjectKey).Msg("backup: não foi possível remover dump expirado")
+				continue
+			}
+		}
+		if _, err := r.pool.Exec(ctx, `
+            UPDATE backup_runs SET status = 'expired', expired_at = now() WHERE id = $1
+        `, run.ID); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// selecionarExpirados devolve os dumps mais velhos que retencao, preservando sempre os minimo
+// mais recentes de cada escopo. Retenção zero desliga a expiração.
+func selecionarExpirados(runs []Run, now time.Time, retencao time.Duration, minimo int) []Run {
+	if retencao <= 0 {
+		return nil
+	}
+	porEscopo := map[string][]Run{}
+	for _, run := range runs {
+		porEscopo[run.Escopo] = append(porEscopo[run.Escopo], run)
+	}
+
+	escopos := make([]string, 0, len(porEscopo))
+	for escopo := range porEscopo {
+		escopos = append(escopos, escopo)
+	}
+	sort.Strings(escopos)
+
+	var expirados []Run
+	for _, escopo := range escopos {
+		lista := porEscopo[escopo]
+		sort.Slice(lista, func(i, j int) bool { return lista[i].StartedAt.After(lista[j].StartedAt) })
+		for i, run := range lista {
+			if i < minimo {
+				continue
+			}
+			if now.Sub(run.StartedAt) > retencao {
+				expirados = append(expirados, run)
+			}
+		}
+	}
+	return expirados
+}
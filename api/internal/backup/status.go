@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Status resume o estado dos backups para o painel do SaaS.
+type Status struct {
+	// UltimosBackups traz o dump bem-sucedido mais recente de cada escopo.
+	UltimosBackups []Run `json:"ultimos_backups"`
+	// Drills lista os testes de restauração mais recentes, com sucesso ou falha.
+	Drills []Run `json:"drills"`
+	// Recentes são as últimas execuções de qualquer tipo, para acompanhar falhas.
+	Recentes []Run `json:"recentes"`
+}
+
+const runColumns = `id, tipo, escopo, status, object_key, bytes, sha256, backup_id, erro, detalhes, started_at, finished_at`
+
+// LoadStatus lê o resumo direto de backup_runs.
+func LoadStatus(ctx context.Context, pool *pgxpool.Pool) (Status, error) {
+	var status Status
+	var err error
+	status.UltimosBackups, err = queryRuns(ctx, pool, `
+        SELECT DISTINCT ON (escopo) `+runColumns+`
+        FROM backup_runs
+        WHERE tipo = 'dump' AND status = 'success'
+        ORDER BY escopo, started_at DESC
+    `)
+	if err != nil {
+		return status, err
+	}
+	status.Drills, err = queryRuns(ctx, pool, `
+        SELECT `+runColumns+` FROM backup_runs
+        WHERE tipo = 'restore_drill'
+        ORDER BY started_at DESC
+        LIMIT 10
+    `)
+	if err != nil {
+		return status, err
+	}
+	status.Recentes, err = queryRuns(ctx, pool, `
+        SELECT `+runColumns+` FROM backup_runs
+        ORDER BY started_at DESC
+        LIMIT 20
+    `)
+	return status, err
+}
+
+func queryRuns(ctx context.Context, pool *pgxpool.Pool, query string) ([]Run, error) {
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]Run, 0)
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.ID, &run.Tipo, &run.Escopo, &run.Status, &run.ObjectKey, &run.Bytes, &run.SHA256,
+			&run.BackupID, &run.Erro, &run.Detalhes, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
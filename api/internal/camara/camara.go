@@ -0,0 +1,279 @@
+// Package camara publica a agenda legislativa da câmara municipal no mesmo app: sessões, pauta com
+// as proposições e o resultado das votações, ata e documentos anexos, com leitura pública.
+package camara
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica sessão inexistente, não publicada ou de outro tenant.
+	ErrNotFound = errors.New("camara: sessão não encontrada")
+	// ErrItemNotFound indica item de pauta inexistente ou de outra sessão.
+	ErrItemNotFound = errors.New("camara: item de pauta não encontrado")
+	// ErrDocumentoNotFound indica documento inexistente ou de outra sessão.
+	ErrDocumentoNotFound = errors.New("camara: documento não encontrado")
+	// ErrPublicada indica exclusão de sessão já publicada; ela deve ser cancelada.
+	ErrPublicada = errors.New("camara: sessão publicada não pode ser excluída")
+)
+
+// Tipos de sessão.
+const (
+	TipoOrdinaria        = "ordinaria"
+	TipoExtraordinaria   = "extraordinaria"
+	TipoSolene           = "solene"
+	TipoAudienciaPublica = "audiencia_publica"
+)
+
+// Situações da sessão.
+const (
+	StatusAgendada  = "agendada"
+	StatusRealizada = "realizada"
+	StatusAdiada    = "adiada"
+	StatusCancelada = "cancelada"
+)
+
+// Tipos de proposição na pauta.
+const (
+	ItemProjetoLei   = "projeto_lei"
+	ItemRequerimento = "requerimento"
+	ItemIndicacao    = "indicacao"
+	ItemMocao        = "mocao"
+	ItemVeto         = "veto"
+	ItemOutro        = "outro"
+)
+
+// Resultados da deliberação.
+const (
+	ResultadoAprovado  = "aprovado"
+	ResultadoRejeitado = "rejeitado"
+	ResultadoAdiado    = "adiado"
+	ResultadoRetirado  = "retirado"
+)
+
+// Tipos de documento anexado.
+const (
+	DocumentoPauta   = "pauta"
+	DocumentoAta     = "ata"
+	DocumentoProjeto = "projeto"
+	DocumentoParecer = "parecer"
+	DocumentoOutro   = "outro"
+)
+
+// Sessao é uma reunião da câmara.
+type Sessao struct {
+	ID             uuid.UUID   `json:"id"`
+	TenantID       uuid.UUID   `json:"tenant_id"`
+	Tipo           string      `json:"tipo"`
+	Numero         int         `json:"numero"`
+	Titulo         string      `json:"titulo"`
+	Inicio         time.Time   `json:"inicio"`
+	Local          string      `json:"local"`
+	Status         string      `json:"status"`
+	TransmissaoURL *string     `json:"transmissao_url,omitempty"`
+	Publicada      bool        `json:"publicada"`
+	PublicadaEm    *time.Time  `json:"publicada_em,omitempty"`
+	AtaResumo      *string     `json:"ata_resumo,omitempty"`
+	AtaAprovadaEm  *time.Time  `json:"ata_aprovada_em,omitempty"`
+	Itens          int         `json:"itens"`
+	Pauta          []ItemPauta `json:"pauta,omitempty"`
+	Documentos     []Documento `json:"documentos,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// SessaoInput contém os campos editáveis da sessão.
+type SessaoInput struct {
+	Tipo           string
+	Numero         int
+	Titulo         string
+	Inicio         time.Time
+	Local          string
+	Status         string
+	TransmissaoURL *string
+}
+
+// Normalize limpa e valida a entrada; sem título, usa "12ª Sessão Ordinária".
+func (in *SessaoInput) Normalize() error {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Titulo = strings.TrimSpace(in.Titulo)
+	in.Local = strings.TrimSpace(in.Local)
+	in.Status = strings.ToLower(strings.TrimSpace(in.Status))
+	in.TransmissaoURL = trimOptional(in.TransmissaoURL)
+	if in.Tipo == "" {
+		in.Tipo = TipoOrdinaria
+	}
+	if in.Status == "" {
+		in.Status = StatusAgendada
+	}
+	switch {
+	case !valido(in.Tipo, TipoOrdinaria, TipoExtraordinaria, TipoSolene, TipoAudienciaPublica):
+		return errors.New("tipo de sessão inválido")
+	case !valido(in.Status, StatusAgendada, StatusRealizada, StatusAdiada, StatusCancelada):
+		return errors.New("situação da sessão inválida")
+	case in.Numero < 0:
+		return errors.New("número da sessão inválido")
+	case in.Inicio.IsZero():
+		return errors.New("início obrigatório")
+	case in.Local == "":
+		return errors.New("local obrigatório")
+	case in.TransmissaoURL != nil && !strings.HasPrefix(*in.TransmissaoURL, "https://"):
+		return errors.New("link da transmissão deve usar https")
+	}
+	if in.Titulo == "" {
+		in.Titulo = TituloPadrao(in.Tipo, in.Numero)
+	}
+	return nil
+}
+
+// TituloPadrao monta o título usual da sessão ("12ª Sessão Ordinária").
+func TituloPadrao(tipo string, numero int) string {
+	nome := map[string]string{
+		TipoOrdinaria:        "Sessão Ordinária",
+		TipoExtraordinaria:   "Sessão Extraordinária",
+		TipoSolene:           "Sessão Solene",
+		TipoAudienciaPublica: "Audiência Pública",
+	}[tipo]
+	if numero <= 0 {
+		return nome
+	}
+	return strconv.Itoa(numero) + "ª " + nome
+}
+
+// AtaInput registra a ata da sessão.
+type AtaInput struct {
+	Resumo     *string
+	AprovadaEm *time.Time
+}
+
+// ItemPauta é uma proposição na ordem do dia, com o resultado quando deliberada.
+type ItemPauta struct {
+	ID         uuid.UUID `json:"id"`
+	SessaoID   uuid.UUID `json:"sessao_id"`
+	Ordem      int       `json:"ordem"`
+	Tipo       string    `json:"tipo"`
+	Numero     *string   `json:"numero,omitempty"`
+	Ementa     string    `json:"ementa"`
+	Autor      *string   `json:"autor,omitempty"`
+	Resultado  *string   `json:"resultado,omitempty"`
+	VotosSim   *int      `json:"votos_sim,omitempty"`
+	VotosNao   *int      `json:"votos_nao,omitempty"`
+	Abstencoes *int      `json:"abstencoes,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ItemInput contém os campos editáveis do item de pauta.
+type ItemInput struct {
+	Ordem      int
+	Tipo       string
+	Numero     *string
+	Ementa     string
+	Autor      *string
+	Resultado  *string
+	VotosSim   *int
+	VotosNao   *int
+	Abstencoes *int
+}
+
+// Normalize limpa e valida a entrada.
+func (in *ItemInput) Normalize() error {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Ementa = strings.TrimSpace(in.Ementa)
+	in.Numero = trimOptional(in.Numero)
+	in.Autor = trimOptional(in.Autor)
+	in.Resultado = trimOptional(in.Resultado)
+	if in.Resultado != nil {
+		resultado := strings.ToLower(*in.Resultado)
+		in.Resultado = &resultado
+	}
+	if in.Tipo == "" {
+		in.Tipo = ItemOutro
+	}
+	switch {
+	case !valido(in.Tipo, ItemProjetoLei, ItemRequerimento, ItemIndicacao, ItemMocao, ItemVeto, ItemOutro):
+		return errors.New("tipo de proposição inválido")
+	case in.Ementa == "":
+		return errors.New("ementa obrigatória")
+	case in.Resultado != nil && !valido(*in.Resultado, ResultadoAprovado, ResultadoRejeitado, ResultadoAdiado, ResultadoRetirado):
+		return errors.New("resultado inválido")
+	}
+	for _, votos := range []*int{in.VotosSim, in.VotosNao, in.Abstencoes} {
+		if votos != nil && *votos < 0 {
+			return errors.New("contagem de votos inválida")
+		}
+	}
+	return nil
+}
+
+// Documento é um arquivo público anexado à sessão.
+type Documento struct {
+	ID          uuid.UUID  `json:"id"`
+	SessaoID    uuid.UUID  `json:"sessao_id"`
+	ItemID      *uuid.UUID `json:"item_id,omitempty"`
+	Tipo        string     `json:"tipo"`
+	Titulo      string     `json:"titulo"`
+	URL         string     `json:"url"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DocumentoInput descreve o arquivo já enviado ao armazenamento.
+type DocumentoInput struct {
+	ItemID      *uuid.UUID
+	Tipo        string
+	Titulo      string
+	URL         string
+	FileKey     string
+	ContentType string
+	SizeBytes   int64
+	UploadedBy  *uuid.UUID
+}
+
+// NormalizeTipoDocumento valida o tipo do documento; vazio vira "outro".
+func NormalizeTipoDocumento(raw string) (string, error) {
+	tipo := strings.ToLower(strings.TrimSpace(raw))
+	if tipo == "" {
+		return DocumentoOutro, nil
+	}
+	if !valido(tipo, DocumentoPauta, DocumentoAta, DocumentoProjeto, DocumentoParecer, DocumentoOutro) {
+		return "", errors.New("tipo de documento inválido")
+	}
+	return tipo, nil
+}
+
+// Filter restringe a listagem de sessões.
+type Filter struct {
+	Ano    int
+	Tipo   string
+	Status string
+	// Busca procura no título da sessão e na ementa, número e autor das proposições.
+	Busca string
+	// Publicadas limita às sessões visíveis ao público.
+	Publicadas bool
+	Limit      int
+}
+
+func valido(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	if v := strings.TrimSpace(*value); v != "" {
+		return &v
+	}
+	return nil
+}
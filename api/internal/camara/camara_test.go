@@ -0,0 +1,42 @@
+package camara
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessaoInputNormalize(t *testing.T) {
+	in := SessaoInput{Numero: 12, Inicio: time.Date(2026, 3, 10, 19, 0, 0, 0, time.UTC), Local: " Plenário "}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if in.Tipo != TipoOrdinaria || in.Status != StatusAgendada {
+		t.Fatalf("padrões inesperados: %q %q", in.Tipo, in.Status)
+	}
+	if in.Titulo != "12ª Sessão Ordinária" || in.Local != "Plenário" {
+		t.Fatalf("título/local inesperados: %q %q", in.Titulo, in.Local)
+	}
+
+	link := "http://youtube.com/live"
+	in = SessaoInput{Inicio: time.Now(), Local: "Plenário", TransmissaoURL: &link}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro para transmissão sem https")
+	}
+}
+
+func TestItemInputNormalize(t *testing.T) {
+	resultado := " Aprovado "
+	in := ItemInput{Ementa: " Denomina rua ", Resultado: &resultado}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if in.Tipo != ItemOutro || *in.Resultado != ResultadoAprovado {
+		t.Fatalf("normalização inesperada: %q %q", in.Tipo, *in.Resultado)
+	}
+
+	negativo := -1
+	in = ItemInput{Ementa: "x", VotosSim: &negativo}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro para votos negativos")
+	}
+}
@@ -0,0 +1,344 @@
+package camara
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const sessaoColumns = `s.id, s.tenant_id, s.tipo, s.numero, s.titulo, s.inicio, s.local, s.status, s.transmissao_url,
+        s.publicada, s.publicada_em, s.ata_resumo, s.ata_aprovada_em,
+        (SELECT count(*) FROM camara_pauta_itens i WHERE i.sessao_id = s.id)::int,
+        s.created_at, s.updated_at`
+
+const itemColumns = `i.id, i.sessao_id, i.ordem, i.tipo, i.numero, i.ementa, i.autor, i.resultado, i.votos_sim, i.votos_nao,
+        i.abstencoes, i.created_at`
+
+const documentoColumns = `d.id, d.sessao_id, d.item_id, d.tipo, d.titulo, d.file_url, d.content_type, d.size_bytes, d.created_at`
+
+// Repository persiste sessões, pauta e documentos da câmara.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// List lista as sessões da mais recente à mais antiga, sem pauta nem documentos.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, filter Filter) ([]Sessao, error) {
+	query := `SELECT ` + sessaoColumns + ` FROM camara_sessoes s WHERE s.tenant_id = $1`
+	args := []any{tenantID}
+	add := func(cond string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+cond, len(args))
+	}
+	if filter.Publicadas {
+		query += " AND s.publicada"
+	}
+	if filter.Ano > 0 {
+		add("extract(year FROM s.inicio AT TIME ZONE 'America/Sao_Paulo') = $%d", filter.Ano)
+	}
+	if filter.Tipo != "" {
+		add("s.tipo = $%d", filter.Tipo)
+	}
+	if filter.Status != "" {
+		add("s.status = $%d", filter.Status)
+	}
+	if filter.Busca != "" {
+		args = append(args, "%"+filter.Busca+"%")
+		n := len(args)
+		query += fmt.Sprintf(` AND (s.titulo ILIKE $%d OR EXISTS (
+            SELECT 1 FROM camara_pauta_itens i
+            WHERE i.sessao_id = s.id AND (i.ementa ILIKE $%d OR i.numero ILIKE $%d OR i.autor ILIKE $%d)))`, n, n, n, n)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY s.inicio DESC LIMIT $%d", len(args))
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Sessao, error) {
+		s, err := scanSessao(row)
+		if err != nil {
+			return Sessao{}, err
+		}
+		return *s, nil
+	})
+}
+
+// Get busca a sessão com a pauta e os documentos; publica esconde as não publicadas.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID, publica bool) (*Sessao, error) {
+	s, err := scanSessao(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+sessaoColumns+`
+        FROM camara_sessoes s
+        WHERE s.id = $1 AND s.tenant_id = $2 AND (NOT $3 OR s.publicada)`, id, tenantID, publica))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+itemColumns+`
+        FROM camara_pauta_itens i
+        WHERE i.sessao_id = $1
+        ORDER BY i.ordem, i.created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	s.Pauta, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (ItemPauta, error) {
+		i, err := scanItem(row)
+		if err != nil {
+			return ItemPauta{}, err
+		}
+		return *i, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows, err = r.conn(ctx).Query(ctx, `
+        SELECT `+documentoColumns+`
+        FROM camara_documentos d
+        WHERE d.sessao_id = $1
+        ORDER BY d.created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	s.Documentos, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Documento, error) {
+		d, err := scanDocumento(row)
+		if err != nil {
+			return Documento{}, err
+		}
+		return *d, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create cadastra a sessão ainda não publicada.
+func (r *Repository) Create(ctx context.Context, tenantID uuid.UUID, in SessaoInput, createdBy *uuid.UUID) (*Sessao, error) {
+	var id uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO camara_sessoes (tenant_id, tipo, numero, titulo, inicio, local, status, transmissao_url, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id
+    `, tenantID, in.Tipo, in.Numero, in.Titulo, in.Inicio, in.Local, in.Status, in.TransmissaoURL, createdBy).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// Update altera a sessão; publicada ou não, para registrar adiamento, cancelamento ou realização.
+func (r *Repository) Update(ctx context.Context, tenantID, id uuid.UUID, in SessaoInput) (*Sessao, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE camara_sessoes
+        SET tipo = $3, numero = $4, titulo = $5, inicio = $6, local = $7, status = $8, transmissao_url = $9,
+            updated_at = now()
+        WHERE id = $1 AND tenant_id = $2
+    `, id, tenantID, in.Tipo, in.Numero, in.Titulo, in.Inicio, in.Local, in.Status, in.TransmissaoURL)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// Delete remove a sessão ainda não publicada; publicada, ela fica no histórico como cancelada.
+func (r *Repository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        DELETE FROM camara_sessoes WHERE id = $1 AND tenant_id = $2 AND NOT publicada
+    `, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.Get(ctx, tenantID, id, false); err != nil {
+			return err
+		}
+		return ErrPublicada
+	}
+	return nil
+}
+
+// Publicar torna a sessão visível na API pública; repetir a chamada mantém a data original.
+func (r *Repository) Publicar(ctx context.Context, tenantID, id uuid.UUID) (*Sessao, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE camara_sessoes
+        SET publicada = TRUE, publicada_em = COALESCE(publicada_em, now()), updated_at = now()
+        WHERE id = $1 AND tenant_id = $2
+    `, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// RegistrarAta grava o resumo e a data de aprovação da ata; o arquivo vai como documento do tipo ata.
+func (r *Repository) RegistrarAta(ctx context.Context, tenantID, id uuid.UUID, in AtaInput) (*Sessao, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE camara_sessoes SET ata_resumo = $3, ata_aprovada_em = $4, updated_at = now()
+        WHERE id = $1 AND tenant_id = $2
+    `, id, tenantID, trimOptional(in.Resumo), in.AprovadaEm)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// CreateItem inclui uma proposição na pauta da sessão.
+func (r *Repository) CreateItem(ctx context.Context, tenantID, sessaoID uuid.UUID, in ItemInput) (*ItemPauta, error) {
+	i, err := scanItem(r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO camara_pauta_itens AS i (sessao_id, ordem, tipo, numero, ementa, autor, resultado, votos_sim, votos_nao, abstencoes)
+        SELECT s.id, $3, $4, $5, $6, $7, $8, $9, $10, $11
+        FROM camara_sessoes s
+        WHERE s.id = $1 AND s.tenant_id = $2
+        RETURNING `+itemColumns,
+		sessaoID, tenantID, in.Ordem, in.Tipo, in.Numero, in.Ementa, in.Autor, in.Resultado, in.VotosSim, in.VotosNao, in.Abstencoes))
+	if errors.Is(err, ErrItemNotFound) {
+		return nil, ErrNotFound
+	}
+	return i, err
+}
+
+// UpdateItem altera o item de pauta, inclusive o resultado da deliberação.
+func (r *Repository) UpdateItem(ctx context.Context, tenantID, sessaoID, id uuid.UUID, in ItemInput) (*ItemPauta, error) {
+	return scanItem(r.conn(ctx).QueryRow(ctx, `
+        UPDATE camara_pauta_itens i
+        SET ordem = $4, tipo = $5, numero = $6, ementa = $7, autor = $8, resultado = $9, votos_sim = $10,
+            votos_nao = $11, abstencoes = $12
+        FROM camara_sessoes s
+        WHERE i.id = $1 AND i.sessao_id = $2 AND s.id = i.sessao_id AND s.tenant_id = $3
+        RETURNING `+itemColumns,
+		id, sessaoID, tenantID, in.Ordem, in.Tipo, in.Numero, in.Ementa, in.Autor, in.Resultado, in.VotosSim, in.VotosNao, in.Abstencoes))
+}
+
+// DeleteItem remove o item de pauta; documentos ligados a ele continuam na sessão.
+func (r *Repository) DeleteItem(ctx context.Context, tenantID, sessaoID, id uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        DELETE FROM camara_pauta_itens i
+        USING camara_sessoes s
+        WHERE i.id = $1 AND i.sessao_id = $2 AND s.id = i.sessao_id AND s.tenant_id = $3
+    `, id, sessaoID, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// ExisteSessao confirma que a sessão é do tenant e, com itemID, que o item pertence a ela; evita
+// enviar ao armazenamento um arquivo que não teria onde ficar.
+func (r *Repository) ExisteSessao(ctx context.Context, tenantID, sessaoID uuid.UUID, itemID *uuid.UUID) error {
+	var sessao, item bool
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM camara_sessoes WHERE id = $1 AND tenant_id = $2),
+               $3::uuid IS NULL OR EXISTS (SELECT 1 FROM camara_pauta_itens WHERE id = $3 AND sessao_id = $1)
+    `, sessaoID, tenantID, itemID).Scan(&sessao, &item); err != nil {
+		return err
+	}
+	switch {
+	case !sessao:
+		return ErrNotFound
+	case !item:
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// AddDocumento registra o arquivo já enviado ao armazenamento público.
+func (r *Repository) AddDocumento(ctx context.Context, tenantID, sessaoID uuid.UUID, in DocumentoInput) (*Documento, error) {
+	d, err := scanDocumento(r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO camara_documentos AS d (sessao_id, item_id, tipo, titulo, file_url, file_key, content_type, size_bytes, uploaded_by)
+        SELECT s.id, $3, $4, $5, $6, $7, $8, $9, $10
+        FROM camara_sessoes s
+        WHERE s.id = $1 AND s.tenant_id = $2
+          AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM camara_pauta_itens i WHERE i.id = $3 AND i.sessao_id = s.id))
+        RETURNING `+documentoColumns,
+		sessaoID, tenantID, in.ItemID, in.Tipo, in.Titulo, in.URL, in.FileKey, in.ContentType, in.SizeBytes, in.UploadedBy))
+	if errors.Is(err, ErrDocumentoNotFound) {
+		if err := r.ExisteSessao(ctx, tenantID, sessaoID, in.ItemID); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// DeleteDocumento retira o documento da sessão; o objeto no armazenamento não é apagado.
+func (r *Repository) DeleteDocumento(ctx context.Context, tenantID, sessaoID, id uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        DELETE FROM camara_documentos d
+        USING camara_sessoes s
+        WHERE d.id = $1 AND d.sessao_id = $2 AND s.id = d.sessao_id AND s.tenant_id = $3
+    `, id, sessaoID, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDocumentoNotFound
+	}
+	return nil
+}
+
+func scanSessao(row pgx.Row) (*Sessao, error) {
+	var s Sessao
+	if err := row.Scan(&s.ID, &s.TenantID, &s.Tipo, &s.Numero, &s.Titulo, &s.Inicio, &s.Local, &s.Status, &s.TransmissaoURL,
+		&s.Publicada, &s.PublicadaEm, &s.AtaResumo, &s.AtaAprovadaEm, &s.Itens, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanItem(row pgx.Row) (*ItemPauta, error) {
+	var i ItemPauta
+	if err := row.Scan(&i.ID, &i.SessaoID, &i.Ordem, &i.Tipo, &i.Numero, &i.Ementa, &i.Autor, &i.Resultado, &i.VotosSim,
+		&i.VotosNao, &i.Abstencoes, &i.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+	return &i, nil
+}
+
+func scanDocumento(row pgx.Row) (*Documento, error) {
+	var d Documento
+	if err := row.Scan(&d.ID, &d.SessaoID, &d.ItemID, &d.Tipo, &d.Titulo, &d.URL, &d.ContentType, &d.SizeBytes,
+		&d.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDocumentoNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
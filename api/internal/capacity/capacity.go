@@ -0,0 +1,125 @@
+// Package capacity calcula a ocupação da equipe SaaS em uma janela de datas a partir de
+// férias, afastamentos e alocações em projetos.
+package capacity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindVacation   = "vacation"
+	KindLeave      = "leave"
+	KindAllocation = "allocation"
+
+	// DefaultLeadPct é a alocação presumida de quem lidera um projeto sem alocação explícita.
+	DefaultLeadPct = 50
+	// MaxWindowDays limita a janela avaliada para evitar varreduras longas.
+	MaxWindowDays = 366
+)
+
+// Kinds lista os tipos aceitos no registro de disponibilidade.
+var Kinds = []string{KindVacation, KindLeave, KindAllocation}
+
+// ValidKind informa se o tipo é aceito.
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Entry é um período de indisponibilidade ou alocação. Datas são inclusivas.
+type Entry struct {
+	Kind      string     `json:"kind"`
+	StartsOn  time.Time  `json:"starts_on"`
+	EndsOn    time.Time  `json:"ends_on"`
+	Percent   float64    `json:"allocation_pct"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	Label     string     `json:"label,omitempty"`
+}
+
+// TimeOff informa se o período representa ausência integral.
+func (e Entry) TimeOff() bool {
+	return e.Kind == KindVacation || e.Kind == KindLeave
+}
+
+// Assessment resume a ocupação de uma pessoa na janela avaliada.
+type Assessment struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	PeakPct       float64   `json:"peak_pct"`
+	PeakDay       time.Time `json:"peak_day"`
+	AveragePct    float64   `json:"average_pct"`
+	TimeOffDays   int       `json:"time_off_days"`
+	OverAllocated bool      `json:"over_allocated"`
+	Warnings      []string  `json:"warnings"`
+}
+
+// Day normaliza o instante para meia-noite UTC.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Window normaliza a janela [from, to], invertendo datas trocadas e limitando a MaxWindowDays.
+func Window(from, to time.Time) (time.Time, time.Time) {
+	from, to = Day(from), Day(to)
+	if to.Before(from) {
+		from, to = to, from
+	}
+	if limit := from.AddDate(0, 0, MaxWindowDays-1); to.After(limit) {
+		to = limit
+	}
+	return from, to
+}
+
+// Assess soma as alocações dia a dia na janela. Dias de férias ou afastamento contam como
+// 100% ocupados; o pico acima de 100% marca a pessoa como sobrealocada.
+func Assess(entries []Entry, from, to time.Time) Assessment {
+	from, to = Window(from, to)
+	result := Assessment{From: from, To: to, PeakDay: from, Warnings: []string{}}
+
+	days := 0
+	total := 0.0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days++
+		load := 0.0
+		off := false
+		for _, e := range entries {
+			if day.Before(Day(e.StartsOn)) || day.After(Day(e.EndsOn)) {
+				continue
+			}
+			if e.TimeOff() {
+				off = true
+				continue
+			}
+			load += e.Percent
+		}
+		if off {
+			result.TimeOffDays++
+			load += 100
+		}
+		total += load
+		if load > result.PeakPct {
+			result.PeakPct = load
+			result.PeakDay = day
+		}
+	}
+	if days > 0 {
+		result.AveragePct = total / float64(days)
+	}
+
+	if result.TimeOffDays > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d dia(s) de férias ou afastamento na janela", result.TimeOffDays))
+	}
+	if result.PeakPct > 100 {
+		result.OverAllocated = true
+		result.Warnings = append(result.Warnings, fmt.Sprintf("alocação de %.0f%% em %s", result.PeakPct, result.PeakDay.Format("2006-01-02")))
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestAssessOverAllocation(t *testing.T) {
+	entries := []Entry{
+		{Kind: KindAllocation, StartsOn: date("2026-03-01"), EndsOn: date("2026-03-31"), Percent: 60},
+		{Kind: KindAllocation, StartsOn: date("2026-03-10"), EndsOn: date("2026-03-12"), Percent: 50},
+	}
+	got := Assess(entries, date("2026-03-01"), date("2026-03-31"))
+	if !got.OverAllocated || got.PeakPct != 110 {
+		t.Fatalf("esperava pico de 110%%, obtido %+v", got)
+	}
+	if !got.PeakDay.Equal(date("2026-03-10")) {
+		t.Fatalf("dia de pico inesperado: %s", got.PeakDay)
+	}
+}
+
+func TestAssessTimeOff(t *testing.T) {
+	entries := []Entry{
+		{Kind: KindVacation, StartsOn: date("2026-07-01"), EndsOn: date("2026-07-05"), Percent: 100},
+	}
+	got := Assess(entries, date("2026-07-04"), date("2026-07-10"))
+	if got.TimeOffDays != 2 {
+		t.Fatalf("esperava 2 dias de ausência, obtido %d", got.TimeOffDays)
+	}
+	if got.OverAllocated || len(got.Warnings) != 1 {
+		t.Fatalf("avaliação inesperada: %+v", got)
+	}
+}
+
+func TestWindowLimits(t *testing.T) {
+	from, to := Window(date("2028-01-01"), date("2026-01-01"))
+	if !from.Equal(date("2026-01-01")) || !to.Equal(date("2027-01-01")) {
+		t.Fatalf("janela inesperada: %s – %s", from, to)
+	}
+}
@@ -0,0 +1,24 @@
+package changelog
+
+import "testing"
+
+func TestNormalizeDefaultsAudience(t *testing.T) {
+	in := Input{ModuleCode: " Educacao ", Version: "2.4.0", Title: "Boletim", Body: "Novo boletim em PDF"}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if in.ModuleCode != "educacao" || in.Audience != AudienceAll {
+		t.Fatalf("normalização inesperada: %+v", in)
+	}
+
+	in.Audience = "imprensa"
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro para público inválido")
+	}
+}
+
+func TestClampLimit(t *testing.T) {
+	if ClampLimit(0) != DefaultFeedLimit || ClampLimit(500) != MaxFeedLimit || ClampLimit(5) != 5 {
+		t.Fatal("limites do feed inesperados")
+	}
+}
@@ -0,0 +1,121 @@
+// Package changelog mantém as notas de versão por módulo do produto e os feeds exibidos
+// no backoffice e no app do cidadão.
+package changelog
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica nota de versão inexistente.
+	ErrNotFound = errors.New("changelog: não encontrado")
+	// ErrDuplicate indica versão já registrada para o módulo.
+	ErrDuplicate = errors.New("changelog: versão já registrada para o módulo")
+)
+
+// Públicos das notas; AudienceAll aparece nos dois feeds.
+const (
+	AudienceAll        = "all"
+	AudienceBackoffice = "backoffice"
+	AudienceCitizen    = "citizen"
+)
+
+// Status de publicação.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+// DefaultFeedLimit e MaxFeedLimit limitam o tamanho dos feeds.
+const (
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 100
+)
+
+// Note é uma nota de versão de um módulo.
+type Note struct {
+	ID          uuid.UUID  `json:"id"`
+	ModuleCode  string     `json:"module_code"`
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Audience    string     `json:"audience"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Input contém os campos editáveis da nota.
+type Input struct {
+	ModuleCode string
+	Version    string
+	Title      string
+	Body       string
+	Audience   string
+	ActorID    *uuid.UUID
+}
+
+// Filter restringe a listagem administrativa.
+type Filter struct {
+	ModuleCode string
+	Status     string
+}
+
+// FeedQuery define o feed de um tenant.
+type FeedQuery struct {
+	TenantID   uuid.UUID
+	Audience   string
+	ModuleCode string
+	Since      *time.Time
+	Limit      int
+}
+
+// ValidAudience informa se o público é aceito.
+func ValidAudience(audience string) bool {
+	switch audience {
+	case AudienceAll, AudienceBackoffice, AudienceCitizen:
+		return true
+	}
+	return false
+}
+
+// Normalize limpa e valida a entrada; público vazio vira AudienceAll.
+func (in *Input) Normalize() error {
+	in.ModuleCode = strings.ToLower(strings.TrimSpace(in.ModuleCode))
+	in.Version = strings.TrimSpace(in.Version)
+	in.Title = strings.TrimSpace(in.Title)
+	in.Body = strings.TrimSpace(in.Body)
+	in.Audience = strings.ToLower(strings.TrimSpace(in.Audience))
+	if in.Audience == "" {
+		in.Audience = AudienceAll
+	}
+	switch {
+	case in.ModuleCode == "":
+		return errors.New("módulo obrigatório")
+	case in.Version == "":
+		return errors.New("versão obrigatória")
+	case in.Title == "":
+		return errors.New("título obrigatório")
+	case in.Body == "":
+		return errors.New("texto obrigatório")
+	case !ValidAudience(in.Audience):
+		return errors.New("público inválido")
+	}
+	return nil
+}
+
+// ClampLimit aplica os limites do feed.
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultFeedLimit
+	}
+	if limit > MaxFeedLimit {
+		return MaxFeedLimit
+	}
+	return limit
+}
@@ -0,0 +1,139 @@
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const noteColumns = `id, module_code, version, title, body, audience, status, published_at, created_at, updated_at`
+
+// Repository provê acesso às notas de versão.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// List devolve as notas para a equipe SaaS, incluindo rascunhos.
+func (r *Repository) List(ctx context.Context, filter Filter) ([]Note, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+noteColumns+`
+        FROM saas_release_notes
+        WHERE ($1 = '' OR module_code = $1)
+          AND ($2 = '' OR status = $2)
+        ORDER BY COALESCE(published_at, created_at) DESC
+    `, strings.ToLower(strings.TrimSpace(filter.ModuleCode)), strings.TrimSpace(filter.Status))
+	if err != nil {
+		return nil, err
+	}
+	return collectNotes(rows)
+}
+
+// Feed devolve as notas publicadas visíveis ao tenant: módulos fora do contrato ficam ocultos
+// quando o contrato define módulos, como na base de conhecimento.
+func (r *Repository) Feed(ctx context.Context, q FeedQuery) ([]Note, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+noteColumns+`
+        FROM saas_release_notes n
+        WHERE n.status = 'published'
+          AND n.audience IN ('all', $2)
+          AND ($3 = '' OR n.module_code = $3)
+          AND ($4::timestamptz IS NULL OR n.published_at > $4)
+          AND (NOT EXISTS (SELECT 1 FROM saas_tenant_contract_modules m WHERE m.tenant_id = $1)
+               OR EXISTS (SELECT 1 FROM saas_tenant_contract_modules m WHERE m.tenant_id = $1 AND m.module_code = n.module_code AND m.enabled))
+        ORDER BY n.published_at DESC
+        LIMIT $5
+    `, q.TenantID, q.Audience, strings.ToLower(strings.TrimSpace(q.ModuleCode)), q.Since, ClampLimit(q.Limit))
+	if err != nil {
+		return nil, err
+	}
+	return collectNotes(rows)
+}
+
+// Create insere nota como rascunho.
+func (r *Repository) Create(ctx context.Context, in Input) (*Note, error) {
+	row := r.pool.QueryRow(ctx, `
+        INSERT INTO saas_release_notes (module_code, version, title, body, audience, created_by, updated_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        RETURNING `+noteColumns,
+		in.ModuleCode, in.Version, in.Title, in.Body, in.Audience, in.ActorID)
+	return scanNote(row)
+}
+
+// Update substitui os campos editáveis da nota.
+func (r *Repository) Update(ctx context.Context, id uuid.UUID, in Input) (*Note, error) {
+	row := r.pool.QueryRow(ctx, `
+        UPDATE saas_release_notes
+        SET module_code = $2, version = $3, title = $4, body = $5, audience = $6, updated_by = $7, updated_at = now()
+        WHERE id = $1
+        RETURNING `+noteColumns,
+		id, in.ModuleCode, in.Version, in.Title, in.Body, in.Audience, in.ActorID)
+	return scanNote(row)
+}
+
+// SetPublished publica (mantendo a data original, se houver) ou volta a nota para rascunho.
+func (r *Repository) SetPublished(ctx context.Context, id uuid.UUID, published bool, actorID *uuid.UUID) (*Note, error) {
+	status := StatusDraft
+	if published {
+		status = StatusPublished
+	}
+	row := r.pool.QueryRow(ctx, `
+        UPDATE saas_release_notes
+        SET status = $2,
+            published_at = CASE WHEN $2 = 'published' THEN COALESCE(published_at, now()) ELSE NULL END,
+            updated_by = $3,
+            updated_at = now()
+        WHERE id = $1
+        RETURNING `+noteColumns, id, status, actorID)
+	return scanNote(row)
+}
+
+// Delete remove a nota.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM saas_release_notes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func collectNotes(rows pgx.Rows) ([]Note, error) {
+	defer rows.Close()
+	notes := make([]Note, 0)
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, *note)
+	}
+	return notes, rows.Err()
+}
+
+func scanNote(row pgx.Row) (*Note, error) {
+	var n Note
+	if err := row.Scan(&n.ID, &n.ModuleCode, &n.Version, &n.Title, &n.Body, &n.Audience, &n.Status, &n.PublishedAt, &n.CreatedAt, &n.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("changelog: %w", err)
+	}
+	return &n, nil
+}
@@ -137,6 +137,36 @@ func (c *Client) CheckCNAMEPropagation(ctx context.Context, fqdn, expected strin
 	return false, nil
 }
 
+// maxPurgeFiles é o limite de URLs por chamada de purge por arquivo.
+const maxPurgeFiles = 30
+
+// PurgeFiles invalida no cache da zona as URLs informadas, em lotes do tamanho aceito pela API.
+func (c *Client) PurgeFiles(ctx context.Context, files []string) error {
+	endpoint := fmt.Sprintf("%s/zones/%s/purge_cache", c.baseURL, c.zoneID)
+	for start := 0; start < len(files); start += maxPurgeFiles {
+		end := start + maxPurgeFiles
+		if end > len(files) {
+			end = len(files)
+		}
+		req, err := c.newRequest(ctx, http.MethodPost, endpoint, map[string]any{"files": files[start:end]})
+		if err != nil {
+			return err
+		}
+
+		var resp struct {
+			Success bool       `json:"success"`
+			Errors  []apiError `json:"errors"`
+		}
+		if err := c.do(req, &resp); err != nil {
+			return err
+		}
+		if !resp.Success {
+			return joinAPIErrors(resp.Errors)
+		}
+	}
+	return nil
+}
+
 func (c *Client) createRecord(ctx context.Context, name, target string, proxied bool, ttl int) (string, error) {
 	endpoint := fmt.Sprintf("%s/zones/%s/dns_records", c.baseURL, c.zoneID)
 	body := map[string]any{
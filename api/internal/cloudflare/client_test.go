@@ -0,0 +1,65 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gestaozabele/municipio/internal/fakes"
+)
+
+func TestClientContract(t *testing.T) {
+	cf := fakes.NewCloudflare("token-teste", "zona1")
+	defer cf.Close()
+	client, err := New(Config{APIToken: "token-teste", ZoneID: "zona1", APIBase: cf.APIBase, DoHURL: cf.DoHURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	id, err := client.EnsureCNAME(ctx, "cabaceiras.cidade.app", "edge.cidade.app.", true, 0)
+	if err != nil {
+		t.Fatalf("criação: %v", err)
+	}
+	again, err := client.EnsureCNAME(ctx, "cabaceiras.cidade.app", "edge.cidade.app", true, 3600)
+	if err != nil || again != id {
+		t.Fatalf("repetição deveria reaproveitar %s: %s %v", id, again, err)
+	}
+	if _, err := client.EnsureCNAME(ctx, "cabaceiras.cidade.app", "novo.cidade.app", false, 300); err != nil {
+		t.Fatalf("atualização: %v", err)
+	}
+	records := cf.Records()
+	if len(records) != 1 || records[0].Content != "novo.cidade.app" || records[0].Proxied || records[0].TTL != 300 {
+		t.Fatalf("registros inesperados: %+v", records)
+	}
+
+	if ok, err := client.CheckCNAMEPropagation(ctx, "cabaceiras.cidade.app", "novo.cidade.app"); err != nil || !ok {
+		t.Fatalf("propagação = %v, %v", ok, err)
+	}
+	cf.SetPropagated(false)
+	if ok, _ := client.CheckCNAMEPropagation(ctx, "cabaceiras.cidade.app", "novo.cidade.app"); ok {
+		t.Fatal("propagação pendente não deveria confirmar")
+	}
+
+	files := make([]string, 65)
+	for i := range files {
+		files[i] = fmt.Sprintf("https://cabaceiras.cidade.app/%d", i)
+	}
+	if err := client.PurgeFiles(ctx, files); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purges := cf.Purges(); len(purges) != 3 || len(purges[2]) != 5 {
+		t.Fatalf("lotes de purge inesperados: %d", len(purges))
+	}
+
+	cf.FailNext(http.StatusServiceUnavailable, 1)
+	if _, err := client.EnsureCNAME(ctx, "outra.cidade.app", "edge.cidade.app", true, 0); err == nil {
+		t.Fatal("esperava erro com a API fora")
+	}
+
+	wrong, _ := New(Config{APIToken: "outro", ZoneID: "zona1", APIBase: cf.APIBase, DoHURL: cf.DoHURL})
+	if _, err := wrong.EnsureCNAME(ctx, "x.cidade.app", "edge.cidade.app", true, 0); err == nil {
+		t.Fatal("token inválido deveria falhar")
+	}
+}
@@ -2,18 +2,26 @@ package config
 
 import (
 	"errors"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/gestaozabele/municipio/internal/db"
 )
 
 // Config centraliza a configuração carregada do ambiente.
 type Config struct {
-	Port             int
-	DBDSN            string
+	Port  int
+	DBDSN string
+	// DBClusters são os bancos adicionais (DB_CLUSTERS) para onde tenants podem ser movidos;
+	// o banco de DBDSN é sempre o cluster "primary".
+	DBClusters map[string]string
+	// DBRoutingTTL é por quanto tempo a API guarda em cache o cluster de cada tenant.
+	DBRoutingTTL     time.Duration
 	RedisURL         string
 	JWTAccessTTL     time.Duration
 	JWTRefreshTTL    time.Duration
@@ -28,6 +36,174 @@ type Config struct {
 	Cloudflare       CloudflareConfig
 	SaaSInviteTTL    time.Duration
 	Monitoring       MonitoringConfig
+	Finance          FinanceConfig
+	ESign            ESignConfig
+	Chamada          ChamadaConfig
+	Mensagens        MensagensConfig
+	Avaliacoes       AvaliacoesConfig
+	DBPool           DBPoolConfig
+	Metrics          MetricsConfig
+	Partitions       PartitionConfig
+	Retention        RetentionConfig
+	LoadShed         LoadShedConfig
+	Edge             EdgeConfig
+	Antivirus        AntivirusConfig
+	Mail             MailConfig
+	SupportEmail     SupportEmailConfig
+	OpenData         OpenDataConfig
+	Audit            AuditConfig
+	IBGE             IBGEConfig
+	Address          AddressConfig
+	Procurement      ProcurementConfig
+	Estoque          EstoqueConfig
+	Eventos          EventosConfig
+	Documentos       DocumentosConfig
+	Consultas        ConsultasConfig
+	ErrorTracking    ErrorTrackingConfig
+}
+
+// ErrorTrackingConfig liga o envio de erros a um servidor Sentry ou GlitchTip; sem DSN fica desligado.
+type ErrorTrackingConfig struct {
+	DSN         string
+	Environment string
+	Release     string
+	SampleRate  float64
+}
+
+// DBPoolConfig dimensiona o pool do Postgres e o modo de cache de statements.
+type DBPoolConfig struct {
+	MaxConns               int
+	MinConns               int
+	MaxConnLifetime        time.Duration
+	MaxConnIdleTime        time.Duration
+	HealthCheckPeriod      time.Duration
+	StatementCacheMode     string
+	StatementCacheCapacity int
+}
+
+// MetricsConfig protege o endpoint /metrics; sem token ele fica aberto para o scraper interno.
+type MetricsConfig struct {
+	Token string
+}
+
+// MailConfig descreve o provedor de e-mail de saída (smtp, sendgrid ou ses); sem credenciais do
+// provedor o envio fica desligado. WorkerInterval é a cadência da fila de envio, PanelURL a base
+// dos links enviados aos administradores do SaaS e BackofficeURL a dos convites à equipe municipal.
+type MailConfig struct {
+	Provider           string
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	From               string
+	SendGridAPIKey     string
+	SESRegion          string
+	SESAccessKeyID     string
+	SESSecretAccessKey string
+	WorkerInterval     time.Duration
+	PanelURL           string
+	BackofficeURL      string
+}
+
+// SupportEmailConfig liga os chamados ao e-mail: Address recebe (com +tag) e responde,
+// MailgunSigningKey valida a rota do Mailgun e InboundToken protege o webhook do SES.
+type SupportEmailConfig struct {
+	Address           string
+	MailgunSigningKey string
+	InboundToken      string
+}
+
+// AntivirusConfig aponta para o clamd que varre notas fiscais enviadas; sem endereço a varredura é pulada.
+type AntivirusConfig struct {
+	Address string
+	Timeout time.Duration
+}
+
+// EdgeConfig guarda a chave que os workers de borda usam para baixar o manifesto de tenants;
+// vazia, o manifesto fica desligado.
+type EdgeConfig struct {
+	ManifestToken string
+}
+
+// OpenDataConfig define a cada quanto os dados abertos dos tenants são regerados; zero desliga.
+type OpenDataConfig struct {
+	Interval time.Duration
+}
+
+// AuditConfig define a cada quanto o hash da trilha de auditoria é ancorado no armazenamento de
+// objetos; zero desliga.
+type AuditConfig struct {
+	AnchorInterval time.Duration
+}
+
+// IBGEConfig controla a consulta de código, região e população do município na criação do tenant.
+type IBGEConfig struct {
+	Enabled bool
+	APIBase string
+}
+
+// AddressConfig aponta os provedores de CEP e o tempo de cache das consultas no Redis.
+type AddressConfig struct {
+	ViaCEPBase    string
+	BrasilAPIBase string
+	CacheTTL      time.Duration
+}
+
+// ProcurementConfig controla os alertas de vencimento de empenhos e demais documentos da licitação;
+// intervalo zero desliga.
+type ProcurementConfig struct {
+	AlertInterval time.Duration
+	ExpiryWindow  time.Duration
+}
+
+// EstoqueConfig controla a verificação de itens do almoxarifado abaixo do estoque mínimo;
+// intervalo zero desliga.
+type EstoqueConfig struct {
+	AlertInterval time.Duration
+}
+
+// EventosConfig controla o envio de lembretes e avisos de vaga confirmada aos inscritos em
+// eventos; intervalo zero desliga.
+type EventosConfig struct {
+	ReminderInterval time.Duration
+}
+
+// DocumentosConfig guarda a semente Ed25519 (32 bytes em base64) que assina os documentos
+// emitidos; vazia, a semente é derivada do JWT_SECRET.
+type DocumentosConfig struct {
+	SigningKey string
+}
+
+// ConsultasConfig guarda o segredo dos hashes de eleitor e origem nas consultas públicas; vazio,
+// usa o JWT_SECRET. Trocá-lo durante uma votação permitiria ao mesmo CPF votar de novo.
+type ConsultasConfig struct {
+	HashKey string
+}
+
+// PartitionConfig controla a manutenção das partições mensais de presenças e logs de acesso.
+// Retenção zero mantém as partições indefinidamente; a dos logs de acesso vem de RetentionConfig.
+type PartitionConfig struct {
+	Interval                 time.Duration
+	PremakeMonths            int
+	PresencasRetentionMonths int
+}
+
+// RetentionConfig define por classe de dado quanto tempo guardar; zero desliga o expurgo da classe.
+type RetentionConfig struct {
+	Interval                time.Duration
+	AccessLogMonths         int
+	RevokedRefreshTokenDays int
+	ExpiredRefreshTokenDays int
+	MonitorReadingDays      int
+	MonitorAlertDays        int
+}
+
+// LoadShedConfig define quando a API passa a descartar tráfego de baixa prioridade.
+type LoadShedConfig struct {
+	Enabled        bool
+	P95Threshold   time.Duration
+	PoolSaturation float64
+	RetryAfter     time.Duration
 }
 
 // StorageConfig descreve provedor padrão de blobs.
@@ -39,6 +215,17 @@ type StorageConfig struct {
 	S3AccessKey string
 	S3SecretKey string
 	S3PublicURL string
+	// LocalDir, LocalBaseURL e LocalSigningSecret configuram o provedor "local", para servidores
+	// da própria prefeitura; LocalPublicPrefixes são as chaves servidas sem link assinado.
+	LocalDir            string
+	LocalBaseURL        string
+	LocalSigningSecret  string
+	LocalPublicPrefixes []string
+	GCSBucket           string
+	GCSCredentialsFile  string
+	GCSPublicURL        string
+	// SignedURLTTL é a validade das URLs pré-assinadas entregues pelo proxy de arquivos privados.
+	SignedURLTTL time.Duration
 }
 
 // CloudflareConfig concentra integração com API da Cloudflare.
@@ -62,6 +249,72 @@ type MonitoringConfig struct {
 	ErrorRateWarn   float64
 	LatencyCritical time.Duration
 	ErrorRateCrit   float64
+	// Detector estatístico (EWMA) que alerta desvios antes dos limites fixos.
+	AnomalyAlpha     float64
+	AnomalyThreshold float64
+	AnomalyWarmup    int
+	// Revalidação de DNS/HTTP dos domínios dos tenants ativos; intervalo zero desativa.
+	DomainInterval    time.Duration
+	DomainConcurrency int
+	// Dependências externas verificadas por HTTP a cada coleta (gateway de pagamento, FCM...),
+	// no formato MONITORING_DEPENDENCY_URLS="pagamentos=https://...,fcm=https://...".
+	DependencyURLs map[string]string
+}
+
+// FinanceConfig define regras do módulo financeiro do SaaS.
+type FinanceConfig struct {
+	// ApprovalThreshold é o valor (em BRL) acima do qual lançamentos exigem aprovação do owner.
+	// Zero ou negativo desativa o fluxo de aprovação.
+	ApprovalThreshold float64
+}
+
+// ChamadaConfig controla o job que lembra professores de chamadas não registradas.
+type ChamadaConfig struct {
+	NudgeEnabled  bool
+	NudgeInterval time.Duration
+	// NudgeHour é a hora local a partir da qual as aulas do dia sem chamada geram lembrete.
+	NudgeHour int
+	Timezone  string
+	// AttestationSecret assina os tokens de atestação de dispositivo aceitos na chamada do app.
+	AttestationSecret string
+}
+
+// MensagensConfig configura os provedores de SMS e WhatsApp usados nos avisos às famílias.
+// SMSProvider aceita "twilio"; WhatsAppProvider aceita "twilio" ou "meta" (WhatsApp Business
+// Cloud API). Sem provedor o canal fica desligado e os avisos desse canal não saem da fila.
+type MensagensConfig struct {
+	SMSProvider        string
+	WhatsAppProvider   string
+	TwilioAccountSID   string
+	TwilioAuthToken    string
+	TwilioSMSFrom      string
+	TwilioWhatsAppFrom string
+	// WhatsAppToken e WhatsAppPhoneNumberID vêm do app do WhatsApp Business na Meta.
+	WhatsAppToken         string
+	WhatsAppPhoneNumberID string
+	WhatsAppAPIBase       string
+	// WhatsAppTemplate é o modelo aprovado usado fora da janela de 24h; o texto do aviso vai como
+	// único parâmetro do corpo.
+	WhatsAppTemplate     string
+	WhatsAppTemplateLang string
+	WorkerInterval       time.Duration
+}
+
+// AvaliacoesConfig controla o fechamento automático das avaliações online: tentativas com prazo
+// vencido são entregues e corrigidas com as respostas já salvas.
+type AvaliacoesConfig struct {
+	EncerramentoInterval time.Duration
+}
+
+// ESignConfig configura o provedor de assinatura eletrônica de contratos.
+type ESignConfig struct {
+	Provider      string
+	APIBase       string
+	APIToken      string
+	CryptKey      string
+	SafeID        string
+	WebhookSecret string
+	WebhookURL    string
 }
 
 // RateLimitConfig representa limites simples para throttling.
@@ -90,6 +343,42 @@ func Load() (*Config, error) {
 	if cfg.DBDSN == "" {
 		return nil, errors.New("DB_DSN ou DATABASE_URL obrigatório")
 	}
+	cfg.DBClusters, err = db.ParseClusters(getEnv("DB_CLUSTERS", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.DBRoutingTTL, err = parseDurationEnv("DB_ROUTING_TTL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	maxConnLifetime, err := parseDurationEnv("DB_MAX_CONN_LIFETIME", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	maxConnIdle, err := parseDurationEnv("DB_MAX_CONN_IDLE_TIME", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	healthCheck, err := parseDurationEnv("DB_HEALTH_CHECK_PERIOD", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DBPool = DBPoolConfig{
+		MaxConns:               parseIntEnv("DB_MAX_CONNS", 10),
+		MinConns:               parseIntEnv("DB_MIN_CONNS", 1),
+		MaxConnLifetime:        maxConnLifetime,
+		MaxConnIdleTime:        maxConnIdle,
+		HealthCheckPeriod:      healthCheck,
+		StatementCacheMode:     strings.ToLower(strings.TrimSpace(getEnv("DB_STATEMENT_CACHE_MODE", "cache_statement"))),
+		StatementCacheCapacity: parseIntEnv("DB_STATEMENT_CACHE_CAPACITY", 512),
+	}
+	if cfg.DBPool.MaxConns <= 0 || cfg.DBPool.MinConns < 0 || cfg.DBPool.MinConns > cfg.DBPool.MaxConns {
+		return nil, errors.New("DB_MAX_CONNS/DB_MIN_CONNS inválidos")
+	}
+
+	cfg.Metrics = MetricsConfig{Token: strings.TrimSpace(getEnv("METRICS_TOKEN", ""))}
+	cfg.Edge = EdgeConfig{ManifestToken: strings.TrimSpace(getEnv("EDGE_MANIFEST_TOKEN", ""))}
 
 	cfg.RedisURL = getEnv("REDIS_URL", "")
 	if cfg.RedisURL == "" {
@@ -172,6 +461,16 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	domainInterval, err := parseDurationEnv("MONITORING_DOMAIN_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencyURLs, err := parseNamedURLsEnv("MONITORING_DEPENDENCY_URLS")
+	if err != nil {
+		return nil, err
+	}
+
 	errorRateWarn := parseFloatEnv("MONITORING_ERROR_RATE_WARN", 0.1)
 	errorRateCrit := parseFloatEnv("MONITORING_ERROR_RATE_CRIT", 0.3)
 
@@ -184,6 +483,108 @@ func Load() (*Config, error) {
 		ErrorRateWarn:   errorRateWarn,
 		LatencyCritical: latencyCrit,
 		ErrorRateCrit:   errorRateCrit,
+
+		AnomalyAlpha:     parseFloatEnv("MONITORING_ANOMALY_ALPHA", 0.1),
+		AnomalyThreshold: parseFloatEnv("MONITORING_ANOMALY_THRESHOLD", 3),
+		AnomalyWarmup:    parseIntEnv("MONITORING_ANOMALY_WARMUP", 30),
+
+		DomainInterval:    domainInterval,
+		DomainConcurrency: parseIntEnv("MONITORING_DOMAIN_CONCURRENCY", 8),
+		DependencyURLs:    dependencyURLs,
+	}
+
+	cfg.Finance = FinanceConfig{
+		ApprovalThreshold: parseFloatEnv("FINANCE_APPROVAL_THRESHOLD", 10000),
+	}
+
+	cfg.ESign = ESignConfig{
+		Provider:      strings.ToLower(strings.TrimSpace(getEnv("ESIGN_PROVIDER", ""))),
+		APIBase:       strings.TrimSpace(getEnv("ESIGN_API_URL", "")),
+		APIToken:      strings.TrimSpace(getEnv("ESIGN_API_TOKEN", "")),
+		CryptKey:      strings.TrimSpace(getEnv("ESIGN_CRYPT_KEY", "")),
+		SafeID:        strings.TrimSpace(getEnv("ESIGN_SAFE_ID", "")),
+		WebhookSecret: strings.TrimSpace(getEnv("ESIGN_WEBHOOK_SECRET", "")),
+		WebhookURL:    strings.TrimSpace(getEnv("ESIGN_WEBHOOK_URL", "")),
+	}
+
+	nudgeInterval, err := parseDurationEnv("CHAMADA_NUDGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	nudgeHour, err := strconv.Atoi(strings.TrimSpace(getEnv("CHAMADA_NUDGE_HOUR", "18")))
+	if err != nil || nudgeHour < 0 || nudgeHour > 23 {
+		return nil, errors.New("CHAMADA_NUDGE_HOUR inválido")
+	}
+
+	cfg.Chamada = ChamadaConfig{
+		NudgeEnabled:  strings.EqualFold(getEnv("CHAMADA_NUDGE_ENABLED", "false"), "true"),
+		NudgeInterval: nudgeInterval,
+		NudgeHour:     nudgeHour,
+		Timezone:      strings.TrimSpace(getEnv("CHAMADA_TIMEZONE", "America/Sao_Paulo")),
+	}
+	cfg.Chamada.AttestationSecret = strings.TrimSpace(getEnv("CHAMADA_ATTESTATION_SECRET", ""))
+
+	mensagensInterval, err := parseDurationEnv("MENSAGENS_WORKER_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Mensagens = MensagensConfig{
+		SMSProvider:           strings.ToLower(strings.TrimSpace(getEnv("SMS_PROVIDER", ""))),
+		WhatsAppProvider:      strings.ToLower(strings.TrimSpace(getEnv("WHATSAPP_PROVIDER", ""))),
+		TwilioAccountSID:      strings.TrimSpace(getEnv("TWILIO_ACCOUNT_SID", "")),
+		TwilioAuthToken:       strings.TrimSpace(getEnv("TWILIO_AUTH_TOKEN", "")),
+		TwilioSMSFrom:         strings.TrimSpace(getEnv("TWILIO_SMS_FROM", "")),
+		TwilioWhatsAppFrom:    strings.TrimSpace(getEnv("TWILIO_WHATSAPP_FROM", "")),
+		WhatsAppToken:         strings.TrimSpace(getEnv("WHATSAPP_TOKEN", "")),
+		WhatsAppPhoneNumberID: strings.TrimSpace(getEnv("WHATSAPP_PHONE_NUMBER_ID", "")),
+		WhatsAppAPIBase:       strings.TrimRight(strings.TrimSpace(getEnv("WHATSAPP_API_BASE", "https://graph.facebook.com/v19.0")), "/"),
+		WhatsAppTemplate:      strings.TrimSpace(getEnv("WHATSAPP_TEMPLATE", "")),
+		WhatsAppTemplateLang:  strings.TrimSpace(getEnv("WHATSAPP_TEMPLATE_LANG", "pt_BR")),
+		WorkerInterval:        mensagensInterval,
+	}
+
+	encerramentoInterval, err := parseDurationEnv("AVALIACOES_ENCERRAMENTO_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Avaliacoes = AvaliacoesConfig{EncerramentoInterval: encerramentoInterval}
+
+	partitionInterval, err := parseDurationEnv("PARTITION_MAINTENANCE_INTERVAL", 6*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Partitions = PartitionConfig{
+		Interval:                 partitionInterval,
+		PremakeMonths:            parseIntEnv("PARTITION_PREMAKE_MONTHS", 3),
+		PresencasRetentionMonths: parseIntEnv("PARTITION_PRESENCAS_RETENTION_MONTHS", 0),
+	}
+
+	retentionInterval, err := parseDurationEnv("RETENTION_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Retention = RetentionConfig{
+		Interval:                retentionInterval,
+		AccessLogMonths:         parseIntEnv("RETENTION_ACCESS_LOG_MONTHS", 12),
+		RevokedRefreshTokenDays: parseIntEnv("RETENTION_REVOKED_REFRESH_TOKEN_DAYS", 30),
+		ExpiredRefreshTokenDays: parseIntEnv("RETENTION_EXPIRED_REFRESH_TOKEN_DAYS", 7),
+		MonitorReadingDays:      parseIntEnv("RETENTION_MONITOR_READING_DAYS", 90),
+		MonitorAlertDays:        parseIntEnv("RETENTION_MONITOR_ALERT_DAYS", 365),
+	}
+
+	shedP95, err := parseDurationEnv("LOADSHED_P95_THRESHOLD", 1500*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	shedRetry, err := parseDurationEnv("LOADSHED_RETRY_AFTER", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.LoadShed = LoadShedConfig{
+		Enabled:        !strings.EqualFold(getEnv("LOADSHED_ENABLED", "true"), "false"),
+		P95Threshold:   shedP95,
+		PoolSaturation: parseFloatEnv("LOADSHED_POOL_SATURATION", 0.9),
+		RetryAfter:     shedRetry,
 	}
 
 	cfg.WebAuthnRPName = strings.TrimSpace(getEnv("WEBAUTHN_RP_NAME", "Gestão Zabelê"))
@@ -192,13 +593,126 @@ func Load() (*Config, error) {
 	}
 
 	cfg.Storage = StorageConfig{
-		Provider:    strings.TrimSpace(strings.ToLower(getEnv("STORAGE_PROVIDER", "noop"))),
-		S3Endpoint:  strings.TrimSpace(getEnv("STORAGE_S3_ENDPOINT", "")),
-		S3Region:    strings.TrimSpace(getEnv("STORAGE_S3_REGION", "")),
-		S3Bucket:    strings.TrimSpace(getEnv("STORAGE_S3_BUCKET", "")),
-		S3AccessKey: strings.TrimSpace(getEnv("STORAGE_S3_ACCESS_KEY", "")),
-		S3SecretKey: strings.TrimSpace(getEnv("STORAGE_S3_SECRET_KEY", "")),
-		S3PublicURL: strings.TrimSpace(getEnv("STORAGE_S3_PUBLIC_BASE_URL", "")),
+		Provider:     strings.TrimSpace(strings.ToLower(getEnv("STORAGE_PROVIDER", "noop"))),
+		S3Endpoint:   strings.TrimSpace(getEnv("STORAGE_S3_ENDPOINT", "")),
+		S3Region:     strings.TrimSpace(getEnv("STORAGE_S3_REGION", "")),
+		S3Bucket:     strings.TrimSpace(getEnv("STORAGE_S3_BUCKET", "")),
+		S3AccessKey:  strings.TrimSpace(getEnv("STORAGE_S3_ACCESS_KEY", "")),
+		S3SecretKey:  strings.TrimSpace(getEnv("STORAGE_S3_SECRET_KEY", "")),
+		S3PublicURL:  strings.TrimSpace(getEnv("STORAGE_S3_PUBLIC_BASE_URL", "")),
+		LocalDir:     strings.TrimSpace(getEnv("STORAGE_LOCAL_DIR", "")),
+		LocalBaseURL: strings.TrimSpace(getEnv("STORAGE_LOCAL_BASE_URL", "")),
+		// Sem segredo próprio, os links locais são assinados a partir do JWT_SECRET.
+		LocalSigningSecret: strings.TrimSpace(getEnv("STORAGE_LOCAL_SIGNING_SECRET", cfg.JWTSecret)),
+		GCSBucket:          strings.TrimSpace(getEnv("STORAGE_GCS_BUCKET", "")),
+		GCSCredentialsFile: strings.TrimSpace(getEnv("STORAGE_GCS_CREDENTIALS_FILE", "")),
+		GCSPublicURL:       strings.TrimSpace(getEnv("STORAGE_GCS_PUBLIC_BASE_URL", "")),
+	}
+	for _, prefix := range strings.Split(getEnv("STORAGE_LOCAL_PUBLIC_PREFIXES", "tenants/,apps/,opendata/"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			cfg.Storage.LocalPublicPrefixes = append(cfg.Storage.LocalPublicPrefixes, prefix)
+		}
+	}
+	signedTTL, err := parseDurationEnv("STORAGE_SIGNED_URL_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Storage.SignedURLTTL = signedTTL
+
+	scanTimeout, err := parseDurationEnv("CLAMAV_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	mailInterval, err := parseDurationEnv("MAIL_WORKER_INTERVAL", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Mail = MailConfig{
+		Provider:           strings.ToLower(strings.TrimSpace(getEnv("MAIL_PROVIDER", "smtp"))),
+		SMTPHost:           strings.TrimSpace(getEnv("SMTP_HOST", "")),
+		SMTPPort:           parseIntEnv("SMTP_PORT", 587),
+		SMTPUsername:       strings.TrimSpace(getEnv("SMTP_USERNAME", "")),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		From:               strings.TrimSpace(getEnv("SMTP_FROM", "")),
+		SendGridAPIKey:     strings.TrimSpace(getEnv("SENDGRID_API_KEY", "")),
+		SESRegion:          strings.TrimSpace(getEnv("SES_REGION", "")),
+		SESAccessKeyID:     strings.TrimSpace(getEnv("SES_ACCESS_KEY_ID", "")),
+		SESSecretAccessKey: strings.TrimSpace(getEnv("SES_SECRET_ACCESS_KEY", "")),
+		WorkerInterval:     mailInterval,
+		PanelURL:           strings.TrimRight(strings.TrimSpace(getEnv("SAAS_PANEL_URL", cfg.WebAuthnRPOrigin)), "/"),
+		BackofficeURL:      strings.TrimRight(strings.TrimSpace(getEnv("BACKOFFICE_URL", cfg.WebAuthnRPOrigin)), "/"),
+	}
+	cfg.SupportEmail = SupportEmailConfig{
+		Address:           strings.ToLower(strings.TrimSpace(getEnv("SUPPORT_EMAIL_ADDRESS", ""))),
+		MailgunSigningKey: strings.TrimSpace(getEnv("SUPPORT_MAILGUN_SIGNING_KEY", "")),
+		InboundToken:      strings.TrimSpace(getEnv("SUPPORT_INBOUND_TOKEN", "")),
+	}
+
+	cfg.Antivirus = AntivirusConfig{
+		Address: strings.TrimSpace(getEnv("CLAMAV_ADDRESS", "")),
+		Timeout: scanTimeout,
+	}
+
+	openDataInterval, err := parseDurationEnv("OPENDATA_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OpenData = OpenDataConfig{Interval: openDataInterval}
+
+	auditAnchorInterval, err := parseDurationEnv("AUDIT_ANCHOR_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Audit = AuditConfig{AnchorInterval: auditAnchorInterval}
+
+	cepCacheTTL, err := parseDurationEnv("CEP_CACHE_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Address = AddressConfig{
+		ViaCEPBase:    strings.TrimSpace(getEnv("VIACEP_API_BASE", "")),
+		BrasilAPIBase: strings.TrimSpace(getEnv("BRASILAPI_CEP_BASE", "")),
+		CacheTTL:      cepCacheTTL,
+	}
+
+	procurementInterval, err := parseDurationEnv("PROCUREMENT_ALERT_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	procurementWindow, err := parseDurationEnv("PROCUREMENT_EXPIRY_WINDOW", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Procurement = ProcurementConfig{AlertInterval: procurementInterval, ExpiryWindow: procurementWindow}
+
+	estoqueInterval, err := parseDurationEnv("ESTOQUE_ALERT_INTERVAL", 6*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Estoque = EstoqueConfig{AlertInterval: estoqueInterval}
+
+	eventosInterval, err := parseDurationEnv("EVENTOS_REMINDER_INTERVAL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Eventos = EventosConfig{ReminderInterval: eventosInterval}
+
+	cfg.Documentos = DocumentosConfig{SigningKey: strings.TrimSpace(getEnv("DOCUMENTOS_SIGNING_KEY", ""))}
+	cfg.Consultas = ConsultasConfig{HashKey: strings.TrimSpace(getEnv("CONSULTAS_HASH_KEY", ""))}
+	if cfg.Consultas.HashKey == "" {
+		cfg.Consultas.HashKey = cfg.JWTSecret
+	}
+
+	cfg.ErrorTracking = ErrorTrackingConfig{
+		DSN:         strings.TrimSpace(getEnv("SENTRY_DSN", "")),
+		Environment: strings.TrimSpace(getEnv("SENTRY_ENVIRONMENT", "production")),
+		Release:     strings.TrimSpace(getEnv("SENTRY_RELEASE", "")),
+		SampleRate:  parseFloatEnv("SENTRY_SAMPLE_RATE", 1),
+	}
+
+	cfg.IBGE = IBGEConfig{
+		Enabled: !strings.EqualFold(getEnv("IBGE_LOOKUP_ENABLED", "true"), "false"),
+		APIBase: strings.TrimSpace(getEnv("IBGE_API_BASE", "")),
 	}
 
 	return cfg, nil
@@ -234,3 +748,34 @@ func parseFloatEnv(key string, def float64) float64 {
 	}
 	return parsed
 }
+
+// parseNamedURLsEnv lê pares nome=url separados por vírgula.
+func parseNamedURLsEnv(key string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, item := range strings.Split(getEnv(key, ""), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		target, err := url.Parse(strings.TrimSpace(raw))
+		if !ok || name == "" || err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+			return nil, errors.New(key + " inválido")
+		}
+		result[name] = target.String()
+	}
+	return result, nil
+}
+
+func parseIntEnv(key string, def int) int {
+	val := strings.TrimSpace(getEnv(key, ""))
+	if val == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(val)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
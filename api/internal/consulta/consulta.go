@@ -0,0 +1,302 @@
+// Package consulta cuida das consultas públicas e do orçamento participativo: a prefeitura publica
+// propostas, cada CPF vota uma vez no período e o resultado sai em agregados públicos. A cédula é
+// gravada sem vínculo com o eleitor; a exportação de auditoria lista só cédulas anônimas.
+package consulta
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica consulta inexistente, não publicada ou de outro tenant.
+	ErrNotFound = errors.New("consulta: consulta não encontrada")
+	// ErrPropostaNotFound indica proposta inexistente ou de outra consulta.
+	ErrPropostaNotFound = errors.New("consulta: proposta não encontrada")
+	// ErrFechada indica voto fora do período de votação ou em consulta cancelada.
+	ErrFechada = errors.New("consulta: votação fechada")
+	// ErrJaVotou indica CPF ou conta que já votou na consulta.
+	ErrJaVotou = errors.New("consulta: voto já registrado")
+	// ErrCPF indica CPF com dígitos verificadores inválidos.
+	ErrCPF = errors.New("consulta: cpf inválido")
+	// ErrEscolhas indica cédula vazia, com propostas repetidas ou acima do máximo permitido.
+	ErrEscolhas = errors.New("consulta: escolhas inválidas")
+	// ErrLimiteOrigem indica que a mesma origem de rede já registrou o máximo de votos da consulta.
+	ErrLimiteOrigem = errors.New("consulta: limite de votos por origem atingido")
+	// ErrEditavel indica alteração de consulta já publicada ou cancelada.
+	ErrEditavel = errors.New("consulta: só consultas em rascunho podem ser alteradas")
+	// ErrSemPropostas indica publicação de consulta sem propostas.
+	ErrSemPropostas = errors.New("consulta: consulta sem propostas")
+	// ErrResultado indica resultado parcial pedido antes do encerramento.
+	ErrResultado = errors.New("consulta: resultado disponível só após o encerramento")
+)
+
+// Status gravados da consulta.
+const (
+	StatusRascunho  = "rascunho"
+	StatusPublicada = "publicada"
+	StatusCancelada = "cancelada"
+)
+
+// Situações calculadas a partir do status e do período.
+const (
+	SituacaoRascunho  = "rascunho"
+	SituacaoAgendada  = "agendada"
+	SituacaoAberta    = "aberta"
+	SituacaoEncerrada = "encerrada"
+	SituacaoCancelada = "cancelada"
+)
+
+// Consulta é uma votação pública com período definido.
+type Consulta struct {
+	ID                 uuid.UUID  `json:"id"`
+	TenantID           uuid.UUID  `json:"tenant_id"`
+	Titulo             string     `json:"titulo"`
+	Descricao          *string    `json:"descricao,omitempty"`
+	Inicio             time.Time  `json:"inicio"`
+	Fim                time.Time  `json:"fim"`
+	MaxEscolhas        int        `json:"max_escolhas"`
+	LimitePorOrigem    int        `json:"limite_por_origem"`
+	ResultadosParciais bool       `json:"resultados_parciais"`
+	Status             string     `json:"status"`
+	Situacao           string     `json:"situacao"`
+	Propostas          []Proposta `json:"propostas,omitempty"`
+	Votantes           int        `json:"votantes"`
+	PublicadaEm        *time.Time `json:"publicada_em,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// SituacaoEm calcula a situação da consulta em now.
+func (c *Consulta) SituacaoEm(now time.Time) string {
+	switch {
+	case c.Status == StatusCancelada:
+		return SituacaoCancelada
+	case c.Status == StatusRascunho:
+		return SituacaoRascunho
+	case now.Before(c.Inicio):
+		return SituacaoAgendada
+	case now.Before(c.Fim):
+		return SituacaoAberta
+	default:
+		return SituacaoEncerrada
+	}
+}
+
+// ResultadoPublico informa se o resultado pode ser divulgado em now.
+func (c *Consulta) ResultadoPublico(now time.Time) bool {
+	switch c.SituacaoEm(now) {
+	case SituacaoEncerrada:
+		return true
+	case SituacaoAberta:
+		return c.ResultadosParciais
+	}
+	return false
+}
+
+// ConsultaInput contém os campos editáveis da consulta.
+type ConsultaInput struct {
+	Titulo             string
+	Descricao          *string
+	Inicio             time.Time
+	Fim                time.Time
+	MaxEscolhas        int
+	LimitePorOrigem    *int
+	ResultadosParciais bool
+}
+
+// limitePorOrigemPadrao cobre famílias e locais de votação assistida sob o mesmo IP.
+const limitePorOrigemPadrao = 30
+
+// Normalize limpa e valida a entrada; sem máximo informado, cada cédula escolhe uma proposta.
+func (in *ConsultaInput) Normalize() error {
+	in.Titulo = strings.TrimSpace(in.Titulo)
+	in.Descricao = trimOptional(in.Descricao)
+	if in.MaxEscolhas == 0 {
+		in.MaxEscolhas = 1
+	}
+	if in.LimitePorOrigem == nil {
+		padrao := limitePorOrigemPadrao
+		in.LimitePorOrigem = &padrao
+	}
+	switch {
+	case in.Titulo == "":
+		return errors.New("título obrigatório")
+	case in.Inicio.IsZero() || in.Fim.IsZero():
+		return errors.New("início e fim obrigatórios")
+	case !in.Fim.After(in.Inicio):
+		return errors.New("fim deve ser posterior ao início")
+	case in.MaxEscolhas < 1 || in.MaxEscolhas > 20:
+		return errors.New("máximo de escolhas deve ser de 1 a 20")
+	case *in.LimitePorOrigem < 0:
+		return errors.New("limite por origem não pode ser negativo")
+	}
+	return nil
+}
+
+// Proposta é uma opção de voto.
+type Proposta struct {
+	ID            uuid.UUID `json:"id"`
+	ConsultaID    uuid.UUID `json:"consulta_id"`
+	Titulo        string    `json:"titulo"`
+	Descricao     *string   `json:"descricao,omitempty"`
+	Bairro        *string   `json:"bairro,omitempty"`
+	ValorEstimado *float64  `json:"valor_estimado,omitempty"`
+	Ordem         int       `json:"ordem"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PropostaInput contém os campos editáveis da proposta.
+type PropostaInput struct {
+	Titulo        string
+	Descricao     *string
+	Bairro        *string
+	ValorEstimado *float64
+	Ordem         int
+}
+
+// Normalize limpa e valida a entrada.
+func (in *PropostaInput) Normalize() error {
+	in.Titulo = strings.TrimSpace(in.Titulo)
+	in.Descricao = trimOptional(in.Descricao)
+	in.Bairro = trimOptional(in.Bairro)
+	switch {
+	case in.Titulo == "":
+		return errors.New("título obrigatório")
+	case in.ValorEstimado != nil && *in.ValorEstimado < 0:
+		return errors.New("valor estimado não pode ser negativo")
+	}
+	return nil
+}
+
+// VotoInput é a cédula enviada pelo cidadão.
+type VotoInput struct {
+	CidadaoID uuid.UUID
+	CPF       string
+	Propostas []uuid.UUID
+	IP        string
+}
+
+// Comprovante confirma o voto sem revelar as escolhas nem a cédula: quem pudesse apontar a própria
+// cédula na exportação também poderia provar o voto a terceiros.
+type Comprovante struct {
+	ConsultaID   uuid.UUID `json:"consulta_id"`
+	RegistradoEm time.Time `json:"registrado_em"`
+}
+
+// Participacao informa ao cidadão se a conta já votou.
+type Participacao struct {
+	Votou        bool       `json:"votou"`
+	RegistradoEm *time.Time `json:"registrado_em,omitempty"`
+}
+
+// ResultadoProposta é o total de uma proposta.
+type ResultadoProposta struct {
+	PropostaID uuid.UUID `json:"proposta_id"`
+	Titulo     string    `json:"titulo"`
+	Bairro     *string   `json:"bairro,omitempty"`
+	Votos      int       `json:"votos"`
+	Percentual float64   `json:"percentual"`
+	Posicao    int       `json:"posicao"`
+}
+
+// Resultado é a apuração agregada; Percentual é sobre o número de cédulas.
+type Resultado struct {
+	ConsultaID uuid.UUID           `json:"consulta_id"`
+	Situacao   string              `json:"situacao"`
+	Cedulas    int                 `json:"cedulas"`
+	Propostas  []ResultadoProposta `json:"propostas"`
+	PorDia     []VotosDia          `json:"por_dia"`
+	ApuradoEm  time.Time           `json:"apurado_em"`
+	// Origens só aparece para a prefeitura: quantas origens de rede distintas votaram e o maior
+	// número de cédulas vindas de uma mesma origem, para revisão de fraude.
+	Origens *Origens `json:"origens,omitempty"`
+}
+
+// VotosDia conta as cédulas por dia de votação.
+type VotosDia struct {
+	Dia     string `json:"dia"`
+	Cedulas int    `json:"cedulas"`
+}
+
+// Origens resume a distribuição das cédulas por origem de rede.
+type Origens struct {
+	Distintas    int `json:"distintas"`
+	MaiorVolume  int `json:"maior_volume"`
+	AcimaDeCinco int `json:"acima_de_cinco"`
+}
+
+// CedulaAnonima é uma linha da exportação de auditoria.
+type CedulaAnonima struct {
+	Cedula     uuid.UUID
+	Dia        time.Time
+	PropostaID uuid.UUID
+	Proposta   string
+}
+
+// ValidarEscolhas confere a cédula: ao menos uma proposta, sem repetição e até o máximo.
+func ValidarEscolhas(escolhas []uuid.UUID, max int) error {
+	if len(escolhas) == 0 || len(escolhas) > max {
+		return ErrEscolhas
+	}
+	vistas := make(map[uuid.UUID]struct{}, len(escolhas))
+	for _, id := range escolhas {
+		if _, ok := vistas[id]; ok {
+			return ErrEscolhas
+		}
+		vistas[id] = struct{}{}
+	}
+	return nil
+}
+
+// Apurar ordena as propostas por votos e calcula percentual e posição; empates dividem a posição.
+func Apurar(propostas []ResultadoProposta, cedulas int) []ResultadoProposta {
+	out := append([]ResultadoProposta(nil), propostas...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Votos > out[j].Votos })
+	for i := range out {
+		if cedulas > 0 {
+			out[i].Percentual = float64(out[i].Votos) / float64(cedulas)
+		}
+		if i > 0 && out[i].Votos == out[i-1].Votos {
+			out[i].Posicao = out[i-1].Posicao
+		} else {
+			out[i].Posicao = i + 1
+		}
+	}
+	return out
+}
+
+// HashEleitor deriva a identificação do eleitor na consulta. A chave e o id da consulta entram no
+// HMAC, então o mesmo CPF não é rastreável entre consultas nem a partir de uma lista de CPFs.
+func HashEleitor(chave []byte, consultaID uuid.UUID, cpf string) string {
+	mac := hmac.New(sha256.New, chave)
+	mac.Write(consultaID[:])
+	mac.Write([]byte(cpf))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashOrigem deriva a identificação da origem de rede usada no limite por origem, sem guardar o IP.
+func HashOrigem(chave []byte, consultaID uuid.UUID, ip string) string {
+	mac := hmac.New(sha256.New, chave)
+	mac.Write([]byte("origem:"))
+	mac.Write(consultaID[:])
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	if v := strings.TrimSpace(*value); v != "" {
+		return &v
+	}
+	return nil
+}
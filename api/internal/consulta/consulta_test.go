@@ -0,0 +1,87 @@
+package consulta
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSituacaoEResultadoPublico(t *testing.T) {
+	inicio := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	c := Consulta{Status: StatusPublicada, Inicio: inicio, Fim: inicio.Add(72 * time.Hour)}
+	cases := []struct {
+		now      time.Time
+		situacao string
+		publico  bool
+	}{
+		{inicio.Add(-time.Hour), SituacaoAgendada, false},
+		{inicio.Add(time.Hour), SituacaoAberta, false},
+		{c.Fim, SituacaoEncerrada, true},
+	}
+	for _, tc := range cases {
+		if got := c.SituacaoEm(tc.now); got != tc.situacao {
+			t.Errorf("SituacaoEm(%v) = %q, want %q", tc.now, got, tc.situacao)
+		}
+		if got := c.ResultadoPublico(tc.now); got != tc.publico {
+			t.Errorf("ResultadoPublico(%v) = %v, want %v", tc.now, got, tc.publico)
+		}
+	}
+	c.ResultadosParciais = true
+	if !c.ResultadoPublico(inicio.Add(time.Hour)) {
+		t.Error("resultado parcial liberado deveria aparecer durante a votação")
+	}
+	c.Status = StatusCancelada
+	if c.ResultadoPublico(c.Fim.Add(time.Hour)) {
+		t.Error("consulta cancelada não divulga resultado")
+	}
+}
+
+func TestValidarEscolhas(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	if err := ValidarEscolhas([]uuid.UUID{a, b}, 2); err != nil {
+		t.Fatalf("cédula válida recusada: %v", err)
+	}
+	if err := ValidarEscolhas(nil, 1); !errors.Is(err, ErrEscolhas) {
+		t.Errorf("cédula vazia aceita: %v", err)
+	}
+	if err := ValidarEscolhas([]uuid.UUID{a, a}, 3); !errors.Is(err, ErrEscolhas) {
+		t.Errorf("proposta repetida aceita: %v", err)
+	}
+	if err := ValidarEscolhas([]uuid.UUID{a, b}, 1); !errors.Is(err, ErrEscolhas) {
+		t.Errorf("escolhas acima do máximo aceitas: %v", err)
+	}
+}
+
+func TestApurarEmpate(t *testing.T) {
+	res := Apurar([]ResultadoProposta{
+		{Titulo: "Praça", Votos: 10},
+		{Titulo: "Posto", Votos: 30},
+		{Titulo: "Creche", Votos: 30},
+		{Titulo: "Ciclovia", Votos: 0},
+	}, 50)
+	if res[0].Titulo != "Posto" || res[1].Titulo != "Creche" {
+		t.Fatalf("ordem = %+v", res)
+	}
+	if res[0].Posicao != 1 || res[1].Posicao != 1 || res[2].Posicao != 3 || res[3].Posicao != 4 {
+		t.Fatalf("posições = %d %d %d %d", res[0].Posicao, res[1].Posicao, res[2].Posicao, res[3].Posicao)
+	}
+	if res[0].Percentual != 0.6 {
+		t.Fatalf("percentual = %v", res[0].Percentual)
+	}
+}
+
+func TestHashEleitorPorConsulta(t *testing.T) {
+	chave := []byte("chave")
+	c1, c2 := uuid.New(), uuid.New()
+	if HashEleitor(chave, c1, "52998224725") != HashEleitor(chave, c1, "52998224725") {
+		t.Fatal("hash do eleitor deveria ser determinístico")
+	}
+	if HashEleitor(chave, c1, "52998224725") == HashEleitor(chave, c2, "52998224725") {
+		t.Fatal("o mesmo CPF não deveria ser rastreável entre consultas")
+	}
+	if HashEleitor(chave, c1, "52998224725") == HashOrigem(chave, c1, "52998224725") {
+		t.Fatal("hashes de eleitor e origem deveriam ser separados")
+	}
+}
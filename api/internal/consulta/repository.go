@@ -0,0 +1,453 @@
+package consulta
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+const consultaColumns = `c.id, c.tenant_id, c.titulo, c.descricao, c.inicio, c.fim, c.max_escolhas, c.limite_por_origem,
+        c.resultados_parciais, c.status,
+        (SELECT count(*) FROM consulta_eleitores v WHERE v.consulta_id = c.id)::int,
+        c.publicada_em, c.created_at, c.updated_at`
+
+const propostaColumns = `p.id, p.consulta_id, p.titulo, p.descricao, p.bairro, p.valor_estimado::float8, p.ordem, p.created_at`
+
+// hoje é a data da votação no fuso da prefeitura; a cédula guarda só o dia.
+const hoje = `(now() AT TIME ZONE 'America/Sao_Paulo')::date`
+
+// Repository persiste consultas, propostas e votos.
+type Repository struct {
+	pool  *pgxpool.Pool
+	chave []byte
+}
+
+// NewRepository cria o repositório; segredo deriva a chave dos hashes de eleitor e origem e não pode
+// mudar durante uma votação, ou o mesmo CPF votaria de novo.
+func NewRepository(pool *pgxpool.Pool, segredo string) *Repository {
+	chave := sha256.Sum256([]byte("consulta:" + segredo))
+	return &Repository{pool: pool, chave: chave[:]}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// List lista as consultas da prefeitura, das mais recentes às mais antigas; publicas restringe às
+// publicadas.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, publicas bool) ([]Consulta, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+consultaColumns+`
+        FROM consultas c
+        WHERE c.tenant_id = $1 AND (NOT $2 OR c.status = 'publicada')
+        ORDER BY c.inicio DESC`, tenantID, publicas)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Consulta, error) {
+		c, err := scanConsulta(row, now)
+		if err != nil {
+			return Consulta{}, err
+		}
+		return *c, nil
+	})
+}
+
+// Get busca a consulta com as propostas; publica esconde os rascunhos.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID, publica bool) (*Consulta, error) {
+	c, err := scanConsulta(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+consultaColumns+`
+        FROM consultas c
+        WHERE c.id = $1 AND c.tenant_id = $2 AND (NOT $3 OR c.status <> 'rascunho')`, id, tenantID, publica), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+propostaColumns+`
+        FROM consulta_propostas p
+        WHERE p.consulta_id = $1
+        ORDER BY p.ordem, p.created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	c.Propostas, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Proposta, error) {
+		p, err := scanProposta(row)
+		if err != nil {
+			return Proposta{}, err
+		}
+		return *p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Create cadastra a consulta em rascunho.
+func (r *Repository) Create(ctx context.Context, tenantID uuid.UUID, in ConsultaInput, createdBy *uuid.UUID) (*Consulta, error) {
+	var id uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO consultas (tenant_id, titulo, descricao, inicio, fim, max_escolhas, limite_por_origem, resultados_parciais, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id
+    `, tenantID, in.Titulo, in.Descricao, in.Inicio, in.Fim, in.MaxEscolhas, *in.LimitePorOrigem, in.ResultadosParciais, createdBy).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// Update altera a consulta ainda em rascunho.
+func (r *Repository) Update(ctx context.Context, tenantID, id uuid.UUID, in ConsultaInput) (*Consulta, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE consultas
+        SET titulo = $3, descricao = $4, inicio = $5, fim = $6, max_escolhas = $7, limite_por_origem = $8,
+            resultados_parciais = $9, updated_at = now()
+        WHERE id = $1 AND tenant_id = $2 AND status = 'rascunho'
+    `, id, tenantID, in.Titulo, in.Descricao, in.Inicio, in.Fim, in.MaxEscolhas, *in.LimitePorOrigem, in.ResultadosParciais)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, r.naoEditavel(ctx, tenantID, id)
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// Publicar abre a consulta para votação no período definido; exige ao menos uma proposta.
+func (r *Repository) Publicar(ctx context.Context, tenantID, id uuid.UUID) (*Consulta, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE consultas c
+        SET status = 'publicada', publicada_em = now(), updated_at = now()
+        WHERE c.id = $1 AND c.tenant_id = $2 AND c.status = 'rascunho'
+          AND EXISTS (SELECT 1 FROM consulta_propostas p WHERE p.consulta_id = c.id)
+    `, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		c, err := r.Get(ctx, tenantID, id, false)
+		if err != nil {
+			return nil, err
+		}
+		if c.Status != StatusRascunho {
+			return nil, ErrEditavel
+		}
+		return nil, ErrSemPropostas
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// Cancelar encerra a consulta sem divulgar resultado; os votos já dados ficam guardados.
+func (r *Repository) Cancelar(ctx context.Context, tenantID, id uuid.UUID) (*Consulta, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE consultas SET status = 'cancelada', updated_at = now()
+        WHERE id = $1 AND tenant_id = $2 AND status <> 'cancelada'
+    `, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.Get(ctx, tenantID, id, false); err != nil {
+			return nil, err
+		}
+		return nil, ErrEditavel
+	}
+	return r.Get(ctx, tenantID, id, false)
+}
+
+// CreateProposta inclui uma proposta na consulta em rascunho.
+func (r *Repository) CreateProposta(ctx context.Context, tenantID, consultaID uuid.UUID, in PropostaInput) (*Proposta, error) {
+	p, err := scanProposta(r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO consulta_propostas (consulta_id, titulo, descricao, bairro, valor_estimado, ordem)
+        SELECT c.id, $3, $4, $5, $6, $7
+        FROM consultas c
+        WHERE c.id = $1 AND c.tenant_id = $2 AND c.status = 'rascunho'
+        RETURNING id, consulta_id, titulo, descricao, bairro, valor_estimado::float8, ordem, created_at
+    `, consultaID, tenantID, in.Titulo, in.Descricao, in.Bairro, in.ValorEstimado, in.Ordem))
+	if errors.Is(err, ErrPropostaNotFound) {
+		return nil, r.naoEditavel(ctx, tenantID, consultaID)
+	}
+	return p, err
+}
+
+// UpdateProposta altera a proposta de consulta em rascunho.
+func (r *Repository) UpdateProposta(ctx context.Context, tenantID, consultaID, id uuid.UUID, in PropostaInput) (*Proposta, error) {
+	p, err := scanProposta(r.conn(ctx).QueryRow(ctx, `
+        UPDATE consulta_propostas p
+        SET titulo = $4, descricao = $5, bairro = $6, valor_estimado = $7, ordem = $8
+        FROM consultas c
+        WHERE p.id = $1 AND p.consulta_id = $2 AND c.id = p.consulta_id AND c.tenant_id = $3 AND c.status = 'rascunho'
+        RETURNING `+propostaColumns, id, consultaID, tenantID, in.Titulo, in.Descricao, in.Bairro, in.ValorEstimado, in.Ordem))
+	if errors.Is(err, ErrPropostaNotFound) {
+		return nil, r.falhaProposta(ctx, tenantID, consultaID)
+	}
+	return p, err
+}
+
+// DeleteProposta remove a proposta de consulta em rascunho.
+func (r *Repository) DeleteProposta(ctx context.Context, tenantID, consultaID, id uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        DELETE FROM consulta_propostas p
+        USING consultas c
+        WHERE p.id = $1 AND p.consulta_id = $2 AND c.id = p.consulta_id AND c.tenant_id = $3 AND c.status = 'rascunho'
+    `, id, consultaID, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return r.falhaProposta(ctx, tenantID, consultaID)
+	}
+	return nil
+}
+
+// naoEditavel explica por que a consulta não foi alterada: inexistente ou fora do rascunho.
+func (r *Repository) naoEditavel(ctx context.Context, tenantID, id uuid.UUID) error {
+	if _, err := r.Get(ctx, tenantID, id, false); err != nil {
+		return err
+	}
+	return ErrEditavel
+}
+
+// falhaProposta distingue consulta inexistente, fora do rascunho ou proposta inexistente.
+func (r *Repository) falhaProposta(ctx context.Context, tenantID, consultaID uuid.UUID) error {
+	c, err := r.Get(ctx, tenantID, consultaID, false)
+	if err != nil {
+		return err
+	}
+	if c.Status != StatusRascunho {
+		return ErrEditavel
+	}
+	return ErrPropostaNotFound
+}
+
+// Votar registra a cédula. O eleitor (hash do CPF) e a conta votam uma vez por consulta; a cédula é
+// gravada à parte, com identificador aleatório e só o dia, sem ligação com quem votou.
+func (r *Repository) Votar(ctx context.Context, tenantID, consultaID uuid.UUID, in VotoInput) (*Comprovante, error) {
+	cpf, err := util.ValidateCPF(in.CPF)
+	if err != nil {
+		return nil, ErrCPF
+	}
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var c Consulta
+	err = tx.QueryRow(ctx, `
+        SELECT status, inicio, fim, max_escolhas, limite_por_origem
+        FROM consultas
+        WHERE id = $1 AND tenant_id = $2 AND status <> 'rascunho'
+        FOR SHARE`, consultaID, tenantID).Scan(&c.Status, &c.Inicio, &c.Fim, &c.MaxEscolhas, &c.LimitePorOrigem)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.SituacaoEm(time.Now()) != SituacaoAberta {
+		return nil, ErrFechada
+	}
+	if err := ValidarEscolhas(in.Propostas, c.MaxEscolhas); err != nil {
+		return nil, err
+	}
+	var validas int
+	if err := tx.QueryRow(ctx, `
+        SELECT count(*) FROM consulta_propostas WHERE consulta_id = $1 AND id = ANY($2)
+    `, consultaID, in.Propostas).Scan(&validas); err != nil {
+		return nil, err
+	}
+	if validas != len(in.Propostas) {
+		return nil, ErrEscolhas
+	}
+
+	origem := HashOrigem(r.chave, consultaID, in.IP)
+	if c.LimitePorOrigem > 0 {
+		// Serializa os votos da mesma origem para o limite valer sob concorrência.
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, origem); err != nil {
+			return nil, err
+		}
+		var daOrigem int
+		if err := tx.QueryRow(ctx, `
+            SELECT count(*) FROM consulta_eleitores WHERE consulta_id = $1 AND origem_hash = $2
+        `, consultaID, origem).Scan(&daOrigem); err != nil {
+			return nil, err
+		}
+		if daOrigem >= c.LimitePorOrigem {
+			return nil, ErrLimiteOrigem
+		}
+	}
+
+	comprovante := Comprovante{ConsultaID: consultaID}
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO consulta_eleitores (consulta_id, eleitor_hash, cidadao_id, origem_hash)
+        VALUES ($1, $2, $3, $4)
+        RETURNING created_at
+    `, consultaID, HashEleitor(r.chave, consultaID, cpf), in.CidadaoID, origem).Scan(&comprovante.RegistradoEm); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrJaVotou
+		}
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO consulta_votos (consulta_id, cedula, proposta_id, dia)
+        SELECT $1, $2, p, `+hoje+` FROM unnest($3::uuid[]) AS p
+    `, consultaID, uuid.New(), in.Propostas); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &comprovante, nil
+}
+
+// Participacao informa se a conta do cidadão já votou na consulta.
+func (r *Repository) Participacao(ctx context.Context, tenantID, consultaID, cidadaoID uuid.UUID) (*Participacao, error) {
+	if _, err := r.Get(ctx, tenantID, consultaID, true); err != nil {
+		return nil, err
+	}
+	var p Participacao
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT created_at FROM consulta_eleitores WHERE consulta_id = $1 AND cidadao_id = $2
+    `, consultaID, cidadaoID).Scan(&p.RegistradoEm)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Votou = true
+	return &p, nil
+}
+
+// Resultado apura a consulta. Sem admin, só sai após o encerramento ou com resultado parcial
+// liberado; com admin, inclui a distribuição por origem de rede para revisão de fraude.
+func (r *Repository) Resultado(ctx context.Context, tenantID, consultaID uuid.UUID, admin bool) (*Resultado, error) {
+	c, err := r.Get(ctx, tenantID, consultaID, !admin)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !admin && !c.ResultadoPublico(now) {
+		return nil, ErrResultado
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT p.id, p.titulo, p.bairro, count(v.cedula)::int
+        FROM consulta_propostas p
+        LEFT JOIN consulta_votos v ON v.proposta_id = p.id
+        WHERE p.consulta_id = $1
+        GROUP BY p.id, p.titulo, p.bairro`, consultaID)
+	if err != nil {
+		return nil, err
+	}
+	propostas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ResultadoProposta, error) {
+		var p ResultadoProposta
+		err := row.Scan(&p.PropostaID, &p.Titulo, &p.Bairro, &p.Votos)
+		return p, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Resultado{ConsultaID: consultaID, Situacao: c.Situacao, ApuradoEm: now, PorDia: []VotosDia{}}
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT count(DISTINCT cedula)::int FROM consulta_votos WHERE consulta_id = $1
+    `, consultaID).Scan(&res.Cedulas); err != nil {
+		return nil, err
+	}
+	res.Propostas = Apurar(propostas, res.Cedulas)
+
+	rows, err = r.conn(ctx).Query(ctx, `
+        SELECT to_char(dia, 'YYYY-MM-DD'), count(DISTINCT cedula)::int
+        FROM consulta_votos WHERE consulta_id = $1
+        GROUP BY dia ORDER BY dia`, consultaID)
+	if err != nil {
+		return nil, err
+	}
+	res.PorDia, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (VotosDia, error) {
+		var d VotosDia
+		err := row.Scan(&d.Dia, &d.Cedulas)
+		return d, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if admin {
+		var o Origens
+		if err := r.conn(ctx).QueryRow(ctx, `
+            SELECT count(*)::int, COALESCE(max(total), 0)::int, count(*) FILTER (WHERE total > 5)::int
+            FROM (SELECT count(*) AS total FROM consulta_eleitores WHERE consulta_id = $1 GROUP BY origem_hash) o
+        `, consultaID).Scan(&o.Distintas, &o.MaiorVolume, &o.AcimaDeCinco); err != nil {
+			return nil, err
+		}
+		res.Origens = &o
+	}
+	return res, nil
+}
+
+// ExportCedulas percorre as cédulas anônimas em ordem de identificador (aleatória), sem horário
+// nem dado do eleitor, para auditoria externa da apuração.
+func (r *Repository) ExportCedulas(ctx context.Context, tenantID, consultaID uuid.UUID, fn func(CedulaAnonima) error) error {
+	if _, err := r.Get(ctx, tenantID, consultaID, false); err != nil {
+		return err
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT v.cedula, v.dia, v.proposta_id, p.titulo
+        FROM consulta_votos v
+        JOIN consulta_propostas p ON p.id = v.proposta_id
+        WHERE v.consulta_id = $1
+        ORDER BY v.cedula, p.ordem, p.titulo`, consultaID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c CedulaAnonima
+		if err := rows.Scan(&c.Cedula, &c.Dia, &c.PropostaID, &c.Proposta); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanConsulta(row pgx.Row, now time.Time) (*Consulta, error) {
+	var c Consulta
+	if err := row.Scan(&c.ID, &c.TenantID, &c.Titulo, &c.Descricao, &c.Inicio, &c.Fim, &c.MaxEscolhas, &c.LimitePorOrigem,
+		&c.ResultadosParciais, &c.Status, &c.Votantes, &c.PublicadaEm, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	c.Situacao = c.SituacaoEm(now)
+	return &c, nil
+}
+
+func scanProposta(row pgx.Row) (*Proposta, error) {
+	var p Proposta
+	if err := row.Scan(&p.ID, &p.ConsultaID, &p.Titulo, &p.Descricao, &p.Bairro, &p.ValorEstimado, &p.Ordem, &p.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPropostaNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persiste os layouts da visão geral.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Get devolve o layout do usuário ou o padrão quando nunca foi salvo.
+func (r *Repository) Get(ctx context.Context, userID uuid.UUID) (Layout, error) {
+	var raw []byte
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx, `
+        SELECT widgets, updated_at FROM saas_dashboard_layouts WHERE user_id = $1
+    `, userID).Scan(&raw, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Default(userID), nil
+	}
+	if err != nil {
+		return Layout{}, err
+	}
+
+	var stored []Widget
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return Layout{}, err
+	}
+	widgets, err := Normalize(stored, true)
+	if err != nil {
+		return Layout{}, err
+	}
+	return Layout{UserID: userID, Widgets: widgets, Custom: true, UpdatedAt: &updatedAt}, nil
+}
+
+// Save grava o layout do usuário; os widgets já devem estar normalizados.
+func (r *Repository) Save(ctx context.Context, userID uuid.UUID, widgets []Widget) (Layout, error) {
+	raw, err := json.Marshal(widgets)
+	if err != nil {
+		return Layout{}, err
+	}
+	var updatedAt time.Time
+	err = r.pool.QueryRow(ctx, `
+        INSERT INTO saas_dashboard_layouts (user_id, widgets, updated_at)
+        VALUES ($1, $2, now())
+        ON CONFLICT (user_id) DO UPDATE SET widgets = EXCLUDED.widgets, updated_at = now()
+        RETURNING updated_at
+    `, userID, raw).Scan(&updatedAt)
+	if err != nil {
+		return Layout{}, err
+	}
+	return Layout{UserID: userID, Widgets: widgets, Custom: true, UpdatedAt: &updatedAt}, nil
+}
+
+// Reset apaga o layout salvo, voltando ao padrão.
+func (r *Repository) Reset(ctx context.Context, userID uuid.UUID) (Layout, error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM saas_dashboard_layouts WHERE user_id = $1`, userID); err != nil {
+		return Layout{}, err
+	}
+	return Default(userID), nil
+}
@@ -0,0 +1,142 @@
+// Package dashboard guarda a configuração de widgets da visão geral de cada usuário SaaS.
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Códigos dos widgets, iguais às chaves da resposta da visão geral.
+const (
+	WidgetMetrics       = "metrics"
+	WidgetProjects      = "projects"
+	WidgetRetention     = "retention"
+	WidgetUsage         = "usage"
+	WidgetCompliance    = "compliance"
+	WidgetCommunication = "communication"
+	WidgetCityInsights  = "city_insights"
+	WidgetAccessLogs    = "access_logs"
+)
+
+// Tamanhos aceitos para um widget na grade.
+const (
+	SizeSmall  = "sm"
+	SizeMedium = "md"
+	SizeLarge  = "lg"
+)
+
+// Definition descreve um widget disponível.
+type Definition struct {
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	DefaultSize string `json:"default_size"`
+}
+
+// Catalog lista os widgets na ordem padrão da visão geral.
+var Catalog = []Definition{
+	{Code: WidgetMetrics, Title: "Indicadores gerais", DefaultSize: SizeLarge},
+	{Code: WidgetProjects, Title: "Projetos", DefaultSize: SizeLarge},
+	{Code: WidgetRetention, Title: "Retenção e NPS", DefaultSize: SizeMedium},
+	{Code: WidgetUsage, Title: "Uso dos módulos", DefaultSize: SizeMedium},
+	{Code: WidgetCompliance, Title: "Compliance", DefaultSize: SizeMedium},
+	{Code: WidgetCommunication, Title: "Central de comunicação", DefaultSize: SizeMedium},
+	{Code: WidgetCityInsights, Title: "Municípios", DefaultSize: SizeMedium},
+	{Code: WidgetAccessLogs, Title: "Últimos acessos", DefaultSize: SizeSmall},
+}
+
+// ErrUnknownWidget indica código fora do catálogo.
+var ErrUnknownWidget = errors.New("widget desconhecido")
+
+// Widget é um item do layout; a ordem do slice é a ordem de exibição.
+type Widget struct {
+	Code string `json:"code"`
+	Size string `json:"size"`
+}
+
+// Layout é a configuração salva de um usuário.
+type Layout struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	Widgets   []Widget   `json:"widgets"`
+	Custom    bool       `json:"custom"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Codes devolve os códigos dos widgets do layout, na ordem de exibição.
+func (l Layout) Codes() []string {
+	codes := make([]string, 0, len(l.Widgets))
+	for _, widget := range l.Widgets {
+		codes = append(codes, widget.Code)
+	}
+	return codes
+}
+
+// Default devolve o layout com todos os widgets do catálogo.
+func Default(userID uuid.UUID) Layout {
+	widgets := make([]Widget, 0, len(Catalog))
+	for _, def := range Catalog {
+		widgets = append(widgets, Widget{Code: def.Code, Size: def.DefaultSize})
+	}
+	return Layout{UserID: userID, Widgets: widgets}
+}
+
+// Normalize valida os widgets, aplica o tamanho padrão e descarta repetições mantendo a
+// primeira ocorrência. Códigos que saíram do catálogo são ignorados quando lenient é true, o
+// que permite carregar layouts antigos; na gravação, lenient é false e geram erro.
+func Normalize(widgets []Widget, lenient bool) ([]Widget, error) {
+	out := make([]Widget, 0, len(widgets))
+	seen := make(map[string]bool, len(widgets))
+	for _, widget := range widgets {
+		code := strings.ToLower(strings.TrimSpace(widget.Code))
+		def, ok := find(code)
+		if !ok {
+			if lenient {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWidget, widget.Code)
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		size := strings.ToLower(strings.TrimSpace(widget.Size))
+		switch size {
+		case SizeSmall, SizeMedium, SizeLarge:
+		case "":
+			size = def.DefaultSize
+		default:
+			return nil, fmt.Errorf("tamanho inválido para %s: %s", code, widget.Size)
+		}
+		out = append(out, Widget{Code: code, Size: size})
+	}
+	return out, nil
+}
+
+// ParseCodes interpreta uma lista separada por vírgulas, como em ?widgets=metrics,projects.
+func ParseCodes(value string) ([]string, error) {
+	widgets := make([]Widget, 0)
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		widgets = append(widgets, Widget{Code: part})
+	}
+	normalized, err := Normalize(widgets, false)
+	if err != nil {
+		return nil, err
+	}
+	return Layout{Widgets: normalized}.Codes(), nil
+}
+
+func find(code string) (Definition, bool) {
+	for _, def := range Catalog {
+		if def.Code == code {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
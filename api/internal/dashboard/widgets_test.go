@@ -0,0 +1,51 @@
+package dashboard
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalize(t *testing.T) {
+	widgets, err := Normalize([]Widget{
+		{Code: " Projects ", Size: "lg"},
+		{Code: "metrics"},
+		{Code: "projects", Size: "sm"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Widget{{Code: "projects", Size: "lg"}, {Code: "metrics", Size: SizeLarge}}
+	if !reflect.DeepEqual(widgets, want) {
+		t.Fatalf("Normalize = %+v, esperado %+v", widgets, want)
+	}
+
+	if _, err := Normalize([]Widget{{Code: "removido"}}, false); !errors.Is(err, ErrUnknownWidget) {
+		t.Fatalf("widget desconhecido deveria falhar, obteve %v", err)
+	}
+	if _, err := Normalize([]Widget{{Code: "metrics", Size: "xl"}}, false); err == nil {
+		t.Fatal("tamanho inválido deveria falhar")
+	}
+
+	widgets, err = Normalize([]Widget{{Code: "removido"}, {Code: "usage"}}, true)
+	if err != nil || len(widgets) != 1 || widgets[0].Code != WidgetUsage {
+		t.Fatalf("modo tolerante = %+v, %v", widgets, err)
+	}
+}
+
+func TestParseCodesAndDefault(t *testing.T) {
+	codes, err := ParseCodes("metrics, access_logs,,metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(codes, []string{"metrics", "access_logs"}) {
+		t.Fatalf("ParseCodes = %v", codes)
+	}
+
+	layout := Default(uuid.New())
+	if len(layout.Widgets) != len(Catalog) || layout.Custom {
+		t.Fatalf("layout padrão inesperado: %+v", layout)
+	}
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// PrimaryCluster é o banco de DB_DSN. Além dos dados dos tenants que ainda não foram movidos, ele
+// guarda o plano de controle: tenants, usuários do SaaS e o roteamento de cada tenant.
+const PrimaryCluster = "primary"
+
+// ParseClusters lê DB_CLUSTERS no formato "nome=postgres://...,outro=postgres://...". Senhas com
+// vírgula ou igual precisam de percent-encoding. O nome "primary" é reservado.
+func ParseClusters(raw string) (map[string]string, error) {
+	clusters := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, dsn, ok := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		dsn = strings.TrimSpace(dsn)
+		if !ok || name == "" || name == PrimaryCluster {
+			return nil, errors.New("DB_CLUSTERS inválido: use nome=dsn e não reutilize \"primary\"")
+		}
+		target, err := url.Parse(dsn)
+		if err != nil || target.Host == "" || (target.Scheme != "postgres" && target.Scheme != "postgresql") {
+			return nil, errors.New("DB_CLUSTERS inválido: dsn de " + name + " deve ser postgres://")
+		}
+		clusters[name] = dsn
+	}
+	return clusters, nil
+}
@@ -2,23 +2,107 @@ package db
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// PoolOptions ajusta dimensionamento, cache de statements e verificação de saúde do pool.
+type PoolOptions struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	// StatementCacheMode segue os modos do pgx: cache_statement (padrão), cache_describe,
+	// describe_exec, exec ou simple_protocol. Atrás de PgBouncer em modo transação use
+	// describe_exec ou simple_protocol.
+	StatementCacheMode     string
+	StatementCacheCapacity int
+}
+
+// DefaultPoolOptions devolve os parâmetros seguros usados pelas ferramentas de linha de comando.
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		MaxConns:               10,
+		MinConns:               1,
+		MaxConnLifetime:        30 * time.Minute,
+		MaxConnIdleTime:        5 * time.Minute,
+		HealthCheckPeriod:      30 * time.Second,
+		StatementCacheMode:     "cache_statement",
+		StatementCacheCapacity: 512,
+	}
+}
+
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// evictions conta conexões descartadas na devolução ao pool por estarem em estado inconsistente.
+var evictions atomic.Int64
+
 // NewPool inicializa o pool de conexões pgx com parâmetros seguros.
-func NewPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+func NewPool(ctx context.Context, dsn string, opts PoolOptions) (*pgxpool.Pool, error) {
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.MaxConns = 10
-	cfg.MinConns = 1
-	cfg.MaxConnLifetime = 30 * time.Minute
-	cfg.MaxConnIdleTime = 5 * time.Minute
-	cfg.HealthCheckPeriod = 30 * time.Second
+	defaults := DefaultPoolOptions()
+	if opts.MaxConns <= 0 {
+		opts.MaxConns = defaults.MaxConns
+	}
+	if opts.MinConns < 0 || opts.MinConns > opts.MaxConns {
+		opts.MinConns = min(defaults.MinConns, opts.MaxConns)
+	}
+	if opts.MaxConnLifetime <= 0 {
+		opts.MaxConnLifetime = defaults.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime <= 0 {
+		opts.MaxConnIdleTime = defaults.MaxConnIdleTime
+	}
+	if opts.HealthCheckPeriod <= 0 {
+		opts.HealthCheckPeriod = defaults.HealthCheckPeriod
+	}
+
+	cfg.MaxConns = opts.MaxConns
+	cfg.MinConns = opts.MinConns
+	cfg.MaxConnLifetime = opts.MaxConnLifetime
+	// Jitter evita que todas as conexões expirem juntas e provoquem rajada de reconexões.
+	cfg.MaxConnLifetimeJitter = opts.MaxConnLifetime / 10
+	cfg.MaxConnIdleTime = opts.MaxConnIdleTime
+	cfg.HealthCheckPeriod = opts.HealthCheckPeriod
+
+	if mode := strings.ToLower(strings.TrimSpace(opts.StatementCacheMode)); mode != "" {
+		execMode, ok := queryExecModes[mode]
+		if !ok {
+			return nil, fmt.Errorf("statement cache mode inválido: %s", opts.StatementCacheMode)
+		}
+		cfg.ConnConfig.DefaultQueryExecMode = execMode
+	}
+	if opts.StatementCacheCapacity > 0 {
+		cfg.ConnConfig.StatementCacheCapacity = opts.StatementCacheCapacity
+		cfg.ConnConfig.DescriptionCacheCapacity = opts.StatementCacheCapacity
+	}
+
+	// Conexões devolvidas ocupadas ou com transação aberta (panic no meio de um handler,
+	// por exemplo) são descartadas em vez de contaminar a próxima requisição.
+	cfg.AfterRelease = func(conn *pgx.Conn) bool {
+		pgConn := conn.PgConn()
+		if pgConn.IsClosed() || pgConn.IsBusy() || pgConn.TxStatus() != 'I' {
+			evictions.Add(1)
+			return false
+		}
+		return true
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -27,3 +111,42 @@ func NewPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// PoolStats resume o estado do pool para /metrics e /ready?deep=1.
+type PoolStats struct {
+	MaxConns                int32   `json:"max_conns"`
+	TotalConns              int32   `json:"total_conns"`
+	AcquiredConns           int32   `json:"acquired_conns"`
+	IdleConns               int32   `json:"idle_conns"`
+	ConstructingConns       int32   `json:"constructing_conns"`
+	AcquireCount            int64   `json:"acquire_count"`
+	AcquireDurationSeconds  float64 `json:"acquire_duration_seconds"`
+	EmptyAcquireCount       int64   `json:"empty_acquire_count"`
+	CanceledAcquireCount    int64   `json:"canceled_acquire_count"`
+	NewConnsCount           int64   `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64   `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64   `json:"max_idle_destroy_count"`
+	EvictedConnsCount       int64   `json:"evicted_conns_count"`
+}
+
+// Stats lê as estatísticas acumuladas do pool. EmptyAcquireCount indica quantas aquisições
+// precisaram esperar por conexão e CanceledAcquireCount quantas desistiram porque o contexto
+// da requisição expirou antes.
+func Stats(pool *pgxpool.Pool) PoolStats {
+	stat := pool.Stat()
+	return PoolStats{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDurationSeconds:  stat.AcquireDuration().Seconds(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+		EvictedConnsCount:       evictions.Load(),
+	}
+}
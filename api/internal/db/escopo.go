@@ -0,0 +1,8 @@
+package db
+
+// TurmaNoTenant devolve o filtro SQL que restringe a coluna de turma às turmas de escolas da
+// prefeitura passada no parâmetro indicado (ex.: "$3"). Turma sem escola não tem prefeitura e fica
+// de fora; com uuid.Nil no parâmetro nenhuma turma passa.
+func TurmaNoTenant(coluna, param string) string {
+	return coluna + ` IN (SELECT tt.id FROM turmas tt JOIN escolas te ON te.id = tt.escola_id WHERE te.tenant_id = ` + param + `::uuid)`
+}
@@ -0,0 +1,311 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrClusterNotConfigured indica tenant roteado para um cluster ausente de DB_CLUSTERS.
+	ErrClusterNotConfigured = errors.New("db: cluster do tenant não configurado")
+	// ErrTenantFrozen indica tenant congelado (tenants.db_frozen) pelo switch de uma migração
+	// entre clusters; nada deve ler ou gravar os dados dele até a troca terminar.
+	ErrTenantFrozen = errors.New("db: tenant congelado pela migração de cluster")
+)
+
+type (
+	poolContextKey    struct{}
+	slotContextKey    struct{}
+	tenantsContextKey struct{}
+)
+
+// WithPool fixa no contexto o pool do banco onde estão os dados do tenant da requisição.
+func WithPool(ctx context.Context, pool *pgxpool.Pool) context.Context {
+	return context.WithValue(ctx, poolContextKey{}, pool)
+}
+
+// poolSlot guarda o pool escolhido depois que o contexto já foi repassado adiante.
+type poolSlot struct {
+	mu   sync.Mutex
+	pool *pgxpool.Pool
+}
+
+// WithPoolSlot reserva no contexto um lugar para o pool do tenant, preenchido depois por SetPool.
+// Serve às rotas em que a prefeitura só é conhecida dentro do handler (secretaria e tenant admin
+// escolhem por ?tenant_id): como o chi.RouteContext, o valor é compartilhado, e o contexto de quem
+// reservou passa a enxergar o pool fixado.
+func WithPoolSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, slotContextKey{}, &poolSlot{})
+}
+
+// SetPool preenche o lugar reservado por WithPoolSlot; sem reserva no contexto devolve false e
+// as consultas seguem no pool padrão dos repositórios.
+func SetPool(ctx context.Context, pool *pgxpool.Pool) bool {
+	slot, ok := ctx.Value(slotContextKey{}).(*poolSlot)
+	if !ok {
+		return false
+	}
+	slot.mu.Lock()
+	slot.pool = pool
+	slot.mu.Unlock()
+	return true
+}
+
+// Conn devolve o pool fixado no contexto por WithPool ou SetPool ou, sem ele, o pool padrão do
+// repositório. Repositórios de dados de tenant chamam Conn em vez de usar o pool direto, e assim
+// seguem o roteamento sem que serviços e handlers precisem saber em que cluster o tenant está.
+func Conn(ctx context.Context, fallback *pgxpool.Pool) *pgxpool.Pool {
+	if pool, ok := ctx.Value(poolContextKey{}).(*pgxpool.Pool); ok && pool != nil {
+		return pool
+	}
+	if slot, ok := ctx.Value(slotContextKey{}).(*poolSlot); ok {
+		slot.mu.Lock()
+		pool := slot.pool
+		slot.mu.Unlock()
+		if pool != nil {
+			return pool
+		}
+	}
+	return fallback
+}
+
+// Tenants devolve os tenants roteados para o pool do contexto quando ele vem de EachCluster, ou
+// nil fora dele. Consultas que varrem vários tenants filtram com
+// ($n::uuid[] IS NULL OR tenant_id = ANY($n)) para não processar a cópia antiga de um tenant
+// movido nem um tenant congelado pelo switch.
+func Tenants(ctx context.Context) []uuid.UUID {
+	tenants, _ := ctx.Value(tenantsContextKey{}).([]uuid.UUID)
+	return tenants
+}
+
+type rota struct {
+	cluster   string
+	congelado bool
+	expira    time.Time
+}
+
+// conexao acompanha a abertura do pool de um cluster; quem chega durante a abertura espera pronto
+// em vez de discar de novo.
+type conexao struct {
+	pronto chan struct{}
+	pool   *pgxpool.Pool
+	err    error
+}
+
+// Resolver mapeia cada tenant para o pool do seu cluster. O mapa fica em tenants.db_cluster no
+// banco principal e é mantido em cache por ttl; pools dos outros clusters são abertos na primeira
+// requisição que precisar deles.
+type Resolver struct {
+	primary  *pgxpool.Pool
+	clusters map[string]string
+	opts     PoolOptions
+	ttl      time.Duration
+	lookup   func(ctx context.Context, tenantID uuid.UUID) (rota, error)
+	listar   func(ctx context.Context, cluster string) ([]uuid.UUID, error)
+	dial     func(ctx context.Context, dsn string, opts PoolOptions) (*pgxpool.Pool, error)
+	now      func() time.Time
+
+	mu      sync.Mutex
+	rotas   map[uuid.UUID]rota
+	pools   map[string]*pgxpool.Pool
+	abrindo map[string]*conexao
+}
+
+// NewResolver cria o roteador; clusters vem de ParseClusters e opts dimensiona os pools extras.
+func NewResolver(primary *pgxpool.Pool, clusters map[string]string, opts PoolOptions, ttl time.Duration) *Resolver {
+	r := &Resolver{
+		primary:  primary,
+		clusters: clusters,
+		opts:     opts,
+		ttl:      ttl,
+		dial:     NewPool,
+		now:      time.Now,
+		rotas:    make(map[uuid.UUID]rota),
+		pools:    make(map[string]*pgxpool.Pool),
+		abrindo:  make(map[string]*conexao),
+	}
+	r.lookup = r.consultarCluster
+	r.listar = r.tenantsDoCluster
+	return r
+}
+
+func (r *Resolver) consultarCluster(ctx context.Context, tenantID uuid.UUID) (rota, error) {
+	var rt rota
+	err := r.primary.QueryRow(ctx, `SELECT db_cluster, db_frozen FROM tenants WHERE id = $1`, tenantID).Scan(&rt.cluster, &rt.congelado)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rota{cluster: PrimaryCluster}, nil
+	}
+	return rt, err
+}
+
+// rota devolve o roteamento do tenant, consultando o banco só quando o cache expirou.
+func (r *Resolver) rota(ctx context.Context, tenantID uuid.UUID) (rota, error) {
+	r.mu.Lock()
+	cached, ok := r.rotas[tenantID]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expira) {
+		return cached, nil
+	}
+
+	rt, err := r.lookup(ctx, tenantID)
+	if err != nil {
+		return rota{}, fmt.Errorf("rotear tenant: %w", err)
+	}
+	rt.expira = r.now().Add(r.ttl)
+	r.mu.Lock()
+	r.rotas[tenantID] = rt
+	r.mu.Unlock()
+	return rt, nil
+}
+
+// Cluster devolve o nome do cluster do tenant.
+func (r *Resolver) Cluster(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	rt, err := r.rota(ctx, tenantID)
+	return rt.cluster, err
+}
+
+// Pool devolve o pool do cluster do tenant, ou ErrTenantFrozen enquanto o switch de uma migração
+// estiver em andamento. O congelamento também fica em cache por ttl; por isso o switch espera ao
+// menos esse tempo antes de conferir a cópia.
+func (r *Resolver) Pool(ctx context.Context, tenantID uuid.UUID) (*pgxpool.Pool, error) {
+	rt, err := r.rota(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if rt.congelado {
+		return nil, ErrTenantFrozen
+	}
+	return r.clusterPool(ctx, rt.cluster)
+}
+
+// WithTenant resolve o pool do tenant e o fixa no contexto com WithPool.
+func (r *Resolver) WithTenant(ctx context.Context, tenantID uuid.UUID) (context.Context, error) {
+	pool, err := r.Pool(ctx, tenantID)
+	if err != nil {
+		return ctx, err
+	}
+	return WithPool(ctx, pool), nil
+}
+
+// Pools devolve o pool de cada cluster conhecido, começando pelo principal. Jobs que varrem
+// todos os tenants usam a lista para não esquecer os que foram movidos.
+func (r *Resolver) Pools(ctx context.Context) ([]*pgxpool.Pool, error) {
+	pools := []*pgxpool.Pool{r.primary}
+	for name := range r.clusters {
+		pool, err := r.clusterPool(ctx, name)
+		if err != nil {
+			return pools, err
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// EachCluster roda fn uma vez por cluster, o principal primeiro, com o pool do cluster fixado no
+// contexto e Tenants restrito aos tenants roteados para ele e não congelados. Jobs e buscas que
+// atravessam tenants (login de responsáveis, escopo da secretaria) passam por aqui; a falha de um
+// cluster não impede os demais e os erros voltam juntos.
+func (r *Resolver) EachCluster(ctx context.Context, fn func(ctx context.Context) error) error {
+	nomes := make([]string, 0, len(r.clusters))
+	for name := range r.clusters {
+		nomes = append(nomes, name)
+	}
+	sort.Strings(nomes)
+
+	var errs []error
+	for _, name := range append([]string{PrimaryCluster}, nomes...) {
+		tenants, err := r.listar(ctx, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", name, err))
+			continue
+		}
+		pool, err := r.clusterPool(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := fn(context.WithValue(WithPool(ctx, pool), tenantsContextKey{}, tenants)); err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PorCluster adapta um job do scheduler para rodar em todos os clusters com EachCluster.
+func (r *Resolver) PorCluster(fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error { return r.EachCluster(ctx, fn) }
+}
+
+func (r *Resolver) tenantsDoCluster(ctx context.Context, cluster string) ([]uuid.UUID, error) {
+	rows, err := r.primary.Query(ctx, `SELECT id FROM tenants WHERE db_cluster = $1 AND NOT db_frozen`, cluster)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if tenants == nil {
+		// Lista vazia, e não nil: um cluster sem tenants não casa com nenhum.
+		tenants = []uuid.UUID{}
+	}
+	return tenants, err
+}
+
+func (r *Resolver) clusterPool(ctx context.Context, cluster string) (*pgxpool.Pool, error) {
+	if cluster == PrimaryCluster {
+		return r.primary, nil
+	}
+	r.mu.Lock()
+	if pool, ok := r.pools[cluster]; ok {
+		r.mu.Unlock()
+		return pool, nil
+	}
+	dsn, ok := r.clusters[cluster]
+	if !ok {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrClusterNotConfigured, cluster)
+	}
+	// A conexão é aberta fora do lock: um cluster lento não pode travar o roteamento (Cluster) nem
+	// os tenants dos outros clusters.
+	if c, ok := r.abrindo[cluster]; ok {
+		r.mu.Unlock()
+		select {
+		case <-c.pronto:
+			return c.pool, c.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c := &conexao{pronto: make(chan struct{})}
+	r.abrindo[cluster] = c
+	r.mu.Unlock()
+
+	c.pool, c.err = r.dial(ctx, dsn, r.opts)
+	if c.err != nil {
+		c.err = fmt.Errorf("conectar ao cluster %s: %w", cluster, c.err)
+	}
+	r.mu.Lock()
+	delete(r.abrindo, cluster)
+	if c.err == nil {
+		r.pools[cluster] = c.pool
+	}
+	r.mu.Unlock()
+	close(c.pronto)
+	return c.pool, c.err
+}
+
+// Close fecha os pools abertos para os outros clusters; o principal continua com quem o criou.
+func (r *Resolver) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, pool := range r.pools {
+		pool.Close()
+		delete(r.pools, name)
+	}
+}
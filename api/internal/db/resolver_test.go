@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestResolverClusterCache(t *testing.T) {
+	tenantID := uuid.New()
+	agora := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	consultas := 0
+	destino := PrimaryCluster
+	congelado := false
+
+	r := NewResolver(nil, map[string]string{"cluster2": "postgres://db2/app"}, PoolOptions{}, time.Minute)
+	r.now = func() time.Time { return agora }
+	r.lookup = func(context.Context, uuid.UUID) (rota, error) {
+		consultas++
+		return rota{cluster: destino, congelado: congelado}, nil
+	}
+
+	ctx := context.Background()
+	if got, _ := r.Cluster(ctx, tenantID); got != PrimaryCluster {
+		t.Fatalf("cluster = %s", got)
+	}
+	destino = "cluster2"
+	if got, _ := r.Cluster(ctx, tenantID); got != PrimaryCluster || consultas != 1 {
+		t.Fatalf("cache ignorado: cluster = %s, consultas = %d", got, consultas)
+	}
+	agora = agora.Add(2 * time.Minute)
+	if got, _ := r.Cluster(ctx, tenantID); got != "cluster2" || consultas != 2 {
+		t.Fatalf("cache não expirou: cluster = %s, consultas = %d", got, consultas)
+	}
+
+	destino = "cluster9"
+	agora = agora.Add(2 * time.Minute)
+	if _, err := r.Pool(ctx, tenantID); !errors.Is(err, ErrClusterNotConfigured) {
+		t.Fatalf("err = %v, want ErrClusterNotConfigured", err)
+	}
+
+	destino, congelado = PrimaryCluster, true
+	agora = agora.Add(2 * time.Minute)
+	if _, err := r.Pool(ctx, tenantID); !errors.Is(err, ErrTenantFrozen) {
+		t.Fatalf("err = %v, want ErrTenantFrozen", err)
+	}
+}
+
+func TestResolverDialForaDoLock(t *testing.T) {
+	r := NewResolver(nil, map[string]string{"cluster2": "postgres://db2/app"}, PoolOptions{}, time.Minute)
+	r.lookup = func(context.Context, uuid.UUID) (rota, error) { return rota{cluster: PrimaryCluster}, nil }
+	liberar := make(chan struct{})
+	discando := make(chan struct{}, 2)
+	var mu sync.Mutex
+	discagens := 0
+	r.dial = func(context.Context, string, PoolOptions) (*pgxpool.Pool, error) {
+		mu.Lock()
+		discagens++
+		mu.Unlock()
+		discando <- struct{}{}
+		<-liberar
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.clusterPool(ctx, "cluster2"); err != nil {
+				t.Errorf("clusterPool: %v", err)
+			}
+		}()
+	}
+	<-discando
+
+	// Com o cluster2 ainda conectando, o roteamento dos outros tenants segue livre.
+	feito := make(chan struct{})
+	go func() {
+		_, _ = r.Cluster(ctx, uuid.New())
+		close(feito)
+	}()
+	select {
+	case <-feito:
+	case <-time.After(time.Second):
+		t.Fatal("Cluster bloqueado pela conexão ao cluster2")
+	}
+
+	close(liberar)
+	wg.Wait()
+	if discagens != 1 {
+		t.Fatalf("discagens = %d, want 1", discagens)
+	}
+}
+
+func TestResolverEachCluster(t *testing.T) {
+	primary := &pgxpool.Pool{}
+	outro := &pgxpool.Pool{}
+	r := NewResolver(primary, map[string]string{"cluster2": "postgres://db2/app", "cluster3": "postgres://db3/app"}, PoolOptions{}, time.Minute)
+	r.dial = func(_ context.Context, dsn string, _ PoolOptions) (*pgxpool.Pool, error) {
+		if dsn == "postgres://db3/app" {
+			return nil, errors.New("fora do ar")
+		}
+		return outro, nil
+	}
+	doPrimary, doCluster2 := uuid.New(), uuid.New()
+	r.listar = func(_ context.Context, cluster string) ([]uuid.UUID, error) {
+		switch cluster {
+		case PrimaryCluster:
+			return []uuid.UUID{doPrimary}, nil
+		case "cluster2":
+			return []uuid.UUID{doCluster2}, nil
+		}
+		return []uuid.UUID{}, nil
+	}
+
+	var visitas []string
+	err := r.EachCluster(context.Background(), func(ctx context.Context) error {
+		tenants := Tenants(ctx)
+		switch Conn(ctx, nil) {
+		case primary:
+			if len(tenants) != 1 || tenants[0] != doPrimary {
+				t.Errorf("tenants do primary = %v", tenants)
+			}
+			visitas = append(visitas, PrimaryCluster)
+		case outro:
+			if len(tenants) != 1 || tenants[0] != doCluster2 {
+				t.Errorf("tenants do cluster2 = %v", tenants)
+			}
+			visitas = append(visitas, "cluster2")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("falha do cluster3 não reportada")
+	}
+	if len(visitas) != 2 || visitas[0] != PrimaryCluster || visitas[1] != "cluster2" {
+		t.Fatalf("visitas = %v", visitas)
+	}
+	if Tenants(context.Background()) != nil {
+		t.Fatal("Tenants fora de EachCluster deve ser nil")
+	}
+}
+
+func TestPoolSlot(t *testing.T) {
+	padrao, fixado := &pgxpool.Pool{}, &pgxpool.Pool{}
+	if SetPool(context.Background(), fixado) {
+		t.Fatal("SetPool sem reserva no contexto")
+	}
+	ctx := WithPoolSlot(context.Background())
+	filho := context.WithValue(ctx, struct{}{}, 1)
+	if Conn(ctx, padrao) != padrao {
+		t.Fatal("reserva vazia deve cair no pool padrão")
+	}
+	if !SetPool(filho, fixado) || Conn(ctx, padrao) != fixado {
+		t.Fatal("pool fixado no contexto filho não chegou a quem reservou")
+	}
+}
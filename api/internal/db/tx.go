@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -21,3 +22,21 @@ func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(pctx context.Contex
 
 	return tx.Commit(ctx)
 }
+
+// SetActor identifica quem executa a transação; triggers de auditoria leem app.actor_id.
+func SetActor(ctx context.Context, tx pgx.Tx, actorID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('app.actor_id', $1, true)`, actorID.String())
+	return err
+}
+
+// WithActorTx executa fn numa transação já identificada com o autor, quando informado.
+func WithActorTx(ctx context.Context, pool *pgxpool.Pool, actorID *uuid.UUID, fn func(pctx context.Context, tx pgx.Tx) error) error {
+	return WithTx(ctx, pool, func(pctx context.Context, tx pgx.Tx) error {
+		if actorID != nil {
+			if err := SetActor(pctx, tx, *actorID); err != nil {
+				return err
+			}
+		}
+		return fn(pctx, tx)
+	})
+}
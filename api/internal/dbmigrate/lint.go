@@ -0,0 +1,320 @@
+// Package dbmigrate protege o banco de produção durante migrações: um linter recusa operações que
+// travam tabelas movimentadas (índice sem CONCURRENTLY, default volátil, troca de tipo) e um
+// executor aplica as migrações em fases expand/contract, liberando a contração só depois que a
+// feature flag associada estiver ligada.
+package dbmigrate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GuardrailsDesde é a primeira migração verificada; as anteriores já rodaram em produção antes
+// das travas existirem e ficam como estão.
+const GuardrailsDesde = 92
+
+const (
+	PhaseExpand   = "expand"
+	PhaseContract = "contract"
+)
+
+// Regras do linter; cada uma pode ser liberada na migração com "-- migrate:allow regra motivo".
+const (
+	RuleIndexConcurrently  = "index-concurrently"
+	RuleConcurrentlyAlone  = "concurrently-alone"
+	RuleVolatileDefault    = "volatile-default"
+	RuleColumnType         = "column-type"
+	RuleNotNullSemDefault  = "not-null-without-default"
+	RuleSetNotNull         = "set-not-null"
+	RuleConstraintNotValid = "constraint-not-valid"
+	RuleDestructiveExpand  = "destructive-expand"
+	RuleContractFlag       = "contract-flag"
+)
+
+// Migration é um arquivo .up.sql com as diretivas lidas do cabeçalho.
+type Migration struct {
+	Version int
+	Name    string
+	Path    string
+	SQL     string
+	// Phase é expand (padrão) ou contract, de "-- migrate:phase contract".
+	Phase string
+	// Flag é a feature flag que precisa estar ligada para a contração rodar.
+	Flag  string
+	Allow map[string]bool
+}
+
+// Finding é uma violação encontrada pelo linter.
+type Finding struct {
+	Version int    `json:"version"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Table   string `json:"table,omitempty"`
+	Message string `json:"message"`
+	// SizeSensitive marca regras que só importam em tabela grande; o executor as descarta quando
+	// a tabela é pequena.
+	SizeSensitive bool `json:"size_sensitive,omitempty"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %s", f.File, f.Line, f.Rule, f.Message)
+}
+
+var fileVersion = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+
+// LoadDir lê as migrações .up.sql do diretório em ordem de versão.
+func LoadDir(dir string) ([]Migration, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return nil, err
+	}
+	var migs []Migration
+	for _, path := range paths {
+		match := fileVersion.FindStringSubmatch(filepath.Base(path))
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		mig, err := Parse(version, match[2], string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		mig.Path = path
+		migs = append(migs, mig)
+	}
+	sort.Slice(migs, func(i, j int) bool { return migs[i].Version < migs[j].Version })
+	for i := 1; i < len(migs); i++ {
+		if migs[i].Version == migs[i-1].Version {
+			return nil, fmt.Errorf("versão %d duplicada", migs[i].Version)
+		}
+	}
+	return migs, nil
+}
+
+// Parse lê as diretivas "-- migrate:" do SQL.
+func Parse(version int, name, sql string) (Migration, error) {
+	mig := Migration{Version: version, Name: name, SQL: sql, Phase: PhaseExpand, Allow: map[string]bool{}}
+	scanner := bufio.NewScanner(strings.NewReader(sql))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		rest, ok := strings.CutPrefix(line, "-- migrate:")
+		if !ok {
+			continue
+		}
+		directive, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		fields := strings.Fields(value)
+		switch directive {
+		case "phase":
+			if len(fields) == 0 || (fields[0] != PhaseExpand && fields[0] != PhaseContract) {
+				return mig, fmt.Errorf("fase inválida: %q", value)
+			}
+			mig.Phase = fields[0]
+		case "requires-flag":
+			if len(fields) == 0 {
+				return mig, fmt.Errorf("requires-flag sem nome")
+			}
+			mig.Flag = fields[0]
+		case "allow":
+			if len(fields) < 2 {
+				return mig, fmt.Errorf("allow precisa de regra e motivo")
+			}
+			for _, rule := range strings.Split(fields[0], ",") {
+				mig.Allow[rule] = true
+			}
+		default:
+			return mig, fmt.Errorf("diretiva desconhecida: %s", directive)
+		}
+	}
+	return mig, scanner.Err()
+}
+
+type statement struct {
+	text string
+	line int
+}
+
+// splitStatements separa o SQL em comandos, ignorando comentários e respeitando strings e
+// blocos $$ de funções. O texto devolvido vem sem comentários e com espaços normalizados.
+func splitStatements(sql string) []statement {
+	var (
+		out     []statement
+		current strings.Builder
+		line    = 1
+		start   = 0
+	)
+	flush := func() {
+		text := strings.Join(strings.Fields(current.String()), " ")
+		if text != "" {
+			out = append(out, statement{text: text, line: start})
+		}
+		current.Reset()
+		start = 0
+	}
+	write := func(s string) {
+		if start == 0 && strings.TrimSpace(s) != "" {
+			start = line
+		}
+		current.WriteString(s)
+		line += strings.Count(s, "\n")
+	}
+
+	for i := 0; i < len(sql); {
+		rest := sql[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest) - 4
+			}
+			line += strings.Count(rest[:end+4], "\n")
+			current.WriteString(" ")
+			i += end + 4
+		case rest[0] == '\'' || rest[0] == '"':
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			write(rest[:end+2])
+			i += end + 2
+		case rest[0] == '$':
+			tag := reDollarTag.FindString(rest)
+			if tag == "" {
+				write("$")
+				i++
+				continue
+			}
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest) - 2*len(tag)
+			}
+			// O corpo de funções não é analisado: só os comandos de fora afetam o schema agora.
+			write(tag + tag)
+			line += strings.Count(rest[:len(tag)+end+len(tag)], "\n")
+			i += len(tag) + end + len(tag)
+		case rest[0] == ';':
+			flush()
+			i++
+		default:
+			write(rest[:1])
+			i++
+		}
+	}
+	flush()
+	return out
+}
+
+var (
+	reCreateTable   = regexp.MustCompile(`(?i)^CREATE (?:UNLOGGED )?TABLE (?:IF NOT EXISTS )?([\w."]+)`)
+	reCreateIndex   = regexp.MustCompile(`(?i)^CREATE (?:UNIQUE )?INDEX\b`)
+	reIndexTable    = regexp.MustCompile(`(?i)\bON (?:ONLY )?([\w."]+)`)
+	reConcurrently  = regexp.MustCompile(`(?i)\bCONCURRENTLY\b`)
+	reAlterTable    = regexp.MustCompile(`(?i)^ALTER TABLE (?:IF EXISTS )?(?:ONLY )?([\w."]+)`)
+	reAddColumn     = regexp.MustCompile(`(?i)\bADD (?:COLUMN )?(?:IF NOT EXISTS )?([\w"]+) ([^,]*)`)
+	reVolatile      = regexp.MustCompile(`(?i)\bDEFAULT\b.*\b(gen_random_uuid|uuid_generate_v[14]|random|clock_timestamp|timeofday|nextval)\s*\(`)
+	reSerial        = regexp.MustCompile(`(?i)^(?:SMALL|BIG)?SERIAL\b`)
+	reNotNull       = regexp.MustCompile(`(?i)\bNOT NULL\b`)
+	reDefault       = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	reColumnType    = regexp.MustCompile(`(?i)\bALTER (?:COLUMN )?[\w"]+ (?:SET DATA )?TYPE\b`)
+	reSetNotNull    = regexp.MustCompile(`(?i)\bALTER (?:COLUMN )?[\w"]+ SET NOT NULL\b`)
+	reAddConstraint = regexp.MustCompile(`(?i)\bADD CONSTRAINT [\w"]+ (FOREIGN KEY|CHECK)\b`)
+	reNotValid      = regexp.MustCompile(`(?i)\bNOT VALID\b`)
+	reDestructive   = regexp.MustCompile(`(?i)^DROP TABLE\b|\bDROP COLUMN\b|^ALTER TABLE .*\bRENAME\b`)
+	reDollarTag     = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+)
+
+func tableName(raw string) string {
+	name := strings.ToLower(strings.ReplaceAll(raw, `"`, ""))
+	return strings.TrimPrefix(name, "public.")
+}
+
+// Lint verifica a migração contra as regras de zero downtime. Tabelas criadas no próprio arquivo
+// estão vazias e não geram alerta.
+func Lint(mig Migration) []Finding {
+	file := fmt.Sprintf("%03d_%s.up.sql", mig.Version, mig.Name)
+	stmts := splitStatements(mig.SQL)
+	novas := map[string]bool{}
+	for _, stmt := range stmts {
+		if m := reCreateTable.FindStringSubmatch(stmt.text); m != nil {
+			novas[tableName(m[1])] = true
+		}
+	}
+
+	var findings []Finding
+	add := func(stmt statement, rule, table, msg string, sizeSensitive bool) {
+		if mig.Allow[rule] {
+			return
+		}
+		findings = append(findings, Finding{Version: mig.Version, File: file, Line: stmt.line, Rule: rule, Table: table, Message: msg, SizeSensitive: sizeSensitive})
+	}
+
+	if mig.Phase == PhaseContract && mig.Flag == "" {
+		add(statement{line: 1}, RuleContractFlag, "", "migração de contração precisa de -- migrate:requires-flag", false)
+	}
+
+	for _, stmt := range stmts {
+		text := stmt.text
+		if reConcurrently.MatchString(text) && len(stmts) > 1 {
+			add(stmt, RuleConcurrentlyAlone, "", "CONCURRENTLY não roda em transação; deixe o comando sozinho no arquivo", false)
+		}
+
+		if reCreateIndex.MatchString(text) && !reConcurrently.MatchString(text) {
+			if m := reIndexTable.FindStringSubmatch(text); m != nil && !novas[tableName(m[1])] {
+				add(stmt, RuleIndexConcurrently, tableName(m[1]), "índice em tabela existente sem CONCURRENTLY bloqueia escritas durante a criação", false)
+			}
+		}
+
+		if mig.Phase == PhaseExpand && reDestructive.MatchString(text) {
+			add(stmt, RuleDestructiveExpand, "", "remoção ou renomeação quebra a versão anterior da API; use uma migração de contração", false)
+		}
+
+		m := reAlterTable.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		table := tableName(m[1])
+		if novas[table] {
+			continue
+		}
+		for _, col := range reAddColumn.FindAllStringSubmatch(text, -1) {
+			switch strings.ToUpper(col[1]) {
+			case "CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE":
+				continue
+			}
+			def := col[2]
+			if reVolatile.MatchString(def) || reSerial.MatchString(strings.TrimSpace(def)) {
+				add(stmt, RuleVolatileDefault, table, "coluna com default volátil reescreve a tabela inteira; adicione sem default e preencha em lotes", true)
+			}
+			if reNotNull.MatchString(def) && !reDefault.MatchString(def) {
+				add(stmt, RuleNotNullSemDefault, table, "coluna NOT NULL sem DEFAULT falha em tabela com linhas", false)
+			}
+		}
+		if reColumnType.MatchString(text) {
+			add(stmt, RuleColumnType, table, "troca de tipo reescreve a tabela sob lock exclusivo", true)
+		}
+		if reSetNotNull.MatchString(text) {
+			add(stmt, RuleSetNotNull, table, "SET NOT NULL varre a tabela sob lock exclusivo; valide antes um CHECK NOT VALID", true)
+		}
+		if reAddConstraint.MatchString(text) && !reNotValid.MatchString(text) {
+			add(stmt, RuleConstraintNotValid, table, "constraint nova valida todas as linhas sob lock; crie com NOT VALID e rode VALIDATE CONSTRAINT depois", true)
+		}
+	}
+	return findings
+}
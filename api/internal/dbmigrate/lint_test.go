@@ -0,0 +1,69 @@
+package dbmigrate
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func rules(t *testing.T, sql string) []string {
+	t.Helper()
+	mig, err := Parse(100, "teste", sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, f := range Lint(mig) {
+		out = append(out, f.Rule)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestLint(t *testing.T) {
+	cases := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"tabela nova com índice", `CREATE TABLE x (id uuid DEFAULT gen_random_uuid()); CREATE INDEX idx_x ON x (id);`, ""},
+		{"índice em tabela existente", `CREATE INDEX idx_presencas ON presencas (aula_inicio);`, RuleIndexConcurrently},
+		{"índice concorrente sozinho", `CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_p ON presencas (aula_inicio);`, ""},
+		{"concorrente acompanhado", "CREATE INDEX CONCURRENTLY idx_p ON presencas (a);\nALTER TABLE notas ADD COLUMN obs TEXT;", RuleConcurrentlyAlone},
+		{"default volátil", `ALTER TABLE presencas ADD COLUMN token UUID NOT NULL DEFAULT gen_random_uuid();`, RuleVolatileDefault},
+		{"default estável", `ALTER TABLE presencas ADD COLUMN criada TIMESTAMPTZ NOT NULL DEFAULT now();`, ""},
+		{"not null sem default", `ALTER TABLE alunos ADD COLUMN cpf TEXT NOT NULL;`, RuleNotNullSemDefault},
+		{"troca de tipo", `ALTER TABLE notas ALTER COLUMN nota TYPE NUMERIC(5,2);`, RuleColumnType},
+		{"fk sem not valid", `ALTER TABLE notas ADD CONSTRAINT fk_x FOREIGN KEY (turma_id) REFERENCES turmas (id);`, RuleConstraintNotValid},
+		{"fk not valid", `ALTER TABLE notas ADD CONSTRAINT fk_x FOREIGN KEY (turma_id) REFERENCES turmas (id) NOT VALID;`, ""},
+		{"drop em expand", `ALTER TABLE notas DROP COLUMN obs;`, RuleDestructiveExpand},
+		{"contração sem flag", "-- migrate:phase contract\nALTER TABLE notas DROP COLUMN obs;", RuleContractFlag},
+		{"contração com flag", "-- migrate:phase contract\n-- migrate:requires-flag notas_obs_v2\nALTER TABLE notas DROP COLUMN obs;", ""},
+		{"regra liberada", "-- migrate:allow index-concurrently tabela com poucas linhas\nCREATE INDEX idx_t ON tenants (slug);", ""},
+		{"texto em função", "CREATE FUNCTION f() RETURNS void AS $$ BEGIN ALTER TABLE t DROP COLUMN c; END; $$ LANGUAGE plpgsql;", ""},
+	}
+	for _, tc := range cases {
+		if got := strings.Join(rules(t, tc.sql), ","); got != tc.want {
+			t.Errorf("%s: regras = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// As migrações do repositório a partir de GuardrailsDesde precisam passar no linter.
+func TestRepoMigrations(t *testing.T) {
+	migs, err := LoadDir("../../migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migs) == 0 {
+		t.Fatal("nenhuma migração encontrada")
+	}
+	for _, mig := range migs {
+		if mig.Version < GuardrailsDesde {
+			continue
+		}
+		for _, f := range Lint(mig) {
+			t.Error(f.String())
+		}
+	}
+}
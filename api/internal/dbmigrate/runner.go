@@ -0,0 +1,225 @@
+package dbmigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrDirty        = errors.New("dbmigrate: schema_migrations marcada como suja; corrija a migração que falhou antes de continuar")
+	ErrGuardrails   = errors.New("dbmigrate: migração recusada pelas travas de zero downtime")
+	ErrFaseInvalida = errors.New("dbmigrate: fase deve ser expand ou contract")
+)
+
+// Runner aplica migrações no mesmo formato de controle do golang-migrate (schema_migrations com
+// version e dirty), de modo que as duas ferramentas podem ser usadas no mesmo banco.
+type Runner struct {
+	pool   *pgxpool.Pool
+	logger zerolog.Logger
+	// BigRows é a partir de quantas linhas estimadas uma tabela conta como grande.
+	BigRows int64
+	// LockTimeout limita quanto cada comando espera por lock antes de desistir, para que uma
+	// migração nunca enfileire as requisições do app atrás dela.
+	LockTimeout time.Duration
+}
+
+// NewRunner cria o executor com os limites padrão.
+func NewRunner(pool *pgxpool.Pool, logger zerolog.Logger) *Runner {
+	return &Runner{pool: pool, logger: logger, BigRows: 100_000, LockTimeout: 5 * time.Second}
+}
+
+// Status devolve a versão aplicada e se a última migração falhou no meio.
+func (r *Runner) Status(ctx context.Context) (int, bool, error) {
+	if _, err := r.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		return 0, false, err
+	}
+	var version int
+	var dirty bool
+	err := r.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Check roda o linter e, para regras que só pesam em tabela grande, consulta o tamanho estimado
+// da tabela: abaixo de BigRows o alerta é descartado.
+func (r *Runner) Check(ctx context.Context, mig Migration) ([]Finding, error) {
+	if mig.Version < GuardrailsDesde {
+		return nil, nil
+	}
+	var out []Finding
+	for _, f := range Lint(mig) {
+		if f.SizeSensitive && f.Table != "" {
+			rows, err := r.estimatedRows(ctx, f.Table)
+			if err != nil {
+				return nil, err
+			}
+			if rows >= 0 && rows < r.BigRows {
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// estimatedRows lê a estimativa do planner; -1 significa tabela nunca analisada, tratada como grande.
+func (r *Runner) estimatedRows(ctx context.Context, table string) (int64, error) {
+	var rows int64
+	err := r.pool.QueryRow(ctx, `
+        SELECT COALESCE((SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)), 0)
+    `, table).Scan(&rows)
+	return rows, err
+}
+
+// FlagAtiva informa se a feature flag está ligada; flag inexistente conta como desligada.
+func (r *Runner) FlagAtiva(ctx context.Context, nome string) (bool, error) {
+	var ativa bool
+	err := r.pool.QueryRow(ctx, `SELECT ativa FROM feature_flags WHERE nome = $1`, nome).Scan(&ativa)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return ativa, err
+}
+
+// Pending devolve as migrações ainda não aplicadas que a fase permite, em ordem. A sequência é
+// linear: a fase expand para na primeira contração pendente, e a contract para na primeira cuja
+// feature flag ainda está desligada. O motivo da parada vem em bloqueio.
+func (r *Runner) Pending(ctx context.Context, migs []Migration, phase string) (pending []Migration, bloqueio string, err error) {
+	if phase != PhaseExpand && phase != PhaseContract {
+		return nil, "", ErrFaseInvalida
+	}
+	version, dirty, err := r.Status(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if dirty {
+		return nil, "", fmt.Errorf("%w (versão %d)", ErrDirty, version)
+	}
+	for _, mig := range migs {
+		if mig.Version <= version {
+			continue
+		}
+		if mig.Phase == PhaseContract {
+			if phase == PhaseExpand {
+				return pending, fmt.Sprintf("%03d_%s é de contração; rode a fase contract depois do deploy", mig.Version, mig.Name), nil
+			}
+			ativa, err := r.FlagAtiva(ctx, mig.Flag)
+			if err != nil {
+				return nil, "", err
+			}
+			if !ativa {
+				return pending, fmt.Sprintf("%03d_%s espera a flag %s", mig.Version, mig.Name, mig.Flag), nil
+			}
+		}
+		pending = append(pending, mig)
+	}
+	return pending, "", nil
+}
+
+// Up confere todas as migrações pendentes da fase antes de aplicar qualquer uma e então as
+// aplica em ordem. Cada migração roda como um único comando (transação implícita), com
+// lock_timeout, e marca schema_migrations como suja enquanto executa.
+func (r *Runner) Up(ctx context.Context, migs []Migration, phase string, dryRun bool) ([]Migration, error) {
+	pending, bloqueio, err := r.Pending(ctx, migs, phase)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, mig := range pending {
+		found, err := r.Check(ctx, mig)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, found...)
+	}
+	if len(findings) > 0 {
+		for _, f := range findings {
+			r.logger.Error().Str("regra", f.Rule).Str("arquivo", f.File).Int("linha", f.Line).Msg(f.Message)
+		}
+		return nil, fmt.Errorf("%w: %d problema(s)", ErrGuardrails, len(findings))
+	}
+	if bloqueio != "" {
+		r.logger.Info().Msg("parando antes de " + bloqueio)
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	var applied []Migration
+	for _, mig := range pending {
+		if err := r.apply(ctx, mig); err != nil {
+			return applied, fmt.Errorf("%03d_%s: %w", mig.Version, mig.Name, err)
+		}
+		r.logger.Info().Int("versao", mig.Version).Str("fase", mig.Phase).Msg("migração aplicada")
+		applied = append(applied, mig)
+	}
+	return applied, nil
+}
+
+func (r *Runner) apply(ctx context.Context, mig Migration) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, fmt.Sprintf("SET lock_timeout = %d", r.LockTimeout.Milliseconds())); err != nil {
+		return err
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "RESET lock_timeout")
+
+	if err := setVersion(ctx, conn, mig.Version, true); err != nil {
+		return err
+	}
+	// Sem argumentos o pgx usa o protocolo simples, que aceita vários comandos no mesmo envio.
+	if _, err := conn.Exec(ctx, mig.SQL); err != nil {
+		return err
+	}
+	return setVersion(ctx, conn, mig.Version, false)
+}
+
+func setVersion(ctx context.Context, conn *pgxpool.Conn, version int, dirty bool) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, version, dirty)
+		return err
+	})
+}
+
+// Flag é uma linha de feature_flags.
+type Flag struct {
+	Nome      string    `json:"nome"`
+	Ativa     bool      `json:"ativa"`
+	Descricao *string   `json:"descricao,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListFlags devolve as feature flags cadastradas.
+func (r *Runner) ListFlags(ctx context.Context) ([]Flag, error) {
+	rows, err := r.pool.Query(ctx, `SELECT nome, ativa, descricao, updated_at FROM feature_flags ORDER BY nome`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[Flag])
+}
+
+// SetFlag liga ou desliga a flag, criando-a se preciso.
+func (r *Runner) SetFlag(ctx context.Context, nome string, ativa bool, descricao string) error {
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO feature_flags (nome, ativa, descricao)
+        VALUES ($1, $2, NULLIF($3, ''))
+        ON CONFLICT (nome) DO UPDATE
+        SET ativa = EXCLUDED.ativa, descricao = COALESCE(EXCLUDED.descricao, feature_flags.descricao), updated_at = now()
+    `, nome, ativa, descricao)
+	return err
+}
@@ -0,0 +1,137 @@
+package demo
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+var firstNames = []string{
+	"Ana", "Maria", "Francisca", "Antônia", "Adriana", "Juliana", "Márcia", "Fernanda", "Patrícia", "Aline",
+	"José", "João", "Antônio", "Francisco", "Carlos", "Paulo", "Pedro", "Lucas", "Luiz", "Marcos",
+	"Gabriel", "Rafael", "Larissa", "Beatriz", "Letícia", "Mateus", "Thiago", "Camila", "Bruna", "Vitória",
+}
+
+var lastNames = []string{
+	"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira", "Lima", "Gomes",
+	"Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes", "Soares", "Fernandes", "Vieira", "Barbosa",
+}
+
+var schoolPatrons = []string{
+	"Monteiro Lobato", "Cecília Meireles", "Paulo Freire", "Rui Barbosa", "Anísio Teixeira",
+	"Darcy Ribeiro", "Rachel de Queiroz", "Castro Alves", "Tiradentes", "Santos Dumont",
+}
+
+var turnos = []string{"MATUTINO", "VESPERTINO"}
+
+// Disciplinas usadas nas aulas e notas geradas.
+var Disciplinas = []string{"Português", "Matemática", "Ciências", "História", "Geografia"}
+
+var highlights = []string{
+	"Aumento de atendimentos digitais na Educação",
+	"Tempo médio de resposta abaixo de 48h",
+	"Adesão crescente ao app do cidadão",
+	"Chamada online adotada por todas as escolas",
+	"Redução de filas presenciais na Saúde",
+}
+
+// Generator produz dados sintéticos plausíveis e reprodutíveis a partir de uma semente.
+type Generator struct {
+	rnd *rand.Rand
+	seq int
+}
+
+// NewGenerator cria gerador determinístico; seed 0 usa o relógio.
+func NewGenerator(seed int64) *Generator {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Generator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// Person devolve nome completo e e-mail fictício no domínio informado.
+// Os e-mails são únicos dentro de um mesmo gerador.
+func (g *Generator) Person(domain string) (string, string) {
+	first := g.pick(firstNames)
+	last := g.pick(lastNames)
+	second := g.pick(lastNames)
+	g.seq++
+	name := fmt.Sprintf("%s %s %s", first, second, last)
+	email := fmt.Sprintf("%s.%s%d@%s", slugify(first), slugify(last), g.seq, domain)
+	return name, email
+}
+
+// SchoolName devolve nome de escola municipal.
+func (g *Generator) SchoolName() string {
+	return "Escola Municipal " + g.pick(schoolPatrons)
+}
+
+// TurmaName devolve nome e turno da turma do ano informado (1 a 9).
+func (g *Generator) TurmaName(ano int, letra rune) (string, string) {
+	return fmt.Sprintf("%dº Ano %c", ano, letra), g.pick(turnos)
+}
+
+// AttendanceStatus sorteia status de presença com distribuição realista.
+func (g *Generator) AttendanceStatus() string {
+	switch n := g.rnd.Intn(100); {
+	case n < 86:
+		return "PRESENTE"
+	case n < 93:
+		return "FALTA"
+	case n < 97:
+		return "ATRASO"
+	default:
+		return "JUSTIFICADA"
+	}
+}
+
+// Grade sorteia nota entre 0 e 10 concentrada em torno de 7.
+func (g *Generator) Grade() float64 {
+	value := g.rnd.NormFloat64()*1.5 + 7
+	if value < 0 {
+		value = 0
+	}
+	if value > 10 {
+		value = 10
+	}
+	return float64(int(value*100)) / 100
+}
+
+// Highlights devolve destaques aleatórios para o painel da cidade.
+func (g *Generator) Highlights(n int) []string {
+	perm := g.rnd.Perm(len(highlights))
+	if n > len(perm) {
+		n = len(perm)
+	}
+	out := make([]string, 0, n)
+	for _, idx := range perm[:n] {
+		out = append(out, highlights[idx])
+	}
+	return out
+}
+
+// Intn expõe sorteio inteiro do gerador.
+func (g *Generator) Intn(n int) int {
+	return g.rnd.Intn(n)
+}
+
+// Float64 expõe sorteio decimal do gerador.
+func (g *Generator) Float64() float64 {
+	return g.rnd.Float64()
+}
+
+func (g *Generator) pick(list []string) string {
+	return list[g.rnd.Intn(len(list))]
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c",
+	"Á", "a", "Â", "a", "É", "e", "Í", "i", "Ó", "o", "Ú", "u",
+)
+
+func slugify(value string) string {
+	return strings.ToLower(strings.ReplaceAll(accentReplacer.Replace(value), " ", ""))
+}
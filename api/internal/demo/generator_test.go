@@ -0,0 +1,56 @@
+package demo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	a := NewGenerator(42)
+	b := NewGenerator(42)
+	for i := 0; i < 5; i++ {
+		nameA, emailA := a.Person("demo.gov.br")
+		nameB, emailB := b.Person("demo.gov.br")
+		if nameA != nameB || emailA != emailB {
+			t.Fatalf("gerador não determinístico: %q/%q vs %q/%q", nameA, emailA, nameB, emailB)
+		}
+		if !strings.HasSuffix(emailA, "@demo.gov.br") || strings.ContainsAny(emailA, "áãéíóúç ") {
+			t.Fatalf("e-mail inválido: %q", emailA)
+		}
+	}
+}
+
+func TestGradeRange(t *testing.T) {
+	gen := NewGenerator(7)
+	for i := 0; i < 1000; i++ {
+		if g := gen.Grade(); g < 0 || g > 10 {
+			t.Fatalf("nota fora do intervalo: %v", g)
+		}
+	}
+}
+
+func TestOptionsNormalize(t *testing.T) {
+	opts, err := Options{}.Normalize()
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if opts.Citizens == 0 || opts.Schools == 0 || opts.Days == 0 {
+		t.Fatalf("defaults não aplicados: %+v", opts)
+	}
+	if _, err := (Options{Citizens: 100000}).Normalize(); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("esperava ErrLimitExceeded, obteve %v", err)
+	}
+}
+
+func TestLastWeekdays(t *testing.T) {
+	monday := time.Date(2026, time.March, 9, 10, 0, 0, 0, time.UTC)
+	days := lastWeekdays(monday, 3)
+	want := []int{6, 5, 4}
+	for i, day := range days {
+		if day.Day() != want[i] {
+			t.Fatalf("dia %d = %v, esperado %d", i, day, want[i])
+		}
+	}
+}
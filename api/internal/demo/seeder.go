@@ -0,0 +1,344 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+// ErrLimitExceeded indica opções acima dos limites aceitos.
+var ErrLimitExceeded = errors.New("demo: volume solicitado acima do limite")
+
+// wipeOrder lista as tabelas rastreadas, na ordem segura de remoção.
+// Presenças, notas, matrículas e vínculos são removidos em cascata.
+var wipeOrder = []string{
+	"aulas",
+	"turmas",
+	"escolas",
+	"alunos",
+	"usuarios",
+	"secretarias",
+	"cidadaos",
+	"saas_city_insights",
+}
+
+// Options controla o volume de dados gerados.
+type Options struct {
+	Seed             int64
+	Citizens         int
+	Staff            int
+	Schools          int
+	TurmasPerSchool  int
+	StudentsPerTurma int
+	Days             int
+	// StaffPassword habilita login dos usuários gerados; vazio gera contas sem acesso.
+	StaffPassword string
+}
+
+// Summary contabiliza o que foi gerado.
+type Summary struct {
+	Citizens  int  `json:"citizens"`
+	Staff     int  `json:"staff"`
+	Schools   int  `json:"schools"`
+	Turmas    int  `json:"turmas"`
+	Students  int  `json:"students"`
+	Aulas     int  `json:"aulas"`
+	Presencas int  `json:"presencas"`
+	Notas     int  `json:"notas"`
+	Metrics   bool `json:"metrics"`
+}
+
+// Normalize aplica valores padrão e valida limites.
+func (o Options) Normalize() (Options, error) {
+	defaults := Options{Citizens: 200, Staff: 8, Schools: 3, TurmasPerSchool: 4, StudentsPerTurma: 25, Days: 10}
+	if o.Citizens <= 0 {
+		o.Citizens = defaults.Citizens
+	}
+	if o.Staff <= 0 {
+		o.Staff = defaults.Staff
+	}
+	if o.Schools <= 0 {
+		o.Schools = defaults.Schools
+	}
+	if o.TurmasPerSchool <= 0 {
+		o.TurmasPerSchool = defaults.TurmasPerSchool
+	}
+	if o.StudentsPerTurma <= 0 {
+		o.StudentsPerTurma = defaults.StudentsPerTurma
+	}
+	if o.Days <= 0 {
+		o.Days = defaults.Days
+	}
+	if o.Citizens > 5000 || o.Staff > 100 || o.Schools > 20 || o.TurmasPerSchool > 18 || o.StudentsPerTurma > 45 || o.Days > 60 {
+		return o, ErrLimitExceeded
+	}
+	return o, nil
+}
+
+// Seeder persiste dados sintéticos vinculados a um tenant.
+type Seeder struct {
+	pool *pgxpool.Pool
+}
+
+// NewSeeder cria um Seeder.
+func NewSeeder(pool *pgxpool.Pool) *Seeder {
+	return &Seeder{pool: pool}
+}
+
+// Target identifica o tenant a ser populado.
+type Target struct {
+	TenantID uuid.UUID
+	Slug     string
+	Domain   string
+}
+
+// Seed gera cidadãos, equipe, escolas, turmas, aulas, presenças, notas e métricas.
+// Cada registro raiz é rastreado em saas_demo_records para permitir o Wipe.
+// Protocolos ainda não possuem tabelas próprias e por isso não são gerados.
+func (s *Seeder) Seed(ctx context.Context, target Target, opts Options) (Summary, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return Summary{}, err
+	}
+
+	gen := NewGenerator(opts.Seed)
+	summary := Summary{}
+	// Subdomínio por execução evita colisão de e-mails entre cargas sucessivas.
+	mailDomain := fmt.Sprintf("demo-%s.%s", uuid.New().String()[:8], target.Domain)
+	records := make([][]any, 0, opts.Citizens+opts.Staff+64)
+	track := func(table string, id uuid.UUID) {
+		records = append(records, []any{target.TenantID, table, id})
+	}
+
+	passwordHash := "!demo-sem-acesso"
+	if opts.StaffPassword != "" {
+		passwordHash, err = auth.Hash(opts.StaffPassword)
+		if err != nil {
+			return Summary{}, err
+		}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Cidadãos.
+	citizens := make([][]any, 0, opts.Citizens)
+	for i := 0; i < opts.Citizens; i++ {
+		id := uuid.New()
+		name, email := gen.Person(mailDomain)
+		citizens = append(citizens, []any{id, name, email, time.Now().Add(-time.Duration(gen.Intn(365*24)) * time.Hour)})
+		track("cidadaos", id)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"cidadaos"}, []string{"id", "nome", "email", "criado_em"}, pgx.CopyFromRows(citizens)); err != nil {
+		return Summary{}, fmt.Errorf("demo: cidadãos: %w", err)
+	}
+	summary.Citizens = len(citizens)
+
+	// Secretaria de Educação e equipe do backoffice.
+	secretariaID := uuid.New()
+	if _, err := tx.Exec(ctx, `INSERT INTO secretarias (id, nome, slug, tenant_id) VALUES ($1, $2, $3, $4)`,
+		secretariaID, "Secretaria de Educação", fmt.Sprintf("%s-educacao-demo-%s", target.Slug, secretariaID.String()[:8]), target.TenantID); err != nil {
+		return Summary{}, fmt.Errorf("demo: secretaria: %w", err)
+	}
+	track("secretarias", secretariaID)
+
+	papeis := []string{"SECRETARIO", "ATENDENTE", "ATENDENTE", "ADMIN_TEC"}
+	var professorID uuid.UUID
+	for i := 0; i < opts.Staff; i++ {
+		id := uuid.New()
+		name, email := gen.Person("equipe." + mailDomain)
+		var lastLogin any
+		if gen.Intn(10) < 8 {
+			lastLogin = time.Now().Add(-time.Duration(gen.Intn(40*24)) * time.Hour)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO usuarios (id, nome, email, senha_hash, ultimo_login_em) VALUES ($1, $2, $3, $4, $5)`,
+			id, name, email, passwordHash, lastLogin); err != nil {
+			return Summary{}, fmt.Errorf("demo: equipe: %w", err)
+		}
+		papel := papeis[i%len(papeis)]
+		if i == 0 {
+			papel = "SECRETARIO"
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO usuarios_secretarias (usuario_id, secretaria_id, papel) VALUES ($1, $2, $3)`, id, secretariaID, papel); err != nil {
+			return Summary{}, fmt.Errorf("demo: equipe: %w", err)
+		}
+		if papel == "ATENDENTE" && professorID == uuid.Nil {
+			professorID = id
+		}
+		track("usuarios", id)
+	}
+	summary.Staff = opts.Staff
+	if professorID == uuid.Nil {
+		professorID = uuid.New()
+	}
+
+	// Escolas, turmas, alunos e matrículas.
+	type turmaRef struct {
+		id         uuid.UUID
+		matriculas []uuid.UUID
+	}
+	var turmas []turmaRef
+	for i := 0; i < opts.Schools; i++ {
+		escolaID := uuid.New()
+		if _, err := tx.Exec(ctx, `INSERT INTO escolas (id, nome, tenant_id) VALUES ($1, $2, $3)`, escolaID, gen.SchoolName(), target.TenantID); err != nil {
+			return Summary{}, fmt.Errorf("demo: escolas: %w", err)
+		}
+		track("escolas", escolaID)
+		summary.Schools++
+
+		for j := 0; j < opts.TurmasPerSchool; j++ {
+			turmaID := uuid.New()
+			nome, turno := gen.TurmaName(j%9+1, rune('A'+j/9))
+			if _, err := tx.Exec(ctx, `INSERT INTO turmas (id, nome, turno, escola_id) VALUES ($1, $2, $3, $4)`, turmaID, nome, turno, escolaID); err != nil {
+				return Summary{}, fmt.Errorf("demo: turmas: %w", err)
+			}
+			if _, err := tx.Exec(ctx, `INSERT INTO professores_turmas (professor_id, turma_id, disciplinas) VALUES ($1, $2, $3)`, professorID, turmaID, Disciplinas); err != nil {
+				return Summary{}, fmt.Errorf("demo: turmas: %w", err)
+			}
+			track("turmas", turmaID)
+			summary.Turmas++
+
+			ref := turmaRef{id: turmaID}
+			alunos := make([][]any, 0, opts.StudentsPerTurma)
+			matriculas := make([][]any, 0, opts.StudentsPerTurma)
+			for k := 0; k < opts.StudentsPerTurma; k++ {
+				alunoID := uuid.New()
+				matriculaID := uuid.New()
+				name, _ := gen.Person(target.Domain)
+				alunos = append(alunos, []any{alunoID, name, fmt.Sprintf("DEMO-%s", alunoID.String()[:13])})
+				matriculas = append(matriculas, []any{matriculaID, alunoID, turmaID})
+				ref.matriculas = append(ref.matriculas, matriculaID)
+				track("alunos", alunoID)
+			}
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{"alunos"}, []string{"id", "nome", "matricula"}, pgx.CopyFromRows(alunos)); err != nil {
+				return Summary{}, fmt.Errorf("demo: alunos: %w", err)
+			}
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{"matriculas"}, []string{"id", "aluno_id", "turma_id"}, pgx.CopyFromRows(matriculas)); err != nil {
+				return Summary{}, fmt.Errorf("demo: matrículas: %w", err)
+			}
+			summary.Students += len(alunos)
+			turmas = append(turmas, ref)
+		}
+	}
+
+	// Aulas dos últimos dias úteis com chamada e notas do 1º bimestre.
+	var (
+		aulas     [][]any
+		presencas [][]any
+		notas     [][]any
+	)
+	days := lastWeekdays(time.Now(), opts.Days)
+	for _, turma := range turmas {
+		for _, day := range days {
+			for idx, disciplina := range Disciplinas {
+				aulaID := uuid.New()
+				inicio := day.Add(time.Duration(7+idx) * time.Hour)
+				aulas = append(aulas, []any{aulaID, turma.id, disciplina, inicio, inicio.Add(50 * time.Minute), professorID})
+				track("aulas", aulaID)
+				for _, matriculaID := range turma.matriculas {
+					presencas = append(presencas, []any{aulaID, inicio, matriculaID, gen.AttendanceStatus(), "DEMO"})
+				}
+			}
+		}
+		for _, disciplina := range Disciplinas {
+			for _, matriculaID := range turma.matriculas {
+				notas = append(notas, []any{turma.id, disciplina, 1, matriculaID, gen.Grade()})
+			}
+		}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"aulas"}, []string{"id", "turma_id", "disciplina", "inicio", "fim", "criado_por"}, pgx.CopyFromRows(aulas)); err != nil {
+		return Summary{}, fmt.Errorf("demo: aulas: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"presencas"}, []string{"aula_id", "aula_inicio", "matricula_id", "status", "origem"}, pgx.CopyFromRows(presencas)); err != nil {
+		return Summary{}, fmt.Errorf("demo: presenças: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"notas"}, []string{"turma_id", "disciplina", "bimestre", "matricula_id", "nota"}, pgx.CopyFromRows(notas)); err != nil {
+		return Summary{}, fmt.Errorf("demo: notas: %w", err)
+	}
+	summary.Aulas = len(aulas)
+	summary.Presencas = len(presencas)
+	summary.Notas = len(notas)
+
+	// Métricas do painel da cidade.
+	const insights = `
+        INSERT INTO saas_city_insights (tenant_id, population, active_users, requests_total, satisfaction, last_sync, highlights)
+        VALUES ($1, $2, $3, $4, $5, now(), $6)
+        ON CONFLICT (tenant_id) DO UPDATE SET population = EXCLUDED.population, active_users = EXCLUDED.active_users,
+            requests_total = EXCLUDED.requests_total, satisfaction = EXCLUDED.satisfaction, last_sync = now(),
+            highlights = EXCLUDED.highlights, updated_at = now()
+        RETURNING id, (xmax = 0) AS inserted
+    `
+	var (
+		insightID uuid.UUID
+		inserted  bool
+	)
+	population := 15000 + gen.Intn(85000)
+	if err := tx.QueryRow(ctx, insights, target.TenantID, population, summary.Citizens+summary.Staff, gen.Intn(5000)+500,
+		70+gen.Float64()*25, gen.Highlights(3)).Scan(&insightID, &inserted); err != nil {
+		return Summary{}, fmt.Errorf("demo: métricas: %w", err)
+	}
+	if inserted {
+		track("saas_city_insights", insightID)
+	}
+	summary.Metrics = true
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"saas_demo_records"}, []string{"tenant_id", "table_name", "record_id"}, pgx.CopyFromRows(records)); err != nil {
+		return Summary{}, fmt.Errorf("demo: rastreio: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Summary{}, err
+	}
+	return summary, nil
+}
+
+// Wipe remove todos os registros gerados para o tenant e devolve quantos foram apagados.
+func (s *Seeder) Wipe(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var removed int64
+	for _, table := range wipeOrder {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE id IN (SELECT record_id FROM saas_demo_records WHERE tenant_id = $1 AND table_name = $2)`, pgx.Identifier{table}.Sanitize())
+		tag, err := tx.Exec(ctx, query, tenantID, table)
+		if err != nil {
+			return 0, fmt.Errorf("demo: limpar %s: %w", table, err)
+		}
+		removed += tag.RowsAffected()
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM saas_demo_records WHERE tenant_id = $1`, tenantID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+func lastWeekdays(now time.Time, n int) []time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	out := make([]time.Time, 0, n)
+	for len(out) < n {
+		day = day.AddDate(0, 0, -1)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		out = append(out, day)
+	}
+	return out
+}
@@ -0,0 +1,305 @@
+// Package documento emite certidões e declarações assinadas digitalmente, com código de
+// verificação impresso em QR code e conferido publicamente em /verify/{code}.
+package documento
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+const (
+	TipoCertidao            = "certidao"
+	TipoDeclaracao          = "declaracao"
+	TipoDeclaracaoMatricula = "declaracao_matricula"
+)
+
+// Situação do documento na verificação pública.
+const (
+	StatusValido   = "valido"
+	StatusRevogado = "revogado"
+	StatusExpirado = "expirado"
+)
+
+var (
+	ErrNotFound   = errors.New("documento não encontrado")
+	ErrSecretaria = errors.New("usuário não pertence à secretaria emissora")
+	ErrAluno      = errors.New("aluno sem matrícula ativa na prefeitura")
+	ErrRevogado   = errors.New("documento já revogado")
+)
+
+// Documento é o registro imutável do que foi emitido; só a revogação altera a linha.
+type Documento struct {
+	ID                    uuid.UUID  `json:"id"`
+	TenantID              uuid.UUID  `json:"tenant_id"`
+	SecretariaID          uuid.UUID  `json:"secretaria_id"`
+	Secretaria            string     `json:"secretaria"`
+	Tipo                  string     `json:"tipo"`
+	Titulo                string     `json:"titulo"`
+	DestinatarioNome      string     `json:"destinatario_nome"`
+	DestinatarioDocumento *string    `json:"destinatario_documento,omitempty"`
+	AlunoID               *uuid.UUID `json:"aluno_id,omitempty"`
+	Conteudo              string     `json:"conteudo"`
+	Codigo                string     `json:"codigo"`
+	Hash                  string     `json:"hash"`
+	Assinatura            string     `json:"assinatura"`
+	EmitidoPor            uuid.UUID  `json:"emitido_por"`
+	EmitidoEm             time.Time  `json:"emitido_em"`
+	ValidoAte             *time.Time `json:"valido_ate,omitempty"`
+	RevogadoEm            *time.Time `json:"revogado_em,omitempty"`
+	RevogadoPor           *uuid.UUID `json:"revogado_por,omitempty"`
+	MotivoRevogacao       *string    `json:"motivo_revogacao,omitempty"`
+}
+
+// Status informa se o documento ainda vale na data consultada.
+func (d *Documento) Status(now time.Time) string {
+	switch {
+	case d.RevogadoEm != nil:
+		return StatusRevogado
+	case d.ValidoAte != nil && now.After(*d.ValidoAte):
+		return StatusExpirado
+	default:
+		return StatusValido
+	}
+}
+
+// EmissaoInput descreve o documento pedido pela secretaria. Em declarações de matrícula o texto
+// e o destinatário vêm do cadastro escolar do aluno.
+type EmissaoInput struct {
+	SecretariaID          uuid.UUID
+	Tipo                  string
+	Titulo                string
+	DestinatarioNome      string
+	DestinatarioDocumento *string
+	Conteudo              string
+	ValidadeDias          *int
+	AlunoID               *uuid.UUID
+}
+
+// Normalize valida a entrada conforme o tipo.
+func (in EmissaoInput) Normalize() (EmissaoInput, error) {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Titulo = strings.TrimSpace(in.Titulo)
+	in.DestinatarioNome = strings.TrimSpace(in.DestinatarioNome)
+	in.Conteudo = strings.TrimSpace(in.Conteudo)
+	if in.SecretariaID == uuid.Nil {
+		return in, errors.New("secretaria_id obrigatório")
+	}
+
+	switch in.Tipo {
+	case TipoDeclaracaoMatricula:
+		if in.AlunoID == nil {
+			return in, errors.New("aluno_id obrigatório na declaração de matrícula")
+		}
+		if in.Titulo == "" {
+			in.Titulo = "Declaração de Matrícula"
+		}
+	case TipoCertidao, TipoDeclaracao:
+		if in.AlunoID != nil {
+			return in, errors.New("aluno_id só se aplica à declaração de matrícula")
+		}
+		if in.Titulo == "" || in.DestinatarioNome == "" || in.Conteudo == "" {
+			return in, errors.New("titulo, destinatario_nome e conteudo são obrigatórios")
+		}
+	default:
+		return in, fmt.Errorf("tipo deve ser %s, %s ou %s", TipoCertidao, TipoDeclaracao, TipoDeclaracaoMatricula)
+	}
+	if len([]rune(in.Titulo)) > 120 || len([]rune(in.Conteudo)) > 4000 {
+		return in, errors.New("titulo ou conteudo longo demais")
+	}
+
+	if in.DestinatarioDocumento != nil {
+		cpf, err := util.ValidateCPF(*in.DestinatarioDocumento)
+		if err != nil {
+			return in, err
+		}
+		in.DestinatarioDocumento = &cpf
+	}
+	if in.ValidadeDias != nil && (*in.ValidadeDias < 1 || *in.ValidadeDias > 365) {
+		return in, errors.New("validade_dias deve ficar entre 1 e 365")
+	}
+	return in, nil
+}
+
+// Matricula reúne os dados escolares impressos na declaração.
+type Matricula struct {
+	AlunoNome  string
+	Matricula  *string
+	Escola     string
+	Turma      string
+	Turno      string
+	AnoLetivo  *int
+	Prefeitura string
+}
+
+// DeclaracaoMatricula redige o texto padrão da declaração.
+func DeclaracaoMatricula(m Matricula) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Declaramos, para os devidos fins, que %s", m.AlunoNome)
+	if m.Matricula != nil && *m.Matricula != "" {
+		fmt.Fprintf(&b, ", matrícula nº %s,", *m.Matricula)
+	}
+	fmt.Fprintf(&b, " está regularmente matriculado(a) na escola %s, turma %s, turno %s", m.Escola, m.Turma, strings.ToLower(m.Turno))
+	if m.AnoLetivo != nil {
+		fmt.Fprintf(&b, ", no ano letivo de %d", *m.AnoLetivo)
+	}
+	fmt.Fprintf(&b, ", da rede municipal de ensino de %s.", m.Prefeitura)
+	return b.String()
+}
+
+// alfabeto Crockford: sem I, L, O e U para não confundir na digitação.
+const alfabeto = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NovoCodigo sorteia 80 bits em 16 caracteres, impressos em quatro grupos.
+func NovoCodigo() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	for i := range raw {
+		raw[i] = alfabeto[raw[i]&31]
+	}
+	return agrupar(string(raw)), nil
+}
+
+// NormalizeCodigo aceita o código digitado com minúsculas, espaços, sem hífens ou com letras
+// ambíguas e devolve a forma canônica; códigos malformados resultam em vazio.
+func NormalizeCodigo(raw string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(raw) {
+		switch {
+		case r == '-' || r == ' ':
+			continue
+		case r == 'O':
+			r = '0'
+		case r == 'I' || r == 'L':
+			r = '1'
+		}
+		if !strings.ContainsRune(alfabeto, r) {
+			return ""
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() != 16 {
+		return ""
+	}
+	return agrupar(b.String())
+}
+
+func agrupar(code string) string {
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
+
+// PublicURL monta o endereço do QR code, aberto no portal da prefeitura.
+func PublicURL(domain, codigo string) string {
+	return "https://" + strings.TrimSuffix(strings.TrimSpace(domain), "/") + "/verify/" + codigo
+}
+
+// canonical serializa os campos assinados em ordem fixa, um por linha, com o tamanho à frente
+// para que nenhuma combinação de conteúdos produza a mesma sequência.
+func canonical(d *Documento) []byte {
+	fields := []string{
+		"v1",
+		d.Codigo,
+		d.TenantID.String(),
+		d.SecretariaID.String(),
+		d.Tipo,
+		d.Titulo,
+		d.DestinatarioNome,
+		deref(d.DestinatarioDocumento),
+		d.Conteudo,
+		d.EmitidoEm.UTC().Format(time.RFC3339),
+		formatOptionalTime(d.ValidoAte),
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(strconv.Itoa(len(f)))
+		b.WriteByte(':')
+		b.WriteString(f)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// Signer assina e confere documentos com a chave Ed25519 da plataforma.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner aceita a semente em base64; vazia, deriva a semente do segredo de fallback.
+func NewSigner(seedBase64, fallbackSecret string) (*Signer, error) {
+	var seed []byte
+	if seedBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(seedBase64)
+		if err != nil || len(decoded) != ed25519.SeedSize {
+			return nil, errors.New("DOCUMENTOS_SIGNING_KEY deve ter 32 bytes em base64")
+		}
+		seed = decoded
+	} else {
+		if fallbackSecret == "" {
+			return nil, errors.New("chave de assinatura de documentos ausente")
+		}
+		sum := sha256.Sum256([]byte("documentos-assinatura|" + fallbackSecret))
+		seed = sum[:]
+	}
+	return &Signer{key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// PublicKey devolve a chave pública em base64, publicada para conferência independente.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign preenche hash (SHA-256 do conteúdo canônico) e assinatura do documento.
+func (s *Signer) Sign(d *Documento) {
+	sum := sha256.Sum256(canonical(d))
+	d.Hash = hex.EncodeToString(sum[:])
+	d.Assinatura = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, sum[:]))
+}
+
+// Verify recalcula o hash a partir dos campos gravados e confere a assinatura; qualquer
+// alteração no banco depois da emissão invalida o documento.
+func (s *Signer) Verify(d *Documento) bool {
+	sum := sha256.Sum256(canonical(d))
+	if hex.EncodeToString(sum[:]) != d.Hash {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(d.Assinatura)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.key.Public().(ed25519.PublicKey), sum[:], sig)
+}
+
+// MaskCPF mostra só os dígitos centrais, como na verificação pública.
+func MaskCPF(cpf string) string {
+	if len(cpf) != 11 {
+		return "***"
+	}
+	return "***." + cpf[3:6] + "." + cpf[6:9] + "-**"
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func formatOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,146 @@
+package documento
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCodigo_GeraENormaliza(t *testing.T) {
+	codigo, err := NovoCodigo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codigo) != 19 || NormalizeCodigo(codigo) != codigo {
+		t.Fatalf("unexpected code %q", codigo)
+	}
+	if got := NormalizeCodigo(" abcd efgh-jkmn pqrO "); got != "ABCD-EFGH-JKMN-PQR0" {
+		t.Fatalf("normalize = %q", got)
+	}
+	if got := NormalizeCodigo("ilo0-0000-0000-0000"); got != "1100-0000-0000-0000" {
+		t.Fatalf("ambiguous letters = %q", got)
+	}
+	for _, invalid := range []string{"", "ABCD-EFGH", "ABCD-EFGH-JKMN-PQRU", "ABCD-EFGH-JKMN-PQRS-T"} {
+		if got := NormalizeCodigo(invalid); got != "" {
+			t.Fatalf("expected %q to be rejected, got %q", invalid, got)
+		}
+	}
+}
+
+func TestSigner_DetectaAlteracao(t *testing.T) {
+	signer, err := NewSigner("", "segredo-de-teste-com-32-caracteres!!")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := &Documento{
+		TenantID:         uuid.New(),
+		SecretariaID:     uuid.New(),
+		Tipo:             TipoCertidao,
+		Titulo:           "Certidão Negativa",
+		DestinatarioNome: "Maria",
+		Conteudo:         "Certificamos que nada consta.",
+		Codigo:           "ABCD-EFGH-JKMN-PQRS",
+		EmitidoEm:        time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+	signer.Sign(d)
+	if !signer.Verify(d) {
+		t.Fatal("signed document should verify")
+	}
+
+	// Mesmo instante lido do banco em outro fuso continua conferindo.
+	d.EmitidoEm = d.EmitidoEm.In(time.FixedZone("BRT", -3*60*60))
+	if !signer.Verify(d) {
+		t.Fatal("timezone should not affect verification")
+	}
+
+	tampered := *d
+	tampered.Conteudo = "Certificamos que consta débito."
+	if signer.Verify(&tampered) {
+		t.Fatal("altered content must not verify")
+	}
+
+	// Campos vizinhos não podem trocar caracteres sem mudar o hash.
+	shifted := *d
+	shifted.Titulo, shifted.DestinatarioNome = "Certidão NegativaM", "aria"
+	if signer.Verify(&shifted) {
+		t.Fatal("field boundaries must be part of the signature")
+	}
+
+	other, _ := NewSigner("", "outro-segredo-com-mais-de-32-caracteres")
+	if other.Verify(d) || other.PublicKey() == signer.PublicKey() {
+		t.Fatal("different keys must not verify")
+	}
+	if _, err := NewSigner("curta", ""); err == nil {
+		t.Fatal("expected invalid seed to fail")
+	}
+}
+
+func TestEmissaoInput_Normalize(t *testing.T) {
+	secretaria := uuid.New()
+	aluno := uuid.New()
+	in, err := EmissaoInput{SecretariaID: secretaria, Tipo: " Declaracao_Matricula ", AlunoID: &aluno}.Normalize()
+	if err != nil || in.Tipo != TipoDeclaracaoMatricula || in.Titulo != "Declaração de Matrícula" {
+		t.Fatalf("unexpected %+v %v", in, err)
+	}
+	if _, err := (EmissaoInput{SecretariaID: secretaria, Tipo: TipoCertidao, Titulo: "x"}).Normalize(); err == nil {
+		t.Fatal("certidão sem conteúdo deveria falhar")
+	}
+	cpf := "529.982.247-25"
+	in, err = EmissaoInput{SecretariaID: secretaria, Tipo: TipoDeclaracao, Titulo: "Declaração", DestinatarioNome: "João", Conteudo: "Texto", DestinatarioDocumento: &cpf}.Normalize()
+	if err != nil || *in.DestinatarioDocumento != "52998224725" {
+		t.Fatalf("unexpected %+v %v", in, err)
+	}
+	dias := 0
+	if _, err := (EmissaoInput{SecretariaID: secretaria, Tipo: TipoDeclaracao, Titulo: "D", DestinatarioNome: "J", Conteudo: "T", ValidadeDias: &dias}).Normalize(); err == nil {
+		t.Fatal("validade zero deveria falhar")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	ontem := now.AddDate(0, 0, -1)
+	d := &Documento{}
+	if d.Status(now) != StatusValido {
+		t.Fatal("expected valido")
+	}
+	d.ValidoAte = &ontem
+	if d.Status(now) != StatusExpirado {
+		t.Fatal("expected expirado")
+	}
+	d.RevogadoEm = &ontem
+	if d.Status(now) != StatusRevogado {
+		t.Fatal("expected revogado")
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	matricula := "2026001"
+	ano := 2026
+	d := &Documento{
+		Titulo:     "Declaração de Matrícula",
+		Secretaria: "Secretaria de Educação",
+		Conteudo:   DeclaracaoMatricula(Matricula{AlunoNome: "Ana Souza", Matricula: &matricula, Escola: "EMEF Centro", Turma: "5º A", Turno: "MANHA", AnoLetivo: &ano, Prefeitura: "Zabelê"}),
+		Codigo:     "ABCD-EFGH-JKMN-PQRS",
+		EmitidoEm:  time.Now(),
+	}
+	if !strings.Contains(d.Conteudo, "matrícula nº 2026001, está regularmente matriculado(a) na escola EMEF Centro, turma 5º A, turno manha, no ano letivo de 2026") {
+		t.Fatalf("unexpected text %q", d.Conteudo)
+	}
+	content, err := RenderPDF(d, "Prefeitura de Zabelê", PublicURL("zabele.gov.br", d.Codigo))
+	if err != nil || !bytes.HasPrefix(content, []byte("%PDF-")) || !bytes.Contains(content, []byte("ABCD-EFGH-JKMN-PQRS")) {
+		t.Fatalf("unexpected pdf: %v", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("um dois três quatro cinco", 9)
+	if strings.Join(lines, "|") != "um dois|três|quatro|cinco" {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	if lines := wrap(strings.Repeat("x", 20), 8); len(lines) != 3 || lines[2] != "xxxx" {
+		t.Fatalf("long word split = %q", lines)
+	}
+}
@@ -0,0 +1,105 @@
+package documento
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/pdf"
+	"github.com/gestaozabele/municipio/internal/qrcode"
+)
+
+// brasilia formata datas impressas; o horário de verão não vigora desde 2019.
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+// Helvetica 11 pt tem em média 5,5 pt por caractere; 88 caracteres cabem na área útil.
+const caracteresPorLinha = 88
+
+// RenderPDF desenha o documento com o texto, os dados da emissão e, no rodapé, o QR code que
+// abre a verificação pública junto do código para digitação.
+func RenderPDF(d *Documento, prefeitura, verifyURL string) ([]byte, error) {
+	code, err := qrcode.Encode(verifyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage()
+	const margin = 56.0
+	y := page.Height() - 72
+
+	page.Text(margin, y, pdf.Bold, 14, prefeitura)
+	y -= 18
+	page.Text(margin, y, pdf.Regular, 11, d.Secretaria)
+	y -= 48
+	page.Text(margin, y, pdf.Bold, 16, strings.ToUpper(d.Titulo))
+	y -= 36
+
+	for _, paragraph := range strings.Split(d.Conteudo, "\n") {
+		for _, line := range wrap(paragraph, caracteresPorLinha) {
+			page.Text(margin, y, pdf.Regular, 11, line)
+			y -= 16
+		}
+		y -= 8
+	}
+
+	y -= 24
+	page.Text(margin, y, pdf.Regular, 11, "Emitido em "+d.EmitidoEm.In(brasilia).Format("02/01/2006 às 15:04"))
+	if d.ValidoAte != nil {
+		y -= 16
+		page.Text(margin, y, pdf.Regular, 11, "Válido até "+d.ValidoAte.In(brasilia).Format("02/01/2006"))
+	}
+
+	// Rodapé: QR de 90 pt com zona de silêncio de 4 módulos e, ao lado, instruções e hash.
+	const qrSize = 90.0
+	modules := float64(code.Size + 8)
+	unit := qrSize / modules
+	qrX, qrY := margin, 60.0
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Dark(col, row) {
+				page.Rect(qrX+float64(col+4)*unit, qrY+qrSize-float64(row+5)*unit, unit, unit)
+			}
+		}
+	}
+	textX := qrX + qrSize + 12
+	page.Text(textX, qrY+70, pdf.Bold, 9, "Documento assinado digitalmente")
+	page.Text(textX, qrY+56, pdf.Regular, 9, "Confira a autenticidade em "+verifyURL)
+	page.Text(textX, qrY+42, pdf.Regular, 9, "ou leia o QR code ao lado. Código de verificação:")
+	page.Text(textX, qrY+26, pdf.Bold, 12, d.Codigo)
+	page.Text(textX, qrY+12, pdf.Regular, 6, "SHA-256 "+d.Hash)
+	return doc.Bytes(), nil
+}
+
+// wrap quebra o parágrafo em linhas de até limit caracteres, sem partir palavras curtas.
+func wrap(paragraph string, limit int) []string {
+	words := strings.Fields(paragraph)
+	if len(words) == 0 {
+		return nil
+	}
+	var lines []string
+	var current []rune
+	for _, word := range words {
+		w := []rune(word)
+		for len(w) > limit {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(w[:limit]))
+			w = w[limit:]
+		}
+		switch {
+		case len(current) == 0:
+			current = w
+		case len(current)+1+len(w) <= limit:
+			current = append(append(current, ' '), w...)
+		default:
+			lines = append(lines, string(current))
+			current = w
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
@@ -0,0 +1,231 @@
+package documento
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const documentoColumns = `d.id, d.tenant_id, d.secretaria_id, s.nome, d.tipo, d.titulo, d.destinatario_nome, d.destinatario_documento,
+        d.aluno_id, d.conteudo, d.codigo, d.hash, d.assinatura, d.emitido_por, d.emitido_em, d.valido_ate,
+        d.revogado_em, d.revogado_por, d.motivo_revogacao`
+
+// Filter restringe a listagem da secretaria.
+type Filter struct {
+	Tipo    string
+	AlunoID *uuid.UUID
+	Query   string
+	Limit   int
+}
+
+// Repository persiste os documentos emitidos.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// Emitir grava o documento assinado; a entrada já deve estar normalizada.
+func (r *Repository) Emitir(ctx context.Context, tenantID, emissorID uuid.UUID, in EmissaoInput, signer *Signer) (*Documento, error) {
+	var membro bool
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM usuarios_secretarias us
+            JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE us.usuario_id = $1 AND us.secretaria_id = $2 AND s.tenant_id = $3
+        )
+    `, emissorID, in.SecretariaID, tenantID).Scan(&membro)
+	if err != nil {
+		return nil, err
+	}
+	if !membro {
+		return nil, ErrSecretaria
+	}
+
+	d := &Documento{
+		TenantID:              tenantID,
+		SecretariaID:          in.SecretariaID,
+		Tipo:                  in.Tipo,
+		Titulo:                in.Titulo,
+		DestinatarioNome:      in.DestinatarioNome,
+		DestinatarioDocumento: in.DestinatarioDocumento,
+		AlunoID:               in.AlunoID,
+		Conteudo:              in.Conteudo,
+		EmitidoPor:            emissorID,
+		// A assinatura cobre o instante com precisão de segundos, que o banco preserva.
+		EmitidoEm: time.Now().UTC().Truncate(time.Second),
+	}
+	if in.Tipo == TipoDeclaracaoMatricula {
+		m, err := r.matricula(ctx, tenantID, *in.AlunoID)
+		if err != nil {
+			return nil, err
+		}
+		d.DestinatarioNome = m.AlunoNome
+		d.Conteudo = DeclaracaoMatricula(*m)
+	}
+	if in.ValidadeDias != nil {
+		validoAte := d.EmitidoEm.AddDate(0, 0, *in.ValidadeDias)
+		d.ValidoAte = &validoAte
+	}
+
+	// Colisão de 80 bits é improvável, mas o índice único decide; tenta de novo com outro código.
+	for attempt := 0; ; attempt++ {
+		if d.Codigo, err = NovoCodigo(); err != nil {
+			return nil, err
+		}
+		signer.Sign(d)
+		err = r.conn(ctx).QueryRow(ctx, `
+            INSERT INTO documentos_emitidos (tenant_id, secretaria_id, tipo, titulo, destinatario_nome, destinatario_documento,
+                                             aluno_id, conteudo, codigo, hash, assinatura, emitido_por, emitido_em, valido_ate)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+            RETURNING id
+        `, d.TenantID, d.SecretariaID, d.Tipo, d.Titulo, d.DestinatarioNome, d.DestinatarioDocumento, d.AlunoID,
+			d.Conteudo, d.Codigo, d.Hash, d.Assinatura, d.EmitidoPor, d.EmitidoEm, d.ValidoAte).Scan(&d.ID)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && attempt < 2 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return r.Get(ctx, tenantID, d.ID)
+	}
+}
+
+func (r *Repository) matricula(ctx context.Context, tenantID, alunoID uuid.UUID) (*Matricula, error) {
+	var m Matricula
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT a.nome, a.matricula, e.nome, t.nome, t.turno, m.ano_letivo, tn.display_name
+        FROM matriculas m
+        JOIN alunos a ON a.id = m.aluno_id
+        JOIN turmas t ON t.id = m.turma_id
+        JOIN escolas e ON e.id = t.escola_id
+        JOIN tenants tn ON tn.id = e.tenant_id
+        WHERE m.aluno_id = $1 AND m.ativo = TRUE AND e.tenant_id = $2
+        ORDER BY m.ano_letivo DESC NULLS LAST
+        LIMIT 1
+    `, alunoID, tenantID).Scan(&m.AlunoNome, &m.Matricula, &m.Escola, &m.Turma, &m.Turno, &m.AnoLetivo, &m.Prefeitura)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAluno
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// List lista os documentos da prefeitura, mais recentes primeiro.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, filter Filter) ([]Documento, error) {
+	args := []any{tenantID}
+	clauses := []string{"d.tenant_id = $1"}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.Tipo != "" {
+		add("d.tipo = $?", filter.Tipo)
+	}
+	if filter.AlunoID != nil {
+		add("d.aluno_id = $?", *filter.AlunoID)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		add("(d.destinatario_nome ILIKE '%' || $? || '%' OR d.codigo = upper($?) OR d.titulo ILIKE '%' || $? || '%')", q)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args = append(args, limit)
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+documentoColumns+`
+        FROM documentos_emitidos d
+        JOIN secretarias s ON s.id = d.secretaria_id
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY d.emitido_em DESC
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Documento, error) {
+		d, err := scanDocumento(row)
+		if err != nil {
+			return Documento{}, err
+		}
+		return *d, nil
+	})
+}
+
+// Get busca o documento da prefeitura.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID) (*Documento, error) {
+	return scanDocumento(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+documentoColumns+`
+        FROM documentos_emitidos d
+        JOIN secretarias s ON s.id = d.secretaria_id
+        WHERE d.tenant_id = $1 AND d.id = $2
+    `, tenantID, id))
+}
+
+// GetByCodigo busca pelo código de verificação, sempre dentro da prefeitura do domínio.
+func (r *Repository) GetByCodigo(ctx context.Context, tenantID uuid.UUID, codigo string) (*Documento, error) {
+	return scanDocumento(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+documentoColumns+`
+        FROM documentos_emitidos d
+        JOIN secretarias s ON s.id = d.secretaria_id
+        WHERE d.tenant_id = $1 AND d.codigo = $2
+    `, tenantID, codigo))
+}
+
+// Revogar invalida o documento; a verificação pública passa a informar a revogação e o motivo.
+func (r *Repository) Revogar(ctx context.Context, tenantID, id, actorID uuid.UUID, motivo string) (*Documento, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE documentos_emitidos
+        SET revogado_em = now(), revogado_por = $3, motivo_revogacao = $4
+        WHERE tenant_id = $1 AND id = $2 AND revogado_em IS NULL
+    `, tenantID, id, actorID, motivo)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		d, err := r.Get(ctx, tenantID, id)
+		if err != nil {
+			return nil, err
+		}
+		if d.RevogadoEm != nil {
+			return nil, ErrRevogado
+		}
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+func scanDocumento(row pgx.Row) (*Documento, error) {
+	var d Documento
+	err := row.Scan(&d.ID, &d.TenantID, &d.SecretariaID, &d.Secretaria, &d.Tipo, &d.Titulo, &d.DestinatarioNome,
+		&d.DestinatarioDocumento, &d.AlunoID, &d.Conteudo, &d.Codigo, &d.Hash, &d.Assinatura, &d.EmitidoPor,
+		&d.EmitidoEm, &d.ValidoAte, &d.RevogadoEm, &d.RevogadoPor, &d.MotivoRevogacao)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -8,6 +8,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/notify"
 )
 
 var (
@@ -25,6 +29,12 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+// conn devolve o pool do cluster da prefeitura da requisição (middleware TenantDatabase) ou o
+// pool padrão fora de requisição.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.db)
+}
+
 type Turma struct {
 	ID    uuid.UUID `json:"id"`
 	Nome  string    `json:"nome"`
@@ -95,13 +105,14 @@ func (r *Repository) ListTurmas(ctx context.Context, professorID uuid.UUID) ([]T
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT t.id, t.nome, t.turno
 		FROM professores_turmas pt
 		JOIN turmas t ON t.id = pt.turma_id
-		WHERE pt.professor_id = $1
+		JOIN escolas e ON e.id = t.escola_id
+		WHERE pt.professor_id = $1 AND e.tenant_id = $2
 		ORDER BY t.nome
-	`, professorID)
+	`, professorID, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -126,14 +137,14 @@ func (r *Repository) ListAulasByDate(ctx context.Context, professorID uuid.UUID,
 	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
 	end := start.Add(24 * time.Hour)
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT a.id, a.turma_id, t.nome, a.disciplina, a.inicio, a.fim
 		FROM aulas a
 		JOIN turmas t ON t.id = a.turma_id
 		JOIN professores_turmas pt ON pt.turma_id = a.turma_id AND pt.professor_id = $1
-		WHERE a.inicio >= $2 AND a.inicio < $3
+		WHERE a.inicio >= $2 AND a.inicio < $3 AND `+db.TurmaNoTenant("a.turma_id", "$4")+`
 		ORDER BY a.inicio
-	`, professorID, start, end)
+	`, professorID, start, end, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -155,13 +166,13 @@ func (r *Repository) GetAulaChamada(ctx context.Context, professorID, aulaID uui
 	defer cancel()
 
 	var aula Aula
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
 		SELECT a.id, a.turma_id, t.nome, a.disciplina, a.inicio, a.fim
 		FROM aulas a
 		JOIN turmas t ON t.id = a.turma_id
 		JOIN professores_turmas pt ON pt.turma_id = a.turma_id AND pt.professor_id = $1
-		WHERE a.id = $2
-	`, professorID, aulaID).Scan(&aula.ID, &aula.TurmaID, &aula.TurmaNome, &aula.Disciplina, &aula.Inicio, &aula.Fim)
+		WHERE a.id = $2 AND `+db.TurmaNoTenant("a.turma_id", "$3")+`
+	`, professorID, aulaID, httpmiddleware.TenantScope(ctx)).Scan(&aula.ID, &aula.TurmaID, &aula.TurmaNome, &aula.Disciplina, &aula.Inicio, &aula.Fim)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return aula, nil, errNotFound
 	}
@@ -169,14 +180,14 @@ func (r *Repository) GetAulaChamada(ctx context.Context, professorID, aulaID uui
 		return aula, nil, err
 	}
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT m.id, al.nome, al.matricula, p.status
 		FROM matriculas m
 		JOIN alunos al ON al.id = m.aluno_id
-		LEFT JOIN presencas p ON p.aula_id = $1 AND p.matricula_id = m.id
+		LEFT JOIN presencas p ON p.aula_id = $1 AND p.matricula_id = m.id AND p.aula_inicio = $3
 		WHERE m.turma_id = $2 AND m.ativo = TRUE
 		ORDER BY al.nome
-	`, aula.ID, aula.TurmaID)
+	`, aula.ID, aula.TurmaID, aula.Inicio)
 	if err != nil {
 		return aula, nil, err
 	}
@@ -199,12 +210,12 @@ func (r *Repository) FindRepeatSource(ctx context.Context, professorID, aulaID u
 
 	var turmaID uuid.UUID
 	var inicio time.Time
-	if err := r.db.QueryRow(ctx, `
+	if err := r.conn(ctx).QueryRow(ctx, `
 		SELECT a.turma_id, a.inicio
 		FROM aulas a
 		JOIN professores_turmas pt ON pt.turma_id = a.turma_id AND pt.professor_id = $1
-		WHERE a.id = $2
-	`, professorID, aulaID).Scan(&turmaID, &inicio); err != nil {
+		WHERE a.id = $2 AND `+db.TurmaNoTenant("a.turma_id", "$3")+`
+	`, professorID, aulaID, httpmiddleware.TenantScope(ctx)).Scan(&turmaID, &inicio); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errNotFound
 		}
@@ -212,7 +223,7 @@ func (r *Repository) FindRepeatSource(ctx context.Context, professorID, aulaID u
 	}
 
 	var source uuid.UUID
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
 		SELECT a2.id
 		FROM aulas a2
 		WHERE a2.turma_id = $1
@@ -234,7 +245,7 @@ func (r *Repository) RepeatPresencas(ctx context.Context, aulaDestino, aulaOrige
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := r.conn(ctx).BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
@@ -243,7 +254,7 @@ func (r *Repository) RepeatPresencas(ctx context.Context, aulaDestino, aulaOrige
 	rows, err := tx.Query(ctx, `
 		SELECT matricula_id, status, origem
 		FROM presencas
-		WHERE aula_id = $1
+		WHERE aula_id = $1 AND aula_inicio = (SELECT inicio FROM aulas WHERE id = $1)
 	`, aulaOrigem)
 	if err != nil {
 		return err
@@ -275,15 +286,19 @@ func (r *Repository) RepeatPresencas(ctx context.Context, aulaDestino, aulaOrige
 
 	for _, e := range entries {
 		if _, err := tx.Exec(ctx, `
-			INSERT INTO presencas (aula_id, matricula_id, status, origem, updated_at)
-			VALUES ($1,$2,$3,$4,now())
-			ON CONFLICT (aula_id, matricula_id)
+			INSERT INTO presencas (aula_id, aula_inicio, matricula_id, status, origem, updated_at)
+			SELECT id, inicio, $2, $3, $4, now() FROM aulas WHERE id = $1
+			ON CONFLICT (aula_id, matricula_id, aula_inicio)
 			DO UPDATE SET status = EXCLUDED.status, origem = EXCLUDED.origem, updated_at = now()
 		`, aulaDestino, e.MatriculaID, e.Status, e.Origem); err != nil {
 			return err
 		}
 	}
 
+	if err := notify.EnfileirarFaltas(ctx, tx, aulaDestino); err != nil {
+		return err
+	}
+
 	if _, err := tx.Exec(ctx, `
 		INSERT INTO chamada_auditoria (aula_destino, aula_origem, merge_biometria, user_id)
 		VALUES ($1,$2,$3,$4)
@@ -298,7 +313,7 @@ func (r *Repository) UpsertPresencas(ctx context.Context, aulaID uuid.UUID, iten
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := r.conn(ctx).BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
@@ -306,15 +321,19 @@ func (r *Repository) UpsertPresencas(ctx context.Context, aulaID uuid.UUID, iten
 
 	for _, item := range itens {
 		if _, err := tx.Exec(ctx, `
-			INSERT INTO presencas (aula_id, matricula_id, status, origem, updated_at)
-			VALUES ($1,$2,$3,'MANUAL',now())
-			ON CONFLICT (aula_id, matricula_id)
+			INSERT INTO presencas (aula_id, aula_inicio, matricula_id, status, origem, updated_at)
+			SELECT id, inicio, $2, $3, 'MANUAL', now() FROM aulas WHERE id = $1
+			ON CONFLICT (aula_id, matricula_id, aula_inicio)
 			DO UPDATE SET status = EXCLUDED.status, origem = 'MANUAL', updated_at = now()
 		`, aulaID, item.MatriculaID, item.Status); err != nil {
 			return err
 		}
 	}
 
+	if err := notify.EnfileirarFaltas(ctx, tx, aulaID); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -322,15 +341,15 @@ func (r *Repository) ListNotas(ctx context.Context, professorID, turmaID uuid.UU
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT n.id, n.matricula_id, n.nota, n.obs, al.nome, al.matricula
 		FROM matriculas m
 		JOIN alunos al ON al.id = m.aluno_id
 		LEFT JOIN notas n ON n.matricula_id = m.id AND n.turma_id = m.turma_id AND n.disciplina = $3 AND n.bimestre = $4
 		JOIN professores_turmas pt ON pt.turma_id = m.turma_id AND pt.professor_id = $1
-		WHERE m.turma_id = $2 AND m.ativo = TRUE
+		WHERE m.turma_id = $2 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$5")+`
 		ORDER BY al.nome
-	`, professorID, turmaID, disciplina, bimestre)
+	`, professorID, turmaID, disciplina, bimestre, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -351,7 +370,7 @@ func (r *Repository) UpsertNotas(ctx context.Context, turmaID uuid.UUID, discipl
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := r.conn(ctx).BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
@@ -361,7 +380,7 @@ func (r *Repository) UpsertNotas(ctx context.Context, turmaID uuid.UUID, discipl
 		if _, err := tx.Exec(ctx, `
 			INSERT INTO notas (turma_id, disciplina, bimestre, matricula_id, nota, obs)
 			VALUES ($1,$2,$3,$4,$5,$6)
-			ON CONFLICT (turma_id, disciplina, bimestre, matricula_id)
+			ON CONFLICT (ano_letivo, turma_id, disciplina, bimestre, matricula_id)
 			DO UPDATE SET nota = EXCLUDED.nota, obs = EXCLUDED.obs
 		`, turmaID, disciplina, bimestre, item.MatriculaID, item.Nota, item.Obs); err != nil {
 			return err
@@ -375,14 +394,15 @@ func (r *Repository) ListAvaliacoes(ctx context.Context, professorID uuid.UUID,
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.turma_id, a.disciplina, a.titulo, a.status, a.inicio, a.fim, a.created_at, a.created_by
         FROM avaliacoes a
         JOIN professores_turmas pt ON pt.turma_id = a.turma_id AND pt.professor_id = $1
         WHERE ($2::uuid IS NULL OR a.turma_id = $2)
           AND ($3 = '' OR a.disciplina = $3)
+          AND `+db.TurmaNoTenant("a.turma_id", "$4")+`
         ORDER BY a.created_at DESC
-    `, professorID, turmaID, disciplina)
+    `, professorID, turmaID, disciplina, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -403,7 +423,7 @@ func (r *Repository) SaveAvaliacao(ctx context.Context, avaliacao Avaliacao, que
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := r.conn(ctx).BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -450,7 +470,7 @@ func (r *Repository) UpdateAvaliacaoStatus(ctx context.Context, avaliacaoID uuid
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	_, err := r.db.Exec(ctx, `UPDATE avaliacoes SET status=$1 WHERE id=$2`, status, avaliacaoID)
+	_, err := r.conn(ctx).Exec(ctx, `UPDATE avaliacoes SET status=$1 WHERE id=$2 AND `+db.TurmaNoTenant("turma_id", "$3"), status, avaliacaoID, httpmiddleware.TenantScope(ctx))
 	return err
 }
 
@@ -458,7 +478,7 @@ func (r *Repository) ListQuestoes(ctx context.Context, avaliacaoID uuid.UUID) ([
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT id, enunciado, alternativas, correta
 		FROM aval_questoes
 		WHERE avaliacao_id = $1
@@ -490,7 +510,7 @@ func (r *Repository) ListRespostas(ctx context.Context, avaliacaoID uuid.UUID) (
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT matricula_id, questao_id, alternativa
 		FROM aval_respostas
 		WHERE avaliacao_id = $1
@@ -515,10 +535,10 @@ func (r *Repository) UpsertNotaFromAvaliacao(ctx context.Context, turmaID uuid.U
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	_, err := r.db.Exec(ctx, `
+	_, err := r.conn(ctx).Exec(ctx, `
 		INSERT INTO notas (turma_id, disciplina, bimestre, matricula_id, nota)
 		VALUES ($1,$2,$3,$4,$5)
-		ON CONFLICT (turma_id, disciplina, bimestre, matricula_id)
+		ON CONFLICT (ano_letivo, turma_id, disciplina, bimestre, matricula_id)
 		DO UPDATE SET nota = EXCLUDED.nota
 	`, turmaID, disciplina, bimestre, matriculaID, nota)
 	return err
@@ -529,11 +549,11 @@ func (r *Repository) GetAvaliacao(ctx context.Context, avaliacaoID uuid.UUID) (A
 	defer cancel()
 
 	var a Avaliacao
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT id, turma_id, disciplina, titulo, status, inicio, fim, created_at, created_by
         FROM avaliacoes
-        WHERE id = $1
-    `, avaliacaoID).Scan(&a.ID, &a.TurmaID, &a.Disciplina, &a.Titulo, &a.Status, &a.Inicio, &a.Fim, &a.CreatedAt, &a.CreatedBy)
+        WHERE id = $1 AND `+db.TurmaNoTenant("turma_id", "$2")+`
+    `, avaliacaoID, httpmiddleware.TenantScope(ctx)).Scan(&a.ID, &a.TurmaID, &a.Disciplina, &a.Titulo, &a.Status, &a.Inicio, &a.Fim, &a.CreatedAt, &a.CreatedBy)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return a, errNotFound
 	}
@@ -545,11 +565,11 @@ func (r *Repository) EnsureProfessorTurma(ctx context.Context, professorID, turm
 	defer cancel()
 
 	var exists bool
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
 		SELECT TRUE
 		FROM professores_turmas
-		WHERE professor_id = $1 AND turma_id = $2
-	`, professorID, turmaID).Scan(&exists)
+		WHERE professor_id = $1 AND turma_id = $2 AND `+db.TurmaNoTenant("turma_id", "$3")+`
+	`, professorID, turmaID, httpmiddleware.TenantScope(ctx)).Scan(&exists)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return errNotFound
 	}
@@ -561,7 +581,7 @@ func (r *Repository) AulaOwner(ctx context.Context, aulaID uuid.UUID) (uuid.UUID
 	defer cancel()
 
 	var turmaID uuid.UUID
-	err := r.db.QueryRow(ctx, `SELECT turma_id FROM aulas WHERE id = $1`, aulaID).Scan(&turmaID)
+	err := r.conn(ctx).QueryRow(ctx, `SELECT turma_id FROM aulas WHERE id = $1 AND `+db.TurmaNoTenant("turma_id", "$2"), aulaID, httpmiddleware.TenantScope(ctx)).Scan(&turmaID)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return uuid.Nil, errNotFound
 	}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
 )
 
 var (
@@ -61,7 +63,8 @@ func (s *ProfessorService) ListTurmas(ctx context.Context, professorID uuid.UUID
 }
 
 func (s *ProfessorService) ListAulas(ctx context.Context, professorID uuid.UUID, day time.Time) ([]Aula, error) {
-	key := fmt.Sprintf("prof:aulas:%s:%s", professorID.String(), day.Format("2006-01-02"))
+	// A prefeitura entra na chave: o mesmo professor pode lecionar em mais de um município.
+	key := fmt.Sprintf("prof:aulas:%s:%s:%s", httpmiddleware.TenantScope(ctx), professorID.String(), day.Format("2006-01-02"))
 	if s.cache != nil {
 		if data, err := s.cache.Get(ctx, key).Bytes(); err == nil {
 			var aulas []Aula
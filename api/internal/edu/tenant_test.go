@@ -0,0 +1,79 @@
+package edu
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// Os testes de escopo por prefeitura exigem um banco migrado em TEST_DATABASE_URL, por exemplo:
+//
+//	TEST_DATABASE_URL=postgres://... go test ./internal/edu -run Tenant
+func tenantFixture(t *testing.T) (*pgxpool.Pool, uuid.UUID, uuid.UUID, uuid.UUID, uuid.UUID) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL não definido")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	var tenants [2]uuid.UUID
+	for i := range tenants {
+		slug := "teste-" + uuid.NewString()[:8]
+		if err := pool.QueryRow(ctx, `
+			INSERT INTO tenants (slug, display_name, domain) VALUES ($1, $1, $1 || '.teste.local') RETURNING id
+		`, slug).Scan(&tenants[i]); err != nil {
+			t.Fatal(err)
+		}
+		id := tenants[i]
+		t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM tenants WHERE id = $1`, id) })
+	}
+
+	var escolaID, turmaID uuid.UUID
+	if err := pool.QueryRow(ctx, `INSERT INTO escolas (nome, tenant_id) VALUES ('Escola Teste', $1) RETURNING id`, tenants[1]).Scan(&escolaID); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM escolas WHERE id = $1`, escolaID) })
+	if err := pool.QueryRow(ctx, `INSERT INTO turmas (nome, turno, escola_id) VALUES ('Turma Teste', 'MANHA', $1) RETURNING id`, escolaID).Scan(&turmaID); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM turmas WHERE id = $1`, turmaID) })
+
+	professorID := uuid.New()
+	if _, err := pool.Exec(ctx, `INSERT INTO professores_turmas (professor_id, turma_id, disciplinas) VALUES ($1, $2, '{Matemática}')`, professorID, turmaID); err != nil {
+		t.Fatal(err)
+	}
+	return pool, tenants[0], tenants[1], professorID, turmaID
+}
+
+func TestListTurmasFiltraTenant(t *testing.T) {
+	pool, outro, dono, professorID, turmaID := tenantFixture(t)
+	repo := NewRepository(pool)
+
+	turmas, err := repo.ListTurmas(httpmiddleware.SetTenant(context.Background(), outro), professorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turmas) != 0 {
+		t.Fatalf("turmas de outra prefeitura listadas: %+v", turmas)
+	}
+
+	turmas, err = repo.ListTurmas(httpmiddleware.SetTenant(context.Background(), dono), professorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turmas) != 1 || turmas[0].ID != turmaID {
+		t.Fatalf("unexpected turmas %+v", turmas)
+	}
+}
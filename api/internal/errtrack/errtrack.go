@@ -0,0 +1,381 @@
+// Package errtrack envia erros e panics para um servidor compatível com a API de ingestão do
+// Sentry (Sentry SaaS ou GlitchTip auto-hospedado), sem dependências externas. Os eventos
+// passam por amostragem e remoção de dados pessoais antes de sair do processo.
+package errtrack
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrNotConfigured indica ausência de DSN.
+var ErrNotConfigured = errors.New("errtrack: dsn não configurado")
+
+const (
+	LevelError   = "error"
+	LevelFatal   = "fatal"
+	LevelWarning = "warning"
+)
+
+// Config descreve o destino e a política de envio.
+type Config struct {
+	DSN         string
+	Environment string
+	Release     string
+	// SampleRate é a fração de eventos enviados (0–1).
+	SampleRate float64
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// Event é um erro a reportar.
+type Event struct {
+	Level     string
+	Message   string
+	ErrorType string
+	// Frames é a pilha do ponto de falha, do chamador mais externo ao mais interno.
+	Frames  []Frame
+	Tags    map[string]string
+	UserID  string
+	Request *Request
+	Extra   map[string]any
+}
+
+// Frame é uma linha da pilha de chamadas.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"filename"`
+	Line     int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Request resume a requisição HTTP do evento.
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+}
+
+// Client envia eventos em segundo plano; quando a fila enche, eventos são descartados para não
+// segurar requisições.
+type Client struct {
+	endpoint   string
+	authHeader string
+	cfg        Config
+	client     *http.Client
+	queue      chan []byte
+	wg         sync.WaitGroup
+	closeOnce  sync.Once
+	serverName string
+}
+
+// New cria o cliente a partir do DSN (https://chave@host/projeto) ou devolve ErrNotConfigured.
+func New(cfg Config) (*Client, error) {
+	if strings.TrimSpace(cfg.DSN) == "" {
+		return nil, ErrNotConfigured
+	}
+	endpoint, key, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+	host, _ := os.Hostname()
+
+	c := &Client{
+		endpoint:   endpoint,
+		authHeader: fmt.Sprintf("Sentry sentry_version=7, sentry_client=municipio-errtrack/1.0, sentry_key=%s", key),
+		cfg:        cfg,
+		client:     httpClient,
+		queue:      make(chan []byte, 100),
+		serverName: host,
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c, nil
+}
+
+// parseDSN converte o DSN no endpoint de ingestão do projeto.
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return "", "", errors.New("errtrack: dsn inválido")
+	}
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	project := path[idx+1:]
+	prefix := ""
+	if idx >= 0 {
+		prefix = "/" + path[:idx]
+	}
+	if project == "" {
+		return "", "", errors.New("errtrack: dsn sem projeto")
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project)
+	return endpoint, u.User.Username(), nil
+}
+
+// Capture enfileira o evento, respeitando a amostragem. Devolve false quando o evento foi
+// descartado pela amostragem ou pela fila cheia.
+func (c *Client) Capture(event Event) bool {
+	if c == nil {
+		return false
+	}
+	if c.cfg.SampleRate < 1 && mathrand.Float64() >= c.cfg.SampleRate {
+		return false
+	}
+	payload, err := json.Marshal(c.build(event, time.Now()))
+	if err != nil {
+		return false
+	}
+	select {
+	case c.queue <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// CaptureError reporta um erro com a pilha do chamador.
+func (c *Client) CaptureError(err error, tags map[string]string) bool {
+	if c == nil || err == nil {
+		return false
+	}
+	return c.Capture(Event{
+		Level:     LevelError,
+		Message:   err.Error(),
+		ErrorType: fmt.Sprintf("%T", err),
+		Frames:    Callers(2),
+		Tags:      tags,
+	})
+}
+
+// Close esvazia a fila, aguardando até o timeout configurado.
+func (c *Client) Close() {
+	if c == nil {
+		return
+	}
+	c.closeOnce.Do(func() {
+		close(c.queue)
+		done := make(chan struct{})
+		go func() {
+			c.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(c.cfg.Timeout):
+		}
+	})
+}
+
+func (c *Client) run() {
+	defer c.wg.Done()
+	for payload := range c.queue {
+		c.send(payload)
+	}
+}
+
+func (c *Client) send(payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.authHeader)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Não usa o logger global: o hook de log reportaria a própria falha de envio.
+		fmt.Fprintf(os.Stderr, "errtrack: envio falhou: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func (c *Client) build(event Event, now time.Time) map[string]any {
+	level := event.Level
+	if level == "" {
+		level = LevelError
+	}
+	message := Scrub(event.Message)
+	errorType := event.ErrorType
+	if errorType == "" {
+		errorType = "error"
+	}
+
+	payload := map[string]any{
+		"event_id":    newEventID(),
+		"timestamp":   now.UTC().Format(time.RFC3339Nano),
+		"level":       level,
+		"platform":    "go",
+		"logger":      "municipio",
+		"server_name": c.serverName,
+		"message":     map[string]any{"formatted": message},
+		"exception": map[string]any{"values": []map[string]any{{
+			"type":       errorType,
+			"value":      message,
+			"stacktrace": map[string]any{"frames": event.Frames},
+		}}},
+	}
+	if c.cfg.Environment != "" {
+		payload["environment"] = c.cfg.Environment
+	}
+	if c.cfg.Release != "" {
+		payload["release"] = c.cfg.Release
+	}
+	if len(event.Tags) > 0 {
+		tags := make(map[string]string, len(event.Tags))
+		for k, v := range event.Tags {
+			tags[k] = Scrub(v)
+		}
+		payload["tags"] = tags
+	}
+	// Apenas o identificador interno do usuário; nome, e-mail e IP nunca são enviados.
+	if event.UserID != "" {
+		payload["user"] = map[string]any{"id": event.UserID}
+	}
+	if event.Request != nil {
+		payload["request"] = map[string]any{
+			"method":  event.Request.Method,
+			"url":     ScrubURL(event.Request.URL),
+			"headers": ScrubHeaders(event.Request.Headers),
+		}
+	}
+	if len(event.Extra) > 0 {
+		extra := make(map[string]any, len(event.Extra))
+		for k, v := range event.Extra {
+			if s, ok := v.(string); ok {
+				v = Scrub(s)
+			}
+			extra[k] = v
+		}
+		payload["extra"] = extra
+	}
+	return payload
+}
+
+// Callers devolve a pilha atual em ordem do Sentry (mais externo primeiro), pulando skip quadros.
+func Callers(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+			InApp:    strings.Contains(frame.Function, "gestaozabele/municipio"),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return stack
+}
+
+// Hook reporta logs de nível error ou acima. Eventos com contexto marcado por Reported são
+// ignorados para não duplicar o que já foi enviado com pilha completa.
+type Hook struct {
+	Client *Client
+}
+
+// Run implementa zerolog.Hook.
+func (h Hook) Run(e *zerolog.Event, level zerolog.Level, message string) {
+	if h.Client == nil || level < zerolog.ErrorLevel || level == zerolog.NoLevel || level == zerolog.Disabled {
+		return
+	}
+	ctx := e.GetCtx()
+	if reported(ctx) {
+		return
+	}
+	eventLevel := LevelError
+	if level >= zerolog.FatalLevel {
+		eventLevel = LevelFatal
+	}
+	h.Client.Capture(Event{
+		Level:     eventLevel,
+		Message:   message,
+		ErrorType: "log",
+		Frames:    Callers(4),
+		Tags:      ContextTags(ctx),
+	})
+}
+
+type contextKey string
+
+const (
+	reportedKey contextKey = "errtrack.reported"
+	tagsKey     contextKey = "errtrack.tags"
+)
+
+// Reported marca o contexto de um log cujo erro já foi reportado.
+func Reported(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reportedKey, true)
+}
+
+func reported(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(reportedKey).(bool)
+	return v
+}
+
+// WithTags anexa tags aos eventos dos logs feitos com .Ctx(ctx).
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for k, v := range ContextTags(ctx) {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, tagsKey, merged)
+}
+
+// ContextTags devolve as tags anexadas ao contexto.
+func ContextTags(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+	return tags
+}
+
+func newEventID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strings.Repeat("0", 32)
+	}
+	return hex.EncodeToString(buf)
+}
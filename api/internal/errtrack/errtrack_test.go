@@ -0,0 +1,103 @@
+package errtrack
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDSN(t *testing.T) {
+	endpoint, key, err := parseDSN("https://abc123@o1.ingest.sentry.io/42")
+	if err != nil || endpoint != "https://o1.ingest.sentry.io/api/42/store/" || key != "abc123" {
+		t.Fatalf("parseDSN = %q %q %v", endpoint, key, err)
+	}
+	endpoint, _, err = parseDSN("http://chave@glitchtip.interno:8000/sub/7")
+	if err != nil || endpoint != "http://glitchtip.interno:8000/sub/api/7/store/" {
+		t.Fatalf("DSN com prefixo = %q %v", endpoint, err)
+	}
+	for _, invalid := range []string{"https://o1.ingest.sentry.io/42", "https://abc@host/", "::"} {
+		if _, _, err := parseDSN(invalid); err == nil {
+			t.Fatalf("DSN %q deveria ser inválido", invalid)
+		}
+	}
+}
+
+func TestScrub(t *testing.T) {
+	got := Scrub("falha para maria@prefeitura.gov.br cpf 123.456.789-09 tel (83) 99876-5432 Bearer eyJhbGciOi.x.y")
+	for _, leaked := range []string{"maria@", "123.456.789-09", "99876-5432", "eyJhbGciOi"} {
+		if strings.Contains(got, leaked) {
+			t.Fatalf("Scrub vazou %q: %s", leaked, got)
+		}
+	}
+
+	u := ScrubURL("https://user:pw@cidade.gov.br/api/x?token=abc&page=2&cpf=12345678909")
+	if strings.Contains(u, "abc") || strings.Contains(u, "12345678909") || strings.Contains(u, "pw@") || !strings.Contains(u, "page=2") {
+		t.Fatalf("ScrubURL = %s", u)
+	}
+
+	headers := ScrubHeaders(map[string]string{"Authorization": "Bearer x", "Cookie": "a=b", "User-Agent": "app"})
+	if headers["Authorization"] != filtered || headers["Cookie"] != filtered || headers["User-Agent"] != "app" {
+		t.Fatalf("ScrubHeaders = %v", headers)
+	}
+}
+
+func TestClientSendsScrubbedEvent(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Sentry-Auth")
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		_ = json.Unmarshal(body, &payload)
+		received <- payload
+	}))
+	defer srv.Close()
+
+	client, err := New(Config{DSN: strings.Replace(srv.URL, "://", "://chave@", 1) + "/1", Environment: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := client.Capture(Event{
+		Message: "erro ao processar joao@exemplo.com",
+		Tags:    map[string]string{"tenant": "cidade.gov.br", "route": "/api/x"},
+		UserID:  "7d1c",
+		Request: &Request{Method: "GET", URL: "https://cidade.gov.br/api/x?senha=123", Headers: map[string]string{"Authorization": "Bearer t"}},
+	})
+	if !ok {
+		t.Fatal("evento deveria ser enfileirado")
+	}
+	client.Close()
+
+	select {
+	case payload := <-received:
+		raw, _ := json.Marshal(payload)
+		if strings.Contains(string(raw), "joao@") || strings.Contains(string(raw), "senha=123") || strings.Contains(string(raw), "Bearer t") {
+			t.Fatalf("payload com dados pessoais: %s", raw)
+		}
+		if payload["environment"] != "test" || payload["tags"].(map[string]any)["tenant"] != "cidade.gov.br" {
+			t.Fatalf("payload sem contexto: %s", raw)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("evento não enviado")
+	}
+	if !strings.Contains(auth, "sentry_key=chave") {
+		t.Fatalf("cabeçalho de autenticação = %q", auth)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	client := &Client{cfg: Config{SampleRate: 0.000001}, queue: make(chan []byte, 10)}
+	sent := 0
+	for i := 0; i < 100; i++ {
+		if client.Capture(Event{Message: "x"}) {
+			sent++
+		}
+	}
+	if sent > 1 {
+		t.Fatalf("amostragem quase nula enviou %d eventos", sent)
+	}
+}
@@ -0,0 +1,68 @@
+package errtrack
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const filtered = "[filtrado]"
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	cpfPattern    = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	phonePattern  = regexp.MustCompile(`\(?\b\d{2}\)?\s?9?\d{4}-?\d{4}\b`)
+)
+
+// sensitiveKeys são nomes de cabeçalhos e parâmetros cujo valor nunca sai do processo.
+var sensitiveKeys = []string{"authorization", "cookie", "token", "password", "senha", "secret", "cpf", "api-key", "apikey", "code", "session"}
+
+// Scrub remove e-mails, CPFs, telefones e tokens de um texto livre.
+func Scrub(value string) string {
+	value = bearerPattern.ReplaceAllString(value, "Bearer "+filtered)
+	value = emailPattern.ReplaceAllString(value, filtered)
+	value = cpfPattern.ReplaceAllString(value, filtered)
+	value = phonePattern.ReplaceAllString(value, filtered)
+	return value
+}
+
+// ScrubURL filtra parâmetros sensíveis da query e dados pessoais do caminho.
+func ScrubURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Scrub(raw)
+	}
+	query := u.Query()
+	for key := range query {
+		if isSensitive(key) {
+			query.Set(key, filtered)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.User = nil
+	return Scrub(u.String())
+}
+
+// ScrubHeaders descarta cabeçalhos sensíveis e limpa os demais.
+func ScrubHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if isSensitive(key) {
+			out[key] = filtered
+			continue
+		}
+		out[key] = Scrub(value)
+	}
+	return out
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package esign
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultClicksignBase = "https://app.clicksign.com"
+
+type clicksign struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	secret     string
+}
+
+func newClicksign(cfg Config, httpClient *http.Client) *clicksign {
+	base := strings.TrimSpace(cfg.APIBase)
+	if base == "" {
+		base = defaultClicksignBase
+	}
+	return &clicksign{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(base, "/"),
+		token:      cfg.APIToken,
+		secret:     cfg.WebhookSecret,
+	}
+}
+
+func (c *clicksign) Name() string { return ProviderClicksign }
+
+// Send cria o documento, cadastra os signatários e os vincula ao documento.
+func (c *clicksign) Send(ctx context.Context, doc Document) (Envelope, error) {
+	if len(doc.Signers) == 0 {
+		return Envelope{}, errors.New("clicksign: informe ao menos um signatário")
+	}
+
+	contentType := doc.ContentType
+	if contentType == "" {
+		contentType = "application/pdf"
+	}
+
+	var created struct {
+		Document struct {
+			Key string `json:"key"`
+		} `json:"document"`
+	}
+	documentBody := map[string]any{
+		"document": map[string]any{
+			"path":           "/contratos/" + doc.Filename,
+			"content_base64": fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(doc.Content)),
+			"auto_close":     true,
+			"locale":         "pt-BR",
+		},
+	}
+	if err := c.do(ctx, "/api/v1/documents", documentBody, &created); err != nil {
+		return Envelope{}, err
+	}
+	if created.Document.Key == "" {
+		return Envelope{}, errors.New("clicksign: documento sem chave")
+	}
+
+	envelope := Envelope{DocumentID: created.Document.Key, Status: StatusPending}
+	for _, signer := range doc.Signers {
+		var signerResp struct {
+			Signer struct {
+				Key string `json:"key"`
+			} `json:"signer"`
+		}
+		signerBody := map[string]any{
+			"signer": map[string]any{
+				"email": signer.Email,
+				"name":  signer.Name,
+				"auths": []string{"email"},
+			},
+		}
+		if err := c.do(ctx, "/api/v1/signers", signerBody, &signerResp); err != nil {
+			return Envelope{}, err
+		}
+
+		var listResp struct {
+			List struct {
+				URL string `json:"url"`
+			} `json:"list"`
+		}
+		listBody := map[string]any{
+			"list": map[string]any{
+				"document_key": created.Document.Key,
+				"signer_key":   signerResp.Signer.Key,
+				"sign_as":      "sign",
+			},
+		}
+		if err := c.do(ctx, "/api/v1/lists", listBody, &listResp); err != nil {
+			return Envelope{}, err
+		}
+		if envelope.SignURL == "" {
+			envelope.SignURL = listResp.List.URL
+		}
+	}
+
+	return envelope, nil
+}
+
+// ParseWebhook valida o cabeçalho Content-Hmac e traduz o evento recebido.
+func (c *clicksign) ParseWebhook(r *http.Request, body []byte) (Event, error) {
+	if !verifyHMAC(c.secret, r.Header.Get("Content-Hmac"), body) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var payload struct {
+		Event struct {
+			Name       string          `json:"name"`
+			OccurredAt time.Time       `json:"occurred_at"`
+			Data       json.RawMessage `json:"data"`
+		} `json:"event"`
+		Document struct {
+			Key    string `json:"key"`
+			Status string `json:"status"`
+		} `json:"document"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return Event{}, fmt.Errorf("clicksign: payload inválido: %w", err)
+	}
+	if payload.Document.Key == "" {
+		return Event{}, errors.New("clicksign: documento ausente no webhook")
+	}
+
+	event := Event{
+		DocumentID: payload.Document.Key,
+		Name:       payload.Event.Name,
+		OccurredAt: payload.Event.OccurredAt,
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	var data struct {
+		Signer struct {
+			Email string `json:"email"`
+		} `json:"signer"`
+	}
+	if len(payload.Event.Data) > 0 {
+		_ = json.Unmarshal(payload.Event.Data, &data)
+	}
+	event.Signer = data.Signer.Email
+
+	switch payload.Event.Name {
+	case "sign":
+		event.Status = StatusPartiallySigned
+	case "auto_close", "close":
+		event.Status = StatusSigned
+	case "refusal":
+		event.Status = StatusRefused
+	case "cancel":
+		event.Status = StatusCancelled
+	case "deadline":
+		event.Status = StatusExpired
+	default:
+		return event, ErrIgnoredEvent
+	}
+	return event, nil
+}
+
+func (c *clicksign) do(ctx context.Context, path string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := c.baseURL + path + "?access_token=" + url.QueryEscape(c.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("clicksign: status %d em %s", resp.StatusCode, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,174 @@
+package esign
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultD4SignBase = "https://secure.d4sign.com.br"
+
+type d4sign struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	cryptKey   string
+	safeID     string
+	secret     string
+	webhookURL string
+}
+
+func newD4Sign(cfg Config, httpClient *http.Client) *d4sign {
+	base := strings.TrimSpace(cfg.APIBase)
+	if base == "" {
+		base = defaultD4SignBase
+	}
+	return &d4sign{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(base, "/"),
+		token:      cfg.APIToken,
+		cryptKey:   cfg.CryptKey,
+		safeID:     cfg.SafeID,
+		secret:     cfg.WebhookSecret,
+		webhookURL: strings.TrimSpace(cfg.WebhookURL),
+	}
+}
+
+func (d *d4sign) Name() string { return ProviderD4Sign }
+
+// Send envia o arquivo ao cofre, cadastra signatários, registra o webhook e dispara os e-mails.
+func (d *d4sign) Send(ctx context.Context, doc Document) (Envelope, error) {
+	if len(doc.Signers) == 0 {
+		return Envelope{}, errors.New("d4sign: informe ao menos um signatário")
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", doc.Filename)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if _, err := part.Write(doc.Content); err != nil {
+		return Envelope{}, err
+	}
+	if err := writer.Close(); err != nil {
+		return Envelope{}, err
+	}
+
+	var uploaded struct {
+		UUID string `json:"uuid"`
+	}
+	if err := d.do(ctx, "/api/v1/documents/"+d.safeID+"/upload", writer.FormDataContentType(), &buf, &uploaded); err != nil {
+		return Envelope{}, err
+	}
+	if uploaded.UUID == "" {
+		return Envelope{}, errors.New("d4sign: documento sem uuid")
+	}
+
+	signers := make([]map[string]string, 0, len(doc.Signers))
+	for _, signer := range doc.Signers {
+		signers = append(signers, map[string]string{
+			"email":                 signer.Email,
+			"act":                   "1",
+			"foreign":               "0",
+			"certificadoicpbr":      "0",
+			"assinatura_presencial": "0",
+		})
+	}
+	if err := d.doJSON(ctx, "/api/v1/documents/"+uploaded.UUID+"/createlist", map[string]any{"signers": signers}); err != nil {
+		return Envelope{}, err
+	}
+
+	if d.webhookURL != "" {
+		if err := d.doJSON(ctx, "/api/v1/documents/"+uploaded.UUID+"/webhooks", map[string]string{"url": d.webhookURL}); err != nil {
+			return Envelope{}, err
+		}
+	}
+
+	if err := d.doJSON(ctx, "/api/v1/documents/"+uploaded.UUID+"/sendtosigner", map[string]string{"message": "", "workflow": "0", "skip_email": "0"}); err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{DocumentID: uploaded.UUID, Status: StatusPending}, nil
+}
+
+// ParseWebhook interpreta o POST de formulário do D4Sign. O cabeçalho Content-Hmac
+// contém o HMAC-SHA256 do uuid do documento.
+func (d *d4sign) ParseWebhook(r *http.Request, body []byte) (Event, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return Event{}, fmt.Errorf("d4sign: payload inválido: %w", err)
+	}
+
+	documentID := strings.TrimSpace(values.Get("uuid"))
+	if documentID == "" {
+		return Event{}, errors.New("d4sign: documento ausente no webhook")
+	}
+	if !verifyHMAC(d.secret, r.Header.Get("Content-Hmac"), []byte(documentID)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	event := Event{
+		DocumentID: documentID,
+		Name:       values.Get("type_post"),
+		Signer:     strings.TrimSpace(values.Get("email")),
+		OccurredAt: time.Now(),
+	}
+
+	switch event.Name {
+	case "1":
+		event.Status = StatusSigned
+	case "3":
+		event.Status = StatusCancelled
+	case "4":
+		event.Status = StatusPartiallySigned
+	default:
+		return event, ErrIgnoredEvent
+	}
+	return event, nil
+}
+
+func (d *d4sign) doJSON(ctx context.Context, path string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return d.do(ctx, path, "application/json", bytes.NewReader(payload), nil)
+}
+
+func (d *d4sign) do(ctx context.Context, path, contentType string, body io.Reader, out any) error {
+	q := url.Values{}
+	q.Set("tokenAPI", d.token)
+	if d.cryptKey != "" {
+		q.Set("cryptKey", d.cryptKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.baseURL+path+"?"+q.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("d4sign: status %d em %s", resp.StatusCode, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,126 @@
+package esign
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Status normalizado do andamento de assinatura, independente do provedor.
+const (
+	StatusPending         = "pending"
+	StatusPartiallySigned = "partially_signed"
+	StatusSigned          = "signed"
+	StatusRefused         = "refused"
+	StatusCancelled       = "cancelled"
+	StatusExpired         = "expired"
+)
+
+const (
+	ProviderClicksign = "clicksign"
+	ProviderD4Sign    = "d4sign"
+)
+
+var (
+	// ErrNotConfigured indica ausência de provedor de assinatura.
+	ErrNotConfigured = errors.New("esign: provedor não configurado")
+	// ErrInvalidSignature indica webhook com assinatura HMAC inválida.
+	ErrInvalidSignature = errors.New("esign: assinatura do webhook inválida")
+	// ErrIgnoredEvent indica evento de webhook sem impacto no status.
+	ErrIgnoredEvent = errors.New("esign: evento ignorado")
+)
+
+// Signer representa um signatário do documento.
+type Signer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Document descreve o arquivo enviado para assinatura.
+type Document struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	Signers     []Signer
+}
+
+// Envelope é o resultado do envio ao provedor.
+type Envelope struct {
+	DocumentID string
+	SignURL    string
+	Status     string
+}
+
+// Event representa uma atualização recebida via webhook.
+type Event struct {
+	DocumentID string
+	Status     string
+	Name       string
+	Signer     string
+	OccurredAt time.Time
+}
+
+// Provider abstrai o provedor de assinatura eletrônica.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, doc Document) (Envelope, error)
+	ParseWebhook(r *http.Request, body []byte) (Event, error)
+}
+
+// Config concentra credenciais do provedor.
+type Config struct {
+	Provider      string
+	APIBase       string
+	APIToken      string
+	CryptKey      string
+	SafeID        string
+	WebhookSecret string
+	WebhookURL    string
+}
+
+// New devolve o provedor configurado ou ErrNotConfigured.
+func New(cfg Config) (Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" {
+		return nil, ErrNotConfigured
+	}
+	if strings.TrimSpace(cfg.APIToken) == "" {
+		return nil, errors.New("esign: api token obrigatório")
+	}
+	if strings.TrimSpace(cfg.WebhookSecret) == "" {
+		return nil, errors.New("esign: segredo do webhook obrigatório")
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+
+	switch name {
+	case ProviderClicksign:
+		return newClicksign(cfg, httpClient), nil
+	case ProviderD4Sign:
+		if strings.TrimSpace(cfg.SafeID) == "" {
+			return nil, errors.New("esign: cofre (safe) do D4Sign obrigatório")
+		}
+		return newD4Sign(cfg, httpClient), nil
+	default:
+		return nil, fmt.Errorf("esign: provedor %s não suportado", name)
+	}
+}
+
+// verifyHMAC compara o cabeçalho "sha256=<hex>" com o HMAC do conteúdo.
+func verifyHMAC(secret string, header string, content []byte) bool {
+	header = strings.TrimSpace(header)
+	header = strings.TrimPrefix(header, "sha256=")
+	received, err := hex.DecodeString(header)
+	if err != nil || len(received) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(content)
+	return hmac.Equal(received, mac.Sum(nil))
+}
@@ -0,0 +1,66 @@
+package esign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func sign(secret string, content []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(content)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestClicksignWebhook(t *testing.T) {
+	provider, err := New(Config{Provider: "clicksign", APIToken: "tok", WebhookSecret: "s3cr3t"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	body := []byte(`{"event":{"name":"auto_close","data":{}},"document":{"key":"doc-1","status":"closed"}}`)
+	req := httptest.NewRequest("POST", "/webhooks/esign/clicksign", strings.NewReader(string(body)))
+	req.Header.Set("Content-Hmac", sign("s3cr3t", body))
+
+	event, err := provider.ParseWebhook(req, body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if event.DocumentID != "doc-1" || event.Status != StatusSigned {
+		t.Fatalf("evento inesperado: %+v", event)
+	}
+
+	req.Header.Set("Content-Hmac", sign("outro", body))
+	if _, err := provider.ParseWebhook(req, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("esperava ErrInvalidSignature, obteve %v", err)
+	}
+}
+
+func TestD4SignWebhook(t *testing.T) {
+	provider, err := New(Config{Provider: "d4sign", APIToken: "tok", SafeID: "safe", WebhookSecret: "s3cr3t"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	body := []byte("uuid=abc-123&type_post=4&email=prefeito%40cidade.gov.br")
+	req := httptest.NewRequest("POST", "/webhooks/esign/d4sign", strings.NewReader(string(body)))
+	req.Header.Set("Content-Hmac", sign("s3cr3t", []byte("abc-123")))
+
+	event, err := provider.ParseWebhook(req, body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if event.Status != StatusPartiallySigned || event.Signer != "prefeito@cidade.gov.br" {
+		t.Fatalf("evento inesperado: %+v", event)
+	}
+}
+
+func TestNewWithoutProvider(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("esperava ErrNotConfigured, obteve %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package estoque
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/notify"
+)
+
+// Alerter avisa os secretários e administradores de cada secretaria quando itens do almoxarifado
+// ficam abaixo do estoque mínimo. Cada item é avisado uma vez até o saldo se recompor.
+type Alerter struct {
+	pool       *pgxpool.Pool
+	dispatcher *notify.Dispatcher
+	logger     zerolog.Logger
+}
+
+// NewAlerter cria o verificador de estoque baixo.
+func NewAlerter(pool *pgxpool.Pool, dispatcher *notify.Dispatcher, logger zerolog.Logger) *Alerter {
+	return &Alerter{pool: pool, dispatcher: dispatcher, logger: logger}
+}
+
+type baixo struct {
+	itemID     uuid.UUID
+	secretaria string
+	nome       string
+	unidade    string
+	saldo      float64
+	minimo     float64
+}
+
+// destinatario agrupa os itens de um usuário por prefeitura, que decide se o aviso é entregue.
+type destinatario struct {
+	userID   uuid.UUID
+	tenantID uuid.UUID
+	email    string
+	itens    []baixo
+}
+
+// RunOnce envia os avisos pendentes e marca os itens avisados. Sob db.Resolver.EachCluster só
+// olha as prefeituras roteadas para o cluster.
+func (a *Alerter) RunOnce(ctx context.Context) error {
+	if a.dispatcher == nil {
+		return nil
+	}
+	pool := db.Conn(ctx, a.pool)
+	rows, err := pool.Query(ctx, `
+        SELECT i.id, s.nome, i.nome, i.unidade, i.saldo::float8, i.estoque_minimo::float8, u.id, u.email, s.tenant_id
+        FROM estoque_itens i
+        JOIN secretarias s ON s.id = i.secretaria_id
+        JOIN usuarios_secretarias us ON us.secretaria_id = i.secretaria_id AND us.papel IN ('SECRETARIO', 'ADMIN_TEC')
+        JOIN usuarios u ON u.id = us.usuario_id AND u.ativo
+        WHERE i.ativo AND i.saldo < i.estoque_minimo AND i.alerta_enviado_em IS NULL
+          AND ($1::uuid[] IS NULL OR s.tenant_id = ANY($1))
+        ORDER BY u.id, s.tenant_id, s.nome, i.nome
+    `, db.Tenants(ctx))
+	if err != nil {
+		return err
+	}
+	type chave struct{ user, tenant uuid.UUID }
+	byUser := map[chave]*destinatario{}
+	var order []chave
+	itens := map[uuid.UUID]struct{}{}
+	for rows.Next() {
+		var (
+			item   baixo
+			user   uuid.UUID
+			email  string
+			tenant uuid.UUID
+		)
+		if err := rows.Scan(&item.itemID, &item.secretaria, &item.nome, &item.unidade, &item.saldo, &item.minimo, &user, &email, &tenant); err != nil {
+			rows.Close()
+			return err
+		}
+		key := chave{user: user, tenant: tenant}
+		d, ok := byUser[key]
+		if !ok {
+			d = &destinatario{userID: user, tenantID: tenant, email: email}
+			byUser[key] = d
+			order = append(order, key)
+		}
+		d.itens = append(d.itens, item)
+		itens[item.itemID] = struct{}{}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(itens) == 0 {
+		return nil
+	}
+
+	for _, key := range order {
+		d := byUser[key]
+		notification := notify.Notification{
+			TenantID: d.tenantID,
+			Audience: "backoffice",
+			UserID:   d.userID,
+			Category: notify.CategoryAvisos,
+			Title:    fmt.Sprintf("Estoque baixo: %d item(ns)", len(d.itens)),
+			Body:     alertBody(d.itens),
+			Email:    d.email,
+		}
+		if _, err := a.dispatcher.Dispatch(ctx, notification); err != nil {
+			a.logger.Warn().Err(err).Str("user_id", d.userID.String()).Msg("estoque: falha ao despachar aviso de estoque baixo")
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(itens))
+	for id := range itens {
+		ids = append(ids, id)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE estoque_itens SET alerta_enviado_em = now() WHERE id = ANY($1)`, ids); err != nil {
+		return err
+	}
+	a.logger.Info().Int("itens", len(ids)).Int("destinatarios", len(order)).Msg("estoque: avisos de estoque baixo enviados")
+	return nil
+}
+
+// alertBody lista os itens por secretaria, uma linha por item.
+func alertBody(itens []baixo) string {
+	sorted := append([]baixo(nil), itens...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].secretaria != sorted[j].secretaria {
+			return sorted[i].secretaria < sorted[j].secretaria
+		}
+		return sorted[i].nome < sorted[j].nome
+	})
+	var sb strings.Builder
+	current := ""
+	for _, item := range sorted {
+		if item.secretaria != current {
+			if current != "" {
+				sb.WriteString("\n")
+			}
+			current = item.secretaria
+			sb.WriteString(current + ":\n")
+		}
+		fmt.Fprintf(&sb, "• %s: saldo %s %s (mínimo %s)\n", item.nome, formatQuantidade(item.saldo), item.unidade, formatQuantidade(item.minimo))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatQuantidade escreve a quantidade com vírgula decimal e sem zeros à direita.
+func formatQuantidade(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+}
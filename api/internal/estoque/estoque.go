@@ -0,0 +1,193 @@
+// Package estoque controla o almoxarifado das secretarias: itens, saldos, movimentos e estoque
+// mínimo. As saídas abastecem as ordens de serviço e a merenda das escolas.
+package estoque
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica item inexistente ou de outro tenant.
+	ErrNotFound = errors.New("estoque: item não encontrado")
+	// ErrDuplicate indica nome de item já usado na secretaria.
+	ErrDuplicate = errors.New("estoque: item já cadastrado")
+	// ErrSecretaria indica secretaria que não pertence à prefeitura.
+	ErrSecretaria = errors.New("estoque: secretaria não pertence à prefeitura")
+	// ErrDestino indica ordem de serviço ou escola de outra prefeitura.
+	ErrDestino = errors.New("estoque: destino inválido")
+	// ErrInactive indica item desativado, que não aceita movimentos.
+	ErrInactive = errors.New("estoque: item inativo")
+)
+
+// SaldoError indica saída maior que o saldo do item.
+type SaldoError struct {
+	Item  string
+	Saldo float64
+}
+
+func (e *SaldoError) Error() string {
+	return "estoque: saldo insuficiente de " + e.Item
+}
+
+// Tipos de movimento.
+const (
+	TipoEntrada = "entrada"
+	TipoSaida   = "saida"
+	TipoAjuste  = "ajuste"
+)
+
+// Item é um material do almoxarifado de uma secretaria.
+type Item struct {
+	ID            uuid.UUID  `json:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	SecretariaID  uuid.UUID  `json:"secretaria_id"`
+	Nome          string     `json:"nome"`
+	Unidade       string     `json:"unidade"`
+	Categoria     *string    `json:"categoria,omitempty"`
+	EstoqueMinimo float64    `json:"estoque_minimo"`
+	Saldo         float64    `json:"saldo"`
+	Ativo         bool       `json:"ativo"`
+	Baixo         bool       `json:"baixo"`
+	AlertadoEm    *time.Time `json:"alertado_em,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ItemInput contém os campos editáveis de um item; SecretariaID só vale no cadastro e o saldo só
+// muda por movimentos.
+type ItemInput struct {
+	SecretariaID  uuid.UUID
+	Nome          string
+	Unidade       string
+	Categoria     *string
+	EstoqueMinimo float64
+	Ativo         bool
+}
+
+// Normalize limpa e valida a entrada.
+func (in *ItemInput) Normalize() error {
+	in.Nome = strings.TrimSpace(in.Nome)
+	in.Unidade = strings.ToLower(strings.TrimSpace(in.Unidade))
+	if in.Categoria != nil {
+		if c := strings.ToLower(strings.TrimSpace(*in.Categoria)); c != "" {
+			in.Categoria = &c
+		} else {
+			in.Categoria = nil
+		}
+	}
+	switch {
+	case in.Nome == "":
+		return errors.New("nome obrigatório")
+	case in.Unidade == "":
+		return errors.New("unidade obrigatória")
+	case in.EstoqueMinimo < 0:
+		return errors.New("estoque mínimo inválido")
+	}
+	return nil
+}
+
+// Movimento é uma entrada, saída ou ajuste de inventário do item.
+type Movimento struct {
+	ID            uuid.UUID  `json:"id"`
+	ItemID        uuid.UUID  `json:"item_id"`
+	Tipo          string     `json:"tipo"`
+	Quantidade    float64    `json:"quantidade"`
+	SaldoApos     float64    `json:"saldo_apos"`
+	CustoUnitario *float64   `json:"custo_unitario,omitempty"`
+	Data          time.Time  `json:"data"`
+	Motivo        *string    `json:"motivo,omitempty"`
+	OrdemID       *uuid.UUID `json:"ordem_id,omitempty"`
+	EscolaID      *uuid.UUID `json:"escola_id,omitempty"`
+	AtorID        *uuid.UUID `json:"ator_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// MovimentoInput descreve um movimento. Em entradas e saídas Quantidade é o volume movimentado;
+// no ajuste é o saldo contado no inventário.
+type MovimentoInput struct {
+	ItemID        uuid.UUID
+	Tipo          string
+	Quantidade    float64
+	CustoUnitario *float64
+	Data          time.Time
+	Motivo        *string
+	OrdemID       *uuid.UUID
+	EscolaID      *uuid.UUID
+	AtorID        *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada; sem data, vale o dia de hoje.
+func (in *MovimentoInput) Normalize() error {
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	if in.Motivo != nil {
+		if m := strings.TrimSpace(*in.Motivo); m != "" {
+			in.Motivo = &m
+		} else {
+			in.Motivo = nil
+		}
+	}
+	if in.Data.IsZero() {
+		in.Data = time.Now()
+	}
+	switch {
+	case in.Tipo != TipoEntrada && in.Tipo != TipoSaida && in.Tipo != TipoAjuste:
+		return errors.New("tipo de movimento inválido")
+	case in.Tipo == TipoAjuste && in.Quantidade < 0:
+		return errors.New("saldo contado inválido")
+	case in.Tipo != TipoAjuste && in.Quantidade <= 0:
+		return errors.New("quantidade deve ser positiva")
+	case in.CustoUnitario != nil && *in.CustoUnitario < 0:
+		return errors.New("custo inválido")
+	case in.Tipo != TipoSaida && (in.OrdemID != nil || in.EscolaID != nil):
+		return errors.New("ordem de serviço e escola só valem para saídas")
+	case in.OrdemID != nil && in.EscolaID != nil:
+		return errors.New("informe ordem de serviço ou escola, não ambos")
+	case in.Tipo == TipoAjuste && in.Motivo == nil:
+		return errors.New("ajuste de inventário exige motivo")
+	}
+	return nil
+}
+
+// Delta calcula a variação do saldo provocada pelo movimento. Saída acima do saldo não é aceita.
+func Delta(tipo string, quantidade, saldo float64) (float64, bool) {
+	var delta float64
+	switch tipo {
+	case TipoEntrada:
+		delta = quantidade
+	case TipoSaida:
+		delta = -quantidade
+	case TipoAjuste:
+		delta = quantidade - saldo
+	}
+	// NUMERIC(14,3) no banco: arredonda para não recusar saídas por resíduo de ponto flutuante.
+	delta = math.Round(delta*1000) / 1000
+	return delta, saldo+delta >= -0.0005
+}
+
+// Filter restringe a listagem de itens; inativos ficam de fora salvo pedido.
+type Filter struct {
+	SecretariaID *uuid.UUID
+	Categoria    string
+	Query        string
+	SomenteBaixo bool
+	Inativos     bool
+}
+
+// Consumo resume os movimentos de um item num mês.
+type Consumo struct {
+	Mes          string    `json:"mes"`
+	ItemID       uuid.UUID `json:"item_id"`
+	Item         string    `json:"item"`
+	Unidade      string    `json:"unidade"`
+	SecretariaID uuid.UUID `json:"secretaria_id"`
+	Entradas     float64   `json:"entradas"`
+	Saidas       float64   `json:"saidas"`
+	Ordens       float64   `json:"saidas_ordens_servico"`
+	Merenda      float64   `json:"saidas_merenda"`
+	Ajustes      float64   `json:"ajustes"`
+}
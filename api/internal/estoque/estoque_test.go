@@ -0,0 +1,68 @@
+package estoque
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDelta(t *testing.T) {
+	cases := []struct {
+		tipo              string
+		quantidade, saldo float64
+		want              float64
+		ok                bool
+	}{
+		{TipoEntrada, 10, 2, 10, true},
+		{TipoSaida, 2, 2, -2, true},
+		{TipoSaida, 2.5, 2, -2.5, false},
+		{TipoSaida, 0.3, 0.1 + 0.2, -0.3, true},
+		{TipoAjuste, 7, 10, -3, true},
+		{TipoAjuste, 12, 10, 2, true},
+	}
+	for _, c := range cases {
+		got, ok := Delta(c.tipo, c.quantidade, c.saldo)
+		if got != c.want || ok != c.ok {
+			t.Errorf("Delta(%s, %v, %v) = %v, %v; want %v, %v", c.tipo, c.quantidade, c.saldo, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestMovimentoInputNormalize(t *testing.T) {
+	escola := uuid.New()
+	blank := "  "
+	cases := []struct {
+		name string
+		in   MovimentoInput
+		ok   bool
+	}{
+		{"entrada", MovimentoInput{Tipo: " Entrada ", Quantidade: 5}, true},
+		{"tipo inválido", MovimentoInput{Tipo: "doacao", Quantidade: 5}, false},
+		{"quantidade zero", MovimentoInput{Tipo: TipoSaida}, false},
+		{"merenda", MovimentoInput{Tipo: TipoSaida, Quantidade: 1, EscolaID: &escola}, true},
+		{"escola em entrada", MovimentoInput{Tipo: TipoEntrada, Quantidade: 1, EscolaID: &escola}, false},
+		{"ajuste sem motivo", MovimentoInput{Tipo: TipoAjuste, Quantidade: 0, Motivo: &blank}, false},
+	}
+	for _, c := range cases {
+		in := c.in
+		err := in.Normalize()
+		if (err == nil) != c.ok {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+		if err == nil && in.Data.IsZero() {
+			t.Errorf("%s: data não preenchida", c.name)
+		}
+	}
+}
+
+func TestAlertBody(t *testing.T) {
+	body := alertBody([]baixo{
+		{secretaria: "Saúde", nome: "Luvas", unidade: "cx", saldo: 1, minimo: 5},
+		{secretaria: "Educação", nome: "Óleo", unidade: "l", saldo: 2.5, minimo: 10},
+		{secretaria: "Educação", nome: "Arroz", unidade: "kg", saldo: 0, minimo: 50},
+	})
+	want := "Educação:\n• Arroz: saldo 0 kg (mínimo 50)\n• Óleo: saldo 2,5 l (mínimo 10)\n\nSaúde:\n• Luvas: saldo 1 cx (mínimo 5)"
+	if body != want {
+		t.Errorf("alertBody = %q", body)
+	}
+}
@@ -0,0 +1,255 @@
+package estoque
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const itemColumns = `id, tenant_id, secretaria_id, nome, unidade, categoria, estoque_minimo::float8, saldo::float8, ativo,
+        ativo AND saldo < estoque_minimo, alerta_enviado_em, created_at, updated_at`
+
+// Repository provê acesso ao almoxarifado.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// ListItens lista os itens da prefeitura segundo o filtro, por nome.
+func (r *Repository) ListItens(ctx context.Context, tenantID uuid.UUID, filter Filter) ([]Item, error) {
+	clauses := []string{"tenant_id = $1"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.SecretariaID != nil {
+		add("secretaria_id = $?", *filter.SecretariaID)
+	}
+	if filter.Categoria != "" {
+		add("categoria = $?", strings.ToLower(filter.Categoria))
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		add("nome ILIKE $?", "%"+q+"%")
+	}
+	if filter.SomenteBaixo {
+		clauses = append(clauses, "ativo AND saldo < estoque_minimo")
+	} else if !filter.Inativos {
+		clauses = append(clauses, "ativo")
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+itemColumns+`
+        FROM estoque_itens
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY nome`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Item, error) {
+		item, err := scanItem(row)
+		if err != nil {
+			return Item{}, err
+		}
+		return *item, nil
+	})
+}
+
+// GetItem busca o item do tenant.
+func (r *Repository) GetItem(ctx context.Context, tenantID, id uuid.UUID) (*Item, error) {
+	return scanItem(r.conn(ctx).QueryRow(ctx, `SELECT `+itemColumns+` FROM estoque_itens WHERE tenant_id = $1 AND id = $2`, tenantID, id))
+}
+
+// CreateItem cadastra o item com saldo zero; a entrada já deve estar normalizada.
+func (r *Repository) CreateItem(ctx context.Context, tenantID uuid.UUID, in ItemInput) (*Item, error) {
+	item, err := scanItem(r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO estoque_itens (tenant_id, secretaria_id, nome, unidade, categoria, estoque_minimo, ativo)
+        SELECT $1, s.id, $3, $4, $5, $6, $7 FROM secretarias s WHERE s.id = $2 AND s.tenant_id = $1
+        RETURNING `+itemColumns,
+		tenantID, in.SecretariaID, in.Nome, in.Unidade, in.Categoria, in.EstoqueMinimo, in.Ativo))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrSecretaria
+	}
+	return itemError(item, err)
+}
+
+// UpdateItem altera os dados do item; a secretaria e o saldo não mudam. Um mínimo novo já abaixo
+// do saldo libera um novo alerta.
+func (r *Repository) UpdateItem(ctx context.Context, tenantID, id uuid.UUID, in ItemInput) (*Item, error) {
+	return itemError(scanItem(r.conn(ctx).QueryRow(ctx, `
+        UPDATE estoque_itens
+        SET nome = $3, unidade = $4, categoria = $5, estoque_minimo = $6, ativo = $7,
+            alerta_enviado_em = CASE WHEN saldo >= $6 THEN NULL ELSE alerta_enviado_em END, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+        RETURNING `+itemColumns,
+		tenantID, id, in.Nome, in.Unidade, in.Categoria, in.EstoqueMinimo, in.Ativo)))
+}
+
+// Movimentar registra o movimento e atualiza o saldo do item.
+func (r *Repository) Movimentar(ctx context.Context, tenantID uuid.UUID, in MovimentoInput) (*Movimento, *Item, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	m, err := Apply(ctx, tx, tenantID, in)
+	if err != nil {
+		return nil, nil, err
+	}
+	item, err := scanItem(tx.QueryRow(ctx, `SELECT `+itemColumns+` FROM estoque_itens WHERE id = $1`, in.ItemID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return m, item, nil
+}
+
+// Apply grava o movimento dentro da transação do chamador (ex.: a conclusão da ordem de serviço).
+// A entrada já deve estar normalizada; ordem e escola precisam ser da mesma prefeitura do item.
+func Apply(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, in MovimentoInput) (*Movimento, error) {
+	var (
+		nome  string
+		saldo float64
+		ativo bool
+	)
+	err := tx.QueryRow(ctx, `
+        SELECT nome, saldo::float8, ativo FROM estoque_itens WHERE tenant_id = $1 AND id = $2 FOR UPDATE
+    `, tenantID, in.ItemID).Scan(&nome, &saldo, &ativo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ativo {
+		return nil, ErrInactive
+	}
+	if in.OrdemID != nil || in.EscolaID != nil {
+		var ok bool
+		if err := tx.QueryRow(ctx, `
+            SELECT ($2::uuid IS NULL OR EXISTS (SELECT 1 FROM ordens_servico WHERE id = $2 AND tenant_id = $1))
+               AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM escolas WHERE id = $3 AND tenant_id = $1))
+        `, tenantID, in.OrdemID, in.EscolaID).Scan(&ok); err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrDestino
+		}
+	}
+	delta, ok := Delta(in.Tipo, in.Quantidade, saldo)
+	if !ok {
+		return nil, &SaldoError{Item: nome, Saldo: saldo}
+	}
+
+	m := Movimento{ItemID: in.ItemID, Tipo: in.Tipo, Quantidade: delta, CustoUnitario: in.CustoUnitario, Motivo: in.Motivo,
+		OrdemID: in.OrdemID, EscolaID: in.EscolaID, AtorID: in.AtorID}
+	if err := tx.QueryRow(ctx, `
+        UPDATE estoque_itens
+        SET saldo = saldo + $2,
+            alerta_enviado_em = CASE WHEN saldo + $2 >= estoque_minimo THEN NULL ELSE alerta_enviado_em END,
+            updated_at = now()
+        WHERE id = $1
+        RETURNING saldo::float8
+    `, in.ItemID, delta).Scan(&m.SaldoApos); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO estoque_movimentos (item_id, tipo, quantidade, saldo_apos, custo_unitario, data, motivo, ordem_id, escola_id, ator_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, data, created_at
+    `, m.ItemID, m.Tipo, m.Quantidade, m.SaldoApos, m.CustoUnitario, in.Data, m.Motivo, m.OrdemID, m.EscolaID, m.AtorID).Scan(&m.ID, &m.Data, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListMovimentos lista os movimentos do item, mais recentes primeiro.
+func (r *Repository) ListMovimentos(ctx context.Context, tenantID, itemID uuid.UUID, limit int) ([]Movimento, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT m.id, m.item_id, m.tipo, m.quantidade::float8, m.saldo_apos::float8, m.custo_unitario::float8, m.data,
+               m.motivo, m.ordem_id, m.escola_id, m.ator_id, m.created_at
+        FROM estoque_movimentos m
+        JOIN estoque_itens i ON i.id = m.item_id
+        WHERE i.tenant_id = $1 AND m.item_id = $2
+        ORDER BY m.data DESC, m.created_at DESC
+        LIMIT $3
+    `, tenantID, itemID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Movimento, error) {
+		var m Movimento
+		err := row.Scan(&m.ID, &m.ItemID, &m.Tipo, &m.Quantidade, &m.SaldoApos, &m.CustoUnitario, &m.Data,
+			&m.Motivo, &m.OrdemID, &m.EscolaID, &m.AtorID, &m.CreatedAt)
+		return m, err
+	})
+}
+
+// Consumo resume, por mês e item, as entradas, as saídas (destacando ordens de serviço e merenda)
+// e os ajustes de inventário em [from, to).
+func (r *Repository) Consumo(ctx context.Context, tenantID uuid.UUID, secretariaID *uuid.UUID, from, to time.Time) ([]Consumo, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT to_char(date_trunc('month', m.data), 'YYYY-MM'), i.id, i.nome, i.unidade, i.secretaria_id,
+               COALESCE(sum(m.quantidade) FILTER (WHERE m.tipo = 'entrada'), 0)::float8,
+               COALESCE(-sum(m.quantidade) FILTER (WHERE m.tipo = 'saida'), 0)::float8,
+               COALESCE(-sum(m.quantidade) FILTER (WHERE m.tipo = 'saida' AND m.ordem_id IS NOT NULL), 0)::float8,
+               COALESCE(-sum(m.quantidade) FILTER (WHERE m.tipo = 'saida' AND m.escola_id IS NOT NULL), 0)::float8,
+               COALESCE(sum(m.quantidade) FILTER (WHERE m.tipo = 'ajuste'), 0)::float8
+        FROM estoque_movimentos m
+        JOIN estoque_itens i ON i.id = m.item_id
+        WHERE i.tenant_id = $1 AND ($2::uuid IS NULL OR i.secretaria_id = $2) AND m.data >= $3 AND m.data < $4
+        GROUP BY 1, i.id, i.nome, i.unidade, i.secretaria_id
+        ORDER BY 1, i.nome
+    `, tenantID, secretariaID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Consumo, error) {
+		var c Consumo
+		err := row.Scan(&c.Mes, &c.ItemID, &c.Item, &c.Unidade, &c.SecretariaID, &c.Entradas, &c.Saidas, &c.Ordens, &c.Merenda, &c.Ajustes)
+		return c, err
+	})
+}
+
+func scanItem(row pgx.Row) (*Item, error) {
+	var item Item
+	if err := row.Scan(&item.ID, &item.TenantID, &item.SecretariaID, &item.Nome, &item.Unidade, &item.Categoria,
+		&item.EstoqueMinimo, &item.Saldo, &item.Ativo, &item.Baixo, &item.AlertadoEm, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func itemError(item *Item, err error) (*Item, error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrDuplicate
+	}
+	return item, err
+}
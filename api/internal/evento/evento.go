@@ -0,0 +1,211 @@
+// Package evento cuida das inscrições em eventos da prefeitura (cursos, dias de vacinação,
+// palestras): vagas limitadas com lista de espera, check-in por QR code no dia e lista de presença.
+package evento
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica evento inexistente ou de outro tenant.
+	ErrNotFound = errors.New("evento: evento não encontrado")
+	// ErrInscricaoNotFound indica inscrição inexistente, de outro cidadão ou de outro evento.
+	ErrInscricaoNotFound = errors.New("evento: inscrição não encontrada")
+	// ErrSecretaria indica secretaria que não pertence à prefeitura.
+	ErrSecretaria = errors.New("evento: secretaria não pertence à prefeitura")
+	// ErrEncerrado indica evento cancelado ou com inscrições encerradas.
+	ErrEncerrado = errors.New("evento: inscrições encerradas")
+	// ErrJaInscrito indica cidadão com inscrição ativa no evento.
+	ErrJaInscrito = errors.New("evento: cidadão já inscrito")
+	// ErrNaoConfirmada indica check-in de inscrição cancelada ou ainda na lista de espera.
+	ErrNaoConfirmada = errors.New("evento: inscrição não confirmada")
+	// ErrJaPresente indica check-in repetido.
+	ErrJaPresente = errors.New("evento: presença já registrada")
+	// ErrCancelada indica inscrição já cancelada.
+	ErrCancelada = errors.New("evento: inscrição já cancelada")
+)
+
+// Tipos de evento.
+const (
+	TipoCurso     = "curso"
+	TipoVacinacao = "vacinacao"
+	TipoPalestra  = "palestra"
+	TipoOutro     = "outro"
+)
+
+// Situações do evento.
+const (
+	StatusPublicado = "publicado"
+	StatusCancelado = "cancelado"
+)
+
+// Situações da inscrição. Sem vaga, a inscrição entra na espera e é confirmada na ordem de chegada
+// quando alguém cancela ou a capacidade aumenta.
+const (
+	InscricaoConfirmada = "confirmada"
+	InscricaoEspera     = "espera"
+	InscricaoCancelada  = "cancelada"
+)
+
+// Evento é uma atividade com inscrição e vagas limitadas.
+type Evento struct {
+	ID            uuid.UUID  `json:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	SecretariaID  uuid.UUID  `json:"secretaria_id"`
+	Secretaria    string     `json:"secretaria"`
+	Titulo        string     `json:"titulo"`
+	Descricao     *string    `json:"descricao,omitempty"`
+	Tipo          string     `json:"tipo"`
+	Local         string     `json:"local"`
+	Inicio        time.Time  `json:"inicio"`
+	Fim           *time.Time `json:"fim,omitempty"`
+	Capacidade    int        `json:"capacidade"`
+	InscricoesAte *time.Time `json:"inscricoes_ate,omitempty"`
+	LembreteHoras int        `json:"lembrete_horas"`
+	Status        string     `json:"status"`
+	Confirmadas   int        `json:"confirmadas"`
+	EmEspera      int        `json:"em_espera"`
+	Presentes     int        `json:"presentes"`
+	Vagas         int        `json:"vagas"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Aberto informa se o evento ainda aceita inscrições em now.
+func (e *Evento) Aberto(now time.Time) bool {
+	if e.Status != StatusPublicado || !now.Before(e.Inicio) {
+		return false
+	}
+	return e.InscricoesAte == nil || now.Before(*e.InscricoesAte)
+}
+
+// EventoInput contém os campos editáveis do evento; SecretariaID só vale no cadastro.
+type EventoInput struct {
+	SecretariaID  uuid.UUID
+	Titulo        string
+	Descricao     *string
+	Tipo          string
+	Local         string
+	Inicio        time.Time
+	Fim           *time.Time
+	Capacidade    int
+	InscricoesAte *time.Time
+	LembreteHoras *int
+}
+
+// Normalize limpa e valida a entrada; sem antecedência informada, o lembrete sai 24 horas antes.
+func (in *EventoInput) Normalize() error {
+	in.Titulo = strings.TrimSpace(in.Titulo)
+	in.Tipo = strings.ToLower(strings.TrimSpace(in.Tipo))
+	in.Local = strings.TrimSpace(in.Local)
+	if in.Descricao != nil {
+		if d := strings.TrimSpace(*in.Descricao); d != "" {
+			in.Descricao = &d
+		} else {
+			in.Descricao = nil
+		}
+	}
+	if in.Tipo == "" {
+		in.Tipo = TipoOutro
+	}
+	if in.LembreteHoras == nil {
+		padrao := 24
+		in.LembreteHoras = &padrao
+	}
+	switch {
+	case in.Titulo == "":
+		return errors.New("título obrigatório")
+	case in.Tipo != TipoCurso && in.Tipo != TipoVacinacao && in.Tipo != TipoPalestra && in.Tipo != TipoOutro:
+		return errors.New("tipo de evento inválido")
+	case in.Local == "":
+		return errors.New("local obrigatório")
+	case in.Inicio.IsZero():
+		return errors.New("início obrigatório")
+	case in.Fim != nil && !in.Fim.After(in.Inicio):
+		return errors.New("fim deve ser posterior ao início")
+	case in.Capacidade <= 0:
+		return errors.New("capacidade deve ser positiva")
+	case in.InscricoesAte != nil && in.InscricoesAte.After(in.Inicio):
+		return errors.New("inscrições devem encerrar até o início do evento")
+	case *in.LembreteHoras < 0 || *in.LembreteHoras > 7*24:
+		return errors.New("lembrete deve ser de 0 a 168 horas antes")
+	}
+	return nil
+}
+
+// Inscricao é a participação de um cidadão no evento. Codigo vai no QR code lido no check-in.
+type Inscricao struct {
+	ID            uuid.UUID  `json:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	EventoID      uuid.UUID  `json:"evento_id"`
+	Evento        string     `json:"evento"`
+	Inicio        time.Time  `json:"inicio"`
+	Local         string     `json:"local"`
+	CidadaoID     uuid.UUID  `json:"cidadao_id"`
+	Nome          string     `json:"nome"`
+	Email         *string    `json:"email,omitempty"`
+	Status        string     `json:"status"`
+	PosicaoEspera *int       `json:"posicao_espera,omitempty"`
+	Codigo        string     `json:"codigo"`
+	CheckinEm     *time.Time `json:"checkin_em,omitempty"`
+	PromovidaEm   *time.Time `json:"promovida_em,omitempty"`
+	CanceladaEm   *time.Time `json:"cancelada_em,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Filter restringe a listagem de eventos.
+type Filter struct {
+	SecretariaID *uuid.UUID
+	Tipo         string
+	// Futuros limita aos eventos que ainda não começaram.
+	Futuros bool
+	// Cancelados inclui os eventos cancelados.
+	Cancelados bool
+}
+
+// SituacaoInicial decide se a nova inscrição ocupa vaga ou entra na espera.
+func SituacaoInicial(confirmadas, capacidade int) string {
+	if confirmadas < capacidade {
+		return InscricaoConfirmada
+	}
+	return InscricaoEspera
+}
+
+// alfabeto do código de check-in: sem 0/O, 1/I/L, fácil de ditar quando o QR não lê.
+const alfabeto = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// NovoCodigo gera o código de check-in, como K7QM-2XHP.
+func NovoCodigo() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	out := make([]byte, 0, 9)
+	for i, b := range buf {
+		if i == 4 {
+			out = append(out, '-')
+		}
+		out = append(out, alfabeto[int(b)%len(alfabeto)])
+	}
+	return string(out), nil
+}
+
+// NormalizeCodigo aceita o código digitado com espaços, minúsculas ou sem hífen.
+func NormalizeCodigo(raw string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(raw) {
+		if strings.ContainsRune(alfabeto, r) {
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+	if len(s) != 8 {
+		return ""
+	}
+	return s[:4] + "-" + s[4:]
+}
@@ -0,0 +1,78 @@
+package evento
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSituacaoInicial(t *testing.T) {
+	if got := SituacaoInicial(9, 10); got != InscricaoConfirmada {
+		t.Fatalf("com vaga: %q", got)
+	}
+	if got := SituacaoInicial(10, 10); got != InscricaoEspera {
+		t.Fatalf("lotado: %q", got)
+	}
+}
+
+func TestCodigo(t *testing.T) {
+	codigo, err := NovoCodigo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codigo) != 9 || codigo[4] != '-' {
+		t.Fatalf("código fora do formato: %q", codigo)
+	}
+	if got := NormalizeCodigo(codigo); got != codigo {
+		t.Fatalf("NormalizeCodigo(%q) = %q", codigo, got)
+	}
+	if got := NormalizeCodigo(" k7qm 2xhp "); got != "K7QM-2XHP" {
+		t.Fatalf("NormalizeCodigo digitado = %q", got)
+	}
+	if got := NormalizeCodigo("K7QM-2XH"); got != "" {
+		t.Fatalf("código curto aceito: %q", got)
+	}
+}
+
+func TestEventoAberto(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	ate := now.Add(-time.Hour)
+	cases := []struct {
+		nome string
+		e    Evento
+		want bool
+	}{
+		{"futuro", Evento{Status: StatusPublicado, Inicio: now.Add(time.Hour)}, true},
+		{"começou", Evento{Status: StatusPublicado, Inicio: now}, false},
+		{"cancelado", Evento{Status: StatusCancelado, Inicio: now.Add(time.Hour)}, false},
+		{"prazo encerrado", Evento{Status: StatusPublicado, Inicio: now.Add(time.Hour), InscricoesAte: &ate}, false},
+	}
+	for _, c := range cases {
+		if got := c.e.Aberto(now); got != c.want {
+			t.Errorf("%s: Aberto = %v, want %v", c.nome, got, c.want)
+		}
+	}
+}
+
+func TestEventoInputNormalize(t *testing.T) {
+	inicio := time.Date(2026, 5, 10, 9, 0, 0, 0, time.UTC)
+	in := EventoInput{Titulo: " Curso de informática ", Local: " CRAS Centro ", Inicio: inicio, Capacidade: 30}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if in.Tipo != TipoOutro || in.LembreteHoras == nil || *in.LembreteHoras != 24 || in.Titulo != "Curso de informática" {
+		t.Fatalf("entrada normalizada inesperada: %+v", in)
+	}
+
+	depois := inicio.Add(time.Hour)
+	invalidos := []EventoInput{
+		{Titulo: "x", Local: "y", Inicio: inicio, Capacidade: 0},
+		{Titulo: "x", Local: "y", Inicio: inicio, Capacidade: 1, Tipo: "show"},
+		{Titulo: "x", Local: "y", Inicio: inicio, Capacidade: 1, InscricoesAte: &depois},
+		{Titulo: "x", Local: "", Inicio: inicio, Capacidade: 1},
+	}
+	for i, in := range invalidos {
+		if err := in.Normalize(); err == nil {
+			t.Errorf("caso %d deveria ser rejeitado", i)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package evento
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/notify"
+)
+
+// brasilia formata o horário nas mensagens; o horário de verão não vigora desde 2019.
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+// Notifier avisa os inscritos pelo mesmo despachante das demais notificações: a confirmação de
+// quem saiu da lista de espera e o lembrete antes do evento.
+type Notifier struct {
+	pool       *pgxpool.Pool
+	dispatcher *notify.Dispatcher
+	logger     zerolog.Logger
+}
+
+// NewNotifier cria o enviador de avisos de eventos.
+func NewNotifier(pool *pgxpool.Pool, dispatcher *notify.Dispatcher, logger zerolog.Logger) *Notifier {
+	return &Notifier{pool: pool, dispatcher: dispatcher, logger: logger}
+}
+
+type aviso struct {
+	inscricaoID uuid.UUID
+	eventoID    uuid.UUID
+	tenantID    uuid.UUID
+	cidadaoID   uuid.UUID
+	email       *string
+	titulo      string
+	local       string
+	inicio      time.Time
+	codigo      string
+}
+
+// conn devolve o pool do cluster fixado no contexto por db.Resolver.EachCluster ou o principal.
+func (n *Notifier) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, n.pool)
+}
+
+// RunOnce envia os avisos pendentes e marca o que foi avisado. Sob db.Resolver.EachCluster só
+// olha as prefeituras roteadas para o cluster.
+func (n *Notifier) RunOnce(ctx context.Context) error {
+	if n.dispatcher == nil {
+		return nil
+	}
+	if err := n.promocoes(ctx); err != nil {
+		return err
+	}
+	return n.lembretes(ctx)
+}
+
+func (n *Notifier) promocoes(ctx context.Context) error {
+	avisos, err := n.carregar(ctx, `
+        SELECT i.id, e.id, e.tenant_id, i.cidadao_id, i.email, e.titulo, e.local, e.inicio, i.codigo
+        FROM evento_inscricoes i
+        JOIN eventos e ON e.id = i.evento_id
+        WHERE i.promovida_em IS NOT NULL AND i.aviso_promocao_em IS NULL
+          AND i.status = 'confirmada' AND e.status = 'publicado' AND e.inicio > now()
+          AND ($1::uuid[] IS NULL OR e.tenant_id = ANY($1))
+        ORDER BY i.promovida_em
+        LIMIT 500
+    `)
+	if err != nil || len(avisos) == 0 {
+		return err
+	}
+	ids := make([]uuid.UUID, 0, len(avisos))
+	for _, a := range avisos {
+		n.enviar(ctx, a, "Vaga confirmada: "+a.titulo,
+			fmt.Sprintf("Abriu uma vaga e sua inscrição saiu da lista de espera.\n%s\nCódigo de check-in: %s", quandoOnde(a), a.codigo))
+		ids = append(ids, a.inscricaoID)
+	}
+	if _, err := n.conn(ctx).Exec(ctx, `UPDATE evento_inscricoes SET aviso_promocao_em = now() WHERE id = ANY($1)`, ids); err != nil {
+		return err
+	}
+	n.logger.Info().Int("inscricoes", len(ids)).Msg("evento: avisos de vaga confirmada enviados")
+	return nil
+}
+
+// lembretes avisa os confirmados dos eventos que entram na janela de antecedência configurada.
+func (n *Notifier) lembretes(ctx context.Context) error {
+	avisos, err := n.carregar(ctx, `
+        SELECT i.id, e.id, e.tenant_id, i.cidadao_id, i.email, e.titulo, e.local, e.inicio, i.codigo
+        FROM eventos e
+        JOIN evento_inscricoes i ON i.evento_id = e.id AND i.status = 'confirmada'
+        WHERE e.status = 'publicado' AND e.lembrete_enviado_em IS NULL AND e.lembrete_horas > 0
+          AND e.inicio > now() AND e.inicio <= now() + make_interval(hours => e.lembrete_horas)
+          AND ($1::uuid[] IS NULL OR e.tenant_id = ANY($1))
+        ORDER BY e.inicio, i.created_at
+    `)
+	if err != nil || len(avisos) == 0 {
+		return err
+	}
+	eventos := map[uuid.UUID]struct{}{}
+	for _, a := range avisos {
+		n.enviar(ctx, a, "Lembrete: "+a.titulo,
+			fmt.Sprintf("Sua inscrição está confirmada.\n%s\nApresente o QR code da inscrição ou informe o código %s na entrada.", quandoOnde(a), a.codigo))
+		eventos[a.eventoID] = struct{}{}
+	}
+	ids := make([]uuid.UUID, 0, len(eventos))
+	for id := range eventos {
+		ids = append(ids, id)
+	}
+	if _, err := n.conn(ctx).Exec(ctx, `UPDATE eventos SET lembrete_enviado_em = now() WHERE id = ANY($1)`, ids); err != nil {
+		return err
+	}
+	n.logger.Info().Int("eventos", len(ids)).Int("inscritos", len(avisos)).Msg("evento: lembretes enviados")
+	return nil
+}
+
+func (n *Notifier) carregar(ctx context.Context, query string) ([]aviso, error) {
+	rows, err := n.conn(ctx).Query(ctx, query, db.Tenants(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var avisos []aviso
+	for rows.Next() {
+		var a aviso
+		if err := rows.Scan(&a.inscricaoID, &a.eventoID, &a.tenantID, &a.cidadaoID, &a.email, &a.titulo, &a.local, &a.inicio, &a.codigo); err != nil {
+			return nil, err
+		}
+		avisos = append(avisos, a)
+	}
+	return avisos, rows.Err()
+}
+
+func (n *Notifier) enviar(ctx context.Context, a aviso, title, body string) {
+	notification := notify.Notification{
+		TenantID: a.tenantID,
+		Audience: "cidadao",
+		UserID:   a.cidadaoID,
+		Category: notify.CategoryAvisos,
+		Title:    title,
+		Body:     body,
+	}
+	if a.email != nil {
+		notification.Email = *a.email
+	}
+	if _, err := n.dispatcher.Dispatch(ctx, notification); err != nil {
+		n.logger.Warn().Err(err).Str("inscricao_id", a.inscricaoID.String()).Msg("evento: falha ao despachar aviso")
+	}
+}
+
+func quandoOnde(a aviso) string {
+	return fmt.Sprintf("Quando: %s\nOnde: %s", a.inicio.In(brasilia).Format("02/01/2006 às 15:04"), a.local)
+}
@@ -0,0 +1,387 @@
+package evento
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const eventoColumns = `e.id, e.tenant_id, e.secretaria_id, s.nome, e.titulo, e.descricao, e.tipo, e.local, e.inicio, e.fim,
+        e.capacidade, e.inscricoes_ate, e.lembrete_horas, e.status,
+        (SELECT count(*) FROM evento_inscricoes i WHERE i.evento_id = e.id AND i.status = 'confirmada')::int,
+        (SELECT count(*) FROM evento_inscricoes i WHERE i.evento_id = e.id AND i.status = 'espera')::int,
+        (SELECT count(*) FROM evento_inscricoes i WHERE i.evento_id = e.id AND i.checkin_em IS NOT NULL)::int,
+        e.created_at, e.updated_at`
+
+// A posição na espera é calculada na consulta, pela ordem de chegada.
+const inscricaoColumns = `i.id, i.tenant_id, i.evento_id, e.titulo, e.inicio, e.local, i.cidadao_id, i.nome, i.email, i.status,
+        CASE WHEN i.status = 'espera' THEN (
+            SELECT count(*) FROM evento_inscricoes o
+            WHERE o.evento_id = i.evento_id AND o.status = 'espera' AND (o.created_at, o.id) <= (i.created_at, i.id)
+        )::int END,
+        i.codigo, i.checkin_em, i.promovida_em, i.cancelada_em, i.created_at`
+
+// Repository persiste eventos e inscrições.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// List lista os eventos da prefeitura por data de início.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, filter Filter) ([]Evento, error) {
+	clauses := []string{"e.tenant_id = $1"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.SecretariaID != nil {
+		add("e.secretaria_id = $?", *filter.SecretariaID)
+	}
+	if filter.Tipo != "" {
+		add("e.tipo = $?", strings.ToLower(filter.Tipo))
+	}
+	if filter.Futuros {
+		clauses = append(clauses, "e.inicio > now()")
+	}
+	if !filter.Cancelados {
+		clauses = append(clauses, "e.status = 'publicado'")
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+eventoColumns+`
+        FROM eventos e
+        JOIN secretarias s ON s.id = e.secretaria_id
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY e.inicio`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Evento, error) {
+		e, err := scanEvento(row)
+		if err != nil {
+			return Evento{}, err
+		}
+		return *e, nil
+	})
+}
+
+// Get busca o evento do tenant.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID) (*Evento, error) {
+	return scanEvento(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+eventoColumns+`
+        FROM eventos e
+        JOIN secretarias s ON s.id = e.secretaria_id
+        WHERE e.tenant_id = $1 AND e.id = $2
+    `, tenantID, id))
+}
+
+// Create cadastra o evento já publicado; a entrada já deve estar normalizada.
+func (r *Repository) Create(ctx context.Context, tenantID, actorID uuid.UUID, in EventoInput) (*Evento, error) {
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO eventos (tenant_id, secretaria_id, titulo, descricao, tipo, local, inicio, fim, capacidade,
+                             inscricoes_ate, lembrete_horas, created_by)
+        SELECT $1, s.id, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12 FROM secretarias s WHERE s.id = $2 AND s.tenant_id = $1
+        RETURNING id
+    `, tenantID, in.SecretariaID, in.Titulo, in.Descricao, in.Tipo, in.Local, in.Inicio, in.Fim, in.Capacidade,
+		in.InscricoesAte, *in.LembreteHoras, actorID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSecretaria
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Update altera o evento. Capacidade maior confirma quem está na espera; capacidade menor não
+// desfaz confirmações já feitas. Mudar o início ou a antecedência reagenda o lembrete.
+func (r *Repository) Update(ctx context.Context, tenantID, id uuid.UUID, in EventoInput) (*Evento, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE eventos
+        SET titulo = $3, descricao = $4, tipo = $5, local = $6, inicio = $7, fim = $8, capacidade = $9,
+            inscricoes_ate = $10, lembrete_horas = $11,
+            lembrete_enviado_em = CASE WHEN inicio = $7 AND lembrete_horas = $11 THEN lembrete_enviado_em END,
+            updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+    `, tenantID, id, in.Titulo, in.Descricao, in.Tipo, in.Local, in.Inicio, in.Fim, in.Capacidade, in.InscricoesAte, *in.LembreteHoras)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	if err := promover(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Cancel cancela o evento; as inscrições ficam como estavam para consulta.
+func (r *Repository) Cancel(ctx context.Context, tenantID, id uuid.UUID) (*Evento, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE eventos SET status = 'cancelado', updated_at = now() WHERE tenant_id = $1 AND id = $2
+    `, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Inscrever inscreve o cidadão com os dados do cadastro. O evento fica travado durante a
+// contagem, então duas inscrições simultâneas não disputam a mesma vaga.
+func (r *Repository) Inscrever(ctx context.Context, tenantID, eventoID, cidadaoID uuid.UUID) (*Inscricao, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var e Evento
+	err = tx.QueryRow(ctx, `
+        SELECT status, inicio, inscricoes_ate, capacidade FROM eventos WHERE tenant_id = $1 AND id = $2 FOR UPDATE
+    `, tenantID, eventoID).Scan(&e.Status, &e.Inicio, &e.InscricoesAte, &e.Capacidade)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !e.Aberto(time.Now()) {
+		return nil, ErrEncerrado
+	}
+	var confirmadas int
+	if err := tx.QueryRow(ctx, `
+        SELECT count(*) FROM evento_inscricoes WHERE evento_id = $1 AND status = 'confirmada'
+    `, eventoID).Scan(&confirmadas); err != nil {
+		return nil, err
+	}
+	codigo, err := NovoCodigo()
+	if err != nil {
+		return nil, err
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `
+        INSERT INTO evento_inscricoes (tenant_id, evento_id, cidadao_id, nome, email, status, codigo)
+        SELECT $1, $2, c.id, COALESCE(NULLIF(trim(c.nome), ''), c.email, 'Cidadão'), c.email, $4, $5
+        FROM cidadaos c WHERE c.id = $3
+        RETURNING id
+    `, tenantID, eventoID, cidadaoID, SituacaoInicial(confirmadas, e.Capacidade), codigo).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_evento_inscricoes_ativa" {
+		return nil, ErrJaInscrito
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInscricaoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetInscricao(ctx, tenantID, id)
+}
+
+// CancelarInscricao desiste da inscrição do cidadão; a vaga liberada vai para o primeiro da espera.
+func (r *Repository) CancelarInscricao(ctx context.Context, tenantID, cidadaoID, id uuid.UUID) (*Inscricao, error) {
+	atual, err := r.GetInscricaoDoCidadao(ctx, tenantID, cidadaoID, id)
+	if err != nil {
+		return nil, err
+	}
+	if atual.Status == InscricaoCancelada {
+		return nil, ErrCancelada
+	}
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM eventos WHERE id = $1 FOR UPDATE`, atual.EventoID); err != nil {
+		return nil, err
+	}
+	tag, err := tx.Exec(ctx, `
+        UPDATE evento_inscricoes SET status = 'cancelada', cancelada_em = now(), updated_at = now()
+        WHERE tenant_id = $1 AND id = $2 AND cidadao_id = $3 AND status <> 'cancelada'
+    `, tenantID, id, cidadaoID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrCancelada
+	}
+	if err := promover(ctx, tx, atual.EventoID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetInscricao(ctx, tenantID, id)
+}
+
+// promover confirma, por ordem de chegada, tantas inscrições da espera quantas vagas houver;
+// evento cancelado não promove ninguém. Deve rodar com o evento travado pelo chamador.
+func promover(ctx context.Context, tx pgx.Tx, eventoID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+        UPDATE evento_inscricoes
+        SET status = 'confirmada', promovida_em = now(), updated_at = now()
+        WHERE id IN (
+            SELECT i.id FROM evento_inscricoes i
+            WHERE i.evento_id = $1 AND i.status = 'espera'
+            ORDER BY i.created_at, i.id
+            LIMIT COALESCE((
+                SELECT GREATEST(e.capacidade - count(c.id), 0)
+                FROM eventos e
+                LEFT JOIN evento_inscricoes c ON c.evento_id = e.id AND c.status = 'confirmada'
+                WHERE e.id = $1 AND e.status = 'publicado'
+                GROUP BY e.capacidade
+            ), 0)
+        )
+    `, eventoID)
+	return err
+}
+
+// GetInscricao busca a inscrição do tenant.
+func (r *Repository) GetInscricao(ctx context.Context, tenantID, id uuid.UUID) (*Inscricao, error) {
+	return scanInscricao(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+inscricaoColumns+`
+        FROM evento_inscricoes i
+        JOIN eventos e ON e.id = i.evento_id
+        WHERE i.tenant_id = $1 AND i.id = $2
+    `, tenantID, id))
+}
+
+// GetInscricaoDoCidadao busca a inscrição feita pelo próprio cidadão.
+func (r *Repository) GetInscricaoDoCidadao(ctx context.Context, tenantID, cidadaoID, id uuid.UUID) (*Inscricao, error) {
+	return scanInscricao(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+inscricaoColumns+`
+        FROM evento_inscricoes i
+        JOIN eventos e ON e.id = i.evento_id
+        WHERE i.tenant_id = $1 AND i.id = $2 AND i.cidadao_id = $3
+    `, tenantID, id, cidadaoID))
+}
+
+// ListDoCidadao lista as inscrições do cidadão, eventos mais próximos primeiro.
+func (r *Repository) ListDoCidadao(ctx context.Context, tenantID, cidadaoID uuid.UUID) ([]Inscricao, error) {
+	return r.listInscricoes(ctx, `
+        WHERE i.tenant_id = $1 AND i.cidadao_id = $2
+        ORDER BY e.inicio DESC
+        LIMIT 50`, tenantID, cidadaoID)
+}
+
+// ListInscricoes lista as inscrições do evento: confirmadas, espera na ordem e canceladas.
+func (r *Repository) ListInscricoes(ctx context.Context, tenantID, eventoID uuid.UUID) ([]Inscricao, error) {
+	return r.listInscricoes(ctx, `
+        WHERE i.tenant_id = $1 AND i.evento_id = $2
+        ORDER BY CASE i.status WHEN 'confirmada' THEN 0 WHEN 'espera' THEN 1 ELSE 2 END, i.created_at, i.id`,
+		tenantID, eventoID)
+}
+
+func (r *Repository) listInscricoes(ctx context.Context, where string, args ...any) ([]Inscricao, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+inscricaoColumns+`
+        FROM evento_inscricoes i
+        JOIN eventos e ON e.id = i.evento_id
+        `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Inscricao, error) {
+		i, err := scanInscricao(row)
+		if err != nil {
+			return Inscricao{}, err
+		}
+		return *i, nil
+	})
+}
+
+// Checkin registra a presença pelo código lido no QR code da inscrição.
+func (r *Repository) Checkin(ctx context.Context, tenantID, eventoID, actorID uuid.UUID, codigo string) (*Inscricao, error) {
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        UPDATE evento_inscricoes SET checkin_em = now(), checkin_por = $4, updated_at = now()
+        WHERE tenant_id = $1 AND evento_id = $2 AND codigo = $3 AND status = 'confirmada' AND checkin_em IS NULL
+        RETURNING id
+    `, tenantID, eventoID, codigo, actorID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		atual, err := scanInscricao(r.conn(ctx).QueryRow(ctx, `
+            SELECT `+inscricaoColumns+`
+            FROM evento_inscricoes i
+            JOIN eventos e ON e.id = i.evento_id
+            WHERE i.tenant_id = $1 AND i.evento_id = $2 AND i.codigo = $3
+        `, tenantID, eventoID, codigo))
+		switch {
+		case err != nil:
+			return nil, err
+		case atual.CheckinEm != nil:
+			return atual, ErrJaPresente
+		default:
+			return atual, ErrNaoConfirmada
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetInscricao(ctx, tenantID, id)
+}
+
+func scanEvento(row pgx.Row) (*Evento, error) {
+	var e Evento
+	err := row.Scan(&e.ID, &e.TenantID, &e.SecretariaID, &e.Secretaria, &e.Titulo, &e.Descricao, &e.Tipo, &e.Local,
+		&e.Inicio, &e.Fim, &e.Capacidade, &e.InscricoesAte, &e.LembreteHoras, &e.Status, &e.Confirmadas, &e.EmEspera,
+		&e.Presentes, &e.CreatedAt, &e.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Vagas = max(e.Capacidade-e.Confirmadas, 0)
+	return &e, nil
+}
+
+func scanInscricao(row pgx.Row) (*Inscricao, error) {
+	var i Inscricao
+	err := row.Scan(&i.ID, &i.TenantID, &i.EventoID, &i.Evento, &i.Inicio, &i.Local, &i.CidadaoID, &i.Nome, &i.Email,
+		&i.Status, &i.PosicaoEspera, &i.Codigo, &i.CheckinEm, &i.PromovidaEm, &i.CanceladaEm, &i.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInscricaoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
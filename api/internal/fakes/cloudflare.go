@@ -0,0 +1,222 @@
+package fakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DNSRecord é um registro guardado pelo fake da Cloudflare.
+type DNSRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+// Cloudflare imita a API v4 de DNS e purge de uma zona e o resolvedor DNS over HTTPS.
+// Use APIBase e DoHURL na configuração do cliente.
+type Cloudflare struct {
+	Behavior
+
+	Server  *httptest.Server
+	APIBase string
+	DoHURL  string
+
+	token string
+	zone  string
+
+	mu      sync.Mutex
+	seq     int
+	records map[string]DNSRecord
+	purged  [][]string
+	pending bool
+}
+
+// NewCloudflare sobe o fake aceitando apenas o token e a zona informados. Feche com Close.
+func NewCloudflare(token, zone string) *Cloudflare {
+	f := &Cloudflare{token: token, zone: zone, records: map[string]DNSRecord{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /client/v4/zones/{zone}/dns_records", f.listRecords)
+	mux.HandleFunc("POST /client/v4/zones/{zone}/dns_records", f.createRecord)
+	mux.HandleFunc("PUT /client/v4/zones/{zone}/dns_records/{id}", f.updateRecord)
+	mux.HandleFunc("POST /client/v4/zones/{zone}/purge_cache", f.purge)
+	mux.HandleFunc("GET /dns-query", f.resolve)
+	f.Server = httptest.NewServer(f.wrap(mux))
+	f.APIBase = f.Server.URL + "/client/v4"
+	f.DoHURL = f.Server.URL + "/dns-query"
+	return f
+}
+
+// Close encerra o servidor.
+func (f *Cloudflare) Close() { f.Server.Close() }
+
+// SetPropagated controla se o DoH já responde com os CNAMEs criados; false simula a propagação
+// ainda pendente.
+func (f *Cloudflare) SetPropagated(ok bool) {
+	f.mu.Lock()
+	f.pending = !ok
+	f.mu.Unlock()
+}
+
+// Records devolve os registros da zona ordenados por nome.
+func (f *Cloudflare) Records() []DNSRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]DNSRecord, 0, len(f.records))
+	for _, rec := range f.records {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Purges devolve os lotes de URLs recebidos pelo purge, na ordem das chamadas.
+func (f *Cloudflare) Purges() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.purged...)
+}
+
+// authorized confere token e zona e responde como a API quando não batem.
+func (f *Cloudflare) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		cloudflareReply(w, http.StatusForbidden, false, nil, "Invalid API Token")
+		return false
+	}
+	if r.PathValue("zone") != f.zone {
+		cloudflareReply(w, http.StatusNotFound, false, nil, "Could not route to zone")
+		return false
+	}
+	return true
+}
+
+func (f *Cloudflare) listRecords(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	kind, name := r.URL.Query().Get("type"), r.URL.Query().Get("name")
+	result := []DNSRecord{}
+	for _, rec := range f.Records() {
+		if (kind == "" || rec.Type == kind) && (name == "" || strings.EqualFold(rec.Name, name)) {
+			result = append(result, rec)
+		}
+	}
+	cloudflareReply(w, http.StatusOK, true, result)
+}
+
+func (f *Cloudflare) decodeRecord(w http.ResponseWriter, r *http.Request) (DNSRecord, bool) {
+	var rec DNSRecord
+	if err := json.NewDecoder(r.Body).Decode(&rec); err != nil || rec.Type == "" || rec.Name == "" || rec.Content == "" {
+		cloudflareReply(w, http.StatusBadRequest, false, nil, "DNS Validation Error")
+		return rec, false
+	}
+	return rec, true
+}
+
+func (f *Cloudflare) createRecord(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	rec, ok := f.decodeRecord(w, r)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	for _, existing := range f.records {
+		if strings.EqualFold(existing.Name, rec.Name) {
+			f.mu.Unlock()
+			cloudflareReply(w, http.StatusBadRequest, false, nil, "A CNAME record with that host already exists.")
+			return
+		}
+	}
+	f.seq++
+	rec.ID = fmt.Sprintf("rec%04d", f.seq)
+	f.records[rec.ID] = rec
+	f.mu.Unlock()
+	cloudflareReply(w, http.StatusOK, true, rec)
+}
+
+func (f *Cloudflare) updateRecord(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	rec, ok := f.decodeRecord(w, r)
+	if !ok {
+		return
+	}
+	rec.ID = r.PathValue("id")
+	f.mu.Lock()
+	_, exists := f.records[rec.ID]
+	if exists {
+		f.records[rec.ID] = rec
+	}
+	f.mu.Unlock()
+	if !exists {
+		cloudflareReply(w, http.StatusNotFound, false, nil, "Record not found")
+		return
+	}
+	cloudflareReply(w, http.StatusOK, true, rec)
+}
+
+// purge aceita no máximo 30 URLs por chamada, como a API real.
+func (f *Cloudflare) purge(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	var body struct {
+		Files []string `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Files) == 0 {
+		cloudflareReply(w, http.StatusBadRequest, false, nil, "Invalid purge request")
+		return
+	}
+	if len(body.Files) > 30 {
+		cloudflareReply(w, http.StatusOK, false, nil, "Only 30 files are allowed per request")
+		return
+	}
+	f.mu.Lock()
+	f.purged = append(f.purged, body.Files)
+	f.mu.Unlock()
+	cloudflareReply(w, http.StatusOK, true, map[string]string{"id": f.zone})
+}
+
+// resolve responde no formato application/dns-json com os CNAMEs da zona.
+func (f *Cloudflare) resolve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(r.URL.Query().Get("name"), ".")
+	type answer struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+		TTL  int    `json:"TTL"`
+		Data string `json:"data"`
+	}
+	answers := []answer{}
+	f.mu.Lock()
+	pending := f.pending
+	f.mu.Unlock()
+	if !pending {
+		for _, rec := range f.Records() {
+			if rec.Type == "CNAME" && strings.EqualFold(rec.Name, name) {
+				answers = append(answers, answer{Name: rec.Name + ".", Type: 5, TTL: rec.TTL, Data: rec.Content + "."})
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/dns-json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"Status": 0, "Answer": answers})
+}
+
+func cloudflareReply(w http.ResponseWriter, status int, success bool, result any, messages ...string) {
+	errs := make([]map[string]any, 0, len(messages))
+	for i, msg := range messages {
+		errs = append(errs, map[string]any{"code": 1000 + i, "message": msg})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"success": success, "errors": errs, "messages": []any{}, "result": result})
+}
@@ -0,0 +1,71 @@
+// Package fakes reúne servidores em memória que imitam as APIs externas usadas pela plataforma
+// (Cloudflare, S3/GCS, FCM, gateway de pagamento e SMTP). Cada fake sobe num httptest.Server ou
+// numa porta TCP local, guarda o que recebeu para as asserções e aceita falhas e latência
+// programadas, de modo que os testes de contrato dos clientes rodam no CI sem credenciais.
+//
+// O pacote não importa os pacotes de domínio: são os testes de cada cliente que usam os fakes.
+package fakes
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Behavior controla falhas e latência injetadas num fake. O valor zero não interfere.
+type Behavior struct {
+	mu       sync.Mutex
+	failures []int
+	latency  time.Duration
+	calls    int
+}
+
+// FailNext faz as próximas n requisições responderem com status (HTTP ou código SMTP).
+func (b *Behavior) FailNext(status, n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := 0; i < n; i++ {
+		b.failures = append(b.failures, status)
+	}
+}
+
+// SetLatency atrasa todas as respostas seguintes em d; zero desliga.
+func (b *Behavior) SetLatency(d time.Duration) {
+	b.mu.Lock()
+	b.latency = d
+	b.mu.Unlock()
+}
+
+// Calls devolve quantas requisições o fake recebeu, inclusive as que falharam.
+func (b *Behavior) Calls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+// next registra a chamada, aplica a latência e devolve a falha programada, se houver.
+func (b *Behavior) next() (int, bool) {
+	b.mu.Lock()
+	b.calls++
+	latency := b.latency
+	status, fail := 0, len(b.failures) > 0
+	if fail {
+		status, b.failures = b.failures[0], b.failures[1:]
+	}
+	b.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	return status, fail
+}
+
+// wrap aplica o comportamento antes do handler do fake.
+func (b *Behavior) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status, fail := b.next(); fail {
+			http.Error(w, "falha programada no fake", status)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,64 @@
+package fakes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// O FCM ainda não tem cliente na API; o teste fixa o contrato do fake para quando tiver.
+func TestFCM(t *testing.T) {
+	f := NewFCM("municipio-app", "ya29.teste")
+	defer f.Close()
+	f.Unregister("aparelho-antigo")
+
+	send := func(token, device string) int {
+		body, _ := json.Marshal(map[string]any{"message": map[string]any{
+			"token":        device,
+			"notification": map[string]string{"title": "Matrícula", "body": "Documentos aprovados"},
+		}})
+		req, _ := http.NewRequest(http.MethodPost, f.URL+"/v1/projects/municipio-app/messages:send", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := send("ya29.teste", "aparelho-1"); code != http.StatusOK {
+		t.Fatalf("envio = %d", code)
+	}
+	if code := send("ya29.teste", "aparelho-antigo"); code != http.StatusNotFound {
+		t.Fatalf("token desregistrado = %d", code)
+	}
+	if code := send("outro", "aparelho-1"); code != http.StatusUnauthorized {
+		t.Fatalf("sem autenticação = %d", code)
+	}
+	f.FailNext(http.StatusTooManyRequests, 1)
+	if code := send("ya29.teste", "aparelho-1"); code != http.StatusTooManyRequests {
+		t.Fatalf("falha programada = %d", code)
+	}
+	if msgs := f.Messages(); len(msgs) != 1 || msgs[0].Title != "Matrícula" || !strings.HasSuffix(msgs[0].Name, "/1") {
+		t.Fatalf("mensagens: %+v", msgs)
+	}
+	if f.Calls() != 4 {
+		t.Fatalf("chamadas = %d", f.Calls())
+	}
+}
+
+func TestBehaviorLatency(t *testing.T) {
+	var b Behavior
+	b.SetLatency(20 * time.Millisecond)
+	start := time.Now()
+	if _, fail := b.next(); fail {
+		t.Fatal("sem falha programada")
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("latência não aplicada")
+	}
+}
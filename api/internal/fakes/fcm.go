@@ -0,0 +1,120 @@
+package fakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// FCMMessage é uma mensagem aceita pelo fake do FCM.
+type FCMMessage struct {
+	Name  string            `json:"name"`
+	Token string            `json:"token"`
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"`
+}
+
+// FCM imita o endpoint HTTP v1 do Firebase Cloud Messaging
+// (POST {URL}/v1/projects/{projeto}/messages:send), autenticado pelo token de acesso informado.
+// Tokens marcados com Unregister respondem 404 UNREGISTERED, como aparelhos desinstalados.
+type FCM struct {
+	Behavior
+
+	Server *httptest.Server
+	URL    string
+
+	project     string
+	accessToken string
+
+	mu           sync.Mutex
+	messages     []FCMMessage
+	unregistered map[string]bool
+}
+
+// NewFCM sobe o fake para o projeto. Feche com Close.
+func NewFCM(project, accessToken string) *FCM {
+	f := &FCM{project: project, accessToken: accessToken, unregistered: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/projects/{project}/messages:send", f.send)
+	f.Server = httptest.NewServer(f.wrap(mux))
+	f.URL = f.Server.URL
+	return f
+}
+
+// Close encerra o servidor.
+func (f *FCM) Close() { f.Server.Close() }
+
+// Unregister faz o token de aparelho ser recusado como não registrado.
+func (f *FCM) Unregister(token string) {
+	f.mu.Lock()
+	f.unregistered[token] = true
+	f.mu.Unlock()
+}
+
+// Messages devolve as mensagens aceitas, na ordem de chegada.
+func (f *FCM) Messages() []FCMMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FCMMessage(nil), f.messages...)
+}
+
+func (f *FCM) send(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+f.accessToken {
+		fcmError(w, http.StatusUnauthorized, "UNAUTHENTICATED", "")
+		return
+	}
+	if r.PathValue("project") != f.project {
+		fcmError(w, http.StatusForbidden, "PERMISSION_DENIED", "SENDER_ID_MISMATCH")
+		return
+	}
+	var payload struct {
+		Message struct {
+			Token        string `json:"token"`
+			Notification struct {
+				Title string `json:"title"`
+				Body  string `json:"body"`
+			} `json:"notification"`
+			Data map[string]string `json:"data"`
+		} `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Message.Token == "" {
+		fcmError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "INVALID_ARGUMENT")
+		return
+	}
+
+	f.mu.Lock()
+	if f.unregistered[payload.Message.Token] {
+		f.mu.Unlock()
+		fcmError(w, http.StatusNotFound, "NOT_FOUND", "UNREGISTERED")
+		return
+	}
+	msg := FCMMessage{
+		Name:  fmt.Sprintf("projects/%s/messages/%d", f.project, len(f.messages)+1),
+		Token: payload.Message.Token,
+		Title: payload.Message.Notification.Title,
+		Body:  payload.Message.Notification.Body,
+		Data:  payload.Message.Data,
+	}
+	f.messages = append(f.messages, msg)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"name": msg.Name})
+}
+
+// fcmError responde no formato de erro do Google, com o código específico do FCM em details.
+func fcmError(w http.ResponseWriter, status int, grpcStatus, fcmCode string) {
+	body := map[string]any{"code": status, "message": grpcStatus, "status": grpcStatus}
+	if fcmCode != "" {
+		body["details"] = []map[string]string{{
+			"@type":     "type.googleapis.com/google.firebase.fcm.v1.FcmError",
+			"errorCode": fcmCode,
+		}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": body})
+}
@@ -0,0 +1,246 @@
+package fakes
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StoredObject é um objeto guardado pelo fake de armazenamento.
+type StoredObject struct {
+	Body         []byte
+	ContentType  string
+	CacheControl string
+	ETag         string
+}
+
+// ObjectStore imita um bucket S3 em path-style ({URL}/{bucket}/{chave}) e a API XML do GCS, que
+// usa o mesmo formato de caminho. Aceita requisições SigV4 com a chave de acesso configurada,
+// tokens OAuth emitidos pelo próprio fake (veja ServiceAccountJSON) e URLs pré-assinadas dentro
+// da validade. As assinaturas em si são cobertas pelos testes de storage; aqui só a credencial
+// e a expiração são conferidas.
+type ObjectStore struct {
+	Behavior
+
+	Server *httptest.Server
+	URL    string
+
+	bucket    string
+	accessKey string
+
+	mu      sync.Mutex
+	objects map[string]StoredObject
+	email   string
+	key     *rsa.PrivateKey
+	tokens  map[string]bool
+}
+
+// NewObjectStore sobe o fake para o bucket, aceitando assinaturas da chave de acesso informada.
+func NewObjectStore(bucket, accessKey string) *ObjectStore {
+	s := &ObjectStore{bucket: bucket, accessKey: accessKey, objects: map[string]StoredObject{}, tokens: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", s.issueToken)
+	mux.HandleFunc("PUT /{bucket}/{key...}", s.put)
+	mux.HandleFunc("GET /{bucket}/{key...}", s.get)
+	mux.HandleFunc("DELETE /{bucket}/{key...}", s.remove)
+	s.Server = httptest.NewServer(s.wrap(mux))
+	s.URL = s.Server.URL
+	return s
+}
+
+// Close encerra o servidor.
+func (s *ObjectStore) Close() { s.Server.Close() }
+
+// ServiceAccountJSON gera uma conta de serviço do GCS cujo token_uri aponta para o fake; a chave
+// pública fica guardada para validar as asserções JWT.
+func (s *ObjectStore) ServiceAccountJSON(email string) ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.email, s.key = email, key
+	s.mu.Unlock()
+	block := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": email,
+		"private_key":  string(block),
+		"token_uri":    s.URL + "/token",
+	})
+}
+
+// Object devolve o objeto guardado na chave.
+func (s *ObjectStore) Object(key string) (StoredObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	return obj, ok
+}
+
+// Keys lista as chaves guardadas em ordem.
+func (s *ObjectStore) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// issueToken troca a asserção JWT da conta de serviço por um token de acesso.
+func (s *ObjectStore) issueToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	key, email := s.key, s.email
+	s.mu.Unlock()
+	if key == nil {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if r.PostFormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+		return
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(r.PostFormValue("assertion"), claims, func(*jwt.Token) (any, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(email), jwt.WithAudience(s.URL+"/token"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	token := fmt.Sprintf("ya29.fake-%d", len(s.tokens)+1)
+	s.tokens[token] = true
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"access_token": token, "expires_in": 3600, "token_type": "Bearer"})
+}
+
+// authorized aceita SigV4 ou Bearer no cabeçalho e, para leitura, URLs pré-assinadas.
+func (s *ObjectStore) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/") {
+		return true
+	}
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.tokens[token]
+	}
+	if r.Method != http.MethodGet {
+		return false
+	}
+	q := r.URL.Query()
+	if q.Get("X-Amz-Signature") != "" && strings.HasPrefix(q.Get("X-Amz-Credential"), s.accessKey+"/") {
+		return presignValid(q.Get("X-Amz-Date"), q.Get("X-Amz-Expires"))
+	}
+	s.mu.Lock()
+	email := s.email
+	s.mu.Unlock()
+	if q.Get("X-Goog-Signature") != "" && email != "" && strings.HasPrefix(q.Get("X-Goog-Credential"), email+"/") {
+		return presignValid(q.Get("X-Goog-Date"), q.Get("X-Goog-Expires"))
+	}
+	return false
+}
+
+func presignValid(date, expires string) bool {
+	signedAt, err := time.Parse("20060102T150405Z", date)
+	if err != nil {
+		return false
+	}
+	seconds, err := strconv.Atoi(expires)
+	if err != nil || seconds <= 0 {
+		return false
+	}
+	return time.Now().Before(signedAt.Add(time.Duration(seconds) * time.Second))
+}
+
+// object confere bucket e credencial e devolve a chave; em caso de erro já respondeu.
+func (s *ObjectStore) object(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.PathValue("bucket") != s.bucket {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return "", false
+	}
+	if !s.authorized(r) {
+		s3Error(w, http.StatusForbidden, "AccessDenied")
+		return "", false
+	}
+	return r.PathValue("key"), true
+}
+
+func (s *ObjectStore) put(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.object(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s3Error(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	sum := md5.Sum(body)
+	obj := StoredObject{
+		Body:         body,
+		ContentType:  r.Header.Get("Content-Type"),
+		CacheControl: r.Header.Get("Cache-Control"),
+		ETag:         hex.EncodeToString(sum[:]),
+	}
+	s.mu.Lock()
+	s.objects[key] = obj
+	s.mu.Unlock()
+	w.Header().Set("ETag", `"`+obj.ETag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *ObjectStore) get(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.object(w, r)
+	if !ok {
+		return
+	}
+	obj, found := s.Object(key)
+	if !found {
+		s3Error(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	if obj.ContentType != "" {
+		w.Header().Set("Content-Type", obj.ContentType)
+	}
+	w.Header().Set("ETag", `"`+obj.ETag+`"`)
+	_, _ = w.Write(obj.Body)
+}
+
+func (s *ObjectStore) remove(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.object(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code></Error>", code)
+}
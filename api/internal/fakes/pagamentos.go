@@ -0,0 +1,204 @@
+package fakes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Debito é uma parcela servida pelo fake do gateway de pagamento, no formato do contrato REST.
+type Debito struct {
+	ID         string  `json:"id"`
+	Documento  string  `json:"documento"`
+	Tributo    string  `json:"tributo"`
+	Inscricao  string  `json:"inscricao"`
+	Exercicio  int     `json:"exercicio"`
+	Parcela    int     `json:"parcela"`
+	Descricao  string  `json:"descricao"`
+	Vencimento string  `json:"vencimento"`
+	Valor      float64 `json:"valor"`
+	Multa      float64 `json:"multa"`
+	Juros      float64 `json:"juros"`
+	Desconto   float64 `json:"desconto"`
+	Total      float64 `json:"total"`
+}
+
+// GuiaEmitida registra uma guia pedida ao fake.
+type GuiaEmitida struct {
+	NossoNumero string
+	Documento   string
+	DebitoIDs   []string
+	Vencimento  string
+	Valor       float64
+}
+
+// PaymentGateway imita o contrato REST genérico dos sistemas tributários (GET /debitos e
+// POST /guias), que é por onde passam consultas e emissões de guias de pagamento.
+type PaymentGateway struct {
+	Behavior
+
+	Server *httptest.Server
+	URL    string
+
+	token string
+
+	mu      sync.Mutex
+	debitos []Debito
+	guias   []GuiaEmitida
+	leak    bool
+	noPDF   bool
+}
+
+// NewPaymentGateway sobe o fake aceitando o token informado. Feche com Close.
+func NewPaymentGateway(token string) *PaymentGateway {
+	g := &PaymentGateway{token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debitos", g.listDebitos)
+	mux.HandleFunc("POST /guias", g.emitirGuia)
+	g.Server = httptest.NewServer(g.wrap(mux))
+	g.URL = g.Server.URL
+	return g
+}
+
+// Close encerra o servidor.
+func (g *PaymentGateway) Close() { g.Server.Close() }
+
+// AddDebito cadastra parcelas em aberto.
+func (g *PaymentGateway) AddDebito(debitos ...Debito) {
+	g.mu.Lock()
+	g.debitos = append(g.debitos, debitos...)
+	g.mu.Unlock()
+}
+
+// SetIgnoreFilters simula um fornecedor que ignora os filtros e devolve débitos de todos os
+// contribuintes, para testar a defesa do cliente contra vazamento.
+func (g *PaymentGateway) SetIgnoreFilters(on bool) {
+	g.mu.Lock()
+	g.leak = on
+	g.mu.Unlock()
+}
+
+// SetOmitPDF faz as guias saírem sem pdf_base64, como nos fornecedores que não geram o documento.
+func (g *PaymentGateway) SetOmitPDF(on bool) {
+	g.mu.Lock()
+	g.noPDF = on
+	g.mu.Unlock()
+}
+
+// Guias devolve as guias emitidas, na ordem.
+func (g *PaymentGateway) Guias() []GuiaEmitida {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]GuiaEmitida(nil), g.guias...)
+}
+
+func (g *PaymentGateway) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "Bearer "+g.token {
+		http.Error(w, `{"erro":"token inválido"}`, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// listDebitos responde 404 quando o documento não tem nenhuma parcela cadastrada.
+func (g *PaymentGateway) listDebitos(w http.ResponseWriter, r *http.Request) {
+	if !g.authorized(w, r) {
+		return
+	}
+	q := r.URL.Query()
+	documento := q.Get("documento")
+	if documento == "" {
+		http.Error(w, `{"erro":"documento obrigatório"}`, http.StatusBadRequest)
+		return
+	}
+
+	g.mu.Lock()
+	known := false
+	out := []Debito{}
+	for _, d := range g.debitos {
+		if d.Documento != documento {
+			if g.leak {
+				out = append(out, d)
+			}
+			continue
+		}
+		known = true
+		if (q.Get("inscricao") == "" || d.Inscricao == q.Get("inscricao")) && (q.Get("tributo") == "" || strings.EqualFold(d.Tributo, q.Get("tributo"))) {
+			out = append(out, d)
+		}
+	}
+	g.mu.Unlock()
+	if !known {
+		http.Error(w, `{"erro":"contribuinte não encontrado"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"debitos": out})
+}
+
+// emitirGuia recusa com 422 débitos inexistentes ou de outro documento.
+func (g *PaymentGateway) emitirGuia(w http.ResponseWriter, r *http.Request) {
+	if !g.authorized(w, r) {
+		return
+	}
+	var body struct {
+		Documento  string   `json:"documento"`
+		Debitos    []string `json:"debitos"`
+		Vencimento string   `json:"vencimento"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Documento == "" || len(body.Debitos) == 0 || body.Vencimento == "" {
+		http.Error(w, `{"erro":"pedido inválido"}`, http.StatusBadRequest)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	byID := make(map[string]Debito, len(g.debitos))
+	for _, d := range g.debitos {
+		byID[d.ID] = d
+	}
+	var (
+		selecionados []Debito
+		valor        float64
+	)
+	for _, id := range body.Debitos {
+		d, ok := byID[id]
+		if !ok || d.Documento != body.Documento {
+			http.Error(w, `{"erro":"débito inválido"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		total := d.Total
+		if total == 0 {
+			total = d.Valor + d.Multa + d.Juros - d.Desconto
+		}
+		valor += total
+		selecionados = append(selecionados, d)
+	}
+	valor = math.Round(valor*100) / 100
+
+	guia := GuiaEmitida{
+		NossoNumero: fmt.Sprintf("%010d", len(g.guias)+1),
+		Documento:   body.Documento,
+		DebitoIDs:   body.Debitos,
+		Vencimento:  body.Vencimento,
+		Valor:       valor,
+	}
+	g.guias = append(g.guias, guia)
+
+	resp := map[string]any{
+		"nosso_numero": guia.NossoNumero,
+		"vencimento":   guia.Vencimento,
+		"valor":        guia.Valor,
+		"debitos":      selecionados,
+	}
+	if !g.noPDF {
+		resp["pdf_base64"] = base64.StdEncoding.EncodeToString([]byte("%PDF-1.4\n% guia " + guia.NossoNumero + "\n%%EOF\n"))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,233 @@
+package fakes
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMTPMessage é uma mensagem aceita pelo fake SMTP, com o conteúdo cru recebido após DATA.
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string
+	// Auth é o usuário autenticado na sessão, vazio quando não houve AUTH.
+	Auth string
+}
+
+// SMTP é um relay mínimo em texto puro (sem STARTTLS) que aceita AUTH PLAIN e guarda as
+// mensagens. Falhas programadas com FailNext respondem ao comando MAIL com o código informado
+// (ex.: 451 para falha temporária).
+type SMTP struct {
+	Behavior
+
+	Host string
+	Port int
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []SMTPMessage
+	rejected map[string]bool
+	users    map[string]string
+}
+
+// NewSMTP sobe o relay numa porta livre de 127.0.0.1. Feche com Close.
+func NewSMTP() (*SMTP, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	s := &SMTP{Host: "127.0.0.1", Port: addr.Port, listener: listener, rejected: map[string]bool{}, users: map[string]string{}}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close para de aceitar conexões e espera as sessões abertas.
+func (s *SMTP) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+// Addr devolve host:porta do relay.
+func (s *SMTP) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// RequireAuth passa a exigir AUTH PLAIN com o usuário e a senha informados.
+func (s *SMTP) RequireAuth(username, password string) {
+	s.mu.Lock()
+	s.users[username] = password
+	s.mu.Unlock()
+}
+
+// RejectRecipient faz o relay recusar o destinatário com 550.
+func (s *SMTP) RejectRecipient(addr string) {
+	s.mu.Lock()
+	s.rejected[strings.ToLower(addr)] = true
+	s.mu.Unlock()
+}
+
+// Messages devolve as mensagens aceitas, na ordem de chegada.
+func (s *SMTP) Messages() []SMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SMTPMessage(nil), s.messages...)
+}
+
+func (s *SMTP) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+			s.session(conn)
+		}()
+	}
+}
+
+// session conduz uma conversa SMTP, um comando por linha.
+func (s *SMTP) session(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	reply := func(code int, text string) {
+		fmt.Fprintf(conn, "%d %s\r\n", code, text)
+	}
+	s.mu.Lock()
+	authRequired := len(s.users) > 0
+	s.mu.Unlock()
+
+	reply(220, "fake.smtp ESMTP pronto")
+	var (
+		msg  SMTPMessage
+		user string
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			fmt.Fprintf(conn, "250-fake.smtp\r\n250-AUTH PLAIN\r\n250 8BITMIME\r\n")
+		case "HELO":
+			reply(250, "fake.smtp")
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			name, ok := s.checkPlain(mech, initial)
+			if !ok {
+				reply(535, "5.7.8 credenciais inválidas")
+				continue
+			}
+			user = name
+			reply(235, "2.7.0 autenticado")
+		case "MAIL":
+			if authRequired && user == "" {
+				reply(530, "5.7.0 autenticação obrigatória")
+				continue
+			}
+			if status, fail := s.next(); fail {
+				reply(status, "falha programada no fake")
+				continue
+			}
+			msg = SMTPMessage{From: addressArg(arg), Auth: user}
+			reply(250, "2.1.0 ok")
+		case "RCPT":
+			to := addressArg(arg)
+			s.mu.Lock()
+			rejected := s.rejected[strings.ToLower(to)]
+			s.mu.Unlock()
+			if rejected {
+				reply(550, "5.1.1 destinatário inexistente")
+				continue
+			}
+			msg.To = append(msg.To, to)
+			reply(250, "2.1.5 ok")
+		case "DATA":
+			if msg.From == "" || len(msg.To) == 0 {
+				reply(503, "5.5.1 sequência de comandos inválida")
+				continue
+			}
+			reply(354, "termine com <CRLF>.<CRLF>")
+			data, err := readData(reader)
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = SMTPMessage{Auth: user}
+			reply(250, "2.0.0 mensagem aceita")
+		case "RSET":
+			msg = SMTPMessage{Auth: user}
+			reply(250, "2.0.0 ok")
+		case "NOOP":
+			reply(250, "2.0.0 ok")
+		case "QUIT":
+			reply(221, "2.0.0 até logo")
+			return
+		default:
+			reply(502, "5.5.2 comando não implementado")
+		}
+	}
+}
+
+// checkPlain valida AUTH PLAIN com resposta inicial, o único formato usado pelo net/smtp.
+func (s *SMTP) checkPlain(mech, initial string) (string, bool) {
+	if !strings.EqualFold(mech, "PLAIN") {
+		return "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	password, ok := s.users[parts[1]]
+	return parts[1], ok && password == parts[2]
+}
+
+// addressArg extrai o endereço de "FROM:<a@b>" ou "TO:<a@b>", ignorando parâmetros ESMTP.
+func addressArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr = strings.TrimSpace(addr)
+	if end := strings.Index(addr, ">"); end >= 0 {
+		addr = addr[:end]
+	}
+	return strings.TrimPrefix(addr, "<")
+}
+
+// readData lê o corpo até a linha com um único ponto, desfazendo o dot-stuffing.
+func readData(reader *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return b.String(), nil
+		}
+		b.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
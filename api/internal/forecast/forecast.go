@@ -0,0 +1,178 @@
+// Package forecast projeta MRR/ARR a partir dos contratos vigentes sob cenários de churn,
+// pipeline comercial e reajustes de preço.
+package forecast
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// MinMonths e MaxMonths limitam o horizonte da projeção.
+	MinMonths = 12
+	MaxMonths = 24
+)
+
+var (
+	// ErrInvalidChurn indica churn fora de 0–100%.
+	ErrInvalidChurn = errors.New("churn deve estar entre 0 e 100%")
+	// ErrInvalidProbability indica probabilidade de fechamento fora de 0–1.
+	ErrInvalidProbability = errors.New("probabilidade deve estar entre 0 e 1")
+)
+
+// Deal é um tenant em negociação; entra na projeção ponderado pela probabilidade.
+type Deal struct {
+	Name         string    `json:"name"`
+	MonthlyValue float64   `json:"monthly_value"`
+	Start        time.Time `json:"start"`
+	Probability  float64   `json:"probability"`
+}
+
+// Adjustment é um reajuste de preço (ex.: IPCA) aplicado a toda a base a partir do mês efetivo.
+type Adjustment struct {
+	Effective time.Time `json:"effective"`
+	Percent   float64   `json:"percent"`
+}
+
+// Scenario reúne as premissas de uma projeção. ChurnPct é anual; nil usa o churn histórico.
+type Scenario struct {
+	Name        string       `json:"name"`
+	ChurnPct    *float64     `json:"churn_pct,omitempty"`
+	Pipeline    []Deal       `json:"pipeline"`
+	Adjustments []Adjustment `json:"price_adjustments"`
+}
+
+// Point é um mês da série projetada.
+type Point struct {
+	Month      string  `json:"month"`
+	MRR        float64 `json:"mrr"`
+	ARR        float64 `json:"arr"`
+	NewMRR     float64 `json:"new_mrr"`
+	ChurnedMRR float64 `json:"churned_mrr"`
+	AdjustMRR  float64 `json:"adjustment_mrr"`
+}
+
+// Result é a projeção de um cenário.
+type Result struct {
+	Scenario        string  `json:"scenario"`
+	AnnualChurnPct  float64 `json:"annual_churn_pct"`
+	MonthlyChurnPct float64 `json:"monthly_churn_pct"`
+	StartMRR        float64 `json:"start_mrr"`
+	EndMRR          float64 `json:"end_mrr"`
+	EndARR          float64 `json:"end_arr"`
+	Revenue         float64 `json:"revenue_total"`
+	Series          []Point `json:"series"`
+}
+
+// Validate confere as premissas do cenário.
+func (s Scenario) Validate() error {
+	if s.ChurnPct != nil && (*s.ChurnPct < 0 || *s.ChurnPct > 100) {
+		return ErrInvalidChurn
+	}
+	for _, deal := range s.Pipeline {
+		if deal.Probability < 0 || deal.Probability > 1 {
+			return ErrInvalidProbability
+		}
+	}
+	return nil
+}
+
+// ClampMonths mantém o horizonte entre MinMonths e MaxMonths.
+func ClampMonths(months int) int {
+	if months < MinMonths {
+		return MinMonths
+	}
+	if months > MaxMonths {
+		return MaxMonths
+	}
+	return months
+}
+
+// MonthlyChurn converte churn anual (%) na taxa mensal equivalente (fração).
+func MonthlyChurn(annualPct float64) float64 {
+	if annualPct <= 0 {
+		return 0
+	}
+	if annualPct >= 100 {
+		return 1
+	}
+	return 1 - math.Pow(1-annualPct/100, 1.0/12)
+}
+
+// Project projeta o cenário mês a mês a partir do mês seguinte a start. A cada mês a base
+// perde o churn, recebe os negócios do pipeline que começam no mês e, por fim, os reajustes.
+func Project(start time.Time, months int, baseMRR, historicalChurnPct float64, scenario Scenario) Result {
+	months = ClampMonths(months)
+	annual := historicalChurnPct
+	if scenario.ChurnPct != nil {
+		annual = *scenario.ChurnPct
+	}
+	churn := MonthlyChurn(annual)
+
+	result := Result{
+		Scenario:        scenario.Name,
+		AnnualChurnPct:  round2(annual),
+		MonthlyChurnPct: round2(churn * 100),
+		StartMRR:        round2(baseMRR),
+		Series:          make([]Point, 0, months),
+	}
+
+	first := monthStart(start).AddDate(0, 1, 0)
+	mrr := baseMRR
+	for i := 0; i < months; i++ {
+		month := first.AddDate(0, i, 0)
+		point := Point{Month: month.Format("2006-01")}
+
+		point.ChurnedMRR = mrr * churn
+		mrr -= point.ChurnedMRR
+
+		for _, deal := range scenario.Pipeline {
+			if sameMonth(deal.Start, month) || (i == 0 && monthStart(deal.Start).Before(month)) {
+				point.NewMRR += deal.MonthlyValue * deal.Probability
+			}
+		}
+		mrr += point.NewMRR
+
+		for _, adj := range scenario.Adjustments {
+			if sameMonth(adj.Effective, month) {
+				delta := mrr * adj.Percent / 100
+				point.AdjustMRR += delta
+				mrr += delta
+			}
+		}
+
+		point.MRR = round2(mrr)
+		point.ARR = round2(mrr * 12)
+		point.NewMRR = round2(point.NewMRR)
+		point.ChurnedMRR = round2(point.ChurnedMRR)
+		point.AdjustMRR = round2(point.AdjustMRR)
+		result.Series = append(result.Series, point)
+		result.Revenue += mrr
+	}
+
+	result.EndMRR = round2(mrr)
+	result.EndARR = round2(mrr * 12)
+	result.Revenue = round2(result.Revenue)
+	return result
+}
+
+// HistoricalChurn calcula o churn (%) acumulado das coortes de retenção.
+func HistoricalChurn(tenants, churned int64) float64 {
+	if tenants <= 0 {
+		return 0
+	}
+	return float64(churned) / float64(tenants) * 100
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func sameMonth(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,59 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMonthlyChurnCompoundsToAnnual(t *testing.T) {
+	monthly := MonthlyChurn(12)
+	retained := math.Pow(1-monthly, 12)
+	if math.Abs(retained-0.88) > 1e-9 {
+		t.Fatalf("retenção anual = %v, esperado 0.88", retained)
+	}
+	if MonthlyChurn(0) != 0 || MonthlyChurn(100) != 1 {
+		t.Fatal("limites de churn incorretos")
+	}
+}
+
+func TestProjectAppliesPipelineAndAdjustments(t *testing.T) {
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	zero := 0.0
+	result := Project(start, 12, 1000, 30, Scenario{
+		Name:     "esperado",
+		ChurnPct: &zero,
+		Pipeline: []Deal{
+			{Name: "Atrasado", MonthlyValue: 100, Start: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), Probability: 1},
+			{Name: "Novo", MonthlyValue: 400, Start: time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC), Probability: 0.5},
+		},
+		Adjustments: []Adjustment{{Effective: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), Percent: 10}},
+	})
+
+	if len(result.Series) != 12 || result.Series[0].Month != "2026-11" {
+		t.Fatalf("série inesperada: %+v", result.Series[:1])
+	}
+	// Negócio com início passado entra no primeiro mês.
+	if result.Series[0].MRR != 1100 {
+		t.Fatalf("MRR nov = %v, esperado 1100", result.Series[0].MRR)
+	}
+	if got := result.Series[2]; got.Month != "2027-01" || got.AdjustMRR != 110 || got.MRR != 1210 {
+		t.Fatalf("reajuste de jan inesperado: %+v", got)
+	}
+	if got := result.Series[3]; got.NewMRR != 200 || got.MRR != 1410 {
+		t.Fatalf("pipeline de fev inesperado: %+v", got)
+	}
+	if result.EndARR != 1410*12 {
+		t.Fatalf("ARR final = %v", result.EndARR)
+	}
+}
+
+func TestProjectUsesHistoricalChurnAndClampsHorizon(t *testing.T) {
+	result := Project(time.Now(), 60, 1000, 12, Scenario{Name: "base"})
+	if len(result.Series) != MaxMonths {
+		t.Fatalf("horizonte = %d, esperado %d", len(result.Series), MaxMonths)
+	}
+	if math.Abs(result.Series[11].MRR-880) > 0.01 {
+		t.Fatalf("MRR após 12 meses = %v, esperado 880", result.Series[11].MRR)
+	}
+}
@@ -0,0 +1,85 @@
+package gestor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+// DefinirAcessoAluno define a senha com que o aluno entra nas avaliações online; senha vazia
+// revoga o acesso. O login usa o código de matrícula do aluno.
+func (s *Service) DefinirAcessoAluno(ctx context.Context, usuarioID, escolaID, alunoID uuid.UUID, senha string) error {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return err
+	}
+	ok, err := s.repo.AlunoNaEscola(ctx, escolaID, alunoID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return validationError("aluno sem matrícula ativa na escola")
+	}
+
+	var senhaHash *string
+	if senha != "" {
+		if err := (auth.Policy{}).ValidatePassword(senha); err != nil {
+			return validationError(err.Error())
+		}
+		hash, err := auth.Hash(senha)
+		if err != nil {
+			return err
+		}
+		senhaHash = &hash
+	}
+	return s.repo.DefinirSenhaAluno(ctx, alunoID, senhaHash)
+}
+
+// DefinirSenhaAluno grava ou remove a senha do aluno; sem código de matrícula não há como entrar.
+func (r *Repository) DefinirSenhaAluno(ctx context.Context, alunoID uuid.UUID, senhaHash *string) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	cmd, err := r.conn(ctx).Exec(ctx, `
+        UPDATE alunos SET senha_hash = $2
+        WHERE id = $1 AND ($2::text IS NULL OR NULLIF(trim(matricula), '') IS NOT NULL)
+    `, alunoID, senhaHash)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return validationError("aluno sem código de matrícula")
+	}
+	return nil
+}
+
+func (h *Handler) definirAcessoAluno(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+	alunoID, err := uuid.Parse(chi.URLParam(r, "alunoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "aluno inválido", nil)
+		return
+	}
+
+	var payload struct {
+		Senha string `json:"senha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	if err := h.service.DefinirAcessoAluno(r.Context(), usuarioID, escolaID, alunoID, payload.Senha); err != nil {
+		writeDomainError(w, err, "não foi possível definir acesso do aluno")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
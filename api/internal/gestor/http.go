@@ -0,0 +1,778 @@
+package gestor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+type ServiceProvider interface {
+	ListEscolas(ctx context.Context, usuarioID uuid.UUID) ([]Escola, error)
+	ListTurmas(ctx context.Context, usuarioID, escolaID uuid.UUID, anoLetivo int) ([]TurmaResumo, error)
+	Frequencia(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) ([]TurmaFrequencia, error)
+	Notas(ctx context.Context, usuarioID, escolaID uuid.UUID, anoLetivo, bimestre int) ([]TurmaNotas, error)
+	ChamadasPendentes(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) ([]ProfessorPendencias, error)
+	LembrarChamadas(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) (int, error)
+	ListJustificativas(ctx context.Context, usuarioID, escolaID uuid.UUID, status string) ([]Justificativa, error)
+	RegistrarJustificativa(ctx context.Context, usuarioID, escolaID uuid.UUID, input JustificativaInput) (Justificativa, error)
+	AnalisarJustificativa(ctx context.Context, usuarioID, escolaID, justificativaID uuid.UUID, aprovar bool, parecer string) (Justificativa, error)
+	ListRotas(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]RotaTransporte, error)
+	SaveRota(ctx context.Context, usuarioID, escolaID uuid.UUID, rotaID *uuid.UUID, input RotaInput) (RotaTransporte, error)
+	ListRotaAlunos(ctx context.Context, usuarioID, escolaID, rotaID uuid.UUID) ([]RotaAluno, error)
+	SetRotaAlunos(ctx context.Context, usuarioID, escolaID, rotaID uuid.UUID, alunos []RotaAluno) ([]RotaAluno, error)
+	RelatorioTransporte(ctx context.Context, usuarioID, escolaID uuid.UUID, mes time.Time) (RelatorioTransporte, error)
+	RelatorioMerenda(ctx context.Context, usuarioID, escolaID uuid.UUID, mes time.Time) (RelatorioMerenda, error)
+	RegistrarMerenda(ctx context.Context, usuarioID, escolaID uuid.UUID, data time.Time, servidas int, observacao string) error
+	EmprestimosAtrasados(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]EmprestimoAtrasado, error)
+	DefinirGeofence(ctx context.Context, usuarioID, escolaID uuid.UUID, geofence Geofence) error
+	ExcecoesChamada(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) ([]ChamadaExcecao, error)
+	ListResponsaveis(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]ResponsavelVinculo, error)
+	VincularResponsavel(ctx context.Context, usuarioID, escolaID uuid.UUID, input ResponsavelInput) (ResponsavelVinculo, error)
+	DesvincularResponsavel(ctx context.Context, usuarioID, escolaID, responsavelID, alunoID uuid.UUID) error
+	ListComunicados(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]Comunicado, error)
+	PublicarComunicado(ctx context.Context, usuarioID, escolaID uuid.UUID, input ComunicadoInput) (Comunicado, error)
+	DefinirAcessoAluno(ctx context.Context, usuarioID, escolaID, alunoID uuid.UUID, senha string) error
+}
+
+// Handler expõe visões consolidadas da escola para diretores e coordenadores.
+type Handler struct {
+	service ServiceProvider
+}
+
+func NewHandler(service ServiceProvider) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/escolas", h.listEscolas)
+	r.Get("/escolas/{escolaID}/turmas", h.listTurmas)
+	r.Get("/escolas/{escolaID}/frequencia", h.frequencia)
+	r.Get("/escolas/{escolaID}/notas", h.notas)
+	r.Get("/escolas/{escolaID}/chamadas-pendentes", h.chamadasPendentes)
+	r.Post("/escolas/{escolaID}/chamadas-pendentes/lembretes", h.lembrarChamadas)
+	r.Get("/escolas/{escolaID}/justificativas", h.listJustificativas)
+	r.Post("/escolas/{escolaID}/justificativas", h.registrarJustificativa)
+	r.Post("/escolas/{escolaID}/justificativas/{justificativaID}/aprovar", h.aprovarJustificativa)
+	r.Post("/escolas/{escolaID}/justificativas/{justificativaID}/rejeitar", h.rejeitarJustificativa)
+	r.Get("/escolas/{escolaID}/transporte/rotas", h.listRotas)
+	r.Post("/escolas/{escolaID}/transporte/rotas", h.createRota)
+	r.Put("/escolas/{escolaID}/transporte/rotas/{rotaID}", h.updateRota)
+	r.Get("/escolas/{escolaID}/transporte/rotas/{rotaID}/alunos", h.listRotaAlunos)
+	r.Put("/escolas/{escolaID}/transporte/rotas/{rotaID}/alunos", h.setRotaAlunos)
+	r.Get("/escolas/{escolaID}/transporte/relatorio", h.relatorioTransporte)
+	r.Get("/escolas/{escolaID}/merenda", h.relatorioMerenda)
+	r.Put("/escolas/{escolaID}/merenda/{data}", h.registrarMerenda)
+	r.Get("/escolas/{escolaID}/emprestimos/atrasados", h.emprestimosAtrasados)
+	r.Put("/escolas/{escolaID}/geofence", h.definirGeofence)
+	r.Get("/escolas/{escolaID}/chamadas/excecoes", h.excecoesChamada)
+	r.Get("/escolas/{escolaID}/responsaveis", h.listResponsaveis)
+	r.Post("/escolas/{escolaID}/responsaveis", h.vincularResponsavel)
+	r.Delete("/escolas/{escolaID}/responsaveis/{responsavelID}/alunos/{alunoID}", h.desvincularResponsavel)
+	r.Get("/escolas/{escolaID}/comunicados", h.listComunicados)
+	r.Post("/escolas/{escolaID}/comunicados", h.publicarComunicado)
+	r.Put("/escolas/{escolaID}/alunos/{alunoID}/acesso", h.definirAcessoAluno)
+}
+
+func (h *Handler) listEscolas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	escolas, err := h.service.ListEscolas(r.Context(), usuarioID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar escolas", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"escolas": escolas})
+}
+
+func (h *Handler) listTurmas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	turmas, err := h.service.ListTurmas(r.Context(), usuarioID, escolaID, anoLetivo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar turmas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"turmas": turmas})
+}
+
+func (h *Handler) frequencia(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	periodo, err := parsePeriodo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	frequencia, err := h.service.Frequencia(r.Context(), usuarioID, escolaID, periodo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar frequência")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"frequencia": frequencia})
+}
+
+func (h *Handler) notas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	bimestre := 0
+	if raw := r.URL.Query().Get("bimestre"); raw != "" {
+		if bimestre, err = strconv.Atoi(raw); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "bimestre inválido", nil)
+			return
+		}
+	}
+
+	notas, err := h.service.Notas(r.Context(), usuarioID, escolaID, anoLetivo, bimestre)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar notas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"notas": notas, "media_minima": mediaMinima})
+}
+
+func (h *Handler) chamadasPendentes(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	periodo, err := parsePeriodo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	pendencias, err := h.service.ChamadasPendentes(r.Context(), usuarioID, escolaID, periodo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar chamadas pendentes")
+		return
+	}
+
+	total := 0
+	for _, p := range pendencias {
+		total += len(p.Aulas)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"professores": pendencias, "total": total})
+}
+
+func (h *Handler) lembrarChamadas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	periodo, err := parsePeriodo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	enviados, err := h.service.LembrarChamadas(r.Context(), usuarioID, escolaID, periodo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível enviar lembretes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"lembretes": enviados})
+}
+
+func (h *Handler) listJustificativas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	justificativas, err := h.service.ListJustificativas(r.Context(), usuarioID, escolaID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar justificativas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"justificativas": justificativas})
+}
+
+// registrarJustificativa aceita multipart (com o campo "arquivo") ou JSON sem anexo.
+func (h *Handler) registrarJustificativa(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	var (
+		payload struct {
+			AlunoID         string `json:"aluno_id"`
+			DataInicio      string `json:"data_inicio"`
+			DataFim         string `json:"data_fim"`
+			Motivo          string `json:"motivo"`
+			SolicitanteTipo string `json:"solicitante_tipo"`
+			SolicitanteNome string `json:"solicitante_nome"`
+		}
+		anexo *Anexo
+	)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(anexoMaxBytes); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "dados multipart inválidos", nil)
+			return
+		}
+		payload.AlunoID = r.FormValue("aluno_id")
+		payload.DataInicio = r.FormValue("data_inicio")
+		payload.DataFim = r.FormValue("data_fim")
+		payload.Motivo = r.FormValue("motivo")
+		payload.SolicitanteTipo = r.FormValue("solicitante_tipo")
+		payload.SolicitanteNome = r.FormValue("solicitante_nome")
+
+		if file, header, err := r.FormFile("arquivo"); err == nil {
+			defer file.Close()
+			body, err := io.ReadAll(io.LimitReader(file, anexoMaxBytes+1))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "VALIDATION", "não foi possível ler o arquivo", nil)
+				return
+			}
+			contentType := header.Header.Get("Content-Type")
+			if contentType == "" || contentType == "application/octet-stream" {
+				contentType = http.DetectContentType(body)
+			}
+			anexo = &Anexo{Nome: header.Filename, ContentType: contentType, Body: body}
+		} else if !errors.Is(err, http.ErrMissingFile) {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "arquivo inválido", nil)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	alunoID, err := uuid.Parse(strings.TrimSpace(payload.AlunoID))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "aluno inválido", nil)
+		return
+	}
+	input := JustificativaInput{
+		AlunoID:         alunoID,
+		Motivo:          payload.Motivo,
+		SolicitanteTipo: payload.SolicitanteTipo,
+		SolicitanteNome: payload.SolicitanteNome,
+		Anexo:           anexo,
+	}
+	if input.DataInicio, err = time.Parse("2006-01-02", strings.TrimSpace(payload.DataInicio)); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "data_inicio inválida", nil)
+		return
+	}
+	if raw := strings.TrimSpace(payload.DataFim); raw != "" {
+		if input.DataFim, err = time.Parse("2006-01-02", raw); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "data_fim inválida", nil)
+			return
+		}
+	}
+
+	justificativa, err := h.service.RegistrarJustificativa(r.Context(), usuarioID, escolaID, input)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível registrar justificativa")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"justificativa": justificativa})
+}
+
+func (h *Handler) aprovarJustificativa(w http.ResponseWriter, r *http.Request) {
+	h.analisarJustificativa(w, r, true)
+}
+
+func (h *Handler) rejeitarJustificativa(w http.ResponseWriter, r *http.Request) {
+	h.analisarJustificativa(w, r, false)
+}
+
+func (h *Handler) analisarJustificativa(w http.ResponseWriter, r *http.Request, aprovar bool) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	justificativaID, err := uuid.Parse(chi.URLParam(r, "justificativaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "justificativa inválida", nil)
+		return
+	}
+
+	var payload struct {
+		Parecer string `json:"parecer"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+			return
+		}
+	}
+
+	justificativa, err := h.service.AnalisarJustificativa(r.Context(), usuarioID, escolaID, justificativaID, aprovar, payload.Parecer)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível analisar justificativa")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"justificativa": justificativa})
+}
+
+type rotaPayload struct {
+	Nome              string  `json:"nome"`
+	Turno             string  `json:"turno"`
+	Zona              string  `json:"zona"`
+	MotoristaNome     *string `json:"motorista_nome"`
+	MotoristaCNH      *string `json:"motorista_cnh"`
+	MotoristaTelefone *string `json:"motorista_telefone"`
+	VeiculoPlaca      *string `json:"veiculo_placa"`
+	VeiculoModelo     *string `json:"veiculo_modelo"`
+	VeiculoCapacidade *int    `json:"veiculo_capacidade"`
+	KmDiario          float64 `json:"km_diario"`
+	Ativo             *bool   `json:"ativo"`
+}
+
+func (p rotaPayload) input() RotaInput {
+	input := RotaInput{
+		Nome:              p.Nome,
+		Turno:             p.Turno,
+		Zona:              p.Zona,
+		MotoristaNome:     trimOptional(p.MotoristaNome),
+		MotoristaCNH:      trimOptional(p.MotoristaCNH),
+		MotoristaTelefone: trimOptional(p.MotoristaTelefone),
+		VeiculoPlaca:      trimOptional(p.VeiculoPlaca),
+		VeiculoModelo:     trimOptional(p.VeiculoModelo),
+		VeiculoCapacidade: p.VeiculoCapacidade,
+		KmDiario:          p.KmDiario,
+		Ativo:             true,
+	}
+	if p.Ativo != nil {
+		input.Ativo = *p.Ativo
+	}
+	return input
+}
+
+func (h *Handler) listRotas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	rotas, err := h.service.ListRotas(r.Context(), usuarioID, escolaID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar rotas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"rotas": rotas})
+}
+
+func (h *Handler) createRota(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	var payload rotaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	rota, err := h.service.SaveRota(r.Context(), usuarioID, escolaID, nil, payload.input())
+	if err != nil {
+		writeDomainError(w, err, "não foi possível criar rota")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"rota": rota})
+}
+
+func (h *Handler) updateRota(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	rotaID, err := uuid.Parse(chi.URLParam(r, "rotaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "rota inválida", nil)
+		return
+	}
+
+	var payload rotaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	rota, err := h.service.SaveRota(r.Context(), usuarioID, escolaID, &rotaID, payload.input())
+	if err != nil {
+		writeDomainError(w, err, "não foi possível atualizar rota")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"rota": rota})
+}
+
+func (h *Handler) listRotaAlunos(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	rotaID, err := uuid.Parse(chi.URLParam(r, "rotaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "rota inválida", nil)
+		return
+	}
+
+	alunos, err := h.service.ListRotaAlunos(r.Context(), usuarioID, escolaID, rotaID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar alunos da rota")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"alunos": alunos})
+}
+
+func (h *Handler) setRotaAlunos(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	rotaID, err := uuid.Parse(chi.URLParam(r, "rotaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "rota inválida", nil)
+		return
+	}
+
+	var payload struct {
+		Alunos []struct {
+			AlunoID       string  `json:"aluno_id"`
+			PontoEmbarque *string `json:"ponto_embarque"`
+		} `json:"alunos"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	alunos := make([]RotaAluno, 0, len(payload.Alunos))
+	for _, item := range payload.Alunos {
+		alunoID, err := uuid.Parse(strings.TrimSpace(item.AlunoID))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "aluno_id inválido", nil)
+			return
+		}
+		alunos = append(alunos, RotaAluno{AlunoID: alunoID, PontoEmbarque: trimOptional(item.PontoEmbarque)})
+	}
+
+	result, err := h.service.SetRotaAlunos(r.Context(), usuarioID, escolaID, rotaID, alunos)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível atualizar alunos da rota")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"alunos": result})
+}
+
+func (h *Handler) relatorioTransporte(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	mes, err := parseMes(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	relatorio, err := h.service.RelatorioTransporte(r.Context(), usuarioID, escolaID, mes)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível gerar relatório de transporte")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"relatorio": relatorio})
+}
+
+func (h *Handler) relatorioMerenda(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	mes, err := parseMes(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	relatorio, err := h.service.RelatorioMerenda(r.Context(), usuarioID, escolaID, mes)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível gerar relatório de merenda")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"relatorio": relatorio})
+}
+
+func (h *Handler) registrarMerenda(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := time.Parse("2006-01-02", chi.URLParam(r, "data"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "data inválida", nil)
+		return
+	}
+
+	var payload struct {
+		RefeicoesServidas *int   `json:"refeicoes_servidas"`
+		Observacao        string `json:"observacao"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+	if payload.RefeicoesServidas == nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "refeicoes_servidas obrigatório", nil)
+		return
+	}
+
+	if err := h.service.RegistrarMerenda(r.Context(), usuarioID, escolaID, data, *payload.RefeicoesServidas, payload.Observacao); err != nil {
+		writeDomainError(w, err, "não foi possível registrar merenda")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) emprestimosAtrasados(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	emprestimos, err := h.service.EmprestimosAtrasados(r.Context(), usuarioID, escolaID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar empréstimos atrasados")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"emprestimos": emprestimos})
+}
+
+func (h *Handler) definirGeofence(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	var payload Geofence
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	if err := h.service.DefinirGeofence(r.Context(), usuarioID, escolaID, payload); err != nil {
+		writeDomainError(w, err, "não foi possível salvar geofence")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"geofence": payload})
+}
+
+func (h *Handler) excecoesChamada(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	periodo, err := parsePeriodo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	excecoes, err := h.service.ExcecoesChamada(r.Context(), usuarioID, escolaID, periodo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar exceções de chamada")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"excecoes": excecoes})
+}
+
+// parseMes lê ?mes=YYYY-MM; vazio significa o mês corrente.
+func parseMes(r *http.Request) (time.Time, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("mes"))
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	mes, err := time.Parse("2006-01", raw)
+	if err != nil {
+		return time.Time{}, errors.New("mes inválido")
+	}
+	return mes, nil
+}
+
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func parseScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	usuarioID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	escolaID, err := uuid.Parse(chi.URLParam(r, "escolaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "escola inválida", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return usuarioID, escolaID, true
+}
+
+// parsePeriodo lê from/to (YYYY-MM-DD) e ano_letivo; o dia final é incluído por completo.
+func parsePeriodo(r *http.Request) (Periodo, error) {
+	var periodo Periodo
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		return periodo, err
+	}
+	periodo.AnoLetivo = anoLetivo
+
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		if periodo.From, err = time.Parse("2006-01-02", raw); err != nil {
+			return periodo, errors.New("from inválido")
+		}
+	}
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		to, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return periodo, errors.New("to inválido")
+		}
+		periodo.To = to.Add(24*time.Hour - time.Nanosecond)
+	}
+	return periodo, nil
+}
+
+func parseAnoLetivo(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("ano_letivo")
+	if raw == "" {
+		return 0, nil
+	}
+	ano, err := strconv.Atoi(raw)
+	if err != nil || ano < 2000 || ano > 2100 {
+		return 0, errors.New("ano_letivo inválido")
+	}
+	return ano, nil
+}
+
+func writeDomainError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso à escola", nil)
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "registro não encontrado", nil)
+	case errors.Is(err, errJustificativaAnalisada):
+		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	case errors.Is(err, errStorageIndisponivel):
+		writeError(w, http.StatusServiceUnavailable, "INTERNAL", err.Error(), nil)
+	case errors.As(err, new(validationError)):
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", fallback, nil)
+	}
+}
+
+func subjectAsUUID(r *http.Request) (uuid.UUID, error) {
+	subject := httpmiddleware.GetSubject(r.Context())
+	return uuid.Parse(subject)
+}
+
+type successEnvelope struct {
+	Data  any `json:"data"`
+	Error any `json:"error"`
+}
+
+type errorEnvelope struct {
+	Data  any        `json:"data"`
+	Error *errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(successEnvelope{Data: data})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorEnvelope{
+		Data: nil,
+		Error: &errorBody{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
@@ -0,0 +1,215 @@
+package gestor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/config"
+	"github.com/gestaozabele/municipio/internal/notify"
+	"github.com/gestaozabele/municipio/internal/scheduler"
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// nudgeLookback limita quantos dias para trás o job procura chamadas esquecidas.
+const nudgeLookback = 7 * 24 * time.Hour
+
+// NudgeResult resume uma execução do job de lembretes.
+type NudgeResult struct {
+	Cutoff      time.Time `json:"cutoff"`
+	Professores int       `json:"professores"`
+	Aulas       int       `json:"aulas"`
+	Lembretes   int       `json:"lembretes"`
+}
+
+// Nudger detecta aulas encerradas sem chamada ao fim do dia e avisa o professor responsável.
+type Nudger struct {
+	repo     *Repository
+	cfg      config.ChamadaConfig
+	location *time.Location
+	logger   zerolog.Logger
+	locker   scheduler.Locker
+	notifier *notify.Dispatcher
+	clusters clusterVisitor
+
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func NewNudger(repo *Repository, cfg config.ChamadaConfig, logger zerolog.Logger) *Nudger {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil || cfg.Timezone == "" {
+		location = time.FixedZone("BRT", -3*60*60)
+	}
+	return &Nudger{repo: repo, cfg: cfg, location: location, logger: logger}
+}
+
+// UseLocker evita que réplicas disputem o mesmo ciclo de lembretes.
+func (n *Nudger) UseLocker(locker scheduler.Locker) {
+	n.locker = locker
+}
+
+// UseDispatcher replica os lembretes pelos canais externos que o professor aceitar.
+func (n *Nudger) UseDispatcher(dispatcher *notify.Dispatcher) {
+	n.notifier = dispatcher
+}
+
+// clusterVisitor percorre os clusters de banco das prefeituras (db.Resolver.EachCluster).
+type clusterVisitor interface {
+	EachCluster(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UseClusters faz cada ciclo rodar em todos os clusters, cada um com as próprias prefeituras.
+func (n *Nudger) UseClusters(clusters clusterVisitor) {
+	n.clusters = clusters
+}
+
+// Start inicia loop periódico. Safe para chamar múltiplas vezes.
+func (n *Nudger) Start(parent context.Context) {
+	if !n.cfg.NudgeEnabled {
+		return
+	}
+	n.once.Do(func() {
+		ctx, cancel := context.WithCancel(parent)
+		n.cancel = cancel
+		go n.runLoop(ctx)
+	})
+}
+
+// Stop encerra loop periódico.
+func (n *Nudger) Stop() {
+	if n.cancel != nil {
+		n.cancel()
+	}
+}
+
+func (n *Nudger) runLoop(ctx context.Context) {
+	interval := n.cfg.NudgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	n.logger.Info().Dur("interval", interval).Int("hour", n.cfg.NudgeHour).Msg("chamadas: loop de lembretes iniciado")
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.logger.Info().Msg("chamadas: loop de lembretes encerrado")
+			return
+		case <-ticker.C:
+			if err := n.runScheduled(ctx, interval); err != nil {
+				n.logger.Error().Err(err).Msg("chamadas: execução de lembretes falhou")
+			}
+		}
+	}
+}
+
+func (n *Nudger) runScheduled(ctx context.Context, interval time.Duration) error {
+	run := func(ctx context.Context) error {
+		result, err := n.RunOnce(ctx, util.Now())
+		if err != nil {
+			return err
+		}
+		if result.Lembretes > 0 {
+			n.logger.Info().Int("lembretes", result.Lembretes).Int("professores", result.Professores).Msg("chamadas: lembretes enviados")
+		}
+		return nil
+	}
+	if n.clusters != nil {
+		umCluster := run
+		run = func(ctx context.Context) error { return n.clusters.EachCluster(ctx, umCluster) }
+	}
+	if n.locker == nil {
+		return run(ctx)
+	}
+	_, err := n.locker.RunExclusive(ctx, "chamadas.nudge", interval, run)
+	return err
+}
+
+// RunOnce envia lembretes para aulas encerradas até o último corte diário sem chamada.
+// Aulas já lembradas são ignoradas, então execuções repetidas são idempotentes.
+func (n *Nudger) RunOnce(ctx context.Context, now time.Time) (NudgeResult, error) {
+	cutoff := nudgeCutoff(now, n.cfg.NudgeHour, n.location)
+	result := NudgeResult{Cutoff: cutoff}
+
+	pendencias, err := n.repo.PendenciasSemLembrete(ctx, cutoff.Add(-nudgeLookback), cutoff)
+	if err != nil {
+		return result, fmt.Errorf("listar pendências: %w", err)
+	}
+
+	for _, p := range pendencias {
+		created, err := n.Notify(ctx, p)
+		if err != nil {
+			n.logger.Warn().Err(err).Str("professor", p.ProfessorID.String()).Msg("chamadas: falha ao registrar lembretes")
+			continue
+		}
+		result.Aulas += len(p.Aulas)
+		result.Lembretes += created
+		if created > 0 {
+			result.Professores++
+		}
+	}
+	return result, nil
+}
+
+// Notify registra lembretes das aulas pendentes na caixa do professor responsável.
+func (n *Nudger) Notify(ctx context.Context, pendencia ProfessorPendencias) (int, error) {
+	if pendencia.ProfessorID == nil {
+		return 0, nil
+	}
+	lembretes := make([]Lembrete, 0, len(pendencia.Aulas))
+	for _, aula := range pendencia.Aulas {
+		lembretes = append(lembretes, Lembrete{
+			AulaID:   aula.AulaID,
+			Titulo:   "Chamada pendente",
+			Mensagem: lembreteMensagem(aula, n.location),
+		})
+	}
+	created, err := n.repo.RegistrarLembretes(ctx, *pendencia.ProfessorID, lembretes)
+	if err != nil || created == 0 || n.notifier == nil {
+		return created, err
+	}
+
+	mensagens := make([]string, 0, len(lembretes))
+	for _, l := range lembretes {
+		mensagens = append(mensagens, l.Mensagem)
+	}
+	notification := notify.Notification{
+		TenantID: pendencia.TenantID,
+		Audience: "backoffice",
+		UserID:   *pendencia.ProfessorID,
+		Category: notify.CategoryEducacao,
+		Title:    "Chamadas pendentes",
+		Body:     strings.Join(mensagens, "\n"),
+	}
+	if pendencia.Email != nil {
+		notification.Email = *pendencia.Email
+	}
+	if _, err := n.notifier.Dispatch(ctx, notification); err != nil {
+		n.logger.Warn().Err(err).Str("professor", pendencia.ProfessorID.String()).Msg("chamadas: falha ao despachar lembretes")
+	}
+	return created, nil
+}
+
+// nudgeCutoff devolve o último horário de corte já atingido: hoje às `hour` ou, antes disso, ontem.
+func nudgeCutoff(now time.Time, hour int, location *time.Location) time.Time {
+	local := now.In(location)
+	cutoff := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, location)
+	if local.Before(cutoff) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	return cutoff.UTC()
+}
+
+func lembreteMensagem(aula AulaPendente, location *time.Location) string {
+	inicio := aula.Inicio.In(location)
+	return fmt.Sprintf("A chamada de %s da turma %s em %s às %s ainda não foi registrada.",
+		aula.Disciplina, aula.Turma, inicio.Format("02/01/2006"), inicio.Format("15:04"))
+}
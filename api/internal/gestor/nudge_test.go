@@ -0,0 +1,36 @@
+package gestor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNudgeCutoff(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+
+	// 20h locais: o corte é hoje às 18h.
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	if got, want := nudgeCutoff(now, 18, loc), time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("cutoff = %v, want %v", got, want)
+	}
+
+	// 10h locais: ainda vale o corte de ontem.
+	now = time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	if got, want := nudgeCutoff(now, 18, loc), time.Date(2026, 3, 9, 21, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("cutoff = %v, want %v", got, want)
+	}
+}
+
+func TestLembreteMensagemUsaHorarioLocal(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+	aula := AulaPendente{
+		Turma:      "5º A",
+		Disciplina: "Matemática",
+		Inicio:     time.Date(2026, 3, 10, 10, 30, 0, 0, time.UTC),
+	}
+	msg := lembreteMensagem(aula, loc)
+	if !strings.Contains(msg, "10/03/2026 às 07:30") || !strings.Contains(msg, "Matemática") {
+		t.Fatalf("mensagem inesperada: %s", msg)
+	}
+}
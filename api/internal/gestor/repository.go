@@ -0,0 +1,1051 @@
+package gestor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+)
+
+const dbTimeout = 5 * time.Second
+
+// Repository encapsula consultas consolidadas por escola.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// conn devolve o pool do cluster da prefeitura da requisição (middleware TenantDatabase) ou o
+// pool padrão fora de requisição.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.db)
+}
+
+type Escola struct {
+	ID     uuid.UUID `json:"id"`
+	Nome   string    `json:"nome"`
+	Cargo  string    `json:"cargo"`
+	Turmas int       `json:"turmas"`
+	Alunos int       `json:"alunos"`
+}
+
+type TurmaResumo struct {
+	ID          uuid.UUID      `json:"id"`
+	Nome        string         `json:"nome"`
+	Turno       string         `json:"turno"`
+	Alunos      int            `json:"alunos"`
+	Professores []ProfessorRef `json:"professores"`
+}
+
+type ProfessorRef struct {
+	ID          uuid.UUID `json:"id"`
+	Nome        string    `json:"nome"`
+	Disciplinas []string  `json:"disciplinas"`
+}
+
+type TurmaFrequencia struct {
+	TurmaID      uuid.UUID `json:"turma_id"`
+	Turma        string    `json:"turma"`
+	Aulas        int       `json:"aulas"`
+	Presentes    int       `json:"presentes"`
+	Faltas       int       `json:"faltas"`
+	Justificadas int       `json:"justificadas"`
+	Registros    int       `json:"registros"`
+	Frequencia   float64   `json:"frequencia"`
+}
+
+type TurmaNotas struct {
+	TurmaID    uuid.UUID `json:"turma_id"`
+	Turma      string    `json:"turma"`
+	Disciplina string    `json:"disciplina"`
+	Media      float64   `json:"media"`
+	Lancadas   int       `json:"lancadas"`
+	Abaixo     int       `json:"abaixo_media"`
+}
+
+type AulaPendente struct {
+	AulaID     uuid.UUID  `json:"aula_id"`
+	TurmaID    uuid.UUID  `json:"turma_id"`
+	Turma      string     `json:"turma"`
+	Disciplina string     `json:"disciplina"`
+	Inicio     time.Time  `json:"inicio"`
+	Fim        time.Time  `json:"fim"`
+	LembreteEm *time.Time `json:"lembrete_em,omitempty"`
+}
+
+// Lembrete é a notificação de chamada pendente enviada ao professor.
+type Lembrete struct {
+	AulaID   uuid.UUID
+	Titulo   string
+	Mensagem string
+}
+
+type ProfessorPendencias struct {
+	ProfessorID *uuid.UUID     `json:"professor_id,omitempty"`
+	Nome        string         `json:"nome"`
+	Email       *string        `json:"email,omitempty"`
+	Aulas       []AulaPendente `json:"aulas"`
+	// TenantID é a prefeitura da primeira aula, usada para suprimir lembretes em sandbox.
+	TenantID uuid.UUID `json:"-"`
+}
+
+// AnoLetivo resolve o ano letivo da prefeitura do gestor para a data de referência.
+func (r *Repository) AnoLetivo(ctx context.Context, usuarioID uuid.UUID, ref time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var ano int
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT al.ano
+        FROM anos_letivos al
+        JOIN secretarias s ON s.tenant_id = al.tenant_id
+        JOIN usuarios_secretarias us ON us.secretaria_id = s.id AND us.usuario_id = $1
+        WHERE $2::date BETWEEN al.inicio AND al.fim OR al.ativo
+        ORDER BY ($2::date BETWEEN al.inicio AND al.fim) DESC, al.ativo DESC
+        LIMIT 1
+    `, usuarioID, ref).Scan(&ano)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ref.Year(), nil
+		}
+		return 0, err
+	}
+	return ano, nil
+}
+
+func (r *Repository) ListEscolas(ctx context.Context, usuarioID uuid.UUID, anoLetivo int) ([]Escola, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT e.id, e.nome, eg.cargo,
+            (SELECT COUNT(*) FROM turmas t WHERE t.escola_id = e.id),
+            (SELECT COUNT(DISTINCT m.aluno_id)
+             FROM matriculas m
+             JOIN turmas t ON t.id = m.turma_id
+             WHERE t.escola_id = e.id AND m.ativo = TRUE AND m.ano_letivo = $2)
+        FROM escolas_gestores eg
+        JOIN escolas e ON e.id = eg.escola_id
+        WHERE eg.usuario_id = $1
+        ORDER BY e.nome
+    `, usuarioID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var escolas []Escola
+	for rows.Next() {
+		var e Escola
+		if err := rows.Scan(&e.ID, &e.Nome, &e.Cargo, &e.Turmas, &e.Alunos); err != nil {
+			return nil, err
+		}
+		escolas = append(escolas, e)
+	}
+	return escolas, rows.Err()
+}
+
+func (r *Repository) EnsureGestorEscola(ctx context.Context, usuarioID, escolaID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var exists bool
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS(
+            SELECT 1 FROM escolas_gestores WHERE usuario_id = $1 AND escola_id = $2
+        )
+    `, usuarioID, escolaID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (r *Repository) ListTurmas(ctx context.Context, escolaID uuid.UUID, anoLetivo int) ([]TurmaResumo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT t.id, t.nome, t.turno,
+            (SELECT COUNT(*) FROM matriculas m WHERE m.turma_id = t.id AND m.ativo = TRUE AND m.ano_letivo = $2),
+            pt.professor_id, u.nome, pt.disciplinas
+        FROM turmas t
+        LEFT JOIN professores_turmas pt ON pt.turma_id = t.id
+        LEFT JOIN usuarios u ON u.id = pt.professor_id
+        WHERE t.escola_id = $1
+        ORDER BY t.nome, u.nome
+    `, escolaID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var turmas []TurmaResumo
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var (
+			turma       TurmaResumo
+			professorID *uuid.UUID
+			nome        *string
+			disciplinas []string
+		)
+		if err := rows.Scan(&turma.ID, &turma.Nome, &turma.Turno, &turma.Alunos, &professorID, &nome, &disciplinas); err != nil {
+			return nil, err
+		}
+		pos, ok := index[turma.ID]
+		if !ok {
+			turma.Professores = []ProfessorRef{}
+			turmas = append(turmas, turma)
+			pos = len(turmas) - 1
+			index[turma.ID] = pos
+		}
+		if professorID != nil {
+			ref := ProfessorRef{ID: *professorID, Disciplinas: disciplinas}
+			if nome != nil {
+				ref.Nome = *nome
+			}
+			if ref.Disciplinas == nil {
+				ref.Disciplinas = []string{}
+			}
+			turmas[pos].Professores = append(turmas[pos].Professores, ref)
+		}
+	}
+	return turmas, rows.Err()
+}
+
+func (r *Repository) FrequenciaPorTurma(ctx context.Context, escolaID uuid.UUID, anoLetivo int, from, to time.Time) ([]TurmaFrequencia, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT t.id, t.nome,
+            COUNT(DISTINCT a.id),
+            COUNT(*) FILTER (WHERE p.status IN ('PRESENTE', 'ATRASO')),
+            COUNT(*) FILTER (WHERE p.status = 'FALTA'),
+            COUNT(*) FILTER (WHERE p.status = 'JUSTIFICADA'),
+            COUNT(p.status)
+        FROM turmas t
+        LEFT JOIN aulas a ON a.turma_id = t.id AND a.ano_letivo = $2 AND a.inicio BETWEEN $3 AND $4
+        LEFT JOIN presencas p ON p.aula_id = a.id AND p.aula_inicio = a.inicio AND p.aula_inicio BETWEEN $3 AND $4
+        WHERE t.escola_id = $1
+        GROUP BY t.id, t.nome
+        ORDER BY t.nome
+    `, escolaID, anoLetivo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []TurmaFrequencia
+	for rows.Next() {
+		var item TurmaFrequencia
+		if err := rows.Scan(&item.TurmaID, &item.Turma, &item.Aulas, &item.Presentes, &item.Faltas, &item.Justificadas, &item.Registros); err != nil {
+			return nil, err
+		}
+		if item.Registros > 0 {
+			item.Frequencia = float64(item.Presentes) / float64(item.Registros)
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) NotasPorTurma(ctx context.Context, escolaID uuid.UUID, anoLetivo, bimestre int, mediaMinima float64) ([]TurmaNotas, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT t.id, t.nome, n.disciplina, AVG(n.nota)::float8, COUNT(*),
+            COUNT(*) FILTER (WHERE n.nota < $4)
+        FROM notas n
+        JOIN turmas t ON t.id = n.turma_id
+        WHERE t.escola_id = $1 AND n.ano_letivo = $2 AND ($3 = 0 OR n.bimestre = $3)
+        GROUP BY t.id, t.nome, n.disciplina
+        ORDER BY t.nome, n.disciplina
+    `, escolaID, anoLetivo, bimestre, mediaMinima)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []TurmaNotas
+	for rows.Next() {
+		var item TurmaNotas
+		if err := rows.Scan(&item.TurmaID, &item.Turma, &item.Disciplina, &item.Media, &item.Lancadas, &item.Abaixo); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// TipoChamadaPendente identifica lembretes de chamada na caixa de notificações do professor.
+const TipoChamadaPendente = "CHAMADA_PENDENTE"
+
+// pendenciasSelect resolve o responsável pela aula: o professor da turma que leciona
+// a disciplina e, na falta dele, quem criou a aula.
+const pendenciasSelect = `
+        SELECT a.id, a.turma_id, t.nome, a.disciplina, a.inicio, a.fim,
+               (SELECT MAX(n.created_at) FROM professor_notificacoes n
+                 WHERE n.tipo = 'CHAMADA_PENDENTE' AND n.referencia_id = a.id),
+               u.id, u.nome, u.email,
+               (SELECT esc.tenant_id FROM escolas esc WHERE esc.id = t.escola_id)
+        FROM aulas a
+        JOIN turmas t ON t.id = a.turma_id
+        LEFT JOIN LATERAL (
+            SELECT pt.professor_id
+            FROM professores_turmas pt
+            WHERE pt.turma_id = a.turma_id
+            ORDER BY (a.disciplina = ANY(pt.disciplinas)) DESC, (pt.professor_id = a.criado_por) DESC
+            LIMIT 1
+        ) resp ON TRUE
+        LEFT JOIN usuarios u ON u.id = COALESCE(resp.professor_id, a.criado_por)
+        WHERE NOT EXISTS (SELECT 1 FROM presencas p WHERE p.aula_id = a.id AND p.aula_inicio = a.inicio)
+`
+
+// ChamadasPendentes lista aulas encerradas da escola sem nenhuma presença registrada.
+func (r *Repository) ChamadasPendentes(ctx context.Context, escolaID uuid.UUID, anoLetivo int, from, to time.Time) ([]ProfessorPendencias, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, pendenciasSelect+`
+          AND t.escola_id = $1
+          AND a.ano_letivo = $2
+          AND a.inicio BETWEEN $3 AND $4
+          AND a.fim < now()
+        ORDER BY u.nome NULLS LAST, a.inicio
+    `, escolaID, anoLetivo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPendencias(rows)
+}
+
+// PendenciasSemLembrete lista, em todas as escolas, aulas encerradas antes do corte
+// sem chamada e cujo responsável ainda não recebeu lembrete. Sob db.Resolver.EachCluster
+// só entram as escolas das prefeituras roteadas para o cluster.
+func (r *Repository) PendenciasSemLembrete(ctx context.Context, from, cutoff time.Time) ([]ProfessorPendencias, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, pendenciasSelect+`
+          AND a.inicio >= $1
+          AND a.fim < $2
+          AND u.id IS NOT NULL
+          AND ($3::uuid[] IS NULL OR t.escola_id IN (SELECT id FROM escolas WHERE tenant_id = ANY($3)))
+          AND NOT EXISTS (
+              SELECT 1 FROM professor_notificacoes n
+              WHERE n.professor_id = u.id AND n.tipo = 'CHAMADA_PENDENTE' AND n.referencia_id = a.id
+          )
+        ORDER BY u.id, a.inicio
+    `, from, cutoff, db.Tenants(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPendencias(rows)
+}
+
+// RegistrarLembretes grava um lembrete por aula na caixa do professor; lembretes já
+// enviados são ignorados. Retorna quantos foram criados.
+func (r *Repository) RegistrarLembretes(ctx context.Context, professorID uuid.UUID, lembretes []Lembrete) (int, error) {
+	if len(lembretes) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, l := range lembretes {
+		batch.Queue(`
+            INSERT INTO professor_notificacoes (professor_id, tipo, titulo, mensagem, referencia_id)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (professor_id, tipo, referencia_id) DO NOTHING
+        `, professorID, TipoChamadaPendente, l.Titulo, l.Mensagem, l.AulaID)
+	}
+
+	results := r.conn(ctx).SendBatch(ctx, batch)
+	defer results.Close()
+
+	created := 0
+	for range lembretes {
+		tag, err := results.Exec()
+		if err != nil {
+			return created, err
+		}
+		created += int(tag.RowsAffected())
+	}
+	return created, nil
+}
+
+func scanPendencias(rows pgx.Rows) ([]ProfessorPendencias, error) {
+	var list []ProfessorPendencias
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var (
+			aula        AulaPendente
+			professorID *uuid.UUID
+			nome        *string
+			email       *string
+			tenantID    *uuid.UUID
+		)
+		if err := rows.Scan(&aula.AulaID, &aula.TurmaID, &aula.Turma, &aula.Disciplina, &aula.Inicio, &aula.Fim, &aula.LembreteEm, &professorID, &nome, &email, &tenantID); err != nil {
+			return nil, err
+		}
+		key := uuid.Nil
+		if professorID != nil {
+			key = *professorID
+		}
+		pos, ok := index[key]
+		if !ok {
+			entry := ProfessorPendencias{ProfessorID: professorID, Nome: "Sem responsável", Email: email}
+			if nome != nil {
+				entry.Nome = *nome
+			}
+			if tenantID != nil {
+				entry.TenantID = *tenantID
+			}
+			list = append(list, entry)
+			pos = len(list) - 1
+			index[key] = pos
+		}
+		list[pos].Aulas = append(list[pos].Aulas, aula)
+	}
+	return list, rows.Err()
+}
+
+// Justificativa é um pedido de abono de faltas de um aluno em um intervalo de datas.
+type Justificativa struct {
+	ID              uuid.UUID  `json:"id"`
+	AlunoID         uuid.UUID  `json:"aluno_id"`
+	Aluno           string     `json:"aluno"`
+	DataInicio      time.Time  `json:"data_inicio"`
+	DataFim         time.Time  `json:"data_fim"`
+	Motivo          string     `json:"motivo"`
+	SolicitanteTipo string     `json:"solicitante_tipo"`
+	SolicitanteNome *string    `json:"solicitante_nome,omitempty"`
+	AnexoNome       *string    `json:"anexo_nome,omitempty"`
+	AnexoURL        *string    `json:"anexo_url,omitempty"`
+	Status          string     `json:"status"`
+	Parecer         *string    `json:"parecer,omitempty"`
+	AulasAfetadas   int        `json:"aulas_afetadas"`
+	AnalisadoEm     *time.Time `json:"analisado_em,omitempty"`
+	CriadoEm        time.Time  `json:"criado_em"`
+}
+
+// NovaJustificativa reúne os dados gravados ao registrar um pedido.
+type NovaJustificativa struct {
+	AlunoID         uuid.UUID
+	DataInicio      time.Time
+	DataFim         time.Time
+	Motivo          string
+	SolicitanteTipo string
+	SolicitanteNome *string
+	AnexoNome       *string
+	AnexoURL        *string
+	AnexoKey        *string
+}
+
+const justificativaColumns = `
+        j.id, j.aluno_id, al.nome, j.data_inicio, j.data_fim, j.motivo, j.solicitante_tipo, j.solicitante_nome,
+        j.anexo_nome, j.anexo_url, j.status, j.parecer, j.aulas_afetadas, j.analisado_em, j.created_at
+`
+
+func scanJustificativa(row pgx.Row) (Justificativa, error) {
+	var j Justificativa
+	err := row.Scan(&j.ID, &j.AlunoID, &j.Aluno, &j.DataInicio, &j.DataFim, &j.Motivo, &j.SolicitanteTipo, &j.SolicitanteNome,
+		&j.AnexoNome, &j.AnexoURL, &j.Status, &j.Parecer, &j.AulasAfetadas, &j.AnalisadoEm, &j.CriadoEm)
+	return j, err
+}
+
+// AlunoNaEscola confirma que o aluno tem matrícula ativa em alguma turma da escola.
+func (r *Repository) AlunoNaEscola(ctx context.Context, escolaID, alunoID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var exists bool
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS(
+            SELECT 1 FROM matriculas m
+            JOIN turmas t ON t.id = m.turma_id
+            WHERE m.aluno_id = $1 AND t.escola_id = $2 AND m.ativo = TRUE
+        )
+    `, alunoID, escolaID).Scan(&exists)
+	return exists, err
+}
+
+func (r *Repository) ListJustificativas(ctx context.Context, escolaID uuid.UUID, status string) ([]Justificativa, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+justificativaColumns+`
+        FROM justificativas_falta j
+        JOIN alunos al ON al.id = j.aluno_id
+        WHERE j.escola_id = $1 AND ($2 = '' OR j.status = $2)
+        ORDER BY (j.status = 'PENDENTE') DESC, j.created_at DESC
+        LIMIT 200
+    `, escolaID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Justificativa, 0)
+	for rows.Next() {
+		j, err := scanJustificativa(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, j)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) CreateJustificativa(ctx context.Context, escolaID, usuarioID uuid.UUID, input NovaJustificativa) (Justificativa, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO justificativas_falta (aluno_id, escola_id, data_inicio, data_fim, motivo, solicitante_tipo, solicitante_nome,
+                                          anexo_nome, anexo_url, anexo_key, registrado_por)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id
+    `, input.AlunoID, escolaID, input.DataInicio, input.DataFim, input.Motivo, input.SolicitanteTipo, input.SolicitanteNome,
+		input.AnexoNome, input.AnexoURL, input.AnexoKey, usuarioID).Scan(&id)
+	if err != nil {
+		return Justificativa{}, err
+	}
+
+	return scanJustificativa(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+justificativaColumns+`
+        FROM justificativas_falta j
+        JOIN alunos al ON al.id = j.aluno_id
+        WHERE j.id = $1
+    `, id))
+}
+
+// AnalisarJustificativa decide um pedido pendente. Na aprovação, as faltas do aluno nas aulas
+// do intervalo passam a JUSTIFICADA na mesma transação.
+func (r *Repository) AnalisarJustificativa(ctx context.Context, escolaID, justificativaID, usuarioID uuid.UUID, aprovar bool, parecer *string) (Justificativa, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return Justificativa{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	status := "REJEITADA"
+	if aprovar {
+		status = "APROVADA"
+	}
+
+	var (
+		alunoID          uuid.UUID
+		inicio, fim      time.Time
+		motivo, anterior string
+	)
+	err = tx.QueryRow(ctx, `
+        SELECT aluno_id, data_inicio, data_fim, motivo, status
+        FROM justificativas_falta
+        WHERE id = $1 AND escola_id = $2
+        FOR UPDATE
+    `, justificativaID, escolaID).Scan(&alunoID, &inicio, &fim, &motivo, &anterior)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Justificativa{}, ErrNotFound
+		}
+		return Justificativa{}, err
+	}
+	if anterior != "PENDENTE" {
+		return Justificativa{}, errJustificativaAnalisada
+	}
+
+	afetadas := 0
+	if aprovar {
+		tag, err := tx.Exec(ctx, `
+            UPDATE presencas p
+            SET status = 'JUSTIFICADA', origem = 'JUSTIFICATIVA', justificativa = $4, updated_at = now()
+            FROM aulas a, matriculas m
+            WHERE a.id = p.aula_id
+              AND p.aula_inicio = a.inicio
+              AND m.id = p.matricula_id
+              AND m.aluno_id = $1
+              AND p.status = 'FALTA'
+              AND (a.inicio AT TIME ZONE 'UTC')::date BETWEEN $2 AND $3
+        `, alunoID, inicio, fim, motivo)
+		if err != nil {
+			return Justificativa{}, err
+		}
+		afetadas = int(tag.RowsAffected())
+	}
+
+	if _, err := tx.Exec(ctx, `
+        UPDATE justificativas_falta
+        SET status = $2, parecer = $3, aulas_afetadas = $4, analisado_por = $5, analisado_em = now(), updated_at = now()
+        WHERE id = $1
+    `, justificativaID, status, parecer, afetadas, usuarioID); err != nil {
+		return Justificativa{}, err
+	}
+
+	justificativa, err := scanJustificativa(tx.QueryRow(ctx, `
+        SELECT `+justificativaColumns+`
+        FROM justificativas_falta j
+        JOIN alunos al ON al.id = j.aluno_id
+        WHERE j.id = $1
+    `, justificativaID))
+	if err != nil {
+		return Justificativa{}, err
+	}
+
+	return justificativa, tx.Commit(ctx)
+}
+
+// RotaTransporte é uma rota de transporte escolar com motorista e veículo.
+type RotaTransporte struct {
+	ID                uuid.UUID `json:"id"`
+	Nome              string    `json:"nome"`
+	Turno             string    `json:"turno"`
+	Zona              string    `json:"zona"`
+	MotoristaNome     *string   `json:"motorista_nome,omitempty"`
+	MotoristaCNH      *string   `json:"motorista_cnh,omitempty"`
+	MotoristaTelefone *string   `json:"motorista_telefone,omitempty"`
+	VeiculoPlaca      *string   `json:"veiculo_placa,omitempty"`
+	VeiculoModelo     *string   `json:"veiculo_modelo,omitempty"`
+	VeiculoCapacidade *int      `json:"veiculo_capacidade,omitempty"`
+	KmDiario          float64   `json:"km_diario"`
+	Ativo             bool      `json:"ativo"`
+	Alunos            int       `json:"alunos"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type RotaInput struct {
+	Nome              string
+	Turno             string
+	Zona              string
+	MotoristaNome     *string
+	MotoristaCNH      *string
+	MotoristaTelefone *string
+	VeiculoPlaca      *string
+	VeiculoModelo     *string
+	VeiculoCapacidade *int
+	KmDiario          float64
+	Ativo             bool
+}
+
+type RotaAluno struct {
+	AlunoID       uuid.UUID `json:"aluno_id"`
+	Nome          string    `json:"nome,omitempty"`
+	PontoEmbarque *string   `json:"ponto_embarque,omitempty"`
+}
+
+// RelatorioRota consolida uma rota no período para prestação de contas do transporte escolar.
+type RelatorioRota struct {
+	RotaID            uuid.UUID `json:"rota_id"`
+	Nome              string    `json:"nome"`
+	Zona              string    `json:"zona"`
+	MotoristaNome     *string   `json:"motorista_nome,omitempty"`
+	VeiculoPlaca      *string   `json:"veiculo_placa,omitempty"`
+	VeiculoCapacidade *int      `json:"veiculo_capacidade,omitempty"`
+	Alunos            int       `json:"alunos"`
+	KmDiario          float64   `json:"km_diario"`
+	DiasLetivos       int       `json:"dias_letivos"`
+	KmTotal           float64   `json:"km_total"`
+}
+
+// MerendaDia compara alunos presentes (base das refeições previstas) com refeições servidas.
+type MerendaDia struct {
+	Data              time.Time `json:"data"`
+	AlunosPresentes   int       `json:"alunos_presentes"`
+	RefeicoesServidas *int      `json:"refeicoes_servidas,omitempty"`
+	Observacao        *string   `json:"observacao,omitempty"`
+}
+
+const rotaColumns = `
+        r.id, r.nome, r.turno, r.zona, r.motorista_nome, r.motorista_cnh, r.motorista_telefone,
+        r.veiculo_placa, r.veiculo_modelo, r.veiculo_capacidade, r.km_diario::float8, r.ativo,
+        (SELECT COUNT(*) FROM transporte_rota_alunos ra WHERE ra.rota_id = r.id),
+        r.created_at, r.updated_at
+`
+
+func scanRota(row pgx.Row) (RotaTransporte, error) {
+	var rota RotaTransporte
+	err := row.Scan(&rota.ID, &rota.Nome, &rota.Turno, &rota.Zona, &rota.MotoristaNome, &rota.MotoristaCNH, &rota.MotoristaTelefone,
+		&rota.VeiculoPlaca, &rota.VeiculoModelo, &rota.VeiculoCapacidade, &rota.KmDiario, &rota.Ativo, &rota.Alunos,
+		&rota.CreatedAt, &rota.UpdatedAt)
+	return rota, err
+}
+
+func (r *Repository) ListRotas(ctx context.Context, escolaID uuid.UUID) ([]RotaTransporte, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+rotaColumns+`
+        FROM transporte_rotas r
+        WHERE r.escola_id = $1
+        ORDER BY r.ativo DESC, r.nome
+    `, escolaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rotas := make([]RotaTransporte, 0)
+	for rows.Next() {
+		rota, err := scanRota(rows)
+		if err != nil {
+			return nil, err
+		}
+		rotas = append(rotas, rota)
+	}
+	return rotas, rows.Err()
+}
+
+// SaveRota cria a rota quando rotaID é nil ou atualiza a existente na escola.
+func (r *Repository) SaveRota(ctx context.Context, escolaID uuid.UUID, rotaID *uuid.UUID, input RotaInput) (RotaTransporte, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var id uuid.UUID
+	var err error
+	if rotaID == nil {
+		err = r.conn(ctx).QueryRow(ctx, `
+            INSERT INTO transporte_rotas (escola_id, nome, turno, zona, motorista_nome, motorista_cnh, motorista_telefone,
+                                          veiculo_placa, veiculo_modelo, veiculo_capacidade, km_diario, ativo)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+            RETURNING id
+        `, escolaID, input.Nome, input.Turno, input.Zona, input.MotoristaNome, input.MotoristaCNH, input.MotoristaTelefone,
+			input.VeiculoPlaca, input.VeiculoModelo, input.VeiculoCapacidade, input.KmDiario, input.Ativo).Scan(&id)
+	} else {
+		err = r.conn(ctx).QueryRow(ctx, `
+            UPDATE transporte_rotas
+            SET nome = $3, turno = $4, zona = $5, motorista_nome = $6, motorista_cnh = $7, motorista_telefone = $8,
+                veiculo_placa = $9, veiculo_modelo = $10, veiculo_capacidade = $11, km_diario = $12, ativo = $13, updated_at = now()
+            WHERE id = $1 AND escola_id = $2
+            RETURNING id
+        `, *rotaID, escolaID, input.Nome, input.Turno, input.Zona, input.MotoristaNome, input.MotoristaCNH, input.MotoristaTelefone,
+			input.VeiculoPlaca, input.VeiculoModelo, input.VeiculoCapacidade, input.KmDiario, input.Ativo).Scan(&id)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return RotaTransporte{}, ErrNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return RotaTransporte{}, validationError("já existe rota com esse nome")
+		}
+		return RotaTransporte{}, err
+	}
+
+	return scanRota(r.conn(ctx).QueryRow(ctx, `SELECT `+rotaColumns+` FROM transporte_rotas r WHERE r.id = $1`, id))
+}
+
+func (r *Repository) ListRotaAlunos(ctx context.Context, escolaID, rotaID uuid.UUID) ([]RotaAluno, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT ra.aluno_id, al.nome, ra.ponto_embarque
+        FROM transporte_rota_alunos ra
+        JOIN transporte_rotas r ON r.id = ra.rota_id
+        JOIN alunos al ON al.id = ra.aluno_id
+        WHERE ra.rota_id = $1 AND r.escola_id = $2
+        ORDER BY al.nome
+    `, rotaID, escolaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alunos := make([]RotaAluno, 0)
+	for rows.Next() {
+		var a RotaAluno
+		if err := rows.Scan(&a.AlunoID, &a.Nome, &a.PontoEmbarque); err != nil {
+			return nil, err
+		}
+		alunos = append(alunos, a)
+	}
+	return alunos, rows.Err()
+}
+
+// SetRotaAlunos substitui os alunos da rota; todos precisam de matrícula ativa na escola.
+func (r *Repository) SetRotaAlunos(ctx context.Context, escolaID, rotaID uuid.UUID, alunos []RotaAluno) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM transporte_rotas WHERE id = $1 AND escola_id = $2)`, rotaID, escolaID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM transporte_rota_alunos WHERE rota_id = $1`, rotaID); err != nil {
+		return err
+	}
+
+	if len(alunos) > 0 {
+		ids := make([]uuid.UUID, len(alunos))
+		pontos := make([]*string, len(alunos))
+		for i, a := range alunos {
+			ids[i] = a.AlunoID
+			pontos[i] = a.PontoEmbarque
+		}
+		tag, err := tx.Exec(ctx, `
+            INSERT INTO transporte_rota_alunos (rota_id, aluno_id, ponto_embarque)
+            SELECT $1, v.aluno_id, v.ponto
+            FROM unnest($2::uuid[], $3::text[]) AS v(aluno_id, ponto)
+            WHERE EXISTS (
+                SELECT 1 FROM matriculas m JOIN turmas t ON t.id = m.turma_id
+                WHERE m.aluno_id = v.aluno_id AND t.escola_id = $4 AND m.ativo = TRUE
+            )
+            ON CONFLICT (rota_id, aluno_id) DO NOTHING
+        `, rotaID, ids, pontos, escolaID)
+		if err != nil {
+			return err
+		}
+		if int(tag.RowsAffected()) != len(alunos) {
+			return validationError("aluno duplicado ou sem matrícula ativa na escola")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RelatorioTransporte soma dias letivos (dias com aula na escola) e quilometragem por rota ativa.
+func (r *Repository) RelatorioTransporte(ctx context.Context, escolaID uuid.UUID, from, to time.Time) ([]RelatorioRota, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        WITH dias AS (
+            SELECT COUNT(DISTINCT (a.inicio AT TIME ZONE 'UTC')::date) AS total
+            FROM aulas a
+            JOIN turmas t ON t.id = a.turma_id
+            WHERE t.escola_id = $1 AND a.inicio >= $2 AND a.inicio < $3
+        )
+        SELECT r.id, r.nome, r.zona, r.motorista_nome, r.veiculo_placa, r.veiculo_capacidade,
+               (SELECT COUNT(*) FROM transporte_rota_alunos ra WHERE ra.rota_id = r.id),
+               r.km_diario::float8, dias.total
+        FROM transporte_rotas r, dias
+        WHERE r.escola_id = $1 AND r.ativo
+        ORDER BY r.zona, r.nome
+    `, escolaID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relatorio := make([]RelatorioRota, 0)
+	for rows.Next() {
+		var item RelatorioRota
+		if err := rows.Scan(&item.RotaID, &item.Nome, &item.Zona, &item.MotoristaNome, &item.VeiculoPlaca, &item.VeiculoCapacidade,
+			&item.Alunos, &item.KmDiario, &item.DiasLetivos); err != nil {
+			return nil, err
+		}
+		item.KmTotal = item.KmDiario * float64(item.DiasLetivos)
+		relatorio = append(relatorio, item)
+	}
+	return relatorio, rows.Err()
+}
+
+// MerendaPeriodo lista, por dia com aula ou registro, os alunos presentes e as refeições servidas.
+func (r *Repository) MerendaPeriodo(ctx context.Context, escolaID uuid.UUID, from, to time.Time) ([]MerendaDia, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        WITH presentes AS (
+            SELECT (a.inicio AT TIME ZONE 'UTC')::date AS dia, COUNT(DISTINCT m.aluno_id) AS alunos
+            FROM aulas a
+            JOIN turmas t ON t.id = a.turma_id
+            JOIN presencas p ON p.aula_id = a.id AND p.aula_inicio = a.inicio
+            JOIN matriculas m ON m.id = p.matricula_id
+            WHERE t.escola_id = $1 AND a.inicio >= $2 AND a.inicio < $3
+              AND p.aula_inicio >= $2 AND p.aula_inicio < $3
+              AND p.status IN ('PRESENTE', 'ATRASO')
+            GROUP BY 1
+        ),
+        registros AS (
+            SELECT data AS dia, refeicoes_servidas, observacao
+            FROM merenda_registros
+            WHERE escola_id = $1 AND data >= $2::date AND data < $3::date
+        )
+        SELECT COALESCE(p.dia, g.dia), COALESCE(p.alunos, 0), g.refeicoes_servidas, g.observacao
+        FROM presentes p
+        FULL OUTER JOIN registros g ON g.dia = p.dia
+        ORDER BY 1
+    `, escolaID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dias := make([]MerendaDia, 0)
+	for rows.Next() {
+		var d MerendaDia
+		if err := rows.Scan(&d.Data, &d.AlunosPresentes, &d.RefeicoesServidas, &d.Observacao); err != nil {
+			return nil, err
+		}
+		dias = append(dias, d)
+	}
+	return dias, rows.Err()
+}
+
+func (r *Repository) SaveMerenda(ctx context.Context, escolaID, usuarioID uuid.UUID, data time.Time, servidas int, observacao *string) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	_, err := r.conn(ctx).Exec(ctx, `
+        INSERT INTO merenda_registros (escola_id, data, refeicoes_servidas, observacao, registrado_por)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (escola_id, data)
+        DO UPDATE SET refeicoes_servidas = EXCLUDED.refeicoes_servidas, observacao = EXCLUDED.observacao,
+                      registrado_por = EXCLUDED.registrado_por, updated_at = now()
+    `, escolaID, data, servidas, observacao, usuarioID)
+	return err
+}
+
+// EmprestimoAtrasado é um material físico não devolvido após o prazo.
+type EmprestimoAtrasado struct {
+	EmprestimoID uuid.UUID  `json:"emprestimo_id"`
+	Material     string     `json:"material"`
+	TurmaID      uuid.UUID  `json:"turma_id"`
+	Turma        string     `json:"turma"`
+	AlunoID      uuid.UUID  `json:"aluno_id"`
+	Aluno        string     `json:"aluno"`
+	Quantidade   int        `json:"quantidade"`
+	EmprestadoEm time.Time  `json:"emprestado_em"`
+	DevolverAte  time.Time  `json:"devolver_ate"`
+	DiasAtraso   int        `json:"dias_atraso"`
+	ProfessorID  *uuid.UUID `json:"professor_id,omitempty"`
+}
+
+func (r *Repository) EmprestimosAtrasados(ctx context.Context, escolaID uuid.UUID) ([]EmprestimoAtrasado, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT e.id, m.titulo, t.id, t.nome, al.id, al.nome, e.quantidade, e.emprestado_em, e.devolver_ate,
+               (CURRENT_DATE - e.devolver_ate), m.professor_id
+        FROM materiais_emprestimos e
+        JOIN materiais m ON m.id = e.material_id
+        JOIN turmas t ON t.id = m.turma_id
+        JOIN alunos al ON al.id = e.aluno_id
+        WHERE t.escola_id = $1 AND e.devolvido_em IS NULL AND e.devolver_ate < CURRENT_DATE
+        ORDER BY e.devolver_ate, t.nome, al.nome
+    `, escolaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]EmprestimoAtrasado, 0)
+	for rows.Next() {
+		var item EmprestimoAtrasado
+		if err := rows.Scan(&item.EmprestimoID, &item.Material, &item.TurmaID, &item.Turma, &item.AlunoID, &item.Aluno, &item.Quantidade,
+			&item.EmprestadoEm, &item.DevolverAte, &item.DiasAtraso, &item.ProfessorID); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// Geofence é a área da escola onde o app aceita a chamada sem justificativa.
+type Geofence struct {
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+	RaioMetros *int     `json:"raio_metros"`
+}
+
+type ChamadaExcecao struct {
+	ID              uuid.UUID `json:"id"`
+	AulaID          uuid.UUID `json:"aula_id"`
+	AulaInicio      time.Time `json:"aula_inicio"`
+	TurmaID         uuid.UUID `json:"turma_id"`
+	Turma           string    `json:"turma"`
+	ProfessorID     uuid.UUID `json:"professor_id"`
+	Professor       string    `json:"professor"`
+	Tipo            string    `json:"tipo"`
+	Detalhe         string    `json:"detalhe"`
+	Motivo          string    `json:"motivo"`
+	Latitude        *float64  `json:"latitude,omitempty"`
+	Longitude       *float64  `json:"longitude,omitempty"`
+	DistanciaMetros *float64  `json:"distancia_metros,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (r *Repository) SetGeofence(ctx context.Context, escolaID uuid.UUID, geofence Geofence) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	_, err := r.conn(ctx).Exec(ctx, `
+        UPDATE escolas SET latitude = $2, longitude = $3, geofence_raio_metros = $4 WHERE id = $1
+    `, escolaID, geofence.Latitude, geofence.Longitude, geofence.RaioMetros)
+	return err
+}
+
+// ExcecoesChamada lista chamadas registradas fora da política de geofence/atestação no período.
+func (r *Repository) ExcecoesChamada(ctx context.Context, escolaID uuid.UUID, from, to time.Time) ([]ChamadaExcecao, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT ce.id, ce.aula_id, a.inicio, t.id, t.nome, u.id, u.nome, ce.tipo, ce.detalhe, ce.motivo,
+               ce.latitude, ce.longitude, ce.distancia_metros, ce.created_at
+        FROM chamada_excecoes ce
+        JOIN turmas t ON t.id = ce.turma_id
+        JOIN aulas a ON a.id = ce.aula_id
+        JOIN usuarios u ON u.id = ce.professor_id
+        WHERE t.escola_id = $1 AND ce.created_at BETWEEN $2 AND $3
+        ORDER BY ce.created_at DESC
+    `, escolaID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]ChamadaExcecao, 0)
+	for rows.Next() {
+		var item ChamadaExcecao
+		if err := rows.Scan(&item.ID, &item.AulaID, &item.AulaInicio, &item.TurmaID, &item.Turma, &item.ProfessorID, &item.Professor,
+			&item.Tipo, &item.Detalhe, &item.Motivo, &item.Latitude, &item.Longitude, &item.DistanciaMetros, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,455 @@
+package gestor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+var errResponsavelOutraPrefeitura = errors.New("e-mail já cadastrado como responsável em outra prefeitura")
+
+// ResponsavelVinculo é um responsável com acesso ao portal das famílias para um aluno da escola.
+type ResponsavelVinculo struct {
+	ResponsavelID uuid.UUID `json:"responsavel_id"`
+	Nome          string    `json:"nome"`
+	Email         string    `json:"email"`
+	Telefone      *string   `json:"telefone,omitempty"`
+	Ativo         bool      `json:"ativo"`
+	AlunoID       uuid.UUID `json:"aluno_id"`
+	Aluno         string    `json:"aluno"`
+	Parentesco    *string   `json:"parentesco,omitempty"`
+	CriadoEm      time.Time `json:"criado_em"`
+}
+
+// ResponsavelInput vincula um responsável, novo ou já cadastrado pelo e-mail, a um aluno. A senha
+// só é exigida no primeiro cadastro.
+type ResponsavelInput struct {
+	Nome       string
+	Email      string
+	Telefone   *string
+	Senha      string
+	AlunoID    uuid.UUID
+	Parentesco *string
+}
+
+// Comunicado é um aviso da escola às famílias; sem turma vale para a escola inteira.
+type Comunicado struct {
+	ID          uuid.UUID  `json:"id"`
+	TurmaID     *uuid.UUID `json:"turma_id,omitempty"`
+	Turma       *string    `json:"turma,omitempty"`
+	Titulo      string     `json:"titulo"`
+	Corpo       string     `json:"corpo"`
+	Autor       string     `json:"autor"`
+	PublicadoEm time.Time  `json:"publicado_em"`
+}
+
+type ComunicadoInput struct {
+	TurmaID *uuid.UUID
+	Titulo  string
+	Corpo   string
+}
+
+func (s *Service) ListResponsaveis(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]ResponsavelVinculo, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListResponsaveis(ctx, escolaID)
+}
+
+func (s *Service) VincularResponsavel(ctx context.Context, usuarioID, escolaID uuid.UUID, input ResponsavelInput) (ResponsavelVinculo, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return ResponsavelVinculo{}, err
+	}
+	input.Nome = strings.TrimSpace(input.Nome)
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.Telefone = trimOptional(input.Telefone)
+	input.Parentesco = trimOptional(input.Parentesco)
+	if input.AlunoID == uuid.Nil {
+		return ResponsavelVinculo{}, validationError("aluno obrigatório")
+	}
+	if _, err := mail.ParseAddress(input.Email); err != nil {
+		return ResponsavelVinculo{}, validationError("e-mail inválido")
+	}
+	ok, err := s.repo.AlunoNaEscola(ctx, escolaID, input.AlunoID)
+	if err != nil {
+		return ResponsavelVinculo{}, err
+	}
+	if !ok {
+		return ResponsavelVinculo{}, validationError("aluno sem matrícula ativa na escola")
+	}
+
+	var senhaHash *string
+	if input.Senha != "" {
+		if err := (auth.Policy{}).ValidatePassword(input.Senha); err != nil {
+			return ResponsavelVinculo{}, validationError(err.Error())
+		}
+		hash, err := auth.Hash(input.Senha)
+		if err != nil {
+			return ResponsavelVinculo{}, err
+		}
+		senhaHash = &hash
+	}
+	return s.repo.VincularResponsavel(ctx, escolaID, usuarioID, input, senhaHash)
+}
+
+func (s *Service) DesvincularResponsavel(ctx context.Context, usuarioID, escolaID, responsavelID, alunoID uuid.UUID) error {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return err
+	}
+	return s.repo.DesvincularResponsavel(ctx, escolaID, responsavelID, alunoID)
+}
+
+func (s *Service) ListComunicados(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]Comunicado, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListComunicados(ctx, escolaID)
+}
+
+func (s *Service) PublicarComunicado(ctx context.Context, usuarioID, escolaID uuid.UUID, input ComunicadoInput) (Comunicado, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return Comunicado{}, err
+	}
+	input.Titulo = strings.TrimSpace(input.Titulo)
+	input.Corpo = strings.TrimSpace(input.Corpo)
+	if input.Titulo == "" || input.Corpo == "" {
+		return Comunicado{}, validationError("título e texto obrigatórios")
+	}
+	return s.repo.CreateComunicado(ctx, escolaID, usuarioID, input)
+}
+
+const responsavelVinculoColumns = `rs.id, rs.nome, rs.email, rs.telefone, rs.ativo, al.id, al.nome, ra.parentesco, ra.criado_em`
+
+// ListResponsaveis lista os vínculos de responsáveis com alunos matriculados na escola.
+func (r *Repository) ListResponsaveis(ctx context.Context, escolaID uuid.UUID) ([]ResponsavelVinculo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+responsavelVinculoColumns+`
+        FROM responsaveis_alunos ra
+        JOIN responsaveis rs ON rs.id = ra.responsavel_id
+        JOIN alunos al ON al.id = ra.aluno_id
+        WHERE ra.aluno_id IN (
+            SELECT m.aluno_id FROM matriculas m JOIN turmas t ON t.id = m.turma_id
+            WHERE t.escola_id = $1 AND m.ativo = TRUE
+        )
+        ORDER BY al.nome, rs.nome
+    `, escolaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]ResponsavelVinculo, 0)
+	for rows.Next() {
+		v, err := scanResponsavelVinculo(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// VincularResponsavel cadastra o responsável na prefeitura da escola, se ainda não existir, e o
+// vincula ao aluno. Um responsável já cadastrado mantém nome e senha; a senha informada só vale
+// para quem ainda não tem uma.
+func (r *Repository) VincularResponsavel(ctx context.Context, escolaID, usuarioID uuid.UUID, input ResponsavelInput, senhaHash *string) (ResponsavelVinculo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return ResponsavelVinculo{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var tenantID *uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT tenant_id FROM escolas WHERE id = $1`, escolaID).Scan(&tenantID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ResponsavelVinculo{}, ErrNotFound
+		}
+		return ResponsavelVinculo{}, err
+	}
+	if tenantID == nil {
+		return ResponsavelVinculo{}, validationError("escola sem prefeitura associada")
+	}
+
+	var responsavelID, responsavelTenant uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT id, tenant_id FROM responsaveis WHERE lower(email) = $1 FOR UPDATE`, input.Email).
+		Scan(&responsavelID, &responsavelTenant)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		if input.Nome == "" {
+			return ResponsavelVinculo{}, validationError("nome obrigatório")
+		}
+		if senhaHash == nil {
+			return ResponsavelVinculo{}, validationError("senha obrigatória no primeiro cadastro")
+		}
+		if err := tx.QueryRow(ctx, `
+            INSERT INTO responsaveis (tenant_id, nome, email, telefone, senha_hash)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id
+        `, *tenantID, input.Nome, input.Email, input.Telefone, *senhaHash).Scan(&responsavelID); err != nil {
+			return ResponsavelVinculo{}, err
+		}
+	case err != nil:
+		return ResponsavelVinculo{}, err
+	case responsavelTenant != *tenantID:
+		return ResponsavelVinculo{}, errResponsavelOutraPrefeitura
+	default:
+		if _, err := tx.Exec(ctx, `
+            UPDATE responsaveis
+            SET telefone = COALESCE($2, telefone), senha_hash = COALESCE(senha_hash, $3)
+            WHERE id = $1
+        `, responsavelID, input.Telefone, senhaHash); err != nil {
+			return ResponsavelVinculo{}, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO responsaveis_alunos (responsavel_id, aluno_id, parentesco, vinculado_por)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (responsavel_id, aluno_id) DO UPDATE SET parentesco = COALESCE(EXCLUDED.parentesco, responsaveis_alunos.parentesco)
+    `, responsavelID, input.AlunoID, input.Parentesco, usuarioID); err != nil {
+		return ResponsavelVinculo{}, err
+	}
+
+	vinculo, err := scanResponsavelVinculo(tx.QueryRow(ctx, `
+        SELECT `+responsavelVinculoColumns+`
+        FROM responsaveis_alunos ra
+        JOIN responsaveis rs ON rs.id = ra.responsavel_id
+        JOIN alunos al ON al.id = ra.aluno_id
+        WHERE ra.responsavel_id = $1 AND ra.aluno_id = $2
+    `, responsavelID, input.AlunoID))
+	if err != nil {
+		return ResponsavelVinculo{}, err
+	}
+	return vinculo, tx.Commit(ctx)
+}
+
+// DesvincularResponsavel remove o acesso do responsável a um aluno matriculado na escola.
+func (r *Repository) DesvincularResponsavel(ctx context.Context, escolaID, responsavelID, alunoID uuid.UUID) error {
+	ok, err := r.AlunoNaEscola(ctx, escolaID, alunoID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM responsaveis_alunos WHERE responsavel_id = $1 AND aluno_id = $2`, responsavelID, alunoID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const comunicadoColumns = `c.id, c.turma_id, t.nome, c.titulo, c.corpo, COALESCE(u.nome, ''), c.publicado_em`
+
+const comunicadoFrom = `
+        FROM comunicados c
+        LEFT JOIN turmas t ON t.id = c.turma_id
+        LEFT JOIN usuarios u ON u.id = c.autor_id`
+
+func (r *Repository) ListComunicados(ctx context.Context, escolaID uuid.UUID) ([]Comunicado, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+comunicadoColumns+comunicadoFrom+`
+        WHERE c.escola_id = $1
+        ORDER BY c.publicado_em DESC
+        LIMIT 200
+    `, escolaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Comunicado, 0)
+	for rows.Next() {
+		c, err := scanComunicado(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// CreateComunicado publica o comunicado; a turma, se informada, precisa ser da escola.
+func (r *Repository) CreateComunicado(ctx context.Context, escolaID, usuarioID uuid.UUID, input ComunicadoInput) (Comunicado, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	if input.TurmaID != nil {
+		var exists bool
+		if err := r.conn(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM turmas WHERE id = $1 AND escola_id = $2)`, *input.TurmaID, escolaID).Scan(&exists); err != nil {
+			return Comunicado{}, err
+		}
+		if !exists {
+			return Comunicado{}, validationError("turma não pertence à escola")
+		}
+	}
+
+	var id uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO comunicados (escola_id, turma_id, titulo, corpo, autor_id)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `, escolaID, input.TurmaID, input.Titulo, input.Corpo, usuarioID).Scan(&id); err != nil {
+		return Comunicado{}, err
+	}
+	return scanComunicado(r.conn(ctx).QueryRow(ctx, `SELECT `+comunicadoColumns+comunicadoFrom+` WHERE c.id = $1`, id))
+}
+
+func scanResponsavelVinculo(row pgx.Row) (ResponsavelVinculo, error) {
+	var v ResponsavelVinculo
+	err := row.Scan(&v.ResponsavelID, &v.Nome, &v.Email, &v.Telefone, &v.Ativo, &v.AlunoID, &v.Aluno, &v.Parentesco, &v.CriadoEm)
+	return v, err
+}
+
+func scanComunicado(row pgx.Row) (Comunicado, error) {
+	var c Comunicado
+	err := row.Scan(&c.ID, &c.TurmaID, &c.Turma, &c.Titulo, &c.Corpo, &c.Autor, &c.PublicadoEm)
+	return c, err
+}
+
+func (h *Handler) listResponsaveis(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	responsaveis, err := h.service.ListResponsaveis(r.Context(), usuarioID, escolaID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível listar responsáveis")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"responsaveis": responsaveis})
+}
+
+func (h *Handler) vincularResponsavel(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Nome       string    `json:"nome"`
+		Email      string    `json:"email"`
+		Telefone   *string   `json:"telefone"`
+		Senha      string    `json:"senha"`
+		AlunoID    uuid.UUID `json:"aluno_id"`
+		Parentesco *string   `json:"parentesco"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	vinculo, err := h.service.VincularResponsavel(r.Context(), usuarioID, escolaID, ResponsavelInput{
+		Nome:       payload.Nome,
+		Email:      payload.Email,
+		Telefone:   payload.Telefone,
+		Senha:      payload.Senha,
+		AlunoID:    payload.AlunoID,
+		Parentesco: payload.Parentesco,
+	})
+	if err != nil {
+		if errors.Is(err, errResponsavelOutraPrefeitura) {
+			writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+			return
+		}
+		writeDomainError(w, err, "não foi possível vincular responsável")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"vinculo": vinculo})
+}
+
+func (h *Handler) desvincularResponsavel(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+	responsavelID, err := uuid.Parse(chi.URLParam(r, "responsavelID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "responsável inválido", nil)
+		return
+	}
+	alunoID, err := uuid.Parse(chi.URLParam(r, "alunoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "aluno inválido", nil)
+		return
+	}
+
+	if err := h.service.DesvincularResponsavel(r.Context(), usuarioID, escolaID, responsavelID, alunoID); err != nil {
+		writeDomainError(w, err, "não foi possível remover vínculo")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listComunicados(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	comunicados, err := h.service.ListComunicados(r.Context(), usuarioID, escolaID)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível listar comunicados")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"comunicados": comunicados})
+}
+
+func (h *Handler) publicarComunicado(w http.ResponseWriter, r *http.Request) {
+	usuarioID, escolaID, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		TurmaID *uuid.UUID `json:"turma_id"`
+		Titulo  string     `json:"titulo"`
+		Corpo   string     `json:"corpo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	comunicado, err := h.service.PublicarComunicado(r.Context(), usuarioID, escolaID, ComunicadoInput{
+		TurmaID: payload.TurmaID,
+		Titulo:  payload.Titulo,
+		Corpo:   payload.Corpo,
+	})
+	if err != nil {
+		writeDomainError(w, err, "não foi possível publicar comunicado")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"comunicado": comunicado})
+}
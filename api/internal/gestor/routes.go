@@ -0,0 +1,8 @@
+package gestor
+
+import "github.com/go-chi/chi/v5"
+
+// Mount registra rotas da gestão escolar.
+func Mount(r chi.Router, handler *Handler) {
+	handler.RegisterRoutes(r)
+}
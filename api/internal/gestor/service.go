@@ -0,0 +1,460 @@
+package gestor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/storage"
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// mediaMinima é a nota de corte usada para destacar alunos abaixo da média.
+const mediaMinima = 6.0
+
+// validationError sinaliza entrada inválida; o handler responde 400 com a mensagem.
+type validationError string
+
+func (e validationError) Error() string { return string(e) }
+
+var (
+	errIntervaloInvalido      = validationError("intervalo inválido")
+	errBimestreInvalido       = validationError("bimestre inválido")
+	errJustificativaAnalisada = errors.New("justificativa já analisada")
+	errStorageIndisponivel    = errors.New("armazenamento indisponível")
+)
+
+// anexoMaxBytes limita o tamanho dos documentos anexados às justificativas.
+const anexoMaxBytes = 10 << 20
+
+type Service struct {
+	repo     *Repository
+	nudger   *Nudger
+	uploader storage.Uploader
+}
+
+func NewService(repository *Repository, nudger *Nudger, uploader storage.Uploader) *Service {
+	return &Service{repo: repository, nudger: nudger, uploader: uploader}
+}
+
+// Periodo delimita consultas por ano letivo e intervalo de datas.
+type Periodo struct {
+	AnoLetivo int
+	From      time.Time
+	To        time.Time
+}
+
+func (s *Service) ListEscolas(ctx context.Context, usuarioID uuid.UUID) ([]Escola, error) {
+	ano, err := s.repo.AnoLetivo(ctx, usuarioID, util.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListEscolas(ctx, usuarioID, ano)
+}
+
+func (s *Service) ListTurmas(ctx context.Context, usuarioID, escolaID uuid.UUID, anoLetivo int) ([]TurmaResumo, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	ano, err := s.resolveAnoLetivo(ctx, usuarioID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListTurmas(ctx, escolaID, ano)
+}
+
+func (s *Service) Frequencia(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) ([]TurmaFrequencia, error) {
+	periodo, err := s.normalizePeriodo(ctx, usuarioID, escolaID, periodo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FrequenciaPorTurma(ctx, escolaID, periodo.AnoLetivo, periodo.From, periodo.To)
+}
+
+func (s *Service) Notas(ctx context.Context, usuarioID, escolaID uuid.UUID, anoLetivo, bimestre int) ([]TurmaNotas, error) {
+	if bimestre < 0 || bimestre > 4 {
+		return nil, errBimestreInvalido
+	}
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	ano, err := s.resolveAnoLetivo(ctx, usuarioID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.NotasPorTurma(ctx, escolaID, ano, bimestre, mediaMinima)
+}
+
+func (s *Service) ChamadasPendentes(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) ([]ProfessorPendencias, error) {
+	periodo, err := s.normalizePeriodo(ctx, usuarioID, escolaID, periodo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ChamadasPendentes(ctx, escolaID, periodo.AnoLetivo, periodo.From, periodo.To)
+}
+
+// LembrarChamadas envia na hora os lembretes das chamadas pendentes da escola.
+func (s *Service) LembrarChamadas(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) (int, error) {
+	pendencias, err := s.ChamadasPendentes(ctx, usuarioID, escolaID, periodo)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, p := range pendencias {
+		created, err := s.nudger.Notify(ctx, p)
+		if err != nil {
+			return total, err
+		}
+		total += created
+	}
+	return total, nil
+}
+
+// Anexo é o documento comprobatório enviado junto da justificativa.
+type Anexo struct {
+	Nome        string
+	ContentType string
+	Body        []byte
+}
+
+type JustificativaInput struct {
+	AlunoID         uuid.UUID
+	DataInicio      time.Time
+	DataFim         time.Time
+	Motivo          string
+	SolicitanteTipo string
+	SolicitanteNome string
+	Anexo           *Anexo
+}
+
+func (s *Service) ListJustificativas(ctx context.Context, usuarioID, escolaID uuid.UUID, status string) ([]Justificativa, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", "PENDENTE", "APROVADA", "REJEITADA":
+	default:
+		return nil, validationError("status inválido")
+	}
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListJustificativas(ctx, escolaID, status)
+}
+
+// RegistrarJustificativa grava o pedido (feito pelo responsável ou pela secretaria) e o anexo.
+func (s *Service) RegistrarJustificativa(ctx context.Context, usuarioID, escolaID uuid.UUID, input JustificativaInput) (Justificativa, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return Justificativa{}, err
+	}
+
+	input.Motivo = strings.TrimSpace(input.Motivo)
+	if input.Motivo == "" {
+		return Justificativa{}, validationError("motivo obrigatório")
+	}
+	if input.DataInicio.IsZero() {
+		return Justificativa{}, validationError("data_inicio obrigatória")
+	}
+	if input.DataFim.IsZero() {
+		input.DataFim = input.DataInicio
+	}
+	if input.DataFim.Before(input.DataInicio) {
+		return Justificativa{}, errIntervaloInvalido
+	}
+	input.SolicitanteTipo = strings.ToUpper(strings.TrimSpace(input.SolicitanteTipo))
+	if input.SolicitanteTipo == "" {
+		input.SolicitanteTipo = "RESPONSAVEL"
+	}
+	if input.SolicitanteTipo != "RESPONSAVEL" && input.SolicitanteTipo != "SECRETARIA" {
+		return Justificativa{}, validationError("solicitante_tipo deve ser RESPONSAVEL ou SECRETARIA")
+	}
+
+	ok, err := s.repo.AlunoNaEscola(ctx, escolaID, input.AlunoID)
+	if err != nil {
+		return Justificativa{}, err
+	}
+	if !ok {
+		return Justificativa{}, validationError("aluno sem matrícula ativa na escola")
+	}
+
+	nova := NovaJustificativa{
+		AlunoID:         input.AlunoID,
+		DataInicio:      input.DataInicio,
+		DataFim:         input.DataFim,
+		Motivo:          input.Motivo,
+		SolicitanteTipo: input.SolicitanteTipo,
+	}
+	if nome := strings.TrimSpace(input.SolicitanteNome); nome != "" {
+		nova.SolicitanteNome = &nome
+	}
+
+	if input.Anexo != nil {
+		if len(input.Anexo.Body) > anexoMaxBytes {
+			return Justificativa{}, validationError("anexo excede 10MB")
+		}
+		if s.uploader == nil {
+			return Justificativa{}, errStorageIndisponivel
+		}
+		switch s.uploader.(type) {
+		case storage.NoopUploader, *storage.NoopUploader:
+			return Justificativa{}, errStorageIndisponivel
+		}
+
+		ext := strings.ToLower(filepath.Ext(input.Anexo.Nome))
+		if ext == "" {
+			ext = ".bin"
+		}
+		key := fmt.Sprintf("justificativas/%s/%s/%d%s", escolaID, input.AlunoID, time.Now().UnixNano(), ext)
+		result, err := s.uploader.Upload(ctx, storage.UploadInput{
+			Key:          key,
+			Body:         input.Anexo.Body,
+			ContentType:  input.Anexo.ContentType,
+			CacheControl: "private,max-age=31536000",
+		})
+		if err != nil {
+			return Justificativa{}, fmt.Errorf("upload anexo: %w", err)
+		}
+		nome := input.Anexo.Nome
+		nova.AnexoNome = &nome
+		nova.AnexoURL = &result.URL
+		nova.AnexoKey = &key
+	}
+
+	return s.repo.CreateJustificativa(ctx, escolaID, usuarioID, nova)
+}
+
+// AnalisarJustificativa aprova ou rejeita um pedido pendente.
+func (s *Service) AnalisarJustificativa(ctx context.Context, usuarioID, escolaID, justificativaID uuid.UUID, aprovar bool, parecer string) (Justificativa, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return Justificativa{}, err
+	}
+	var parecerPtr *string
+	if trimmed := strings.TrimSpace(parecer); trimmed != "" {
+		parecerPtr = &trimmed
+	}
+	if !aprovar && parecerPtr == nil {
+		return Justificativa{}, validationError("parecer obrigatório na rejeição")
+	}
+	return s.repo.AnalisarJustificativa(ctx, escolaID, justificativaID, usuarioID, aprovar, parecerPtr)
+}
+
+// RelatorioTransporte é o consolidado mensal das rotas da escola.
+type RelatorioTransporte struct {
+	Mes          string          `json:"mes"`
+	DiasLetivos  int             `json:"dias_letivos"`
+	Alunos       int             `json:"alunos"`
+	AlunosRurais int             `json:"alunos_zona_rural"`
+	KmTotal      float64         `json:"km_total"`
+	Rotas        []RelatorioRota `json:"rotas"`
+}
+
+// RelatorioMerenda é o consolidado mensal de refeições da escola.
+type RelatorioMerenda struct {
+	Mes             string       `json:"mes"`
+	DiasAtendimento int          `json:"dias_atendimento"`
+	TotalPresencas  int          `json:"total_presencas"`
+	TotalServidas   int          `json:"total_servidas"`
+	Dias            []MerendaDia `json:"dias"`
+}
+
+func (s *Service) ListRotas(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]RotaTransporte, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRotas(ctx, escolaID)
+}
+
+// SaveRota cria (rotaID nil) ou atualiza uma rota de transporte.
+func (s *Service) SaveRota(ctx context.Context, usuarioID, escolaID uuid.UUID, rotaID *uuid.UUID, input RotaInput) (RotaTransporte, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return RotaTransporte{}, err
+	}
+
+	input.Nome = strings.TrimSpace(input.Nome)
+	if input.Nome == "" {
+		return RotaTransporte{}, validationError("nome obrigatório")
+	}
+	input.Turno = strings.ToUpper(strings.TrimSpace(input.Turno))
+	switch input.Turno {
+	case "":
+		input.Turno = "MANHA"
+	case "MANHA", "TARDE", "NOITE":
+	default:
+		return RotaTransporte{}, validationError("turno inválido")
+	}
+	input.Zona = strings.ToUpper(strings.TrimSpace(input.Zona))
+	switch input.Zona {
+	case "":
+		input.Zona = "RURAL"
+	case "RURAL", "URBANA":
+	default:
+		return RotaTransporte{}, validationError("zona deve ser RURAL ou URBANA")
+	}
+	if input.KmDiario < 0 {
+		return RotaTransporte{}, validationError("km_diario inválido")
+	}
+	if input.VeiculoCapacidade != nil && *input.VeiculoCapacidade <= 0 {
+		return RotaTransporte{}, validationError("veiculo_capacidade inválida")
+	}
+	if input.VeiculoPlaca != nil {
+		placa := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(*input.VeiculoPlaca), "-", ""))
+		input.VeiculoPlaca = &placa
+	}
+
+	return s.repo.SaveRota(ctx, escolaID, rotaID, input)
+}
+
+func (s *Service) ListRotaAlunos(ctx context.Context, usuarioID, escolaID, rotaID uuid.UUID) ([]RotaAluno, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRotaAlunos(ctx, escolaID, rotaID)
+}
+
+func (s *Service) SetRotaAlunos(ctx context.Context, usuarioID, escolaID, rotaID uuid.UUID, alunos []RotaAluno) ([]RotaAluno, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetRotaAlunos(ctx, escolaID, rotaID, alunos); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRotaAlunos(ctx, escolaID, rotaID)
+}
+
+// RelatorioTransporte consolida alunos transportados e quilometragem do mês (base para o PNATE).
+func (s *Service) RelatorioTransporte(ctx context.Context, usuarioID, escolaID uuid.UUID, mes time.Time) (RelatorioTransporte, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return RelatorioTransporte{}, err
+	}
+	from, to := mesIntervalo(mes)
+	rotas, err := s.repo.RelatorioTransporte(ctx, escolaID, from, to)
+	if err != nil {
+		return RelatorioTransporte{}, err
+	}
+
+	relatorio := RelatorioTransporte{Mes: from.Format("2006-01"), Rotas: rotas}
+	for _, rota := range rotas {
+		relatorio.DiasLetivos = rota.DiasLetivos
+		relatorio.Alunos += rota.Alunos
+		if rota.Zona == "RURAL" {
+			relatorio.AlunosRurais += rota.Alunos
+		}
+		relatorio.KmTotal += rota.KmTotal
+	}
+	return relatorio, nil
+}
+
+// RelatorioMerenda compara presenças e refeições servidas no mês (base para o PNAE).
+func (s *Service) RelatorioMerenda(ctx context.Context, usuarioID, escolaID uuid.UUID, mes time.Time) (RelatorioMerenda, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return RelatorioMerenda{}, err
+	}
+	from, to := mesIntervalo(mes)
+	dias, err := s.repo.MerendaPeriodo(ctx, escolaID, from, to)
+	if err != nil {
+		return RelatorioMerenda{}, err
+	}
+
+	relatorio := RelatorioMerenda{Mes: from.Format("2006-01"), Dias: dias}
+	for _, dia := range dias {
+		if dia.AlunosPresentes > 0 || dia.RefeicoesServidas != nil {
+			relatorio.DiasAtendimento++
+		}
+		relatorio.TotalPresencas += dia.AlunosPresentes
+		if dia.RefeicoesServidas != nil {
+			relatorio.TotalServidas += *dia.RefeicoesServidas
+		}
+	}
+	return relatorio, nil
+}
+
+func (s *Service) RegistrarMerenda(ctx context.Context, usuarioID, escolaID uuid.UUID, data time.Time, servidas int, observacao string) error {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return err
+	}
+	if servidas < 0 {
+		return validationError("refeicoes_servidas inválido")
+	}
+	if data.After(util.Now()) {
+		return validationError("data futura")
+	}
+	var obs *string
+	if trimmed := strings.TrimSpace(observacao); trimmed != "" {
+		obs = &trimmed
+	}
+	return s.repo.SaveMerenda(ctx, escolaID, usuarioID, data, servidas, obs)
+}
+
+func (s *Service) EmprestimosAtrasados(ctx context.Context, usuarioID, escolaID uuid.UUID) ([]EmprestimoAtrasado, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return nil, err
+	}
+	return s.repo.EmprestimosAtrasados(ctx, escolaID)
+}
+
+// DefinirGeofence cadastra a área da escola; sem coordenadas a geofence fica desativada.
+func (s *Service) DefinirGeofence(ctx context.Context, usuarioID, escolaID uuid.UUID, geofence Geofence) error {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return err
+	}
+	if (geofence.Latitude == nil) != (geofence.Longitude == nil) {
+		return validationError("latitude e longitude devem ser informadas juntas")
+	}
+	if geofence.Latitude != nil && (*geofence.Latitude < -90 || *geofence.Latitude > 90 || *geofence.Longitude < -180 || *geofence.Longitude > 180) {
+		return validationError("coordenadas inválidas")
+	}
+	if geofence.RaioMetros != nil && (*geofence.RaioMetros < 20 || *geofence.RaioMetros > 5000) {
+		return validationError("raio_metros deve estar entre 20 e 5000")
+	}
+	return s.repo.SetGeofence(ctx, escolaID, geofence)
+}
+
+func (s *Service) ExcecoesChamada(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) ([]ChamadaExcecao, error) {
+	periodo, err := s.normalizePeriodo(ctx, usuarioID, escolaID, periodo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ExcecoesChamada(ctx, escolaID, periodo.From, periodo.To)
+}
+
+// mesIntervalo devolve [primeiro dia do mês, primeiro dia do mês seguinte) em UTC.
+func mesIntervalo(mes time.Time) (time.Time, time.Time) {
+	if mes.IsZero() {
+		mes = util.Now()
+	}
+	from := time.Date(mes.Year(), mes.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, 0)
+}
+
+// normalizePeriodo valida o acesso à escola e aplica padrão de 30 dias no ano letivo vigente.
+func (s *Service) normalizePeriodo(ctx context.Context, usuarioID, escolaID uuid.UUID, periodo Periodo) (Periodo, error) {
+	if err := s.repo.EnsureGestorEscola(ctx, usuarioID, escolaID); err != nil {
+		return Periodo{}, err
+	}
+	now := util.Now()
+	if periodo.To.IsZero() {
+		periodo.To = now
+	}
+	if periodo.From.IsZero() {
+		periodo.From = periodo.To.AddDate(0, 0, -30)
+	}
+	if periodo.To.Before(periodo.From) {
+		return Periodo{}, errIntervaloInvalido
+	}
+	ano, err := s.resolveAnoLetivo(ctx, usuarioID, periodo.AnoLetivo)
+	if err != nil {
+		return Periodo{}, err
+	}
+	periodo.AnoLetivo = ano
+	return periodo, nil
+}
+
+func (s *Service) resolveAnoLetivo(ctx context.Context, usuarioID uuid.UUID, anoLetivo int) (int, error) {
+	if anoLetivo > 0 {
+		return anoLetivo, nil
+	}
+	return s.repo.AnoLetivo(ctx, usuarioID, util.Now())
+}
@@ -0,0 +1,16 @@
+package gestor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMesIntervalo(t *testing.T) {
+	from, to := mesIntervalo(time.Date(2026, 12, 15, 10, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC); !from.Equal(want) {
+		t.Fatalf("from = %v, want %v", from, want)
+	}
+	if want := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC); !to.Equal(want) {
+		t.Fatalf("to = %v, want %v", to, want)
+	}
+}
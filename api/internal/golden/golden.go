@@ -0,0 +1,94 @@
+// Package golden compara payloads JSON com arquivos de referência versionados em
+// testdata/golden. Serve para flagrar mudanças acidentais no formato das respostas
+// consumidas pelo app e pelo painel: qualquer campo renomeado, removido ou com tipo
+// trocado quebra o teste até que o snapshot seja regenerado de propósito.
+//
+// Para regenerar, rode os testes com UPDATE_GOLDEN=1 (ou make golden-update) e
+// revise o diff dos arquivos .json antes de commitar.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// EnvUpdate é a variável que, quando igual a "1", reescreve os snapshots em vez de comparar.
+const EnvUpdate = "UPDATE_GOLDEN"
+
+// Dir é o diretório, relativo ao pacote em teste, onde ficam os snapshots.
+const Dir = "testdata/golden"
+
+// JSON compara body, já normalizado, com testdata/golden/<name>.json.
+func JSON(t testing.TB, name string, body []byte) {
+	t.Helper()
+
+	got, err := normalize(body)
+	if err != nil {
+		t.Fatalf("golden %s: payload não é JSON válido: %v\n%s", name, err, body)
+	}
+
+	path := filepath.Join(Dir, name+".json")
+	if os.Getenv(EnvUpdate) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden %s: %v (rode %s=1 go test para criar)", name, err, EnvUpdate)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	line, wantLine, gotLine := firstDiff(string(want), string(got))
+	t.Fatalf("golden %s: payload divergiu do snapshot na linha %d\n  esperado: %s\n  obtido:   %s\nse a mudança for intencional, rode %s=1 go test ./... e revise o diff de %s",
+		name, line, wantLine, gotLine, EnvUpdate, path)
+}
+
+// Value serializa v com encoding/json e compara com o snapshot.
+func Value(t testing.TB, name string, v any) {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+	}
+	JSON(t, name, body)
+}
+
+// normalize reindenta o JSON com dois espaços; a ordem das chaves vem do
+// encoding/json (campos na ordem do struct, mapas em ordem alfabética).
+func normalize(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(body), "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func firstDiff(want, got string) (int, string, string) {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return i + 1, strings.TrimSpace(w), strings.TrimSpace(g)
+		}
+	}
+	return 0, "", ""
+}
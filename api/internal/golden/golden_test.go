@@ -0,0 +1,24 @@
+package golden
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	got, err := normalize([]byte(` {"b":1,"a":[true, null]} `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null\n  ]\n}\n"
+	if string(got) != want {
+		t.Fatalf("normalize = %q", got)
+	}
+	if _, err := normalize([]byte(`{"a":`)); err == nil {
+		t.Fatal("JSON inválido aceito")
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	line, want, got := firstDiff("{\n  \"id\": 1\n}\n", "{\n  \"uuid\": 1\n}\n")
+	if line != 2 || want != `"id": 1` || got != `"uuid": 1` {
+		t.Fatalf("firstDiff = %d %q %q", line, want, got)
+	}
+}
@@ -0,0 +1,482 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/assistencia"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+type assistenciaMembroPayload struct {
+	Nome           string  `json:"nome"`
+	Parentesco     string  `json:"parentesco"`
+	DataNascimento *string `json:"data_nascimento"`
+	NIS            *string `json:"nis"`
+}
+
+type assistenciaFamiliaPayload struct {
+	Unidade         *string                    `json:"unidade"`
+	CodigoFamiliar  *string                    `json:"codigo_familiar"`
+	ResponsavelNome string                     `json:"responsavel_nome"`
+	ResponsavelCPF  *string                    `json:"responsavel_cpf"`
+	ResponsavelNIS  *string                    `json:"responsavel_nis"`
+	Endereco        *string                    `json:"endereco"`
+	Bairro          *string                    `json:"bairro"`
+	Telefone        *string                    `json:"telefone"`
+	RendaPerCapita  *float64                   `json:"renda_per_capita"`
+	TecnicoID       *string                    `json:"tecnico_id"`
+	Sigilosa        bool                       `json:"sigilosa"`
+	Situacao        string                     `json:"situacao"`
+	Observacoes     *string                    `json:"observacoes"`
+	Membros         []assistenciaMembroPayload `json:"membros"`
+}
+
+type assistenciaBeneficioPayload struct {
+	Programa       string   `json:"programa"`
+	Descricao      *string  `json:"descricao"`
+	Situacao       string   `json:"situacao"`
+	Inicio         string   `json:"inicio"`
+	Fim            *string  `json:"fim"`
+	ValorMensal    *float64 `json:"valor_mensal"`
+	NISTitular     *string  `json:"nis_titular"`
+	CodigoFamiliar *string  `json:"codigo_familiar"`
+	Referencia     *string  `json:"referencia"`
+}
+
+// assistenciaAcesso exige profissional ativo do módulo e identifica a requisição para a auditoria.
+// As respostas do módulo não vão para cache.
+func (h *Handler) assistenciaAcesso(w http.ResponseWriter, r *http.Request) (uuid.UUID, assistencia.Acesso, bool) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return uuid.Nil, assistencia.Acesso{}, false
+	}
+	p, err := h.assistencia.Profissional(r.Context(), tenantID, userID)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return uuid.Nil, assistencia.Acesso{}, false
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	return tenantID, assistencia.Acesso{
+		UsuarioID:   userID,
+		Coordenador: p.Funcao == assistencia.FuncaoCoordenador,
+		IP:          httpmiddleware.ClientIP(r),
+		UserAgent:   r.UserAgent(),
+	}, true
+}
+
+// assistenciaGestor libera a gestão de acessos a coordenadores e a secretários ou administradores
+// técnicos da prefeitura.
+func (h *Handler) assistenciaGestor(w http.ResponseWriter, r *http.Request) (uuid.UUID, assistencia.Acesso, bool) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return uuid.Nil, assistencia.Acesso{}, false
+	}
+	allowed, err := h.assistencia.PodeGerir(r.Context(), tenantID, userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível verificar permissões", nil)
+		return uuid.Nil, assistencia.Acesso{}, false
+	}
+	if !allowed {
+		writeAssistenciaError(w, assistencia.ErrForbidden)
+		return uuid.Nil, assistencia.Acesso{}, false
+	}
+	return tenantID, assistencia.Acesso{UsuarioID: userID, IP: httpmiddleware.ClientIP(r), UserAgent: r.UserAgent()}, true
+}
+
+// ListAssistenciaProfissionais lista quem tem ou teve acesso ao módulo.
+func (h *Handler) ListAssistenciaProfissionais(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.assistenciaGestor(w, r)
+	if !ok {
+		return
+	}
+	profissionais, err := h.assistencia.ListProfissionais(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar profissionais", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"profissionais": profissionais})
+}
+
+// SetAssistenciaProfissional concede ou altera o acesso de um usuário ao módulo.
+func (h *Handler) SetAssistenciaProfissional(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaGestor(w, r)
+	if !ok {
+		return
+	}
+	usuarioID, err := parseUUIDParam(r, "usuario_id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuario_id inválido", nil)
+		return
+	}
+	var payload struct {
+		Funcao  string  `json:"funcao"`
+		Unidade *string `json:"unidade"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := assistencia.ProfissionalInput{UsuarioID: usuarioID, Funcao: payload.Funcao, Unidade: payload.Unidade}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	p, err := h.assistencia.SetProfissional(r.Context(), tenantID, acesso, input)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"profissional": p})
+}
+
+// RevokeAssistenciaProfissional retira o acesso do usuário ao módulo.
+func (h *Handler) RevokeAssistenciaProfissional(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaGestor(w, r)
+	if !ok {
+		return
+	}
+	usuarioID, err := parseUUIDParam(r, "usuario_id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuario_id inválido", nil)
+		return
+	}
+	if err := h.assistencia.RevokeProfissional(r.Context(), tenantID, acesso, usuarioID); err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAssistenciaFamilias lista as famílias visíveis (?q=&bairro=&programa=&situacao=&tecnico_id=&limit=).
+func (h *Handler) ListAssistenciaFamilias(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := assistencia.Filter{
+		Query:    query.Get("q"),
+		Bairro:   strings.TrimSpace(query.Get("bairro")),
+		Programa: strings.TrimSpace(query.Get("programa")),
+		Situacao: strings.TrimSpace(query.Get("situacao")),
+	}
+	raw := query.Get("tecnico_id")
+	tecnicoID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tecnico_id inválido", nil)
+		return
+	}
+	filter.TecnicoID = tecnicoID
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	familias, err := h.assistencia.ListFamilias(r.Context(), tenantID, acesso, filter)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"familias": familias})
+}
+
+// CreateAssistenciaFamilia abre o prontuário de uma família.
+func (h *Handler) CreateAssistenciaFamilia(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeAssistenciaFamilia(w, r)
+	if !ok {
+		return
+	}
+	f, err := h.assistencia.CreateFamilia(r.Context(), tenantID, acesso, input)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"familia": f})
+}
+
+// GetAssistenciaFamilia abre o prontuário completo: membros, benefícios e visitas.
+func (h *Handler) GetAssistenciaFamilia(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	f, err := h.assistencia.GetFamilia(r.Context(), tenantID, acesso, id)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"familia": f})
+}
+
+// UpdateAssistenciaFamilia substitui os dados e a composição da família.
+func (h *Handler) UpdateAssistenciaFamilia(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeAssistenciaFamilia(w, r)
+	if !ok {
+		return
+	}
+	f, err := h.assistencia.UpdateFamilia(r.Context(), tenantID, acesso, id, input)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"familia": f})
+}
+
+// AddAssistenciaBeneficio registra a inserção da família num programa ou benefício.
+func (h *Handler) AddAssistenciaBeneficio(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeAssistenciaBeneficio(w, r)
+	if !ok {
+		return
+	}
+	b, err := h.assistencia.AddBeneficio(r.Context(), tenantID, acesso, id, input)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"beneficio": b})
+}
+
+// UpdateAssistenciaBeneficio altera a inserção (suspensão, encerramento, folha de referência).
+func (h *Handler) UpdateAssistenciaBeneficio(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	beneficioID, err := parseUUIDParam(r, "beneficio_id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "beneficio_id inválido", nil)
+		return
+	}
+	input, ok := decodeAssistenciaBeneficio(w, r)
+	if !ok {
+		return
+	}
+	b, err := h.assistencia.UpdateBeneficio(r.Context(), tenantID, acesso, id, beneficioID, input)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"beneficio": b})
+}
+
+// AddAssistenciaVisita registra visita domiciliar, atendimento ou acompanhamento.
+func (h *Handler) AddAssistenciaVisita(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Tipo            string  `json:"tipo"`
+		Data            *string `json:"data"`
+		Relato          string  `json:"relato"`
+		Encaminhamentos *string `json:"encaminhamentos"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := assistencia.VisitaInput{Tipo: payload.Tipo, Relato: payload.Relato, Encaminhamentos: payload.Encaminhamentos}
+	data, err := optionalDate(payload.Data)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "data inválida", nil)
+		return
+	}
+	if data != nil {
+		input.Data = *data
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	v, err := h.assistencia.AddVisita(r.Context(), tenantID, acesso, id, input)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"visita": v})
+}
+
+// AssistenciaAuditoria consulta a trilha de acessos do módulo (?familia_id=&usuario_id=&from=&to=&limit=).
+func (h *Handler) AssistenciaAuditoria(w http.ResponseWriter, r *http.Request) {
+	tenantID, acesso, ok := h.assistenciaAcesso(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	var filter assistencia.AuditoriaFilter
+	var err error
+	raw := query.Get("familia_id")
+	if filter.FamiliaID, err = optionalUUID(&raw); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "familia_id inválido", nil)
+		return
+	}
+	raw = query.Get("usuario_id")
+	if filter.UsuarioID, err = optionalUUID(&raw); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuario_id inválido", nil)
+		return
+	}
+	if value := strings.TrimSpace(query.Get("from")); value != "" {
+		from, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		filter.From = &from
+	}
+	if value := strings.TrimSpace(query.Get("to")); value != "" {
+		to, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		to = to.Add(24 * time.Hour)
+		filter.To = &to
+	}
+	if value := strings.TrimSpace(query.Get("limit")); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	registros, err := h.assistencia.Auditoria(r.Context(), tenantID, acesso, filter)
+	if err != nil {
+		writeAssistenciaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"registros": registros})
+}
+
+func decodeAssistenciaFamilia(w http.ResponseWriter, r *http.Request) (assistencia.FamiliaInput, bool) {
+	var payload assistenciaFamiliaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return assistencia.FamiliaInput{}, false
+	}
+	input := assistencia.FamiliaInput{
+		Unidade:         payload.Unidade,
+		CodigoFamiliar:  payload.CodigoFamiliar,
+		ResponsavelNome: payload.ResponsavelNome,
+		ResponsavelCPF:  payload.ResponsavelCPF,
+		ResponsavelNIS:  payload.ResponsavelNIS,
+		Endereco:        payload.Endereco,
+		Bairro:          payload.Bairro,
+		Telefone:        payload.Telefone,
+		RendaPerCapita:  payload.RendaPerCapita,
+		Sigilosa:        payload.Sigilosa,
+		Situacao:        payload.Situacao,
+		Observacoes:     payload.Observacoes,
+		Membros:         make([]assistencia.Membro, 0, len(payload.Membros)),
+	}
+	var err error
+	if input.TecnicoID, err = optionalUUID(payload.TecnicoID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tecnico_id inválido", nil)
+		return assistencia.FamiliaInput{}, false
+	}
+	for _, m := range payload.Membros {
+		nascimento, err := optionalDate(m.DataNascimento)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "data_nascimento inválida", nil)
+			return assistencia.FamiliaInput{}, false
+		}
+		input.Membros = append(input.Membros, assistencia.Membro{Nome: m.Nome, Parentesco: m.Parentesco, DataNascimento: nascimento, NIS: m.NIS})
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return assistencia.FamiliaInput{}, false
+	}
+	return input, true
+}
+
+func decodeAssistenciaBeneficio(w http.ResponseWriter, r *http.Request) (assistencia.BeneficioInput, bool) {
+	var payload assistenciaBeneficioPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return assistencia.BeneficioInput{}, false
+	}
+	input := assistencia.BeneficioInput{
+		Programa:       payload.Programa,
+		Descricao:      payload.Descricao,
+		Situacao:       payload.Situacao,
+		ValorMensal:    payload.ValorMensal,
+		NISTitular:     payload.NISTitular,
+		CodigoFamiliar: payload.CodigoFamiliar,
+		Referencia:     payload.Referencia,
+	}
+	inicio, err := optionalDate(&payload.Inicio)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "inicio inválido", nil)
+		return assistencia.BeneficioInput{}, false
+	}
+	if inicio != nil {
+		input.Inicio = *inicio
+	}
+	if input.Fim, err = optionalDate(payload.Fim); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fim inválido", nil)
+		return assistencia.BeneficioInput{}, false
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return assistencia.BeneficioInput{}, false
+	}
+	return input, true
+}
+
+func writeAssistenciaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, assistencia.ErrForbidden):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito aos profissionais da assistência social", nil)
+	case errors.Is(err, assistencia.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "não encontrado", nil)
+	case errors.Is(err, assistencia.ErrDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "família já cadastrada com este código familiar ou CPF", nil)
+	case errors.Is(err, assistencia.ErrUsuario):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "usuário não é profissional ativo da prefeitura", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a solicitação", nil)
+	}
+}
@@ -0,0 +1,296 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/ativo"
+)
+
+type ativoPayload struct {
+	SecretariaID *string        `json:"secretaria_id"`
+	Tipo         string         `json:"tipo"`
+	Codigo       string         `json:"codigo"`
+	Nome         string         `json:"nome"`
+	Descricao    *string        `json:"descricao"`
+	Endereco     *string        `json:"endereco"`
+	Bairro       *string        `json:"bairro"`
+	Latitude     *float64       `json:"latitude"`
+	Longitude    *float64       `json:"longitude"`
+	Atributos    map[string]any `json:"atributos"`
+	Status       string         `json:"status"`
+}
+
+// ListAtivos lista os ativos da prefeitura (?tipo=&status=&secretaria_id=&bairro=&q=&limit=).
+func (h *Handler) ListAtivos(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := ativo.Filter{
+		Tipo:   strings.TrimSpace(query.Get("tipo")),
+		Status: strings.TrimSpace(query.Get("status")),
+		Bairro: strings.TrimSpace(query.Get("bairro")),
+		Query:  query.Get("q"),
+	}
+	raw := query.Get("secretaria_id")
+	secretariaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	filter.SecretariaID = secretariaID
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	ativos, err := h.ativos.List(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar ativos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ativos": ativos})
+}
+
+// CreateAtivo cadastra um ativo; o token da etiqueta QR é gerado no cadastro.
+func (h *Handler) CreateAtivo(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeAtivo(w, r, userID)
+	if !ok {
+		return
+	}
+	created, err := h.ativos.Create(r.Context(), tenantID, input)
+	if err != nil {
+		writeAtivoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"ativo": created})
+}
+
+// GetAtivo detalha o ativo com o histórico de serviço.
+func (h *Handler) GetAtivo(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	a, err := h.ativos.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeAtivoError(w, err)
+		return
+	}
+	historico, err := h.ativos.Historico(r.Context(), a.ID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar o histórico", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ativo": a, "historico": historico})
+}
+
+// UpdateAtivo substitui os dados do ativo.
+func (h *Handler) UpdateAtivo(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeAtivo(w, r, userID)
+	if !ok {
+		return
+	}
+	updated, err := h.ativos.Update(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeAtivoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ativo": updated})
+}
+
+// AddAtivoHistorico registra uma manutenção ou nota no histórico do ativo.
+func (h *Handler) AddAtivoHistorico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Tipo        string   `json:"tipo"`
+		Descricao   string   `json:"descricao"`
+		ProtocoloID *string  `json:"protocolo_id"`
+		Custo       *float64 `json:"custo"`
+		RealizadoEm *string  `json:"realizado_em"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := ativo.HistoricoInput{Tipo: payload.Tipo, Descricao: payload.Descricao, Custo: payload.Custo, AtorID: &userID}
+	if input.ProtocoloID, err = optionalUUID(payload.ProtocoloID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "protocolo_id inválido", nil)
+		return
+	}
+	if payload.RealizadoEm != nil && strings.TrimSpace(*payload.RealizadoEm) != "" {
+		realizado, err := time.Parse("2006-01-02", strings.TrimSpace(*payload.RealizadoEm))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "realizado_em deve estar no formato AAAA-MM-DD", nil)
+			return
+		}
+		input.RealizadoEm = &realizado
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	entry, err := h.ativos.AddHistorico(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeAtivoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"registro": entry})
+}
+
+// AtivoEtiqueta devolve a etiqueta SVG do ativo com o QR code que abre a página pública dele.
+func (h *Handler) AtivoEtiqueta(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	a, err := h.ativos.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeAtivoError(w, err)
+		return
+	}
+	tenantInfo, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+	svg, err := ativo.Etiqueta(tenantInfo.DisplayName, ativo.PublicURL(tenantInfo.Domain, a.QRToken), a)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar a etiqueta", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(svg))
+}
+
+// PublicAtivo mostra o ativo da etiqueta escaneada, para o cidadão relatar um problema nele.
+func (h *Handler) PublicAtivo(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	a, err := h.ativos.GetByQRToken(r.Context(), tenantInfo.ID, chi.URLParam(r, "token"))
+	if err != nil {
+		writeAtivoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ativo": a.Public()})
+}
+
+// LinkProtocoloAtivo vincula o protocolo a um ativo ({"ativo_id": null} desfaz o vínculo).
+func (h *Handler) LinkProtocoloAtivo(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		AtivoID *string `json:"ativo_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	ativoID, err := optionalUUID(payload.AtivoID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "ativo_id inválido", nil)
+		return
+	}
+	p, err := h.protocolos.SetAtivo(r.Context(), tenantID, id, ativoID)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolo": p})
+}
+
+func decodeAtivo(w http.ResponseWriter, r *http.Request, actorID uuid.UUID) (ativo.Input, bool) {
+	var payload ativoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return ativo.Input{}, false
+	}
+	secretariaID, err := optionalUUID(payload.SecretariaID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return ativo.Input{}, false
+	}
+	input := ativo.Input{
+		SecretariaID: secretariaID,
+		Tipo:         payload.Tipo,
+		Codigo:       payload.Codigo,
+		Nome:         payload.Nome,
+		Descricao:    payload.Descricao,
+		Endereco:     payload.Endereco,
+		Bairro:       payload.Bairro,
+		Latitude:     payload.Latitude,
+		Longitude:    payload.Longitude,
+		Atributos:    payload.Atributos,
+		Status:       payload.Status,
+		ActorID:      &actorID,
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return ativo.Input{}, false
+	}
+	return input, true
+}
+
+func writeAtivoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ativo.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "ativo não encontrado", nil)
+	case errors.Is(err, ativo.ErrDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existe ativo com este código", nil)
+	case errors.Is(err, ativo.ErrSecretaria):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria não pertence à prefeitura", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar o ativo", nil)
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+// TenantAdminAuthPolicy devolve a política de autenticação da equipe da prefeitura.
+func (h *Handler) TenantAdminAuthPolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	policy, err := h.tenantAuthPolicy(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a política de acesso", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"politica": policy})
+}
+
+// TenantAdminUpdateAuthPolicy grava em settings.auth as exigências de MFA, SSO, sessão e senha da
+// equipe. Vale a partir do próximo login; sessões abertas seguem até expirar ou renovar.
+func (h *Handler) TenantAdminUpdateAuthPolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	var policy auth.Policy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if err := policy.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar a política de acesso", nil)
+		return
+	}
+	err = h.tenantSettingsTx(r.Context(), r, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+            UPDATE tenants
+            SET settings = jsonb_set(settings, '{auth}', $2::jsonb), updated_at = now()
+            WHERE id = $1`, tenantID, raw)
+		return err
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar a política de acesso", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"politica": policy})
+}
+
+func (h *Handler) tenantAuthPolicy(ctx context.Context, tenantID uuid.UUID) (auth.Policy, error) {
+	var raw []byte
+	if err := h.pool.QueryRow(ctx, `SELECT settings->'auth' FROM tenants WHERE id = $1`, tenantID).Scan(&raw); err != nil {
+		return auth.Policy{}, err
+	}
+	return auth.ParsePolicy(raw)
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/config"
+	"github.com/gestaozabele/municipio/internal/notify"
+)
+
+// TenantAdminAvisosFaltas devolve a adesão da prefeitura aos avisos de falta por SMS/WhatsApp.
+func (h *Handler) TenantAdminAvisosFaltas(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	cfg, err := h.tenantAvisosFaltas(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar os avisos de falta", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"config": cfg, "canais_disponiveis": h.canaisAvisos})
+}
+
+// TenantAdminUpdateAvisosFaltas grava em settings.notificacoes_faltas. Vale para as chamadas
+// salvas a partir de agora; avisos já enfileirados mantêm o canal com que entraram na fila.
+func (h *Handler) TenantAdminUpdateAvisosFaltas(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	var cfg notify.FaltasConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if err := cfg.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if cfg.Ativo && !h.canalAvisoDisponivel(cfg.Canal) {
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "canal sem provedor configurado no servidor", nil)
+		return
+	}
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar os avisos de falta", nil)
+		return
+	}
+	err = h.tenantSettingsTx(r.Context(), r, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+            UPDATE tenants
+            SET settings = jsonb_set(settings, '{notificacoes_faltas}', $2::jsonb), updated_at = now()
+            WHERE id = $1`, tenantID, raw)
+		return err
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar os avisos de falta", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"config": cfg, "canais_disponiveis": h.canaisAvisos})
+}
+
+// TenantAdminAvisosFaltasEnvios lista o histórico de entregas, filtrável por status.
+func (h *Handler) TenantAdminAvisosFaltasEnvios(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch status {
+	case "", notify.FaltaQueued, notify.FaltaSending, notify.FaltaSent, notify.FaltaFailed, notify.FaltaCancelled:
+	default:
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "status inválido", nil)
+		return
+	}
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	envios, err := notify.ListFaltaEnvios(r.Context(), h.conn(r.Context()), tenantID, status, limit)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar os avisos de falta", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"envios": envios})
+}
+
+func (h *Handler) tenantAvisosFaltas(ctx context.Context, tenantID uuid.UUID) (notify.FaltasConfig, error) {
+	var raw []byte
+	if err := h.pool.QueryRow(ctx, `SELECT settings->'notificacoes_faltas' FROM tenants WHERE id = $1`, tenantID).Scan(&raw); err != nil {
+		return notify.FaltasConfig{}, err
+	}
+	return notify.ParseFaltasConfig(raw)
+}
+
+func (h *Handler) canalAvisoDisponivel(canal string) bool {
+	for _, c := range h.canaisAvisos {
+		if c == canal {
+			return true
+		}
+	}
+	return false
+}
+
+// textSenders monta os provedores de SMS e WhatsApp configurados; canal sem provedor fica nil.
+func textSenders(cfg config.MensagensConfig) (notify.TextSender, notify.TextSender, error) {
+	var sms, whatsapp notify.TextSender
+	switch cfg.SMSProvider {
+	case "":
+	case "twilio":
+		if sms = notify.NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioSMSFrom, false); sms == nil {
+			return nil, nil, errors.New("mensagens: TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN e TWILIO_SMS_FROM são obrigatórios")
+		}
+	default:
+		return nil, nil, fmt.Errorf("mensagens: provedor de SMS %s não suportado", cfg.SMSProvider)
+	}
+	switch cfg.WhatsAppProvider {
+	case "":
+	case "twilio":
+		if whatsapp = notify.NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioWhatsAppFrom, true); whatsapp == nil {
+			return nil, nil, errors.New("mensagens: TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN e TWILIO_WHATSAPP_FROM são obrigatórios")
+		}
+	case "meta":
+		whatsapp = notify.NewWhatsAppCloudSender(cfg.WhatsAppAPIBase, cfg.WhatsAppToken, cfg.WhatsAppPhoneNumberID, cfg.WhatsAppTemplate, cfg.WhatsAppTemplateLang)
+		if whatsapp == nil {
+			return nil, nil, errors.New("mensagens: WHATSAPP_TOKEN e WHATSAPP_PHONE_NUMBER_ID são obrigatórios")
+		}
+	default:
+		return nil, nil, fmt.Errorf("mensagens: provedor de WhatsApp %s não suportado", cfg.WhatsAppProvider)
+	}
+	return sms, whatsapp, nil
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+)
+
+type backofficeRolePayload struct {
+	Codigo     string   `json:"codigo"`
+	Nome       string   `json:"nome"`
+	Descricao  *string  `json:"descricao"`
+	Permissoes []string `json:"permissoes"`
+}
+
+// TenantAdminPermissoes lista o catálogo de permissões disponíveis para montar papéis.
+func (h *Handler) TenantAdminPermissoes(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]any{"permissoes": authz.Catalog()})
+}
+
+// TenantAdminPapeis lista os papéis personalizados da prefeitura com os membros.
+func (h *Handler) TenantAdminPapeis(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	roles, err := h.roles.List(r.Context(), tenantID)
+	if err != nil {
+		writeBackofficeRoleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"papeis": roles})
+}
+
+// TenantAdminGetPapel detalha um papel personalizado.
+func (h *Handler) TenantAdminGetPapel(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.backofficeRoleScope(w, r)
+	if !ok {
+		return
+	}
+	role, err := h.roles.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeBackofficeRoleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"papel": role})
+}
+
+// TenantAdminCreatePapel cria um papel a partir de permissões do catálogo.
+func (h *Handler) TenantAdminCreatePapel(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeBackofficeRole(w, r)
+	if !ok {
+		return
+	}
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	role, err := h.roles.Create(r.Context(), tenantID, input, userID)
+	if err != nil {
+		writeBackofficeRoleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"papel": role})
+}
+
+// TenantAdminUpdatePapel altera o papel; os membros passam a ter as novas permissões na próxima
+// requisição, sem novo login.
+func (h *Handler) TenantAdminUpdatePapel(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.backofficeRoleScope(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeBackofficeRole(w, r)
+	if !ok {
+		return
+	}
+	role, err := h.roles.Update(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeBackofficeRoleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"papel": role})
+}
+
+// TenantAdminDeletePapel remove o papel e os vínculos dos membros.
+func (h *Handler) TenantAdminDeletePapel(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.backofficeRoleScope(w, r)
+	if !ok {
+		return
+	}
+	if err := h.roles.Delete(r.Context(), tenantID, id); err != nil {
+		writeBackofficeRoleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TenantAdminSetPapelMembros troca a lista de membros do papel. Quem ganha o primeiro papel
+// personalizado precisa entrar de novo para que o token passe a liberar o backoffice.
+func (h *Handler) TenantAdminSetPapelMembros(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.backofficeRoleScope(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Membros []uuid.UUID `json:"membros"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	role, err := h.roles.SetMembros(r.Context(), tenantID, id, payload.Membros)
+	if err != nil {
+		writeBackofficeRoleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"papel": role})
+}
+
+// SecretariaPermissoes devolve as permissões do usuário na prefeitura, para o painel esconder o
+// que ele não pode usar.
+func (h *Handler) SecretariaPermissoes(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	perms, err := h.roles.Permissions(r.Context(), tenantID, userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar permissões", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"tenant_id": tenantID, "permissoes": perms})
+}
+
+func (h *Handler) backofficeRoleScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, id, true
+}
+
+func decodeBackofficeRole(w http.ResponseWriter, r *http.Request) (authz.RoleInput, bool) {
+	var payload backofficeRolePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return authz.RoleInput{}, false
+	}
+	input := authz.RoleInput(payload)
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return authz.RoleInput{}, false
+	}
+	return input, true
+}
+
+func writeBackofficeRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, authz.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "papel não encontrado", nil)
+	case errors.Is(err, authz.ErrCodigoEmUso):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existe papel com este código", nil)
+	case errors.Is(err, authz.ErrMembroInvalido):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "todos os membros devem pertencer a uma secretaria da prefeitura", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar o papel", nil)
+	}
+}
@@ -0,0 +1,480 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/camara"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+const camaraDocumentoMaxBytes = 20 << 20
+
+// camaraDocumentoTypes são os formatos aceitos nos anexos públicos da câmara.
+var camaraDocumentoTypes = map[string]bool{
+	"application/pdf":                         true,
+	"application/vnd.oasis.opendocument.text": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+type camaraSessaoPayload struct {
+	Tipo           string     `json:"tipo"`
+	Numero         int        `json:"numero"`
+	Titulo         string     `json:"titulo"`
+	Inicio         *time.Time `json:"inicio"`
+	Local          string     `json:"local"`
+	Status         string     `json:"status"`
+	TransmissaoURL *string    `json:"transmissao_url"`
+}
+
+type camaraItemPayload struct {
+	Ordem      int     `json:"ordem"`
+	Tipo       string  `json:"tipo"`
+	Numero     *string `json:"numero"`
+	Ementa     string  `json:"ementa"`
+	Autor      *string `json:"autor"`
+	Resultado  *string `json:"resultado"`
+	VotosSim   *int    `json:"votos_sim"`
+	VotosNao   *int    `json:"votos_nao"`
+	Abstencoes *int    `json:"abstencoes"`
+}
+
+// ListPublicCamaraSessoes lista as sessões publicadas da câmara, com filtros por ano, tipo,
+// situação e busca na pauta.
+func (h *Handler) ListPublicCamaraSessoes(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := camaraFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.Publicadas = true
+	sessoes, err := h.camara.List(r.Context(), tenantInfo.ID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as sessões", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessoes": sessoes})
+}
+
+// GetPublicCamaraSessao mostra a sessão publicada com pauta, resultados, ata e documentos.
+func (h *Handler) GetPublicCamaraSessao(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	sessao, err := h.camara.Get(r.Context(), tenantInfo.ID, id, true)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessao": sessao})
+}
+
+// TenantAdminCamaraSessoes lista todas as sessões, inclusive as não publicadas.
+func (h *Handler) TenantAdminCamaraSessoes(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := camaraFilter(w, r)
+	if !ok {
+		return
+	}
+	sessoes, err := h.camara.List(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as sessões", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessoes": sessoes})
+}
+
+// TenantAdminGetCamaraSessao mostra a sessão com pauta e documentos.
+func (h *Handler) TenantAdminGetCamaraSessao(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	sessao, err := h.camara.Get(r.Context(), tenantID, id, false)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessao": sessao})
+}
+
+// TenantAdminCreateCamaraSessao cadastra a sessão; ela só aparece ao público depois de publicada.
+func (h *Handler) TenantAdminCreateCamaraSessao(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeCamaraSessao(w, r)
+	if !ok {
+		return
+	}
+	var createdBy *uuid.UUID
+	if userID, err := h.subjectUUID(r); err == nil {
+		createdBy = &userID
+	}
+	sessao, err := h.camara.Create(r.Context(), tenantID, input, createdBy)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"sessao": sessao})
+}
+
+// TenantAdminUpdateCamaraSessao altera a sessão, inclusive para marcar adiamento ou realização.
+func (h *Handler) TenantAdminUpdateCamaraSessao(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeCamaraSessao(w, r)
+	if !ok {
+		return
+	}
+	sessao, err := h.camara.Update(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessao": sessao})
+}
+
+// TenantAdminDeleteCamaraSessao exclui sessão ainda não publicada.
+func (h *Handler) TenantAdminDeleteCamaraSessao(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	if err := h.camara.Delete(r.Context(), tenantID, id); err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TenantAdminPublicarCamaraSessao torna a sessão visível na API pública.
+func (h *Handler) TenantAdminPublicarCamaraSessao(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	sessao, err := h.camara.Publicar(r.Context(), tenantID, id)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessao": sessao})
+}
+
+// TenantAdminCamaraAta registra o resumo e a aprovação da ata; o arquivo é enviado como documento.
+func (h *Handler) TenantAdminCamaraAta(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Resumo     *string `json:"resumo"`
+		AprovadaEm *string `json:"aprovada_em"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := camara.AtaInput{Resumo: payload.Resumo}
+	if payload.AprovadaEm != nil && strings.TrimSpace(*payload.AprovadaEm) != "" {
+		aprovada, err := parseISODate(*payload.AprovadaEm)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "aprovada_em deve estar no formato AAAA-MM-DD", nil)
+			return
+		}
+		input.AprovadaEm = &aprovada
+	}
+	sessao, err := h.camara.RegistrarAta(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"sessao": sessao})
+}
+
+// TenantAdminCreateCamaraItem inclui uma proposição na pauta.
+func (h *Handler) TenantAdminCreateCamaraItem(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeCamaraItem(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.camara.CreateItem(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"item": item})
+}
+
+// TenantAdminUpdateCamaraItem altera o item de pauta, inclusive o resultado da votação.
+func (h *Handler) TenantAdminUpdateCamaraItem(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	itemID, err := parseUUIDParam(r, "itemID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "item inválido", nil)
+		return
+	}
+	input, ok := decodeCamaraItem(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.camara.UpdateItem(r.Context(), tenantID, id, itemID, input)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"item": item})
+}
+
+// TenantAdminDeleteCamaraItem remove o item da pauta.
+func (h *Handler) TenantAdminDeleteCamaraItem(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	itemID, err := parseUUIDParam(r, "itemID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "item inválido", nil)
+		return
+	}
+	if err := h.camara.DeleteItem(r.Context(), tenantID, id, itemID); err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TenantAdminUploadCamaraDocumento anexa um arquivo à sessão (ou a um item da pauta). O arquivo vai
+// para o armazenamento público, já que pauta, projetos e atas são documentos de acesso livre.
+func (h *Handler) TenantAdminUploadCamaraDocumento(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(camaraDocumentoMaxBytes); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "formulário inválido", nil)
+		return
+	}
+	header, err := getFirstFile(r.MultipartForm, "file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	tipo, err := camara.NormalizeTipoDocumento(r.FormValue("tipo"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	input := camara.DocumentoInput{Tipo: tipo, Titulo: strings.TrimSpace(r.FormValue("titulo"))}
+	if input.Titulo == "" {
+		input.Titulo = strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	}
+	if raw := strings.TrimSpace(r.FormValue("item_id")); raw != "" {
+		itemID, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "item_id inválido", nil)
+			return
+		}
+		input.ItemID = &itemID
+	}
+	if userID, err := h.subjectUUID(r); err == nil {
+		input.UploadedBy = &userID
+	}
+
+	if h.storage == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
+		return
+	}
+	switch h.storage.(type) {
+	case storage.NoopUploader, *storage.NoopUploader:
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
+		return
+	}
+	if err := h.camara.ExisteSessao(r.Context(), tenantID, id, input.ItemID); err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+
+	data, contentType, err := readMultipartFile(header, camaraDocumentoMaxBytes)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if !camaraDocumentoTypes[contentType] {
+		WriteError(w, http.StatusUnsupportedMediaType, "VALIDATION", "documento deve ser PDF, ODT, DOCX, JPEG ou PNG", nil)
+		return
+	}
+	if h.scanner != nil {
+		if result, err := h.scanner.Scan(r.Context(), data); err != nil || result.Infected() {
+			log.Warn().Err(err).Str("sessao", id.String()).Str("signature", result.Signature).Msg("camara: documento recusado pelo antivírus")
+			WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "documento recusado pelo antivírus", nil)
+			return
+		}
+	}
+
+	input.ContentType = contentType
+	input.SizeBytes = int64(len(data))
+	input.FileKey = fmt.Sprintf("camara/%s/%s/%s-%s%s", tenantID, id, tipo, uuid.NewString(), strings.ToLower(filepath.Ext(header.Filename)))
+	result, err := h.storage.Upload(r.Context(), storage.UploadInput{
+		Key:          input.FileKey,
+		Body:         data,
+		ContentType:  contentType,
+		CacheControl: "public,max-age=31536000,immutable",
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível enviar o documento", nil)
+		return
+	}
+	input.URL = result.URL
+	documento, err := h.camara.AddDocumento(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"documento": documento})
+}
+
+// TenantAdminDeleteCamaraDocumento retira o documento da sessão.
+func (h *Handler) TenantAdminDeleteCamaraDocumento(w http.ResponseWriter, r *http.Request) {
+	tenantID, id, ok := h.camaraSessaoScope(w, r)
+	if !ok {
+		return
+	}
+	documentoID, err := parseUUIDParam(r, "documentoID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "documento inválido", nil)
+		return
+	}
+	if err := h.camara.DeleteDocumento(r.Context(), tenantID, id, documentoID); err != nil {
+		writeCamaraError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// camaraSessaoScope resolve o tenant administrado e o id da sessão da rota.
+func (h *Handler) camaraSessaoScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, id, true
+}
+
+func camaraFilter(w http.ResponseWriter, r *http.Request) (camara.Filter, bool) {
+	query := r.URL.Query()
+	filter := camara.Filter{
+		Tipo:   strings.ToLower(strings.TrimSpace(query.Get("tipo"))),
+		Status: strings.ToLower(strings.TrimSpace(query.Get("status"))),
+		Busca:  strings.TrimSpace(query.Get("q")),
+	}
+	if raw := strings.TrimSpace(query.Get("ano")); raw != "" {
+		ano, err := strconv.Atoi(raw)
+		if err != nil || ano < 1900 || ano > 2200 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "ano inválido", nil)
+			return camara.Filter{}, false
+		}
+		filter.Ano = ano
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > 200 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit deve estar entre 1 e 200", nil)
+			return camara.Filter{}, false
+		}
+		filter.Limit = limit
+	}
+	return filter, true
+}
+
+func decodeCamaraSessao(w http.ResponseWriter, r *http.Request) (camara.SessaoInput, bool) {
+	var payload camaraSessaoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return camara.SessaoInput{}, false
+	}
+	input := camara.SessaoInput{
+		Tipo:           payload.Tipo,
+		Numero:         payload.Numero,
+		Titulo:         payload.Titulo,
+		Local:          payload.Local,
+		Status:         payload.Status,
+		TransmissaoURL: payload.TransmissaoURL,
+	}
+	if payload.Inicio != nil {
+		input.Inicio = *payload.Inicio
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return camara.SessaoInput{}, false
+	}
+	return input, true
+}
+
+func decodeCamaraItem(w http.ResponseWriter, r *http.Request) (camara.ItemInput, bool) {
+	var payload camaraItemPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return camara.ItemInput{}, false
+	}
+	input := camara.ItemInput(payload)
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return camara.ItemInput{}, false
+	}
+	return input, true
+}
+
+func writeCamaraError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, camara.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "sessão não encontrada", nil)
+	case errors.Is(err, camara.ErrItemNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "item de pauta não encontrado", nil)
+	case errors.Is(err, camara.ErrDocumentoNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "documento não encontrado", nil)
+	case errors.Is(err, camara.ErrPublicada):
+		WriteError(w, http.StatusConflict, "CONFLICT", "sessão publicada não pode ser excluída; marque-a como cancelada", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a sessão", nil)
+	}
+}
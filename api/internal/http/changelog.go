@@ -0,0 +1,193 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/changelog"
+)
+
+type releaseNotePayload struct {
+	ModuleCode string `json:"module_code"`
+	Version    string `json:"version"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	Audience   string `json:"audience"`
+}
+
+// PublicChangelog devolve as novidades publicadas para o app do cidadão do tenant do domínio.
+func (h *Handler) PublicChangelog(w http.ResponseWriter, r *http.Request) {
+	h.writeChangelogFeed(w, r, changelog.AudienceCitizen)
+}
+
+// BackofficeChangelog devolve as novidades publicadas para a equipe da prefeitura.
+func (h *Handler) BackofficeChangelog(w http.ResponseWriter, r *http.Request) {
+	h.writeChangelogFeed(w, r, changelog.AudienceBackoffice)
+}
+
+func (h *Handler) writeChangelogFeed(w http.ResponseWriter, r *http.Request, audience string) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	feed := changelog.FeedQuery{
+		TenantID:   tenantInfo.ID,
+		Audience:   audience,
+		ModuleCode: query.Get("module"),
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+		feed.Limit = limit
+	}
+	if raw := strings.TrimSpace(query.Get("since")); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "since deve estar em RFC3339", nil)
+			return
+		}
+		feed.Since = &since
+	}
+
+	notes, err := h.changelog.Feed(r.Context(), feed)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar novidades", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"notes": notes})
+}
+
+// ListReleaseNotes lista notas de versão para a equipe SaaS, incluindo rascunhos.
+func (h *Handler) ListReleaseNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.changelog.List(r.Context(), changelog.Filter{
+		ModuleCode: r.URL.Query().Get("module"),
+		Status:     r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar notas de versão", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"notes": notes})
+}
+
+// CreateReleaseNote registra uma nota de versão como rascunho.
+func (h *Handler) CreateReleaseNote(w http.ResponseWriter, r *http.Request) {
+	input, ok := h.decodeReleaseNote(w, r)
+	if !ok {
+		return
+	}
+	note, err := h.changelog.Create(r.Context(), input)
+	if err != nil {
+		writeChangelogError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"note": note})
+}
+
+// UpdateReleaseNote altera uma nota de versão.
+func (h *Handler) UpdateReleaseNote(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := h.decodeReleaseNote(w, r)
+	if !ok {
+		return
+	}
+	note, err := h.changelog.Update(r.Context(), id, input)
+	if err != nil {
+		writeChangelogError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"note": note})
+}
+
+// PublishReleaseNote publica a nota nos feeds.
+func (h *Handler) PublishReleaseNote(w http.ResponseWriter, r *http.Request) {
+	h.setReleaseNotePublished(w, r, true)
+}
+
+// UnpublishReleaseNote retira a nota dos feeds.
+func (h *Handler) UnpublishReleaseNote(w http.ResponseWriter, r *http.Request) {
+	h.setReleaseNotePublished(w, r, false)
+}
+
+func (h *Handler) setReleaseNotePublished(w http.ResponseWriter, r *http.Request, published bool) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	note, err := h.changelog.SetPublished(r.Context(), id, published, &actorID)
+	if err != nil {
+		writeChangelogError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"note": note})
+}
+
+// DeleteReleaseNote remove uma nota de versão.
+func (h *Handler) DeleteReleaseNote(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if err := h.changelog.Delete(r.Context(), id); err != nil {
+		writeChangelogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) decodeReleaseNote(w http.ResponseWriter, r *http.Request) (changelog.Input, bool) {
+	var payload releaseNotePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return changelog.Input{}, false
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return changelog.Input{}, false
+	}
+	input := changelog.Input{
+		ModuleCode: payload.ModuleCode,
+		Version:    payload.Version,
+		Title:      payload.Title,
+		Body:       payload.Body,
+		Audience:   payload.Audience,
+		ActorID:    &actorID,
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return changelog.Input{}, false
+	}
+	return input, true
+}
+
+func writeChangelogError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, changelog.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "nota de versão não encontrada", nil)
+	case errors.Is(err, changelog.ErrDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "versão já registrada para o módulo", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar nota de versão", nil)
+	}
+}
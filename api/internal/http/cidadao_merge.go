@@ -0,0 +1,229 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// cidadaoMergeStep reescreve um vínculo do cidadão absorvido ($2) para o sobrevivente ($1).
+// count devolve quantas linhas seriam afetadas e é usado na prévia.
+type cidadaoMergeStep struct {
+	name  string
+	count string
+	apply string
+}
+
+// cidadaoMergeSteps cobre tudo que hoje referencia cidadaos; novos módulos com vínculo ao
+// cidadão (dispositivos, por exemplo) devem acrescentar o seu passo aqui.
+var cidadaoMergeSteps = []cidadaoMergeStep{
+	{
+		name: "credenciais",
+		count: `SELECT (COALESCE(s.nome, '') = '' AND COALESCE(d.nome, '') <> '')::int
+                     + (s.email IS NULL AND d.email IS NOT NULL)::int
+                     + (s.senha_hash IS NULL AND d.senha_hash IS NOT NULL)::int
+                FROM cidadaos s, cidadaos d WHERE s.id = $1 AND d.id = $2`,
+		// O e-mail é único: sai do absorvido antes de ir para o sobrevivente.
+		apply: `WITH d AS (
+                    SELECT id, nome, email, senha_hash FROM cidadaos WHERE id = $2
+                ), cleared AS (
+                    UPDATE cidadaos SET email = NULL WHERE id = $2 RETURNING id
+                )
+                UPDATE cidadaos s
+                SET nome = CASE WHEN COALESCE(s.nome, '') = '' THEN d.nome ELSE s.nome END,
+                    email = COALESCE(s.email, d.email),
+                    senha_hash = COALESCE(s.senha_hash, d.senha_hash)
+                FROM d, cleared
+                WHERE s.id = $1`,
+	},
+	{
+		name:  "sessoes_revogadas",
+		count: `SELECT count(*) FROM tokens_refresh WHERE subject = $2 AND audience = 'cidadao' AND NOT revogado AND $1::uuid IS NOT NULL`,
+		apply: `UPDATE tokens_refresh SET revogado = TRUE WHERE subject = $2 AND audience = 'cidadao' AND NOT revogado AND $1::uuid IS NOT NULL`,
+	},
+	{
+		name: "preferencias_notificacao",
+		count: `SELECT count(*) FROM notification_preferences d
+                WHERE d.audience = 'cidadao' AND d.user_id = $2
+                  AND NOT EXISTS (SELECT 1 FROM notification_preferences s WHERE s.audience = 'cidadao' AND s.user_id = $1)`,
+		// Preferências do sobrevivente prevalecem; as do absorvido só migram se ele não tiver nenhuma.
+		apply: `WITH moved AS (
+                    UPDATE notification_preferences d SET user_id = $1
+                    WHERE d.audience = 'cidadao' AND d.user_id = $2
+                      AND NOT EXISTS (SELECT 1 FROM notification_preferences s WHERE s.audience = 'cidadao' AND s.user_id = $1)
+                    RETURNING 1
+                )
+                DELETE FROM notification_preferences WHERE audience = 'cidadao' AND user_id = $2 AND NOT EXISTS (SELECT 1 FROM moved)`,
+	},
+	{
+		name:  "protocolos",
+		count: `SELECT count(*) FROM protocolos WHERE cidadao_id = $2 AND $1::uuid IS NOT NULL`,
+		apply: `UPDATE protocolos SET cidadao_id = $1 WHERE cidadao_id = $2`,
+	},
+	{
+		name: "doses_vacina",
+		count: `SELECT count(*) FROM saude_doses d
+                WHERE d.cidadao_id = $2
+                  AND NOT EXISTS (SELECT 1 FROM saude_doses s WHERE s.cidadao_id = $1 AND s.campanha_id = d.campanha_id AND s.dose = d.dose)`,
+		// Dose que o sobrevivente já tem na mesma campanha fica com o cadastro absorvido, como histórico.
+		apply: `UPDATE saude_doses d SET cidadao_id = $1
+                WHERE d.cidadao_id = $2
+                  AND NOT EXISTS (SELECT 1 FROM saude_doses s WHERE s.cidadao_id = $1 AND s.campanha_id = d.campanha_id AND s.dose = d.dose)`,
+	},
+	{
+		name:  "guias_tributos",
+		count: `SELECT count(*) FROM tributos_guias WHERE cidadao_id = $2 AND $1::uuid IS NOT NULL`,
+		apply: `UPDATE tributos_guias SET cidadao_id = $1 WHERE cidadao_id = $2`,
+	},
+}
+
+type cidadaoMergeResult struct {
+	DryRun     bool           `json:"dry_run"`
+	SurvivorID uuid.UUID      `json:"survivor_id"`
+	MergedID   uuid.UUID      `json:"merged_id"`
+	Affected   map[string]int `json:"affected"`
+	MergeID    *uuid.UUID     `json:"merge_id,omitempty"`
+}
+
+var (
+	errMergeSameCidadao = errors.New("informe dois cadastros diferentes")
+	errMergeNotFound    = errors.New("cidadão não encontrado")
+	errMergeAlreadyDone = errors.New("cadastro já unificado a outro")
+)
+
+// MergeCidadaos unifica dois cadastros de cidadão mantendo o sobrevivente. Com dry_run
+// (padrão) só devolve a prévia das linhas afetadas, sem alterar nada.
+func (h *Handler) MergeCidadaos(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		SurvivorID string `json:"survivor_id"`
+		MergedID   string `json:"merged_id"`
+		DryRun     *bool  `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	survivorID, err := uuid.Parse(strings.TrimSpace(payload.SurvivorID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "survivor_id inválido", nil)
+		return
+	}
+	mergedID, err := uuid.Parse(strings.TrimSpace(payload.MergedID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "merged_id inválido", nil)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	dryRun := payload.DryRun == nil || *payload.DryRun
+
+	result, err := h.mergeCidadaos(r.Context(), survivorID, mergedID, actorID, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, errMergeSameCidadao):
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		case errors.Is(err, errMergeNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+		case errors.Is(err, errMergeAlreadyDone):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+		default:
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível unificar cadastros", nil)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun {
+		status = http.StatusCreated
+	}
+	WriteJSON(w, status, result)
+}
+
+func (h *Handler) mergeCidadaos(ctx context.Context, survivorID, mergedID, actorID uuid.UUID, dryRun bool) (*cidadaoMergeResult, error) {
+	if survivorID == mergedID {
+		return nil, errMergeSameCidadao
+	}
+
+	tx, err := h.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Trava os dois cadastros em ordem fixa para que unificações cruzadas não entrem em deadlock.
+	rows, err := tx.Query(ctx, `SELECT id, merged_into IS NOT NULL FROM cidadaos WHERE id = ANY($1) ORDER BY id FOR UPDATE`, []uuid.UUID{survivorID, mergedID})
+	if err != nil {
+		return nil, err
+	}
+	found := 0
+	alreadyMerged := false
+	for rows.Next() {
+		var id uuid.UUID
+		var merged bool
+		if err := rows.Scan(&id, &merged); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found++
+		alreadyMerged = alreadyMerged || merged
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if found != 2 {
+		return nil, errMergeNotFound
+	}
+	if alreadyMerged {
+		return nil, errMergeAlreadyDone
+	}
+
+	result := &cidadaoMergeResult{DryRun: dryRun, SurvivorID: survivorID, MergedID: mergedID, Affected: map[string]int{}}
+	for _, step := range cidadaoMergeSteps {
+		var count int
+		if err := tx.QueryRow(ctx, step.count, survivorID, mergedID).Scan(&count); err != nil {
+			return nil, err
+		}
+		result.Affected[step.name] = count
+		if dryRun || count == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, step.apply, survivorID, mergedID); err != nil {
+			return nil, err
+		}
+	}
+	result.Affected["cadastro_desativado"] = 1
+	if dryRun {
+		return result, nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+        UPDATE cidadaos SET ativo = FALSE, merged_into = $1, merged_at = now() WHERE id = $2
+    `, survivorID, mergedID); err != nil {
+		return nil, err
+	}
+
+	affected, err := json.Marshal(result.Affected)
+	if err != nil {
+		return nil, err
+	}
+	var mergeID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO cidadao_merges (survivor_id, merged_id, actor_id, affected)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, survivorID, mergedID, actorID, affected).Scan(&mergeID); err != nil {
+		return nil, err
+	}
+	result.MergeID = &mergeID
+
+	return result, tx.Commit(ctx)
+}
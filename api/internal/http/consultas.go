@@ -0,0 +1,462 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/consulta"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+type consultaPayload struct {
+	Titulo             string     `json:"titulo"`
+	Descricao          *string    `json:"descricao"`
+	Inicio             *time.Time `json:"inicio"`
+	Fim                *time.Time `json:"fim"`
+	MaxEscolhas        int        `json:"max_escolhas"`
+	LimitePorOrigem    *int       `json:"limite_por_origem"`
+	ResultadosParciais bool       `json:"resultados_parciais"`
+}
+
+type propostaPayload struct {
+	Titulo        string   `json:"titulo"`
+	Descricao     *string  `json:"descricao"`
+	Bairro        *string  `json:"bairro"`
+	ValorEstimado *float64 `json:"valor_estimado"`
+	Ordem         int      `json:"ordem"`
+}
+
+// ListPublicConsultas lista as consultas publicadas da prefeitura do domínio.
+func (h *Handler) ListPublicConsultas(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	consultas, err := h.consultas.List(r.Context(), tenantInfo.ID, true)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as consultas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"consultas": consultas})
+}
+
+// GetPublicConsulta mostra a consulta publicada com as propostas.
+func (h *Handler) GetPublicConsulta(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	c, err := h.consultas.Get(r.Context(), tenantInfo.ID, id, true)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"consulta": c})
+}
+
+// ConsultaResultado divulga a apuração agregada: após o encerramento ou, se liberado, parcial.
+func (h *Handler) ConsultaResultado(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	resultado, err := h.consultas.Resultado(r.Context(), tenantInfo.ID, id, false)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"resultado": resultado})
+}
+
+// VotarConsulta registra a cédula do cidadão; o CPF informado identifica o eleitor.
+func (h *Handler) VotarConsulta(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		CPF       string   `json:"cpf"`
+		Propostas []string `json:"propostas"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	propostas := make([]uuid.UUID, 0, len(payload.Propostas))
+	for _, raw := range payload.Propostas {
+		propostaID, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "proposta inválida", nil)
+			return
+		}
+		propostas = append(propostas, propostaID)
+	}
+	comprovante, err := h.consultas.Votar(r.Context(), tenantInfo.ID, id, consulta.VotoInput{
+		CidadaoID: cidadaoID,
+		CPF:       payload.CPF,
+		Propostas: propostas,
+		IP:        httpmiddleware.ClientIP(r),
+	})
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"comprovante": comprovante})
+}
+
+// MinhaParticipacaoConsulta informa se o cidadão já votou, sem revelar as escolhas.
+func (h *Handler) MinhaParticipacaoConsulta(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	participacao, err := h.consultas.Participacao(r.Context(), tenantInfo.ID, id, cidadaoID)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"participacao": participacao})
+}
+
+// TenantAdminConsultas lista todas as consultas da prefeitura, inclusive rascunhos.
+func (h *Handler) TenantAdminConsultas(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	consultas, err := h.consultas.List(r.Context(), tenantID, false)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as consultas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"consultas": consultas})
+}
+
+// TenantAdminGetConsulta mostra a consulta com as propostas.
+func (h *Handler) TenantAdminGetConsulta(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	c, err := h.consultas.Get(r.Context(), tenantID, id, false)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"consulta": c})
+}
+
+// TenantAdminCreateConsulta cadastra a consulta em rascunho.
+func (h *Handler) TenantAdminCreateConsulta(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeConsulta(w, r)
+	if !ok {
+		return
+	}
+	var createdBy *uuid.UUID
+	if userID, err := h.subjectUUID(r); err == nil {
+		createdBy = &userID
+	}
+	c, err := h.consultas.Create(r.Context(), tenantID, input, createdBy)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"consulta": c})
+}
+
+// TenantAdminUpdateConsulta altera a consulta ainda em rascunho.
+func (h *Handler) TenantAdminUpdateConsulta(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeConsulta(w, r)
+	if !ok {
+		return
+	}
+	c, err := h.consultas.Update(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"consulta": c})
+}
+
+// TenantAdminPublicarConsulta publica a consulta; a votação abre no início definido.
+func (h *Handler) TenantAdminPublicarConsulta(w http.ResponseWriter, r *http.Request) {
+	h.transicaoConsulta(w, r, h.consultas.Publicar)
+}
+
+// TenantAdminCancelarConsulta cancela a consulta sem divulgar resultado.
+func (h *Handler) TenantAdminCancelarConsulta(w http.ResponseWriter, r *http.Request) {
+	h.transicaoConsulta(w, r, h.consultas.Cancelar)
+}
+
+func (h *Handler) transicaoConsulta(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, tenantID, id uuid.UUID) (*consulta.Consulta, error)) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	c, err := fn(r.Context(), tenantID, id)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"consulta": c})
+}
+
+// TenantAdminCreateProposta inclui proposta na consulta em rascunho.
+func (h *Handler) TenantAdminCreateProposta(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeProposta(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.consultas.CreateProposta(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"proposta": p})
+}
+
+// TenantAdminUpdateProposta altera proposta de consulta em rascunho.
+func (h *Handler) TenantAdminUpdateProposta(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	propostaID, err := parseUUIDParam(r, "propostaID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "proposta inválida", nil)
+		return
+	}
+	input, ok := decodeProposta(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.consultas.UpdateProposta(r.Context(), tenantID, id, propostaID, input)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"proposta": p})
+}
+
+// TenantAdminDeleteProposta remove proposta de consulta em rascunho.
+func (h *Handler) TenantAdminDeleteProposta(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	propostaID, err := parseUUIDParam(r, "propostaID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "proposta inválida", nil)
+		return
+	}
+	if err := h.consultas.DeleteProposta(r.Context(), tenantID, id, propostaID); err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TenantAdminConsultaResultado apura a qualquer momento, com a distribuição por origem de rede.
+func (h *Handler) TenantAdminConsultaResultado(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	resultado, err := h.consultas.Resultado(r.Context(), tenantID, id, true)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"resultado": resultado})
+}
+
+// TenantAdminExportCedulas exporta em CSV as cédulas anônimas para auditoria da apuração: uma linha
+// por proposta escolhida, agrupadas pelo identificador aleatório da cédula.
+func (h *Handler) TenantAdminExportCedulas(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	c, err := h.consultas.Get(r.Context(), tenantID, id, false)
+	if err != nil {
+		writeConsultaError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("cedulas-%s.csv", c.ID.String()[:8])
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"cedula", "dia", "proposta_id", "proposta"})
+	err = h.consultas.ExportCedulas(r.Context(), tenantID, id, func(v consulta.CedulaAnonima) error {
+		return cw.Write([]string{v.Cedula.String(), v.Dia.Format("2006-01-02"), v.PropostaID.String(), v.Proposta})
+	})
+	cw.Flush()
+	if err != nil {
+		// O cabeçalho já saiu; a linha final sinaliza o arquivo incompleto a quem confere.
+		_, _ = fmt.Fprintln(w, "# exportação interrompida")
+	}
+}
+
+func decodeConsulta(w http.ResponseWriter, r *http.Request) (consulta.ConsultaInput, bool) {
+	var payload consultaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return consulta.ConsultaInput{}, false
+	}
+	input := consulta.ConsultaInput{
+		Titulo:             payload.Titulo,
+		Descricao:          payload.Descricao,
+		MaxEscolhas:        payload.MaxEscolhas,
+		LimitePorOrigem:    payload.LimitePorOrigem,
+		ResultadosParciais: payload.ResultadosParciais,
+	}
+	if payload.Inicio != nil {
+		input.Inicio = *payload.Inicio
+	}
+	if payload.Fim != nil {
+		input.Fim = *payload.Fim
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return consulta.ConsultaInput{}, false
+	}
+	return input, true
+}
+
+func decodeProposta(w http.ResponseWriter, r *http.Request) (consulta.PropostaInput, bool) {
+	var payload propostaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return consulta.PropostaInput{}, false
+	}
+	input := consulta.PropostaInput{
+		Titulo:        payload.Titulo,
+		Descricao:     payload.Descricao,
+		Bairro:        payload.Bairro,
+		ValorEstimado: payload.ValorEstimado,
+		Ordem:         payload.Ordem,
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return consulta.PropostaInput{}, false
+	}
+	return input, true
+}
+
+func writeConsultaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, consulta.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "consulta não encontrada", nil)
+	case errors.Is(err, consulta.ErrPropostaNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "proposta não encontrada", nil)
+	case errors.Is(err, consulta.ErrFechada):
+		WriteError(w, http.StatusConflict, "CONFLICT", "a votação não está aberta", nil)
+	case errors.Is(err, consulta.ErrJaVotou):
+		WriteError(w, http.StatusConflict, "CONFLICT", "este CPF ou esta conta já votou nesta consulta", nil)
+	case errors.Is(err, consulta.ErrCPF):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "CPF inválido", nil)
+	case errors.Is(err, consulta.ErrEscolhas):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "escolhas inválidas para esta consulta", nil)
+	case errors.Is(err, consulta.ErrLimiteOrigem):
+		WriteError(w, http.StatusTooManyRequests, "RATE_LIMIT", "limite de votos a partir desta rede atingido", nil)
+	case errors.Is(err, consulta.ErrEditavel):
+		WriteError(w, http.StatusConflict, "CONFLICT", "só consultas em rascunho podem ser alteradas", nil)
+	case errors.Is(err, consulta.ErrSemPropostas):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "cadastre ao menos uma proposta antes de publicar", nil)
+	case errors.Is(err, consulta.ErrResultado):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "o resultado será divulgado após o encerramento", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a consulta", nil)
+	}
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+)
+
+type delegacaoPayload struct {
+	DelegadoID uuid.UUID `json:"delegado_id"`
+	Permissoes []string  `json:"permissoes"`
+	Inicio     string    `json:"inicio"`
+	Fim        string    `json:"fim"`
+	Motivo     *string   `json:"motivo"`
+}
+
+// ListDelegacoes lista as delegações dadas e recebidas pelo usuário na prefeitura.
+func (h *Handler) ListDelegacoes(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	delegacoes, err := h.roles.ListDelegacoes(r.Context(), tenantID, userID)
+	if err != nil {
+		writeDelegacaoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"delegacoes": delegacoes})
+}
+
+// CreateDelegacao delega parte das permissões do secretário a outro usuário da prefeitura por um
+// período. O delegado passa a agir em nome do delegante nas rotas cobertas pelas permissões.
+func (h *Handler) CreateDelegacao(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	var payload delegacaoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := authz.DelegacaoInput{DelegadoID: payload.DelegadoID, Permissoes: payload.Permissoes, Motivo: payload.Motivo}
+	var err error
+	if input.Inicio, err = parseISODate(payload.Inicio); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "início inválido", nil)
+		return
+	}
+	if input.Fim, err = parseISODate(payload.Fim); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fim inválido", nil)
+		return
+	}
+	if err := input.Normalize(time.Now()); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	delegacao, err := h.roles.CreateDelegacao(r.Context(), tenantID, userID, input)
+	if err != nil {
+		writeDelegacaoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"delegacao": delegacao})
+}
+
+// GetDelegacao detalha uma delegação dada ou recebida.
+func (h *Handler) GetDelegacao(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, id, ok := h.delegacaoScope(w, r)
+	if !ok {
+		return
+	}
+	delegacao, err := h.roles.GetDelegacao(r.Context(), tenantID, id, userID)
+	if err != nil {
+		writeDelegacaoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"delegacao": delegacao})
+}
+
+// RevogarDelegacao encerra a delegação antes do fim previsto.
+func (h *Handler) RevogarDelegacao(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, id, ok := h.delegacaoScope(w, r)
+	if !ok {
+		return
+	}
+	delegacao, err := h.roles.RevogarDelegacao(r.Context(), tenantID, id, userID)
+	if err != nil {
+		writeDelegacaoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"delegacao": delegacao})
+}
+
+// ListDelegacaoAcoes mostra as escritas feitas sob a delegação, para a conferência do delegante.
+func (h *Handler) ListDelegacaoAcoes(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, id, ok := h.delegacaoScope(w, r)
+	if !ok {
+		return
+	}
+	acoes, err := h.roles.ListDelegacaoAcoes(r.Context(), tenantID, id, userID)
+	if err != nil {
+		writeDelegacaoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"acoes": acoes})
+}
+
+func (h *Handler) delegacaoScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, userID, id, true
+}
+
+func writeDelegacaoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, authz.ErrDelegacaoNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "delegação não encontrada", nil)
+	case errors.Is(err, authz.ErrDelegacaoEncerrada):
+		WriteError(w, http.StatusConflict, "CONFLICT", "delegação já encerrada", nil)
+	case errors.Is(err, authz.ErrDeleganteInvalido):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "só secretário ou prefeito pode delegar", nil)
+	case errors.Is(err, authz.ErrMembroInvalido):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "o delegado deve ser outro usuário da prefeitura", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a delegação", nil)
+	}
+}
@@ -0,0 +1,254 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/documento"
+)
+
+type documentoPayload struct {
+	SecretariaID          string  `json:"secretaria_id"`
+	Tipo                  string  `json:"tipo"`
+	Titulo                string  `json:"titulo"`
+	DestinatarioNome      string  `json:"destinatario_nome"`
+	DestinatarioDocumento *string `json:"destinatario_documento"`
+	Conteudo              string  `json:"conteudo"`
+	ValidadeDias          *int    `json:"validade_dias"`
+	AlunoID               *string `json:"aluno_id"`
+}
+
+// ListDocumentos lista os documentos emitidos pela prefeitura.
+func (h *Handler) ListDocumentos(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := documento.Filter{Tipo: strings.TrimSpace(query.Get("tipo")), Query: query.Get("q")}
+	if raw := strings.TrimSpace(query.Get("aluno_id")); raw != "" {
+		alunoID, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "aluno_id inválido", nil)
+			return
+		}
+		filter.AlunoID = &alunoID
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	docs, err := h.documentos.List(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar documentos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"documentos": docs})
+}
+
+// EmitirDocumento assina e registra o documento pedido pela secretaria.
+func (h *Handler) EmitirDocumento(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	var payload documentoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	secretariaID, err := uuid.Parse(strings.TrimSpace(payload.SecretariaID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	alunoID, err := optionalUUID(payload.AlunoID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "aluno_id inválido", nil)
+		return
+	}
+	in, err := documento.EmissaoInput{
+		SecretariaID:          secretariaID,
+		Tipo:                  payload.Tipo,
+		Titulo:                payload.Titulo,
+		DestinatarioNome:      payload.DestinatarioNome,
+		DestinatarioDocumento: payload.DestinatarioDocumento,
+		Conteudo:              payload.Conteudo,
+		ValidadeDias:          payload.ValidadeDias,
+		AlunoID:               alunoID,
+	}.Normalize()
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	doc, err := h.documentos.Emitir(r.Context(), tenantID, userID, in, h.docSigner)
+	if err != nil {
+		writeDocumentoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"documento": doc})
+}
+
+// GetDocumento mostra o documento emitido com a conferência da assinatura.
+func (h *Handler) GetDocumento(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	doc, err := h.documentos.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeDocumentoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"documento":          doc,
+		"status":             doc.Status(time.Now()),
+		"assinatura_confere": h.docSigner.Verify(doc),
+	})
+}
+
+// DocumentoPDF redesenha o PDF do documento a partir do registro assinado.
+func (h *Handler) DocumentoPDF(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	doc, err := h.documentos.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeDocumentoError(w, err)
+		return
+	}
+	tenantInfo, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+	content, err := documento.RenderPDF(doc, tenantInfo.DisplayName, documento.PublicURL(tenantInfo.Domain, doc.Codigo))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar o PDF", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+doc.Tipo+`-`+doc.Codigo+`.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+// RevogarDocumento invalida o documento informando o motivo, exibido na verificação pública.
+func (h *Handler) RevogarDocumento(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Motivo string `json:"motivo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || strings.TrimSpace(payload.Motivo) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe o motivo da revogação", nil)
+		return
+	}
+	doc, err := h.documentos.Revogar(r.Context(), tenantID, id, userID, strings.TrimSpace(payload.Motivo))
+	if err != nil {
+		writeDocumentoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"documento": doc})
+}
+
+// VerifyDocumento confirma publicamente a autenticidade do documento pelo código impresso.
+func (h *Handler) VerifyDocumento(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	codigo := documento.NormalizeCodigo(chi.URLParam(r, "code"))
+	if codigo == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "código de verificação inválido", nil)
+		return
+	}
+	doc, err := h.documentos.GetByCodigo(r.Context(), tenantInfo.ID, codigo)
+	if errors.Is(err, documento.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "nenhum documento emitido com este código", nil)
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível verificar o documento", nil)
+		return
+	}
+
+	// Registro alterado fora do fluxo de emissão não confere com a assinatura e não é exibido.
+	if !h.docSigner.Verify(doc) {
+		WriteJSON(w, http.StatusOK, map[string]any{"autentico": false, "codigo": doc.Codigo})
+		return
+	}
+	resp := map[string]any{
+		"autentico":         true,
+		"codigo":            doc.Codigo,
+		"status":            doc.Status(time.Now()),
+		"tipo":              doc.Tipo,
+		"titulo":            doc.Titulo,
+		"prefeitura":        tenantInfo.DisplayName,
+		"secretaria":        doc.Secretaria,
+		"destinatario_nome": doc.DestinatarioNome,
+		"emitido_em":        doc.EmitidoEm,
+		"valido_ate":        doc.ValidoAte,
+		"conteudo":          doc.Conteudo,
+		"hash":              doc.Hash,
+		"assinatura":        doc.Assinatura,
+		"chave_publica":     h.docSigner.PublicKey(),
+	}
+	if doc.DestinatarioDocumento != nil {
+		resp["destinatario_documento"] = documento.MaskCPF(*doc.DestinatarioDocumento)
+	}
+	if doc.RevogadoEm != nil {
+		resp["revogado_em"] = doc.RevogadoEm
+		resp["motivo_revogacao"] = doc.MotivoRevogacao
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+func writeDocumentoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, documento.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "documento não encontrado", nil)
+	case errors.Is(err, documento.ErrSecretaria):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error(), nil)
+	case errors.Is(err, documento.ErrAluno):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", err.Error(), nil)
+	case errors.Is(err, documento.ErrRevogado):
+		WriteError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar o documento", nil)
+	}
+}
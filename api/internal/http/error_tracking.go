@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/errtrack"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// errorTracker envia os panics recuperados ao Sentry com tenant, rota e usuário.
+type errorTracker struct {
+	client *errtrack.Client
+}
+
+// ReportPanic implementa httpmiddleware.PanicReporter.
+func (t errorTracker) ReportPanic(_ context.Context, report httpmiddleware.PanicReport) {
+	if t.client == nil {
+		return
+	}
+	tags := map[string]string{
+		"route":    report.Route,
+		"tenant":   requestHost(report.Request),
+		"audience": report.Audience,
+	}
+	if report.RequestID != "" {
+		tags["request_id"] = report.RequestID
+	}
+	t.client.Capture(errtrack.Event{
+		Level:     errtrack.LevelFatal,
+		Message:   fmt.Sprint(report.Value),
+		ErrorType: "panic",
+		Frames:    errtrack.Callers(1),
+		Tags:      tags,
+		UserID:    report.Subject,
+		Request:   trackedRequest(report.Request),
+	})
+}
+
+func trackedRequest(r *http.Request) *errtrack.Request {
+	if r == nil {
+		return nil
+	}
+	headers := make(map[string]string, len(r.Header))
+	for key := range r.Header {
+		headers[key] = r.Header.Get(key)
+	}
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") == "http" {
+		scheme = "http"
+	}
+	return &errtrack.Request{
+		Method:  r.Method,
+		URL:     scheme + "://" + r.Host + r.URL.RequestURI(),
+		Headers: headers,
+	}
+}
+
+// requestHost devolve o domínio do tenant da requisição, sem porta.
+func requestHost(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if domain := strings.TrimSpace(r.URL.Query().Get("domain")); domain != "" {
+		return strings.ToLower(domain)
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
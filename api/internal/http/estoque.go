@@ -0,0 +1,291 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/estoque"
+)
+
+type estoqueItemPayload struct {
+	SecretariaID  string  `json:"secretaria_id"`
+	Nome          string  `json:"nome"`
+	Unidade       string  `json:"unidade"`
+	Categoria     *string `json:"categoria"`
+	EstoqueMinimo float64 `json:"estoque_minimo"`
+	Ativo         *bool   `json:"ativo"`
+}
+
+// ListEstoqueItens lista os itens do almoxarifado (?secretaria_id=&categoria=&q=&baixo=&inativos=).
+func (h *Handler) ListEstoqueItens(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := estoque.Filter{
+		Categoria: strings.TrimSpace(query.Get("categoria")),
+		Query:     query.Get("q"),
+	}
+	filter.SomenteBaixo, _ = strconv.ParseBool(query.Get("baixo"))
+	filter.Inativos, _ = strconv.ParseBool(query.Get("inativos"))
+	raw := query.Get("secretaria_id")
+	secretariaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	filter.SecretariaID = secretariaID
+	itens, err := h.estoque.ListItens(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar o estoque", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"itens": itens})
+}
+
+// EstoqueAlertas lista os itens ativos abaixo do estoque mínimo.
+func (h *Handler) EstoqueAlertas(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	itens, err := h.estoque.ListItens(r.Context(), tenantID, estoque.Filter{SomenteBaixo: true})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar o estoque", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"itens": itens})
+}
+
+// CreateEstoqueItem cadastra um item com saldo zero; o saldo inicial entra por movimento.
+func (h *Handler) CreateEstoqueItem(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeEstoqueItem(w, r, true)
+	if !ok {
+		return
+	}
+	created, err := h.estoque.CreateItem(r.Context(), tenantID, input)
+	if err != nil {
+		writeEstoqueError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"item": created})
+}
+
+// GetEstoqueItem detalha o item com os movimentos mais recentes (?limit=).
+func (h *Handler) GetEstoqueItem(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	item, err := h.estoque.GetItem(r.Context(), tenantID, id)
+	if err != nil {
+		writeEstoqueError(w, err)
+		return
+	}
+	movimentos, err := h.estoque.ListMovimentos(r.Context(), tenantID, id, limit)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar os movimentos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"item": item, "movimentos": movimentos})
+}
+
+// UpdateEstoqueItem altera nome, unidade, categoria, mínimo e situação do item.
+func (h *Handler) UpdateEstoqueItem(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeEstoqueItem(w, r, false)
+	if !ok {
+		return
+	}
+	updated, err := h.estoque.UpdateItem(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeEstoqueError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"item": updated})
+}
+
+// CreateEstoqueMovimento registra entrada, saída ou ajuste de inventário. Saídas para a merenda
+// informam escola_id; saídas de ordens de serviço são lançadas na conclusão da ordem.
+func (h *Handler) CreateEstoqueMovimento(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Tipo          string   `json:"tipo"`
+		Quantidade    float64  `json:"quantidade"`
+		CustoUnitario *float64 `json:"custo_unitario"`
+		Data          string   `json:"data"`
+		Motivo        *string  `json:"motivo"`
+		OrdemID       *string  `json:"ordem_id"`
+		EscolaID      *string  `json:"escola_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := estoque.MovimentoInput{
+		ItemID:        id,
+		Tipo:          payload.Tipo,
+		Quantidade:    payload.Quantidade,
+		CustoUnitario: payload.CustoUnitario,
+		Motivo:        payload.Motivo,
+		AtorID:        &userID,
+	}
+	if strings.TrimSpace(payload.Data) != "" {
+		if input.Data, err = time.Parse("2006-01-02", strings.TrimSpace(payload.Data)); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "data inválida", nil)
+			return
+		}
+	}
+	if input.OrdemID, err = optionalUUID(payload.OrdemID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "ordem_id inválido", nil)
+		return
+	}
+	if input.EscolaID, err = optionalUUID(payload.EscolaID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "escola_id inválido", nil)
+		return
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	movimento, item, err := h.estoque.Movimentar(r.Context(), tenantID, input)
+	if err != nil {
+		writeEstoqueError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"movimento": movimento, "item": item})
+}
+
+// EstoqueConsumo devolve o consumo mensal por item (?from=&to=&secretaria_id=). Sem período,
+// considera os últimos doze meses.
+func (h *Handler) EstoqueConsumo(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	from := to.AddDate(-1, 0, 0)
+	if value := strings.TrimSpace(query.Get("from")); value != "" {
+		parsed, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		from = parsed
+	}
+	if value := strings.TrimSpace(query.Get("to")); value != "" {
+		parsed, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+		return
+	}
+	raw := query.Get("secretaria_id")
+	secretariaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	consumo, err := h.estoque.Consumo(r.Context(), tenantID, secretariaID, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar o relatório de consumo", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"from":    from.Format("2006-01-02"),
+		"to":      to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"consumo": consumo,
+	})
+}
+
+func decodeEstoqueItem(w http.ResponseWriter, r *http.Request, create bool) (estoque.ItemInput, bool) {
+	var payload estoqueItemPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return estoque.ItemInput{}, false
+	}
+	input := estoque.ItemInput{
+		Nome:          payload.Nome,
+		Unidade:       payload.Unidade,
+		Categoria:     payload.Categoria,
+		EstoqueMinimo: payload.EstoqueMinimo,
+		Ativo:         payload.Ativo == nil || *payload.Ativo,
+	}
+	if create {
+		secretariaID, err := optionalUUID(&payload.SecretariaID)
+		if err != nil || secretariaID == nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return estoque.ItemInput{}, false
+		}
+		input.SecretariaID = *secretariaID
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return estoque.ItemInput{}, false
+	}
+	return input, true
+}
+
+func writeEstoqueError(w http.ResponseWriter, err error) {
+	var saldoErr *estoque.SaldoError
+	switch {
+	case errors.As(err, &saldoErr):
+		WriteError(w, http.StatusConflict, "CONFLICT", "saldo insuficiente", map[string]any{"item": saldoErr.Item, "saldo": saldoErr.Saldo})
+	case errors.Is(err, estoque.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "item não encontrado", nil)
+	case errors.Is(err, estoque.ErrDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existe item com este nome na secretaria", nil)
+	case errors.Is(err, estoque.ErrSecretaria):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "secretaria inválida", nil)
+	case errors.Is(err, estoque.ErrDestino):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "ordem de serviço ou escola inválida", nil)
+	case errors.Is(err, estoque.ErrInactive):
+		WriteError(w, http.StatusConflict, "CONFLICT", "item inativo não aceita movimentos", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar o estoque", nil)
+	}
+}
@@ -0,0 +1,418 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/evento"
+	"github.com/gestaozabele/municipio/internal/qrcode"
+)
+
+type eventoPayload struct {
+	SecretariaID  string     `json:"secretaria_id"`
+	Titulo        string     `json:"titulo"`
+	Descricao     *string    `json:"descricao"`
+	Tipo          string     `json:"tipo"`
+	Local         string     `json:"local"`
+	Inicio        *time.Time `json:"inicio"`
+	Fim           *time.Time `json:"fim"`
+	Capacidade    int        `json:"capacidade"`
+	InscricoesAte *time.Time `json:"inscricoes_ate"`
+	LembreteHoras *int       `json:"lembrete_horas"`
+}
+
+// ListPublicEventos lista os próximos eventos da prefeitura do domínio (?tipo=).
+func (h *Handler) ListPublicEventos(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	eventos, err := h.eventos.List(r.Context(), tenantInfo.ID, evento.Filter{
+		Tipo:    strings.TrimSpace(r.URL.Query().Get("tipo")),
+		Futuros: true,
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar os eventos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"eventos": eventos})
+}
+
+// GetPublicEvento detalha o evento com as vagas restantes.
+func (h *Handler) GetPublicEvento(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	e, err := h.eventos.Get(r.Context(), tenantInfo.ID, id)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"evento": e, "inscricoes_abertas": e.Aberto(time.Now())})
+}
+
+// InscreverEvento inscreve o cidadão logado; sem vaga, entra na lista de espera.
+func (h *Handler) InscreverEvento(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	inscricao, err := h.eventos.Inscrever(r.Context(), tenantInfo.ID, id, cidadaoID)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"inscricao": inscricao})
+}
+
+// ListMinhasInscricoes lista as inscrições do cidadão logado.
+func (h *Handler) ListMinhasInscricoes(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	inscricoes, err := h.eventos.ListDoCidadao(r.Context(), tenantInfo.ID, cidadaoID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as inscrições", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"inscricoes": inscricoes})
+}
+
+// CancelarMinhaInscricao desiste da inscrição; a vaga passa ao primeiro da espera.
+func (h *Handler) CancelarMinhaInscricao(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	inscricao, err := h.eventos.CancelarInscricao(r.Context(), tenantInfo.ID, cidadaoID, id)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"inscricao": inscricao})
+}
+
+// MinhaInscricaoQRCode devolve o QR code SVG apresentado no check-in.
+func (h *Handler) MinhaInscricaoQRCode(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	inscricao, err := h.eventos.GetInscricaoDoCidadao(r.Context(), tenantInfo.ID, cidadaoID, id)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	if inscricao.Status != evento.InscricaoConfirmada {
+		writeEventoError(w, evento.ErrNaoConfirmada)
+		return
+	}
+	code, err := qrcode.Encode(inscricao.Codigo)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar o QR code", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(code.SVG(8)))
+}
+
+// ListEventos lista os eventos da prefeitura (?secretaria_id=&tipo=&futuros=&cancelados=).
+func (h *Handler) ListEventos(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	raw := query.Get("secretaria_id")
+	secretariaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	filter := evento.Filter{SecretariaID: secretariaID, Tipo: strings.TrimSpace(query.Get("tipo"))}
+	filter.Futuros, _ = strconv.ParseBool(query.Get("futuros"))
+	filter.Cancelados, _ = strconv.ParseBool(query.Get("cancelados"))
+	eventos, err := h.eventos.List(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar os eventos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"eventos": eventos})
+}
+
+// CreateEvento publica um evento com inscrição.
+func (h *Handler) CreateEvento(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeEvento(w, r, true)
+	if !ok {
+		return
+	}
+	e, err := h.eventos.Create(r.Context(), tenantID, userID, input)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"evento": e})
+}
+
+// GetEvento detalha o evento com as inscrições.
+func (h *Handler) GetEvento(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	e, err := h.eventos.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	inscricoes, err := h.eventos.ListInscricoes(r.Context(), tenantID, id)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as inscrições", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"evento": e, "inscricoes": inscricoes})
+}
+
+// UpdateEvento altera o evento; aumentar a capacidade confirma quem está na espera.
+func (h *Handler) UpdateEvento(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeEvento(w, r, false)
+	if !ok {
+		return
+	}
+	e, err := h.eventos.Update(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"evento": e})
+}
+
+// CancelEvento cancela o evento e encerra as inscrições.
+func (h *Handler) CancelEvento(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	e, err := h.eventos.Cancel(r.Context(), tenantID, id)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"evento": e})
+}
+
+// CheckinEvento registra a presença pelo código lido do QR code ou digitado.
+func (h *Handler) CheckinEvento(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Codigo string `json:"codigo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	codigo := evento.NormalizeCodigo(payload.Codigo)
+	if codigo == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "código de check-in inválido", nil)
+		return
+	}
+	inscricao, err := h.eventos.Checkin(r.Context(), tenantID, id, userID, codigo)
+	if errors.Is(err, evento.ErrJaPresente) {
+		WriteError(w, http.StatusConflict, "CONFLICT", "presença já registrada", map[string]any{"nome": inscricao.Nome, "checkin_em": inscricao.CheckinEm})
+		return
+	}
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"inscricao": inscricao})
+}
+
+// EventoPresenca exporta a lista de inscritos confirmados com a presença (?format=csv).
+func (h *Handler) EventoPresenca(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	e, err := h.eventos.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeEventoError(w, err)
+		return
+	}
+	inscricoes, err := h.eventos.ListInscricoes(r.Context(), tenantID, id)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as inscrições", nil)
+		return
+	}
+	confirmadas := make([]evento.Inscricao, 0, len(inscricoes))
+	for _, i := range inscricoes {
+		if i.Status == evento.InscricaoConfirmada {
+			confirmadas = append(confirmadas, i)
+		}
+	}
+
+	if !strings.EqualFold(r.URL.Query().Get("format"), "csv") {
+		WriteJSON(w, http.StatusOK, map[string]any{"evento": e, "presenca": confirmadas})
+		return
+	}
+	filename := fmt.Sprintf("presenca-%s-%s.csv", e.Inicio.Format("2006-01-02"), e.ID.String()[:8])
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"nome", "email", "codigo", "inscrito_em", "presente", "checkin_em"})
+	for _, i := range confirmadas {
+		email, checkin := "", ""
+		if i.Email != nil {
+			email = *i.Email
+		}
+		if i.CheckinEm != nil {
+			checkin = i.CheckinEm.Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{i.Nome, email, i.Codigo, i.CreatedAt.Format(time.RFC3339), strconv.FormatBool(i.CheckinEm != nil), checkin})
+	}
+	cw.Flush()
+}
+
+func decodeEvento(w http.ResponseWriter, r *http.Request, create bool) (evento.EventoInput, bool) {
+	var payload eventoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return evento.EventoInput{}, false
+	}
+	input := evento.EventoInput{
+		Titulo:        payload.Titulo,
+		Descricao:     payload.Descricao,
+		Tipo:          payload.Tipo,
+		Local:         payload.Local,
+		Fim:           payload.Fim,
+		Capacidade:    payload.Capacidade,
+		InscricoesAte: payload.InscricoesAte,
+		LembreteHoras: payload.LembreteHoras,
+	}
+	if payload.Inicio != nil {
+		input.Inicio = *payload.Inicio
+	}
+	if create {
+		secretariaID, err := optionalUUID(&payload.SecretariaID)
+		if err != nil || secretariaID == nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return evento.EventoInput{}, false
+		}
+		input.SecretariaID = *secretariaID
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return evento.EventoInput{}, false
+	}
+	return input, true
+}
+
+func writeEventoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, evento.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "evento não encontrado", nil)
+	case errors.Is(err, evento.ErrInscricaoNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "inscrição não encontrada", nil)
+	case errors.Is(err, evento.ErrSecretaria):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "secretaria inválida", nil)
+	case errors.Is(err, evento.ErrEncerrado):
+		WriteError(w, http.StatusConflict, "CONFLICT", "inscrições encerradas para este evento", nil)
+	case errors.Is(err, evento.ErrJaInscrito):
+		WriteError(w, http.StatusConflict, "CONFLICT", "você já está inscrito neste evento", nil)
+	case errors.Is(err, evento.ErrNaoConfirmada):
+		WriteError(w, http.StatusConflict, "CONFLICT", "inscrição não confirmada", nil)
+	case errors.Is(err, evento.ErrJaPresente):
+		WriteError(w, http.StatusConflict, "CONFLICT", "presença já registrada", nil)
+	case errors.Is(err, evento.ErrCancelada):
+		WriteError(w, http.StatusConflict, "CONFLICT", "inscrição já cancelada", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar o evento", nil)
+	}
+}
@@ -0,0 +1,416 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/kb"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+type kbCategoryPayload struct {
+	Slug        string  `json:"slug"`
+	Name        string  `json:"name"`
+	ProductArea *string `json:"product_area"`
+	Description *string `json:"description"`
+	Position    int     `json:"position"`
+}
+
+type kbArticlePayload struct {
+	CategoryID  *string  `json:"category_id"`
+	TenantID    *string  `json:"tenant_id"`
+	Slug        string   `json:"slug"`
+	Title       string   `json:"title"`
+	Summary     *string  `json:"summary"`
+	Body        string   `json:"body"`
+	ProductArea *string  `json:"product_area"`
+	Tags        []string `json:"tags"`
+	Status      string   `json:"status"`
+}
+
+// ListPublicKBCategories lista as categorias da base de conhecimento para o portal do tenant.
+func (h *Handler) ListPublicKBCategories(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requestTenant(w, r); !ok {
+		return
+	}
+	categories, err := h.kb.ListCategories(r.Context(), r.URL.Query().Get("area"))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar categorias", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"categories": categories})
+}
+
+// ListPublicKBArticles lista ou busca (?q=) artigos publicados visíveis ao tenant do domínio.
+func (h *Handler) ListPublicKBArticles(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	filter, err := kbFilterFromQuery(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	articles, err := h.kb.PublicArticles(r.Context(), tenantInfo.ID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar artigos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"articles": articles})
+}
+
+// GetPublicKBArticle devolve artigo publicado pelo slug.
+func (h *Handler) GetPublicKBArticle(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	article, err := h.kb.PublicArticle(r.Context(), tenantInfo.ID, chi.URLParam(r, "slug"))
+	if err != nil {
+		writeKBError(w, err, "artigo não encontrado")
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"article": article})
+}
+
+// AskFAQ responde perguntas do app do cidadão com a base de conhecimento do tenant;
+// sem resposta confiável, devolve os dados para abrir um protocolo.
+func (h *Handler) AskFAQ(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Question string `json:"question"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	question := strings.TrimSpace(payload.Question)
+	if question == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "pergunta obrigatória", nil)
+		return
+	}
+	if len(question) > 1000 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "pergunta muito longa", nil)
+		return
+	}
+
+	answer, err := h.kb.Answer(r.Context(), tenantInfo.ID, question)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível consultar a base de conhecimento", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, answer)
+}
+
+// ListKBCategories lista categorias para a equipe SaaS.
+func (h *Handler) ListKBCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.kb.ListCategories(r.Context(), r.URL.Query().Get("area"))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar categorias", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"categories": categories})
+}
+
+// CreateKBCategory cadastra categoria.
+func (h *Handler) CreateKBCategory(w http.ResponseWriter, r *http.Request) {
+	h.saveKBCategory(w, r, nil)
+}
+
+// UpdateKBCategory atualiza categoria.
+func (h *Handler) UpdateKBCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	h.saveKBCategory(w, r, &id)
+}
+
+func (h *Handler) saveKBCategory(w http.ResponseWriter, r *http.Request, id *uuid.UUID) {
+	var payload kbCategoryPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	category, err := h.kb.SaveCategory(r.Context(), id, kb.CategoryInput{
+		Slug:        payload.Slug,
+		Name:        payload.Name,
+		ProductArea: payload.ProductArea,
+		Description: payload.Description,
+		Position:    payload.Position,
+	})
+	if err != nil {
+		writeKBError(w, err, "categoria não encontrada")
+		return
+	}
+
+	status := http.StatusOK
+	if id == nil {
+		status = http.StatusCreated
+	}
+	WriteJSON(w, status, map[string]any{"category": category})
+}
+
+// DeleteKBCategory remove categoria.
+func (h *Handler) DeleteKBCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if err := h.kb.DeleteCategory(r.Context(), id); err != nil {
+		writeKBError(w, err, "categoria não encontrada")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListKBArticles lista artigos de todos os status (?status=&area=&category_id=&tenant_id=&q=).
+func (h *Handler) ListKBArticles(w http.ResponseWriter, r *http.Request) {
+	filter, err := kbFilterFromQuery(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	filter.Status = r.URL.Query().Get("status")
+	if raw := strings.TrimSpace(r.URL.Query().Get("tenant_id")); raw != "" {
+		tenantID, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant_id inválido", nil)
+			return
+		}
+		filter.TenantID = &tenantID
+	}
+
+	articles, err := h.kb.ListArticles(r.Context(), filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar artigos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"articles": articles})
+}
+
+// GetKBArticle devolve artigo, inclusive rascunho.
+func (h *Handler) GetKBArticle(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	article, err := h.kb.GetArticle(r.Context(), id)
+	if err != nil {
+		writeKBError(w, err, "artigo não encontrado")
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"article": article})
+}
+
+// CreateKBArticle cadastra artigo.
+func (h *Handler) CreateKBArticle(w http.ResponseWriter, r *http.Request) {
+	h.saveKBArticle(w, r, nil)
+}
+
+// UpdateKBArticle atualiza artigo.
+func (h *Handler) UpdateKBArticle(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	h.saveKBArticle(w, r, &id)
+}
+
+func (h *Handler) saveKBArticle(w http.ResponseWriter, r *http.Request, id *uuid.UUID) {
+	var payload kbArticlePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	categoryID, err := optionalUUID(payload.CategoryID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "category_id inválido", nil)
+		return
+	}
+	tenantID, err := optionalUUID(payload.TenantID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant_id inválido", nil)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	article, err := h.kb.SaveArticle(r.Context(), id, kb.ArticleInput{
+		CategoryID:  categoryID,
+		TenantID:    tenantID,
+		Slug:        payload.Slug,
+		Title:       payload.Title,
+		Summary:     payload.Summary,
+		Body:        payload.Body,
+		ProductArea: payload.ProductArea,
+		Tags:        payload.Tags,
+		Status:      payload.Status,
+		ActorID:     &actorID,
+	})
+	if err != nil {
+		writeKBError(w, err, "artigo não encontrado")
+		return
+	}
+
+	status := http.StatusOK
+	if id == nil {
+		status = http.StatusCreated
+	}
+	WriteJSON(w, status, map[string]any{"article": article})
+}
+
+// DeleteKBArticle remove artigo.
+func (h *Handler) DeleteKBArticle(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if err := h.kb.DeleteArticle(r.Context(), id); err != nil {
+		writeKBError(w, err, "artigo não encontrado")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SuggestKBArticlesForTicket sugere artigos relacionados ao assunto e descrição do chamado.
+func (h *Handler) SuggestKBArticlesForTicket(w http.ResponseWriter, r *http.Request) {
+	if h.support == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "módulo de suporte indisponível", nil)
+		return
+	}
+	ticketID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	ticket, err := h.support.GetTicket(r.Context(), ticketID)
+	if err != nil {
+		writeCannedError(w, err)
+		return
+	}
+	articles, err := h.kb.Suggest(r.Context(), ticket.TenantID, ticket.Subject, ticket.Description)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível sugerir artigos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"articles": articles})
+}
+
+// suggestKBArticles é usado na abertura do chamado; falhas só são registradas.
+func (h *Handler) suggestKBArticles(r *http.Request, tenantID uuid.UUID, subject, description string) []kb.Article {
+	articles, err := h.kb.Suggest(r.Context(), tenantID, subject, description)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("kb: falha ao sugerir artigos")
+		return []kb.Article{}
+	}
+	return articles
+}
+
+// requestTenant resolve o tenant pelo host da requisição (ou ?domain=), como em /tenant.
+func (h *Handler) requestTenant(w http.ResponseWriter, r *http.Request) (*tenant.Tenant, bool) {
+	host := r.Host
+	if domain := strings.TrimSpace(r.URL.Query().Get("domain")); domain != "" {
+		host = domain
+	}
+	tenantInfo, err := h.tenants.Resolve(r.Context(), host)
+	if err != nil {
+		if errors.Is(err, tenant.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "TENANT_NOT_FOUND", "tenant não configurado para este domínio", nil)
+			return nil, false
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenant", nil)
+		return nil, false
+	}
+	return tenantInfo, true
+}
+
+// tenantResolver adapta o serviço de tenants ao middleware que escopa as rotas autenticadas.
+type tenantResolver struct {
+	tenants *tenant.Service
+}
+
+// ResolveTenantID implementa httpmiddleware.TenantResolver.
+func (t tenantResolver) ResolveTenantID(ctx context.Context, host string) (uuid.UUID, error) {
+	tenantInfo, err := t.tenants.Resolve(ctx, host)
+	if errors.Is(err, tenant.ErrNotFound) {
+		return uuid.Nil, httpmiddleware.ErrTenantNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return tenantInfo.ID, nil
+}
+
+func kbFilterFromQuery(r *http.Request) (kb.ArticleFilter, error) {
+	query := r.URL.Query()
+	filter := kb.ArticleFilter{
+		ProductArea: query.Get("area"),
+		Query:       query.Get("q"),
+	}
+	if raw := strings.TrimSpace(query.Get("category_id")); raw != "" {
+		categoryID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, errors.New("category_id inválido")
+		}
+		filter.CategoryID = &categoryID
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("limit inválido")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+func optionalUUID(raw *string) (*uuid.UUID, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil, nil
+	}
+	parsed, err := uuid.Parse(strings.TrimSpace(*raw))
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func writeKBError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, kb.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", notFound, nil)
+	case errors.Is(err, kb.ErrSlugTaken):
+		WriteError(w, http.StatusConflict, "CONFLICT", "slug já utilizado", nil)
+	case errors.Is(err, kb.ErrInvalidStatus):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "status inválido", nil)
+	case errors.Is(err, kb.ErrUnknownCategory):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "categoria ou tenant inexistente", nil)
+	default:
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/metrics"
+	"github.com/gestaozabele/municipio/internal/monitor"
+)
+
+// Metrics expõe no formato texto do Prometheus as estatísticas do pool, a latência por rota e dos
+// comandos Redis, as tentativas de login e o estado do coletor de monitoramento.
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	if token := h.cfg.Metrics.Token; token != "" {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			WriteError(w, http.StatusUnauthorized, "AUTH", "token de métricas inválido", nil)
+			return
+		}
+	}
+
+	stats := db.Stats(h.pool)
+	utilization := 0.0
+	if stats.MaxConns > 0 {
+		utilization = float64(stats.AcquiredConns) / float64(stats.MaxConns)
+	}
+	gauges := []struct {
+		name, kind, help string
+		value            float64
+	}{
+		{"db_pool_max_conns", "gauge", "Tamanho máximo do pool.", float64(stats.MaxConns)},
+		{"db_pool_total_conns", "gauge", "Conexões abertas no pool.", float64(stats.TotalConns)},
+		{"db_pool_acquired_conns", "gauge", "Conexões em uso.", float64(stats.AcquiredConns)},
+		{"db_pool_idle_conns", "gauge", "Conexões ociosas.", float64(stats.IdleConns)},
+		{"db_pool_utilization_ratio", "gauge", "Fração do pool em uso.", utilization},
+		{"db_pool_constructing_conns", "gauge", "Conexões sendo abertas.", float64(stats.ConstructingConns)},
+		{"db_pool_acquire_total", "counter", "Aquisições de conexão concluídas.", float64(stats.AcquireCount)},
+		{"db_pool_acquire_duration_seconds_total", "counter", "Tempo acumulado aguardando conexão.", stats.AcquireDurationSeconds},
+		{"db_pool_empty_acquire_total", "counter", "Aquisições que esperaram por falta de conexão ociosa.", float64(stats.EmptyAcquireCount)},
+		{"db_pool_canceled_acquire_total", "counter", "Aquisições abandonadas por cancelamento do contexto.", float64(stats.CanceledAcquireCount)},
+		{"db_pool_new_conns_total", "counter", "Conexões criadas.", float64(stats.NewConnsCount)},
+		{"db_pool_max_lifetime_destroy_total", "counter", "Conexões fechadas por tempo de vida.", float64(stats.MaxLifetimeDestroyCount)},
+		{"db_pool_max_idle_destroy_total", "counter", "Conexões fechadas por ociosidade.", float64(stats.MaxIdleDestroyCount)},
+		{"db_pool_evicted_conns_total", "counter", "Conexões descartadas na devolução por estado inconsistente.", float64(stats.EvictedConnsCount)},
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	sessions, sessionsErr := h.loadActiveSessions(ctx)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	for _, m := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+	}
+	metrics.HTTPRequestDuration.Write(w)
+	metrics.RedisCommandDuration.Write(w)
+	metrics.LoginAttempts.Write(w)
+	if h.monitor != nil {
+		writeMonitorStats(w, h.monitor.Stats())
+	}
+
+	// Falhas em sessões ou presença não derrubam o scrape das métricas do pool.
+	if sessionsErr == nil {
+		fmt.Fprint(w, "# HELP auth_active_sessions Refresh tokens válidos por audiência.\n# TYPE auth_active_sessions gauge\n")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "auth_active_sessions{audience=%q} %d\n", s.Audience, s.Sessions)
+		}
+		fmt.Fprint(w, "# HELP auth_active_users Titulares distintos com sessão válida por audiência.\n# TYPE auth_active_users gauge\n")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "auth_active_users{audience=%q} %d\n", s.Audience, s.Users)
+		}
+	}
+	if h.loadShedder != nil {
+		fmt.Fprintf(w, "# HELP http_load_shed_p95_seconds P95 de latência usado pelo descarte de carga.\n# TYPE http_load_shed_p95_seconds gauge\nhttp_load_shed_p95_seconds %g\n", h.loadShedder.P95().Seconds())
+		fmt.Fprint(w, "# HELP http_load_shed_total Requisições descartadas por sobrecarga.\n# TYPE http_load_shed_total counter\n")
+		for priority, total := range h.loadShedder.Shed() {
+			fmt.Fprintf(w, "http_load_shed_total{priority=%q} %d\n", priority, total)
+		}
+	}
+	if online, err := h.presence.Snapshot(ctx); err == nil {
+		fmt.Fprint(w, "# HELP presence_online_users Usuários com heartbeat na janela de presença.\n# TYPE presence_online_users gauge\n")
+		for audience, total := range online.Audiences {
+			fmt.Fprintf(w, "presence_online_users{audience=%q} %d\n", audience, total)
+		}
+	}
+}
+
+func writeMonitorStats(w io.Writer, stats monitor.CollectorStats) {
+	lastSuccess := 0.0
+	if !stats.LastSuccessAt.IsZero() {
+		lastSuccess = float64(stats.LastSuccessAt.Unix())
+	}
+	fmt.Fprintf(w, "# HELP monitor_collector_runs_total Coletas do monitor executadas nesta instância.\n# TYPE monitor_collector_runs_total counter\nmonitor_collector_runs_total %d\n", stats.Runs)
+	fmt.Fprintf(w, "# HELP monitor_collector_failures_total Coletas interrompidas por erro.\n# TYPE monitor_collector_failures_total counter\nmonitor_collector_failures_total %d\n", stats.Failures)
+	fmt.Fprintf(w, "# HELP monitor_tenant_checks_total Verificações de prefeitura feitas pelo coletor.\n# TYPE monitor_tenant_checks_total counter\nmonitor_tenant_checks_total %d\n", stats.TenantChecks)
+	fmt.Fprintf(w, "# HELP monitor_tenant_check_errors_total Verificações de prefeitura que falharam.\n# TYPE monitor_tenant_check_errors_total counter\nmonitor_tenant_check_errors_total %d\n", stats.TenantErrors)
+	fmt.Fprintf(w, "# HELP monitor_collector_last_duration_seconds Duração da última coleta.\n# TYPE monitor_collector_last_duration_seconds gauge\nmonitor_collector_last_duration_seconds %g\n", stats.LastDuration.Seconds())
+	fmt.Fprintf(w, "# HELP monitor_collector_last_success_timestamp_seconds Fim da última coleta bem-sucedida, em segundos Unix.\n# TYPE monitor_collector_last_success_timestamp_seconds gauge\nmonitor_collector_last_success_timestamp_seconds %g\n", lastSuccess)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/gestaozabele/municipio/internal/auth"
+	"github.com/gestaozabele/municipio/internal/authz"
 )
 
 type contextKey string
@@ -16,6 +17,7 @@ const (
 	ContextKeyAudience   contextKey = "audience"
 	ContextKeyRoles      contextKey = "roles"
 	ContextKeySecretaria contextKey = "secretaria"
+	ContextKeyScope      contextKey = "scope"
 )
 
 // Auth valida JWT de acesso e injeta claims no contexto.
@@ -43,6 +45,8 @@ func Auth(jwtManager *auth.JWTManager) func(http.Handler) http.Handler {
 			ctx := context.WithValue(r.Context(), ContextKeySubject, claims.Subject)
 			ctx = context.WithValue(ctx, ContextKeyAudience, claims.Audience[0])
 			ctx = context.WithValue(ctx, ContextKeyRoles, claims.Roles)
+			ctx = context.WithValue(ctx, ContextKeyScope, claims.Scope)
+			annotateRequest(ctx, claims.Subject, claims.Audience[0])
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -67,6 +71,12 @@ func GetRoles(ctx context.Context) []string {
 	return val
 }
 
+// GetScope recupera o escopo do token; vazio é acesso completo.
+func GetScope(ctx context.Context) string {
+	val, _ := ctx.Value(ContextKeyScope).(string)
+	return val
+}
+
 // RequireProfessor garante papel de professor.
 func RequireProfessor(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -82,12 +92,97 @@ func RequireProfessor(next http.Handler) http.Handler {
 	})
 }
 
+// RequireEscolaGestor garante papel de direção/coordenação escolar.
+func RequireEscolaGestor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roles := GetRoles(r.Context())
+		for _, role := range roles {
+			if strings.EqualFold(role, "ESCOLA_GESTOR") {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito à gestão escolar")
+	})
+}
+
+// RequireTenantAdmin garante papel de administrador delegado da prefeitura. Auditores também
+// passam, para consultar a administração; as escritas já foram barradas por ReadOnly.
+func RequireTenantAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roles := GetRoles(r.Context())
+		for _, role := range roles {
+			if strings.EqualFold(role, "TENANT_ADMIN") || strings.EqualFold(role, authz.RoleAuditor) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito à administração da prefeitura")
+	})
+}
+
+// RequireSecretaria garante papel de gestão municipal (secretário, prefeito, administração técnica
+// ou auditoria) ou papel personalizado; neste caso cada rota confere a permissão com RequirePermission.
+func RequireSecretaria(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roles := GetRoles(r.Context())
+		for _, role := range roles {
+			switch strings.ToUpper(role) {
+			case "SECRETARIO", "PREFEITO", "ADMIN_TEC", authz.RoleAuditor, authz.RoleMarker:
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito à secretaria")
+	})
+}
+
+// RequireCidadao garante token emitido para o app do cidadão.
+func RequireCidadao(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAudience(r.Context()) == "cidadao" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito a cidadãos")
+	})
+}
+
+// RequireResponsavel garante token emitido para o portal das famílias.
+func RequireResponsavel(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAudience(r.Context()) == "responsavel" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito a responsáveis")
+	})
+}
+
+// RequireAluno garante token emitido para as avaliações online dos estudantes.
+func RequireAluno(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAudience(r.Context()) == "aluno" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito a alunos")
+	})
+}
+
 // RequireSaaSAdmin garante que o usuário é administrador SaaS.
 func RequireSaaSAdmin(next http.Handler) http.Handler {
 	return RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER")(next)
 }
 
-// RequireSaaSRoles garante que o usuário SaaS possua pelo menos um dos papéis informados.
+// RequireSaaSRoles garante que o usuário SaaS possua pelo menos um dos papéis informados. O auditor
+// SaaS passa em qualquer leitura.
 func RequireSaaSRoles(requiredRoles ...string) func(http.Handler) http.Handler {
 	normalized := make([]string, 0, len(requiredRoles))
 	for _, role := range requiredRoles {
@@ -106,6 +201,10 @@ func RequireSaaSRoles(requiredRoles ...string) func(http.Handler) http.Handler {
 			roles := GetRoles(r.Context())
 			for _, role := range roles {
 				roleUpper := strings.ToUpper(strings.TrimSpace(role))
+				if roleUpper == SaaSAuditorRole && isSafeMethod(r.Method) {
+					next.ServeHTTP(w, r)
+					return
+				}
 				for _, required := range normalized {
 					if roleUpper == required {
 						next.ServeHTTP(w, r)
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// SparseFields aplica `?fields=` em respostas GET bem-sucedidas, reduzindo o campo `data` do
+// envelope aos campos pedidos (ex.: `?fields=id,nome,settings.theme`). Sem o parâmetro a
+// resposta segue sem buffer; respostas que não são JSON passam intactas.
+func SparseFields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		fields := util.ParseFields(r.URL.Query().Get("fields"))
+		if fields == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedWriter{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		body := buf.body.Bytes()
+		if buf.status == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			var envelope map[string]any
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			if err := decoder.Decode(&envelope); err == nil {
+				if data, ok := envelope["data"]; ok {
+					envelope["data"] = util.SelectFields(data, fields)
+					if filtered, err := json.Marshal(envelope); err == nil {
+						body = append(filtered, '\n')
+					}
+				}
+			}
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(body)
+	})
+}
+
+type bufferedWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.status = status
+	b.wroteHeader = true
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority classifica requisições para o descarte sob sobrecarga.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityCritical
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// PriorityRule associa método (vazio casa qualquer um) e padrão de caminho a uma prioridade.
+type PriorityRule struct {
+	Method   string
+	Pattern  *regexp.Regexp
+	Priority Priority
+}
+
+// LoadShedConfig define os gatilhos de sobrecarga.
+type LoadShedConfig struct {
+	// P95 de latência das requisições normais e críticas a partir do qual a instância está sobrecarregada.
+	P95Threshold time.Duration
+	// Fração do pool de conexões em uso a partir da qual a instância está sobrecarregada.
+	PoolSaturation float64
+	RetryAfter     time.Duration
+}
+
+// LoadShedder descarta tráfego de baixa prioridade (relatórios, analytics, exportações) quando a
+// latência ou o pool do banco passam dos limites; em sobrecarga severa (os dois sinais juntos e
+// latência no dobro do limite) descarta também o tráfego normal. Logins e gravação de chamada
+// são críticos e nunca são descartados.
+type LoadShedder struct {
+	cfg   LoadShedConfig
+	rules []PriorityRule
+	pool  func() float64
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	filled  bool
+	p95     time.Duration
+	p95At   time.Time
+
+	shed [3]atomic.Int64
+}
+
+// NewLoadShedder cria o middleware; pool devolve a fração atual de conexões em uso.
+func NewLoadShedder(cfg LoadShedConfig, rules []PriorityRule, pool func() float64) *LoadShedder {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 10 * time.Second
+	}
+	return &LoadShedder{cfg: cfg, rules: rules, pool: pool, samples: make([]time.Duration, 512)}
+}
+
+// Classify devolve a prioridade da requisição pela primeira regra que casar.
+func (l *LoadShedder) Classify(r *http.Request) Priority {
+	for _, rule := range l.rules {
+		if rule.Method != "" && rule.Method != r.Method {
+			continue
+		}
+		if rule.Pattern.MatchString(r.URL.Path) {
+			return rule.Priority
+		}
+	}
+	return PriorityNormal
+}
+
+// Handler aplica o descarte e alimenta a janela de latência.
+func (l *LoadShedder) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority := l.Classify(r)
+		if priority != PriorityCritical && l.shouldShed(priority) {
+			l.shed[priority].Add(1)
+			w.Header().Set("Retry-After", strconv.Itoa(int(l.cfg.RetryAfter.Seconds())))
+			writeError(w, http.StatusServiceUnavailable, "OVERLOADED", "Serviço sobrecarregado, tente novamente em instantes")
+			return
+		}
+
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		// Relatórios lentos não entram na janela: o sinal é a latência do tráfego que queremos proteger.
+		if priority != PriorityLow {
+			l.observe(time.Since(start))
+		}
+	})
+}
+
+// Shed devolve quantas requisições foram descartadas por prioridade desde o início do processo.
+func (l *LoadShedder) Shed() map[string]int64 {
+	return map[string]int64{
+		PriorityLow.String():    l.shed[PriorityLow].Load(),
+		PriorityNormal.String(): l.shed[PriorityNormal].Load(),
+	}
+}
+
+// P95 devolve o percentil 95 de latência da janela atual.
+func (l *LoadShedder) P95() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.currentP95(time.Now())
+}
+
+func (l *LoadShedder) shouldShed(priority Priority) bool {
+	p95 := l.P95()
+	latencyHigh := l.cfg.P95Threshold > 0 && p95 >= l.cfg.P95Threshold
+	poolHigh := l.pool != nil && l.cfg.PoolSaturation > 0 && l.pool() >= l.cfg.PoolSaturation
+
+	switch priority {
+	case PriorityLow:
+		return latencyHigh || poolHigh
+	case PriorityNormal:
+		return poolHigh && l.cfg.P95Threshold > 0 && p95 >= 2*l.cfg.P95Threshold
+	}
+	return false
+}
+
+func (l *LoadShedder) observe(d time.Duration) {
+	l.mu.Lock()
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % len(l.samples)
+	if l.next == 0 {
+		l.filled = true
+	}
+	l.mu.Unlock()
+}
+
+// currentP95 recalcula o percentil no máximo uma vez por segundo; chamar com mu travado.
+func (l *LoadShedder) currentP95(now time.Time) time.Duration {
+	if now.Sub(l.p95At) < time.Second {
+		return l.p95
+	}
+	n := l.next
+	if l.filled {
+		n = len(l.samples)
+	}
+	l.p95At = now
+	// Poucas amostras não sustentam um percentil; sem sinal, sem descarte por latência.
+	if n < 20 {
+		l.p95 = 0
+		return 0
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, l.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	l.p95 = sorted[(n*95)/100]
+	return l.p95
+}
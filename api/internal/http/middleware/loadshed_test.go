@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestLoadShedderPriorities(t *testing.T) {
+	saturation := 0.0
+	shedder := NewLoadShedder(LoadShedConfig{P95Threshold: time.Second, PoolSaturation: 0.9, RetryAfter: 5 * time.Second}, []PriorityRule{
+		{Method: http.MethodPost, Pattern: regexp.MustCompile(`^/auth/`), Priority: PriorityCritical},
+		{Pattern: regexp.MustCompile(`/relatorios/`), Priority: PriorityLow},
+	}, func() float64 { return saturation })
+	handler := shedder.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/prof/relatorios/frequencia"); rec.Code != http.StatusOK {
+		t.Fatalf("expected report to pass without overload, got %d", rec.Code)
+	}
+
+	saturation = 0.95
+	rec := do(http.MethodGet, "/prof/relatorios/frequencia")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "5" {
+		t.Fatalf("expected low priority shed with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := do(http.MethodGet, "/prof/turmas"); rec.Code != http.StatusOK {
+		t.Fatalf("expected normal traffic kept under pool saturation alone, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/auth/backoffice/login"); rec.Code != http.StatusOK {
+		t.Fatalf("expected login kept, got %d", rec.Code)
+	}
+	if got := shedder.Shed()["low"]; got != 1 {
+		t.Fatalf("expected one low priority shed, got %d", got)
+	}
+}
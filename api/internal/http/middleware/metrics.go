@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/gestaozabele/municipio/internal/metrics"
+)
+
+// RouteMetrics registra a latência de cada requisição pelo padrão de rota do chi, resolvido só
+// depois do roteamento. Caminhos sem rota ficam agrupados em "unmatched".
+func RouteMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), r.Method, route, metrics.StatusClass(ww.Status()))
+	})
+}
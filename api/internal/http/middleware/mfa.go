@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+// MFAEnrollment recusa tokens de cadastro de biometria fora dos prefixos em allowed. Sem ele, quem
+// entrou por senha numa prefeitura que exige MFA teria acesso completo sem nunca cadastrar a passkey.
+func MFAEnrollment(allowed ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetScope(r.Context()) != auth.ScopeMFACadastro {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range allowed {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			writeError(w, http.StatusForbidden, "MFA_REQUIRED", "cadastre a biometria exigida pela prefeitura para continuar")
+		})
+	}
+}
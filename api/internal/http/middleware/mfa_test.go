@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+func TestMFAEnrollment(t *testing.T) {
+	handler := MFAEnrollment("/auth/passkey/register", "/me")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		path, scope string
+		want        int
+	}{
+		{"/auth/passkey/register/start", auth.ScopeMFACadastro, http.StatusNoContent},
+		{"/me", auth.ScopeMFACadastro, http.StatusNoContent},
+		{"/secretaria/protocolos", auth.ScopeMFACadastro, http.StatusForbidden},
+		{"/prof/turmas", auth.ScopeMFACadastro, http.StatusForbidden},
+		{"/secretaria/protocolos", "", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyScope, tc.scope))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %q: status %d, esperado %d", tc.path, tc.scope, rec.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+)
+
+// ContextKeyPermissions guarda as permissões exigidas pela rota, usadas depois para restringir as
+// prefeituras em que o usuário atua por papel personalizado.
+const ContextKeyPermissions contextKey = "permissions"
+
+// PermissionChecker consulta as permissões concedidas por papéis personalizados e delegações.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, userID uuid.UUID, perms []string) (bool, error)
+	ActiveDelegacao(ctx context.Context, userID uuid.UUID, perms []string) (*authz.Delegacao, error)
+	RecordDelegacaoAcao(ctx context.Context, delegacaoID, userID uuid.UUID, metodo, rota string, status int) error
+}
+
+// RequirePermission libera a rota para papéis fixos de gestão ou para quem tem, por papel
+// personalizado ou delegação vigente, ao menos uma das permissões informadas. Requisições de
+// escrita autorizadas só pela delegação ficam registradas nela.
+func RequirePermission(checker PermissionChecker, perms ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ContextKeyPermissions, perms)
+			for _, role := range GetRoles(ctx) {
+				if authz.FullAccess(role) {
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
+			userID, err := uuid.Parse(GetSubject(ctx))
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, "AUTH", "subject inválido")
+				return
+			}
+			allowed, err := checker.HasAnyPermission(ctx, userID, perms)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível verificar permissões")
+				return
+			}
+			if allowed {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			delegacao, err := checker.ActiveDelegacao(ctx, userID, perms)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível verificar permissões")
+				return
+			}
+			if delegacao == nil {
+				writeError(w, http.StatusForbidden, "FORBIDDEN", "sem permissão para esta ação")
+				return
+			}
+			ctx = authz.WithDelegacao(ctx, delegacao)
+			w.Header().Set("X-Delegacao-Id", delegacao.ID.String())
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if err := checker.RecordDelegacaoAcao(context.WithoutCancel(ctx), delegacao.ID, userID, r.Method, r.URL.Path, status); err != nil {
+				log.Error().Err(err).Str("delegacao_id", delegacao.ID.String()).Msg("delegação: falha ao registrar ação")
+			}
+		})
+	}
+}
+
+// GetPermissions recupera as permissões exigidas pela rota.
+func GetPermissions(ctx context.Context) []string {
+	val, _ := ctx.Value(ContextKeyPermissions).([]string)
+	return val
+}
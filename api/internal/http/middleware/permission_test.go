@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+)
+
+type stubChecker struct {
+	allowed   bool
+	delegacao *authz.Delegacao
+	calls     int
+	acoes     []string
+}
+
+func (s *stubChecker) HasAnyPermission(ctx context.Context, userID uuid.UUID, perms []string) (bool, error) {
+	s.calls++
+	return s.allowed, nil
+}
+
+func (s *stubChecker) ActiveDelegacao(ctx context.Context, userID uuid.UUID, perms []string) (*authz.Delegacao, error) {
+	return s.delegacao, nil
+}
+
+func (s *stubChecker) RecordDelegacaoAcao(ctx context.Context, delegacaoID, userID uuid.UUID, metodo, rota string, status int) error {
+	s.acoes = append(s.acoes, metodo+" "+rota)
+	return nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	run := func(checker *stubChecker, roles ...string) (int, []string) {
+		var seen []string
+		handler := RequirePermission(checker, "protocolos.ver")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = GetPermissions(r.Context())
+			if d := authz.DelegacaoFrom(r.Context()); d != nil && d != checker.delegacao {
+				t.Errorf("delegação no contexto = %v", d)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		ctx := context.WithValue(context.Background(), ContextKeySubject, uuid.NewString())
+		ctx = context.WithValue(ctx, ContextKeyRoles, roles)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/secretaria/protocolos/1/status", nil).WithContext(ctx))
+		return rec.Code, seen
+	}
+
+	fixed := &stubChecker{}
+	if code, _ := run(fixed, "SECRETARIO"); code != http.StatusNoContent || fixed.calls != 0 {
+		t.Fatalf("papel fixo: status %d, consultas %d", code, fixed.calls)
+	}
+	if code, seen := run(&stubChecker{allowed: true}, "EQUIPE"); code != http.StatusNoContent || len(seen) != 1 {
+		t.Fatalf("papel personalizado com permissão: status %d, permissões %v", code, seen)
+	}
+	if code, _ := run(&stubChecker{}, "EQUIPE"); code != http.StatusForbidden {
+		t.Fatalf("papel personalizado sem permissão: status %d", code)
+	}
+	delegado := &stubChecker{delegacao: &authz.Delegacao{ID: uuid.New()}}
+	if code, _ := run(delegado, "EQUIPE"); code != http.StatusNoContent || len(delegado.acoes) != 1 {
+		t.Fatalf("delegação: status %d, ações registradas %v", code, delegado.acoes)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PresenceToucher registra atividade de um usuário autenticado.
+type PresenceToucher interface {
+	Touch(ctx context.Context, audience, subject string)
+}
+
+// Presence registra heartbeat do usuário autenticado a cada requisição; deve vir depois de Auth.
+// Falhas no registro nunca bloqueiam a requisição.
+func Presence(tracker PresenceToucher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), 500*time.Millisecond)
+			tracker.Touch(ctx, GetAudience(r.Context()), GetSubject(r.Context()))
+			cancel()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+)
+
+// SaaSAuditorRole é a claim do auditor da plataforma, que lê todas as áreas do SaaS.
+const SaaSAuditorRole = "SAAS_AUDITOR"
+
+// ReadOnly recusa qualquer escrita de tokens de auditoria, no backoffice ou no SaaS, antes que a
+// requisição chegue às rotas. exempt lista prefixos que só dizem respeito ao próprio auditor, como
+// aceite de termos e cadastro de biometria.
+func ReadOnly(exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isSafeMethod(r.Method) || !IsAuditor(GetRoles(r.Context())) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range exempt {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			writeError(w, http.StatusForbidden, "READ_ONLY", "acesso de auditoria é somente leitura")
+		})
+	}
+}
+
+// IsAuditor informa se as claims vêm de um acesso de auditoria. Basta um papel de auditor para a
+// sessão inteira ficar somente leitura.
+func IsAuditor(roles []string) bool {
+	for _, role := range roles {
+		switch strings.ToUpper(strings.TrimSpace(role)) {
+		case authz.RoleAuditor, SaaSAuditorRole:
+			return true
+		}
+	}
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
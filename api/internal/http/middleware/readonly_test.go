@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	handler := ReadOnly("/terms/accept")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		method, path string
+		roles        []string
+		want         int
+	}{
+		{http.MethodGet, "/secretaria/protocolos", []string{"AUDITOR"}, http.StatusNoContent},
+		{http.MethodPost, "/secretaria/protocolos", []string{"AUDITOR"}, http.StatusForbidden},
+		{http.MethodDelete, "/tenants/1", []string{"SAAS_USER", "SAAS_AUDITOR"}, http.StatusForbidden},
+		{http.MethodPost, "/terms/accept", []string{"AUDITOR"}, http.StatusNoContent},
+		{http.MethodPost, "/secretaria/protocolos", []string{"SECRETARIO"}, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyRoles, tc.roles))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s %v: status %d, esperado %d", tc.method, tc.path, tc.roles, rec.Code, tc.want)
+		}
+	}
+}
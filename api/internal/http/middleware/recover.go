@@ -1,23 +1,88 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"runtime/debug"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/errtrack"
 )
 
-// Recover garante resposta sanitizada em caso de panic.
-func Recover(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				log.Error().Interface("panic", rec).Msg("panic recuperado")
+// PanicReport descreve um panic recuperado e a requisição que o causou.
+type PanicReport struct {
+	Value     any
+	Stack     []byte
+	Request   *http.Request
+	Route     string
+	RequestID string
+	Subject   string
+	Audience  string
+}
+
+// PanicReporter recebe os panics recuperados (rastreamento de erros, abertura de incidentes).
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, report PanicReport)
+}
+
+type requestInfoKey struct{}
+
+// requestInfo é preenchido pelo Auth para que o Recover, que roda antes, conheça o usuário.
+type requestInfo struct {
+	subject  string
+	audience string
+}
+
+// Recover garante resposta sanitizada em caso de panic e repassa o ocorrido aos reporters.
+func Recover(reporters ...PanicReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := &requestInfo{}
+			r = r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				report := PanicReport{
+					Value:     rec,
+					Stack:     debug.Stack(),
+					Request:   r,
+					RequestID: middleware.GetReqID(r.Context()),
+					Subject:   info.subject,
+					Audience:  info.audience,
+				}
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					report.Route = rctx.RoutePattern()
+				}
+				// O log fica marcado como já reportado para o hook de erros não duplicar o evento.
+				ctx := errtrack.Reported(r.Context())
+				for _, reporter := range reporters {
+					if reporter != nil {
+						reporter.ReportPanic(ctx, report)
+					}
+				}
+				log.Error().Ctx(ctx).Interface("panic", rec).Str("route", report.Route).Str("request_id", report.RequestID).Msg("panic recuperado")
 				writeRecoverError(w)
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// annotateRequest registra o usuário autenticado para eventuais relatórios de panic.
+func annotateRequest(ctx context.Context, subject, audience string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.subject = subject
+		info.audience = audience
+	}
 }
 
 func writeRecoverError(w http.ResponseWriter) {
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type capturedPanics struct {
+	reports []PanicReport
+}
+
+func (c *capturedPanics) ReportPanic(_ context.Context, report PanicReport) {
+	c.reports = append(c.reports, report)
+}
+
+func TestRecoverReportsPanic(t *testing.T) {
+	captured := &capturedPanics{}
+	r := chi.NewRouter()
+	r.Use(Recover(captured))
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			annotateRequest(req.Context(), "user-1", "backoffice")
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Get("/escolas/{id}", func(http.ResponseWriter, *http.Request) {
+		panic("falha inesperada")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/escolas/42", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if len(captured.reports) != 1 {
+		t.Fatalf("esperado 1 relatório, obteve %d", len(captured.reports))
+	}
+	report := captured.reports[0]
+	if report.Route != "/escolas/{id}" || report.Subject != "user-1" || report.Audience != "backoffice" || report.Value != "falha inesperada" {
+		t.Fatalf("relatório incompleto: %+v", report)
+	}
+	if len(report.Stack) == 0 {
+		t.Fatal("relatório sem pilha")
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// ContextKeyTenant guarda a prefeitura resolvida pelo domínio da requisição.
+const ContextKeyTenant contextKey = "tenant"
+
+// ErrTenantNotFound indica domínio sem prefeitura configurada.
+var ErrTenantNotFound = errors.New("tenant não configurado para este domínio")
+
+// TenantResolver identifica a prefeitura dona do domínio.
+type TenantResolver interface {
+	ResolveTenantID(ctx context.Context, host string) (uuid.UUID, error)
+}
+
+// Tenant resolve a prefeitura pelo host e a injeta no contexto; os repositórios escopados leem
+// dali o tenant_id de cada consulta. Ao contrário das rotas públicas, ?domain= não é aceito: nas
+// rotas autenticadas ele deixaria o usuário de uma prefeitura escolher o escopo de outra.
+func Tenant(resolver TenantResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, err := resolver.ResolveTenantID(r.Context(), r.Host)
+			if err != nil {
+				if errors.Is(err, ErrTenantNotFound) {
+					writeError(w, http.StatusNotFound, "TENANT_NOT_FOUND", "tenant não configurado para este domínio")
+					return
+				}
+				writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenant")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(SetTenant(r.Context(), tenantID)))
+		})
+	}
+}
+
+// SetTenant injeta a prefeitura no contexto.
+func SetTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, ContextKeyTenant, tenantID)
+}
+
+// GetTenant retorna a prefeitura do contexto; ausente, devolve uuid.Nil e false.
+func GetTenant(ctx context.Context) (uuid.UUID, bool) {
+	val, ok := ctx.Value(ContextKeyTenant).(uuid.UUID)
+	return val, ok && val != uuid.Nil
+}
+
+// TenantScope devolve a prefeitura do contexto para escopar consultas. Sem ela devolve uuid.Nil,
+// que não casa com escola alguma: a consulta volta vazia em vez de cruzar prefeituras.
+func TenantScope(ctx context.Context) uuid.UUID {
+	tenantID, _ := GetTenant(ctx)
+	return tenantID
+}
+
+// TenantRouter fixa no contexto a conexão com o banco onde ficam os dados da prefeitura.
+type TenantRouter interface {
+	WithTenant(ctx context.Context, tenantID uuid.UUID) (context.Context, error)
+}
+
+// TenantDatabase roteia a requisição para o cluster de banco da prefeitura resolvida pelo
+// middleware Tenant, que precisa vir antes; sem prefeitura no contexto segue no banco padrão.
+func TenantDatabase(router TenantRouter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, ok := GetTenant(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, err := router.WithTenant(r.Context(), tenantID)
+			if errors.Is(err, db.ErrTenantFrozen) {
+				w.Header().Set("Retry-After", "30")
+				writeError(w, http.StatusServiceUnavailable, "TENANT_MIGRATING", "prefeitura em migração de banco; tente novamente em instantes")
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusServiceUnavailable, "DATABASE_UNAVAILABLE", "banco da prefeitura indisponível")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// TenantDatabaseSlot reserva no contexto o lugar do pool da prefeitura (db.WithPoolSlot) para as
+// rotas em que ela só é conhecida dentro do handler, como secretaria e tenant admin; o handler o
+// preenche depois de resolver o escopo, e tudo o que rodar sob a requisição segue esse pool.
+func TenantDatabaseSlot(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(db.WithPoolSlot(r.Context())))
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type stubTenantResolver map[string]uuid.UUID
+
+func (s stubTenantResolver) ResolveTenantID(_ context.Context, host string) (uuid.UUID, error) {
+	if id, ok := s[host]; ok {
+		return id, nil
+	}
+	return uuid.Nil, ErrTenantNotFound
+}
+
+func TestTenantInjectsResolvedTenant(t *testing.T) {
+	zabele := uuid.New()
+	resolver := stubTenantResolver{"zabele.pb.gov.br": zabele}
+	var seen uuid.UUID
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = GetTenant(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	do := func(host, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		Tenant(resolver)(next).ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("zabele.pb.gov.br", "/prof/turmas"); rec.Code != http.StatusOK || seen != zabele {
+		t.Fatalf("expected tenant injected, got %d %s", rec.Code, seen)
+	}
+	seen = uuid.Nil
+	if rec := do("api.example.com", "/prof/turmas"); rec.Code != http.StatusNotFound || seen != uuid.Nil {
+		t.Fatalf("expected unknown host rejected, got %d", rec.Code)
+	}
+	if rec := do("api.example.com", "/prof/turmas?domain=zabele.pb.gov.br"); rec.Code != http.StatusNotFound || seen != uuid.Nil {
+		t.Fatalf("expected ?domain= ignored on authenticated routes, got %d %s", rec.Code, seen)
+	}
+	if _, ok := GetTenant(context.Background()); ok {
+		t.Fatal("expected no tenant outside middleware")
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TermsGate informa as versões de termos pendentes de aceite para o usuário.
+type TermsGate interface {
+	Gate(ctx context.Context, audience, subject string) (pending []string, blocking bool, err error)
+}
+
+// Terms sinaliza no cabeçalho X-Terms-Pending as versões ainda não aceitas e, quando alguma é
+// bloqueante, recusa a requisição até o aceite. Caminhos com os prefixos isentos (ex.: o próprio
+// endpoint de aceite) sempre passam. Deve vir depois de Auth; falhas na consulta não bloqueiam.
+func Terms(gate TermsGate, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+			pending, blocking, err := gate.Gate(ctx, GetAudience(r.Context()), GetSubject(r.Context()))
+			cancel()
+			if err != nil {
+				log.Warn().Err(err).Msg("falha ao verificar aceite de termos")
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(pending) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-Terms-Pending", strings.Join(pending, ","))
+			if blocking && !hasPrefix(r.URL.Path, exempt) {
+				writeError(w, http.StatusForbidden, "TERMS_NOT_ACCEPTED", "aceite os termos vigentes para continuar")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP devolve o IP do cliente considerando os cabeçalhos do proxy.
+func ClientIP(r *http.Request) string {
+	return realIPFromRequest(r)
+}
+
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
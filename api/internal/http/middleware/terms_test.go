@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type stubTermsGate struct {
+	pending  []string
+	blocking bool
+}
+
+func (s stubTermsGate) Gate(context.Context, string, string) ([]string, bool, error) {
+	return s.pending, s.blocking, nil
+}
+
+func TestTermsBlocksUntilAccepted(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	do := func(gate TermsGate, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		Terms(gate, "/terms")(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	blocking := stubTermsGate{pending: []string{"tos:2026.2"}, blocking: true}
+	if rec := do(blocking, "/secretaria/presenca/ao-vivo"); rec.Code != http.StatusForbidden || rec.Header().Get("X-Terms-Pending") != "tos:2026.2" {
+		t.Fatalf("expected blocked request flagged with pending version, got %d %q", rec.Code, rec.Header().Get("X-Terms-Pending"))
+	}
+	if rec := do(blocking, "/terms/accept"); rec.Code != http.StatusOK {
+		t.Fatalf("expected acceptance endpoint exempt, got %d", rec.Code)
+	}
+	if rec := do(stubTermsGate{pending: []string{"dpa:3"}}, "/secretaria/presenca/ao-vivo"); rec.Code != http.StatusOK || rec.Header().Get("X-Terms-Pending") != "dpa:3" {
+		t.Fatalf("expected non-blocking version only flagged, got %d", rec.Code)
+	}
+	if rec := do(stubTermsGate{}, "/me"); rec.Code != http.StatusOK || rec.Header().Get("X-Terms-Pending") != "" {
+		t.Fatalf("expected no flag without pending versions, got %d", rec.Code)
+	}
+}
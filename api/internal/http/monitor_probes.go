@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/config"
+	"github.com/gestaozabele/municipio/internal/mail"
+	"github.com/gestaozabele/municipio/internal/monitor"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// platformProbes monta as verificações sintéticas das dependências configuradas: provedor de
+// e-mail sem envio, gravação de um objeto de controle no bucket e os endpoints HTTP informados.
+func platformProbes(cfg *config.Config, mailer mail.Sender, uploader storage.Uploader) []monitor.Probe {
+	var probes []monitor.Probe
+
+	if pinger, ok := mailer.(mail.Pinger); ok {
+		probes = append(probes, monitor.NewProbe("email", pinger.Ping))
+	}
+
+	switch uploader.(type) {
+	case nil, storage.NoopUploader, *storage.NoopUploader:
+	default:
+		probes = append(probes, monitor.NewProbe("storage", func(ctx context.Context) error {
+			_, err := uploader.Upload(ctx, storage.UploadInput{
+				Key:          "_monitor/healthcheck.txt",
+				Body:         []byte(fmt.Sprintf("ok %s\n", time.Now().UTC().Format(time.RFC3339))),
+				ContentType:  "text/plain; charset=utf-8",
+				CacheControl: "no-store",
+			})
+			return err
+		}))
+	}
+
+	names := make([]string, 0, len(cfg.Monitoring.DependencyURLs))
+	for name := range cfg.Monitoring.DependencyURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	client := &http.Client{Timeout: cfg.Monitoring.RequestTimeout}
+	for _, name := range names {
+		probes = append(probes, monitor.NewHTTPProbe(name, cfg.Monitoring.DependencyURLs[name], client))
+	}
+	return probes
+}
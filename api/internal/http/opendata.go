@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/opendata"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+// OpenDataCatalog publica o catálogo DCAT (JSON-LD) dos dados abertos do tenant do domínio.
+func (h *Handler) OpenDataCatalog(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	resources, err := opendata.Resources(r.Context(), h.pool, tenantInfo.ID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar catálogo", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ld+json; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(opendata.DCATCatalog(openDataPublisher(tenantInfo), resources))
+}
+
+// CKANPackageList responde como /api/3/action/package_list do CKAN, para coletores de portais.
+func (h *Handler) CKANPackageList(w http.ResponseWriter, r *http.Request) {
+	_, resources, ok := h.ckanResources(w, r)
+	if !ok {
+		return
+	}
+	writeCKAN(w, r, http.StatusOK, opendata.CKANPackageList(resources), nil)
+}
+
+// CKANPackageShow responde como /api/3/action/package_show?id= do CKAN.
+func (h *Handler) CKANPackageShow(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, resources, ok := h.ckanResources(w, r)
+	if !ok {
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("id"))
+	name = strings.TrimPrefix(name, tenantInfo.Slug+"/")
+	ds, found := opendata.Lookup(name)
+	var pkg map[string]any
+	if found {
+		pkg = opendata.CKANPackage(openDataPublisher(tenantInfo), ds, resources)
+	}
+	if pkg == nil || pkg["num_resources"] == 0 {
+		writeCKAN(w, r, http.StatusNotFound, nil, map[string]any{"__type": "Not Found Error", "message": "Não encontrado"})
+		return
+	}
+	writeCKAN(w, r, http.StatusOK, pkg, nil)
+}
+
+// ListTenantOpenData lista os arquivos de dados abertos publicados para o tenant.
+func (h *Handler) ListTenantOpenData(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	resources, err := opendata.Resources(r.Context(), h.pool, tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar dados abertos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"resources": resources, "enabled": h.opendata != nil})
+}
+
+// ExportTenantOpenData regera agora os dados abertos do tenant, sem esperar o agendamento.
+func (h *Handler) ExportTenantOpenData(w http.ResponseWriter, r *http.Request) {
+	if h.opendata == nil {
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "storage não configurado para dados abertos", nil)
+		return
+	}
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	tenantInfo, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, tenant.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "tenant não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenant", nil)
+		return
+	}
+
+	resources, err := h.opendata.ExportTenant(r.Context(), tenantInfo.ID, tenantInfo.Slug)
+	if err != nil {
+		WriteError(w, http.StatusBadGateway, "EXPORT_FAILED", "falha ao gerar dados abertos", map[string]any{"error": err.Error()})
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"resources": resources})
+}
+
+func (h *Handler) ckanResources(w http.ResponseWriter, r *http.Request) (*tenant.Tenant, []opendata.Resource, bool) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	resources, err := opendata.Resources(r.Context(), h.pool, tenantInfo.ID)
+	if err != nil {
+		writeCKAN(w, r, http.StatusInternalServerError, nil, map[string]any{"__type": "Internal Error", "message": "falha ao carregar pacotes"})
+		return nil, nil, false
+	}
+	return tenantInfo, resources, true
+}
+
+// writeCKAN usa o envelope da Action API do CKAN em vez do envelope padrão da API.
+func writeCKAN(w http.ResponseWriter, r *http.Request, status int, result any, ckanErr map[string]any) {
+	body := map[string]any{"help": r.URL.Path, "success": ckanErr == nil}
+	if ckanErr != nil {
+		body["error"] = ckanErr
+	} else {
+		body["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func openDataPublisher(t *tenant.Tenant) opendata.Publisher {
+	publisher := opendata.Publisher{Slug: t.Slug, Name: t.DisplayName}
+	if t.Domain != "" {
+		publisher.Homepage = "https://" + t.Domain
+	}
+	return publisher
+}
@@ -0,0 +1,549 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/estoque"
+	"github.com/gestaozabele/municipio/internal/ordem"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// ordemFotoMaxBytes limita cada foto enviada pelo aplicativo de campo.
+const ordemFotoMaxBytes = 12 << 20
+
+var ordemFotoTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true, "image/heic": true}
+
+type equipePayload struct {
+	SecretariaID string `json:"secretaria_id"`
+	Nome         string `json:"nome"`
+	Ativo        *bool  `json:"ativo"`
+}
+
+type ordemMaterialPayload struct {
+	ItemID        *string  `json:"item_id"`
+	Descricao     string   `json:"descricao"`
+	Quantidade    float64  `json:"quantidade"`
+	Unidade       string   `json:"unidade"`
+	CustoUnitario *float64 `json:"custo_unitario"`
+}
+
+// ListEquipes lista as equipes de campo da prefeitura com os membros.
+func (h *Handler) ListEquipes(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	equipes, err := h.ordens.ListEquipes(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar equipes", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"equipes": equipes})
+}
+
+// CreateEquipe cadastra uma equipe de campo numa secretaria da prefeitura.
+func (h *Handler) CreateEquipe(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeEquipe(w, r)
+	if !ok {
+		return
+	}
+	if input.SecretariaID == uuid.Nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id obrigatório", nil)
+		return
+	}
+	equipe, err := h.ordens.CreateEquipe(r.Context(), tenantID, input)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"equipe": equipe})
+}
+
+// UpdateEquipe altera nome e situação da equipe.
+func (h *Handler) UpdateEquipe(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeEquipe(w, r)
+	if !ok {
+		return
+	}
+	equipe, err := h.ordens.UpdateEquipe(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"equipe": equipe})
+}
+
+// SetEquipeMembros substitui os membros da equipe ({"usuarios": [...]}).
+func (h *Handler) SetEquipeMembros(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Usuarios []uuid.UUID `json:"usuarios"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if payload.Usuarios == nil {
+		payload.Usuarios = []uuid.UUID{}
+	}
+	membros, err := h.ordens.SetMembros(r.Context(), tenantID, id, payload.Usuarios)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"membros": membros})
+}
+
+// CreateOrdemServico converte o protocolo em ordem de serviço, opcionalmente já agendada para uma equipe.
+func (h *Handler) CreateOrdemServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	protocoloID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		EquipeID     *string                `json:"equipe_id"`
+		Descricao    string                 `json:"descricao"`
+		AgendadaPara *time.Time             `json:"agendada_para"`
+		Materiais    []ordemMaterialPayload `json:"materiais"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	materiais, err := ordemMateriais(payload.Materiais)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "item_id inválido", nil)
+		return
+	}
+	input := ordem.NovaOrdem{
+		ProtocoloID:  protocoloID,
+		Descricao:    payload.Descricao,
+		AgendadaPara: payload.AgendadaPara,
+		Materiais:    materiais,
+		ActorID:      &userID,
+	}
+	if input.EquipeID, err = optionalUUID(payload.EquipeID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "equipe_id inválido", nil)
+		return
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	created, err := h.ordens.Create(r.Context(), tenantID, input)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"ordem": created})
+}
+
+// ListOrdensServico lista as ordens (?status=&equipe_id=&from=&to=&limit=). Com ?minhas=true, só as
+// das equipes do usuário — é a agenda do aplicativo de campo.
+func (h *Handler) ListOrdensServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := ordem.Filter{Status: strings.TrimSpace(query.Get("status"))}
+	raw := query.Get("equipe_id")
+	equipeID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "equipe_id inválido", nil)
+		return
+	}
+	filter.EquipeID = equipeID
+	if minhas, _ := strconv.ParseBool(query.Get("minhas")); minhas {
+		filter.UsuarioID = &userID
+	}
+	if value := strings.TrimSpace(query.Get("from")); value != "" {
+		from, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		filter.From = &from
+	}
+	if value := strings.TrimSpace(query.Get("to")); value != "" {
+		to, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		filter.To = &to
+	}
+	if value := strings.TrimSpace(query.Get("limit")); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	ordens, err := h.ordens.List(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar ordens de serviço", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordens": ordens})
+}
+
+// GetOrdemServico detalha a ordem com materiais e fotos.
+func (h *Handler) GetOrdemServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	o, err := h.ordens.Get(r.Context(), tenantID, id)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordem": o})
+}
+
+// ScheduleOrdemServico agenda (ou reagenda) a ordem para uma equipe.
+func (h *Handler) ScheduleOrdemServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		EquipeID     string     `json:"equipe_id"`
+		AgendadaPara *time.Time `json:"agendada_para"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	equipeID, err := uuid.Parse(strings.TrimSpace(payload.EquipeID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "equipe_id inválido", nil)
+		return
+	}
+	if payload.AgendadaPara == nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "agendada_para obrigatório", nil)
+		return
+	}
+	o, err := h.ordens.Schedule(r.Context(), tenantID, id, equipeID, *payload.AgendadaPara, &userID)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordem": o})
+}
+
+// StartOrdemServico marca a chegada da equipe em campo.
+func (h *Handler) StartOrdemServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	o, err := h.ordens.Start(r.Context(), tenantID, id, &userID)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordem": o})
+}
+
+// CompleteOrdemServico encerra a ordem com a assinatura de quem recebeu o serviço; o protocolo é
+// concluído e o cidadão passa a vê-lo assim.
+func (h *Handler) CompleteOrdemServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Nota           *string `json:"nota"`
+		AssinaturaNome string  `json:"assinatura_nome"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := ordem.Conclusao{Nota: payload.Nota, AssinaturaNome: payload.AssinaturaNome, AtorID: &userID}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	o, err := h.ordens.Complete(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordem": o})
+}
+
+// CancelOrdemServico cancela a ordem; o motivo vai para o histórico do protocolo.
+func (h *Handler) CancelOrdemServico(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Motivo string `json:"motivo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	motivo := strings.TrimSpace(payload.Motivo)
+	if motivo == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "motivo obrigatório", nil)
+		return
+	}
+	o, err := h.ordens.Cancel(r.Context(), tenantID, id, motivo, &userID)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordem": o})
+}
+
+// SetOrdemServicoMateriais substitui a lista de materiais ({"materiais": [...]}).
+func (h *Handler) SetOrdemServicoMateriais(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Materiais []ordemMaterialPayload `json:"materiais"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	materiais, err := ordemMateriais(payload.Materiais)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "item_id inválido", nil)
+		return
+	}
+	if err := ordem.NormalizeMateriais(materiais); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	o, err := h.ordens.SetMateriais(r.Context(), tenantID, id, materiais)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ordem": o})
+}
+
+// UploadOrdemServicoFoto recebe uma foto do aplicativo de campo: multipart com "foto", "fase"
+// (antes ou depois) e, se o aparelho informar, "latitude", "longitude" e "tirada_em" (RFC 3339).
+func (h *Handler) UploadOrdemServicoFoto(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	switch h.storage.(type) {
+	case nil, storage.NoopUploader, *storage.NoopUploader:
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, ordemFotoMaxBytes+(1<<20))
+	if err := r.ParseMultipartForm(ordemFotoMaxBytes); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "formulário inválido ou grande demais", nil)
+		return
+	}
+	foto := ordem.Foto{Fase: strings.ToLower(strings.TrimSpace(r.FormValue("fase"))), EnviadoPor: &userID}
+	if !ordem.ValidFase(foto.Fase) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fase deve ser antes ou depois", nil)
+		return
+	}
+	lat, lng := strings.TrimSpace(r.FormValue("latitude")), strings.TrimSpace(r.FormValue("longitude"))
+	if lat != "" || lng != "" {
+		latitude, errLat := strconv.ParseFloat(lat, 64)
+		longitude, errLng := strconv.ParseFloat(lng, 64)
+		if errLat != nil || errLng != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "localização inválida", nil)
+			return
+		}
+		foto.Latitude, foto.Longitude = &latitude, &longitude
+	}
+	if raw := strings.TrimSpace(r.FormValue("tirada_em")); raw != "" {
+		tirada, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "tirada_em inválido", nil)
+			return
+		}
+		foto.TiradaEm = &tirada
+	}
+	header, err := getFirstFile(r.MultipartForm, "foto")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	data, contentType, err := readMultipartFile(header, ordemFotoMaxBytes)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if !ordemFotoTypes[contentType] {
+		WriteError(w, http.StatusUnsupportedMediaType, "VALIDATION", "foto deve ser JPEG, PNG, WebP ou HEIC", nil)
+		return
+	}
+	if h.scanner != nil {
+		if result, err := h.scanner.Scan(r.Context(), data); err != nil || result.Infected() {
+			log.Warn().Err(err).Str("ordem", id.String()).Str("signature", result.Signature).Msg("ordem: foto recusada pelo antivírus")
+			WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "foto recusada pelo antivírus", nil)
+			return
+		}
+	}
+
+	foto.FileName = filepath.Base(header.Filename)
+	foto.ContentType = contentType
+	foto.SizeBytes = int64(len(data))
+	foto.ObjectKey = fmt.Sprintf("ordens/%s/%s/%s-%s%s", tenantID, id, foto.Fase, uuid.NewString(), strings.ToLower(filepath.Ext(header.Filename)))
+	result, err := h.storage.Upload(r.Context(), storage.UploadInput{
+		Key:          foto.ObjectKey,
+		Body:         data,
+		ContentType:  contentType,
+		CacheControl: "private,max-age=31536000",
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível enviar a foto", nil)
+		return
+	}
+	foto.FileURL = result.URL
+	created, err := h.ordens.AddFoto(r.Context(), tenantID, id, foto)
+	if err != nil {
+		writeOrdemError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"foto": created})
+}
+
+func decodeEquipe(w http.ResponseWriter, r *http.Request) (ordem.EquipeInput, bool) {
+	var payload equipePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return ordem.EquipeInput{}, false
+	}
+	input := ordem.EquipeInput{Nome: payload.Nome, Ativo: payload.Ativo == nil || *payload.Ativo}
+	if raw := strings.TrimSpace(payload.SecretariaID); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return ordem.EquipeInput{}, false
+		}
+		input.SecretariaID = id
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return ordem.EquipeInput{}, false
+	}
+	return input, true
+}
+
+func ordemMateriais(payload []ordemMaterialPayload) ([]ordem.Material, error) {
+	materiais := make([]ordem.Material, 0, len(payload))
+	for _, m := range payload {
+		itemID, err := optionalUUID(m.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		materiais = append(materiais, ordem.Material{ItemID: itemID, Descricao: m.Descricao, Quantidade: m.Quantidade, Unidade: m.Unidade, CustoUnitario: m.CustoUnitario})
+	}
+	return materiais, nil
+}
+
+func writeOrdemError(w http.ResponseWriter, err error) {
+	var saldoErr *estoque.SaldoError
+	switch {
+	case errors.As(err, &saldoErr):
+		WriteError(w, http.StatusConflict, "CONFLICT", "saldo insuficiente no almoxarifado", map[string]any{"item": saldoErr.Item, "saldo": saldoErr.Saldo})
+	case errors.Is(err, estoque.ErrNotFound), errors.Is(err, estoque.ErrInactive):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "item de estoque inválido ou inativo", nil)
+	case errors.Is(err, ordem.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "não encontrado", nil)
+	case errors.Is(err, ordem.ErrProtocolo):
+		WriteError(w, http.StatusConflict, "CONFLICT", "protocolo inexistente ou já encerrado", nil)
+	case errors.Is(err, ordem.ErrOpenOrder):
+		WriteError(w, http.StatusConflict, "CONFLICT", "protocolo já possui ordem de serviço em aberto", nil)
+	case errors.Is(err, ordem.ErrEquipe):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "equipe inválida ou inativa", nil)
+	case errors.Is(err, ordem.ErrEquipeDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existe equipe com este nome na secretaria", nil)
+	case errors.Is(err, ordem.ErrNotMember):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "usuário não pertence à secretaria da equipe", nil)
+	case errors.Is(err, ordem.ErrInvalidTransition):
+		WriteError(w, http.StatusConflict, "CONFLICT", "operação não permitida na situação atual da ordem", nil)
+	case errors.Is(err, ordem.ErrFotoDepois):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "envie ao menos uma foto de depois antes de concluir", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a ordem de serviço", nil)
+	}
+}
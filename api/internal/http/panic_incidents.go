@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/errtrack"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/monitor"
+	"github.com/gestaozabele/municipio/internal/support"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+const (
+	// incidentCooldown evita consultas repetidas ao banco numa rajada do mesmo panic.
+	incidentCooldown = time.Minute
+	// incidentStackLimit limita o stack trace gravado no chamado.
+	incidentStackLimit = 16 * 1024
+)
+
+// panicIncidents abre chamado interno de incidente para panics em rotas de tenant, para que
+// quedas não fiquem visíveis apenas nos logs.
+type panicIncidents struct {
+	support  *support.Service
+	tenants  *tenant.Service
+	notifier monitor.Notifier
+
+	mu     sync.Mutex
+	recent map[string]time.Time
+}
+
+func newPanicIncidents(supportService *support.Service, tenants *tenant.Service, notifier monitor.Notifier) *panicIncidents {
+	return &panicIncidents{
+		support:  supportService,
+		tenants:  tenants,
+		notifier: notifier,
+		recent:   make(map[string]time.Time),
+	}
+}
+
+// ReportPanic implementa httpmiddleware.PanicReporter. O registro roda em segundo plano para
+// não atrasar a resposta de erro.
+func (p *panicIncidents) ReportPanic(_ context.Context, report httpmiddleware.PanicReport) {
+	r := report.Request
+	if r == nil || strings.HasPrefix(r.URL.Path, "/saas") || strings.HasPrefix(r.URL.Path, "/health") {
+		return
+	}
+	host := requestHost(r)
+	if host == "" {
+		return
+	}
+	route := report.Route
+	if route == "" {
+		route = r.URL.Path
+	}
+	fingerprint := support.PanicFingerprint(route, report.Stack)
+	if !p.allow(host+"|"+fingerprint, time.Now()) {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Panic recuperado em %s %s.\n\n", r.Method, route)
+	fmt.Fprintf(&b, "Erro: %s\n", errtrack.Scrub(fmt.Sprint(report.Value)))
+	fmt.Fprintf(&b, "URL: %s\n", errtrack.ScrubURL(r.URL.RequestURI()))
+	fmt.Fprintf(&b, "Domínio: %s\n", host)
+	if report.RequestID != "" {
+		fmt.Fprintf(&b, "Request ID: %s\n", report.RequestID)
+	}
+	if report.Audience != "" {
+		fmt.Fprintf(&b, "Público: %s\n", report.Audience)
+	}
+	if report.Subject != "" {
+		fmt.Fprintf(&b, "Usuário: %s\n", report.Subject)
+	}
+	fmt.Fprintf(&b, "Ocorrido em: %s\n", time.Now().UTC().Format(time.RFC3339))
+	stack := report.Stack
+	if len(stack) > incidentStackLimit {
+		stack = stack[:incidentStackLimit]
+	}
+	fmt.Fprintf(&b, "\nStack trace:\n%s", stack)
+
+	subject := fmt.Sprintf("Erro interno em %s %s", r.Method, route)
+	description := b.String()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		t, err := p.tenants.Resolve(ctx, host)
+		if err != nil {
+			return
+		}
+		ticket, created, err := p.support.OpenIncident(ctx, support.Incident{
+			TenantID:    t.ID,
+			Fingerprint: fingerprint,
+			Subject:     subject,
+			Description: description,
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("tenant", t.Slug).Str("route", route).Msg("incidente: falha ao abrir chamado")
+			return
+		}
+		if created {
+			p.notify(ctx, t, ticket.ID, subject)
+		}
+	}()
+}
+
+// allow aplica o intervalo mínimo entre registros da mesma assinatura no mesmo domínio.
+func (p *panicIncidents) allow(key string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, at := range p.recent {
+		if now.Sub(at) >= incidentCooldown {
+			delete(p.recent, k)
+		}
+	}
+	if _, ok := p.recent[key]; ok {
+		return false
+	}
+	p.recent[key] = now
+	return true
+}
+
+func (p *panicIncidents) notify(ctx context.Context, t *tenant.Tenant, ticketID uuid.UUID, subject string) {
+	if p.notifier == nil {
+		return
+	}
+	msg := monitor.AlertMessage{
+		Title:    "Incidente aberto automaticamente",
+		Text:     fmt.Sprintf("%s — %s (chamado %s)", t.DisplayName, subject, ticketID),
+		Severity: "critical",
+	}
+	if err := p.notifier.Notify(ctx, msg); err != nil {
+		log.Warn().Err(err).Str("ticket", ticketID.String()).Msg("incidente: falha ao notificar")
+	}
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/notify"
+)
+
+// GetMyPreferences devolve canais e categorias de notificação do usuário autenticado.
+func (h *Handler) GetMyPreferences(w http.ResponseWriter, r *http.Request) {
+	audience, userID, ok := preferenceOwner(w, r)
+	if !ok {
+		return
+	}
+	prefs, err := h.dispatcher.Preferences(r.Context(), audience, userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar preferências", nil)
+		return
+	}
+	writePreferences(w, prefs)
+}
+
+// UpdateMyPreferences altera canais e categorias recusadas; campos omitidos ficam como estão.
+func (h *Handler) UpdateMyPreferences(w http.ResponseWriter, r *http.Request) {
+	audience, userID, ok := preferenceOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Channels map[string]bool `json:"channels"`
+		OptOuts  []string        `json:"opt_outs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	prefs, err := h.dispatcher.UpdatePreferences(r.Context(), audience, userID, payload.Channels, payload.OptOuts)
+	if err != nil {
+		if errors.Is(err, notify.ErrInvalidChannel) || errors.Is(err, notify.ErrInvalidCategory) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar preferências", nil)
+		return
+	}
+	writePreferences(w, prefs)
+}
+
+func preferenceOwner(w http.ResponseWriter, r *http.Request) (string, uuid.UUID, bool) {
+	userID, err := uuid.Parse(httpmiddleware.GetSubject(r.Context()))
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "subject inválido", nil)
+		return "", uuid.Nil, false
+	}
+	return httpmiddleware.GetAudience(r.Context()), userID, true
+}
+
+func writePreferences(w http.ResponseWriter, prefs notify.Preferences) {
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+		"available": map[string]any{
+			"channels":   notify.Channels,
+			"categories": notify.Categories,
+			"mandatory":  []string{notify.CategorySeguranca},
+		},
+	})
+}
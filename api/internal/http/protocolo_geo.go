@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/protocolo"
+)
+
+// ProtocoloGeoClusters devolve os protocolos da caixa do mapa agrupados em clusters
+// (?bbox=minLng,minLat,maxLng,maxLat&categoria_id=&status=&from=&to=).
+func (h *Handler) ProtocoloGeoClusters(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	box, err := protocolo.ParseBBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	filter, ok := parseProtocoloGeoFilter(w, r)
+	if !ok {
+		return
+	}
+	clusters, err := h.protocolos.GeoClusters(r.Context(), tenantID, box, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar o mapa", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"clusters": clusters, "grid": box.GridSize()})
+}
+
+// ProtocoloGeoBairros agrega os protocolos localizados por bairro (?categoria_id=&status=&from=&to=).
+func (h *Handler) ProtocoloGeoBairros(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := parseProtocoloGeoFilter(w, r)
+	if !ok {
+		return
+	}
+	bairros, err := h.protocolos.Bairros(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível agregar por bairro", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"bairros": bairros})
+}
+
+func parseProtocoloGeoFilter(w http.ResponseWriter, r *http.Request) (protocolo.GeoFilter, bool) {
+	query := r.URL.Query()
+	filter := protocolo.GeoFilter{Status: strings.TrimSpace(query.Get("status"))}
+	raw := query.Get("categoria_id")
+	categoriaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "categoria_id inválido", nil)
+		return filter, false
+	}
+	filter.CategoriaID = categoriaID
+	if value := strings.TrimSpace(query.Get("from")); value != "" {
+		from, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return filter, false
+		}
+		filter.From = &from
+	}
+	if value := strings.TrimSpace(query.Get("to")); value != "" {
+		to, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return filter, false
+		}
+		filter.To = &to
+	}
+	return filter, true
+}
@@ -0,0 +1,400 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/protocolo"
+)
+
+type protocoloFilaPayload struct {
+	SecretariaID string `json:"secretaria_id"`
+	Nome         string `json:"nome"`
+	Estrategia   string `json:"estrategia"`
+	Ativo        *bool  `json:"ativo"`
+}
+
+// ListProtocoloFilas lista as filas de atendimento com os membros e a carga atual de cada um.
+func (h *Handler) ListProtocoloFilas(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	filas, err := h.protocolos.ListFilas(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar filas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"filas": filas})
+}
+
+// CreateProtocoloFila cadastra uma fila numa secretaria da prefeitura.
+func (h *Handler) CreateProtocoloFila(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeProtocoloFila(w, r)
+	if !ok {
+		return
+	}
+	if input.SecretariaID == uuid.Nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id obrigatório", nil)
+		return
+	}
+	fila, err := h.protocolos.CreateFila(r.Context(), tenantID, input)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"fila": fila})
+}
+
+// UpdateProtocoloFila altera nome, estratégia e situação da fila.
+func (h *Handler) UpdateProtocoloFila(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeProtocoloFila(w, r)
+	if !ok {
+		return
+	}
+	fila, err := h.protocolos.UpdateFila(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"fila": fila})
+}
+
+// SetProtocoloFilaMembros define os atendentes da fila.
+func (h *Handler) SetProtocoloFilaMembros(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Usuarios []string `json:"usuarios"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	usuarios := make([]uuid.UUID, 0, len(payload.Usuarios))
+	for _, raw := range payload.Usuarios {
+		usuarioID, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "usuário inválido", nil)
+			return
+		}
+		usuarios = append(usuarios, usuarioID)
+	}
+	membros, err := h.protocolos.SetMembros(r.Context(), tenantID, id, usuarios)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"membros": membros})
+}
+
+// ProtocoloFilaMetrics mede a produtividade das filas no período (?from=&to=, padrão últimos 30 dias).
+func (h *Handler) ProtocoloFilaMetrics(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+	var err error
+	if value := strings.TrimSpace(r.URL.Query().Get("from")); value != "" {
+		if from, err = parseISODate(value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+	}
+	if value := strings.TrimSpace(r.URL.Query().Get("to")); value != "" {
+		if to, err = parseISODate(value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+	}
+	if !to.After(from) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+		return
+	}
+	metrics, err := h.protocolos.QueueMetrics(r.Context(), tenantID, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível calcular métricas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"from": from, "to": to, "filas": metrics})
+}
+
+// ListProtocoloRegras lista o roteamento configurado por categoria.
+func (h *Handler) ListProtocoloRegras(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	regras, err := h.protocolos.ListRegras(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar regras", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"regras": regras})
+}
+
+// SaveProtocoloRegra define a secretaria (e a fila) que recebe os protocolos da categoria.
+func (h *Handler) SaveProtocoloRegra(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	categoriaID, err := parseUUIDParam(r, "categoriaID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "categoria inválida", nil)
+		return
+	}
+	var payload struct {
+		SecretariaID string  `json:"secretaria_id"`
+		FilaID       *string `json:"fila_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	regra := protocolo.Regra{CategoriaID: categoriaID}
+	if regra.SecretariaID, err = uuid.Parse(strings.TrimSpace(payload.SecretariaID)); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	if regra.FilaID, err = optionalUUID(payload.FilaID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fila_id inválido", nil)
+		return
+	}
+	saved, err := h.protocolos.SaveRegra(r.Context(), tenantID, regra)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"regra": saved})
+}
+
+// DeleteProtocoloRegra remove o roteamento da categoria.
+func (h *Handler) DeleteProtocoloRegra(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	categoriaID, err := parseUUIDParam(r, "categoriaID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "categoria inválida", nil)
+		return
+	}
+	if err := h.protocolos.DeleteRegra(r.Context(), tenantID, categoriaID); err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSecretariaProtocolos lista a caixa de protocolos
+// (?secretaria_id=&fila_id=&responsavel_id=&duplicado_de=&status=&limit=).
+func (h *Handler) ListSecretariaProtocolos(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := protocolo.ProtocoloFilter{Status: strings.TrimSpace(query.Get("status"))}
+	for param, target := range map[string]**uuid.UUID{
+		"secretaria_id":  &filter.SecretariaID,
+		"fila_id":        &filter.FilaID,
+		"responsavel_id": &filter.ResponsavelID,
+		"duplicado_de":   &filter.DuplicadoDe,
+	} {
+		raw := strings.TrimSpace(query.Get(param))
+		if raw == "me" && param == "responsavel_id" {
+			*target = &userID
+			continue
+		}
+		id, err := optionalUUID(&raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", param+" inválido", nil)
+			return
+		}
+		*target = id
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+	protocolos, err := h.protocolos.ListProtocolos(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar protocolos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolos": protocolos})
+}
+
+// GetSecretariaProtocolo detalha o protocolo com anexos e histórico de tramitação.
+func (h *Handler) GetSecretariaProtocolo(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	p, err := h.protocolos.GetProtocolo(r.Context(), tenantID, id)
+	if err == nil {
+		p.Eventos, err = h.protocolos.ListEventos(r.Context(), p.ID)
+	}
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolo": p})
+}
+
+// TransferProtocolo move o protocolo para outra secretaria/fila; o motivo fica no histórico.
+func (h *Handler) TransferProtocolo(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		SecretariaID string  `json:"secretaria_id"`
+		FilaID       *string `json:"fila_id"`
+		Motivo       string  `json:"motivo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	t := protocolo.Transferencia{Motivo: strings.TrimSpace(payload.Motivo), AtorID: &userID}
+	if t.SecretariaID, err = uuid.Parse(strings.TrimSpace(payload.SecretariaID)); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	if t.FilaID, err = optionalUUID(payload.FilaID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fila_id inválido", nil)
+		return
+	}
+	if t.Motivo == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "motivo obrigatório", nil)
+		return
+	}
+	p, err := h.protocolos.Transfer(r.Context(), tenantID, id, t)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolo": p})
+}
+
+// AssignProtocolo atribui o protocolo manualmente a um atendente.
+func (h *Handler) AssignProtocolo(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		UsuarioID string `json:"usuario_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	usuarioID, err := uuid.Parse(strings.TrimSpace(payload.UsuarioID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuario_id inválido", nil)
+		return
+	}
+	p, err := h.protocolos.Assign(r.Context(), tenantID, id, usuarioID, &userID)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolo": p})
+}
+
+// SetProtocoloStatus muda o status do protocolo (em_andamento, concluido ou cancelado).
+func (h *Handler) SetProtocoloStatus(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Status string  `json:"status"`
+		Motivo *string `json:"motivo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(payload.Status))
+	p, err := h.protocolos.SetStatus(r.Context(), tenantID, id, status, trimmedOrNil(payload.Motivo), &userID)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolo": p})
+}
+
+func decodeProtocoloFila(w http.ResponseWriter, r *http.Request) (protocolo.FilaInput, bool) {
+	var payload protocoloFilaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return protocolo.FilaInput{}, false
+	}
+	input := protocolo.FilaInput{Nome: payload.Nome, Estrategia: payload.Estrategia, Ativo: true}
+	if payload.Ativo != nil {
+		input.Ativo = *payload.Ativo
+	}
+	if id, err := uuid.Parse(strings.TrimSpace(payload.SecretariaID)); err == nil {
+		input.SecretariaID = id
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return protocolo.FilaInput{}, false
+	}
+	return input, true
+}
@@ -0,0 +1,463 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/ativo"
+	"github.com/gestaozabele/municipio/internal/protocolo"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// protocoloMaxBody limita o corpo da abertura de protocolo (dados + anexos).
+const protocoloMaxBody = 64 << 20
+
+type protocoloCategoriaPayload struct {
+	SecretariaID    *string        `json:"secretaria_id"`
+	Slug            string         `json:"slug"`
+	Nome            string         `json:"nome"`
+	Descricao       *string        `json:"descricao"`
+	Ativo           *bool          `json:"ativo"`
+	Form            protocolo.Form `json:"form"`
+	DedupRaioMetros *int           `json:"dedup_raio_metros"`
+	DedupJanelaDias int            `json:"dedup_janela_dias"`
+}
+
+// protocoloSubmission é a abertura de protocolo enviada pelo cidadão, ainda não validada.
+type protocoloSubmission struct {
+	Categoria   string
+	Dados       map[string]any
+	Localizacao *protocolo.Ponto
+	Bairro      *string
+	AtivoToken  string
+	AtivoID     *uuid.UUID
+	Files       []protocoloFile
+}
+
+type protocoloFile struct {
+	upload protocolo.Upload
+	data   []byte
+}
+
+// ListProtocoloCategorias lista as categorias de protocolo da prefeitura, inclusive as inativas.
+func (h *Handler) ListProtocoloCategorias(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	categorias, err := h.protocolos.ListCategorias(r.Context(), tenantID, false)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar categorias", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"categorias": categorias})
+}
+
+// CreateProtocoloCategoria cadastra uma categoria com o formulário que o cidadão preencherá.
+func (h *Handler) CreateProtocoloCategoria(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := h.decodeProtocoloCategoria(w, r, tenantID, actorID)
+	if !ok {
+		return
+	}
+	categoria, err := h.protocolos.CreateCategoria(r.Context(), tenantID, input)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"categoria": categoria})
+}
+
+// UpdateProtocoloCategoria substitui a categoria; alterar o formulário gera nova versão.
+func (h *Handler) UpdateProtocoloCategoria(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := h.decodeProtocoloCategoria(w, r, tenantID, actorID)
+	if !ok {
+		return
+	}
+	categoria, err := h.protocolos.UpdateCategoria(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"categoria": categoria})
+}
+
+// ListPublicProtocoloCategorias lista as categorias ativas da prefeitura do domínio, com os formulários.
+func (h *Handler) ListPublicProtocoloCategorias(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	categorias, err := h.protocolos.ListCategorias(r.Context(), tenantInfo.ID, true)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar categorias", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"categorias": categorias})
+}
+
+// CreateProtocolo abre um protocolo do cidadão. Aceita JSON ({categoria, dados, localizacao, bairro,
+// ativo}) ou multipart com "categoria", "dados" (JSON), "latitude", "longitude", "bairro", "ativo" (o
+// token da etiqueta QR) e os arquivos nos campos de anexo do formulário. A submissão é validada contra o formulário vigente antes de
+// qualquer envio ao armazenamento.
+func (h *Handler) CreateProtocolo(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, protocoloMaxBody)
+	submission, err := parseProtocoloSubmission(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	files := submission.Files
+
+	categoria, err := h.lookupProtocoloCategoria(r.Context(), tenantInfo.ID, submission.Categoria)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	if !categoria.Ativo {
+		writeProtocoloError(w, protocolo.ErrInactive)
+		return
+	}
+
+	if submission.AtivoToken != "" {
+		a, err := h.ativos.GetByQRToken(r.Context(), tenantInfo.ID, submission.AtivoToken)
+		if err != nil {
+			if errors.Is(err, ativo.ErrNotFound) {
+				WriteError(w, http.StatusBadRequest, "VALIDATION", "ativo inválido", nil)
+				return
+			}
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar o ativo", nil)
+			return
+		}
+		// Relatos feitos pela etiqueta herdam o local do ativo quando o aparelho não informa o seu.
+		submission.AtivoID = &a.ID
+		if submission.Localizacao == nil && a.Latitude != nil && a.Longitude != nil {
+			submission.Localizacao = &protocolo.Ponto{Lat: *a.Latitude, Lng: *a.Longitude}
+		}
+		if submission.Bairro == nil {
+			submission.Bairro = a.Bairro
+		}
+	}
+
+	uploads := make([]protocolo.Upload, 0, len(files))
+	for _, f := range files {
+		uploads = append(uploads, f.upload)
+	}
+	clean, err := categoria.Form.Validate(submission.Dados, uploads)
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+
+	novo := protocolo.NovoProtocolo{
+		ID:          uuid.New(),
+		TenantID:    tenantInfo.ID,
+		Categoria:   categoria,
+		CidadaoID:   cidadaoID,
+		Dados:       clean,
+		Localizacao: submission.Localizacao,
+		Bairro:      submission.Bairro,
+		AtivoID:     submission.AtivoID,
+		SubmittedAt: time.Now(),
+	}
+	if len(files) > 0 {
+		anexos, err := h.storeProtocoloFiles(r.Context(), tenantInfo.ID, novo.ID, files)
+		if err != nil {
+			if errors.Is(err, errProtocoloInfected) {
+				WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", err.Error(), nil)
+				return
+			}
+			WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "não foi possível armazenar os anexos", nil)
+			return
+		}
+		novo.Anexos = anexos
+	}
+
+	created, err := h.protocolos.CreateProtocolo(r.Context(), novo)
+	if err != nil {
+		log.Error().Err(err).Str("tenant", tenantInfo.Slug).Str("categoria", categoria.Slug).Msg("protocolo: falha ao registrar")
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar o protocolo", nil)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"protocolo": created})
+}
+
+// ListMyProtocolos lista os protocolos do cidadão na prefeitura do domínio.
+func (h *Handler) ListMyProtocolos(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	protocolos, err := h.protocolos.ListByCidadao(r.Context(), tenantInfo.ID, cidadaoID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar protocolos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolos": protocolos})
+}
+
+// GetMyProtocolo detalha um protocolo do próprio cidadão, com o andamento da ordem de serviço quando houver.
+func (h *Handler) GetMyProtocolo(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	p, err := h.protocolos.GetProtocolo(r.Context(), tenantInfo.ID, id)
+	if err == nil && p.CidadaoID != cidadaoID {
+		err = protocolo.ErrNotFound
+	}
+	if err != nil {
+		writeProtocoloError(w, err)
+		return
+	}
+	resumo, err := h.ordens.Resumo(r.Context(), tenantInfo.ID, p.ID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a ordem de serviço", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"protocolo": p, "ordem_servico": resumo})
+}
+
+func (h *Handler) decodeProtocoloCategoria(w http.ResponseWriter, r *http.Request, tenantID, actorID uuid.UUID) (protocolo.CategoriaInput, bool) {
+	var payload protocoloCategoriaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return protocolo.CategoriaInput{}, false
+	}
+	input := protocolo.CategoriaInput{
+		Slug:            payload.Slug,
+		Nome:            payload.Nome,
+		Descricao:       payload.Descricao,
+		Ativo:           true,
+		Form:            payload.Form,
+		ActorID:         &actorID,
+		DedupRaioMetros: payload.DedupRaioMetros,
+		DedupJanelaDias: payload.DedupJanelaDias,
+	}
+	if payload.Ativo != nil {
+		input.Ativo = *payload.Ativo
+	}
+	secretariaID, err := optionalUUID(payload.SecretariaID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return protocolo.CategoriaInput{}, false
+	}
+	if secretariaID != nil {
+		inTenant, err := h.protocolos.SecretariaInTenant(r.Context(), tenantID, *secretariaID)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível validar secretaria", nil)
+			return protocolo.CategoriaInput{}, false
+		}
+		if !inTenant {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria não pertence à prefeitura", nil)
+			return protocolo.CategoriaInput{}, false
+		}
+	}
+	input.SecretariaID = secretariaID
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return protocolo.CategoriaInput{}, false
+	}
+	return input, true
+}
+
+func (h *Handler) lookupProtocoloCategoria(ctx context.Context, tenantID uuid.UUID, ref string) (*protocolo.Categoria, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return h.protocolos.GetCategoria(ctx, tenantID, id)
+	}
+	return h.protocolos.GetCategoriaBySlug(ctx, tenantID, strings.ToLower(ref))
+}
+
+// parseProtocoloSubmission lê a categoria, os dados, o local e os arquivos da abertura de protocolo.
+func parseProtocoloSubmission(r *http.Request) (protocoloSubmission, error) {
+	var payload struct {
+		Categoria   string           `json:"categoria"`
+		Dados       map[string]any   `json:"dados"`
+		Localizacao *protocolo.Ponto `json:"localizacao"`
+		Bairro      *string          `json:"bairro"`
+		Ativo       string           `json:"ativo"`
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			return protocoloSubmission{}, errors.New("JSON inválido")
+		}
+	} else {
+		if err := r.ParseMultipartForm(16 << 20); err != nil {
+			return protocoloSubmission{}, errors.New("formulário inválido ou grande demais")
+		}
+		payload.Categoria = r.FormValue("categoria")
+		if raw := strings.TrimSpace(r.FormValue("dados")); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &payload.Dados); err != nil {
+				return protocoloSubmission{}, errors.New("dados inválidos")
+			}
+		}
+		lat, lng := strings.TrimSpace(r.FormValue("latitude")), strings.TrimSpace(r.FormValue("longitude"))
+		if lat != "" || lng != "" {
+			var ponto protocolo.Ponto
+			var errLat, errLng error
+			ponto.Lat, errLat = strconv.ParseFloat(lat, 64)
+			ponto.Lng, errLng = strconv.ParseFloat(lng, 64)
+			if errLat != nil || errLng != nil {
+				return protocoloSubmission{}, errors.New("localização inválida")
+			}
+			payload.Localizacao = &ponto
+		}
+		if bairro := r.FormValue("bairro"); bairro != "" {
+			payload.Bairro = &bairro
+		}
+		payload.Ativo = r.FormValue("ativo")
+	}
+	submission := protocoloSubmission{
+		Categoria:   strings.TrimSpace(payload.Categoria),
+		Dados:       payload.Dados,
+		Localizacao: payload.Localizacao,
+		Bairro:      protocolo.NormalizeBairro(payload.Bairro),
+		AtivoToken:  strings.TrimSpace(payload.Ativo),
+	}
+	if submission.Categoria == "" {
+		return protocoloSubmission{}, errors.New("categoria obrigatória")
+	}
+	if submission.Dados == nil {
+		submission.Dados = map[string]any{}
+	}
+	if submission.Localizacao != nil {
+		if err := submission.Localizacao.Validate(); err != nil {
+			return protocoloSubmission{}, err
+		}
+	}
+
+	if r.MultipartForm != nil {
+		for field, headers := range r.MultipartForm.File {
+			for _, fh := range headers {
+				file, err := fh.Open()
+				if err != nil {
+					return protocoloSubmission{}, fmt.Errorf("não foi possível ler %s", fh.Filename)
+				}
+				data, err := io.ReadAll(file)
+				file.Close()
+				if err != nil {
+					return protocoloSubmission{}, fmt.Errorf("não foi possível ler %s", fh.Filename)
+				}
+				contentType := fh.Header.Get("Content-Type")
+				if contentType == "" || contentType == "application/octet-stream" {
+					contentType = http.DetectContentType(data)
+				}
+				submission.Files = append(submission.Files, protocoloFile{
+					upload: protocolo.Upload{Field: field, FileName: filepath.Base(fh.Filename), ContentType: contentType, Size: int64(len(data))},
+					data:   data,
+				})
+			}
+		}
+	}
+	return submission, nil
+}
+
+var errProtocoloInfected = errors.New("anexo recusado pelo antivírus")
+
+// storeProtocoloFiles passa os anexos pelo antivírus e os envia ao armazenamento privado.
+func (h *Handler) storeProtocoloFiles(ctx context.Context, tenantID, protocoloID uuid.UUID, files []protocoloFile) ([]protocolo.Anexo, error) {
+	switch h.storage.(type) {
+	case nil, storage.NoopUploader, *storage.NoopUploader:
+		return nil, errors.New("armazenamento indisponível")
+	}
+	anexos := make([]protocolo.Anexo, 0, len(files))
+	for _, f := range files {
+		if h.scanner != nil {
+			if result, err := h.scanner.Scan(ctx, f.data); err != nil || result.Infected() {
+				log.Warn().Err(err).Str("protocolo", protocoloID.String()).Str("file", f.upload.FileName).Str("signature", result.Signature).Msg("protocolo: anexo recusado pelo antivírus")
+				return nil, fmt.Errorf("%w: %s", errProtocoloInfected, f.upload.FileName)
+			}
+		}
+		key := fmt.Sprintf("protocolos/%s/%s/%s%s", tenantID, protocoloID, uuid.NewString(), strings.ToLower(filepath.Ext(f.upload.FileName)))
+		result, err := h.storage.Upload(ctx, storage.UploadInput{
+			Key:          key,
+			Body:         f.data,
+			ContentType:  f.upload.ContentType,
+			CacheControl: "private,max-age=31536000",
+		})
+		if err != nil {
+			return nil, err
+		}
+		anexos = append(anexos, protocolo.Anexo{
+			Campo:       f.upload.Field,
+			FileName:    f.upload.FileName,
+			ContentType: f.upload.ContentType,
+			SizeBytes:   f.upload.Size,
+			ObjectKey:   key,
+			FileURL:     result.URL,
+		})
+	}
+	return anexos, nil
+}
+
+func writeProtocoloError(w http.ResponseWriter, err error) {
+	var verr *protocolo.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "formulário inválido", map[string]any{"fields": verr.Fields})
+	case errors.Is(err, protocolo.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "não encontrado", nil)
+	case errors.Is(err, protocolo.ErrDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existe categoria com este slug", nil)
+	case errors.Is(err, protocolo.ErrInactive):
+		WriteError(w, http.StatusConflict, "CONFLICT", "categoria não aceita novos protocolos", nil)
+	case errors.Is(err, protocolo.ErrFilaDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existe fila com este nome na secretaria", nil)
+	case errors.Is(err, protocolo.ErrInvalidTransition):
+		WriteError(w, http.StatusConflict, "CONFLICT", "mudança de status não permitida", nil)
+	case errors.Is(err, protocolo.ErrClosed):
+		WriteError(w, http.StatusConflict, "CONFLICT", "protocolo já encerrado", nil)
+	case errors.Is(err, protocolo.ErrNotMember):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "usuário não pertence à fila ou à secretaria", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar protocolo", nil)
+	}
+}
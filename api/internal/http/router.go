@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,49 +19,148 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/gestaozabele/municipio/internal/address"
+	"github.com/gestaozabele/municipio/internal/aluno"
+	"github.com/gestaozabele/municipio/internal/antivirus"
+	"github.com/gestaozabele/municipio/internal/assistencia"
+	"github.com/gestaozabele/municipio/internal/ativo"
+	"github.com/gestaozabele/municipio/internal/audit"
+	"github.com/gestaozabele/municipio/internal/authz"
+	"github.com/gestaozabele/municipio/internal/camara"
+	"github.com/gestaozabele/municipio/internal/changelog"
 	"github.com/gestaozabele/municipio/internal/cloudflare"
 	"github.com/gestaozabele/municipio/internal/config"
+	"github.com/gestaozabele/municipio/internal/consulta"
+	"github.com/gestaozabele/municipio/internal/dashboard"
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/demo"
+	"github.com/gestaozabele/municipio/internal/documento"
+	"github.com/gestaozabele/municipio/internal/errtrack"
+	"github.com/gestaozabele/municipio/internal/esign"
+	"github.com/gestaozabele/municipio/internal/estoque"
+	"github.com/gestaozabele/municipio/internal/evento"
+	"github.com/gestaozabele/municipio/internal/gestor"
 	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/ibge"
+	"github.com/gestaozabele/municipio/internal/kb"
+	"github.com/gestaozabele/municipio/internal/kpi"
+	"github.com/gestaozabele/municipio/internal/legalhold"
+	"github.com/gestaozabele/municipio/internal/mail"
+	"github.com/gestaozabele/municipio/internal/metrics"
 	"github.com/gestaozabele/municipio/internal/monitor"
+	"github.com/gestaozabele/municipio/internal/notify"
+	"github.com/gestaozabele/municipio/internal/onboarding"
+	"github.com/gestaozabele/municipio/internal/opendata"
+	"github.com/gestaozabele/municipio/internal/ordem"
+	"github.com/gestaozabele/municipio/internal/partitions"
+	"github.com/gestaozabele/municipio/internal/presence"
+	"github.com/gestaozabele/municipio/internal/procurement"
 	"github.com/gestaozabele/municipio/internal/prof"
+	"github.com/gestaozabele/municipio/internal/protocolo"
 	"github.com/gestaozabele/municipio/internal/provision"
 	"github.com/gestaozabele/municipio/internal/repo"
+	"github.com/gestaozabele/municipio/internal/responsavel"
+	"github.com/gestaozabele/municipio/internal/retention"
 	"github.com/gestaozabele/municipio/internal/saas"
+	"github.com/gestaozabele/municipio/internal/saude"
+	"github.com/gestaozabele/municipio/internal/scheduler"
+	"github.com/gestaozabele/municipio/internal/scim"
+	"github.com/gestaozabele/municipio/internal/senha"
 	"github.com/gestaozabele/municipio/internal/service"
 	"github.com/gestaozabele/municipio/internal/settings"
 	"github.com/gestaozabele/municipio/internal/storage"
 	"github.com/gestaozabele/municipio/internal/support"
 	"github.com/gestaozabele/municipio/internal/tenant"
+	"github.com/gestaozabele/municipio/internal/terms"
+	"github.com/gestaozabele/municipio/internal/tributos"
 	"github.com/rs/zerolog/log"
 )
 
 type Handler struct {
-	cfg           *config.Config
-	pool          *pgxpool.Pool
-	redis         *redis.Client
-	authService   *service.AuthService
-	tenants       *tenant.Service
-	saasUsers     *service.SaaSUserService
-	support       *support.Service
-	settings      *settings.Service
-	provisioner   *provision.Service
-	storage       storage.Uploader
-	monitor       *monitor.Service
-	monitorOn     bool
+	cfg  *config.Config
+	pool *pgxpool.Pool
+	// databases roteia os dados de cada prefeitura para o cluster dela (tenants.db_cluster).
+	databases   *db.Resolver
+	redis       *redis.Client
+	authService *service.AuthService
+	tenants     *tenant.Service
+	saasUsers   *service.SaaSUserService
+	support     *support.Service
+	kb          *kb.Service
+	settings    *settings.Service
+	provisioner *provision.Service
+	storage     storage.Uploader
+	esign       esign.Provider
+	mailer      mail.Sender
+	outbox      *mail.Outbox
+	scanner     antivirus.Scanner
+	demo        *demo.Seeder
+	monitor     *monitor.Service
+	monitorOn   bool
+	notifier    monitor.Notifier
+	dispatcher  *notify.Dispatcher
+	// canaisAvisos são os canais de aviso de falta com provedor configurado.
+	canaisAvisos  []string
+	opendata      *opendata.Exporter
+	ibge          *ibge.Client
+	address       *address.Service
+	procurement   *procurement.Repository
+	changelog     *changelog.Repository
+	onboarding    *onboarding.Repository
+	terms         *terms.Service
+	legalHolds    *legalhold.Repository
+	kpis          *kpi.Repository
+	dashboards    *dashboard.Repository
+	protocolos    *protocolo.Repository
+	ativos        *ativo.Repository
+	ordens        *ordem.Repository
+	estoque       *estoque.Repository
+	saude         *saude.Repository
+	assistencia   *assistencia.Repository
+	tributos      *tributos.Repository
+	documentos    *documento.Repository
+	docSigner     *documento.Signer
+	senhas        *senha.Repository
+	eventos       *evento.Repository
+	consultas     *consulta.Repository
+	camara        *camara.Repository
+	audit         *audit.Repository
+	roles         *authz.Repository
 	webauthn      *webauthn.WebAuthn
 	publicLimiter *httpmiddleware.RateLimiter
 	authLimiter   *httpmiddleware.RateLimiter
 	devCookies    bool
+	livePresence  *prof.LivePresenceCache
+	scim          *scim.Service
+	scheduler     *scheduler.Scheduler
+	partitions    *partitions.Manager
+	retention     *retention.Service
+	presence      *presence.Tracker
+	loadShedder   *httpmiddleware.LoadShedder
 }
 
 const (
 	passkeyRegisterSessionPrefix = "webauthn:register:"
 	passkeyLoginSessionPrefix    = "webauthn:login:"
 	passkeySessionTTL            = 5 * time.Minute
+	livePresenceTTL              = 30 * time.Second
+	onlinePresenceTTL            = 5 * time.Minute
 )
 
+// loadShedRules classifica rotas para o descarte sob sobrecarga: sondas, autenticação e gravação
+// de chamada são críticas; relatórios, painéis analíticos e exportações são os primeiros a sair.
+var loadShedRules = []httpmiddleware.PriorityRule{
+	{Pattern: regexp.MustCompile(`^/(health|ready|metrics)$`), Priority: httpmiddleware.PriorityCritical},
+	{Method: http.MethodPost, Pattern: regexp.MustCompile(`^/auth/`), Priority: httpmiddleware.PriorityCritical},
+	{Method: http.MethodPost, Pattern: regexp.MustCompile(`^/prof/turmas/[^/]+/chamada$`), Priority: httpmiddleware.PriorityCritical},
+	{Pattern: regexp.MustCompile(`/(relatorios?|analytics|analise|export|exports)(/|$)`), Priority: httpmiddleware.PriorityLow},
+	{Pattern: regexp.MustCompile(`^/prof/dashboard/`), Priority: httpmiddleware.PriorityLow},
+	{Method: http.MethodGet, Pattern: regexp.MustCompile(`^/gestor/escolas/[^/]+/(frequencia|notas|merenda|chamadas/excecoes)$`), Priority: httpmiddleware.PriorityLow},
+	{Method: http.MethodGet, Pattern: regexp.MustCompile(`^/saas/metrics/overview$`), Priority: httpmiddleware.PriorityLow},
+}
+
 // NewRouter devolve roteador configurado.
-func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client, authService *service.AuthService) (http.Handler, error) {
+func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client, authService *service.AuthService, tracker *errtrack.Client) (http.Handler, error) {
 	devCookies := false
 	for _, origin := range cfg.AllowOrigins {
 		if strings.Contains(origin, "localhost") {
@@ -77,6 +178,18 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 		return nil, fmt.Errorf("webauthn: %w", err)
 	}
 
+	// Tenants movidos para outro cluster (tenants.db_cluster) têm os dados escolares lidos de lá.
+	dbResolver := db.NewResolver(pool, cfg.DBClusters, db.PoolOptions{
+		MaxConns:               int32(cfg.DBPool.MaxConns),
+		MinConns:               int32(cfg.DBPool.MinConns),
+		MaxConnLifetime:        cfg.DBPool.MaxConnLifetime,
+		MaxConnIdleTime:        cfg.DBPool.MaxConnIdleTime,
+		HealthCheckPeriod:      cfg.DBPool.HealthCheckPeriod,
+		StatementCacheMode:     cfg.DBPool.StatementCacheMode,
+		StatementCacheCapacity: cfg.DBPool.StatementCacheCapacity,
+	}, cfg.DBRoutingTTL)
+	authService.UseClusters(dbResolver)
+
 	tenantRepo := tenant.NewRepository(pool)
 	tenantService := tenant.NewService(tenantRepo)
 	saasRepo := saas.NewRepository(pool)
@@ -136,12 +249,14 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 	monitorRepo := monitor.NewRepository(pool)
 	monitorNotifier := monitor.NewSlackNotifier(cfg.Monitoring.SlackWebhookURL)
 	monitorLogger := log.With().Str("component", "monitor").Logger()
+	jobScheduler := scheduler.New(pool, log.With().Str("component", "scheduler").Logger())
 	monitorService := monitor.NewService(monitorRepo, tenantService, cfg.Monitoring, monitorLogger, monitorNotifier)
-	if err := monitorService.Start(ctx); err != nil {
-		return nil, fmt.Errorf("monitor: %w", err)
-	}
+	monitorService.UseLocker(jobScheduler)
+	presenceTracker := presence.NewTracker(redisClient, pool, onlinePresenceTTL, log.With().Str("component", "presence").Logger())
+	monitorService.UseTraffic(presenceTracker)
 
 	var uploader storage.Uploader = storage.NoopUploader{}
+	var localFiles *storage.LocalUploader
 	switch cfg.Storage.Provider {
 	case "", "noop":
 		// mantém uploader padrão
@@ -158,20 +273,117 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 		if err != nil {
 			return nil, fmt.Errorf("storage: %w", err)
 		}
+	case "local", "filesystem":
+		localFiles, err = storage.NewLocalUploader(storage.LocalConfig{
+			Root:           cfg.Storage.LocalDir,
+			BaseURL:        cfg.Storage.LocalBaseURL,
+			SigningSecret:  cfg.Storage.LocalSigningSecret,
+			PublicPrefixes: cfg.Storage.LocalPublicPrefixes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("storage: %w", err)
+		}
+		uploader = localFiles
+	case "gcs", "google":
+		creds, err := os.ReadFile(cfg.Storage.GCSCredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("storage: credenciais do GCS: %w", err)
+		}
+		uploader, err = storage.NewGCSUploader(storage.GCSConfig{
+			Bucket:          cfg.Storage.GCSBucket,
+			CredentialsJSON: creds,
+			PublicDomain:    cfg.Storage.GCSPublicURL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("storage: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("storage: provedor %s não suportado", cfg.Storage.Provider)
 	}
 
+	signer, err := esign.New(esign.Config{
+		Provider:      cfg.ESign.Provider,
+		APIBase:       cfg.ESign.APIBase,
+		APIToken:      cfg.ESign.APIToken,
+		CryptKey:      cfg.ESign.CryptKey,
+		SafeID:        cfg.ESign.SafeID,
+		WebhookSecret: cfg.ESign.WebhookSecret,
+		WebhookURL:    cfg.ESign.WebhookURL,
+	})
+	if err != nil && !errors.Is(err, esign.ErrNotConfigured) {
+		return nil, fmt.Errorf("esign: %w", err)
+	}
+
+	docSigner, err := documento.NewSigner(cfg.Documentos.SigningKey, cfg.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("documentos: %w", err)
+	}
+
+	scanner, err := antivirus.New(antivirus.Config{Address: cfg.Antivirus.Address, Timeout: cfg.Antivirus.Timeout})
+	if err != nil && !errors.Is(err, antivirus.ErrNotConfigured) {
+		return nil, fmt.Errorf("antivirus: %w", err)
+	}
+
+	mailer, err := mail.New(mail.Config{
+		Provider:        cfg.Mail.Provider,
+		Host:            cfg.Mail.SMTPHost,
+		Port:            cfg.Mail.SMTPPort,
+		Username:        cfg.Mail.SMTPUsername,
+		Password:        cfg.Mail.SMTPPassword,
+		From:            cfg.Mail.From,
+		APIKey:          cfg.Mail.SendGridAPIKey,
+		Region:          cfg.Mail.SESRegion,
+		AccessKeyID:     cfg.Mail.SESAccessKeyID,
+		SecretAccessKey: cfg.Mail.SESSecretAccessKey,
+	})
+	if err != nil && !errors.Is(err, mail.ErrNotConfigured) {
+		return nil, fmt.Errorf("mail: %w", err)
+	}
+
+	monitorService.UseDependencies(platformProbes(cfg, mailer, uploader)...)
+	if err := monitorService.Start(ctx); err != nil {
+		return nil, fmt.Errorf("monitor: %w", err)
+	}
+
 	h := &Handler{
 		cfg:           cfg,
 		pool:          pool,
+		databases:     dbResolver,
 		redis:         redisClient,
 		authService:   authService,
 		tenants:       tenantService,
 		saasUsers:     saasUserService,
 		support:       supportService,
+		kb:            kb.NewService(kb.NewRepository(pool)),
+		procurement:   procurement.NewRepository(pool),
+		changelog:     changelog.NewRepository(pool),
+		onboarding:    onboarding.NewRepository(pool),
+		terms:         terms.NewService(pool),
+		legalHolds:    legalhold.NewRepository(pool),
+		kpis:          kpi.NewRepository(pool),
+		dashboards:    dashboard.NewRepository(pool),
+		protocolos:    protocolo.NewRepository(pool),
+		ativos:        ativo.NewRepository(pool),
+		ordens:        ordem.NewRepository(pool),
+		estoque:       estoque.NewRepository(pool),
+		saude:         saude.NewRepository(pool),
+		assistencia:   assistencia.NewRepository(pool),
+		tributos:      tributos.NewRepository(pool),
+		documentos:    documento.NewRepository(pool),
+		docSigner:     docSigner,
+		senhas:        senha.NewRepository(pool),
+		eventos:       evento.NewRepository(pool),
+		consultas:     consulta.NewRepository(pool, cfg.Consultas.HashKey),
+		camara:        camara.NewRepository(pool),
+		audit:         audit.NewRepository(pool),
+		roles:         authz.NewRepository(pool),
 		settings:      settingsService,
 		storage:       uploader,
+		esign:         signer,
+		scanner:       scanner,
+		mailer:        mailer,
+		outbox:        mail.NewOutbox(pool),
+		demo:          demo.NewSeeder(pool),
 		monitor:       monitorService,
 		monitorOn:     cfg.Monitoring.Enabled,
 		webauthn:      wa,
@@ -181,29 +393,168 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 	}
 
 	h.provisioner = provisionService
+	h.address = address.New(redisClient, address.Config{
+		ViaCEPBase:    cfg.Address.ViaCEPBase,
+		BrasilAPIBase: cfg.Address.BrasilAPIBase,
+		CacheTTL:      cfg.Address.CacheTTL,
+	})
+	if cfg.IBGE.Enabled {
+		h.ibge = ibge.New(cfg.IBGE.APIBase)
+	}
+	h.scheduler = jobScheduler
+	h.partitions = partitions.New(pool, []partitions.Table{
+		{Name: "presencas", Column: "aula_inicio", RetentionMonths: cfg.Partitions.PresencasRetentionMonths},
+		{Name: "saas_access_logs", Column: "logged_at"},
+	}, cfg.Partitions.PremakeMonths, log.With().Str("component", "partitions").Logger())
+	go jobScheduler.Every(ctx, "partitions.maintain", cfg.Partitions.Interval, dbResolver.PorCluster(h.partitions.Maintain))
+	h.retention = retention.New(pool, redisClient, h.partitions, cfg.Retention, log.With().Str("component", "retention").Logger())
+	go jobScheduler.Every(ctx, "retention.purge", cfg.Retention.Interval, h.retention.RunOnce)
+	switch uploader.(type) {
+	case storage.NoopUploader, *storage.NoopUploader:
+	default:
+		h.opendata = opendata.NewExporter(pool, uploader, log.With().Str("component", "opendata").Logger())
+		h.opendata.UseRouter(dbResolver)
+		if cfg.OpenData.Interval > 0 {
+			go jobScheduler.Every(ctx, "opendata.export", cfg.OpenData.Interval, h.opendata.RunOnce)
+		}
+		if cfg.Audit.AnchorInterval > 0 {
+			anchorer := audit.NewAnchorer(h.audit, uploader, log.With().Str("component", "audit").Logger())
+			go jobScheduler.Every(ctx, "audit.anchor", cfg.Audit.AnchorInterval, anchorer.RunOnce)
+		}
+	}
+	if monitorNotifier != nil {
+		h.notifier = monitorNotifier
+	}
+	if cfg.Monitoring.DomainInterval > 0 {
+		verifier := monitor.NewDomainVerifier(monitorRepo, tenantService, cfg.Monitoring.RequestTimeout, cfg.Monitoring.DomainConcurrency, h.notifier, log.With().Str("component", "domains").Logger())
+		go jobScheduler.Every(ctx, "monitor.domains", cfg.Monitoring.DomainInterval, verifier.RunOnce)
+	}
+	if cfg.Procurement.AlertInterval > 0 && h.notifier != nil {
+		alerter := procurement.NewAlerter(pool, h.notifier, cfg.Procurement.ExpiryWindow, log.With().Str("component", "procurement").Logger())
+		go jobScheduler.Every(ctx, "procurement.expiry", cfg.Procurement.AlertInterval, alerter.RunOnce)
+	}
+	if mailer != nil && cfg.Mail.WorkerInterval > 0 {
+		mailWorker := mail.NewWorker(h.outbox, mailer, cfg.Mail.Provider, log.With().Str("component", "mail").Logger())
+		go jobScheduler.Every(ctx, "mail.outbox", cfg.Mail.WorkerInterval, mailWorker.RunOnce)
+	}
 
 	profRepo := prof.NewRepository(pool)
 	profService := prof.NewService(repo.New(pool), profRepo)
-	profHandler := prof.NewHandler(profService)
+	if cfg.Chamada.AttestationSecret != "" {
+		profService.WithAttestor(prof.NewHMACAttestor(cfg.Chamada.AttestationSecret))
+	}
+	liveHub := prof.NewLiveHub(pool, log.With().Str("component", "prof.live").Logger())
+	go liveHub.Run(ctx)
+	profHandler := prof.NewHandler(profService).WithLiveHub(liveHub)
+	h.livePresence = prof.NewLivePresenceCache(profRepo, livePresenceTTL)
+	h.presence = presenceTracker
+	if cfg.LoadShed.Enabled {
+		h.loadShedder = httpmiddleware.NewLoadShedder(httpmiddleware.LoadShedConfig{
+			P95Threshold:   cfg.LoadShed.P95Threshold,
+			PoolSaturation: cfg.LoadShed.PoolSaturation,
+			RetryAfter:     cfg.LoadShed.RetryAfter,
+		}, loadShedRules, func() float64 {
+			stats := db.Stats(pool)
+			if stats.MaxConns == 0 {
+				return 0
+			}
+			return float64(stats.AcquiredConns) / float64(stats.MaxConns)
+		})
+	}
+	h.dispatcher = notify.NewDispatcher(notify.NewRepository(pool), log.With().Str("component", "notify").Logger())
+	h.dispatcher.Register(notify.ChannelEmail, notify.NewEmailSender(mailer))
+	smsSender, whatsappSender, err := textSenders(cfg.Mensagens)
+	if err != nil {
+		return nil, err
+	}
+	h.dispatcher.Register(notify.ChannelWhatsApp, notify.NewTextChannel(whatsappSender))
+	faltaWorker := notify.NewFaltaWorker(pool, log.With().Str("component", "faltas").Logger())
+	faltaWorker.Register(notify.CanalSMS, cfg.Mensagens.SMSProvider, smsSender)
+	faltaWorker.Register(notify.CanalWhatsApp, cfg.Mensagens.WhatsAppProvider, whatsappSender)
+	h.canaisAvisos = faltaWorker.Canais()
+	if faltaWorker.Enabled() && cfg.Mensagens.WorkerInterval > 0 {
+		go jobScheduler.Every(ctx, "faltas.avisos", cfg.Mensagens.WorkerInterval, dbResolver.PorCluster(faltaWorker.RunOnce))
+	}
+	if cfg.Estoque.AlertInterval > 0 {
+		estoqueAlerter := estoque.NewAlerter(pool, h.dispatcher, log.With().Str("component", "estoque").Logger())
+		go jobScheduler.Every(ctx, "estoque.alertas", cfg.Estoque.AlertInterval, dbResolver.PorCluster(estoqueAlerter.RunOnce))
+	}
+	if cfg.Eventos.ReminderInterval > 0 {
+		eventoNotifier := evento.NewNotifier(pool, h.dispatcher, log.With().Str("component", "eventos").Logger())
+		go jobScheduler.Every(ctx, "eventos.avisos", cfg.Eventos.ReminderInterval, dbResolver.PorCluster(eventoNotifier.RunOnce))
+	}
+	responsavelHandler := responsavel.NewHandler(responsavel.NewService(responsavel.NewRepository(pool)))
+	alunoService := aluno.NewService(aluno.NewRepository(pool), log.With().Str("component", "avaliacoes-online").Logger())
+	go jobScheduler.Every(ctx, "avaliacoes.encerrar", cfg.Avaliacoes.EncerramentoInterval, dbResolver.PorCluster(alunoService.EncerrarVencidas))
+	gestorRepo := gestor.NewRepository(pool)
+	chamadaNudger := gestor.NewNudger(gestorRepo, cfg.Chamada, log.With().Str("component", "chamadas").Logger())
+	chamadaNudger.UseLocker(jobScheduler)
+	chamadaNudger.UseDispatcher(h.dispatcher)
+	chamadaNudger.UseClusters(dbResolver)
+	chamadaNudger.Start(ctx)
+	gestorHandler := gestor.NewHandler(gestor.NewService(gestorRepo, chamadaNudger, uploader))
+	h.scim = scim.NewService(scim.NewRepository(pool))
 
 	r := chi.NewRouter()
 
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
 	r.Use(httpmiddleware.Logging)
-	r.Use(httpmiddleware.Recover)
+	r.Use(httpmiddleware.Recover(errorTracker{client: tracker}, newPanicIncidents(supportService, tenantService, h.notifier)))
+	r.Use(httpmiddleware.RouteMetrics)
 	r.Use(httpmiddleware.CORS(cfg.AllowOrigins))
+	if h.loadShedder != nil {
+		r.Use(h.loadShedder.Handler)
+	}
+	r.Use(httpmiddleware.SparseFields)
 
 	r.Group(func(public chi.Router) {
 		public.Use(httpmiddleware.IPRateLimit(h.publicLimiter))
+		if localFiles != nil {
+			// STORAGE_LOCAL_BASE_URL precisa apontar para este caminho da API.
+			public.Handle("/files/*", http.StripPrefix("/files", localFiles))
+		}
 
 		public.Get("/health", h.Health)
 		public.Get("/ready", h.Ready)
+		public.Get("/metrics", h.Metrics)
 		public.Get("/tenant", h.TenantConfig)
+		public.Get("/util/cep/{cep}", h.LookupCEP)
+		public.Get("/tenants/manifest", h.TenantManifest)
+		public.Get("/kb/categories", h.ListPublicKBCategories)
+		public.Get("/protocolos/categorias", h.ListPublicProtocoloCategorias)
+		public.Get("/ativos/{token}", h.PublicAtivo)
+		public.Get("/verify/{code}", h.VerifyDocumento)
+		public.Get("/senhas/filas", h.ListPublicSenhaFilas)
+		public.Get("/senhas/painel", h.SenhaPainel)
+		public.Post("/senhas/totem", h.EmitirSenhaTotem)
+		public.Get("/eventos", h.ListPublicEventos)
+		public.Get("/eventos/{id}", h.GetPublicEvento)
+		public.Get("/consultas", h.ListPublicConsultas)
+		public.Get("/consultas/{id}", h.GetPublicConsulta)
+		public.Get("/consultas/{id}/resultado", h.ConsultaResultado)
+		public.Get("/camara/sessoes", h.ListPublicCamaraSessoes)
+		public.Get("/camara/sessoes/{id}", h.GetPublicCamaraSessao)
+		public.Get("/kb/articles", h.ListPublicKBArticles)
+		public.Get("/kb/articles/{slug}", h.GetPublicKBArticle)
+		public.Post("/kb/faq", h.AskFAQ)
+		public.Get("/changelog", h.PublicChangelog)
+		public.Get("/opendata/catalog", h.OpenDataCatalog)
+		public.Get("/opendata/api/3/action/package_list", h.CKANPackageList)
+		public.Get("/opendata/api/3/action/package_show", h.CKANPackageShow)
+		public.Post("/support/inbound/mailgun", h.InboundSupportMailgun)
+		public.Post("/support/inbound/ses", h.InboundSupportSES)
+		public.Post("/webhooks/esign/{provider}", h.ESignWebhook)
+		public.Route("/scim/v2", func(r chi.Router) {
+			scim.Mount(r, scim.NewHandler(h.scim).WithRouter(dbResolver))
+		})
 
 		public.Route("/auth", func(auth chi.Router) {
 			auth.Post("/cidadao/login", h.LoginCidadao)
+			auth.Post("/responsavel/login", h.LoginResponsavel)
+			auth.Post("/aluno/login", h.LoginAluno)
 			auth.Post("/backoffice/login", h.LoginBackoffice)
+			auth.Post("/backoffice/convite", h.AcceptStaffInvite)
 			auth.Post("/saas/login", h.LoginSaaS)
 			auth.Post("/passkey/login/start", h.PasskeyLoginStart)
 			auth.Post("/passkey/login/finish", h.PasskeyLoginFinish)
@@ -214,27 +565,303 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 
 	r.Group(func(private chi.Router) {
 		private.Use(httpmiddleware.Auth(authService.JWT()))
+		private.Use(httpmiddleware.ReadOnly("/terms/accept", "/me/preferences", "/auth/passkey/register"))
+		private.Use(httpmiddleware.MFAEnrollment("/auth/passkey/register", "/me", "/terms"))
 		private.Use(httpmiddleware.UserRateLimit(h.authLimiter))
+		private.Use(httpmiddleware.Presence(h.presence))
+		private.Use(httpmiddleware.Terms(h.terms, "/terms", "/me"))
 
 		private.Get("/me", h.Me)
+		private.Get("/terms/pending", h.PendingTerms)
+		private.Post("/terms/accept", h.AcceptTerms)
+		private.Get("/me/preferences", h.GetMyPreferences)
+		private.Put("/me/preferences", h.UpdateMyPreferences)
+		private.Get("/backoffice/changelog", h.BackofficeChangelog)
 		private.Route("/auth/passkey/register", func(r chi.Router) {
 			r.Post("/start", h.PasskeyRegisterStart)
 			r.Post("/finish", h.PasskeyRegisterFinish)
 		})
 		private.Group(func(protected chi.Router) {
 			protected.Use(httpmiddleware.RequireProfessor)
+			protected.Use(httpmiddleware.Tenant(tenantResolver{tenants: tenantService}))
+			protected.Use(httpmiddleware.TenantDatabase(dbResolver))
 			protected.Route("/prof", func(r chi.Router) {
 				prof.Mount(r, profHandler)
 			})
 		})
+		private.Group(func(sec chi.Router) {
+			sec.Use(httpmiddleware.RequireSecretaria)
+			sec.Use(httpmiddleware.TenantDatabaseSlot)
+			permissoes := permissoesPorCluster{roles: h.roles, databases: dbResolver}
+			perm := func(perms ...string) func(http.Handler) http.Handler {
+				return httpmiddleware.RequirePermission(permissoes, perms...)
+			}
+			sec.Get("/secretaria/permissoes", h.SecretariaPermissoes)
+			sec.Route("/secretaria/delegacoes", func(d chi.Router) {
+				d.Get("/", h.ListDelegacoes)
+				d.Post("/", h.CreateDelegacao)
+				d.Get("/{id}", h.GetDelegacao)
+				d.Post("/{id}/revogar", h.RevogarDelegacao)
+				d.Get("/{id}/acoes", h.ListDelegacaoAcoes)
+			})
+			sec.With(perm(authz.PermPresencaVer)).Get("/secretaria/presenca/ao-vivo", h.SecretariaLivePresence)
+			sec.With(perm(authz.PermCidadaosUnificar)).Post("/secretaria/cidadaos/merge", h.MergeCidadaos)
+			sec.Route("/secretaria/protocolos/categorias", func(c chi.Router) {
+				c.Use(perm(authz.PermProtocolosConfigurar))
+				c.Get("/", h.ListProtocoloCategorias)
+				c.Post("/", h.CreateProtocoloCategoria)
+				c.Put("/{id}", h.UpdateProtocoloCategoria)
+			})
+			sec.Route("/secretaria/protocolos/filas", func(f chi.Router) {
+				f.Use(perm(authz.PermProtocolosConfigurar))
+				f.Get("/", h.ListProtocoloFilas)
+				f.Post("/", h.CreateProtocoloFila)
+				f.Get("/metricas", h.ProtocoloFilaMetrics)
+				f.Put("/{id}", h.UpdateProtocoloFila)
+				f.Put("/{id}/membros", h.SetProtocoloFilaMembros)
+			})
+			sec.Route("/secretaria/protocolos/regras", func(g chi.Router) {
+				g.Use(perm(authz.PermProtocolosConfigurar))
+				g.Get("/", h.ListProtocoloRegras)
+				g.Put("/{categoriaID}", h.SaveProtocoloRegra)
+				g.Delete("/{categoriaID}", h.DeleteProtocoloRegra)
+			})
+			sec.Group(func(p chi.Router) {
+				p.Use(perm(authz.PermProtocolosVer, authz.PermProtocolosResponder))
+				p.Get("/secretaria/protocolos", h.ListSecretariaProtocolos)
+				p.Get("/secretaria/protocolos/{id}", h.GetSecretariaProtocolo)
+				p.Get("/backoffice/protocolos/geo", h.ProtocoloGeoClusters)
+				p.Get("/backoffice/protocolos/geo/bairros", h.ProtocoloGeoBairros)
+			})
+			sec.Group(func(p chi.Router) {
+				p.Use(perm(authz.PermProtocolosResponder))
+				p.Post("/secretaria/protocolos/{id}/transferir", h.TransferProtocolo)
+				p.Post("/secretaria/protocolos/{id}/atribuir", h.AssignProtocolo)
+				p.Post("/secretaria/protocolos/{id}/status", h.SetProtocoloStatus)
+				p.Post("/secretaria/protocolos/{id}/ativo", h.LinkProtocoloAtivo)
+				p.With(perm(authz.PermOrdensGerenciar)).Post("/secretaria/protocolos/{id}/ordem", h.CreateOrdemServico)
+			})
+			sec.Route("/secretaria/ativos", func(a chi.Router) {
+				a.Use(perm(authz.PermAtivosGerenciar))
+				a.Get("/", h.ListAtivos)
+				a.Post("/", h.CreateAtivo)
+				a.Get("/{id}", h.GetAtivo)
+				a.Put("/{id}", h.UpdateAtivo)
+				a.Post("/{id}/historico", h.AddAtivoHistorico)
+				a.Get("/{id}/etiqueta", h.AtivoEtiqueta)
+			})
+			sec.Route("/secretaria/equipes", func(e chi.Router) {
+				e.Use(perm(authz.PermEquipesGerenciar))
+				e.Get("/", h.ListEquipes)
+				e.Post("/", h.CreateEquipe)
+				e.Put("/{id}", h.UpdateEquipe)
+				e.Put("/{id}/membros", h.SetEquipeMembros)
+			})
+			sec.Route("/secretaria/ordens", func(o chi.Router) {
+				o.Use(perm(authz.PermOrdensGerenciar))
+				o.Get("/", h.ListOrdensServico)
+				o.Get("/{id}", h.GetOrdemServico)
+				o.Put("/{id}/agenda", h.ScheduleOrdemServico)
+				o.Post("/{id}/iniciar", h.StartOrdemServico)
+				o.Post("/{id}/concluir", h.CompleteOrdemServico)
+				o.Post("/{id}/cancelar", h.CancelOrdemServico)
+				o.Put("/{id}/materiais", h.SetOrdemServicoMateriais)
+				o.Post("/{id}/fotos", h.UploadOrdemServicoFoto)
+			})
+			sec.Route("/secretaria/estoque", func(e chi.Router) {
+				e.Use(perm(authz.PermEstoqueGerenciar))
+				e.Get("/itens", h.ListEstoqueItens)
+				e.Post("/itens", h.CreateEstoqueItem)
+				e.Get("/itens/{id}", h.GetEstoqueItem)
+				e.Put("/itens/{id}", h.UpdateEstoqueItem)
+				e.Post("/itens/{id}/movimentos", h.CreateEstoqueMovimento)
+				e.Get("/alertas", h.EstoqueAlertas)
+				e.Get("/consumo", h.EstoqueConsumo)
+			})
+			sec.Route("/secretaria/saude/campanhas", func(c chi.Router) {
+				c.Use(perm(authz.PermSaudeCampanhas))
+				c.Get("/", h.ListSaudeCampanhas)
+				c.Post("/", h.CreateSaudeCampanha)
+				c.Get("/{id}", h.GetSaudeCampanha)
+				c.Put("/{id}", h.UpdateSaudeCampanha)
+				c.Post("/{id}/status", h.SetSaudeCampanhaStatus)
+				c.Get("/{id}/doses", h.ListSaudeDoses)
+				c.Post("/{id}/doses", h.RegistrarSaudeDose)
+				c.Get("/{id}/cobertura", h.SaudeCobertura)
+			})
+			sec.Route("/secretaria/documentos", func(d chi.Router) {
+				d.Use(perm(authz.PermDocumentosEmitir))
+				d.Get("/", h.ListDocumentos)
+				d.Post("/", h.EmitirDocumento)
+				d.Get("/{id}", h.GetDocumento)
+				d.Get("/{id}/pdf", h.DocumentoPDF)
+				d.Post("/{id}/revogar", h.RevogarDocumento)
+			})
+			sec.Route("/secretaria/senhas", func(s chi.Router) {
+				s.Use(perm(authz.PermSenhasAtender))
+				s.Get("/filas", h.ListSenhaFilas)
+				s.Post("/filas", h.CreateSenhaFila)
+				s.Put("/filas/{id}", h.UpdateSenhaFila)
+				s.Post("/chamar", h.ChamarProximaSenha)
+				s.Post("/{id}/rechamar", h.RechamarSenha)
+				s.Post("/{id}/finalizar", h.FinalizarSenha)
+				s.Post("/{id}/ausente", h.SenhaAusente)
+				s.Get("/metricas", h.SenhaMetricas)
+			})
+			sec.Route("/secretaria/eventos", func(e chi.Router) {
+				e.Use(perm(authz.PermEventosGerenciar))
+				e.Get("/", h.ListEventos)
+				e.Post("/", h.CreateEvento)
+				e.Get("/{id}", h.GetEvento)
+				e.Put("/{id}", h.UpdateEvento)
+				e.Post("/{id}/cancelar", h.CancelEvento)
+				e.Post("/{id}/checkin", h.CheckinEvento)
+				e.Get("/{id}/presenca", h.EventoPresenca)
+			})
+			sec.Route("/assistencia", func(a chi.Router) {
+				a.Group(func(c chi.Router) {
+					c.Use(perm(authz.PermAssistenciaConfigurar))
+					c.Get("/profissionais", h.ListAssistenciaProfissionais)
+					c.Put("/profissionais/{usuario_id}", h.SetAssistenciaProfissional)
+					c.Delete("/profissionais/{usuario_id}", h.RevokeAssistenciaProfissional)
+					c.Get("/auditoria", h.AssistenciaAuditoria)
+				})
+				a.Group(func(f chi.Router) {
+					f.Use(perm(authz.PermAssistenciaAtender))
+					f.Get("/familias", h.ListAssistenciaFamilias)
+					f.Post("/familias", h.CreateAssistenciaFamilia)
+					f.Get("/familias/{id}", h.GetAssistenciaFamilia)
+					f.Put("/familias/{id}", h.UpdateAssistenciaFamilia)
+					f.Post("/familias/{id}/beneficios", h.AddAssistenciaBeneficio)
+					f.Put("/familias/{id}/beneficios/{beneficio_id}", h.UpdateAssistenciaBeneficio)
+					f.Post("/familias/{id}/visitas", h.AddAssistenciaVisita)
+				})
+			})
+		})
+		private.Group(func(cidadao chi.Router) {
+			cidadao.Use(httpmiddleware.RequireCidadao)
+			cidadao.Get("/protocolos", h.ListMyProtocolos)
+			cidadao.Post("/protocolos", h.CreateProtocolo)
+			cidadao.Get("/protocolos/{id}", h.GetMyProtocolo)
+			cidadao.Get("/saude/vacinas", h.MinhaCarteiraVacinacao)
+			cidadao.Get("/tributos/debitos", h.ListMeusDebitos)
+			cidadao.Get("/tributos/guias", h.ListMinhasGuias)
+			cidadao.Post("/tributos/guias", h.EmitirGuiaTributo)
+			cidadao.Get("/tributos/guias/{id}/pdf", h.GuiaTributoPDF)
+			cidadao.Get("/senhas", h.ListMinhasSenhas)
+			cidadao.Post("/senhas", h.RetirarSenha)
+			cidadao.Get("/senhas/{id}", h.GetMinhaSenha)
+			cidadao.Post("/senhas/{id}/cancelar", h.CancelarMinhaSenha)
+			cidadao.Get("/eventos/inscricoes", h.ListMinhasInscricoes)
+			cidadao.Post("/eventos/{id}/inscricoes", h.InscreverEvento)
+			cidadao.Post("/eventos/inscricoes/{id}/cancelar", h.CancelarMinhaInscricao)
+			cidadao.Get("/eventos/inscricoes/{id}/qrcode", h.MinhaInscricaoQRCode)
+			cidadao.Get("/consultas/{id}/participacao", h.MinhaParticipacaoConsulta)
+			cidadao.Post("/consultas/{id}/votos", h.VotarConsulta)
+		})
+		private.Group(func(tenantAdmin chi.Router) {
+			tenantAdmin.Use(httpmiddleware.RequireTenantAdmin)
+			tenantAdmin.Use(httpmiddleware.TenantDatabaseSlot)
+			tenantAdmin.Route("/tenant-admin", func(ta chi.Router) {
+				ta.Get("/staff", h.TenantAdminStaff)
+				ta.Post("/staff", h.TenantAdminCreateStaff)
+				ta.Post("/staff/import", h.TenantAdminImportStaff)
+				ta.Put("/staff/{userID}/papeis", h.TenantAdminUpdateStaffPapeis)
+				ta.Patch("/staff/{userID}", h.TenantAdminUpdateStaffStatus)
+				ta.Get("/contract", h.TenantAdminContract)
+				ta.Get("/usage", h.TenantAdminUsage)
+				ta.Get("/monitor", h.TenantAdminMonitor)
+				ta.Get("/tributos", h.TenantAdminTributos)
+				ta.Put("/tributos", h.TenantAdminUpdateTributos)
+				ta.Get("/senhas/totens", h.TenantAdminSenhaTotens)
+				ta.Post("/senhas/totens", h.TenantAdminCreateSenhaTotem)
+				ta.Delete("/senhas/totens/{id}", h.TenantAdminRevokeSenhaTotem)
+				ta.Route("/consultas", func(c chi.Router) {
+					c.Get("/", h.TenantAdminConsultas)
+					c.Post("/", h.TenantAdminCreateConsulta)
+					c.Get("/{id}", h.TenantAdminGetConsulta)
+					c.Put("/{id}", h.TenantAdminUpdateConsulta)
+					c.Post("/{id}/publicar", h.TenantAdminPublicarConsulta)
+					c.Post("/{id}/cancelar", h.TenantAdminCancelarConsulta)
+					c.Post("/{id}/propostas", h.TenantAdminCreateProposta)
+					c.Put("/{id}/propostas/{propostaID}", h.TenantAdminUpdateProposta)
+					c.Delete("/{id}/propostas/{propostaID}", h.TenantAdminDeleteProposta)
+					c.Get("/{id}/resultado", h.TenantAdminConsultaResultado)
+					c.Get("/{id}/cedulas.csv", h.TenantAdminExportCedulas)
+				})
+				ta.Get("/politica-acesso", h.TenantAdminAuthPolicy)
+				ta.Put("/politica-acesso", h.TenantAdminUpdateAuthPolicy)
+				ta.Get("/avisos-faltas", h.TenantAdminAvisosFaltas)
+				ta.Put("/avisos-faltas", h.TenantAdminUpdateAvisosFaltas)
+				ta.Get("/avisos-faltas/envios", h.TenantAdminAvisosFaltasEnvios)
+				ta.Get("/permissoes", h.TenantAdminPermissoes)
+				ta.Route("/papeis", func(p chi.Router) {
+					p.Get("/", h.TenantAdminPapeis)
+					p.Post("/", h.TenantAdminCreatePapel)
+					p.Get("/{id}", h.TenantAdminGetPapel)
+					p.Put("/{id}", h.TenantAdminUpdatePapel)
+					p.Delete("/{id}", h.TenantAdminDeletePapel)
+					p.Put("/{id}/membros", h.TenantAdminSetPapelMembros)
+				})
+				ta.Route("/camara/sessoes", func(c chi.Router) {
+					c.Get("/", h.TenantAdminCamaraSessoes)
+					c.Post("/", h.TenantAdminCreateCamaraSessao)
+					c.Get("/{id}", h.TenantAdminGetCamaraSessao)
+					c.Put("/{id}", h.TenantAdminUpdateCamaraSessao)
+					c.Delete("/{id}", h.TenantAdminDeleteCamaraSessao)
+					c.Post("/{id}/publicar", h.TenantAdminPublicarCamaraSessao)
+					c.Put("/{id}/ata", h.TenantAdminCamaraAta)
+					c.Post("/{id}/pauta", h.TenantAdminCreateCamaraItem)
+					c.Put("/{id}/pauta/{itemID}", h.TenantAdminUpdateCamaraItem)
+					c.Delete("/{id}/pauta/{itemID}", h.TenantAdminDeleteCamaraItem)
+					c.Post("/{id}/documentos", h.TenantAdminUploadCamaraDocumento)
+					c.Delete("/{id}/documentos/{documentoID}", h.TenantAdminDeleteCamaraDocumento)
+				})
+				ta.Get("/onboarding", h.TenantAdminOnboarding)
+				ta.Post("/onboarding/tasks/{code}/skip", h.TenantAdminSkipOnboardingTask)
+				ta.Delete("/onboarding/tasks/{code}/skip", h.TenantAdminUnskipOnboardingTask)
+				ta.Post("/onboarding/dismiss", h.TenantAdminDismissOnboarding)
+				ta.Delete("/onboarding/dismiss", h.TenantAdminReopenOnboarding)
+			})
+		})
+		private.Group(func(familia chi.Router) {
+			familia.Use(httpmiddleware.RequireResponsavel)
+			familia.Use(httpmiddleware.Tenant(tenantResolver{tenants: tenantService}))
+			familia.Use(httpmiddleware.TenantDatabase(dbResolver))
+			familia.Route("/responsavel", func(r chi.Router) {
+				responsavel.Mount(r, responsavelHandler)
+			})
+		})
+		private.Group(func(estudante chi.Router) {
+			estudante.Use(httpmiddleware.RequireAluno)
+			estudante.Use(httpmiddleware.Tenant(tenantResolver{tenants: tenantService}))
+			estudante.Use(httpmiddleware.TenantDatabase(dbResolver))
+			estudante.Route("/aluno", func(r chi.Router) {
+				aluno.Mount(r, aluno.NewHandler(alunoService))
+			})
+		})
+		private.Group(func(escola chi.Router) {
+			escola.Use(httpmiddleware.RequireEscolaGestor)
+			escola.Use(httpmiddleware.Tenant(tenantResolver{tenants: tenantService}))
+			escola.Use(httpmiddleware.TenantDatabase(dbResolver))
+			escola.Route("/gestor", func(r chi.Router) {
+				gestor.Mount(r, gestorHandler)
+			})
+		})
 	})
 
 	saasRouter := chi.NewRouter()
 	saasRouter.Use(httpmiddleware.Auth(h.authService.JWT()))
+	saasRouter.Use(httpmiddleware.ReadOnly())
+	saasRouter.Use(httpmiddleware.Presence(h.presence))
+	saasRouter.Use(httpmiddleware.Terms(h.terms))
+	saasRouter.Use(audit.NewRecorder(h.audit, log.With().Str("component", "audit").Logger()).Middleware)
+
+	saasRouter.With(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE", "SAAS_SUPPORT")).Get("/files/{id}", h.DownloadPrivateFile)
+	saasRouter.With(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE", "SAAS_SUPPORT")).Get("/files/{id}/signed-url", h.SignedPrivateFileURL)
 
 	saasRouter.Group(func(admin chi.Router) {
 		admin.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER"))
-		admin.Get("/metrics/overview", h.DashboardOverview)
 		admin.Get("/tenants", h.ListTenants)
 		admin.Post("/tenants", h.CreateTenant)
 		admin.Route("/users", func(u chi.Router) {
@@ -244,12 +871,58 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 			u.Post("/invite", h.InviteSaaSUser)
 			u.Patch("/{id}", h.UpdateSaaSUser)
 			u.Delete("/{id}", h.DeleteSaaSUser)
+			u.Get("/{id}/portfolio", h.GetSaaSUserPortfolio)
+			u.Put("/{id}/portfolio", h.UpdateSaaSUserPortfolio)
 		})
+		admin.Get("/emails", h.ListMailDeliveries)
+		admin.Post("/emails/{deliveryID}/retry", h.RetryMailDelivery)
 		admin.Post("/tenants/import", h.ImportTenants)
+		admin.Get("/tenants/bulk", h.ListTenantBulkOperations)
+		admin.Post("/tenants/bulk", h.CreateTenantBulkOperation)
+		admin.Get("/tenants/bulk/{bulkID}", h.GetTenantBulkOperation)
+		admin.Post("/tenants/bulk/{bulkID}/resume", h.ResumeTenantBulkOperation)
 		admin.Post("/tenants/{id}/dns/provision", h.ProvisionTenantDNS)
 		admin.Post("/tenants/{id}/dns/check", h.CheckTenantDNS)
+		admin.With(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_SUPPORT")).Post("/tenants/{id}/cache/purge", h.PurgeTenantEdgeCache)
+		admin.Post("/tenants/{id}/ibge/sync", h.SyncTenantIBGE)
+		admin.Get("/tenants/{id}/opendata", h.ListTenantOpenData)
+		admin.Post("/tenants/{id}/opendata/export", h.ExportTenantOpenData)
+		admin.Get("/tenants/{id}/staff", h.ListTenantStaff)
+		admin.Get("/tenants/{id}/admins", h.ListTenantAdmins)
+		admin.Put("/tenants/{id}/admins", h.UpdateTenantAdmins)
+		admin.Put("/tenants/{id}/environment", h.UpdateTenantEnvironment)
+		admin.Post("/tenants/{id}/sandbox/reset", h.ResetSandboxTenant)
+		admin.Get("/tenants/{id}/legal-hold", h.ListTenantLegalHolds)
+		admin.Get("/tenants/{id}/database", h.GetTenantDatabase)
+		admin.Get("/tenants/{id}/legal-hold/{holdID}/snapshot", h.DownloadTenantLegalHoldSnapshot)
+		admin.Group(func(owner chi.Router) {
+			owner.Use(httpmiddleware.RequireSaaSRoles("SAAS_OWNER"))
+			owner.Post("/tenants/{id}/legal-hold", h.RequestTenantLegalHold)
+			owner.Post("/tenants/{id}/legal-hold/confirm", h.ConfirmTenantLegalHold)
+			owner.Post("/tenants/{id}/legal-hold/cancel", h.CancelTenantLegalHold)
+			owner.Post("/tenants/{id}/legal-hold/release", h.RequestTenantLegalHoldRelease)
+			owner.Post("/tenants/{id}/legal-hold/release/confirm", h.ConfirmTenantLegalHoldRelease)
+			owner.Post("/tenants/{id}/legal-hold/release/abort", h.AbortTenantLegalHoldRelease)
+		})
+		admin.Post("/tenants/{id}/demo-data", h.GenerateDemoData)
+		admin.Delete("/tenants/{id}/demo-data", h.WipeDemoData)
+		admin.Delete("/tenants/{id}", h.DeleteTenant)
+		admin.Put("/tenants/{id}/staff/secretarias", h.UpdateTenantStaffSecretarias)
+		admin.Put("/tenants/{id}/staff/{userID}/escolas", h.UpdateTenantStaffEscolas)
+		admin.Get("/tenants/{id}/scim", h.GetTenantSCIM)
+		admin.Post("/tenants/{id}/scim/token", h.IssueTenantSCIMToken)
+		admin.Delete("/tenants/{id}/scim/token", h.RevokeTenantSCIMToken)
+		admin.Put("/tenants/{id}/scim/rules", h.UpdateTenantSCIMRules)
+		admin.Put("/tenants/{id}/chamada/politica", h.UpdateChamadaPolitica)
+		admin.Get("/tenants/{id}/anos-letivos", h.ListAnosLetivos)
+		admin.Post("/tenants/{id}/anos-letivos", h.SaveAnoLetivo)
+		admin.Post("/tenants/{id}/anos-letivos/{ano}/ativar", h.ActivateAnoLetivo)
 		admin.Route("/projects", func(p chi.Router) {
 			p.Get("/", h.ListProjects)
+			p.Get("/capacity", h.ProjectCapacity)
+			p.Get("/availability", h.ListStaffAvailability)
+			p.Post("/availability", h.CreateStaffAvailability)
+			p.Delete("/availability/{id}", h.DeleteStaffAvailability)
 			p.Post("/", h.CreateProject)
 			p.Patch("/{id}", h.UpdateProject)
 			p.Delete("/{id}", h.DeleteProject)
@@ -257,14 +930,53 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 			p.Patch("/{id}/tasks/{taskID}", h.UpdateProjectTask)
 			p.Delete("/{id}/tasks/{taskID}", h.DeleteProjectTask)
 		})
+		admin.Get("/terms", h.ListTermsVersions)
+		admin.Post("/terms", h.CreateTermsVersion)
+		admin.Route("/changelog", func(c chi.Router) {
+			c.Get("/", h.ListReleaseNotes)
+			c.Post("/", h.CreateReleaseNote)
+			c.Put("/{id}", h.UpdateReleaseNote)
+			c.Post("/{id}/publish", h.PublishReleaseNote)
+			c.Post("/{id}/unpublish", h.UnpublishReleaseNote)
+			c.Delete("/{id}", h.DeleteReleaseNote)
+		})
+		admin.Route("/okrs", func(o chi.Router) {
+			o.Get("/", h.OKRDashboard)
+			o.Post("/", h.CreateObjective)
+			o.Patch("/{id}", h.UpdateObjective)
+			o.Delete("/{id}", h.DeleteObjective)
+			o.Post("/{id}/key-results", h.CreateKeyResult)
+			o.Patch("/{id}/key-results/{krID}", h.UpdateKeyResult)
+			o.Delete("/{id}/key-results/{krID}", h.DeleteKeyResult)
+		})
+		admin.Route("/metrics", func(m chi.Router) {
+			m.Get("/overview", h.DashboardOverview)
+			m.Get("/overview/layout", h.GetDashboardLayout)
+			m.Put("/overview/layout", h.UpdateDashboardLayout)
+			m.Delete("/overview/layout", h.ResetDashboardLayout)
+			m.Get("/snapshot", h.MetricsSnapshot)
+			m.With(httpmiddleware.RequireSaaSRoles("SAAS_OWNER")).Post("/snapshot", h.ReissueMetricsSnapshot)
+			m.Get("/snapshots", h.ListMetricsSnapshots)
+		})
 		admin.Route("/finance", func(f chi.Router) {
 			f.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"))
 			f.Get("/entries", h.ListFinanceEntries)
 			f.Post("/entries", h.CreateFinanceEntry)
 			f.Patch("/entries/{id}", h.UpdateFinanceEntry)
 			f.Delete("/entries/{id}", h.DeleteFinanceEntry)
+			f.Get("/entries/{id}/approvals", h.ListFinanceEntryApprovals)
+			f.With(httpmiddleware.RequireSaaSRoles("SAAS_OWNER")).Post("/entries/{id}/approve", h.ApproveFinanceEntry)
+			f.With(httpmiddleware.RequireSaaSRoles("SAAS_OWNER")).Post("/entries/{id}/reject", h.RejectFinanceEntry)
 			f.Post("/entries/{id}/attachments", h.UploadFinanceAttachment)
 			f.Delete("/entries/{id}/attachments/{attachmentID}", h.DeleteFinanceAttachment)
+			f.Get("/forecast", h.FinanceForecast)
+			f.Post("/forecast", h.FinanceForecast)
+			f.Get("/partners", h.ListPartners)
+			f.Post("/partners", h.CreatePartner)
+			f.Patch("/partners/{id}", h.UpdatePartner)
+			f.Put("/partners/{id}/referrals", h.SavePartnerReferral)
+			f.Delete("/partners/{id}/referrals/{tenantID}", h.DeletePartnerReferral)
+			f.Get("/partners/{id}/statement", h.PartnerStatement)
 		})
 		admin.Route("/communications", func(c chi.Router) {
 			c.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_SUPPORT"))
@@ -276,21 +988,15 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 		admin.Route("/cities", func(c chi.Router) {
 			c.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_SUPPORT"))
 			c.Get("/", h.ListCityInsights)
+			c.Get("/presenca/ao-vivo", h.CitiesLivePresence)
 			c.Post("/{id}/sync", h.SyncCityInsight)
 		})
 		admin.Route("/access", func(a chi.Router) {
 			a.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER"))
 			a.Get("/logs", h.ListAccessLogs)
 			a.Post("/logs", h.CreateAccessLog)
-		})
-		admin.Route("/tenants/{id}/contract", func(c chi.Router) {
-			c.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"))
-			c.Get("/", h.GetTenantContract)
-			c.Put("/", h.UpdateTenantContract)
-			c.Put("/modules", h.UpdateTenantModules)
-			c.Post("/file", h.UploadTenantContractFile)
-			c.Post("/invoices", h.UploadTenantInvoice)
-			c.Delete("/invoices/{invoiceID}", h.DeleteTenantInvoice)
+			a.Get("/privileges", h.ListPrivilegedActions)
+			a.Get("/online", h.ListOnlineUsers)
 		})
 		admin.Route("/tenants/{id}/app", func(app chi.Router) {
 			app.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER"))
@@ -298,27 +1004,89 @@ func NewRouter(cfg *config.Config, pool *pgxpool.Pool, redisClient *redis.Client
 			app.Put("/", h.UpdateAppCustomization)
 			app.Post("/logo", h.UploadAppLogo)
 		})
-		admin.Route("/monitor", func(m chi.Router) {
-			m.Get("/summary", h.MonitorSummary)
-			m.Post("/run", h.MonitorRun)
-			m.Get("/tenants/{id}", h.MonitorTenant)
+		admin.Post("/monitor/run", h.MonitorRun)
+		admin.Get("/monitor/jobs", h.MonitorSchedulerJobs)
+		admin.Get("/monitor/partitions", h.MonitorPartitions)
+		admin.Get("/database/migrations", h.ListDatabaseMigrations)
+		admin.Route("/compliance", func(c chi.Router) {
+			c.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER"))
+			c.Get("/retention", h.GetRetentionReport)
+			c.With(httpmiddleware.RequireSaaSRoles("SAAS_OWNER")).Post("/retention/run", h.RunRetentionPurge)
+		})
+		admin.Route("/audit", func(a chi.Router) {
+			a.Get("/", h.ListAuditLog)
+			a.Get("/export", h.ExportAuditLog)
+			a.Get("/verify", h.VerifyAuditLog)
+			a.Get("/anchors", h.ListAuditAnchors)
+			a.Get("/{entryID}", h.GetAuditLogEntry)
 		})
 		admin.Route("/settings", func(settingsRouter chi.Router) {
 			settingsRouter.Use(httpmiddleware.RequireSaaSRoles("SAAS_OWNER"))
 			settingsRouter.Get("/cloudflare", h.GetCloudflareSettings)
 			settingsRouter.Put("/cloudflare", h.UpdateCloudflareSettings)
+			settingsRouter.Get("/backups", h.GetBackupSettings)
+		})
+	})
+
+	// Rotas abertas a suporte/financeiro, filtradas pela carteira de tenants de cada usuário.
+	saasRouter.Group(func(portfolio chi.Router) {
+		portfolio.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_SUPPORT", "SAAS_FINANCE"))
+		portfolio.Get("/monitor/summary", h.MonitorSummary)
+		portfolio.With(h.requirePortfolioTenant).Get("/monitor/tenants/{id}", h.MonitorTenant)
+		portfolio.Get("/monitor/anomalies", h.MonitorAnomalies)
+		portfolio.With(h.requirePortfolioTenant).Get("/tenants/{id}/onboarding", h.GetTenantOnboarding)
+		portfolio.Route("/tenants/{id}/contract", func(c chi.Router) {
+			c.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"))
+			c.Use(h.requirePortfolioTenant)
+			c.Get("/", h.GetTenantContract)
+			c.Put("/", h.UpdateTenantContract)
+			c.Put("/modules", h.UpdateTenantModules)
+			c.Post("/file", h.UploadTenantContractFile)
+			c.Get("/versions", h.ListTenantContractVersions)
+			c.Get("/versions/{versionID}/signature/events", h.ListContractSignatureEvents)
+			c.Post("/invoices", h.UploadTenantInvoice)
+			c.Delete("/invoices/{invoiceID}", h.DeleteTenantInvoice)
+			c.Post("/invoices/{invoiceID}/paid", h.MarkTenantInvoicePaid)
+			c.Get("/procurement", h.ListProcurementDocuments)
+			c.Post("/procurement", h.UploadProcurementDocument)
+			c.Delete("/procurement/{documentID}", h.DeleteProcurementDocument)
+			c.With(httpmiddleware.RequireSaaSRoles("SAAS_OWNER")).Post("/invoices/{invoiceID}/release", h.ReleaseTenantInvoice)
 		})
 	})
 
 	saasRouter.Group(func(supportGroup chi.Router) {
 		supportGroup.Use(httpmiddleware.RequireSaaSRoles("SAAS_ADMIN", "SAAS_OWNER", "SAAS_SUPPORT"))
+		supportGroup.With(h.requirePortfolioTenant).Get("/tenants/{id}/diagnostics", h.TenantDiagnostics)
 		supportGroup.Route("/tickets", func(t chi.Router) {
 			t.Get("/", h.ListSupportTickets)
 			t.Post("/", h.CreateSupportTicket)
-			t.Get("/{id}", h.GetSupportTicket)
-			t.Patch("/{id}", h.UpdateSupportTicket)
-			t.Get("/{id}/messages", h.ListSupportTicketMessages)
-			t.Post("/{id}/messages", h.AddSupportTicketMessage)
+			t.Group(func(ticket chi.Router) {
+				ticket.Use(h.requirePortfolioTicket)
+				ticket.Get("/{id}", h.GetSupportTicket)
+				ticket.Patch("/{id}", h.UpdateSupportTicket)
+				ticket.Get("/{id}/messages", h.ListSupportTicketMessages)
+				ticket.Post("/{id}/messages", h.AddSupportTicketMessage)
+				ticket.Get("/{id}/canned/{cannedID}", h.PreviewCannedResponse)
+				ticket.Post("/{id}/canned/{cannedID}", h.ReplyWithCannedResponse)
+				ticket.Get("/{id}/kb-suggestions", h.SuggestKBArticlesForTicket)
+			})
+		})
+		supportGroup.Route("/kb", func(k chi.Router) {
+			k.Get("/categories", h.ListKBCategories)
+			k.Post("/categories", h.CreateKBCategory)
+			k.Put("/categories/{id}", h.UpdateKBCategory)
+			k.Delete("/categories/{id}", h.DeleteKBCategory)
+			k.Get("/articles", h.ListKBArticles)
+			k.Post("/articles", h.CreateKBArticle)
+			k.Get("/articles/{id}", h.GetKBArticle)
+			k.Put("/articles/{id}", h.UpdateKBArticle)
+			k.Delete("/articles/{id}", h.DeleteKBArticle)
+		})
+		supportGroup.Route("/support/canned-responses", func(c chi.Router) {
+			c.Get("/", h.ListCannedResponses)
+			c.Post("/", h.CreateCannedResponse)
+			c.Put("/{cannedID}", h.UpdateCannedResponse)
+			c.Delete("/{cannedID}", h.DeleteCannedResponse)
 		})
 	})
 
@@ -348,7 +1116,33 @@ func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, map[string]bool{"ready": true})
+	if !isDeepReady(r) {
+		WriteJSON(w, http.StatusOK, map[string]bool{"ready": true})
+		return
+	}
+
+	// Modo profundo: mede ida e volta real ao banco e devolve o estado do pool.
+	start := time.Now()
+	var one int
+	if err := h.pool.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "consulta de verificação falhou", map[string]any{"db": err.Error()})
+		return
+	}
+	stats := db.Stats(h.pool)
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"ready":          true,
+		"db_latency_ms":  time.Since(start).Milliseconds(),
+		"pool":           stats,
+		"pool_saturated": stats.AcquiredConns >= stats.MaxConns,
+	})
+}
+
+func isDeepReady(r *http.Request) bool {
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("deep"))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
 }
 
 func errorString(err error) string {
@@ -376,6 +1170,7 @@ func (h *Handler) LoginBackoffice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.authService.LoginBackoffice(r.Context(), payload.Email, payload.Senha)
+	recordLogin("backoffice", err)
 	if err != nil {
 		h.handleAuthError(w, err)
 		return
@@ -624,6 +1419,7 @@ func (h *Handler) PasskeyLoginFinish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.authService.LoginBackofficeWithUser(ctx, user)
+	recordLogin("backoffice", err)
 	if err != nil {
 		h.handleAuthError(w, err)
 		return
@@ -650,6 +1446,61 @@ func (h *Handler) LoginCidadao(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.authService.LoginCidadao(r.Context(), payload.Email, payload.Senha)
+	recordLogin("cidadao", err)
+	if err != nil {
+		h.handleAuthError(w, err)
+		return
+	}
+
+	h.writeLoginSuccess(w, result)
+}
+
+// LoginResponsavel autentica familiares no portal dos alunos.
+func (h *Handler) LoginResponsavel(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Email string `json:"email"`
+		Senha string `json:"senha"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	if strings.TrimSpace(payload.Email) == "" || strings.TrimSpace(payload.Senha) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "email e senha são obrigatórios", nil)
+		return
+	}
+
+	result, err := h.authService.LoginResponsavel(r.Context(), payload.Email, payload.Senha)
+	recordLogin("responsavel", err)
+	if err != nil {
+		h.handleAuthError(w, err)
+		return
+	}
+
+	h.writeLoginSuccess(w, result)
+}
+
+// LoginAluno autentica estudantes nas avaliações online.
+func (h *Handler) LoginAluno(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Matricula string `json:"matricula"`
+		Senha     string `json:"senha"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	if strings.TrimSpace(payload.Matricula) == "" || strings.TrimSpace(payload.Senha) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "matrícula e senha são obrigatórias", nil)
+		return
+	}
+
+	result, err := h.authService.LoginAluno(r.Context(), payload.Matricula, payload.Senha)
+	recordLogin("aluno", err)
 	if err != nil {
 		h.handleAuthError(w, err)
 		return
@@ -676,6 +1527,7 @@ func (h *Handler) LoginSaaS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.authService.LoginSaaS(r.Context(), payload.Email, payload.Senha)
+	recordLogin("saas", err)
 	if err != nil {
 		h.handleAuthError(w, err)
 		return
@@ -703,6 +1555,12 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 			WriteError(w, http.StatusUnauthorized, "AUTH", err.Error(), nil)
 			return
 		}
+		// A política da prefeitura pode ter mudado desde o login: a sessão não se renova por fora dela.
+		var sso *service.SSORequiredError
+		if errors.Is(err, service.ErrMFARequired) || errors.As(err, &sso) {
+			h.handleAuthError(w, err)
+			return
+		}
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "erro ao renovar sessão", nil)
 		return
 	}
@@ -719,6 +1577,8 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	h.clearRefreshCookie(w, "cidadao")
 	h.clearRefreshCookie(w, "backoffice")
 	h.clearRefreshCookie(w, "saas")
+	h.clearRefreshCookie(w, "responsavel")
+	h.clearRefreshCookie(w, "aluno")
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "logged_out"})
 }
 
@@ -743,14 +1603,42 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, map[string]any{
+	WriteJSON(w, http.StatusOK, meResponse(profile, roles))
+}
+
+// meResponse monta o corpo de /me; o formato é fixado pelos snapshots em testdata/golden.
+func meResponse(profile any, roles []string) map[string]any {
+	return map[string]any{
 		"user":  profile,
 		"roles": roles,
-	})
+	}
+}
+
+// recordLogin conta a tentativa de login; erros internos ficam separados das credenciais recusadas.
+func recordLogin(audience string, err error) {
+	result := "success"
+	var sso *service.SSORequiredError
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrMFARequired), errors.As(err, &sso):
+		result = "policy_denied"
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrNoEligibleRoles):
+		result = "failure"
+	default:
+		result = "error"
+	}
+	metrics.LoginAttempts.Inc(audience, result)
 }
 
 func (h *Handler) handleAuthError(w http.ResponseWriter, err error) {
+	var sso *service.SSORequiredError
+	if errors.As(err, &sso) {
+		WriteError(w, http.StatusForbidden, "SSO_REQUIRED", err.Error(), map[string]string{"sso_url": sso.URL})
+		return
+	}
 	switch err {
+	case service.ErrMFARequired:
+		WriteError(w, http.StatusForbidden, "MFA_REQUIRED", err.Error(), nil)
 	case service.ErrInvalidCredentials:
 		WriteError(w, http.StatusUnauthorized, "AUTH", err.Error(), nil)
 	case service.ErrAccountDisabled:
@@ -765,10 +1653,14 @@ func (h *Handler) handleAuthError(w http.ResponseWriter, err error) {
 func (h *Handler) writeLoginSuccess(w http.ResponseWriter, result *service.LoginResult) {
 	h.setRefreshCookie(w, result.Audience, result.RefreshToken, result.RefreshExpiry)
 
-	WriteJSON(w, http.StatusOK, map[string]any{
+	body := map[string]any{
 		"access_token": result.AccessToken,
 		"user":         result.Profile,
-	})
+	}
+	if result.MFAPendente {
+		body["mfa_pendente"] = true
+	}
+	WriteJSON(w, http.StatusOK, body)
 }
 
 type webauthnSessionEnvelope struct {
@@ -906,9 +1798,11 @@ func toAuthenticatorTransports(values []string) []protocol.AuthenticatorTranspor
 }
 
 const (
-	refreshCookieCidadao    = "cidadao"
-	refreshCookieBackoffice = "backoffice"
-	refreshCookieSaaS       = "saas"
+	refreshCookieCidadao     = "cidadao"
+	refreshCookieBackoffice  = "backoffice"
+	refreshCookieSaaS        = "saas"
+	refreshCookieResponsavel = "responsavel"
+	refreshCookieAluno       = "aluno"
 )
 
 func getRefreshFromRequest(r *http.Request) (string, string, error) {
@@ -918,6 +1812,12 @@ func getRefreshFromRequest(r *http.Request) (string, string, error) {
 	if c, err := r.Cookie(refreshCookieBackoffice); err == nil && c.Value != "" {
 		return "backoffice", c.Value, nil
 	}
+	if c, err := r.Cookie(refreshCookieResponsavel); err == nil && c.Value != "" {
+		return "responsavel", c.Value, nil
+	}
+	if c, err := r.Cookie(refreshCookieAluno); err == nil && c.Value != "" {
+		return "aluno", c.Value, nil
+	}
 	if c, err := r.Cookie(refreshCookieCidadao); err == nil && c.Value != "" {
 		return "cidadao", c.Value, nil
 	}
@@ -931,6 +1831,10 @@ func (h *Handler) setRefreshCookie(w http.ResponseWriter, audience, token string
 		name = refreshCookieBackoffice
 	case "saas":
 		name = refreshCookieSaaS
+	case "responsavel":
+		name = refreshCookieResponsavel
+	case "aluno":
+		name = refreshCookieAluno
 	}
 	secure := !h.devCookies
 	sameSite := http.SameSiteNoneMode
@@ -955,6 +1859,10 @@ func (h *Handler) clearRefreshCookie(w http.ResponseWriter, audience string) {
 		name = refreshCookieBackoffice
 	case "saas":
 		name = refreshCookieSaaS
+	case "responsavel":
+		name = refreshCookieResponsavel
+	case "aluno":
+		name = refreshCookieAluno
 	}
 	secure := !h.devCookies
 	sameSite := http.SameSiteNoneMode
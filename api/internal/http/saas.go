@@ -17,6 +17,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 
+	"github.com/gestaozabele/municipio/internal/audit"
+	"github.com/gestaozabele/municipio/internal/backup"
 	"github.com/gestaozabele/municipio/internal/cloudflare"
 	"github.com/gestaozabele/municipio/internal/monitor"
 	"github.com/gestaozabele/municipio/internal/provision"
@@ -34,11 +36,13 @@ type tenantPayload struct {
 	DisplayName string              `json:"display_name"`
 	Domain      string              `json:"domain"`
 	Status      string              `json:"status"`
+	Environment string              `json:"environment"`
 	Notes       *string             `json:"notes"`
 	Contact     map[string]any      `json:"contact"`
 	Theme       map[string]any      `json:"theme"`
 	Settings    map[string]any      `json:"settings"`
 	InitialTeam []teamMemberPayload `json:"initial_team"`
+	IBGE        ibgeHint            `json:"ibge"`
 }
 
 type teamMemberPayload struct {
@@ -77,6 +81,12 @@ func (h *Handler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	environment := tenant.NormalizeEnvironment(payload.Environment)
+	if !tenant.IsValidEnvironment(environment) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "ambiente inválido", map[string]any{"allowed": []string{tenant.EnvironmentProduction, tenant.EnvironmentSandbox}})
+		return
+	}
+
 	creatorID, err := h.subjectUUID(r)
 	if err != nil {
 		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
@@ -109,6 +119,7 @@ func (h *Handler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 		DisplayName: payload.DisplayName,
 		Domain:      payload.Domain,
 		Status:      status,
+		Environment: environment,
 		Contact:     payload.Contact,
 		Theme:       payload.Theme,
 		Settings:    payload.Settings,
@@ -125,6 +136,8 @@ func (h *Handler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível criar tenant", nil)
 		return
 	}
+	audit.Entity(r.Context(), "tenants", tenantCreated.ID.String())
+	audit.Changes(r.Context(), nil, tenantCreated)
 
 	teamInvites, err := h.inviteInitialTeam(r.Context(), payload.InitialTeam, createdBy)
 	if err != nil {
@@ -137,6 +150,17 @@ func (h *Handler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 		"team_invites": teamInvites,
 	}
 
+	if h.ibge != nil {
+		lookupCtx, cancel := context.WithTimeout(r.Context(), ibgeLookupTimeout)
+		registry, ibgeErr := h.enrichTenantIBGE(lookupCtx, tenantCreated, payload.IBGE)
+		cancel()
+		if ibgeErr != nil {
+			response["ibge_warning"] = ibgeErr.Error()
+		} else {
+			response["registry"] = registry
+		}
+	}
+
 	if h.provisioner != nil && h.provisioner.IsConfigured() && status == tenant.StatusActive {
 		updated, provErr := h.provisioner.ProvisionTenant(r.Context(), tenantCreated.ID, false)
 		if provErr != nil {
@@ -243,6 +267,9 @@ func (h *Handler) CreateSaaSUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Entity(r.Context(), "users", user.ID.String())
+	audit.Changes(r.Context(), nil, user)
+	h.notifyOwnerGrants()
 	WriteJSON(w, http.StatusCreated, map[string]any{"user": user})
 }
 
@@ -284,6 +311,7 @@ func (h *Handler) InviteSaaSUser(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusCreated, map[string]any{
 		"invite": invite.Invite,
 		"token":  invite.Token,
+		"email":  h.sendInviteEmail(r.Context(), invite),
 	})
 }
 
@@ -342,6 +370,7 @@ func (h *Handler) UpdateSaaSUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.notifyOwnerGrants()
 	WriteJSON(w, http.StatusOK, map[string]any{"user": updated})
 }
 
@@ -370,7 +399,7 @@ func (h *Handler) DeleteSaaSUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.saasUsers.DeleteUser(r.Context(), userID); err != nil {
+	if err := h.saasUsers.DeleteUser(r.Context(), userID, &currentID); err != nil {
 		if errors.Is(err, saas.ErrNotFound) {
 			WriteError(w, http.StatusNotFound, "NOT_FOUND", "usuário não encontrado", nil)
 			return
@@ -522,6 +551,7 @@ func (h *Handler) inviteInitialTeam(ctx context.Context, members []teamMemberPay
 		invites = append(invites, map[string]any{
 			"invite": invite.Invite,
 			"token":  invite.Token,
+			"email":  h.sendInviteEmail(ctx, invite),
 		})
 	}
 
@@ -784,9 +814,41 @@ func (h *Handler) MonitorSummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dependencies, err := h.monitor.Dependencies(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar dependências", nil)
+		return
+	}
+
+	portfolio, err := h.loadSaaSPortfolio(r)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return
+	}
+	if portfolio.scoped {
+		visible := summaries[:0]
+		for _, summary := range summaries {
+			if portfolio.Allows(summary.TenantID) {
+				visible = append(visible, summary)
+			}
+		}
+		summaries = visible
+
+		// Alertas globais (sem tenant) ficam restritos a quem não tem carteira.
+		scopedAlerts := alerts[:0]
+		for _, alert := range alerts {
+			if alert.TenantID != nil && portfolio.Allows(*alert.TenantID) {
+				scopedAlerts = append(scopedAlerts, alert)
+			}
+		}
+		alerts = scopedAlerts
+		dependencies = []monitor.DependencyHealth{}
+	}
+
 	WriteJSON(w, http.StatusOK, map[string]any{
-		"summaries": summaries,
-		"alerts":    alerts,
+		"summaries":    summaries,
+		"alerts":       alerts,
+		"dependencies": dependencies,
 	})
 }
 
@@ -813,7 +875,44 @@ func (h *Handler) MonitorTenant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, map[string]any{"health": health})
+	anomalies, err := h.monitor.Anomalies(r.Context(), &tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar métricas", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"health": health, "anomalies": anomalies})
+}
+
+// MonitorAnomalies lista o estado do detector de anomalias de todos os tenants, desvios recentes primeiro.
+func (h *Handler) MonitorAnomalies(w http.ResponseWriter, r *http.Request) {
+	if h.monitor == nil || !h.monitorOn {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "monitoramento indisponível", nil)
+		return
+	}
+
+	anomalies, err := h.monitor.Anomalies(r.Context(), nil)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar anomalias", nil)
+		return
+	}
+
+	portfolio, err := h.loadSaaSPortfolio(r)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return
+	}
+	if portfolio.scoped {
+		visible := anomalies[:0]
+		for _, anomaly := range anomalies {
+			if portfolio.Allows(anomaly.TenantID) {
+				visible = append(visible, anomaly)
+			}
+		}
+		anomalies = visible
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"anomalies": anomalies})
 }
 
 // MonitorRun força uma coleta imediata.
@@ -831,6 +930,31 @@ func (h *Handler) MonitorRun(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusAccepted, map[string]any{"status": "running"})
 }
 
+// MonitorSchedulerJobs mostra qual réplica executou cada tarefa periódica e quando.
+func (h *Handler) MonitorSchedulerJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.scheduler.Jobs(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tarefas agendadas", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"instance": h.scheduler.Instance(),
+		"jobs":     jobs,
+	})
+}
+
+// MonitorPartitions lista as partições mensais de presenças e logs de acesso com volume estimado.
+func (h *Handler) MonitorPartitions(w http.ResponseWriter, r *http.Request) {
+	parts, err := h.partitions.List(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar partições", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"partitions": parts})
+}
+
 // GetCloudflareSettings devolve configuração sanitizada da Cloudflare.
 func (h *Handler) GetCloudflareSettings(w http.ResponseWriter, r *http.Request) {
 	if h.settings == nil {
@@ -879,6 +1003,12 @@ func (h *Handler) UpdateCloudflareSettings(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	previous, err := h.settings.GetSanitizedCloudflareConfig(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar configuração", nil)
+		return
+	}
+
 	merged, err := h.settings.MergeCloudflareConfig(r.Context(), settings.UpdateCloudflareConfigInput{
 		APIToken:       payload.APIToken,
 		ZoneID:         payload.ZoneID,
@@ -934,9 +1064,31 @@ func (h *Handler) UpdateCloudflareSettings(w http.ResponseWriter, r *http.Reques
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar configuração", nil)
 		return
 	}
+	audit.Changes(r.Context(), previous, sanitized)
 
 	WriteJSON(w, http.StatusOK, map[string]any{
 		"config":     sanitized,
 		"configured": h.provisioner != nil && h.provisioner.IsConfigured(),
 	})
 }
+
+// GetBackupSettings mostra o último dump bem-sucedido de cada escopo e os testes de restauração
+// registrados pelo cmd/backup.
+func (h *Handler) GetBackupSettings(w http.ResponseWriter, r *http.Request) {
+	status, err := backup.LoadStatus(r.Context(), h.pool)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar backups", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, status)
+}
+
+// writeTenantLookupError traduz falhas ao localizar tenant por id.
+func writeTenantLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, tenant.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "tenant não encontrado", nil)
+		return
+	}
+	WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenant", nil)
+}
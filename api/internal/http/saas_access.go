@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/presence"
 )
 
 type accessLogPayload struct {
@@ -30,6 +32,34 @@ func (h *Handler) ListAccessLogs(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, map[string]any{"access_logs": logs})
 }
 
+// ListOnlineUsers devolve usuários simultâneos por prefeitura e audiência a partir dos heartbeats.
+func (h *Handler) ListOnlineUsers(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.presence.Snapshot(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "presença online indisponível", nil)
+		return
+	}
+
+	if raw := strings.TrimSpace(r.URL.Query().Get("tenant_id")); raw != "" {
+		tenantID, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant_id inválido", nil)
+			return
+		}
+		tenant := presence.TenantOnline{TenantID: tenantID, Audiences: map[string]int64{}}
+		for _, item := range snapshot.Tenants {
+			if item.TenantID == tenantID {
+				tenant = item
+				break
+			}
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{"tenant": tenant, "janela": snapshot.Janela, "gerado_em": snapshot.GeradoEm})
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, snapshot)
+}
+
 // CreateAccessLog registra um novo evento de acesso.
 func (h *Handler) CreateAccessLog(w http.ResponseWriter, r *http.Request) {
 	var payload accessLogPayload
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type anoLetivoView struct {
+	ID        uuid.UUID `json:"id"`
+	Ano       int       `json:"ano"`
+	Inicio    string    `json:"inicio"`
+	Fim       string    `json:"fim"`
+	Ativo     bool      `json:"ativo"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type anoLetivoPayload struct {
+	Ano    int    `json:"ano"`
+	Inicio string `json:"inicio"`
+	Fim    string `json:"fim"`
+	Ativo  bool   `json:"ativo"`
+}
+
+// ListAnosLetivos lista os anos letivos cadastrados para a prefeitura.
+func (h *Handler) ListAnosLetivos(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	anos, err := h.loadAnosLetivos(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar anos letivos", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"anos_letivos": anos})
+}
+
+// SaveAnoLetivo cria ou atualiza o período de um ano letivo, opcionalmente ativando-o.
+func (h *Handler) SaveAnoLetivo(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	var payload anoLetivoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	if payload.Ano < 2000 || payload.Ano > 2100 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "ano inválido", nil)
+		return
+	}
+	inicio, err := parseISODate(payload.Inicio)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "inicio inválido", nil)
+		return
+	}
+	fim, err := parseISODate(payload.Fim)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fim inválido", nil)
+		return
+	}
+	if !fim.After(inicio) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fim deve ser posterior ao início", nil)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar ano letivo", nil)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if payload.Ativo {
+		if _, err := tx.Exec(r.Context(), `UPDATE anos_letivos SET ativo = FALSE, updated_at = now() WHERE tenant_id = $1 AND ativo AND ano <> $2`, tenantID, payload.Ano); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar ano letivo", nil)
+			return
+		}
+	}
+
+	if _, err := tx.Exec(r.Context(), `
+		INSERT INTO anos_letivos (tenant_id, ano, inicio, fim, ativo)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, ano)
+		DO UPDATE SET inicio = EXCLUDED.inicio, fim = EXCLUDED.fim, ativo = anos_letivos.ativo OR EXCLUDED.ativo, updated_at = now()
+	`, tenantID, payload.Ano, inicio, fim, payload.Ativo); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23514" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar ano letivo", nil)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar ano letivo", nil)
+		return
+	}
+
+	anos, err := h.loadAnosLetivos(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar anos letivos", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"anos_letivos": anos})
+}
+
+// ActivateAnoLetivo define o ano letivo vigente da prefeitura; os demais ficam apenas para consulta.
+func (h *Handler) ActivateAnoLetivo(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	ano, err := strconv.Atoi(strings.TrimSpace(chi.URLParam(r, "ano")))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "ano inválido", nil)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível ativar ano letivo", nil)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if _, err := tx.Exec(r.Context(), `UPDATE anos_letivos SET ativo = FALSE, updated_at = now() WHERE tenant_id = $1 AND ativo AND ano <> $2`, tenantID, ano); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível ativar ano letivo", nil)
+		return
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRow(r.Context(), `
+		UPDATE anos_letivos SET ativo = TRUE, updated_at = now()
+		WHERE tenant_id = $1 AND ano = $2
+		RETURNING id
+	`, tenantID, ano).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "ano letivo não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível ativar ano letivo", nil)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível ativar ano letivo", nil)
+		return
+	}
+
+	anos, err := h.loadAnosLetivos(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar anos letivos", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"anos_letivos": anos})
+}
+
+func (h *Handler) loadAnosLetivos(ctx context.Context, tenantID uuid.UUID) ([]anoLetivoView, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT id, ano, inicio, fim, ativo, created_at, updated_at
+		FROM anos_letivos
+		WHERE tenant_id = $1
+		ORDER BY ano DESC
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anos := make([]anoLetivoView, 0)
+	for rows.Next() {
+		var (
+			item        anoLetivoView
+			inicio, fim time.Time
+		)
+		if err := rows.Scan(&item.ID, &item.Ano, &inicio, &fim, &item.Ativo, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.Inicio = inicio.Format("2006-01-02")
+		item.Fim = fim.Format("2006-01-02")
+		anos = append(anos, item)
+	}
+	return anos, rows.Err()
+}
@@ -141,6 +141,7 @@ func (h *Handler) UpdateAppCustomization(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	h.purgeBrandingAsync(tenantID)
 	WriteJSON(w, http.StatusOK, map[string]any{"app": customization})
 }
 
@@ -196,6 +197,12 @@ func (h *Handler) UploadAppLogo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A logo antiga continua em cache com max-age longo; entra no purge junto com as páginas.
+	var previousLogo []string
+	if current, err := h.fetchAppCustomization(r.Context(), tenantID); err == nil && current.LogoURL != nil {
+		previousLogo = append(previousLogo, *current.LogoURL)
+	}
+
 	update := `
         INSERT INTO saas_app_customizations (tenant_id, logo_url, logo_key)
         VALUES ($1, $2, $3)
@@ -213,6 +220,7 @@ func (h *Handler) UploadAppLogo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.purgeBrandingAsync(tenantID, previousLogo...)
 	WriteJSON(w, http.StatusOK, map[string]any{"app": customization})
 }
 
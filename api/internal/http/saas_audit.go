@@ -0,0 +1,183 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/audit"
+)
+
+// ListAuditLog consulta a trilha de ações de escrita do painel SaaS. Por padrão só traz as ações
+// concluídas; falhas=true inclui as recusadas.
+func (h *Handler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Entity:   strings.TrimSpace(query.Get("entity")),
+		EntityID: strings.TrimSpace(query.Get("entity_id")),
+		Action:   strings.TrimSpace(query.Get("action")),
+		Falhas:   strings.EqualFold(query.Get("falhas"), "true"),
+	}
+	for _, param := range []struct {
+		name string
+		dst  **uuid.UUID
+	}{{"actor_id", &filter.ActorID}, {"tenant_id", &filter.TenantID}} {
+		raw := strings.TrimSpace(query.Get(param.name))
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", param.name+" inválido", nil)
+			return
+		}
+		*param.dst = &id
+	}
+	if raw := strings.TrimSpace(query.Get("from")); raw != "" {
+		from, err := parseISODate(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		filter.From = &from
+	}
+	if raw := strings.TrimSpace(query.Get("to")); raw != "" {
+		to, err := parseISODate(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		to = to.Add(24 * time.Hour)
+		filter.To = &to
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > 500 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit deve estar entre 1 e 500", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.audit.List(r.Context(), filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível consultar a auditoria", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// GetAuditLogEntry devolve um registro da trilha com os valores antes/depois completos.
+func (h *Handler) GetAuditLogEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "entryID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	entry, err := h.audit.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, audit.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "registro não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar o registro", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"entry": entry})
+}
+
+// ExportAuditLog exporta a cadeia em NDJSON, um registro por linha em ordem de seq, para que o
+// auditor recalcule os hashes por conta própria (ver cmd/auditverify). Aceita from_seq e to_seq.
+func (h *Handler) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	fromSeq, toSeq, ok := parseAuditSeqRange(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"auditoria-%d-%d.ndjson\"", fromSeq, toSeq))
+	enc := json.NewEncoder(w)
+	// Depois do primeiro registro o status já foi enviado; uma falha no meio só trunca o arquivo,
+	// o que a verificação acusa como cadeia incompleta.
+	if err := h.audit.Chain(r.Context(), fromSeq, toSeq, func(e audit.Entry) error {
+		return enc.Encode(e)
+	}); err != nil {
+		log.Error().Err(err).Msg("audit: exportação interrompida")
+	}
+}
+
+// VerifyAuditLog recalcula a cadeia no intervalo e confere as âncoras publicadas.
+func (h *Handler) VerifyAuditLog(w http.ResponseWriter, r *http.Request) {
+	fromSeq, toSeq, ok := parseAuditSeqRange(w, r)
+	if !ok {
+		return
+	}
+	anchors, err := h.audit.ListAnchors(r.Context(), fromSeq, toSeq)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar as âncoras", nil)
+		return
+	}
+	hashes := make(map[int64]string, len(anchors))
+	for _, a := range anchors {
+		hashes[a.Seq] = a.Hash
+	}
+
+	verifier := audit.NewVerifier(hashes)
+	err = h.audit.Chain(r.Context(), fromSeq, toSeq, verifier.Add)
+	var chainErr *audit.ChainError
+	if err != nil && !errors.As(err, &chainErr) {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível verificar a auditoria", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"valid":           chainErr == nil,
+		"checked":         verifier.Checked,
+		"first_seq":       verifier.FirstSeq,
+		"last_seq":        verifier.LastSeq,
+		"last_hash":       verifier.LastHash,
+		"anchors":         len(anchors),
+		"anchors_matched": verifier.Anchored,
+		"error":           chainErr,
+	})
+}
+
+// ListAuditAnchors lista as âncoras publicadas, com a chave do objeto para conferência externa.
+func (h *Handler) ListAuditAnchors(w http.ResponseWriter, r *http.Request) {
+	fromSeq, toSeq, ok := parseAuditSeqRange(w, r)
+	if !ok {
+		return
+	}
+	anchors, err := h.audit.ListAnchors(r.Context(), fromSeq, toSeq)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar as âncoras", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"anchors": anchors})
+}
+
+func parseAuditSeqRange(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	var bounds [2]int64
+	for i, name := range []string{"from_seq", "to_seq"} {
+		raw := strings.TrimSpace(r.URL.Query().Get(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 1 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", name+" inválido", nil)
+			return 0, 0, false
+		}
+		bounds[i] = value
+	}
+	if bounds[1] != 0 && bounds[1] < bounds[0] {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "to_seq deve ser maior ou igual a from_seq", nil)
+		return 0, 0, false
+	}
+	return bounds[0], bounds[1], true
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type chamadaPoliticaPayload struct {
+	GeofenceObrigatorio  bool `json:"geofence_obrigatorio"`
+	AtestacaoObrigatoria bool `json:"atestacao_obrigatoria"`
+	RaioMetros           *int `json:"raio_metros,omitempty"`
+}
+
+// UpdateChamadaPolitica grava em settings.chamada se a chamada do app exige geofence e atestação do dispositivo.
+func (h *Handler) UpdateChamadaPolitica(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	var payload chamadaPoliticaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if payload.RaioMetros != nil && (*payload.RaioMetros < 20 || *payload.RaioMetros > 5000) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "raio_metros deve estar entre 20 e 5000", nil)
+		return
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar política", nil)
+		return
+	}
+	if _, err := h.pool.Exec(r.Context(), `
+		UPDATE tenants
+		SET settings = jsonb_set(settings, '{chamada}', $2::jsonb), updated_at = now()
+		WHERE id = $1
+	`, tenantID, raw); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar política", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"chamada": payload})
+}
@@ -29,6 +29,16 @@ func (h *Handler) ListCityInsights(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, map[string]any{"cities": insights})
 }
 
+// CitiesLivePresence agrega os alunos presentes agora em todas as prefeituras.
+func (h *Handler) CitiesLivePresence(w http.ResponseWriter, r *http.Request) {
+	live, err := h.livePresence.Cidades(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar presença", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, live)
+}
+
 // SyncCityInsight atualiza métricas coletadas e registra timestamp de sincronização.
 func (h *Handler) SyncCityInsight(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := parseUUIDParam(r, "id")
@@ -101,6 +101,7 @@ func (h *Handler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
 }
 
 // ApprovePushNotification aprova notificação pendente e registra auditoria.
+// Em tenants sandbox a notificação é marcada como suprimida em vez de enviada.
 func (h *Handler) ApprovePushNotification(w http.ResponseWriter, r *http.Request) {
 	pushID, err := parseUUIDParam(r, "id")
 	if err != nil {
@@ -118,9 +119,15 @@ func (h *Handler) ApprovePushNotification(w http.ResponseWriter, r *http.Request
 	_ = json.NewDecoder(r.Body).Decode(&payload)
 
 	const update = `
-        UPDATE saas_push_notifications
-        SET status = 'approved', decided_by = $1, decided_at = now(), decision_reason = NULL, updated_at = now()
-        WHERE id = $2 AND status = 'pending'
+        UPDATE saas_push_notifications p
+        SET status = CASE WHEN sandbox.flag THEN 'suppressed' ELSE 'approved' END,
+            decision_reason = CASE WHEN sandbox.flag THEN 'envio suprimido: tenant em sandbox' ELSE NULL END,
+            decided_by = $1, decided_at = now(), updated_at = now()
+        FROM (SELECT EXISTS (
+            SELECT 1 FROM saas_push_notifications n JOIN tenants t ON t.id = n.tenant_id
+            WHERE n.id = $2 AND t.environment = 'sandbox'
+        ) AS flag) sandbox
+        WHERE p.id = $2 AND p.status = 'pending'
     `
 
 	tag, err := h.pool.Exec(r.Context(), update, actorID, pushID)
@@ -0,0 +1,405 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/esign"
+)
+
+// terminalSignatureStatuses não são sobrescritos por eventos atrasados do provedor.
+var terminalSignatureStatuses = map[string]struct{}{
+	esign.StatusSigned:    {},
+	esign.StatusRefused:   {},
+	esign.StatusCancelled: {},
+	esign.StatusExpired:   {},
+}
+
+type contractVersionView struct {
+	ID                 uuid.UUID  `json:"id"`
+	Version            int        `json:"version"`
+	FileURL            string     `json:"file_url"`
+	DownloadURL        string     `json:"download_url"`
+	FileName           *string    `json:"file_name,omitempty"`
+	EffectiveFrom      time.Time  `json:"effective_from"`
+	EffectiveTo        *time.Time `json:"effective_to,omitempty"`
+	Notes              *string    `json:"notes,omitempty"`
+	SignatureProvider  *string    `json:"signature_provider,omitempty"`
+	SignatureStatus    string     `json:"signature_status"`
+	SignatureURL       *string    `json:"signature_url,omitempty"`
+	SignedAt           *time.Time `json:"signed_at,omitempty"`
+	SignatureUpdatedAt *time.Time `json:"signature_updated_at,omitempty"`
+	UploadedBy         *uuid.UUID `json:"uploaded_by,omitempty"`
+	UploadedAt         time.Time  `json:"uploaded_at"`
+}
+
+type contractSignatureEventView struct {
+	ID          uuid.UUID `json:"id"`
+	Provider    string    `json:"provider"`
+	Event       string    `json:"event"`
+	Status      string    `json:"status"`
+	SignerEmail *string   `json:"signer_email,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+type contractVersionInput struct {
+	TenantID      uuid.UUID
+	FileKey       string
+	FileName      string
+	EffectiveFrom time.Time
+	Notes         sql.NullString
+	UploadedBy    uuid.UUID
+}
+
+// ListTenantContractVersions lista as versões do contrato, da mais recente para a mais antiga.
+func (h *Handler) ListTenantContractVersions(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	versions, err := h.loadContractVersions(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar versões", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"versions": versions})
+}
+
+// ListContractSignatureEvents devolve o histórico de eventos de assinatura da versão.
+func (h *Handler) ListContractSignatureEvents(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	versionID, err := parseUUIDParam(r, "versionID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id da versão inválido", nil)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(), `
+        SELECT e.id, e.provider, e.event, e.status, e.signer_email, e.occurred_at, e.received_at
+        FROM saas_contract_signature_events e
+        JOIN saas_tenant_contract_versions v ON v.id = e.version_id
+        WHERE v.tenant_id = $1 AND v.id = $2
+        ORDER BY e.received_at DESC
+    `, tenantID, versionID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar eventos", nil)
+		return
+	}
+	defer rows.Close()
+
+	events := []contractSignatureEventView{}
+	for rows.Next() {
+		var (
+			event  contractSignatureEventView
+			signer sql.NullString
+		)
+		if err := rows.Scan(&event.ID, &event.Provider, &event.Event, &event.Status, &signer, &event.OccurredAt, &event.ReceivedAt); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar eventos", nil)
+			return
+		}
+		if signer.Valid {
+			str := strings.TrimSpace(signer.String)
+			event.SignerEmail = &str
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar eventos", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"events": events})
+}
+
+// ESignWebhook recebe atualizações de andamento do provedor de assinatura.
+func (h *Handler) ESignWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.esign == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "assinatura eletrônica não configurada", nil)
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "provider")))
+	if provider != h.esign.Name() {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "provedor desconhecido", nil)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	event, err := h.esign.ParseWebhook(r, body)
+	switch {
+	case errors.Is(err, esign.ErrInvalidSignature):
+		WriteError(w, http.StatusUnauthorized, "AUTH", "assinatura do webhook inválida", nil)
+		return
+	case errors.Is(err, esign.ErrIgnoredEvent):
+		WriteJSON(w, http.StatusOK, map[string]any{"ignored": true})
+		return
+	case err != nil:
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	status, err := h.applyContractSignatureEvent(r.Context(), provider, event, body)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "documento não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar evento", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"status": status})
+}
+
+// createContractVersion grava a nova versão, encerra a vigência da anterior e
+// mantém contract_file_key apontando para o arquivo mais recente. A URL do bucket não é gravada:
+// o contrato só sai por link assinado.
+func (h *Handler) createContractVersion(ctx context.Context, input contractVersionInput) (contractVersionView, error) {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return contractVersionView{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "INSERT INTO saas_tenant_contracts (tenant_id) VALUES ($1) ON CONFLICT DO NOTHING", input.TenantID); err != nil {
+		return contractVersionView{}, err
+	}
+	if _, err := tx.Exec(ctx, "SELECT 1 FROM saas_tenant_contracts WHERE tenant_id = $1 FOR UPDATE", input.TenantID); err != nil {
+		return contractVersionView{}, err
+	}
+
+	const closePrevious = `
+        UPDATE saas_tenant_contract_versions
+        SET effective_to = GREATEST(effective_from, $2::date - 1)
+        WHERE tenant_id = $1 AND effective_to IS NULL
+    `
+	if _, err := tx.Exec(ctx, closePrevious, input.TenantID, input.EffectiveFrom); err != nil {
+		return contractVersionView{}, err
+	}
+
+	const insert = `
+        INSERT INTO saas_tenant_contract_versions (tenant_id, version, file_key, file_name, effective_from, notes, uploaded_by)
+        VALUES ($1, (SELECT COALESCE(MAX(version), 0) + 1 FROM saas_tenant_contract_versions WHERE tenant_id = $1), $2, NULLIF($3,''), $4, $5, $6)
+        RETURNING ` + contractVersionColumns
+
+	version, err := scanContractVersion(tx.QueryRow(ctx, insert, input.TenantID, input.FileKey, input.FileName, input.EffectiveFrom, nullableString(input.Notes), input.UploadedBy))
+	if err != nil {
+		return contractVersionView{}, err
+	}
+
+	const update = `
+        UPDATE saas_tenant_contracts
+        SET contract_file_url = NULL, contract_file_key = $2, updated_by = $3, updated_at = now()
+        WHERE tenant_id = $1
+    `
+	if _, err := tx.Exec(ctx, update, input.TenantID, input.FileKey, input.UploadedBy); err != nil {
+		return contractVersionView{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return contractVersionView{}, err
+	}
+	return version, nil
+}
+
+func (h *Handler) requestContractSignature(ctx context.Context, version contractVersionView, filename, contentType string, content []byte, signers []esign.Signer) error {
+	envelope, err := h.esign.Send(ctx, esign.Document{
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     content,
+		Signers:     signers,
+	})
+	if err != nil {
+		return err
+	}
+
+	const update = `
+        UPDATE saas_tenant_contract_versions
+        SET signature_provider = $2, signature_document_id = $3, signature_status = $4, signature_url = NULLIF($5,''), signature_updated_at = now()
+        WHERE id = $1
+    `
+	_, err = h.pool.Exec(ctx, update, version.ID, h.esign.Name(), envelope.DocumentID, envelope.Status, envelope.SignURL)
+	return err
+}
+
+func (h *Handler) applyContractSignatureEvent(ctx context.Context, provider string, event esign.Event, payload []byte) (string, error) {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		versionID uuid.UUID
+		current   string
+	)
+	const lookup = `
+        SELECT id, signature_status
+        FROM saas_tenant_contract_versions
+        WHERE signature_provider = $1 AND signature_document_id = $2
+        FOR UPDATE
+    `
+	if err := tx.QueryRow(ctx, lookup, provider, event.DocumentID).Scan(&versionID, &current); err != nil {
+		return "", err
+	}
+
+	var rawPayload any
+	if json.Valid(payload) {
+		rawPayload = payload
+	} else {
+		encoded, _ := json.Marshal(map[string]string{"raw": string(payload)})
+		rawPayload = encoded
+	}
+
+	const insertEvent = `
+        INSERT INTO saas_contract_signature_events (version_id, provider, event, status, signer_email, payload, occurred_at)
+        VALUES ($1, $2, $3, $4, NULLIF($5,''), $6, $7)
+    `
+	if _, err := tx.Exec(ctx, insertEvent, versionID, provider, event.Name, event.Status, event.Signer, rawPayload, event.OccurredAt); err != nil {
+		return "", err
+	}
+
+	status := current
+	if _, done := terminalSignatureStatuses[current]; !done {
+		status = event.Status
+		const update = `
+            UPDATE saas_tenant_contract_versions
+            SET signature_status = $2,
+                signed_at = CASE WHEN $2 = 'signed' THEN $3 ELSE signed_at END,
+                signature_updated_at = now()
+            WHERE id = $1
+        `
+		if _, err := tx.Exec(ctx, update, versionID, status, event.OccurredAt); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+const contractVersionColumns = `id, version, file_name, effective_from, effective_to, notes, signature_provider, signature_document_id, signature_status, signature_url, signed_at, signature_updated_at, uploaded_by, uploaded_at`
+
+func (h *Handler) loadContractVersions(ctx context.Context, tenantID uuid.UUID) ([]contractVersionView, error) {
+	rows, err := h.pool.Query(ctx, `
+        SELECT `+contractVersionColumns+`
+        FROM saas_tenant_contract_versions
+        WHERE tenant_id = $1
+        ORDER BY version DESC
+    `, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []contractVersionView{}
+	for rows.Next() {
+		version, err := scanContractVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func scanContractVersion(row pgx.Row) (contractVersionView, error) {
+	var (
+		version     contractVersionView
+		fileName    sql.NullString
+		effectiveTo sql.NullTime
+		notes       sql.NullString
+		provider    sql.NullString
+		signURL     sql.NullString
+		signedAt    sql.NullTime
+		updatedAt   sql.NullTime
+		uploadedBy  uuid.NullUUID
+		documentID  sql.NullString
+	)
+
+	if err := row.Scan(&version.ID, &version.Version, &fileName, &version.EffectiveFrom, &effectiveTo, &notes, &provider, &documentID, &version.SignatureStatus, &signURL, &signedAt, &updatedAt, &uploadedBy, &version.UploadedAt); err != nil {
+		return contractVersionView{}, err
+	}
+
+	version.DownloadURL = fileDownloadPath(version.ID)
+	version.FileURL = version.DownloadURL
+	if fileName.Valid {
+		str := fileName.String
+		version.FileName = &str
+	}
+	if effectiveTo.Valid {
+		ts := effectiveTo.Time
+		version.EffectiveTo = &ts
+	}
+	if notes.Valid {
+		str := strings.TrimSpace(notes.String)
+		version.Notes = &str
+	}
+	if provider.Valid {
+		str := provider.String
+		version.SignatureProvider = &str
+	}
+	if signURL.Valid {
+		str := signURL.String
+		version.SignatureURL = &str
+	}
+	if signedAt.Valid {
+		ts := signedAt.Time
+		version.SignedAt = &ts
+	}
+	if updatedAt.Valid {
+		ts := updatedAt.Time
+		version.SignatureUpdatedAt = &ts
+	}
+	if uploadedBy.Valid {
+		id := uploadedBy.UUID
+		version.UploadedBy = &id
+	}
+	return version, nil
+}
+
+// parseContractSigners interpreta o campo "signers" (JSON) do formulário de upload.
+func parseContractSigners(raw string) ([]esign.Signer, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var signers []esign.Signer
+	if err := json.Unmarshal([]byte(raw), &signers); err != nil {
+		return nil, errors.New("signers deve ser uma lista JSON de {name, email}")
+	}
+	for i := range signers {
+		signers[i].Name = strings.TrimSpace(signers[i].Name)
+		signers[i].Email = strings.ToLower(strings.TrimSpace(signers[i].Email))
+		if signers[i].Email == "" || !strings.Contains(signers[i].Email, "@") {
+			return nil, fmt.Errorf("signatário %d sem e-mail válido", i+1)
+		}
+	}
+	return signers, nil
+}
@@ -14,7 +14,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
 
+	"github.com/gestaozabele/municipio/internal/audit"
+	"github.com/gestaozabele/municipio/internal/ibge"
 	"github.com/gestaozabele/municipio/internal/storage"
 )
 
@@ -31,24 +34,33 @@ type contractModulePayload struct {
 }
 
 type contractView struct {
-	Status        string              `json:"status"`
-	ContractValue *float64            `json:"contract_value"`
-	StartDate     *time.Time          `json:"start_date"`
-	RenewalDate   *time.Time          `json:"renewal_date"`
-	Notes         *string             `json:"notes"`
-	ContractFile  *string             `json:"contract_file_url"`
-	Modules       map[string]bool     `json:"modules"`
-	Invoices      []tenantInvoiceView `json:"invoices"`
+	Status        string                `json:"status"`
+	ContractValue *float64              `json:"contract_value"`
+	StartDate     *time.Time            `json:"start_date"`
+	RenewalDate   *time.Time            `json:"renewal_date"`
+	Notes         *string               `json:"notes"`
+	ContractFile  *string               `json:"contract_file_url"`
+	Modules       map[string]bool       `json:"modules"`
+	Versions      []contractVersionView `json:"versions"`
+	Invoices      []tenantInvoiceView   `json:"invoices"`
+	Registry      *tenantRegistryView   `json:"registry,omitempty"`
+	BillingTier   *ibge.Tier            `json:"billing_tier,omitempty"`
 }
 
 type tenantInvoiceView struct {
-	ID             uuid.UUID `json:"id"`
-	ReferenceMonth time.Time `json:"reference_month"`
-	Amount         *float64  `json:"amount"`
-	Status         string    `json:"status"`
-	FileURL        *string   `json:"file_url"`
-	UploadedAt     time.Time `json:"uploaded_at"`
-	Notes          *string   `json:"notes"`
+	ID             uuid.UUID  `json:"id"`
+	ReferenceMonth time.Time  `json:"reference_month"`
+	Amount         *float64   `json:"amount"`
+	Status         string     `json:"status"`
+	FileURL        *string    `json:"file_url"`
+	DownloadURL    *string    `json:"download_url"`
+	UploadedAt     time.Time  `json:"uploaded_at"`
+	Notes          *string    `json:"notes"`
+	ScanStatus     string     `json:"scan_status"`
+	ScanDetail     *string    `json:"scan_detail,omitempty"`
+	ScannedAt      *time.Time `json:"scanned_at,omitempty"`
+	Quarantined    bool       `json:"quarantined"`
+	ReleasedAt     *time.Time `json:"released_at,omitempty"`
 }
 
 // GetTenantContract retorna os detalhes contratuais da prefeitura.
@@ -139,6 +151,12 @@ func (h *Handler) UpdateTenantContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// O retrato anterior alimenta o diff da auditoria; contrato ausente cai no 404 abaixo.
+	var before any
+	if previous, err := h.fetchTenantContract(r.Context(), tenantID); err == nil {
+		before = previous
+	}
+
 	args = append(args, tenantID)
 	query := fmt.Sprintf("UPDATE saas_tenant_contracts SET %s, updated_at = now() WHERE tenant_id = $%d", strings.Join(setParts, ", "), idx)
 
@@ -158,6 +176,7 @@ func (h *Handler) UpdateTenantContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Changes(r.Context(), before, contract)
 	WriteJSON(w, http.StatusOK, map[string]any{"contract": contract})
 }
 
@@ -218,7 +237,8 @@ func (h *Handler) UpdateTenantModules(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, map[string]any{"contract": contract})
 }
 
-// UploadTenantContractFile envia o PDF do contrato assinado.
+// UploadTenantContractFile registra nova versão do contrato, preservando as anteriores.
+// Quando informados signatários, a versão é enviada ao provedor de assinatura eletrônica.
 func (h *Handler) UploadTenantContractFile(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := parseUUIDParam(r, "id")
 	if err != nil {
@@ -237,6 +257,34 @@ func (h *Handler) UploadTenantContractFile(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	effectiveFrom := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := strings.TrimSpace(r.FormValue("effective_from")); value != "" {
+		parsed, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "effective_from deve estar no formato YYYY-MM-DD", nil)
+			return
+		}
+		effectiveFrom = parsed
+	}
+
+	signers, err := parseContractSigners(r.FormValue("signers"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if len(signers) > 0 && h.esign == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "assinatura eletrônica não configurada", nil)
+		return
+	}
+
+	notesVal := strings.TrimSpace(r.FormValue("notes"))
+
+	uploaderID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
 	if h.storage == nil {
 		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
 		return
@@ -259,28 +307,37 @@ func (h *Handler) UploadTenantContractFile(w http.ResponseWriter, r *http.Reques
 	}
 
 	key := fmt.Sprintf("contracts/%s/%d%s", tenantID.String(), time.Now().UnixNano(), ext)
-	result, err := h.storage.Upload(r.Context(), storage.UploadInput{
+	if _, err := h.storage.Upload(r.Context(), storage.UploadInput{
 		Key:          key,
 		Body:         data,
 		ContentType:  contentType,
 		CacheControl: "private,max-age=31536000",
-	})
-	if err != nil {
+	}); err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao enviar contrato", nil)
 		return
 	}
 
-	const update = `
-        INSERT INTO saas_tenant_contracts (tenant_id, contract_file_url, contract_file_key)
-        VALUES ($1, $2, $3)
-        ON CONFLICT (tenant_id) DO UPDATE SET contract_file_url = EXCLUDED.contract_file_url, contract_file_key = EXCLUDED.contract_file_key, updated_at = now()
-    `
-
-	if _, err := h.pool.Exec(r.Context(), update, tenantID, result.URL, key); err != nil {
+	version, err := h.createContractVersion(r.Context(), contractVersionInput{
+		TenantID:      tenantID,
+		FileKey:       key,
+		FileName:      fileHeader.Filename,
+		EffectiveFrom: effectiveFrom,
+		Notes:         sql.NullString{String: notesVal, Valid: notesVal != ""},
+		UploadedBy:    uploaderID,
+	})
+	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar arquivo", nil)
 		return
 	}
 
+	if len(signers) > 0 {
+		if err := h.requestContractSignature(r.Context(), version, fileHeader.Filename, contentType, data, signers); err != nil {
+			log.Error().Err(err).Str("tenant", tenantID.String()).Str("version", version.ID.String()).Msg("esign: falha ao enviar contrato")
+			WriteError(w, http.StatusBadGateway, "INTERNAL", "versão registrada, mas o envio para assinatura falhou", map[string]any{"version_id": version.ID})
+			return
+		}
+	}
+
 	contract, err := h.fetchTenantContract(r.Context(), tenantID)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar contrato", nil)
@@ -350,36 +407,48 @@ func (h *Handler) UploadTenantInvoice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	scan := h.scanInvoice(r.Context(), data)
+
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
 	if ext == "" {
 		ext = ".pdf"
 	}
 
 	key := fmt.Sprintf("contracts/%s/invoices/%d%s", tenantID.String(), time.Now().UnixNano(), ext)
-	result, err := h.storage.Upload(r.Context(), storage.UploadInput{
+	if _, err := h.storage.Upload(r.Context(), storage.UploadInput{
 		Key:          key,
 		Body:         data,
 		ContentType:  contentType,
 		CacheControl: "private,max-age=31536000",
-	})
-	if err != nil {
+	}); err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao enviar nota", nil)
 		return
 	}
 
 	const insert = `
-        INSERT INTO saas_tenant_invoices (tenant_id, reference_month, amount, status, file_url, file_key, notes)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-        ON CONFLICT (tenant_id, reference_month) DO UPDATE SET amount = EXCLUDED.amount, status = EXCLUDED.status, file_url = EXCLUDED.file_url, file_key = EXCLUDED.file_key, notes = EXCLUDED.notes, uploaded_at = now()
+        INSERT INTO saas_tenant_invoices (tenant_id, reference_month, amount, status, file_url, file_key, notes, scan_status, scan_detail, scanned_at)
+        VALUES ($1, $2, $3, $4, NULL, $5, $6, $7, $8, $9)
+        ON CONFLICT (tenant_id, reference_month) DO UPDATE SET amount = EXCLUDED.amount, status = EXCLUDED.status, file_url = NULL, file_key = EXCLUDED.file_key, notes = EXCLUDED.notes, uploaded_at = now(),
+            scan_status = EXCLUDED.scan_status, scan_detail = EXCLUDED.scan_detail, scanned_at = EXCLUDED.scanned_at, released_by = NULL, released_at = NULL
         RETURNING id
     `
 
 	var invoiceID uuid.UUID
-	if err := h.pool.QueryRow(r.Context(), insert, tenantID, referenceMonth, nullableFloat(amount), status, result.URL, key, nullableString(sql.NullString{String: notesVal, Valid: notesVal != ""})).Scan(&invoiceID); err != nil {
+	if err := h.pool.QueryRow(r.Context(), insert, tenantID, referenceMonth, nullableFloat(amount), status, key, nullableString(sql.NullString{String: notesVal, Valid: notesVal != ""}), scan.Status, scan.Detail, scan.ScannedAt).Scan(&invoiceID); err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar nota", nil)
 		return
 	}
 
+	if status == "paid" {
+		var actorID *uuid.UUID
+		if subject, err := h.subjectUUID(r); err == nil {
+			actorID = &subject
+		}
+		if _, err := h.accrueInvoiceCommission(r.Context(), invoiceID, actorID); err != nil {
+			log.Error().Err(err).Str("invoice_id", invoiceID.String()).Msg("comissão de parceiro não gerada")
+		}
+	}
+
 	contract, err := h.fetchTenantContract(r.Context(), tenantID)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar contrato", nil)
@@ -402,6 +471,20 @@ func (h *Handler) DeleteTenantInvoice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var scanStatus string
+	if err := h.pool.QueryRow(r.Context(), "SELECT scan_status FROM saas_tenant_invoices WHERE tenant_id = $1 AND id = $2", tenantID, invoiceID).Scan(&scanStatus); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "nota não encontrada", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover nota", nil)
+		return
+	}
+	if invoiceQuarantined(scanStatus) && !hasSaaSRole(r, "SAAS_OWNER") {
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "somente SAAS_OWNER remove nota em quarentena", nil)
+		return
+	}
+
 	tag, err := h.pool.Exec(r.Context(), "DELETE FROM saas_tenant_invoices WHERE tenant_id = $1 AND id = $2", tenantID, invoiceID)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover nota", nil)
@@ -423,7 +506,8 @@ func (h *Handler) DeleteTenantInvoice(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) fetchTenantContract(ctx context.Context, tenantID uuid.UUID) (contractView, error) {
 	const contractQuery = `
-        SELECT status, contract_value, start_date, renewal_date, notes, contract_file_url
+        SELECT status, contract_value, start_date, renewal_date, notes,
+               contract_file_key IS NOT NULL OR contract_file_url IS NOT NULL
         FROM saas_tenant_contracts
         WHERE tenant_id = $1
     `
@@ -434,10 +518,10 @@ func (h *Handler) fetchTenantContract(ctx context.Context, tenantID uuid.UUID) (
 		start    sql.NullTime
 		renewal  sql.NullTime
 		notes    sql.NullString
-		fileURL  sql.NullString
+		hasFile  bool
 	)
 
-	err := h.pool.QueryRow(ctx, contractQuery, tenantID).Scan(&contract.Status, &value, &start, &renewal, &notes, &fileURL)
+	err := h.pool.QueryRow(ctx, contractQuery, tenantID).Scan(&contract.Status, &value, &start, &renewal, &notes, &hasFile)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			// initialize default record
@@ -465,9 +549,14 @@ func (h *Handler) fetchTenantContract(ctx context.Context, tenantID uuid.UUID) (
 		note := strings.TrimSpace(notes.String)
 		contract.Notes = &note
 	}
-	if fileURL.Valid {
-		url := strings.TrimSpace(fileURL.String)
-		contract.ContractFile = &url
+
+	registry, err := h.loadTenantRegistry(ctx, tenantID)
+	if err != nil {
+		return contractView{}, err
+	}
+	if registry != nil {
+		contract.Registry = registry
+		contract.BillingTier = registry.BillingTier
 	}
 
 	modulesRows, err := h.pool.Query(ctx, `SELECT module_code, enabled FROM saas_tenant_contract_modules WHERE tenant_id = $1`, tenantID)
@@ -488,7 +577,7 @@ func (h *Handler) fetchTenantContract(ctx context.Context, tenantID uuid.UUID) (
 	}
 
 	invoicesRows, err := h.pool.Query(ctx, `
-        SELECT id, reference_month, amount, status, file_url, uploaded_at, notes
+        SELECT id, reference_month, amount, status, file_key IS NOT NULL OR file_url IS NOT NULL, uploaded_at, notes, scan_status, scan_detail, scanned_at, released_at
         FROM saas_tenant_invoices
         WHERE tenant_id = $1
         ORDER BY reference_month DESC
@@ -502,19 +591,21 @@ func (h *Handler) fetchTenantContract(ctx context.Context, tenantID uuid.UUID) (
 			var (
 				invoice tenantInvoiceView
 				amount  sql.NullFloat64
-				file    sql.NullString
+				file    bool
 				note    sql.NullString
 			)
-			if err := invoicesRows.Scan(&invoice.ID, &invoice.ReferenceMonth, &amount, &invoice.Status, &file, &invoice.UploadedAt, &note); err != nil {
+			if err := invoicesRows.Scan(&invoice.ID, &invoice.ReferenceMonth, &amount, &invoice.Status, &file, &invoice.UploadedAt, &note, &invoice.ScanStatus, &invoice.ScanDetail, &invoice.ScannedAt, &invoice.ReleasedAt); err != nil {
 				return contractView{}, err
 			}
+			invoice.Quarantined = invoiceQuarantined(invoice.ScanStatus)
 			if amount.Valid {
 				val := amount.Float64
 				invoice.Amount = &val
 			}
-			if file.Valid {
-				str := strings.TrimSpace(file.String)
-				invoice.FileURL = &str
+			if file && !invoice.Quarantined {
+				download := fileDownloadPath(invoice.ID)
+				invoice.FileURL = &download
+				invoice.DownloadURL = &download
 			}
 			if note.Valid {
 				str := strings.TrimSpace(note.String)
@@ -524,6 +615,17 @@ func (h *Handler) fetchTenantContract(ctx context.Context, tenantID uuid.UUID) (
 		}
 	}
 
+	versions, err := h.loadContractVersions(ctx, tenantID)
+	if err != nil {
+		return contractView{}, err
+	}
+	contract.Versions = versions
+	// O arquivo vigente é a versão mais recente, servida pelo proxy de arquivos privados.
+	if hasFile && len(versions) > 0 {
+		download := versions[0].DownloadURL
+		contract.ContractFile = &download
+	}
+
 	return contract, nil
 }
 
@@ -10,6 +10,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/dashboard"
 )
 
 type overviewMetrics struct {
@@ -28,6 +31,14 @@ type overviewMetrics struct {
 	StaffTotal       int64   `json:"staff_total"`
 	UsersOnline      int64   `json:"users_online"`
 	TotalAccesses    int64   `json:"total_accesses"`
+	// ActiveSessions detalha o tile de usuários online pelas sessões com refresh token válido.
+	ActiveSessions []sessionAudience `json:"active_sessions"`
+}
+
+type sessionAudience struct {
+	Audience string `json:"audience"`
+	Sessions int64  `json:"sessions"`
+	Users    int64  `json:"users"`
 }
 
 type projectOverview struct {
@@ -158,6 +169,9 @@ type cityInsightView struct {
 	Satisfaction  float64   `json:"satisfaction"`
 	LastSync      time.Time `json:"last_sync"`
 	Highlights    []string  `json:"highlights"`
+	// Atendimento presencial por senhas nos últimos 30 dias; espera nula sem chamadas no período.
+	AvgWaitMinutes *float64 `json:"avg_wait_minutes"`
+	ServedLast30d  int64    `json:"served_last_30d"`
 }
 
 type accessLogView struct {
@@ -172,81 +186,98 @@ type accessLogView struct {
 	Status    string    `json:"status"`
 }
 
-type dashboardResponse struct {
-	Metrics       overviewMetrics     `json:"metrics"`
-	Projects      []projectOverview   `json:"projects"`
-	Retention     retentionSummary    `json:"retention"`
-	Usage         usageAnalytics      `json:"usage"`
-	Compliance    []complianceRecord  `json:"compliance"`
-	Communication communicationCenter `json:"communication"`
-	CityInsights  []cityInsightView   `json:"city_insights"`
-	AccessLogs    []accessLogView     `json:"access_logs"`
+type overviewWidget struct {
+	load    func(ctx context.Context) (any, error)
+	message string
 }
 
-// DashboardOverview agrega os dados necessários para a visão principal do painel.
-func (h *Handler) DashboardOverview(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	metrics, err := h.loadOverviewMetrics(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar métricas", nil)
-		return
-	}
-
-	projects, err := h.loadProjects(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar projetos", nil)
-		return
-	}
-
-	retention, err := h.loadRetention(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar retenção", nil)
-		return
-	}
-
-	usage, err := h.loadUsageAnalytics(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar analytics", nil)
-		return
+// overviewWidgets associa cada widget do painel ao carregamento dos seus dados.
+func (h *Handler) overviewWidgets() map[string]overviewWidget {
+	return map[string]overviewWidget{
+		dashboard.WidgetMetrics: {
+			load:    func(ctx context.Context) (any, error) { return h.loadOverviewMetrics(ctx) },
+			message: "não foi possível carregar métricas",
+		},
+		dashboard.WidgetProjects: {
+			load:    func(ctx context.Context) (any, error) { return h.loadProjects(ctx) },
+			message: "não foi possível carregar projetos",
+		},
+		dashboard.WidgetRetention: {
+			load:    func(ctx context.Context) (any, error) { return h.loadRetention(ctx) },
+			message: "não foi possível carregar retenção",
+		},
+		dashboard.WidgetUsage: {
+			load:    func(ctx context.Context) (any, error) { return h.loadUsageAnalytics(ctx) },
+			message: "não foi possível carregar analytics",
+		},
+		dashboard.WidgetCompliance: {
+			load:    func(ctx context.Context) (any, error) { return h.loadCompliance(ctx) },
+			message: "não foi possível carregar compliance",
+		},
+		dashboard.WidgetCommunication: {
+			load:    func(ctx context.Context) (any, error) { return h.loadCommunication(ctx) },
+			message: "não foi possível carregar comunicações",
+		},
+		dashboard.WidgetCityInsights: {
+			load:    func(ctx context.Context) (any, error) { return h.loadCityInsights(ctx) },
+			message: "não foi possível carregar insights",
+		},
+		dashboard.WidgetAccessLogs: {
+			load:    func(ctx context.Context) (any, error) { return h.loadAccessLogs(ctx) },
+			message: "não foi possível carregar acessos",
+		},
 	}
+}
 
-	compliance, err := h.loadCompliance(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar compliance", nil)
-		return
-	}
+// DashboardOverview agrega os dados da visão principal do painel. Só calcula os widgets
+// exibidos: os de ?widgets=metrics,projects ou, sem o parâmetro, os do layout salvo do usuário.
+func (h *Handler) DashboardOverview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	communication, err := h.loadCommunication(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar comunicações", nil)
-		return
+	var codes []string
+	if raw, ok := r.URL.Query()["widgets"]; ok {
+		parsed, err := dashboard.ParseCodes(strings.Join(raw, ","))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		codes = parsed
+	} else {
+		userID, err := h.subjectUUID(r)
+		if err != nil {
+			WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+			return
+		}
+		layout, err := h.dashboards.Get(ctx, userID)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar layout do painel", nil)
+			return
+		}
+		codes = layout.Codes()
 	}
 
-	insights, err := h.loadCityInsights(ctx)
+	response, message, err := buildOverview(ctx, codes, h.overviewWidgets())
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar insights", nil)
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", message, nil)
 		return
 	}
 
-	accessLogs, err := h.loadAccessLogs(ctx)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar acessos", nil)
-		return
-	}
+	WriteJSON(w, http.StatusOK, response)
+}
 
-	response := dashboardResponse{
-		Metrics:       metrics,
-		Projects:      projects,
-		Retention:     retention,
-		Usage:         usage,
-		Compliance:    compliance,
-		Communication: communication,
-		CityInsights:  insights,
-		AccessLogs:    accessLogs,
+// buildOverview carrega os widgets pedidos, cada um sob a chave do seu código. Em caso de
+// erro devolve a mensagem do widget que falhou.
+func buildOverview(ctx context.Context, codes []string, widgets map[string]overviewWidget) (map[string]any, string, error) {
+	response := map[string]any{"widgets": codes}
+	for _, code := range codes {
+		widget := widgets[code]
+		data, err := widget.load(ctx)
+		if err != nil {
+			return nil, widget.message, err
+		}
+		response[code] = data
 	}
-
-	WriteJSON(w, http.StatusOK, response)
+	return response, "", nil
 }
 
 func (h *Handler) loadOverviewMetrics(ctx context.Context) (overviewMetrics, error) {
@@ -263,11 +294,10 @@ func (h *Handler) loadOverviewMetrics(ctx context.Context) (overviewMetrics, err
             (SELECT COUNT(*) FROM tenants WHERE status = 'active') AS tenants_active,
             (SELECT COUNT(*) FROM tenants) AS tenants_total,
             COALESCE((SELECT SUM(usage_count) FROM saas_usage_heatmap), 0) AS traffic_gb,
-            COALESCE((SELECT SUM(amount) FROM saas_finance_entries WHERE entry_type IN ('revenue','subscription') AND paid = TRUE), 0) AS mrr,
-            COALESCE((SELECT SUM(amount) FROM saas_finance_entries WHERE entry_type IN ('expense','investment','payroll') AND paid = FALSE), 0) AS expenses_forecast,
-            COALESCE((SELECT SUM(amount) FROM saas_finance_entries WHERE entry_type IN ('revenue','subscription') AND paid = FALSE), 0) AS revenue_forecast,
+            COALESCE((SELECT SUM(amount_brl) FROM saas_finance_entries WHERE entry_type IN ('revenue','subscription') AND paid = TRUE AND approval_status = 'approved'), 0) AS mrr,
+            COALESCE((SELECT SUM(amount_brl) FROM saas_finance_entries WHERE entry_type IN ('expense','investment','payroll') AND paid = FALSE AND approval_status = 'approved'), 0) AS expenses_forecast,
+            COALESCE((SELECT SUM(amount_brl) FROM saas_finance_entries WHERE entry_type IN ('revenue','subscription') AND paid = FALSE AND approval_status = 'approved'), 0) AS revenue_forecast,
             (SELECT COUNT(*) FROM saas_users) AS staff_total,
-            COALESCE((SELECT COUNT(DISTINCT user_name) FROM saas_access_logs WHERE logged_at >= now() - interval '10 minutes' AND lower(coalesce(status, '')) IN ('success','sucesso')), 0) AS users_online,
             COALESCE((SELECT COUNT(*) FROM saas_access_logs), 0) AS total_accesses
     `
 
@@ -286,7 +316,6 @@ func (h *Handler) loadOverviewMetrics(ctx context.Context) (overviewMetrics, err
 		&metrics.ExpensesForecast,
 		&metrics.RevenueForecast,
 		&metrics.StaffTotal,
-		&metrics.UsersOnline,
 		&metrics.TotalAccesses,
 	); err != nil {
 		return overviewMetrics{}, err
@@ -297,9 +326,47 @@ func (h *Handler) loadOverviewMetrics(ctx context.Context) (overviewMetrics, err
 		metrics.RequestsPending = 0
 	}
 
+	sessions, err := h.loadActiveSessions(ctx)
+	if err != nil {
+		return overviewMetrics{}, err
+	}
+	metrics.ActiveSessions = sessions
+
+	// Usuários online vêm dos heartbeats; sem Redis o tile mostra zero em vez de falhar o painel.
+	if online, err := h.presence.Snapshot(ctx); err == nil {
+		metrics.UsersOnline = online.Total
+	} else {
+		log.Warn().Err(err).Msg("dashboard: presença online indisponível")
+	}
+
 	return metrics, nil
 }
 
+// loadActiveSessions conta refresh tokens válidos e titulares distintos por audiência.
+func (h *Handler) loadActiveSessions(ctx context.Context) ([]sessionAudience, error) {
+	rows, err := h.pool.Query(ctx, `
+        SELECT a.audience, COUNT(t.subject), COUNT(DISTINCT t.subject)
+        FROM unnest(ARRAY['backoffice', 'cidadao']) AS a(audience)
+        LEFT JOIN tokens_refresh t ON t.audience = a.audience AND NOT t.revogado AND t.expiracao > now()
+        GROUP BY a.audience
+        ORDER BY a.audience
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]sessionAudience, 0, 2)
+	for rows.Next() {
+		var item sessionAudience
+		if err := rows.Scan(&item.Audience, &item.Sessions, &item.Users); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, item)
+	}
+	return sessions, rows.Err()
+}
+
 func (h *Handler) loadProjects(ctx context.Context) ([]projectOverview, error) {
 	const projectQuery = `
         SELECT id, name, description, status, progress, lead_id, owner_id, started_at, target_date, updated_at
@@ -746,9 +813,16 @@ func (h *Handler) loadCommunication(ctx context.Context) (communicationCenter, e
 
 func (h *Handler) loadCityInsights(ctx context.Context) ([]cityInsightView, error) {
 	const query = `
-        SELECT ci.id, ci.tenant_id, t.display_name, ci.population, ci.active_users, ci.requests_total, ci.satisfaction, ci.last_sync, ci.highlights
+        SELECT ci.id, ci.tenant_id, t.display_name, ci.population, ci.active_users, ci.requests_total, ci.satisfaction, ci.last_sync, ci.highlights,
+               sn.espera, sn.atendidas
         FROM saas_city_insights ci
         JOIN tenants t ON t.id = ci.tenant_id
+        LEFT JOIN LATERAL (
+            SELECT (avg(EXTRACT(EPOCH FROM s.chamada_em - s.emitida_em)) / 60)::float8 AS espera,
+                   count(*) FILTER (WHERE s.status = 'atendida') AS atendidas
+            FROM senhas s
+            WHERE s.tenant_id = ci.tenant_id AND s.emitida_em >= now() - interval '30 days'
+        ) sn ON TRUE
         ORDER BY t.display_name ASC
     `
 
@@ -768,7 +842,7 @@ func (h *Handler) loadCityInsights(ctx context.Context) ([]cityInsightView, erro
 			lastSync   sql.NullTime
 			highlights []string
 		)
-		if err := rows.Scan(&view.ID, &view.TenantID, &view.Name, &view.Population, &view.ActiveUsers, &view.RequestsTotal, &view.Satisfaction, &lastSync, &highlights); err != nil {
+		if err := rows.Scan(&view.ID, &view.TenantID, &view.Name, &view.Population, &view.ActiveUsers, &view.RequestsTotal, &view.Satisfaction, &lastSync, &highlights, &view.AvgWaitMinutes, &view.ServedLast30d); err != nil {
 			return nil, err
 		}
 		if lastSync.Valid {
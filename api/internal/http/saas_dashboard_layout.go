@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gestaozabele/municipio/internal/dashboard"
+)
+
+// GetDashboardLayout devolve o layout da visão geral do usuário e o catálogo de widgets.
+func (h *Handler) GetDashboardLayout(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	layout, err := h.dashboards.Get(r.Context(), userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar layout do painel", nil)
+		return
+	}
+	writeDashboardLayout(w, layout)
+}
+
+// UpdateDashboardLayout salva os widgets exibidos, na ordem informada.
+func (h *Handler) UpdateDashboardLayout(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	var payload struct {
+		Widgets []dashboard.Widget `json:"widgets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	widgets, err := dashboard.Normalize(payload.Widgets, false)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if len(widgets) == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "selecione ao menos um widget", nil)
+		return
+	}
+
+	layout, err := h.dashboards.Save(r.Context(), userID, widgets)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar layout do painel", nil)
+		return
+	}
+	writeDashboardLayout(w, layout)
+}
+
+// ResetDashboardLayout descarta o layout salvo e volta ao padrão.
+func (h *Handler) ResetDashboardLayout(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	layout, err := h.dashboards.Reset(r.Context(), userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível restaurar layout do painel", nil)
+		return
+	}
+	writeDashboardLayout(w, layout)
+}
+
+func writeDashboardLayout(w http.ResponseWriter, layout dashboard.Layout) {
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"layout":    layout,
+		"available": dashboard.Catalog,
+	})
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/tenantmove"
+)
+
+// GetTenantDatabase mostra em qual cluster o tenant está e o histórico de migrações entre
+// clusters, conduzidas pelo cmd/tenant move.
+func (h *Handler) GetTenantDatabase(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var cluster string
+	err = h.pool.QueryRow(r.Context(), `SELECT db_cluster FROM tenants WHERE id = $1`, tenantID).Scan(&cluster)
+	if errors.Is(err, pgx.ErrNoRows) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "tenant não encontrado", nil)
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenant", nil)
+		return
+	}
+	migs, err := tenantmove.List(r.Context(), h.pool, &tenantID, 20)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar migrações", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"cluster": cluster, "migracoes": migs})
+}
+
+// ListDatabaseMigrations lista as migrações de tenant mais recentes e os clusters configurados.
+func (h *Handler) ListDatabaseMigrations(w http.ResponseWriter, r *http.Request) {
+	migs, err := tenantmove.List(r.Context(), h.pool, nil, 50)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar migrações", nil)
+		return
+	}
+	clusters := []string{db.PrimaryCluster}
+	for name := range h.cfg.DBClusters {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters[1:])
+	WriteJSON(w, http.StatusOK, map[string]any{"clusters": clusters, "migracoes": migs})
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gestaozabele/municipio/internal/demo"
+)
+
+type demoDataPayload struct {
+	Seed             int64  `json:"seed"`
+	Citizens         int    `json:"citizens"`
+	Staff            int    `json:"staff"`
+	Schools          int    `json:"schools"`
+	TurmasPerSchool  int    `json:"turmas_per_school"`
+	StudentsPerTurma int    `json:"students_per_turma"`
+	Days             int    `json:"days"`
+	StaffPassword    string `json:"staff_password"`
+}
+
+// GenerateDemoData popula um tenant sandbox com dados sintéticos para demonstrações.
+func (h *Handler) GenerateDemoData(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if !h.requireSandboxTenant(w, r) {
+		return
+	}
+
+	var payload demoDataPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	target, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	summary, err := h.demo.Seed(r.Context(), demo.Target{TenantID: target.ID, Slug: target.Slug, Domain: target.Domain}, demo.Options{
+		Seed:             payload.Seed,
+		Citizens:         payload.Citizens,
+		Staff:            payload.Staff,
+		Schools:          payload.Schools,
+		TurmasPerSchool:  payload.TurmasPerSchool,
+		StudentsPerTurma: payload.StudentsPerTurma,
+		Days:             payload.Days,
+		StaffPassword:    payload.StaffPassword,
+	})
+	if err != nil {
+		if errors.Is(err, demo.ErrLimitExceeded) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "volume solicitado acima do limite", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar dados de demonstração", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{"summary": summary})
+}
+
+// WipeDemoData remove os dados sintéticos gerados para o tenant.
+func (h *Handler) WipeDemoData(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if !h.requireSandboxTenant(w, r) {
+		return
+	}
+
+	removed, err := h.demo.Wipe(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover dados de demonstração", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"removed": removed})
+}
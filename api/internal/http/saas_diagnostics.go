@@ -0,0 +1,295 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/monitor"
+	"github.com/gestaozabele/municipio/internal/support"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+const (
+	// diagnosticsErrorWindow limita as falhas e incidentes listados no diagnóstico.
+	diagnosticsErrorWindow = 7 * 24 * time.Hour
+	diagnosticsListLimit   = 10
+)
+
+type diagnosticsCheck struct {
+	Source     string    `json:"source"`
+	OccurredAt time.Time `json:"occurred_at"`
+	StatusCode *int      `json:"status_code,omitempty"`
+	Success    bool      `json:"success"`
+	Error      *string   `json:"error,omitempty"`
+}
+
+type diagnosticsIncident struct {
+	ID        uuid.UUID `json:"id"`
+	Subject   string    `json:"subject"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type diagnosticsLogin struct {
+	ID          uuid.UUID `json:"id"`
+	Nome        *string   `json:"nome,omitempty"`
+	Email       string    `json:"email"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+type diagnosticsStorage struct {
+	Files int `json:"files"`
+	// Bytes soma apenas os arquivos com tamanho registrado (anexos do suporte e documentos de licitação).
+	Bytes  int64          `json:"bytes"`
+	ByKind map[string]int `json:"by_kind"`
+}
+
+// TenantDiagnostics reúne num só lugar o que o suporte consulta ao investigar um tenant: DNS,
+// últimas leituras do monitor, contrato e módulos, erros recentes, últimos acessos e armazenamento.
+// Seções que falham voltam nulas e listadas em "unavailable", sem derrubar o restante.
+func (h *Handler) TenantDiagnostics(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	t, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	unavailable := []string{}
+	section := func(name string, load func(context.Context) (any, error)) any {
+		value, err := load(ctx)
+		if err != nil {
+			log.Warn().Err(err).Str("tenant", t.Slug).Str("section", name).Msg("diagnóstico: seção indisponível")
+			unavailable = append(unavailable, name)
+			return nil
+		}
+		return value
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"tenant": map[string]any{
+			"id":           t.ID,
+			"slug":         t.Slug,
+			"display_name": t.DisplayName,
+			"domain":       t.Domain,
+			"status":       t.Status,
+			"environment":  t.Environment,
+			"activated_at": t.ActivatedAt,
+		},
+		"dns": section("dns", func(ctx context.Context) (any, error) {
+			return h.diagnosticsDNS(ctx, t)
+		}),
+		"monitor": section("monitor", func(ctx context.Context) (any, error) {
+			return h.diagnosticsMonitor(ctx, tenantID)
+		}),
+		"contract": section("contract", func(ctx context.Context) (any, error) {
+			return h.diagnosticsContract(ctx, tenantID, !hasSaaSRole(r, "SAAS_ADMIN") && !hasSaaSRole(r, "SAAS_OWNER"))
+		}),
+		"errors": section("errors", func(ctx context.Context) (any, error) {
+			return h.diagnosticsErrors(ctx, tenantID)
+		}),
+		"logins": section("logins", func(ctx context.Context) (any, error) {
+			return h.diagnosticsLogins(ctx, tenantID)
+		}),
+		"storage": section("storage", func(ctx context.Context) (any, error) {
+			return h.diagnosticsStorage(ctx, tenantID)
+		}),
+		"unavailable":  unavailable,
+		"generated_at": time.Now().UTC(),
+	})
+}
+
+func (h *Handler) diagnosticsDNS(ctx context.Context, t *tenant.Tenant) (any, error) {
+	// Última verificação noturna (DNS + /ready), gravada pelo verificador de domínios.
+	var last *diagnosticsCheck
+	var check diagnosticsCheck
+	err := h.pool.QueryRow(ctx, `
+        SELECT source, occurred_at, status_code, success, error
+        FROM monitor_check_events
+        WHERE tenant_id = $1 AND source = 'dns'
+        ORDER BY occurred_at DESC
+        LIMIT 1
+    `, t.ID).Scan(&check.Source, &check.OccurredAt, &check.StatusCode, &check.Success, &check.Error)
+	switch {
+	case err == nil:
+		last = &check
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+	return map[string]any{
+		"status":          t.DNSStatus,
+		"last_checked_at": t.DNSLastChecked,
+		"error":           t.DNSError,
+		"last_verify":     last,
+	}, nil
+}
+
+func (h *Handler) diagnosticsMonitor(ctx context.Context, tenantID uuid.UUID) (any, error) {
+	if h.monitor == nil || !h.monitorOn {
+		return map[string]any{"enabled": false}, nil
+	}
+	health, err := h.monitor.TenantHealth(ctx, tenantID)
+	if err != nil && !errors.Is(err, monitor.ErrNoData) {
+		return nil, err
+	}
+	anomalies, err := h.monitor.Anomalies(ctx, &tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"enabled": true, "health": health, "anomalies": anomalies}, nil
+}
+
+// diagnosticsContract omite valores e faturas para o suporte, que não enxerga o financeiro do contrato.
+func (h *Handler) diagnosticsContract(ctx context.Context, tenantID uuid.UUID, supportOnly bool) (any, error) {
+	contract, err := h.fetchTenantContract(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return map[string]any{"status": "none", "modules": map[string]bool{}}, nil
+		}
+		return nil, err
+	}
+	view := map[string]any{
+		"status":       contract.Status,
+		"start_date":   contract.StartDate,
+		"renewal_date": contract.RenewalDate,
+		"modules":      contract.Modules,
+		"billing_tier": contract.BillingTier,
+	}
+	if !supportOnly {
+		pending := 0
+		for _, invoice := range contract.Invoices {
+			if invoice.Status != "paid" {
+				pending++
+			}
+		}
+		view["contract_value"] = contract.ContractValue
+		view["pending_invoices"] = pending
+	}
+	return view, nil
+}
+
+func (h *Handler) diagnosticsErrors(ctx context.Context, tenantID uuid.UUID) (any, error) {
+	since := time.Now().Add(-diagnosticsErrorWindow)
+	rows, err := h.pool.Query(ctx, `
+        SELECT source, occurred_at, status_code, success, error
+        FROM monitor_check_events
+        WHERE tenant_id = $1 AND NOT success AND occurred_at >= $2
+        ORDER BY occurred_at DESC
+        LIMIT $3
+    `, tenantID, since, diagnosticsListLimit)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (diagnosticsCheck, error) {
+		var c diagnosticsCheck
+		err := row.Scan(&c.Source, &c.OccurredAt, &c.StatusCode, &c.Success, &c.Error)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = h.pool.Query(ctx, `
+        SELECT id, subject, status, created_at, updated_at
+        FROM support_tickets
+        WHERE tenant_id = $1 AND $2 = ANY(tags) AND (status IN ('open', 'in_progress') OR updated_at >= $3)
+        ORDER BY updated_at DESC
+        LIMIT $4
+    `, tenantID, support.TagIncident, since, diagnosticsListLimit)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (diagnosticsIncident, error) {
+		var i diagnosticsIncident
+		err := row.Scan(&i.ID, &i.Subject, &i.Status, &i.CreatedAt, &i.UpdatedAt)
+		return i, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"failed_checks": checks, "incidents": incidents}, nil
+}
+
+func (h *Handler) diagnosticsLogins(ctx context.Context, tenantID uuid.UUID) (any, error) {
+	staff, err := h.loadTenantStaff(ctx, tenantID, "")
+	if err != nil {
+		return nil, err
+	}
+	recent := make([]diagnosticsLogin, 0, len(staff))
+	for _, member := range staff {
+		if member.LastLoginAt == nil {
+			continue
+		}
+		recent = append(recent, diagnosticsLogin{
+			ID:          member.ID,
+			Nome:        member.Nome,
+			Email:       member.Email,
+			LastLoginAt: *member.LastLoginAt,
+		})
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i].LastLoginAt.After(recent[j].LastLoginAt) })
+	if len(recent) > diagnosticsListLimit {
+		recent = recent[:diagnosticsListLimit]
+	}
+	return map[string]any{
+		"summary": summarizeTenantStaff(staff, time.Now()),
+		"recent":  recent,
+	}, nil
+}
+
+func (h *Handler) diagnosticsStorage(ctx context.Context, tenantID uuid.UUID) (any, error) {
+	rows, err := h.pool.Query(ctx, `
+        SELECT kind, count(*), COALESCE(sum(size_bytes), 0)::bigint FROM (
+            SELECT 'finance_attachment' AS kind, NULL::bigint AS size_bytes
+            FROM saas_finance_attachments a
+            JOIN saas_finance_entries e ON e.id = a.finance_entry_id
+            WHERE e.tenant_id = $1
+            UNION ALL
+            SELECT 'contract_version', NULL FROM saas_tenant_contract_versions WHERE tenant_id = $1 AND (file_key IS NOT NULL OR file_url IS NOT NULL)
+            UNION ALL
+            SELECT 'invoice', NULL FROM saas_tenant_invoices WHERE tenant_id = $1
+            UNION ALL
+            SELECT 'support_attachment', a.size_bytes
+            FROM support_ticket_attachments a
+            JOIN support_ticket_messages m ON m.id = a.message_id
+            JOIN support_tickets t ON t.id = m.ticket_id
+            WHERE t.tenant_id = $1
+            UNION ALL
+            SELECT 'procurement_document', size_bytes FROM saas_procurement_documents WHERE tenant_id = $1
+        ) files
+        GROUP BY kind
+    `, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := diagnosticsStorage{ByKind: make(map[string]int)}
+	for rows.Next() {
+		var (
+			kind  string
+			count int
+			bytes int64
+		)
+		if err := rows.Scan(&kind, &count, &bytes); err != nil {
+			return nil, err
+		}
+		usage.ByKind[kind] = count
+		usage.Files += count
+		usage.Bytes += bytes
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+const edgePurgeTimeout = 20 * time.Second
+
+type edgePurgePayload struct {
+	URLs []string `json:"urls"`
+}
+
+// PurgeTenantEdgeCache invalida manualmente o cache de borda do município, para o suporte
+// resolver casos de tema ou logo desatualizados. URLs extras podem ser enviadas em "urls".
+func (h *Handler) PurgeTenantEdgeCache(w http.ResponseWriter, r *http.Request) {
+	if h.provisioner == nil || !h.provisioner.IsConfigured() {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "integração com Cloudflare indisponível", nil)
+		return
+	}
+
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var payload edgePurgePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	for _, raw := range payload.URLs {
+		parsed, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "URL inválida: "+raw, nil)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), edgePurgeTimeout)
+	defer cancel()
+
+	purged, err := h.provisioner.PurgeTenantCache(ctx, tenantID, payload.URLs...)
+	if err != nil {
+		if errors.Is(err, tenant.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "tenant não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusBadGateway, "PURGE", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"purged": purged})
+}
+
+// purgeBrandingAsync invalida a borda depois de uma mudança de identidade visual sem segurar a
+// resposta; falhas só são registradas, já que o suporte pode repetir pelo endpoint manual.
+func (h *Handler) purgeBrandingAsync(tenantID uuid.UUID, extra ...string) {
+	if h.provisioner == nil || !h.provisioner.IsConfigured() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), edgePurgeTimeout)
+		defer cancel()
+		purged, err := h.provisioner.PurgeTenantCache(ctx, tenantID, extra...)
+		if err != nil {
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("cache de borda: purge após mudança de identidade visual falhou")
+			return
+		}
+		log.Info().Str("tenant_id", tenantID.String()).Int("urls", len(purged)).Msg("cache de borda: identidade visual invalidada")
+	}()
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+type privateFile struct {
+	ID       uuid.UUID
+	Kind     string
+	TenantID *uuid.UUID
+	Key      *string
+	URL      *string
+	// Quarantined bloqueia o download enquanto o antivírus mantiver o arquivo retido.
+	Quarantined bool
+}
+
+// fileDownloadPath é o caminho do proxy devolvido nas listagens no lugar da URL do bucket.
+func fileDownloadPath(id uuid.UUID) string {
+	return "/saas/files/" + id.String()
+}
+
+// fileKindRoles define quem baixa cada tipo de arquivo privado.
+var fileKindRoles = map[string][]string{
+	"finance_attachment":   {"SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"},
+	"contract_version":     {"SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"},
+	"invoice":              {"SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"},
+	"support_attachment":   {"SAAS_ADMIN", "SAAS_OWNER", "SAAS_SUPPORT"},
+	"procurement_document": {"SAAS_ADMIN", "SAAS_OWNER", "SAAS_FINANCE"},
+}
+
+// DownloadPrivateFile autoriza o acesso a anexos financeiros, contratos, faturas e anexos do suporte, registra o
+// download e redireciona para uma URL pré-assinada de curta duração. Registros antigos sem
+// chave de objeto seguem para a URL gravada no upload. Sem o registro do acesso o download é negado.
+func (h *Handler) DownloadPrivateFile(w http.ResponseWriter, r *http.Request) {
+	_, target, ok := h.privateFileTarget(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// SignedPrivateFileURL devolve em JSON o link assinado do arquivo, para o painel abrir em nova aba
+// ou repassar a quem não tem sessão. O acesso é registrado como no download direto.
+func (h *Handler) SignedPrivateFileURL(w http.ResponseWriter, r *http.Request) {
+	file, target, ok := h.privateFileTarget(w, r)
+	if !ok {
+		return
+	}
+	response := map[string]any{"id": file.ID, "url": target}
+	if file.Key != nil && strings.TrimSpace(*file.Key) != "" {
+		ttl := h.cfg.Storage.SignedURLTTL
+		response["expires_in"] = int(ttl.Seconds())
+		response["expires_at"] = time.Now().Add(ttl).UTC()
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// privateFileTarget carrega o arquivo do parâmetro id, confere a carteira e o papel do usuário e a
+// quarentena, gera o link e registra o acesso. Em caso de erro a resposta já foi escrita.
+func (h *Handler) privateFileTarget(w http.ResponseWriter, r *http.Request) (privateFile, string, bool) {
+	fileID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return privateFile{}, "", false
+	}
+
+	file, err := h.lookupPrivateFile(r.Context(), fileID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "arquivo não encontrado", nil)
+			return file, "", false
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar arquivo", nil)
+		return file, "", false
+	}
+
+	portfolio, err := h.loadSaaSPortfolio(r)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return file, "", false
+	}
+	// 404 para não revelar arquivos de outras carteiras; os sem tenant ficam só com quem vê todas.
+	if portfolio.scoped && (file.TenantID == nil || !portfolio.Allows(*file.TenantID)) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "arquivo não encontrado", nil)
+		return file, "", false
+	}
+
+	allowed := false
+	for _, role := range fileKindRoles[file.Kind] {
+		if hasSaaSRole(r, role) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "acesso restrito ao arquivo", nil)
+		return file, "", false
+	}
+
+	if file.Quarantined {
+		WriteError(w, http.StatusLocked, "QUARANTINED", "arquivo retido em quarentena pelo antivírus", nil)
+		return file, "", false
+	}
+
+	var target string
+	if file.Key != nil && strings.TrimSpace(*file.Key) != "" {
+		switch h.storage.(type) {
+		case nil, storage.NoopUploader, *storage.NoopUploader:
+			WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
+			return file, "", false
+		}
+		target, err = h.storage.PresignGet(*file.Key, h.cfg.Storage.SignedURLTTL)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar link do arquivo", nil)
+			return file, "", false
+		}
+	} else if file.URL != nil && strings.TrimSpace(*file.URL) != "" {
+		target = strings.TrimSpace(*file.URL)
+	} else {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "arquivo sem conteúdo", nil)
+		return file, "", false
+	}
+
+	if err := h.logFileAccess(r, file); err != nil {
+		log.Error().Err(err).Str("file_id", file.ID.String()).Msg("arquivos privados: falha ao registrar acesso")
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar acesso ao arquivo", nil)
+		return file, "", false
+	}
+	return file, target, true
+}
+
+func (h *Handler) lookupPrivateFile(ctx context.Context, id uuid.UUID) (privateFile, error) {
+	const query = `
+        SELECT 'finance_attachment', e.tenant_id, a.object_key, a.file_url, FALSE
+        FROM saas_finance_attachments a
+        JOIN saas_finance_entries e ON e.id = a.finance_entry_id
+        WHERE a.id = $1
+        UNION ALL
+        SELECT 'contract_version', tenant_id, file_key, file_url, FALSE
+        FROM saas_tenant_contract_versions
+        WHERE id = $1
+        UNION ALL
+        SELECT 'invoice', tenant_id, file_key, file_url, scan_status IN ('quarantined', 'failed')
+        FROM saas_tenant_invoices
+        WHERE id = $1
+        UNION ALL
+        SELECT 'support_attachment', t.tenant_id, a.object_key, a.file_url, FALSE
+        FROM support_ticket_attachments a
+        JOIN support_ticket_messages m ON m.id = a.message_id
+        JOIN support_tickets t ON t.id = m.ticket_id
+        WHERE a.id = $1
+        UNION ALL
+        SELECT 'procurement_document', tenant_id, file_key, file_url, FALSE
+        FROM saas_procurement_documents
+        WHERE id = $1
+        LIMIT 1
+    `
+
+	file := privateFile{ID: id}
+	err := h.pool.QueryRow(ctx, query, id).Scan(&file.Kind, &file.TenantID, &file.Key, &file.URL, &file.Quarantined)
+	return file, err
+}
+
+func (h *Handler) logFileAccess(r *http.Request, file privateFile) error {
+	var actorID *uuid.UUID
+	if subject, err := uuid.Parse(httpmiddleware.GetSubject(r.Context())); err == nil {
+		actorID = &subject
+	}
+
+	const insert = `
+        INSERT INTO saas_file_access_logs (file_id, kind, tenant_id, actor_id, ip_address, user_agent)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+    `
+	_, err := h.pool.Exec(r.Context(), insert, file.ID, file.Kind, file.TenantID, actorID, r.RemoteAddr, r.UserAgent())
+	return err
+}
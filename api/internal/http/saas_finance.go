@@ -11,13 +11,16 @@ import (
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/gestaozabele/municipio/internal/storage"
+	"github.com/gestaozabele/municipio/internal/util"
 )
 
 var allowedEntryTypes = map[string]struct{}{
@@ -28,52 +31,98 @@ var allowedEntryTypes = map[string]struct{}{
 	"subscription": {},
 }
 
+var allowedCurrencies = map[string]struct{}{
+	"BRL": {},
+	"USD": {},
+	"EUR": {},
+}
+
 type financeEntryPayload struct {
-	EntryType   string  `json:"entry_type"`
-	Category    string  `json:"category"`
-	Description string  `json:"description"`
-	Amount      float64 `json:"amount"`
-	DueDate     *string `json:"due_date"`
-	Paid        *bool   `json:"paid"`
-	Method      *string `json:"method"`
-	CostCenter  *string `json:"cost_center"`
-	Responsible *string `json:"responsible"`
-	Notes       *string `json:"notes"`
-	TenantID    *string `json:"tenant_id"`
+	EntryType    string   `json:"entry_type"`
+	Category     string   `json:"category"`
+	Description  string   `json:"description"`
+	Amount       float64  `json:"amount"`
+	DueDate      *string  `json:"due_date"`
+	Paid         *bool    `json:"paid"`
+	Method       *string  `json:"method"`
+	CostCenter   *string  `json:"cost_center"`
+	Responsible  *string  `json:"responsible"`
+	Notes        *string  `json:"notes"`
+	TenantID     *string  `json:"tenant_id"`
+	Currency     *string  `json:"currency"`
+	ExchangeRate *float64 `json:"exchange_rate"`
+	ISSRetention *float64 `json:"iss_retention"`
+	IRRetention  *float64 `json:"ir_retention"`
 }
 
 type financeEntryView struct {
-	ID          uuid.UUID           `json:"id"`
-	EntryType   string              `json:"entry_type"`
-	Category    string              `json:"category"`
-	Description string              `json:"description"`
-	Amount      float64             `json:"amount"`
-	DueDate     *time.Time          `json:"due_date,omitempty"`
-	Paid        bool                `json:"paid"`
-	PaidAt      *time.Time          `json:"paid_at,omitempty"`
-	Method      *string             `json:"method,omitempty"`
-	CostCenter  *string             `json:"cost_center,omitempty"`
-	Responsible *string             `json:"responsible,omitempty"`
-	Notes       *string             `json:"notes,omitempty"`
-	Attachments []financeAttachment `json:"attachments"`
-	CreatedAt   time.Time           `json:"created_at"`
+	ID              uuid.UUID           `json:"id"`
+	EntryType       string              `json:"entry_type"`
+	Category        string              `json:"category"`
+	Description     string              `json:"description"`
+	Amount          float64             `json:"amount"`
+	Currency        string              `json:"currency"`
+	ExchangeRate    float64             `json:"exchange_rate"`
+	ISSRetention    float64             `json:"iss_retention"`
+	IRRetention     float64             `json:"ir_retention"`
+	NetAmount       float64             `json:"net_amount"`
+	AmountBRL       float64             `json:"amount_brl"`
+	NetAmountBRL    float64             `json:"net_amount_brl"`
+	DueDate         *time.Time          `json:"due_date,omitempty"`
+	Paid            bool                `json:"paid"`
+	PaidAt          *time.Time          `json:"paid_at,omitempty"`
+	Method          *string             `json:"method,omitempty"`
+	CostCenter      *string             `json:"cost_center,omitempty"`
+	Responsible     *string             `json:"responsible,omitempty"`
+	Notes           *string             `json:"notes,omitempty"`
+	ApprovalStatus  string              `json:"approval_status"`
+	ApprovalComment *string             `json:"approval_comment,omitempty"`
+	DecidedBy       *uuid.UUID          `json:"decided_by,omitempty"`
+	DecidedAt       *time.Time          `json:"decided_at,omitempty"`
+	Attachments     []financeAttachment `json:"attachments"`
+	CreatedAt       time.Time           `json:"created_at"`
+}
+
+// financeCurrencyTotal consolida os lançamentos de uma moeda.
+type financeCurrencyTotal struct {
+	Currency     string  `json:"currency"`
+	Entries      int     `json:"entries"`
+	Amount       float64 `json:"amount"`
+	ISSRetention float64 `json:"iss_retention"`
+	IRRetention  float64 `json:"ir_retention"`
+	NetAmount    float64 `json:"net_amount"`
+	AmountBRL    float64 `json:"amount_brl"`
+	NetAmountBRL float64 `json:"net_amount_brl"`
 }
 
 type financeAttachment struct {
-	ID         uuid.UUID `json:"id"`
-	Name       string    `json:"name"`
-	URL        string    `json:"url"`
-	UploadedAt time.Time `json:"uploaded_at"`
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// URL repete o caminho do proxy para clientes antigos; a URL do bucket não é mais exposta.
+	URL         string    `json:"url"`
+	DownloadURL string    `json:"download_url"`
+	UploadedAt  time.Time `json:"uploaded_at"`
 }
 
 // ListFinanceEntries retorna os lançamentos financeiros cadastrados.
 func (h *Handler) ListFinanceEntries(w http.ResponseWriter, r *http.Request) {
-	entries, err := h.loadFinanceEntries(r.Context())
+	approvalStatus := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("approval_status")))
+	if approvalStatus != "" {
+		if _, ok := allowedApprovalStatuses[approvalStatus]; !ok {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "approval_status inválido", nil)
+			return
+		}
+	}
+
+	entries, err := h.loadFinanceEntries(r.Context(), approvalStatus)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar lançamentos", nil)
 		return
 	}
-	WriteJSON(w, http.StatusOK, map[string]any{"entries": entries})
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"entries": entries,
+		"totals":  summarizeFinanceByCurrency(entries),
+	})
 }
 
 // CreateFinanceEntry registra um novo lançamento de caixa.
@@ -102,12 +151,42 @@ func (h *Handler) CreateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	amount := payload.Amount
+	amount := util.RoundMoney(payload.Amount)
 	if amount <= 0 {
 		WriteError(w, http.StatusBadRequest, "VALIDATION", "valor deve ser positivo", nil)
 		return
 	}
 
+	currency := util.DefaultCurrency
+	if payload.Currency != nil {
+		currency = util.NormalizeCurrency(*payload.Currency)
+	}
+	if _, ok := allowedCurrencies[currency]; !ok {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "moeda inválida", nil)
+		return
+	}
+
+	exchangeRate := 1.0
+	if currency != util.DefaultCurrency {
+		if payload.ExchangeRate == nil || *payload.ExchangeRate <= 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "taxa de câmbio obrigatória para moeda estrangeira", nil)
+			return
+		}
+		exchangeRate = *payload.ExchangeRate
+	}
+
+	var issRetention, irRetention float64
+	if payload.ISSRetention != nil {
+		issRetention = util.RoundMoney(*payload.ISSRetention)
+	}
+	if payload.IRRetention != nil {
+		irRetention = util.RoundMoney(*payload.IRRetention)
+	}
+	if issRetention < 0 || irRetention < 0 || issRetention+irRetention > amount {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "retenções devem ser positivas e não exceder o valor", nil)
+		return
+	}
+
 	var due sql.NullTime
 	if payload.DueDate != nil && strings.TrimSpace(*payload.DueDate) != "" {
 		if ts, err := parseISODate(*payload.DueDate); err == nil {
@@ -158,9 +237,11 @@ func (h *Handler) CreateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 		paidAt = time.Now()
 	}
 
+	approvalStatus := h.financeApprovalStatusFor(util.ConvertMoney(amount, exchangeRate))
+
 	const insert = `
-        INSERT INTO saas_finance_entries (tenant_id, entry_type, category, description, amount, due_date, paid, paid_at, method, cost_center, responsible, notes, created_by, updated_by)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9,''), NULLIF($10,''), NULLIF($11,''), $12, $13, $13)
+        INSERT INTO saas_finance_entries (tenant_id, entry_type, category, description, amount, due_date, paid, paid_at, method, cost_center, responsible, notes, created_by, updated_by, currency, exchange_rate, iss_retention, ir_retention, approval_status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9,''), NULLIF($10,''), NULLIF($11,''), $12, $13, $13, $14, $15, $16, $17, $18)
         RETURNING id
     `
 
@@ -179,6 +260,11 @@ func (h *Handler) CreateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 		responsible.String,
 		nullableString(notes),
 		creatorID,
+		currency,
+		exchangeRate,
+		issRetention,
+		irRetention,
+		approvalStatus,
 	).Scan(&entryID); err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar lançamento", nil)
 		return
@@ -190,6 +276,14 @@ func (h *Handler) CreateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if entry.ApprovalStatus == financeApprovalPending {
+		if err := recordFinanceApproval(r.Context(), h.pool, entry, financeDecisionRequested, nil, creatorID); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar solicitação de aprovação", nil)
+			return
+		}
+		h.notifyFinanceApproval(entry, financeDecisionRequested)
+	}
+
 	WriteJSON(w, http.StatusCreated, map[string]any{"entry": entry})
 }
 
@@ -216,6 +310,7 @@ func (h *Handler) UpdateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 	setParts := make([]string, 0, 10)
 	args := make([]any, 0, 10)
 	idx := 1
+	valueChanged := payload.Amount > 0 || payload.Currency != nil || payload.ExchangeRate != nil
 
 	if payload.Category != "" {
 		category := strings.TrimSpace(payload.Category)
@@ -241,7 +336,54 @@ func (h *Handler) UpdateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 
 	if payload.Amount > 0 {
 		setParts = append(setParts, fmt.Sprintf("amount = $%d", idx))
-		args = append(args, payload.Amount)
+		args = append(args, util.RoundMoney(payload.Amount))
+		idx++
+	}
+
+	if payload.Currency != nil {
+		currency := util.NormalizeCurrency(*payload.Currency)
+		if _, ok := allowedCurrencies[currency]; !ok {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "moeda inválida", nil)
+			return
+		}
+		setParts = append(setParts, fmt.Sprintf("currency = $%d", idx))
+		args = append(args, currency)
+		idx++
+		if currency == util.DefaultCurrency {
+			setParts = append(setParts, "exchange_rate = 1")
+		} else if payload.ExchangeRate == nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "taxa de câmbio obrigatória para moeda estrangeira", nil)
+			return
+		}
+	}
+
+	if payload.ExchangeRate != nil && (payload.Currency == nil || util.NormalizeCurrency(*payload.Currency) != util.DefaultCurrency) {
+		if *payload.ExchangeRate <= 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "taxa de câmbio inválida", nil)
+			return
+		}
+		setParts = append(setParts, fmt.Sprintf("exchange_rate = $%d", idx))
+		args = append(args, *payload.ExchangeRate)
+		idx++
+	}
+
+	if payload.ISSRetention != nil {
+		if *payload.ISSRetention < 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "retenção de ISS inválida", nil)
+			return
+		}
+		setParts = append(setParts, fmt.Sprintf("iss_retention = $%d", idx))
+		args = append(args, util.RoundMoney(*payload.ISSRetention))
+		idx++
+	}
+
+	if payload.IRRetention != nil {
+		if *payload.IRRetention < 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "retenção de IR inválida", nil)
+			return
+		}
+		setParts = append(setParts, fmt.Sprintf("ir_retention = $%d", idx))
+		args = append(args, util.RoundMoney(*payload.IRRetention))
 		idx++
 	}
 
@@ -323,6 +465,11 @@ func (h *Handler) UpdateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 
 	tag, err := h.pool.Exec(r.Context(), query, args...)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23514" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "moeda, câmbio ou retenções inconsistentes com o valor", nil)
+			return
+		}
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar lançamento", nil)
 		return
 	}
@@ -331,6 +478,13 @@ func (h *Handler) UpdateFinanceEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if valueChanged {
+		if err := h.reevaluateFinanceApproval(r.Context(), entryID, updaterID); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível reavaliar aprovação", nil)
+			return
+		}
+	}
+
 	entry, err := h.fetchFinanceEntry(r.Context(), entryID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -407,20 +561,20 @@ func (h *Handler) UploadFinanceAttachment(w http.ResponseWriter, r *http.Request
 
 	key := fmt.Sprintf("finance/%s/%d%s", entryID.String(), time.Now().UnixNano(), ext)
 
-	result, err := h.storage.Upload(r.Context(), storage.UploadInput{
+	// Só a chave é gravada: o anexo é baixado por link assinado, nunca pela URL do bucket.
+	if _, err := h.storage.Upload(r.Context(), storage.UploadInput{
 		Key:          key,
 		Body:         data,
 		ContentType:  contentType,
 		CacheControl: "private,max-age=31536000",
-	})
-	if err != nil {
+	}); err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao enviar arquivo", nil)
 		return
 	}
 
 	const insert = `
-        INSERT INTO saas_finance_attachments (finance_entry_id, file_name, file_url, object_key, uploaded_by)
-        VALUES ($1, $2, $3, $4, $5)
+        INSERT INTO saas_finance_attachments (finance_entry_id, file_name, object_key, uploaded_by)
+        VALUES ($1, $2, $3, $4)
         RETURNING id, uploaded_at
     `
 
@@ -429,16 +583,17 @@ func (h *Handler) UploadFinanceAttachment(w http.ResponseWriter, r *http.Request
 		attachmentID uuid.UUID
 		uploadedAt   time.Time
 	)
-	if err := h.pool.QueryRow(r.Context(), insert, entryID, fileHeader.Filename, result.URL, key, uploaderID).Scan(&attachmentID, &uploadedAt); err != nil {
+	if err := h.pool.QueryRow(r.Context(), insert, entryID, fileHeader.Filename, key, uploaderID).Scan(&attachmentID, &uploadedAt); err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar anexo", nil)
 		return
 	}
 
 	attachment := financeAttachment{
-		ID:         attachmentID,
-		Name:       fileHeader.Filename,
-		URL:        result.URL,
-		UploadedAt: uploadedAt,
+		ID:          attachmentID,
+		Name:        fileHeader.Filename,
+		URL:         fileDownloadPath(attachmentID),
+		DownloadURL: fileDownloadPath(attachmentID),
+		UploadedAt:  uploadedAt,
 	}
 
 	WriteJSON(w, http.StatusCreated, map[string]any{"attachment": attachment})
@@ -470,14 +625,17 @@ func (h *Handler) DeleteFinanceAttachment(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) loadFinanceEntries(ctx context.Context) ([]financeEntryView, error) {
-	const query = `
-        SELECT id, entry_type, category, description, amount, due_date, paid, paid_at, method, cost_center, responsible, notes, created_at
+const financeEntryColumns = `id, entry_type, category, description, amount, currency, exchange_rate, iss_retention, ir_retention, net_amount, amount_brl, net_amount_brl, due_date, paid, paid_at, method, cost_center, responsible, notes, approval_status, approval_comment, decided_by, decided_at, created_at`
+
+func (h *Handler) loadFinanceEntries(ctx context.Context, approvalStatus string) ([]financeEntryView, error) {
+	query := `
+        SELECT ` + financeEntryColumns + `
         FROM saas_finance_entries
+        WHERE ($1 = '' OR approval_status = $1)
         ORDER BY created_at DESC
     `
 
-	rows, err := h.pool.Query(ctx, query)
+	rows, err := h.pool.Query(ctx, query, approvalStatus)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return []financeEntryView{}, nil
@@ -488,61 +646,48 @@ func (h *Handler) loadFinanceEntries(ctx context.Context) ([]financeEntryView, e
 
 	var entries []financeEntryView
 	for rows.Next() {
-		var (
-			entry       financeEntryView
-			due         sql.NullTime
-			paidAt      sql.NullTime
-			method      sql.NullString
-			cost        sql.NullString
-			responsible sql.NullString
-			notes       sql.NullString
-		)
-		if err := rows.Scan(&entry.ID, &entry.EntryType, &entry.Category, &entry.Description, &entry.Amount, &due, &entry.Paid, &paidAt, &method, &cost, &responsible, &notes, &entry.CreatedAt); err != nil {
+		entry, err := scanFinanceEntry(rows)
+		if err != nil {
 			return nil, err
 		}
-		if due.Valid {
-			ts := due.Time
-			entry.DueDate = &ts
-		}
-		if paidAt.Valid {
-			ts := paidAt.Time
-			entry.PaidAt = &ts
-		}
-		if method.Valid {
-			str := strings.TrimSpace(method.String)
-			entry.Method = &str
-		}
-		if cost.Valid {
-			str := strings.TrimSpace(cost.String)
-			entry.CostCenter = &str
-		}
-		if responsible.Valid {
-			str := strings.TrimSpace(responsible.String)
-			entry.Responsible = &str
-		}
-		if notes.Valid {
-			str := strings.TrimSpace(notes.String)
-			entry.Notes = &str
-		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		attachments, err := h.loadFinanceAttachments(ctx, entry.ID)
+	for i := range entries {
+		attachments, err := h.loadFinanceAttachments(ctx, entries[i].ID)
 		if err != nil {
 			return nil, err
 		}
-		entry.Attachments = attachments
-		entries = append(entries, entry)
+		entries[i].Attachments = attachments
 	}
 
-	return entries, rows.Err()
+	return entries, nil
 }
 
 func (h *Handler) fetchFinanceEntry(ctx context.Context, entryID uuid.UUID) (financeEntryView, error) {
-	const query = `
-        SELECT id, entry_type, category, description, amount, due_date, paid, paid_at, method, cost_center, responsible, notes, created_at
+	query := `
+        SELECT ` + financeEntryColumns + `
         FROM saas_finance_entries
         WHERE id = $1
     `
 
+	entry, err := scanFinanceEntry(h.pool.QueryRow(ctx, query, entryID))
+	if err != nil {
+		return financeEntryView{}, err
+	}
+
+	attachments, err := h.loadFinanceAttachments(ctx, entry.ID)
+	if err != nil {
+		return financeEntryView{}, err
+	}
+	entry.Attachments = attachments
+	return entry, nil
+}
+
+func scanFinanceEntry(row pgx.Row) (financeEntryView, error) {
 	var (
 		entry       financeEntryView
 		due         sql.NullTime
@@ -551,9 +696,12 @@ func (h *Handler) fetchFinanceEntry(ctx context.Context, entryID uuid.UUID) (fin
 		cost        sql.NullString
 		responsible sql.NullString
 		notes       sql.NullString
+		comment     sql.NullString
+		decidedBy   uuid.NullUUID
+		decidedAt   sql.NullTime
 	)
 
-	if err := h.pool.QueryRow(ctx, query, entryID).Scan(&entry.ID, &entry.EntryType, &entry.Category, &entry.Description, &entry.Amount, &due, &entry.Paid, &paidAt, &method, &cost, &responsible, &notes, &entry.CreatedAt); err != nil {
+	if err := row.Scan(&entry.ID, &entry.EntryType, &entry.Category, &entry.Description, &entry.Amount, &entry.Currency, &entry.ExchangeRate, &entry.ISSRetention, &entry.IRRetention, &entry.NetAmount, &entry.AmountBRL, &entry.NetAmountBRL, &due, &entry.Paid, &paidAt, &method, &cost, &responsible, &notes, &entry.ApprovalStatus, &comment, &decidedBy, &decidedAt, &entry.CreatedAt); err != nil {
 		return financeEntryView{}, err
 	}
 
@@ -581,18 +729,64 @@ func (h *Handler) fetchFinanceEntry(ctx context.Context, entryID uuid.UUID) (fin
 		str := strings.TrimSpace(notes.String)
 		entry.Notes = &str
 	}
-
-	attachments, err := h.loadFinanceAttachments(ctx, entry.ID)
-	if err != nil {
-		return financeEntryView{}, err
+	if comment.Valid {
+		str := strings.TrimSpace(comment.String)
+		entry.ApprovalComment = &str
+	}
+	if decidedBy.Valid {
+		id := decidedBy.UUID
+		entry.DecidedBy = &id
+	}
+	if decidedAt.Valid {
+		ts := decidedAt.Time
+		entry.DecidedAt = &ts
 	}
-	entry.Attachments = attachments
 	return entry, nil
 }
 
+// summarizeFinanceByCurrency soma os lançamentos por moeda, mantendo BRL em primeiro.
+func summarizeFinanceByCurrency(entries []financeEntryView) []financeCurrencyTotal {
+	byCurrency := make(map[string]*financeCurrencyTotal)
+	for _, entry := range entries {
+		total, ok := byCurrency[entry.Currency]
+		if !ok {
+			total = &financeCurrencyTotal{Currency: entry.Currency}
+			byCurrency[entry.Currency] = total
+		}
+		total.Entries++
+		total.Amount += entry.Amount
+		total.ISSRetention += entry.ISSRetention
+		total.IRRetention += entry.IRRetention
+		total.NetAmount += entry.NetAmount
+		total.AmountBRL += entry.AmountBRL
+		total.NetAmountBRL += entry.NetAmountBRL
+	}
+
+	totals := make([]financeCurrencyTotal, 0, len(byCurrency))
+	for _, total := range byCurrency {
+		total.Amount = util.RoundMoney(total.Amount)
+		total.ISSRetention = util.RoundMoney(total.ISSRetention)
+		total.IRRetention = util.RoundMoney(total.IRRetention)
+		total.NetAmount = util.RoundMoney(total.NetAmount)
+		total.AmountBRL = util.RoundMoney(total.AmountBRL)
+		total.NetAmountBRL = util.RoundMoney(total.NetAmountBRL)
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Currency == util.DefaultCurrency {
+			return true
+		}
+		if totals[j].Currency == util.DefaultCurrency {
+			return false
+		}
+		return totals[i].Currency < totals[j].Currency
+	})
+	return totals
+}
+
 func (h *Handler) loadFinanceAttachments(ctx context.Context, entryID uuid.UUID) ([]financeAttachment, error) {
 	rows, err := h.pool.Query(ctx, `
-        SELECT id, file_name, file_url, uploaded_at
+        SELECT id, file_name, uploaded_at
         FROM saas_finance_attachments
         WHERE finance_entry_id = $1
         ORDER BY uploaded_at DESC
@@ -608,9 +802,11 @@ func (h *Handler) loadFinanceAttachments(ctx context.Context, entryID uuid.UUID)
 	var attachments []financeAttachment
 	for rows.Next() {
 		var att financeAttachment
-		if err := rows.Scan(&att.ID, &att.Name, &att.URL, &att.UploadedAt); err != nil {
+		if err := rows.Scan(&att.ID, &att.Name, &att.UploadedAt); err != nil {
 			return nil, err
 		}
+		att.DownloadURL = fileDownloadPath(att.ID)
+		att.URL = att.DownloadURL
 		attachments = append(attachments, att)
 	}
 	return attachments, rows.Err()
@@ -0,0 +1,302 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/monitor"
+)
+
+const (
+	financeApprovalPending  = "pending"
+	financeApprovalApproved = "approved"
+	financeApprovalRejected = "rejected"
+
+	financeDecisionRequested = "requested"
+)
+
+var allowedApprovalStatuses = map[string]struct{}{
+	financeApprovalPending:  {},
+	financeApprovalApproved: {},
+	financeApprovalRejected: {},
+}
+
+type financeApprovalPayload struct {
+	Comment *string `json:"comment"`
+}
+
+type financeApprovalView struct {
+	ID        uuid.UUID  `json:"id"`
+	Decision  string     `json:"decision"`
+	Comment   *string    `json:"comment,omitempty"`
+	AmountBRL *float64   `json:"amount_brl,omitempty"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	ActorName *string    `json:"actor_name,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type financeExecer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// ApproveFinanceEntry libera lançamento pendente para compor MRR e previsões.
+func (h *Handler) ApproveFinanceEntry(w http.ResponseWriter, r *http.Request) {
+	h.decideFinanceEntry(w, r, financeApprovalApproved)
+}
+
+// RejectFinanceEntry reprova lançamento pendente, mantendo-o fora dos indicadores.
+func (h *Handler) RejectFinanceEntry(w http.ResponseWriter, r *http.Request) {
+	h.decideFinanceEntry(w, r, financeApprovalRejected)
+}
+
+// ListFinanceEntryApprovals devolve o histórico de aprovação do lançamento.
+func (h *Handler) ListFinanceEntryApprovals(w http.ResponseWriter, r *http.Request) {
+	entryID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(), `
+        SELECT a.id, a.decision, a.comment, a.amount_brl, a.actor_id, su.name, a.created_at
+        FROM saas_finance_entry_approvals a
+        LEFT JOIN saas_users su ON su.id = a.actor_id
+        WHERE a.finance_entry_id = $1
+        ORDER BY a.created_at DESC
+    `, entryID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar histórico", nil)
+		return
+	}
+	defer rows.Close()
+
+	history := []financeApprovalView{}
+	for rows.Next() {
+		var (
+			item      financeApprovalView
+			comment   sql.NullString
+			amount    sql.NullFloat64
+			actorID   uuid.NullUUID
+			actorName sql.NullString
+		)
+		if err := rows.Scan(&item.ID, &item.Decision, &comment, &amount, &actorID, &actorName, &item.CreatedAt); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar histórico", nil)
+			return
+		}
+		if comment.Valid {
+			str := strings.TrimSpace(comment.String)
+			item.Comment = &str
+		}
+		if amount.Valid {
+			val := amount.Float64
+			item.AmountBRL = &val
+		}
+		if actorID.Valid {
+			id := actorID.UUID
+			item.ActorID = &id
+		}
+		if actorName.Valid {
+			name := actorName.String
+			item.ActorName = &name
+		}
+		history = append(history, item)
+	}
+	if err := rows.Err(); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar histórico", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"approvals": history})
+}
+
+func (h *Handler) decideFinanceEntry(w http.ResponseWriter, r *http.Request, decision string) {
+	entryID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	var payload financeApprovalPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	var comment sql.NullString
+	if payload.Comment != nil && strings.TrimSpace(*payload.Comment) != "" {
+		comment = sql.NullString{String: strings.TrimSpace(*payload.Comment), Valid: true}
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar decisão", nil)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	const update = `
+        UPDATE saas_finance_entries
+        SET approval_status = $1, approval_comment = $2, decided_by = $3, decided_at = now(), updated_by = $3
+        WHERE id = $4 AND approval_status = 'pending'
+    `
+
+	tag, err := tx.Exec(r.Context(), update, decision, nullableString(comment), actorID, entryID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar decisão", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "lançamento inexistente ou sem aprovação pendente", nil)
+		return
+	}
+
+	entry, err := scanFinanceEntry(tx.QueryRow(r.Context(), `SELECT `+financeEntryColumns+` FROM saas_finance_entries WHERE id = $1`, entryID))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar lançamento", nil)
+		return
+	}
+
+	if err := recordFinanceApproval(r.Context(), tx, entry, decision, entry.ApprovalComment, actorID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar decisão", nil)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar decisão", nil)
+		return
+	}
+
+	entry, err = h.fetchFinanceEntry(r.Context(), entryID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar lançamento", nil)
+		return
+	}
+
+	h.notifyFinanceApproval(entry, decision)
+
+	WriteJSON(w, http.StatusOK, map[string]any{"entry": entry})
+}
+
+// financeApprovalStatusFor define o status inicial conforme o limite configurado.
+func (h *Handler) financeApprovalStatusFor(amountBRL float64) string {
+	threshold := h.cfg.Finance.ApprovalThreshold
+	if threshold > 0 && amountBRL > threshold {
+		return financeApprovalPending
+	}
+	return financeApprovalApproved
+}
+
+// reevaluateFinanceApproval recalcula a necessidade de aprovação após mudança de valor.
+// Lançamentos que continuam acima do limite voltam para a fila, mesmo se já decididos. A troca de
+// status e o histórico são gravados na mesma transação, e nada é gravado se o status não muda.
+func (h *Handler) reevaluateFinanceApproval(ctx context.Context, entryID, actorID uuid.UUID) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	entry := financeEntryView{ID: entryID}
+	if err := tx.QueryRow(ctx, `
+        SELECT approval_status, amount_brl FROM saas_finance_entries WHERE id = $1 FOR UPDATE
+    `, entryID).Scan(&entry.ApprovalStatus, &entry.AmountBRL); err != nil {
+		return err
+	}
+
+	status := h.financeApprovalStatusFor(entry.AmountBRL)
+	change, request := financeApprovalTransition(entry.ApprovalStatus, status)
+	if !change {
+		return nil
+	}
+
+	const update = `
+        UPDATE saas_finance_entries
+        SET approval_status = $1, approval_comment = NULL, decided_by = NULL, decided_at = NULL
+        WHERE id = $2
+    `
+	if _, err := tx.Exec(ctx, update, status, entryID); err != nil {
+		return err
+	}
+	if request {
+		if err := recordFinanceApproval(ctx, tx, entry, financeDecisionRequested, nil, actorID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if request {
+		if entry, err := h.fetchFinanceEntry(ctx, entryID); err == nil {
+			h.notifyFinanceApproval(entry, financeDecisionRequested)
+		}
+	}
+	return nil
+}
+
+// financeApprovalTransition diz se o lançamento muda de status ao passar de current para next e
+// se a mudança é uma volta à fila, que entra no histórico como pedido de aprovação. Abaixo do
+// limite, decisões já tomadas ficam como estão; só pendências saem da fila.
+func financeApprovalTransition(current, next string) (change, request bool) {
+	if next == financeApprovalPending {
+		change = current != financeApprovalPending
+		return change, change
+	}
+	return current == financeApprovalPending, false
+}
+
+func recordFinanceApproval(ctx context.Context, db financeExecer, entry financeEntryView, decision string, comment *string, actorID uuid.UUID) error {
+	const insert = `
+        INSERT INTO saas_finance_entry_approvals (finance_entry_id, decision, comment, amount_brl, actor_id)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	_, err := db.Exec(ctx, insert, entry.ID, decision, comment, entry.AmountBRL, actorID)
+	return err
+}
+
+// notifyFinanceApproval dispara o hook de notificação sem bloquear a requisição.
+func (h *Handler) notifyFinanceApproval(entry financeEntryView, decision string) {
+	if h.notifier == nil {
+		return
+	}
+
+	msg := monitor.AlertMessage{Severity: "info"}
+	switch decision {
+	case financeDecisionRequested:
+		msg.Title = "Lançamento aguardando aprovação"
+		msg.Severity = "warning"
+	case financeApprovalApproved:
+		msg.Title = "Lançamento aprovado"
+	case financeApprovalRejected:
+		msg.Title = "Lançamento reprovado"
+	}
+	msg.Text = fmt.Sprintf("%s — %s %.2f (R$ %.2f)", entry.Description, entry.Currency, entry.Amount, entry.AmountBRL)
+	if entry.ApprovalComment != nil && decision != financeDecisionRequested {
+		msg.Text += "\nComentário: " + *entry.ApprovalComment
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := h.notifier.Notify(ctx, msg); err != nil {
+			log.Warn().Err(err).Str("entry", entry.ID.String()).Msg("finance: falha ao notificar aprovação")
+		}
+	}()
+}
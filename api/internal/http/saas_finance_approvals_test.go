@@ -0,0 +1,25 @@
+package http
+
+import "testing"
+
+func TestFinanceApprovalTransition(t *testing.T) {
+	cases := []struct {
+		current, next   string
+		change, request bool
+	}{
+		// Continua acima do limite e já estava na fila: nada de novo no histórico.
+		{financeApprovalPending, financeApprovalPending, false, false},
+		{financeApprovalApproved, financeApprovalPending, true, true},
+		{financeApprovalRejected, financeApprovalPending, true, true},
+		// Caiu abaixo do limite: pendência sai da fila sem pedido; decisões ficam.
+		{financeApprovalPending, financeApprovalApproved, true, false},
+		{financeApprovalApproved, financeApprovalApproved, false, false},
+		{financeApprovalRejected, financeApprovalApproved, false, false},
+	}
+	for _, tc := range cases {
+		change, request := financeApprovalTransition(tc.current, tc.next)
+		if change != tc.change || request != tc.request {
+			t.Errorf("%s -> %s: change=%v request=%v, want change=%v request=%v", tc.current, tc.next, change, request, tc.change, tc.request)
+		}
+	}
+}
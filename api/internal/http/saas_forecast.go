@@ -0,0 +1,159 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/forecast"
+)
+
+type forecastDealPayload struct {
+	Name         string   `json:"name"`
+	MonthlyValue float64  `json:"monthly_value"`
+	AnnualValue  float64  `json:"annual_value"`
+	Start        string   `json:"start"`
+	Probability  *float64 `json:"probability"`
+}
+
+type forecastAdjustmentPayload struct {
+	Effective string  `json:"effective"`
+	Percent   float64 `json:"percent"`
+}
+
+type forecastScenarioPayload struct {
+	Name        string                      `json:"name"`
+	ChurnPct    *float64                    `json:"churn_pct"`
+	Pipeline    []forecastDealPayload       `json:"pipeline"`
+	Adjustments []forecastAdjustmentPayload `json:"price_adjustments"`
+}
+
+// FinanceForecast projeta MRR/ARR dos contratos vigentes. GET devolve o cenário base com o
+// churn histórico das coortes; POST aceita cenários com churn, pipeline e reajustes.
+func (h *Handler) FinanceForecast(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Months    int                       `json:"months"`
+		Scenarios []forecastScenarioPayload `json:"scenarios"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+			return
+		}
+	}
+	if monthsStr := strings.TrimSpace(r.URL.Query().Get("months")); monthsStr != "" {
+		if v, err := strconv.Atoi(monthsStr); err == nil {
+			payload.Months = v
+		}
+	}
+	if len(payload.Scenarios) == 0 {
+		payload.Scenarios = []forecastScenarioPayload{{Name: "base"}}
+	}
+
+	scenarios := make([]forecast.Scenario, 0, len(payload.Scenarios))
+	for i, item := range payload.Scenarios {
+		scenario, err := parseForecastScenario(item, i)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	ctx := r.Context()
+	// contract_value é o valor anual do contrato; a receita recorrente mensal é 1/12 dele.
+	var baseMRR float64
+	var contracts int
+	if err := h.pool.QueryRow(ctx, `
+        SELECT COALESCE(SUM(c.contract_value), 0) / 12, COUNT(*)
+        FROM saas_tenant_contracts c
+        JOIN tenants t ON t.id = c.tenant_id
+        WHERE c.status IN ('active', 'renewal') AND c.contract_value IS NOT NULL
+          AND t.status = 'active'
+    `).Scan(&baseMRR, &contracts); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar contratos", nil)
+		return
+	}
+
+	var cohortTenants, cohortChurn int64
+	if err := h.pool.QueryRow(ctx, `
+        SELECT COALESCE(SUM(tenants_count), 0), COALESCE(SUM(churn_count), 0) FROM saas_retention_cohorts
+    `).Scan(&cohortTenants, &cohortChurn); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar coortes", nil)
+		return
+	}
+	historical := forecast.HistoricalChurn(cohortTenants, cohortChurn)
+
+	now := time.Now().UTC()
+	months := forecast.ClampMonths(payload.Months)
+	results := make([]forecast.Result, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		results = append(results, forecast.Project(now, months, baseMRR, historical, scenario))
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"generated_at":         now,
+		"months":               months,
+		"active_contracts":     contracts,
+		"historical_churn_pct": historical,
+		"scenarios":            results,
+	})
+}
+
+func parseForecastScenario(item forecastScenarioPayload, index int) (forecast.Scenario, error) {
+	scenario := forecast.Scenario{
+		Name:     strings.TrimSpace(item.Name),
+		ChurnPct: item.ChurnPct,
+	}
+	if scenario.Name == "" {
+		scenario.Name = "cenario-" + strconv.Itoa(index+1)
+	}
+
+	for _, deal := range item.Pipeline {
+		start, err := parseForecastMonth(deal.Start)
+		if err != nil {
+			return forecast.Scenario{}, errors.New("pipeline: start deve estar no formato AAAA-MM")
+		}
+		monthly := deal.MonthlyValue
+		if monthly == 0 && deal.AnnualValue > 0 {
+			monthly = deal.AnnualValue / 12
+		}
+		if monthly <= 0 {
+			return forecast.Scenario{}, errors.New("pipeline: informe monthly_value ou annual_value")
+		}
+		probability := 1.0
+		if deal.Probability != nil {
+			probability = *deal.Probability
+		}
+		scenario.Pipeline = append(scenario.Pipeline, forecast.Deal{
+			Name:         strings.TrimSpace(deal.Name),
+			MonthlyValue: monthly,
+			Start:        start,
+			Probability:  probability,
+		})
+	}
+
+	for _, adj := range item.Adjustments {
+		effective, err := parseForecastMonth(adj.Effective)
+		if err != nil {
+			return forecast.Scenario{}, errors.New("price_adjustments: effective deve estar no formato AAAA-MM")
+		}
+		scenario.Adjustments = append(scenario.Adjustments, forecast.Adjustment{Effective: effective, Percent: adj.Percent})
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return forecast.Scenario{}, err
+	}
+	return scenario, nil
+}
+
+func parseForecastMonth(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01", value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/ibge"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+// ibgeLookupTimeout limita a consulta feita durante a criação do tenant.
+const ibgeLookupTimeout = 8 * time.Second
+
+// ibgeHint identifica o município: pelo código IBGE ou pelo nome e UF.
+type ibgeHint struct {
+	Code         *int   `json:"ibge_code"`
+	Municipality string `json:"municipio"`
+	UF           string `json:"uf"`
+}
+
+type tenantRegistryView struct {
+	IBGECode       int        `json:"ibge_code"`
+	Municipality   string     `json:"municipio"`
+	UF             string     `json:"uf"`
+	Region         string     `json:"region"`
+	Population     int64      `json:"population"`
+	PopulationYear *int       `json:"population_year,omitempty"`
+	BillingTier    *ibge.Tier `json:"billing_tier,omitempty"`
+	SyncedAt       time.Time  `json:"synced_at"`
+}
+
+// SyncTenantIBGE reconsulta o IBGE para o tenant; o corpo opcional corrige código, município ou UF.
+func (h *Handler) SyncTenantIBGE(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var hint ibgeHint
+	if err := json.NewDecoder(r.Body).Decode(&hint); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	tenantInfo, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, tenant.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "tenant não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenant", nil)
+		return
+	}
+
+	registry, err := h.enrichTenantIBGE(r.Context(), tenantInfo, hint)
+	if err != nil {
+		if errors.Is(err, ibge.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "município não encontrado no IBGE", nil)
+			return
+		}
+		WriteError(w, http.StatusBadGateway, "IBGE_UNAVAILABLE", err.Error(), nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"registry": registry})
+}
+
+// enrichTenantIBGE localiza o município, grava código, região, população e faixa de cobrança
+// e usa a população como base dos indicadores da cidade.
+func (h *Handler) enrichTenantIBGE(ctx context.Context, t *tenant.Tenant, hint ibgeHint) (*tenantRegistryView, error) {
+	if h.ibge == nil {
+		return nil, errors.New("consulta ao IBGE desativada")
+	}
+
+	municipality, err := h.lookupMunicipality(ctx, t, hint)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &tenantRegistryView{
+		IBGECode:     municipality.Code,
+		Municipality: municipality.Name,
+		UF:           municipality.UF,
+		Region:       municipality.Region,
+		Population:   municipality.Population,
+		BillingTier:  ibge.TierFor(municipality.Population),
+	}
+	if municipality.PopulationYear > 0 {
+		year := municipality.PopulationYear
+		view.PopulationYear = &year
+	}
+	var tierCode *string
+	if view.BillingTier != nil {
+		tierCode = &view.BillingTier.Code
+	}
+
+	tx, err := h.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+        INSERT INTO saas_tenant_registry (tenant_id, ibge_code, municipality, uf, region, population, population_year, billing_tier, synced_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+        ON CONFLICT (tenant_id) DO UPDATE
+        SET ibge_code = EXCLUDED.ibge_code, municipality = EXCLUDED.municipality, uf = EXCLUDED.uf, region = EXCLUDED.region,
+            population = EXCLUDED.population, population_year = EXCLUDED.population_year,
+            billing_tier = EXCLUDED.billing_tier, synced_at = now()
+        RETURNING synced_at
+    `, t.ID, view.IBGECode, view.Municipality, view.UF, view.Region, view.Population, view.PopulationYear, tierCode).Scan(&view.SyncedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if view.Population > 0 {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO saas_city_insights (tenant_id, population) VALUES ($1, $2)
+            ON CONFLICT (tenant_id) DO UPDATE SET population = EXCLUDED.population, updated_at = now()
+        `, t.ID, view.Population); err != nil {
+			return nil, err
+		}
+	}
+
+	return view, tx.Commit(ctx)
+}
+
+// lookupMunicipality usa o código informado, o nome e UF informados ou, por fim, o nome
+// extraído do display_name com a UF do contato do tenant.
+func (h *Handler) lookupMunicipality(ctx context.Context, t *tenant.Tenant, hint ibgeHint) (*ibge.Municipality, error) {
+	if hint.Code != nil && *hint.Code > 0 {
+		return h.ibge.ByCode(ctx, *hint.Code)
+	}
+
+	name := strings.TrimSpace(hint.Municipality)
+	if name == "" {
+		name = ibge.MunicipalityFromDisplayName(t.DisplayName)
+	}
+	state := strings.TrimSpace(hint.UF)
+	if state == "" {
+		if value, ok := t.Contact["uf"].(string); ok {
+			state = value
+		}
+	}
+	if name == "" || state == "" {
+		return nil, errors.New("informe ibge_code ou municipio e uf")
+	}
+	return h.ibge.ByName(ctx, name, state)
+}
+
+// loadTenantRegistry devolve o cadastro IBGE do tenant ou nil quando ainda não sincronizado.
+func (h *Handler) loadTenantRegistry(ctx context.Context, tenantID uuid.UUID) (*tenantRegistryView, error) {
+	var view tenantRegistryView
+	err := h.pool.QueryRow(ctx, `
+        SELECT ibge_code, municipality, uf, region, population, population_year, synced_at
+        FROM saas_tenant_registry WHERE tenant_id = $1
+    `, tenantID).Scan(&view.IBGECode, &view.Municipality, &view.UF, &view.Region, &view.Population, &view.PopulationYear, &view.SyncedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	view.BillingTier = ibge.TierFor(view.Population)
+	return &view, nil
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// Estados da varredura antivírus de uma nota fiscal.
+const (
+	invoiceScanSkipped     = "skipped"
+	invoiceScanClean       = "clean"
+	invoiceScanQuarantined = "quarantined"
+	invoiceScanFailed      = "failed"
+	invoiceScanReleased    = "released"
+)
+
+// invoiceQuarantined indica se a nota está retida: sinalizada pelo scanner ou sem veredito por falha.
+func invoiceQuarantined(status string) bool {
+	return status == invoiceScanQuarantined || status == invoiceScanFailed
+}
+
+type invoiceScan struct {
+	Status    string
+	Detail    *string
+	ScannedAt *time.Time
+}
+
+// scanInvoice varre o arquivo antes do registro. Falha do scanner retém a nota em vez de liberá-la.
+func (h *Handler) scanInvoice(ctx context.Context, data []byte) invoiceScan {
+	if h.scanner == nil {
+		return invoiceScan{Status: invoiceScanSkipped}
+	}
+
+	now := time.Now()
+	result, err := h.scanner.Scan(ctx, data)
+	if err != nil {
+		log.Error().Err(err).Msg("antivírus: falha ao varrer nota fiscal")
+		detail := err.Error()
+		return invoiceScan{Status: invoiceScanFailed, Detail: &detail, ScannedAt: &now}
+	}
+	if result.Infected() {
+		detail := result.Signature
+		return invoiceScan{Status: invoiceScanQuarantined, Detail: &detail, ScannedAt: &now}
+	}
+	return invoiceScan{Status: invoiceScanClean, ScannedAt: &now}
+}
+
+// ReleaseTenantInvoice tira uma nota da quarentena após análise do SAAS_OWNER.
+func (h *Handler) ReleaseTenantInvoice(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	invoiceID, err := parseUUIDParam(r, "invoiceID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id da nota inválido", nil)
+		return
+	}
+
+	var releasedBy *uuid.UUID
+	if subject, err := uuid.Parse(httpmiddleware.GetSubject(r.Context())); err == nil {
+		releasedBy = &subject
+	}
+
+	const update = `
+        UPDATE saas_tenant_invoices
+        SET scan_status = $3, released_by = $4, released_at = now()
+        WHERE tenant_id = $1 AND id = $2 AND scan_status IN ($5, $6)
+        RETURNING id
+    `
+	var released uuid.UUID
+	err = h.pool.QueryRow(r.Context(), update, tenantID, invoiceID, invoiceScanReleased, releasedBy, invoiceScanQuarantined, invoiceScanFailed).Scan(&released)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "nota em quarentena não encontrada", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível liberar nota", nil)
+		return
+	}
+
+	log.Warn().Str("invoice_id", invoiceID.String()).Str("tenant_id", tenantID.String()).Msg("antivírus: nota liberada da quarentena")
+
+	contract, err := h.fetchTenantContract(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar contrato", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"contract": contract})
+}
+
+func hasSaaSRole(r *http.Request, role string) bool {
+	for _, candidate := range httpmiddleware.GetRoles(r.Context()) {
+		if strings.EqualFold(strings.TrimSpace(candidate), role) {
+			return true
+		}
+	}
+	return false
+}
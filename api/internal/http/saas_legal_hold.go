@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/legalhold"
+	"github.com/gestaozabele/municipio/internal/monitor"
+)
+
+type legalHoldPayload struct {
+	Reason        string  `json:"reason"`
+	CaseReference *string `json:"case_reference"`
+}
+
+// ListTenantLegalHolds mostra o histórico de retenções legais do tenant.
+func (h *Handler) ListTenantLegalHolds(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	holds, err := h.legalHolds.History(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar retenções", nil)
+		return
+	}
+	onHold := false
+	for _, hold := range holds {
+		switch hold.Status {
+		case legalhold.StatusPending, legalhold.StatusActive, legalhold.StatusReleasing:
+			onHold = true
+		}
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"on_hold": onHold, "holds": holds})
+}
+
+// RequestTenantLegalHold abre a retenção; outro SAAS_OWNER precisa confirmá-la.
+func (h *Handler) RequestTenantLegalHold(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, ok := h.legalHoldActor(w, r)
+	if !ok {
+		return
+	}
+	var payload legalHoldPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if strings.TrimSpace(payload.Reason) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "motivo é obrigatório", nil)
+		return
+	}
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	hold, err := h.legalHolds.Request(r.Context(), tenantID, actorID, payload.Reason, trimmedOrNil(payload.CaseReference))
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	h.notifyLegalHold(hold, "Retenção legal aguardando confirmação", "warning")
+	WriteJSON(w, http.StatusCreated, map[string]any{"hold": hold})
+}
+
+// ConfirmTenantLegalHold ativa a retenção pendente e grava o retrato imutável dos dados.
+func (h *Handler) ConfirmTenantLegalHold(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, ok := h.legalHoldActor(w, r)
+	if !ok {
+		return
+	}
+	hold, err := h.legalHolds.Confirm(r.Context(), tenantID, actorID)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	h.notifyLegalHold(hold, "Retenção legal ativada", "info")
+	WriteJSON(w, http.StatusOK, map[string]any{"hold": hold})
+}
+
+// CancelTenantLegalHold descarta uma solicitação ainda não confirmada.
+func (h *Handler) CancelTenantLegalHold(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.legalHoldActor(w, r)
+	if !ok {
+		return
+	}
+	hold, err := h.legalHolds.Cancel(r.Context(), tenantID)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"hold": hold})
+}
+
+// RequestTenantLegalHoldRelease pede a liberação; outro SAAS_OWNER precisa confirmá-la.
+func (h *Handler) RequestTenantLegalHoldRelease(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, ok := h.legalHoldActor(w, r)
+	if !ok {
+		return
+	}
+	var payload legalHoldPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if strings.TrimSpace(payload.Reason) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "motivo é obrigatório", nil)
+		return
+	}
+	hold, err := h.legalHolds.RequestRelease(r.Context(), tenantID, actorID, payload.Reason)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	h.notifyLegalHold(hold, "Liberação de retenção legal aguardando confirmação", "warning")
+	WriteJSON(w, http.StatusOK, map[string]any{"hold": hold})
+}
+
+// ConfirmTenantLegalHoldRelease libera a retenção.
+func (h *Handler) ConfirmTenantLegalHoldRelease(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, ok := h.legalHoldActor(w, r)
+	if !ok {
+		return
+	}
+	hold, err := h.legalHolds.ConfirmRelease(r.Context(), tenantID, actorID)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	h.notifyLegalHold(hold, "Retenção legal liberada", "info")
+	WriteJSON(w, http.StatusOK, map[string]any{"hold": hold})
+}
+
+// AbortTenantLegalHoldRelease mantém a retenção ativa, descartando o pedido de liberação.
+func (h *Handler) AbortTenantLegalHoldRelease(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.legalHoldActor(w, r)
+	if !ok {
+		return
+	}
+	hold, err := h.legalHolds.AbortRelease(r.Context(), tenantID)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"hold": hold})
+}
+
+// DownloadTenantLegalHoldSnapshot baixa o retrato gravado na ativação, com o hash para conferência.
+func (h *Handler) DownloadTenantLegalHoldSnapshot(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	holdID, err := parseUUIDParam(r, "holdID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "retenção inválida", nil)
+		return
+	}
+
+	snapshot, digest, err := h.legalHolds.Snapshot(r.Context(), tenantID, holdID)
+	if err != nil {
+		writeLegalHoldError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"retencao-%s.json\"", holdID.String()[:8]))
+	w.Header().Set("X-Content-SHA256", digest)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(snapshot)
+}
+
+// requireNoLegalHold recusa operações destrutivas em tenants sob retenção legal.
+func (h *Handler) requireNoLegalHold(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) bool {
+	held, err := h.legalHolds.OnHold(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível verificar retenção legal", nil)
+		return false
+	}
+	if held {
+		WriteError(w, http.StatusConflict, "LEGAL_HOLD", "tenant sob retenção legal", nil)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) legalHoldActor(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, actorID, true
+}
+
+func (h *Handler) notifyLegalHold(hold *legalhold.Hold, title, severity string) {
+	if h.notifier == nil || hold == nil {
+		return
+	}
+	msg := monitor.AlertMessage{
+		Title:    title,
+		Text:     fmt.Sprintf("Tenant %s — %s", hold.TenantID, hold.Reason),
+		Severity: severity,
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := h.notifier.Notify(ctx, msg); err != nil {
+			log.Warn().Err(err).Str("hold", hold.ID.String()).Msg("legalhold: falha ao notificar")
+		}
+	}()
+}
+
+func writeLegalHoldError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, legalhold.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "retenção não encontrada no estado esperado", nil)
+	case errors.Is(err, legalhold.ErrAlreadyOpen):
+		WriteError(w, http.StatusConflict, "CONFLICT", "tenant já possui retenção aberta", nil)
+	case errors.Is(err, legalhold.ErrSameOwner):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "a confirmação exige outro SAAS_OWNER", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar retenção legal", nil)
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/mail"
+	"github.com/gestaozabele/municipio/internal/service"
+)
+
+// queueMail preenche o modelo e coloca a mensagem na fila de envio. Devolve "queued", "suppressed"
+// (tenant sandbox, registrada sem envio), "skipped" (sem provedor de e-mail configurado) ou "failed".
+func (h *Handler) queueMail(ctx context.Context, template string, data any, msg mail.Message) string {
+	if h.mailer == nil || h.outbox == nil {
+		return "skipped"
+	}
+	subject, text, err := mail.RenderTemplate(template, data)
+	if err != nil {
+		log.Error().Err(err).Str("template", template).Msg("mail: falha ao montar mensagem")
+		return "failed"
+	}
+	msg.Subject, msg.Text = subject, text
+	delivery, err := h.outbox.Enqueue(ctx, template, msg)
+	if err != nil {
+		log.Error().Err(err).Str("template", template).Msg("mail: falha ao enfileirar mensagem")
+		return "failed"
+	}
+	if delivery.Status == mail.StatusSuppressed {
+		return "suppressed"
+	}
+	return "queued"
+}
+
+// sendInviteEmail envia o link de aceite do convite ao administrador convidado.
+func (h *Handler) sendInviteEmail(ctx context.Context, invite *service.InviteResult) string {
+	if h.cfg.Mail.PanelURL == "" {
+		return "skipped"
+	}
+	return h.queueMail(ctx, mail.TemplateInvite, mail.InviteData{
+		Name:      invite.Invite.Name,
+		Role:      invite.Invite.Role,
+		Link:      h.cfg.Mail.PanelURL + "/convite?token=" + url.QueryEscape(invite.Token),
+		ExpiresAt: invite.Invite.ExpiresAt,
+	}, mail.Message{To: []string{invite.Invite.Email}})
+}
+
+// ListMailDeliveries lista a fila de e-mails com o status de entrega de cada mensagem.
+func (h *Handler) ListMailDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := mail.DeliveryFilter{
+		Status:   strings.TrimSpace(query.Get("status")),
+		To:       strings.TrimSpace(query.Get("to")),
+		Template: strings.TrimSpace(query.Get("template")),
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > 500 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit deve estar entre 1 e 500", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+	deliveries, err := h.outbox.List(r.Context(), filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar os e-mails", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"deliveries": deliveries, "provider_configured": h.mailer != nil})
+}
+
+// RetryMailDelivery devolve à fila um e-mail que esgotou as tentativas.
+func (h *Handler) RetryMailDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "deliveryID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	delivery, err := h.outbox.Retry(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, mail.ErrDeliveryNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "e-mail não encontrado", nil)
+		case errors.Is(err, mail.ErrNotRetryable):
+			WriteError(w, http.StatusConflict, "CONFLICT", "só e-mails com falha podem ser reenviados", nil)
+		default:
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível reenviar o e-mail", nil)
+		}
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"delivery": delivery})
+}
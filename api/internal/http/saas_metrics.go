@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/kpi"
+)
+
+// MetricsSnapshot devolve o retrato congelado dos indicadores do mês (padrão: o último mês
+// encerrado). Na primeira consulta de um mês encerrado o retrato é calculado e gravado como
+// versão 1; meses em aberto retornam apenas uma prévia, sem gravação.
+func (h *Handler) MetricsSnapshot(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	month, err := kpi.ParseMonth(r.URL.Query().Get("month"), now)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	if raw := strings.TrimSpace(r.URL.Query().Get("version")); raw != "" {
+		version, err := strconv.Atoi(raw)
+		if err != nil || version < 1 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "versão inválida", nil)
+			return
+		}
+		snapshot, err := h.kpis.Version(r.Context(), month, version)
+		if err != nil {
+			writeMetricsError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{"frozen": true, "snapshot": snapshot})
+		return
+	}
+
+	snapshot, err := h.kpis.Latest(r.Context(), month)
+	switch {
+	case err == nil:
+		WriteJSON(w, http.StatusOK, map[string]any{"frozen": true, "snapshot": snapshot})
+		return
+	case !errors.Is(err, kpi.ErrNotFound):
+		writeMetricsError(w, err)
+		return
+	}
+
+	if !month.Closed(now) {
+		metrics, err := h.kpis.Compute(r.Context(), month)
+		if err != nil {
+			writeMetricsError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{
+			"frozen":      false,
+			"month":       month.String(),
+			"definitions": kpi.Definitions,
+			"metrics":     metrics,
+		})
+		return
+	}
+
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	snapshot, err = h.kpis.Freeze(r.Context(), month, &actorID, "")
+	if err != nil {
+		writeMetricsError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"frozen": true, "snapshot": snapshot})
+}
+
+// ReissueMetricsSnapshot recalcula o mês e grava nova versão; as anteriores permanecem
+// disponíveis por ?version=. Exige motivo quando já existe retrato.
+func (h *Handler) ReissueMetricsSnapshot(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	now := time.Now().UTC()
+	month, err := kpi.ParseMonth(r.URL.Query().Get("month"), now)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if !month.Closed(now) {
+		writeMetricsError(w, kpi.ErrOpenMonth)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	if _, err := h.kpis.Latest(r.Context(), month); err == nil {
+		if strings.TrimSpace(payload.Reason) == "" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "motivo é obrigatório para reemitir o retrato", nil)
+			return
+		}
+	} else if !errors.Is(err, kpi.ErrNotFound) {
+		writeMetricsError(w, err)
+		return
+	}
+
+	snapshot, err := h.kpis.Freeze(r.Context(), month, &actorID, payload.Reason)
+	if err != nil {
+		writeMetricsError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"frozen": true, "snapshot": snapshot})
+}
+
+// ListMetricsSnapshots lista o histórico de retratos, opcionalmente filtrado por ?month=.
+func (h *Handler) ListMetricsSnapshots(w http.ResponseWriter, r *http.Request) {
+	var filter *kpi.Month
+	if raw := strings.TrimSpace(r.URL.Query().Get("month")); raw != "" {
+		month, err := kpi.ParseMonth(raw, time.Now().UTC())
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		filter = &month
+	}
+	snapshots, err := h.kpis.History(r.Context(), filter)
+	if err != nil {
+		writeMetricsError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"snapshots": snapshots})
+}
+
+func writeMetricsError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, kpi.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "retrato não encontrado", nil)
+	case errors.Is(err, kpi.ErrOpenMonth):
+		WriteError(w, http.StatusConflict, "CONFLICT", "só meses encerrados podem ser congelados", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar indicadores", nil)
+	}
+}
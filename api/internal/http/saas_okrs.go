@@ -0,0 +1,579 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/okr"
+)
+
+type objectivePayload struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Period      *string `json:"period"`
+	OwnerID     *string `json:"owner_id"`
+}
+
+type keyResultPayload struct {
+	Title        *string  `json:"title"`
+	Source       *string  `json:"source"`
+	ProjectID    *string  `json:"project_id"`
+	StartValue   *float64 `json:"start_value"`
+	TargetValue  *float64 `json:"target_value"`
+	CurrentValue *float64 `json:"current_value"`
+	Unit         *string  `json:"unit"`
+	Weight       *float64 `json:"weight"`
+	Position     *int     `json:"position"`
+}
+
+type objectiveView struct {
+	ID          uuid.UUID       `json:"id"`
+	Title       string          `json:"title"`
+	Description *string         `json:"description,omitempty"`
+	Period      string          `json:"period"`
+	OwnerID     *uuid.UUID      `json:"owner_id,omitempty"`
+	OwnerName   *string         `json:"owner_name,omitempty"`
+	Progress    float64         `json:"progress"`
+	Health      string          `json:"health"`
+	KeyResults  []keyResultView `json:"key_results"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type keyResultView struct {
+	ID           uuid.UUID  `json:"id"`
+	Title        string     `json:"title"`
+	Source       string     `json:"source"`
+	ProjectID    *uuid.UUID `json:"project_id,omitempty"`
+	ProjectName  *string    `json:"project_name,omitempty"`
+	StartValue   float64    `json:"start_value"`
+	TargetValue  float64    `json:"target_value"`
+	CurrentValue float64    `json:"current_value"`
+	Unit         *string    `json:"unit,omitempty"`
+	Weight       float64    `json:"weight"`
+	Position     int        `json:"position"`
+	TasksTotal   int        `json:"tasks_total"`
+	TasksDone    int        `json:"tasks_done"`
+	Progress     float64    `json:"progress"`
+
+	calc okr.KeyResult
+}
+
+// OKRDashboard devolve os objetivos do trimestre com o progresso consolidado para a liderança.
+func (h *Handler) OKRDashboard(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	period := okr.PeriodOf(now)
+	if raw := strings.TrimSpace(r.URL.Query().Get("period")); raw != "" {
+		parsed, err := okr.ParsePeriod(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		period = parsed
+	}
+
+	objectives, err := h.loadObjectives(r.Context(), period, nil, now)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar OKRs", nil)
+		return
+	}
+
+	health := map[string]int{okr.HealthOnTrack: 0, okr.HealthAtRisk: 0, okr.HealthOffTrack: 0, okr.HealthDone: 0}
+	total := 0.0
+	for _, obj := range objectives {
+		health[obj.Health]++
+		total += obj.Progress
+	}
+	average := 0.0
+	if len(objectives) > 0 {
+		average = total / float64(len(objectives))
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"period":     period.String(),
+		"starts_on":  period.Start(),
+		"ends_on":    period.End(),
+		"elapsed":    period.Elapsed(now),
+		"progress":   average,
+		"health":     health,
+		"objectives": objectives,
+	})
+}
+
+// CreateObjective cadastra um objetivo trimestral.
+func (h *Handler) CreateObjective(w http.ResponseWriter, r *http.Request) {
+	var payload objectivePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	title := ""
+	if payload.Title != nil {
+		title = strings.TrimSpace(*payload.Title)
+	}
+	if title == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "título é obrigatório", nil)
+		return
+	}
+
+	period := okr.PeriodOf(time.Now().UTC())
+	if payload.Period != nil && strings.TrimSpace(*payload.Period) != "" {
+		parsed, err := okr.ParsePeriod(*payload.Period)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		period = parsed
+	}
+
+	ownerID, err := optionalUUID(payload.OwnerID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "owner_id inválido", nil)
+		return
+	}
+
+	creatorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	const insert = `
+        INSERT INTO saas_objectives (title, description, period, owner_id, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+	var id uuid.UUID
+	if err := h.pool.QueryRow(r.Context(), insert, title, trimmedOrNil(payload.Description), period.String(), ownerID, creatorID).Scan(&id); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível criar objetivo", nil)
+		return
+	}
+
+	h.writeObjective(w, r, http.StatusCreated, id)
+}
+
+// UpdateObjective altera título, descrição, período ou responsável do objetivo.
+func (h *Handler) UpdateObjective(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var payload objectivePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	setParts := make([]string, 0, 4)
+	args := make([]any, 0, 5)
+	add := func(column string, value any) {
+		args = append(args, value)
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if payload.Title != nil {
+		title := strings.TrimSpace(*payload.Title)
+		if title == "" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "título inválido", nil)
+			return
+		}
+		add("title", title)
+	}
+	if payload.Description != nil {
+		add("description", trimmedOrNil(payload.Description))
+	}
+	if payload.Period != nil {
+		period, err := okr.ParsePeriod(*payload.Period)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		add("period", period.String())
+	}
+	if payload.OwnerID != nil {
+		ownerID, err := optionalUUID(payload.OwnerID)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "owner_id inválido", nil)
+			return
+		}
+		add("owner_id", ownerID)
+	}
+
+	if len(setParts) == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "nenhum campo para atualizar", nil)
+		return
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE saas_objectives SET %s, updated_at = now() WHERE id = $%d", strings.Join(setParts, ", "), len(args))
+	tag, err := h.pool.Exec(r.Context(), query, args...)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar objetivo", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "objetivo não encontrado", nil)
+		return
+	}
+
+	h.writeObjective(w, r, http.StatusOK, id)
+}
+
+// DeleteObjective remove o objetivo e seus resultados-chave.
+func (h *Handler) DeleteObjective(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), "DELETE FROM saas_objectives WHERE id = $1", id)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover objetivo", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "objetivo não encontrado", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateKeyResult adiciona um resultado-chave ao objetivo.
+func (h *Handler) CreateKeyResult(w http.ResponseWriter, r *http.Request) {
+	objectiveID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var payload keyResultPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	title := ""
+	if payload.Title != nil {
+		title = strings.TrimSpace(*payload.Title)
+	}
+	if title == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "título é obrigatório", nil)
+		return
+	}
+
+	source := okr.SourceManual
+	if payload.Source != nil && strings.TrimSpace(*payload.Source) != "" {
+		source = strings.ToLower(strings.TrimSpace(*payload.Source))
+	}
+	if source != okr.SourceManual && source != okr.SourceProject {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "origem inválida", map[string]any{"allowed": []string{okr.SourceManual, okr.SourceProject}})
+		return
+	}
+
+	projectID, err := optionalUUID(payload.ProjectID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "project_id inválido", nil)
+		return
+	}
+	if source == okr.SourceProject && projectID == nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "project_id é obrigatório para resultados ligados a projeto", nil)
+		return
+	}
+
+	startValue := floatOr(payload.StartValue, 0)
+	targetValue := floatOr(payload.TargetValue, 100)
+	currentValue := floatOr(payload.CurrentValue, startValue)
+	weight := floatOr(payload.Weight, 1)
+	if weight <= 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "peso deve ser positivo", nil)
+		return
+	}
+	position := 0
+	if payload.Position != nil {
+		position = *payload.Position
+	}
+
+	const insert = `
+        INSERT INTO saas_key_results (objective_id, title, source, project_id, start_value, target_value, current_value, unit, weight, position)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+        WHERE EXISTS (SELECT 1 FROM saas_objectives WHERE id = $1)
+        RETURNING id
+    `
+	var id uuid.UUID
+	if err := h.pool.QueryRow(r.Context(), insert, objectiveID, title, source, projectID, startValue, targetValue,
+		currentValue, trimmedOrNil(payload.Unit), weight, position).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "objetivo não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível criar resultado-chave", nil)
+		return
+	}
+
+	h.touchObjective(r.Context(), objectiveID)
+	h.writeObjective(w, r, http.StatusCreated, objectiveID)
+}
+
+// UpdateKeyResult altera um resultado-chave; em geral usado para registrar o valor atual.
+func (h *Handler) UpdateKeyResult(w http.ResponseWriter, r *http.Request) {
+	objectiveID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	krID, err := parseUUIDParam(r, "krID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "resultado-chave inválido", nil)
+		return
+	}
+
+	var payload keyResultPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	setParts := make([]string, 0, 9)
+	args := make([]any, 0, 11)
+	add := func(column string, value any) {
+		args = append(args, value)
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if payload.Title != nil {
+		title := strings.TrimSpace(*payload.Title)
+		if title == "" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "título inválido", nil)
+			return
+		}
+		add("title", title)
+	}
+	if payload.Source != nil {
+		source := strings.ToLower(strings.TrimSpace(*payload.Source))
+		if source != okr.SourceManual && source != okr.SourceProject {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "origem inválida", map[string]any{"allowed": []string{okr.SourceManual, okr.SourceProject}})
+			return
+		}
+		add("source", source)
+	}
+	if payload.ProjectID != nil {
+		projectID, err := optionalUUID(payload.ProjectID)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "project_id inválido", nil)
+			return
+		}
+		add("project_id", projectID)
+	}
+	if payload.StartValue != nil {
+		add("start_value", *payload.StartValue)
+	}
+	if payload.TargetValue != nil {
+		add("target_value", *payload.TargetValue)
+	}
+	if payload.CurrentValue != nil {
+		add("current_value", *payload.CurrentValue)
+	}
+	if payload.Unit != nil {
+		add("unit", trimmedOrNil(payload.Unit))
+	}
+	if payload.Weight != nil {
+		if *payload.Weight <= 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "peso deve ser positivo", nil)
+			return
+		}
+		add("weight", *payload.Weight)
+	}
+	if payload.Position != nil {
+		add("position", *payload.Position)
+	}
+
+	if len(setParts) == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "nenhum campo para atualizar", nil)
+		return
+	}
+
+	args = append(args, objectiveID, krID)
+	query := fmt.Sprintf("UPDATE saas_key_results SET %s, updated_at = now() WHERE objective_id = $%d AND id = $%d",
+		strings.Join(setParts, ", "), len(args)-1, len(args))
+	tag, err := h.pool.Exec(r.Context(), query, args...)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar resultado-chave", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "resultado-chave não encontrado", nil)
+		return
+	}
+
+	h.touchObjective(r.Context(), objectiveID)
+	h.writeObjective(w, r, http.StatusOK, objectiveID)
+}
+
+// DeleteKeyResult remove um resultado-chave do objetivo.
+func (h *Handler) DeleteKeyResult(w http.ResponseWriter, r *http.Request) {
+	objectiveID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	krID, err := parseUUIDParam(r, "krID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "resultado-chave inválido", nil)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), "DELETE FROM saas_key_results WHERE objective_id = $1 AND id = $2", objectiveID, krID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover resultado-chave", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "resultado-chave não encontrado", nil)
+		return
+	}
+
+	h.touchObjective(r.Context(), objectiveID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeObjective(w http.ResponseWriter, r *http.Request, status int, id uuid.UUID) {
+	objectives, err := h.loadObjectives(r.Context(), okr.Period{}, &id, time.Now().UTC())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar objetivo", nil)
+		return
+	}
+	if len(objectives) == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "objetivo não encontrado", nil)
+		return
+	}
+	WriteJSON(w, status, map[string]any{"objective": objectives[0]})
+}
+
+func (h *Handler) touchObjective(ctx context.Context, id uuid.UUID) {
+	_, _ = h.pool.Exec(ctx, "UPDATE saas_objectives SET updated_at = now() WHERE id = $1", id)
+}
+
+// loadObjectives carrega objetivos de um período (ou um objetivo específico) com os resultados-chave
+// e o progresso consolidado a partir das tarefas dos projetos vinculados.
+func (h *Handler) loadObjectives(ctx context.Context, period okr.Period, id *uuid.UUID, now time.Time) ([]objectiveView, error) {
+	query := `
+        SELECT o.id, o.title, o.description, o.period, o.owner_id, u.name, o.updated_at
+        FROM saas_objectives o
+        LEFT JOIN saas_users u ON u.id = o.owner_id
+    `
+	var args []any
+	if id != nil {
+		query += " WHERE o.id = $1"
+		args = append(args, *id)
+	} else {
+		query += " WHERE o.period = $1"
+		args = append(args, period.String())
+	}
+	query += " ORDER BY o.created_at"
+
+	rows, err := h.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	objectives := make([]objectiveView, 0)
+	index := make(map[uuid.UUID]int)
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var obj objectiveView
+		if err := rows.Scan(&obj.ID, &obj.Title, &obj.Description, &obj.Period, &obj.OwnerID, &obj.OwnerName, &obj.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		obj.KeyResults = []keyResultView{}
+		index[obj.ID] = len(objectives)
+		ids = append(ids, obj.ID)
+		objectives = append(objectives, obj)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return objectives, nil
+	}
+
+	const krQuery = `
+        SELECT k.objective_id, k.id, k.title, k.source, k.project_id, p.name,
+               k.start_value::float8, k.target_value::float8, k.current_value::float8, k.unit, k.weight::float8, k.position,
+               COALESCE(t.total, 0), COALESCE(t.done, 0)
+        FROM saas_key_results k
+        LEFT JOIN saas_projects p ON p.id = k.project_id
+        LEFT JOIN LATERAL (
+            SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE status = 'done') AS done
+            FROM saas_project_tasks
+            WHERE project_id = k.project_id
+        ) t ON TRUE
+        WHERE k.objective_id = ANY($1)
+        ORDER BY k.position, k.created_at
+    `
+	krRows, err := h.pool.Query(ctx, krQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer krRows.Close()
+
+	for krRows.Next() {
+		var objectiveID uuid.UUID
+		var kr keyResultView
+		if err := krRows.Scan(&objectiveID, &kr.ID, &kr.Title, &kr.Source, &kr.ProjectID, &kr.ProjectName,
+			&kr.StartValue, &kr.TargetValue, &kr.CurrentValue, &kr.Unit, &kr.Weight, &kr.Position,
+			&kr.TasksTotal, &kr.TasksDone); err != nil {
+			return nil, err
+		}
+		kr.calc = okr.KeyResult{
+			Source:       kr.Source,
+			StartValue:   kr.StartValue,
+			TargetValue:  kr.TargetValue,
+			CurrentValue: kr.CurrentValue,
+			Weight:       kr.Weight,
+			TasksTotal:   kr.TasksTotal,
+			TasksDone:    kr.TasksDone,
+		}
+		kr.Progress = kr.calc.Progress()
+		if i, ok := index[objectiveID]; ok {
+			objectives[i].KeyResults = append(objectives[i].KeyResults, kr)
+		}
+	}
+	if err := krRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range objectives {
+		calc := make([]okr.KeyResult, len(objectives[i].KeyResults))
+		for j, kr := range objectives[i].KeyResults {
+			calc[j] = kr.calc
+		}
+		objectives[i].Progress = okr.Rollup(calc)
+		elapsed := 0.0
+		if p, err := okr.ParsePeriod(objectives[i].Period); err == nil {
+			elapsed = p.Elapsed(now)
+		}
+		objectives[i].Health = okr.Health(objectives[i].Progress, elapsed)
+	}
+
+	return objectives, nil
+}
+
+func floatOr(value *float64, def float64) float64 {
+	if value == nil {
+		return def
+	}
+	return *value
+}
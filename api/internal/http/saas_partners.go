@@ -0,0 +1,571 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// commissionCategory identifica no financeiro os lançamentos gerados por comissões de parceiros.
+const commissionCategory = "comissao_parceiro"
+
+type partnerPayload struct {
+	Name        *string  `json:"name"`
+	Document    *string  `json:"document"`
+	Email       *string  `json:"email"`
+	Phone       *string  `json:"phone"`
+	DefaultRate *float64 `json:"default_rate"`
+	Active      *bool    `json:"active"`
+}
+
+type partnerView struct {
+	ID          uuid.UUID             `json:"id"`
+	Name        string                `json:"name"`
+	Document    *string               `json:"document,omitempty"`
+	Email       *string               `json:"email,omitempty"`
+	Phone       *string               `json:"phone,omitempty"`
+	DefaultRate float64               `json:"default_rate"`
+	Active      bool                  `json:"active"`
+	Referrals   []partnerReferralView `json:"referrals"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+type partnerReferralView struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	TenantName string    `json:"tenant_name"`
+	Rate       *float64  `json:"rate,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+	Months     int       `json:"months"`
+}
+
+type partnerCommissionView struct {
+	ID             uuid.UUID  `json:"id"`
+	TenantID       uuid.UUID  `json:"tenant_id"`
+	TenantName     string     `json:"tenant_name"`
+	InvoiceID      uuid.UUID  `json:"invoice_id"`
+	ReferenceMonth time.Time  `json:"reference_month"`
+	InvoiceAmount  float64    `json:"invoice_amount"`
+	Rate           float64    `json:"rate"`
+	Amount         float64    `json:"amount"`
+	FinanceEntryID *uuid.UUID `json:"finance_entry_id,omitempty"`
+	Paid           bool       `json:"paid"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ListPartners lista parceiros comerciais com os tenants indicados.
+func (h *Handler) ListPartners(w http.ResponseWriter, r *http.Request) {
+	partners, err := h.loadPartners(r.Context(), nil)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar parceiros", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"partners": partners})
+}
+
+// CreatePartner cadastra um parceiro comercial.
+func (h *Handler) CreatePartner(w http.ResponseWriter, r *http.Request) {
+	var payload partnerPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if payload.Name == nil || strings.TrimSpace(*payload.Name) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "nome é obrigatório", nil)
+		return
+	}
+	rate := 10.0
+	if payload.DefaultRate != nil {
+		rate = *payload.DefaultRate
+	}
+	if rate < 0 || rate > 100 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "default_rate deve estar entre 0 e 100", nil)
+		return
+	}
+
+	var id uuid.UUID
+	err := h.pool.QueryRow(r.Context(), `
+        INSERT INTO saas_partners (name, document, email, phone, default_rate)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `, strings.TrimSpace(*payload.Name), trimmedOrNil(payload.Document), trimmedOrNil(payload.Email), trimmedOrNil(payload.Phone), rate).Scan(&id)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível cadastrar parceiro", nil)
+		return
+	}
+
+	h.writePartner(w, r, id, http.StatusCreated)
+}
+
+// UpdatePartner altera dados cadastrais, taxa padrão ou situação do parceiro.
+func (h *Handler) UpdatePartner(w http.ResponseWriter, r *http.Request) {
+	partnerID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload partnerPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	if payload.Name != nil && strings.TrimSpace(*payload.Name) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "nome é obrigatório", nil)
+		return
+	}
+	if payload.DefaultRate != nil && (*payload.DefaultRate < 0 || *payload.DefaultRate > 100) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "default_rate deve estar entre 0 e 100", nil)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), `
+        UPDATE saas_partners
+        SET name = COALESCE($2, name),
+            document = CASE WHEN $3::boolean THEN $4 ELSE document END,
+            email = CASE WHEN $5::boolean THEN $6 ELSE email END,
+            phone = CASE WHEN $7::boolean THEN $8 ELSE phone END,
+            default_rate = COALESCE($9, default_rate),
+            active = COALESCE($10, active),
+            updated_at = now()
+        WHERE id = $1
+    `, partnerID, trimmedOrNil(payload.Name),
+		payload.Document != nil, trimmedOrNil(payload.Document),
+		payload.Email != nil, trimmedOrNil(payload.Email),
+		payload.Phone != nil, trimmedOrNil(payload.Phone),
+		payload.DefaultRate, payload.Active)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar parceiro", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "parceiro não encontrado", nil)
+		return
+	}
+
+	h.writePartner(w, r, partnerID, http.StatusOK)
+}
+
+// SavePartnerReferral vincula um tenant ao parceiro que o indicou. A janela de comissão começa
+// em starts_at (padrão: início do contrato) e dura months meses (padrão: 12).
+func (h *Handler) SavePartnerReferral(w http.ResponseWriter, r *http.Request) {
+	partnerID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var payload struct {
+		TenantID string   `json:"tenant_id"`
+		Rate     *float64 `json:"rate"`
+		StartsAt *string  `json:"starts_at"`
+		Months   *int     `json:"months"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	tenantID, err := uuid.Parse(strings.TrimSpace(payload.TenantID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant_id inválido", nil)
+		return
+	}
+	if payload.Rate != nil && (*payload.Rate < 0 || *payload.Rate > 100) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "rate deve estar entre 0 e 100", nil)
+		return
+	}
+	months := 12
+	if payload.Months != nil {
+		months = *payload.Months
+	}
+	if months <= 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "months deve ser positivo", nil)
+		return
+	}
+	var startsAt *time.Time
+	if payload.StartsAt != nil && strings.TrimSpace(*payload.StartsAt) != "" {
+		ts, err := parseISODate(*payload.StartsAt)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "starts_at inválido", nil)
+			return
+		}
+		startsAt = &ts
+	}
+
+	_, err = h.pool.Exec(r.Context(), `
+        INSERT INTO saas_partner_referrals (tenant_id, partner_id, rate, starts_at, months)
+        SELECT t.id, $2, $3,
+               COALESCE($4::date, (SELECT c.start_date FROM saas_tenant_contracts c WHERE c.tenant_id = t.id), t.created_at::date),
+               $5
+        FROM tenants t WHERE t.id = $1
+        ON CONFLICT (tenant_id) DO UPDATE
+        SET partner_id = EXCLUDED.partner_id, rate = EXCLUDED.rate, starts_at = EXCLUDED.starts_at, months = EXCLUDED.months
+    `, tenantID, partnerID, payload.Rate, startsAt, months)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "parceiro não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar indicação", nil)
+		return
+	}
+
+	h.writePartner(w, r, partnerID, http.StatusOK)
+}
+
+// DeletePartnerReferral desfaz a indicação; comissões já geradas permanecem.
+func (h *Handler) DeletePartnerReferral(w http.ResponseWriter, r *http.Request) {
+	partnerID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	tenantID, err := parseUUIDParam(r, "tenantID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant inválido", nil)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), `DELETE FROM saas_partner_referrals WHERE partner_id = $1 AND tenant_id = $2`, partnerID, tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover indicação", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "indicação não encontrada", nil)
+		return
+	}
+
+	h.writePartner(w, r, partnerID, http.StatusOK)
+}
+
+// PartnerStatement gera o extrato de comissões do parceiro no período (?from=AAAA-MM&to=AAAA-MM),
+// em JSON ou CSV (?format=csv).
+func (h *Handler) PartnerStatement(w http.ResponseWriter, r *http.Request) {
+	partnerID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if value := strings.TrimSpace(r.URL.Query().Get("from")); value != "" {
+		if from, err = time.Parse("2006-01", value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from deve estar no formato AAAA-MM", nil)
+			return
+		}
+	}
+	if value := strings.TrimSpace(r.URL.Query().Get("to")); value != "" {
+		if to, err = time.Parse("2006-01", value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to deve estar no formato AAAA-MM", nil)
+			return
+		}
+	}
+	if to.Before(from) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+		return
+	}
+
+	partners, err := h.loadPartners(r.Context(), &partnerID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar parceiro", nil)
+		return
+	}
+	if len(partners) == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "parceiro não encontrado", nil)
+		return
+	}
+	partner := partners[0]
+
+	commissions, err := h.loadPartnerCommissions(r.Context(), partnerID, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar comissões", nil)
+		return
+	}
+
+	var total, paid float64
+	for _, c := range commissions {
+		total += c.Amount
+		if c.Paid {
+			paid += c.Amount
+		}
+	}
+
+	if strings.EqualFold(r.URL.Query().Get("format"), "csv") {
+		filename := fmt.Sprintf("extrato-comissoes-%s-%s-%s.csv", partner.ID.String()[:8], from.Format("2006-01"), to.Format("2006-01"))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"competencia", "tenant", "nota_id", "valor_nota", "taxa_pct", "comissao", "paga"})
+		for _, c := range commissions {
+			_ = cw.Write([]string{
+				c.ReferenceMonth.Format("2006-01"),
+				c.TenantName,
+				c.InvoiceID.String(),
+				strconv.FormatFloat(c.InvoiceAmount, 'f', 2, 64),
+				strconv.FormatFloat(c.Rate, 'f', 2, 64),
+				strconv.FormatFloat(c.Amount, 'f', 2, 64),
+				strconv.FormatBool(c.Paid),
+			})
+		}
+		_ = cw.Write([]string{"total", "", "", "", "", strconv.FormatFloat(util.RoundMoney(total), 'f', 2, 64), ""})
+		cw.Flush()
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"partner":     partner,
+		"from":        from.Format("2006-01"),
+		"to":          to.Format("2006-01"),
+		"commissions": commissions,
+		"total":       util.RoundMoney(total),
+		"paid":        util.RoundMoney(paid),
+		"outstanding": util.RoundMoney(total - paid),
+	})
+}
+
+// MarkTenantInvoicePaid registra o pagamento da nota e gera a comissão do parceiro, se houver.
+func (h *Handler) MarkTenantInvoicePaid(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	invoiceID, err := parseUUIDParam(r, "invoiceID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id da nota inválido", nil)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), `UPDATE saas_tenant_invoices SET status = 'paid' WHERE tenant_id = $1 AND id = $2`, tenantID, invoiceID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar nota", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "nota não encontrada", nil)
+		return
+	}
+
+	var actorID *uuid.UUID
+	if subject, err := h.subjectUUID(r); err == nil {
+		actorID = &subject
+	}
+	commission, err := h.accrueInvoiceCommission(r.Context(), invoiceID, actorID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "nota paga, mas não foi possível gerar comissão", nil)
+		return
+	}
+
+	contract, err := h.fetchTenantContract(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar contrato", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"contract": contract, "commission": commission})
+}
+
+// accrueInvoiceCommission gera, uma única vez por nota paga, a comissão do parceiro indicador
+// quando a competência cai na janela da indicação, lançando a despesa no financeiro.
+func (h *Handler) accrueInvoiceCommission(ctx context.Context, invoiceID uuid.UUID, actorID *uuid.UUID) (*partnerCommissionView, error) {
+	var (
+		view        partnerCommissionView
+		partnerID   uuid.UUID
+		partnerName string
+	)
+	err := h.pool.QueryRow(ctx, `
+        SELECT i.tenant_id, t.display_name, i.reference_month, i.amount::float8,
+               COALESCE(ref.rate, p.default_rate)::float8, p.id, p.name
+        FROM saas_tenant_invoices i
+        JOIN tenants t ON t.id = i.tenant_id
+        JOIN saas_partner_referrals ref ON ref.tenant_id = i.tenant_id
+        JOIN saas_partners p ON p.id = ref.partner_id
+        WHERE i.id = $1 AND i.status = 'paid' AND i.amount IS NOT NULL AND p.active
+          AND i.reference_month >= ref.starts_at
+          AND i.reference_month < ref.starts_at + make_interval(months => ref.months)
+    `, invoiceID).Scan(&view.TenantID, &view.TenantName, &view.ReferenceMonth, &view.InvoiceAmount, &view.Rate, &partnerID, &partnerName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	view.InvoiceID = invoiceID
+	view.Amount = util.RoundMoney(view.InvoiceAmount * view.Rate / 100)
+	if view.Amount <= 0 {
+		return nil, nil
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+        INSERT INTO saas_partner_commissions (partner_id, tenant_id, invoice_id, reference_month, invoice_amount, rate, amount)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (invoice_id) DO NOTHING
+        RETURNING id, created_at
+    `, partnerID, view.TenantID, invoiceID, view.ReferenceMonth, view.InvoiceAmount, view.Rate, view.Amount).Scan(&view.ID, &view.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Nota já comissionada (ex.: marcada como paga duas vezes).
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	approvalStatus := h.financeApprovalStatusFor(view.Amount)
+	description := fmt.Sprintf("Comissão %s — %s (%s)", partnerName, view.TenantName, view.ReferenceMonth.Format("01/2006"))
+	var entryID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO saas_finance_entries (tenant_id, entry_type, category, description, amount, responsible, created_by, updated_by, approval_status)
+        VALUES ($1, 'expense', $2, $3, $4, $5, $6, $6, $7)
+        RETURNING id
+    `, view.TenantID, commissionCategory, description, view.Amount, partnerName, actorID, approvalStatus).Scan(&entryID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE saas_partner_commissions SET finance_entry_id = $2 WHERE id = $1`, view.ID, entryID); err != nil {
+		return nil, err
+	}
+	view.FinanceEntryID = &entryID
+
+	if approvalStatus == financeApprovalPending && actorID != nil {
+		entry := financeEntryView{ID: entryID, AmountBRL: view.Amount}
+		if err := recordFinanceApproval(ctx, tx, entry, financeDecisionRequested, nil, *actorID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if approvalStatus == financeApprovalPending {
+		if entry, err := h.fetchFinanceEntry(ctx, entryID); err == nil {
+			h.notifyFinanceApproval(entry, financeDecisionRequested)
+		}
+	}
+	log.Info().Str("invoice_id", invoiceID.String()).Str("partner_id", partnerID.String()).Float64("amount", view.Amount).Msg("comissão de parceiro gerada")
+
+	return &view, nil
+}
+
+func (h *Handler) writePartner(w http.ResponseWriter, r *http.Request, partnerID uuid.UUID, status int) {
+	partners, err := h.loadPartners(r.Context(), &partnerID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar parceiro", nil)
+		return
+	}
+	if len(partners) == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "parceiro não encontrado", nil)
+		return
+	}
+	WriteJSON(w, status, map[string]any{"partner": partners[0]})
+}
+
+func (h *Handler) loadPartners(ctx context.Context, partnerID *uuid.UUID) ([]partnerView, error) {
+	rows, err := h.pool.Query(ctx, `
+        SELECT id, name, document, email, phone, default_rate::float8, active, created_at
+        FROM saas_partners
+        WHERE $1::uuid IS NULL OR id = $1
+        ORDER BY name
+    `, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partners := make([]partnerView, 0)
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var p partnerView
+		if err := rows.Scan(&p.ID, &p.Name, &p.Document, &p.Email, &p.Phone, &p.DefaultRate, &p.Active, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Referrals = []partnerReferralView{}
+		index[p.ID] = len(partners)
+		partners = append(partners, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(partners) == 0 {
+		return partners, nil
+	}
+
+	refRows, err := h.pool.Query(ctx, `
+        SELECT ref.partner_id, ref.tenant_id, t.display_name, ref.rate::float8, ref.starts_at, ref.months
+        FROM saas_partner_referrals ref
+        JOIN tenants t ON t.id = ref.tenant_id
+        WHERE $1::uuid IS NULL OR ref.partner_id = $1
+        ORDER BY t.display_name
+    `, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer refRows.Close()
+
+	for refRows.Next() {
+		var owner uuid.UUID
+		var ref partnerReferralView
+		if err := refRows.Scan(&owner, &ref.TenantID, &ref.TenantName, &ref.Rate, &ref.StartsAt, &ref.Months); err != nil {
+			return nil, err
+		}
+		if i, ok := index[owner]; ok {
+			partners[i].Referrals = append(partners[i].Referrals, ref)
+		}
+	}
+	return partners, refRows.Err()
+}
+
+func (h *Handler) loadPartnerCommissions(ctx context.Context, partnerID uuid.UUID, from, to time.Time) ([]partnerCommissionView, error) {
+	rows, err := h.pool.Query(ctx, `
+        SELECT c.id, c.tenant_id, t.display_name, c.invoice_id, c.reference_month,
+               c.invoice_amount::float8, c.rate::float8, c.amount::float8, c.finance_entry_id,
+               COALESCE(f.paid, FALSE), c.created_at
+        FROM saas_partner_commissions c
+        JOIN tenants t ON t.id = c.tenant_id
+        LEFT JOIN saas_finance_entries f ON f.id = c.finance_entry_id
+        WHERE c.partner_id = $1 AND c.reference_month BETWEEN $2 AND $3
+        ORDER BY c.reference_month, t.display_name
+    `, partnerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commissions := make([]partnerCommissionView, 0)
+	for rows.Next() {
+		var c partnerCommissionView
+		if err := rows.Scan(&c.ID, &c.TenantID, &c.TenantName, &c.InvoiceID, &c.ReferenceMonth,
+			&c.InvoiceAmount, &c.Rate, &c.Amount, &c.FinanceEntryID, &c.Paid, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		commissions = append(commissions, c)
+	}
+	return commissions, rows.Err()
+}
+
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	return optionalString(*value)
+}
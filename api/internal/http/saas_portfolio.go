@@ -0,0 +1,253 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/saas"
+	"github.com/gestaozabele/municipio/internal/support"
+)
+
+// saasPortfolio descreve quais tenants o usuário SaaS atual enxerga. Sem carteira atribuída
+// (ou para SAAS_OWNER) o acesso não é restrito.
+type saasPortfolio struct {
+	scoped  bool
+	tenants map[uuid.UUID]struct{}
+}
+
+func (p saasPortfolio) Allows(tenantID uuid.UUID) bool {
+	if !p.scoped {
+		return true
+	}
+	_, ok := p.tenants[tenantID]
+	return ok
+}
+
+func (p saasPortfolio) IDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(p.tenants))
+	for id := range p.tenants {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (h *Handler) loadSaaSPortfolio(r *http.Request) (saasPortfolio, error) {
+	if hasSaaSRole(r, "SAAS_OWNER") {
+		return saasPortfolio{}, nil
+	}
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		return saasPortfolio{}, err
+	}
+	ids, err := h.portfolioTenants(r.Context(), userID)
+	if err != nil || len(ids) == 0 {
+		return saasPortfolio{}, err
+	}
+	portfolio := saasPortfolio{scoped: true, tenants: make(map[uuid.UUID]struct{}, len(ids))}
+	for _, id := range ids {
+		portfolio.tenants[id] = struct{}{}
+	}
+	return portfolio, nil
+}
+
+func (h *Handler) portfolioTenants(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := h.pool.Query(ctx, `SELECT tenant_id FROM saas_user_portfolios WHERE saas_user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// requirePortfolioTenant bloqueia rotas /tenants/{id}/... fora da carteira do usuário.
+func (h *Handler) requirePortfolioTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+			return
+		}
+		portfolio, err := h.loadSaaSPortfolio(r)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+			return
+		}
+		if !portfolio.Allows(tenantID) {
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", "tenant fora da sua carteira", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requirePortfolioTicket bloqueia rotas /tickets/{id}/... de tenants fora da carteira do usuário.
+func (h *Handler) requirePortfolioTicket(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.support == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ticketID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+			return
+		}
+		portfolio, err := h.loadSaaSPortfolio(r)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+			return
+		}
+		if portfolio.scoped {
+			ticket, err := h.support.GetTicket(r.Context(), ticketID)
+			if err != nil {
+				if errors.Is(err, support.ErrNotFound) {
+					WriteError(w, http.StatusNotFound, "NOT_FOUND", "ticket não encontrado", nil)
+					return
+				}
+				WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar ticket", nil)
+				return
+			}
+			// 404 para não revelar chamados de outras carteiras.
+			if !portfolio.Allows(ticket.TenantID) {
+				WriteError(w, http.StatusNotFound, "NOT_FOUND", "ticket não encontrado", nil)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetSaaSUserPortfolio lista os tenants atribuídos a um usuário de suporte/financeiro.
+func (h *Handler) GetSaaSUserPortfolio(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if _, err := h.portfolioUserRole(r.Context(), userID); err != nil {
+		h.writePortfolioError(w, err)
+		return
+	}
+
+	ids, err := h.portfolioTenants(r.Context(), userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"user_id": userID, "tenant_ids": ids})
+}
+
+// UpdateSaaSUserPortfolio substitui a carteira de tenants do usuário. Lista vazia remove a
+// restrição.
+func (h *Handler) UpdateSaaSUserPortfolio(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var payload struct {
+		TenantIDs []uuid.UUID `json:"tenant_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	role, err := h.portfolioUserRole(r.Context(), userID)
+	if err != nil {
+		h.writePortfolioError(w, err)
+		return
+	}
+	if role != saas.RoleSupport && role != saas.RoleFinance {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "carteiras valem apenas para usuários de suporte ou financeiro", nil)
+		return
+	}
+
+	var assignedBy *uuid.UUID
+	if actorID, err := h.subjectUUID(r); err == nil {
+		assignedBy = &actorID
+	}
+
+	ctx := r.Context()
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar carteira", nil)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM saas_user_portfolios WHERE saas_user_id = $1`, userID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar carteira", nil)
+		return
+	}
+	if len(payload.TenantIDs) > 0 {
+		tag, err := tx.Exec(ctx, `
+            INSERT INTO saas_user_portfolios (saas_user_id, tenant_id, assigned_by)
+            SELECT $1, t.id, $3 FROM tenants t WHERE t.id = ANY($2)
+        `, userID, payload.TenantIDs, assignedBy)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar carteira", nil)
+			return
+		}
+		if int(tag.RowsAffected()) != len(uniqueUUIDs(payload.TenantIDs)) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant_ids contém tenants inexistentes", nil)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar carteira", nil)
+		return
+	}
+
+	ids, err := h.portfolioTenants(ctx, userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"user_id": userID, "tenant_ids": ids})
+}
+
+var errPortfolioUserNotFound = errors.New("usuário não encontrado")
+
+func (h *Handler) portfolioUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	var role string
+	err := h.pool.QueryRow(ctx, `SELECT role FROM saas_users WHERE id = $1`, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errPortfolioUserNotFound
+	}
+	return role, err
+}
+
+func (h *Handler) writePortfolioError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errPortfolioUserNotFound) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+		return
+	}
+	WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+}
+
+func uniqueUUIDs(ids []uuid.UUID) map[uuid.UUID]struct{} {
+	set := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/monitor"
+)
+
+type privilegedActionView struct {
+	ID          uuid.UUID       `json:"id"`
+	Entity      string          `json:"entity"`
+	Action      string          `json:"action"`
+	SubjectID   uuid.UUID       `json:"subject_id"`
+	SubjectName *string         `json:"subject_name,omitempty"`
+	ScopeID     *uuid.UUID      `json:"scope_id,omitempty"`
+	ScopeName   *string         `json:"scope_name,omitempty"`
+	ActorID     *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorName   *string         `json:"actor_name,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	Escalation  bool            `json:"escalation"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+var privilegedEntities = []string{"SAAS_USER", "SECRETARIA_PAPEL", "PROFESSOR_TURMA", "ESCOLA_GESTOR"}
+
+// ListPrivilegedActions consulta o log de mudanças de papéis e vínculos com valores antes/depois.
+func (h *Handler) ListPrivilegedActions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	conditions := make([]string, 0, 6)
+	args := make([]any, 0, 7)
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if entity := strings.ToUpper(strings.TrimSpace(query.Get("entity"))); entity != "" {
+		if !containsString(privilegedEntities, entity) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "entity inválida", nil)
+			return
+		}
+		add("pa.entity = $%d", entity)
+	}
+	for _, param := range []struct{ name, column string }{{"subject_id", "pa.subject_id"}, {"actor_id", "pa.actor_id"}} {
+		raw := strings.TrimSpace(query.Get(param.name))
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", param.name+" inválido", nil)
+			return
+		}
+		add(param.column+" = $%d", id)
+	}
+	if strings.EqualFold(query.Get("escalation"), "true") {
+		conditions = append(conditions, "pa.escalation")
+	}
+	if raw := strings.TrimSpace(query.Get("from")); raw != "" {
+		from, err := parseISODate(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		add("pa.created_at >= $%d", from)
+	}
+	if raw := strings.TrimSpace(query.Get("to")); raw != "" {
+		to, err := parseISODate(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		add("pa.created_at < $%d", to.Add(24*time.Hour))
+	}
+
+	limit := 100
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit deve estar entre 1 e 500", nil)
+			return
+		}
+		limit = parsed
+	}
+	args = append(args, limit)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := h.pool.Query(r.Context(), fmt.Sprintf(`
+		SELECT pa.id, pa.entity, pa.action, pa.subject_id,
+		       COALESCE(su.name, u.nome),
+		       pa.scope_id,
+		       CASE pa.entity
+		           WHEN 'SECRETARIA_PAPEL' THEN (SELECT nome FROM secretarias WHERE id = pa.scope_id)
+		           WHEN 'PROFESSOR_TURMA' THEN (SELECT nome FROM turmas WHERE id = pa.scope_id)
+		           WHEN 'ESCOLA_GESTOR' THEN (SELECT nome FROM escolas WHERE id = pa.scope_id)
+		       END,
+		       pa.actor_id, COALESCE(asu.name, au.nome),
+		       pa.before, pa.after, pa.escalation, pa.created_at
+		FROM saas_privileged_actions pa
+		LEFT JOIN saas_users su ON pa.entity = 'SAAS_USER' AND su.id = pa.subject_id
+		LEFT JOIN usuarios u ON pa.entity <> 'SAAS_USER' AND u.id = pa.subject_id
+		LEFT JOIN saas_users asu ON asu.id = pa.actor_id
+		LEFT JOIN usuarios au ON asu.id IS NULL AND au.id = pa.actor_id
+		%s
+		ORDER BY pa.created_at DESC
+		LIMIT $%d
+	`, where, len(args)), args...)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar ações privilegiadas", nil)
+		return
+	}
+	defer rows.Close()
+
+	actions := make([]privilegedActionView, 0)
+	for rows.Next() {
+		var item privilegedActionView
+		var before, after []byte
+		if err := rows.Scan(&item.ID, &item.Entity, &item.Action, &item.SubjectID, &item.SubjectName, &item.ScopeID, &item.ScopeName,
+			&item.ActorID, &item.ActorName, &before, &after, &item.Escalation, &item.CreatedAt); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar ações privilegiadas", nil)
+			return
+		}
+		item.Before = before
+		item.After = after
+		actions = append(actions, item)
+	}
+	if err := rows.Err(); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar ações privilegiadas", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"privileged_actions": actions})
+}
+
+// notifyOwnerGrants avisa sobre concessões de SAAS_OWNER ainda não notificadas, sem bloquear a requisição.
+// Marcar notified_at no mesmo UPDATE evita avisos duplicados entre réplicas.
+func (h *Handler) notifyOwnerGrants() {
+	if h.notifier == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		rows, err := h.pool.Query(ctx, `
+			UPDATE saas_privileged_actions pa
+			SET notified_at = now()
+			FROM saas_users su
+			WHERE su.id = pa.subject_id
+			  AND pa.entity = 'SAAS_USER' AND pa.escalation AND pa.notified_at IS NULL
+			  AND pa.after->>'role' = 'owner'
+			RETURNING su.name, su.email,
+			          (SELECT name FROM saas_users WHERE id = pa.actor_id)
+		`)
+		if err != nil {
+			log.Warn().Err(err).Msg("privileges: falha ao buscar concessões de owner")
+			return
+		}
+
+		var messages []monitor.AlertMessage
+		for rows.Next() {
+			var name, email string
+			var actor *string
+			if err := rows.Scan(&name, &email, &actor); err != nil {
+				rows.Close()
+				log.Warn().Err(err).Msg("privileges: falha ao ler concessão de owner")
+				return
+			}
+			by := "autor desconhecido"
+			if actor != nil {
+				by = *actor
+			}
+			messages = append(messages, monitor.AlertMessage{
+				Title:    "Papel SAAS_OWNER concedido",
+				Text:     fmt.Sprintf("%s <%s> recebeu acesso de owner (por %s).", name, email, by),
+				Severity: "warning",
+			})
+		}
+		rows.Close()
+
+		for _, msg := range messages {
+			if err := h.notifier.Notify(ctx, msg); err != nil {
+				log.Warn().Err(err).Msg("privileges: falha ao notificar concessão de owner")
+			}
+		}
+	}()
+}
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/procurement"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// ListProcurementDocuments devolve o cofre da licitação do tenant com o checklist obrigatório.
+func (h *Handler) ListProcurementDocuments(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	category := ""
+	if value := strings.TrimSpace(r.URL.Query().Get("category")); value != "" {
+		if category, err = procurement.NormalizeCategory(value); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+	}
+
+	docs, err := h.procurement.List(r.Context(), tenantID, category)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar documentos", nil)
+		return
+	}
+	for i := range docs {
+		path := fileDownloadPath(docs[i].ID)
+		docs[i].DownloadURL = &path
+	}
+
+	// O checklist sempre considera o cofre inteiro, mesmo com filtro de categoria.
+	all := docs
+	if category != "" {
+		if all, err = h.procurement.List(r.Context(), tenantID, ""); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar documentos", nil)
+			return
+		}
+	}
+	checklist, complete := procurement.Checklist(all, time.Now(), h.cfg.Procurement.ExpiryWindow)
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"documents":  docs,
+		"checklist":  checklist,
+		"complete":   complete,
+		"categories": procurement.Categories,
+	})
+}
+
+// UploadProcurementDocument envia edital, ata, empenho ou outro documento da licitação ao cofre.
+func (h *Handler) UploadProcurementDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	if err := r.ParseMultipartForm(20 << 20); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "formulário inválido", nil)
+		return
+	}
+	fileHeader, err := getFirstFile(r.MultipartForm, "file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	category, err := procurement.NormalizeCategory(r.FormValue("category"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		title = strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+	}
+
+	input := procurement.DocumentInput{
+		TenantID: tenantID,
+		Category: category,
+		Title:    title,
+		Number:   optionalString(r.FormValue("number")),
+		Notes:    optionalString(r.FormValue("notes")),
+	}
+	if value := strings.TrimSpace(r.FormValue("amount")); value != "" {
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil || amount < 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "valor inválido", nil)
+			return
+		}
+		input.Amount = &amount
+	}
+	for field, target := range map[string]**time.Time{"issued_at": &input.IssuedAt, "expires_at": &input.ExpiresAt} {
+		if value := strings.TrimSpace(r.FormValue(field)); value != "" {
+			ts, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "VALIDATION", field+" deve estar no formato AAAA-MM-DD", nil)
+				return
+			}
+			*target = &ts
+		}
+	}
+	if category == procurement.CategoryEmpenho && input.ExpiresAt == nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "empenho exige expires_at para o alerta de vencimento", nil)
+		return
+	}
+
+	if h.storage == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
+		return
+	}
+	switch h.storage.(type) {
+	case storage.NoopUploader, *storage.NoopUploader:
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "armazenamento indisponível", nil)
+		return
+	}
+
+	data, contentType, err := readMultipartFile(fileHeader, 20<<20)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if ext == "" {
+		ext = ".pdf"
+	}
+	key := fmt.Sprintf("contracts/%s/procurement/%s/%d%s", tenantID.String(), category, time.Now().UnixNano(), ext)
+	result, err := h.storage.Upload(r.Context(), storage.UploadInput{
+		Key:          key,
+		Body:         data,
+		ContentType:  contentType,
+		CacheControl: "private,max-age=31536000",
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao enviar documento", nil)
+		return
+	}
+
+	input.FileURL = result.URL
+	input.FileKey = key
+	input.ContentType = contentType
+	input.SizeBytes = int64(len(data))
+	if subject, err := h.subjectUUID(r); err == nil {
+		input.UploadedBy = &subject
+	}
+
+	doc, err := h.procurement.Create(r.Context(), input)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar documento", nil)
+		return
+	}
+	path := fileDownloadPath(doc.ID)
+	doc.DownloadURL = &path
+
+	WriteJSON(w, http.StatusCreated, map[string]any{"document": doc})
+}
+
+// DeleteProcurementDocument remove um documento do cofre.
+func (h *Handler) DeleteProcurementDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	documentID, err := parseUUIDParam(r, "documentID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id do documento inválido", nil)
+		return
+	}
+
+	if err := h.procurement.Delete(r.Context(), tenantID, documentID); err != nil {
+		if errors.Is(err, procurement.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover documento", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
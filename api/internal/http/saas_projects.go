@@ -136,7 +136,9 @@ func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusCreated, map[string]any{"project": project})
+	response := map[string]any{"project": project}
+	h.attachLeadCapacity(r.Context(), response, project)
+	WriteJSON(w, http.StatusCreated, response)
 }
 
 // UpdateProject altera dados básicos do projeto.
@@ -261,7 +263,11 @@ func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, map[string]any{"project": project})
+	response := map[string]any{"project": project}
+	if payload.LeadID != nil || payload.StartedAt != nil || payload.TargetDate != nil {
+		h.attachLeadCapacity(r.Context(), response, project)
+	}
+	WriteJSON(w, http.StatusOK, response)
 }
 
 // DeleteProject remove um projeto e suas tarefas.
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GetRetentionReport publica as políticas de retenção vigentes e o volume expurgado por classe,
+// material de apoio para a documentação de conformidade.
+func (h *Handler) GetRetentionReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var from, to *time.Time
+	if raw := strings.TrimSpace(query.Get("from")); raw != "" {
+		parsed, err := parseISODate(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		from = &parsed
+	}
+	if raw := strings.TrimSpace(query.Get("to")); raw != "" {
+		parsed, err := parseISODate(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		end := parsed.Add(24 * time.Hour)
+		to = &end
+	}
+	class := strings.TrimSpace(query.Get("class"))
+
+	totals, err := h.retention.Totals(r.Context(), from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar expurgos", nil)
+		return
+	}
+	history, err := h.retention.History(r.Context(), class, from, to, 200)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar expurgos", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"policies": h.retention.Policies(),
+		"totals":   totals,
+		"purges":   history,
+	})
+}
+
+// RunRetentionPurge dispara um expurgo fora do agendamento; o resultado aparece no relatório.
+func (h *Handler) RunRetentionPurge(w http.ResponseWriter, r *http.Request) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		if err := h.retention.RunOnce(ctx); err != nil {
+			log.Warn().Err(err).Msg("retenção: expurgo manual com falhas")
+		}
+	}()
+
+	WriteJSON(w, http.StatusAccepted, map[string]bool{"started": true})
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+type tenantEnvironmentPayload struct {
+	Environment string `json:"environment"`
+}
+
+// UpdateTenantEnvironment alterna o tenant entre produção e sandbox; recusa levar para sandbox
+// um tenant que já foi ativado.
+func (h *Handler) UpdateTenantEnvironment(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	var payload tenantEnvironmentPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	updated, err := h.tenants.SetEnvironment(r.Context(), tenantID, payload.Environment)
+	if err != nil {
+		if errors.Is(err, tenant.ErrInvalidEnv) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "ambiente inválido", map[string]any{"allowed": []string{tenant.EnvironmentProduction, tenant.EnvironmentSandbox}})
+			return
+		}
+		if errors.Is(err, tenant.ErrWasActivated) {
+			WriteError(w, http.StatusConflict, "CONFLICT", "tenant já ativado em produção não volta para sandbox", nil)
+			return
+		}
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"tenant": updated})
+}
+
+// ResetSandboxTenant apaga os dados operacionais de um tenant sandbox.
+func (h *Handler) ResetSandboxTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if !h.requireSandboxTenant(w, r) {
+		return
+	}
+
+	if _, err := h.demo.Wipe(r.Context(), tenantID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível limpar sandbox", nil)
+		return
+	}
+
+	updated, err := h.tenants.ResetSandbox(r.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, tenant.ErrNotSandbox) {
+			WriteError(w, http.StatusConflict, "CONFLICT", "operação permitida apenas em sandbox", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível limpar sandbox", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"tenant": updated})
+}
+
+// DeleteTenant remove definitivamente um tenant sandbox.
+func (h *Handler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if !h.requireSandboxTenant(w, r) {
+		return
+	}
+
+	// Os registros sintéticos não possuem tenant_id e seriam órfãos após a exclusão.
+	if _, err := h.demo.Wipe(r.Context(), tenantID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover tenant", nil)
+		return
+	}
+
+	if err := h.tenants.Delete(r.Context(), tenantID); err != nil {
+		if errors.Is(err, tenant.ErrNotSandbox) {
+			WriteError(w, http.StatusConflict, "CONFLICT", "operação permitida apenas em sandbox", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover tenant", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireSandboxTenant bloqueia operações destrutivas fora do ambiente sandbox ou sob retenção legal.
+func (h *Handler) requireSandboxTenant(w http.ResponseWriter, r *http.Request) bool {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return false
+	}
+
+	current, err := h.tenants.GetByID(r.Context(), tenantID)
+	if err != nil {
+		writeTenantLookupError(w, err)
+		return false
+	}
+	if !current.Sandbox {
+		WriteError(w, http.StatusConflict, "CONFLICT", "operação permitida apenas em sandbox", nil)
+		return false
+	}
+	return h.requireNoLegalHold(w, r, tenantID)
+}
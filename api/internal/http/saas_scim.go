@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/scim"
+)
+
+type scimRulePayload struct {
+	Attribute    string `json:"attribute"`
+	Value        string `json:"value"`
+	SecretariaID string `json:"secretaria_id"`
+	Papel        string `json:"papel"`
+}
+
+// GetTenantSCIM mostra o estado do token, as regras de mapeamento e o total provisionado.
+func (h *Handler) GetTenantSCIM(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scimTenant(w, r)
+	if !ok {
+		return
+	}
+
+	// Regras e identidades estão no cluster da prefeitura; o token, no primary.
+	ctx, ok := h.rotearTenant(w, r, tenantID)
+	if !ok {
+		return
+	}
+	cfg, err := h.scim.Configuracao(ctx, tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar configuração SCIM", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"scim": cfg, "endpoint": "/scim/v2"})
+}
+
+// IssueTenantSCIMToken gera o bearer token usado pelo IdP; ele só é exibido nesta resposta.
+func (h *Handler) IssueTenantSCIMToken(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scimTenant(w, r)
+	if !ok {
+		return
+	}
+
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	token, err := h.scim.EmitirToken(r.Context(), tenantID, &actorID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar token SCIM", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{"token": token, "endpoint": "/scim/v2"})
+}
+
+// RevokeTenantSCIMToken interrompe o provisionamento automático da prefeitura.
+func (h *Handler) RevokeTenantSCIMToken(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scimTenant(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.scim.RevogarToken(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível revogar token SCIM", nil)
+		return
+	}
+	if !revoked {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "nenhum token SCIM ativo", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateTenantSCIMRules substitui as regras que ligam atributos do IdP a secretarias e papéis.
+func (h *Handler) UpdateTenantSCIMRules(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scimTenant(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Rules []scimRulePayload `json:"rules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	regras := make([]scim.Regra, 0, len(payload.Rules))
+	for _, item := range payload.Rules {
+		secretariaID, err := uuid.Parse(strings.TrimSpace(item.SecretariaID))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return
+		}
+		regras = append(regras, scim.Regra{
+			Atributo:     item.Attribute,
+			Valor:        item.Value,
+			SecretariaID: secretariaID,
+			Papel:        item.Papel,
+		})
+	}
+
+	ctx, ok := h.rotearTenant(w, r, tenantID)
+	if !ok {
+		return
+	}
+	salvas, err := h.scim.DefinirRegras(ctx, tenantID, regras)
+	if err != nil {
+		if errors.Is(err, scim.ErrRegraInvalida) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar regras SCIM", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"rules": salvas})
+}
+
+func (h *Handler) scimTenant(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return uuid.Nil, false
+	}
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
@@ -0,0 +1,415 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// staffActiveWindow define o período considerado "uso recente" no diretório.
+const staffActiveWindow = 30 * 24 * time.Hour
+
+type staffSecretariaView struct {
+	ID    uuid.UUID `json:"id"`
+	Nome  string    `json:"nome"`
+	Slug  string    `json:"slug"`
+	Papel string    `json:"papel"`
+}
+
+type staffMemberView struct {
+	ID          uuid.UUID             `json:"id"`
+	Nome        *string               `json:"nome,omitempty"`
+	Email       string                `json:"email"`
+	Ativo       bool                  `json:"ativo"`
+	Roles       []string              `json:"roles"`
+	Secretarias []staffSecretariaView `json:"secretarias"`
+	LastLoginAt *time.Time            `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+type staffSummaryView struct {
+	Total           int            `json:"total"`
+	Active          int            `json:"active"`
+	ActiveLast30d   int            `json:"active_last_30d"`
+	NeverLoggedIn   int            `json:"never_logged_in"`
+	ByRole          map[string]int `json:"by_role"`
+	Secretarias     int            `json:"secretarias"`
+	LastActivityAt  *time.Time     `json:"last_activity_at,omitempty"`
+	SecretariaUsage map[string]int `json:"secretaria_usage"`
+}
+
+type staffSecretariasPayload struct {
+	SecretariaIDs []string `json:"secretaria_ids"`
+}
+
+type staffEscolaPayload struct {
+	EscolaID string `json:"escola_id"`
+	Cargo    string `json:"cargo"`
+}
+
+type staffEscolasPayload struct {
+	Escolas []staffEscolaPayload `json:"escolas"`
+}
+
+// ListTenantStaff agrega usuários do backoffice, secretarias e papéis da prefeitura.
+func (h *Handler) ListTenantStaff(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	search := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+
+	staff, err := h.loadTenantStaff(r.Context(), tenantID, search)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar equipe", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"staff":   staff,
+		"summary": summarizeTenantStaff(staff, time.Now()),
+	})
+}
+
+// UpdateTenantStaffSecretarias vincula secretarias do backoffice à prefeitura.
+func (h *Handler) UpdateTenantStaffSecretarias(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	var payload staffSecretariasPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(payload.SecretariaIDs))
+	for _, raw := range payload.SecretariaIDs {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_ids contém id inválido", nil)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar secretarias", nil)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if _, err := tx.Exec(r.Context(), `UPDATE secretarias SET tenant_id = NULL WHERE tenant_id = $1 AND NOT (id = ANY($2))`, tenantID, ids); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar secretarias", nil)
+		return
+	}
+	if len(ids) > 0 {
+		tag, err := tx.Exec(r.Context(), `UPDATE secretarias SET tenant_id = $1 WHERE id = ANY($2) AND (tenant_id IS NULL OR tenant_id = $1)`, tenantID, ids)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar secretarias", nil)
+			return
+		}
+		if int(tag.RowsAffected()) != len(ids) {
+			WriteError(w, http.StatusConflict, "CONFLICT", "secretaria inexistente ou vinculada a outra prefeitura", nil)
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar secretarias", nil)
+		return
+	}
+
+	staff, err := h.loadTenantStaff(r.Context(), tenantID, "")
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar equipe", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"staff":   staff,
+		"summary": summarizeTenantStaff(staff, time.Now()),
+	})
+}
+
+// UpdateTenantStaffEscolas define as escolas geridas (diretor/coordenador) por um membro da equipe.
+func (h *Handler) UpdateTenantStaffEscolas(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	userID, err := parseUUIDParam(r, "userID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuário inválido", nil)
+		return
+	}
+
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	var payload staffEscolasPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	escolaIDs := make([]uuid.UUID, 0, len(payload.Escolas))
+	cargos := make([]string, 0, len(payload.Escolas))
+	for _, item := range payload.Escolas {
+		id, err := uuid.Parse(strings.TrimSpace(item.EscolaID))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "escola_id inválido", nil)
+			return
+		}
+		cargo := strings.ToUpper(strings.TrimSpace(item.Cargo))
+		if cargo == "" {
+			cargo = "DIRETOR"
+		}
+		if cargo != "DIRETOR" && cargo != "COORDENADOR" {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "cargo deve ser DIRETOR ou COORDENADOR", nil)
+			return
+		}
+		escolaIDs = append(escolaIDs, id)
+		cargos = append(cargos, cargo)
+	}
+
+	var member bool
+	err = h.pool.QueryRow(r.Context(), `
+		SELECT EXISTS (
+			SELECT 1 FROM usuarios_secretarias us
+			JOIN secretarias s ON s.id = us.secretaria_id
+			WHERE us.usuario_id = $1 AND s.tenant_id = $2
+		)
+	`, userID, tenantID).Scan(&member)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+		return
+	}
+	if !member {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "usuário não pertence à prefeitura", nil)
+		return
+	}
+
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if err := db.SetActor(r.Context(), tx, actorID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+		return
+	}
+	// Remove apenas os vínculos descartados para que o log de privilégios registre a diferença real;
+	// vínculos do usuário com escolas de outras prefeituras não são desta tela.
+	if _, err := tx.Exec(r.Context(), `
+		DELETE FROM escolas_gestores
+		WHERE usuario_id = $1 AND NOT (escola_id = ANY($2::uuid[]))
+		  AND escola_id IN (SELECT id FROM escolas WHERE tenant_id = $3)
+	`, userID, escolaIDs, tenantID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+		return
+	}
+	if len(escolaIDs) > 0 {
+		// Escolas ainda sem prefeitura passam a pertencer ao tenant do gestor.
+		if _, err := tx.Exec(r.Context(), `
+			UPDATE escolas SET tenant_id = $1 WHERE id = ANY($2::uuid[]) AND tenant_id IS NULL
+		`, tenantID, escolaIDs); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+			return
+		}
+		tag, err := tx.Exec(r.Context(), `
+			INSERT INTO escolas_gestores (usuario_id, escola_id, cargo)
+			SELECT $1, e.id, v.cargo
+			FROM unnest($2::uuid[], $3::text[]) AS v(escola_id, cargo)
+			JOIN escolas e ON e.id = v.escola_id AND e.tenant_id = $4
+			ON CONFLICT (usuario_id, escola_id) DO UPDATE SET cargo = EXCLUDED.cargo
+		`, userID, escolaIDs, cargos, tenantID)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+			return
+		}
+		if int(tag.RowsAffected()) != len(escolaIDs) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "escola inexistente, duplicada ou de outra prefeitura", nil)
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar escolas", nil)
+		return
+	}
+
+	staff, err := h.loadTenantStaff(r.Context(), tenantID, "")
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar equipe", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"staff":   staff,
+		"summary": summarizeTenantStaff(staff, time.Now()),
+	})
+}
+
+// escapeLike neutraliza os curingas do LIKE na busca digitada, para que "%" ou "_" casem
+// literalmente; a consulta declara ESCAPE '\'.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (h *Handler) loadTenantStaff(ctx context.Context, tenantID uuid.UUID, search string) ([]staffMemberView, error) {
+	const query = `
+        SELECT u.id, u.nome, u.email, u.ativo, u.ultimo_login_em, u.criado_em,
+               s.id, s.nome, s.slug, us.papel,
+               EXISTS (SELECT 1 FROM professores_turmas pt WHERE pt.professor_id = u.id) AS professor,
+               EXISTS (SELECT 1 FROM escolas_gestores eg WHERE eg.usuario_id = u.id) AS gestor
+        FROM usuarios u
+        JOIN usuarios_secretarias us ON us.usuario_id = u.id
+        JOIN secretarias s ON s.id = us.secretaria_id
+        WHERE s.tenant_id = $1
+          AND ($2 = '' OR LOWER(COALESCE(u.nome, '')) LIKE '%' || $2 || '%' ESCAPE '\' OR LOWER(u.email) LIKE '%' || $2 || '%' ESCAPE '\')
+        ORDER BY u.nome NULLS LAST, u.email, s.nome
+    `
+
+	rows, err := h.conn(ctx).Query(ctx, query, tenantID, escapeLike(search))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	staff := []staffMemberView{}
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var (
+			member    staffMemberView
+			nome      sql.NullString
+			lastLogin sql.NullTime
+			sec       staffSecretariaView
+			professor bool
+			gestor    bool
+		)
+		if err := rows.Scan(&member.ID, &nome, &member.Email, &member.Ativo, &lastLogin, &member.CreatedAt, &sec.ID, &sec.Nome, &sec.Slug, &sec.Papel, &professor, &gestor); err != nil {
+			return nil, err
+		}
+
+		pos, ok := index[member.ID]
+		if !ok {
+			if nome.Valid {
+				str := strings.TrimSpace(nome.String)
+				member.Nome = &str
+			}
+			if lastLogin.Valid {
+				ts := lastLogin.Time
+				member.LastLoginAt = &ts
+			}
+			member.Roles = []string{}
+			if professor {
+				member.Roles = append(member.Roles, "PROFESSOR")
+			}
+			if gestor {
+				member.Roles = append(member.Roles, "ESCOLA_GESTOR")
+			}
+			member.Secretarias = []staffSecretariaView{}
+			staff = append(staff, member)
+			pos = len(staff) - 1
+			index[member.ID] = pos
+		}
+
+		staff[pos].Secretarias = append(staff[pos].Secretarias, sec)
+		if !containsString(staff[pos].Roles, sec.Papel) {
+			staff[pos].Roles = append(staff[pos].Roles, sec.Papel)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range staff {
+		sort.Strings(staff[i].Roles)
+	}
+	return staff, nil
+}
+
+func summarizeTenantStaff(staff []staffMemberView, now time.Time) staffSummaryView {
+	summary := staffSummaryView{
+		Total:           len(staff),
+		ByRole:          make(map[string]int),
+		SecretariaUsage: make(map[string]int),
+	}
+	secretarias := make(map[uuid.UUID]struct{})
+	cutoff := now.Add(-staffActiveWindow)
+
+	for _, member := range staff {
+		if member.Ativo {
+			summary.Active++
+		}
+		for _, role := range member.Roles {
+			summary.ByRole[role]++
+		}
+		for _, sec := range member.Secretarias {
+			secretarias[sec.ID] = struct{}{}
+		}
+		if member.LastLoginAt == nil {
+			summary.NeverLoggedIn++
+			continue
+		}
+		if member.LastLoginAt.After(cutoff) {
+			summary.ActiveLast30d++
+			for _, sec := range member.Secretarias {
+				summary.SecretariaUsage[sec.Slug]++
+			}
+		}
+		if summary.LastActivityAt == nil || member.LastLoginAt.After(*summary.LastActivityAt) {
+			ts := *member.LastLoginAt
+			summary.LastActivityAt = &ts
+		}
+	}
+	summary.Secretarias = len(secretarias)
+	return summary
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
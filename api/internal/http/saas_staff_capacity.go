@@ -0,0 +1,377 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/capacity"
+)
+
+// projectDefaultWindowDays é a janela usada quando o projeto não tem data-alvo.
+const projectDefaultWindowDays = 90
+
+type staffAvailabilityPayload struct {
+	SaaSUserID    string   `json:"saas_user_id"`
+	Kind          string   `json:"kind"`
+	StartsOn      string   `json:"starts_on"`
+	EndsOn        string   `json:"ends_on"`
+	AllocationPct *float64 `json:"allocation_pct"`
+	ProjectID     *string  `json:"project_id"`
+	Notes         *string  `json:"notes"`
+}
+
+type staffAvailabilityView struct {
+	ID            uuid.UUID  `json:"id"`
+	SaaSUserID    uuid.UUID  `json:"saas_user_id"`
+	UserName      string     `json:"user_name"`
+	Kind          string     `json:"kind"`
+	StartsOn      time.Time  `json:"starts_on"`
+	EndsOn        time.Time  `json:"ends_on"`
+	AllocationPct float64    `json:"allocation_pct"`
+	ProjectID     *uuid.UUID `json:"project_id,omitempty"`
+	ProjectName   *string    `json:"project_name,omitempty"`
+	Notes         *string    `json:"notes,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type staffCapacityView struct {
+	SaaSUserID uuid.UUID           `json:"saas_user_id"`
+	Name       string              `json:"name"`
+	Email      string              `json:"email"`
+	Entries    []capacity.Entry    `json:"entries"`
+	Capacity   capacity.Assessment `json:"capacity"`
+}
+
+// ListStaffAvailability lista férias, afastamentos e alocações que tocam a janela informada.
+func (h *Handler) ListStaffAvailability(w http.ResponseWriter, r *http.Request) {
+	from, to, err := capacityWindowFromQuery(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+		return
+	}
+
+	userIDs := []uuid.UUID{}
+	if raw := strings.TrimSpace(r.URL.Query().Get("user_id")); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "user_id inválido", nil)
+			return
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	const query = `
+        SELECT a.id, a.saas_user_id, u.name, a.kind, a.starts_on, a.ends_on, a.allocation_pct::float8,
+               a.project_id, p.name, a.notes, a.created_at
+        FROM saas_staff_availability a
+        JOIN saas_users u ON u.id = a.saas_user_id
+        LEFT JOIN saas_projects p ON p.id = a.project_id
+        WHERE a.starts_on <= $2 AND a.ends_on >= $1
+          AND (cardinality($3::uuid[]) = 0 OR a.saas_user_id = ANY($3))
+        ORDER BY a.starts_on, u.name
+    `
+
+	rows, err := h.pool.Query(r.Context(), query, from, to, userIDs)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar disponibilidade", nil)
+		return
+	}
+	defer rows.Close()
+
+	items := make([]staffAvailabilityView, 0)
+	for rows.Next() {
+		var item staffAvailabilityView
+		if err := rows.Scan(&item.ID, &item.SaaSUserID, &item.UserName, &item.Kind, &item.StartsOn, &item.EndsOn,
+			&item.AllocationPct, &item.ProjectID, &item.ProjectName, &item.Notes, &item.CreatedAt); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao ler disponibilidade", nil)
+			return
+		}
+		items = append(items, item)
+	}
+	if rows.Err() != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao ler disponibilidade", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"from": from, "to": to, "availability": items})
+}
+
+// CreateStaffAvailability registra férias, afastamento ou alocação parcial de um membro da equipe.
+func (h *Handler) CreateStaffAvailability(w http.ResponseWriter, r *http.Request) {
+	var payload staffAvailabilityPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(strings.TrimSpace(payload.SaaSUserID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "saas_user_id inválido", nil)
+		return
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(payload.Kind))
+	if !capacity.ValidKind(kind) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tipo inválido", map[string]any{"allowed": capacity.Kinds})
+		return
+	}
+
+	startsOn, err := parseISODate(payload.StartsOn)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "starts_on inválido", nil)
+		return
+	}
+	endsOn, err := parseISODate(payload.EndsOn)
+	if err != nil || endsOn.Before(startsOn) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "ends_on inválido", nil)
+		return
+	}
+
+	pct := 100.0
+	if kind == capacity.KindAllocation {
+		if payload.AllocationPct == nil || *payload.AllocationPct <= 0 || *payload.AllocationPct > 100 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "allocation_pct deve estar entre 0 e 100", nil)
+			return
+		}
+		pct = *payload.AllocationPct
+	}
+
+	var projectID *uuid.UUID
+	if payload.ProjectID != nil && strings.TrimSpace(*payload.ProjectID) != "" {
+		id, err := uuid.Parse(strings.TrimSpace(*payload.ProjectID))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "project_id inválido", nil)
+			return
+		}
+		projectID = &id
+	}
+
+	creatorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	const insert = `
+        INSERT INTO saas_staff_availability (saas_user_id, kind, starts_on, ends_on, allocation_pct, project_id, notes, created_by)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8
+        WHERE EXISTS (SELECT 1 FROM saas_users WHERE id = $1)
+        RETURNING id
+    `
+
+	var id uuid.UUID
+	if err := h.pool.QueryRow(r.Context(), insert, userID, kind, startsOn, endsOn, pct, projectID,
+		trimmedOrNil(payload.Notes), creatorID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "usuário não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar disponibilidade", nil)
+		return
+	}
+
+	from, to := capacity.Window(startsOn, endsOn)
+	assessment, err := h.staffCapacity(r.Context(), userID, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao calcular capacidade", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{"id": id, "capacity": assessment})
+}
+
+// DeleteStaffAvailability remove um registro de disponibilidade.
+func (h *Handler) DeleteStaffAvailability(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), "DELETE FROM saas_staff_availability WHERE id = $1", id)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover disponibilidade", nil)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "registro não encontrado", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ProjectCapacity mostra a ocupação de cada membro ativo da equipe na janela de planejamento.
+func (h *Handler) ProjectCapacity(w http.ResponseWriter, r *http.Request) {
+	from, to, err := capacityWindowFromQuery(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(), `SELECT id, name, email FROM saas_users WHERE active ORDER BY name`)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar equipe", nil)
+		return
+	}
+	staff := make([]staffCapacityView, 0)
+	for rows.Next() {
+		var item staffCapacityView
+		if err := rows.Scan(&item.SaaSUserID, &item.Name, &item.Email); err != nil {
+			rows.Close()
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao ler equipe", nil)
+			return
+		}
+		staff = append(staff, item)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao ler equipe", nil)
+		return
+	}
+
+	entries, err := h.loadCapacityEntries(r.Context(), nil, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao calcular capacidade", nil)
+		return
+	}
+
+	overAllocated := 0
+	for i := range staff {
+		staff[i].Entries = entries[staff[i].SaaSUserID]
+		if staff[i].Entries == nil {
+			staff[i].Entries = []capacity.Entry{}
+		}
+		staff[i].Capacity = capacity.Assess(staff[i].Entries, from, to)
+		if staff[i].Capacity.OverAllocated {
+			overAllocated++
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"from":           from,
+		"to":             to,
+		"staff":          staff,
+		"over_allocated": overAllocated,
+	})
+}
+
+// attachLeadCapacity inclui na resposta a ocupação do líder e os avisos de sobrealocação. Falhas no
+// cálculo não impedem o salvamento do projeto.
+func (h *Handler) attachLeadCapacity(ctx context.Context, response map[string]any, project projectOverview) {
+	assessment, err := h.projectLeadCapacity(ctx, project)
+	if err != nil {
+		log.Warn().Err(err).Str("project_id", project.ID.String()).Msg("falha ao calcular capacidade do líder")
+		return
+	}
+	if assessment == nil {
+		return
+	}
+	response["lead_capacity"] = assessment
+	response["warnings"] = assessment.Warnings
+}
+
+// projectLeadCapacity avalia o líder do projeto na janela [started_at, target_date]. Devolve nil
+// quando o projeto não tem líder.
+func (h *Handler) projectLeadCapacity(ctx context.Context, project projectOverview) (*capacity.Assessment, error) {
+	if project.Lead == nil {
+		return nil, nil
+	}
+	from := time.Now()
+	if project.StartedAt != nil {
+		from = *project.StartedAt
+	}
+	to := from.AddDate(0, 0, projectDefaultWindowDays)
+	if project.TargetDate != nil && !project.TargetDate.Before(from) {
+		to = *project.TargetDate
+	}
+	from, to = capacity.Window(from, to)
+	assessment, err := h.staffCapacity(ctx, *project.Lead, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &assessment, nil
+}
+
+func (h *Handler) staffCapacity(ctx context.Context, userID uuid.UUID, from, to time.Time) (capacity.Assessment, error) {
+	entries, err := h.loadCapacityEntries(ctx, []uuid.UUID{userID}, from, to)
+	if err != nil {
+		return capacity.Assessment{}, err
+	}
+	return capacity.Assess(entries[userID], from, to), nil
+}
+
+// loadCapacityEntries reúne os registros de disponibilidade e os projetos liderados que tocam a
+// janela. Projetos sem alocação explícita do líder contam com capacity.DefaultLeadPct.
+func (h *Handler) loadCapacityEntries(ctx context.Context, userIDs []uuid.UUID, from, to time.Time) (map[uuid.UUID][]capacity.Entry, error) {
+	if userIDs == nil {
+		userIDs = []uuid.UUID{}
+	}
+
+	const query = `
+        SELECT a.saas_user_id, a.kind, a.starts_on, a.ends_on, a.allocation_pct::float8, a.project_id, COALESCE(p.name, a.notes, '')
+        FROM saas_staff_availability a
+        LEFT JOIN saas_projects p ON p.id = a.project_id
+        WHERE a.starts_on <= $2 AND a.ends_on >= $1
+          AND (cardinality($3::uuid[]) = 0 OR a.saas_user_id = ANY($3))
+        UNION ALL
+        SELECT p.lead_id, 'allocation', COALESCE(p.started_at, p.created_at::date), COALESCE(p.target_date, $2::date),
+               $4::float8, p.id, p.name
+        FROM saas_projects p
+        WHERE p.lead_id IS NOT NULL
+          AND p.status <> 'completed'
+          AND COALESCE(p.started_at, p.created_at::date) <= $2
+          AND COALESCE(p.target_date, $2::date) >= $1
+          AND (cardinality($3::uuid[]) = 0 OR p.lead_id = ANY($3))
+          AND NOT EXISTS (
+              SELECT 1 FROM saas_staff_availability a
+              WHERE a.saas_user_id = p.lead_id AND a.project_id = p.id
+          )
+    `
+
+	rows, err := h.pool.Query(ctx, query, from, to, userIDs, float64(capacity.DefaultLeadPct))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[uuid.UUID][]capacity.Entry)
+	for rows.Next() {
+		var userID uuid.UUID
+		var entry capacity.Entry
+		if err := rows.Scan(&userID, &entry.Kind, &entry.StartsOn, &entry.EndsOn, &entry.Percent, &entry.ProjectID, &entry.Label); err != nil {
+			return nil, err
+		}
+		result[userID] = append(result[userID], entry)
+	}
+	return result, rows.Err()
+}
+
+func capacityWindowFromQuery(r *http.Request) (time.Time, time.Time, error) {
+	from := time.Now()
+	if raw := strings.TrimSpace(r.URL.Query().Get("from")); raw != "" {
+		ts, err := parseISODate(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = ts
+	}
+	to := from.AddDate(0, 0, projectDefaultWindowDays)
+	if raw := strings.TrimSpace(r.URL.Query().Get("to")); raw != "" {
+		ts, err := parseISODate(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = ts
+	}
+	from, to = capacity.Window(from, to)
+	return from, to, nil
+}
@@ -0,0 +1,19 @@
+package http
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"":           "",
+		"maria":      "maria",
+		"100%":       `100\%`,
+		"joao_silva": `joao\_silva`,
+		`c:\temp`:    `c:\\temp`,
+		`%_\`:        `\%\_\\`,
+	}
+	for in, want := range cases {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
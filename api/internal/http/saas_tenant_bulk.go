@@ -0,0 +1,511 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+const (
+	bulkOperationStatus      = "status"
+	bulkOperationModules     = "modules"
+	bulkOperationReprovision = "reprovision"
+
+	bulkItemTimeout = 30 * time.Second
+)
+
+type tenantBulkFilter struct {
+	All         bool     `json:"all,omitempty"`
+	IDs         []string `json:"ids,omitempty"`
+	Statuses    []string `json:"statuses,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	DNSStatus   string   `json:"dns_status,omitempty"`
+	Query       string   `json:"q,omitempty"`
+}
+
+type tenantBulkParams struct {
+	Status  string          `json:"status,omitempty"`
+	Modules map[string]bool `json:"modules,omitempty"`
+	Proxied *bool           `json:"proxied,omitempty"`
+}
+
+type tenantBulkPayload struct {
+	Operation string           `json:"operation"`
+	Filter    tenantBulkFilter `json:"filter"`
+	tenantBulkParams
+	DryRun bool `json:"dry_run"`
+}
+
+type tenantBulkItemView struct {
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	Slug        string     `json:"slug"`
+	DisplayName string     `json:"display_name"`
+	Status      string     `json:"status"`
+	Error       *string    `json:"error,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+type tenantBulkView struct {
+	ID         uuid.UUID            `json:"id"`
+	Operation  string               `json:"operation"`
+	Params     json.RawMessage      `json:"params"`
+	Filter     json.RawMessage      `json:"filter"`
+	Status     string               `json:"status"`
+	Total      int                  `json:"total"`
+	Succeeded  int                  `json:"succeeded"`
+	Failed     int                  `json:"failed"`
+	Skipped    int                  `json:"skipped"`
+	Pending    int                  `json:"pending"`
+	CreatedBy  *uuid.UUID           `json:"created_by,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Items      []tenantBulkItemView `json:"items,omitempty"`
+}
+
+// CreateTenantBulkOperation aplica mudança de status, módulos ou reprovisionamento de DNS
+// a um conjunto filtrado de tenants. A execução é assíncrona; o progresso fica em GET /tenants/bulk/{bulkID}.
+func (h *Handler) CreateTenantBulkOperation(w http.ResponseWriter, r *http.Request) {
+	var payload tenantBulkPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	payload.Operation = strings.ToLower(strings.TrimSpace(payload.Operation))
+	params := payload.tenantBulkParams
+	switch payload.Operation {
+	case bulkOperationStatus:
+		params = tenantBulkParams{Status: strings.ToLower(strings.TrimSpace(payload.Status))}
+		if params.Status == "" || !tenant.IsValidStatus(params.Status) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "status inválido", map[string]any{"allowed": []string{tenant.StatusDraft, tenant.StatusReview, tenant.StatusActive, tenant.StatusSuspended, tenant.StatusArchived}})
+			return
+		}
+	case bulkOperationModules:
+		modules := make(map[string]bool, len(payload.Modules))
+		for code, enabled := range payload.Modules {
+			if code = strings.TrimSpace(code); code != "" {
+				modules[code] = enabled
+			}
+		}
+		if len(modules) == 0 {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "informe ao menos um módulo", nil)
+			return
+		}
+		params = tenantBulkParams{Modules: modules}
+	case bulkOperationReprovision:
+		if h.provisioner == nil || !h.provisioner.IsConfigured() {
+			WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "provisionamento de DNS indisponível", nil)
+			return
+		}
+		params = tenantBulkParams{Proxied: payload.Proxied}
+	default:
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "operation deve ser status, modules ou reprovision", nil)
+		return
+	}
+
+	targets, err := h.resolveBulkTargets(r.Context(), payload.Filter)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if len(targets) == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "nenhum tenant corresponde ao filtro", nil)
+		return
+	}
+
+	if payload.DryRun {
+		items := make([]tenantBulkItemView, 0, len(targets))
+		for _, t := range targets {
+			items = append(items, tenantBulkItemView{TenantID: t.ID, Slug: t.Slug, DisplayName: t.DisplayName, Status: "pending"})
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{"dry_run": true, "total": len(items), "items": items})
+		return
+	}
+
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	paramsJSON, _ := json.Marshal(params)
+	filterJSON, _ := json.Marshal(payload.Filter)
+	ids := make([]uuid.UUID, 0, len(targets))
+	for _, t := range targets {
+		ids = append(ids, t.ID)
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar operação", nil)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	var opID uuid.UUID
+	if err := tx.QueryRow(r.Context(), `
+		INSERT INTO saas_tenant_bulk_operations (operation, params, filter, total, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, payload.Operation, paramsJSON, filterJSON, len(ids), actorID).Scan(&opID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar operação", nil)
+		return
+	}
+	if _, err := tx.Exec(r.Context(), `
+		INSERT INTO saas_tenant_bulk_operation_items (operation_id, tenant_id)
+		SELECT $1, unnest($2::uuid[])
+	`, opID, ids); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar operação", nil)
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar operação", nil)
+		return
+	}
+
+	go h.runTenantBulkOperation(opID, payload.Operation, params, actorID)
+
+	view, err := h.fetchTenantBulkOperation(r.Context(), opID, false)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar operação", nil)
+		return
+	}
+	WriteJSON(w, http.StatusAccepted, map[string]any{"operation": view})
+}
+
+// ListTenantBulkOperations lista as operações mais recentes com seus contadores.
+func (h *Handler) ListTenantBulkOperations(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.pool.Query(r.Context(), tenantBulkSelect+` ORDER BY o.created_at DESC LIMIT 50`)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar operações", nil)
+		return
+	}
+	defer rows.Close()
+
+	operations := make([]tenantBulkView, 0)
+	for rows.Next() {
+		view, err := scanTenantBulkOperation(rows)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar operações", nil)
+			return
+		}
+		operations = append(operations, view)
+	}
+	if err := rows.Err(); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar operações", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"operations": operations})
+}
+
+// GetTenantBulkOperation devolve progresso e resultado por tenant.
+func (h *Handler) GetTenantBulkOperation(w http.ResponseWriter, r *http.Request) {
+	opID, err := parseUUIDParam(r, "bulkID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	view, err := h.fetchTenantBulkOperation(r.Context(), opID, true)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "operação não encontrada", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar operação", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"operation": view})
+}
+
+// ResumeTenantBulkOperation retoma itens pendentes, por exemplo após reinício da API no meio da execução.
+func (h *Handler) ResumeTenantBulkOperation(w http.ResponseWriter, r *http.Request) {
+	opID, err := parseUUIDParam(r, "bulkID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	var (
+		operation  string
+		paramsJSON []byte
+	)
+	err = h.pool.QueryRow(r.Context(), `
+		UPDATE saas_tenant_bulk_operations SET status = 'pending', finished_at = NULL
+		WHERE id = $1 AND status <> 'completed'
+		  AND EXISTS (SELECT 1 FROM saas_tenant_bulk_operation_items WHERE operation_id = $1 AND status = 'pending')
+		RETURNING operation, params
+	`, opID).Scan(&operation, &paramsJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		WriteError(w, http.StatusConflict, "CONFLICT", "operação inexistente ou sem itens pendentes", nil)
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível retomar operação", nil)
+		return
+	}
+
+	var params tenantBulkParams
+	_ = json.Unmarshal(paramsJSON, &params)
+	go h.runTenantBulkOperation(opID, operation, params, actorID)
+
+	view, err := h.fetchTenantBulkOperation(r.Context(), opID, false)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar operação", nil)
+		return
+	}
+	WriteJSON(w, http.StatusAccepted, map[string]any{"operation": view})
+}
+
+// resolveBulkTargets aplica o filtro à lista de tenants. Filtro vazio exige all=true
+// para que um payload incompleto não atinja a plataforma inteira.
+func (h *Handler) resolveBulkTargets(ctx context.Context, filter tenantBulkFilter) ([]tenant.Tenant, error) {
+	ids := make(map[uuid.UUID]struct{}, len(filter.IDs))
+	for _, raw := range filter.IDs {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, errors.New("filter.ids contém id inválido")
+		}
+		ids[id] = struct{}{}
+	}
+	statuses := make([]string, 0, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, strings.ToLower(strings.TrimSpace(status)))
+	}
+	environment := strings.ToLower(strings.TrimSpace(filter.Environment))
+	dnsStatus := strings.ToLower(strings.TrimSpace(filter.DNSStatus))
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+
+	if !filter.All && len(ids) == 0 && len(statuses) == 0 && environment == "" && dnsStatus == "" && query == "" {
+		return nil, errors.New("informe um filtro ou filter.all=true")
+	}
+
+	tenants, err := h.tenants.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]tenant.Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		if _, ok := ids[t.ID]; len(ids) > 0 && !ok {
+			continue
+		}
+		if len(statuses) > 0 && !containsString(statuses, t.Status) {
+			continue
+		}
+		if environment != "" && t.Environment != environment {
+			continue
+		}
+		if dnsStatus != "" && t.DNSStatus != dnsStatus {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(t.Slug+" "+t.DisplayName+" "+t.Domain), query) {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// runTenantBulkOperation processa os itens em sequência para não saturar o provedor de DNS.
+// Cada item é reivindicado com SKIP LOCKED, então retomar uma operação em andamento não duplica trabalho.
+func (h *Handler) runTenantBulkOperation(opID uuid.UUID, operation string, params tenantBulkParams, actorID uuid.UUID) {
+	ctx := context.Background()
+	logger := log.With().Str("bulk_operation", opID.String()).Logger()
+
+	if _, err := h.pool.Exec(ctx, `
+		UPDATE saas_tenant_bulk_operations SET status = 'running', started_at = COALESCE(started_at, now()) WHERE id = $1
+	`, opID); err != nil {
+		logger.Error().Err(err).Msg("bulk: falha ao iniciar operação")
+		return
+	}
+
+	for {
+		tx, err := h.pool.Begin(ctx)
+		if err != nil {
+			h.failTenantBulkOperation(ctx, opID, err)
+			return
+		}
+
+		var tenantID uuid.UUID
+		err = tx.QueryRow(ctx, `
+			SELECT tenant_id FROM saas_tenant_bulk_operation_items
+			WHERE operation_id = $1 AND status = 'pending'
+			ORDER BY tenant_id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		`, opID).Scan(&tenantID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			_ = tx.Rollback(ctx)
+			break
+		}
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			h.failTenantBulkOperation(ctx, opID, err)
+			return
+		}
+
+		itemCtx, cancel := context.WithTimeout(ctx, bulkItemTimeout)
+		status, applyErr := h.applyTenantBulkItem(itemCtx, operation, params, tenantID, actorID)
+		cancel()
+
+		var errMsg *string
+		if applyErr != nil {
+			status = "failed"
+			msg := applyErr.Error()
+			errMsg = &msg
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE saas_tenant_bulk_operation_items SET status = $3, error = $4, processed_at = now()
+			WHERE operation_id = $1 AND tenant_id = $2
+		`, opID, tenantID, status, errMsg); err != nil {
+			_ = tx.Rollback(ctx)
+			h.failTenantBulkOperation(ctx, opID, err)
+			return
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE saas_tenant_bulk_operations
+			SET succeeded = succeeded + CASE WHEN $2 = 'succeeded' THEN 1 ELSE 0 END,
+			    failed = failed + CASE WHEN $2 = 'failed' THEN 1 ELSE 0 END
+			WHERE id = $1
+		`, opID, status); err != nil {
+			_ = tx.Rollback(ctx)
+			h.failTenantBulkOperation(ctx, opID, err)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			h.failTenantBulkOperation(ctx, opID, err)
+			return
+		}
+	}
+
+	if _, err := h.pool.Exec(ctx, `
+		UPDATE saas_tenant_bulk_operations SET status = 'completed', finished_at = now()
+		WHERE id = $1 AND NOT EXISTS (
+			SELECT 1 FROM saas_tenant_bulk_operation_items WHERE operation_id = $1 AND status = 'pending'
+		)
+	`, opID); err != nil {
+		logger.Error().Err(err).Msg("bulk: falha ao concluir operação")
+	}
+}
+
+// applyTenantBulkItem executa a operação em um tenant; devolve "skipped" quando nada muda.
+func (h *Handler) applyTenantBulkItem(ctx context.Context, operation string, params tenantBulkParams, tenantID, actorID uuid.UUID) (string, error) {
+	switch operation {
+	case bulkOperationStatus:
+		current, err := h.tenants.GetByID(ctx, tenantID)
+		if err != nil {
+			return "", err
+		}
+		if current.Status == params.Status {
+			return "skipped", nil
+		}
+		if _, err := h.tenants.SetStatus(ctx, tenantID, params.Status); err != nil {
+			return "", err
+		}
+	case bulkOperationModules:
+		codes := make([]string, 0, len(params.Modules))
+		enabled := make([]bool, 0, len(params.Modules))
+		for code, on := range params.Modules {
+			codes = append(codes, code)
+			enabled = append(enabled, on)
+		}
+		tag, err := h.pool.Exec(ctx, `
+			INSERT INTO saas_tenant_contract_modules (tenant_id, module_code, enabled, updated_by)
+			SELECT $1, v.code, v.enabled, $4
+			FROM unnest($2::text[], $3::boolean[]) AS v(code, enabled)
+			ON CONFLICT (tenant_id, module_code) DO UPDATE
+			SET enabled = EXCLUDED.enabled, updated_at = now(), updated_by = EXCLUDED.updated_by
+			WHERE saas_tenant_contract_modules.enabled <> EXCLUDED.enabled
+		`, tenantID, codes, enabled, actorID)
+		if err != nil {
+			return "", err
+		}
+		if tag.RowsAffected() == 0 {
+			return "skipped", nil
+		}
+	case bulkOperationReprovision:
+		if h.provisioner == nil || !h.provisioner.IsConfigured() {
+			return "", errors.New("provisionamento de DNS indisponível")
+		}
+		proxied := h.provisioner.DefaultProxied()
+		if params.Proxied != nil {
+			proxied = *params.Proxied
+		}
+		if _, err := h.provisioner.ProvisionTenant(ctx, tenantID, proxied); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("operação desconhecida")
+	}
+	return "succeeded", nil
+}
+
+func (h *Handler) failTenantBulkOperation(ctx context.Context, opID uuid.UUID, cause error) {
+	log.Error().Err(cause).Str("bulk_operation", opID.String()).Msg("bulk: operação interrompida")
+	if _, err := h.pool.Exec(ctx, `
+		UPDATE saas_tenant_bulk_operations SET status = 'failed', finished_at = now() WHERE id = $1
+	`, opID); err != nil {
+		log.Error().Err(err).Str("bulk_operation", opID.String()).Msg("bulk: falha ao marcar operação como falha")
+	}
+}
+
+const tenantBulkSelect = `
+	SELECT o.id, o.operation, o.params, o.filter, o.status, o.total, o.succeeded, o.failed,
+	       (SELECT COUNT(*) FROM saas_tenant_bulk_operation_items i WHERE i.operation_id = o.id AND i.status = 'skipped'),
+	       (SELECT COUNT(*) FROM saas_tenant_bulk_operation_items i WHERE i.operation_id = o.id AND i.status = 'pending'),
+	       o.created_by, o.created_at, o.started_at, o.finished_at
+	FROM saas_tenant_bulk_operations o
+`
+
+func scanTenantBulkOperation(row pgx.Row) (tenantBulkView, error) {
+	var view tenantBulkView
+	err := row.Scan(&view.ID, &view.Operation, &view.Params, &view.Filter, &view.Status, &view.Total, &view.Succeeded, &view.Failed,
+		&view.Skipped, &view.Pending, &view.CreatedBy, &view.CreatedAt, &view.StartedAt, &view.FinishedAt)
+	return view, err
+}
+
+func (h *Handler) fetchTenantBulkOperation(ctx context.Context, opID uuid.UUID, withItems bool) (tenantBulkView, error) {
+	view, err := scanTenantBulkOperation(h.pool.QueryRow(ctx, tenantBulkSelect+` WHERE o.id = $1`, opID))
+	if err != nil || !withItems {
+		return view, err
+	}
+
+	rows, err := h.pool.Query(ctx, `
+		SELECT i.tenant_id, t.slug, t.display_name, i.status, i.error, i.processed_at
+		FROM saas_tenant_bulk_operation_items i
+		JOIN tenants t ON t.id = i.tenant_id
+		WHERE i.operation_id = $1
+		ORDER BY (i.status = 'failed') DESC, t.display_name
+	`, opID)
+	if err != nil {
+		return view, err
+	}
+	defer rows.Close()
+
+	view.Items = make([]tenantBulkItemView, 0)
+	for rows.Next() {
+		var item tenantBulkItemView
+		if err := rows.Scan(&item.TenantID, &item.Slug, &item.DisplayName, &item.Status, &item.Error, &item.ProcessedAt); err != nil {
+			return view, err
+		}
+		view.Items = append(view.Items, item)
+	}
+	return view, rows.Err()
+}
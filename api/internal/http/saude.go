@@ -0,0 +1,363 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/saude"
+)
+
+type saudeCampanhaPayload struct {
+	SecretariaID  string   `json:"secretaria_id"`
+	Nome          string   `json:"nome"`
+	Vacina        string   `json:"vacina"`
+	Descricao     *string  `json:"descricao"`
+	Inicio        string   `json:"inicio"`
+	Fim           *string  `json:"fim"`
+	DosesEsquema  int      `json:"doses_esquema"`
+	IdadeMinMeses *int     `json:"idade_min_meses"`
+	IdadeMaxMeses *int     `json:"idade_max_meses"`
+	Grupos        []string `json:"grupos"`
+	MetaPopulacao *int     `json:"meta_populacao"`
+	MetaCobertura float64  `json:"meta_cobertura"`
+}
+
+// ListSaudeCampanhas lista as campanhas de saúde da prefeitura (?status=).
+func (h *Handler) ListSaudeCampanhas(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	campanhas, err := h.saude.ListCampanhas(r.Context(), tenantID, strings.TrimSpace(r.URL.Query().Get("status")))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar campanhas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"campanhas": campanhas})
+}
+
+// CreateSaudeCampanha cadastra uma campanha, que começa planejada.
+func (h *Handler) CreateSaudeCampanha(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeSaudeCampanha(w, r, true)
+	if !ok {
+		return
+	}
+	input.ActorID = &userID
+	created, err := h.saude.CreateCampanha(r.Context(), tenantID, input)
+	if err != nil {
+		writeSaudeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"campanha": created})
+}
+
+// GetSaudeCampanha detalha a campanha.
+func (h *Handler) GetSaudeCampanha(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	c, err := h.saude.GetCampanha(r.Context(), tenantID, id)
+	if err != nil {
+		writeSaudeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"campanha": c})
+}
+
+// UpdateSaudeCampanha altera uma campanha ainda não encerrada.
+func (h *Handler) UpdateSaudeCampanha(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeSaudeCampanha(w, r, false)
+	if !ok {
+		return
+	}
+	updated, err := h.saude.UpdateCampanha(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeSaudeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"campanha": updated})
+}
+
+// SetSaudeCampanhaStatus ativa ou encerra a campanha.
+func (h *Handler) SetSaudeCampanhaStatus(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	updated, err := h.saude.SetStatus(r.Context(), tenantID, id, strings.ToLower(strings.TrimSpace(payload.Status)))
+	if err != nil {
+		writeSaudeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"campanha": updated})
+}
+
+// RegistrarSaudeDose registra a dose aplicada pelo agente de saúde autenticado. Com cidadao_id, a
+// dose aparece na carteira de vacinação do aplicativo.
+func (h *Handler) RegistrarSaudeDose(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	var payload struct {
+		CidadaoID      *string `json:"cidadao_id"`
+		PacienteNome   string  `json:"paciente_nome"`
+		DataNascimento *string `json:"data_nascimento"`
+		Grupo          *string `json:"grupo"`
+		Bairro         *string `json:"bairro"`
+		Dose           int     `json:"dose"`
+		Lote           *string `json:"lote"`
+		Unidade        *string `json:"unidade"`
+		AplicadaEm     *string `json:"aplicada_em"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	input := saude.DoseInput{
+		PacienteNome: payload.PacienteNome,
+		Grupo:        payload.Grupo,
+		Bairro:       payload.Bairro,
+		Dose:         payload.Dose,
+		Lote:         payload.Lote,
+		Unidade:      payload.Unidade,
+		AgenteID:     userID,
+	}
+	if input.CidadaoID, err = optionalUUID(payload.CidadaoID); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "cidadao_id inválido", nil)
+		return
+	}
+	if input.DataNascimento, err = optionalDate(payload.DataNascimento); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "data_nascimento inválida", nil)
+		return
+	}
+	aplicada, err := optionalDate(payload.AplicadaEm)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "aplicada_em inválida", nil)
+		return
+	}
+	if aplicada != nil {
+		input.AplicadaEm = *aplicada
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	dose, err := h.saude.RegistrarDose(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeSaudeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"dose": dose})
+}
+
+// ListSaudeDoses lista as doses da campanha (?q=&limit=).
+func (h *Handler) ListSaudeDoses(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "limit inválido", nil)
+			return
+		}
+	}
+	doses, err := h.saude.ListDoses(r.Context(), tenantID, id, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar doses", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"doses": doses})
+}
+
+// SaudeCobertura devolve o painel de cobertura da campanha.
+func (h *Handler) SaudeCobertura(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	cobertura, err := h.saude.Cobertura(r.Context(), tenantID, id)
+	if err != nil {
+		writeSaudeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, cobertura)
+}
+
+// MinhaCarteiraVacinacao mostra ao cidadão as doses registradas no cadastro dele e as campanhas
+// em andamento na cidade.
+func (h *Handler) MinhaCarteiraVacinacao(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	doses, err := h.saude.Carteira(r.Context(), tenantInfo.ID, cidadaoID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a carteira de vacinação", nil)
+		return
+	}
+	ativas, err := h.saude.ListCampanhas(r.Context(), tenantInfo.ID, saude.StatusAtiva)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar campanhas", nil)
+		return
+	}
+	campanhas := make([]map[string]any, 0, len(ativas))
+	for _, c := range ativas {
+		campanhas = append(campanhas, map[string]any{
+			"id":              c.ID,
+			"nome":            c.Nome,
+			"vacina":          c.Vacina,
+			"descricao":       c.Descricao,
+			"inicio":          c.Inicio,
+			"fim":             c.Fim,
+			"doses_esquema":   c.DosesEsquema,
+			"idade_min_meses": c.IdadeMinMeses,
+			"idade_max_meses": c.IdadeMaxMeses,
+			"grupos":          c.Grupos,
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"doses": doses, "campanhas_ativas": campanhas})
+}
+
+func decodeSaudeCampanha(w http.ResponseWriter, r *http.Request, create bool) (saude.CampanhaInput, bool) {
+	var payload saudeCampanhaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return saude.CampanhaInput{}, false
+	}
+	input := saude.CampanhaInput{
+		Nome:          payload.Nome,
+		Vacina:        payload.Vacina,
+		Descricao:     payload.Descricao,
+		DosesEsquema:  payload.DosesEsquema,
+		IdadeMinMeses: payload.IdadeMinMeses,
+		IdadeMaxMeses: payload.IdadeMaxMeses,
+		Grupos:        payload.Grupos,
+		MetaPopulacao: payload.MetaPopulacao,
+		MetaCobertura: payload.MetaCobertura,
+	}
+	if create {
+		secretariaID, err := uuid.Parse(strings.TrimSpace(payload.SecretariaID))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return saude.CampanhaInput{}, false
+		}
+		input.SecretariaID = secretariaID
+	}
+	inicio, err := optionalDate(&payload.Inicio)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "inicio inválido", nil)
+		return saude.CampanhaInput{}, false
+	}
+	if inicio != nil {
+		input.Inicio = *inicio
+	}
+	if input.Fim, err = optionalDate(payload.Fim); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fim inválido", nil)
+		return saude.CampanhaInput{}, false
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return saude.CampanhaInput{}, false
+	}
+	return input, true
+}
+
+// optionalDate interpreta uma data YYYY-MM-DD opcional.
+func optionalDate(raw *string) (*time.Time, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", strings.TrimSpace(*raw))
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func writeSaudeError(w http.ResponseWriter, err error) {
+	var elegibilidade *saude.ElegibilidadeError
+	switch {
+	case errors.As(err, &elegibilidade):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", elegibilidade.Motivo, nil)
+	case errors.Is(err, saude.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "campanha não encontrada", nil)
+	case errors.Is(err, saude.ErrSecretaria):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "secretaria inválida", nil)
+	case errors.Is(err, saude.ErrInvalidTransition):
+		WriteError(w, http.StatusConflict, "CONFLICT", "operação não permitida na situação atual da campanha", nil)
+	case errors.Is(err, saude.ErrInactive):
+		WriteError(w, http.StatusConflict, "CONFLICT", "a campanha não está ativa", nil)
+	case errors.Is(err, saude.ErrNotAgent):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "somente agentes da secretaria da campanha registram doses", nil)
+	case errors.Is(err, saude.ErrCidadao):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "cidadão inválido", nil)
+	case errors.Is(err, saude.ErrDuplicate):
+		WriteError(w, http.StatusConflict, "CONFLICT", "dose já registrada para este cidadão", nil)
+	case errors.Is(err, saude.ErrDoseAnterior):
+		WriteError(w, http.StatusConflict, "CONFLICT", "registre antes a dose anterior do esquema", nil)
+	case errors.Is(err, saude.ErrEsquema):
+		WriteError(w, http.StatusConflict, "CONFLICT", "já existem doses acima do novo esquema", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a campanha", nil)
+	}
+}
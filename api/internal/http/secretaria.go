@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// SecretariaLivePresence mostra quantos alunos estão presentes agora nas escolas da prefeitura do usuário.
+// Quem atua em mais de uma prefeitura escolhe via ?tenant_id.
+func (h *Handler) SecretariaLivePresence(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(httpmiddleware.GetSubject(r.Context()))
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	tenantID, ok := h.secretariaScope(w, r, userID)
+	if !ok {
+		return
+	}
+
+	live, err := h.livePresence.Tenant(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar presença", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, live)
+}
+
+// secretariaGestor resolve o usuário e a prefeitura das rotas de gestão da secretaria.
+func (h *Handler) secretariaGestor(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	tenantID, ok := h.secretariaScope(w, r, userID)
+	return tenantID, userID, ok
+}
+
+// secretariaScope resolve a prefeitura do gestor municipal; quem atua em mais de uma escolhe via ?tenant_id.
+func (h *Handler) secretariaScope(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (uuid.UUID, bool) {
+	tenants, err := h.secretariaTenants(r.Context(), userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível identificar a prefeitura", nil)
+		return uuid.Nil, false
+	}
+	if len(tenants) == 0 {
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "usuário sem prefeitura vinculada", nil)
+		return uuid.Nil, false
+	}
+
+	tenantID := tenants[0]
+	if raw := strings.TrimSpace(r.URL.Query().Get("tenant_id")); raw != "" {
+		requested, err := uuid.Parse(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "tenant_id inválido", nil)
+			return uuid.Nil, false
+		}
+		found := false
+		for _, id := range tenants {
+			if id == requested {
+				found = true
+				break
+			}
+		}
+		if !found {
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso à prefeitura", nil)
+			return uuid.Nil, false
+		}
+		tenantID = requested
+	} else if len(tenants) > 1 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe tenant_id", map[string]any{"tenants": tenants})
+		return uuid.Nil, false
+	}
+	if _, ok := h.rotearTenant(w, r, tenantID); !ok {
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
+
+// secretariaTenants lista as prefeituras em que o usuário tem papel fixo de gestão, papel
+// personalizado ou delegação vigente que conceda alguma das permissões exigidas pela rota. Os
+// vínculos de uma prefeitura movida estão no cluster dela, então a busca passa por todos.
+func (h *Handler) secretariaTenants(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return h.tenantsPorCluster(ctx, func(ctx context.Context) ([]uuid.UUID, error) {
+		return h.secretariaTenantsNoCluster(ctx, userID)
+	})
+}
+
+func (h *Handler) secretariaTenantsNoCluster(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	perms := httpmiddleware.GetPermissions(ctx)
+	rows, err := h.conn(ctx).Query(ctx, `
+		SELECT s.tenant_id
+		FROM usuarios_secretarias us
+		JOIN secretarias s ON s.id = us.secretaria_id
+		WHERE us.usuario_id = $1
+		  AND us.papel IN ('SECRETARIO', 'PREFEITO', 'ADMIN_TEC', 'AUDITOR')
+		  AND s.tenant_id IS NOT NULL
+		  AND ($3::uuid[] IS NULL OR s.tenant_id = ANY($3))
+		UNION
+		SELECT r.tenant_id
+		FROM backoffice_role_membros m
+		JOIN backoffice_roles r ON r.id = m.role_id
+		WHERE m.usuario_id = $1
+		  AND (cardinality($2::text[]) = 0 OR r.permissoes && $2::text[])
+		  AND ($3::uuid[] IS NULL OR r.tenant_id = ANY($3))
+		UNION
+		SELECT d.tenant_id
+		FROM delegacoes d
+		WHERE d.delegado_id = $1
+		  AND d.revogada_em IS NULL
+		  AND (now() AT TIME ZONE 'America/Sao_Paulo')::date BETWEEN d.inicio AND d.fim
+		  AND (cardinality($2::text[]) = 0 OR d.permissoes && $2::text[])
+		  AND ($3::uuid[] IS NULL OR d.tenant_id = ANY($3))
+		ORDER BY 1
+	`, userID, append([]string{}, perms...), db.Tenants(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, id)
+	}
+	return tenants, rows.Err()
+}
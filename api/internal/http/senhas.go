@@ -0,0 +1,524 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/senha"
+)
+
+type senhaFilaPayload struct {
+	SecretariaID string  `json:"secretaria_id"`
+	Nome         string  `json:"nome"`
+	Prefixo      string  `json:"prefixo"`
+	Local        *string `json:"local"`
+	Ativa        *bool   `json:"ativa"`
+}
+
+type senhaEmissaoPayload struct {
+	FilaID       string `json:"fila_id"`
+	Preferencial bool   `json:"preferencial"`
+}
+
+// ListPublicSenhaFilas lista as filas abertas da prefeitura do domínio, para o app e o totem.
+func (h *Handler) ListPublicSenhaFilas(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	filas, err := h.senhas.ListFilas(r.Context(), tenantInfo.ID, senha.FilaFilter{SomenteAtivas: true})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as filas", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"filas": filas})
+}
+
+// SenhaPainel alimenta a TV da unidade com as últimas chamadas do dia (?fila_id=).
+func (h *Handler) SenhaPainel(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	raw := r.URL.Query().Get("fila_id")
+	filaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fila_id inválido", nil)
+		return
+	}
+	chamadas, err := h.senhas.Painel(r.Context(), tenantInfo.ID, filaID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar o painel", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"chamadas": chamadas})
+}
+
+// EmitirSenhaTotem imprime a senha pedida no totem; o totem se identifica pelo bearer token
+// gerado pelo administrador da prefeitura.
+func (h *Handler) EmitirSenhaTotem(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	tenantID, totemID, err := h.senhas.TotemPorToken(r.Context(), token)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	filaID, preferencial, ok := decodeSenhaEmissao(w, r)
+	if !ok {
+		return
+	}
+	emitida, err := h.senhas.Emitir(r.Context(), tenantID, senha.EmissaoInput{
+		FilaID:       filaID,
+		Preferencial: preferencial,
+		Origem:       senha.OrigemTotem,
+		TotemID:      &totemID,
+	})
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"senha": emitida})
+}
+
+// RetirarSenha emite a senha pelo app para o cidadão logado.
+func (h *Handler) RetirarSenha(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	filaID, preferencial, ok := decodeSenhaEmissao(w, r)
+	if !ok {
+		return
+	}
+	emitida, err := h.senhas.Emitir(r.Context(), tenantInfo.ID, senha.EmissaoInput{
+		FilaID:       filaID,
+		Preferencial: preferencial,
+		Origem:       senha.OrigemApp,
+		CidadaoID:    &cidadaoID,
+	})
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"senha": emitida})
+}
+
+// ListMinhasSenhas lista as senhas recentes do cidadão, com posição das que aguardam.
+func (h *Handler) ListMinhasSenhas(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	senhas, err := h.senhas.ListDoCidadao(r.Context(), tenantInfo.ID, cidadaoID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as senhas", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"senhas": senhas})
+}
+
+// GetMinhaSenha acompanha a senha do cidadão: situação, posição e espera estimada.
+func (h *Handler) GetMinhaSenha(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	s, err := h.senhas.GetDoCidadao(r.Context(), tenantInfo.ID, cidadaoID, id)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"senha": s})
+}
+
+// CancelarMinhaSenha desiste da senha que ainda não foi chamada.
+func (h *Handler) CancelarMinhaSenha(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	s, err := h.senhas.Cancelar(r.Context(), tenantInfo.ID, cidadaoID, id)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"senha": s})
+}
+
+// ListSenhaFilas lista as filas da prefeitura (?secretaria_id=&ativas=).
+func (h *Handler) ListSenhaFilas(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	raw := query.Get("secretaria_id")
+	secretariaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	filter := senha.FilaFilter{SecretariaID: secretariaID}
+	filter.SomenteAtivas, _ = strconv.ParseBool(query.Get("ativas"))
+	filas, err := h.senhas.ListFilas(r.Context(), tenantID, filter)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar as filas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"filas": filas})
+}
+
+// CreateSenhaFila cadastra um serviço atendido por senha.
+func (h *Handler) CreateSenhaFila(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	input, ok := decodeSenhaFila(w, r, true)
+	if !ok {
+		return
+	}
+	fila, err := h.senhas.CreateFila(r.Context(), tenantID, input)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"fila": fila})
+}
+
+// UpdateSenhaFila altera nome, prefixo, local e situação da fila.
+func (h *Handler) UpdateSenhaFila(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	input, ok := decodeSenhaFila(w, r, false)
+	if !ok {
+		return
+	}
+	fila, err := h.senhas.UpdateFila(r.Context(), tenantID, id, input)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"fila": fila})
+}
+
+// ChamarProximaSenha chama ao guichê a próxima senha das filas que ele atende.
+func (h *Handler) ChamarProximaSenha(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		FilaIDs []string `json:"fila_ids"`
+		Guiche  string   `json:"guiche"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	guiche, err := senha.NormalizeGuiche(payload.Guiche)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	filaIDs := make([]uuid.UUID, 0, len(payload.FilaIDs))
+	vistas := make(map[uuid.UUID]bool, len(payload.FilaIDs))
+	for _, raw := range payload.FilaIDs {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "fila_ids inválido", nil)
+			return
+		}
+		if !vistas[id] {
+			vistas[id] = true
+			filaIDs = append(filaIDs, id)
+		}
+	}
+	if len(filaIDs) == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe as filas atendidas pelo guichê", nil)
+		return
+	}
+	s, err := h.senhas.ChamarProxima(r.Context(), tenantID, userID, filaIDs, guiche)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"senha": s})
+}
+
+// RechamarSenha repete no painel a chamada da senha.
+func (h *Handler) RechamarSenha(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	s, err := h.senhas.Rechamar(r.Context(), tenantID, userID, id)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"senha": s})
+}
+
+// FinalizarSenha encerra o atendimento da senha chamada.
+func (h *Handler) FinalizarSenha(w http.ResponseWriter, r *http.Request) {
+	h.encerrarSenha(w, r, senha.StatusAtendida)
+}
+
+// SenhaAusente registra que o cidadão não compareceu à chamada.
+func (h *Handler) SenhaAusente(w http.ResponseWriter, r *http.Request) {
+	h.encerrarSenha(w, r, senha.StatusAusente)
+}
+
+func (h *Handler) encerrarSenha(w http.ResponseWriter, r *http.Request, status string) {
+	tenantID, userID, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	s, err := h.senhas.Finalizar(r.Context(), tenantID, userID, id, status)
+	if err != nil {
+		writeSenhaError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"senha": s})
+}
+
+// SenhaMetricas resume espera e atendimento por fila (?from=&to=&secretaria_id=); sem período,
+// os últimos 30 dias.
+func (h *Handler) SenhaMetricas(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := h.secretariaGestor(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -30)
+	if value := strings.TrimSpace(query.Get("from")); value != "" {
+		parsed, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "from inválido", nil)
+			return
+		}
+		from = parsed
+	}
+	if value := strings.TrimSpace(query.Get("to")); value != "" {
+		parsed, err := parseISODate(value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "to inválido", nil)
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "período inválido", nil)
+		return
+	}
+	raw := query.Get("secretaria_id")
+	secretariaID, err := optionalUUID(&raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+		return
+	}
+	metricas, err := h.senhas.Metricas(r.Context(), tenantID, secretariaID, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível calcular as métricas", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"metricas": metricas,
+	})
+}
+
+// TenantAdminSenhaTotens lista os totens de autoatendimento da prefeitura.
+func (h *Handler) TenantAdminSenhaTotens(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	totens, err := h.senhas.ListTotens(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar os totens", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"totens": totens})
+}
+
+// TenantAdminCreateSenhaTotem autoriza um totem; o token só aparece nesta resposta.
+func (h *Handler) TenantAdminCreateSenhaTotem(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Nome string `json:"nome"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || strings.TrimSpace(payload.Nome) == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe o nome do totem", nil)
+		return
+	}
+	var createdBy *uuid.UUID
+	if userID, err := h.subjectUUID(r); err == nil {
+		createdBy = &userID
+	}
+	token, err := senha.NovoTokenTotem()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar o token", nil)
+		return
+	}
+	totem, err := h.senhas.CreateTotem(r.Context(), tenantID, strings.TrimSpace(payload.Nome), token, createdBy)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível cadastrar o totem", nil)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"totem": totem, "token": token})
+}
+
+// TenantAdminRevokeSenhaTotem desautoriza o totem.
+func (h *Handler) TenantAdminRevokeSenhaTotem(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if err := h.senhas.RevogarTotem(r.Context(), tenantID, id); err != nil {
+		if errors.Is(err, senha.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "totem não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível revogar o totem", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeSenhaEmissao(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool, bool) {
+	var payload senhaEmissaoPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return uuid.Nil, false, false
+	}
+	filaID, err := uuid.Parse(strings.TrimSpace(payload.FilaID))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "fila_id inválido", nil)
+		return uuid.Nil, false, false
+	}
+	return filaID, payload.Preferencial, true
+}
+
+func decodeSenhaFila(w http.ResponseWriter, r *http.Request, create bool) (senha.FilaInput, bool) {
+	var payload senhaFilaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return senha.FilaInput{}, false
+	}
+	input := senha.FilaInput{
+		Nome:    payload.Nome,
+		Prefixo: payload.Prefixo,
+		Local:   payload.Local,
+		Ativa:   payload.Ativa == nil || *payload.Ativa,
+	}
+	if create {
+		secretariaID, err := optionalUUID(&payload.SecretariaID)
+		if err != nil || secretariaID == nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return senha.FilaInput{}, false
+		}
+		input.SecretariaID = *secretariaID
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return senha.FilaInput{}, false
+	}
+	return input, true
+}
+
+func writeSenhaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, senha.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "senha não encontrada", nil)
+	case errors.Is(err, senha.ErrFilaNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "fila não encontrada", nil)
+	case errors.Is(err, senha.ErrFilaFechada):
+		WriteError(w, http.StatusConflict, "CONFLICT", "fila fechada para novas senhas", nil)
+	case errors.Is(err, senha.ErrPrefixo):
+		WriteError(w, http.StatusConflict, "CONFLICT", "prefixo já usado por outra fila", nil)
+	case errors.Is(err, senha.ErrSenhaAtiva):
+		WriteError(w, http.StatusConflict, "CONFLICT", "você já tem uma senha ativa nesta fila", nil)
+	case errors.Is(err, senha.ErrFilaVazia):
+		WriteError(w, http.StatusConflict, "QUEUE_EMPTY", "nenhuma senha aguardando", nil)
+	case errors.Is(err, senha.ErrSituacao):
+		WriteError(w, http.StatusConflict, "CONFLICT", "operação não permitida na situação atual da senha", nil)
+	case errors.Is(err, senha.ErrSecretaria):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "secretaria inválida", nil)
+	case errors.Is(err, senha.ErrAtendente):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "usuário não atende a secretaria da fila", nil)
+	case errors.Is(err, senha.ErrTotem):
+		WriteError(w, http.StatusUnauthorized, "AUTH", "totem não autorizado", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível processar a senha", nil)
+	}
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/dashboard"
+	"github.com/gestaozabele/municipio/internal/golden"
+	"github.com/gestaozabele/municipio/internal/service"
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+var (
+	snapshotTime = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	snapshotID   = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	snapshotID2  = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+)
+
+// snapshot passa data pelo WriteJSON para que o envelope também faça parte do snapshot.
+func snapshot(t *testing.T, name string, data any) {
+	t.Helper()
+	res := httptest.NewRecorder()
+	WriteJSON(res, http.StatusOK, data)
+	golden.JSON(t, name, res.Body.Bytes())
+}
+
+func TestSnapshotTenantConfig(t *testing.T) {
+	logo := "https://cdn.exemplo.gov.br/zabele/logo.png"
+	checked := snapshotTime.Add(-time.Hour)
+	snapshot(t, "tenant_config", &tenant.Tenant{
+		ID:             snapshotID,
+		Slug:           "zabele",
+		DisplayName:    "Prefeitura de Zabelê",
+		Domain:         "zabele.gestao.app",
+		Status:         tenant.StatusActive,
+		DNSStatus:      tenant.DNSStatusConfigured,
+		DNSLastChecked: &checked,
+		LogoURL:        &logo,
+		Contact:        map[string]any{"email": "contato@zabele.pb.gov.br", "telefone": "(83) 3333-0000"},
+		Theme:          map[string]any{"primary": "#0b5394", "secondary": "#f1c232"},
+		Settings:       map[string]any{"modulos": []string{"educacao", "saude"}},
+		ActivatedAt:    &checked,
+		Environment:    tenant.EnvironmentProduction,
+		CreatedAt:      snapshotTime.AddDate(0, -6, 0),
+		UpdatedAt:      snapshotTime,
+	})
+}
+
+func TestSnapshotMe(t *testing.T) {
+	email := "maria@exemplo.com"
+	snapshot(t, "me_backoffice", meResponse(&service.BackofficeProfile{
+		ID:    snapshotID.String(),
+		Nome:  "João Gestor",
+		Email: "joao@zabele.pb.gov.br",
+		Secretarias: []service.BackofficeSecretaria{
+			{ID: snapshotID2.String(), Nome: "Secretaria de Educação", Slug: "educacao", Papel: "GESTOR"},
+		},
+	}, []string{"GESTOR"}))
+	snapshot(t, "me_cidadao", meResponse(&service.CidadaoProfile{
+		ID:    snapshotID.String(),
+		Nome:  "Maria Cidadã",
+		Email: &email,
+	}, []string{"CIDADAO"}))
+}
+
+func TestSnapshotDashboardOverview(t *testing.T) {
+	owner := snapshotID2
+	insightWait := 12.5
+	static := func(v any) overviewWidget {
+		return overviewWidget{load: func(context.Context) (any, error) { return v, nil }}
+	}
+	widgets := map[string]overviewWidget{
+		dashboard.WidgetMetrics: static(overviewMetrics{
+			CitizensTotal: 1520, ManagersTotal: 12, SecretariesTotal: 8,
+			RequestsTotal: 340, RequestsResolved: 300, RequestsPending: 40,
+			TenantsActive: 3, TenantsTotal: 4, TrafficGB: 18.4, MRR: 12500,
+			ExpensesForecast: 4000, RevenueForecast: 15000, StaffTotal: 25,
+			UsersOnline: 7, TotalAccesses: 9800,
+			ActiveSessions: []sessionAudience{{Audience: "backoffice", Sessions: 5, Users: 4}},
+		}),
+		dashboard.WidgetProjects: static([]projectOverview{{
+			ID: snapshotID, Name: "Implantação Zabelê", Status: "em_andamento", Progress: 0.6,
+			Owner: &owner, UpdatedAt: snapshotTime,
+			Tasks: []projectTaskView{{ID: snapshotID2, Title: "Migrar cadastro", Status: "pendente", Position: 1, CreatedAt: snapshotTime, UpdatedAt: snapshotTime}},
+		}}),
+		dashboard.WidgetCityInsights: static([]cityInsightView{{
+			ID: snapshotID, TenantID: snapshotID2, Name: "Zabelê", Population: 2200,
+			ActiveUsers: 410, RequestsTotal: 340, Satisfaction: 4.6, LastSync: snapshotTime,
+			Highlights: []string{"matrículas online"}, AvgWaitMinutes: &insightWait, ServedLast30d: 180,
+		}}),
+	}
+
+	codes := []string{dashboard.WidgetMetrics, dashboard.WidgetProjects, dashboard.WidgetCityInsights}
+	response, _, err := buildOverview(context.Background(), codes, widgets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot(t, "dashboard_overview", response)
+
+	widgets[dashboard.WidgetProjects] = overviewWidget{
+		load:    func(context.Context) (any, error) { return nil, errors.New("falhou") },
+		message: "não foi possível carregar projetos",
+	}
+	if _, message, err := buildOverview(context.Background(), codes, widgets); err == nil || message != "não foi possível carregar projetos" {
+		t.Fatalf("erro do widget não propagado: %q, %v", message, err)
+	}
+}
@@ -53,6 +53,19 @@ func (h *Handler) ListSupportTickets(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	portfolio, err := h.loadSaaSPortfolio(r)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return
+	}
+	if portfolio.scoped {
+		if filter.TenantID != nil && !portfolio.Allows(*filter.TenantID) {
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", "tenant fora da sua carteira", nil)
+			return
+		}
+		filter.TenantIDs = portfolio.IDs()
+	}
+
 	tickets, err := h.support.ListTickets(r.Context(), filter)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar tickets", nil)
@@ -91,6 +104,16 @@ func (h *Handler) CreateSupportTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	portfolio, err := h.loadSaaSPortfolio(r)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar carteira", nil)
+		return
+	}
+	if !portfolio.Allows(tenantID) {
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "tenant fora da sua carteira", nil)
+		return
+	}
+
 	creatorID, err := h.subjectUUID(r)
 	if err != nil {
 		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
@@ -125,7 +148,10 @@ func (h *Handler) CreateSupportTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusCreated, map[string]any{"ticket": ticket})
+	WriteJSON(w, http.StatusCreated, map[string]any{
+		"ticket":             ticket,
+		"suggested_articles": h.suggestKBArticles(r, ticket.TenantID, ticket.Subject, ticket.Description),
+	})
 }
 
 // GetSupportTicket devolve detalhes do chamado.
@@ -223,6 +249,11 @@ func (h *Handler) ListSupportTicketMessages(w http.ResponseWriter, r *http.Reque
 		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar mensagens", nil)
 		return
 	}
+	for i := range messages {
+		for j := range messages[i].Attachments {
+			messages[i].Attachments[j].DownloadURL = fileDownloadPath(messages[i].Attachments[j].ID)
+		}
+	}
 
 	WriteJSON(w, http.StatusOK, map[string]any{"messages": messages})
 }
@@ -242,6 +273,8 @@ func (h *Handler) AddSupportTicketMessage(w http.ResponseWriter, r *http.Request
 
 	var payload struct {
 		Body string `json:"body"`
+		// Internal registra nota da equipe: não vai por e-mail nem aparece para o contato do tenant.
+		Internal bool `json:"internal"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -260,6 +293,7 @@ func (h *Handler) AddSupportTicketMessage(w http.ResponseWriter, r *http.Request
 		AuthorType: support.AuthorSaaS,
 		AuthorID:   &authorID,
 		Body:       payload.Body,
+		Internal:   payload.Internal,
 	})
 	if err != nil {
 		switch {
@@ -271,5 +305,10 @@ func (h *Handler) AddSupportTicketMessage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	WriteJSON(w, http.StatusCreated, map[string]any{"message": message})
+	emailStatus := "skipped"
+	if !payload.Internal {
+		emailStatus = h.sendSupportReply(r.Context(), ticketID, message)
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{"message": message, "email": emailStatus})
 }
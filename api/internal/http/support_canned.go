@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/support"
+)
+
+type cannedResponsePayload struct {
+	Title    string   `json:"title"`
+	Shortcut *string  `json:"shortcut"`
+	Category *string  `json:"category"`
+	Body     string   `json:"body"`
+	Tags     []string `json:"tags"`
+}
+
+// ListCannedResponses lista respostas prontas, filtrando por categoria e texto (?category=&q=).
+func (h *Handler) ListCannedResponses(w http.ResponseWriter, r *http.Request) {
+	if h.support == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "módulo de suporte indisponível", nil)
+		return
+	}
+
+	responses, err := h.support.ListCanned(r.Context(), support.CannedFilter{
+		Category: r.URL.Query().Get("category"),
+		Query:    r.URL.Query().Get("q"),
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar respostas prontas", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"canned_responses": responses})
+}
+
+// CreateCannedResponse cadastra resposta pronta.
+func (h *Handler) CreateCannedResponse(w http.ResponseWriter, r *http.Request) {
+	h.saveCannedResponse(w, r, nil)
+}
+
+// UpdateCannedResponse substitui resposta pronta.
+func (h *Handler) UpdateCannedResponse(w http.ResponseWriter, r *http.Request) {
+	cannedID, err := parseUUIDParam(r, "cannedID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	h.saveCannedResponse(w, r, &cannedID)
+}
+
+func (h *Handler) saveCannedResponse(w http.ResponseWriter, r *http.Request, cannedID *uuid.UUID) {
+	if h.support == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "módulo de suporte indisponível", nil)
+		return
+	}
+
+	var payload cannedResponsePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	canned, err := h.support.SaveCanned(r.Context(), cannedID, support.CannedResponseInput{
+		Title:    payload.Title,
+		Shortcut: payload.Shortcut,
+		Category: payload.Category,
+		Body:     payload.Body,
+		Tags:     payload.Tags,
+		ActorID:  &actorID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, support.ErrCannedNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "resposta pronta não encontrada", nil)
+		case errors.Is(err, support.ErrCannedShortcut):
+			WriteError(w, http.StatusConflict, "CONFLICT", "atalho já utilizado", nil)
+		default:
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if cannedID == nil {
+		status = http.StatusCreated
+	}
+	WriteJSON(w, status, map[string]any{"canned_response": canned})
+}
+
+// DeleteCannedResponse remove resposta pronta.
+func (h *Handler) DeleteCannedResponse(w http.ResponseWriter, r *http.Request) {
+	if h.support == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "módulo de suporte indisponível", nil)
+		return
+	}
+	cannedID, err := parseUUIDParam(r, "cannedID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+
+	if err := h.support.DeleteCanned(r.Context(), cannedID); err != nil {
+		if errors.Is(err, support.ErrCannedNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "resposta pronta não encontrada", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível remover resposta pronta", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewCannedResponse devolve a resposta pronta preenchida com os dados do chamado, para edição antes do envio.
+func (h *Handler) PreviewCannedResponse(w http.ResponseWriter, r *http.Request) {
+	ticketID, cannedID, ok := h.cannedParams(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := h.support.RenderCanned(r.Context(), cannedID, ticketID, h.cannedVars(r, ticketID))
+	if err != nil {
+		writeCannedError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"body": body})
+}
+
+// ReplyWithCannedResponse insere a resposta pronta no chamado de uma vez; com "internal" vira nota interna.
+func (h *Handler) ReplyWithCannedResponse(w http.ResponseWriter, r *http.Request) {
+	ticketID, cannedID, ok := h.cannedParams(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Internal bool              `json:"internal"`
+		Vars     map[string]string `json:"vars"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	authorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	vars := h.cannedVars(r, ticketID)
+	for key, value := range payload.Vars {
+		vars[key] = value
+	}
+	body, err := h.support.RenderCanned(r.Context(), cannedID, ticketID, vars)
+	if err != nil {
+		writeCannedError(w, err)
+		return
+	}
+
+	message, err := h.support.AddMessage(r.Context(), support.CreateMessageInput{
+		TicketID:   ticketID,
+		AuthorType: support.AuthorSaaS,
+		AuthorID:   &authorID,
+		Body:       body,
+		Internal:   payload.Internal,
+	})
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	_ = h.support.UseCanned(r.Context(), cannedID)
+
+	emailStatus := "skipped"
+	if !payload.Internal {
+		emailStatus = h.sendSupportReply(r.Context(), ticketID, message)
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{"message": message, "email": emailStatus})
+}
+
+func (h *Handler) cannedParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	if h.support == nil {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "módulo de suporte indisponível", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	ticketID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	cannedID, err := parseUUIDParam(r, "cannedID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id da resposta inválido", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return ticketID, cannedID, true
+}
+
+// cannedVars reúne nome do atendente e do município para os marcadores da resposta pronta.
+func (h *Handler) cannedVars(r *http.Request, ticketID uuid.UUID) map[string]string {
+	vars := map[string]string{}
+	ctx := r.Context()
+	if agentID, err := h.subjectUUID(r); err == nil {
+		var name string
+		if err := h.pool.QueryRow(ctx, `SELECT name FROM saas_users WHERE id = $1`, agentID).Scan(&name); err == nil {
+			vars["atendente.nome"] = strings.TrimSpace(name)
+		}
+	}
+	if ticket, err := h.support.GetTicket(ctx, ticketID); err == nil {
+		if name := h.tenantDisplayName(ctx, ticket.TenantID); name != "" {
+			vars["tenant.nome"] = name
+		}
+	}
+	return vars
+}
+
+func (h *Handler) tenantDisplayName(ctx context.Context, tenantID uuid.UUID) string {
+	t, err := h.tenants.GetByID(ctx, tenantID)
+	if err != nil {
+		return ""
+	}
+	return t.DisplayName
+}
+
+func writeCannedError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, support.ErrCannedNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "resposta pronta não encontrada", nil)
+	case errors.Is(err, support.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "ticket não encontrado", nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível preparar resposta pronta", nil)
+	}
+}
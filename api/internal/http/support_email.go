@@ -0,0 +1,288 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/mail"
+	"github.com/gestaozabele/municipio/internal/storage"
+	"github.com/gestaozabele/municipio/internal/support"
+)
+
+const (
+	maxInboundEmailBytes = 40 << 20
+	// mailgunSignatureMaxAge rejeita reenvios antigos de uma mesma assinatura.
+	mailgunSignatureMaxAge = 15 * time.Minute
+	supportReplyTimeout    = 20 * time.Second
+)
+
+// InboundSupportMailgun recebe a rota de e-mail do Mailgun e converte em chamado ou resposta.
+func (h *Handler) InboundSupportMailgun(w http.ResponseWriter, r *http.Request) {
+	if h.support == nil || h.cfg.SupportEmail.MailgunSigningKey == "" {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "recebimento de e-mail indisponível", nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundEmailBytes)
+	if err := r.ParseMultipartForm(maxInboundEmailBytes); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "formulário inválido", nil)
+		return
+	}
+
+	timestamp := r.FormValue("timestamp")
+	if !support.VerifyMailgunSignature(h.cfg.SupportEmail.MailgunSigningKey, timestamp, r.FormValue("token"), r.FormValue("signature")) {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "assinatura inválida", nil)
+		return
+	}
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(ts, 0)).Abs() > mailgunSignatureMaxAge {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "assinatura expirada", nil)
+		return
+	}
+
+	email, err := support.ParseMailgunForm(r.MultipartForm)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	h.receiveSupportEmail(w, r, email)
+}
+
+// InboundSupportSES recebe a notificação SNS de uma regra de recebimento do SES com o e-mail bruto.
+// O tópico deve publicar para esta URL com ?token=<SUPPORT_INBOUND_TOKEN>.
+func (h *Handler) InboundSupportSES(w http.ResponseWriter, r *http.Request) {
+	token := h.cfg.SupportEmail.InboundToken
+	if h.support == nil || token == "" {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "recebimento de e-mail indisponível", nil)
+		return
+	}
+	provided := r.URL.Query().Get("token")
+	if provided == "" {
+		provided = r.Header.Get("X-Inbound-Token")
+	}
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "token inválido", nil)
+		return
+	}
+
+	var envelope struct {
+		Type         string `json:"Type"`
+		Message      string `json:"Message"`
+		SubscribeURL string `json:"SubscribeURL"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxInboundEmailBytes)).Decode(&envelope); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	switch envelope.Type {
+	case "SubscriptionConfirmation":
+		if err := confirmSNSSubscription(r.Context(), envelope.SubscribeURL); err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "subscribed"})
+		return
+	case "Notification":
+	default:
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	var notification struct {
+		NotificationType string `json:"notificationType"`
+		Content          string `json:"content"`
+		Receipt          struct {
+			Action struct {
+				Encoding string `json:"encoding"`
+			} `json:"action"`
+		} `json:"receipt"`
+	}
+	if err := json.Unmarshal([]byte(envelope.Message), &notification); err != nil || notification.NotificationType != "Received" {
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	raw := []byte(notification.Content)
+	if strings.EqualFold(notification.Receipt.Action.Encoding, "BASE64") {
+		decoded, err := base64.StdEncoding.DecodeString(notification.Content)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "conteúdo base64 inválido", nil)
+			return
+		}
+		raw = decoded
+	}
+
+	email, err := support.ParseMIME(raw)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	h.receiveSupportEmail(w, r, email)
+}
+
+func (h *Handler) receiveSupportEmail(w http.ResponseWriter, r *http.Request, email support.InboundEmail) {
+	ticket, message, created, err := h.support.ReceiveEmail(r.Context(), h.cfg.SupportEmail.Address, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, support.ErrDuplicateEmail):
+			WriteJSON(w, http.StatusOK, map[string]string{"status": "duplicate"})
+		case errors.Is(err, support.ErrUnroutableEmail):
+			// 406 faz o Mailgun desistir da entrega em vez de repetir.
+			WriteError(w, http.StatusNotAcceptable, "UNROUTABLE", err.Error(), nil)
+		default:
+			log.Error().Err(err).Str("from", email.From).Msg("suporte: falha ao registrar e-mail recebido")
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar e-mail", nil)
+		}
+		return
+	}
+
+	stored := h.storeSupportAttachments(r.Context(), ticket.ID, message.ID, email.Attachments)
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"ticket_id":   ticket.ID,
+		"message_id":  message.ID,
+		"created":     created,
+		"attachments": stored,
+	})
+}
+
+// storeSupportAttachments envia os anexos ao armazenamento; arquivos sinalizados pelo antivírus
+// ou que falharem no envio são descartados sem perder a mensagem.
+func (h *Handler) storeSupportAttachments(ctx context.Context, ticketID, messageID uuid.UUID, attachments []support.InboundAttachment) int {
+	if len(attachments) == 0 || h.storage == nil {
+		return 0
+	}
+	switch h.storage.(type) {
+	case storage.NoopUploader, *storage.NoopUploader:
+		log.Warn().Str("ticket_id", ticketID.String()).Int("attachments", len(attachments)).Msg("suporte: anexos descartados, armazenamento indisponível")
+		return 0
+	}
+
+	stored := 0
+	for _, att := range attachments {
+		if len(att.Data) == 0 {
+			continue
+		}
+		if h.scanner != nil {
+			if result, err := h.scanner.Scan(ctx, att.Data); err != nil || result.Infected() {
+				log.Warn().Err(err).Str("ticket_id", ticketID.String()).Str("file", att.Name).Str("signature", result.Signature).Msg("suporte: anexo descartado pelo antivírus")
+				continue
+			}
+		}
+
+		contentType := att.ContentType
+		if contentType == "" {
+			contentType = http.DetectContentType(att.Data)
+		}
+		key := fmt.Sprintf("support/%s/%s/%s%s", ticketID, messageID, uuid.NewString(), strings.ToLower(filepath.Ext(att.Name)))
+		result, err := h.storage.Upload(ctx, storage.UploadInput{
+			Key:          key,
+			Body:         att.Data,
+			ContentType:  contentType,
+			CacheControl: "private,max-age=31536000",
+		})
+		if err != nil {
+			log.Error().Err(err).Str("ticket_id", ticketID.String()).Str("file", att.Name).Msg("suporte: falha ao enviar anexo")
+			continue
+		}
+		if _, err := h.support.AddAttachment(ctx, support.CreateAttachmentInput{
+			MessageID:   messageID,
+			FileName:    att.Name,
+			ContentType: contentType,
+			SizeBytes:   int64(len(att.Data)),
+			ObjectKey:   key,
+			FileURL:     result.URL,
+		}); err != nil {
+			log.Error().Err(err).Str("ticket_id", ticketID.String()).Str("file", att.Name).Msg("suporte: falha ao registrar anexo")
+			continue
+		}
+		stored++
+	}
+	return stored
+}
+
+// sendSupportReply coloca na fila de e-mail a mensagem ao solicitante do chamado aberto por e-mail,
+// encadeada às anteriores. Devolve "queued", "suppressed" (tenant sandbox), "skipped" (sem
+// solicitante ou sem provedor) ou "failed".
+func (h *Handler) sendSupportReply(ctx context.Context, ticketID uuid.UUID, message *support.Message) string {
+	if h.mailer == nil || h.outbox == nil || h.cfg.SupportEmail.Address == "" {
+		return "skipped"
+	}
+	ticket, err := h.support.GetTicket(ctx, ticketID)
+	if err != nil || ticket.RequesterEmail == nil || *ticket.RequesterEmail == "" {
+		return "skipped"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, supportReplyTimeout)
+	defer cancel()
+
+	thread, err := h.support.EmailThread(ctx, ticket.ID)
+	if err != nil {
+		log.Error().Err(err).Str("ticket_id", ticket.ID.String()).Msg("suporte: falha ao carregar encadeamento")
+		return "failed"
+	}
+
+	subject := ticket.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	_, domain, _ := strings.Cut(h.cfg.SupportEmail.Address, "@")
+	msg := mail.Message{
+		To:         []string{*ticket.RequesterEmail},
+		ReplyTo:    support.ReplyAddress(h.cfg.SupportEmail.Address, ticket.ID),
+		MessageID:  mail.NewMessageID(domain),
+		References: thread,
+		TenantID:   ticket.TenantID,
+	}
+	if len(thread) > 0 {
+		msg.InReplyTo = thread[len(thread)-1]
+	}
+
+	if status := h.queueMail(ctx, mail.TemplateTicketReply, mail.TicketReplyData{Subject: subject, Body: message.Body}, msg); status != "queued" {
+		return status
+	}
+	// O Message-ID é gravado já na fila para que respostas do solicitante encadeiem no chamado
+	// mesmo antes da entrega.
+	if err := h.support.SetMessageEmail(ctx, message.ID, msg.MessageID); err != nil {
+		log.Error().Err(err).Str("ticket_id", ticket.ID.String()).Msg("suporte: falha ao gravar Message-ID da resposta")
+	}
+	message.EmailMessageID = &msg.MessageID
+	return "queued"
+}
+
+// confirmSNSSubscription segue o SubscribeURL somente para endpoints HTTPS da AWS.
+func confirmSNSSubscription(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || !strings.HasSuffix(parsed.Hostname(), ".amazonaws.com") {
+		return errors.New("SubscribeURL inválida")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("confirmação SNS: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("confirmação SNS: status %d", resp.StatusCode)
+	}
+	return nil
+}
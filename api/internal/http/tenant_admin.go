@@ -0,0 +1,671 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/monitor"
+	"github.com/gestaozabele/municipio/internal/scim"
+)
+
+// tenantAdminPapeis lista os papéis que o administrador delegado pode conceder; ADMIN_TEC
+// continua reservado à equipe SaaS.
+var tenantAdminPapeis = map[string]struct{}{
+	"ATENDENTE":  {},
+	"SECRETARIO": {},
+	"PREFEITO":   {},
+	"AUDITOR":    {},
+}
+
+type tenantAdminPapelPayload struct {
+	SecretariaID string `json:"secretaria_id"`
+	Papel        string `json:"papel"`
+}
+
+// tenantAdminScope resolve a prefeitura administrada pelo usuário. Quem administra mais de
+// uma precisa informar ?tenant_id=.
+func (h *Handler) tenantAdminScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return uuid.Nil, false
+	}
+
+	tenants, err := h.tenantAdminTenants(r.Context(), userID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar prefeitura", nil)
+		return uuid.Nil, false
+	}
+
+	tenantID := uuid.Nil
+	switch len(tenants) {
+	case 0:
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "usuário não administra nenhuma prefeitura", nil)
+		return uuid.Nil, false
+	case 1:
+		tenantID = tenants[0]
+	default:
+		requested, err := uuid.Parse(strings.TrimSpace(r.URL.Query().Get("tenant_id")))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "informe tenant_id", nil)
+			return uuid.Nil, false
+		}
+		for _, id := range tenants {
+			if id == requested {
+				tenantID = id
+				break
+			}
+		}
+		if tenantID == uuid.Nil {
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", "prefeitura fora da sua administração", nil)
+			return uuid.Nil, false
+		}
+	}
+	if _, ok := h.rotearTenant(w, r, tenantID); !ok {
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
+
+// tenantAdminTenants lista as prefeituras administradas pelo usuário. tenant_admins é do plano de
+// controle e fica no primary; os auditores, que consultam a administração das prefeituras em que
+// têm o papel AUDITOR, vêm dos vínculos de cada cluster.
+func (h *Handler) tenantAdminTenants(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := h.pool.Query(ctx, `SELECT tenant_id FROM tenant_admins WHERE usuario_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	admins, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, err
+	}
+	auditados, err := h.tenantsPorCluster(ctx, func(ctx context.Context) ([]uuid.UUID, error) {
+		rows, err := h.conn(ctx).Query(ctx, `
+            SELECT DISTINCT s.tenant_id FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE us.usuario_id = $1 AND us.papel = 'AUDITOR' AND s.tenant_id IS NOT NULL
+              AND ($2::uuid[] IS NULL OR s.tenant_id = ANY($2))`, userID, db.Tenants(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	})
+	if err != nil {
+		return nil, err
+	}
+	tenants := admins
+	for _, id := range auditados {
+		if !slices.Contains(tenants, id) {
+			tenants = append(tenants, id)
+		}
+	}
+	return tenants, nil
+}
+
+// TenantAdminStaff lista a equipe do backoffice da própria prefeitura.
+func (h *Handler) TenantAdminStaff(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+
+	staff, err := h.loadTenantStaff(r.Context(), tenantID, strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q"))))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar equipe", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"staff":   staff,
+		"summary": summarizeTenantStaff(staff, time.Now()),
+	})
+}
+
+// TenantAdminCreateStaff cadastra um usuário do backoffice já vinculado a secretarias da prefeitura.
+func (h *Handler) TenantAdminCreateStaff(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Nome   string                    `json:"nome"`
+		Email  string                    `json:"email"`
+		Senha  string                    `json:"senha"`
+		Papeis []tenantAdminPapelPayload `json:"papeis"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	nome := strings.TrimSpace(payload.Nome)
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+	if nome == "" || !strings.Contains(email, "@") {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "nome e email são obrigatórios", nil)
+		return
+	}
+	policy, err := h.tenantAuthPolicy(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a política de acesso", nil)
+		return
+	}
+	if err := policy.ValidatePassword(payload.Senha); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	if len(payload.Papeis) == 0 {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe ao menos um papel", nil)
+		return
+	}
+	secretarias, papeis, ok := parseTenantAdminPapeis(w, payload.Papeis)
+	if !ok {
+		return
+	}
+
+	hash, err := auth.Hash(payload.Senha)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível cadastrar usuário", nil)
+		return
+	}
+
+	userID := uuid.New()
+	err = h.tenantAdminTx(r.Context(), r, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `INSERT INTO usuarios (id, nome, email, senha_hash) VALUES ($1, $2, $3, $4)`, userID, nome, email, hash); err != nil {
+			return err
+		}
+		return replaceTenantStaffPapeis(ctx, tx, tenantID, userID, secretarias, papeis)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			WriteError(w, http.StatusConflict, "CONFLICT", "email já cadastrado", nil)
+			return
+		}
+		writeTenantAdminStaffError(w, err)
+		return
+	}
+
+	h.writeTenantAdminStaff(w, r, tenantID, http.StatusCreated)
+}
+
+// TenantAdminUpdateStaffPapeis substitui os papéis do usuário nas secretarias da prefeitura.
+func (h *Handler) TenantAdminUpdateStaffPapeis(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	userID, err := parseUUIDParam(r, "userID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuário inválido", nil)
+		return
+	}
+
+	var payload struct {
+		Papeis []tenantAdminPapelPayload `json:"papeis"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	secretarias, papeis, ok := parseTenantAdminPapeis(w, payload.Papeis)
+	if !ok {
+		return
+	}
+
+	err = h.tenantAdminTx(r.Context(), r, func(ctx context.Context, tx pgx.Tx) error {
+		if err := ensureTenantStaffMember(ctx, tx, tenantID, userID); err != nil {
+			return err
+		}
+		return replaceTenantStaffPapeis(ctx, tx, tenantID, userID, secretarias, papeis)
+	})
+	if err != nil {
+		writeTenantAdminStaffError(w, err)
+		return
+	}
+
+	h.writeTenantAdminStaff(w, r, tenantID, http.StatusOK)
+}
+
+// TenantAdminUpdateStaffStatus ativa ou desativa um usuário da prefeitura, encerrando as sessões
+// de quem for desativado.
+func (h *Handler) TenantAdminUpdateStaffStatus(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	userID, err := parseUUIDParam(r, "userID")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "usuário inválido", nil)
+		return
+	}
+
+	var payload struct {
+		Ativo *bool `json:"ativo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Ativo == nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe ativo", nil)
+		return
+	}
+	if actorID, err := h.subjectUUID(r); err == nil && actorID == userID && !*payload.Ativo {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "não é possível desativar a si mesmo", nil)
+		return
+	}
+
+	err = h.tenantAdminTx(r.Context(), r, func(ctx context.Context, tx pgx.Tx) error {
+		if err := ensureTenantStaffMember(ctx, tx, tenantID, userID); err != nil {
+			return err
+		}
+		if err := ensureTenantStaffExclusivo(ctx, tx, tenantID, userID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE usuarios SET ativo = $2 WHERE id = $1`, userID, *payload.Ativo); err != nil {
+			return err
+		}
+		if !*payload.Ativo {
+			_, err := tx.Exec(ctx, `UPDATE tokens_refresh SET revogado = TRUE WHERE subject = $1 AND audience = 'backoffice' AND NOT revogado`, userID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		writeTenantAdminStaffError(w, err)
+		return
+	}
+
+	h.writeTenantAdminStaff(w, r, tenantID, http.StatusOK)
+}
+
+// TenantAdminContract mostra o contrato e as faturas liberadas da própria prefeitura.
+func (h *Handler) TenantAdminContract(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+
+	contract, err := h.fetchTenantContract(r.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "contrato não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar contrato", nil)
+		return
+	}
+
+	// Faturas em quarentena não chegam à prefeitura e o download assinado é exclusivo do SaaS.
+	invoices := make([]tenantInvoiceView, 0, len(contract.Invoices))
+	for _, invoice := range contract.Invoices {
+		if invoice.Quarantined {
+			continue
+		}
+		invoice.DownloadURL = nil
+		invoices = append(invoices, invoice)
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"contract": map[string]any{
+			"status":         contract.Status,
+			"contract_value": contract.ContractValue,
+			"start_date":     contract.StartDate,
+			"renewal_date":   contract.RenewalDate,
+			"modules":        contract.Modules,
+			"billing_tier":   contract.BillingTier,
+		},
+		"invoices": invoices,
+	})
+}
+
+// TenantAdminUsage resume o uso da plataforma pela prefeitura.
+func (h *Handler) TenantAdminUsage(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+
+	staff, err := h.loadTenantStaff(r.Context(), tenantID, "")
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar uso", nil)
+		return
+	}
+
+	var escolas, turmas, matriculas int
+	err = h.conn(r.Context()).QueryRow(r.Context(), `
+        SELECT (SELECT count(*) FROM escolas WHERE tenant_id = $1),
+               (SELECT count(*) FROM turmas t JOIN escolas e ON e.id = t.escola_id WHERE e.tenant_id = $1),
+               (SELECT count(*) FROM matriculas m
+                  JOIN turmas t ON t.id = m.turma_id
+                  JOIN escolas e ON e.id = t.escola_id
+                 WHERE e.tenant_id = $1 AND m.ativo)
+    `, tenantID).Scan(&escolas, &turmas, &matriculas)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar uso", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"staff": summarizeTenantStaff(staff, time.Now()),
+		"educacao": map[string]int{
+			"escolas":           escolas,
+			"turmas":            turmas,
+			"matriculas_ativas": matriculas,
+		},
+	})
+}
+
+// TenantAdminMonitor mostra disponibilidade e anomalias recentes da própria prefeitura.
+func (h *Handler) TenantAdminMonitor(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	if h.monitor == nil || !h.monitorOn {
+		WriteError(w, http.StatusServiceUnavailable, "INTERNAL", "monitoramento indisponível", nil)
+		return
+	}
+
+	health, err := h.monitor.TenantHealth(r.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, monitor.ErrNoData) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "sem leituras ainda", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar métricas", nil)
+		return
+	}
+
+	anomalies, err := h.monitor.Anomalies(r.Context(), &tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar métricas", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"health": health, "anomalies": anomalies})
+}
+
+// ListTenantAdmins lista os administradores delegados da prefeitura (SaaS admin).
+func (h *Handler) ListTenantAdmins(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	h.writeTenantAdmins(w, r, tenantID)
+}
+
+// UpdateTenantAdmins define quais membros da equipe administram a prefeitura (SaaS admin).
+func (h *Handler) UpdateTenantAdmins(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	if _, err := h.tenants.GetByID(r.Context(), tenantID); err != nil {
+		writeTenantLookupError(w, err)
+		return
+	}
+
+	var payload struct {
+		UsuarioIDs []uuid.UUID `json:"usuario_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if actorID, err := h.subjectUUID(r); err == nil {
+		createdBy = &actorID
+	}
+
+	// Só membros da equipe da própria prefeitura podem administrá-la. A equipe está no cluster da
+	// prefeitura; tenant_admins, no primary.
+	routed, ok := h.rotearTenant(w, r, tenantID)
+	if !ok {
+		return
+	}
+	admins := payload.UsuarioIDs
+	if len(admins) > 0 {
+		rows, err := h.conn(routed).Query(routed, `
+            SELECT DISTINCT us.usuario_id
+            FROM usuarios_secretarias us
+            JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE s.tenant_id = $1 AND us.usuario_id = ANY($2)
+        `, tenantID, payload.UsuarioIDs)
+		if err == nil {
+			admins, err = pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		}
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar administradores", nil)
+			return
+		}
+		if len(admins) != len(uniqueUUIDs(payload.UsuarioIDs)) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "usuario_ids contém usuários fora da equipe da prefeitura", nil)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar administradores", nil)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tenant_admins WHERE tenant_id = $1 AND NOT (usuario_id = ANY($2))`, tenantID, admins); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar administradores", nil)
+		return
+	}
+	if len(admins) > 0 {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO tenant_admins (tenant_id, usuario_id, created_by)
+            SELECT $1, unnest($2::uuid[]), $3::uuid
+            ON CONFLICT (tenant_id, usuario_id) DO NOTHING
+        `, tenantID, admins, createdBy); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar administradores", nil)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar administradores", nil)
+		return
+	}
+
+	h.writeTenantAdmins(w, r, tenantID)
+}
+
+func (h *Handler) writeTenantAdmins(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) {
+	rows, err := h.pool.Query(r.Context(), `
+        SELECT u.id, u.nome, u.email, u.ativo, ta.created_at
+        FROM tenant_admins ta
+        JOIN usuarios u ON u.id = ta.usuario_id
+        WHERE ta.tenant_id = $1
+        ORDER BY u.nome NULLS LAST, u.email
+    `, tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar administradores", nil)
+		return
+	}
+	defer rows.Close()
+
+	type adminView struct {
+		ID        uuid.UUID `json:"id"`
+		Nome      *string   `json:"nome,omitempty"`
+		Email     string    `json:"email"`
+		Ativo     bool      `json:"ativo"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	admins := make([]adminView, 0)
+	for rows.Next() {
+		var item adminView
+		if err := rows.Scan(&item.ID, &item.Nome, &item.Email, &item.Ativo, &item.CreatedAt); err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar administradores", nil)
+			return
+		}
+		admins = append(admins, item)
+	}
+	if err := rows.Err(); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar administradores", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"admins": admins})
+}
+
+var (
+	errTenantStaffNotMember = errors.New("usuário não pertence à prefeitura")
+	errTenantSecretaria     = errors.New("secretaria inexistente ou de outra prefeitura")
+	errTenantStaffShared    = errors.New("usuário também atua em outra prefeitura; remova os papéis dele nesta em vez de desativar a conta")
+)
+
+func parseTenantAdminPapeis(w http.ResponseWriter, items []tenantAdminPapelPayload) ([]uuid.UUID, []string, bool) {
+	secretarias := make([]uuid.UUID, 0, len(items))
+	papeis := make([]string, 0, len(items))
+	seen := make(map[uuid.UUID]struct{}, len(items))
+	for _, item := range items {
+		id, err := uuid.Parse(strings.TrimSpace(item.SecretariaID))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria_id inválido", nil)
+			return nil, nil, false
+		}
+		if _, dup := seen[id]; dup {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "secretaria repetida", nil)
+			return nil, nil, false
+		}
+		seen[id] = struct{}{}
+		papel := strings.ToUpper(strings.TrimSpace(item.Papel))
+		if _, ok := tenantAdminPapeis[papel]; !ok {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "papel deve ser ATENDENTE, SECRETARIO, PREFEITO ou AUDITOR", nil)
+			return nil, nil, false
+		}
+		secretarias = append(secretarias, id)
+		papeis = append(papeis, papel)
+	}
+	return secretarias, papeis, true
+}
+
+// tenantAdminTx identifica o autor na transação para que o log de privilégios registre quem
+// alterou os papéis. Roda no cluster da prefeitura fixado por tenantAdminScope.
+func (h *Handler) tenantAdminTx(ctx context.Context, r *http.Request, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		return err
+	}
+	return db.WithActorTx(ctx, h.conn(ctx), &actorID, fn)
+}
+
+// tenantSettingsTx é o tenantAdminTx das configurações em tenants.settings, que pertencem ao plano
+// de controle e ficam no primary, onde o login e os workers as leem.
+func (h *Handler) tenantSettingsTx(ctx context.Context, r *http.Request, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		return err
+	}
+	return db.WithActorTx(ctx, h.pool, &actorID, fn)
+}
+
+func ensureTenantStaffMember(ctx context.Context, tx pgx.Tx, tenantID, userID uuid.UUID) error {
+	var member bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM usuarios_secretarias us
+            JOIN secretarias s ON s.id = us.secretaria_id
+            WHERE us.usuario_id = $1 AND s.tenant_id = $2
+        )
+    `, userID, tenantID).Scan(&member); err != nil {
+		return err
+	}
+	if !member {
+		return errTenantStaffNotMember
+	}
+	return nil
+}
+
+// ensureTenantStaffExclusivo recusa mexer na conta de quem tem vínculo com outra prefeitura:
+// usuarios.ativo e os refresh tokens do backoffice valem para todas elas.
+func ensureTenantStaffExclusivo(ctx context.Context, tx pgx.Tx, tenantID, userID uuid.UUID) error {
+	var shared bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM (`+scim.VinculosDoUsuario+`) vinculos
+            WHERE vinculos.tenant_id IS DISTINCT FROM $2
+        )
+        FROM usuarios u
+        WHERE u.id = $1
+        FOR UPDATE OF u
+    `, userID, tenantID).Scan(&shared); err != nil {
+		return err
+	}
+	if shared {
+		return errTenantStaffShared
+	}
+	return nil
+}
+
+// replaceTenantStaffPapeis troca apenas os vínculos com secretarias desta prefeitura; papéis
+// em outras prefeituras e o ADMIN_TEC concedido pelo SaaS permanecem.
+func replaceTenantStaffPapeis(ctx context.Context, tx pgx.Tx, tenantID, userID uuid.UUID, secretarias []uuid.UUID, papeis []string) error {
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM usuarios_secretarias us
+        USING secretarias s
+        WHERE s.id = us.secretaria_id AND s.tenant_id = $2 AND us.usuario_id = $1
+          AND us.papel <> 'ADMIN_TEC' AND NOT (us.secretaria_id = ANY($3::uuid[]))
+    `, userID, tenantID, secretarias); err != nil {
+		return err
+	}
+	if len(secretarias) == 0 {
+		return nil
+	}
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO usuarios_secretarias (usuario_id, secretaria_id, papel)
+        SELECT $1, s.id, v.papel
+        FROM unnest($3::uuid[], $4::text[]) AS v(secretaria_id, papel)
+        JOIN secretarias s ON s.id = v.secretaria_id AND s.tenant_id = $2
+        ON CONFLICT (usuario_id, secretaria_id) DO UPDATE SET papel = EXCLUDED.papel
+        WHERE usuarios_secretarias.papel <> 'ADMIN_TEC'
+    `, userID, tenantID, secretarias, papeis)
+	if err != nil {
+		return err
+	}
+	if int(tag.RowsAffected()) != len(secretarias) {
+		return errTenantSecretaria
+	}
+	return nil
+}
+
+func writeTenantAdminStaffError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errTenantStaffNotMember):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, errTenantSecretaria):
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+	case errors.Is(err, errTenantStaffShared):
+		WriteError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	default:
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar equipe", nil)
+	}
+}
+
+func (h *Handler) writeTenantAdminStaff(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID, status int) {
+	staff, err := h.loadTenantStaff(r.Context(), tenantID, "")
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "falha ao carregar equipe", nil)
+		return
+	}
+
+	WriteJSON(w, status, map[string]any{
+		"staff":   staff,
+		"summary": summarizeTenantStaff(staff, time.Now()),
+	})
+}
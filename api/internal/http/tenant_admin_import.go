@@ -0,0 +1,359 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+	"github.com/gestaozabele/municipio/internal/mail"
+)
+
+const (
+	staffImportMaxLines = 1000
+	staffInviteTTL      = 7 * 24 * time.Hour
+)
+
+type staffImportResult struct {
+	Line      int        `json:"line"`
+	Email     string     `json:"email"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error,omitempty"`
+	UsuarioID *uuid.UUID `json:"usuario_id,omitempty"`
+	Convite   string     `json:"convite,omitempty"`
+}
+
+// staffImportSecretaria é a secretaria da prefeitura, localizada no CSV por id, slug ou nome.
+type staffImportSecretaria struct {
+	ID   uuid.UUID
+	Nome string
+}
+
+// TenantAdminImportStaff cadastra a equipe do backoffice a partir de um CSV com as colunas nome,
+// email, secretaria e papel. O papel é ATENDENTE, SECRETARIO, PREFEITO ou o código de um papel
+// personalizado (o usuário entra como atendente e recebe o papel). Cada usuário criado recebe um
+// convite por e-mail para definir a senha; com ?dry_run=1 as linhas são só validadas.
+func (h *Handler) TenantAdminImportStaff(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	dryRun := false
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("dry_run"))) {
+	case "1", "true", "yes", "sim":
+		dryRun = true
+	}
+
+	if err := r.ParseMultipartForm(5 << 20); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "não foi possível ler arquivo", nil)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "arquivo CSV obrigatório", nil)
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "CSV vazio ou inválido", nil)
+		return
+	}
+	if len(headers) == 1 && strings.Contains(headers[0], ";") {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "use vírgula como separador", nil)
+		return
+	}
+	columnIndex := map[string]int{}
+	for idx, col := range headers {
+		columnIndex[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))] = idx
+	}
+	for _, key := range []string{"nome", "email", "secretaria", "papel"} {
+		if _, ok := columnIndex[key]; !ok {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", fmt.Sprintf("coluna %s obrigatória", key), nil)
+			return
+		}
+	}
+
+	secretarias, roles, err := h.loadStaffImportLookups(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar secretarias", nil)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	results := []staffImportResult{}
+	seenEmails := map[string]int{}
+	createdCount := 0
+	lineNumber := 1
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			WriteError(w, http.StatusBadRequest, "VALIDATION", fmt.Sprintf("erro ao ler CSV: %v", err), nil)
+			return
+		}
+		lineNumber++
+		if lineNumber-1 > staffImportMaxLines {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", fmt.Sprintf("importe no máximo %d linhas por arquivo", staffImportMaxLines), nil)
+			return
+		}
+
+		nome := strings.TrimSpace(valueFromCSV(record, columnIndex, "nome"))
+		email := strings.ToLower(strings.TrimSpace(valueFromCSV(record, columnIndex, "email")))
+		secretariaRef := strings.ToLower(strings.TrimSpace(valueFromCSV(record, columnIndex, "secretaria")))
+		papelRef := strings.TrimSpace(valueFromCSV(record, columnIndex, "papel"))
+
+		res := staffImportResult{Line: lineNumber, Email: email}
+		if nome == "" && email == "" && secretariaRef == "" && papelRef == "" {
+			continue
+		}
+		if nome == "" || !strings.Contains(email, "@") || secretariaRef == "" || papelRef == "" {
+			res.Error = "nome, email, secretaria e papel são obrigatórios"
+			results = append(results, res)
+			continue
+		}
+		if prevLine, ok := seenEmails[email]; ok {
+			res.Error = fmt.Sprintf("email duplicado (linha %d)", prevLine)
+			results = append(results, res)
+			continue
+		}
+		seenEmails[email] = lineNumber
+
+		secretaria, ok := secretarias[secretariaRef]
+		if !ok {
+			res.Error = "secretaria não encontrada na prefeitura"
+			results = append(results, res)
+			continue
+		}
+		papel := strings.ToUpper(papelRef)
+		var roleID *uuid.UUID
+		if _, fixo := tenantAdminPapeis[papel]; !fixo {
+			id, ok := roles[strings.ToLower(papelRef)]
+			if !ok {
+				res.Error = "papel deve ser ATENDENTE, SECRETARIO, PREFEITO ou o código de um papel personalizado"
+				results = append(results, res)
+				continue
+			}
+			papel, roleID = "ATENDENTE", &id
+		}
+
+		var exists bool
+		if err := h.conn(r.Context()).QueryRow(r.Context(), `SELECT EXISTS (SELECT 1 FROM usuarios WHERE lower(email) = $1)`, email).Scan(&exists); err != nil {
+			res.Error = "não foi possível verificar o email"
+			results = append(results, res)
+			continue
+		}
+		if exists {
+			res.Error = "email já cadastrado"
+			results = append(results, res)
+			continue
+		}
+
+		if dryRun {
+			res.Success = true
+			results = append(results, res)
+			continue
+		}
+
+		userID, token, err := h.createImportedStaff(r.Context(), r, tenantID, actorID, nome, email, secretaria.ID, papel, roleID)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				res.Error = "email já cadastrado"
+			} else {
+				res.Error = "não foi possível cadastrar usuário"
+			}
+			results = append(results, res)
+			continue
+		}
+		createdCount++
+		res.Success = true
+		res.UsuarioID = &userID
+		res.Convite = h.sendStaffInviteEmail(r.Context(), tenantID, nome, email, papelRef, token)
+		results = append(results, res)
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"dry_run": dryRun,
+		"created": createdCount,
+		"results": results,
+	})
+}
+
+// AcceptStaffInvite define a senha do usuário convidado e invalida o convite.
+func (h *Handler) AcceptStaffInvite(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token string `json:"token"`
+		Senha string `json:"senha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	token := strings.TrimSpace(payload.Token)
+	if token == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "token obrigatório", nil)
+		return
+	}
+	tokenHash := auth.HashRefreshToken(token)
+
+	// A senha segue a política mais rígida entre as prefeituras em que o convidado já atua.
+	var invitedID uuid.UUID
+	if err := h.pool.QueryRow(r.Context(), `
+        SELECT usuario_id FROM usuarios_convites
+        WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > now()`, tokenHash).Scan(&invitedID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "convite inválido, expirado ou já utilizado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível definir a senha", nil)
+		return
+	}
+	policy, err := h.authService.BackofficePolicy(r.Context(), invitedID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível definir a senha", nil)
+		return
+	}
+	if err := policy.ValidatePassword(payload.Senha); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+	hash, err := auth.Hash(payload.Senha)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível definir a senha", nil)
+		return
+	}
+
+	var email string
+	err = pgx.BeginFunc(r.Context(), h.pool, func(tx pgx.Tx) error {
+		var userID uuid.UUID
+		if err := tx.QueryRow(r.Context(), `
+            UPDATE usuarios_convites SET accepted_at = now()
+            WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > now()
+            RETURNING usuario_id`, tokenHash).Scan(&userID); err != nil {
+			return err
+		}
+		return tx.QueryRow(r.Context(), `UPDATE usuarios SET senha_hash = $2 WHERE id = $1 RETURNING email`, userID, hash).Scan(&email)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "convite inválido, expirado ou já utilizado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível definir a senha", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"email": email})
+}
+
+// loadStaffImportLookups indexa as secretarias da prefeitura por id, slug e nome e os papéis
+// personalizados por código.
+func (h *Handler) loadStaffImportLookups(ctx context.Context, tenantID uuid.UUID) (map[string]staffImportSecretaria, map[string]uuid.UUID, error) {
+	rows, err := h.conn(ctx).Query(ctx, `SELECT id, slug, nome FROM secretarias WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	secretarias := map[string]staffImportSecretaria{}
+	for rows.Next() {
+		var s staffImportSecretaria
+		var slug string
+		if err := rows.Scan(&s.ID, &slug, &s.Nome); err != nil {
+			return nil, nil, err
+		}
+		for _, key := range []string{s.ID.String(), strings.ToLower(slug), strings.ToLower(strings.TrimSpace(s.Nome))} {
+			if key != "" {
+				secretarias[key] = s
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	roleList, err := h.roles.List(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	roles := make(map[string]uuid.UUID, len(roleList))
+	for _, role := range roleList {
+		roles[role.Codigo] = role.ID
+	}
+	return secretarias, roles, nil
+}
+
+// createImportedStaff cria o usuário com senha aleatória, o vínculo com a secretaria, o papel
+// personalizado se houver e o convite, tudo na mesma transação.
+func (h *Handler) createImportedStaff(ctx context.Context, r *http.Request, tenantID, actorID uuid.UUID, nome, email string, secretariaID uuid.UUID, papel string, roleID *uuid.UUID) (uuid.UUID, string, error) {
+	senha := make([]byte, 32)
+	if _, err := rand.Read(senha); err != nil {
+		return uuid.Nil, "", err
+	}
+	senhaHash, err := auth.Hash(hex.EncodeToString(senha))
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	token, tokenHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	userID := uuid.New()
+	err = h.tenantAdminTx(ctx, r, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `INSERT INTO usuarios (id, nome, email, senha_hash) VALUES ($1, $2, $3, $4)`, userID, nome, email, senhaHash); err != nil {
+			return err
+		}
+		if err := replaceTenantStaffPapeis(ctx, tx, tenantID, userID, []uuid.UUID{secretariaID}, []string{papel}); err != nil {
+			return err
+		}
+		if roleID != nil {
+			if _, err := tx.Exec(ctx, `INSERT INTO backoffice_role_membros (role_id, usuario_id) VALUES ($1, $2)`, *roleID, userID); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(ctx, `
+            INSERT INTO usuarios_convites (usuario_id, tenant_id, token_hash, expires_at, created_by)
+            VALUES ($1, $2, $3, $4, $5)`, userID, tenantID, tokenHash, time.Now().Add(staffInviteTTL), actorID)
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return userID, token, nil
+}
+
+// sendStaffInviteEmail envia o link de primeiro acesso ao servidor importado.
+func (h *Handler) sendStaffInviteEmail(ctx context.Context, tenantID uuid.UUID, nome, email, papel, token string) string {
+	if h.cfg.Mail.BackofficeURL == "" {
+		return "skipped"
+	}
+	return h.queueMail(ctx, mail.TemplateInvite, mail.InviteData{
+		Name:      nome,
+		Role:      papel,
+		Link:      h.cfg.Mail.BackofficeURL + "/convite?token=" + url.QueryEscape(token),
+		ExpiresAt: time.Now().Add(staffInviteTTL),
+	}, mail.Message{To: []string{email}, TenantID: tenantID})
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool principal.
+func (h *Handler) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, h.pool)
+}
+
+// rotearTenant fixa o pool do cluster da prefeitura no lugar reservado por TenantDatabaseSlot, que
+// o contexto da requisição já enxerga, e também no contexto devolvido, para as rotas sem reserva.
+// Responde 503 quando a prefeitura está congelada pela migração de cluster ou o banco dela caiu.
+func (h *Handler) rotearTenant(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) (context.Context, bool) {
+	if h.databases == nil {
+		return r.Context(), true
+	}
+	pool, err := h.databases.Pool(r.Context(), tenantID)
+	if errors.Is(err, db.ErrTenantFrozen) {
+		w.Header().Set("Retry-After", "30")
+		WriteError(w, http.StatusServiceUnavailable, "TENANT_MIGRATING", "prefeitura em migração de banco; tente novamente em instantes", nil)
+		return nil, false
+	}
+	if err != nil {
+		WriteError(w, http.StatusServiceUnavailable, "DATABASE_UNAVAILABLE", "banco da prefeitura indisponível", nil)
+		return nil, false
+	}
+	db.SetPool(r.Context(), pool)
+	return db.WithPool(r.Context(), pool), true
+}
+
+// eachCluster roda fn em cada cluster (db.Resolver.EachCluster); sem resolver, só no principal.
+func (h *Handler) eachCluster(ctx context.Context, fn func(ctx context.Context) error) error {
+	if h.databases == nil {
+		return fn(ctx)
+	}
+	return h.databases.EachCluster(ctx, fn)
+}
+
+// tenantsPorCluster junta as prefeituras devolvidas por fn em cada cluster, sem repetição e na
+// ordem do uuid, a mesma do ORDER BY do Postgres.
+func (h *Handler) tenantsPorCluster(ctx context.Context, fn func(ctx context.Context) ([]uuid.UUID, error)) ([]uuid.UUID, error) {
+	var tenants []uuid.UUID
+	err := h.eachCluster(ctx, func(ctx context.Context) error {
+		ids, err := fn(ctx)
+		tenants = append(tenants, ids...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(tenants, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	return slices.Compact(tenants), nil
+}
+
+// permissoesPorCluster confere papéis personalizados e delegações em todos os clusters: os de uma
+// prefeitura movida só existem no banco dela.
+type permissoesPorCluster struct {
+	roles     *authz.Repository
+	databases *db.Resolver
+}
+
+func (p permissoesPorCluster) HasAnyPermission(ctx context.Context, userID uuid.UUID, perms []string) (bool, error) {
+	var allowed bool
+	err := p.databases.EachCluster(ctx, func(ctx context.Context) error {
+		if allowed {
+			return nil
+		}
+		ok, err := p.roles.HasAnyPermission(ctx, userID, perms)
+		allowed = ok
+		return err
+	})
+	if allowed {
+		// Um cluster fora do ar não tira a permissão já encontrada em outro.
+		return true, nil
+	}
+	return false, err
+}
+
+func (p permissoesPorCluster) ActiveDelegacao(ctx context.Context, userID uuid.UUID, perms []string) (*authz.Delegacao, error) {
+	var found *authz.Delegacao
+	err := p.databases.EachCluster(ctx, func(ctx context.Context) error {
+		d, err := p.roles.ActiveDelegacao(ctx, userID, perms)
+		if d != nil && (found == nil || d.Fim.Before(found.Fim)) {
+			found = d
+		}
+		return err
+	})
+	if found != nil {
+		return found, nil
+	}
+	return nil, err
+}
+
+// RecordDelegacaoAcao grava a ação no cluster da prefeitura da delegação.
+func (p permissoesPorCluster) RecordDelegacaoAcao(ctx context.Context, delegacaoID, userID uuid.UUID, metodo, rota string, status int) error {
+	if d := authz.DelegacaoFrom(ctx); d != nil && d.ID == delegacaoID {
+		routed, err := p.databases.WithTenant(ctx, d.TenantID)
+		if err != nil {
+			return err
+		}
+		ctx = routed
+	}
+	return p.roles.RecordDelegacaoAcao(ctx, delegacaoID, userID, metodo, rota, status)
+}
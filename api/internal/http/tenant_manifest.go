@@ -0,0 +1,119 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+type tenantManifestEntry struct {
+	Domain      string    `json:"domain"`
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug"`
+	Status      string    `json:"status"`
+	Environment string    `json:"environment"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type tenantManifest struct {
+	Tenants   []tenantManifestEntry `json:"tenants"`
+	UpdatedAt *time.Time            `json:"updated_at,omitempty"`
+}
+
+// TenantManifest devolve o mapa domínio→tenant para os workers de borda sincronizarem de uma vez,
+// em vez de consultar /tenant a cada requisição. Rascunhos e arquivados ficam de fora; suspensos
+// seguem listados para a borda exibir a página de suspensão.
+func (h *Handler) TenantManifest(w http.ResponseWriter, r *http.Request) {
+	token := h.cfg.Edge.ManifestToken
+	if token == "" {
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "manifesto de tenants não configurado", nil)
+		return
+	}
+	provided := strings.TrimSpace(r.Header.Get("X-API-Key"))
+	if provided == "" {
+		provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "chave de API inválida", nil)
+		return
+	}
+
+	tenants, err := h.tenants.List(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("manifesto de tenants: listar")
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar tenants", nil)
+		return
+	}
+
+	manifest := buildTenantManifest(tenants)
+	body, err := json.Marshal(SuccessEnvelope{Data: manifest})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível montar manifesto", nil)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=0, must-revalidate")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func buildTenantManifest(tenants []tenant.Tenant) tenantManifest {
+	manifest := tenantManifest{Tenants: make([]tenantManifestEntry, 0, len(tenants))}
+	for _, t := range tenants {
+		if t.Domain == "" || t.Status == tenant.StatusDraft || t.Status == tenant.StatusArchived {
+			continue
+		}
+		manifest.Tenants = append(manifest.Tenants, tenantManifestEntry{
+			Domain:      t.Domain,
+			ID:          t.ID,
+			Slug:        t.Slug,
+			Status:      t.Status,
+			Environment: t.Environment,
+			UpdatedAt:   t.UpdatedAt.UTC(),
+		})
+		if manifest.UpdatedAt == nil || t.UpdatedAt.After(*manifest.UpdatedAt) {
+			updated := t.UpdatedAt.UTC()
+			manifest.UpdatedAt = &updated
+		}
+	}
+	sort.Slice(manifest.Tenants, func(i, j int) bool {
+		return manifest.Tenants[i].Domain < manifest.Tenants[j].Domain
+	})
+	return manifest
+}
+
+// etagMatches interpreta If-None-Match, aceitando lista de tags, "*" e o prefixo fraco W/.
+func etagMatches(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
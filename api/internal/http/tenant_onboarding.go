@@ -0,0 +1,97 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/onboarding"
+)
+
+// TenantAdminOnboarding devolve o checklist de implantação exibido no primeiro acesso.
+func (h *Handler) TenantAdminOnboarding(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	h.writeOnboarding(w, r, tenantID)
+}
+
+// TenantAdminSkipOnboardingTask pula uma tarefa opcional do checklist.
+func (h *Handler) TenantAdminSkipOnboardingTask(w http.ResponseWriter, r *http.Request) {
+	h.setOnboardingTaskSkipped(w, r, true)
+}
+
+// TenantAdminUnskipOnboardingTask volta a exigir uma tarefa pulada.
+func (h *Handler) TenantAdminUnskipOnboardingTask(w http.ResponseWriter, r *http.Request) {
+	h.setOnboardingTaskSkipped(w, r, false)
+}
+
+func (h *Handler) setOnboardingTaskSkipped(w http.ResponseWriter, r *http.Request, skip bool) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	if err := h.onboarding.Skip(r.Context(), tenantID, chi.URLParam(r, "code"), skip); err != nil {
+		if errors.Is(err, onboarding.ErrUnknownTask) {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "tarefa inexistente ou obrigatória", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar checklist", nil)
+		return
+	}
+	h.writeOnboarding(w, r, tenantID)
+}
+
+// TenantAdminDismissOnboarding oculta o checklist da prefeitura.
+func (h *Handler) TenantAdminDismissOnboarding(w http.ResponseWriter, r *http.Request) {
+	h.setOnboardingDismissed(w, r, true)
+}
+
+// TenantAdminReopenOnboarding volta a exibir o checklist.
+func (h *Handler) TenantAdminReopenOnboarding(w http.ResponseWriter, r *http.Request) {
+	h.setOnboardingDismissed(w, r, false)
+}
+
+func (h *Handler) setOnboardingDismissed(w http.ResponseWriter, r *http.Request, dismiss bool) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	userID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	if err := h.onboarding.Dismiss(r.Context(), tenantID, userID, dismiss); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar checklist", nil)
+		return
+	}
+	h.writeOnboarding(w, r, tenantID)
+}
+
+// GetTenantOnboarding mostra à equipe SaaS o andamento da implantação do tenant.
+func (h *Handler) GetTenantOnboarding(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "id inválido", nil)
+		return
+	}
+	h.writeOnboarding(w, r, tenantID)
+}
+
+func (h *Handler) writeOnboarding(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) {
+	checklist, err := h.onboarding.Load(r.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "tenant não encontrado", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar checklist", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"tenant_id": tenantID, "onboarding": checklist})
+}
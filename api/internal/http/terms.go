@@ -0,0 +1,147 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/terms"
+)
+
+type termsVersionPayload struct {
+	Audience    string  `json:"audience"`
+	Kind        string  `json:"kind"`
+	Version     string  `json:"version"`
+	Title       string  `json:"title"`
+	Body        string  `json:"body"`
+	Blocking    *bool   `json:"blocking"`
+	EffectiveAt *string `json:"effective_at"`
+}
+
+// PendingTerms devolve as versões vigentes dos termos do público do token e as ainda não aceitas.
+func (h *Handler) PendingTerms(w http.ResponseWriter, r *http.Request) {
+	audience := httpmiddleware.GetAudience(r.Context())
+	current, err := h.terms.Current(r.Context(), audience)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar termos", nil)
+		return
+	}
+	pending, err := h.terms.Pending(r.Context(), audience, httpmiddleware.GetSubject(r.Context()))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar termos", nil)
+		return
+	}
+	if pending == nil {
+		pending = []terms.Version{}
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"current": current, "pending": pending})
+}
+
+// AcceptTerms registra o aceite com IP e user agent. Sem version_ids, aceita todas as pendentes.
+func (h *Handler) AcceptTerms(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		VersionIDs []string `json:"version_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+
+	audience := httpmiddleware.GetAudience(r.Context())
+	subject := httpmiddleware.GetSubject(r.Context())
+
+	ids := make([]uuid.UUID, 0, len(payload.VersionIDs))
+	for _, raw := range payload.VersionIDs {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "version_ids inválido", nil)
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		pending, err := h.terms.Pending(r.Context(), audience, subject)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar termos", nil)
+			return
+		}
+		for _, v := range pending {
+			ids = append(ids, v.ID)
+		}
+	}
+
+	if err := h.terms.Accept(r.Context(), audience, subject, ids, httpmiddleware.ClientIP(r), r.UserAgent()); err != nil {
+		if errors.Is(err, terms.ErrNotCurrent) {
+			WriteError(w, http.StatusConflict, "CONFLICT", "versão dos termos não está vigente", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar aceite", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{"accepted": ids})
+}
+
+// ListTermsVersions lista as versões publicadas para a equipe SaaS.
+func (h *Handler) ListTermsVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.terms.List(r.Context(), r.URL.Query().Get("audience"))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar termos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"versions": versions})
+}
+
+// CreateTermsVersion publica uma nova versão dos termos ou do DPA para um público.
+func (h *Handler) CreateTermsVersion(w http.ResponseWriter, r *http.Request) {
+	var payload termsVersionPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "JSON inválido", nil)
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	input := terms.Input{
+		Audience: payload.Audience,
+		Kind:     payload.Kind,
+		Version:  payload.Version,
+		Title:    payload.Title,
+		Body:     payload.Body,
+		Blocking: true,
+		ActorID:  &actorID,
+	}
+	if payload.Blocking != nil {
+		input.Blocking = *payload.Blocking
+	}
+	if payload.EffectiveAt != nil && strings.TrimSpace(*payload.EffectiveAt) != "" {
+		ts, err := parseISODate(*payload.EffectiveAt)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "effective_at inválido", nil)
+			return
+		}
+		input.EffectiveAt = &ts
+	}
+	if err := input.Normalize(); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	version, err := h.terms.Create(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, terms.ErrDuplicate) {
+			WriteError(w, http.StatusConflict, "CONFLICT", "versão já registrada", nil)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível publicar termos", nil)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, map[string]any{"version": version})
+}
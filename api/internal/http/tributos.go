@@ -0,0 +1,306 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+	"github.com/gestaozabele/municipio/internal/tributos"
+)
+
+// TenantAdminTributos mostra a integração tributária da prefeitura sem expor o token.
+func (h *Handler) TenantAdminTributos(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.tributos.GetConfig(r.Context(), tenantID)
+	if errors.Is(err, tributos.ErrNotConfigured) {
+		WriteJSON(w, http.StatusOK, map[string]any{"configurado": false, "providers": []string{tributos.ProviderREST, tributos.ProviderDemo}})
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a integração tributária", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, tributosConfigView(cfg))
+}
+
+// TenantAdminUpdateTributos grava a integração; token omitido preserva o já cadastrado.
+func (h *Handler) TenantAdminUpdateTributos(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantAdminScope(w, r)
+	if !ok {
+		return
+	}
+	actorID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	var payload struct {
+		Provider string  `json:"provider"`
+		APIBase  string  `json:"api_base"`
+		APIToken *string `json:"api_token"`
+		Convenio string  `json:"convenio"`
+		Ativo    *bool   `json:"ativo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	cfg := tributos.Config{
+		Provider: strings.ToLower(strings.TrimSpace(payload.Provider)),
+		APIBase:  strings.TrimSpace(payload.APIBase),
+		Convenio: strings.TrimSpace(payload.Convenio),
+		Ativo:    payload.Ativo == nil || *payload.Ativo,
+	}
+	if payload.APIToken != nil {
+		cfg.APIToken = strings.TrimSpace(*payload.APIToken)
+	} else if current, err := h.tributos.GetConfig(r.Context(), tenantID); err == nil {
+		cfg.APIToken = current.APIToken
+	} else if !errors.Is(err, tributos.ErrNotConfigured) {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a integração tributária", nil)
+		return
+	}
+	if cfg.Provider == "" {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "provider obrigatório", nil)
+		return
+	}
+	if cfg.APIBase != "" && !strings.HasPrefix(cfg.APIBase, "https://") {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "a API do sistema tributário deve usar https", nil)
+		return
+	}
+
+	if cfg.Provider == tributos.ProviderDemo {
+		info, err := h.tenants.GetByID(r.Context(), tenantID)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar prefeitura", nil)
+			return
+		}
+		if info.Environment != tenant.EnvironmentSandbox {
+			WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "o provedor demo só pode ser usado em ambientes sandbox", nil)
+			return
+		}
+	}
+	// Desativada, a integração pode ficar incompleta; ativa, precisa montar o adaptador.
+	check := cfg
+	check.Ativo = true
+	if _, err := tributos.New(check); err != nil {
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	if err := h.tributos.SaveConfig(r.Context(), tenantID, cfg, actorID); err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível salvar a integração tributária", nil)
+		return
+	}
+	saved, err := h.tributos.GetConfig(r.Context(), tenantID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a integração tributária", nil)
+		return
+	}
+	WriteJSON(w, http.StatusOK, tributosConfigView(saved))
+}
+
+// ListMeusDebitos consulta no sistema tributário os débitos em aberto do CPF/CNPJ informado.
+func (h *Handler) ListMeusDebitos(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	documento, err := tributos.NormalizeDocumento(query.Get("documento"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe um CPF ou CNPJ válido", nil)
+		return
+	}
+	tributo, err := tributos.NormalizeTributo(query.Get("tributo"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "tributo inválido", map[string]any{"allowed": []string{tributos.TributoIPTU, tributos.TributoISS}})
+		return
+	}
+
+	provider, err := h.tributos.Provider(r.Context(), tenantInfo.ID)
+	if err != nil {
+		writeTributosError(w, err)
+		return
+	}
+	debitos, err := provider.Debitos(r.Context(), tributos.Consulta{
+		Documento: documento,
+		Inscricao: strings.TrimSpace(query.Get("inscricao")),
+		Tributo:   tributo,
+	})
+	if err != nil {
+		writeTributosError(w, err)
+		return
+	}
+	if debitos == nil {
+		debitos = []tributos.Debito{}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"debitos": debitos, "total": tributos.Totalizar(debitos)})
+}
+
+// EmitirGuiaTributo emite a segunda via dos débitos escolhidos e a registra para o cidadão.
+func (h *Handler) EmitirGuiaTributo(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	var payload struct {
+		Documento  string   `json:"documento"`
+		Debitos    []string `json:"debitos"`
+		Vencimento *string  `json:"vencimento"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+	documento, err := tributos.NormalizeDocumento(payload.Documento)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "informe um CPF ou CNPJ válido", nil)
+		return
+	}
+	vencimento, err := optionalDate(payload.Vencimento)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "vencimento inválido (use AAAA-MM-DD)", nil)
+		return
+	}
+	emissao := tributos.Emissao{Documento: documento, DebitoIDs: payload.Debitos}
+	if vencimento != nil {
+		emissao.Vencimento = *vencimento
+	}
+	if emissao, err = emissao.Normalize(time.Now()); err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "selecione de 1 a 24 débitos e um vencimento nos próximos 30 dias", nil)
+		return
+	}
+
+	provider, err := h.tributos.Provider(r.Context(), tenantInfo.ID)
+	if err != nil {
+		writeTributosError(w, err)
+		return
+	}
+	guia, err := provider.EmitirGuia(r.Context(), emissao)
+	if err != nil {
+		writeTributosError(w, err)
+		return
+	}
+	emitida, err := h.tributos.CreateGuia(r.Context(), tenantInfo.ID, cidadaoID, provider.Name(), documento, guia)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível registrar a guia", nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusCreated, emitida)
+}
+
+// ListMinhasGuias lista as segundas vias emitidas pelo cidadão.
+func (h *Handler) ListMinhasGuias(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	guias, err := h.tributos.ListGuias(r.Context(), tenantInfo.ID, cidadaoID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar guias", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, map[string]any{"guias": guias})
+}
+
+// GuiaTributoPDF devolve o PDF oficial do fornecedor ou, sem ele, a guia desenhada pelo app.
+func (h *Handler) GuiaTributoPDF(w http.ResponseWriter, r *http.Request) {
+	tenantInfo, ok := h.requestTenant(w, r)
+	if !ok {
+		return
+	}
+	cidadaoID, err := h.subjectUUID(r)
+	if err != nil {
+		WriteError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	id, err := parseUUIDParam(r, "id")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "VALIDATION", "guia inválida", nil)
+		return
+	}
+
+	guia, content, err := h.tributos.GetGuia(r.Context(), tenantInfo.ID, cidadaoID, id)
+	if errors.Is(err, tributos.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "guia não encontrada", nil)
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar a guia", nil)
+		return
+	}
+	if len(content) == 0 {
+		content, err = tributos.RenderPDF(guia.Guia, tributos.Cabecalho{
+			Prefeitura:   tenantInfo.DisplayName,
+			Contribuinte: tributos.MaskDocumento(guia.Documento),
+		})
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível gerar o PDF da guia", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="guia-`+guia.NossoNumero+`.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+func tributosConfigView(cfg *tributos.Integracao) map[string]any {
+	return map[string]any{
+		"configurado":     true,
+		"provider":        cfg.Provider,
+		"api_base":        cfg.APIBase,
+		"api_token_salvo": cfg.APIToken != "",
+		"convenio":        cfg.Convenio,
+		"ativo":           cfg.Ativo,
+		"updated_by":      cfg.UpdatedBy,
+		"updated_at":      cfg.UpdatedAt,
+		"providers":       []string{tributos.ProviderREST, tributos.ProviderDemo},
+	}
+}
+
+func writeTributosError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, tributos.ErrNotConfigured):
+		WriteError(w, http.StatusNotFound, "NOT_CONFIGURED", "a prefeitura ainda não oferece consulta de tributos pelo app", nil)
+	case errors.Is(err, tributos.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "débito não encontrado para o contribuinte", nil)
+	case errors.Is(err, tributos.ErrInvalid):
+		WriteError(w, http.StatusUnprocessableEntity, "VALIDATION", "o sistema tributário recusou os dados informados", nil)
+	case errors.Is(err, tributos.ErrUnavailable):
+		WriteError(w, http.StatusBadGateway, "UPSTREAM", "sistema tributário indisponível, tente novamente mais tarde", nil)
+	default:
+		// Configuração gravada que não monta adaptador (ex.: token removido) também cai aqui.
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "integração tributária indisponível", nil)
+	}
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/address"
+)
+
+// LookupCEP devolve o endereço normalizado do CEP para preencher cadastros e formulários.
+func (h *Handler) LookupCEP(w http.ResponseWriter, r *http.Request) {
+	addr, err := h.address.Lookup(r.Context(), chi.URLParam(r, "cep"))
+	if err != nil {
+		switch {
+		case errors.Is(err, address.ErrInvalidCEP):
+			WriteError(w, http.StatusBadRequest, "VALIDATION", "CEP inválido", nil)
+		case errors.Is(err, address.ErrNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "CEP não encontrado", nil)
+		default:
+			log.Warn().Err(err).Msg("cep: provedores indisponíveis")
+			WriteError(w, http.StatusBadGateway, "UPSTREAM", "consulta de CEP indisponível no momento", nil)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	WriteJSON(w, http.StatusOK, map[string]any{"address": addr})
+}
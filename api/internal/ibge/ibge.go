@@ -0,0 +1,212 @@
+package ibge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultAPIBase = "https://servicodados.ibge.gov.br/api"
+
+// populationAggregate é a tabela SIDRA 6579 (estimativas de população), variável 9324.
+const populationAggregate = "/v3/agregados/6579/periodos/-1/variaveis/9324"
+
+// ErrNotFound indica município não localizado no IBGE.
+var ErrNotFound = errors.New("ibge: município não encontrado")
+
+// Municipality reúne os dados cadastrais e a população estimada do município.
+type Municipality struct {
+	Code           int    `json:"code"`
+	Name           string `json:"name"`
+	UF             string `json:"uf"`
+	Region         string `json:"region"`
+	Population     int64  `json:"population"`
+	PopulationYear int    `json:"population_year"`
+}
+
+// Client consulta as APIs de localidades e agregados do IBGE.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// New cria o cliente; base vazia usa a API pública do IBGE.
+func New(base string) *Client {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		base = defaultAPIBase
+	}
+	return &Client{httpClient: &http.Client{Timeout: 10 * time.Second}, baseURL: strings.TrimRight(base, "/")}
+}
+
+type localidade struct {
+	ID           int    `json:"id"`
+	Nome         string `json:"nome"`
+	Microrregiao *struct {
+		Mesorregiao struct {
+			UF uf `json:"UF"`
+		} `json:"mesorregiao"`
+	} `json:"microrregiao"`
+	RegiaoImediata *struct {
+		RegiaoIntermediaria struct {
+			UF uf `json:"UF"`
+		} `json:"regiao-intermediaria"`
+	} `json:"regiao-imediata"`
+}
+
+type uf struct {
+	Sigla  string `json:"sigla"`
+	Regiao struct {
+		Nome string `json:"nome"`
+	} `json:"regiao"`
+}
+
+// state devolve a UF pela microrregião ou, nos municípios criados depois dela, pela região imediata.
+func (l localidade) state() uf {
+	if l.Microrregiao != nil && l.Microrregiao.Mesorregiao.UF.Sigla != "" {
+		return l.Microrregiao.Mesorregiao.UF
+	}
+	if l.RegiaoImediata != nil {
+		return l.RegiaoImediata.RegiaoIntermediaria.UF
+	}
+	return uf{}
+}
+
+func (l localidade) municipality() Municipality {
+	state := l.state()
+	return Municipality{Code: l.ID, Name: l.Nome, UF: state.Sigla, Region: state.Regiao.Nome}
+}
+
+// ByCode busca o município pelo código IBGE de 7 dígitos, com a população.
+func (c *Client) ByCode(ctx context.Context, code int) (*Municipality, error) {
+	// Códigos inexistentes voltam como 200 com [] em vez de objeto.
+	var raw json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/v1/localidades/municipios/%d", code), &raw); err != nil {
+		return nil, err
+	}
+	var loc localidade
+	if err := json.Unmarshal(raw, &loc); err != nil || loc.ID == 0 {
+		return nil, ErrNotFound
+	}
+	m := loc.municipality()
+	return c.withPopulation(ctx, m)
+}
+
+// ByName busca o município pelo nome dentro da UF, ignorando acentos e caixa, com a população.
+func (c *Client) ByName(ctx context.Context, name, state string) (*Municipality, error) {
+	state = strings.ToUpper(strings.TrimSpace(state))
+	if len(state) != 2 {
+		return nil, fmt.Errorf("ibge: UF inválida %q", state)
+	}
+	var list []localidade
+	if err := c.get(ctx, "/v1/localidades/estados/"+url.PathEscape(state)+"/municipios", &list); err != nil {
+		return nil, err
+	}
+	wanted := normalize(name)
+	for _, loc := range list {
+		if normalize(loc.Nome) == wanted {
+			return c.withPopulation(ctx, loc.municipality())
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (c *Client) withPopulation(ctx context.Context, m Municipality) (*Municipality, error) {
+	var payload []struct {
+		Resultados []struct {
+			Series []struct {
+				Serie map[string]string `json:"serie"`
+			} `json:"series"`
+		} `json:"resultados"`
+	}
+	path := populationAggregate + "?localidades=" + url.QueryEscape(fmt.Sprintf("N6[%d]", m.Code))
+	if err := c.get(ctx, path, &payload); err != nil {
+		return nil, err
+	}
+	for _, variable := range payload {
+		for _, result := range variable.Resultados {
+			for _, series := range result.Series {
+				m.PopulationYear, m.Population = latest(series.Serie)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// latest escolhe o período mais recente com valor numérico; o SIDRA usa "..." e "-" para ausentes.
+func latest(serie map[string]string) (int, int64) {
+	periods := make([]string, 0, len(serie))
+	for period := range serie {
+		periods = append(periods, period)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(periods)))
+	for _, period := range periods {
+		value, err := strconv.ParseInt(strings.TrimSpace(serie[period]), 10, 64)
+		if err != nil {
+			continue
+		}
+		year, _ := strconv.Atoi(period)
+		return year, value
+	}
+	return 0, 0
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ibge: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ibge: status %d em %s", resp.StatusCode, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ibge: resposta inválida: %w", err)
+	}
+	return nil
+}
+
+// MunicipalityFromDisplayName extrai o nome do município de "Prefeitura Municipal de X".
+func MunicipalityFromDisplayName(displayName string) string {
+	name := strings.TrimSpace(displayName)
+	lower := strings.ToLower(name)
+	for _, prefix := range []string{"prefeitura municipal de ", "prefeitura municipal do ", "prefeitura municipal da ", "prefeitura de ", "prefeitura do ", "prefeitura da ", "município de ", "municipio de "} {
+		if strings.HasPrefix(lower, prefix) {
+			name = strings.TrimSpace(name[len(prefix):])
+			break
+		}
+	}
+	// "Zabelê - PB" ou "Zabelê/PB"
+	if idx := strings.LastIndexAny(name, "-/"); idx > 0 && len(strings.TrimSpace(name[idx+1:])) == 2 {
+		name = strings.TrimSpace(name[:idx])
+	}
+	return name
+}
+
+var accentFold = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+func normalize(name string) string {
+	name = accentFold.Replace(strings.ToLower(strings.TrimSpace(name)))
+	name = strings.ReplaceAll(name, "'", "")
+	return strings.Join(strings.Fields(strings.ReplaceAll(name, "-", " ")), " ")
+}
@@ -0,0 +1,66 @@
+package ibge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestByName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/localidades/estados/PB/municipios":
+			w.Write([]byte(`[
+				{"id": 2500106, "nome": "Água Branca", "microrregiao": {"mesorregiao": {"UF": {"sigla": "PB", "regiao": {"nome": "Nordeste"}}}}},
+				{"id": 2517407, "nome": "Zabelê", "microrregiao": null,
+				 "regiao-imediata": {"regiao-intermediaria": {"UF": {"sigla": "PB", "regiao": {"nome": "Nordeste"}}}}}
+			]`))
+		case "/v3/agregados/6579/periodos/-1/variaveis/9324":
+			if got := r.URL.Query().Get("localidades"); got != "N6[2517407]" {
+				t.Errorf("localidades = %q", got)
+			}
+			w.Write([]byte(`[{"resultados": [{"series": [{"serie": {"2024": "2301", "2025": "..."}}]}]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m, err := New(srv.URL).ByName(context.Background(), "zabele", "pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Municipality{Code: 2517407, Name: "Zabelê", UF: "PB", Region: "Nordeste", Population: 2301, PopulationYear: 2024}
+	if *m != want {
+		t.Fatalf("municipality = %+v, want %+v", *m, want)
+	}
+
+	if _, err := New(srv.URL).ByName(context.Background(), "Inexistente", "PB"); err != ErrNotFound {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMunicipalityFromDisplayName(t *testing.T) {
+	cases := map[string]string{
+		"Prefeitura Municipal de Zabelê": "Zabelê",
+		"Prefeitura de São José - PB":    "São José",
+		"Campina Grande/PB":              "Campina Grande",
+	}
+	for in, want := range cases {
+		if got := MunicipalityFromDisplayName(in); got != want {
+			t.Errorf("MunicipalityFromDisplayName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTierFor(t *testing.T) {
+	if TierFor(0) != nil {
+		t.Fatal("sem população não há faixa")
+	}
+	for population, code := range map[int64]string{2301: "P1", 20_000: "P1", 45_000: "P2", 400_000: "G", 12_000_000: "MT"} {
+		if got := TierFor(population); got == nil || got.Code != code {
+			t.Errorf("TierFor(%d) = %+v, want %s", population, got, code)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package ibge
+
+// Tier é a faixa de cobrança definida pela população do município.
+type Tier struct {
+	Code          string `json:"code"`
+	Label         string `json:"label"`
+	MaxPopulation int64  `json:"max_population,omitempty"`
+}
+
+// Tiers segue a classificação de porte dos municípios (PNAS) usada na precificação dos contratos.
+var Tiers = []Tier{
+	{Code: "P1", Label: "Pequeno porte I (até 20 mil hab.)", MaxPopulation: 20_000},
+	{Code: "P2", Label: "Pequeno porte II (até 50 mil hab.)", MaxPopulation: 50_000},
+	{Code: "M", Label: "Médio porte (até 100 mil hab.)", MaxPopulation: 100_000},
+	{Code: "G", Label: "Grande porte (até 900 mil hab.)", MaxPopulation: 900_000},
+	{Code: "MT", Label: "Metrópole (acima de 900 mil hab.)"},
+}
+
+// TierFor devolve a faixa de cobrança da população; sem população conhecida não há faixa.
+func TierFor(population int64) *Tier {
+	if population <= 0 {
+		return nil
+	}
+	for i := range Tiers {
+		if Tiers[i].MaxPopulation == 0 || population <= Tiers[i].MaxPopulation {
+			tier := Tiers[i]
+			return &tier
+		}
+	}
+	return nil
+}
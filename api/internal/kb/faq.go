@@ -0,0 +1,144 @@
+package kb
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// faqCandidates é quantos artigos são avaliados para responder a uma pergunta.
+	faqCandidates = 5
+	// faqMinConfidence é a cobertura mínima dos termos da pergunta para responder direto.
+	faqMinConfidence = 0.5
+	// stemLength aproxima singular/plural e flexões comparando só o início das palavras.
+	stemLength = 5
+	// answerMaxRunes limita o trecho do artigo devolvido como resposta.
+	answerMaxRunes = 600
+)
+
+// FAQAnswer é a resposta do assistente do app do cidadão.
+type FAQAnswer struct {
+	Answered   bool      `json:"answered"`
+	Confidence float64   `json:"confidence"`
+	Article    *Article  `json:"article,omitempty"`
+	Answer     string    `json:"answer,omitempty"`
+	Related    []Article `json:"related"`
+	Fallback   *Fallback `json:"fallback,omitempty"`
+}
+
+// Fallback orienta o app a abrir um protocolo quando a base não responde à pergunta.
+type Fallback struct {
+	Action      string `json:"action"`
+	Subject     string `json:"subject"`
+	Description string `json:"description"`
+}
+
+// Answer procura nos artigos publicados do tenant a melhor resposta para a pergunta.
+// Abaixo da confiança mínima devolve os candidatos e sugere abrir protocolo.
+func (s *Service) Answer(ctx context.Context, tenantID uuid.UUID, question string) (*FAQAnswer, error) {
+	question = strings.TrimSpace(question)
+	terms := Keywords(question)
+	candidates, err := s.repo.Related(ctx, tenantID, terms, faqCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FAQAnswer{Related: []Article{}}
+	best := -1
+	for i := range candidates {
+		confidence := Coverage(terms, candidates[i])
+		if best < 0 || confidence > result.Confidence {
+			best, result.Confidence = i, confidence
+		}
+	}
+
+	if best >= 0 && result.Confidence >= faqMinConfidence {
+		article := candidates[best]
+		result.Answered = true
+		result.Answer = Excerpt(article)
+		article.Body = ""
+		result.Article = &article
+	} else {
+		result.Fallback = &Fallback{Action: "protocolo", Subject: subjectFrom(question), Description: question}
+	}
+
+	for i := range candidates {
+		if result.Answered && i == best {
+			continue
+		}
+		candidates[i].Body = ""
+		result.Related = append(result.Related, candidates[i])
+	}
+	return result, nil
+}
+
+// Coverage mede a fração dos termos da pergunta presentes no artigo; termos no título,
+// resumo ou tags valem o dobro dos encontrados só no corpo.
+func Coverage(terms []string, article Article) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	summary := ""
+	if article.Summary != nil {
+		summary = *article.Summary
+	}
+	head := stems(article.Title + " " + summary + " " + strings.Join(article.Tags, " "))
+	body := stems(article.Body)
+
+	var score float64
+	for _, term := range terms {
+		stem := stemOf(term)
+		switch {
+		case head[stem]:
+			score += 2
+		case body[stem]:
+			score++
+		}
+	}
+	return score / float64(2*len(terms))
+}
+
+// Excerpt devolve o resumo do artigo ou, sem ele, o primeiro parágrafo do corpo.
+func Excerpt(article Article) string {
+	if article.Summary != nil && strings.TrimSpace(*article.Summary) != "" {
+		return strings.TrimSpace(*article.Summary)
+	}
+	text := strings.TrimSpace(article.Body)
+	if idx := strings.Index(text, "\n\n"); idx > 0 {
+		text = text[:idx]
+	}
+	runes := []rune(text)
+	if len(runes) > answerMaxRunes {
+		return strings.TrimSpace(string(runes[:answerMaxRunes])) + "…"
+	}
+	return text
+}
+
+func stems(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		set[stemOf(word)] = true
+	}
+	return set
+}
+
+func stemOf(word string) string {
+	runes := []rune(Slugify(word))
+	if len(runes) > stemLength {
+		runes = runes[:stemLength]
+	}
+	return string(runes)
+}
+
+func subjectFrom(question string) string {
+	runes := []rune(strings.Join(strings.Fields(question), " "))
+	if len(runes) > 80 {
+		return strings.TrimSpace(string(runes[:80])) + "…"
+	}
+	return string(runes)
+}
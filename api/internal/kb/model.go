@@ -0,0 +1,137 @@
+package kb
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica categoria ou artigo inexistente.
+	ErrNotFound = errors.New("kb: não encontrado")
+	// ErrSlugTaken indica slug já utilizado no mesmo escopo.
+	ErrSlugTaken = errors.New("kb: slug já utilizado")
+	// ErrUnknownCategory indica categoria ou tenant referenciado inexistente.
+	ErrUnknownCategory = errors.New("kb: categoria ou tenant inexistente")
+	// ErrInvalidStatus indica status de artigo desconhecido.
+	ErrInvalidStatus = errors.New("kb: status inválido")
+)
+
+// Status de publicação dos artigos.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+var validStatuses = map[string]struct{}{
+	StatusDraft:     {},
+	StatusPublished: {},
+	StatusArchived:  {},
+}
+
+// IsValidStatus informa se o status do artigo é permitido.
+func IsValidStatus(status string) bool {
+	_, ok := validStatuses[status]
+	return ok
+}
+
+// Category agrupa artigos de uma área de produto.
+type Category struct {
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	ProductArea *string   `json:"product_area,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Position    int       `json:"position"`
+	Articles    int       `json:"articles"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CategoryInput contém os campos editáveis da categoria.
+type CategoryInput struct {
+	Slug        string
+	Name        string
+	ProductArea *string
+	Description *string
+	Position    int
+}
+
+// Article é um artigo de ajuda; sem tenant vale para todos os municípios.
+type Article struct {
+	ID          uuid.UUID  `json:"id"`
+	CategoryID  *uuid.UUID `json:"category_id,omitempty"`
+	TenantID    *uuid.UUID `json:"tenant_id,omitempty"`
+	Slug        string     `json:"slug"`
+	Title       string     `json:"title"`
+	Summary     *string    `json:"summary,omitempty"`
+	Body        string     `json:"body,omitempty"`
+	ProductArea *string    `json:"product_area,omitempty"`
+	Tags        []string   `json:"tags"`
+	Status      string     `json:"status"`
+	Views       int        `json:"views"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	UpdatedBy   *uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Score       float64    `json:"score,omitempty"`
+}
+
+// ArticleInput contém os campos editáveis do artigo.
+type ArticleInput struct {
+	CategoryID  *uuid.UUID
+	TenantID    *uuid.UUID
+	Slug        string
+	Title       string
+	Summary     *string
+	Body        string
+	ProductArea *string
+	Tags        []string
+	Status      string
+	ActorID     *uuid.UUID
+}
+
+// ArticleFilter restringe listagens e buscas.
+type ArticleFilter struct {
+	// TenantID, quando informado, limita a artigos globais e aos do próprio tenant,
+	// respeitando os módulos contratados.
+	TenantID    *uuid.UUID
+	CategoryID  *uuid.UUID
+	ProductArea string
+	Status      string
+	Query       string
+	Limit       int
+}
+
+// Slugify gera slug minúsculo sem acentos a partir de um título.
+func Slugify(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(value)) {
+		if folded, ok := accentFold[r]; ok {
+			r = folded
+		}
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+var accentFold = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
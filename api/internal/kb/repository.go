@@ -0,0 +1,260 @@
+package kb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository provê acesso às tabelas da base de conhecimento.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+const (
+	categoryColumns = `c.id, c.slug, c.name, c.product_area, c.description, c.position, c.created_at, c.updated_at`
+	articleColumns  = `a.id, a.category_id, a.tenant_id, a.slug, a.title, a.summary, a.body, a.product_area, a.tags, a.status, a.views, a.published_at, a.created_by, a.updated_by, a.created_at, a.updated_at`
+
+	// tenantScope limita a artigos globais e do tenant; áreas de produto fora dos módulos
+	// contratados ficam ocultas quando o contrato define módulos.
+	tenantScope = `(a.tenant_id IS NULL OR a.tenant_id = %[1]s)
+          AND (a.product_area IS NULL
+               OR NOT EXISTS (SELECT 1 FROM saas_tenant_contract_modules m WHERE m.tenant_id = %[1]s)
+               OR EXISTS (SELECT 1 FROM saas_tenant_contract_modules m WHERE m.tenant_id = %[1]s AND m.module_code = a.product_area AND m.enabled))`
+)
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// ListCategories lista categorias com a contagem de artigos publicados.
+func (r *Repository) ListCategories(ctx context.Context, productArea string) ([]Category, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+categoryColumns+`,
+               (SELECT count(*) FROM kb_articles a WHERE a.category_id = c.id AND a.status = 'published')
+        FROM kb_categories c
+        WHERE ($1 = '' OR c.product_area = $1)
+        ORDER BY c.position ASC, c.name ASC
+    `, strings.TrimSpace(productArea))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make([]Category, 0)
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(&c.ID, &c.Slug, &c.Name, &c.ProductArea, &c.Description, &c.Position, &c.CreatedAt, &c.UpdatedAt, &c.Articles); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// CreateCategory insere categoria.
+func (r *Repository) CreateCategory(ctx context.Context, input CategoryInput) (*Category, error) {
+	const query = `
+        INSERT INTO kb_categories AS c (slug, name, product_area, description, position)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + categoryColumns
+	category, err := scanCategory(r.pool.QueryRow(ctx, query, input.Slug, input.Name, input.ProductArea, input.Description, input.Position))
+	return category, constraintError(err)
+}
+
+// UpdateCategory substitui os dados da categoria.
+func (r *Repository) UpdateCategory(ctx context.Context, id uuid.UUID, input CategoryInput) (*Category, error) {
+	const query = `
+        UPDATE kb_categories AS c
+        SET slug = $2, name = $3, product_area = $4, description = $5, position = $6, updated_at = now()
+        WHERE c.id = $1
+        RETURNING ` + categoryColumns
+	category, err := scanCategory(r.pool.QueryRow(ctx, query, id, input.Slug, input.Name, input.ProductArea, input.Description, input.Position))
+	return category, constraintError(err)
+}
+
+// DeleteCategory remove categoria; os artigos ficam sem categoria.
+func (r *Repository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM kb_categories WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListArticles lista artigos conforme o filtro; com busca, ordena por relevância.
+func (r *Repository) ListArticles(ctx context.Context, filter ArticleFilter) ([]Article, error) {
+	clauses := []string{"TRUE"}
+	args := []any{}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.TenantID != nil {
+		clauses = append(clauses, fmt.Sprintf(tenantScope, arg(*filter.TenantID)))
+	}
+	if filter.CategoryID != nil {
+		clauses = append(clauses, "a.category_id = "+arg(*filter.CategoryID))
+	}
+	if area := strings.TrimSpace(filter.ProductArea); area != "" {
+		clauses = append(clauses, "a.product_area = "+arg(area))
+	}
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		clauses = append(clauses, "a.status = "+arg(status))
+	}
+
+	score := "0::float8"
+	order := "a.title ASC"
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		tsq := fmt.Sprintf("websearch_to_tsquery('portuguese', %s)", arg(q))
+		like := arg("%" + q + "%")
+		clauses = append(clauses, fmt.Sprintf("(a.search @@ %s OR a.title ILIKE %s)", tsq, like))
+		score = fmt.Sprintf("ts_rank(a.search, %s)::float8", tsq)
+		order = "score DESC, a.views DESC"
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := `SELECT ` + articleColumns + `, ` + score + ` AS score
+        FROM kb_articles a
+        WHERE ` + strings.Join(clauses, " AND ") + `
+        ORDER BY ` + order + `
+        LIMIT ` + arg(limit)
+	return r.queryArticles(ctx, query, args...)
+}
+
+// Related devolve artigos publicados que compartilham termos com o texto, por relevância.
+func (r *Repository) Related(ctx context.Context, tenantID uuid.UUID, terms []string, limit int) ([]Article, error) {
+	if len(terms) == 0 {
+		return []Article{}, nil
+	}
+	query := `SELECT ` + articleColumns + `, ts_rank(a.search, q)::float8 AS score
+        FROM kb_articles a, to_tsquery('portuguese', $2) q
+        WHERE a.status = 'published' AND a.search @@ q AND ` + fmt.Sprintf(tenantScope, "$1") + `
+        ORDER BY score DESC, a.views DESC
+        LIMIT $3`
+	return r.queryArticles(ctx, query, tenantID, anyTermQuery(terms), limit)
+}
+
+// GetArticle busca artigo pelo id.
+func (r *Repository) GetArticle(ctx context.Context, id uuid.UUID) (*Article, error) {
+	return scanArticle(r.pool.QueryRow(ctx, `SELECT `+articleColumns+`, 0::float8 FROM kb_articles a WHERE a.id = $1`, id))
+}
+
+// PublishedArticle busca artigo publicado visível ao tenant e contabiliza a visualização.
+// O artigo próprio do tenant prevalece sobre o global de mesmo slug.
+func (r *Repository) PublishedArticle(ctx context.Context, tenantID uuid.UUID, slug string) (*Article, error) {
+	query := `
+        WITH found AS (
+            SELECT a.id FROM kb_articles a
+            WHERE a.slug = $2 AND a.status = 'published' AND ` + fmt.Sprintf(tenantScope, "$1") + `
+            ORDER BY a.tenant_id NULLS LAST
+            LIMIT 1
+        )
+        UPDATE kb_articles a SET views = a.views + 1
+        FROM found WHERE a.id = found.id
+        RETURNING ` + articleColumns + `, 0::float8`
+	return scanArticle(r.pool.QueryRow(ctx, query, tenantID, slug))
+}
+
+// CreateArticle insere artigo.
+func (r *Repository) CreateArticle(ctx context.Context, input ArticleInput) (*Article, error) {
+	const query = `
+        INSERT INTO kb_articles AS a (category_id, tenant_id, slug, title, summary, body, product_area, tags, status, published_at, created_by, updated_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CASE WHEN $9 = 'published' THEN now() END, $10, $10)
+        RETURNING ` + articleColumns + `, 0::float8`
+	article, err := scanArticle(r.pool.QueryRow(ctx, query, input.CategoryID, input.TenantID, input.Slug, input.Title, input.Summary, input.Body, input.ProductArea, input.Tags, input.Status, input.ActorID))
+	return article, constraintError(err)
+}
+
+// UpdateArticle substitui o conteúdo do artigo; a primeira publicação registra published_at.
+func (r *Repository) UpdateArticle(ctx context.Context, id uuid.UUID, input ArticleInput) (*Article, error) {
+	const query = `
+        UPDATE kb_articles AS a
+        SET category_id = $2, tenant_id = $3, slug = $4, title = $5, summary = $6, body = $7,
+            product_area = $8, tags = $9, status = $10, updated_by = $11, updated_at = now(),
+            published_at = CASE WHEN $10 = 'published' THEN COALESCE(a.published_at, now()) ELSE a.published_at END
+        WHERE a.id = $1
+        RETURNING ` + articleColumns + `, 0::float8`
+	article, err := scanArticle(r.pool.QueryRow(ctx, query, id, input.CategoryID, input.TenantID, input.Slug, input.Title, input.Summary, input.Body, input.ProductArea, input.Tags, input.Status, input.ActorID))
+	return article, constraintError(err)
+}
+
+// DeleteArticle remove artigo.
+func (r *Repository) DeleteArticle(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM kb_articles WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) queryArticles(ctx context.Context, query string, args ...any) ([]Article, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	articles := make([]Article, 0)
+	for rows.Next() {
+		article, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, *article)
+	}
+	return articles, rows.Err()
+}
+
+func scanCategory(row pgx.Row) (*Category, error) {
+	var c Category
+	if err := row.Scan(&c.ID, &c.Slug, &c.Name, &c.ProductArea, &c.Description, &c.Position, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanArticle(row pgx.Row) (*Article, error) {
+	var a Article
+	if err := row.Scan(&a.ID, &a.CategoryID, &a.TenantID, &a.Slug, &a.Title, &a.Summary, &a.Body, &a.ProductArea, &a.Tags, &a.Status, &a.Views, &a.PublishedAt, &a.CreatedBy, &a.UpdatedBy, &a.CreatedAt, &a.UpdatedAt, &a.Score); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func constraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return ErrSlugTaken
+		case "23503":
+			return ErrUnknownCategory
+		}
+	}
+	return err
+}
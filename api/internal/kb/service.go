@@ -0,0 +1,147 @@
+package kb
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// suggestionLimit é o número de artigos sugeridos ao abrir um chamado.
+const suggestionLimit = 5
+
+// Service reúne regras da base de conhecimento.
+type Service struct {
+	repo *Repository
+}
+
+// NewService cria uma nova instância do serviço.
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// ListCategories lista categorias, opcionalmente de uma área de produto.
+func (s *Service) ListCategories(ctx context.Context, productArea string) ([]Category, error) {
+	return s.repo.ListCategories(ctx, productArea)
+}
+
+// SaveCategory cria (id nulo) ou atualiza categoria.
+func (s *Service) SaveCategory(ctx context.Context, id *uuid.UUID, input CategoryInput) (*Category, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, errors.New("nome obrigatório")
+	}
+	input.Slug = Slugify(input.Slug)
+	if input.Slug == "" {
+		input.Slug = Slugify(input.Name)
+	}
+	input.ProductArea = trimmedOrNil(input.ProductArea)
+	input.Description = trimmedOrNil(input.Description)
+
+	if id == nil {
+		return s.repo.CreateCategory(ctx, input)
+	}
+	return s.repo.UpdateCategory(ctx, *id, input)
+}
+
+// DeleteCategory remove categoria.
+func (s *Service) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCategory(ctx, id)
+}
+
+// ListArticles lista artigos para a equipe SaaS, incluindo rascunhos.
+func (s *Service) ListArticles(ctx context.Context, filter ArticleFilter) ([]Article, error) {
+	return s.repo.ListArticles(ctx, filter)
+}
+
+// PublicArticles lista artigos publicados visíveis ao tenant, sem o corpo.
+func (s *Service) PublicArticles(ctx context.Context, tenantID uuid.UUID, filter ArticleFilter) ([]Article, error) {
+	filter.TenantID = &tenantID
+	filter.Status = StatusPublished
+	articles, err := s.repo.ListArticles(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range articles {
+		articles[i].Body = ""
+	}
+	return articles, nil
+}
+
+// PublicArticle devolve artigo publicado pelo slug, contabilizando a visualização.
+func (s *Service) PublicArticle(ctx context.Context, tenantID uuid.UUID, slug string) (*Article, error) {
+	return s.repo.PublishedArticle(ctx, tenantID, strings.TrimSpace(slug))
+}
+
+// GetArticle recupera artigo pelo id.
+func (s *Service) GetArticle(ctx context.Context, id uuid.UUID) (*Article, error) {
+	return s.repo.GetArticle(ctx, id)
+}
+
+// SaveArticle cria (id nulo) ou atualiza artigo.
+func (s *Service) SaveArticle(ctx context.Context, id *uuid.UUID, input ArticleInput) (*Article, error) {
+	input.Title = strings.TrimSpace(input.Title)
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Title == "" {
+		return nil, errors.New("título obrigatório")
+	}
+	if input.Body == "" {
+		return nil, errors.New("conteúdo obrigatório")
+	}
+	input.Slug = Slugify(input.Slug)
+	if input.Slug == "" {
+		input.Slug = Slugify(input.Title)
+	}
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	if input.Status == "" {
+		input.Status = StatusDraft
+	}
+	if !IsValidStatus(input.Status) {
+		return nil, ErrInvalidStatus
+	}
+	input.Summary = trimmedOrNil(input.Summary)
+	input.ProductArea = trimmedOrNil(input.ProductArea)
+	tags := make([]string, 0, len(input.Tags))
+	for _, tag := range input.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	input.Tags = tags
+
+	if id == nil {
+		return s.repo.CreateArticle(ctx, input)
+	}
+	return s.repo.UpdateArticle(ctx, *id, input)
+}
+
+// DeleteArticle remove artigo.
+func (s *Service) DeleteArticle(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteArticle(ctx, id)
+}
+
+// Suggest devolve artigos publicados relacionados ao assunto e à descrição de um chamado do tenant.
+func (s *Service) Suggest(ctx context.Context, tenantID uuid.UUID, subject, description string) ([]Article, error) {
+	// O assunto entra duas vezes para pesar mais que a descrição na escolha dos termos.
+	terms := Keywords(subject + "\n" + subject + "\n" + description)
+	articles, err := s.repo.Related(ctx, tenantID, terms, suggestionLimit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range articles {
+		articles[i].Body = ""
+	}
+	return articles, nil
+}
+
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
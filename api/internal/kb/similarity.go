@@ -0,0 +1,64 @@
+package kb
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxKeywords limita os termos usados para sugerir artigos a partir de um chamado.
+const maxKeywords = 12
+
+var stopwords = map[string]struct{}{
+	"para": {}, "como": {}, "com": {}, "sem": {}, "que": {}, "por": {}, "uma": {}, "uns": {}, "umas": {},
+	"dos": {}, "das": {}, "nos": {}, "nas": {}, "num": {}, "numa": {}, "pelo": {}, "pela": {}, "pelos": {},
+	"pelas": {}, "este": {}, "esta": {}, "esse": {}, "essa": {}, "isso": {}, "isto": {}, "aquele": {},
+	"aquela": {}, "ele": {}, "ela": {}, "eles": {}, "elas": {}, "nao": {}, "não": {}, "sim": {}, "mais": {},
+	"mas": {}, "foi": {}, "ser": {}, "ter": {}, "tem": {}, "têm": {}, "está": {}, "estão": {}, "estou": {},
+	"minha": {}, "meu": {}, "seu": {}, "sua": {}, "nosso": {}, "nossa": {}, "quando": {}, "onde": {},
+	"qual": {}, "quais": {}, "porque": {}, "pois": {}, "também": {}, "já": {}, "ainda": {}, "muito": {},
+	"bom": {}, "dia": {}, "boa": {}, "tarde": {}, "noite": {}, "olá": {}, "obrigado": {}, "obrigada": {},
+	"favor": {}, "gostaria": {}, "preciso": {}, "consigo": {}, "ajuda": {}, "problema": {},
+}
+
+// Keywords extrai os termos mais frequentes e relevantes de um texto livre, na ordem
+// de relevância. Os termos contêm só letras e dígitos, prontos para compor um tsquery.
+func Keywords(text string) []string {
+	counts := map[string]int{}
+	first := map[string]int{}
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range fields {
+		if len([]rune(word)) < 3 {
+			continue
+		}
+		if _, stop := stopwords[word]; stop {
+			continue
+		}
+		if _, seen := counts[word]; !seen {
+			first[word] = i
+		}
+		counts[word]++
+	}
+
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return first[words[i]] < first[words[j]]
+	})
+	if len(words) > maxKeywords {
+		words = words[:maxKeywords]
+	}
+	return words
+}
+
+// anyTermQuery monta um tsquery que casa com qualquer um dos termos.
+func anyTermQuery(terms []string) string {
+	return strings.Join(terms, " | ")
+}
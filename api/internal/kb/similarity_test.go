@@ -0,0 +1,39 @@
+package kb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	got := Keywords("Olá, não consigo emitir o boletim. O boletim da turma 5A não aparece para a escola!")
+	want := []string{"boletim", "emitir", "turma", "aparece", "escola"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Keywords = %v, want %v", got, want)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	if got := Slugify("  Como emitir a Declaração de Matrícula?  "); got != "como-emitir-a-declaracao-de-matricula" {
+		t.Fatalf("Slugify = %q", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	summary := "Passo a passo para emitir a segunda via do IPTU."
+	article := Article{
+		Title:   "Segunda via do IPTU",
+		Summary: &summary,
+		Body:    "Acesse o portal de tributos e informe a inscrição do imóvel.",
+	}
+
+	if got := Coverage(Keywords("Como tiro a segunda via do IPTU?"), article); got != 0.75 {
+		t.Fatalf("Coverage = %v, want 0.75", got)
+	}
+	if got := Coverage(Keywords("inscrição do imóvel no cadastro"), article); got != 1.0/3 {
+		t.Fatalf("Coverage = %v, want 1/3", got)
+	}
+	if got := Coverage(nil, article); got != 0 {
+		t.Fatalf("Coverage sem termos = %v", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package kpi congela mensalmente os indicadores-chave reportados a investidores e ao conselho.
+// Cada retrato é versionado: corrigir dados na origem não altera relatórios já emitidos, e uma
+// reemissão gera nova versão com o motivo registrado.
+package kpi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Definitions identifica a versão das fórmulas usadas no cálculo; muda quando uma fórmula muda.
+const Definitions = "2026.1"
+
+var (
+	// ErrInvalidMonth indica mês fora do formato AAAA-MM.
+	ErrInvalidMonth = errors.New("mês deve seguir o formato AAAA-MM")
+	// ErrOpenMonth indica tentativa de congelar um mês ainda não encerrado.
+	ErrOpenMonth = errors.New("mês ainda não encerrado")
+	// ErrNotFound indica que não há retrato para o mês.
+	ErrNotFound = errors.New("kpi: retrato não encontrado")
+)
+
+// Metrics reúne os indicadores de um mês.
+type Metrics struct {
+	MRR              float64  `json:"mrr"`
+	ARR              float64  `json:"arr"`
+	ActiveContracts  int64    `json:"active_contracts"`
+	BilledRevenue    float64  `json:"billed_revenue"`
+	CollectedRevenue float64  `json:"collected_revenue"`
+	ActiveTenants    int64    `json:"active_tenants"`
+	NewTenants       int64    `json:"new_tenants"`
+	CohortTenants    int64    `json:"cohort_tenants"`
+	ChurnedTenants   int64    `json:"churned_tenants"`
+	ChurnPct         *float64 `json:"churn_pct"`
+	NPS              *float64 `json:"nps"`
+	Accesses         int64    `json:"accesses"`
+	ActiveUsers      int64    `json:"active_users"`
+	TicketsOpened    int64    `json:"tickets_opened"`
+}
+
+// Finalize deriva os indicadores calculados a partir dos brutos e arredonda os valores.
+func (m *Metrics) Finalize() {
+	m.MRR = round2(m.MRR)
+	m.ARR = round2(m.MRR * 12)
+	m.BilledRevenue = round2(m.BilledRevenue)
+	m.CollectedRevenue = round2(m.CollectedRevenue)
+	m.ChurnPct = nil
+	if m.CohortTenants > 0 {
+		pct := round2(float64(m.ChurnedTenants) / float64(m.CohortTenants) * 100)
+		m.ChurnPct = &pct
+	}
+}
+
+// Checksum devolve o SHA-256 do JSON dos indicadores, usado para conferir relatórios emitidos.
+func (m Metrics) Checksum() (string, []byte, error) {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), raw, nil
+}
+
+// Snapshot é um retrato congelado dos indicadores de um mês.
+type Snapshot struct {
+	ID          uuid.UUID  `json:"id"`
+	Month       string     `json:"month"`
+	Version     int        `json:"version"`
+	Definitions string     `json:"definitions"`
+	Metrics     Metrics    `json:"metrics"`
+	SHA256      string     `json:"sha256"`
+	Reason      *string    `json:"reason,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Month é um mês civil em UTC.
+type Month struct {
+	Year  int
+	Month time.Month
+}
+
+// ParseMonth interpreta "2026-03"; vazio devolve o mês anterior a now, o último encerrado.
+func ParseMonth(value string, now time.Time) (Month, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return MonthOf(now).Prev(), nil
+	}
+	t, err := time.Parse("2006-01", value)
+	if err != nil || t.Year() < 2000 || t.Year() > 2100 {
+		return Month{}, ErrInvalidMonth
+	}
+	return MonthOf(t), nil
+}
+
+// MonthOf devolve o mês que contém t.
+func MonthOf(t time.Time) Month {
+	t = t.UTC()
+	return Month{Year: t.Year(), Month: t.Month()}
+}
+
+// String formata o mês como AAAA-MM.
+func (m Month) String() string {
+	return m.Start().Format("2006-01")
+}
+
+// Start é o primeiro instante do mês.
+func (m Month) Start() time.Time {
+	return time.Date(m.Year, m.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End é o primeiro instante do mês seguinte.
+func (m Month) End() time.Time {
+	return m.Start().AddDate(0, 1, 0)
+}
+
+// Prev devolve o mês anterior.
+func (m Month) Prev() Month {
+	return MonthOf(m.Start().AddDate(0, -1, 0))
+}
+
+// Closed informa se o mês já terminou em now; só meses encerrados podem ser congelados.
+func (m Month) Closed(now time.Time) bool {
+	return !now.Before(m.End())
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
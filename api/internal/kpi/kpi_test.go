@@ -0,0 +1,65 @@
+package kpi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMonth(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	m, err := ParseMonth("", now)
+	if err != nil || m.String() != "2026-02" {
+		t.Fatalf("mês padrão = %v, %v; esperado 2026-02", m, err)
+	}
+	m, err = ParseMonth("2026-01", now)
+	if err != nil || m.String() != "2026-01" {
+		t.Fatalf("ParseMonth(2026-01) = %v, %v", m, err)
+	}
+	if _, err := ParseMonth("2026-13", now); err != ErrInvalidMonth {
+		t.Fatalf("mês inválido deveria falhar, obteve %v", err)
+	}
+	if prev := (Month{Year: 2026, Month: time.January}).Prev(); prev.String() != "2025-12" {
+		t.Fatalf("Prev de 2026-01 = %s", prev)
+	}
+}
+
+func TestMonthClosed(t *testing.T) {
+	m := Month{Year: 2026, Month: time.February}
+	if m.Closed(time.Date(2026, 2, 28, 23, 59, 0, 0, time.UTC)) {
+		t.Fatal("fevereiro ainda não deveria estar encerrado")
+	}
+	if !m.Closed(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("fevereiro deveria estar encerrado em 1º de março")
+	}
+}
+
+func TestFinalizeAndChecksum(t *testing.T) {
+	m := Metrics{MRR: 1000.004, CohortTenants: 40, ChurnedTenants: 3}
+	m.Finalize()
+	if m.ARR != 12000 {
+		t.Fatalf("ARR = %v", m.ARR)
+	}
+	if m.ChurnPct == nil || *m.ChurnPct != 7.5 {
+		t.Fatalf("churn = %v", m.ChurnPct)
+	}
+
+	a, _, err := m.Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, _ := m.Checksum()
+	if a != b {
+		t.Fatal("checksum deveria ser determinístico")
+	}
+	m.Accesses++
+	if c, _, _ := m.Checksum(); c == a {
+		t.Fatal("checksum deveria mudar com os indicadores")
+	}
+
+	empty := Metrics{}
+	empty.Finalize()
+	if empty.ChurnPct != nil {
+		t.Fatal("sem coorte, churn deve ficar nulo")
+	}
+}
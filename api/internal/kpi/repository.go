@@ -0,0 +1,189 @@
+package kpi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const snapshotColumns = `id, to_char(month, 'YYYY-MM'), version, definitions, metrics, metrics_sha256, reason, created_by, created_at`
+
+// Repository calcula e guarda os retratos mensais.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Latest devolve a versão mais recente do retrato do mês.
+func (r *Repository) Latest(ctx context.Context, month Month) (*Snapshot, error) {
+	row := r.pool.QueryRow(ctx, `
+        SELECT `+snapshotColumns+`
+        FROM saas_metric_snapshots
+        WHERE month = $1
+        ORDER BY version DESC
+        LIMIT 1
+    `, month.Start())
+	snapshot, err := scanSnapshot(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return snapshot, err
+}
+
+// Version devolve uma versão específica do retrato do mês.
+func (r *Repository) Version(ctx context.Context, month Month, version int) (*Snapshot, error) {
+	row := r.pool.QueryRow(ctx, `
+        SELECT `+snapshotColumns+`
+        FROM saas_metric_snapshots
+        WHERE month = $1 AND version = $2
+    `, month.Start(), version)
+	snapshot, err := scanSnapshot(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return snapshot, err
+}
+
+// History lista os retratos, do mais recente ao mais antigo, opcionalmente de um único mês.
+func (r *Repository) History(ctx context.Context, month *Month) ([]Snapshot, error) {
+	var filter any
+	if month != nil {
+		filter = month.Start()
+	}
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+snapshotColumns+`
+        FROM saas_metric_snapshots
+        WHERE ($1::date IS NULL OR month = $1)
+        ORDER BY month DESC, version DESC
+    `, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	snapshots := make([]Snapshot, 0)
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// Compute calcula os indicadores do mês a partir dos dados atuais, sem gravá-los.
+func (r *Repository) Compute(ctx context.Context, month Month) (Metrics, error) {
+	return compute(ctx, r.pool, month)
+}
+
+// Freeze calcula e grava uma nova versão do retrato do mês. A versão é serializada por mês com
+// advisory lock para que congelamentos simultâneos não colidam.
+func (r *Repository) Freeze(ctx context.Context, month Month, actorID *uuid.UUID, reason string) (*Snapshot, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('saas_metric_snapshots:' || $1))`, month.String()); err != nil {
+		return nil, err
+	}
+
+	metrics, err := compute(ctx, tx, month)
+	if err != nil {
+		return nil, err
+	}
+	digest, raw, err := metrics.Checksum()
+	if err != nil {
+		return nil, err
+	}
+
+	var reasonArg *string
+	if trimmed := strings.TrimSpace(reason); trimmed != "" {
+		reasonArg = &trimmed
+	}
+
+	row := tx.QueryRow(ctx, `
+        INSERT INTO saas_metric_snapshots (month, version, definitions, metrics, metrics_sha256, reason, created_by)
+        SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6
+        FROM saas_metric_snapshots WHERE month = $1
+        RETURNING `+snapshotColumns, month.Start(), Definitions, raw, digest, reasonArg, actorID)
+	snapshot, err := scanSnapshot(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// compute agrega contratos, faturas, tenants, coortes e uso do mês. MRR e contratos refletem a
+// carteira no momento do cálculo; os demais indicadores são recortados pelo intervalo do mês.
+func compute(ctx context.Context, q querier, month Month) (Metrics, error) {
+	var m Metrics
+	err := q.QueryRow(ctx, `
+        SELECT
+            COALESCE((SELECT SUM(c.contract_value) / 12 FROM saas_tenant_contracts c JOIN tenants t ON t.id = c.tenant_id
+                      WHERE c.status IN ('active', 'renewal') AND c.contract_value IS NOT NULL
+                        AND t.environment = 'production' AND (c.start_date IS NULL OR c.start_date < $2)), 0)::float8,
+            (SELECT COUNT(*) FROM saas_tenant_contracts c JOIN tenants t ON t.id = c.tenant_id
+             WHERE c.status IN ('active', 'renewal') AND t.environment = 'production'
+               AND (c.start_date IS NULL OR c.start_date < $2)),
+            COALESCE((SELECT SUM(amount) FROM saas_tenant_invoices WHERE reference_month >= $1 AND reference_month < $2), 0)::float8,
+            COALESCE((SELECT SUM(amount) FROM saas_tenant_invoices WHERE reference_month >= $1 AND reference_month < $2 AND status = 'paid'), 0)::float8,
+            (SELECT COUNT(*) FROM tenants WHERE status = 'active' AND environment = 'production'
+               AND COALESCE(activated_at, created_at) < $2),
+            (SELECT COUNT(*) FROM tenants WHERE environment = 'production'
+               AND activated_at >= $1 AND activated_at < $2),
+            COALESCE((SELECT tenants_count FROM saas_retention_cohorts WHERE cohort_month = $1), 0),
+            COALESCE((SELECT churn_count FROM saas_retention_cohorts WHERE cohort_month = $1), 0),
+            (SELECT nps::float8 FROM saas_retention_cohorts WHERE cohort_month = $1),
+            (SELECT COUNT(*) FROM saas_access_logs WHERE logged_at >= $1 AND logged_at < $2),
+            (SELECT COUNT(DISTINCT user_id) FROM saas_access_logs WHERE logged_at >= $1 AND logged_at < $2),
+            (SELECT COUNT(*) FROM support_tickets WHERE created_at >= $1 AND created_at < $2)
+    `, month.Start(), month.End()).Scan(
+		&m.MRR,
+		&m.ActiveContracts,
+		&m.BilledRevenue,
+		&m.CollectedRevenue,
+		&m.ActiveTenants,
+		&m.NewTenants,
+		&m.CohortTenants,
+		&m.ChurnedTenants,
+		&m.NPS,
+		&m.Accesses,
+		&m.ActiveUsers,
+		&m.TicketsOpened,
+	)
+	if err != nil {
+		return Metrics{}, err
+	}
+	m.Finalize()
+	return m, nil
+}
+
+func scanSnapshot(row pgx.Row) (*Snapshot, error) {
+	var s Snapshot
+	var raw []byte
+	if err := row.Scan(&s.ID, &s.Month, &s.Version, &s.Definitions, &raw, &s.SHA256, &s.Reason, &s.CreatedBy, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &s.Metrics); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
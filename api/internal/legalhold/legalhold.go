@@ -0,0 +1,284 @@
+// Package legalhold controla a retenção legal de tenants em litígio: enquanto houver retenção
+// aberta, expurgos e limpezas de dados do tenant ficam bloqueados. Ativar e liberar exigem
+// dois SAAS_OWNER distintos.
+package legalhold
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Status da retenção. Pending, active e releasing bloqueiam operações destrutivas.
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusReleasing = "releasing"
+	StatusReleased  = "released"
+	StatusCancelled = "cancelled"
+)
+
+// OpenStatusesSQL é o filtro SQL das retenções que bloqueiam expurgos.
+const OpenStatusesSQL = `('pending','active','releasing')`
+
+var (
+	// ErrNotFound indica que o tenant não tem retenção no estado esperado.
+	ErrNotFound = errors.New("legalhold: retenção não encontrada")
+	// ErrAlreadyOpen indica retenção já aberta para o tenant.
+	ErrAlreadyOpen = errors.New("legalhold: tenant já possui retenção aberta")
+	// ErrSameOwner indica tentativa de confirmar a própria solicitação.
+	ErrSameOwner = errors.New("legalhold: a confirmação exige outro SAAS_OWNER")
+	// ErrOnHold indica operação destrutiva bloqueada por retenção legal.
+	ErrOnHold = errors.New("legalhold: tenant sob retenção legal")
+)
+
+// Hold é o registro de retenção legal.
+type Hold struct {
+	ID                 uuid.UUID       `json:"id"`
+	TenantID           uuid.UUID       `json:"tenant_id"`
+	Status             string          `json:"status"`
+	Reason             string          `json:"reason"`
+	CaseReference      *string         `json:"case_reference,omitempty"`
+	RequestedBy        uuid.UUID       `json:"requested_by"`
+	RequestedAt        time.Time       `json:"requested_at"`
+	ConfirmedBy        *uuid.UUID      `json:"confirmed_by,omitempty"`
+	ConfirmedAt        *time.Time      `json:"confirmed_at,omitempty"`
+	ReleaseReason      *string         `json:"release_reason,omitempty"`
+	ReleaseRequestedBy *uuid.UUID      `json:"release_requested_by,omitempty"`
+	ReleaseRequestedAt *time.Time      `json:"release_requested_at,omitempty"`
+	ReleasedBy         *uuid.UUID      `json:"released_by,omitempty"`
+	ReleasedAt         *time.Time      `json:"released_at,omitempty"`
+	SnapshotSHA256     *string         `json:"snapshot_sha256,omitempty"`
+	Snapshot           json.RawMessage `json:"snapshot,omitempty"`
+}
+
+const holdColumns = `id, tenant_id, status, reason, case_reference, requested_by, requested_at, confirmed_by, confirmed_at,
+        release_reason, release_requested_by, release_requested_at, released_by, released_at, snapshot_sha256`
+
+// Repository persiste as retenções.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// OnHold informa se o tenant tem retenção aberta.
+func (r *Repository) OnHold(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var held bool
+	err := r.pool.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM tenant_legal_holds WHERE tenant_id = $1 AND status IN `+OpenStatusesSQL+`)
+    `, tenantID).Scan(&held)
+	return held, err
+}
+
+// History lista as retenções do tenant, da mais recente para a mais antiga.
+func (r *Repository) History(ctx context.Context, tenantID uuid.UUID) ([]Hold, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+holdColumns+` FROM tenant_legal_holds WHERE tenant_id = $1 ORDER BY requested_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	holds := make([]Hold, 0)
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, *hold)
+	}
+	return holds, rows.Err()
+}
+
+// Snapshot devolve o retrato gravado na ativação de uma retenção.
+func (r *Repository) Snapshot(ctx context.Context, tenantID, holdID uuid.UUID) (json.RawMessage, string, error) {
+	var (
+		snapshot []byte
+		digest   *string
+	)
+	err := r.pool.QueryRow(ctx, `
+        SELECT snapshot, snapshot_sha256 FROM tenant_legal_holds WHERE tenant_id = $1 AND id = $2 AND snapshot IS NOT NULL
+    `, tenantID, holdID).Scan(&snapshot, &digest)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return snapshot, derefString(digest), nil
+}
+
+// Request abre a retenção aguardando a confirmação de outro SAAS_OWNER.
+func (r *Repository) Request(ctx context.Context, tenantID, actorID uuid.UUID, reason string, caseReference *string) (*Hold, error) {
+	row := r.pool.QueryRow(ctx, `
+        INSERT INTO tenant_legal_holds (tenant_id, reason, case_reference, requested_by)
+        VALUES ($1, $2, $3, $4)
+        RETURNING `+holdColumns, tenantID, strings.TrimSpace(reason), caseReference, actorID)
+	hold, err := scanHold(row)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrAlreadyOpen
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Confirm ativa a retenção pendente e grava o retrato imutável dos dados do tenant.
+func (r *Repository) Confirm(ctx context.Context, tenantID, actorID uuid.UUID) (*Hold, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	pending, err := lockOpen(ctx, tx, tenantID, StatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if pending.RequestedBy == actorID {
+		return nil, ErrSameOwner
+	}
+
+	snapshot, err := buildSnapshot(ctx, tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(snapshot)
+
+	row := tx.QueryRow(ctx, `
+        UPDATE tenant_legal_holds
+        SET status = 'active', confirmed_by = $2, confirmed_at = now(), snapshot = $3, snapshot_sha256 = $4
+        WHERE id = $1
+        RETURNING `+holdColumns, pending.ID, actorID, snapshot, hex.EncodeToString(sum[:]))
+	hold, err := scanHold(row)
+	if err != nil {
+		return nil, err
+	}
+	return hold, tx.Commit(ctx)
+}
+
+// Cancel descarta uma solicitação ainda não confirmada.
+func (r *Repository) Cancel(ctx context.Context, tenantID uuid.UUID) (*Hold, error) {
+	row := r.pool.QueryRow(ctx, `
+        UPDATE tenant_legal_holds SET status = 'cancelled'
+        WHERE tenant_id = $1 AND status = 'pending'
+        RETURNING `+holdColumns, tenantID)
+	return scanHold(row)
+}
+
+// RequestRelease solicita a liberação de uma retenção ativa.
+func (r *Repository) RequestRelease(ctx context.Context, tenantID, actorID uuid.UUID, reason string) (*Hold, error) {
+	row := r.pool.QueryRow(ctx, `
+        UPDATE tenant_legal_holds
+        SET status = 'releasing', release_reason = $3, release_requested_by = $2, release_requested_at = now()
+        WHERE tenant_id = $1 AND status = 'active'
+        RETURNING `+holdColumns, tenantID, actorID, strings.TrimSpace(reason))
+	return scanHold(row)
+}
+
+// ConfirmRelease libera a retenção; exige SAAS_OWNER diferente de quem pediu a liberação.
+func (r *Repository) ConfirmRelease(ctx context.Context, tenantID, actorID uuid.UUID) (*Hold, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	releasing, err := lockOpen(ctx, tx, tenantID, StatusReleasing)
+	if err != nil {
+		return nil, err
+	}
+	if releasing.ReleaseRequestedBy != nil && *releasing.ReleaseRequestedBy == actorID {
+		return nil, ErrSameOwner
+	}
+
+	row := tx.QueryRow(ctx, `
+        UPDATE tenant_legal_holds SET status = 'released', released_by = $2, released_at = now()
+        WHERE id = $1
+        RETURNING `+holdColumns, releasing.ID, actorID)
+	hold, err := scanHold(row)
+	if err != nil {
+		return nil, err
+	}
+	return hold, tx.Commit(ctx)
+}
+
+// AbortRelease devolve uma liberação pendente ao estado ativo.
+func (r *Repository) AbortRelease(ctx context.Context, tenantID uuid.UUID) (*Hold, error) {
+	row := r.pool.QueryRow(ctx, `
+        UPDATE tenant_legal_holds
+        SET status = 'active', release_reason = NULL, release_requested_by = NULL, release_requested_at = NULL
+        WHERE tenant_id = $1 AND status = 'releasing'
+        RETURNING `+holdColumns, tenantID)
+	return scanHold(row)
+}
+
+func lockOpen(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, status string) (*Hold, error) {
+	row := tx.QueryRow(ctx, `
+        SELECT `+holdColumns+` FROM tenant_legal_holds
+        WHERE tenant_id = $1 AND status = $2
+        FOR UPDATE
+    `, tenantID, status)
+	return scanHold(row)
+}
+
+// buildSnapshot registra cadastro, contrato, módulos, faturas, documentos e volumes do tenant.
+func buildSnapshot(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID) ([]byte, error) {
+	const query = `
+        SELECT jsonb_build_object(
+            'taken_at', now(),
+            'tenant', (SELECT to_jsonb(t) FROM tenants t WHERE t.id = $1),
+            'contract', (SELECT to_jsonb(c) - 'contract_file_url' FROM saas_tenant_contracts c WHERE c.tenant_id = $1),
+            'modules', COALESCE((SELECT jsonb_object_agg(m.module_code, m.enabled) FROM saas_tenant_contract_modules m WHERE m.tenant_id = $1), '{}'::jsonb),
+            'invoices', COALESCE((SELECT jsonb_agg(jsonb_build_object('id', i.id, 'reference_month', i.reference_month, 'amount', i.amount, 'status', i.status, 'file_key', i.file_key) ORDER BY i.reference_month)
+                                  FROM saas_tenant_invoices i WHERE i.tenant_id = $1), '[]'::jsonb),
+            'procurement_documents', COALESCE((SELECT jsonb_agg(to_jsonb(d) ORDER BY d.created_at) FROM saas_procurement_documents d WHERE d.tenant_id = $1), '[]'::jsonb),
+            'counts', jsonb_build_object(
+                'secretarias', (SELECT count(*) FROM secretarias s WHERE s.tenant_id = $1),
+                'staff', (SELECT count(DISTINCT us.usuario_id) FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id WHERE s.tenant_id = $1),
+                'support_tickets', (SELECT count(*) FROM support_tickets st WHERE st.tenant_id = $1),
+                'monitor_events', (SELECT count(*) FROM monitor_check_events e WHERE e.tenant_id = $1),
+                'monitor_alerts', (SELECT count(*) FROM monitor_alerts a WHERE a.tenant_id = $1)
+            )
+        )
+    `
+	var snapshot []byte
+	if err := tx.QueryRow(ctx, query, tenantID).Scan(&snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func scanHold(row pgx.Row) (*Hold, error) {
+	var h Hold
+	err := row.Scan(&h.ID, &h.TenantID, &h.Status, &h.Reason, &h.CaseReference, &h.RequestedBy, &h.RequestedAt,
+		&h.ConfirmedBy, &h.ConfirmedAt, &h.ReleaseReason, &h.ReleaseRequestedBy, &h.ReleaseRequestedAt,
+		&h.ReleasedBy, &h.ReleasedAt, &h.SnapshotSHA256)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,271 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotConfigured indica ausência de provedor de envio configurado.
+var ErrNotConfigured = errors.New("mail: envio de e-mail não configurado")
+
+// Provedores de envio suportados.
+const (
+	ProviderSMTP     = "smtp"
+	ProviderSendGrid = "sendgrid"
+	ProviderSES      = "ses"
+)
+
+// Message é um e-mail de texto simples com os cabeçalhos de encadeamento.
+type Message struct {
+	From       string
+	To         []string
+	ReplyTo    string
+	Subject    string
+	Text       string
+	MessageID  string
+	InReplyTo  string
+	References []string
+	// TenantID é a prefeitura de origem; a fila não entrega mensagens de tenant sandbox.
+	TenantID uuid.UUID
+}
+
+// Sender entrega mensagens de saída.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Deliverer é implementado pelos provedores que devolvem o identificador da entrega, gravado no
+// histórico da fila para cruzar com o painel do provedor.
+type Deliverer interface {
+	Deliver(ctx context.Context, msg Message) (string, error)
+}
+
+// Pinger é implementado pelos remetentes que conseguem verificar o relay sem enviar mensagem.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Config descreve o provedor de envio; From é o remetente padrão. Host, Port, Username e Password
+// valem para o SMTP, APIKey para o SendGrid e Region, AccessKeyID e SecretAccessKey para o SES.
+// Endpoint substitui a URL da API HTTP do provedor (testes e regiões privadas).
+type Config struct {
+	Provider        string
+	Host            string
+	Port            int
+	Username        string
+	Password        string
+	From            string
+	APIKey          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Endpoint        string
+	Timeout         time.Duration
+}
+
+// New devolve o remetente do provedor configurado ou ErrNotConfigured. Sem Provider, usa SMTP.
+func New(cfg Config) (Sender, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if provider == "" {
+		provider = ProviderSMTP
+	}
+	switch provider {
+	case ProviderSMTP:
+		if strings.TrimSpace(cfg.Host) == "" {
+			return nil, ErrNotConfigured
+		}
+	case ProviderSendGrid:
+		if strings.TrimSpace(cfg.APIKey) == "" {
+			return nil, ErrNotConfigured
+		}
+	case ProviderSES:
+		if cfg.Region == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, ErrNotConfigured
+		}
+	default:
+		return nil, fmt.Errorf("mail: provedor desconhecido %q", cfg.Provider)
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("mail: remetente inválido: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	switch provider {
+	case ProviderSendGrid:
+		return newSendGridSender(cfg), nil
+	case ProviderSES:
+		return newSESSender(cfg), nil
+	}
+	if cfg.Port <= 0 {
+		cfg.Port = 587
+	}
+	return &smtpSender{cfg: cfg}, nil
+}
+
+// NewMessageID gera um Message-ID único no domínio informado.
+func NewMessageID(domain string) string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(buf), domain)
+}
+
+type smtpSender struct {
+	cfg Config
+}
+
+// Send abre uma conexão por mensagem, com STARTTLS quando o servidor oferece.
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	_, err := s.Deliver(ctx, msg)
+	return err
+}
+
+// Deliver envia a mensagem; o identificador da entrega é o Message-ID, gerado quando ausente.
+func (s *smtpSender) Deliver(ctx context.Context, msg Message) (string, error) {
+	from, recipients, err := prepare(&msg, s.cfg.From)
+	if err != nil {
+		return "", err
+	}
+	if msg.MessageID == "" {
+		msg.MessageID = NewMessageID(from.Address[strings.LastIndex(from.Address, "@")+1:])
+	}
+	return msg.MessageID, s.deliver(ctx, msg, from, recipients)
+}
+
+// prepare aplica o remetente padrão e valida remetente e destinatários.
+func prepare(msg *Message, defaultFrom string) (*mail.Address, []string, error) {
+	if msg.From == "" {
+		msg.From = defaultFrom
+	}
+	if len(msg.To) == 0 {
+		return nil, nil, errors.New("mail: destinatário obrigatório")
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mail: remetente inválido: %w", err)
+	}
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("mail: destinatário inválido %q: %w", to, err)
+		}
+		recipients = append(recipients, addr.Address)
+	}
+	return from, recipients, nil
+}
+
+func (s *smtpSender) deliver(ctx context.Context, msg Message, from *mail.Address, recipients []string) error {
+	client, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mail: MAIL FROM: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("mail: RCPT TO %s: %w", rcpt, err)
+		}
+	}
+	wc, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	if _, err := wc.Write(Render(msg, time.Now())); err != nil {
+		wc.Close()
+		return fmt.Errorf("mail: corpo: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("mail: corpo: %w", err)
+	}
+	return client.Quit()
+}
+
+// Ping conecta, negocia TLS e autentica sem enviar mensagem; usado pelo monitor da plataforma.
+func (s *smtpSender) Ping(ctx context.Context) error {
+	client, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("mail: NOOP: %w", err)
+	}
+	return client.Quit()
+}
+
+// connect abre a sessão SMTP com STARTTLS quando oferecido e autenticação quando configurada.
+func (s *smtpSender) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := net.Dialer{Timeout: s.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mail: conectar: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(s.cfg.Timeout))
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mail: handshake: %w", err)
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("mail: starttls: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("mail: autenticação: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// Render monta a mensagem RFC 5322 em UTF-8, com quoted-printable no corpo.
+func Render(msg Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		if value != "" {
+			buf.WriteString(key + ": " + value + "\r\n")
+		}
+	}
+	header("From", msg.From)
+	header("To", strings.Join(msg.To, ", "))
+	header("Reply-To", msg.ReplyTo)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", msg.MessageID)
+	header("In-Reply-To", msg.InReplyTo)
+	header("References", strings.Join(msg.References, " "))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	_, _ = qp.Write([]byte(strings.ReplaceAll(msg.Text, "\n", "\r\n")))
+	_ = qp.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplateInvite(t *testing.T) {
+	subject, text, err := RenderTemplate(TemplateInvite, InviteData{
+		Name:      "Ana",
+		Role:      "SAAS_ADMIN",
+		Link:      "https://painel.example/convite?token=abc",
+		ExpiresAt: time.Date(2026, 5, 2, 15, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if subject != "Convite para o painel de gestão" {
+		t.Fatalf("assunto inesperado: %q", subject)
+	}
+	for _, want := range []string{"Olá, Ana!", "https://painel.example/convite?token=abc", "02/05/2026 12:00"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("corpo sem %q:\n%s", want, text)
+		}
+	}
+	if _, _, err := RenderTemplate("inexistente", nil); err == nil {
+		t.Fatal("esperava erro para modelo desconhecido")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{0: time.Minute, 1: time.Minute, 3: 4 * time.Minute, 7: time.Hour, 40: time.Hour}
+	for attempt, want := range cases {
+		if got := Backoff(attempt); got != want {
+			t.Fatalf("Backoff(%d) = %s, esperado %s", attempt, got, want)
+		}
+	}
+}
+
+func TestSendGridDeliver(t *testing.T) {
+	var payload sendGridPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer chave" {
+			t.Errorf("requisição inesperada: %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.Header().Set("X-Message-Id", "sg-123")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sender, err := New(Config{Provider: ProviderSendGrid, APIKey: "chave", From: "Prefeitura <nao-responda@example.com>", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	id, err := sender.(Deliverer).Deliver(context.Background(), Message{To: []string{"ana@example.com"}, Subject: "Oi", Text: "corpo", InReplyTo: "<a@b>"})
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if id != "sg-123" {
+		t.Fatalf("id inesperado: %q", id)
+	}
+	if payload.From.Email != "nao-responda@example.com" || payload.Personalizations[0].To[0].Email != "ana@example.com" || payload.Headers["In-Reply-To"] != "<a@b>" {
+		t.Fatalf("payload inesperado: %+v", payload)
+	}
+}
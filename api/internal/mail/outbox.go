@@ -0,0 +1,273 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// ErrDeliveryNotFound indica envio inexistente na fila.
+var ErrDeliveryNotFound = errors.New("mail: envio não encontrado")
+
+// ErrNotRetryable indica reenvio pedido para mensagem que não falhou.
+var ErrNotRetryable = errors.New("mail: só envios com falha podem ser reenviados")
+
+// Status de um envio na fila; suppressed marca mensagem de tenant sandbox, registrada sem envio.
+const (
+	StatusQueued     = "queued"
+	StatusSending    = "sending"
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+	StatusSuppressed = "suppressed"
+)
+
+// MaxAttempts é o número de tentativas antes de o envio ficar como falha definitiva.
+const MaxAttempts = 6
+
+// Delivery é uma mensagem na fila de envio, com o histórico das tentativas.
+type Delivery struct {
+	ID                uuid.UUID  `json:"id"`
+	Template          *string    `json:"template,omitempty"`
+	To                []string   `json:"to"`
+	Subject           string     `json:"subject"`
+	Status            string     `json:"status"`
+	Attempts          int        `json:"attempts"`
+	LastError         *string    `json:"last_error,omitempty"`
+	Provider          *string    `json:"provider,omitempty"`
+	ProviderMessageID *string    `json:"provider_message_id,omitempty"`
+	NextAttemptAt     time.Time  `json:"next_attempt_at"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// DeliveryFilter restringe a listagem da fila.
+type DeliveryFilter struct {
+	Status   string
+	To       string
+	Template string
+	Limit    int
+}
+
+// Backoff devolve a espera antes da próxima tentativa: 1, 2, 4... minutos, até uma hora.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	wait := time.Minute << (attempt - 1)
+	if attempt > 7 || wait > time.Hour {
+		return time.Hour
+	}
+	return wait
+}
+
+const deliveryColumns = `id, template, to_addresses, subject, status, attempts, last_error, provider, provider_message_id,
+        next_attempt_at, sent_at, created_at`
+
+// Outbox grava as mensagens de saída para envio assíncrono e guarda o resultado de cada entrega.
+type Outbox struct {
+	pool *pgxpool.Pool
+}
+
+// NewOutbox cria a fila de envio.
+func NewOutbox(pool *pgxpool.Pool) *Outbox {
+	return &Outbox{pool: pool}
+}
+
+// Enqueue coloca a mensagem na fila; template identifica o modelo usado, vazio para texto livre.
+func (o *Outbox) Enqueue(ctx context.Context, template string, msg Message) (*Delivery, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("mail: destinatário obrigatório")
+	}
+	var tmpl *string
+	if template != "" {
+		tmpl = &template
+	}
+	var tenantID *uuid.UUID
+	if msg.TenantID != uuid.Nil {
+		tenantID = &msg.TenantID
+	}
+	return scanDelivery(o.pool.QueryRow(ctx, `
+        INSERT INTO mail_outbox (template, from_address, to_addresses, reply_to, subject, body, message_id, in_reply_to, "references",
+            tenant_id, status)
+        VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''), NULLIF($8, ''), COALESCE($9, '{}'::text[]),
+            $10, CASE WHEN EXISTS (SELECT 1 FROM tenants WHERE id = $10 AND environment = 'sandbox') THEN 'suppressed' ELSE 'queued' END)
+        RETURNING `+deliveryColumns,
+		tmpl, msg.From, msg.To, msg.ReplyTo, msg.Subject, msg.Text, msg.MessageID, msg.InReplyTo, msg.References, tenantID))
+}
+
+// List lista a fila da mensagem mais recente à mais antiga.
+func (o *Outbox) List(ctx context.Context, filter DeliveryFilter) ([]Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM mail_outbox WHERE TRUE`
+	args := []any{}
+	add := func(cond string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+cond, len(args))
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.To != "" {
+		add("EXISTS (SELECT 1 FROM unnest(to_addresses) AS a WHERE lower(a) = lower($%d))", filter.To)
+	}
+	if filter.Template != "" {
+		add("template = $%d", filter.Template)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := o.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Delivery, error) {
+		d, err := scanDelivery(row)
+		if err != nil {
+			return Delivery{}, err
+		}
+		return *d, nil
+	})
+}
+
+// Retry devolve à fila um envio com falha definitiva, zerando as tentativas.
+func (o *Outbox) Retry(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	d, err := scanDelivery(o.pool.QueryRow(ctx, `
+        UPDATE mail_outbox
+        SET status = 'queued', attempts = 0, next_attempt_at = now(), updated_at = now()
+        WHERE id = $1 AND status = 'failed'
+        RETURNING `+deliveryColumns, id))
+	if errors.Is(err, ErrDeliveryNotFound) {
+		var exists bool
+		if err := o.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM mail_outbox WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrNotRetryable
+		}
+	}
+	return d, err
+}
+
+// Worker envia as mensagens pendentes da fila pelo provedor configurado.
+type Worker struct {
+	outbox   *Outbox
+	sender   Sender
+	provider string
+	logger   zerolog.Logger
+	batch    int
+}
+
+// NewWorker cria o worker; provider é gravado em cada entrega para consulta posterior.
+func NewWorker(outbox *Outbox, sender Sender, provider string, logger zerolog.Logger) *Worker {
+	if provider == "" {
+		provider = ProviderSMTP
+	}
+	return &Worker{outbox: outbox, sender: sender, provider: provider, logger: logger, batch: 50}
+}
+
+type claimed struct {
+	id       uuid.UUID
+	attempts int
+	msg      Message
+}
+
+// RunOnce reserva um lote de mensagens vencidas e tenta entregá-las. Mensagens presas em "sending"
+// por uma réplica que caiu voltam a ser elegíveis depois de dez minutos. Mensagens de tenant que
+// virou sandbox depois de enfileiradas são suprimidas antes da reserva.
+func (w *Worker) RunOnce(ctx context.Context) error {
+	if _, err := w.outbox.pool.Exec(ctx, `
+        UPDATE mail_outbox o
+        SET status = 'suppressed', updated_at = now()
+        FROM tenants t
+        WHERE t.id = o.tenant_id AND t.environment = 'sandbox' AND o.status IN ('queued', 'sending')`); err != nil {
+		return err
+	}
+	rows, err := w.outbox.pool.Query(ctx, `
+        UPDATE mail_outbox o
+        SET status = 'sending', attempts = o.attempts + 1, next_attempt_at = now() + interval '10 minutes',
+            updated_at = now()
+        WHERE o.id IN (
+            SELECT id FROM mail_outbox
+            WHERE status IN ('queued', 'sending') AND next_attempt_at <= now()
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING o.id, o.attempts, COALESCE(o.from_address, ''), o.to_addresses, COALESCE(o.reply_to, ''), o.subject, o.body,
+            COALESCE(o.message_id, ''), COALESCE(o.in_reply_to, ''), o."references"`, w.batch)
+	if err != nil {
+		return err
+	}
+	batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (claimed, error) {
+		var c claimed
+		err := row.Scan(&c.id, &c.attempts, &c.msg.From, &c.msg.To, &c.msg.ReplyTo, &c.msg.Subject, &c.msg.Text,
+			&c.msg.MessageID, &c.msg.InReplyTo, &c.msg.References)
+		return c, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range batch {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		providerID, sendErr := w.deliver(ctx, c.msg)
+		if err := w.record(ctx, c, providerID, sendErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) deliver(ctx context.Context, msg Message) (string, error) {
+	if deliverer, ok := w.sender.(Deliverer); ok {
+		return deliverer.Deliver(ctx, msg)
+	}
+	return "", w.sender.Send(ctx, msg)
+}
+
+// record grava o resultado da tentativa: enviado, nova tentativa com espera crescente ou falha
+// definitiva após MaxAttempts.
+func (w *Worker) record(ctx context.Context, c claimed, providerID string, sendErr error) error {
+	if sendErr == nil {
+		_, err := w.outbox.pool.Exec(ctx, `
+            UPDATE mail_outbox
+            SET status = 'sent', sent_at = now(), provider = $2, provider_message_id = NULLIF($3, ''), last_error = NULL,
+                updated_at = now()
+            WHERE id = $1`, c.id, w.provider, providerID)
+		return err
+	}
+
+	status := StatusQueued
+	if c.attempts >= MaxAttempts {
+		status = StatusFailed
+	}
+	w.logger.Warn().Err(sendErr).Str("delivery_id", c.id.String()).Int("attempts", c.attempts).Str("status", status).Msg("mail: falha no envio")
+	_, err := w.outbox.pool.Exec(ctx, `
+        UPDATE mail_outbox
+        SET status = $2, last_error = $3, provider = $4, next_attempt_at = now() + make_interval(secs => $5), updated_at = now()
+        WHERE id = $1`, c.id, status, sendErr.Error(), w.provider, Backoff(c.attempts).Seconds())
+	return err
+}
+
+func scanDelivery(row pgx.Row) (*Delivery, error) {
+	var d Delivery
+	if err := row.Scan(&d.ID, &d.Template, &d.To, &d.Subject, &d.Status, &d.Attempts, &d.LastError, &d.Provider,
+		&d.ProviderMessageID, &d.NextAttemptAt, &d.SentAt, &d.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
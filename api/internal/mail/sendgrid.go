@@ -0,0 +1,137 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const sendGridEndpoint = "https://api.sendgrid.com"
+
+type sendGridSender struct {
+	cfg      Config
+	endpoint string
+	client   *http.Client
+}
+
+func newSendGridSender(cfg Config) *sendGridSender {
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = sendGridEndpoint
+	}
+	return &sendGridSender{cfg: cfg, endpoint: endpoint, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPayload struct {
+	Personalizations []struct {
+		To []sendGridAddress `json:"to"`
+	} `json:"personalizations"`
+	From    sendGridAddress   `json:"from"`
+	ReplyTo *sendGridAddress  `json:"reply_to,omitempty"`
+	Subject string            `json:"subject"`
+	Content []sendGridContent `json:"content"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Send envia pela API v3 do SendGrid.
+func (s *sendGridSender) Send(ctx context.Context, msg Message) error {
+	_, err := s.Deliver(ctx, msg)
+	return err
+}
+
+// Deliver envia e devolve o X-Message-Id atribuído pelo SendGrid.
+func (s *sendGridSender) Deliver(ctx context.Context, msg Message) (string, error) {
+	payload, err := s.payload(msg)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.do(ctx, http.MethodPost, "/v3/mail/send", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("mail: sendgrid respondeu %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// Ping confere a chave de API listando os escopos dela.
+func (s *sendGridSender) Ping(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodGet, "/v3/scopes", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("mail: sendgrid respondeu %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *sendGridSender) payload(msg Message) (*sendGridPayload, error) {
+	from, recipients, err := prepare(&msg, s.cfg.From)
+	if err != nil {
+		return nil, err
+	}
+	payload := &sendGridPayload{
+		From:    sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject: msg.Subject,
+		Content: []sendGridContent{{Type: "text/plain", Value: msg.Text}},
+		Headers: map[string]string{},
+	}
+	payload.Personalizations = make([]struct {
+		To []sendGridAddress `json:"to"`
+	}, 1)
+	for _, rcpt := range recipients {
+		payload.Personalizations[0].To = append(payload.Personalizations[0].To, sendGridAddress{Email: rcpt})
+	}
+	if msg.ReplyTo != "" {
+		payload.ReplyTo = &sendGridAddress{Email: msg.ReplyTo}
+	}
+	for key, value := range map[string]string{
+		"Message-ID":  msg.MessageID,
+		"In-Reply-To": msg.InReplyTo,
+		"References":  strings.Join(msg.References, " "),
+	} {
+		if value != "" {
+			payload.Headers[key] = value
+		}
+	}
+	return payload, nil
+}
+
+func (s *sendGridSender) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mail: sendgrid: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,142 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type sesSender struct {
+	cfg      Config
+	endpoint string
+	client   *http.Client
+}
+
+func newSESSender(cfg Config) *sesSender {
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://email." + cfg.Region + ".amazonaws.com"
+	}
+	return &sesSender{cfg: cfg, endpoint: endpoint, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Send envia pela API v2 do SES com a mensagem MIME montada aqui, o que preserva os cabeçalhos de
+// encadeamento das respostas de chamado.
+func (s *sesSender) Send(ctx context.Context, msg Message) error {
+	_, err := s.Deliver(ctx, msg)
+	return err
+}
+
+// Deliver envia e devolve o MessageId atribuído pelo SES.
+func (s *sesSender) Deliver(ctx context.Context, msg Message) (string, error) {
+	from, recipients, err := prepare(&msg, s.cfg.From)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(map[string]any{
+		"FromEmailAddress": from.Address,
+		"Destination":      map[string]any{"ToAddresses": recipients},
+		"Content":          map[string]any{"Raw": map[string]any{"Data": Render(msg, time.Now())}},
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.do(ctx, http.MethodPost, "/v2/email/outbound-emails", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("mail: ses respondeu %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	var out struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("mail: resposta do ses: %w", err)
+	}
+	return out.MessageID, nil
+}
+
+// Ping consulta a conta do SES, o que valida credenciais e região.
+func (s *sesSender) Ping(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodGet, "/v2/email/account", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("mail: ses respondeu %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *sesSender) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	signSES(req, body, s.cfg, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mail: ses: %w", err)
+	}
+	return resp, nil
+}
+
+// signSES assina a requisição com AWS Signature V4 (serviço "ses"), cobrindo host e x-amz-date.
+func signSES(req *http.Request, body []byte, cfg Config, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256.Sum256(body)
+	canonicalPath := req.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\nx-amz-date:" + amzDate + "\n",
+		"host;x-amz-date",
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := dateStamp + "/" + cfg.Region + "/ses/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSum([]byte("AWS4"+cfg.SecretAccessKey), dateStamp)
+	key = hmacSum(key, cfg.Region)
+	key = hmacSum(key, "ses")
+	key = hmacSum(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-date, Signature=%s",
+		cfg.AccessKeyID, scope, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gestaozabele/municipio/internal/fakes"
+)
+
+func TestSMTPContract(t *testing.T) {
+	relay, err := fakes.NewSMTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer relay.Close()
+	relay.RequireAuth("app", "senha")
+	relay.RejectRecipient("inexistente@example.com")
+
+	sender, err := New(Config{Host: relay.Host, Port: relay.Port, Username: "app", Password: "senha", From: "Prefeitura <nao-responda@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := sender.(Pinger).Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if err := sender.Send(ctx, Message{To: []string{"ana@example.com"}, Subject: "Protocolo", Text: "Seu protocolo foi aberto."}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	msgs := relay.Messages()
+	if len(msgs) != 1 || msgs[0].From != "nao-responda@example.com" || msgs[0].To[0] != "ana@example.com" || msgs[0].Auth != "app" {
+		t.Fatalf("mensagens inesperadas: %+v", msgs)
+	}
+	if !strings.Contains(msgs[0].Data, "Subject: Protocolo") {
+		t.Fatalf("cabeçalho ausente:\n%s", msgs[0].Data)
+	}
+
+	if err := sender.Send(ctx, Message{To: []string{"inexistente@example.com"}, Subject: "x", Text: "x"}); err == nil || !strings.Contains(err.Error(), "RCPT") {
+		t.Fatalf("destinatário recusado deveria falhar no RCPT: %v", err)
+	}
+	relay.FailNext(451, 1)
+	if err := sender.Send(ctx, Message{To: []string{"ana@example.com"}, Subject: "x", Text: "x"}); err == nil || !strings.Contains(err.Error(), "451") {
+		t.Fatalf("falha temporária esperada: %v", err)
+	}
+
+	wrong, _ := New(Config{Host: relay.Host, Port: relay.Port, Username: "app", Password: "errada", From: "nao-responda@example.com"})
+	if err := wrong.(Pinger).Ping(ctx); err == nil {
+		t.Fatal("senha errada deveria falhar")
+	}
+}
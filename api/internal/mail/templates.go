@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Modelos de mensagem; o nome fica gravado na fila de envio.
+const (
+	TemplateInvite        = "convite"
+	TemplatePasswordReset = "redefinicao_senha"
+	TemplateTicketReply   = "resposta_chamado"
+)
+
+// InviteData preenche o convite de acesso ao painel SaaS.
+type InviteData struct {
+	Name      string
+	Role      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// PasswordResetData preenche o pedido de redefinição de senha.
+type PasswordResetData struct {
+	Name      string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+// TicketReplyData preenche a resposta da equipe a um chamado aberto por e-mail.
+type TicketReplyData struct {
+	Subject string
+	Body    string
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	text    *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"data": func(t time.Time) string {
+		return t.In(saoPaulo).Format("02/01/2006 15:04")
+	},
+	"minutos": func(d time.Duration) int {
+		return int(d.Round(time.Minute) / time.Minute)
+	},
+}
+
+var saoPaulo = func() *time.Location {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.FixedZone("BRT", -3*60*60)
+	}
+	return loc
+}()
+
+var templates = map[string]messageTemplate{
+	TemplateInvite: parseTemplate(TemplateInvite,
+		`Convite para o painel de gestão`,
+		`Olá{{if .Name}}, {{.Name}}{{end}}!
+
+Você foi convidado para acessar o painel de gestão{{if .Role}} com o papel {{.Role}}{{end}}.
+Para criar sua senha e ativar o acesso, abra o link abaixo:
+
+{{.Link}}
+
+O convite vale até {{data .ExpiresAt}}. Se você não esperava este e-mail, ignore-o.
+`),
+	TemplatePasswordReset: parseTemplate(TemplatePasswordReset,
+		`Redefinição de senha`,
+		`Olá{{if .Name}}, {{.Name}}{{end}}!
+
+Recebemos um pedido para redefinir a sua senha. Para escolher uma nova, abra o link abaixo:
+
+{{.Link}}
+
+O link expira em {{minutos .ExpiresIn}} minutos e só pode ser usado uma vez. Se você não fez o
+pedido, ignore este e-mail: a senha atual continua valendo.
+`),
+	TemplateTicketReply: parseTemplate(TemplateTicketReply,
+		`{{.Subject}}`,
+		`{{.Body}}
+
+--
+Responda a este e-mail para continuar o atendimento.
+`),
+}
+
+func parseTemplate(name, subject, text string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New(name + ".subject").Funcs(templateFuncs).Parse(subject)),
+		text:    template.Must(template.New(name + ".text").Funcs(templateFuncs).Parse(text)),
+	}
+}
+
+// RenderTemplate preenche o modelo e devolve assunto e corpo em texto simples.
+func RenderTemplate(name string, data any) (string, string, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("mail: modelo desconhecido %q", name)
+	}
+	var subject, text bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("mail: assunto de %s: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return "", "", fmt.Errorf("mail: corpo de %s: %w", name, err)
+	}
+	return strings.TrimSpace(subject.String()), text.String(), nil
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// HTTPRequestDuration mede a latência por rota do chi, não pelo caminho bruto, para manter a
+	// cardinalidade baixa.
+	HTTPRequestDuration = NewHistogram("http_request_duration_seconds", "Latência das requisições HTTP por rota.",
+		[]string{"method", "route", "status"}, DefaultBuckets)
+
+	// RedisCommandDuration mede a latência dos comandos Redis, incluindo pipelines.
+	RedisCommandDuration = NewHistogram("redis_command_duration_seconds", "Latência dos comandos Redis.",
+		[]string{"command", "result"}, FastBuckets)
+
+	// LoginAttempts conta tentativas de login por audiência e resultado.
+	LoginAttempts = NewCounter("auth_login_attempts_total", "Tentativas de login por audiência e resultado.",
+		[]string{"audience", "result"})
+)
+
+// RedisHook registra a latência de cada comando em RedisCommandDuration.
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		RedisCommandDuration.Observe(time.Since(start).Seconds(), strings.ToLower(cmd.Name()), redisResult(err))
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		RedisCommandDuration.Observe(time.Since(start).Seconds(), "pipeline", redisResult(err))
+		return err
+	}
+}
+
+// redisResult trata redis.Nil como sucesso: chave ausente é resposta normal.
+func redisResult(err error) string {
+	if err == nil || err == redis.Nil {
+		return "ok"
+	}
+	return "error"
+}
+
+// StatusClass agrupa o status HTTP em 2xx, 3xx, 4xx ou 5xx.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "2xx"
+	}
+	return string(rune('0'+status/100)) + "xx"
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHistogramWrite(t *testing.T) {
+	h := NewHistogram("x_seconds", "Teste.", []string{"route"}, []float64{0.1, 1})
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(2, "/a")
+
+	var b strings.Builder
+	h.Write(&b)
+	want := `# HELP x_seconds Teste.
+# TYPE x_seconds histogram
+x_seconds_bucket{route="/a",le="0.1"} 1
+x_seconds_bucket{route="/a",le="1"} 2
+x_seconds_bucket{route="/a",le="+Inf"} 3
+x_seconds_sum{route="/a"} 2.55
+x_seconds_count{route="/a"} 3
+`
+	if b.String() != want {
+		t.Fatalf("saída inesperada:\n%s", b.String())
+	}
+}
+
+func TestCounterWrite(t *testing.T) {
+	c := NewCounter("logins_total", "Teste.", []string{"audience", "result"})
+	c.Inc("saas", "failure")
+	c.Inc("backoffice", "success")
+	c.Inc("backoffice", "success")
+
+	var b strings.Builder
+	c.Write(&b)
+	want := `# HELP logins_total Teste.
+# TYPE logins_total counter
+logins_total{audience="backoffice",result="success"} 2
+logins_total{audience="saas",result="failure"} 1
+`
+	if b.String() != want {
+		t.Fatalf("saída inesperada:\n%s", b.String())
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, want := range map[int]string{200: "2xx", 204: "2xx", 302: "3xx", 404: "4xx", 503: "5xx", 0: "2xx"} {
+		if got := StatusClass(status); got != want {
+			t.Errorf("StatusClass(%d) = %s, esperado %s", status, got, want)
+		}
+	}
+}
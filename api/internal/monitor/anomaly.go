@@ -0,0 +1,238 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+const (
+	MetricLatency = "latency_ms"
+	MetricTraffic = "online_users"
+)
+
+// TrafficSource informa quantos usuários estão ativos agora em cada tenant.
+type TrafficSource interface {
+	OnlineByTenant(ctx context.Context) (map[uuid.UUID]int64, error)
+}
+
+// AnomalyState é o estado exponencial de uma série de um tenant.
+type AnomalyState struct {
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	Metric        string     `json:"metric"`
+	Mean          float64    `json:"mean"`
+	Variance      float64    `json:"variance"`
+	Samples       int        `json:"samples"`
+	LastValue     *float64   `json:"last_value,omitempty"`
+	LastScore     *float64   `json:"last_score,omitempty"`
+	LastAnomalyAt *time.Time `json:"last_anomaly_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Anomaly descreve um desvio detectado.
+type Anomaly struct {
+	Direction string
+	Score     float64
+	Expected  float64
+	Value     float64
+}
+
+// Detector aplica média e variância móveis exponenciais (EWMA). Um valor é anômalo quando se
+// afasta mais de Threshold desvios da média, depois de Warmup amostras.
+type Detector struct {
+	Alpha     float64
+	Threshold float64
+	Warmup    int
+}
+
+// Observe pontua o valor contra o estado atual e devolve o estado atualizado.
+func (d Detector) Observe(state AnomalyState, value float64) (AnomalyState, *Anomaly) {
+	if state.Samples == 0 {
+		state.Mean = value
+		state.Variance = 0
+		state.Samples = 1
+		state.LastValue = &value
+		return state, nil
+	}
+
+	diff := value - state.Mean
+	// O piso no desvio evita que séries muito estáveis disparem com variações irrelevantes.
+	std := math.Max(math.Sqrt(state.Variance), math.Max(0.05*math.Abs(state.Mean), 1))
+	score := diff / std
+
+	var anomaly *Anomaly
+	if state.Samples >= d.Warmup && math.Abs(score) >= d.Threshold {
+		direction := "spike"
+		if score < 0 {
+			direction = "drop"
+		}
+		anomaly = &Anomaly{Direction: direction, Score: score, Expected: state.Mean, Value: value}
+	}
+
+	state.Mean += d.Alpha * diff
+	state.Variance = (1 - d.Alpha) * (state.Variance + d.Alpha*diff*diff)
+	state.Samples++
+	state.LastValue = &value
+	state.LastScore = &score
+	return state, anomaly
+}
+
+// Anomalies lista o estado do detector, opcionalmente de um único tenant.
+func (s *Service) Anomalies(ctx context.Context, tenantID *uuid.UUID) ([]AnomalyState, error) {
+	return s.repo.ListAnomalyStates(ctx, tenantID)
+}
+
+// UseTraffic liga a série de usuários online ao detector.
+func (s *Service) UseTraffic(source TrafficSource) {
+	s.traffic = source
+}
+
+func (s *Service) detector() Detector {
+	d := Detector{Alpha: s.cfg.AnomalyAlpha, Threshold: s.cfg.AnomalyThreshold, Warmup: s.cfg.AnomalyWarmup}
+	if d.Alpha <= 0 || d.Alpha >= 1 {
+		d.Alpha = 0.1
+	}
+	if d.Threshold <= 0 {
+		d.Threshold = 3
+	}
+	return d
+}
+
+// observeAnomaly alimenta a série e alerta desvios relevantes: latência só preocupa quando sobe;
+// tráfego alerta picos e quedas, estas apenas em tenants com movimento suficiente para significar algo.
+func (s *Service) observeAnomaly(ctx context.Context, t *tenant.Tenant, metric string, value float64) {
+	state, err := s.repo.GetAnomalyState(ctx, t.ID, metric)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("tenant", t.Slug).Str("metric", metric).Msg("monitor: estado de anomalia indisponível")
+		return
+	}
+
+	next, anomaly := s.detector().Observe(state, value)
+	if anomaly != nil {
+		relevant := true
+		switch metric {
+		case MetricLatency:
+			relevant = anomaly.Direction == "spike"
+		case MetricTraffic:
+			relevant = anomaly.Direction == "spike" || anomaly.Expected >= 5
+		}
+		if relevant {
+			now := time.Now()
+			next.LastAnomalyAt = &now
+			s.raiseAnomaly(ctx, t, metric, anomaly, now)
+		}
+	}
+
+	if err := s.repo.SaveAnomalyState(ctx, next); err != nil {
+		s.logger.Warn().Err(err).Str("tenant", t.Slug).Str("metric", metric).Msg("monitor: falha ao salvar estado de anomalia")
+	}
+}
+
+func (s *Service) raiseAnomaly(ctx context.Context, t *tenant.Tenant, metric string, anomaly *Anomaly, now time.Time) {
+	if !s.cfg.Enabled {
+		return
+	}
+	alertType := "anomaly_" + metric
+	if s.shouldThrottleAlert(ctx, &t.ID, alertType, now) {
+		return
+	}
+
+	var message string
+	switch metric {
+	case MetricLatency:
+		message = fmt.Sprintf("Latência incomum: %.0fms contra média recente de %.0fms", anomaly.Value, anomaly.Expected)
+	case MetricTraffic:
+		verbo := "acima"
+		if anomaly.Direction == "drop" {
+			verbo = "abaixo"
+		}
+		message = fmt.Sprintf("Usuários online %s do padrão: %.0f contra média recente de %.0f", verbo, anomaly.Value, anomaly.Expected)
+	default:
+		message = fmt.Sprintf("Série %s fora do padrão: %.2f contra %.2f", metric, anomaly.Value, anomaly.Expected)
+	}
+
+	alert := Alert{
+		TenantID:    &t.ID,
+		AlertType:   alertType,
+		Severity:    "warning",
+		Message:     message,
+		TriggeredAt: now,
+		Metadata: map[string]any{
+			"metric":    metric,
+			"direction": anomaly.Direction,
+			"score":     math.Round(anomaly.Score*100) / 100,
+			"expected":  anomaly.Expected,
+			"value":     anomaly.Value,
+		},
+	}
+	if err := s.repo.InsertAlert(ctx, alert); err != nil {
+		s.logger.Error().Err(err).Str("tenant", t.Slug).Msg("monitor: falha ao registrar anomalia")
+		return
+	}
+	if s.notifier != nil {
+		title := fmt.Sprintf("Tenant %s (%s)", t.DisplayName, t.Slug)
+		if err := s.notifier.Notify(ctx, AlertMessage{Title: title, Text: message, Severity: "warning"}); err != nil {
+			s.logger.Error().Err(err).Str("tenant", t.Slug).Msg("monitor: falha ao enviar anomalia")
+		}
+	}
+}
+
+// GetAnomalyState devolve o estado da série; séries novas começam vazias.
+func (r *Repository) GetAnomalyState(ctx context.Context, tenantID uuid.UUID, metric string) (AnomalyState, error) {
+	state := AnomalyState{TenantID: tenantID, Metric: metric}
+	err := r.pool.QueryRow(ctx, `
+        SELECT mean, variance, samples, last_value, last_score, last_anomaly_at, updated_at
+        FROM monitor_anomaly_state
+        WHERE tenant_id = $1 AND metric = $2
+    `, tenantID, metric).Scan(&state.Mean, &state.Variance, &state.Samples, &state.LastValue, &state.LastScore, &state.LastAnomalyAt, &state.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return state, nil
+	}
+	return state, err
+}
+
+func (r *Repository) SaveAnomalyState(ctx context.Context, state AnomalyState) error {
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO monitor_anomaly_state (tenant_id, metric, mean, variance, samples, last_value, last_score, last_anomaly_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+        ON CONFLICT (tenant_id, metric) DO UPDATE
+        SET mean = EXCLUDED.mean,
+            variance = EXCLUDED.variance,
+            samples = EXCLUDED.samples,
+            last_value = EXCLUDED.last_value,
+            last_score = EXCLUDED.last_score,
+            last_anomaly_at = COALESCE(EXCLUDED.last_anomaly_at, monitor_anomaly_state.last_anomaly_at),
+            updated_at = now()
+    `, state.TenantID, state.Metric, state.Mean, state.Variance, state.Samples, state.LastValue, state.LastScore, state.LastAnomalyAt)
+	return err
+}
+
+func (r *Repository) ListAnomalyStates(ctx context.Context, tenantID *uuid.UUID) ([]AnomalyState, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT tenant_id, metric, mean, variance, samples, last_value, last_score, last_anomaly_at, updated_at
+        FROM monitor_anomaly_state
+        WHERE $1::uuid IS NULL OR tenant_id = $1
+        ORDER BY last_anomaly_at DESC NULLS LAST, tenant_id, metric
+    `, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make([]AnomalyState, 0)
+	for rows.Next() {
+		var state AnomalyState
+		if err := rows.Scan(&state.TenantID, &state.Metric, &state.Mean, &state.Variance, &state.Samples, &state.LastValue, &state.LastScore, &state.LastAnomalyAt, &state.UpdatedAt); err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
@@ -0,0 +1,40 @@
+package monitor
+
+import "testing"
+
+func TestDetectorObserve(t *testing.T) {
+	d := Detector{Alpha: 0.1, Threshold: 3, Warmup: 10}
+	state := AnomalyState{}
+
+	for i := 0; i < 40; i++ {
+		value := 200.0
+		if i%2 == 0 {
+			value = 220
+		}
+		var anomaly *Anomaly
+		state, anomaly = d.Observe(state, value)
+		if anomaly != nil {
+			t.Fatalf("unexpected anomaly on stable series at %d: %+v", i, anomaly)
+		}
+	}
+
+	next, anomaly := d.Observe(state, 900)
+	if anomaly == nil || anomaly.Direction != "spike" {
+		t.Fatalf("expected spike, got %+v", anomaly)
+	}
+	if next.Mean <= state.Mean {
+		t.Fatalf("expected mean to move toward the new value")
+	}
+
+	if _, anomaly := d.Observe(state, 0); anomaly == nil || anomaly.Direction != "drop" {
+		t.Fatalf("expected drop, got %+v", anomaly)
+	}
+}
+
+func TestDetectorWarmup(t *testing.T) {
+	d := Detector{Alpha: 0.1, Threshold: 3, Warmup: 10}
+	state, _ := d.Observe(AnomalyState{}, 100)
+	if _, anomaly := d.Observe(state, 10000); anomaly != nil {
+		t.Fatalf("expected no anomaly before warmup, got %+v", anomaly)
+	}
+}
@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const alertTypeDependency = "dependency"
+
+// Probe verifica uma dependência da plataforma (e-mail, storage, gateways) com uma operação
+// sintética, sem efeito para usuários.
+type Probe interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type funcProbe struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (p funcProbe) Name() string                    { return p.name }
+func (p funcProbe) Check(ctx context.Context) error { return p.fn(ctx) }
+
+// NewProbe adapta uma função a Probe.
+func NewProbe(name string, fn func(ctx context.Context) error) Probe {
+	return funcProbe{name: name, fn: fn}
+}
+
+// NewHTTPProbe considera a dependência disponível quando o endpoint responde abaixo de 500;
+// respostas 401/404 ainda provam que o serviço está no ar.
+func NewHTTPProbe(name, url string, client *http.Client) Probe {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return NewProbe(name, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// UseDependencies registra as dependências verificadas a cada coleta.
+func (s *Service) UseDependencies(probes ...Probe) {
+	s.probes = append(s.probes, probes...)
+}
+
+// Dependencies devolve o último estado das dependências da plataforma.
+func (s *Service) Dependencies(ctx context.Context) ([]DependencyHealth, error) {
+	return s.repo.ListDependencies(ctx)
+}
+
+func (s *Service) checkDependencies(ctx context.Context) {
+	for _, probe := range s.probes {
+		health := runProbe(ctx, probe, s.client.Timeout)
+		previous, err := s.repo.RecordDependency(ctx, health)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("dependency", health.Name).Msg("monitor: falha ao registrar dependência")
+			continue
+		}
+		if severity, message, ok := dependencyTransition(previous, health); ok {
+			s.alertDependency(ctx, severity, message)
+		}
+	}
+}
+
+func runProbe(ctx context.Context, probe Probe, timeout time.Duration) DependencyHealth {
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe.Check(probeCtx)
+	ms := int(time.Since(start).Milliseconds())
+
+	health := DependencyHealth{Name: probe.Name(), Status: "up", LatencyMS: &ms, LastCheckedAt: time.Now()}
+	if err != nil {
+		msg := err.Error()
+		health.Status = "down"
+		health.Error = &msg
+	}
+	return health
+}
+
+// dependencyTransition decide o alerta da leitura: queda ao passar de "up" (ou da primeira
+// leitura) para "down" e recuperação ao voltar de "down".
+func dependencyTransition(previous string, health DependencyHealth) (string, string, bool) {
+	switch {
+	case health.Status == "down" && previous != "down":
+		reason := ""
+		if health.Error != nil {
+			reason = *health.Error
+		}
+		return "critical", fmt.Sprintf("Dependência %s indisponível: %s", health.Name, strings.TrimSpace(reason)), true
+	case health.Status == "up" && previous == "down":
+		return "info", fmt.Sprintf("Dependência %s restabelecida", health.Name), true
+	}
+	return "", "", false
+}
+
+func (s *Service) alertDependency(ctx context.Context, severity, message string) {
+	alert := Alert{
+		ID:          uuid.New(),
+		AlertType:   alertTypeDependency,
+		Severity:    severity,
+		Message:     message,
+		TriggeredAt: time.Now(),
+	}
+	if err := s.repo.InsertAlert(ctx, alert); err != nil {
+		s.logger.Error().Err(err).Msg("monitor: falha ao registrar alerta de dependência")
+		return
+	}
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.Notify(ctx, AlertMessage{Title: "Plataforma", Text: message, Severity: severity}); err != nil {
+		s.logger.Error().Err(err).Msg("monitor: falha ao enviar alerta de dependência")
+		return
+	}
+	if err := s.repo.MarkAlertDelivered(ctx, alert.ID, "slack"); err != nil {
+		s.logger.Error().Err(err).Msg("monitor: falha ao marcar alerta entregue")
+	}
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDependencyTransition(t *testing.T) {
+	msg := "connection refused"
+	down := DependencyHealth{Name: "email", Status: "down", Error: &msg}
+	up := DependencyHealth{Name: "email", Status: "up"}
+
+	if severity, _, ok := dependencyTransition("", down); !ok || severity != "critical" {
+		t.Fatalf("primeira leitura com falha deveria alertar, obteve %q %v", severity, ok)
+	}
+	if severity, _, ok := dependencyTransition("up", down); !ok || severity != "critical" {
+		t.Fatalf("queda deveria alertar, obteve %q %v", severity, ok)
+	}
+	if _, _, ok := dependencyTransition("down", down); ok {
+		t.Fatal("falha contínua não deveria repetir o alerta")
+	}
+	if severity, _, ok := dependencyTransition("down", up); !ok || severity != "info" {
+		t.Fatalf("recuperação deveria avisar, obteve %q %v", severity, ok)
+	}
+	if _, _, ok := dependencyTransition("", up); ok {
+		t.Fatal("primeira leitura saudável não deveria alertar")
+	}
+}
+
+func TestRunProbe(t *testing.T) {
+	failing := NewProbe("pagamentos", func(ctx context.Context) error { return errors.New("timeout") })
+	health := runProbe(context.Background(), failing, time.Second)
+	if health.Status != "down" || health.Error == nil || *health.Error != "timeout" {
+		t.Fatalf("probe com erro = %+v", health)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/quebrado" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	if health := runProbe(context.Background(), NewHTTPProbe("fcm", srv.URL, srv.Client()), time.Second); health.Status != "up" {
+		t.Fatalf("401 prova que o serviço está no ar: %+v", health)
+	}
+	if health := runProbe(context.Background(), NewHTTPProbe("fcm", srv.URL+"/quebrado", srv.Client()), time.Second); health.Status != "down" {
+		t.Fatalf("503 deveria marcar a dependência como fora: %+v", health)
+	}
+}
@@ -0,0 +1,245 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+// SourceDNS identifica nos eventos de check as verificações de domínio.
+const SourceDNS = "dns"
+
+const alertTypeDNS = "dns"
+
+// HostResolver resolve nomes de domínio; *net.Resolver satisfaz a interface.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DomainResult é o resultado da verificação de um domínio.
+type DomainResult struct {
+	TenantID   uuid.UUID
+	Domain     string
+	Addresses  []string
+	Resolved   bool
+	StatusCode *int
+	Reachable  bool
+	Duration   time.Duration
+	Error      string
+}
+
+// DomainVerifier revalida periodicamente DNS e acesso HTTP dos domínios dos tenants ativos,
+// em paralelo e com concorrência limitada. Domínios configurados que deixam de resolver (caso
+// típico de registro expirado) viram falha de DNS no tenant e abrem alerta.
+type DomainVerifier struct {
+	repo        *Repository
+	tenants     *tenant.Service
+	resolver    HostResolver
+	client      *http.Client
+	notifier    Notifier
+	concurrency int
+	logger      zerolog.Logger
+}
+
+// NewDomainVerifier cria o verificador; concurrency <= 0 usa 8 verificações simultâneas.
+func NewDomainVerifier(repo *Repository, tenants *tenant.Service, timeout time.Duration, concurrency int, notifier Notifier, logger zerolog.Logger) *DomainVerifier {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	return &DomainVerifier{
+		repo:        repo,
+		tenants:     tenants,
+		resolver:    net.DefaultResolver,
+		client:      &http.Client{Timeout: timeout},
+		notifier:    notifier,
+		concurrency: concurrency,
+		logger:      logger,
+	}
+}
+
+// RunOnce verifica todos os domínios de tenants ativos e registra os resultados.
+func (v *DomainVerifier) RunOnce(ctx context.Context) error {
+	all, err := v.tenants.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listar tenants: %w", err)
+	}
+	targets := make([]tenant.Tenant, 0, len(all))
+	for _, t := range all {
+		if t.Status == "active" && strings.TrimSpace(t.Domain) != "" {
+			targets = append(targets, t)
+		}
+	}
+
+	started := time.Now()
+	results := v.VerifyAll(ctx, targets)
+	failed := 0
+	for i, result := range results {
+		if !result.Resolved {
+			failed++
+		}
+		if err := v.record(ctx, &targets[i], result); err != nil {
+			v.logger.Warn().Err(err).Str("tenant", targets[i].Slug).Msg("monitor: falha ao registrar verificação de domínio")
+		}
+	}
+	v.logger.Info().
+		Int("domains", len(results)).
+		Int("unresolved", failed).
+		Dur("elapsed", time.Since(started)).
+		Msg("monitor: verificação de domínios concluída")
+	return ctx.Err()
+}
+
+// VerifyAll verifica os domínios com no máximo concurrency verificações simultâneas. O resultado
+// segue a ordem de targets; domínios não verificados por cancelamento trazem o erro do contexto.
+func (v *DomainVerifier) VerifyAll(ctx context.Context, targets []tenant.Tenant) []DomainResult {
+	results := make([]DomainResult, len(targets))
+	sem := make(chan struct{}, v.concurrency)
+	var wg sync.WaitGroup
+
+	for i := range targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(targets); j++ {
+				results[j] = DomainResult{TenantID: targets[j].ID, Domain: targets[j].Domain, Error: ctx.Err().Error()}
+			}
+			wg.Wait()
+			return results
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = v.verify(ctx, targets[i].ID, targets[i].Domain)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func (v *DomainVerifier) verify(ctx context.Context, tenantID uuid.UUID, domain string) DomainResult {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	result := DomainResult{TenantID: tenantID, Domain: domain}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	lookupCtx, cancel := context.WithTimeout(ctx, v.client.Timeout)
+	addresses, err := v.resolver.LookupHost(lookupCtx, domain)
+	cancel()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Addresses = addresses
+	result.Resolved = len(addresses) > 0
+	if !result.Resolved {
+		result.Error = "domínio sem endereços"
+		return result
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, v.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, "https://"+domain+"/ready", nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+	code := resp.StatusCode
+	result.StatusCode = &code
+	result.Reachable = code < 500
+	if !result.Reachable {
+		result.Error = fmt.Sprintf("HTTP %d", code)
+	}
+	return result
+}
+
+// record grava o evento, atualiza o status de DNS do tenant e alerta quando um domínio
+// configurado deixa de resolver. Tenants ainda em provisionamento só têm o evento gravado.
+func (v *DomainVerifier) record(ctx context.Context, t *tenant.Tenant, result DomainResult) error {
+	now := time.Now()
+	ms := int(result.Duration.Milliseconds())
+	event := CheckEvent{
+		TenantID:   t.ID,
+		Source:     SourceDNS,
+		OccurredAt: now,
+		StatusCode: result.StatusCode,
+		ResponseMS: &ms,
+		Success:    result.Resolved && result.Reachable,
+		Metadata: map[string]any{
+			"domain":    result.Domain,
+			"addresses": result.Addresses,
+			"resolved":  result.Resolved,
+			"reachable": result.Reachable,
+		},
+	}
+	if result.Error != "" {
+		msg := result.Error
+		event.Error = &msg
+	}
+	if err := v.repo.InsertCheckEvent(ctx, event); err != nil {
+		return fmt.Errorf("salvar evento: %w", err)
+	}
+
+	switch {
+	case t.DNSStatus == tenant.DNSStatusConfigured && !result.Resolved:
+		msg := result.Error
+		if err := v.tenants.UpdateDNSStatus(ctx, t.ID, tenant.DNSStatusFailed, &now, &msg); err != nil {
+			return fmt.Errorf("atualizar dns: %w", err)
+		}
+		v.alert(ctx, t, "critical", fmt.Sprintf("Domínio %s deixou de resolver: %s. Verifique o registro do domínio.", result.Domain, result.Error))
+	case t.DNSStatus == tenant.DNSStatusFailed && result.Resolved:
+		if err := v.tenants.UpdateDNSStatus(ctx, t.ID, tenant.DNSStatusConfigured, &now, nil); err != nil {
+			return fmt.Errorf("atualizar dns: %w", err)
+		}
+		v.alert(ctx, t, "info", fmt.Sprintf("Domínio %s voltou a resolver.", result.Domain))
+	case t.DNSStatus == tenant.DNSStatusConfigured || t.DNSStatus == tenant.DNSStatusFailed:
+		if err := v.tenants.UpdateDNSStatus(ctx, t.ID, t.DNSStatus, &now, t.DNSError); err != nil {
+			return fmt.Errorf("atualizar dns: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *DomainVerifier) alert(ctx context.Context, t *tenant.Tenant, severity, message string) {
+	alert := Alert{
+		ID:          uuid.New(),
+		TenantID:    &t.ID,
+		AlertType:   alertTypeDNS,
+		Severity:    severity,
+		Message:     message,
+		TriggeredAt: time.Now(),
+	}
+	if err := v.repo.InsertAlert(ctx, alert); err != nil {
+		v.logger.Error().Err(err).Str("tenant", t.Slug).Msg("monitor: falha ao registrar alerta de domínio")
+		return
+	}
+	if v.notifier == nil {
+		return
+	}
+	title := fmt.Sprintf("Tenant %s (%s)", t.DisplayName, t.Slug)
+	if err := v.notifier.Notify(ctx, AlertMessage{Title: title, Text: message, Severity: severity}); err != nil {
+		v.logger.Error().Err(err).Str("tenant", t.Slug).Msg("monitor: falha ao enviar alerta de domínio")
+		return
+	}
+	if err := v.repo.MarkAlertDelivered(ctx, alert.ID, "slack"); err != nil {
+		v.logger.Error().Err(err).Msg("monitor: falha ao marcar alerta entregue")
+	}
+}
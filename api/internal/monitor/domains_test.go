@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/tenant"
+)
+
+type fakeResolver struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	calls    atomic.Int32
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	f.calls.Add(1)
+	current := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if current <= peak || f.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	select {
+	case <-time.After(time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if strings.HasPrefix(host, "expirado") {
+		return nil, errors.New("no such host")
+	}
+	return []string{"203.0.113.10"}, nil
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newTestVerifier(resolver HostResolver, concurrency int) *DomainVerifier {
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			status := http.StatusOK
+			if strings.HasPrefix(r.URL.Host, "fora") {
+				status = http.StatusBadGateway
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+		}),
+	}
+	return &DomainVerifier{resolver: resolver, client: client, concurrency: concurrency, logger: zerolog.Nop()}
+}
+
+// TestVerifyAllSoak verifica muitos domínios e confere o limite de concorrência e a ordem dos
+// resultados, como na execução noturna com centenas de tenants.
+func TestVerifyAllSoak(t *testing.T) {
+	const total = 600
+	const concurrency = 16
+
+	targets := make([]tenant.Tenant, total)
+	for i := range targets {
+		prefix := "ok"
+		switch {
+		case i%50 == 0:
+			prefix = "expirado"
+		case i%75 == 0:
+			prefix = "fora"
+		}
+		targets[i] = tenant.Tenant{ID: uuid.New(), Domain: fmt.Sprintf("%s-%d.example.gov.br", prefix, i)}
+	}
+
+	resolver := &fakeResolver{}
+	v := newTestVerifier(resolver, concurrency)
+	results := v.VerifyAll(context.Background(), targets)
+
+	if len(results) != total || int(resolver.calls.Load()) != total {
+		t.Fatalf("esperado %d verificações, obteve %d resultados e %d consultas", total, len(results), resolver.calls.Load())
+	}
+	if peak := resolver.peak.Load(); peak > concurrency {
+		t.Fatalf("concorrência máxima %d acima do limite %d", peak, concurrency)
+	}
+
+	unresolved, unreachable := 0, 0
+	for i, result := range results {
+		if result.TenantID != targets[i].ID {
+			t.Fatalf("resultado %d fora de ordem", i)
+		}
+		if !result.Resolved {
+			unresolved++
+			if result.Error == "" {
+				t.Fatalf("domínio não resolvido sem erro: %+v", result)
+			}
+			continue
+		}
+		if !result.Reachable {
+			unreachable++
+		}
+	}
+	if unresolved != total/50 {
+		t.Fatalf("esperado %d domínios sem resolução, obteve %d", total/50, unresolved)
+	}
+	if unreachable != 4 {
+		t.Fatalf("esperado 4 domínios inacessíveis, obteve %d", unreachable)
+	}
+}
+
+func TestVerifyAllCancelled(t *testing.T) {
+	targets := make([]tenant.Tenant, 50)
+	for i := range targets {
+		targets[i] = tenant.Tenant{ID: uuid.New(), Domain: fmt.Sprintf("ok-%d.example.gov.br", i)}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := newTestVerifier(&fakeResolver{}, 4).VerifyAll(ctx, targets)
+	if len(results) != len(targets) {
+		t.Fatalf("esperado %d resultados, obteve %d", len(targets), len(results))
+	}
+	for _, result := range results {
+		if result.Resolved {
+			t.Fatalf("nenhum domínio deveria ser verificado após o cancelamento: %+v", result)
+		}
+	}
+}
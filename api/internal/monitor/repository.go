@@ -389,3 +389,70 @@ func toStatusLabel(code int) string {
 	}
 	return "warning"
 }
+
+// DependencyHealth é o último estado conhecido de uma dependência da plataforma.
+type DependencyHealth struct {
+	Name                string     `json:"name"`
+	Status              string     `json:"status"`
+	LatencyMS           *int       `json:"latency_ms"`
+	Error               *string    `json:"error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastCheckedAt       time.Time  `json:"last_checked_at"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+}
+
+// RecordDependency grava o resultado do check e devolve o status anterior ("" na primeira leitura).
+func (r *Repository) RecordDependency(ctx context.Context, health DependencyHealth) (string, error) {
+	const query = `
+        WITH previous AS (
+            SELECT status FROM monitor_dependency_health WHERE name = $1
+        ), upsert AS (
+            INSERT INTO monitor_dependency_health (name, status, latency_ms, error, consecutive_failures, last_checked_at, last_success_at, updated_at)
+            VALUES ($1, $2, $3, $4, CASE WHEN $2 = 'down' THEN 1 ELSE 0 END, $5, CASE WHEN $2 = 'up' THEN $5 END, now())
+            ON CONFLICT (name) DO UPDATE SET
+                status = EXCLUDED.status,
+                latency_ms = EXCLUDED.latency_ms,
+                error = EXCLUDED.error,
+                consecutive_failures = CASE WHEN EXCLUDED.status = 'down' THEN monitor_dependency_health.consecutive_failures + 1 ELSE 0 END,
+                last_checked_at = EXCLUDED.last_checked_at,
+                last_success_at = COALESCE(EXCLUDED.last_success_at, monitor_dependency_health.last_success_at),
+                updated_at = now()
+        )
+        SELECT COALESCE((SELECT status FROM previous), '')
+    `
+
+	var previous string
+	err := r.pool.QueryRow(ctx, query,
+		health.Name,
+		health.Status,
+		health.LatencyMS,
+		health.Error,
+		health.LastCheckedAt,
+	).Scan(&previous)
+	return previous, err
+}
+
+// ListDependencies devolve o estado de todas as dependências verificadas.
+func (r *Repository) ListDependencies(ctx context.Context) ([]DependencyHealth, error) {
+	const query = `
+        SELECT name, status, latency_ms, error, consecutive_failures, last_checked_at, last_success_at
+        FROM monitor_dependency_health
+        ORDER BY name
+    `
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]DependencyHealth, 0)
+	for rows.Next() {
+		var d DependencyHealth
+		if err := rows.Scan(&d.Name, &d.Status, &d.LatencyMS, &d.Error, &d.ConsecutiveFailures, &d.LastCheckedAt, &d.LastSuccessAt); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
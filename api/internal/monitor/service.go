@@ -12,6 +12,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/gestaozabele/municipio/internal/config"
+	"github.com/gestaozabele/municipio/internal/scheduler"
 	"github.com/gestaozabele/municipio/internal/tenant"
 )
 
@@ -23,10 +24,47 @@ type Service struct {
 	client   *http.Client
 	notifier Notifier
 	logger   zerolog.Logger
+	locker   scheduler.Locker
+	traffic  TrafficSource
+	probes   []Probe
 
 	once     sync.Once
 	startErr error
 	cancel   context.CancelFunc
+
+	statsMu sync.Mutex
+	stats   CollectorStats
+}
+
+// CollectorStats resume as coletas feitas por esta instância desde que subiu.
+type CollectorStats struct {
+	Runs          int64
+	Failures      int64
+	TenantChecks  int64
+	TenantErrors  int64
+	LastDuration  time.Duration
+	LastSuccessAt time.Time
+}
+
+// Stats devolve uma cópia das estatísticas do coletor.
+func (s *Service) Stats() CollectorStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return s.stats
+}
+
+func (s *Service) recordRun(start time.Time, checks, failed int, err error) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.stats.Runs++
+	s.stats.TenantChecks += int64(checks)
+	s.stats.TenantErrors += int64(failed)
+	s.stats.LastDuration = time.Since(start)
+	if err != nil {
+		s.stats.Failures++
+		return
+	}
+	s.stats.LastSuccessAt = time.Now()
 }
 
 func NewService(repo *Repository, tenants *tenant.Service, cfg config.MonitoringConfig, logger zerolog.Logger, notifier Notifier) *Service {
@@ -45,6 +83,11 @@ func NewService(repo *Repository, tenants *tenant.Service, cfg config.Monitoring
 	}
 }
 
+// UseLocker coordena o loop com as demais réplicas; sem ele cada instância coleta sozinha.
+func (s *Service) UseLocker(locker scheduler.Locker) {
+	s.locker = locker
+}
+
 // Start inicia loop periódico. Safe para chamar múltiplas vezes.
 func (s *Service) Start(parent context.Context) error {
 	if !s.cfg.Enabled {
@@ -76,7 +119,7 @@ func (s *Service) runLoop(ctx context.Context) {
 
 	s.logger.Info().Dur("interval", interval).Msg("monitor: loop iniciado")
 
-	if err := s.RunOnce(ctx); err != nil {
+	if err := s.runScheduled(ctx, interval); err != nil {
 		s.logger.Error().Err(err).Msg("monitor: primeira execução falhou")
 	}
 
@@ -86,26 +129,51 @@ func (s *Service) runLoop(ctx context.Context) {
 			s.logger.Info().Msg("monitor: loop encerrado")
 			return
 		case <-ticker.C:
-			if err := s.RunOnce(ctx); err != nil {
+			if err := s.runScheduled(ctx, interval); err != nil {
 				s.logger.Error().Err(err).Msg("monitor: execução periódica falhou")
 			}
 		}
 	}
 }
 
+func (s *Service) runScheduled(ctx context.Context, interval time.Duration) error {
+	if s.locker == nil {
+		return s.RunOnce(ctx)
+	}
+	_, err := s.locker.RunExclusive(ctx, "monitor.collect", interval, s.RunOnce)
+	return err
+}
+
 // RunOnce coleta métricas e atualiza snapshots.
 func (s *Service) RunOnce(ctx context.Context) error {
+	start := time.Now()
 	tenants, err := s.tenants.List(ctx)
 	if err != nil {
-		return fmt.Errorf("listar tenants: %w", err)
+		err = fmt.Errorf("listar tenants: %w", err)
+		s.recordRun(start, 0, 0, err)
+		return err
 	}
 
+	var online map[uuid.UUID]int64
+	if s.traffic != nil {
+		if online, err = s.traffic.OnlineByTenant(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("monitor: série de tráfego indisponível")
+		}
+	}
+
+	failed := 0
 	for _, t := range tenants {
 		if err := s.checkTenant(ctx, &t); err != nil {
+			failed++
 			s.logger.Warn().Err(err).Str("tenant", t.Slug).Msg("monitor: check falhou")
 		}
+		if online != nil && t.Status == "active" {
+			s.observeAnomaly(ctx, &t, MetricTraffic, float64(online[t.ID]))
+		}
 	}
 
+	s.checkDependencies(ctx)
+	s.recordRun(start, len(tenants), failed, nil)
 	return nil
 }
 
@@ -196,6 +264,9 @@ func (s *Service) checkTenant(ctx context.Context, t *tenant.Tenant) error {
 	}
 
 	s.evaluateAlerts(ctx, t, health, responseMS, errRate)
+	if success && responseMS != nil {
+		s.observeAnomaly(ctx, t, MetricLatency, float64(*responseMS))
+	}
 
 	return nil
 }
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/mail"
+)
+
+// Status de entrega por canal.
+const (
+	StatusSent       = "sent"
+	StatusOptedOut   = "opted_out"
+	StatusNoProvider = "no_provider"
+	StatusNoContact  = "no_contact"
+	StatusFailed     = "failed"
+	StatusSuppressed = "suppressed"
+)
+
+// Notification é uma mensagem destinada a um usuário; os contatos vêm do chamador.
+type Notification struct {
+	// TenantID é a prefeitura de origem; notificações de tenant sandbox não são entregues.
+	TenantID uuid.UUID
+	Audience string
+	UserID   uuid.UUID
+	Category string
+	Title    string
+	Body     string
+	Email    string
+	Phone    string
+	// DeviceTokens são os tokens de push registrados para o usuário.
+	DeviceTokens []string
+}
+
+// Sender entrega a notificação por um canal.
+type Sender interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// ErrNoContact indica que o usuário não possui contato para o canal.
+var ErrNoContact = errors.New("notificação: usuário sem contato para o canal")
+
+// Dispatcher é o ponto único de envio: consulta as preferências do usuário e só entrega
+// pelos canais e categorias permitidos.
+type Dispatcher struct {
+	repo    *Repository
+	senders map[string]Sender
+	logger  zerolog.Logger
+	sandbox func(ctx context.Context, tenantID uuid.UUID) (bool, error)
+}
+
+// NewDispatcher cria o despachante sem canais; registre-os com Register.
+func NewDispatcher(repo *Repository, logger zerolog.Logger) *Dispatcher {
+	return &Dispatcher{repo: repo, senders: map[string]Sender{}, logger: logger, sandbox: repo.TenantSandbox}
+}
+
+// Register associa o provedor de um canal; nil deixa o canal sem provedor.
+func (d *Dispatcher) Register(channel string, sender Sender) {
+	if sender == nil {
+		delete(d.senders, channel)
+		return
+	}
+	d.senders[channel] = sender
+}
+
+// Preferences devolve as preferências do usuário.
+func (d *Dispatcher) Preferences(ctx context.Context, audience string, userID uuid.UUID) (Preferences, error) {
+	return d.repo.Get(ctx, audience, userID)
+}
+
+// UpdatePreferences aplica alterações parciais e grava as preferências do usuário.
+func (d *Dispatcher) UpdatePreferences(ctx context.Context, audience string, userID uuid.UUID, channels map[string]bool, optOuts []string) (Preferences, error) {
+	prefs, err := d.repo.Get(ctx, audience, userID)
+	if err != nil {
+		return prefs, err
+	}
+	if err := prefs.Apply(channels, optOuts); err != nil {
+		return prefs, err
+	}
+	return d.repo.Save(ctx, prefs)
+}
+
+// Dispatch entrega a notificação nos canais permitidos e devolve o status de cada canal.
+// Falhas de um canal não impedem os demais; só a leitura das preferências gera erro. Notificações
+// de tenant sandbox voltam com todos os canais suppressed, sem chamar os provedores.
+func (d *Dispatcher) Dispatch(ctx context.Context, n Notification) (map[string]string, error) {
+	if n.TenantID != uuid.Nil {
+		sandbox, err := d.sandbox(ctx, n.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		if sandbox {
+			result := make(map[string]string, len(Channels))
+			for _, channel := range Channels {
+				result[channel] = StatusSuppressed
+			}
+			d.logger.Info().Str("tenant_id", n.TenantID.String()).Str("user_id", n.UserID.String()).Str("category", n.Category).Msg("notificação: suprimida em tenant sandbox")
+			return result, nil
+		}
+	}
+
+	prefs, err := d.repo.Get(ctx, n.Audience, n.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(Channels))
+	for _, channel := range Channels {
+		if !prefs.Allows(n.Category, channel) {
+			result[channel] = StatusOptedOut
+			continue
+		}
+		sender, ok := d.senders[channel]
+		if !ok {
+			result[channel] = StatusNoProvider
+			continue
+		}
+		switch err := sender.Send(ctx, n); {
+		case err == nil:
+			result[channel] = StatusSent
+		case errors.Is(err, ErrNoContact):
+			result[channel] = StatusNoContact
+		default:
+			result[channel] = StatusFailed
+			d.logger.Warn().Err(err).Str("channel", channel).Str("user_id", n.UserID.String()).Str("category", n.Category).Msg("notificação: falha no envio")
+		}
+	}
+	return result, nil
+}
+
+// EmailSender entrega notificações pelo SMTP configurado.
+type EmailSender struct {
+	mailer mail.Sender
+}
+
+// NewEmailSender adapta o envio de e-mail; devolve nil sem mailer configurado.
+func NewEmailSender(mailer mail.Sender) Sender {
+	if mailer == nil {
+		return nil
+	}
+	return &EmailSender{mailer: mailer}
+}
+
+// Send envia a notificação por e-mail.
+func (s *EmailSender) Send(ctx context.Context, n Notification) error {
+	to := strings.TrimSpace(n.Email)
+	if to == "" {
+		return ErrNoContact
+	}
+	return s.mailer.Send(ctx, mail.Message{To: []string{to}, Subject: n.Title, Text: n.Body})
+}
@@ -0,0 +1,328 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/mail"
+)
+
+// Canais dos avisos de falta às famílias.
+const (
+	CanalSMS      = "sms"
+	CanalWhatsApp = "whatsapp"
+)
+
+// Status de um aviso de falta; cancelled indica que a falta foi corrigida antes do envio e
+// suppressed, aviso de tenant sandbox registrado sem envio.
+const (
+	FaltaQueued     = "queued"
+	FaltaSending    = "sending"
+	FaltaSent       = "sent"
+	FaltaFailed     = "failed"
+	FaltaCancelled  = "cancelled"
+	FaltaSuppressed = "suppressed"
+)
+
+// faltaMaxTentativas limita as tentativas de um aviso antes de ficar como falha definitiva.
+const faltaMaxTentativas = 4
+
+// ErrFaltasConfig indica configuração de avisos de falta inválida.
+var ErrFaltasConfig = errors.New("configuração de avisos de falta inválida")
+
+// FaltasConfig é a adesão da prefeitura aos avisos de falta, guardada em settings.notificacoes_faltas.
+// AtrasoMinutos segura o envio para que correções da chamada cancelem o aviso.
+type FaltasConfig struct {
+	Ativo         bool   `json:"ativo"`
+	Canal         string `json:"canal"`
+	AtrasoMinutos int    `json:"atraso_minutos"`
+}
+
+// ParseFaltasConfig lê a configuração do tenant; ausente, os avisos ficam desligados.
+func ParseFaltasConfig(raw []byte) (FaltasConfig, error) {
+	cfg := FaltasConfig{Canal: CanalSMS, AtrasoMinutos: 30}
+	if len(raw) == 0 || string(raw) == "null" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Normalize()
+}
+
+// Normalize valida o canal e o atraso, aplicando os padrões.
+func (c *FaltasConfig) Normalize() error {
+	c.Canal = strings.ToLower(strings.TrimSpace(c.Canal))
+	if c.Canal == "" {
+		c.Canal = CanalSMS
+	}
+	if c.Canal != CanalSMS && c.Canal != CanalWhatsApp {
+		return fmt.Errorf("%w: canal deve ser sms ou whatsapp", ErrFaltasConfig)
+	}
+	if c.AtrasoMinutos < 0 || c.AtrasoMinutos > 24*60 {
+		return fmt.Errorf("%w: atraso_minutos entre 0 e 1440", ErrFaltasConfig)
+	}
+	return nil
+}
+
+// EnfileirarFaltas registra, na transação que salvou a chamada, um aviso por responsável com
+// telefone para cada aluno com FALTA na aula, se a prefeitura aderiu. Há no máximo um aviso por
+// aluno, responsável e dia, então várias aulas perdidas no mesmo dia geram uma só mensagem.
+func EnfileirarFaltas(ctx context.Context, tx pgx.Tx, aulaID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+        INSERT INTO falta_notificacoes (tenant_id, aula_id, aluno_id, responsavel_id, data, canal, telefone, next_attempt_at)
+        SELECT r.tenant_id, p.aula_id, m.aluno_id, r.id, (p.aula_inicio AT TIME ZONE 'UTC')::date,
+               COALESCE(NULLIF(t.settings->'notificacoes_faltas'->>'canal', ''), 'sms'), r.telefone,
+               now() + make_interval(mins => COALESCE((t.settings->'notificacoes_faltas'->>'atraso_minutos')::int, 30))
+        FROM presencas p
+        JOIN matriculas m ON m.id = p.matricula_id
+        JOIN responsaveis_alunos ra ON ra.aluno_id = m.aluno_id
+        JOIN responsaveis r ON r.id = ra.responsavel_id AND r.ativo AND COALESCE(r.telefone, '') <> ''
+        JOIN tenants t ON t.id = r.tenant_id
+        WHERE p.aula_id = $1 AND p.status = 'FALTA'
+          AND COALESCE((t.settings->'notificacoes_faltas'->>'ativo')::boolean, false)
+        ON CONFLICT (responsavel_id, aluno_id, data) DO NOTHING
+    `, aulaID)
+	return err
+}
+
+// FaltaEnvio é uma linha do histórico de avisos de falta.
+type FaltaEnvio struct {
+	ID                uuid.UUID  `json:"id"`
+	AlunoID           uuid.UUID  `json:"aluno_id"`
+	Aluno             string     `json:"aluno"`
+	ResponsavelID     uuid.UUID  `json:"responsavel_id"`
+	Responsavel       string     `json:"responsavel"`
+	Data              time.Time  `json:"data"`
+	Canal             string     `json:"canal"`
+	Telefone          string     `json:"telefone"`
+	Status            string     `json:"status"`
+	Tentativas        int        `json:"tentativas"`
+	UltimoErro        *string    `json:"ultimo_erro,omitempty"`
+	Provider          *string    `json:"provider,omitempty"`
+	ProviderMessageID *string    `json:"provider_message_id,omitempty"`
+	EnviadoEm         *time.Time `json:"enviado_em,omitempty"`
+	CriadoEm          time.Time  `json:"criado_em"`
+}
+
+// ListFaltaEnvios devolve os avisos mais recentes da prefeitura, opcionalmente por status.
+func ListFaltaEnvios(ctx context.Context, pool *pgxpool.Pool, tenantID uuid.UUID, status string, limit int) ([]FaltaEnvio, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := pool.Query(ctx, `
+        SELECT f.id, f.aluno_id, al.nome, f.responsavel_id, r.nome, f.data, f.canal, f.telefone, f.status,
+               f.attempts, f.last_error, f.provider, f.provider_message_id, f.sent_at, f.created_at
+        FROM falta_notificacoes f
+        JOIN alunos al ON al.id = f.aluno_id
+        JOIN responsaveis r ON r.id = f.responsavel_id
+        WHERE f.tenant_id = $1 AND ($2 = '' OR f.status = $2)
+        ORDER BY f.created_at DESC
+        LIMIT $3
+    `, tenantID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	envios := make([]FaltaEnvio, 0)
+	for rows.Next() {
+		var e FaltaEnvio
+		if err := rows.Scan(&e.ID, &e.AlunoID, &e.Aluno, &e.ResponsavelID, &e.Responsavel, &e.Data, &e.Canal, &e.Telefone,
+			&e.Status, &e.Tentativas, &e.UltimoErro, &e.Provider, &e.ProviderMessageID, &e.EnviadoEm, &e.CriadoEm); err != nil {
+			return nil, err
+		}
+		envios = append(envios, e)
+	}
+	return envios, rows.Err()
+}
+
+// FaltaWorker entrega os avisos de falta vencidos pelo provedor do canal escolhido pela prefeitura.
+type FaltaWorker struct {
+	pool      *pgxpool.Pool
+	senders   map[string]TextSender
+	providers map[string]string
+	logger    zerolog.Logger
+	batch     int
+}
+
+// NewFaltaWorker cria o worker sem canais; registre os provedores com Register.
+func NewFaltaWorker(pool *pgxpool.Pool, logger zerolog.Logger) *FaltaWorker {
+	return &FaltaWorker{pool: pool, senders: map[string]TextSender{}, providers: map[string]string{}, logger: logger, batch: 50}
+}
+
+// conn devolve o pool do cluster fixado no contexto por db.Resolver.EachCluster ou o principal.
+func (w *FaltaWorker) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, w.pool)
+}
+
+// Register associa o provedor de um canal; provider é gravado em cada entrega.
+func (w *FaltaWorker) Register(canal, provider string, sender TextSender) {
+	if sender == nil {
+		return
+	}
+	w.senders[canal] = sender
+	w.providers[canal] = provider
+}
+
+// Enabled informa se algum canal tem provedor.
+func (w *FaltaWorker) Enabled() bool {
+	return len(w.senders) > 0
+}
+
+// Canais lista os canais com provedor, em ordem fixa.
+func (w *FaltaWorker) Canais() []string {
+	canais := make([]string, 0, 2)
+	for _, canal := range []string{CanalSMS, CanalWhatsApp} {
+		if _, ok := w.senders[canal]; ok {
+			canais = append(canais, canal)
+		}
+	}
+	return canais
+}
+
+type faltaClaimed struct {
+	id         uuid.UUID
+	attempts   int
+	canal      string
+	telefone   string
+	data       time.Time
+	aluno      string
+	escola     string
+	aindaFalta bool
+}
+
+// RunOnce reserva um lote de avisos vencidos dos canais com provedor e tenta entregá-los.
+// Avisos cujo aluno não tem mais falta no dia são cancelados sem envio, e os de tenant sandbox
+// ficam suppressed antes da reserva. Sob db.Resolver.EachCluster só reserva avisos das prefeituras
+// roteadas para o cluster.
+func (w *FaltaWorker) RunOnce(ctx context.Context) error {
+	canais := make([]string, 0, len(w.senders))
+	for canal := range w.senders {
+		canais = append(canais, canal)
+	}
+	// O ambiente do tenant é lido no primary: a cópia de tenants num cluster não acompanha a troca.
+	rows, err := w.pool.Query(ctx, `SELECT id FROM tenants WHERE environment = 'sandbox'`)
+	if err != nil {
+		return err
+	}
+	sandbox, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return err
+	}
+	if _, err := w.conn(ctx).Exec(ctx, `
+        UPDATE falta_notificacoes
+        SET status = 'suppressed', updated_at = now()
+        WHERE tenant_id = ANY($1) AND status IN ('queued', 'sending')`, sandbox); err != nil {
+		return err
+	}
+	rows, err = w.conn(ctx).Query(ctx, `
+        WITH claimed AS (
+            UPDATE falta_notificacoes f
+            SET status = 'sending', attempts = f.attempts + 1, next_attempt_at = now() + interval '10 minutes',
+                updated_at = now()
+            WHERE f.id IN (
+                SELECT id FROM falta_notificacoes
+                WHERE status IN ('queued', 'sending') AND next_attempt_at <= now() AND canal = ANY($2)
+                  AND ($3::uuid[] IS NULL OR tenant_id = ANY($3))
+                ORDER BY next_attempt_at
+                LIMIT $1
+                FOR UPDATE SKIP LOCKED
+            )
+            RETURNING f.id, f.attempts, f.canal, f.telefone, f.data, f.aluno_id, f.aula_id
+        )
+        SELECT c.id, c.attempts, c.canal, c.telefone, c.data, al.nome, COALESCE(e.nome, ''),
+               EXISTS (
+                   SELECT 1 FROM presencas p
+                   JOIN matriculas m ON m.id = p.matricula_id
+                   WHERE m.aluno_id = c.aluno_id AND p.status = 'FALTA'
+                     AND p.aula_inicio >= c.data::timestamp AT TIME ZONE 'UTC'
+                     AND p.aula_inicio < (c.data + 1)::timestamp AT TIME ZONE 'UTC'
+               )
+        FROM claimed c
+        JOIN alunos al ON al.id = c.aluno_id
+        LEFT JOIN aulas a ON a.id = c.aula_id
+        LEFT JOIN turmas t ON t.id = a.turma_id
+        LEFT JOIN escolas e ON e.id = t.escola_id`, w.batch, canais, db.Tenants(ctx))
+	if err != nil {
+		return err
+	}
+	batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (faltaClaimed, error) {
+		var c faltaClaimed
+		err := row.Scan(&c.id, &c.attempts, &c.canal, &c.telefone, &c.data, &c.aluno, &c.escola, &c.aindaFalta)
+		return c, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range batch {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !c.aindaFalta {
+			if _, err := w.conn(ctx).Exec(ctx, `
+                UPDATE falta_notificacoes SET status = 'cancelled', updated_at = now() WHERE id = $1`, c.id); err != nil {
+				return err
+			}
+			continue
+		}
+		providerID, sendErr := w.senders[c.canal].SendText(ctx, c.telefone, MensagemFalta(c.aluno, c.escola, c.data))
+		if err := w.record(ctx, c, providerID, sendErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record grava o resultado: enviado, nova tentativa com espera crescente ou falha definitiva.
+// Telefone inválido não tem nova tentativa.
+func (w *FaltaWorker) record(ctx context.Context, c faltaClaimed, providerID string, sendErr error) error {
+	provider := w.providers[c.canal]
+	if sendErr == nil {
+		_, err := w.conn(ctx).Exec(ctx, `
+            UPDATE falta_notificacoes
+            SET status = 'sent', sent_at = now(), provider = $2, provider_message_id = NULLIF($3, ''), last_error = NULL,
+                updated_at = now()
+            WHERE id = $1`, c.id, provider, providerID)
+		return err
+	}
+
+	status := FaltaQueued
+	if c.attempts >= faltaMaxTentativas || errors.Is(sendErr, ErrNoContact) {
+		status = FaltaFailed
+	}
+	w.logger.Warn().Err(sendErr).Str("aviso_id", c.id.String()).Int("attempts", c.attempts).Str("status", status).Msg("faltas: falha no envio")
+	_, err := w.conn(ctx).Exec(ctx, `
+        UPDATE falta_notificacoes
+        SET status = $2, last_error = $3, provider = $4, next_attempt_at = now() + make_interval(secs => $5), updated_at = now()
+        WHERE id = $1`, c.id, status, sendErr.Error(), provider, mail.Backoff(c.attempts).Seconds())
+	return err
+}
+
+// MensagemFalta monta o texto do aviso, curto o bastante para um único SMS na maioria dos casos.
+func MensagemFalta(aluno, escola string, data time.Time) string {
+	texto := fmt.Sprintf("%s foi registrado(a) como ausente em %s", primeiroNome(aluno), data.Format("02/01"))
+	if escola != "" {
+		texto += " na " + escola
+	}
+	return texto + ". Em caso de dúvida, procure a secretaria da escola."
+}
+
+func primeiroNome(nome string) string {
+	campos := strings.Fields(nome)
+	if len(campos) == 0 {
+		return "O(a) aluno(a)"
+	}
+	return campos[0]
+}
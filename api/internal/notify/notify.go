@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Canais de entrega suportados.
+const (
+	ChannelEmail    = "email"
+	ChannelPush     = "push"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Categorias de notificação que o usuário pode recusar.
+const (
+	CategoryAvisos     = "avisos"
+	CategoryEducacao   = "educacao"
+	CategoryProtocolos = "protocolos"
+	CategoryFinanceiro = "financeiro"
+	CategoryMarketing  = "marketing"
+	// CategorySeguranca cobre acesso e senha; não pode ser recusada.
+	CategorySeguranca = "seguranca"
+)
+
+// Channels lista os canais na ordem de entrega.
+var Channels = []string{ChannelEmail, ChannelPush, ChannelWhatsApp}
+
+// Categories lista as categorias conhecidas.
+var Categories = []string{CategoryAvisos, CategoryEducacao, CategoryProtocolos, CategoryFinanceiro, CategoryMarketing, CategorySeguranca}
+
+// defaultChannels vale para quem nunca salvou preferências: WhatsApp exige adesão explícita.
+var defaultChannels = map[string]bool{ChannelEmail: true, ChannelPush: true, ChannelWhatsApp: false}
+
+var (
+	// ErrInvalidChannel indica canal desconhecido.
+	ErrInvalidChannel = errors.New("canal de notificação inválido")
+	// ErrInvalidCategory indica categoria desconhecida ou que não pode ser recusada.
+	ErrInvalidCategory = errors.New("categoria de notificação inválida")
+)
+
+// Preferences guarda os canais habilitados e as categorias recusadas de um usuário.
+type Preferences struct {
+	Audience  string          `json:"audience"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Channels  map[string]bool `json:"channels"`
+	OptOuts   []string        `json:"opt_outs"`
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+}
+
+// Defaults devolve as preferências de quem ainda não configurou nada.
+func Defaults(audience string, userID uuid.UUID) Preferences {
+	channels := make(map[string]bool, len(defaultChannels))
+	for channel, enabled := range defaultChannels {
+		channels[channel] = enabled
+	}
+	return Preferences{Audience: audience, UserID: userID, Channels: channels, OptOuts: []string{}}
+}
+
+// Allows informa se a categoria pode ser entregue pelo canal.
+func (p Preferences) Allows(category, channel string) bool {
+	if category == CategorySeguranca {
+		// Avisos de segurança ignoram recusas, mas só saem por e-mail se nenhum canal estiver ativo.
+		return p.Channels[channel] || (channel == ChannelEmail && !p.anyChannel())
+	}
+	if !p.Channels[channel] {
+		return false
+	}
+	for _, optOut := range p.OptOuts {
+		if optOut == category {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Preferences) anyChannel() bool {
+	for _, enabled := range p.Channels {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply valida e incorpora alterações parciais às preferências.
+func (p *Preferences) Apply(channels map[string]bool, optOuts []string) error {
+	for channel, enabled := range channels {
+		if _, ok := defaultChannels[channel]; !ok {
+			return ErrInvalidChannel
+		}
+		p.Channels[channel] = enabled
+	}
+	if optOuts == nil {
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	cleaned := make([]string, 0, len(optOuts))
+	for _, category := range optOuts {
+		if !isCategory(category) || category == CategorySeguranca {
+			return ErrInvalidCategory
+		}
+		if _, dup := seen[category]; dup {
+			continue
+		}
+		seen[category] = struct{}{}
+		cleaned = append(cleaned, category)
+	}
+	sort.Strings(cleaned)
+	p.OptOuts = cleaned
+	return nil
+}
+
+func isCategory(category string) bool {
+	for _, known := range Categories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
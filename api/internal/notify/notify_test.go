@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestPreferencesAllows(t *testing.T) {
+	prefs := Defaults("cidadao", uuid.New())
+	if !prefs.Allows(CategoryAvisos, ChannelEmail) || prefs.Allows(CategoryAvisos, ChannelWhatsApp) {
+		t.Fatalf("padrões inesperados: %+v", prefs.Channels)
+	}
+
+	if err := prefs.Apply(map[string]bool{ChannelWhatsApp: true}, []string{CategoryMarketing, CategoryMarketing}); err != nil {
+		t.Fatal(err)
+	}
+	if !prefs.Allows(CategoryAvisos, ChannelWhatsApp) {
+		t.Fatal("whatsapp deveria estar habilitado")
+	}
+	if prefs.Allows(CategoryMarketing, ChannelEmail) {
+		t.Fatal("categoria recusada não deveria ser entregue")
+	}
+	if !reflect.DeepEqual(prefs.OptOuts, []string{CategoryMarketing}) {
+		t.Fatalf("opt-outs = %v", prefs.OptOuts)
+	}
+
+	prefs.Apply(map[string]bool{ChannelEmail: false, ChannelPush: false, ChannelWhatsApp: false}, nil)
+	if prefs.Allows(CategoryAvisos, ChannelEmail) {
+		t.Fatal("canal desligado não deveria entregar")
+	}
+	if !prefs.Allows(CategorySeguranca, ChannelEmail) {
+		t.Fatal("avisos de segurança precisam de ao menos o e-mail")
+	}
+}
+
+func TestPreferencesApplyRejects(t *testing.T) {
+	prefs := Defaults("backoffice", uuid.New())
+	if err := prefs.Apply(map[string]bool{"sms": true}, nil); !errors.Is(err, ErrInvalidChannel) {
+		t.Fatalf("canal desconhecido: %v", err)
+	}
+	if err := prefs.Apply(nil, []string{CategorySeguranca}); !errors.Is(err, ErrInvalidCategory) {
+		t.Fatalf("segurança não pode ser recusada: %v", err)
+	}
+}
+
+type senderContador struct{ envios int }
+
+func (s *senderContador) Send(context.Context, Notification) error {
+	s.envios++
+	return nil
+}
+
+func TestDispatchSuprimeTenantSandbox(t *testing.T) {
+	sandbox := uuid.New()
+	sender := &senderContador{}
+	d := &Dispatcher{
+		senders: map[string]Sender{ChannelEmail: sender},
+		logger:  zerolog.Nop(),
+		sandbox: func(_ context.Context, tenantID uuid.UUID) (bool, error) { return tenantID == sandbox, nil },
+	}
+
+	result, err := d.Dispatch(context.Background(), Notification{
+		TenantID: sandbox,
+		Audience: "cidadao",
+		UserID:   uuid.New(),
+		Category: CategoryAvisos,
+		Title:    "Lembrete",
+		Email:    "familia@example.com",
+	})
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if sender.envios != 0 {
+		t.Fatalf("envios = %d, want 0", sender.envios)
+	}
+	for _, channel := range Channels {
+		if result[channel] != StatusSuppressed {
+			t.Fatalf("canal %s = %q, want %q", channel, result[channel], StatusSuppressed)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persiste preferências de notificação.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// TenantSandbox informa se o tenant está em sandbox, ambiente em que nada é entregue de fato.
+func (r *Repository) TenantSandbox(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var sandbox bool
+	err := r.pool.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM tenants WHERE id = $1 AND environment = 'sandbox')
+    `, tenantID).Scan(&sandbox)
+	return sandbox, err
+}
+
+// Get devolve as preferências do usuário ou os padrões quando nunca foram salvas.
+func (r *Repository) Get(ctx context.Context, audience string, userID uuid.UUID) (Preferences, error) {
+	prefs := Defaults(audience, userID)
+	var raw []byte
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx, `
+        SELECT channels, opt_outs, updated_at FROM notification_preferences
+        WHERE audience = $1 AND user_id = $2
+    `, audience, userID).Scan(&raw, &prefs.OptOuts, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+
+	// Canais ausentes do JSON mantêm o padrão, o que cobre canais criados depois.
+	var stored map[string]bool
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return prefs, err
+	}
+	for channel, enabled := range stored {
+		prefs.Channels[channel] = enabled
+	}
+	prefs.UpdatedAt = &updatedAt
+	return prefs, nil
+}
+
+// Save grava as preferências do usuário.
+func (r *Repository) Save(ctx context.Context, prefs Preferences) (Preferences, error) {
+	channels, err := json.Marshal(prefs.Channels)
+	if err != nil {
+		return prefs, err
+	}
+	var updatedAt time.Time
+	err = r.pool.QueryRow(ctx, `
+        INSERT INTO notification_preferences (audience, user_id, channels, opt_outs, updated_at)
+        VALUES ($1, $2, $3, $4, now())
+        ON CONFLICT (audience, user_id) DO UPDATE
+        SET channels = EXCLUDED.channels, opt_outs = EXCLUDED.opt_outs, updated_at = now()
+        RETURNING updated_at
+    `, prefs.Audience, prefs.UserID, channels, prefs.OptOuts).Scan(&updatedAt)
+	if err != nil {
+		return prefs, err
+	}
+	prefs.UpdatedAt = &updatedAt
+	return prefs, nil
+}
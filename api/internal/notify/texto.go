@@ -0,0 +1,233 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TextSender entrega mensagens curtas de texto para um telefone e devolve o identificador da
+// mensagem no provedor.
+type TextSender interface {
+	SendText(ctx context.Context, to, body string) (string, error)
+}
+
+// NormalizePhoneBR converte um telefone brasileiro para E.164 (+55DDDNÚMERO). Números com DDI
+// diferente de 55 são aceitos quando vêm com "+". Devolve vazio se o número não for válido.
+func NormalizePhoneBR(raw string) string {
+	raw = strings.TrimSpace(raw)
+	internacional := strings.HasPrefix(raw, "+")
+	var digits strings.Builder
+	for _, r := range raw {
+		if unicode.IsDigit(r) {
+			digits.WriteRune(r)
+		}
+	}
+	d := strings.TrimLeft(digits.String(), "0")
+	switch {
+	case internacional && len(d) >= 10 && len(d) <= 15:
+		return "+" + d
+	case (len(d) == 12 || len(d) == 13) && strings.HasPrefix(d, "55"):
+		return "+" + d
+	case len(d) == 10 || len(d) == 11:
+		return "+55" + d
+	default:
+		return ""
+	}
+}
+
+// TwilioSender envia SMS ou WhatsApp pela API de mensagens da Twilio.
+type TwilioSender struct {
+	accountSID string
+	authToken  string
+	from       string
+	whatsapp   bool
+	apiBase    string
+	client     *http.Client
+}
+
+// NewTwilioSender cria o envio pela Twilio; com whatsapp verdadeiro, origem e destino recebem o
+// prefixo "whatsapp:". Devolve nil sem credenciais ou número de origem.
+func NewTwilioSender(accountSID, authToken, from string, whatsapp bool) TextSender {
+	if accountSID == "" || authToken == "" || from == "" {
+		return nil
+	}
+	return &TwilioSender{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		whatsapp:   whatsapp,
+		apiBase:    "https://api.twilio.com/2010-04-01",
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// SendText envia a mensagem e devolve o SID gerado pela Twilio.
+func (s *TwilioSender) SendText(ctx context.Context, to, body string) (string, error) {
+	to = NormalizePhoneBR(to)
+	if to == "" {
+		return "", ErrNoContact
+	}
+	from := s.from
+	if s.whatsapp {
+		to = "whatsapp:" + to
+		if !strings.HasPrefix(from, "whatsapp:") {
+			from = "whatsapp:" + from
+		}
+	}
+
+	form := url.Values{"To": {to}, "From": {from}, "Body": {body}}
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.apiBase, url.PathEscape(s.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		SID     string `json:"sid"`
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(raw, &out)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("twilio: status %d: %d %s", resp.StatusCode, out.Code, out.Message)
+	}
+	return out.SID, nil
+}
+
+// WhatsAppCloudSender envia pela WhatsApp Business Cloud API da Meta. Mensagens iniciadas pela
+// empresa fora da janela de 24h exigem modelo aprovado; com template definido o texto vai como
+// parâmetro {{1}} do corpo.
+type WhatsAppCloudSender struct {
+	token         string
+	phoneNumberID string
+	apiBase       string
+	template      string
+	templateLang  string
+	client        *http.Client
+}
+
+// NewWhatsAppCloudSender cria o envio pela Meta; devolve nil sem token ou número de origem.
+func NewWhatsAppCloudSender(apiBase, token, phoneNumberID, template, templateLang string) TextSender {
+	if token == "" || phoneNumberID == "" {
+		return nil
+	}
+	if apiBase == "" {
+		apiBase = "https://graph.facebook.com/v19.0"
+	}
+	if templateLang == "" {
+		templateLang = "pt_BR"
+	}
+	return &WhatsAppCloudSender{
+		token:         token,
+		phoneNumberID: phoneNumberID,
+		apiBase:       strings.TrimRight(apiBase, "/"),
+		template:      template,
+		templateLang:  templateLang,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// SendText envia a mensagem e devolve o wamid gerado pela Meta.
+func (s *WhatsAppCloudSender) SendText(ctx context.Context, to, body string) (string, error) {
+	to = strings.TrimPrefix(NormalizePhoneBR(to), "+")
+	if to == "" {
+		return "", ErrNoContact
+	}
+
+	payload := map[string]any{"messaging_product": "whatsapp", "to": to}
+	if s.template != "" {
+		payload["type"] = "template"
+		payload["template"] = map[string]any{
+			"name":     s.template,
+			"language": map[string]string{"code": s.templateLang},
+			"components": []map[string]any{{
+				"type":       "body",
+				"parameters": []map[string]string{{"type": "text", "text": body}},
+			}},
+		}
+	} else {
+		payload["type"] = "text"
+		payload["text"] = map[string]any{"body": body, "preview_url": false}
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/messages", s.apiBase, url.PathEscape(s.phoneNumberID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(encoded))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+		Error struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+		} `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(raw, &out)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("whatsapp: status %d: %d %s", resp.StatusCode, out.Error.Code, out.Error.Message)
+	}
+	if len(out.Messages) == 0 {
+		return "", errors.New("whatsapp: resposta sem id da mensagem")
+	}
+	return out.Messages[0].ID, nil
+}
+
+// textChannel adapta um TextSender ao despachante, usando o telefone da notificação.
+type textChannel struct {
+	sender TextSender
+}
+
+// NewTextChannel expõe um TextSender como canal do Dispatcher; devolve nil sem provedor.
+func NewTextChannel(sender TextSender) Sender {
+	if sender == nil {
+		return nil
+	}
+	return &textChannel{sender: sender}
+}
+
+// Send envia título e corpo numa única mensagem.
+func (c *textChannel) Send(ctx context.Context, n Notification) error {
+	if strings.TrimSpace(n.Phone) == "" {
+		return ErrNoContact
+	}
+	body := n.Body
+	if n.Title != "" {
+		body = n.Title + "\n" + n.Body
+	}
+	_, err := c.sender.SendText(ctx, n.Phone, body)
+	return err
+}
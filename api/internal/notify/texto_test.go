@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizePhoneBR(t *testing.T) {
+	cases := map[string]string{
+		"(83) 99876-5432":   "+5583998765432",
+		"083 3333-4444":     "+558333334444",
+		"+55 83 99876-5432": "+5583998765432",
+		"5583998765432":     "+5583998765432",
+		"+1 415 555 0100":   "+14155550100",
+		"99876-5432":        "",
+		"":                  "",
+	}
+	for in, want := range cases {
+		if got := NormalizePhoneBR(in); got != want {
+			t.Errorf("NormalizePhoneBR(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTwilioSenderWhatsApp(t *testing.T) {
+	var form map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "AC1" || pass != "tok" {
+			t.Errorf("basic auth inesperado: %s/%s", user, pass)
+		}
+		if !strings.HasSuffix(r.URL.Path, "/Accounts/AC1/Messages.json") {
+			t.Errorf("caminho inesperado: %s", r.URL.Path)
+		}
+		_ = r.ParseForm()
+		form = map[string]string{"To": r.Form.Get("To"), "From": r.Form.Get("From"), "Body": r.Form.Get("Body")}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sid":"SM123"}`))
+	}))
+	defer srv.Close()
+
+	sender := NewTwilioSender("AC1", "tok", "+5511900000000", true).(*TwilioSender)
+	sender.apiBase = srv.URL
+	id, err := sender.SendText(context.Background(), "(83) 99876-5432", "oi")
+	if err != nil || id != "SM123" {
+		t.Fatalf("SendText = %q, %v", id, err)
+	}
+	if form["To"] != "whatsapp:+5583998765432" || form["From"] != "whatsapp:+5511900000000" || form["Body"] != "oi" {
+		t.Fatalf("formulário inesperado: %v", form)
+	}
+
+	if _, err := sender.SendText(context.Background(), "123", "oi"); err != ErrNoContact {
+		t.Fatalf("telefone inválido deveria ser ErrNoContact: %v", err)
+	}
+}
+
+func TestWhatsAppCloudSenderTemplate(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" || r.URL.Path != "/123/messages" {
+			t.Errorf("requisição inesperada: %s %s", r.Header.Get("Authorization"), r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte(`{"messages":[{"id":"wamid.X"}]}`))
+	}))
+	defer srv.Close()
+
+	sender := NewWhatsAppCloudSender(srv.URL, "tok", "123", "aviso_falta", "")
+	id, err := sender.SendText(context.Background(), "83998765432", "Ana faltou")
+	if err != nil || id != "wamid.X" {
+		t.Fatalf("SendText = %q, %v", id, err)
+	}
+	if payload["to"] != "5583998765432" || payload["type"] != "template" {
+		t.Fatalf("payload inesperado: %v", payload)
+	}
+}
+
+func TestFaltasConfigEMensagem(t *testing.T) {
+	cfg, err := ParseFaltasConfig(nil)
+	if err != nil || cfg.Ativo || cfg.Canal != CanalSMS || cfg.AtrasoMinutos != 30 {
+		t.Fatalf("padrão inesperado: %+v %v", cfg, err)
+	}
+	if _, err := ParseFaltasConfig([]byte(`{"ativo":true,"canal":"telegram"}`)); err == nil {
+		t.Fatal("canal desconhecido deveria falhar")
+	}
+
+	got := MensagemFalta("Ana Maria Souza", "EMEF Zabelê", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC))
+	want := "Ana foi registrado(a) como ausente em 09/03 na EMEF Zabelê. Em caso de dúvida, procure a secretaria da escola."
+	if got != want {
+		t.Fatalf("mensagem = %q", got)
+	}
+}
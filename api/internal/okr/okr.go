@@ -0,0 +1,155 @@
+// Package okr concentra as regras de períodos trimestrais e de consolidação de progresso dos
+// objetivos e resultados-chave ligados aos projetos estratégicos.
+package okr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SourceManual  = "manual"
+	SourceProject = "project"
+
+	HealthOnTrack  = "on_track"
+	HealthAtRisk   = "at_risk"
+	HealthOffTrack = "off_track"
+	HealthDone     = "done"
+)
+
+// ErrInvalidPeriod indica período fora do formato AAAA-Qn.
+var ErrInvalidPeriod = errors.New("período deve seguir o formato AAAA-Qn")
+
+// Period é um trimestre civil, ex.: 2026-Q3.
+type Period struct {
+	Year    int
+	Quarter int
+}
+
+// ParsePeriod interpreta textos como "2026-Q3" ou "2026q3".
+func ParsePeriod(value string) (Period, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	parts := strings.SplitN(value, "Q", 2)
+	if len(parts) != 2 {
+		return Period{}, ErrInvalidPeriod
+	}
+	year, err := strconv.Atoi(strings.TrimSuffix(parts[0], "-"))
+	if err != nil || year < 2000 || year > 2100 {
+		return Period{}, ErrInvalidPeriod
+	}
+	quarter, err := strconv.Atoi(parts[1])
+	if err != nil || quarter < 1 || quarter > 4 {
+		return Period{}, ErrInvalidPeriod
+	}
+	return Period{Year: year, Quarter: quarter}, nil
+}
+
+// PeriodOf devolve o trimestre que contém a data.
+func PeriodOf(t time.Time) Period {
+	return Period{Year: t.Year(), Quarter: (int(t.Month())-1)/3 + 1}
+}
+
+// String formata o período como AAAA-Qn.
+func (p Period) String() string {
+	return fmt.Sprintf("%d-Q%d", p.Year, p.Quarter)
+}
+
+// Start é o primeiro dia do trimestre.
+func (p Period) Start() time.Time {
+	return time.Date(p.Year, time.Month((p.Quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End é o último dia do trimestre.
+func (p Period) End() time.Time {
+	return p.Start().AddDate(0, 3, -1)
+}
+
+// Elapsed devolve a fração (0–1) do trimestre já decorrida em now.
+func (p Period) Elapsed(now time.Time) float64 {
+	start := p.Start()
+	end := p.End().AddDate(0, 0, 1)
+	if !now.After(start) {
+		return 0
+	}
+	if !now.Before(end) {
+		return 1
+	}
+	return now.Sub(start).Seconds() / end.Sub(start).Seconds()
+}
+
+// KeyResult reúne os dados necessários para calcular o progresso de um resultado-chave.
+// Resultados do tipo project usam as tarefas concluídas do projeto vinculado.
+type KeyResult struct {
+	Source       string
+	StartValue   float64
+	TargetValue  float64
+	CurrentValue float64
+	Weight       float64
+	TasksTotal   int
+	TasksDone    int
+}
+
+// Progress calcula o percentual (0–100) do resultado-chave.
+func (kr KeyResult) Progress() float64 {
+	if kr.Source == SourceProject {
+		if kr.TasksTotal == 0 {
+			return 0
+		}
+		return round(float64(kr.TasksDone) / float64(kr.TasksTotal) * 100)
+	}
+	span := kr.TargetValue - kr.StartValue
+	if span == 0 {
+		if kr.CurrentValue >= kr.TargetValue {
+			return 100
+		}
+		return 0
+	}
+	return round(clamp((kr.CurrentValue-kr.StartValue)/span*100, 0, 100))
+}
+
+// Rollup consolida o progresso do objetivo como média ponderada dos resultados-chave.
+// Pesos não positivos contam como 1.
+func Rollup(krs []KeyResult) float64 {
+	total := 0.0
+	weights := 0.0
+	for _, kr := range krs {
+		weight := kr.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += kr.Progress() * weight
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return round(total / weights)
+}
+
+// Health compara o progresso com o tempo decorrido do trimestre.
+func Health(progress, elapsed float64) string {
+	if progress >= 100 {
+		return HealthDone
+	}
+	expected := elapsed * 100
+	switch {
+	case progress >= expected-10:
+		return HealthOnTrack
+	case progress >= expected-25:
+		return HealthAtRisk
+	default:
+		return HealthOffTrack
+	}
+}
+
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
+
+func round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,43 @@
+package okr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	p, err := ParsePeriod("2026-q3")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if p.String() != "2026-Q3" {
+		t.Fatalf("período inesperado: %s", p)
+	}
+	if got := p.End().Format("2006-01-02"); got != "2026-09-30" {
+		t.Fatalf("fim do trimestre inesperado: %s", got)
+	}
+	if _, err := ParsePeriod("2026-Q5"); err == nil {
+		t.Fatal("esperava erro para trimestre inválido")
+	}
+}
+
+func TestRollup(t *testing.T) {
+	krs := []KeyResult{
+		{Source: SourceManual, StartValue: 0, TargetValue: 200, CurrentValue: 100, Weight: 1},
+		{Source: SourceProject, TasksTotal: 4, TasksDone: 4, Weight: 3},
+	}
+	if got := Rollup(krs); got != 87.5 {
+		t.Fatalf("esperava 87.5, obtido %v", got)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	p := Period{Year: 2026, Quarter: 1}
+	elapsed := p.Elapsed(time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC))
+	if got := Health(10, elapsed); got != HealthOffTrack {
+		t.Fatalf("esperava off_track, obtido %s", got)
+	}
+	if got := Health(50, elapsed); got != HealthOnTrack {
+		t.Fatalf("esperava on_track, obtido %s", got)
+	}
+}
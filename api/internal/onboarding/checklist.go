@@ -0,0 +1,100 @@
+// Package onboarding monta o checklist guiado de implantação para novos administradores de
+// prefeitura, detectando a conclusão de cada tarefa a partir dos dados do tenant.
+package onboarding
+
+import (
+	"errors"
+	"time"
+)
+
+// Códigos das tarefas do checklist.
+const (
+	TaskBranding     = "branding"
+	TaskSecretarias  = "secretarias"
+	TaskInviteStaff  = "invite_staff"
+	TaskAnoLetivo    = "ano_letivo"
+	TaskAnnouncement = "first_announcement"
+)
+
+// ErrUnknownTask indica código de tarefa inexistente.
+var ErrUnknownTask = errors.New("onboarding: tarefa desconhecida")
+
+// Definition descreve uma tarefa do checklist. Tarefas opcionais podem ser puladas.
+type Definition struct {
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Optional    bool   `json:"optional"`
+}
+
+// Definitions lista as tarefas na ordem em que a interface as apresenta.
+var Definitions = []Definition{
+	{Code: TaskBranding, Title: "Configurar identidade visual", Description: "Envie o logotipo e defina as cores do app da prefeitura."},
+	{Code: TaskSecretarias, Title: "Cadastrar secretarias", Description: "Cadastre ao menos uma secretaria para organizar a equipe."},
+	{Code: TaskInviteStaff, Title: "Convidar a equipe", Description: "Cadastre outros usuários do backoffice além do administrador."},
+	{Code: TaskAnoLetivo, Title: "Definir o ano letivo", Description: "Ative o ano letivo vigente da rede municipal.", Optional: true},
+	{Code: TaskAnnouncement, Title: "Publicar o primeiro comunicado", Description: "Envie o primeiro comunicado aos cidadãos pelo app."},
+}
+
+// Find devolve a definição da tarefa pelo código.
+func Find(code string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Code == code {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Task é o estado de uma tarefa para o tenant.
+type Task struct {
+	Definition
+	Done    bool `json:"done"`
+	Skipped bool `json:"skipped"`
+}
+
+// Checklist é o resultado exibido ao administrador.
+type Checklist struct {
+	Tasks       []Task     `json:"tasks"`
+	Completed   int        `json:"completed"`
+	Total       int        `json:"total"`
+	Percent     float64    `json:"percent"`
+	Done        bool       `json:"done"`
+	Show        bool       `json:"show"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// State guarda as escolhas do administrador persistidas para o tenant.
+type State struct {
+	Skipped     []string
+	DismissedAt *time.Time
+	CompletedAt *time.Time
+}
+
+// Build combina a detecção (done por código) com o estado salvo. Tarefas puladas contam como
+// resolvidas; o checklist deixa de aparecer quando tudo está resolvido ou foi dispensado.
+func Build(done map[string]bool, state State) Checklist {
+	skipped := make(map[string]bool, len(state.Skipped))
+	for _, code := range state.Skipped {
+		skipped[code] = true
+	}
+
+	list := Checklist{Tasks: make([]Task, 0, len(Definitions)), Total: len(Definitions)}
+	for _, def := range Definitions {
+		task := Task{Definition: def, Done: done[def.Code]}
+		task.Skipped = !task.Done && def.Optional && skipped[def.Code]
+		if task.Done || task.Skipped {
+			list.Completed++
+		}
+		list.Tasks = append(list.Tasks, task)
+	}
+	if list.Total > 0 {
+		list.Percent = float64(list.Completed) / float64(list.Total) * 100
+	}
+	list.Done = list.Completed == list.Total
+	list.Show = !list.Done && state.DismissedAt == nil
+	list.DismissedAt = state.DismissedAt
+	list.CompletedAt = state.CompletedAt
+	return list
+}
@@ -0,0 +1,28 @@
+package onboarding
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildSkipsOnlyOptionalTasks(t *testing.T) {
+	done := map[string]bool{TaskBranding: true, TaskSecretarias: true, TaskInviteStaff: true}
+	list := Build(done, State{Skipped: []string{TaskAnoLetivo, TaskAnnouncement}})
+	if list.Completed != 4 || list.Done || !list.Show {
+		t.Fatalf("checklist inesperado: %+v", list)
+	}
+
+	done[TaskAnnouncement] = true
+	list = Build(done, State{Skipped: []string{TaskAnoLetivo}})
+	if !list.Done || list.Show || list.Percent != 100 {
+		t.Fatalf("esperava checklist concluído: %+v", list)
+	}
+}
+
+func TestBuildHiddenWhenDismissed(t *testing.T) {
+	now := time.Now()
+	list := Build(map[string]bool{}, State{DismissedAt: &now})
+	if list.Show || list.Completed != 0 {
+		t.Fatalf("checklist dispensado não deveria aparecer: %+v", list)
+	}
+}
@@ -0,0 +1,130 @@
+package onboarding
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository detecta o progresso e persiste o estado do checklist.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria instância do repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Load monta o checklist do tenant e registra completed_at na primeira vez que tudo é resolvido.
+func (r *Repository) Load(ctx context.Context, tenantID uuid.UUID) (Checklist, error) {
+	done, err := r.detect(ctx, tenantID)
+	if err != nil {
+		return Checklist{}, err
+	}
+	state, err := r.state(ctx, tenantID)
+	if err != nil {
+		return Checklist{}, err
+	}
+
+	list := Build(done, state)
+	if list.Done && state.CompletedAt == nil {
+		if err := r.pool.QueryRow(ctx, `
+            INSERT INTO tenant_onboarding (tenant_id, completed_at)
+            VALUES ($1, now())
+            ON CONFLICT (tenant_id) DO UPDATE SET completed_at = COALESCE(tenant_onboarding.completed_at, now()), updated_at = now()
+            RETURNING completed_at
+        `, tenantID).Scan(&list.CompletedAt); err != nil {
+			return Checklist{}, err
+		}
+	}
+	return list, nil
+}
+
+// Skip marca (ou desmarca) uma tarefa opcional como pulada.
+func (r *Repository) Skip(ctx context.Context, tenantID uuid.UUID, code string, skip bool) error {
+	def, ok := Find(code)
+	if !ok || !def.Optional {
+		return ErrUnknownTask
+	}
+	query := `
+        INSERT INTO tenant_onboarding (tenant_id, skipped_tasks)
+        VALUES ($1, ARRAY[$2::text])
+        ON CONFLICT (tenant_id) DO UPDATE
+        SET skipped_tasks = array_append(array_remove(tenant_onboarding.skipped_tasks, $2::text), $2::text), updated_at = now()
+    `
+	if !skip {
+		query = `
+            INSERT INTO tenant_onboarding (tenant_id)
+            VALUES ($1)
+            ON CONFLICT (tenant_id) DO UPDATE
+            SET skipped_tasks = array_remove(tenant_onboarding.skipped_tasks, $2::text), updated_at = now()
+        `
+	}
+	_, err := r.pool.Exec(ctx, query, tenantID, code)
+	return err
+}
+
+// Dismiss oculta o checklist para o tenant; reopen volta a exibi-lo.
+func (r *Repository) Dismiss(ctx context.Context, tenantID, userID uuid.UUID, dismiss bool) error {
+	if !dismiss {
+		_, err := r.pool.Exec(ctx, `
+            UPDATE tenant_onboarding SET dismissed_at = NULL, dismissed_by = NULL, updated_at = now()
+            WHERE tenant_id = $1
+        `, tenantID)
+		return err
+	}
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO tenant_onboarding (tenant_id, dismissed_at, dismissed_by)
+        VALUES ($1, now(), $2)
+        ON CONFLICT (tenant_id) DO UPDATE SET dismissed_at = now(), dismissed_by = $2, updated_at = now()
+    `, tenantID, userID)
+	return err
+}
+
+func (r *Repository) state(ctx context.Context, tenantID uuid.UUID) (State, error) {
+	var state State
+	err := r.pool.QueryRow(ctx, `
+        SELECT skipped_tasks, dismissed_at, completed_at FROM tenant_onboarding WHERE tenant_id = $1
+    `, tenantID).Scan(&state.Skipped, &state.DismissedAt, &state.CompletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return State{}, nil
+	}
+	return state, err
+}
+
+// detect verifica nos dados reais do tenant quais tarefas já foram feitas.
+func (r *Repository) detect(ctx context.Context, tenantID uuid.UUID) (map[string]bool, error) {
+	const query = `
+        SELECT
+            (t.logo_url IS NOT NULL
+             OR EXISTS (SELECT 1 FROM saas_app_customizations c
+                        WHERE c.tenant_id = t.id
+                          AND (c.logo_url IS NOT NULL OR c.primary_color <> '#06AA48' OR c.secondary_color <> '#0F172A'))),
+            EXISTS (SELECT 1 FROM secretarias s WHERE s.tenant_id = t.id),
+            (SELECT count(DISTINCT us.usuario_id)
+             FROM usuarios_secretarias us
+             JOIN secretarias s ON s.id = us.secretaria_id
+             WHERE s.tenant_id = t.id
+               AND us.usuario_id NOT IN (SELECT a.usuario_id FROM tenant_admins a WHERE a.tenant_id = t.id)) > 0,
+            EXISTS (SELECT 1 FROM anos_letivos a WHERE a.tenant_id = t.id AND a.ativo),
+            EXISTS (SELECT 1 FROM saas_push_notifications p WHERE p.tenant_id = t.id AND p.status IN ('approved', 'sent'))
+        FROM tenants t
+        WHERE t.id = $1
+    `
+
+	var branding, secretarias, staff, anoLetivo, announcement bool
+	if err := r.pool.QueryRow(ctx, query, tenantID).Scan(&branding, &secretarias, &staff, &anoLetivo, &announcement); err != nil {
+		return nil, err
+	}
+	return map[string]bool{
+		TaskBranding:     branding,
+		TaskSecretarias:  secretarias,
+		TaskInviteStaff:  staff,
+		TaskAnoLetivo:    anoLetivo,
+		TaskAnnouncement: announcement,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package opendata
+
+import (
+	"strings"
+	"time"
+)
+
+// Publisher identifica o município responsável pelos dados.
+type Publisher struct {
+	Slug     string
+	Name     string
+	Homepage string
+}
+
+// mediaTypes mapeia formatos para os media types IANA exigidos pelo DCAT.
+var mediaTypes = map[string]string{
+	"csv":  "text/csv",
+	"json": "application/json",
+}
+
+// DCATCatalog monta o catálogo DCAT (JSON-LD) com os conjuntos e seus arquivos publicados.
+func DCATCatalog(publisher Publisher, resources []Resource) map[string]any {
+	byDataset := groupResources(resources)
+	datasets := make([]map[string]any, 0, len(Datasets))
+	var modified time.Time
+	for _, ds := range Datasets {
+		files := byDataset[ds.Name]
+		if len(files) == 0 {
+			continue
+		}
+		distributions := make([]map[string]any, 0, len(files))
+		var issued time.Time
+		for _, r := range files {
+			if r.GeneratedAt.After(issued) {
+				issued = r.GeneratedAt
+			}
+			distributions = append(distributions, map[string]any{
+				"@type":            "dcat:Distribution",
+				"dct:title":        ds.Title + " (" + strings.ToUpper(r.Format) + ")",
+				"dcat:accessURL":   r.URL,
+				"dcat:downloadURL": r.URL,
+				"dcat:mediaType":   mediaTypes[r.Format],
+				"dct:format":       strings.ToUpper(r.Format),
+				"dcat:byteSize":    r.Bytes,
+				"spdx:checksum": map[string]any{
+					"spdx:algorithm":     "spdx:checksumAlgorithm_sha256",
+					"spdx:checksumValue": r.SHA256,
+				},
+				"dct:modified": r.GeneratedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		if issued.After(modified) {
+			modified = issued
+		}
+		datasets = append(datasets, map[string]any{
+			"@type":                  "dcat:Dataset",
+			"dct:identifier":         publisher.Slug + "/" + ds.Name,
+			"dct:title":              ds.Title,
+			"dct:description":        ds.Description,
+			"dcat:keyword":           ds.Keywords,
+			"dcat:theme":             ds.Theme,
+			"dct:accrualPeriodicity": ds.Periodicity,
+			"dct:publisher":          map[string]any{"@type": "foaf:Organization", "foaf:name": publisher.Name},
+			"dct:modified":           issued.UTC().Format(time.RFC3339),
+			"dct:license":            "http://opendefinition.org/licenses/odc-odbl/",
+			"dcat:distribution":      distributions,
+		})
+	}
+
+	catalog := map[string]any{
+		"@context": map[string]any{
+			"dcat": "http://www.w3.org/ns/dcat#",
+			"dct":  "http://purl.org/dc/terms/",
+			"foaf": "http://xmlns.com/foaf/0.1/",
+			"spdx": "http://spdx.org/rdf/terms#",
+		},
+		"@type":         "dcat:Catalog",
+		"dct:title":     "Dados abertos — " + publisher.Name,
+		"dct:publisher": map[string]any{"@type": "foaf:Organization", "foaf:name": publisher.Name},
+		"dct:language":  "pt-BR",
+		"dcat:dataset":  datasets,
+	}
+	if publisher.Homepage != "" {
+		catalog["foaf:homepage"] = publisher.Homepage
+	}
+	if !modified.IsZero() {
+		catalog["dct:modified"] = modified.UTC().Format(time.RFC3339)
+	}
+	return catalog
+}
+
+// CKANPackageList devolve os nomes de pacotes publicados, como package_list do CKAN.
+func CKANPackageList(resources []Resource) []string {
+	byDataset := groupResources(resources)
+	names := make([]string, 0, len(Datasets))
+	for _, ds := range Datasets {
+		if len(byDataset[ds.Name]) > 0 {
+			names = append(names, ds.Name)
+		}
+	}
+	return names
+}
+
+// CKANPackage monta o pacote no formato de package_show do CKAN.
+func CKANPackage(publisher Publisher, ds Dataset, resources []Resource) map[string]any {
+	files := groupResources(resources)[ds.Name]
+	tags := make([]map[string]any, 0, len(ds.Keywords))
+	for _, keyword := range ds.Keywords {
+		tags = append(tags, map[string]any{"name": keyword, "display_name": keyword})
+	}
+
+	ckanResources := make([]map[string]any, 0, len(files))
+	var modified time.Time
+	for _, r := range files {
+		if r.GeneratedAt.After(modified) {
+			modified = r.GeneratedAt
+		}
+		ckanResources = append(ckanResources, map[string]any{
+			"id":            publisher.Slug + "/" + ds.Name + "." + r.Format,
+			"name":          ds.Title + " (" + strings.ToUpper(r.Format) + ")",
+			"url":           r.URL,
+			"format":        strings.ToUpper(r.Format),
+			"mimetype":      mediaTypes[r.Format],
+			"size":          r.Bytes,
+			"hash":          "sha256:" + r.SHA256,
+			"last_modified": r.GeneratedAt.UTC().Format("2006-01-02T15:04:05"),
+		})
+	}
+
+	pkg := map[string]any{
+		"id":            publisher.Slug + "/" + ds.Name,
+		"name":          ds.Name,
+		"title":         ds.Title,
+		"notes":         ds.Description,
+		"license_id":    "odc-odbl",
+		"license_title": "Open Data Commons Open Database License (ODbL)",
+		"organization":  map[string]any{"name": publisher.Slug, "title": publisher.Name},
+		"tags":          tags,
+		"num_resources": len(ckanResources),
+		"resources":     ckanResources,
+		"extras": []map[string]any{
+			{"key": "periodicidade", "value": ds.Periodicity},
+			{"key": "tema", "value": ds.Theme},
+		},
+	}
+	if !modified.IsZero() {
+		pkg["metadata_modified"] = modified.UTC().Format("2006-01-02T15:04:05")
+	}
+	return pkg
+}
+
+func groupResources(resources []Resource) map[string][]Resource {
+	grouped := make(map[string][]Resource)
+	for _, r := range resources {
+		grouped[r.Dataset] = append(grouped[r.Dataset], r)
+	}
+	return grouped
+}
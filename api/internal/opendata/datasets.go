@@ -0,0 +1,67 @@
+package opendata
+
+// Dataset é um conjunto de dados abertos gerado por tenant. A consulta recebe o tenant em $1
+// e só pode devolver dados agregados ou públicos, nunca dados pessoais.
+type Dataset struct {
+	Name        string
+	Title       string
+	Description string
+	Keywords    []string
+	Theme       string
+	Periodicity string
+	Columns     []string
+	query       string
+}
+
+// Datasets lista os conjuntos publicados. Protocolos e despesas entram quando os módulos
+// correspondentes tiverem tabelas próprias.
+var Datasets = []Dataset{
+	{
+		Name:        "indicadores-escolares",
+		Title:       "Indicadores da rede municipal de ensino",
+		Description: "Turmas, matrículas ativas, frequência e média de notas por escola nos últimos 12 meses.",
+		Keywords:    []string{"educação", "escolas", "frequência", "matrículas"},
+		Theme:       "educacao",
+		Periodicity: "diaria",
+		Columns:     []string{"escola", "turmas", "matriculas_ativas", "aulas_12m", "frequencia_percentual", "media_notas"},
+		query: `
+            SELECT e.nome,
+                   (SELECT count(*) FROM turmas t WHERE t.escola_id = e.id),
+                   (SELECT count(*) FROM matriculas m JOIN turmas t ON t.id = m.turma_id WHERE t.escola_id = e.id AND m.ativo),
+                   (SELECT count(*) FROM aulas a JOIN turmas t ON t.id = a.turma_id
+                     WHERE t.escola_id = e.id AND a.inicio >= now() - interval '12 months'),
+                   (SELECT round(100.0 * count(*) FILTER (WHERE p.status IN ('PRESENTE', 'ATRASO', 'JUSTIFICADA')) / NULLIF(count(*), 0), 1)::float8
+                      FROM presencas p JOIN aulas a ON a.id = p.aula_id JOIN turmas t ON t.id = a.turma_id
+                     WHERE t.escola_id = e.id AND p.aula_inicio >= now() - interval '12 months'),
+                   (SELECT round(avg(n.nota), 2)::float8 FROM notas n JOIN turmas t ON t.id = n.turma_id WHERE t.escola_id = e.id)
+            FROM escolas e
+            WHERE e.tenant_id = $1
+            ORDER BY e.nome`,
+	},
+	{
+		Name:        "merenda-refeicoes",
+		Title:       "Refeições da merenda escolar por mês",
+		Description: "Total de refeições servidas e dias com registro por escola e mês.",
+		Keywords:    []string{"educação", "merenda", "alimentação escolar"},
+		Theme:       "educacao",
+		Periodicity: "diaria",
+		Columns:     []string{"escola", "mes", "refeicoes_servidas", "dias_registrados"},
+		query: `
+            SELECT e.nome, to_char(date_trunc('month', r.data), 'YYYY-MM'), sum(r.refeicoes_servidas)::bigint, count(*)
+            FROM merenda_registros r
+            JOIN escolas e ON e.id = r.escola_id
+            WHERE e.tenant_id = $1
+            GROUP BY e.nome, date_trunc('month', r.data)
+            ORDER BY 2 DESC, e.nome`,
+	},
+}
+
+// Lookup devolve o conjunto pelo nome.
+func Lookup(name string) (Dataset, bool) {
+	for _, ds := range Datasets {
+		if ds.Name == name {
+			return ds, true
+		}
+	}
+	return Dataset{}, false
+}
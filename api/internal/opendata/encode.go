@@ -0,0 +1,71 @@
+package opendata
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EncodeCSV serializa as linhas com cabeçalho, separador vírgula e UTF-8.
+func EncodeCSV(columns []string, rows [][]any) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = formatCell(row[i])
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// EncodeJSON serializa as linhas como lista de objetos indexados pelas colunas.
+func EncodeJSON(columns []string, rows [][]any) ([]byte, error) {
+	records := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		record := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if i < len(row) {
+				record[column] = row[i]
+			} else {
+				record[column] = nil
+			}
+		}
+		records = append(records, record)
+	}
+	return json.Marshal(records)
+}
+
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
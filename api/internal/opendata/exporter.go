@@ -0,0 +1,198 @@
+package opendata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/storage"
+)
+
+// Resource é um arquivo publicado de um conjunto de dados.
+type Resource struct {
+	Dataset     string    `json:"dataset"`
+	Format      string    `json:"format"`
+	URL         string    `json:"url"`
+	Rows        int       `json:"rows"`
+	Bytes       int       `json:"bytes"`
+	SHA256      string    `json:"sha256"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Exporter gera os conjuntos de dados abertos de cada tenant ativo e os envia ao storage.
+type Exporter struct {
+	pool     *pgxpool.Pool
+	uploader storage.Uploader
+	logger   zerolog.Logger
+	router   TenantRouter
+}
+
+// NewExporter cria o exportador; uploader deve ser um storage real.
+func NewExporter(pool *pgxpool.Pool, uploader storage.Uploader, logger zerolog.Logger) *Exporter {
+	return &Exporter{pool: pool, uploader: uploader, logger: logger}
+}
+
+// TenantRouter fixa no contexto o pool do cluster de banco do tenant (db.Resolver).
+type TenantRouter interface {
+	WithTenant(ctx context.Context, tenantID uuid.UUID) (context.Context, error)
+}
+
+// UseRouter faz os conjuntos serem lidos do cluster de cada tenant. O catálogo publicado
+// (opendata_resources) continua no primary, onde a API pública o consulta.
+func (e *Exporter) UseRouter(router TenantRouter) {
+	e.router = router
+}
+
+// RunOnce exporta todos os tenants ativos; a falha de um tenant não interrompe os demais.
+func (e *Exporter) RunOnce(ctx context.Context) error {
+	rows, err := e.pool.Query(ctx, `SELECT id, slug FROM tenants WHERE status = 'active' ORDER BY slug`)
+	if err != nil {
+		return fmt.Errorf("dados abertos: listar tenants: %w", err)
+	}
+	type target struct {
+		id   uuid.UUID
+		slug string
+	}
+	var targets []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.id, &t.slug); err != nil {
+			rows.Close()
+			return err
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var failed int
+	for _, t := range targets {
+		if _, err := e.ExportTenant(ctx, t.id, t.slug); err != nil {
+			failed++
+			e.logger.Warn().Err(err).Str("tenant", t.slug).Msg("dados abertos: exportação falhou")
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("dados abertos: %d de %d tenants falharam", failed, len(targets))
+	}
+	return nil
+}
+
+// ExportTenant gera CSV e JSON de cada conjunto do tenant e registra os arquivos publicados.
+func (e *Exporter) ExportTenant(ctx context.Context, tenantID uuid.UUID, slug string) ([]Resource, error) {
+	resources := make([]Resource, 0, 2*len(Datasets))
+	dados := ctx
+	if e.router != nil {
+		routed, err := e.router.WithTenant(ctx, tenantID)
+		if err != nil {
+			return resources, err
+		}
+		dados = routed
+	}
+	for _, ds := range Datasets {
+		data, err := e.collect(dados, ds, tenantID)
+		if err != nil {
+			return resources, fmt.Errorf("%s: %w", ds.Name, err)
+		}
+
+		csvBody, err := EncodeCSV(ds.Columns, data)
+		if err != nil {
+			return resources, err
+		}
+		jsonBody, err := EncodeJSON(ds.Columns, data)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, file := range []struct {
+			format      string
+			contentType string
+			body        []byte
+		}{
+			{"csv", "text/csv; charset=utf-8", csvBody},
+			{"json", "application/json", jsonBody},
+		} {
+			resource, err := e.publish(ctx, tenantID, slug, ds.Name, file.format, file.contentType, file.body, len(data))
+			if err != nil {
+				return resources, fmt.Errorf("%s.%s: %w", ds.Name, file.format, err)
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func (e *Exporter) collect(ctx context.Context, ds Dataset, tenantID uuid.UUID) ([][]any, error) {
+	rows, err := db.Conn(ctx, e.pool).Query(ctx, ds.query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := make([][]any, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, values)
+	}
+	return data, rows.Err()
+}
+
+func (e *Exporter) publish(ctx context.Context, tenantID uuid.UUID, slug, dataset, format, contentType string, body []byte, rowCount int) (Resource, error) {
+	sum := sha256.Sum256(body)
+	key := fmt.Sprintf("opendata/%s/%s.%s", slug, dataset, format)
+	uploaded, err := e.uploader.Upload(ctx, storage.UploadInput{
+		Key:          key,
+		Body:         body,
+		ContentType:  contentType,
+		CacheControl: "public, max-age=3600",
+	})
+	if err != nil {
+		return Resource{}, err
+	}
+
+	resource := Resource{Dataset: dataset, Format: format, URL: uploaded.URL, Rows: rowCount, Bytes: len(body), SHA256: hex.EncodeToString(sum[:])}
+	err = e.pool.QueryRow(ctx, `
+        INSERT INTO opendata_resources (tenant_id, dataset, format, url, storage_key, rows, bytes, sha256, generated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+        ON CONFLICT (tenant_id, dataset, format) DO UPDATE
+        SET url = EXCLUDED.url, storage_key = EXCLUDED.storage_key, rows = EXCLUDED.rows,
+            bytes = EXCLUDED.bytes, sha256 = EXCLUDED.sha256, generated_at = now()
+        RETURNING generated_at
+    `, tenantID, dataset, format, resource.URL, key, rowCount, resource.Bytes, resource.SHA256).Scan(&resource.GeneratedAt)
+	return resource, err
+}
+
+// Resources lista os arquivos publicados do tenant.
+func Resources(ctx context.Context, pool *pgxpool.Pool, tenantID uuid.UUID) ([]Resource, error) {
+	rows, err := pool.Query(ctx, `
+        SELECT dataset, format, url, rows, bytes, sha256, generated_at
+        FROM opendata_resources WHERE tenant_id = $1
+        ORDER BY dataset, format
+    `, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := make([]Resource, 0)
+	for rows.Next() {
+		var r Resource
+		if err := rows.Scan(&r.Dataset, &r.Format, &r.URL, &r.Rows, &r.Bytes, &r.SHA256, &r.GeneratedAt); err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, rows.Err()
+}
@@ -0,0 +1,35 @@
+package opendata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncodeCSV(t *testing.T) {
+	body, err := EncodeCSV([]string{"escola", "turmas", "frequencia"}, [][]any{
+		{"E.M. José, \"Zé\"", int64(4), 93.5},
+		{"Escola Sem Dados", int64(0), nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "escola,turmas,frequencia\n\"E.M. José, \"\"Zé\"\"\",4,93.5\nEscola Sem Dados,0,\n"
+	if string(body) != want {
+		t.Fatalf("CSV = %q, want %q", body, want)
+	}
+}
+
+func TestCKANPackageListsOnlyPublished(t *testing.T) {
+	publisher := Publisher{Slug: "zabele", Name: "Prefeitura de Zabelê"}
+	resources := []Resource{{Dataset: "merenda-refeicoes", Format: "csv", URL: "https://cdn/x.csv", GeneratedAt: time.Now()}}
+
+	names := CKANPackageList(resources)
+	if len(names) != 1 || names[0] != "merenda-refeicoes" {
+		t.Fatalf("package_list = %v", names)
+	}
+	ds, _ := Lookup("merenda-refeicoes")
+	pkg := CKANPackage(publisher, ds, resources)
+	if pkg["num_resources"] != 1 || pkg["id"] != "zabele/merenda-refeicoes" {
+		t.Fatalf("package = %v", pkg)
+	}
+}
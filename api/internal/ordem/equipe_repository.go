@@ -0,0 +1,151 @@
+package ordem
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const equipeColumns = `id, tenant_id, secretaria_id, nome, ativo, created_at, updated_at`
+
+// ListEquipes lista as equipes de campo da prefeitura com os membros.
+func (r *Repository) ListEquipes(ctx context.Context, tenantID uuid.UUID) ([]Equipe, error) {
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+equipeColumns+` FROM equipes WHERE tenant_id = $1 ORDER BY nome`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	equipes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Equipe, error) {
+		e, err := scanEquipe(row)
+		if err != nil {
+			return Equipe{}, err
+		}
+		return *e, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range equipes {
+		if equipes[i].Membros, err = r.listMembros(ctx, r.conn(ctx), equipes[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return equipes, nil
+}
+
+// CreateEquipe cadastra uma equipe na secretaria; a entrada já deve estar normalizada.
+func (r *Repository) CreateEquipe(ctx context.Context, tenantID uuid.UUID, in EquipeInput) (*Equipe, error) {
+	row := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO equipes (tenant_id, secretaria_id, nome, ativo)
+        SELECT $1, s.id, $3, $4 FROM secretarias s WHERE s.id = $2 AND s.tenant_id = $1
+        RETURNING `+equipeColumns,
+		tenantID, in.SecretariaID, in.Nome, in.Ativo)
+	e, err := equipeError(scanEquipe(row))
+	if err != nil {
+		return nil, err
+	}
+	e.Membros = []Membro{}
+	return e, nil
+}
+
+// UpdateEquipe altera nome e situação da equipe; a secretaria não muda.
+func (r *Repository) UpdateEquipe(ctx context.Context, tenantID, id uuid.UUID, in EquipeInput) (*Equipe, error) {
+	row := r.conn(ctx).QueryRow(ctx, `
+        UPDATE equipes SET nome = $3, ativo = $4, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+        RETURNING `+equipeColumns,
+		tenantID, id, in.Nome, in.Ativo)
+	e, err := equipeError(scanEquipe(row))
+	if err != nil {
+		return nil, err
+	}
+	if e.Membros, err = r.listMembros(ctx, r.conn(ctx), e.ID); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetMembros substitui os membros da equipe; todos precisam estar vinculados à secretaria dela.
+func (r *Repository) SetMembros(ctx context.Context, tenantID, equipeID uuid.UUID, usuarios []uuid.UUID) ([]Membro, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var secretariaID uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT secretaria_id FROM equipes WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, equipeID).Scan(&secretariaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var linked, distinct int
+	if err := tx.QueryRow(ctx, `
+        SELECT (SELECT count(DISTINCT usuario_id) FROM usuarios_secretarias WHERE secretaria_id = $1 AND usuario_id = ANY($2)),
+               (SELECT count(DISTINCT u) FROM unnest($2::uuid[]) u)
+    `, secretariaID, usuarios).Scan(&linked, &distinct); err != nil {
+		return nil, err
+	}
+	if linked != distinct {
+		return nil, ErrNotMember
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM equipe_membros WHERE equipe_id = $1 AND NOT (usuario_id = ANY($2))`, equipeID, usuarios); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO equipe_membros (equipe_id, usuario_id)
+        SELECT $1, unnest($2::uuid[])
+        ON CONFLICT DO NOTHING
+    `, equipeID, usuarios); err != nil {
+		return nil, err
+	}
+	membros, err := r.listMembros(ctx, tx, equipeID)
+	if err != nil {
+		return nil, err
+	}
+	return membros, tx.Commit(ctx)
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (r *Repository) listMembros(ctx context.Context, q querier, equipeID uuid.UUID) ([]Membro, error) {
+	rows, err := q.Query(ctx, `
+        SELECT m.usuario_id, u.nome
+        FROM equipe_membros m
+        JOIN usuarios u ON u.id = m.usuario_id
+        WHERE m.equipe_id = $1
+        ORDER BY u.nome
+    `, equipeID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Membro, error) {
+		var m Membro
+		err := row.Scan(&m.UsuarioID, &m.Nome)
+		return m, err
+	})
+}
+
+func scanEquipe(row pgx.Row) (*Equipe, error) {
+	var e Equipe
+	if err := row.Scan(&e.ID, &e.TenantID, &e.SecretariaID, &e.Nome, &e.Ativo, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func equipeError(e *Equipe, err error) (*Equipe, error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrEquipeDuplicate
+	}
+	return e, err
+}
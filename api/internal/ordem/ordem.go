@@ -0,0 +1,250 @@
+// Package ordem converte protocolos em ordens de serviço executadas pelas equipes de campo das
+// secretarias: agendamento, materiais, fotos de antes e depois e a conclusão assinada que encerra
+// o protocolo para o cidadão.
+package ordem
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica ordem, equipe ou foto inexistente (ou de outro tenant).
+	ErrNotFound = errors.New("ordem: não encontrada")
+	// ErrProtocolo indica protocolo inexistente ou já encerrado.
+	ErrProtocolo = errors.New("ordem: protocolo indisponível")
+	// ErrOpenOrder indica protocolo que já tem ordem de serviço em aberto.
+	ErrOpenOrder = errors.New("ordem: protocolo já possui ordem de serviço")
+	// ErrEquipe indica equipe inativa ou de outra prefeitura.
+	ErrEquipe = errors.New("ordem: equipe inválida")
+	// ErrEquipeDuplicate indica nome de equipe já usado na secretaria.
+	ErrEquipeDuplicate = errors.New("ordem: equipe já cadastrada")
+	// ErrNotMember indica servidor não vinculado à secretaria da equipe.
+	ErrNotMember = errors.New("ordem: usuário não pertence à secretaria")
+	// ErrInvalidTransition indica mudança de situação não permitida.
+	ErrInvalidTransition = errors.New("ordem: mudança de situação inválida")
+	// ErrFotoDepois indica conclusão sem foto do serviço pronto.
+	ErrFotoDepois = errors.New("ordem: conclusão exige foto de depois")
+)
+
+// Situação da ordem de serviço.
+const (
+	StatusPendente  = "pendente"
+	StatusAgendada  = "agendada"
+	StatusExecucao  = "em_execucao"
+	StatusConcluida = "concluida"
+	StatusCancelada = "cancelada"
+)
+
+// Fases das fotos do serviço.
+const (
+	FaseAntes  = "antes"
+	FaseDepois = "depois"
+)
+
+const (
+	maxMateriais     = 100
+	maxDescricaoSize = 2000
+)
+
+// Equipe é uma equipe de campo de uma secretaria.
+type Equipe struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	SecretariaID uuid.UUID `json:"secretaria_id"`
+	Nome         string    `json:"nome"`
+	Ativo        bool      `json:"ativo"`
+	Membros      []Membro  `json:"membros"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Membro é um servidor da equipe.
+type Membro struct {
+	UsuarioID uuid.UUID `json:"usuario_id"`
+	Nome      *string   `json:"nome,omitempty"`
+}
+
+// EquipeInput contém os campos editáveis de uma equipe; SecretariaID só vale na criação.
+type EquipeInput struct {
+	SecretariaID uuid.UUID
+	Nome         string
+	Ativo        bool
+}
+
+// Normalize limpa e valida a entrada.
+func (in *EquipeInput) Normalize() error {
+	in.Nome = strings.TrimSpace(in.Nome)
+	if in.Nome == "" {
+		return errors.New("nome obrigatório")
+	}
+	return nil
+}
+
+// Ordem é uma ordem de serviço aberta a partir de um protocolo.
+type Ordem struct {
+	ID              uuid.UUID  `json:"id"`
+	TenantID        uuid.UUID  `json:"tenant_id"`
+	Numero          string     `json:"numero"`
+	ProtocoloID     uuid.UUID  `json:"protocolo_id"`
+	ProtocoloNumero string     `json:"protocolo_numero"`
+	SecretariaID    *uuid.UUID `json:"secretaria_id,omitempty"`
+	EquipeID        *uuid.UUID `json:"equipe_id,omitempty"`
+	AtivoID         *uuid.UUID `json:"ativo_id,omitempty"`
+	Descricao       string     `json:"descricao"`
+	Status          string     `json:"status"`
+	AgendadaPara    *time.Time `json:"agendada_para,omitempty"`
+	IniciadaEm      *time.Time `json:"iniciada_em,omitempty"`
+	ConcluidaEm     *time.Time `json:"concluida_em,omitempty"`
+	ConclusaoNota   *string    `json:"conclusao_nota,omitempty"`
+	AssinadoPor     *uuid.UUID `json:"assinado_por,omitempty"`
+	AssinaturaNome  *string    `json:"assinatura_nome,omitempty"`
+	Materiais       []Material `json:"materiais,omitempty"`
+	Fotos           []Foto     `json:"fotos,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Material é um item da lista de materiais da ordem. Com ItemID, a quantidade sai do almoxarifado
+// quando a ordem é concluída.
+type Material struct {
+	ID            uuid.UUID  `json:"id"`
+	ItemID        *uuid.UUID `json:"item_id,omitempty"`
+	Descricao     string     `json:"descricao"`
+	Quantidade    float64    `json:"quantidade"`
+	Unidade       string     `json:"unidade"`
+	CustoUnitario *float64   `json:"custo_unitario,omitempty"`
+}
+
+// Foto é uma imagem de antes ou depois enviada pela equipe em campo.
+type Foto struct {
+	ID          uuid.UUID  `json:"id"`
+	Fase        string     `json:"fase"`
+	FileName    string     `json:"file_name"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	ObjectKey   string     `json:"-"`
+	FileURL     string     `json:"-"`
+	Latitude    *float64   `json:"latitude,omitempty"`
+	Longitude   *float64   `json:"longitude,omitempty"`
+	TiradaEm    *time.Time `json:"tirada_em,omitempty"`
+	EnviadoPor  *uuid.UUID `json:"enviado_por,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Resumo é o que o cidadão vê da ordem de serviço do seu protocolo.
+type Resumo struct {
+	Numero       string     `json:"numero"`
+	Status       string     `json:"status"`
+	AgendadaPara *time.Time `json:"agendada_para,omitempty"`
+	ConcluidaEm  *time.Time `json:"concluida_em,omitempty"`
+}
+
+// NovaOrdem contém os dados para converter um protocolo em ordem de serviço.
+type NovaOrdem struct {
+	ProtocoloID  uuid.UUID
+	EquipeID     *uuid.UUID
+	Descricao    string
+	AgendadaPara *time.Time
+	Materiais    []Material
+	ActorID      *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada. Ordem com equipe e data já nasce agendada.
+func (in *NovaOrdem) Normalize() error {
+	in.Descricao = strings.TrimSpace(in.Descricao)
+	switch {
+	case in.Descricao == "":
+		return errors.New("descrição obrigatória")
+	case len(in.Descricao) > maxDescricaoSize:
+		return errors.New("descrição longa demais")
+	case in.AgendadaPara != nil && in.EquipeID == nil:
+		return errors.New("agendamento exige equipe")
+	}
+	return NormalizeMateriais(in.Materiais)
+}
+
+// NormalizeMateriais limpa e valida a lista de materiais.
+func NormalizeMateriais(materiais []Material) error {
+	if len(materiais) > maxMateriais {
+		return fmt.Errorf("no máximo %d materiais", maxMateriais)
+	}
+	for i := range materiais {
+		m := &materiais[i]
+		m.Descricao = strings.TrimSpace(m.Descricao)
+		m.Unidade = strings.ToLower(strings.TrimSpace(m.Unidade))
+		if m.Unidade == "" {
+			m.Unidade = "un"
+		}
+		switch {
+		case m.Descricao == "":
+			return fmt.Errorf("material %d: descrição obrigatória", i+1)
+		case m.Quantidade <= 0:
+			return fmt.Errorf("material %d: quantidade inválida", i+1)
+		case m.CustoUnitario != nil && *m.CustoUnitario < 0:
+			return fmt.Errorf("material %d: custo inválido", i+1)
+		}
+	}
+	return nil
+}
+
+// Conclusao é o encerramento da ordem em campo, assinado por quem recebe o serviço.
+type Conclusao struct {
+	Nota           *string
+	AssinaturaNome string
+	AtorID         *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada.
+func (in *Conclusao) Normalize() error {
+	in.AssinaturaNome = strings.TrimSpace(in.AssinaturaNome)
+	if in.Nota != nil {
+		if n := strings.TrimSpace(*in.Nota); n != "" {
+			in.Nota = &n
+		} else {
+			in.Nota = nil
+		}
+	}
+	if in.AssinaturaNome == "" {
+		return errors.New("nome de quem assina a conclusão é obrigatório")
+	}
+	return nil
+}
+
+// Filter restringe a listagem de ordens. UsuarioID limita às equipes de que o usuário participa.
+type Filter struct {
+	Status    string
+	EquipeID  *uuid.UUID
+	UsuarioID *uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+}
+
+// CanTransition informa se a ordem pode passar de from para to. Reagendar mantém a situação
+// agendada; concluída e cancelada são finais.
+func CanTransition(from, to string) bool {
+	switch from {
+	case StatusPendente:
+		return to == StatusAgendada || to == StatusExecucao || to == StatusCancelada
+	case StatusAgendada:
+		return to == StatusAgendada || to == StatusExecucao || to == StatusCancelada
+	case StatusExecucao:
+		return to == StatusConcluida || to == StatusCancelada
+	}
+	return false
+}
+
+// ValidFase informa se a fase da foto é conhecida.
+func ValidFase(fase string) bool {
+	return fase == FaseAntes || fase == FaseDepois
+}
+
+// FormatNumero monta o número da ordem, ex.: OS-2026-000042.
+func FormatNumero(ano, seq int) string {
+	return fmt.Sprintf("OS-%04d-%06d", ano, seq)
+}
@@ -0,0 +1,56 @@
+package ordem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusPendente, StatusAgendada, true},
+		{StatusAgendada, StatusAgendada, true},
+		{StatusAgendada, StatusExecucao, true},
+		{StatusPendente, StatusConcluida, false},
+		{StatusExecucao, StatusConcluida, true},
+		{StatusExecucao, StatusAgendada, false},
+		{StatusConcluida, StatusCancelada, false},
+		{StatusCancelada, StatusAgendada, false},
+	}
+	for _, c := range cases {
+		if got := CanTransition(c.from, c.to); got != c.want {
+			t.Errorf("CanTransition(%s, %s) = %v", c.from, c.to, got)
+		}
+	}
+}
+
+func TestNovaOrdemNormalize(t *testing.T) {
+	quando := time.Now()
+	in := NovaOrdem{Descricao: " trocar lâmpada ", AgendadaPara: &quando}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("agendamento sem equipe deveria falhar")
+	}
+	equipe := uuid.New()
+	in.EquipeID = &equipe
+	in.Materiais = []Material{{Descricao: " Lâmpada LED ", Quantidade: 2}}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if in.Descricao != "trocar lâmpada" || in.Materiais[0].Unidade != "un" || in.Materiais[0].Descricao != "Lâmpada LED" {
+		t.Fatalf("entrada não normalizada: %+v", in)
+	}
+	in.Materiais = []Material{{Descricao: "Cabo", Quantidade: 0}}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("quantidade zero deveria falhar")
+	}
+}
+
+func TestFormatNumero(t *testing.T) {
+	if got := FormatNumero(2026, 42); got != "OS-2026-000042" {
+		t.Fatalf("FormatNumero = %s", got)
+	}
+}
@@ -0,0 +1,498 @@
+package ordem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+	"github.com/gestaozabele/municipio/internal/db"
+	"github.com/gestaozabele/municipio/internal/estoque"
+	"github.com/gestaozabele/municipio/internal/protocolo"
+)
+
+const ordemColumns = `o.id, o.tenant_id, o.numero, o.protocolo_id, p.numero, o.secretaria_id, o.equipe_id, o.ativo_id,
+        o.descricao, o.status, o.agendada_para, o.iniciada_em, o.concluida_em, o.conclusao_nota, o.assinado_por,
+        o.assinatura_nome, o.created_at, o.updated_at`
+
+// Repository provê acesso às ordens de serviço e às equipes de campo.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// Create converte o protocolo em ordem de serviço. O protocolo precisa estar em aberto e sem outra
+// ordem ativa; se ainda não estava em andamento, passa a estar, e o cidadão vê o evento no histórico.
+func (r *Repository) Create(ctx context.Context, tenantID uuid.UUID, in NovaOrdem) (*Ordem, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		protocoloStatus string
+		o               = Ordem{TenantID: tenantID, ProtocoloID: in.ProtocoloID, EquipeID: in.EquipeID, Descricao: in.Descricao, AgendadaPara: in.AgendadaPara, Status: StatusPendente}
+	)
+	err = tx.QueryRow(ctx, `
+        SELECT numero, status, secretaria_id, ativo_id FROM protocolos WHERE tenant_id = $1 AND id = $2 FOR UPDATE
+    `, tenantID, in.ProtocoloID).Scan(&o.ProtocoloNumero, &protocoloStatus, &o.SecretariaID, &o.AtivoID)
+	if errors.Is(err, pgx.ErrNoRows) || protocoloStatus == protocolo.StatusConcluido || protocoloStatus == protocolo.StatusCancelado {
+		return nil, ErrProtocolo
+	}
+	if err != nil {
+		return nil, err
+	}
+	if in.EquipeID != nil {
+		if o.SecretariaID, err = equipeSecretaria(ctx, tx, tenantID, *in.EquipeID); err != nil {
+			return nil, err
+		}
+	}
+	if in.AgendadaPara != nil {
+		o.Status = StatusAgendada
+	}
+
+	ano := time.Now().Year()
+	var seq int
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO ordem_servico_sequencias (tenant_id, ano, ultimo) VALUES ($1, $2, 1)
+        ON CONFLICT (tenant_id, ano) DO UPDATE SET ultimo = ordem_servico_sequencias.ultimo + 1
+        RETURNING ultimo
+    `, tenantID, ano).Scan(&seq); err != nil {
+		return nil, err
+	}
+	o.Numero = FormatNumero(ano, seq)
+	err = tx.QueryRow(ctx, `
+        INSERT INTO ordens_servico (tenant_id, numero, protocolo_id, secretaria_id, equipe_id, ativo_id, descricao, status,
+                                    agendada_para, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, created_at, updated_at
+    `, tenantID, o.Numero, o.ProtocoloID, o.SecretariaID, o.EquipeID, o.AtivoID, o.Descricao, o.Status,
+		o.AgendadaPara, in.ActorID).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrOpenOrder
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.Materiais, err = replaceMateriais(ctx, tx, tenantID, o.ID, in.Materiais); err != nil {
+		return nil, err
+	}
+	o.Fotos = []Foto{}
+
+	var novoStatus *string
+	if protocoloStatus == protocolo.StatusAberto {
+		status := protocolo.StatusEmAndamento
+		if _, err := tx.Exec(ctx, `UPDATE protocolos SET status = $2, updated_at = now() WHERE id = $1`, o.ProtocoloID, status); err != nil {
+			return nil, err
+		}
+		novoStatus = &status
+	}
+	motivo := fmt.Sprintf("ordem de serviço %s aberta", o.Numero)
+	if o.AgendadaPara != nil {
+		motivo += ", agendada para " + o.AgendadaPara.Format("02/01/2006")
+	}
+	if err := protocoloEvento(ctx, tx, o.ProtocoloID, novoStatus, motivo, in.ActorID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// List lista as ordens da prefeitura, as agendadas primeiro pela data.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, filter Filter) ([]Ordem, error) {
+	clauses := []string{"o.tenant_id = $1"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, strings.ReplaceAll(clause, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.Status != "" {
+		add("o.status = $?", filter.Status)
+	}
+	if filter.EquipeID != nil {
+		add("o.equipe_id = $?", *filter.EquipeID)
+	}
+	if filter.UsuarioID != nil {
+		add("o.equipe_id IN (SELECT equipe_id FROM equipe_membros WHERE usuario_id = $?)", *filter.UsuarioID)
+	}
+	if filter.From != nil {
+		add("o.agendada_para >= $?", *filter.From)
+	}
+	if filter.To != nil {
+		add("o.agendada_para < $?", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+ordemColumns+`
+        FROM ordens_servico o
+        JOIN protocolos p ON p.id = o.protocolo_id
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY o.agendada_para NULLS LAST, o.created_at
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Ordem, error) {
+		o, err := scanOrdem(row)
+		if err != nil {
+			return Ordem{}, err
+		}
+		return *o, nil
+	})
+}
+
+// Get busca a ordem do tenant com materiais e fotos.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID) (*Ordem, error) {
+	o, err := scanOrdem(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+ordemColumns+`
+        FROM ordens_servico o
+        JOIN protocolos p ON p.id = o.protocolo_id
+        WHERE o.tenant_id = $1 AND o.id = $2
+    `, tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadDetalhes(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Resumo devolve a ordem mais recente do protocolo para o cidadão; nil quando não há ordem ativa.
+func (r *Repository) Resumo(ctx context.Context, tenantID, protocoloID uuid.UUID) (*Resumo, error) {
+	var s Resumo
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT numero, status, agendada_para, concluida_em
+        FROM ordens_servico
+        WHERE tenant_id = $1 AND protocolo_id = $2 AND status <> 'cancelada'
+        ORDER BY created_at DESC
+        LIMIT 1
+    `, tenantID, protocoloID).Scan(&s.Numero, &s.Status, &s.AgendadaPara, &s.ConcluidaEm)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Schedule define equipe e data da execução (também serve para reagendar).
+func (r *Repository) Schedule(ctx context.Context, tenantID, id, equipeID uuid.UUID, quando time.Time, actorID *uuid.UUID) (*Ordem, error) {
+	return r.withOrdem(ctx, tenantID, id, func(tx pgx.Tx, o *Ordem) error {
+		if !CanTransition(o.Status, StatusAgendada) {
+			return ErrInvalidTransition
+		}
+		secretariaID, err := equipeSecretaria(ctx, tx, tenantID, equipeID)
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, `
+            UPDATE ordens_servico SET equipe_id = $2, secretaria_id = $3, agendada_para = $4, status = 'agendada', updated_at = now()
+            WHERE id = $1
+            RETURNING equipe_id, secretaria_id, agendada_para, status, updated_at
+        `, o.ID, equipeID, secretariaID, quando).Scan(&o.EquipeID, &o.SecretariaID, &o.AgendadaPara, &o.Status, &o.UpdatedAt); err != nil {
+			return err
+		}
+		return protocoloEvento(ctx, tx, o.ProtocoloID, nil, fmt.Sprintf("ordem de serviço %s agendada para %s", o.Numero, quando.Format("02/01/2006")), actorID)
+	})
+}
+
+// Start marca o início da execução em campo.
+func (r *Repository) Start(ctx context.Context, tenantID, id uuid.UUID, actorID *uuid.UUID) (*Ordem, error) {
+	return r.withOrdem(ctx, tenantID, id, func(tx pgx.Tx, o *Ordem) error {
+		if !CanTransition(o.Status, StatusExecucao) {
+			return ErrInvalidTransition
+		}
+		if o.EquipeID == nil {
+			return ErrEquipe
+		}
+		if err := tx.QueryRow(ctx, `
+            UPDATE ordens_servico SET status = 'em_execucao', iniciada_em = now(), updated_at = now()
+            WHERE id = $1
+            RETURNING status, iniciada_em, updated_at
+        `, o.ID).Scan(&o.Status, &o.IniciadaEm, &o.UpdatedAt); err != nil {
+			return err
+		}
+		return protocoloEvento(ctx, tx, o.ProtocoloID, nil, fmt.Sprintf("equipe em campo (ordem de serviço %s)", o.Numero), actorID)
+	})
+}
+
+// Cancel cancela a ordem; o protocolo continua em andamento e pode gerar outra ordem.
+func (r *Repository) Cancel(ctx context.Context, tenantID, id uuid.UUID, motivo string, actorID *uuid.UUID) (*Ordem, error) {
+	return r.withOrdem(ctx, tenantID, id, func(tx pgx.Tx, o *Ordem) error {
+		if !CanTransition(o.Status, StatusCancelada) {
+			return ErrInvalidTransition
+		}
+		if err := tx.QueryRow(ctx, `
+            UPDATE ordens_servico SET status = 'cancelada', conclusao_nota = $2, updated_at = now()
+            WHERE id = $1
+            RETURNING status, conclusao_nota, updated_at
+        `, o.ID, motivo).Scan(&o.Status, &o.ConclusaoNota, &o.UpdatedAt); err != nil {
+			return err
+		}
+		return protocoloEvento(ctx, tx, o.ProtocoloID, nil, fmt.Sprintf("ordem de serviço %s cancelada: %s", o.Numero, motivo), actorID)
+	})
+}
+
+// Complete encerra a ordem com a assinatura de quem recebeu o serviço e conclui o protocolo.
+// Exige ao menos uma foto de depois; os materiais de estoque saem do almoxarifado na mesma transação.
+func (r *Repository) Complete(ctx context.Context, tenantID, id uuid.UUID, in Conclusao) (*Ordem, error) {
+	return r.withOrdem(ctx, tenantID, id, func(tx pgx.Tx, o *Ordem) error {
+		if !CanTransition(o.Status, StatusConcluida) {
+			return ErrInvalidTransition
+		}
+		var depois bool
+		if err := tx.QueryRow(ctx, `
+            SELECT EXISTS (SELECT 1 FROM ordem_servico_fotos WHERE ordem_id = $1 AND fase = 'depois')
+        `, o.ID).Scan(&depois); err != nil {
+			return err
+		}
+		if !depois {
+			return ErrFotoDepois
+		}
+		if err := r.baixarMateriais(ctx, tx, o, in.AtorID); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, `
+            UPDATE ordens_servico
+            SET status = 'concluida', concluida_em = now(), conclusao_nota = $2, assinado_por = $3, assinatura_nome = $4, updated_at = now()
+            WHERE id = $1
+            RETURNING status, concluida_em, conclusao_nota, assinado_por, assinatura_nome, updated_at
+        `, o.ID, in.Nota, in.AtorID, in.AssinaturaNome).Scan(&o.Status, &o.ConcluidaEm, &o.ConclusaoNota, &o.AssinadoPor, &o.AssinaturaNome, &o.UpdatedAt); err != nil {
+			return err
+		}
+		var status *string
+		if err := tx.QueryRow(ctx, `
+            UPDATE protocolos SET status = 'concluido', concluido_em = now(), updated_at = now()
+            WHERE id = $1 AND status IN ('aberto', 'em_andamento')
+            RETURNING status
+        `, o.ProtocoloID).Scan(&status); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		return protocoloEvento(ctx, tx, o.ProtocoloID, status, fmt.Sprintf("serviço concluído (ordem de serviço %s)", o.Numero), in.AtorID)
+	})
+}
+
+// SetMateriais substitui a lista de materiais enquanto a ordem estiver aberta.
+func (r *Repository) SetMateriais(ctx context.Context, tenantID, id uuid.UUID, materiais []Material) (*Ordem, error) {
+	return r.withOrdem(ctx, tenantID, id, func(tx pgx.Tx, o *Ordem) error {
+		if o.Status == StatusConcluida || o.Status == StatusCancelada {
+			return ErrInvalidTransition
+		}
+		_, err := replaceMateriais(ctx, tx, tenantID, o.ID, materiais)
+		return err
+	})
+}
+
+// AddFoto registra uma foto já enviada ao armazenamento. Fotos de depois só entram com a equipe em
+// campo; nenhuma entra depois do encerramento.
+func (r *Repository) AddFoto(ctx context.Context, tenantID, id uuid.UUID, f Foto) (*Foto, error) {
+	_, err := r.withOrdem(ctx, tenantID, id, func(tx pgx.Tx, o *Ordem) error {
+		switch {
+		case o.Status == StatusConcluida || o.Status == StatusCancelada:
+			return ErrInvalidTransition
+		case f.Fase == FaseDepois && o.Status != StatusExecucao:
+			return ErrInvalidTransition
+		}
+		return tx.QueryRow(ctx, `
+            INSERT INTO ordem_servico_fotos (ordem_id, fase, file_name, content_type, size_bytes, object_key, file_url,
+                                             localizacao, tirada_em, enviado_por)
+            VALUES ($1, $2, $3, $4, $5, $6, $7,
+                    CASE WHEN $8::float8 IS NULL THEN NULL ELSE ST_SetSRID(ST_MakePoint($9, $8), 4326)::geography END,
+                    $10, $11)
+            RETURNING id, created_at
+        `, o.ID, f.Fase, f.FileName, f.ContentType, f.SizeBytes, f.ObjectKey, f.FileURL,
+			f.Latitude, f.Longitude, f.TiradaEm, f.EnviadoPor).Scan(&f.ID, &f.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// baixarMateriais dá saída no almoxarifado dos materiais da ordem ligados a itens de estoque.
+func (r *Repository) baixarMateriais(ctx context.Context, tx pgx.Tx, o *Ordem, actorID *uuid.UUID) error {
+	rows, err := tx.Query(ctx, `
+        SELECT item_id, quantidade::float8 FROM ordem_servico_materiais WHERE ordem_id = $1 AND item_id IS NOT NULL ORDER BY posicao
+    `, o.ID)
+	if err != nil {
+		return err
+	}
+	type baixa struct {
+		item       uuid.UUID
+		quantidade float64
+	}
+	baixas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (baixa, error) {
+		var b baixa
+		err := row.Scan(&b.item, &b.quantidade)
+		return b, err
+	})
+	if err != nil {
+		return err
+	}
+	motivo := "ordem de serviço " + o.Numero
+	for _, b := range baixas {
+		in := estoque.MovimentoInput{ItemID: b.item, Tipo: estoque.TipoSaida, Quantidade: b.quantidade, Motivo: &motivo, OrdemID: &o.ID, AtorID: actorID}
+		if err := in.Normalize(); err != nil {
+			return err
+		}
+		if _, err := estoque.Apply(ctx, tx, o.TenantID, in); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withOrdem carrega a ordem com lock, aplica fn e devolve o estado final com materiais e fotos.
+func (r *Repository) withOrdem(ctx context.Context, tenantID, id uuid.UUID, fn func(pgx.Tx, *Ordem) error) (*Ordem, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	o, err := scanOrdem(tx.QueryRow(ctx, `
+        SELECT `+ordemColumns+`
+        FROM ordens_servico o
+        JOIN protocolos p ON p.id = o.protocolo_id
+        WHERE o.tenant_id = $1 AND o.id = $2
+        FOR UPDATE OF o
+    `, tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(tx, o); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.loadDetalhes(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *Repository) loadDetalhes(ctx context.Context, o *Ordem) error {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT id, item_id, descricao, quantidade::float8, unidade, custo_unitario::float8
+        FROM ordem_servico_materiais WHERE ordem_id = $1 ORDER BY posicao
+    `, o.ID)
+	if err != nil {
+		return err
+	}
+	if o.Materiais, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Material, error) {
+		var m Material
+		err := row.Scan(&m.ID, &m.ItemID, &m.Descricao, &m.Quantidade, &m.Unidade, &m.CustoUnitario)
+		return m, err
+	}); err != nil {
+		return err
+	}
+
+	rows, err = r.conn(ctx).Query(ctx, `
+        SELECT id, fase, file_name, content_type, size_bytes, object_key, file_url,
+               ST_Y(localizacao::geometry), ST_X(localizacao::geometry), tirada_em, enviado_por, created_at
+        FROM ordem_servico_fotos WHERE ordem_id = $1 ORDER BY fase, created_at
+    `, o.ID)
+	if err != nil {
+		return err
+	}
+	o.Fotos, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Foto, error) {
+		var f Foto
+		err := row.Scan(&f.ID, &f.Fase, &f.FileName, &f.ContentType, &f.SizeBytes, &f.ObjectKey, &f.FileURL,
+			&f.Latitude, &f.Longitude, &f.TiradaEm, &f.EnviadoPor, &f.CreatedAt)
+		return f, err
+	})
+	return err
+}
+
+// equipeSecretaria confere que a equipe é da prefeitura e está ativa e devolve a secretaria dela.
+func equipeSecretaria(ctx context.Context, tx pgx.Tx, tenantID, equipeID uuid.UUID) (*uuid.UUID, error) {
+	var secretariaID uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT secretaria_id FROM equipes WHERE tenant_id = $1 AND id = $2 AND ativo`, tenantID, equipeID).Scan(&secretariaID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEquipe
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &secretariaID, nil
+}
+
+// replaceMateriais grava a lista de materiais; itens do almoxarifado precisam ser da prefeitura.
+func replaceMateriais(ctx context.Context, tx pgx.Tx, tenantID, ordemID uuid.UUID, materiais []Material) ([]Material, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM ordem_servico_materiais WHERE ordem_id = $1`, ordemID); err != nil {
+		return nil, err
+	}
+	out := make([]Material, 0, len(materiais))
+	for i, m := range materiais {
+		err := tx.QueryRow(ctx, `
+            INSERT INTO ordem_servico_materiais (ordem_id, item_id, descricao, quantidade, unidade, custo_unitario, posicao)
+            SELECT $1, $2, $3, $4, $5, $6, $7
+            WHERE $2::uuid IS NULL OR EXISTS (SELECT 1 FROM estoque_itens WHERE id = $2 AND tenant_id = $8)
+            RETURNING id
+        `, ordemID, m.ItemID, m.Descricao, m.Quantidade, m.Unidade, m.CustoUnitario, i, tenantID).Scan(&m.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, estoque.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// protocoloEvento registra o andamento da ordem no histórico do protocolo; status só é informado
+// quando a ordem mudou a situação do protocolo.
+func protocoloEvento(ctx context.Context, tx pgx.Tx, protocoloID uuid.UUID, status *string, motivo string, actorID *uuid.UUID) error {
+	var delegacaoID, emNomeDeID *uuid.UUID
+	if d := authz.DelegacaoFrom(ctx); d != nil {
+		delegacaoID, emNomeDeID = &d.ID, &d.DeleganteID
+	}
+	_, err := tx.Exec(ctx, `
+        INSERT INTO protocolo_eventos (protocolo_id, tipo, para_secretaria_id, para_fila_id, responsavel_id, status, motivo, ator_id,
+                                       delegacao_id, em_nome_de_id)
+        SELECT id, $2, secretaria_id, fila_id, responsavel_id, $3, $4, $5, $6, $7 FROM protocolos WHERE id = $1
+    `, protocoloID, protocolo.EventoOrdemServico, status, motivo, actorID, delegacaoID, emNomeDeID)
+	return err
+}
+
+func scanOrdem(row pgx.Row) (*Ordem, error) {
+	var o Ordem
+	if err := row.Scan(&o.ID, &o.TenantID, &o.Numero, &o.ProtocoloID, &o.ProtocoloNumero, &o.SecretariaID, &o.EquipeID, &o.AtivoID,
+		&o.Descricao, &o.Status, &o.AgendadaPara, &o.IniciadaEm, &o.ConcluidaEm, &o.ConclusaoNota, &o.AssinadoPor,
+		&o.AssinaturaNome, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,212 @@
+package partitions
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// Table descreve uma tabela particionada por mês pela coluna indicada.
+type Table struct {
+	Name   string
+	Column string
+	// RetentionMonths define quantos meses completos manter; zero desliga o descarte.
+	RetentionMonths int
+}
+
+// Partition é uma partição mensal existente e o volume que ela guarda.
+type Partition struct {
+	Table string    `json:"table"`
+	Name  string    `json:"name"`
+	Month time.Time `json:"month"`
+	Rows  int64     `json:"rows_estimate"`
+	Bytes int64     `json:"bytes"`
+}
+
+// Dropped registra uma partição descartada pela retenção.
+type Dropped struct {
+	Table string    `json:"table"`
+	Name  string    `json:"name"`
+	Month time.Time `json:"month"`
+	Rows  int64     `json:"rows"`
+}
+
+// Report resume uma rodada de manutenção.
+type Report struct {
+	Created []string  `json:"created"`
+	Dropped []Dropped `json:"dropped"`
+}
+
+// Manager cria partições futuras e descarta as que saíram da janela de retenção.
+type Manager struct {
+	pool    *pgxpool.Pool
+	tables  []Table
+	premake int
+	logger  zerolog.Logger
+	now     func() time.Time
+}
+
+// New configura o gerenciador; premake é o número de meses à frente mantidos prontos.
+func New(pool *pgxpool.Pool, tables []Table, premake int, logger zerolog.Logger) *Manager {
+	if premake < 1 {
+		premake = 1
+	}
+	return &Manager{pool: pool, tables: tables, premake: premake, logger: logger, now: time.Now}
+}
+
+// conn devolve o pool fixado no contexto (db.Conn) ou o principal: cada cluster tem as próprias
+// partições, e o job de manutenção roda em todos com db.Resolver.PorCluster.
+func (m *Manager) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, m.pool)
+}
+
+var partitionName = regexp.MustCompile(`_p(\d{4})_(\d{2})$`)
+
+// Maintain garante as partições do mês corrente até premake meses à frente e descarta
+// as anteriores à retenção. Partições criadas absorvem linhas que caíram na DEFAULT.
+func (m *Manager) Maintain(ctx context.Context) error {
+	report, err := m.Run(ctx)
+	for _, name := range report.Created {
+		m.logger.Info().Str("partition", name).Msg("partições: criada")
+	}
+	for _, d := range report.Dropped {
+		m.logger.Info().Str("partition", d.Name).Int64("rows", d.Rows).Msg("partições: descartada por retenção")
+	}
+	return err
+}
+
+// Run executa a manutenção e devolve o que foi criado e descartado.
+func (m *Manager) Run(ctx context.Context) (Report, error) {
+	report := Report{Created: []string{}, Dropped: []Dropped{}}
+	current := monthStart(m.now())
+
+	for _, table := range m.tables {
+		for i := 0; i <= m.premake; i++ {
+			month := current.AddDate(0, i, 0)
+			var created bool
+			if err := m.conn(ctx).QueryRow(ctx, `SELECT particao_mensal_garantir($1, $2, $3)`, table.Name, table.Column, month).Scan(&created); err != nil {
+				return report, fmt.Errorf("partições: garantir %s %s: %w", table.Name, month.Format("2006-01"), err)
+			}
+			if created {
+				report.Created = append(report.Created, fmt.Sprintf("%s_p%s", table.Name, month.Format("2006_01")))
+			}
+		}
+
+		if table.RetentionMonths <= 0 {
+			continue
+		}
+		dropped, err := m.DropBefore(ctx, table.Name, current.AddDate(0, -table.RetentionMonths, 0))
+		report.Dropped = append(report.Dropped, dropped...)
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// DropBefore descarta as partições mensais da tabela inteiramente anteriores a cutoff e devolve
+// quantas linhas cada uma guardava. Linhas anteriores ao corte em partições parciais ficam.
+func (m *Manager) DropBefore(ctx context.Context, table string, cutoff time.Time) ([]Dropped, error) {
+	parts, err := m.list(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	var dropped []Dropped
+	for _, part := range parts {
+		if part.Month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		rows, err := m.drop(ctx, table, part.Name)
+		if err != nil {
+			return dropped, fmt.Errorf("partições: descartar %s: %w", part.Name, err)
+		}
+		dropped = append(dropped, Dropped{Table: table, Name: part.Name, Month: part.Month, Rows: rows})
+	}
+	return dropped, nil
+}
+
+// List devolve as partições mensais de todas as tabelas gerenciadas.
+func (m *Manager) List(ctx context.Context) ([]Partition, error) {
+	list := make([]Partition, 0)
+	for _, table := range m.tables {
+		parts, err := m.list(ctx, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, parts...)
+	}
+	return list, nil
+}
+
+func (m *Manager) list(ctx context.Context, table string) ([]Partition, error) {
+	rows, err := m.conn(ctx).Query(ctx, `
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint, pg_total_relation_size(c.oid)
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)
+		ORDER BY c.relname
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parts []Partition
+	for rows.Next() {
+		part := Partition{Table: table}
+		if err := rows.Scan(&part.Name, &part.Rows, &part.Bytes); err != nil {
+			return nil, err
+		}
+		month, ok := parseMonth(part.Name)
+		if !ok {
+			continue
+		}
+		part.Month = month
+		parts = append(parts, part)
+	}
+	return parts, rows.Err()
+}
+
+func (m *Manager) drop(ctx context.Context, table, name string) (int64, error) {
+	tx, err := m.conn(ctx).BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var rows int64
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT count(*) FROM %s`, pgx.Identifier{name}.Sanitize())).Scan(&rows); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`, pgx.Identifier{table}.Sanitize(), pgx.Identifier{name}.Sanitize())); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`DROP TABLE %s`, pgx.Identifier{name}.Sanitize())); err != nil {
+		return 0, err
+	}
+	return rows, tx.Commit(ctx)
+}
+
+func parseMonth(name string) (time.Time, bool) {
+	match := partitionName.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	month, err := time.Parse("2006-01", match[1]+"-"+match[2])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,132 @@
+// Package pdf gera documentos PDF simples (texto em Helvetica, linhas e retângulos) sem
+// dependências externas, suficientes para guias, comprovantes e relatórios impressos.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Dimensões de uma página A4 em pontos.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font identifica uma das fontes padrão embutidas em todo leitor de PDF.
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+var fontNames = [...]string{Regular: "F1", Bold: "F2"}
+
+// Document acumula as páginas até a serialização.
+type Document struct {
+	pages []*Page
+}
+
+// Page registra os operadores de desenho de uma página; a origem fica no canto inferior esquerdo.
+type Page struct {
+	width   float64
+	height  float64
+	content bytes.Buffer
+}
+
+// New cria um documento vazio.
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage adiciona uma página A4 em retrato.
+func (d *Document) AddPage() *Page {
+	page := &Page{width: A4Width, height: A4Height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Height devolve a altura da página, útil para posicionar a partir do topo.
+func (p *Page) Height() float64 {
+	return p.height
+}
+
+// Text escreve uma linha na posição (x, y) da linha de base.
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", fontNames[font], num(size), num(x), num(y), escape(text))
+}
+
+// Rect preenche um retângulo em preto a partir do canto inferior esquerdo.
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// StrokeRect desenha apenas o contorno do retângulo.
+func (p *Page) StrokeRect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n", num(lineWidth), num(x), num(y), num(w), num(h))
+}
+
+// Line traça um segmento de reta.
+func (p *Page) Line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(lineWidth), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Bytes serializa o documento com tabela xref válida.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objetos fixos: 1 catálogo, 2 árvore de páginas, 3 e 4 fontes; páginas começam no 5.
+	kids := new(bytes.Buffer)
+	for i := range d.pages {
+		fmt.Fprintf(kids, "%d 0 R ", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids.Bytes()), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(page.width), num(page.height), 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// escape converte para WinAnsi (Latin-1 cobre os acentos do português) e protege os delimitadores.
+func escape(text string) string {
+	var out bytes.Buffer
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			out.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			out.WriteByte('?')
+		default:
+			out.WriteByte(byte(r))
+		}
+	}
+	return out.String()
+}
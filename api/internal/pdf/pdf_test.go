@@ -0,0 +1,40 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestBytes_XrefApontaParaObjetos(t *testing.T) {
+	doc := New()
+	page := doc.AddPage()
+	page.Text(40, 800, Bold, 12, "Guia (2ª via) de São Paulo \\ teste")
+	page.Rect(40, 700, 10, 50)
+	doc.AddPage().Line(0, 0, 10, 10, 1)
+	out := doc.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing header or trailer")
+	}
+	if !bytes.Contains(out, []byte(`(Guia \(2`+"\xaa"+` via\) de S`+"\xe3"+`o Paulo \\ teste)`)) {
+		t.Fatal("text not escaped as WinAnsi")
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n0 9\n")) {
+		t.Fatalf("startxref does not point to xref table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 8 {
+		t.Fatalf("expected 8 objects, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(out[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Fatalf("xref entry %d points to wrong offset", i+1)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package presence
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const keyPrefix = "presence:"
+
+// Snapshot é a fotografia dos usuários com heartbeat ativo.
+type Snapshot struct {
+	Total     int64            `json:"total"`
+	Audiences map[string]int64 `json:"audiences"`
+	Tenants   []TenantOnline   `json:"tenants"`
+	SemTenant map[string]int64 `json:"sem_tenant"`
+	Janela    string           `json:"janela"`
+	GeradoEm  time.Time        `json:"gerado_em"`
+}
+
+// TenantOnline conta usuários simultâneos de uma prefeitura por audiência.
+type TenantOnline struct {
+	TenantID  uuid.UUID        `json:"tenant_id"`
+	Total     int64            `json:"total"`
+	Audiences map[string]int64 `json:"audiences"`
+}
+
+// Tracker registra heartbeats em chaves Redis por usuário (presence:{audience}:{subject}) com TTL;
+// a chave guarda a prefeitura do usuário para agregação. Cada réplica limita a escrita a um
+// toque por usuário a cada throttle, e a prefeitura resolvida fica em memória pelo mesmo TTL.
+type Tracker struct {
+	redis    *redis.Client
+	pool     *pgxpool.Pool
+	ttl      time.Duration
+	throttle time.Duration
+	logger   zerolog.Logger
+
+	mu      sync.Mutex
+	touched map[string]touchEntry
+
+	snapMu  sync.Mutex
+	snap    *Snapshot
+	snapTTL time.Duration
+}
+
+type touchEntry struct {
+	at     time.Time
+	tenant string
+}
+
+// NewTracker cria o rastreador; ttl é a janela em que um usuário conta como online.
+func NewTracker(redisClient *redis.Client, pool *pgxpool.Pool, ttl time.Duration, logger zerolog.Logger) *Tracker {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &Tracker{
+		redis:    redisClient,
+		pool:     pool,
+		ttl:      ttl,
+		throttle: ttl / 5,
+		logger:   logger,
+		touched:  make(map[string]touchEntry),
+		snapTTL:  10 * time.Second,
+	}
+}
+
+// Touch registra atividade do usuário. É barato em chamadas repetidas: só grava no Redis
+// quando o último toque desta réplica passou do throttle.
+func (t *Tracker) Touch(ctx context.Context, audience, subject string) {
+	if subject == "" || audience == "" {
+		return
+	}
+	id := audience + ":" + subject
+	now := time.Now()
+
+	t.mu.Lock()
+	entry, ok := t.touched[id]
+	if ok && now.Sub(entry.at) < t.throttle {
+		t.mu.Unlock()
+		return
+	}
+	t.touched[id] = touchEntry{at: now, tenant: entry.tenant}
+	if !ok {
+		for k, e := range t.touched {
+			if now.Sub(e.at) > t.ttl {
+				delete(t.touched, k)
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	tenant := entry.tenant
+	if !ok {
+		tenant = t.resolveTenant(ctx, audience, subject)
+		t.mu.Lock()
+		if current, exists := t.touched[id]; exists {
+			current.tenant = tenant
+			t.touched[id] = current
+		}
+		t.mu.Unlock()
+	}
+
+	if err := t.redis.Set(ctx, keyPrefix+id, tenant, t.ttl).Err(); err != nil {
+		t.logger.Warn().Err(err).Msg("presença: falha ao registrar heartbeat")
+	}
+}
+
+// Snapshot agrega os heartbeats vivos por prefeitura e audiência, com cache curto para não
+// varrer o Redis a cada atualização dos painéis.
+func (t *Tracker) Snapshot(ctx context.Context) (Snapshot, error) {
+	t.snapMu.Lock()
+	defer t.snapMu.Unlock()
+	if t.snap != nil && time.Since(t.snap.GeradoEm) < t.snapTTL {
+		return *t.snap, nil
+	}
+
+	snap := Snapshot{
+		Audiences: map[string]int64{},
+		Tenants:   []TenantOnline{},
+		SemTenant: map[string]int64{},
+		Janela:    t.ttl.String(),
+		GeradoEm:  time.Now().UTC(),
+	}
+	porTenant := map[uuid.UUID]*TenantOnline{}
+
+	iter := t.redis.Scan(ctx, 0, keyPrefix+"*", 1000).Iterator()
+	var keys []string
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		values, err := t.redis.MGet(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			tenant, ok := value.(string)
+			if !ok {
+				continue // expirou entre o SCAN e o MGET
+			}
+			audience := strings.SplitN(strings.TrimPrefix(keys[i], keyPrefix), ":", 2)[0]
+			snap.Total++
+			snap.Audiences[audience]++
+			tenantID, err := uuid.Parse(tenant)
+			if err != nil {
+				snap.SemTenant[audience]++
+				continue
+			}
+			item := porTenant[tenantID]
+			if item == nil {
+				item = &TenantOnline{TenantID: tenantID, Audiences: map[string]int64{}}
+				porTenant[tenantID] = item
+			}
+			item.Total++
+			item.Audiences[audience]++
+		}
+		keys = keys[:0]
+		return nil
+	}
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			if err := flush(); err != nil {
+				return Snapshot{}, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return Snapshot{}, err
+	}
+	if err := flush(); err != nil {
+		return Snapshot{}, err
+	}
+
+	for _, item := range porTenant {
+		snap.Tenants = append(snap.Tenants, *item)
+	}
+	sort.Slice(snap.Tenants, func(i, j int) bool { return snap.Tenants[i].Total > snap.Tenants[j].Total })
+
+	t.snap = &snap
+	return snap, nil
+}
+
+// resolveTenant encontra a prefeitura do usuário de backoffice pelas secretarias ou escolas
+// vinculadas. Cidadãos e equipe SaaS não pertencem a uma prefeitura.
+func (t *Tracker) resolveTenant(ctx context.Context, audience, subject string) string {
+	if audience != "backoffice" || t.pool == nil {
+		return ""
+	}
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return ""
+	}
+	var tenantID uuid.UUID
+	err = t.pool.QueryRow(ctx, `
+		SELECT tenant_id FROM (
+			SELECT s.tenant_id
+			FROM usuarios_secretarias us
+			JOIN secretarias s ON s.id = us.secretaria_id
+			WHERE us.usuario_id = $1 AND s.tenant_id IS NOT NULL
+			UNION ALL
+			SELECT e.tenant_id
+			FROM professores_turmas pt
+			JOIN turmas tu ON tu.id = pt.turma_id
+			JOIN escolas e ON e.id = tu.escola_id
+			WHERE pt.professor_id = $1 AND e.tenant_id IS NOT NULL
+		) v
+		LIMIT 1
+	`, userID).Scan(&tenantID)
+	if err != nil {
+		return ""
+	}
+	return tenantID.String()
+}
+
+// OnlineByTenant resume o snapshot em usuários online por prefeitura.
+func (t *Tracker) OnlineByTenant(ctx context.Context) (map[uuid.UUID]int64, error) {
+	snap, err := t.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	online := make(map[uuid.UUID]int64, len(snap.Tenants))
+	for _, item := range snap.Tenants {
+		online[item.TenantID] = item.Total
+	}
+	return online, nil
+}
@@ -0,0 +1,136 @@
+package procurement
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/monitor"
+)
+
+// Alerter avisa a equipe SaaS sobre documentos prestes a vencer ou vencidos. Cada documento é
+// alertado uma vez na janela de aviso e outra ao vencer.
+type Alerter struct {
+	pool     *pgxpool.Pool
+	notifier monitor.Notifier
+	window   time.Duration
+	logger   zerolog.Logger
+}
+
+// NewAlerter cria o verificador de vencimentos.
+func NewAlerter(pool *pgxpool.Pool, notifier monitor.Notifier, window time.Duration, logger zerolog.Logger) *Alerter {
+	return &Alerter{pool: pool, notifier: notifier, window: window, logger: logger}
+}
+
+type expiring struct {
+	id        uuid.UUID
+	tenant    string
+	category  string
+	title     string
+	number    *string
+	expiresAt time.Time
+	expired   bool
+}
+
+// RunOnce envia os alertas pendentes agrupados por tenant.
+func (a *Alerter) RunOnce(ctx context.Context) error {
+	if a.notifier == nil {
+		return nil
+	}
+	today := dateOnly(time.Now())
+	rows, err := a.pool.Query(ctx, `
+        SELECT d.id, t.display_name, d.category, d.title, d.number, d.expires_at, d.expires_at < $1
+        FROM saas_procurement_documents d
+        JOIN tenants t ON t.id = d.tenant_id
+        WHERE d.expires_at IS NOT NULL
+          AND ((d.expires_at < $1 AND d.expired_notified_at IS NULL)
+            OR (d.expires_at >= $1 AND d.expires_at <= $2 AND d.expiry_warned_at IS NULL))
+        ORDER BY t.display_name, d.expires_at
+    `, today, today.Add(a.window))
+	if err != nil {
+		return err
+	}
+	var pending []expiring
+	for rows.Next() {
+		var item expiring
+		if err := rows.Scan(&item.id, &item.tenant, &item.category, &item.title, &item.number, &item.expiresAt, &item.expired); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	for _, msg := range expiryMessages(pending) {
+		if err := a.notifier.Notify(ctx, msg); err != nil {
+			// Sem marcar os documentos, o próximo ciclo tenta de novo.
+			return fmt.Errorf("procurement: notificar vencimentos: %w", err)
+		}
+	}
+
+	var warned, expired []uuid.UUID
+	for _, item := range pending {
+		if item.expired {
+			expired = append(expired, item.id)
+		} else {
+			warned = append(warned, item.id)
+		}
+	}
+	if _, err := a.pool.Exec(ctx, `
+        UPDATE saas_procurement_documents
+        SET expiry_warned_at = CASE WHEN id = ANY($1) THEN now() ELSE expiry_warned_at END,
+            expired_notified_at = CASE WHEN id = ANY($2) THEN now() ELSE expired_notified_at END
+        WHERE id = ANY($1) OR id = ANY($2)
+    `, warned, expired); err != nil {
+		return err
+	}
+
+	a.logger.Info().Int("warned", len(warned)).Int("expired", len(expired)).Msg("alertas de vencimento enviados")
+	return nil
+}
+
+func expiryMessages(items []expiring) []monitor.AlertMessage {
+	byTenant := make(map[string][]expiring)
+	for _, item := range items {
+		byTenant[item.tenant] = append(byTenant[item.tenant], item)
+	}
+	tenants := make([]string, 0, len(byTenant))
+	for name := range byTenant {
+		tenants = append(tenants, name)
+	}
+	sort.Strings(tenants)
+
+	msgs := make([]monitor.AlertMessage, 0, len(tenants))
+	for _, name := range tenants {
+		msg := monitor.AlertMessage{Title: "Documentos da licitação vencendo — " + name, Severity: "warning"}
+		lines := make([]string, 0, len(byTenant[name]))
+		for _, item := range byTenant[name] {
+			label := item.title
+			if item.number != nil && *item.number != "" {
+				label += " nº " + *item.number
+			}
+			state := "vence em"
+			if item.expired {
+				state = "venceu em"
+				msg.Severity = "critical"
+				msg.Title = "Documentos da licitação vencidos — " + name
+			}
+			lines = append(lines, fmt.Sprintf("• [%s] %s %s %s", item.category, label, state, item.expiresAt.Format("02/01/2006")))
+		}
+		msg.Text = strings.Join(lines, "\n")
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
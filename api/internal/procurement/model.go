@@ -0,0 +1,149 @@
+// Package procurement guarda os documentos da licitação de cada contrato de tenant (edital, ata,
+// empenhos...), confere o checklist obrigatório e alerta sobre vencimentos.
+package procurement
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CategoryEdital          = "edital"
+	CategoryTermoReferencia = "termo_referencia"
+	CategoryAta             = "ata"
+	CategoryContrato        = "contrato"
+	CategoryEmpenho         = "empenho"
+	CategoryAditivo         = "aditivo"
+	CategoryCertidao        = "certidao"
+	CategoryOutros          = "outros"
+)
+
+var (
+	// ErrInvalidCategory indica categoria fora da lista suportada.
+	ErrInvalidCategory = errors.New("categoria de documento inválida")
+	// ErrNotFound indica documento inexistente no cofre do tenant.
+	ErrNotFound = errors.New("documento não encontrado")
+)
+
+// Category descreve uma categoria do cofre e se ela faz parte do checklist obrigatório.
+type Category struct {
+	Code     string `json:"code"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+// Categories segue a ordem do processo licitatório.
+var Categories = []Category{
+	{Code: CategoryEdital, Label: "Edital", Required: true},
+	{Code: CategoryTermoReferencia, Label: "Termo de referência"},
+	{Code: CategoryAta, Label: "Ata de julgamento/homologação", Required: true},
+	{Code: CategoryContrato, Label: "Contrato assinado", Required: true},
+	{Code: CategoryEmpenho, Label: "Nota de empenho", Required: true},
+	{Code: CategoryAditivo, Label: "Termo aditivo"},
+	{Code: CategoryCertidao, Label: "Certidões de regularidade"},
+	{Code: CategoryOutros, Label: "Outros"},
+}
+
+// NormalizeCategory padroniza e valida a categoria informada.
+func NormalizeCategory(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, c := range Categories {
+		if c.Code == value {
+			return value, nil
+		}
+	}
+	return "", ErrInvalidCategory
+}
+
+// Document é um arquivo do cofre.
+type Document struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	Category    string     `json:"category"`
+	Title       string     `json:"title"`
+	Number      *string    `json:"number,omitempty"`
+	Amount      *float64   `json:"amount,omitempty"`
+	IssuedAt    *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Notes       *string    `json:"notes,omitempty"`
+	FileURL     *string    `json:"-"`
+	FileKey     *string    `json:"-"`
+	DownloadURL *string    `json:"download_url,omitempty"`
+	ContentType *string    `json:"content_type,omitempty"`
+	SizeBytes   int64      `json:"size_bytes"`
+	UploadedAt  time.Time  `json:"uploaded_at"`
+}
+
+// Expired informa se o documento já venceu na data de referência.
+func (d Document) Expired(now time.Time) bool {
+	return d.ExpiresAt != nil && d.ExpiresAt.Before(dateOnly(now))
+}
+
+// ChecklistItem é a situação de uma categoria do cofre para o contrato.
+type ChecklistItem struct {
+	Category  string     `json:"category"`
+	Label     string     `json:"label"`
+	Required  bool       `json:"required"`
+	Documents int        `json:"documents"`
+	Valid     int        `json:"valid"`
+	ExpiresAt *time.Time `json:"next_expiry,omitempty"`
+	Status    string     `json:"status"`
+}
+
+const (
+	StatusOK       = "ok"
+	StatusMissing  = "missing"
+	StatusExpired  = "expired"
+	StatusExpiring = "expiring"
+	StatusOptional = "optional"
+)
+
+// Checklist confronta os documentos com as categorias. Uma categoria obrigatória só fica ok com
+// ao menos um documento vigente; vencimento dentro de window marca a categoria como expiring.
+func Checklist(docs []Document, now time.Time, window time.Duration) ([]ChecklistItem, bool) {
+	complete := true
+	items := make([]ChecklistItem, 0, len(Categories))
+	limit := dateOnly(now).Add(window)
+	for _, c := range Categories {
+		item := ChecklistItem{Category: c.Code, Label: c.Label, Required: c.Required}
+		for _, d := range docs {
+			if d.Category != c.Code {
+				continue
+			}
+			item.Documents++
+			if d.Expired(now) {
+				continue
+			}
+			item.Valid++
+			if d.ExpiresAt != nil && (item.ExpiresAt == nil || d.ExpiresAt.Before(*item.ExpiresAt)) {
+				expires := *d.ExpiresAt
+				item.ExpiresAt = &expires
+			}
+		}
+
+		switch {
+		case item.Documents == 0 && c.Required:
+			item.Status = StatusMissing
+		case item.Documents == 0:
+			item.Status = StatusOptional
+		case item.Valid == 0:
+			item.Status = StatusExpired
+		case item.ExpiresAt != nil && !item.ExpiresAt.After(limit):
+			item.Status = StatusExpiring
+		default:
+			item.Status = StatusOK
+		}
+		if c.Required && (item.Status == StatusMissing || item.Status == StatusExpired) {
+			complete = false
+		}
+		items = append(items, item)
+	}
+	return items, complete
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,55 @@
+package procurement
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) *time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestChecklistStatuses(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	docs := []Document{
+		{Category: CategoryEdital},
+		{Category: CategoryContrato, ExpiresAt: date(2027, 12, 31)},
+		{Category: CategoryEmpenho, ExpiresAt: date(2026, 10, 1)},
+		{Category: CategoryEmpenho, ExpiresAt: date(2026, 11, 5)},
+		{Category: CategoryCertidao, ExpiresAt: date(2026, 9, 1)},
+	}
+
+	items, complete := Checklist(docs, now, 30*24*time.Hour)
+	if complete {
+		t.Fatal("sem ata o checklist não pode estar completo")
+	}
+
+	want := map[string]string{
+		CategoryEdital:   StatusOK,
+		CategoryAta:      StatusMissing,
+		CategoryContrato: StatusOK,
+		CategoryEmpenho:  StatusExpiring,
+		CategoryCertidao: StatusExpired,
+		CategoryAditivo:  StatusOptional,
+	}
+	for _, item := range items {
+		if status, ok := want[item.Category]; ok && item.Status != status {
+			t.Errorf("%s: status %s, esperado %s", item.Category, item.Status, status)
+		}
+	}
+
+	// Certidão vencida é opcional e não bloqueia; com a ata o checklist fecha.
+	if _, complete := Checklist(append(docs, Document{Category: CategoryAta}), now, 30*24*time.Hour); !complete {
+		t.Fatal("checklist deveria estar completo com a ata")
+	}
+}
+
+func TestNormalizeCategory(t *testing.T) {
+	if c, err := NormalizeCategory(" Empenho "); err != nil || c != CategoryEmpenho {
+		t.Fatalf("NormalizeCategory = %q, %v", c, err)
+	}
+	if _, err := NormalizeCategory("boleto"); err != ErrInvalidCategory {
+		t.Fatalf("esperado ErrInvalidCategory, veio %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package procurement
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const documentColumns = `id, tenant_id, category, title, number, amount::float8, issued_at, expires_at, notes,
+        file_url, file_key, content_type, size_bytes, uploaded_at`
+
+// Repository acessa o cofre de documentos.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório do cofre.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// DocumentInput reúne os metadados de um novo documento.
+type DocumentInput struct {
+	TenantID    uuid.UUID
+	Category    string
+	Title       string
+	Number      *string
+	Amount      *float64
+	IssuedAt    *time.Time
+	ExpiresAt   *time.Time
+	Notes       *string
+	FileURL     string
+	FileKey     string
+	ContentType string
+	SizeBytes   int64
+	UploadedBy  *uuid.UUID
+}
+
+// List devolve os documentos do tenant, opcionalmente filtrando a categoria.
+func (r *Repository) List(ctx context.Context, tenantID uuid.UUID, category string) ([]Document, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+documentColumns+`
+        FROM saas_procurement_documents
+        WHERE tenant_id = $1 AND ($2 = '' OR category = $2)
+        ORDER BY category, issued_at DESC NULLS LAST, uploaded_at DESC
+    `, tenantID, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := make([]Document, 0)
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// Create registra o documento já enviado ao armazenamento.
+func (r *Repository) Create(ctx context.Context, in DocumentInput) (Document, error) {
+	row := r.pool.QueryRow(ctx, `
+        INSERT INTO saas_procurement_documents (tenant_id, category, title, number, amount, issued_at, expires_at, notes,
+            file_url, file_key, content_type, size_bytes, uploaded_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING `+documentColumns,
+		in.TenantID, in.Category, in.Title, in.Number, in.Amount, in.IssuedAt, in.ExpiresAt, in.Notes,
+		in.FileURL, in.FileKey, in.ContentType, in.SizeBytes, in.UploadedBy)
+	return scanDocument(row)
+}
+
+// Delete remove o registro do documento; o objeto no armazenamento segue a política do bucket.
+func (r *Repository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM saas_procurement_documents WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanDocument(row pgx.Row) (Document, error) {
+	var d Document
+	err := row.Scan(&d.ID, &d.TenantID, &d.Category, &d.Title, &d.Number, &d.Amount, &d.IssuedAt, &d.ExpiresAt, &d.Notes,
+		&d.FileURL, &d.FileKey, &d.ContentType, &d.SizeBytes, &d.UploadedAt)
+	return d, err
+}
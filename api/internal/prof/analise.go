@@ -0,0 +1,177 @@
+package prof
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// Limiares clássicos de análise de itens usados para sinalizar questões suspeitas.
+const (
+	itemMuitoFacil         = 0.9
+	itemMuitoDificil       = 0.2
+	itemDiscriminacaoBaixa = 0.2
+)
+
+// RespostaAluno é a alternativa marcada por um aluno numa questão.
+type RespostaAluno struct {
+	MatriculaID uuid.UUID
+	QuestaoID   uuid.UUID
+	Alternativa *int16
+}
+
+// AnaliseQuestao resume dificuldade e discriminação de uma questão objetiva.
+type AnaliseQuestao struct {
+	QuestaoID        uuid.UUID `json:"questao_id"`
+	Ordem            int       `json:"ordem"`
+	Enunciado        string    `json:"enunciado"`
+	Objetiva         bool      `json:"objetiva"`
+	Respondentes     int       `json:"respondentes"`
+	Acertos          int       `json:"acertos"`
+	PercentualAcerto *float64  `json:"percentual_acerto,omitempty"`
+	PontoBisserial   *float64  `json:"ponto_bisserial,omitempty"`
+	Alternativas     []int     `json:"alternativas"`
+	EmBranco         int       `json:"em_branco"`
+	Alertas          []string  `json:"alertas"`
+}
+
+// AnaliseAvaliacao é o relatório de itens de uma avaliação corrigida.
+type AnaliseAvaliacao struct {
+	Avaliacao     Avaliacao        `json:"avaliacao"`
+	Participantes int              `json:"participantes"`
+	MediaAcertos  float64          `json:"media_acertos"`
+	Questoes      []AnaliseQuestao `json:"questoes"`
+}
+
+// analisarItens calcula, por questão objetiva, o percentual de acerto (índice de dificuldade) e o
+// ponto-bisserial corrigido, correlacionando o acerto com a pontuação do aluno nas demais questões.
+// Questões sem resposta correta cadastrada (discursivas) só recebem a contagem de respostas.
+func analisarItens(questoes []AvaliacaoQuestao, respostas []RespostaAluno) ([]AnaliseQuestao, int, float64) {
+	marcadas := make(map[uuid.UUID]map[uuid.UUID]*int16)
+	var alunos []uuid.UUID
+	for _, resp := range respostas {
+		porQuestao, ok := marcadas[resp.MatriculaID]
+		if !ok {
+			porQuestao = make(map[uuid.UUID]*int16)
+			marcadas[resp.MatriculaID] = porQuestao
+			alunos = append(alunos, resp.MatriculaID)
+		}
+		porQuestao[resp.QuestaoID] = resp.Alternativa
+	}
+
+	acertou := func(aluno uuid.UUID, q AvaliacaoQuestao) bool {
+		alt := marcadas[aluno][q.ID]
+		return q.Correta != nil && alt != nil && *alt == *q.Correta
+	}
+
+	totais := make(map[uuid.UUID]int, len(alunos))
+	for _, aluno := range alunos {
+		for _, q := range questoes {
+			if acertou(aluno, q) {
+				totais[aluno]++
+			}
+		}
+	}
+
+	var media float64
+	if len(alunos) > 0 {
+		soma := 0
+		for _, total := range totais {
+			soma += total
+		}
+		media = float64(soma) / float64(len(alunos))
+	}
+
+	itens := make([]AnaliseQuestao, 0, len(questoes))
+	for idx, q := range questoes {
+		item := AnaliseQuestao{
+			QuestaoID:    q.ID,
+			Ordem:        idx + 1,
+			Enunciado:    q.Enunciado,
+			Objetiva:     q.Correta != nil,
+			Respondentes: len(alunos),
+			Alternativas: make([]int, len(q.Alternativas)),
+			Alertas:      []string{},
+		}
+
+		acertos := make([]bool, len(alunos))
+		restos := make([]float64, len(alunos))
+		for i, aluno := range alunos {
+			alt := marcadas[aluno][q.ID]
+			if alt == nil || int(*alt) < 0 || int(*alt) >= len(item.Alternativas) {
+				item.EmBranco++
+			} else {
+				item.Alternativas[*alt]++
+			}
+			acertos[i] = acertou(aluno, q)
+			restos[i] = float64(totais[aluno])
+			if acertos[i] {
+				item.Acertos++
+				restos[i]--
+			}
+		}
+
+		if item.Objetiva && len(alunos) > 0 {
+			p := float64(item.Acertos) / float64(len(alunos))
+			item.PercentualAcerto = floatPtr(math.Round(p*1000) / 10)
+			item.PontoBisserial = pontoBisserial(acertos, restos)
+
+			switch {
+			case p >= itemMuitoFacil:
+				item.Alertas = append(item.Alertas, "MUITO_FACIL")
+			case p <= itemMuitoDificil:
+				item.Alertas = append(item.Alertas, "MUITO_DIFICIL")
+			}
+			if item.PontoBisserial != nil {
+				switch {
+				case *item.PontoBisserial < 0:
+					item.Alertas = append(item.Alertas, "DISCRIMINACAO_NEGATIVA")
+				case *item.PontoBisserial < itemDiscriminacaoBaixa:
+					item.Alertas = append(item.Alertas, "DISCRIMINACAO_BAIXA")
+				}
+			}
+		}
+		itens = append(itens, item)
+	}
+	return itens, len(alunos), media
+}
+
+// pontoBisserial devolve nil quando não há variação no acerto ou na pontuação dos alunos.
+func pontoBisserial(acertos []bool, escores []float64) *float64 {
+	n := float64(len(escores))
+	if n < 2 {
+		return nil
+	}
+	var soma, somaAcerto float64
+	var nAcerto int
+	for i, escore := range escores {
+		soma += escore
+		if acertos[i] {
+			somaAcerto += escore
+			nAcerto++
+		}
+	}
+	if nAcerto == 0 || nAcerto == len(escores) {
+		return nil
+	}
+
+	media := soma / n
+	var variancia float64
+	for _, escore := range escores {
+		variancia += (escore - media) * (escore - media)
+	}
+	desvio := math.Sqrt(variancia / n)
+	if desvio == 0 {
+		return nil
+	}
+
+	p := float64(nAcerto) / n
+	mediaAcerto := somaAcerto / float64(nAcerto)
+	mediaErro := (soma - somaAcerto) / (n - float64(nAcerto))
+	r := (mediaAcerto - mediaErro) / desvio * math.Sqrt(p*(1-p))
+	return floatPtr(math.Round(r*1000) / 1000)
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,43 @@
+package prof
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAnalisarItens(t *testing.T) {
+	zero, um := int16(0), int16(1)
+	q1 := AvaliacaoQuestao{ID: uuid.New(), Alternativas: []string{"a", "b"}, Correta: &zero}
+	q2 := AvaliacaoQuestao{ID: uuid.New(), Alternativas: []string{"a", "b"}, Correta: &um}
+	q3 := AvaliacaoQuestao{ID: uuid.New(), Alternativas: []string{"a", "b"}, Correta: &zero}
+	q4 := AvaliacaoQuestao{ID: uuid.New(), Alternativas: []string{"a", "b"}, Correta: &um}
+	alunos := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+
+	// Alunos 0 e 1 acertam q1, q2 e q4; q3 é acertada só pelos alunos com pior desempenho.
+	marcar := [][4]int16{{0, 1, 1, 1}, {0, 1, 1, 1}, {1, 0, 0, 0}, {1, 0, 0, 0}}
+	var respostas []RespostaAluno
+	for i, aluno := range alunos {
+		for j, q := range []AvaliacaoQuestao{q1, q2, q3, q4} {
+			alt := marcar[i][j]
+			respostas = append(respostas, RespostaAluno{MatriculaID: aluno, QuestaoID: q.ID, Alternativa: &alt})
+		}
+	}
+
+	itens, participantes, _ := analisarItens([]AvaliacaoQuestao{q1, q2, q3, q4}, respostas)
+	if participantes != 4 {
+		t.Fatalf("expected 4 participantes, got %d", participantes)
+	}
+	if itens[0].PercentualAcerto == nil || *itens[0].PercentualAcerto != 50 {
+		t.Fatalf("expected 50%% acerto, got %v", itens[0].PercentualAcerto)
+	}
+	if itens[0].PontoBisserial == nil || *itens[0].PontoBisserial <= 0 {
+		t.Fatalf("expected positive discrimination for q1, got %v", itens[0].PontoBisserial)
+	}
+	if itens[2].PontoBisserial == nil || *itens[2].PontoBisserial >= 0 {
+		t.Fatalf("expected negative discrimination for q3, got %v", itens[2].PontoBisserial)
+	}
+	if len(itens[2].Alertas) == 0 || itens[2].Alertas[0] != "DISCRIMINACAO_NEGATIVA" {
+		t.Fatalf("expected q3 flagged, got %v", itens[2].Alertas)
+	}
+}
@@ -0,0 +1,180 @@
+package prof
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// duracaoMaximaMinutos limita o tempo de prova por aluno a um turno.
+const duracaoMaximaMinutos = 300
+
+// AplicacaoOnline configura a avaliação para ser respondida pelos alunos. Inicio e Fim delimitam
+// a janela em que é possível começar e entregar; DuracaoMinutos limita cada tentativa.
+type AplicacaoOnline struct {
+	Online         bool       `json:"online"`
+	Inicio         *time.Time `json:"inicio,omitempty"`
+	Fim            *time.Time `json:"fim,omitempty"`
+	DuracaoMinutos *int       `json:"duracao_minutos,omitempty"`
+}
+
+// TentativaResumo é a situação de um aluno da turma na avaliação online.
+type TentativaResumo struct {
+	AlunoID    uuid.UUID  `json:"aluno_id"`
+	Nome       string     `json:"nome"`
+	Matricula  *string    `json:"matricula,omitempty"`
+	IniciadaEm *time.Time `json:"iniciada_em,omitempty"`
+	Prazo      *time.Time `json:"prazo,omitempty"`
+	EntregueEm *time.Time `json:"entregue_em,omitempty"`
+	Acertos    *int       `json:"acertos,omitempty"`
+	Objetivas  *int       `json:"objetivas,omitempty"`
+	Pendentes  *int       `json:"pendentes,omitempty"`
+	Nota       *float64   `json:"nota,omitempty"`
+}
+
+func (s *Service) ConfigurarAplicacaoOnline(ctx context.Context, professorID, avaliacaoID uuid.UUID, input AplicacaoOnline) error {
+	if input.Online {
+		if input.Fim == nil {
+			return errors.New("fim obrigatório para avaliação online")
+		}
+		if input.Inicio != nil && !input.Fim.After(*input.Inicio) {
+			return errors.New("fim deve ser posterior ao início")
+		}
+		if input.DuracaoMinutos != nil && (*input.DuracaoMinutos < 1 || *input.DuracaoMinutos > duracaoMaximaMinutos) {
+			return errors.New("duração inválida")
+		}
+	}
+	return s.repo.UpdateAplicacaoOnline(ctx, professorID, avaliacaoID, input)
+}
+
+func (s *Service) ListTentativas(ctx context.Context, professorID, avaliacaoID uuid.UUID) ([]TentativaResumo, error) {
+	avaliacao, _, err := s.repo.GetAvaliacao(ctx, professorID, avaliacaoID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListTentativas(ctx, avaliacao)
+}
+
+// UpdateAplicacaoOnline só altera avaliações ainda não encerradas. Desligar o modo online mantém
+// as tentativas já feitas.
+func (r *Repository) UpdateAplicacaoOnline(ctx context.Context, professorID, avaliacaoID uuid.UUID, input AplicacaoOnline) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	cmd, err := r.conn(ctx).Exec(ctx, `
+        UPDATE avaliacoes
+        SET online = $1, inicio = COALESCE($2, inicio), fim = $3, duracao_minutos = $4
+        WHERE id = $5 AND status <> 'ENCERRADA' AND turma_id IN (
+            SELECT turma_id FROM professores_turmas WHERE professor_id = $6
+        ) AND `+db.TurmaNoTenant("turma_id", "$7")+`
+    `, input.Online, input.Inicio, input.Fim, input.DuracaoMinutos, avaliacaoID, professorID, httpmiddleware.TenantScope(ctx))
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListTentativas lista todos os alunos ativos da turma, com ou sem tentativa.
+func (r *Repository) ListTentativas(ctx context.Context, avaliacao Avaliacao) ([]TentativaResumo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.id, a.nome, a.matricula, t.iniciada_em, t.prazo, t.entregue_em,
+               t.acertos, t.objetivas, t.pendentes, t.nota
+        FROM matriculas m
+        JOIN alunos a ON a.id = m.aluno_id
+        LEFT JOIN aval_tentativas t ON t.matricula_id = m.id AND t.avaliacao_id = $1
+        WHERE m.turma_id = $2 AND m.ano_letivo = $3 AND m.ativo = TRUE
+        ORDER BY a.nome
+    `, avaliacao.ID, avaliacao.TurmaID, avaliacao.AnoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]TentativaResumo, 0)
+	for rows.Next() {
+		var item TentativaResumo
+		if err := rows.Scan(&item.AlunoID, &item.Nome, &item.Matricula, &item.IniciadaEm, &item.Prazo, &item.EntregueEm,
+			&item.Acertos, &item.Objetivas, &item.Pendentes, &item.Nota); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func (h *Handler) configurarAplicacaoOnline(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	avaliacaoID, err := uuid.Parse(chi.URLParam(r, "avaliacaoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "avaliação inválida", nil)
+		return
+	}
+
+	var payload AplicacaoOnline
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	if err := h.service.ConfigurarAplicacaoOnline(r.Context(), professorID, avaliacaoID, payload); err != nil {
+		switch err {
+		case ErrNotFound:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "avaliação não encontrada ou encerrada", nil)
+		case ErrForbidden:
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso", nil)
+		default:
+			writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"aplicacao": payload})
+}
+
+func (h *Handler) listTentativas(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	avaliacaoID, err := uuid.Parse(chi.URLParam(r, "avaliacaoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "avaliação inválida", nil)
+		return
+	}
+
+	tentativas, err := h.service.ListTentativas(r.Context(), professorID, avaliacaoID)
+	if err != nil {
+		switch err {
+		case ErrNotFound:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "avaliação não encontrada", nil)
+		case ErrForbidden:
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso", nil)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar tentativas", nil)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"tentativas": tentativas})
+}
@@ -0,0 +1,294 @@
+package prof
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/pdf"
+)
+
+// boletimMediaMinima é a nota de corte da rede, a mesma usada na visão da gestão escolar.
+const boletimMediaMinima = 6.0
+
+// BoletimTurma consolida notas e frequência de cada aluno da turma. Com bimestre zero as notas
+// são as médias anuais por disciplina.
+type BoletimTurma struct {
+	TurmaID       uuid.UUID      `json:"turma_id"`
+	Turma         string         `json:"turma"`
+	Escola        *string        `json:"escola,omitempty"`
+	AnoLetivo     int            `json:"ano_letivo"`
+	Bimestre      int            `json:"bimestre"`
+	PeriodoInicio time.Time      `json:"periodo_inicio"`
+	PeriodoFim    time.Time      `json:"periodo_fim"`
+	Disciplinas   []string       `json:"disciplinas"`
+	Alunos        []BoletimAluno `json:"alunos"`
+}
+
+// BoletimAluno é uma linha do boletim; disciplinas sem nota lançada ficam fora do mapa.
+type BoletimAluno struct {
+	AlunoID      uuid.UUID          `json:"aluno_id"`
+	Nome         string             `json:"nome"`
+	Matricula    *string            `json:"matricula,omitempty"`
+	Notas        map[string]float64 `json:"notas"`
+	Media        *float64           `json:"media,omitempty"`
+	Faltas       int                `json:"faltas"`
+	Justificadas int                `json:"justificadas"`
+	Aulas        int                `json:"aulas"`
+	Frequencia   *float64           `json:"frequencia,omitempty"`
+	AbaixoMedia  bool               `json:"abaixo_media"`
+}
+
+// NotaBoletim é uma nota lançada para a matrícula do aluno na turma.
+type NotaBoletim struct {
+	AlunoID    uuid.UUID
+	Disciplina string
+	Bimestre   int
+	Nota       float64
+}
+
+// periodoBimestre divide o ano letivo em quatro partes iguais; bimestre zero devolve o ano todo.
+// O fim é inclusivo até o último instante do dia.
+func periodoBimestre(inicio, fim time.Time, bimestre int) (time.Time, time.Time) {
+	inicio = time.Date(inicio.Year(), inicio.Month(), inicio.Day(), 0, 0, 0, 0, time.UTC)
+	fim = time.Date(fim.Year(), fim.Month(), fim.Day(), 0, 0, 0, 0, time.UTC)
+	if bimestre < 1 || bimestre > 4 {
+		return inicio, fim.Add(24*time.Hour - time.Nanosecond)
+	}
+	dias := int(fim.Sub(inicio).Hours()/24) + 1
+	de := inicio.AddDate(0, 0, dias*(bimestre-1)/4)
+	ate := inicio.AddDate(0, 0, dias*bimestre/4)
+	return de, ate.Add(-time.Nanosecond)
+}
+
+// montarBoletimTurma cruza a frequência de cada aluno com as notas. No boletim anual a nota da
+// disciplina é a média dos bimestres lançados; a média do aluno é a média das disciplinas.
+func montarBoletimTurma(frequencia []FrequenciaAluno, notas []NotaBoletim, bimestre int) ([]string, []BoletimAluno) {
+	type acumulado struct {
+		soma float64
+		n    int
+	}
+	porAluno := map[uuid.UUID]map[string]*acumulado{}
+	disciplinas := map[string]bool{}
+	for _, n := range notas {
+		if bimestre > 0 && n.Bimestre != bimestre {
+			continue
+		}
+		disciplinas[n.Disciplina] = true
+		if porAluno[n.AlunoID] == nil {
+			porAluno[n.AlunoID] = map[string]*acumulado{}
+		}
+		acc := porAluno[n.AlunoID][n.Disciplina]
+		if acc == nil {
+			acc = &acumulado{}
+			porAluno[n.AlunoID][n.Disciplina] = acc
+		}
+		acc.soma += n.Nota
+		acc.n++
+	}
+
+	nomes := make([]string, 0, len(disciplinas))
+	for d := range disciplinas {
+		nomes = append(nomes, d)
+	}
+	sort.Strings(nomes)
+
+	alunos := make([]BoletimAluno, 0, len(frequencia))
+	for _, f := range frequencia {
+		aluno := BoletimAluno{
+			AlunoID:      f.AlunoID,
+			Nome:         f.Nome,
+			Matricula:    f.Matricula,
+			Notas:        map[string]float64{},
+			Faltas:       f.Faltas,
+			Justificadas: f.Justificadas,
+			Aulas:        f.Total,
+		}
+		var soma float64
+		for disciplina, acc := range porAluno[f.AlunoID] {
+			nota := arredondarNota(acc.soma / float64(acc.n))
+			aluno.Notas[disciplina] = nota
+			soma += nota
+		}
+		if len(aluno.Notas) > 0 {
+			media := arredondarNota(soma / float64(len(aluno.Notas)))
+			aluno.Media = &media
+			aluno.AbaixoMedia = media < boletimMediaMinima
+		}
+		if f.Total > 0 {
+			freq := float64(f.Total-f.Faltas-f.Justificadas) / float64(f.Total)
+			aluno.Frequencia = &freq
+		}
+		alunos = append(alunos, aluno)
+	}
+	return nomes, alunos
+}
+
+func arredondarNota(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// boletimLinhasPorPagina cabe numa página A4 em retrato com o cabeçalho e a legenda.
+const boletimLinhasPorPagina = 48
+
+// RenderBoletimPDF imprime o boletim da turma em tabela, com as disciplinas abreviadas no
+// cabeçalho e a legenda no rodapé de cada página.
+func RenderBoletimPDF(b BoletimTurma) []byte {
+	doc := pdf.New()
+	const margin = 36.0
+	width := pdf.A4Width - 2*margin
+
+	const colNome = 190.0
+	const colResumo = 40.0
+	colDisc := 0.0
+	if len(b.Disciplinas) > 0 {
+		colDisc = math.Min(40, (width-colNome-3*colResumo)/float64(len(b.Disciplinas)))
+	}
+	siglas := siglasDisciplinas(b.Disciplinas)
+
+	titulo := "Boletim escolar - " + b.Turma
+	periodo := fmt.Sprintf("Ano letivo %d", b.AnoLetivo)
+	if b.Bimestre > 0 {
+		periodo = fmt.Sprintf("%dº bimestre de %d", b.Bimestre, b.AnoLetivo)
+	}
+	periodo += " (" + b.PeriodoInicio.Format("02/01/2006") + " a " + b.PeriodoFim.Format("02/01/2006") + ")"
+
+	paginas := (len(b.Alunos) + boletimLinhasPorPagina - 1) / boletimLinhasPorPagina
+	if paginas == 0 {
+		paginas = 1
+	}
+	for p := 0; p < paginas; p++ {
+		page := doc.AddPage()
+		y := page.Height() - 50
+		if b.Escola != nil {
+			page.Text(margin, y, pdf.Bold, 13, *b.Escola)
+			y -= 17
+		}
+		page.Text(margin, y, pdf.Bold, 11, titulo)
+		y -= 14
+		page.Text(margin, y, pdf.Regular, 9, periodo)
+		page.Text(margin+width-60, y, pdf.Regular, 8, fmt.Sprintf("Página %d/%d", p+1, paginas))
+		y -= 22
+
+		page.Line(margin, y+10, margin+width, y+10, 0.5)
+		page.Text(margin, y, pdf.Bold, 7, "Aluno")
+		x := margin + colNome
+		for _, sigla := range siglas {
+			page.Text(x, y, pdf.Bold, 7, sigla)
+			x += colDisc
+		}
+		page.Text(x, y, pdf.Bold, 7, "Média")
+		page.Text(x+colResumo, y, pdf.Bold, 7, "Faltas")
+		page.Text(x+2*colResumo, y, pdf.Bold, 7, "Freq.")
+		y -= 4
+		page.Line(margin, y, margin+width, y, 0.5)
+		y -= 10
+
+		fim := min((p+1)*boletimLinhasPorPagina, len(b.Alunos))
+		for _, aluno := range b.Alunos[p*boletimLinhasPorPagina : fim] {
+			nome := aluno.Nome
+			if aluno.Matricula != nil && *aluno.Matricula != "" {
+				nome = *aluno.Matricula + " " + nome
+			}
+			page.Text(margin, y, pdf.Regular, 7, cortarTexto(nome, 44))
+			x := margin + colNome
+			for _, disciplina := range b.Disciplinas {
+				if nota, ok := aluno.Notas[disciplina]; ok {
+					font := pdf.Regular
+					if nota < boletimMediaMinima {
+						font = pdf.Bold
+					}
+					page.Text(x, y, font, 7, formatarNota(nota))
+				} else {
+					page.Text(x, y, pdf.Regular, 7, "-")
+				}
+				x += colDisc
+			}
+			if aluno.Media != nil {
+				font := pdf.Regular
+				if aluno.AbaixoMedia {
+					font = pdf.Bold
+				}
+				page.Text(x, y, font, 7, formatarNota(*aluno.Media))
+			} else {
+				page.Text(x, y, pdf.Regular, 7, "-")
+			}
+			page.Text(x+colResumo, y, pdf.Regular, 7, strconv.Itoa(aluno.Faltas))
+			if aluno.Frequencia != nil {
+				page.Text(x+2*colResumo, y, pdf.Regular, 7, strconv.FormatFloat(*aluno.Frequencia*100, 'f', 0, 64)+"%")
+			} else {
+				page.Text(x+2*colResumo, y, pdf.Regular, 7, "-")
+			}
+			y -= 13
+		}
+		page.Line(margin, y+9, margin+width, y+9, 0.5)
+
+		y = 60
+		legenda := make([]string, 0, len(b.Disciplinas))
+		for i, disciplina := range b.Disciplinas {
+			legenda = append(legenda, siglas[i]+" = "+disciplina)
+		}
+		for _, linha := range quebrarLegenda(legenda, 110) {
+			page.Text(margin, y, pdf.Regular, 7, linha)
+			y -= 10
+		}
+		page.Text(margin, y-2, pdf.Regular, 7, fmt.Sprintf("Notas em negrito estão abaixo da média %s.", formatarNota(boletimMediaMinima)))
+	}
+	return doc.Bytes()
+}
+
+// siglasDisciplinas abrevia cada disciplina em até quatro letras, numerando siglas repetidas.
+func siglasDisciplinas(disciplinas []string) []string {
+	siglas := make([]string, len(disciplinas))
+	vistas := map[string]int{}
+	for i, d := range disciplinas {
+		runes := []rune(strings.ToUpper(strings.TrimSpace(d)))
+		if len(runes) > 4 {
+			runes = runes[:4]
+		}
+		sigla := string(runes)
+		vistas[sigla]++
+		if vistas[sigla] > 1 {
+			sigla += strconv.Itoa(vistas[sigla])
+		}
+		siglas[i] = sigla
+	}
+	return siglas
+}
+
+func quebrarLegenda(itens []string, limite int) []string {
+	var linhas []string
+	atual := ""
+	for _, item := range itens {
+		switch {
+		case atual == "":
+			atual = item
+		case len([]rune(atual))+3+len([]rune(item)) > limite:
+			linhas = append(linhas, atual)
+			atual = item
+		default:
+			atual += " · " + item
+		}
+	}
+	if atual != "" {
+		linhas = append(linhas, atual)
+	}
+	return linhas
+}
+
+func formatarNota(v float64) string {
+	return strings.ReplaceAll(strconv.FormatFloat(v, 'f', 1, 64), ".", ",")
+}
+
+func cortarTexto(value string, limite int) string {
+	runes := []rune(value)
+	if len(runes) <= limite {
+		return value
+	}
+	return string(runes[:limite-3]) + "..."
+}
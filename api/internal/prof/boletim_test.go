@@ -0,0 +1,73 @@
+package prof
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPeriodoBimestre(t *testing.T) {
+	inicio := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+	fim := time.Date(2026, 12, 18, 0, 0, 0, 0, time.UTC)
+
+	de, _ := periodoBimestre(inicio, fim, 1)
+	if !de.Equal(inicio) {
+		t.Fatalf("início do 1º bimestre = %v", de)
+	}
+	_, ate := periodoBimestre(inicio, fim, 4)
+	if want := fim.Add(24*time.Hour - time.Nanosecond); !ate.Equal(want) {
+		t.Fatalf("fim do 4º bimestre = %v, want %v", ate, want)
+	}
+	_, ate2 := periodoBimestre(inicio, fim, 2)
+	de3, _ := periodoBimestre(inicio, fim, 3)
+	if !de3.Equal(ate2.Add(time.Nanosecond)) {
+		t.Fatalf("bimestres não são contíguos: %v / %v", ate2, de3)
+	}
+	de, ate = periodoBimestre(inicio, fim, 0)
+	if !de.Equal(inicio) || ate.Before(fim) {
+		t.Fatalf("ano todo = %v..%v", de, ate)
+	}
+}
+
+func TestMontarBoletimTurma(t *testing.T) {
+	ana, bruno := uuid.New(), uuid.New()
+	frequencia := []FrequenciaAluno{
+		{AlunoID: ana, Nome: "Ana", Presentes: 18, Faltas: 2, Total: 20},
+		{AlunoID: bruno, Nome: "Bruno"},
+	}
+	notas := []NotaBoletim{
+		{AlunoID: ana, Disciplina: "Português", Bimestre: 1, Nota: 6},
+		{AlunoID: ana, Disciplina: "Português", Bimestre: 2, Nota: 8},
+		{AlunoID: ana, Disciplina: "Matemática", Bimestre: 2, Nota: 4},
+	}
+
+	disciplinas, alunos := montarBoletimTurma(frequencia, notas, 2)
+	if len(disciplinas) != 2 || disciplinas[0] != "Matemática" {
+		t.Fatalf("disciplinas = %v", disciplinas)
+	}
+	if got := alunos[0].Notas["Português"]; got != 8 {
+		t.Fatalf("nota do bimestre = %v", got)
+	}
+	if alunos[0].Media == nil || *alunos[0].Media != 6 || alunos[0].AbaixoMedia {
+		t.Fatalf("média = %+v", alunos[0])
+	}
+	if alunos[0].Frequencia == nil || *alunos[0].Frequencia != 0.9 {
+		t.Fatalf("frequência = %v", alunos[0].Frequencia)
+	}
+	if alunos[1].Media != nil || alunos[1].Frequencia != nil || len(alunos[1].Notas) != 0 {
+		t.Fatalf("aluno sem lançamentos = %+v", alunos[1])
+	}
+
+	_, anual := montarBoletimTurma(frequencia, notas, 0)
+	if got := anual[0].Notas["Português"]; got != 7 {
+		t.Fatalf("média anual = %v", got)
+	}
+	if anual[0].Media == nil || *anual[0].Media != 5.5 || !anual[0].AbaixoMedia {
+		t.Fatalf("média anual do aluno = %+v", anual[0])
+	}
+
+	if siglas := siglasDisciplinas([]string{"Matemática", "Matemática Financeira", "Arte"}); siglas[1] != "MATE2" || siglas[2] != "ARTE" {
+		t.Fatalf("siglas = %v", siglas)
+	}
+}
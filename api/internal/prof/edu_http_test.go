@@ -1,9 +1,11 @@
 package prof
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -18,33 +20,38 @@ import (
 )
 
 type stubService struct {
-	overview     *Overview
-	turmas       []Turma
-	alunos       []Aluno
-	err          error
-	alunosErr    error
-	chamada      *ChamadaResponse
-	chamadaErr   error
-	salvarErr    error
-	diario       []AlunoDiarioEntrada
-	diarioErr    error
-	diarioEntry  AlunoDiarioEntrada
-	avaliacoes   []Avaliacao
-	avaliacao    Avaliacao
-	questoes     []AvaliacaoQuestao
-	avaliacaoErr error
-	statusErr    error
-	notas        []NotaResumo
-	notasErr     error
-	materiais    []Material
-	materialErr  error
-	agenda       []AgendaItem
-	frequencia   []FrequenciaAluno
-	freqErr      error
-	relAval      []RelatorioAvaliacao
-	relAvalErr   error
-	analytics    DashboardAnalytics
-	live         []LivePresence
+	overview      *Overview
+	turmas        []Turma
+	alunos        []Aluno
+	err           error
+	alunosErr     error
+	chamada       *ChamadaResponse
+	chamadaErr    error
+	salvarErr     error
+	diario        []AlunoDiarioEntrada
+	diarioErr     error
+	diarioEntry   AlunoDiarioEntrada
+	avaliacoes    []Avaliacao
+	avaliacao     Avaliacao
+	questoes      []AvaliacaoQuestao
+	avaliacaoErr  error
+	statusErr     error
+	notas         []NotaResumo
+	notasErr      error
+	materiais     []Material
+	materialErr   error
+	agenda        []AgendaItem
+	frequencia    []FrequenciaAluno
+	freqErr       error
+	relAval       []RelatorioAvaliacao
+	relAvalErr    error
+	analytics     DashboardAnalytics
+	live          []LivePresence
+	notificacoes  []Notificacao
+	emprestimos   []Emprestimo
+	emprestimoErr error
+	importacao    ImportacaoNotas
+	boletim       BoletimTurma
 }
 
 func (s *stubService) GetOverview(_ context.Context, _ uuid.UUID) (*Overview, error) {
@@ -55,8 +62,8 @@ func (s *stubService) ListTurmas(_ context.Context, _ uuid.UUID) ([]Turma, error
 	return s.turmas, s.err
 }
 
-func (s *stubService) ListAlunosByTurma(_ context.Context, _ uuid.UUID, _ uuid.UUID) ([]Aluno, error) {
-	return s.alunos, s.alunosErr
+func (s *stubService) ListAlunosByTurma(_ context.Context, _ uuid.UUID, _ uuid.UUID, params ListaParams) (Pagina[Aluno], error) {
+	return Pagina[Aluno]{Itens: s.alunos, Total: len(s.alunos), Limit: params.Limit, Offset: params.Offset}, s.alunosErr
 }
 
 func (s *stubService) GetChamada(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time, _ string) (*ChamadaResponse, error) {
@@ -70,8 +77,8 @@ func (s *stubService) SalvarChamada(_ context.Context, _ uuid.UUID, _ uuid.UUID,
 	return uuid.New(), s.salvarErr
 }
 
-func (s *stubService) ListAlunoDiario(_ context.Context, _ uuid.UUID, _ uuid.UUID) ([]AlunoDiarioEntrada, error) {
-	return s.diario, s.diarioErr
+func (s *stubService) ListAlunoDiario(_ context.Context, _ uuid.UUID, _ uuid.UUID, params ListaParams) (Pagina[AlunoDiarioEntrada], error) {
+	return Pagina[AlunoDiarioEntrada]{Itens: s.diario, Total: len(s.diario), Limit: params.Limit, Offset: params.Offset}, s.diarioErr
 }
 
 func (s *stubService) CreateAlunoDiario(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ string) (AlunoDiarioEntrada, error) {
@@ -86,8 +93,8 @@ func (s *stubService) DeleteAlunoDiario(_ context.Context, _ uuid.UUID, _ uuid.U
 	return s.diarioErr
 }
 
-func (s *stubService) ListAvaliacoes(_ context.Context, _ uuid.UUID, _ uuid.UUID) ([]Avaliacao, error) {
-	return s.avaliacoes, s.err
+func (s *stubService) ListAvaliacoes(_ context.Context, _ uuid.UUID, _ uuid.UUID, params ListaParams) (Pagina[Avaliacao], error) {
+	return Pagina[Avaliacao]{Itens: s.avaliacoes, Total: len(s.avaliacoes), Limit: params.Limit, Offset: params.Offset}, s.err
 }
 
 func (s *stubService) CreateAvaliacao(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ CreateAvaliacaoInput) (uuid.UUID, error) {
@@ -106,22 +113,73 @@ func (s *stubService) LancarNotas(_ context.Context, _ uuid.UUID, _ uuid.UUID, _
 	return s.salvarErr
 }
 
-func (s *stubService) ListarNotas(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ int) ([]NotaResumo, error) {
+func (s *stubService) ConfigurarAplicacaoOnline(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ AplicacaoOnline) error {
+	return s.statusErr
+}
+
+func (s *stubService) ListTentativas(_ context.Context, _ uuid.UUID, _ uuid.UUID) ([]TentativaResumo, error) {
+	return nil, s.avaliacaoErr
+}
+
+func (s *stubService) ListarNotas(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ int, _ int) ([]NotaResumo, error) {
 	return s.notas, s.notasErr
 }
 
-func (s *stubService) ListMateriais(_ context.Context, _ uuid.UUID, _ uuid.UUID) ([]Material, error) {
-	return s.materiais, s.materialErr
+func (s *stubService) ImportarNotas(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ ImportarNotasInput) (ImportacaoNotas, error) {
+	return s.importacao, s.notasErr
+}
+
+func (s *stubService) ModeloNotas(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ string, _ int, _ int) ([]NotaResumo, error) {
+	return s.notas, s.notasErr
+}
+
+func (s *stubService) AnalisarAvaliacao(_ context.Context, _ uuid.UUID, _ uuid.UUID) (AnaliseAvaliacao, error) {
+	return AnaliseAvaliacao{Avaliacao: s.avaliacao}, s.avaliacaoErr
+}
+
+func (s *stubService) TurmaAnalytics(_ context.Context, _ uuid.UUID, turmaID uuid.UUID, filtro AnalyticsFiltro) (TurmaAnalytics, error) {
+	return TurmaAnalytics{TurmaID: turmaID, Bimestre: filtro.Bimestre, From: filtro.From, To: filtro.To}, s.err
+}
+
+func (s *stubService) AlunoAnalytics(_ context.Context, _ uuid.UUID, turmaID, alunoID uuid.UUID, filtro AnalyticsFiltro) (AlunoAnalytics, error) {
+	return AlunoAnalytics{AlunoID: alunoID, TurmaID: turmaID, Bimestre: filtro.Bimestre}, s.err
 }
 
-func (s *stubService) CreateMaterial(_ context.Context, _ uuid.UUID, _ uuid.UUID, titulo string, descricao, url *string) (Material, error) {
+func (s *stubService) ListMateriais(_ context.Context, _ uuid.UUID, _ uuid.UUID, params ListaParams) (Pagina[Material], error) {
+	return Pagina[Material]{Itens: s.materiais, Total: len(s.materiais), Limit: params.Limit, Offset: params.Offset}, s.materialErr
+}
+
+func (s *stubService) CreateMaterial(_ context.Context, _ uuid.UUID, _ uuid.UUID, titulo string, descricao, url *string, quantidade *int) (Material, error) {
 	if s.materialErr != nil {
 		return Material{}, s.materialErr
 	}
 	if len(titulo) == 0 {
 		return Material{}, errors.New("titulo obrigatório")
 	}
-	return Material{ID: uuid.New(), Titulo: titulo, Descricao: descricao, URL: url, CriadoEm: time.Now()}, nil
+	return Material{ID: uuid.New(), Titulo: titulo, Descricao: descricao, URL: url, Quantidade: quantidade, CriadoEm: time.Now()}, nil
+}
+
+func (s *stubService) AtualizarEstoque(_ context.Context, _, _ uuid.UUID, quantidade int) (Material, error) {
+	return Material{ID: uuid.New(), Quantidade: &quantidade}, s.materialErr
+}
+
+func (s *stubService) ListEmprestimos(_ context.Context, _, _ uuid.UUID, _ bool) ([]Emprestimo, error) {
+	return s.emprestimos, s.emprestimoErr
+}
+
+func (s *stubService) Emprestar(_ context.Context, _, materialID uuid.UUID, input EmprestimoInput) (Emprestimo, error) {
+	if s.emprestimoErr != nil {
+		return Emprestimo{}, s.emprestimoErr
+	}
+	return Emprestimo{ID: uuid.New(), MaterialID: materialID, AlunoID: input.AlunoID, Quantidade: input.Quantidade, DevolverAte: input.DevolverAte}, nil
+}
+
+func (s *stubService) Devolver(_ context.Context, _, _ uuid.UUID) error {
+	return s.emprestimoErr
+}
+
+func (s *stubService) ListEmprestimosAtrasados(_ context.Context, _ uuid.UUID) ([]Emprestimo, error) {
+	return s.emprestimos, s.emprestimoErr
 }
 
 func (s *stubService) ListAgenda(_ context.Context, _ uuid.UUID, _ time.Time, _ time.Time) ([]AgendaItem, error) {
@@ -131,21 +189,33 @@ func (s *stubService) ListAgenda(_ context.Context, _ uuid.UUID, _ time.Time, _
 	return s.agenda, nil
 }
 
-func (s *stubService) RelatorioFrequencia(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time, _ time.Time) ([]FrequenciaAluno, error) {
+func (s *stubService) RelatorioFrequencia(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time, _ time.Time, _ int) ([]FrequenciaAluno, error) {
 	return s.frequencia, s.freqErr
 }
 
-func (s *stubService) RelatorioAvaliacoes(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ int) ([]RelatorioAvaliacao, error) {
+func (s *stubService) RelatorioAvaliacoes(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ int, _ int) ([]RelatorioAvaliacao, error) {
 	return s.relAval, s.relAvalErr
 }
 
-func (s *stubService) DashboardAnalytics(_ context.Context, _ uuid.UUID) (DashboardAnalytics, error) {
+func (s *stubService) Boletim(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ int, _ int) (BoletimTurma, error) {
+	return s.boletim, s.err
+}
+
+func (s *stubService) DashboardAnalytics(_ context.Context, _ uuid.UUID, _ int) (DashboardAnalytics, error) {
 	if s.err != nil {
 		return DashboardAnalytics{}, s.err
 	}
 	return s.analytics, nil
 }
 
+func (s *stubService) ListNotificacoes(_ context.Context, _ uuid.UUID, _ bool) ([]Notificacao, error) {
+	return s.notificacoes, s.err
+}
+
+func (s *stubService) MarcarNotificacaoLida(_ context.Context, _, _ uuid.UUID) error {
+	return s.err
+}
+
 func (s *stubService) LivePresence(_ context.Context, _ uuid.UUID) ([]LivePresence, error) {
 	if s.err != nil {
 		return nil, s.err
@@ -353,6 +423,44 @@ func TestHandler_ListNotas_RequiresBimestre(t *testing.T) {
 	}
 }
 
+func TestHandler_BoletimPDF(t *testing.T) {
+	profID := uuid.New()
+	turmaID := uuid.New()
+	svc := &stubService{boletim: BoletimTurma{TurmaID: turmaID, Turma: "5º A", AnoLetivo: 2026, Bimestre: 2,
+		Disciplinas: []string{"Matemática"},
+		Alunos:      []BoletimAluno{{AlunoID: uuid.New(), Nome: "Ana", Notas: map[string]float64{"Matemática": 8}}}}}
+	h := NewHandler(svc)
+	router := chi.NewRouter()
+	h.RegisterRoutes(router)
+
+	for _, tc := range []struct {
+		accept, query, contentType string
+	}{
+		{"", "", "application/json"},
+		{"application/pdf", "", "application/pdf"},
+		{"application/pdf", "?formato=json", "application/json"},
+		{"", "?formato=pdf", "application/pdf"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/turmas/"+turmaID.String()+"/boletim"+tc.query, nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), httpmiddleware.ContextKeySubject, profID.String()))
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		if res.Code != http.StatusOK {
+			t.Fatalf("accept %q query %q: expected 200, got %d", tc.accept, tc.query, res.Code)
+		}
+		if got := res.Header().Get("Content-Type"); got != tc.contentType {
+			t.Fatalf("accept %q query %q: content-type %q, want %q", tc.accept, tc.query, got, tc.contentType)
+		}
+		if tc.contentType == "application/pdf" && !bytes.HasPrefix(res.Body.Bytes(), []byte("%PDF-")) {
+			t.Fatalf("expected PDF body")
+		}
+	}
+}
+
 func TestHandler_ListMateriais(t *testing.T) {
 	profID := uuid.New()
 	turmaID := uuid.New()
@@ -431,3 +539,74 @@ func TestHandler_RelatorioFrequencia_MissingParams(t *testing.T) {
 		t.Fatalf("expected 400, got %d", res.Code)
 	}
 }
+
+func TestHandler_Emprestar_EstoqueInsuficiente(t *testing.T) {
+	profID := uuid.New()
+	materialID := uuid.New()
+	svc := &stubService{emprestimoErr: ErrEstoqueInsuficiente}
+	h := NewHandler(svc)
+	router := chi.NewRouter()
+	h.RegisterRoutes(router)
+
+	body := `{"aluno_id":"` + uuid.NewString() + `","quantidade":2,"devolver_ate":"2030-12-01"}`
+	req := httptest.NewRequest(http.MethodPost, "/materiais/"+materialID.String()+"/emprestimos", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(req.Context(), httpmiddleware.ContextKeySubject, profID.String())
+	req = req.WithContext(ctx)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", res.Code)
+	}
+}
+
+func TestHandler_ImportarNotas_LinhasInvalidas(t *testing.T) {
+	profID := uuid.New()
+	turmaID := uuid.New()
+	svc := &stubService{importacao: ImportacaoNotas{
+		Linhas: 2,
+		Erros:  []ImportacaoErro{{Linha: 3, Matricula: "2024-002", Mensagem: "nota fora do intervalo 0 a 10"}},
+	}}
+	h := NewHandler(svc)
+	router := chi.NewRouter()
+	h.RegisterRoutes(router)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("disciplina", "Matemática")
+	_ = mw.WriteField("bimestre", "1")
+	part, _ := mw.CreateFormFile("arquivo", "notas.csv")
+	_, _ = part.Write([]byte("matricula;nota\n2024-001;8\n2024-002;11\n"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/turmas/"+turmaID.String()+"/notas/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	ctx := context.WithValue(req.Context(), httpmiddleware.ContextKeySubject, profID.String())
+	req = req.WithContext(ctx)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", res.Code)
+	}
+}
+
+func TestHandler_TurmaAnalytics_FiltroInvalido(t *testing.T) {
+	h := NewHandler(&stubService{})
+	router := chi.NewRouter()
+	h.RegisterRoutes(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/turmas/"+uuid.NewString()+"?from=01-02-2024", nil)
+	ctx := context.WithValue(req.Context(), httpmiddleware.ContextKeySubject, uuid.NewString())
+	req = req.WithContext(ctx)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
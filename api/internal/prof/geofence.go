@@ -0,0 +1,151 @@
+package prof
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// geofenceRaioPadrao vale para escolas sem raio próprio cadastrado.
+	geofenceRaioPadrao = 200
+	// atestacaoValidade limita a idade do token de atestação enviado pelo app.
+	atestacaoValidade = 10 * time.Minute
+
+	ExcecaoGeofence  = "GEOFENCE"
+	ExcecaoAtestacao = "ATESTACAO"
+)
+
+// PoliticaChamada é a configuração do tenant (settings.chamada) combinada com a localização da escola.
+type PoliticaChamada struct {
+	GeofenceObrigatorio  bool
+	AtestacaoObrigatoria bool
+	RaioMetros           int
+	Latitude             *float64
+	Longitude            *float64
+}
+
+// ChamadaOrigem descreve de onde a chamada foi enviada pelo app móvel.
+type ChamadaOrigem struct {
+	Latitude       *float64
+	Longitude      *float64
+	PrecisaoMetros *float64
+	Atestacao      string
+	// OverrideMotivo permite registrar a chamada mesmo violando a política; a exceção fica registrada.
+	OverrideMotivo string
+}
+
+// ChamadaViolacao explica por que a chamada não atende à política.
+type ChamadaViolacao struct {
+	Tipo            string   `json:"tipo"`
+	Detalhe         string   `json:"detalhe"`
+	DistanciaMetros *float64 `json:"distancia_metros,omitempty"`
+}
+
+// ChamadaBloqueadaError é devolvido quando há violações e o professor não informou justificativa.
+type ChamadaBloqueadaError struct {
+	Violacoes []ChamadaViolacao
+}
+
+func (e *ChamadaBloqueadaError) Error() string {
+	return "chamada fora da política da prefeitura"
+}
+
+// Attestor verifica o token de integridade do dispositivo enviado junto com a chamada.
+type Attestor interface {
+	Verify(ctx context.Context, token string, now time.Time) error
+}
+
+var errAtestacaoInvalida = errors.New("atestação do dispositivo inválida")
+
+// HMACAttestor aceita tokens "dispositivo.emitidoUnix.assinaturaHex" assinados com HMAC-SHA256
+// pelo serviço que valida Play Integrity/App Attest antes de liberar o envio da chamada.
+type HMACAttestor struct {
+	secret []byte
+}
+
+func NewHMACAttestor(secret string) *HMACAttestor {
+	return &HMACAttestor{secret: []byte(secret)}
+}
+
+func (a *HMACAttestor) Verify(_ context.Context, token string, now time.Time) error {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(a.secret) == 0 || len(parts) != 3 || parts[0] == "" {
+		return errAtestacaoInvalida
+	}
+	emitido, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return errAtestacaoInvalida
+	}
+	idade := now.Sub(time.Unix(emitido, 0))
+	if idade > atestacaoValidade || idade < -time.Minute {
+		return errors.New("atestação do dispositivo expirada")
+	}
+	signature, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return errAtestacaoInvalida
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errAtestacaoInvalida
+	}
+	return nil
+}
+
+// avaliarPolitica confere a origem da chamada contra a política; erroAtestacao é o resultado do Attestor.
+func avaliarPolitica(politica PoliticaChamada, origem ChamadaOrigem, erroAtestacao error) []ChamadaViolacao {
+	var violacoes []ChamadaViolacao
+
+	if politica.GeofenceObrigatorio && politica.Latitude != nil && politica.Longitude != nil {
+		switch {
+		case origem.Latitude == nil || origem.Longitude == nil:
+			violacoes = append(violacoes, ChamadaViolacao{Tipo: ExcecaoGeofence, Detalhe: "localização não informada"})
+		default:
+			distancia := distanciaMetros(*politica.Latitude, *politica.Longitude, *origem.Latitude, *origem.Longitude)
+			raio := float64(politica.RaioMetros)
+			if raio <= 0 {
+				raio = geofenceRaioPadrao
+			}
+			// A imprecisão do GPS conta a favor do professor, até o dobro do raio.
+			if origem.PrecisaoMetros != nil && *origem.PrecisaoMetros > 0 {
+				raio += math.Min(*origem.PrecisaoMetros, raio)
+			}
+			if distancia > raio {
+				arredondada := math.Round(distancia)
+				violacoes = append(violacoes, ChamadaViolacao{
+					Tipo:            ExcecaoGeofence,
+					Detalhe:         fmt.Sprintf("fora da área da escola (%.0fm do limite de %.0fm)", arredondada, raio),
+					DistanciaMetros: &arredondada,
+				})
+			}
+		}
+	}
+
+	if politica.AtestacaoObrigatoria {
+		switch {
+		case strings.TrimSpace(origem.Atestacao) == "":
+			violacoes = append(violacoes, ChamadaViolacao{Tipo: ExcecaoAtestacao, Detalhe: "atestação do dispositivo não informada"})
+		case erroAtestacao != nil:
+			violacoes = append(violacoes, ChamadaViolacao{Tipo: ExcecaoAtestacao, Detalhe: erroAtestacao.Error()})
+		}
+	}
+	return violacoes
+}
+
+// distanciaMetros usa a fórmula de haversine, suficiente para raios de algumas centenas de metros.
+func distanciaMetros(lat1, lng1, lat2, lng2 float64) float64 {
+	const raioTerra = 6371000.0
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * raioTerra * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,55 @@
+package prof
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAvaliarPolitica_Geofence(t *testing.T) {
+	lat, lng := -7.1195, -34.8450
+	politica := PoliticaChamada{GeofenceObrigatorio: true, RaioMetros: 150, Latitude: &lat, Longitude: &lng}
+
+	perto, pertoLng := -7.1200, -34.8452
+	if v := avaliarPolitica(politica, ChamadaOrigem{Latitude: &perto, Longitude: &pertoLng}, nil); len(v) != 0 {
+		t.Fatalf("expected no violation inside geofence, got %+v", v)
+	}
+
+	longe, longeLng := -7.1300, -34.8450
+	v := avaliarPolitica(politica, ChamadaOrigem{Latitude: &longe, Longitude: &longeLng}, nil)
+	if len(v) != 1 || v[0].Tipo != ExcecaoGeofence || v[0].DistanciaMetros == nil {
+		t.Fatalf("expected geofence violation, got %+v", v)
+	}
+
+	if v := avaliarPolitica(politica, ChamadaOrigem{}, nil); len(v) != 1 {
+		t.Fatalf("expected violation without location, got %+v", v)
+	}
+
+	politica.GeofenceObrigatorio = false
+	if v := avaliarPolitica(politica, ChamadaOrigem{}, nil); len(v) != 0 {
+		t.Fatalf("expected policy disabled, got %+v", v)
+	}
+}
+
+func TestHMACAttestor(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	attestor := NewHMACAttestor("segredo")
+	payload := "device-1." + strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("segredo"))
+	mac.Write([]byte(payload))
+	token := payload + "." + hex.EncodeToString(mac.Sum(nil))
+
+	if err := attestor.Verify(context.Background(), token, now.Add(time.Minute)); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if err := attestor.Verify(context.Background(), token, now.Add(time.Hour)); err == nil {
+		t.Fatal("expected expired token to fail")
+	}
+	if err := attestor.Verify(context.Background(), payload+".00", now); err == nil {
+		t.Fatal("expected bad signature to fail")
+	}
+}
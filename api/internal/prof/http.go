@@ -2,9 +2,13 @@ package prof
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -17,32 +21,48 @@ import (
 type ServiceProvider interface {
 	GetOverview(ctx context.Context, professorID uuid.UUID) (*Overview, error)
 	ListTurmas(ctx context.Context, professorID uuid.UUID) ([]Turma, error)
-	ListAlunosByTurma(ctx context.Context, professorID, turmaID uuid.UUID) ([]Aluno, error)
+	ListAlunosByTurma(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Aluno], error)
 	GetChamada(ctx context.Context, professorID, turmaID uuid.UUID, day time.Time, turno string) (*ChamadaResponse, error)
 	SalvarChamada(ctx context.Context, professorID, turmaID uuid.UUID, input SalvarChamadaInput) (uuid.UUID, error)
-	ListAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID) ([]AlunoDiarioEntrada, error)
+	ListAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID, params ListaParams) (Pagina[AlunoDiarioEntrada], error)
 	CreateAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID, conteudo string) (AlunoDiarioEntrada, error)
 	UpdateAlunoDiario(ctx context.Context, professorID, alunoID, anotacaoID uuid.UUID, conteudo string) (AlunoDiarioEntrada, error)
 	DeleteAlunoDiario(ctx context.Context, professorID, alunoID, anotacaoID uuid.UUID) error
-	ListAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID) ([]Avaliacao, error)
+	ListAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Avaliacao], error)
 	CreateAvaliacao(ctx context.Context, professorID, turmaID uuid.UUID, input CreateAvaliacaoInput) (uuid.UUID, error)
 	GetAvaliacaoDetalhes(ctx context.Context, professorID, avaliacaoID uuid.UUID) (Avaliacao, []AvaliacaoQuestao, error)
+	AnalisarAvaliacao(ctx context.Context, professorID, avaliacaoID uuid.UUID) (AnaliseAvaliacao, error)
 	AtualizarStatusAvaliacao(ctx context.Context, professorID, avaliacaoID uuid.UUID, status string) error
 	LancarNotas(ctx context.Context, professorID, avaliacaoID uuid.UUID, input LancarNotasInput) error
-	ListarNotas(ctx context.Context, professorID, turmaID uuid.UUID, bimestre int) ([]NotaResumo, error)
-	ListMateriais(ctx context.Context, professorID, turmaID uuid.UUID) ([]Material, error)
-	CreateMaterial(ctx context.Context, professorID, turmaID uuid.UUID, titulo string, descricao, url *string) (Material, error)
+	ConfigurarAplicacaoOnline(ctx context.Context, professorID, avaliacaoID uuid.UUID, input AplicacaoOnline) error
+	ListTentativas(ctx context.Context, professorID, avaliacaoID uuid.UUID) ([]TentativaResumo, error)
+	ListarNotas(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) ([]NotaResumo, error)
+	ImportarNotas(ctx context.Context, professorID, turmaID uuid.UUID, input ImportarNotasInput) (ImportacaoNotas, error)
+	ModeloNotas(ctx context.Context, professorID, turmaID uuid.UUID, disciplina string, bimestre, anoLetivo int) ([]NotaResumo, error)
+	ListMateriais(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Material], error)
+	CreateMaterial(ctx context.Context, professorID, turmaID uuid.UUID, titulo string, descricao, url *string, quantidade *int) (Material, error)
+	AtualizarEstoque(ctx context.Context, professorID, materialID uuid.UUID, quantidade int) (Material, error)
+	ListEmprestimos(ctx context.Context, professorID, materialID uuid.UUID, apenasAtivos bool) ([]Emprestimo, error)
+	Emprestar(ctx context.Context, professorID, materialID uuid.UUID, input EmprestimoInput) (Emprestimo, error)
+	Devolver(ctx context.Context, professorID, emprestimoID uuid.UUID) error
+	ListEmprestimosAtrasados(ctx context.Context, professorID uuid.UUID) ([]Emprestimo, error)
 	ListAgenda(ctx context.Context, professorID uuid.UUID, from, to time.Time) ([]AgendaItem, error)
-	RelatorioFrequencia(ctx context.Context, professorID, turmaID uuid.UUID, from, to time.Time) ([]FrequenciaAluno, error)
-	RelatorioAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, bimestre int) ([]RelatorioAvaliacao, error)
-	DashboardAnalytics(ctx context.Context, professorID uuid.UUID) (DashboardAnalytics, error)
+	RelatorioFrequencia(ctx context.Context, professorID, turmaID uuid.UUID, from, to time.Time, anoLetivo int) ([]FrequenciaAluno, error)
+	RelatorioAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) ([]RelatorioAvaliacao, error)
+	Boletim(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) (BoletimTurma, error)
+	DashboardAnalytics(ctx context.Context, professorID uuid.UUID, anoLetivo int) (DashboardAnalytics, error)
+	TurmaAnalytics(ctx context.Context, professorID, turmaID uuid.UUID, filtro AnalyticsFiltro) (TurmaAnalytics, error)
+	AlunoAnalytics(ctx context.Context, professorID, turmaID, alunoID uuid.UUID, filtro AnalyticsFiltro) (AlunoAnalytics, error)
 	LivePresence(ctx context.Context, professorID uuid.UUID) ([]LivePresence, error)
 	UpdateProfile(ctx context.Context, professorID uuid.UUID, nome, email string) (*repo.Usuario, error)
+	ListNotificacoes(ctx context.Context, professorID uuid.UUID, apenasNaoLidas bool) ([]Notificacao, error)
+	MarcarNotificacaoLida(ctx context.Context, professorID, notificacaoID uuid.UUID) error
 }
 
 // Handler expõe endpoints REST do professor.
 type Handler struct {
 	service ServiceProvider
+	liveHub *LiveHub
 }
 
 func NewHandler(service ServiceProvider) *Handler {
@@ -62,17 +82,33 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Post("/turmas/{turmaID}/chamada", h.saveChamada)
 	r.Get("/turmas/{turmaID}/materiais", h.listMateriais)
 	r.Post("/turmas/{turmaID}/materiais", h.createMaterial)
+	r.Put("/materiais/{materialID}/estoque", h.atualizarEstoque)
+	r.Get("/materiais/{materialID}/emprestimos", h.listEmprestimos)
+	r.Post("/materiais/{materialID}/emprestimos", h.emprestar)
+	r.Post("/emprestimos/{emprestimoID}/devolver", h.devolver)
+	r.Get("/emprestimos/atrasados", h.listEmprestimosAtrasados)
 	r.Get("/turmas/{turmaID}/avaliacoes", h.listAvaliacoes)
 	r.Post("/turmas/{turmaID}/avaliacoes", h.createAvaliacao)
 	r.Get("/avaliacoes/{avaliacaoID}", h.getAvaliacao)
+	r.Get("/avaliacoes/{avaliacaoID}/analise", h.analisarAvaliacao)
 	r.Post("/avaliacoes/{avaliacaoID}/publicar", h.publicarAvaliacao)
 	r.Post("/avaliacoes/{avaliacaoID}/notas", h.lancarNotas)
+	r.Put("/avaliacoes/{avaliacaoID}/online", h.configurarAplicacaoOnline)
+	r.Get("/avaliacoes/{avaliacaoID}/tentativas", h.listTentativas)
 	r.Get("/turmas/{turmaID}/notas", h.listNotas)
+	r.Get("/turmas/{turmaID}/notas/modelo", h.modeloNotas)
+	r.Post("/turmas/{turmaID}/notas/import", h.importarNotas)
+	r.Get("/turmas/{turmaID}/boletim", h.boletim)
 	r.Get("/agenda", h.listAgenda)
 	r.Get("/relatorios/frequencia", h.relatorioFrequencia)
 	r.Get("/relatorios/avaliacoes", h.relatorioAvaliacoes)
 	r.Get("/dashboard/analytics", h.getAnalytics)
+	r.Get("/dashboard/turmas/{turmaID}", h.getTurmaAnalytics)
+	r.Get("/dashboard/turmas/{turmaID}/alunos/{alunoID}", h.getAlunoAnalytics)
 	r.Get("/dashboard/live", h.getLivePresence)
+	r.Get("/dashboard/live/stream", h.streamLivePresence)
+	r.Get("/notificacoes", h.listNotificacoes)
+	r.Post("/notificacoes/{notificacaoID}/lida", h.marcarNotificacaoLida)
 }
 
 func (h *Handler) getMe(w http.ResponseWriter, r *http.Request) {
@@ -165,7 +201,13 @@ func (h *Handler) listAlunos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	alunos, err := h.service.ListAlunosByTurma(r.Context(), professorID, turmaID)
+	params, err := parseListaParams(r.URL.Query(), ordemAlunos)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "paginação, ordenação ou busca inválida", nil)
+		return
+	}
+
+	alunos, err := h.service.ListAlunosByTurma(r.Context(), professorID, turmaID, params)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -178,7 +220,7 @@ func (h *Handler) listAlunos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"alunos": alunos})
+	writeJSON(w, http.StatusOK, paginaJSON("alunos", alunos))
 }
 
 func (h *Handler) listAlunoDiario(w http.ResponseWriter, r *http.Request) {
@@ -194,7 +236,13 @@ func (h *Handler) listAlunoDiario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	registros, err := h.service.ListAlunoDiario(r.Context(), professorID, alunoID)
+	params, err := parseListaParams(r.URL.Query(), ordemDiario)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "paginação, ordenação ou busca inválida", nil)
+		return
+	}
+
+	registros, err := h.service.ListAlunoDiario(r.Context(), professorID, alunoID, params)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -205,7 +253,7 @@ func (h *Handler) listAlunoDiario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"anotacoes": registros})
+	writeJSON(w, http.StatusOK, paginaJSON("anotacoes", registros))
 }
 
 func (h *Handler) createAlunoDiario(w http.ResponseWriter, r *http.Request) {
@@ -322,6 +370,49 @@ func (h *Handler) deleteAlunoDiario(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) listNotificacoes(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	apenasNaoLidas := r.URL.Query().Get("nao_lidas") == "true"
+	notificacoes, err := h.service.ListNotificacoes(r.Context(), professorID, apenasNaoLidas)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar notificações", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"notificacoes": notificacoes})
+}
+
+func (h *Handler) marcarNotificacaoLida(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	notificacaoID, err := uuid.Parse(chi.URLParam(r, "notificacaoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "notificação inválida", nil)
+		return
+	}
+
+	if err := h.service.MarcarNotificacaoLida(r.Context(), professorID, notificacaoID); err != nil {
+		switch err {
+		case ErrNotFound:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "notificação não encontrada", nil)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível atualizar notificação", nil)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) getChamada(w http.ResponseWriter, r *http.Request) {
 	professorID, err := subjectAsUUID(r)
 	if err != nil {
@@ -387,6 +478,13 @@ func (h *Handler) saveChamada(w http.ResponseWriter, r *http.Request) {
 			Status        *string   `json:"status"`
 			Justificativa *string   `json:"justificativa"`
 		} `json:"itens"`
+		Localizacao *struct {
+			Latitude  float64  `json:"latitude"`
+			Longitude float64  `json:"longitude"`
+			Precisao  *float64 `json:"precisao"`
+		} `json:"localizacao"`
+		Atestacao      string `json:"atestacao"`
+		OverrideMotivo string `json:"override_motivo"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -414,13 +512,31 @@ func (h *Handler) saveChamada(w http.ResponseWriter, r *http.Request) {
 		itens = append(itens, SalvarChamadaItem{AlunoID: item.AlunoID, Status: item.Status, Justificativa: item.Justificativa})
 	}
 
+	origem := ChamadaOrigem{Atestacao: payload.Atestacao, OverrideMotivo: strings.TrimSpace(payload.OverrideMotivo)}
+	if payload.Localizacao != nil {
+		loc := payload.Localizacao
+		if loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180 {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "localização inválida", nil)
+			return
+		}
+		origem.Latitude = &loc.Latitude
+		origem.Longitude = &loc.Longitude
+		origem.PrecisaoMetros = loc.Precisao
+	}
+
 	aulaID, err := h.service.SalvarChamada(r.Context(), professorID, turmaID, SalvarChamadaInput{
 		Data:       day,
 		Turno:      payload.Turno,
 		Disciplina: payload.Disciplina,
 		Itens:      itens,
+		Origem:     origem,
 	})
 	if err != nil {
+		var bloqueada *ChamadaBloqueadaError
+		if errors.As(err, &bloqueada) {
+			writeError(w, http.StatusUnprocessableEntity, "CHAMADA_BLOQUEADA", "chamada fora da política da prefeitura; informe override_motivo para registrar mesmo assim", bloqueada.Violacoes)
+			return
+		}
 		switch err {
 		case ErrForbidden:
 			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso à turma", nil)
@@ -446,7 +562,13 @@ func (h *Handler) listMateriais(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	materiais, err := h.service.ListMateriais(r.Context(), professorID, turmaID)
+	params, err := parseListaParams(r.URL.Query(), ordemMateriais)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "paginação, ordenação ou busca inválida", nil)
+		return
+	}
+
+	materiais, err := h.service.ListMateriais(r.Context(), professorID, turmaID, params)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -457,7 +579,7 @@ func (h *Handler) listMateriais(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"materiais": materiais})
+	writeJSON(w, http.StatusOK, paginaJSON("materiais", materiais))
 }
 
 func (h *Handler) createMaterial(w http.ResponseWriter, r *http.Request) {
@@ -474,9 +596,10 @@ func (h *Handler) createMaterial(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload struct {
-		Titulo    string  `json:"titulo"`
-		Descricao *string `json:"descricao"`
-		URL       *string `json:"url"`
+		Titulo     string  `json:"titulo"`
+		Descricao  *string `json:"descricao"`
+		URL        *string `json:"url"`
+		Quantidade *int    `json:"quantidade"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -484,7 +607,7 @@ func (h *Handler) createMaterial(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	material, err := h.service.CreateMaterial(r.Context(), professorID, turmaID, payload.Titulo, payload.Descricao, payload.URL)
+	material, err := h.service.CreateMaterial(r.Context(), professorID, turmaID, payload.Titulo, payload.Descricao, payload.URL, payload.Quantidade)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -498,6 +621,163 @@ func (h *Handler) createMaterial(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, map[string]any{"material": material})
 }
 
+func (h *Handler) atualizarEstoque(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	materialID, err := uuid.Parse(chi.URLParam(r, "materialID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "material inválido", nil)
+		return
+	}
+
+	var payload struct {
+		Quantidade *int `json:"quantidade"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Quantidade == nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "quantidade obrigatória", nil)
+		return
+	}
+
+	material, err := h.service.AtualizarEstoque(r.Context(), professorID, materialID, *payload.Quantidade)
+	if err != nil {
+		writeEmprestimoError(w, err, "não foi possível atualizar estoque")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"material": material})
+}
+
+func (h *Handler) listEmprestimos(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	materialID, err := uuid.Parse(chi.URLParam(r, "materialID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "material inválido", nil)
+		return
+	}
+
+	apenasAtivos := r.URL.Query().Get("ativos") == "true"
+	emprestimos, err := h.service.ListEmprestimos(r.Context(), professorID, materialID, apenasAtivos)
+	if err != nil {
+		writeEmprestimoError(w, err, "não foi possível carregar empréstimos")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"emprestimos": emprestimos})
+}
+
+func (h *Handler) emprestar(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	materialID, err := uuid.Parse(chi.URLParam(r, "materialID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "material inválido", nil)
+		return
+	}
+
+	var payload struct {
+		AlunoID     string  `json:"aluno_id"`
+		Quantidade  int     `json:"quantidade"`
+		DevolverAte string  `json:"devolver_ate"`
+		Observacao  *string `json:"observacao"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "payload inválido", nil)
+		return
+	}
+
+	alunoID, err := uuid.Parse(payload.AlunoID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "aluno inválido", nil)
+		return
+	}
+	devolverAte, err := time.Parse("2006-01-02", payload.DevolverAte)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "devolver_ate inválido", nil)
+		return
+	}
+
+	emprestimo, err := h.service.Emprestar(r.Context(), professorID, materialID, EmprestimoInput{
+		AlunoID:     alunoID,
+		Quantidade:  payload.Quantidade,
+		DevolverAte: devolverAte,
+		Observacao:  payload.Observacao,
+	})
+	if err != nil {
+		writeEmprestimoError(w, err, "")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"emprestimo": emprestimo})
+}
+
+func (h *Handler) devolver(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	emprestimoID, err := uuid.Parse(chi.URLParam(r, "emprestimoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "empréstimo inválido", nil)
+		return
+	}
+
+	if err := h.service.Devolver(r.Context(), professorID, emprestimoID); err != nil {
+		writeEmprestimoError(w, err, "não foi possível registrar devolução")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listEmprestimosAtrasados(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	emprestimos, err := h.service.ListEmprestimosAtrasados(r.Context(), professorID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar empréstimos atrasados", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"emprestimos": emprestimos})
+}
+
+// writeEmprestimoError traduz erros de estoque/empréstimo; fallback vazio trata o resto como validação.
+func writeEmprestimoError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case ErrForbidden:
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso ao material ou aluno fora da turma", nil)
+	case ErrNotFound:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "registro não encontrado", nil)
+	case ErrEstoqueInsuficiente, ErrMaterialDigital:
+		writeError(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	default:
+		if fallback == "" {
+			writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", fallback, nil)
+	}
+}
+
 func (h *Handler) listAvaliacoes(w http.ResponseWriter, r *http.Request) {
 	professorID, err := subjectAsUUID(r)
 	if err != nil {
@@ -511,7 +791,13 @@ func (h *Handler) listAvaliacoes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	avaliacoes, err := h.service.ListAvaliacoes(r.Context(), professorID, turmaID)
+	params, err := parseListaParams(r.URL.Query(), ordemAvaliacoes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "paginação, ordenação ou busca inválida", nil)
+		return
+	}
+
+	avaliacoes, err := h.service.ListAvaliacoes(r.Context(), professorID, turmaID, params)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -522,7 +808,7 @@ func (h *Handler) listAvaliacoes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"avaliacoes": avaliacoes})
+	writeJSON(w, http.StatusOK, paginaJSON("avaliacoes", avaliacoes))
 }
 
 func (h *Handler) createAvaliacao(w http.ResponseWriter, r *http.Request) {
@@ -629,6 +915,35 @@ func (h *Handler) getAvaliacao(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) analisarAvaliacao(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	avaliacaoID, err := uuid.Parse(chi.URLParam(r, "avaliacaoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "avaliação inválida", nil)
+		return
+	}
+
+	analise, err := h.service.AnalisarAvaliacao(r.Context(), professorID, avaliacaoID)
+	if err != nil {
+		switch err {
+		case ErrNotFound:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "avaliação não encontrada", nil)
+		case ErrForbidden:
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso", nil)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível analisar avaliação", nil)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analise)
+}
+
 func (h *Handler) publicarAvaliacao(w http.ResponseWriter, r *http.Request) {
 	professorID, err := subjectAsUUID(r)
 	if err != nil {
@@ -732,7 +1047,13 @@ func (h *Handler) listNotas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	notas, err := h.service.ListarNotas(r.Context(), professorID, turmaID, bimestre)
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+		return
+	}
+
+	notas, err := h.service.ListarNotas(r.Context(), professorID, turmaID, bimestre, anoLetivo)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -746,6 +1067,207 @@ func (h *Handler) listNotas(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"notas": notas})
 }
 
+// boletim devolve o boletim consolidado da turma. Com Accept: application/pdf (ou formato=pdf)
+// responde o PDF pronto para impressão.
+func (h *Handler) boletim(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	turmaID, err := uuid.Parse(chi.URLParam(r, "turmaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "turma inválida", nil)
+		return
+	}
+
+	bimestre := 0
+	if raw := r.URL.Query().Get("bimestre"); raw != "" {
+		if bimestre, err = strconv.Atoi(raw); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "bimestre inválido", nil)
+			return
+		}
+	}
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+		return
+	}
+
+	boletim, err := h.service.Boletim(r.Context(), professorID, turmaID, bimestre, anoLetivo)
+	if err != nil {
+		switch err {
+		case ErrForbidden:
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso à turma", nil)
+		case ErrNotFound:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "turma não encontrada", nil)
+		default:
+			writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		}
+		return
+	}
+
+	if !querPDF(r) {
+		writeJSON(w, http.StatusOK, map[string]any{"boletim": boletim})
+		return
+	}
+	nome := "boletim-" + strconv.Itoa(boletim.AnoLetivo)
+	if boletim.Bimestre > 0 {
+		nome += "-bimestre-" + strconv.Itoa(boletim.Bimestre)
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+nome+`.pdf"`)
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(RenderBoletimPDF(boletim))
+}
+
+// querPDF decide o formato pelo parâmetro formato ou, sem ele, pelo cabeçalho Accept.
+func querPDF(r *http.Request) bool {
+	if formato := r.URL.Query().Get("formato"); formato != "" {
+		return strings.EqualFold(formato, "pdf")
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(mediaType, "application/pdf") {
+			return true
+		}
+	}
+	return false
+}
+
+// importarNotas recebe a planilha (multipart "arquivo") e grava as notas apenas se todas as linhas forem válidas.
+// Com dry_run=true apenas valida, devolvendo o mesmo relatório por linha.
+func (h *Handler) importarNotas(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	turmaID, err := uuid.Parse(chi.URLParam(r, "turmaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "turma inválida", nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, planilhaMaxBytes+1<<20)
+	if err := r.ParseMultipartForm(planilhaMaxBytes); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "envie a planilha no campo arquivo (até 5MB)", nil)
+		return
+	}
+	file, header, err := r.FormFile("arquivo")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "arquivo é obrigatório", nil)
+		return
+	}
+	defer file.Close()
+
+	conteudo, err := io.ReadAll(io.LimitReader(file, planilhaMaxBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "falha ao ler arquivo", nil)
+		return
+	}
+
+	bimestre, err := strconv.Atoi(r.FormValue("bimestre"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "bimestre inválido", nil)
+		return
+	}
+	var anoLetivo int
+	if raw := r.FormValue("ano_letivo"); raw != "" {
+		anoLetivo, err = strconv.Atoi(raw)
+		if err != nil || anoLetivo < 2000 || anoLetivo > 2100 {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+			return
+		}
+	}
+
+	result, err := h.service.ImportarNotas(r.Context(), professorID, turmaID, ImportarNotasInput{
+		Disciplina:  r.FormValue("disciplina"),
+		Bimestre:    bimestre,
+		AnoLetivo:   anoLetivo,
+		NomeArquivo: header.Filename,
+		Conteudo:    conteudo,
+		DryRun:      r.URL.Query().Get("dry_run") == "true",
+	})
+	if err != nil {
+		switch err {
+		case ErrForbidden:
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso", nil)
+		default:
+			writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		}
+		return
+	}
+
+	if len(result.Erros) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION", "planilha contém linhas inválidas; nenhuma nota foi gravada", result)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// modeloNotas gera o CSV (separador ";", compatível com Excel pt-BR) usado na importação.
+func (h *Handler) modeloNotas(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	turmaID, err := uuid.Parse(chi.URLParam(r, "turmaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "turma inválida", nil)
+		return
+	}
+
+	bimestre, err := strconv.Atoi(r.URL.Query().Get("bimestre"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "bimestre inválido", nil)
+		return
+	}
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+		return
+	}
+
+	notas, err := h.service.ModeloNotas(r.Context(), professorID, turmaID, r.URL.Query().Get("disciplina"), bimestre, anoLetivo)
+	if err != nil {
+		switch err {
+		case ErrForbidden:
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso", nil)
+		default:
+			writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="notas-bimestre-`+strconv.Itoa(bimestre)+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("\xef\xbb\xbf"))
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	_ = cw.Write([]string{"matricula", "nome", "nota", "observacao"})
+	for _, item := range notas {
+		row := []string{"", item.Nome, "", ""}
+		if item.Matricula != nil {
+			row[0] = *item.Matricula
+		}
+		if item.Nota != nil {
+			row[2] = strings.ReplaceAll(strconv.FormatFloat(*item.Nota, 'f', -1, 64), ".", ",")
+		}
+		if item.Observacao != nil {
+			row[3] = *item.Observacao
+		}
+		_ = cw.Write(row)
+	}
+	cw.Flush()
+}
+
 func (h *Handler) listAgenda(w http.ResponseWriter, r *http.Request) {
 	professorID, err := subjectAsUUID(r)
 	if err != nil {
@@ -819,7 +1341,13 @@ func (h *Handler) relatorioFrequencia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	relatorio, err := h.service.RelatorioFrequencia(r.Context(), professorID, turmaID, from, to)
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+		return
+	}
+
+	relatorio, err := h.service.RelatorioFrequencia(r.Context(), professorID, turmaID, from, to, anoLetivo)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -860,7 +1388,13 @@ func (h *Handler) relatorioAvaliacoes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	relatorio, err := h.service.RelatorioAvaliacoes(r.Context(), professorID, turmaID, bimestre)
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+		return
+	}
+
+	relatorio, err := h.service.RelatorioAvaliacoes(r.Context(), professorID, turmaID, bimestre, anoLetivo)
 	if err != nil {
 		switch err {
 		case ErrForbidden:
@@ -881,7 +1415,13 @@ func (h *Handler) getAnalytics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	analytics, err := h.service.DashboardAnalytics(r.Context(), professorID)
+	anoLetivo, err := parseAnoLetivo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+		return
+	}
+
+	analytics, err := h.service.DashboardAnalytics(r.Context(), professorID, anoLetivo)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível carregar indicadores", nil)
 		return
@@ -890,6 +1430,104 @@ func (h *Handler) getAnalytics(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"analytics": analytics})
 }
 
+func (h *Handler) getTurmaAnalytics(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	turmaID, err := uuid.Parse(chi.URLParam(r, "turmaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "turma inválida", nil)
+		return
+	}
+
+	filtro, err := parseAnalyticsFiltro(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	analytics, err := h.service.TurmaAnalytics(r.Context(), professorID, turmaID, filtro)
+	if err != nil {
+		writeAnalyticsError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analytics)
+}
+
+func (h *Handler) getAlunoAnalytics(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	turmaID, err := uuid.Parse(chi.URLParam(r, "turmaID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "turma inválida", nil)
+		return
+	}
+	alunoID, err := uuid.Parse(chi.URLParam(r, "alunoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "aluno inválido", nil)
+		return
+	}
+
+	filtro, err := parseAnalyticsFiltro(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	analytics, err := h.service.AlunoAnalytics(r.Context(), professorID, turmaID, alunoID, filtro)
+	if err != nil {
+		writeAnalyticsError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analytics)
+}
+
+// parseAnalyticsFiltro lê ano_letivo, bimestre, from e to (YYYY-MM-DD), todos opcionais.
+func parseAnalyticsFiltro(r *http.Request) (AnalyticsFiltro, error) {
+	var filtro AnalyticsFiltro
+	var err error
+	if filtro.AnoLetivo, err = parseAnoLetivo(r); err != nil {
+		return filtro, err
+	}
+	query := r.URL.Query()
+	if raw := query.Get("bimestre"); raw != "" {
+		if filtro.Bimestre, err = strconv.Atoi(raw); err != nil {
+			return filtro, errors.New("bimestre inválido")
+		}
+	}
+	if raw := query.Get("from"); raw != "" {
+		if filtro.From, err = time.Parse("2006-01-02", raw); err != nil {
+			return filtro, errors.New("from inválido")
+		}
+	}
+	if raw := query.Get("to"); raw != "" {
+		if filtro.To, err = time.Parse("2006-01-02", raw); err != nil {
+			return filtro, errors.New("to inválido")
+		}
+	}
+	return filtro, nil
+}
+
+func writeAnalyticsError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrNotFound:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "registro não encontrado", nil)
+	case ErrForbidden:
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "sem acesso", nil)
+	default:
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+	}
+}
+
 func (h *Handler) getLivePresence(w http.ResponseWriter, r *http.Request) {
 	professorID, err := subjectAsUUID(r)
 	if err != nil {
@@ -906,6 +1544,19 @@ func (h *Handler) getLivePresence(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"live": live})
 }
 
+// parseAnoLetivo lê o filtro opcional ano_letivo; zero indica o ano vigente.
+func parseAnoLetivo(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("ano_letivo")
+	if raw == "" {
+		return 0, nil
+	}
+	ano, err := strconv.Atoi(raw)
+	if err != nil || ano < 2000 || ano > 2100 {
+		return 0, errors.New("ano_letivo inválido")
+	}
+	return ano, nil
+}
+
 func subjectAsUUID(r *http.Request) (uuid.UUID, error) {
 	subject := httpmiddleware.GetSubject(r.Context())
 	return uuid.Parse(subject)
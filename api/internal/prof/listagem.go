@@ -0,0 +1,127 @@
+package prof
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	listaLimitePadrao = 50
+	listaLimiteMaximo = 200
+)
+
+// ErrListaParams indica paginação, ordenação ou busca inválida na query string.
+var ErrListaParams = errors.New("parâmetros de listagem inválidos")
+
+// ListaParams descreve paginação por offset, ordenação e busca das listagens do professor.
+type ListaParams struct {
+	Limit  int
+	Offset int
+	Ordem  string
+	Busca  string
+}
+
+// Pagina carrega o total filtrado junto da janela retornada.
+type Pagina[T any] struct {
+	Itens  []T
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// ordenacao mapeia chaves públicas de ordenação para cláusulas ORDER BY; a primeira é o padrão.
+type ordenacao struct {
+	padrao string
+	sql    map[string]string
+}
+
+func (o ordenacao) clausula(chave string) string {
+	if clause, ok := o.sql[chave]; ok {
+		return clause
+	}
+	return o.sql[o.padrao]
+}
+
+var (
+	ordemAlunos = ordenacao{padrao: "nome", sql: map[string]string{
+		"nome":       "a.nome, a.id",
+		"-nome":      "a.nome DESC, a.id",
+		"matricula":  "a.matricula NULLS LAST, a.nome, a.id",
+		"-matricula": "a.matricula DESC NULLS LAST, a.nome, a.id",
+	}}
+	ordemMateriais = ordenacao{padrao: "-criado_em", sql: map[string]string{
+		"-criado_em": "m.criado_em DESC, m.id",
+		"criado_em":  "m.criado_em, m.id",
+		"titulo":     "m.titulo, m.id",
+		"-titulo":    "m.titulo DESC, m.id",
+	}}
+	ordemAvaliacoes = ordenacao{padrao: "-criado_em", sql: map[string]string{
+		"-criado_em": "a.created_at DESC, a.id",
+		"criado_em":  "a.created_at, a.id",
+		"-data":      "a.inicio DESC NULLS LAST, a.id",
+		"data":       "a.inicio NULLS LAST, a.id",
+		"titulo":     "a.titulo, a.id",
+		"-titulo":    "a.titulo DESC, a.id",
+	}}
+	ordemDiario = ordenacao{padrao: "-atualizado_em", sql: map[string]string{
+		"-atualizado_em": "COALESCE(atualizado_em, criado_em) DESC, id",
+		"-criado_em":     "criado_em DESC, id",
+		"criado_em":      "criado_em, id",
+	}}
+)
+
+// parseListaParams lê limit, offset, sort e q; limit ausente usa o padrão e acima do máximo é truncado.
+func parseListaParams(query url.Values, ordem ordenacao) (ListaParams, error) {
+	params := ListaParams{Limit: listaLimitePadrao, Ordem: ordem.padrao}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return ListaParams{}, ErrListaParams
+		}
+		params.Limit = min(limit, listaLimiteMaximo)
+	}
+	if raw := strings.TrimSpace(query.Get("offset")); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return ListaParams{}, ErrListaParams
+		}
+		params.Offset = offset
+	}
+	if raw := strings.TrimSpace(query.Get("sort")); raw != "" {
+		if _, ok := ordem.sql[raw]; !ok {
+			return ListaParams{}, ErrListaParams
+		}
+		params.Ordem = raw
+	}
+	params.Busca = strings.TrimSpace(query.Get("q"))
+	if len([]rune(params.Busca)) > 100 {
+		return ListaParams{}, ErrListaParams
+	}
+	return params, nil
+}
+
+// normalizar aplica os limites quando o service é chamado sem passar pelo handler.
+func (p ListaParams) normalizar() ListaParams {
+	if p.Limit <= 0 {
+		p.Limit = listaLimitePadrao
+	}
+	p.Limit = min(p.Limit, listaLimiteMaximo)
+	p.Offset = max(p.Offset, 0)
+	p.Busca = strings.TrimSpace(p.Busca)
+	return p
+}
+
+func paginaJSON[T any](chave string, pagina Pagina[T]) map[string]any {
+	itens := pagina.Itens
+	if itens == nil {
+		itens = []T{}
+	}
+	return map[string]any{
+		chave:    itens,
+		"total":  pagina.Total,
+		"limit":  pagina.Limit,
+		"offset": pagina.Offset,
+	}
+}
@@ -0,0 +1,38 @@
+package prof
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestParseListaParams_Padroes(t *testing.T) {
+	params, err := parseListaParams(url.Values{}, ordemAlunos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Limit != listaLimitePadrao || params.Offset != 0 || params.Ordem != "nome" || params.Busca != "" {
+		t.Fatalf("unexpected params %+v", params)
+	}
+}
+
+func TestParseListaParams_LimitaEValida(t *testing.T) {
+	params, err := parseListaParams(url.Values{"limit": {"5000"}, "offset": {"400"}, "sort": {"-matricula"}, "q": {"  silva "}}, ordemAlunos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Limit != listaLimiteMaximo || params.Offset != 400 || params.Ordem != "-matricula" || params.Busca != "silva" {
+		t.Fatalf("unexpected params %+v", params)
+	}
+
+	for _, query := range []url.Values{
+		{"limit": {"0"}},
+		{"offset": {"-1"}},
+		{"sort": {"titulo"}},
+		{"sort": {"nome; DROP TABLE alunos"}},
+	} {
+		if _, err := parseListaParams(query, ordemAlunos); !errors.Is(err, ErrListaParams) {
+			t.Fatalf("expected ErrListaParams for %v, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package prof
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// LiveEscopo define quais turmas entram na presença ao vivo; campos nulos não filtram.
+// TodosTenants restringe às escolas vinculadas a alguma prefeitura (visão SaaS).
+type LiveEscopo struct {
+	ProfessorID  *uuid.UUID
+	TenantID     *uuid.UUID
+	TodosTenants bool
+}
+
+type EscolaLivePresence struct {
+	EscolaID     uuid.UUID `json:"escola_id"`
+	Escola       string    `json:"escola"`
+	Turmas       int       `json:"turmas"`
+	TurmasEmAula int       `json:"turmas_em_aula"`
+	Presentes    int       `json:"presentes"`
+	Esperados    int       `json:"esperados"`
+	Percentual   float64   `json:"percentual"`
+}
+
+// TenantLivePresence soma a presença das aulas em andamento hoje nas escolas de uma prefeitura.
+// Esperados considera apenas turmas que já tiveram aula no dia.
+type TenantLivePresence struct {
+	TenantID     uuid.UUID            `json:"tenant_id"`
+	Escolas      []EscolaLivePresence `json:"escolas,omitempty"`
+	TurmasEmAula int                  `json:"turmas_em_aula"`
+	Presentes    int                  `json:"presentes"`
+	Esperados    int                  `json:"esperados"`
+	Percentual   float64              `json:"percentual"`
+	GeradoEm     time.Time            `json:"gerado_em"`
+}
+
+type CidadesLivePresence struct {
+	Tenants    []TenantLivePresence `json:"tenants"`
+	Presentes  int                  `json:"presentes"`
+	Esperados  int                  `json:"esperados"`
+	Percentual float64              `json:"percentual"`
+	GeradoEm   time.Time            `json:"gerado_em"`
+}
+
+func (r *Repository) LivePresence(ctx context.Context, professorID uuid.UUID) ([]LivePresence, error) {
+	tenantID := httpmiddleware.TenantScope(ctx)
+	return r.LivePresenceEscopo(ctx, LiveEscopo{ProfessorID: &professorID, TenantID: &tenantID})
+}
+
+// LivePresenceEscopo lista, por turma, os presentes na aula mais recente do dia e os alunos esperados.
+func (r *Repository) LivePresenceEscopo(ctx context.Context, escopo LiveEscopo) ([]LivePresence, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	today := time.Now()
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	end := start.Add(24 * time.Hour)
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        WITH turmas_escopo AS (
+            SELECT t.id, t.nome, t.escola_id, e.nome AS escola, e.tenant_id
+            FROM turmas t
+            LEFT JOIN escolas e ON e.id = t.escola_id
+            WHERE ($1::uuid IS NULL OR EXISTS (
+                      SELECT 1 FROM professores_turmas pt WHERE pt.turma_id = t.id AND pt.professor_id = $1))
+              AND ($2::uuid IS NULL OR e.tenant_id = $2)
+              AND (NOT $3::bool OR e.tenant_id IS NOT NULL)
+        ),
+        aula_recente AS (
+            SELECT te.id AS turma_id, te.nome, te.escola_id, te.escola, te.tenant_id, a.id AS aula_id, a.inicio
+            FROM turmas_escopo te
+            LEFT JOIN LATERAL (
+                SELECT a1.id, a1.inicio
+                FROM aulas a1
+                WHERE a1.turma_id = te.id AND a1.inicio >= $4 AND a1.inicio < $5
+                ORDER BY a1.inicio DESC
+                LIMIT 1
+            ) a ON true
+        ),
+        presentes AS (
+            SELECT au.turma_id, COUNT(*) AS total
+            FROM aula_recente au
+            JOIN presencas p ON p.aula_id = au.aula_id AND p.aula_inicio = au.inicio AND p.status = 'PRESENTE'
+            WHERE p.aula_inicio >= $4 AND p.aula_inicio < $5
+            GROUP BY au.turma_id
+        ),
+        esperados AS (
+            SELECT te.id AS turma_id, COUNT(m.id) AS total
+            FROM turmas_escopo te
+            LEFT JOIN matriculas m ON m.turma_id = te.id AND m.ativo = TRUE
+            GROUP BY te.id
+        )
+        SELECT au.turma_id,
+               au.nome,
+               au.escola_id,
+               au.escola,
+               au.tenant_id,
+               COALESCE(pr.total, 0) AS presentes,
+               COALESCE(es.total, 0) AS esperados,
+               au.inicio
+        FROM aula_recente au
+        LEFT JOIN presentes pr ON pr.turma_id = au.turma_id
+        LEFT JOIN esperados es ON es.turma_id = au.turma_id
+        ORDER BY au.nome;
+    `, escopo.ProfessorID, escopo.TenantID, escopo.TodosTenants, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []LivePresence
+	for rows.Next() {
+		var item LivePresence
+		var inicio *time.Time
+		if err := rows.Scan(&item.TurmaID, &item.Turma, &item.EscolaID, &item.Escola, &item.TenantID, &item.Presentes, &item.Esperados, &inicio); err != nil {
+			return nil, err
+		}
+		item.AtualizadoEm = inicio
+		if item.Esperados > 0 {
+			item.Percentual = float64(item.Presentes) / float64(item.Esperados)
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// agregarLiveTenant consolida as turmas de uma prefeitura por escola; turmas sem escola ficam fora.
+func agregarLiveTenant(tenantID uuid.UUID, turmas []LivePresence, now time.Time) TenantLivePresence {
+	result := TenantLivePresence{TenantID: tenantID, Escolas: []EscolaLivePresence{}, GeradoEm: now}
+	porEscola := make(map[uuid.UUID]*EscolaLivePresence)
+	for _, turma := range turmas {
+		if turma.EscolaID == nil {
+			continue
+		}
+		escola, ok := porEscola[*turma.EscolaID]
+		if !ok {
+			escola = &EscolaLivePresence{EscolaID: *turma.EscolaID}
+			if turma.Escola != nil {
+				escola.Escola = *turma.Escola
+			}
+			porEscola[*turma.EscolaID] = escola
+		}
+		escola.Turmas++
+		if turma.AtualizadoEm == nil {
+			continue
+		}
+		escola.TurmasEmAula++
+		escola.Presentes += turma.Presentes
+		escola.Esperados += turma.Esperados
+	}
+
+	for _, escola := range porEscola {
+		if escola.Esperados > 0 {
+			escola.Percentual = float64(escola.Presentes) / float64(escola.Esperados)
+		}
+		result.TurmasEmAula += escola.TurmasEmAula
+		result.Presentes += escola.Presentes
+		result.Esperados += escola.Esperados
+		result.Escolas = append(result.Escolas, *escola)
+	}
+	sort.Slice(result.Escolas, func(i, j int) bool { return result.Escolas[i].Escola < result.Escolas[j].Escola })
+	if result.Esperados > 0 {
+		result.Percentual = float64(result.Presentes) / float64(result.Esperados)
+	}
+	return result
+}
+
+type liveCacheEntry struct {
+	value    any
+	expiraEm time.Time
+}
+
+// LivePresenceCache guarda por alguns segundos as agregações de presença ao vivo, evitando que
+// painéis de secretaria e SaaS refaçam a consulta de todas as turmas a cada atualização.
+type LivePresenceCache struct {
+	repo *Repository
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]liveCacheEntry
+}
+
+func NewLivePresenceCache(repo *Repository, ttl time.Duration) *LivePresenceCache {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &LivePresenceCache{repo: repo, ttl: ttl, entries: make(map[string]liveCacheEntry)}
+}
+
+// Tenant devolve a presença ao vivo das escolas da prefeitura, com detalhamento por escola.
+func (c *LivePresenceCache) Tenant(ctx context.Context, tenantID uuid.UUID) (TenantLivePresence, error) {
+	value, err := c.get(ctx, "tenant:"+tenantID.String(), func(ctx context.Context, now time.Time) (any, error) {
+		turmas, err := c.repo.LivePresenceEscopo(ctx, LiveEscopo{TenantID: &tenantID})
+		if err != nil {
+			return nil, err
+		}
+		return agregarLiveTenant(tenantID, turmas, now), nil
+	})
+	if err != nil {
+		return TenantLivePresence{}, err
+	}
+	return value.(TenantLivePresence), nil
+}
+
+// Cidades devolve o total de presentes agora por prefeitura, sem o detalhamento por escola.
+func (c *LivePresenceCache) Cidades(ctx context.Context) (CidadesLivePresence, error) {
+	value, err := c.get(ctx, "cidades", func(ctx context.Context, now time.Time) (any, error) {
+		turmas, err := c.repo.LivePresenceEscopo(ctx, LiveEscopo{TodosTenants: true})
+		if err != nil {
+			return nil, err
+		}
+		porTenant := make(map[uuid.UUID][]LivePresence)
+		for _, turma := range turmas {
+			if turma.TenantID != nil {
+				porTenant[*turma.TenantID] = append(porTenant[*turma.TenantID], turma)
+			}
+		}
+
+		result := CidadesLivePresence{Tenants: []TenantLivePresence{}, GeradoEm: now}
+		for tenantID, lista := range porTenant {
+			tenant := agregarLiveTenant(tenantID, lista, now)
+			tenant.Escolas = nil
+			result.Presentes += tenant.Presentes
+			result.Esperados += tenant.Esperados
+			result.Tenants = append(result.Tenants, tenant)
+		}
+		sort.Slice(result.Tenants, func(i, j int) bool { return result.Tenants[i].Presentes > result.Tenants[j].Presentes })
+		if result.Esperados > 0 {
+			result.Percentual = float64(result.Presentes) / float64(result.Esperados)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return CidadesLivePresence{}, err
+	}
+	return value.(CidadesLivePresence), nil
+}
+
+func (c *LivePresenceCache) get(ctx context.Context, key string, load func(context.Context, time.Time) (any, error)) (any, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiraEm) {
+		return entry.value, nil
+	}
+
+	value, err := load(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = liveCacheEntry{value: value, expiraEm: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return value, nil
+}
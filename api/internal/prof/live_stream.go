@@ -0,0 +1,233 @@
+package prof
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// canalPresencas é o canal do LISTEN/NOTIFY avisado por UpsertPresencas com o id da turma; o aviso só
+// é entregue no commit, então todas as instâncias da API veem a mesma chamada gravada.
+const canalPresencas = "presencas_live"
+
+const (
+	// liveDebounce agrupa as gravações em sequência (vários professores salvando juntos) numa só consulta.
+	liveDebounce = 2 * time.Second
+	// liveHeartbeat mantém a conexão aberta através de proxies que derrubam streams ociosos.
+	liveHeartbeat = 25 * time.Second
+	// liveReconexao é a espera antes de refazer o LISTEN depois de perder a conexão.
+	liveReconexao = 5 * time.Second
+)
+
+// LiveHub repassa aos streams abertos do painel os avisos de chamada gravada, filtrando pelas turmas
+// que cada stream exibe. Sem o hub, o stream cai para atualização periódica.
+type LiveHub struct {
+	pool   *pgxpool.Pool
+	logger zerolog.Logger
+
+	mu   sync.Mutex
+	subs map[*LiveAssinatura]struct{}
+}
+
+// LiveAssinatura recebe um sinal (coalescido) quando alguma das suas turmas tem presença gravada.
+type LiveAssinatura struct {
+	hub    *LiveHub
+	sinal  chan struct{}
+	turmas map[uuid.UUID]struct{}
+}
+
+func NewLiveHub(pool *pgxpool.Pool, logger zerolog.Logger) *LiveHub {
+	return &LiveHub{pool: pool, logger: logger, subs: make(map[*LiveAssinatura]struct{})}
+}
+
+// Assinar registra um stream; chame Close ao encerrar.
+func (h *LiveHub) Assinar() *LiveAssinatura {
+	a := &LiveAssinatura{hub: h, sinal: make(chan struct{}, 1), turmas: map[uuid.UUID]struct{}{}}
+	h.mu.Lock()
+	h.subs[a] = struct{}{}
+	h.mu.Unlock()
+	return a
+}
+
+// Turmas troca o conjunto de turmas acompanhadas pela assinatura.
+func (a *LiveAssinatura) Turmas(ids []uuid.UUID) {
+	turmas := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		turmas[id] = struct{}{}
+	}
+	a.hub.mu.Lock()
+	a.turmas = turmas
+	a.hub.mu.Unlock()
+}
+
+// Sinal dispara quando há presença nova; avisos acumulados enquanto ninguém lê viram um só.
+func (a *LiveAssinatura) Sinal() <-chan struct{} {
+	return a.sinal
+}
+
+func (a *LiveAssinatura) Close() {
+	a.hub.mu.Lock()
+	delete(a.hub.subs, a)
+	a.hub.mu.Unlock()
+}
+
+// publicar sinaliza as assinaturas que acompanham a turma sem bloquear o laço do LISTEN.
+func (h *LiveHub) publicar(turmaID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for a := range h.subs {
+		if _, ok := a.turmas[turmaID]; !ok {
+			continue
+		}
+		select {
+		case a.sinal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run mantém uma conexão dedicada em LISTEN até ctx terminar, reconectando após falhas.
+func (h *LiveHub) Run(ctx context.Context) {
+	for {
+		if err := h.escutar(ctx); err != nil && ctx.Err() == nil {
+			h.logger.Warn().Err(err).Msg("prof: LISTEN de presenças interrompido")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(liveReconexao):
+		}
+	}
+}
+
+func (h *LiveHub) escutar(ctx context.Context) error {
+	conn, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A conexão sai do pool ainda em LISTEN; fechá-la evita que outra requisição herde o canal.
+	defer func() {
+		_ = conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+canalPresencas); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		turmaID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			continue
+		}
+		h.publicar(turmaID)
+	}
+}
+
+// WithLiveHub liga o stream do painel aos avisos de chamada gravada.
+func (h *Handler) WithLiveHub(hub *LiveHub) *Handler {
+	h.liveHub = hub
+	return h
+}
+
+// streamLivePresence envia a presença ao vivo como Server-Sent Events: o retrato atual ao conectar e
+// um novo evento "live" a cada chamada gravada nas turmas do professor. Sem hub, reenvia a cada heartbeat.
+func (h *Handler) streamLivePresence(w http.ResponseWriter, r *http.Request) {
+	professorID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "streaming não suportado", nil)
+		return
+	}
+
+	ctx := r.Context()
+	var assinatura *LiveAssinatura
+	if h.liveHub != nil {
+		assinatura = h.liveHub.Assinar()
+		defer assinatura.Close()
+	}
+
+	enviar := func() bool {
+		live, err := h.service.LivePresence(ctx, professorID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			fmt.Fprint(w, "event: erro\ndata: {\"message\":\"não foi possível carregar presença em tempo real\"}\n\n")
+			flusher.Flush()
+			return true
+		}
+		if assinatura != nil {
+			ids := make([]uuid.UUID, 0, len(live))
+			for _, item := range live {
+				ids = append(ids, item.TurmaID)
+			}
+			assinatura.Turmas(ids)
+		}
+		payload, err := json.Marshal(map[string]any{"live": live})
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: live\ndata: %s\n\n", payload); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if !enviar() {
+		return
+	}
+
+	var sinal <-chan struct{}
+	if assinatura != nil {
+		sinal = assinatura.Sinal()
+	}
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sinal:
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(liveDebounce):
+			}
+			if !enviar() {
+				return
+			}
+		case <-heartbeat.C:
+			if assinatura == nil {
+				if !enviar() {
+					return
+				}
+				continue
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package prof
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestAgregarLiveTenant(t *testing.T) {
+	escolaA, escolaB := uuid.New(), uuid.New()
+	nomeA, nomeB := "EMEF Alfa", "EMEF Beta"
+	agora := time.Now()
+	turmas := []LivePresence{
+		{EscolaID: &escolaB, Escola: &nomeB, Presentes: 10, Esperados: 20, AtualizadoEm: &agora},
+		{EscolaID: &escolaA, Escola: &nomeA, Presentes: 15, Esperados: 20, AtualizadoEm: &agora},
+		{EscolaID: &escolaA, Escola: &nomeA, Esperados: 25},
+		{Presentes: 5, Esperados: 5, AtualizadoEm: &agora},
+	}
+
+	result := agregarLiveTenant(uuid.New(), turmas, agora)
+	if result.Presentes != 25 || result.Esperados != 40 || result.TurmasEmAula != 2 {
+		t.Fatalf("unexpected totals: %+v", result)
+	}
+	if len(result.Escolas) != 2 || result.Escolas[0].Escola != nomeA {
+		t.Fatalf("expected escolas sorted by name, got %+v", result.Escolas)
+	}
+	if result.Escolas[0].Turmas != 2 || result.Escolas[0].TurmasEmAula != 1 {
+		t.Fatalf("unexpected escola A counters: %+v", result.Escolas[0])
+	}
+}
+
+func TestLiveHubPublicarFiltraTurmas(t *testing.T) {
+	hub := NewLiveHub(nil, zerolog.Nop())
+	turma, outra := uuid.New(), uuid.New()
+	a := hub.Assinar()
+	defer a.Close()
+	a.Turmas([]uuid.UUID{turma})
+
+	hub.publicar(outra)
+	select {
+	case <-a.Sinal():
+		t.Fatal("sinal de turma não acompanhada")
+	default:
+	}
+
+	hub.publicar(turma)
+	hub.publicar(turma)
+	<-a.Sinal()
+	select {
+	case <-a.Sinal():
+		t.Fatal("avisos acumulados deveriam virar um só sinal")
+	default:
+	}
+
+	a.Close()
+	hub.publicar(turma)
+	select {
+	case <-a.Sinal():
+		t.Fatal("assinatura encerrada recebeu sinal")
+	default:
+	}
+}
@@ -0,0 +1,267 @@
+package prof
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// planilhaMaxBytes limita o tamanho de planilhas importadas.
+const planilhaMaxBytes = 5 << 20
+
+var errPlanilhaInvalida = errors.New("planilha inválida: envie CSV ou XLSX gerado pelo modelo")
+
+// lerPlanilha devolve as linhas da primeira aba de um XLSX ou de um CSV (vírgula ou ponto e vírgula).
+func lerPlanilha(nome string, data []byte) ([][]string, error) {
+	if strings.EqualFold(filepath.Ext(nome), ".xlsx") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return lerXLSX(data)
+	}
+	return lerCSV(data)
+}
+
+func lerCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine := data
+	if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+		firstLine = data[:idx]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errPlanilhaInvalida
+	}
+	return rows, nil
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref       string `xml:"r,attr"`
+			Type      string `xml:"t,attr"`
+			Value     string `xml:"v"`
+			InlineStr struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func lerXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errPlanilhaInvalida
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	var sheets []string
+	for _, f := range archive.File {
+		files[f.Name] = f
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, errPlanilhaInvalida
+	}
+	sort.Strings(sheets)
+	sheetName := sheets[0]
+	if _, ok := files["xl/worksheets/sheet1.xml"]; ok {
+		sheetName = "xl/worksheets/sheet1.xml"
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStrings
+		if err := decodeZipXML(f, &sst); err != nil {
+			return nil, errPlanilhaInvalida
+		}
+		for _, item := range sst.Items {
+			text := item.Text
+			for _, run := range item.Runs {
+				text += run.Text
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXML(files[sheetName], &sheet); err != nil {
+		return nil, errPlanilhaInvalida
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = colunaXLSX(cell.Ref)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, errPlanilhaInvalida
+				}
+				values[col] = shared[idx]
+			case "inlineStr":
+				values[col] = cell.InlineStr.Text
+			default:
+				values[col] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+func decodeZipXML(f *zip.File, target any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, planilhaMaxBytes*4)).Decode(target)
+}
+
+// colunaXLSX converte a referência da célula ("C12") no índice da coluna (2).
+func colunaXLSX(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+// normalizarCabecalho deixa cabeçalhos comparáveis ("Matrícula " -> "matricula").
+func normalizarCabecalho(value string) string {
+	replacer := strings.NewReplacer("á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i", "ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c")
+	value = replacer.Replace(strings.ToLower(strings.TrimSpace(value)))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+// parseNotaPlanilha aceita vírgula ou ponto como separador decimal.
+func parseNotaPlanilha(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", ".")
+	return strconv.ParseFloat(value, 64)
+}
+
+// buscarMatricula localiza o código entre as matrículas da turma (chaves em minúsculas). Sem
+// correspondência exata, compara sem zeros à esquerda, que o Excel remove quando trata a
+// matrícula como número ("00123" vira 123); só aceita se um único aluno casar.
+func buscarMatricula(matriculas map[string]uuid.UUID, codigo string) (uuid.UUID, bool) {
+	key := strings.ToLower(strings.TrimSpace(codigo))
+	if id, ok := matriculas[key]; ok {
+		return id, true
+	}
+	semZeros := strings.TrimLeft(key, "0")
+	if semZeros == "" {
+		return uuid.Nil, false
+	}
+	var encontrado uuid.UUID
+	achados := 0
+	for k, id := range matriculas {
+		if strings.TrimLeft(k, "0") == semZeros {
+			encontrado = id
+			achados++
+		}
+	}
+	return encontrado, achados == 1
+}
+
+// conferirNotas valida as linhas da planilha de notas contra as matrículas da turma. A repetição é
+// conferida pela matrícula encontrada, não pelo código digitado: "00123" e "123" são o mesmo aluno.
+func conferirNotas(rows [][]string, colMatricula, colNota, colObs int, matriculas map[string]uuid.UUID) (ImportacaoNotas, []NotaLancamento) {
+	result := ImportacaoNotas{Erros: []ImportacaoErro{}}
+	notas := make([]NotaLancamento, 0, len(rows))
+	vistas := make(map[uuid.UUID]int)
+	cell := func(row []string, idx int) string {
+		if idx < 0 || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	for i, row := range rows[1:] {
+		linha := i + 2
+		codigo := cell(row, colMatricula)
+		valor := cell(row, colNota)
+		if codigo == "" && valor == "" {
+			continue
+		}
+		result.Linhas++
+		if valor == "" {
+			result.Ignoradas++
+			continue
+		}
+		if codigo == "" {
+			result.Erros = append(result.Erros, ImportacaoErro{Linha: linha, Mensagem: "matrícula em branco"})
+			continue
+		}
+
+		matriculaID, ok := buscarMatricula(matriculas, codigo)
+		if !ok {
+			result.Erros = append(result.Erros, ImportacaoErro{Linha: linha, Matricula: codigo, Mensagem: "matrícula não encontrada na turma"})
+			continue
+		}
+		if anterior, ok := vistas[matriculaID]; ok {
+			result.Erros = append(result.Erros, ImportacaoErro{Linha: linha, Matricula: codigo, Mensagem: "matrícula repetida (linha " + strconv.Itoa(anterior) + ")"})
+			continue
+		}
+		vistas[matriculaID] = linha
+
+		nota, err := parseNotaPlanilha(valor)
+		if err != nil {
+			result.Erros = append(result.Erros, ImportacaoErro{Linha: linha, Matricula: codigo, Mensagem: "nota não numérica"})
+			continue
+		}
+		if nota < 0 || nota > 10 {
+			result.Erros = append(result.Erros, ImportacaoErro{Linha: linha, Matricula: codigo, Mensagem: "nota fora do intervalo 0 a 10"})
+			continue
+		}
+
+		item := NotaLancamento{MatriculaID: matriculaID, Nota: nota}
+		if obs := cell(row, colObs); obs != "" {
+			item.Observacao = &obs
+		}
+		notas = append(notas, item)
+	}
+	return result, notas
+}
@@ -0,0 +1,86 @@
+package prof
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLerPlanilha_CSVPontoEVirgula(t *testing.T) {
+	data := []byte("\xef\xbb\xbfMatrícula;Nome;Nota\n2024-001;Ana;8,5\n")
+	rows, err := lerPlanilha("notas.csv", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 || normalizarCabecalho(rows[0][0]) != "matricula" || rows[1][2] != "8,5" {
+		t.Fatalf("unexpected rows: %#v", rows)
+	}
+	nota, err := parseNotaPlanilha(rows[1][2])
+	if err != nil || nota != 8.5 {
+		t.Fatalf("expected 8.5, got %v (%v)", nota, err)
+	}
+}
+
+func TestLerPlanilha_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>matricula</t></si><si><t>nota</t></si><si><t>2024-001</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>7.25</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := lerPlanilha("notas.xlsx", buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 || rows[0][0] != "matricula" || rows[0][2] != "nota" || rows[1][0] != "2024-001" || rows[1][2] != "7.25" {
+		t.Fatalf("unexpected rows: %#v", rows)
+	}
+}
+
+func TestBuscarMatricula_ZerosAEsquerda(t *testing.T) {
+	ana, bia := uuid.New(), uuid.New()
+	matriculas := map[string]uuid.UUID{"00123": ana, "2024-b7": bia}
+	if id, ok := buscarMatricula(matriculas, "2024-B7"); !ok || id != bia {
+		t.Fatalf("expected exact case-insensitive match")
+	}
+	if id, ok := buscarMatricula(matriculas, "123"); !ok || id != ana {
+		t.Fatalf("expected match without leading zeros")
+	}
+	matriculas["0123"] = uuid.New()
+	if _, ok := buscarMatricula(matriculas, "123"); ok {
+		t.Fatal("ambiguous codes must not match")
+	}
+	if _, ok := buscarMatricula(matriculas, "000"); ok {
+		t.Fatal("zeros only must not match")
+	}
+}
+
+func TestConferirNotas_RepeticaoSemZerosAEsquerda(t *testing.T) {
+	ana := uuid.New()
+	matriculas := map[string]uuid.UUID{"00123": ana}
+	rows := [][]string{{"matricula", "nota"}, {"00123", "8"}, {"123", "9"}}
+
+	result, notas := conferirNotas(rows, 0, 1, -1, matriculas)
+	if len(notas) != 1 || notas[0].MatriculaID != ana || notas[0].Nota != 8 {
+		t.Fatalf("unexpected notas %+v", notas)
+	}
+	if len(result.Erros) != 1 || result.Erros[0].Linha != 3 || result.Erros[0].Mensagem != "matrícula repetida (linha 2)" {
+		t.Fatalf("expected duplicate error on line 3, got %+v", result.Erros)
+	}
+}
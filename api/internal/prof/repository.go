@@ -9,11 +9,18 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+	"github.com/gestaozabele/municipio/internal/notify"
 )
 
 var (
 	ErrNotFound  = errors.New("not found")
 	ErrForbidden = errors.New("forbidden")
+
+	ErrEstoqueInsuficiente = errors.New("estoque insuficiente")
+	ErrMaterialDigital     = errors.New("material digital não pode ser emprestado")
 )
 
 const dbTimeout = 3 * time.Second
@@ -27,6 +34,12 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+// conn devolve o pool do cluster da prefeitura da requisição (middleware TenantDatabase) ou o
+// pool padrão fora de requisição.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.db)
+}
+
 type Turma struct {
 	ID         uuid.UUID  `json:"id"`
 	Nome       string     `json:"nome"`
@@ -59,6 +72,7 @@ type Avaliacao struct {
 	Status     string     `json:"status"`
 	Data       *time.Time `json:"data,omitempty"`
 	Peso       float64    `json:"peso"`
+	AnoLetivo  int        `json:"ano_letivo"`
 	CreatedAt  time.Time  `json:"created_at"`
 	CreatedBy  uuid.UUID  `json:"created_by"`
 }
@@ -104,6 +118,17 @@ type DiarioEntrada struct {
 	AtualizadoEm *time.Time `json:"atualizado_em,omitempty"`
 }
 
+type Notificacao struct {
+	ID           uuid.UUID  `json:"id"`
+	Tipo         string     `json:"tipo"`
+	Titulo       string     `json:"titulo"`
+	Mensagem     string     `json:"mensagem"`
+	ReferenciaID *uuid.UUID `json:"referencia_id,omitempty"`
+	Resolvida    bool       `json:"resolvida"`
+	LidaEm       *time.Time `json:"lida_em,omitempty"`
+	CriadoEm     time.Time  `json:"criado_em"`
+}
+
 type Material struct {
 	ID          uuid.UUID `json:"id"`
 	TurmaID     uuid.UUID `json:"turma_id"`
@@ -111,9 +136,28 @@ type Material struct {
 	Titulo      string    `json:"titulo"`
 	Descricao   *string   `json:"descricao,omitempty"`
 	URL         *string   `json:"url,omitempty"`
+	Quantidade  *int      `json:"quantidade,omitempty"`
+	Emprestados int       `json:"emprestados"`
+	Disponivel  *int      `json:"disponivel,omitempty"`
 	CriadoEm    time.Time `json:"criado_em"`
 }
 
+// Emprestimo registra a retirada de um material físico por um aluno.
+type Emprestimo struct {
+	ID           uuid.UUID  `json:"id"`
+	MaterialID   uuid.UUID  `json:"material_id"`
+	Material     string     `json:"material"`
+	TurmaID      uuid.UUID  `json:"turma_id"`
+	AlunoID      uuid.UUID  `json:"aluno_id"`
+	Aluno        string     `json:"aluno"`
+	Quantidade   int        `json:"quantidade"`
+	EmprestadoEm time.Time  `json:"emprestado_em"`
+	DevolverAte  time.Time  `json:"devolver_ate"`
+	DevolvidoEm  *time.Time `json:"devolvido_em,omitempty"`
+	Observacao   *string    `json:"observacao,omitempty"`
+	Atrasado     bool       `json:"atrasado"`
+}
+
 type AgendaItem struct {
 	ID        uuid.UUID  `json:"id"`
 	Tipo      string     `json:"tipo"`
@@ -145,6 +189,7 @@ type RelatorioAvaliacao struct {
 }
 
 type DashboardAnalytics struct {
+	AnoLetivo   int               `json:"ano_letivo"`
 	Averages    []TurmaMedia      `json:"averages"`
 	TopStudents []AlunoMedia      `json:"top_students"`
 	Attendance  []TurmaFrequencia `json:"attendance"`
@@ -178,9 +223,75 @@ type AlunoAlerta struct {
 	Valor   float64   `json:"valor"`
 }
 
+// FaixaNota é uma coluna do histograma de notas (intervalo fechado à esquerda).
+type FaixaNota struct {
+	Faixa      string  `json:"faixa"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Quantidade int     `json:"quantidade"`
+}
+
+type SemanaFrequencia struct {
+	Semana     time.Time `json:"semana"`
+	Presentes  int       `json:"presentes"`
+	Total      int       `json:"total"`
+	Frequencia float64   `json:"frequencia"`
+}
+
+// Comparativo põe a turma (ou o aluno) lado a lado com a média da escola no mesmo período.
+type Comparativo struct {
+	Media            *float64 `json:"media"`
+	MediaTurma       *float64 `json:"media_turma,omitempty"`
+	MediaEscola      *float64 `json:"media_escola"`
+	Frequencia       *float64 `json:"frequencia"`
+	FrequenciaTurma  *float64 `json:"frequencia_turma,omitempty"`
+	FrequenciaEscola *float64 `json:"frequencia_escola"`
+}
+
+type TurmaAnalytics struct {
+	TurmaID           uuid.UUID          `json:"turma_id"`
+	Turma             string             `json:"turma"`
+	AnoLetivo         int                `json:"ano_letivo"`
+	Bimestre          int                `json:"bimestre,omitempty"`
+	From              time.Time          `json:"from"`
+	To                time.Time          `json:"to"`
+	Distribuicao      []FaixaNota        `json:"distribuicao"`
+	FrequenciaSemanal []SemanaFrequencia `json:"frequencia_semanal"`
+	Comparativo       Comparativo        `json:"comparativo"`
+}
+
+type NotaDisciplina struct {
+	Disciplina string  `json:"disciplina"`
+	Bimestre   int     `json:"bimestre"`
+	Nota       float64 `json:"nota"`
+}
+
+type AlunoAnalytics struct {
+	AlunoID           uuid.UUID          `json:"aluno_id"`
+	Nome              string             `json:"nome"`
+	TurmaID           uuid.UUID          `json:"turma_id"`
+	AnoLetivo         int                `json:"ano_letivo"`
+	Bimestre          int                `json:"bimestre,omitempty"`
+	From              time.Time          `json:"from"`
+	To                time.Time          `json:"to"`
+	Notas             []NotaDisciplina   `json:"notas"`
+	FrequenciaSemanal []SemanaFrequencia `json:"frequencia_semanal"`
+	Comparativo       Comparativo        `json:"comparativo"`
+}
+
+// analyticsEscopo restringe as agregações de drill-down; campos nulos não filtram.
+type analyticsEscopo struct {
+	EscolaID    *uuid.UUID
+	TurmaID     *uuid.UUID
+	MatriculaID *uuid.UUID
+}
+
 type LivePresence struct {
 	TurmaID      uuid.UUID  `json:"turma_id"`
 	Turma        string     `json:"turma"`
+	EscolaID     *uuid.UUID `json:"escola_id,omitempty"`
+	Escola       *string    `json:"escola,omitempty"`
+	TenantID     *uuid.UUID `json:"-"`
 	Presentes    int        `json:"presentes"`
 	Esperados    int        `json:"esperados"`
 	Percentual   float64    `json:"percentual"`
@@ -215,18 +326,44 @@ func normalizeTurno(turno string) string {
 	return "MANHA"
 }
 
+// AnoLetivo resolve o ano letivo da prefeitura do professor para a data de referência.
+// Prioriza o período que contém a data, depois o ano ativo e, sem cadastro, o ano civil.
+// Só vale o calendário da prefeitura da requisição, mesmo que o professor lecione em outras.
+func (r *Repository) AnoLetivo(ctx context.Context, professorID uuid.UUID, ref time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var ano int
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT al.ano
+        FROM anos_letivos al
+        JOIN secretarias s ON s.tenant_id = al.tenant_id
+        JOIN usuarios_secretarias us ON us.secretaria_id = s.id AND us.usuario_id = $1
+        WHERE al.tenant_id = $3 AND ($2::date BETWEEN al.inicio AND al.fim OR al.ativo)
+        ORDER BY ($2::date BETWEEN al.inicio AND al.fim) DESC, al.ativo DESC
+        LIMIT 1
+    `, professorID, ref, httpmiddleware.TenantScope(ctx)).Scan(&ano)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ref.Year(), nil
+		}
+		return 0, err
+	}
+	return ano, nil
+}
+
 func (r *Repository) FirstTurma(ctx context.Context, professorID uuid.UUID) (*uuid.UUID, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
 	var turmaID uuid.UUID
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT turma_id
         FROM professores_turmas
-        WHERE professor_id = $1
+        WHERE professor_id = $1 AND `+db.TurmaNoTenant("turma_id", "$2")+`
         ORDER BY turma_id
         LIMIT 1
-    `, professorID).Scan(&turmaID)
+    `, professorID, httpmiddleware.TenantScope(ctx)).Scan(&turmaID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -240,14 +377,14 @@ func (r *Repository) ListTurmas(ctx context.Context, professorID uuid.UUID) ([]T
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT t.id, t.nome, t.turno, t.escola_id, e.nome
 		FROM professores_turmas pt
 		JOIN turmas t ON t.id = pt.turma_id
-		LEFT JOIN escolas e ON e.id = t.escola_id
-		WHERE pt.professor_id = $1
+		JOIN escolas e ON e.id = t.escola_id
+		WHERE pt.professor_id = $1 AND e.tenant_id = $2
 		ORDER BY t.nome
-	`, professorID)
+	`, professorID, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -269,12 +406,12 @@ func (r *Repository) CountDistinctAlunos(ctx context.Context, professorID uuid.U
 	defer cancel()
 
 	var total int
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT COALESCE(COUNT(DISTINCT m.aluno_id), 0)
         FROM professores_turmas pt
         JOIN matriculas m ON m.turma_id = pt.turma_id AND m.ativo = TRUE
-        WHERE pt.professor_id = $1
-    `, professorID).Scan(&total)
+        WHERE pt.professor_id = $1 AND `+db.TurmaNoTenant("pt.turma_id", "$2")+`
+    `, professorID, httpmiddleware.TenantScope(ctx)).Scan(&total)
 	if err != nil {
 		return 0, err
 	}
@@ -292,15 +429,15 @@ func (r *Repository) ListUpcomingAulas(ctx context.Context, professorID uuid.UUI
 		startOfDay = now
 	}
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.turma_id, t.nome, a.disciplina, a.inicio, a.fim
         FROM aulas a
         JOIN turmas t ON t.id = a.turma_id
         JOIN professores_turmas pt ON pt.turma_id = a.turma_id AND pt.professor_id = $1
-        WHERE a.inicio >= $2 AND a.inicio < $3
+        WHERE a.inicio >= $2 AND a.inicio < $3 AND `+db.TurmaNoTenant("a.turma_id", "$4")+`
         ORDER BY a.inicio
         LIMIT 5
-    `, professorID, startOfDay, dayEnd)
+    `, professorID, startOfDay, dayEnd, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -317,18 +454,20 @@ func (r *Repository) ListUpcomingAulas(ctx context.Context, professorID uuid.UUI
 	return aulas, rows.Err()
 }
 
+// ProfessorHasTurma confere o vínculo do professor com a turma na prefeitura da requisição; um
+// ID de turma de outra prefeitura nunca passa, mesmo que o professor também lecione lá.
 func (r *Repository) ProfessorHasTurma(ctx context.Context, professorID, turmaID uuid.UUID) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
 	var exists bool
-	if err := r.db.QueryRow(ctx, `
+	if err := r.conn(ctx).QueryRow(ctx, `
         SELECT EXISTS(
             SELECT 1
             FROM professores_turmas
-            WHERE professor_id = $1 AND turma_id = $2
+            WHERE professor_id = $1 AND turma_id = $2 AND `+db.TurmaNoTenant("turma_id", "$3")+`
         )
-    `, professorID, turmaID).Scan(&exists); err != nil {
+    `, professorID, turmaID, httpmiddleware.TenantScope(ctx)).Scan(&exists); err != nil {
 		return false, err
 	}
 	return exists, nil
@@ -350,13 +489,13 @@ func (r *Repository) ensureProfessorAluno(ctx context.Context, professorID, alun
 	defer cancel()
 
 	var turmaID uuid.UUID
-	if err := r.db.QueryRow(ctx, `
+	if err := r.conn(ctx).QueryRow(ctx, `
         SELECT turma_id
         FROM professor_diario_aluno
-        WHERE professor_id = $1 AND aluno_id = $2
+        WHERE professor_id = $1 AND aluno_id = $2 AND `+db.TurmaNoTenant("turma_id", "$3")+`
         ORDER BY criado_em DESC
         LIMIT 1
-    `, professorID, alunoID).Scan(&turmaID); err == nil {
+    `, professorID, alunoID, httpmiddleware.TenantScope(ctx)).Scan(&turmaID); err == nil {
 		if turmaID == uuid.Nil {
 			return nil, nil
 		}
@@ -366,14 +505,14 @@ func (r *Repository) ensureProfessorAluno(ctx context.Context, professorID, alun
 	}
 
 	var turma uuid.UUID
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT m.turma_id
         FROM matriculas m
         JOIN professores_turmas pt ON pt.turma_id = m.turma_id
-        WHERE pt.professor_id = $1 AND m.aluno_id = $2 AND m.ativo = TRUE
+        WHERE pt.professor_id = $1 AND m.aluno_id = $2 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$3")+`
         ORDER BY m.turma_id
         LIMIT 1
-    `, professorID, alunoID).Scan(&turma)
+    `, professorID, alunoID, httpmiddleware.TenantScope(ctx)).Scan(&turma)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrForbidden
@@ -392,13 +531,13 @@ func (r *Repository) ListAlunosByTurma(ctx context.Context, turmaID uuid.UUID) (
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.nome, a.matricula
         FROM matriculas m
         JOIN alunos a ON a.id = m.aluno_id
-        WHERE m.turma_id = $1 AND m.ativo = TRUE
+        WHERE m.turma_id = $1 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$2")+`
         ORDER BY a.nome
-    `, turmaID)
+    `, turmaID, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -415,6 +554,43 @@ func (r *Repository) ListAlunosByTurma(ctx context.Context, turmaID uuid.UUID) (
 	return alunos, rows.Err()
 }
 
+// ListAlunosPagina lista a janela de alunos ativos da turma filtrando por nome ou matrícula.
+func (r *Repository) ListAlunosPagina(ctx context.Context, turmaID uuid.UUID, params ListaParams) (Pagina[Aluno], error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	filtro := `
+        FROM matriculas m
+        JOIN alunos a ON a.id = m.aluno_id
+        WHERE m.turma_id = $1 AND m.ativo = TRUE AND ` + db.TurmaNoTenant("m.turma_id", "$3") + `
+          AND ($2 = '' OR a.nome ILIKE '%' || $2 || '%' OR a.matricula ILIKE '%' || $2 || '%')
+    `
+	tenantID := httpmiddleware.TenantScope(ctx)
+	pagina := Pagina[Aluno]{Limit: params.Limit, Offset: params.Offset}
+	if err := r.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) `+filtro, turmaID, params.Busca, tenantID).Scan(&pagina.Total); err != nil {
+		return pagina, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.id, a.nome, a.matricula `+filtro+`
+        ORDER BY `+ordemAlunos.clausula(params.Ordem)+`
+        LIMIT $4 OFFSET $5
+    `, turmaID, params.Busca, tenantID, params.Limit, params.Offset)
+	if err != nil {
+		return pagina, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Aluno
+		if err := rows.Scan(&a.ID, &a.Nome, &a.Matricula); err != nil {
+			return pagina, err
+		}
+		pagina.Itens = append(pagina.Itens, a)
+	}
+	return pagina, rows.Err()
+}
+
 func (r *Repository) findAula(ctx context.Context, turmaID uuid.UUID, day time.Time, turno string) (*uuid.UUID, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
@@ -423,13 +599,13 @@ func (r *Repository) findAula(ctx context.Context, turmaID uuid.UUID, day time.T
 	start, end := turnoWindow(day, turno)
 
 	var aulaID uuid.UUID
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT id
         FROM aulas
-        WHERE turma_id = $1 AND inicio >= $2 AND inicio < $3
+        WHERE turma_id = $1 AND inicio >= $2 AND inicio < $3 AND `+db.TurmaNoTenant("turma_id", "$4")+`
         ORDER BY inicio DESC
         LIMIT 1
-    `, turmaID, start, end).Scan(&aulaID)
+    `, turmaID, start, end, httpmiddleware.TenantScope(ctx)).Scan(&aulaID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -439,7 +615,7 @@ func (r *Repository) findAula(ctx context.Context, turmaID uuid.UUID, day time.T
 	return &aulaID, nil
 }
 
-func (r *Repository) createAula(ctx context.Context, turmaID, professorID uuid.UUID, day time.Time, turno, disciplina string) (uuid.UUID, error) {
+func (r *Repository) createAula(ctx context.Context, turmaID, professorID uuid.UUID, day time.Time, turno, disciplina string, anoLetivo int) (uuid.UUID, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
@@ -451,43 +627,44 @@ func (r *Repository) createAula(ctx context.Context, turmaID, professorID uuid.U
 	}
 
 	var aulaID uuid.UUID
-	err := r.db.QueryRow(ctx, `
-        INSERT INTO aulas (turma_id, disciplina, inicio, fim, criado_por)
-        VALUES ($1, $2, $3, $4, $5)
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO aulas (turma_id, disciplina, inicio, fim, criado_por, ano_letivo)
+        VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING id
-    `, turmaID, disciplina, start, end, professorID).Scan(&aulaID)
+    `, turmaID, disciplina, start, end, professorID, anoLetivo).Scan(&aulaID)
 	if err != nil {
 		return uuid.Nil, err
 	}
 	return aulaID, nil
 }
 
-func (r *Repository) FindOrCreateAula(ctx context.Context, turmaID, professorID uuid.UUID, day time.Time, turno, disciplina string) (uuid.UUID, error) {
+func (r *Repository) FindOrCreateAula(ctx context.Context, turmaID, professorID uuid.UUID, day time.Time, turno, disciplina string, anoLetivo int) (uuid.UUID, error) {
 	if aulaID, err := r.findAula(ctx, turmaID, day, turno); err == nil {
 		return *aulaID, nil
 	} else if !errors.Is(err, ErrNotFound) {
 		return uuid.Nil, err
 	}
 
-	return r.createAula(ctx, turmaID, professorID, day, turno, disciplina)
+	return r.createAula(ctx, turmaID, professorID, day, turno, disciplina, anoLetivo)
 }
 
 func (r *Repository) ListChamadaItens(ctx context.Context, turmaID, aulaID uuid.UUID) ([]ChamadaItem, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         WITH alunos_turma AS (
             SELECT m.id AS matricula_id, m.aluno_id, a.nome, a.matricula
             FROM matriculas m
             JOIN alunos a ON a.id = m.aluno_id
-            WHERE m.turma_id = $1 AND m.ativo = TRUE
+            WHERE m.turma_id = $1 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$3")+`
         )
         SELECT at.aluno_id, at.nome, at.matricula, at.matricula_id, p.status, p.justificativa
         FROM alunos_turma at
         LEFT JOIN presencas p ON p.matricula_id = at.matricula_id AND p.aula_id = $2
+            AND p.aula_inicio = (SELECT inicio FROM aulas WHERE id = $2)
         ORDER BY at.nome
-    `, turmaID, aulaID)
+    `, turmaID, aulaID, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -509,13 +686,13 @@ func (r *Repository) LastChamadaBefore(ctx context.Context, turmaID uuid.UUID, r
 	defer cancel()
 
 	var aulaID uuid.UUID
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT id
         FROM aulas
-        WHERE turma_id = $1 AND inicio < $2
+        WHERE turma_id = $1 AND inicio < $2 AND `+db.TurmaNoTenant("turma_id", "$3")+`
         ORDER BY inicio DESC
         LIMIT 1
-    `, turmaID, reference).Scan(&aulaID)
+    `, turmaID, reference, httpmiddleware.TenantScope(ctx)).Scan(&aulaID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -530,12 +707,13 @@ func (r *Repository) AulaByID(ctx context.Context, aulaID uuid.UUID) (AulaResumo
 	defer cancel()
 
 	var aula AulaResumo
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         SELECT a.id, a.turma_id, t.nome, a.disciplina, a.inicio, a.fim
         FROM aulas a
         JOIN turmas t ON t.id = a.turma_id
-        WHERE a.id = $1
-    `, aulaID).Scan(&aula.ID, &aula.TurmaID, &aula.TurmaNome, &aula.Disciplina, &aula.Inicio, &aula.Fim)
+        JOIN escolas e ON e.id = t.escola_id
+        WHERE a.id = $1 AND e.tenant_id = $2
+    `, aulaID, httpmiddleware.TenantScope(ctx)).Scan(&aula.ID, &aula.TurmaID, &aula.TurmaNome, &aula.Disciplina, &aula.Inicio, &aula.Fim)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return AulaResumo{}, ErrNotFound
@@ -549,34 +727,49 @@ func (r *Repository) UpsertPresencas(ctx context.Context, aulaID uuid.UUID, iten
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	tx, err := r.db.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	now := time.Now().UTC()
-	batch := &pgx.Batch{}
-	for _, item := range itens {
-		status := "PRESENTE"
-		if item.Status != nil {
-			status = strings.ToUpper(strings.TrimSpace(*item.Status))
-		}
-		var justificativa *string
-		if item.Observacao != nil {
-			if trimmed := strings.TrimSpace(*item.Observacao); trimmed != "" {
-				justificativa = &trimmed
-			}
-		}
-		batch.Queue(`
-            INSERT INTO presencas (aula_id, matricula_id, status, origem, justificativa, updated_at)
-            VALUES ($1, $2, $3, 'MANUAL', $4, $5)
-            ON CONFLICT (aula_id, matricula_id)
-            DO UPDATE SET status = EXCLUDED.status, origem = EXCLUDED.origem, justificativa = EXCLUDED.justificativa, updated_at = EXCLUDED.updated_at
-        `, aulaID, item.MatriculaID, status, justificativa, now)
+	// Um único INSERT com unnest substitui um statement por aluno; em turmas grandes o
+	// batch antigo gerava centenas de round-trips lógicos e planos por requisição.
+	cols := presencaColunas(itens)
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO presencas (aula_id, aula_inicio, matricula_id, status, origem, justificativa, updated_at)
+        SELECT a.id, a.inicio, v.matricula_id, v.status, 'MANUAL', v.justificativa, $5
+        FROM aulas a, unnest($2::uuid[], $3::text[], $4::text[]) AS v(matricula_id, status, justificativa)
+        WHERE a.id = $1 AND `+db.TurmaNoTenant("a.turma_id", "$6")+`
+        ON CONFLICT (aula_id, matricula_id, aula_inicio)
+        DO UPDATE SET status = EXCLUDED.status, origem = EXCLUDED.origem, justificativa = EXCLUDED.justificativa, updated_at = EXCLUDED.updated_at
+    `, aulaID, cols.matriculas, cols.status, cols.justificativas, time.Now().UTC(), httpmiddleware.TenantScope(ctx)); err != nil {
+		return err
 	}
-	br := tx.SendBatch(ctx, batch)
-	if err := br.Close(); err != nil {
+
+	// Faltas cobertas por justificativa aprovada entram já como JUSTIFICADA.
+	if _, err := tx.Exec(ctx, `
+        UPDATE presencas p
+        SET status = 'JUSTIFICADA', origem = 'JUSTIFICATIVA', justificativa = j.motivo
+        FROM aulas a, matriculas m, justificativas_falta j
+        WHERE p.aula_id = $1
+          AND a.id = p.aula_id
+          AND p.aula_inicio = a.inicio
+          AND m.id = p.matricula_id
+          AND j.aluno_id = m.aluno_id
+          AND j.status = 'APROVADA'
+          AND p.status = 'FALTA'
+          AND (a.inicio AT TIME ZONE 'UTC')::date BETWEEN j.data_inicio AND j.data_fim
+    `, aulaID); err != nil {
+		return err
+	}
+
+	if err := notify.EnfileirarFaltas(ctx, tx, aulaID); err != nil {
+		return err
+	}
+
+	// Avisa os streams do painel ao vivo; o NOTIFY só sai se a transação for confirmada.
+	if _, err := tx.Exec(ctx, `SELECT pg_notify('`+canalPresencas+`', turma_id::text) FROM aulas WHERE id = $1`, aulaID); err != nil {
 		return err
 	}
 
@@ -587,7 +780,7 @@ func (r *Repository) InsertAuditoria(ctx context.Context, destino, origem, user
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	_, err := r.db.Exec(ctx, `
+	_, err := r.conn(ctx).Exec(ctx, `
         INSERT INTO chamada_auditoria (aula_destino, aula_origem, merge_biometria, user_id)
         VALUES ($1, $2, $3, $4)
     `, destino, origem, merge, user)
@@ -598,11 +791,11 @@ func (r *Repository) MatriculasByTurma(ctx context.Context, turmaID uuid.UUID) (
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT m.aluno_id, m.id
         FROM matriculas m
-        WHERE m.turma_id = $1 AND m.ativo = TRUE
-    `, turmaID)
+        WHERE m.turma_id = $1 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$2")+`
+    `, turmaID, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -619,34 +812,42 @@ func (r *Repository) MatriculasByTurma(ctx context.Context, turmaID uuid.UUID) (
 	return out, rows.Err()
 }
 
-func (r *Repository) ListAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID) ([]DiarioEntrada, error) {
+func (r *Repository) ListAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID, params ListaParams) (Pagina[DiarioEntrada], error) {
+	pagina := Pagina[DiarioEntrada]{Limit: params.Limit, Offset: params.Offset}
 	if err := r.EnsureProfessorAluno(ctx, professorID, alunoID); err != nil {
-		return nil, err
+		return pagina, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
-        SELECT id, professor_id, aluno_id, turma_id, conteudo, criado_em, atualizado_em
+	const filtro = `
         FROM professor_diario_aluno
         WHERE professor_id = $1 AND aluno_id = $2
-        ORDER BY COALESCE(atualizado_em, criado_em) DESC
-    `, professorID, alunoID)
+          AND ($3 = '' OR conteudo ILIKE '%' || $3 || '%')
+    `
+	if err := r.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) `+filtro, professorID, alunoID, params.Busca).Scan(&pagina.Total); err != nil {
+		return pagina, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT id, professor_id, aluno_id, turma_id, conteudo, criado_em, atualizado_em `+filtro+`
+        ORDER BY `+ordemDiario.clausula(params.Ordem)+`
+        LIMIT $4 OFFSET $5
+    `, professorID, alunoID, params.Busca, params.Limit, params.Offset)
 	if err != nil {
-		return nil, err
+		return pagina, err
 	}
 	defer rows.Close()
 
-	var entradas []DiarioEntrada
 	for rows.Next() {
 		var entry DiarioEntrada
 		if err := rows.Scan(&entry.ID, &entry.ProfessorID, &entry.AlunoID, &entry.TurmaID, &entry.Conteudo, &entry.CriadoEm, &entry.AtualizadoEm); err != nil {
-			return nil, err
+			return pagina, err
 		}
-		entradas = append(entradas, entry)
+		pagina.Itens = append(pagina.Itens, entry)
 	}
-	return entradas, rows.Err()
+	return pagina, rows.Err()
 }
 
 func (r *Repository) CreateAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID, turmaID *uuid.UUID, conteudo string) (DiarioEntrada, error) {
@@ -662,7 +863,7 @@ func (r *Repository) CreateAlunoDiario(ctx context.Context, professorID, alunoID
 	defer cancel()
 
 	var entry DiarioEntrada
-	err = r.db.QueryRow(ctx, `
+	err = r.conn(ctx).QueryRow(ctx, `
         INSERT INTO professor_diario_aluno (professor_id, aluno_id, turma_id, conteudo)
         VALUES ($1, $2, $3, $4)
         RETURNING id, professor_id, aluno_id, turma_id, conteudo, criado_em, atualizado_em
@@ -682,7 +883,7 @@ func (r *Repository) UpdateAlunoDiario(ctx context.Context, professorID, alunoID
 	defer cancel()
 
 	var entry DiarioEntrada
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
         UPDATE professor_diario_aluno
         SET conteudo = $1, atualizado_em = now()
         WHERE id = $2 AND professor_id = $3 AND aluno_id = $4
@@ -705,7 +906,7 @@ func (r *Repository) DeleteAlunoDiario(ctx context.Context, professorID, alunoID
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	cmd, err := r.db.Exec(ctx, `
+	cmd, err := r.conn(ctx).Exec(ctx, `
         DELETE FROM professor_diario_aluno
         WHERE id = $1 AND professor_id = $2 AND aluno_id = $3
     `, anotacaoID, professorID, alunoID)
@@ -718,76 +919,362 @@ func (r *Repository) DeleteAlunoDiario(ctx context.Context, professorID, alunoID
 	return nil
 }
 
-func (r *Repository) ListMateriais(ctx context.Context, professorID, turmaID uuid.UUID) ([]Material, error) {
-	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+// ListNotificacoes lista a caixa do professor; lembretes de chamada já registrada saem como resolvidos.
+func (r *Repository) ListNotificacoes(ctx context.Context, professorID uuid.UUID, apenasNaoLidas bool) ([]Notificacao, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT n.id, n.tipo, n.titulo, n.mensagem, n.referencia_id,
+               n.tipo = 'CHAMADA_PENDENTE' AND EXISTS (SELECT 1 FROM presencas p WHERE p.aula_id = n.referencia_id) AS resolvida,
+               n.lida_em, n.created_at
+        FROM professor_notificacoes n
+        WHERE n.professor_id = $1
+          AND (NOT $2 OR n.lida_em IS NULL)
+        ORDER BY n.created_at DESC
+        LIMIT 100
+    `, professorID, apenasNaoLidas)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
+	notificacoes := make([]Notificacao, 0)
+	for rows.Next() {
+		var n Notificacao
+		if err := rows.Scan(&n.ID, &n.Tipo, &n.Titulo, &n.Mensagem, &n.ReferenciaID, &n.Resolvida, &n.LidaEm, &n.CriadoEm); err != nil {
+			return nil, err
+		}
+		notificacoes = append(notificacoes, n)
+	}
+	return notificacoes, rows.Err()
+}
+
+func (r *Repository) MarcarNotificacaoLida(ctx context.Context, professorID, notificacaoID uuid.UUID) error {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
-        SELECT id, turma_id, professor_id, titulo, descricao, url, criado_em
-        FROM materiais
-        WHERE turma_id = $1
-        ORDER BY criado_em DESC
-    `, turmaID)
+	cmd, err := r.conn(ctx).Exec(ctx, `
+        UPDATE professor_notificacoes
+        SET lida_em = COALESCE(lida_em, now())
+        WHERE id = $1 AND professor_id = $2
+    `, notificacaoID, professorID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const materialColumns = `
+        m.id, m.turma_id, m.professor_id, m.titulo, m.descricao, m.url, m.quantidade,
+        COALESCE((SELECT SUM(e.quantidade) FROM materiais_emprestimos e WHERE e.material_id = m.id AND e.devolvido_em IS NULL), 0),
+        m.criado_em
+`
+
+func scanMaterial(row pgx.Row) (Material, error) {
+	var m Material
+	if err := row.Scan(&m.ID, &m.TurmaID, &m.ProfessorID, &m.Titulo, &m.Descricao, &m.URL, &m.Quantidade, &m.Emprestados, &m.CriadoEm); err != nil {
+		return Material{}, err
+	}
+	if m.Quantidade != nil {
+		disponivel := *m.Quantidade - m.Emprestados
+		m.Disponivel = &disponivel
+	}
+	return m, nil
+}
+
+func (r *Repository) ListMateriais(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Material], error) {
+	pagina := Pagina[Material]{Limit: params.Limit, Offset: params.Offset}
+	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return pagina, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	filtro := `
+        FROM materiais m
+        WHERE m.turma_id = $1 AND ` + db.TurmaNoTenant("m.turma_id", "$3") + `
+          AND ($2 = '' OR m.titulo ILIKE '%' || $2 || '%')
+    `
+	tenantID := httpmiddleware.TenantScope(ctx)
+	if err := r.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) `+filtro, turmaID, params.Busca, tenantID).Scan(&pagina.Total); err != nil {
+		return pagina, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+materialColumns+filtro+`
+        ORDER BY `+ordemMateriais.clausula(params.Ordem)+`
+        LIMIT $4 OFFSET $5
+    `, turmaID, params.Busca, tenantID, params.Limit, params.Offset)
+	if err != nil {
+		return pagina, err
 	}
 	defer rows.Close()
 
-	var materiais []Material
 	for rows.Next() {
-		var m Material
-		if err := rows.Scan(&m.ID, &m.TurmaID, &m.ProfessorID, &m.Titulo, &m.Descricao, &m.URL, &m.CriadoEm); err != nil {
-			return nil, err
+		m, err := scanMaterial(rows)
+		if err != nil {
+			return pagina, err
 		}
-		materiais = append(materiais, m)
+		pagina.Itens = append(pagina.Itens, m)
+	}
+	return pagina, rows.Err()
+}
+
+// CreateMaterial grava um material; quantidade informada indica item físico em estoque.
+func (r *Repository) CreateMaterial(ctx context.Context, professorID, turmaID uuid.UUID, titulo string, descricao, url *string, quantidade *int) (Material, error) {
+	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return Material{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO materiais (turma_id, professor_id, titulo, descricao, url, quantidade)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, turmaID, professorID, titulo, descricao, url, quantidade).Scan(&id)
+	if err != nil {
+		return Material{}, err
 	}
-	return materiais, rows.Err()
+	return scanMaterial(r.conn(ctx).QueryRow(ctx, `SELECT `+materialColumns+` FROM materiais m WHERE m.id = $1`, id))
 }
 
-func (r *Repository) CreateMaterial(ctx context.Context, professorID, turmaID uuid.UUID, titulo string, descricao, url *string) (Material, error) {
+// materialDoProfessor garante que o material pertence a uma turma do professor.
+func (r *Repository) materialDoProfessor(ctx context.Context, professorID, materialID uuid.UUID) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var turmaID uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `SELECT turma_id FROM materiais WHERE id = $1 AND `+db.TurmaNoTenant("turma_id", "$2"), materialID, httpmiddleware.TenantScope(ctx)).Scan(&turmaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrNotFound
+		}
+		return uuid.Nil, err
+	}
 	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return uuid.Nil, err
+	}
+	return turmaID, nil
+}
+
+// AtualizarEstoque ajusta a quantidade de um material físico; não pode ficar abaixo do que está emprestado.
+func (r *Repository) AtualizarEstoque(ctx context.Context, professorID, materialID uuid.UUID, quantidade int) (Material, error) {
+	if _, err := r.materialDoProfessor(ctx, professorID, materialID); err != nil {
 		return Material{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	var material Material
-	err := r.db.QueryRow(ctx, `
-        INSERT INTO materiais (turma_id, professor_id, titulo, descricao, url)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id, turma_id, professor_id, titulo, descricao, url, criado_em
-    `, turmaID, professorID, titulo, descricao, url).Scan(&material.ID, &material.TurmaID, &material.ProfessorID, &material.Titulo, &material.Descricao, &material.URL, &material.CriadoEm)
+	cmd, err := r.conn(ctx).Exec(ctx, `
+        UPDATE materiais m
+        SET quantidade = $2
+        WHERE m.id = $1
+          AND $2 >= COALESCE((SELECT SUM(e.quantidade) FROM materiais_emprestimos e WHERE e.material_id = m.id AND e.devolvido_em IS NULL), 0)
+    `, materialID, quantidade)
 	if err != nil {
 		return Material{}, err
 	}
-	return material, nil
+	if cmd.RowsAffected() == 0 {
+		return Material{}, ErrEstoqueInsuficiente
+	}
+	return scanMaterial(r.conn(ctx).QueryRow(ctx, `SELECT `+materialColumns+` FROM materiais m WHERE m.id = $1`, materialID))
+}
+
+const emprestimoColumns = `
+        e.id, e.material_id, m.titulo, m.turma_id, e.aluno_id, a.nome, e.quantidade, e.emprestado_em,
+        e.devolver_ate, e.devolvido_em, e.observacao,
+        e.devolvido_em IS NULL AND e.devolver_ate < CURRENT_DATE
+`
+
+func scanEmprestimos(rows pgx.Rows) ([]Emprestimo, error) {
+	emprestimos := make([]Emprestimo, 0)
+	for rows.Next() {
+		var e Emprestimo
+		if err := rows.Scan(&e.ID, &e.MaterialID, &e.Material, &e.TurmaID, &e.AlunoID, &e.Aluno, &e.Quantidade, &e.EmprestadoEm,
+			&e.DevolverAte, &e.DevolvidoEm, &e.Observacao, &e.Atrasado); err != nil {
+			return nil, err
+		}
+		emprestimos = append(emprestimos, e)
+	}
+	return emprestimos, rows.Err()
+}
+
+func (r *Repository) ListEmprestimos(ctx context.Context, professorID, materialID uuid.UUID, apenasAtivos bool) ([]Emprestimo, error) {
+	if _, err := r.materialDoProfessor(ctx, professorID, materialID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+emprestimoColumns+`
+        FROM materiais_emprestimos e
+        JOIN materiais m ON m.id = e.material_id
+        JOIN alunos a ON a.id = e.aluno_id
+        WHERE e.material_id = $1 AND (NOT $2 OR e.devolvido_em IS NULL)
+        ORDER BY e.devolvido_em IS NULL DESC, e.devolver_ate, a.nome
+    `, materialID, apenasAtivos)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEmprestimos(rows)
+}
+
+// Emprestar registra a retirada validando matrícula do aluno na turma e saldo em estoque.
+func (r *Repository) Emprestar(ctx context.Context, professorID, materialID, alunoID uuid.UUID, quantidade int, devolverAte time.Time, observacao *string) (Emprestimo, error) {
+	turmaID, err := r.materialDoProfessor(ctx, professorID, materialID)
+	if err != nil {
+		return Emprestimo{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return Emprestimo{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var estoque *int
+	if err := tx.QueryRow(ctx, `SELECT quantidade FROM materiais WHERE id = $1 FOR UPDATE`, materialID).Scan(&estoque); err != nil {
+		return Emprestimo{}, err
+	}
+	if estoque == nil {
+		return Emprestimo{}, ErrMaterialDigital
+	}
+
+	var emprestados int
+	if err := tx.QueryRow(ctx, `
+        SELECT COALESCE(SUM(quantidade), 0) FROM materiais_emprestimos WHERE material_id = $1 AND devolvido_em IS NULL
+    `, materialID).Scan(&emprestados); err != nil {
+		return Emprestimo{}, err
+	}
+	if emprestados+quantidade > *estoque {
+		return Emprestimo{}, ErrEstoqueInsuficiente
+	}
+
+	var matriculado bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS(SELECT 1 FROM matriculas WHERE aluno_id = $1 AND turma_id = $2 AND ativo = TRUE)
+    `, alunoID, turmaID).Scan(&matriculado); err != nil {
+		return Emprestimo{}, err
+	}
+	if !matriculado {
+		return Emprestimo{}, ErrForbidden
+	}
+
+	var id uuid.UUID
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO materiais_emprestimos (material_id, aluno_id, quantidade, devolver_ate, observacao, registrado_por)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, materialID, alunoID, quantidade, devolverAte, observacao, professorID).Scan(&id); err != nil {
+		return Emprestimo{}, err
+	}
+
+	rows, err := tx.Query(ctx, `
+        SELECT `+emprestimoColumns+`
+        FROM materiais_emprestimos e
+        JOIN materiais m ON m.id = e.material_id
+        JOIN alunos a ON a.id = e.aluno_id
+        WHERE e.id = $1
+    `, id)
+	if err != nil {
+		return Emprestimo{}, err
+	}
+	list, err := scanEmprestimos(rows)
+	rows.Close()
+	if err != nil {
+		return Emprestimo{}, err
+	}
+	if len(list) == 0 {
+		return Emprestimo{}, ErrNotFound
+	}
+
+	return list[0], tx.Commit(ctx)
+}
+
+func (r *Repository) Devolver(ctx context.Context, professorID, emprestimoID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var materialID uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT e.material_id
+        FROM materiais_emprestimos e
+        JOIN materiais m ON m.id = e.material_id
+        WHERE e.id = $1 AND `+db.TurmaNoTenant("m.turma_id", "$2")+`
+    `, emprestimoID, httpmiddleware.TenantScope(ctx)).Scan(&materialID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if _, err := r.materialDoProfessor(ctx, professorID, materialID); err != nil {
+		return err
+	}
+
+	_, err := r.conn(ctx).Exec(ctx, `
+        UPDATE materiais_emprestimos SET devolvido_em = now()
+        WHERE id = $1 AND devolvido_em IS NULL
+    `, emprestimoID)
+	return err
+}
+
+// ListEmprestimosAtrasados lista empréstimos vencidos nas turmas do professor.
+func (r *Repository) ListEmprestimosAtrasados(ctx context.Context, professorID uuid.UUID) ([]Emprestimo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+emprestimoColumns+`
+        FROM materiais_emprestimos e
+        JOIN materiais m ON m.id = e.material_id
+        JOIN alunos a ON a.id = e.aluno_id
+        JOIN professores_turmas pt ON pt.turma_id = m.turma_id AND pt.professor_id = $1
+        WHERE e.devolvido_em IS NULL AND e.devolver_ate < CURRENT_DATE AND `+db.TurmaNoTenant("m.turma_id", "$2")+`
+        ORDER BY e.devolver_ate, a.nome
+    `, professorID, httpmiddleware.TenantScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEmprestimos(rows)
 }
 
 func (r *Repository) ListAgenda(ctx context.Context, professorID uuid.UUID, from, to time.Time) ([]AgendaItem, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT * FROM (
             SELECT a.id, 'AULA' AS tipo, a.turma_id, t.nome, a.disciplina AS titulo, a.inicio, a.fim
             FROM aulas a
             JOIN turmas t ON t.id = a.turma_id
             JOIN professores_turmas pt ON pt.turma_id = a.turma_id AND pt.professor_id = $1
-            WHERE a.inicio BETWEEN $2 AND $3
+            WHERE a.inicio BETWEEN $2 AND $3 AND `+db.TurmaNoTenant("a.turma_id", "$4")+`
             UNION ALL
             SELECT av.id, 'AVALIACAO' AS tipo, av.turma_id, t.nome, av.titulo, COALESCE(av.inicio, av.created_at), av.fim
             FROM avaliacoes av
             JOIN turmas t ON t.id = av.turma_id
             JOIN professores_turmas pt ON pt.turma_id = av.turma_id AND pt.professor_id = $1
-            WHERE COALESCE(av.inicio, av.created_at) BETWEEN $2 AND $3
+            WHERE COALESCE(av.inicio, av.created_at) BETWEEN $2 AND $3 AND `+db.TurmaNoTenant("av.turma_id", "$4")+`
         ) eventos
         ORDER BY inicio
-    `, professorID, from, to)
+    `, professorID, from, to, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -804,7 +1291,7 @@ func (r *Repository) ListAgenda(ctx context.Context, professorID uuid.UUID, from
 	return agenda, rows.Err()
 }
 
-func (r *Repository) RelatorioFrequencia(ctx context.Context, professorID, turmaID uuid.UUID, from, to time.Time) ([]FrequenciaAluno, error) {
+func (r *Repository) RelatorioFrequencia(ctx context.Context, professorID, turmaID uuid.UUID, from, to time.Time, anoLetivo int) ([]FrequenciaAluno, error) {
 	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
 		return nil, err
 	}
@@ -812,7 +1299,7 @@ func (r *Repository) RelatorioFrequencia(ctx context.Context, professorID, turma
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.nome, a.matricula,
             SUM(CASE WHEN p.status = 'PRESENTE' THEN 1 ELSE 0 END) AS presentes,
             SUM(CASE WHEN p.status = 'FALTA' THEN 1 ELSE 0 END) AS faltas,
@@ -820,12 +1307,13 @@ func (r *Repository) RelatorioFrequencia(ctx context.Context, professorID, turma
             COUNT(p.status) AS total
         FROM matriculas m
         JOIN alunos a ON a.id = m.aluno_id
-        LEFT JOIN aulas au ON au.turma_id = m.turma_id AND au.inicio BETWEEN $2 AND $3
+        LEFT JOIN aulas au ON au.turma_id = m.turma_id AND au.ano_letivo = $4 AND au.inicio BETWEEN $2 AND $3
         LEFT JOIN presencas p ON p.aula_id = au.id AND p.matricula_id = m.id
-        WHERE m.turma_id = $1 AND m.ativo = TRUE
+            AND p.aula_inicio = au.inicio AND p.aula_inicio BETWEEN $2 AND $3
+        WHERE m.turma_id = $1 AND m.ano_letivo = $4 AND m.ativo = TRUE
         GROUP BY a.id, a.nome, a.matricula
         ORDER BY a.nome
-    `, turmaID, from, to)
+    `, turmaID, from, to, anoLetivo)
 	if err != nil {
 		return nil, err
 	}
@@ -842,7 +1330,7 @@ func (r *Repository) RelatorioFrequencia(ctx context.Context, professorID, turma
 	return relatorio, rows.Err()
 }
 
-func (r *Repository) RelatorioAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, bimestre int) ([]RelatorioAvaliacao, error) {
+func (r *Repository) RelatorioAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) ([]RelatorioAvaliacao, error) {
 	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
 		return nil, err
 	}
@@ -850,14 +1338,14 @@ func (r *Repository) RelatorioAvaliacoes(ctx context.Context, professorID, turma
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT av.id, av.titulo, av.disciplina, $2::int AS bimestre, AVG(n.nota), av.inicio, av.status
         FROM avaliacoes av
-        LEFT JOIN notas n ON n.turma_id = av.turma_id AND n.disciplina = av.disciplina AND n.bimestre = $2
-        WHERE av.turma_id = $1
+        LEFT JOIN notas n ON n.turma_id = av.turma_id AND n.disciplina = av.disciplina AND n.bimestre = $2 AND n.ano_letivo = av.ano_letivo
+        WHERE av.turma_id = $1 AND av.ano_letivo = $3
         GROUP BY av.id, av.titulo, av.disciplina, av.inicio, av.status
         ORDER BY av.inicio DESC NULLS LAST, av.created_at DESC
-    `, turmaID, bimestre)
+    `, turmaID, bimestre, anoLetivo)
 	if err != nil {
 		return nil, err
 	}
@@ -874,19 +1362,21 @@ func (r *Repository) RelatorioAvaliacoes(ctx context.Context, professorID, turma
 	return relatorio, rows.Err()
 }
 
-func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UUID) (DashboardAnalytics, error) {
+func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UUID, anoLetivo int) (DashboardAnalytics, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
+	tenantID := httpmiddleware.TenantScope(ctx)
 
 	// Médias por turma
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT t.id, t.nome, COALESCE(AVG(n.nota), 0)
         FROM turmas t
         JOIN professores_turmas pt ON pt.turma_id = t.id AND pt.professor_id = $1
-        LEFT JOIN notas n ON n.turma_id = t.id
+        LEFT JOIN notas n ON n.turma_id = t.id AND n.ano_letivo = $2
+        WHERE `+db.TurmaNoTenant("t.id", "$3")+`
         GROUP BY t.id, t.nome
         ORDER BY t.nome
-    `, professorID)
+    `, professorID, anoLetivo, tenantID)
 	if err != nil {
 		return DashboardAnalytics{}, err
 	}
@@ -905,17 +1395,18 @@ func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UU
 	}
 
 	// Top alunos
-	topRows, err := r.db.Query(ctx, `
+	topRows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.nome, t.nome, AVG(n.nota) AS media
         FROM notas n
         JOIN matriculas m ON m.id = n.matricula_id
         JOIN alunos a ON a.id = m.aluno_id
         JOIN turmas t ON t.id = n.turma_id
         JOIN professores_turmas pt ON pt.turma_id = t.id AND pt.professor_id = $1
+        WHERE n.ano_letivo = $2 AND `+db.TurmaNoTenant("t.id", "$3")+`
         GROUP BY a.id, a.nome, t.nome
         ORDER BY media DESC
         LIMIT 10
-    `, professorID)
+    `, professorID, anoLetivo, tenantID)
 	if err != nil {
 		return DashboardAnalytics{}, err
 	}
@@ -935,16 +1426,17 @@ func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UU
 
 	// Frequência por turma (últimos 30 dias)
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
-	freqRows, err := r.db.Query(ctx, `
+	freqRows, err := r.conn(ctx).Query(ctx, `
         SELECT t.id, t.nome,
             COALESCE(SUM(CASE WHEN p.status = 'PRESENTE' THEN 1 ELSE 0 END)::float / NULLIF(COUNT(p.status),0), 0)
         FROM turmas t
         JOIN professores_turmas pt ON pt.turma_id = t.id AND pt.professor_id = $1
-        LEFT JOIN aulas a ON a.turma_id = t.id AND a.inicio >= $2
-        LEFT JOIN presencas p ON p.aula_id = a.id
+        LEFT JOIN aulas a ON a.turma_id = t.id AND a.ano_letivo = $3 AND a.inicio >= $2
+        LEFT JOIN presencas p ON p.aula_id = a.id AND p.aula_inicio = a.inicio AND p.aula_inicio >= $2
+        WHERE `+db.TurmaNoTenant("t.id", "$4")+`
         GROUP BY t.id, t.nome
         ORDER BY t.nome
-    `, professorID, thirtyDaysAgo)
+    `, professorID, thirtyDaysAgo, anoLetivo, tenantID)
 	if err != nil {
 		return DashboardAnalytics{}, err
 	}
@@ -963,21 +1455,22 @@ func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UU
 	}
 
 	// Alertas (alunos com presença < 75% no período)
-	alertRows, err := r.db.Query(ctx, `
+	alertRows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.nome, t.nome,
             COALESCE(SUM(CASE WHEN p.status = 'PRESENTE' THEN 1 ELSE 0 END)::float / NULLIF(COUNT(p.status),0), 0) AS freq
         FROM matriculas m
         JOIN alunos a ON a.id = m.aluno_id
         JOIN turmas t ON t.id = m.turma_id
         JOIN professores_turmas pt ON pt.turma_id = t.id AND pt.professor_id = $1
-        LEFT JOIN aulas au ON au.turma_id = t.id AND au.inicio >= $2
+        LEFT JOIN aulas au ON au.turma_id = t.id AND au.ano_letivo = $3 AND au.inicio >= $2
         LEFT JOIN presencas p ON p.aula_id = au.id AND p.matricula_id = m.id
-        WHERE m.ativo = TRUE
+            AND p.aula_inicio = au.inicio AND p.aula_inicio >= $2
+        WHERE m.ativo = TRUE AND m.ano_letivo = $3 AND `+db.TurmaNoTenant("t.id", "$4")+`
         GROUP BY a.id, a.nome, t.nome
         HAVING COALESCE(SUM(CASE WHEN p.status = 'PRESENTE' THEN 1 ELSE 0 END)::float / NULLIF(COUNT(p.status),0), 0) < 0.75
         ORDER BY freq ASC
         LIMIT 10
-    `, professorID, thirtyDaysAgo)
+    `, professorID, thirtyDaysAgo, anoLetivo, tenantID)
 	if err != nil {
 		return DashboardAnalytics{}, err
 	}
@@ -997,6 +1490,7 @@ func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UU
 	}
 
 	return DashboardAnalytics{
+		AnoLetivo:   anoLetivo,
 		Averages:    medias,
 		TopStudents: top,
 		Attendance:  freq,
@@ -1004,110 +1498,262 @@ func (r *Repository) DashboardAnalytics(ctx context.Context, professorID uuid.UU
 	}, nil
 }
 
-func (r *Repository) LivePresence(ctx context.Context, professorID uuid.UUID) ([]LivePresence, error) {
+// TurmaAnalytics monta o drill-down de uma turma: histograma de notas, frequência semanal e comparação com a escola.
+// Bimestre zero considera o ano letivo inteiro; o período [from, to] vale para a frequência.
+func (r *Repository) TurmaAnalytics(ctx context.Context, professorID, turmaID uuid.UUID, anoLetivo, bimestre int, from, to time.Time) (TurmaAnalytics, error) {
+	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return TurmaAnalytics{}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	today := time.Now()
-	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	end := start.Add(24 * time.Hour)
-
-	rows, err := r.db.Query(ctx, `
-        WITH turmas_prof AS (
-            SELECT t.id, t.nome
-            FROM turmas t
-            JOIN professores_turmas pt ON pt.turma_id = t.id
-            WHERE pt.professor_id = $1
-        ),
-        aula_recente AS (
-            SELECT tp.id AS turma_id, tp.nome, a.id AS aula_id, a.inicio
-            FROM turmas_prof tp
-            LEFT JOIN LATERAL (
-                SELECT a1.id, a1.inicio
-                FROM aulas a1
-                WHERE a1.turma_id = tp.id AND a1.inicio >= $2 AND a1.inicio < $3
-                ORDER BY a1.inicio DESC
-                LIMIT 1
-            ) a ON true
-        ),
-        presentes AS (
-            SELECT au.turma_id, COUNT(*) AS total
-            FROM aula_recente au
-            JOIN presencas p ON p.aula_id = au.aula_id AND p.status = 'PRESENTE'
-            GROUP BY au.turma_id
-        ),
-        esperados AS (
-            SELECT tp.id AS turma_id, COUNT(m.id) AS total
-            FROM turmas_prof tp
-            LEFT JOIN matriculas m ON m.turma_id = tp.id AND m.ativo = TRUE
-            GROUP BY tp.id
-        )
-        SELECT au.turma_id,
-               au.nome,
-               COALESCE(pr.total, 0) AS presentes,
-               COALESCE(es.total, 0) AS esperados,
-               au.inicio
-        FROM aula_recente au
-        LEFT JOIN presentes pr ON pr.turma_id = au.turma_id
-        LEFT JOIN esperados es ON es.turma_id = au.turma_id
-        ORDER BY au.nome;
-    `, professorID, start, end)
+	result := TurmaAnalytics{TurmaID: turmaID, AnoLetivo: anoLetivo, Bimestre: bimestre, From: from, To: to}
+	var escolaID *uuid.UUID
+	if err := r.conn(ctx).QueryRow(ctx, `SELECT nome, escola_id FROM turmas WHERE id = $1 AND `+db.TurmaNoTenant("id", "$2"), turmaID, httpmiddleware.TenantScope(ctx)).Scan(&result.Turma, &escolaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TurmaAnalytics{}, ErrNotFound
+		}
+		return TurmaAnalytics{}, err
+	}
+
+	result.Distribuicao = []FaixaNota{
+		{Faixa: "0-2", Min: 0, Max: 2},
+		{Faixa: "2-4", Min: 2, Max: 4},
+		{Faixa: "4-6", Min: 4, Max: 6},
+		{Faixa: "6-8", Min: 6, Max: 8},
+		{Faixa: "8-10", Min: 8, Max: 10},
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT LEAST(FLOOR(n.nota / 2), 4)::int AS faixa, COUNT(*)
+        FROM notas n
+        WHERE n.turma_id = $1 AND n.ano_letivo = $2 AND ($3 = 0 OR n.bimestre = $3)
+        GROUP BY faixa
+    `, turmaID, anoLetivo, bimestre)
 	if err != nil {
-		return nil, err
+		return TurmaAnalytics{}, err
 	}
 	defer rows.Close()
-
-	var result []LivePresence
 	for rows.Next() {
-		var item LivePresence
-		var inicio *time.Time
-		if err := rows.Scan(&item.TurmaID, &item.Turma, &item.Presentes, &item.Esperados, &inicio); err != nil {
-			return nil, err
+		var faixa, total int
+		if err := rows.Scan(&faixa, &total); err != nil {
+			return TurmaAnalytics{}, err
 		}
-		item.AtualizadoEm = inicio
-		if item.Esperados > 0 {
-			item.Percentual = float64(item.Presentes) / float64(item.Esperados)
+		if faixa >= 0 && faixa < len(result.Distribuicao) {
+			result.Distribuicao[faixa].Quantidade = total
 		}
-		result = append(result, item)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return TurmaAnalytics{}, err
 	}
 
+	turma := analyticsEscopo{TurmaID: &turmaID}
+	if result.FrequenciaSemanal, err = r.frequenciaSemanal(ctx, turma, from, to); err != nil {
+		return TurmaAnalytics{}, err
+	}
+	if result.Comparativo.Media, err = r.mediaNotas(ctx, turma, anoLetivo, bimestre); err != nil {
+		return TurmaAnalytics{}, err
+	}
+	if result.Comparativo.Frequencia, err = r.frequenciaPeriodo(ctx, turma, from, to); err != nil {
+		return TurmaAnalytics{}, err
+	}
+	if escolaID != nil {
+		escola := analyticsEscopo{EscolaID: escolaID}
+		if result.Comparativo.MediaEscola, err = r.mediaNotas(ctx, escola, anoLetivo, bimestre); err != nil {
+			return TurmaAnalytics{}, err
+		}
+		if result.Comparativo.FrequenciaEscola, err = r.frequenciaPeriodo(ctx, escola, from, to); err != nil {
+			return TurmaAnalytics{}, err
+		}
+	}
 	return result, nil
 }
 
-func (r *Repository) ListAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID) ([]Avaliacao, error) {
+// AlunoAnalytics detalha notas por disciplina e frequência semanal de um aluno da turma, comparando com turma e escola.
+func (r *Repository) AlunoAnalytics(ctx context.Context, professorID, turmaID, alunoID uuid.UUID, anoLetivo, bimestre int, from, to time.Time) (AlunoAnalytics, error) {
 	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
-		return nil, err
+		return AlunoAnalytics{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
-        SELECT a.id, a.turma_id, a.disciplina, a.titulo, a.tipo, a.status, a.inicio, a.peso, a.created_at, a.created_by
-        FROM avaliacoes a
-        WHERE a.turma_id = $1
-        ORDER BY a.created_at DESC
-    `, turmaID)
+	result := AlunoAnalytics{AlunoID: alunoID, TurmaID: turmaID, AnoLetivo: anoLetivo, Bimestre: bimestre, From: from, To: to}
+	var matriculaID uuid.UUID
+	var escolaID *uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT m.id, a.nome, t.escola_id
+        FROM matriculas m
+        JOIN alunos a ON a.id = m.aluno_id
+        JOIN turmas t ON t.id = m.turma_id
+        WHERE m.turma_id = $1 AND m.aluno_id = $2 AND m.ano_letivo = $3 AND `+db.TurmaNoTenant("t.id", "$4")+`
+        ORDER BY m.ativo DESC
+        LIMIT 1
+    `, turmaID, alunoID, anoLetivo, httpmiddleware.TenantScope(ctx)).Scan(&matriculaID, &result.Nome, &escolaID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AlunoAnalytics{}, ErrNotFound
+		}
+		return AlunoAnalytics{}, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT disciplina, bimestre, nota
+        FROM notas
+        WHERE matricula_id = $1 AND turma_id = $2 AND ano_letivo = $3 AND ($4 = 0 OR bimestre = $4)
+        ORDER BY disciplina, bimestre
+    `, matriculaID, turmaID, anoLetivo, bimestre)
+	if err != nil {
+		return AlunoAnalytics{}, err
+	}
+	defer rows.Close()
+	result.Notas = []NotaDisciplina{}
+	for rows.Next() {
+		var item NotaDisciplina
+		if err := rows.Scan(&item.Disciplina, &item.Bimestre, &item.Nota); err != nil {
+			return AlunoAnalytics{}, err
+		}
+		result.Notas = append(result.Notas, item)
+	}
+	if err := rows.Err(); err != nil {
+		return AlunoAnalytics{}, err
+	}
+
+	aluno := analyticsEscopo{TurmaID: &turmaID, MatriculaID: &matriculaID}
+	turma := analyticsEscopo{TurmaID: &turmaID}
+	if result.FrequenciaSemanal, err = r.frequenciaSemanal(ctx, aluno, from, to); err != nil {
+		return AlunoAnalytics{}, err
+	}
+	comp := &result.Comparativo
+	if comp.Media, err = r.mediaNotas(ctx, aluno, anoLetivo, bimestre); err != nil {
+		return AlunoAnalytics{}, err
+	}
+	if comp.MediaTurma, err = r.mediaNotas(ctx, turma, anoLetivo, bimestre); err != nil {
+		return AlunoAnalytics{}, err
+	}
+	if comp.Frequencia, err = r.frequenciaPeriodo(ctx, aluno, from, to); err != nil {
+		return AlunoAnalytics{}, err
+	}
+	if comp.FrequenciaTurma, err = r.frequenciaPeriodo(ctx, turma, from, to); err != nil {
+		return AlunoAnalytics{}, err
+	}
+	if escolaID != nil {
+		escola := analyticsEscopo{EscolaID: escolaID}
+		if comp.MediaEscola, err = r.mediaNotas(ctx, escola, anoLetivo, bimestre); err != nil {
+			return AlunoAnalytics{}, err
+		}
+		if comp.FrequenciaEscola, err = r.frequenciaPeriodo(ctx, escola, from, to); err != nil {
+			return AlunoAnalytics{}, err
+		}
+	}
+	return result, nil
+}
+
+func (r *Repository) frequenciaSemanal(ctx context.Context, escopo analyticsEscopo, from, to time.Time) ([]SemanaFrequencia, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT date_trunc('week', a.inicio AT TIME ZONE 'UTC')::date AS semana,
+               COUNT(*) FILTER (WHERE p.status IN ('PRESENTE', 'ATRASO')),
+               COUNT(*)
+        FROM aulas a
+        JOIN turmas t ON t.id = a.turma_id
+        JOIN presencas p ON p.aula_id = a.id AND p.aula_inicio = a.inicio
+        WHERE a.inicio >= $1 AND a.inicio < $2
+          AND p.aula_inicio >= $1 AND p.aula_inicio < $2
+          AND ($3::uuid IS NULL OR t.escola_id = $3)
+          AND ($4::uuid IS NULL OR a.turma_id = $4)
+          AND ($5::uuid IS NULL OR p.matricula_id = $5)
+        GROUP BY semana
+        ORDER BY semana
+    `, from, to.AddDate(0, 0, 1), escopo.EscolaID, escopo.TurmaID, escopo.MatriculaID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var list []Avaliacao
+	list := []SemanaFrequencia{}
 	for rows.Next() {
-		var av Avaliacao
-		if err := rows.Scan(&av.ID, &av.TurmaID, &av.Disciplina, &av.Titulo, &av.Tipo, &av.Status, &av.Data, &av.Peso, &av.CreatedAt, &av.CreatedBy); err != nil {
+		var item SemanaFrequencia
+		if err := rows.Scan(&item.Semana, &item.Presentes, &item.Total); err != nil {
 			return nil, err
 		}
-		list = append(list, av)
+		if item.Total > 0 {
+			item.Frequencia = float64(item.Presentes) / float64(item.Total)
+		}
+		list = append(list, item)
 	}
 	return list, rows.Err()
 }
 
-func (r *Repository) InsertAvaliacao(ctx context.Context, turmaID, professorID uuid.UUID, tipo, titulo, disciplina string, data *time.Time, peso float64) (uuid.UUID, error) {
+func (r *Repository) frequenciaPeriodo(ctx context.Context, escopo analyticsEscopo, from, to time.Time) (*float64, error) {
+	var freq *float64
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT COUNT(*) FILTER (WHERE p.status IN ('PRESENTE', 'ATRASO'))::float / NULLIF(COUNT(*), 0)
+        FROM aulas a
+        JOIN turmas t ON t.id = a.turma_id
+        JOIN presencas p ON p.aula_id = a.id AND p.aula_inicio = a.inicio
+        WHERE a.inicio >= $1 AND a.inicio < $2
+          AND p.aula_inicio >= $1 AND p.aula_inicio < $2
+          AND ($3::uuid IS NULL OR t.escola_id = $3)
+          AND ($4::uuid IS NULL OR a.turma_id = $4)
+          AND ($5::uuid IS NULL OR p.matricula_id = $5)
+    `, from, to.AddDate(0, 0, 1), escopo.EscolaID, escopo.TurmaID, escopo.MatriculaID).Scan(&freq)
+	return freq, err
+}
+
+func (r *Repository) mediaNotas(ctx context.Context, escopo analyticsEscopo, anoLetivo, bimestre int) (*float64, error) {
+	var media *float64
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT AVG(n.nota)::float
+        FROM notas n
+        JOIN turmas t ON t.id = n.turma_id
+        WHERE n.ano_letivo = $1 AND ($2 = 0 OR n.bimestre = $2)
+          AND ($3::uuid IS NULL OR t.escola_id = $3)
+          AND ($4::uuid IS NULL OR n.turma_id = $4)
+          AND ($5::uuid IS NULL OR n.matricula_id = $5)
+    `, anoLetivo, bimestre, escopo.EscolaID, escopo.TurmaID, escopo.MatriculaID).Scan(&media)
+	return media, err
+}
+
+func (r *Repository) ListAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Avaliacao], error) {
+	pagina := Pagina[Avaliacao]{Limit: params.Limit, Offset: params.Offset}
+	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return pagina, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	filtro := `
+        FROM avaliacoes a
+        WHERE a.turma_id = $1 AND ` + db.TurmaNoTenant("a.turma_id", "$3") + `
+          AND ($2 = '' OR a.titulo ILIKE '%' || $2 || '%' OR a.disciplina ILIKE '%' || $2 || '%')
+    `
+	tenantID := httpmiddleware.TenantScope(ctx)
+	if err := r.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) `+filtro, turmaID, params.Busca, tenantID).Scan(&pagina.Total); err != nil {
+		return pagina, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.id, a.turma_id, a.disciplina, a.titulo, a.tipo, a.status, a.inicio, a.peso, a.ano_letivo, a.created_at, a.created_by `+filtro+`
+        ORDER BY `+ordemAvaliacoes.clausula(params.Ordem)+`
+        LIMIT $4 OFFSET $5
+    `, turmaID, params.Busca, tenantID, params.Limit, params.Offset)
+	if err != nil {
+		return pagina, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var av Avaliacao
+		if err := rows.Scan(&av.ID, &av.TurmaID, &av.Disciplina, &av.Titulo, &av.Tipo, &av.Status, &av.Data, &av.Peso, &av.AnoLetivo, &av.CreatedAt, &av.CreatedBy); err != nil {
+			return pagina, err
+		}
+		pagina.Itens = append(pagina.Itens, av)
+	}
+	return pagina, rows.Err()
+}
+
+func (r *Repository) InsertAvaliacao(ctx context.Context, turmaID, professorID uuid.UUID, tipo, titulo, disciplina string, data *time.Time, peso float64, anoLetivo int) (uuid.UUID, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
@@ -1116,11 +1762,11 @@ func (r *Repository) InsertAvaliacao(ctx context.Context, turmaID, professorID u
 	}
 
 	var avaliacaoID uuid.UUID
-	err := r.db.QueryRow(ctx, `
-        INSERT INTO avaliacoes (turma_id, disciplina, titulo, tipo, status, inicio, peso, created_by)
-        VALUES ($1, $2, $3, $4, 'RASCUNHO', $5, $6, $7)
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO avaliacoes (turma_id, disciplina, titulo, tipo, status, inicio, peso, created_by, ano_letivo)
+        VALUES ($1, $2, $3, $4, 'RASCUNHO', $5, $6, $7, $8)
         RETURNING id
-    `, turmaID, disciplina, titulo, tipo, data, peso, professorID).Scan(&avaliacaoID)
+    `, turmaID, disciplina, titulo, tipo, data, peso, professorID, anoLetivo).Scan(&avaliacaoID)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -1135,7 +1781,7 @@ func (r *Repository) InsertQuestoes(ctx context.Context, avaliacaoID uuid.UUID,
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	tx, err := r.db.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -1161,12 +1807,12 @@ func (r *Repository) GetAvaliacao(ctx context.Context, professorID, avaliacaoID
 	defer cancel()
 
 	var av Avaliacao
-	err := r.db.QueryRow(ctx, `
-        SELECT a.id, a.turma_id, a.disciplina, a.titulo, a.tipo, a.status, a.inicio, a.peso, a.created_at, a.created_by
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT a.id, a.turma_id, a.disciplina, a.titulo, a.tipo, a.status, a.inicio, a.peso, a.ano_letivo, a.created_at, a.created_by
         FROM avaliacoes a
         JOIN professores_turmas pt ON pt.turma_id = a.turma_id
-        WHERE a.id = $1 AND pt.professor_id = $2
-    `, avaliacaoID, professorID).Scan(&av.ID, &av.TurmaID, &av.Disciplina, &av.Titulo, &av.Tipo, &av.Status, &av.Data, &av.Peso, &av.CreatedAt, &av.CreatedBy)
+        WHERE a.id = $1 AND pt.professor_id = $2 AND `+db.TurmaNoTenant("a.turma_id", "$3")+`
+    `, avaliacaoID, professorID, httpmiddleware.TenantScope(ctx)).Scan(&av.ID, &av.TurmaID, &av.Disciplina, &av.Titulo, &av.Tipo, &av.Status, &av.Data, &av.Peso, &av.AnoLetivo, &av.CreatedAt, &av.CreatedBy)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Avaliacao{}, nil, ErrNotFound
@@ -1174,7 +1820,7 @@ func (r *Repository) GetAvaliacao(ctx context.Context, professorID, avaliacaoID
 		return Avaliacao{}, nil, err
 	}
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT id, avaliacao_id, enunciado, alternativas, correta
         FROM aval_questoes
         WHERE avaliacao_id = $1
@@ -1197,17 +1843,44 @@ func (r *Repository) GetAvaliacao(ctx context.Context, professorID, avaliacaoID
 	return av, questoes, rows.Err()
 }
 
+// ListRespostas devolve as alternativas marcadas pelos alunos na avaliação.
+func (r *Repository) ListRespostas(ctx context.Context, avaliacaoID uuid.UUID) ([]RespostaAluno, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT r.matricula_id, r.questao_id, r.alternativa
+        FROM aval_respostas r
+        JOIN avaliacoes av ON av.id = r.avaliacao_id
+        WHERE r.avaliacao_id = $1 AND `+db.TurmaNoTenant("av.turma_id", "$2")+`
+    `, avaliacaoID, httpmiddleware.TenantScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []RespostaAluno
+	for rows.Next() {
+		var item RespostaAluno
+		if err := rows.Scan(&item.MatriculaID, &item.QuestaoID, &item.Alternativa); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
 func (r *Repository) UpdateAvaliacaoStatus(ctx context.Context, professorID, avaliacaoID uuid.UUID, status string) error {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	cmd, err := r.db.Exec(ctx, `
+	cmd, err := r.conn(ctx).Exec(ctx, `
         UPDATE avaliacoes
         SET status = $1
         WHERE id = $2 AND turma_id IN (
             SELECT turma_id FROM professores_turmas WHERE professor_id = $3
-        )
-    `, status, avaliacaoID, professorID)
+        ) AND `+db.TurmaNoTenant("turma_id", "$4")+`
+    `, status, avaliacaoID, professorID, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return err
 	}
@@ -1217,7 +1890,7 @@ func (r *Repository) UpdateAvaliacaoStatus(ctx context.Context, professorID, ava
 	return nil
 }
 
-func (r *Repository) UpsertNotas(ctx context.Context, professorID, avaliacaoID uuid.UUID, disciplina string, turmaID uuid.UUID, bimestre int, notas []NotaLancamento) error {
+func (r *Repository) UpsertNotas(ctx context.Context, professorID, avaliacaoID uuid.UUID, disciplina string, turmaID uuid.UUID, bimestre, anoLetivo int, notas []NotaLancamento) error {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
@@ -1225,31 +1898,90 @@ func (r *Repository) UpsertNotas(ctx context.Context, professorID, avaliacaoID u
 		return err
 	}
 
-	tx, err := r.db.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	batch := &pgx.Batch{}
-	for _, item := range notas {
-		batch.Queue(`
-            INSERT INTO notas (turma_id, disciplina, bimestre, matricula_id, nota, obs)
-            VALUES ($1, $2, $3, $4, $5, $6)
-            ON CONFLICT (turma_id, disciplina, bimestre, matricula_id)
-            DO UPDATE SET nota = EXCLUDED.nota, obs = EXCLUDED.obs
-        `, turmaID, disciplina, bimestre, item.MatriculaID, item.Nota, item.Observacao)
-	}
-
-	br := tx.SendBatch(ctx, batch)
-	if err := br.Close(); err != nil {
+	cols := notaColunas(notas)
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO notas (turma_id, disciplina, bimestre, matricula_id, nota, obs, ano_letivo)
+        SELECT $1, $2, $3, v.matricula_id, v.nota, v.obs, $7
+        FROM unnest($4::uuid[], $5::numeric[], $6::text[]) AS v(matricula_id, nota, obs)
+        ON CONFLICT (ano_letivo, turma_id, disciplina, bimestre, matricula_id)
+        DO UPDATE SET nota = EXCLUDED.nota, obs = EXCLUDED.obs
+    `, turmaID, disciplina, bimestre, cols.matriculas, cols.notas, cols.observacoes, anoLetivo); err != nil {
 		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
-func (r *Repository) ListNotasBimestre(ctx context.Context, professorID, turmaID uuid.UUID, bimestre int) ([]NotaResumo, error) {
+// MatriculasPorCodigo indexa as matrículas ativas da turma pelo código de matrícula do aluno.
+func (r *Repository) MatriculasPorCodigo(ctx context.Context, turmaID uuid.UUID, anoLetivo int) (map[string]uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.matricula, m.id
+        FROM matriculas m
+        JOIN alunos a ON a.id = m.aluno_id
+        WHERE m.turma_id = $1 AND m.ano_letivo = $2 AND m.ativo = TRUE AND a.matricula IS NOT NULL
+          AND `+db.TurmaNoTenant("m.turma_id", "$3")+`
+    `, turmaID, anoLetivo, httpmiddleware.TenantScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var codigo string
+		var matriculaID uuid.UUID
+		if err := rows.Scan(&codigo, &matriculaID); err != nil {
+			return nil, err
+		}
+		out[strings.ToLower(strings.TrimSpace(codigo))] = matriculaID
+	}
+	return out, rows.Err()
+}
+
+// ListNotasDisciplina lista alunos ativos com a nota lançada na disciplina e bimestre (base do modelo de planilha).
+func (r *Repository) ListNotasDisciplina(ctx context.Context, professorID, turmaID uuid.UUID, disciplina string, bimestre, anoLetivo int) ([]NotaResumo, error) {
+	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.id, a.nome, a.matricula, n.nota, n.obs
+        FROM matriculas m
+        JOIN alunos a ON a.id = m.aluno_id
+        LEFT JOIN notas n ON n.matricula_id = m.id AND n.turma_id = $1 AND n.disciplina = $2
+                         AND n.bimestre = $3 AND n.ano_letivo = $4
+        WHERE m.turma_id = $1 AND m.ano_letivo = $4 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$5")+`
+        ORDER BY a.nome
+    `, turmaID, disciplina, bimestre, anoLetivo, httpmiddleware.TenantScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []NotaResumo
+	for rows.Next() {
+		var item NotaResumo
+		if err := rows.Scan(&item.AlunoID, &item.Nome, &item.Matricula, &item.Nota, &item.Observacao); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) ListNotasBimestre(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) ([]NotaResumo, error) {
 	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
 		return nil, err
 	}
@@ -1257,14 +1989,14 @@ func (r *Repository) ListNotasBimestre(ctx context.Context, professorID, turmaID
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
         SELECT a.id, a.nome, a.matricula, n.nota, n.obs
         FROM matriculas m
         JOIN alunos a ON a.id = m.aluno_id
-        LEFT JOIN notas n ON n.matricula_id = m.id AND n.turma_id = $1 AND n.bimestre = $2
-        WHERE m.turma_id = $1 AND m.ativo = TRUE
+        LEFT JOIN notas n ON n.matricula_id = m.id AND n.turma_id = $1 AND n.bimestre = $2 AND n.ano_letivo = $3
+        WHERE m.turma_id = $1 AND m.ano_letivo = $3 AND m.ativo = TRUE AND `+db.TurmaNoTenant("m.turma_id", "$4")+`
         ORDER BY a.nome
-    `, turmaID, bimestre)
+    `, turmaID, bimestre, anoLetivo, httpmiddleware.TenantScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -1281,3 +2013,119 @@ func (r *Repository) ListNotasBimestre(ctx context.Context, professorID, turmaID
 
 	return list, rows.Err()
 }
+
+// PoliticaChamada lê settings.chamada do tenant dono da escola da turma; sem tenant não há restrição.
+func (r *Repository) PoliticaChamada(ctx context.Context, turmaID uuid.UUID) (PoliticaChamada, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var politica PoliticaChamada
+	var raioEscola, raioTenant *int
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT COALESCE((tn.settings->'chamada'->>'geofence_obrigatorio')::boolean, FALSE),
+               COALESCE((tn.settings->'chamada'->>'atestacao_obrigatoria')::boolean, FALSE),
+               (tn.settings->'chamada'->>'raio_metros')::int,
+               e.geofence_raio_metros, e.latitude, e.longitude
+        FROM turmas t
+        JOIN escolas e ON e.id = t.escola_id
+        JOIN tenants tn ON tn.id = e.tenant_id
+        WHERE t.id = $1 AND tn.id = $2
+    `, turmaID, httpmiddleware.TenantScope(ctx)).Scan(&politica.GeofenceObrigatorio, &politica.AtestacaoObrigatoria, &raioTenant, &raioEscola, &politica.Latitude, &politica.Longitude)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PoliticaChamada{}, nil
+		}
+		return PoliticaChamada{}, err
+	}
+	switch {
+	case raioEscola != nil:
+		politica.RaioMetros = *raioEscola
+	case raioTenant != nil:
+		politica.RaioMetros = *raioTenant
+	}
+	return politica, nil
+}
+
+// RegistrarExcecoesChamada guarda as violações aceitas mediante justificativa do professor.
+func (r *Repository) RegistrarExcecoesChamada(ctx context.Context, aulaID, turmaID, professorID uuid.UUID, origem ChamadaOrigem, violacoes []ChamadaViolacao) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, v := range violacoes {
+		batch.Queue(`
+            INSERT INTO chamada_excecoes (aula_id, turma_id, professor_id, tipo, detalhe, motivo, latitude, longitude, distancia_metros)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        `, aulaID, turmaID, professorID, v.Tipo, v.Detalhe, origem.OverrideMotivo, origem.Latitude, origem.Longitude, v.DistanciaMetros)
+	}
+	return r.conn(ctx).SendBatch(ctx, batch).Close()
+}
+
+// TurmaBoletim devolve nome da turma e da escola para o cabeçalho do boletim.
+func (r *Repository) TurmaBoletim(ctx context.Context, professorID, turmaID uuid.UUID) (string, *string, error) {
+	if err := r.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return "", nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var nome string
+	var escola *string
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT t.nome, e.nome
+        FROM turmas t
+        LEFT JOIN escolas e ON e.id = t.escola_id
+        WHERE t.id = $1 AND `+db.TurmaNoTenant("t.id", "$2")+`
+    `, turmaID, httpmiddleware.TenantScope(ctx)).Scan(&nome, &escola); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, ErrNotFound
+		}
+		return "", nil, err
+	}
+	return nome, escola, nil
+}
+
+// NotasBoletim lista as notas lançadas na turma no ano letivo; bimestre zero traz todos.
+func (r *Repository) NotasBoletim(ctx context.Context, turmaID uuid.UUID, bimestre, anoLetivo int) ([]NotaBoletim, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT m.aluno_id, n.disciplina, n.bimestre, n.nota::float8
+        FROM notas n
+        JOIN matriculas m ON m.id = n.matricula_id
+        WHERE n.turma_id = $1 AND n.ano_letivo = $2 AND ($3 = 0 OR n.bimestre = $3)
+          AND `+db.TurmaNoTenant("n.turma_id", "$4")+`
+    `, turmaID, anoLetivo, bimestre, httpmiddleware.TenantScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []NotaBoletim
+	for rows.Next() {
+		var n NotaBoletim
+		if err := rows.Scan(&n.AlunoID, &n.Disciplina, &n.Bimestre, &n.Nota); err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+// PeriodoAnoLetivo devolve início e fim do ano letivo cadastrado na prefeitura; sem cadastro vale
+// o ano civil.
+func (r *Repository) PeriodoAnoLetivo(ctx context.Context, anoLetivo int) (time.Time, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var inicio, fim time.Time
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT inicio, fim FROM anos_letivos WHERE tenant_id = $1 AND ano = $2
+    `, httpmiddleware.TenantScope(ctx), anoLetivo).Scan(&inicio, &fim)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Date(anoLetivo, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(anoLetivo, time.December, 31, 0, 0, 0, 0, time.UTC), nil
+	}
+	return inicio, fim, err
+}
@@ -3,6 +3,7 @@ package prof
 import (
 	"context"
 	"errors"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -14,8 +15,9 @@ import (
 )
 
 type Service struct {
-	users *repo.Queries
-	repo  *Repository
+	users    *repo.Queries
+	repo     *Repository
+	attestor Attestor
 }
 
 type Overview struct {
@@ -33,6 +35,12 @@ func NewService(users *repo.Queries, repository *Repository) *Service {
 	return &Service{users: users, repo: repository}
 }
 
+// WithAttestor define o verificador de atestação de dispositivo usado nas chamadas do app.
+func (s *Service) WithAttestor(attestor Attestor) *Service {
+	s.attestor = attestor
+	return s
+}
+
 func (s *Service) GetOverview(ctx context.Context, professorID uuid.UUID) (*Overview, error) {
 	usuario, err := s.users.GetUsuarioByID(ctx, professorID)
 	if err != nil {
@@ -72,16 +80,11 @@ func (s *Service) ListTurmas(ctx context.Context, professorID uuid.UUID) ([]Turm
 	return s.repo.ListTurmas(ctx, professorID)
 }
 
-func (s *Service) ListAlunosByTurma(ctx context.Context, professorID, turmaID uuid.UUID) ([]Aluno, error) {
+func (s *Service) ListAlunosByTurma(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Aluno], error) {
 	if err := s.repo.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
-		return nil, err
-	}
-
-	alunos, err := s.repo.ListAlunosByTurma(ctx, turmaID)
-	if err != nil {
-		return nil, err
+		return Pagina[Aluno]{}, err
 	}
-	return alunos, nil
+	return s.repo.ListAlunosPagina(ctx, turmaID, params.normalizar())
 }
 
 func (s *Service) FirstTurmaID(ctx context.Context, professorID uuid.UUID) (*uuid.UUID, error) {
@@ -118,6 +121,7 @@ type SalvarChamadaInput struct {
 	Turno      string
 	Disciplina string
 	Itens      []SalvarChamadaItem
+	Origem     ChamadaOrigem
 }
 
 type SalvarChamadaItem struct {
@@ -206,8 +210,17 @@ func (s *Service) SalvarChamada(ctx context.Context, professorID, turmaID uuid.U
 		return uuid.Nil, err
 	}
 
+	violacoes, err := s.validarOrigemChamada(ctx, turmaID, input.Origem)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
 	turno := normalizeTurno(input.Turno)
-	aulaID, err := s.repo.FindOrCreateAula(ctx, turmaID, professorID, input.Data, turno, input.Disciplina)
+	anoLetivo, err := s.repo.AnoLetivo(ctx, professorID, input.Data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	aulaID, err := s.repo.FindOrCreateAula(ctx, turmaID, professorID, input.Data, turno, input.Disciplina, anoLetivo)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -241,15 +254,53 @@ func (s *Service) SalvarChamada(ctx context.Context, professorID, turmaID uuid.U
 		return uuid.Nil, err
 	}
 
+	if len(violacoes) > 0 {
+		if err := s.repo.RegistrarExcecoesChamada(ctx, aulaID, turmaID, professorID, input.Origem, violacoes); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
 	return aulaID, nil
 }
 
-func (s *Service) ListAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID) ([]AlunoDiarioEntrada, error) {
-	entries, err := s.repo.ListAlunoDiario(ctx, professorID, alunoID)
+// validarOrigemChamada aplica a política de geofence/atestação do tenant. Violações só são aceitas
+// com justificativa (OverrideMotivo) e são devolvidas para registro após salvar a chamada.
+func (s *Service) validarOrigemChamada(ctx context.Context, turmaID uuid.UUID, origem ChamadaOrigem) ([]ChamadaViolacao, error) {
+	politica, err := s.repo.PoliticaChamada(ctx, turmaID)
 	if err != nil {
 		return nil, err
 	}
-	return toAlunoDiarioEntrada(entries), nil
+	if !politica.GeofenceObrigatorio && !politica.AtestacaoObrigatoria {
+		return nil, nil
+	}
+
+	var erroAtestacao error
+	if politica.AtestacaoObrigatoria && strings.TrimSpace(origem.Atestacao) != "" {
+		if s.attestor == nil {
+			erroAtestacao = errors.New("atestação de dispositivo não configurada no servidor")
+		} else {
+			erroAtestacao = s.attestor.Verify(ctx, origem.Atestacao, time.Now())
+		}
+	}
+
+	violacoes := avaliarPolitica(politica, origem, erroAtestacao)
+	if len(violacoes) > 0 && strings.TrimSpace(origem.OverrideMotivo) == "" {
+		return nil, &ChamadaBloqueadaError{Violacoes: violacoes}
+	}
+	return violacoes, nil
+}
+
+func (s *Service) ListAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID, params ListaParams) (Pagina[AlunoDiarioEntrada], error) {
+	entries, err := s.repo.ListAlunoDiario(ctx, professorID, alunoID, params.normalizar())
+	if err != nil {
+		return Pagina[AlunoDiarioEntrada]{}, err
+	}
+	return Pagina[AlunoDiarioEntrada]{
+		Itens:  toAlunoDiarioEntrada(entries.Itens),
+		Total:  entries.Total,
+		Limit:  entries.Limit,
+		Offset: entries.Offset,
+	}, nil
 }
 
 func (s *Service) CreateAlunoDiario(ctx context.Context, professorID, alunoID uuid.UUID, conteudo string) (AlunoDiarioEntrada, error) {
@@ -280,6 +331,13 @@ func (s *Service) DeleteAlunoDiario(ctx context.Context, professorID, alunoID, a
 	return s.repo.DeleteAlunoDiario(ctx, professorID, alunoID, anotacaoID)
 }
 
+type EmprestimoInput struct {
+	AlunoID     uuid.UUID
+	Quantidade  int
+	DevolverAte time.Time
+	Observacao  *string
+}
+
 type CreateAvaliacaoInput struct {
 	Tipo       string
 	Titulo     string
@@ -306,8 +364,8 @@ type LancarNotasInput struct {
 	Itens    []LancarNotasItem
 }
 
-func (s *Service) ListAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID) ([]Avaliacao, error) {
-	return s.repo.ListAvaliacoes(ctx, professorID, turmaID)
+func (s *Service) ListAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Avaliacao], error) {
+	return s.repo.ListAvaliacoes(ctx, professorID, turmaID, params.normalizar())
 }
 
 func (s *Service) CreateAvaliacao(ctx context.Context, professorID, turmaID uuid.UUID, input CreateAvaliacaoInput) (uuid.UUID, error) {
@@ -326,7 +384,16 @@ func (s *Service) CreateAvaliacao(ctx context.Context, professorID, turmaID uuid
 		return uuid.Nil, errors.New("disciplina obrigatória")
 	}
 
-	avaliacaoID, err := rInsertAvaliacao(ctx, s.repo, professorID, turmaID, tipo, input.Titulo, disciplina, input.Data, input.Peso)
+	ref := util.Now()
+	if input.Data != nil {
+		ref = *input.Data
+	}
+	anoLetivo, err := s.repo.AnoLetivo(ctx, professorID, ref)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	avaliacaoID, err := rInsertAvaliacao(ctx, s.repo, professorID, turmaID, tipo, input.Titulo, disciplina, input.Data, input.Peso, anoLetivo)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -364,15 +431,35 @@ func (s *Service) CreateAvaliacao(ctx context.Context, professorID, turmaID uuid
 	return avaliacaoID, nil
 }
 
-func rInsertAvaliacao(ctx context.Context, repo *Repository, professorID, turmaID uuid.UUID, tipo, titulo, disciplina string, data *time.Time, peso float64) (uuid.UUID, error) {
+func rInsertAvaliacao(ctx context.Context, repo *Repository, professorID, turmaID uuid.UUID, tipo, titulo, disciplina string, data *time.Time, peso float64, anoLetivo int) (uuid.UUID, error) {
 	trimmedTitulo := strings.TrimSpace(titulo)
-	return repo.InsertAvaliacao(ctx, turmaID, professorID, tipo, trimmedTitulo, disciplina, data, peso)
+	return repo.InsertAvaliacao(ctx, turmaID, professorID, tipo, trimmedTitulo, disciplina, data, peso, anoLetivo)
 }
 
 func (s *Service) GetAvaliacaoDetalhes(ctx context.Context, professorID, avaliacaoID uuid.UUID) (Avaliacao, []AvaliacaoQuestao, error) {
 	return s.repo.GetAvaliacao(ctx, professorID, avaliacaoID)
 }
 
+// AnalisarAvaliacao gera a análise de itens a partir das respostas já registradas.
+func (s *Service) AnalisarAvaliacao(ctx context.Context, professorID, avaliacaoID uuid.UUID) (AnaliseAvaliacao, error) {
+	avaliacao, questoes, err := s.repo.GetAvaliacao(ctx, professorID, avaliacaoID)
+	if err != nil {
+		return AnaliseAvaliacao{}, err
+	}
+	respostas, err := s.repo.ListRespostas(ctx, avaliacaoID)
+	if err != nil {
+		return AnaliseAvaliacao{}, err
+	}
+
+	itens, participantes, media := analisarItens(questoes, respostas)
+	return AnaliseAvaliacao{
+		Avaliacao:     avaliacao,
+		Participantes: participantes,
+		MediaAcertos:  math.Round(media*100) / 100,
+		Questoes:      itens,
+	}, nil
+}
+
 func (s *Service) AtualizarStatusAvaliacao(ctx context.Context, professorID, avaliacaoID uuid.UUID, status string) error {
 	status = strings.ToUpper(strings.TrimSpace(status))
 	if status == "" {
@@ -407,21 +494,135 @@ func (s *Service) LancarNotas(ctx context.Context, professorID, avaliacaoID uuid
 		notas = append(notas, NotaLancamento{MatriculaID: matriculaID, Nota: item.Nota, Observacao: item.Observacao})
 	}
 
-	return s.repo.UpsertNotas(ctx, professorID, avaliacaoID, avaliacao.Disciplina, avaliacao.TurmaID, input.Bimestre, notas)
+	return s.repo.UpsertNotas(ctx, professorID, avaliacaoID, avaliacao.Disciplina, avaliacao.TurmaID, input.Bimestre, avaliacao.AnoLetivo, notas)
+}
+
+type ImportarNotasInput struct {
+	Disciplina  string
+	Bimestre    int
+	AnoLetivo   int
+	NomeArquivo string
+	Conteudo    []byte
+	DryRun      bool
+}
+
+// ImportacaoErro aponta a linha da planilha (contando o cabeçalho como linha 1) rejeitada.
+type ImportacaoErro struct {
+	Linha     int    `json:"linha"`
+	Matricula string `json:"matricula,omitempty"`
+	Mensagem  string `json:"mensagem"`
+}
+
+type ImportacaoNotas struct {
+	Linhas     int              `json:"linhas"`
+	Importadas int              `json:"importadas"`
+	Ignoradas  int              `json:"ignoradas"`
+	Erros      []ImportacaoErro `json:"erros"`
+	Aplicada   bool             `json:"aplicada"`
 }
 
-func (s *Service) ListarNotas(ctx context.Context, professorID, turmaID uuid.UUID, bimestre int) ([]NotaResumo, error) {
+// ImportarNotas valida toda a planilha e só grava quando nenhuma linha tem erro, numa única transação.
+// Linhas com nota em branco são ignoradas, permitindo reenviar o modelo parcialmente preenchido.
+func (s *Service) ImportarNotas(ctx context.Context, professorID, turmaID uuid.UUID, input ImportarNotasInput) (ImportacaoNotas, error) {
+	input.Disciplina = strings.TrimSpace(input.Disciplina)
+	if input.Disciplina == "" {
+		return ImportacaoNotas{}, errors.New("disciplina obrigatória")
+	}
+	if input.Bimestre < 1 || input.Bimestre > 4 {
+		return ImportacaoNotas{}, errors.New("bimestre inválido")
+	}
+	if len(input.Conteudo) > planilhaMaxBytes {
+		return ImportacaoNotas{}, errors.New("planilha excede 5MB")
+	}
+	if err := s.repo.EnsureProfessorTurma(ctx, professorID, turmaID); err != nil {
+		return ImportacaoNotas{}, err
+	}
+	anoLetivo, err := s.resolveAnoLetivo(ctx, professorID, input.AnoLetivo)
+	if err != nil {
+		return ImportacaoNotas{}, err
+	}
+
+	rows, err := lerPlanilha(input.NomeArquivo, input.Conteudo)
+	if err != nil {
+		return ImportacaoNotas{}, err
+	}
+	if len(rows) == 0 {
+		return ImportacaoNotas{}, errors.New("planilha vazia")
+	}
+
+	colMatricula, colNota, colObs := -1, -1, -1
+	for i, header := range rows[0] {
+		switch normalizarCabecalho(header) {
+		case "matricula":
+			colMatricula = i
+		case "nota":
+			colNota = i
+		case "observacao", "obs":
+			colObs = i
+		}
+	}
+	if colMatricula < 0 || colNota < 0 {
+		return ImportacaoNotas{}, errors.New("cabeçalho deve conter as colunas matricula e nota")
+	}
+
+	matriculas, err := s.repo.MatriculasPorCodigo(ctx, turmaID, anoLetivo)
+	if err != nil {
+		return ImportacaoNotas{}, err
+	}
+
+	result, notas := conferirNotas(rows, colMatricula, colNota, colObs, matriculas)
+	result.Importadas = len(notas)
+	if len(result.Erros) > 0 || input.DryRun || len(notas) == 0 {
+		return result, nil
+	}
+
+	if err := s.repo.UpsertNotas(ctx, professorID, uuid.Nil, input.Disciplina, turmaID, input.Bimestre, anoLetivo, notas); err != nil {
+		return ImportacaoNotas{}, err
+	}
+	result.Aplicada = true
+	return result, nil
+}
+
+// ModeloNotas devolve os alunos ativos com as notas atuais para preencher o modelo de planilha.
+func (s *Service) ModeloNotas(ctx context.Context, professorID, turmaID uuid.UUID, disciplina string, bimestre, anoLetivo int) ([]NotaResumo, error) {
+	disciplina = strings.TrimSpace(disciplina)
+	if disciplina == "" {
+		return nil, errors.New("disciplina obrigatória")
+	}
 	if bimestre < 1 || bimestre > 4 {
 		return nil, errors.New("bimestre inválido")
 	}
-	return s.repo.ListNotasBimestre(ctx, professorID, turmaID, bimestre)
+	anoLetivo, err := s.resolveAnoLetivo(ctx, professorID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListNotasDisciplina(ctx, professorID, turmaID, disciplina, bimestre, anoLetivo)
 }
 
-func (s *Service) ListMateriais(ctx context.Context, professorID, turmaID uuid.UUID) ([]Material, error) {
-	return s.repo.ListMateriais(ctx, professorID, turmaID)
+func (s *Service) ListarNotas(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) ([]NotaResumo, error) {
+	if bimestre < 1 || bimestre > 4 {
+		return nil, errors.New("bimestre inválido")
+	}
+	anoLetivo, err := s.resolveAnoLetivo(ctx, professorID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListNotasBimestre(ctx, professorID, turmaID, bimestre, anoLetivo)
 }
 
-func (s *Service) CreateMaterial(ctx context.Context, professorID, turmaID uuid.UUID, titulo string, descricao, url *string) (Material, error) {
+func (s *Service) ListNotificacoes(ctx context.Context, professorID uuid.UUID, apenasNaoLidas bool) ([]Notificacao, error) {
+	return s.repo.ListNotificacoes(ctx, professorID, apenasNaoLidas)
+}
+
+func (s *Service) MarcarNotificacaoLida(ctx context.Context, professorID, notificacaoID uuid.UUID) error {
+	return s.repo.MarcarNotificacaoLida(ctx, professorID, notificacaoID)
+}
+
+func (s *Service) ListMateriais(ctx context.Context, professorID, turmaID uuid.UUID, params ListaParams) (Pagina[Material], error) {
+	return s.repo.ListMateriais(ctx, professorID, turmaID, params.normalizar())
+}
+
+func (s *Service) CreateMaterial(ctx context.Context, professorID, turmaID uuid.UUID, titulo string, descricao, url *string, quantidade *int) (Material, error) {
 	titulo = strings.TrimSpace(titulo)
 	if titulo == "" {
 		return Material{}, errors.New("titulo obrigatório")
@@ -438,7 +639,52 @@ func (s *Service) CreateMaterial(ctx context.Context, professorID, turmaID uuid.
 			url = &trimmed
 		}
 	}
-	return s.repo.CreateMaterial(ctx, professorID, turmaID, titulo, descricao, url)
+	if quantidade != nil && *quantidade < 0 {
+		return Material{}, errors.New("quantidade inválida")
+	}
+	return s.repo.CreateMaterial(ctx, professorID, turmaID, titulo, descricao, url, quantidade)
+}
+
+func (s *Service) AtualizarEstoque(ctx context.Context, professorID, materialID uuid.UUID, quantidade int) (Material, error) {
+	if quantidade < 0 {
+		return Material{}, errors.New("quantidade inválida")
+	}
+	return s.repo.AtualizarEstoque(ctx, professorID, materialID, quantidade)
+}
+
+func (s *Service) ListEmprestimos(ctx context.Context, professorID, materialID uuid.UUID, apenasAtivos bool) ([]Emprestimo, error) {
+	return s.repo.ListEmprestimos(ctx, professorID, materialID, apenasAtivos)
+}
+
+func (s *Service) Emprestar(ctx context.Context, professorID, materialID uuid.UUID, input EmprestimoInput) (Emprestimo, error) {
+	if input.Quantidade == 0 {
+		input.Quantidade = 1
+	}
+	if input.Quantidade < 0 {
+		return Emprestimo{}, errors.New("quantidade inválida")
+	}
+	if input.DevolverAte.IsZero() {
+		return Emprestimo{}, errors.New("devolver_ate obrigatório")
+	}
+	today := util.Now().Truncate(24 * time.Hour)
+	if input.DevolverAte.Before(today) {
+		return Emprestimo{}, errors.New("devolver_ate no passado")
+	}
+	var observacao *string
+	if input.Observacao != nil {
+		if trimmed := strings.TrimSpace(*input.Observacao); trimmed != "" {
+			observacao = &trimmed
+		}
+	}
+	return s.repo.Emprestar(ctx, professorID, materialID, input.AlunoID, input.Quantidade, input.DevolverAte, observacao)
+}
+
+func (s *Service) Devolver(ctx context.Context, professorID, emprestimoID uuid.UUID) error {
+	return s.repo.Devolver(ctx, professorID, emprestimoID)
+}
+
+func (s *Service) ListEmprestimosAtrasados(ctx context.Context, professorID uuid.UUID) ([]Emprestimo, error) {
+	return s.repo.ListEmprestimosAtrasados(ctx, professorID)
 }
 
 func (s *Service) ListAgenda(ctx context.Context, professorID uuid.UUID, from, to time.Time) ([]AgendaItem, error) {
@@ -448,22 +694,135 @@ func (s *Service) ListAgenda(ctx context.Context, professorID uuid.UUID, from, t
 	return s.repo.ListAgenda(ctx, professorID, from, to)
 }
 
-func (s *Service) RelatorioFrequencia(ctx context.Context, professorID, turmaID uuid.UUID, from, to time.Time) ([]FrequenciaAluno, error) {
+func (s *Service) RelatorioFrequencia(ctx context.Context, professorID, turmaID uuid.UUID, from, to time.Time, anoLetivo int) ([]FrequenciaAluno, error) {
 	if to.Before(from) {
 		return nil, errors.New("intervalo inválido")
 	}
-	return s.repo.RelatorioFrequencia(ctx, professorID, turmaID, from, to)
+	if anoLetivo <= 0 {
+		var err error
+		if anoLetivo, err = s.repo.AnoLetivo(ctx, professorID, from); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.RelatorioFrequencia(ctx, professorID, turmaID, from, to, anoLetivo)
 }
 
-func (s *Service) RelatorioAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, bimestre int) ([]RelatorioAvaliacao, error) {
+// Boletim consolida notas e frequência da turma no bimestre (ou no ano, com bimestre zero). A
+// frequência considera as aulas do período do bimestre dentro do ano letivo.
+func (s *Service) Boletim(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) (BoletimTurma, error) {
+	if bimestre < 0 || bimestre > 4 {
+		return BoletimTurma{}, errors.New("bimestre inválido")
+	}
+	turma, escola, err := s.repo.TurmaBoletim(ctx, professorID, turmaID)
+	if err != nil {
+		return BoletimTurma{}, err
+	}
+	anoLetivo, err = s.resolveAnoLetivo(ctx, professorID, anoLetivo)
+	if err != nil {
+		return BoletimTurma{}, err
+	}
+	inicio, fim, err := s.repo.PeriodoAnoLetivo(ctx, anoLetivo)
+	if err != nil {
+		return BoletimTurma{}, err
+	}
+	from, to := periodoBimestre(inicio, fim, bimestre)
+
+	frequencia, err := s.repo.RelatorioFrequencia(ctx, professorID, turmaID, from, to, anoLetivo)
+	if err != nil {
+		return BoletimTurma{}, err
+	}
+	notas, err := s.repo.NotasBoletim(ctx, turmaID, bimestre, anoLetivo)
+	if err != nil {
+		return BoletimTurma{}, err
+	}
+	disciplinas, alunos := montarBoletimTurma(frequencia, notas, bimestre)
+	return BoletimTurma{
+		TurmaID:       turmaID,
+		Turma:         turma,
+		Escola:        escola,
+		AnoLetivo:     anoLetivo,
+		Bimestre:      bimestre,
+		PeriodoInicio: from,
+		PeriodoFim:    to,
+		Disciplinas:   disciplinas,
+		Alunos:        alunos,
+	}, nil
+}
+
+func (s *Service) RelatorioAvaliacoes(ctx context.Context, professorID, turmaID uuid.UUID, bimestre, anoLetivo int) ([]RelatorioAvaliacao, error) {
 	if bimestre < 1 || bimestre > 4 {
 		return nil, errors.New("bimestre inválido")
 	}
-	return s.repo.RelatorioAvaliacoes(ctx, professorID, turmaID, bimestre)
+	anoLetivo, err := s.resolveAnoLetivo(ctx, professorID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.RelatorioAvaliacoes(ctx, professorID, turmaID, bimestre, anoLetivo)
 }
 
-func (s *Service) DashboardAnalytics(ctx context.Context, professorID uuid.UUID) (DashboardAnalytics, error) {
-	return s.repo.DashboardAnalytics(ctx, professorID)
+func (s *Service) DashboardAnalytics(ctx context.Context, professorID uuid.UUID, anoLetivo int) (DashboardAnalytics, error) {
+	anoLetivo, err := s.resolveAnoLetivo(ctx, professorID, anoLetivo)
+	if err != nil {
+		return DashboardAnalytics{}, err
+	}
+	return s.repo.DashboardAnalytics(ctx, professorID, anoLetivo)
+}
+
+// analyticsJanelaPadrao é o período de frequência usado quando o drill-down não recebe datas.
+const analyticsJanelaPadrao = 12 * 7
+
+// AnalyticsFiltro recorta o drill-down; datas zero usam as últimas doze semanas.
+type AnalyticsFiltro struct {
+	AnoLetivo int
+	Bimestre  int
+	From      time.Time
+	To        time.Time
+}
+
+func (s *Service) TurmaAnalytics(ctx context.Context, professorID, turmaID uuid.UUID, filtro AnalyticsFiltro) (TurmaAnalytics, error) {
+	filtro, err := s.normalizarAnalyticsFiltro(ctx, professorID, filtro)
+	if err != nil {
+		return TurmaAnalytics{}, err
+	}
+	return s.repo.TurmaAnalytics(ctx, professorID, turmaID, filtro.AnoLetivo, filtro.Bimestre, filtro.From, filtro.To)
+}
+
+func (s *Service) AlunoAnalytics(ctx context.Context, professorID, turmaID, alunoID uuid.UUID, filtro AnalyticsFiltro) (AlunoAnalytics, error) {
+	filtro, err := s.normalizarAnalyticsFiltro(ctx, professorID, filtro)
+	if err != nil {
+		return AlunoAnalytics{}, err
+	}
+	return s.repo.AlunoAnalytics(ctx, professorID, turmaID, alunoID, filtro.AnoLetivo, filtro.Bimestre, filtro.From, filtro.To)
+}
+
+func (s *Service) normalizarAnalyticsFiltro(ctx context.Context, professorID uuid.UUID, filtro AnalyticsFiltro) (AnalyticsFiltro, error) {
+	if filtro.Bimestre < 0 || filtro.Bimestre > 4 {
+		return filtro, errors.New("bimestre inválido")
+	}
+	if filtro.To.IsZero() {
+		now := time.Now().UTC()
+		filtro.To = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if filtro.From.IsZero() {
+		filtro.From = filtro.To.AddDate(0, 0, -analyticsJanelaPadrao)
+	}
+	if filtro.To.Before(filtro.From) {
+		return filtro, errors.New("intervalo inválido")
+	}
+	anoLetivo, err := s.resolveAnoLetivo(ctx, professorID, filtro.AnoLetivo)
+	if err != nil {
+		return filtro, err
+	}
+	filtro.AnoLetivo = anoLetivo
+	return filtro, nil
+}
+
+// resolveAnoLetivo usa o ano informado ou, quando zero, o ano letivo vigente da prefeitura.
+func (s *Service) resolveAnoLetivo(ctx context.Context, professorID uuid.UUID, anoLetivo int) (int, error) {
+	if anoLetivo > 0 {
+		return anoLetivo, nil
+	}
+	return s.repo.AnoLetivo(ctx, professorID, util.Now())
 }
 
 func (s *Service) LivePresence(ctx context.Context, professorID uuid.UUID) ([]LivePresence, error) {
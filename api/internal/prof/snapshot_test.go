@@ -0,0 +1,88 @@
+package prof
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/golden"
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+// Os snapshots fixam o JSON que o app do professor lê; fixtures com IDs e datas fixos
+// para que o arquivo só mude quando o formato mudar.
+func TestSnapshotPayloads(t *testing.T) {
+	profID := uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	turmaID := uuid.MustParse("00000000-0000-0000-0000-0000000000b1")
+	escolaID := uuid.MustParse("00000000-0000-0000-0000-0000000000c1")
+	aulaID := uuid.MustParse("00000000-0000-0000-0000-0000000000d1")
+	alunoA := uuid.MustParse("00000000-0000-0000-0000-0000000000e1")
+	alunoB := uuid.MustParse("00000000-0000-0000-0000-0000000000e2")
+	inicio := time.Date(2026, 3, 9, 7, 30, 0, 0, time.UTC)
+	escola := "EMEF Centro"
+	matricula := "2026001"
+	presente, falta := "PRESENTE", "FALTA"
+	justificativa := "atestado médico"
+
+	turmas := []Turma{{ID: turmaID, Nome: "5º Ano A", Turno: "MATUTINO", EscolaID: &escolaID, EscolaNome: &escola}}
+	svc := &stubService{
+		overview: &Overview{
+			ProfessorName:  "Ana Souza",
+			ProfessorEmail: "ana@escola.gov.br",
+			Turmas:         turmas,
+			Upcoming:       []AulaResumo{{ID: aulaID, TurmaID: turmaID, TurmaNome: "5º Ano A", Disciplina: "Matemática", Inicio: inicio, Fim: inicio.Add(50 * time.Minute)}},
+			TotalTurmas:    1,
+			TotalAlunos:    2,
+		},
+		turmas: turmas,
+		alunos: []Aluno{{ID: alunoA, Nome: "Bruno Lima", Matricula: &matricula}, {ID: alunoB, Nome: "Carla Dias"}},
+		chamada: &ChamadaResponse{
+			Atual: ChamadaView{Data: "2026-03-10", Turno: "MATUTINO", Itens: []ChamadaAluno{
+				{AlunoID: alunoA, Nome: "Bruno Lima", Matricula: &matricula},
+				{AlunoID: alunoB, Nome: "Carla Dias"},
+			}},
+			UltimaChamada: &ChamadaView{AulaID: &aulaID, Data: "2026-03-09", Turno: "MATUTINO", Disciplina: "Matemática", Itens: []ChamadaAluno{
+				{AlunoID: alunoA, Nome: "Bruno Lima", Matricula: &matricula, Status: &presente},
+				{AlunoID: alunoB, Nome: "Carla Dias", Status: &falta, Justificativa: &justificativa},
+			}},
+		},
+		analytics: DashboardAnalytics{
+			AnoLetivo:   2026,
+			Averages:    []TurmaMedia{{TurmaID: turmaID, Turma: "5º Ano A", Media: 7.25}},
+			TopStudents: []AlunoMedia{{AlunoID: alunoA, Nome: "Bruno Lima", Turma: "5º Ano A", Media: 9.5}},
+			Attendance:  []TurmaFrequencia{{TurmaID: turmaID, Turma: "5º Ano A", Frequencia: 92.5}},
+			Alerts:      []AlunoAlerta{{AlunoID: alunoB, Nome: "Carla Dias", Turma: "5º Ano A", Motivo: "frequencia", Valor: 70}},
+		},
+	}
+
+	router := chi.NewRouter()
+	NewHandler(svc).RegisterRoutes(router)
+
+	cases := []struct {
+		name string
+		path string
+	}{
+		{"prof_me", "/me"},
+		{"prof_turmas", "/turmas"},
+		{"prof_turma_alunos", "/turmas/" + turmaID.String() + "/alunos"},
+		{"prof_chamada", "/turmas/" + turmaID.String() + "/chamada?data=2026-03-10"},
+		{"prof_dashboard_analytics", "/dashboard/analytics?ano_letivo=2026"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), httpmiddleware.ContextKeySubject, profID.String()))
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", res.Code, res.Body.String())
+			}
+			golden.JSON(t, tc.name, res.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package prof
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// presencaArrays guarda a chamada em colunas paralelas para o INSERT ... SELECT FROM unnest.
+type presencaArrays struct {
+	matriculas     []uuid.UUID
+	status         []string
+	justificativas []*string
+}
+
+type notaArrays struct {
+	matriculas  []uuid.UUID
+	notas       []float64
+	observacoes []*string
+}
+
+// presencaColunas normaliza os itens e remove matrículas repetidas mantendo o último valor,
+// como acontecia no batch: ON CONFLICT não pode atualizar a mesma linha duas vezes num comando.
+func presencaColunas(itens []ChamadaItem) presencaArrays {
+	idx := make(map[uuid.UUID]int, len(itens))
+	cols := presencaArrays{
+		matriculas:     make([]uuid.UUID, 0, len(itens)),
+		status:         make([]string, 0, len(itens)),
+		justificativas: make([]*string, 0, len(itens)),
+	}
+	for _, item := range itens {
+		status := "PRESENTE"
+		if item.Status != nil {
+			status = strings.ToUpper(strings.TrimSpace(*item.Status))
+		}
+		var justificativa *string
+		if item.Observacao != nil {
+			if trimmed := strings.TrimSpace(*item.Observacao); trimmed != "" {
+				justificativa = &trimmed
+			}
+		}
+		if i, ok := idx[item.MatriculaID]; ok {
+			cols.status[i] = status
+			cols.justificativas[i] = justificativa
+			continue
+		}
+		idx[item.MatriculaID] = len(cols.matriculas)
+		cols.matriculas = append(cols.matriculas, item.MatriculaID)
+		cols.status = append(cols.status, status)
+		cols.justificativas = append(cols.justificativas, justificativa)
+	}
+	return cols
+}
+
+// notaColunas aplica a mesma deduplicação (último valor vence) às notas lançadas.
+func notaColunas(notas []NotaLancamento) notaArrays {
+	idx := make(map[uuid.UUID]int, len(notas))
+	cols := notaArrays{
+		matriculas:  make([]uuid.UUID, 0, len(notas)),
+		notas:       make([]float64, 0, len(notas)),
+		observacoes: make([]*string, 0, len(notas)),
+	}
+	for _, item := range notas {
+		if i, ok := idx[item.MatriculaID]; ok {
+			cols.notas[i] = item.Nota
+			cols.observacoes[i] = item.Observacao
+			continue
+		}
+		idx[item.MatriculaID] = len(cols.matriculas)
+		cols.matriculas = append(cols.matriculas, item.MatriculaID)
+		cols.notas = append(cols.notas, item.Nota)
+		cols.observacoes = append(cols.observacoes, item.Observacao)
+	}
+	return cols
+}
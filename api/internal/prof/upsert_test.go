@@ -0,0 +1,140 @@
+package prof
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestPresencaColunas_UltimoValorVence(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	falta, atraso, obs := "falta", " ATRASO ", "  chegou 7h20 "
+	cols := presencaColunas([]ChamadaItem{
+		{MatriculaID: a, Status: &falta},
+		{MatriculaID: b},
+		{MatriculaID: a, Status: &atraso, Observacao: &obs},
+	})
+
+	if len(cols.matriculas) != 2 || cols.matriculas[0] != a || cols.matriculas[1] != b {
+		t.Fatalf("unexpected matriculas %v", cols.matriculas)
+	}
+	if cols.status[0] != "ATRASO" || cols.status[1] != "PRESENTE" {
+		t.Fatalf("unexpected status %v", cols.status)
+	}
+	if cols.justificativas[0] == nil || *cols.justificativas[0] != "chegou 7h20" || cols.justificativas[1] != nil {
+		t.Fatalf("unexpected justificativas %v", cols.justificativas)
+	}
+}
+
+func TestNotaColunas_UltimoValorVence(t *testing.T) {
+	a := uuid.New()
+	cols := notaColunas([]NotaLancamento{{MatriculaID: a, Nota: 5}, {MatriculaID: a, Nota: 7.5}})
+	if len(cols.matriculas) != 1 || cols.notas[0] != 7.5 {
+		t.Fatalf("unexpected columns %+v", cols)
+	}
+}
+
+// Os benchmarks abaixo exigem um banco migrado em PROF_BENCH_DATABASE_URL, por exemplo:
+//
+//	PROF_BENCH_DATABASE_URL=postgres://... go test ./internal/prof -run '^$' -bench Upsert -benchtime 20x
+func benchFixture(b *testing.B, alunos int) (*Repository, uuid.UUID, uuid.UUID, uuid.UUID, []uuid.UUID) {
+	b.Helper()
+	dsn := os.Getenv("PROF_BENCH_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("PROF_BENCH_DATABASE_URL não definido")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(pool.Close)
+
+	professorID := uuid.New()
+	var turmaID, aulaID uuid.UUID
+	if err := pool.QueryRow(ctx, `INSERT INTO turmas (nome, turno) VALUES ('Benchmark', 'MANHA') RETURNING id`).Scan(&turmaID); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM turmas WHERE id = $1`, turmaID) })
+
+	if _, err := pool.Exec(ctx, `INSERT INTO professores_turmas (professor_id, turma_id, disciplinas) VALUES ($1, $2, '{Matemática}')`, professorID, turmaID); err != nil {
+		b.Fatal(err)
+	}
+	inicio := time.Now().UTC().Truncate(time.Hour)
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO aulas (turma_id, disciplina, inicio, fim, criado_por) VALUES ($1, 'Matemática', $2, $3, $4) RETURNING id
+	`, turmaID, inicio, inicio.Add(time.Hour), professorID).Scan(&aulaID); err != nil {
+		b.Fatal(err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		WITH novos AS (
+			INSERT INTO alunos (nome) SELECT 'Aluno ' || g FROM generate_series(1, $2) g RETURNING id
+		)
+		INSERT INTO matriculas (aluno_id, turma_id) SELECT id, $1 FROM novos RETURNING id
+	`, turmaID, alunos)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var matriculas []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			b.Fatal(err)
+		}
+		matriculas = append(matriculas, id)
+	}
+	rows.Close()
+	b.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM alunos WHERE id IN (SELECT aluno_id FROM matriculas WHERE id = ANY($1))`, matriculas)
+	})
+
+	return NewRepository(pool), professorID, turmaID, aulaID, matriculas
+}
+
+func BenchmarkUpsertPresencas(b *testing.B) {
+	for _, n := range []int{100, 500, 1000} {
+		b.Run(fmt.Sprintf("alunos=%d", n), func(b *testing.B) {
+			repo, _, _, aulaID, matriculas := benchFixture(b, n)
+			itens := make([]ChamadaItem, len(matriculas))
+			for i, id := range matriculas {
+				status := "PRESENTE"
+				if i%7 == 0 {
+					status = "FALTA"
+				}
+				itens[i] = ChamadaItem{MatriculaID: id, Status: &status}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := repo.UpsertPresencas(context.Background(), aulaID, itens); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUpsertNotas(b *testing.B) {
+	for _, n := range []int{100, 500, 1000} {
+		b.Run(fmt.Sprintf("alunos=%d", n), func(b *testing.B) {
+			repo, professorID, turmaID, _, matriculas := benchFixture(b, n)
+			notas := make([]NotaLancamento, len(matriculas))
+			for i, id := range matriculas {
+				notas[i] = NotaLancamento{MatriculaID: id, Nota: float64(i % 11)}
+			}
+			anoLetivo := time.Now().Year()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := repo.UpsertNotas(context.Background(), professorID, uuid.Nil, "Matemática", turmaID, 1, anoLetivo, notas); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
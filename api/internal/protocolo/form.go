@@ -0,0 +1,431 @@
+package protocolo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// Tipos de campo aceitos nos formulários das categorias.
+const (
+	FieldText        = "text"
+	FieldTextarea    = "textarea"
+	FieldNumber      = "number"
+	FieldInteger     = "integer"
+	FieldBoolean     = "boolean"
+	FieldDate        = "date"
+	FieldEmail       = "email"
+	FieldCPF         = "cpf"
+	FieldPhone       = "phone"
+	FieldSelect      = "select"
+	FieldMultiselect = "multiselect"
+)
+
+const (
+	maxFields          = 50
+	maxAttachmentRules = 10
+	// defaultMaxSizeMB vale para anexos sem limite próprio.
+	defaultMaxSizeMB = 10
+	maxSizeMB        = 25
+)
+
+var (
+	fieldTypes = map[string]struct{}{
+		FieldText: {}, FieldTextarea: {}, FieldNumber: {}, FieldInteger: {}, FieldBoolean: {}, FieldDate: {},
+		FieldEmail: {}, FieldCPF: {}, FieldPhone: {}, FieldSelect: {}, FieldMultiselect: {},
+	}
+	fieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+)
+
+// Form é a definição do formulário de uma categoria: campos com validação e anexos exigidos.
+type Form struct {
+	Fields      []Field          `json:"fields"`
+	Attachments []AttachmentRule `json:"attachments"`
+}
+
+// Field descreve um campo do formulário, no estilo de um JSON Schema simplificado.
+type Field struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Help        string   `json:"help,omitempty"`
+	MinLength   *int     `json:"min_length,omitempty"`
+	MaxLength   *int     `json:"max_length,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Options     []string `json:"options,omitempty"`
+	MaxSelected *int     `json:"max_selected,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// AttachmentRule descreve um anexo aceito (ou exigido) pelo formulário.
+type AttachmentRule struct {
+	Name         string   `json:"name"`
+	Label        string   `json:"label"`
+	Required     bool     `json:"required"`
+	ContentTypes []string `json:"content_types,omitempty"`
+	MaxSizeMB    int      `json:"max_size_mb"`
+	MaxFiles     int      `json:"max_files"`
+}
+
+// Upload resume um arquivo enviado para conferência contra as regras de anexo.
+type Upload struct {
+	Field       string
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+// ValidationError reúne os problemas por campo, para devolver ao formulário de uma vez.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+e.Fields[name])
+	}
+	return "protocolo: formulário inválido (" + strings.Join(parts, "; ") + ")"
+}
+
+// Normalize confere a definição do formulário montada pela secretaria.
+func (f *Form) Normalize() error {
+	if f.Fields == nil {
+		f.Fields = []Field{}
+	}
+	if f.Attachments == nil {
+		f.Attachments = []AttachmentRule{}
+	}
+	if len(f.Fields) > maxFields {
+		return fmt.Errorf("no máximo %d campos", maxFields)
+	}
+	if len(f.Attachments) > maxAttachmentRules {
+		return fmt.Errorf("no máximo %d anexos", maxAttachmentRules)
+	}
+
+	seen := make(map[string]bool, len(f.Fields)+len(f.Attachments))
+	for i := range f.Fields {
+		field := &f.Fields[i]
+		field.Name = strings.TrimSpace(field.Name)
+		field.Label = strings.TrimSpace(field.Label)
+		field.Type = strings.ToLower(strings.TrimSpace(field.Type))
+		if !fieldName.MatchString(field.Name) {
+			return fmt.Errorf("campo %d: nome deve usar letras minúsculas, números e _", i+1)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("campo %s duplicado", field.Name)
+		}
+		seen[field.Name] = true
+		if field.Label == "" {
+			return fmt.Errorf("campo %s: rótulo obrigatório", field.Name)
+		}
+		if _, ok := fieldTypes[field.Type]; !ok {
+			return fmt.Errorf("campo %s: tipo %q inválido", field.Name, field.Type)
+		}
+		if field.MinLength != nil && field.MaxLength != nil && *field.MinLength > *field.MaxLength {
+			return fmt.Errorf("campo %s: min_length maior que max_length", field.Name)
+		}
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			return fmt.Errorf("campo %s: min maior que max", field.Name)
+		}
+		if field.Pattern != "" {
+			re, err := regexp.Compile(field.Pattern)
+			if err != nil {
+				return fmt.Errorf("campo %s: pattern inválido", field.Name)
+			}
+			field.pattern = re
+		}
+		if field.Type == FieldSelect || field.Type == FieldMultiselect {
+			options := make([]string, 0, len(field.Options))
+			for _, option := range field.Options {
+				if option = strings.TrimSpace(option); option != "" {
+					options = append(options, option)
+				}
+			}
+			if len(options) == 0 {
+				return fmt.Errorf("campo %s: informe as opções", field.Name)
+			}
+			field.Options = options
+		} else {
+			field.Options = nil
+		}
+	}
+
+	for i := range f.Attachments {
+		rule := &f.Attachments[i]
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Label = strings.TrimSpace(rule.Label)
+		if !fieldName.MatchString(rule.Name) {
+			return fmt.Errorf("anexo %d: nome deve usar letras minúsculas, números e _", i+1)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("anexo %s duplicado", rule.Name)
+		}
+		seen[rule.Name] = true
+		if rule.Label == "" {
+			return fmt.Errorf("anexo %s: rótulo obrigatório", rule.Name)
+		}
+		if rule.MaxSizeMB <= 0 {
+			rule.MaxSizeMB = defaultMaxSizeMB
+		}
+		if rule.MaxSizeMB > maxSizeMB {
+			return fmt.Errorf("anexo %s: limite máximo de %d MB", rule.Name, maxSizeMB)
+		}
+		if rule.MaxFiles <= 0 {
+			rule.MaxFiles = 1
+		}
+		for j, ct := range rule.ContentTypes {
+			rule.ContentTypes[j] = strings.ToLower(strings.TrimSpace(ct))
+		}
+	}
+	return nil
+}
+
+// Validate confere a submissão do cidadão contra o formulário (já passado por Normalize) e devolve
+// os dados normalizados. Campos desconhecidos são recusados; erros vêm todos juntos em *ValidationError.
+func (f *Form) Validate(data map[string]any, uploads []Upload) (map[string]any, error) {
+	problems := make(map[string]string)
+	clean := make(map[string]any, len(f.Fields))
+
+	known := make(map[string]bool, len(f.Fields))
+	for i := range f.Fields {
+		field := &f.Fields[i]
+		known[field.Name] = true
+		raw, present := data[field.Name]
+		if present && isBlank(raw) {
+			present = false
+		}
+		if !present {
+			if field.Required {
+				problems[field.Name] = "obrigatório"
+			}
+			continue
+		}
+		value, err := field.check(raw)
+		if err != nil {
+			problems[field.Name] = err.Error()
+			continue
+		}
+		clean[field.Name] = value
+	}
+	for name := range data {
+		if !known[name] {
+			problems[name] = "campo desconhecido"
+		}
+	}
+
+	byField := make(map[string][]Upload)
+	for _, upload := range uploads {
+		byField[upload.Field] = append(byField[upload.Field], upload)
+	}
+	rules := make(map[string]bool, len(f.Attachments))
+	for _, rule := range f.Attachments {
+		rules[rule.Name] = true
+		if msg := rule.check(byField[rule.Name]); msg != "" {
+			problems[rule.Name] = msg
+		}
+	}
+	for name := range byField {
+		if !rules[name] {
+			problems[name] = "anexo não previsto no formulário"
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Fields: problems}
+	}
+	return clean, nil
+}
+
+// Rule devolve a regra de anexo com o nome informado.
+func (f *Form) Rule(name string) (AttachmentRule, bool) {
+	for _, rule := range f.Attachments {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return AttachmentRule{}, false
+}
+
+func (field *Field) check(raw any) (any, error) {
+	switch field.Type {
+	case FieldText, FieldTextarea:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("deve ser texto")
+		}
+		s = strings.TrimSpace(s)
+		n := utf8.RuneCountInString(s)
+		if field.MinLength != nil && n < *field.MinLength {
+			return nil, fmt.Errorf("mínimo de %d caracteres", *field.MinLength)
+		}
+		if field.MaxLength != nil && n > *field.MaxLength {
+			return nil, fmt.Errorf("máximo de %d caracteres", *field.MaxLength)
+		}
+		if field.pattern != nil && !field.pattern.MatchString(s) {
+			return nil, errors.New("formato inválido")
+		}
+		return s, nil
+	case FieldNumber, FieldInteger:
+		v, ok := raw.(float64)
+		if !ok {
+			return nil, errors.New("deve ser número")
+		}
+		if field.Type == FieldInteger && v != math.Trunc(v) {
+			return nil, errors.New("deve ser número inteiro")
+		}
+		if field.Min != nil && v < *field.Min {
+			return nil, fmt.Errorf("mínimo %v", *field.Min)
+		}
+		if field.Max != nil && v > *field.Max {
+			return nil, fmt.Errorf("máximo %v", *field.Max)
+		}
+		return v, nil
+	case FieldBoolean:
+		v, ok := raw.(bool)
+		if !ok {
+			return nil, errors.New("deve ser verdadeiro ou falso")
+		}
+		return v, nil
+	case FieldDate:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("data inválida")
+		}
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+		if err != nil {
+			return nil, errors.New("data inválida, use AAAA-MM-DD")
+		}
+		return d.Format("2006-01-02"), nil
+	case FieldEmail:
+		s, ok := raw.(string)
+		if !ok || util.ValidateEmail(s) != nil {
+			return nil, errors.New("e-mail inválido")
+		}
+		return strings.ToLower(strings.TrimSpace(s)), nil
+	case FieldCPF:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("cpf inválido")
+		}
+		return util.ValidateCPF(s)
+	case FieldPhone:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("telefone inválido")
+		}
+		digits := onlyDigits(s)
+		if len(digits) < 10 || len(digits) > 11 {
+			return nil, errors.New("telefone deve ter DDD e 8 ou 9 dígitos")
+		}
+		return digits, nil
+	case FieldSelect:
+		s, ok := raw.(string)
+		if !ok || !field.hasOption(s) {
+			return nil, errors.New("opção inválida")
+		}
+		return s, nil
+	case FieldMultiselect:
+		items, ok := raw.([]any)
+		if !ok {
+			return nil, errors.New("deve ser lista de opções")
+		}
+		if field.MaxSelected != nil && len(items) > *field.MaxSelected {
+			return nil, fmt.Errorf("no máximo %d opções", *field.MaxSelected)
+		}
+		selected := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !field.hasOption(s) {
+				return nil, errors.New("opção inválida")
+			}
+			selected = append(selected, s)
+		}
+		if field.Required && len(selected) == 0 {
+			return nil, errors.New("obrigatório")
+		}
+		return selected, nil
+	}
+	return nil, errors.New("tipo de campo não suportado")
+}
+
+func (field *Field) hasOption(value string) bool {
+	for _, option := range field.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule AttachmentRule) check(files []Upload) string {
+	if len(files) == 0 {
+		if rule.Required {
+			return "anexo obrigatório"
+		}
+		return ""
+	}
+	if len(files) > rule.MaxFiles {
+		return fmt.Sprintf("no máximo %d arquivo(s)", rule.MaxFiles)
+	}
+	limit := int64(rule.MaxSizeMB) << 20
+	for _, file := range files {
+		if file.Size > limit {
+			return fmt.Sprintf("%s excede %d MB", file.FileName, rule.MaxSizeMB)
+		}
+		if len(rule.ContentTypes) > 0 && !acceptsType(rule.ContentTypes, file.ContentType) {
+			return fmt.Sprintf("%s: tipo de arquivo não aceito", file.FileName)
+		}
+	}
+	return ""
+}
+
+// acceptsType aceita o tipo exato ou curingas como "image/*".
+func acceptsType(allowed []string, contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	for _, candidate := range allowed {
+		if candidate == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(candidate, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+	return false
+}
+
+func onlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package protocolo
+
+import (
+	"errors"
+	"testing"
+)
+
+func sampleForm(t *testing.T) Form {
+	t.Helper()
+	maxLen := 200
+	form := Form{
+		Fields: []Field{
+			{Name: "endereco", Label: "Endereço", Type: "text", Required: true, MaxLength: &maxLen},
+			{Name: "cpf", Label: "CPF", Type: "cpf", Required: true},
+			{Name: "tipo", Label: "Tipo", Type: "select", Options: []string{"poda", "remocao"}},
+			{Name: "arvores", Label: "Árvores", Type: "integer"},
+		},
+		Attachments: []AttachmentRule{
+			{Name: "foto", Label: "Foto do local", Required: true, ContentTypes: []string{"image/*"}, MaxSizeMB: 2},
+		},
+	}
+	if err := form.Normalize(); err != nil {
+		t.Fatalf("formulário de exemplo inválido: %v", err)
+	}
+	return form
+}
+
+func TestFormNormalizeRejectsBadDefinitions(t *testing.T) {
+	cases := []Form{
+		{Fields: []Field{{Name: "Endereco", Label: "x", Type: "text"}}},
+		{Fields: []Field{{Name: "a", Label: "x", Type: "text"}, {Name: "a", Label: "y", Type: "text"}}},
+		{Fields: []Field{{Name: "a", Label: "x", Type: "mapa"}}},
+		{Fields: []Field{{Name: "a", Label: "x", Type: "select"}}},
+		{Fields: []Field{{Name: "a", Label: "x", Type: "text", Pattern: "("}}},
+		{Attachments: []AttachmentRule{{Name: "doc", Label: "Doc", MaxSizeMB: 500}}},
+	}
+	for i, form := range cases {
+		if err := form.Normalize(); err == nil {
+			t.Fatalf("caso %d: esperava erro", i)
+		}
+	}
+}
+
+func TestFormValidate(t *testing.T) {
+	form := sampleForm(t)
+	foto := []Upload{{Field: "foto", FileName: "arvore.jpg", ContentType: "image/jpeg", Size: 1 << 20}}
+
+	clean, err := form.Validate(map[string]any{
+		"endereco": "  Rua A, 10 ",
+		"cpf":      "529.982.247-25",
+		"tipo":     "poda",
+		"arvores":  float64(2),
+	}, foto)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if clean["endereco"] != "Rua A, 10" || clean["cpf"] != "52998224725" {
+		t.Fatalf("dados normalizados inesperados: %+v", clean)
+	}
+
+	_, err = form.Validate(map[string]any{
+		"cpf":     "111.111.111-11",
+		"tipo":    "corte",
+		"arvores": 1.5,
+		"extra":   "x",
+	}, []Upload{{Field: "foto", FileName: "a.pdf", ContentType: "application/pdf", Size: 10}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("esperava ValidationError, veio %v", err)
+	}
+	for _, name := range []string{"endereco", "cpf", "tipo", "arvores", "extra", "foto"} {
+		if verr.Fields[name] == "" {
+			t.Fatalf("esperava erro em %s: %+v", name, verr.Fields)
+		}
+	}
+
+	_, err = form.Validate(map[string]any{"endereco": "Rua B", "cpf": "52998224725"}, nil)
+	if !errors.As(err, &verr) || verr.Fields["foto"] != "anexo obrigatório" {
+		t.Fatalf("esperava anexo obrigatório, veio %v", err)
+	}
+}
+
+func TestFormatNumero(t *testing.T) {
+	if got := FormatNumero(2026, 42); got != "2026-000042" {
+		t.Fatalf("FormatNumero = %q", got)
+	}
+}
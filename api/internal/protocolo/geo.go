@@ -0,0 +1,110 @@
+package protocolo
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// geoGridCells é a quantidade aproximada de células por eixo da caixa do mapa ao agrupar pontos.
+	geoGridCells = 48
+	// geoMaxClusters limita a resposta do mapa mesmo em caixas muito grandes.
+	geoMaxClusters = 2000
+)
+
+// Ponto é uma coordenada WGS84.
+type Ponto struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Validate confere se a coordenada está dentro dos limites do globo.
+func (p Ponto) Validate() error {
+	if math.IsNaN(p.Lat) || math.IsNaN(p.Lng) || p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+		return errors.New("localização inválida")
+	}
+	return nil
+}
+
+// BBox é a caixa visível do mapa, em graus.
+type BBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// ParseBBox lê "minLng,minLat,maxLng,maxLat", a ordem usada pelas bibliotecas de mapa.
+func ParseBBox(raw string) (BBox, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return BBox{}, errors.New("bbox deve ter minLng,minLat,maxLng,maxLat")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, errors.New("bbox com coordenada inválida")
+		}
+		values[i] = v
+	}
+	b := BBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	if (Ponto{Lat: b.MinLat, Lng: b.MinLng}).Validate() != nil || (Ponto{Lat: b.MaxLat, Lng: b.MaxLng}).Validate() != nil {
+		return BBox{}, errors.New("bbox fora dos limites")
+	}
+	if b.MinLng >= b.MaxLng || b.MinLat >= b.MaxLat {
+		return BBox{}, errors.New("bbox com cantos invertidos")
+	}
+	return b, nil
+}
+
+// GridSize devolve o lado da célula, em graus, usada para agrupar os pontos da caixa.
+func (b BBox) GridSize() float64 {
+	return math.Max(b.MaxLng-b.MinLng, b.MaxLat-b.MinLat) / geoGridCells
+}
+
+// NormalizeBairro remove espaços excedentes; vazio vira nil.
+func NormalizeBairro(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	bairro := strings.Join(strings.Fields(*raw), " ")
+	if bairro == "" {
+		return nil
+	}
+	return &bairro
+}
+
+// GeoFilter restringe os protocolos exibidos no mapa e na agregação por bairro.
+type GeoFilter struct {
+	CategoriaID *uuid.UUID
+	Status      string
+	From        *time.Time
+	To          *time.Time
+}
+
+// GeoCluster agrupa os protocolos de uma célula do mapa. Com um único protocolo, traz seus dados
+// para o marcador.
+type GeoCluster struct {
+	Ponto
+	Total       int        `json:"total"`
+	ProtocoloID *uuid.UUID `json:"protocolo_id,omitempty"`
+	Numero      *string    `json:"numero,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+}
+
+// BairroResumo agrega os protocolos de um bairro.
+type BairroResumo struct {
+	Bairro      *string  `json:"bairro"`
+	Total       int      `json:"total"`
+	EmAberto    int      `json:"em_aberto"`
+	Concluidos  int      `json:"concluidos"`
+	Duplicados  int      `json:"duplicados"`
+	HorasMedias *float64 `json:"horas_medias_conclusao"`
+	Centro      *Ponto   `json:"centro,omitempty"`
+}
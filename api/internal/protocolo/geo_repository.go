@@ -0,0 +1,166 @@
+package protocolo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GeoClusters agrupa os protocolos localizados dentro da caixa em células do tamanho de
+// BBox.GridSize, com o centro de cada grupo.
+func (r *Repository) GeoClusters(ctx context.Context, tenantID uuid.UUID, box BBox, filter GeoFilter) ([]GeoCluster, error) {
+	clauses, args := geoClauses(tenantID, filter)
+	args = append(args, box.MinLng, box.MinLat, box.MaxLng, box.MaxLat)
+	n := len(args)
+	clauses = append(clauses, fmt.Sprintf("ST_Intersects(p.localizacao, ST_MakeEnvelope($%d, $%d, $%d, $%d, 4326)::geography)", n-3, n-2, n-1, n))
+	args = append(args, box.GridSize(), geoMaxClusters)
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        WITH pts AS (
+            SELECT p.id, p.numero, p.status, p.localizacao::geometry AS g
+            FROM protocolos p
+            WHERE `+strings.Join(clauses, " AND ")+`
+        )
+        SELECT count(*), ST_Y(ST_Centroid(ST_Collect(g))), ST_X(ST_Centroid(ST_Collect(g))),
+               (array_agg(id))[1], (array_agg(numero))[1], (array_agg(status))[1]
+        FROM pts
+        GROUP BY ST_SnapToGrid(g, $`+fmt.Sprint(len(args)-1)+`)
+        ORDER BY count(*) DESC
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (GeoCluster, error) {
+		var (
+			c      GeoCluster
+			id     uuid.UUID
+			numero string
+			status string
+		)
+		if err := row.Scan(&c.Total, &c.Lat, &c.Lng, &id, &numero, &status); err != nil {
+			return c, err
+		}
+		if c.Total == 1 {
+			c.ProtocoloID, c.Numero, c.Status = &id, &numero, &status
+		}
+		return c, nil
+	})
+}
+
+// Bairros agrega os protocolos por bairro (sem diferenciar maiúsculas), mais demandados primeiro.
+func (r *Repository) Bairros(ctx context.Context, tenantID uuid.UUID, filter GeoFilter) ([]BairroResumo, error) {
+	clauses, args := geoClauses(tenantID, filter)
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT min(p.bairro), count(*),
+               count(*) FILTER (WHERE p.status IN ('aberto', 'em_andamento')),
+               count(*) FILTER (WHERE p.status = 'concluido'),
+               count(*) FILTER (WHERE p.duplicado_de IS NOT NULL),
+               avg(extract(epoch FROM p.concluido_em - p.created_at) / 3600) FILTER (WHERE p.status = 'concluido'),
+               ST_Y(ST_Centroid(ST_Collect(p.localizacao::geometry))), ST_X(ST_Centroid(ST_Collect(p.localizacao::geometry)))
+        FROM protocolos p
+        WHERE `+strings.Join(clauses, " AND ")+`
+        GROUP BY lower(p.bairro)
+        ORDER BY count(*) DESC
+    `, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (BairroResumo, error) {
+		var (
+			b        BairroResumo
+			lat, lng *float64
+		)
+		if err := row.Scan(&b.Bairro, &b.Total, &b.EmAberto, &b.Concluidos, &b.Duplicados, &b.HorasMedias, &lat, &lng); err != nil {
+			return b, err
+		}
+		if lat != nil && lng != nil {
+			b.Centro = &Ponto{Lat: *lat, Lng: *lng}
+		}
+		return b, nil
+	})
+}
+
+func geoClauses(tenantID uuid.UUID, filter GeoFilter) ([]string, []any) {
+	clauses := []string{"p.tenant_id = $1", "p.localizacao IS NOT NULL"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+	if filter.CategoriaID != nil {
+		add("p.categoria_id = $%d", *filter.CategoriaID)
+	}
+	if filter.Status != "" {
+		add("p.status = $%d", filter.Status)
+	}
+	if filter.From != nil {
+		add("p.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("p.created_at < $%d", *filter.To)
+	}
+	return clauses, args
+}
+
+// findDuplicado procura, na categoria com detecção ligada, o protocolo em aberto mais próximo
+// dentro do raio e da janela; devolve sempre o original da cadeia. O lock por categoria evita que
+// relatos simultâneos do mesmo problema escapem um do outro.
+func findDuplicado(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, categoria *Categoria, ponto *Ponto) (*uuid.UUID, error) {
+	if ponto == nil || categoria.DedupRaioMetros == nil {
+		return nil, nil
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "protocolo-dedup:"+categoria.ID.String()); err != nil {
+		return nil, err
+	}
+	var original uuid.UUID
+	err := tx.QueryRow(ctx, `
+        WITH alvo AS (SELECT ST_SetSRID(ST_MakePoint($4, $3), 4326)::geography AS g)
+        SELECT COALESCE(p.duplicado_de, p.id)
+        FROM protocolos p, alvo
+        WHERE p.tenant_id = $1 AND p.categoria_id = $2
+          AND p.status IN ('aberto', 'em_andamento')
+          AND p.created_at >= now() - make_interval(days => $5)
+          AND ST_DWithin(p.localizacao, alvo.g, $6)
+        ORDER BY ST_Distance(p.localizacao, alvo.g), p.created_at
+        LIMIT 1
+    `, tenantID, categoria.ID, ponto.Lat, ponto.Lng, categoria.DedupJanelaDias, *categoria.DedupRaioMetros).Scan(&original)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &original, nil
+}
+
+// routeDuplicado coloca o duplicado com a mesma secretaria, fila e responsável do original, para
+// que o problema seja tratado uma única vez.
+func (r *Repository) routeDuplicado(ctx context.Context, tx pgx.Tx, p *Protocolo) error {
+	var numero string
+	err := tx.QueryRow(ctx, `
+        UPDATE protocolos d
+        SET secretaria_id = o.secretaria_id, fila_id = o.fila_id, responsavel_id = o.responsavel_id,
+            atribuido_em = CASE WHEN o.responsavel_id IS NULL THEN NULL ELSE now() END
+        FROM protocolos o
+        WHERE d.id = $1 AND o.id = $2
+        RETURNING d.secretaria_id, d.fila_id, d.responsavel_id, d.atribuido_em, o.numero
+    `, p.ID, *p.DuplicadoDe).Scan(&p.SecretariaID, &p.FilaID, &p.ResponsavelID, &p.AtribuidoEm, &numero)
+	if err != nil {
+		return err
+	}
+	if p.SecretariaID == nil {
+		return nil
+	}
+	motivo := "duplicado de " + numero
+	return insertEvento(ctx, tx, p.ID, Evento{
+		Tipo:             EventoRoteado,
+		ParaSecretariaID: p.SecretariaID,
+		ParaFilaID:       p.FilaID,
+		ResponsavelID:    p.ResponsavelID,
+		Motivo:           &motivo,
+	})
+}
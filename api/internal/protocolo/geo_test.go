@@ -0,0 +1,32 @@
+package protocolo
+
+import "testing"
+
+func TestParseBBox(t *testing.T) {
+	box, err := ParseBBox("-38.55, -3.80,-38.45,-3.70")
+	if err != nil {
+		t.Fatalf("bbox válida rejeitada: %v", err)
+	}
+	if box.MinLng != -38.55 || box.MaxLat != -3.70 {
+		t.Fatalf("bbox lida errado: %+v", box)
+	}
+	if got := box.GridSize(); got <= 0 || got > 0.1/geoGridCells+1e-9 {
+		t.Fatalf("grid inesperado: %v", got)
+	}
+	for _, raw := range []string{"", "1,2,3", "a,b,c,d", "-38.45,-3.80,-38.55,-3.70", "0,0,200,10"} {
+		if _, err := ParseBBox(raw); err == nil {
+			t.Fatalf("esperava erro para %q", raw)
+		}
+	}
+}
+
+func TestNormalizeBairro(t *testing.T) {
+	raw := "  Vila   Nova "
+	if got := NormalizeBairro(&raw); got == nil || *got != "Vila Nova" {
+		t.Fatalf("bairro normalizado errado: %v", got)
+	}
+	blank := "   "
+	if NormalizeBairro(&blank) != nil || NormalizeBairro(nil) != nil {
+		t.Fatal("bairro vazio deveria virar nil")
+	}
+}
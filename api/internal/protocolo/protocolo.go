@@ -0,0 +1,153 @@
+// Package protocolo mantém as categorias de protocolo configuradas pelas secretarias, com
+// formulários dinâmicos, e os protocolos abertos pelos cidadãos.
+package protocolo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica categoria ou protocolo inexistente (ou de outro tenant/cidadão).
+	ErrNotFound = errors.New("protocolo: não encontrado")
+	// ErrDuplicate indica slug de categoria já usado no tenant.
+	ErrDuplicate = errors.New("protocolo: categoria já cadastrada")
+	// ErrInactive indica categoria desativada, que não aceita novos protocolos.
+	ErrInactive = errors.New("protocolo: categoria inativa")
+)
+
+// Status do protocolo.
+const (
+	StatusAberto      = "aberto"
+	StatusEmAndamento = "em_andamento"
+	StatusConcluido   = "concluido"
+	StatusCancelado   = "cancelado"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Categoria é um tipo de solicitação aberto ao cidadão, com o formulário a preencher.
+type Categoria struct {
+	ID           uuid.UUID  `json:"id"`
+	TenantID     uuid.UUID  `json:"tenant_id"`
+	SecretariaID *uuid.UUID `json:"secretaria_id,omitempty"`
+	Slug         string     `json:"slug"`
+	Nome         string     `json:"nome"`
+	Descricao    *string    `json:"descricao,omitempty"`
+	Ativo        bool       `json:"ativo"`
+	Form         Form       `json:"form"`
+	FormVersion  int        `json:"form_version"`
+	// DedupRaioMetros liga a detecção de duplicados: protocolos abertos da categoria a essa
+	// distância, nos últimos DedupJanelaDias, são tratados como o mesmo problema.
+	DedupRaioMetros *int      `json:"dedup_raio_metros,omitempty"`
+	DedupJanelaDias int       `json:"dedup_janela_dias"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CategoriaInput contém os campos editáveis de uma categoria.
+type CategoriaInput struct {
+	SecretariaID    *uuid.UUID
+	Slug            string
+	Nome            string
+	Descricao       *string
+	Ativo           bool
+	Form            Form
+	DedupRaioMetros *int
+	DedupJanelaDias int
+	ActorID         *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada, incluindo a definição do formulário.
+func (in *CategoriaInput) Normalize() error {
+	in.Slug = strings.ToLower(strings.TrimSpace(in.Slug))
+	in.Nome = strings.TrimSpace(in.Nome)
+	if in.Descricao != nil {
+		if d := strings.TrimSpace(*in.Descricao); d != "" {
+			in.Descricao = &d
+		} else {
+			in.Descricao = nil
+		}
+	}
+	switch {
+	case !slugPattern.MatchString(in.Slug):
+		return errors.New("slug inválido")
+	case in.Nome == "":
+		return errors.New("nome obrigatório")
+	case in.DedupRaioMetros != nil && (*in.DedupRaioMetros < 1 || *in.DedupRaioMetros > 5000):
+		return errors.New("raio de duplicidade deve estar entre 1 e 5000 metros")
+	}
+	if in.DedupJanelaDias == 0 {
+		in.DedupJanelaDias = 30
+	}
+	if in.DedupJanelaDias < 1 || in.DedupJanelaDias > 365 {
+		return errors.New("janela de duplicidade deve estar entre 1 e 365 dias")
+	}
+	return in.Form.Normalize()
+}
+
+// Protocolo é uma solicitação registrada por um cidadão.
+type Protocolo struct {
+	ID          uuid.UUID      `json:"id"`
+	TenantID    uuid.UUID      `json:"tenant_id"`
+	CategoriaID uuid.UUID      `json:"categoria_id"`
+	Categoria   string         `json:"categoria"`
+	Numero      string         `json:"numero"`
+	CidadaoID   uuid.UUID      `json:"cidadao_id"`
+	Status      string         `json:"status"`
+	Dados       map[string]any `json:"dados"`
+	FormVersion int            `json:"form_version"`
+	Anexos      []Anexo        `json:"anexos,omitempty"`
+	// Roteamento: secretaria e fila atuais e o atendente responsável.
+	SecretariaID  *uuid.UUID `json:"secretaria_id,omitempty"`
+	FilaID        *uuid.UUID `json:"fila_id,omitempty"`
+	ResponsavelID *uuid.UUID `json:"responsavel_id,omitempty"`
+	AtribuidoEm   *time.Time `json:"atribuido_em,omitempty"`
+	ConcluidoEm   *time.Time `json:"concluido_em,omitempty"`
+	// Local do problema e o protocolo original quando outro cidadão já o relatou.
+	Localizacao *Ponto     `json:"localizacao,omitempty"`
+	Bairro      *string    `json:"bairro,omitempty"`
+	DuplicadoDe *uuid.UUID `json:"duplicado_de,omitempty"`
+	// AtivoID é o bem municipal (poste, praça...) a que o protocolo se refere.
+	AtivoID   *uuid.UUID `json:"ativo_id,omitempty"`
+	Eventos   []Evento   `json:"eventos,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Anexo é um arquivo enviado junto ao protocolo, ligado a uma regra de anexo do formulário.
+type Anexo struct {
+	ID          uuid.UUID `json:"id"`
+	Campo       string    `json:"campo"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	ObjectKey   string    `json:"-"`
+	FileURL     string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NovoProtocolo contém a submissão já validada; ID é gerado antes para nomear os anexos no
+// armazenamento.
+type NovoProtocolo struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Categoria   *Categoria
+	CidadaoID   uuid.UUID
+	Dados       map[string]any
+	Anexos      []Anexo
+	Localizacao *Ponto
+	Bairro      *string
+	AtivoID     *uuid.UUID
+	SubmittedAt time.Time
+}
+
+// FormatNumero monta o número público do protocolo, ex.: 2026-000042.
+func FormatNumero(ano, seq int) string {
+	return fmt.Sprintf("%04d-%06d", ano, seq)
+}
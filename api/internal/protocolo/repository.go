@@ -0,0 +1,317 @@
+package protocolo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const (
+	categoriaColumns = `id, tenant_id, secretaria_id, slug, nome, descricao, ativo, form, form_version,
+        dedup_raio_metros, dedup_janela_dias, created_at, updated_at`
+	protocoloColumns = `p.id, p.tenant_id, p.categoria_id, c.nome, p.numero, p.cidadao_id, p.status, p.dados, p.form_version,
+        p.secretaria_id, p.fila_id, p.responsavel_id, p.atribuido_em, p.concluido_em,
+        ST_Y(p.localizacao::geometry), ST_X(p.localizacao::geometry), p.bairro, p.duplicado_de, p.ativo_id, p.created_at, p.updated_at`
+)
+
+// Repository provê acesso às tabelas de protocolo.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// ListCategorias lista as categorias do tenant; onlyActive restringe às abertas ao cidadão.
+func (r *Repository) ListCategorias(ctx context.Context, tenantID uuid.UUID, onlyActive bool) ([]Categoria, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+categoriaColumns+`
+        FROM protocolo_categorias
+        WHERE tenant_id = $1 AND (NOT $2 OR ativo)
+        ORDER BY nome
+    `, tenantID, onlyActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	categorias := make([]Categoria, 0)
+	for rows.Next() {
+		c, err := scanCategoria(rows)
+		if err != nil {
+			return nil, err
+		}
+		categorias = append(categorias, *c)
+	}
+	return categorias, rows.Err()
+}
+
+// GetCategoria busca a categoria do tenant.
+func (r *Repository) GetCategoria(ctx context.Context, tenantID, id uuid.UUID) (*Categoria, error) {
+	row := r.conn(ctx).QueryRow(ctx, `SELECT `+categoriaColumns+` FROM protocolo_categorias WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	return scanCategoria(row)
+}
+
+// GetCategoriaBySlug busca a categoria do tenant pelo slug.
+func (r *Repository) GetCategoriaBySlug(ctx context.Context, tenantID uuid.UUID, slug string) (*Categoria, error) {
+	row := r.conn(ctx).QueryRow(ctx, `SELECT `+categoriaColumns+` FROM protocolo_categorias WHERE tenant_id = $1 AND slug = $2`, tenantID, slug)
+	return scanCategoria(row)
+}
+
+// CreateCategoria cadastra uma categoria; a entrada já deve estar normalizada.
+func (r *Repository) CreateCategoria(ctx context.Context, tenantID uuid.UUID, in CategoriaInput) (*Categoria, error) {
+	form, err := json.Marshal(in.Form)
+	if err != nil {
+		return nil, err
+	}
+	row := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO protocolo_categorias (tenant_id, secretaria_id, slug, nome, descricao, ativo, form, dedup_raio_metros, dedup_janela_dias, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING `+categoriaColumns,
+		tenantID, in.SecretariaID, in.Slug, in.Nome, in.Descricao, in.Ativo, form, in.DedupRaioMetros, in.DedupJanelaDias, in.ActorID)
+	return categoriaError(scanCategoria(row))
+}
+
+// UpdateCategoria substitui os campos da categoria; a versão do formulário só sobe quando ele muda,
+// e protocolos antigos continuam ligados à versão com que foram validados.
+func (r *Repository) UpdateCategoria(ctx context.Context, tenantID, id uuid.UUID, in CategoriaInput) (*Categoria, error) {
+	form, err := json.Marshal(in.Form)
+	if err != nil {
+		return nil, err
+	}
+	row := r.conn(ctx).QueryRow(ctx, `
+        UPDATE protocolo_categorias
+        SET secretaria_id = $3, slug = $4, nome = $5, descricao = $6, ativo = $7,
+            form_version = form_version + CASE WHEN form = $8::jsonb THEN 0 ELSE 1 END,
+            form = $8, dedup_raio_metros = $9, dedup_janela_dias = $10, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+        RETURNING `+categoriaColumns,
+		tenantID, id, in.SecretariaID, in.Slug, in.Nome, in.Descricao, in.Ativo, form, in.DedupRaioMetros, in.DedupJanelaDias)
+	return categoriaError(scanCategoria(row))
+}
+
+// SecretariaInTenant confere se a secretaria pertence ao tenant.
+func (r *Repository) SecretariaInTenant(ctx context.Context, tenantID, secretariaID uuid.UUID) (bool, error) {
+	var ok bool
+	err := r.conn(ctx).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM secretarias WHERE id = $1 AND tenant_id = $2)`, secretariaID, tenantID).Scan(&ok)
+	return ok, err
+}
+
+// CreateProtocolo numera, roteia e grava o protocolo com seus anexos numa só transação.
+func (r *Repository) CreateProtocolo(ctx context.Context, in NovoProtocolo) (*Protocolo, error) {
+	dados, err := json.Marshal(in.Dados)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ano := in.SubmittedAt.Year()
+	var seq int
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO protocolo_sequencias (tenant_id, ano, ultimo) VALUES ($1, $2, 1)
+        ON CONFLICT (tenant_id, ano) DO UPDATE SET ultimo = protocolo_sequencias.ultimo + 1
+        RETURNING ultimo
+    `, in.TenantID, ano).Scan(&seq); err != nil {
+		return nil, err
+	}
+
+	p := Protocolo{
+		ID:          in.ID,
+		TenantID:    in.TenantID,
+		CategoriaID: in.Categoria.ID,
+		Categoria:   in.Categoria.Nome,
+		Numero:      FormatNumero(ano, seq),
+		CidadaoID:   in.CidadaoID,
+		Status:      StatusAberto,
+		Dados:       in.Dados,
+		FormVersion: in.Categoria.FormVersion,
+		Anexos:      make([]Anexo, 0, len(in.Anexos)),
+		Localizacao: in.Localizacao,
+		Bairro:      in.Bairro,
+		AtivoID:     in.AtivoID,
+	}
+	if p.DuplicadoDe, err = findDuplicado(ctx, tx, in.TenantID, in.Categoria, in.Localizacao); err != nil {
+		return nil, err
+	}
+	var lat, lng *float64
+	if p.Localizacao != nil {
+		lat, lng = &p.Localizacao.Lat, &p.Localizacao.Lng
+	}
+	if err := tx.QueryRow(ctx, `
+        INSERT INTO protocolos (id, tenant_id, categoria_id, numero, cidadao_id, status, dados, form_version,
+                                localizacao, bairro, duplicado_de, ativo_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+                CASE WHEN $9::float8 IS NULL THEN NULL ELSE ST_SetSRID(ST_MakePoint($10, $9), 4326)::geography END,
+                $11, $12, $13, $14, $14)
+        RETURNING created_at, updated_at
+    `, p.ID, p.TenantID, p.CategoriaID, p.Numero, p.CidadaoID, p.Status, dados, p.FormVersion,
+		lat, lng, p.Bairro, p.DuplicadoDe, p.AtivoID, in.SubmittedAt).Scan(&p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if p.DuplicadoDe != nil {
+		err = r.routeDuplicado(ctx, tx, &p)
+	} else {
+		err = r.routeNew(ctx, tx, &p, in.Categoria)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range in.Anexos {
+		if err := tx.QueryRow(ctx, `
+            INSERT INTO protocolo_anexos (protocolo_id, campo, file_name, content_type, size_bytes, object_key, file_url)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            RETURNING id, created_at
+        `, p.ID, a.Campo, a.FileName, a.ContentType, a.SizeBytes, a.ObjectKey, a.FileURL).Scan(&a.ID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Anexos = append(p.Anexos, a)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListByCidadao lista os protocolos do cidadão no tenant, mais recentes primeiro.
+func (r *Repository) ListByCidadao(ctx context.Context, tenantID, cidadaoID uuid.UUID) ([]Protocolo, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+protocoloColumns+`
+        FROM protocolos p
+        JOIN protocolo_categorias c ON c.id = p.categoria_id
+        WHERE p.tenant_id = $1 AND p.cidadao_id = $2
+        ORDER BY p.created_at DESC
+    `, tenantID, cidadaoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	protocolos := make([]Protocolo, 0)
+	for rows.Next() {
+		p, err := scanProtocolo(rows)
+		if err != nil {
+			return nil, err
+		}
+		protocolos = append(protocolos, *p)
+	}
+	return protocolos, rows.Err()
+}
+
+// GetProtocolo busca o protocolo do tenant com os anexos.
+func (r *Repository) GetProtocolo(ctx context.Context, tenantID, id uuid.UUID) (*Protocolo, error) {
+	p, err := scanProtocolo(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+protocoloColumns+`
+        FROM protocolos p
+        JOIN protocolo_categorias c ON c.id = p.categoria_id
+        WHERE p.tenant_id = $1 AND p.id = $2
+    `, tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT id, campo, file_name, content_type, size_bytes, object_key, file_url, created_at
+        FROM protocolo_anexos
+        WHERE protocolo_id = $1
+        ORDER BY created_at
+    `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	p.Anexos = make([]Anexo, 0)
+	for rows.Next() {
+		var a Anexo
+		if err := rows.Scan(&a.ID, &a.Campo, &a.FileName, &a.ContentType, &a.SizeBytes, &a.ObjectKey, &a.FileURL, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Anexos = append(p.Anexos, a)
+	}
+	return p, rows.Err()
+}
+
+// SetAtivo vincula o protocolo a um ativo do mesmo tenant (ou desfaz o vínculo com nil).
+func (r *Repository) SetAtivo(ctx context.Context, tenantID, protocoloID uuid.UUID, ativoID *uuid.UUID) (*Protocolo, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE protocolos SET ativo_id = $3, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+          AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM ativos WHERE id = $3 AND tenant_id = $1))
+    `, tenantID, protocoloID, ativoID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.GetProtocolo(ctx, tenantID, protocoloID)
+}
+
+func scanCategoria(row pgx.Row) (*Categoria, error) {
+	var (
+		c    Categoria
+		form []byte
+	)
+	if err := row.Scan(&c.ID, &c.TenantID, &c.SecretariaID, &c.Slug, &c.Nome, &c.Descricao, &c.Ativo, &form, &c.FormVersion,
+		&c.DedupRaioMetros, &c.DedupJanelaDias, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(form, &c.Form); err != nil {
+		return nil, err
+	}
+	if err := c.Form.Normalize(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanProtocolo(row pgx.Row) (*Protocolo, error) {
+	var (
+		p        Protocolo
+		dados    []byte
+		lat, lng *float64
+	)
+	if err := row.Scan(&p.ID, &p.TenantID, &p.CategoriaID, &p.Categoria, &p.Numero, &p.CidadaoID, &p.Status, &dados, &p.FormVersion,
+		&p.SecretariaID, &p.FilaID, &p.ResponsavelID, &p.AtribuidoEm, &p.ConcluidoEm,
+		&lat, &lng, &p.Bairro, &p.DuplicadoDe, &p.AtivoID, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(dados, &p.Dados); err != nil {
+		return nil, err
+	}
+	if lat != nil && lng != nil {
+		p.Localizacao = &Ponto{Lat: *lat, Lng: *lng}
+	}
+	return &p, nil
+}
+
+func categoriaError(c *Categoria, err error) (*Categoria, error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrDuplicate
+	}
+	return c, err
+}
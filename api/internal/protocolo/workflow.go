@@ -0,0 +1,196 @@
+package protocolo
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Estratégias de atribuição automática das filas.
+const (
+	StrategyManual     = "manual"
+	StrategyRoundRobin = "round_robin"
+	StrategyLoad       = "load"
+)
+
+// Tipos de evento do histórico do protocolo.
+const (
+	EventoRoteado     = "roteado"
+	EventoAtribuido   = "atribuido"
+	EventoTransferido = "transferido"
+	EventoStatus      = "status"
+	// EventoOrdemServico registra o andamento da ordem de serviço gerada pelo protocolo.
+	EventoOrdemServico = "ordem_servico"
+)
+
+var (
+	// ErrInvalidTransition indica mudança de status não permitida.
+	ErrInvalidTransition = errors.New("protocolo: mudança de status inválida")
+	// ErrNotMember indica responsável fora da fila (ou da secretaria) do protocolo.
+	ErrNotMember = errors.New("protocolo: responsável não pertence à fila")
+	// ErrClosed indica protocolo já concluído ou cancelado.
+	ErrClosed = errors.New("protocolo: protocolo encerrado")
+	// ErrFilaDuplicate indica nome de fila já usado na secretaria.
+	ErrFilaDuplicate = errors.New("protocolo: fila já cadastrada")
+)
+
+// Fila agrupa o atendimento de uma secretaria e define como os protocolos são atribuídos.
+type Fila struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	SecretariaID uuid.UUID `json:"secretaria_id"`
+	Nome         string    `json:"nome"`
+	Estrategia   string    `json:"estrategia"`
+	Ativo        bool      `json:"ativo"`
+	Membros      []Membro  `json:"membros"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FilaInput contém os campos editáveis de uma fila; SecretariaID só vale na criação.
+type FilaInput struct {
+	SecretariaID uuid.UUID
+	Nome         string
+	Estrategia   string
+	Ativo        bool
+}
+
+// Normalize limpa e valida a entrada.
+func (in *FilaInput) Normalize() error {
+	in.Nome = strings.TrimSpace(in.Nome)
+	in.Estrategia = strings.ToLower(strings.TrimSpace(in.Estrategia))
+	if in.Estrategia == "" {
+		in.Estrategia = StrategyManual
+	}
+	switch {
+	case in.Nome == "":
+		return errors.New("nome obrigatório")
+	case in.Estrategia != StrategyManual && in.Estrategia != StrategyRoundRobin && in.Estrategia != StrategyLoad:
+		return errors.New("estratégia inválida")
+	}
+	return nil
+}
+
+// Membro é um atendente da fila, com a carga atual usada na atribuição por carga.
+type Membro struct {
+	UsuarioID      uuid.UUID  `json:"usuario_id"`
+	Nome           *string    `json:"nome,omitempty"`
+	Ativo          bool       `json:"ativo"`
+	EmAberto       int        `json:"em_aberto"`
+	LastAssignedAt *time.Time `json:"last_assigned_at,omitempty"`
+}
+
+// Regra roteia os protocolos de uma categoria para a secretaria (e opcionalmente a fila).
+type Regra struct {
+	CategoriaID  uuid.UUID  `json:"categoria_id"`
+	SecretariaID uuid.UUID  `json:"secretaria_id"`
+	FilaID       *uuid.UUID `json:"fila_id,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Evento é uma entrada do histórico do protocolo.
+type Evento struct {
+	ID               uuid.UUID  `json:"id"`
+	Tipo             string     `json:"tipo"`
+	DeSecretariaID   *uuid.UUID `json:"de_secretaria_id,omitempty"`
+	ParaSecretariaID *uuid.UUID `json:"para_secretaria_id,omitempty"`
+	DeFilaID         *uuid.UUID `json:"de_fila_id,omitempty"`
+	ParaFilaID       *uuid.UUID `json:"para_fila_id,omitempty"`
+	ResponsavelID    *uuid.UUID `json:"responsavel_id,omitempty"`
+	Status           *string    `json:"status,omitempty"`
+	Motivo           *string    `json:"motivo,omitempty"`
+	AtorID           *uuid.UUID `json:"ator_id,omitempty"`
+	DelegacaoID      *uuid.UUID `json:"delegacao_id,omitempty"`
+	EmNomeDeID       *uuid.UUID `json:"em_nome_de_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// Transferencia move o protocolo para outra secretaria/fila.
+type Transferencia struct {
+	SecretariaID uuid.UUID
+	FilaID       *uuid.UUID
+	Motivo       string
+	AtorID       *uuid.UUID
+}
+
+// ProtocoloFilter filtra a caixa de protocolos da prefeitura.
+type ProtocoloFilter struct {
+	SecretariaID  *uuid.UUID
+	FilaID        *uuid.UUID
+	ResponsavelID *uuid.UUID
+	DuplicadoDe   *uuid.UUID
+	Status        string
+	Limit         int
+}
+
+// FilaMetrics resume a produtividade de uma fila no período.
+type FilaMetrics struct {
+	FilaID          uuid.UUID            `json:"fila_id"`
+	Nome            string               `json:"nome"`
+	SecretariaID    uuid.UUID            `json:"secretaria_id"`
+	Recebidos       int                  `json:"recebidos"`
+	TransferidosOut int                  `json:"transferidos_saida"`
+	Concluidos      int                  `json:"concluidos"`
+	EmAberto        int                  `json:"em_aberto"`
+	SemResponsavel  int                  `json:"sem_responsavel"`
+	HorasMedias     *float64             `json:"horas_medias_conclusao"`
+	Responsaveis    []ResponsavelMetrics `json:"responsaveis"`
+}
+
+// ResponsavelMetrics resume a produtividade de um atendente na fila.
+type ResponsavelMetrics struct {
+	UsuarioID   uuid.UUID `json:"usuario_id"`
+	Nome        *string   `json:"nome,omitempty"`
+	Concluidos  int       `json:"concluidos"`
+	EmAberto    int       `json:"em_aberto"`
+	HorasMedias *float64  `json:"horas_medias_conclusao"`
+}
+
+// CanTransition informa se o status pode passar de from para to. Concluído e cancelado são finais.
+func CanTransition(from, to string) bool {
+	switch from {
+	case StatusAberto:
+		return to == StatusEmAndamento || to == StatusConcluido || to == StatusCancelado
+	case StatusEmAndamento:
+		return to == StatusConcluido || to == StatusCancelado
+	}
+	return false
+}
+
+// PickAssignee escolhe o atendente segundo a estratégia da fila. Round-robin entrega a quem está
+// há mais tempo sem receber (nunca atribuídos primeiro); por carga, a quem tem menos protocolos
+// em aberto, desempatando pelo round-robin. Manual ou fila sem membros ativos não atribui.
+func PickAssignee(strategy string, membros []Membro) *uuid.UUID {
+	if strategy != StrategyRoundRobin && strategy != StrategyLoad {
+		return nil
+	}
+	candidates := make([]Membro, 0, len(membros))
+	for _, m := range membros {
+		if m.Ativo {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if strategy == StrategyLoad && a.EmAberto != b.EmAberto {
+			return a.EmAberto < b.EmAberto
+		}
+		switch {
+		case a.LastAssignedAt == nil && b.LastAssignedAt != nil:
+			return true
+		case a.LastAssignedAt != nil && b.LastAssignedAt == nil:
+			return false
+		case a.LastAssignedAt != nil && !a.LastAssignedAt.Equal(*b.LastAssignedAt):
+			return a.LastAssignedAt.Before(*b.LastAssignedAt)
+		}
+		return a.UsuarioID.String() < b.UsuarioID.String()
+	})
+	id := candidates[0].UsuarioID
+	return &id
+}
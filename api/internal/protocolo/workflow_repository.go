@@ -0,0 +1,557 @@
+package protocolo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/gestaozabele/municipio/internal/authz"
+)
+
+const filaColumns = `id, tenant_id, secretaria_id, nome, estrategia, ativo, created_at, updated_at`
+
+// ListFilas lista as filas da prefeitura com os membros e a carga de cada um.
+func (r *Repository) ListFilas(ctx context.Context, tenantID uuid.UUID) ([]Fila, error) {
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+filaColumns+` FROM protocolo_filas WHERE tenant_id = $1 ORDER BY nome`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	filas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Fila, error) {
+		f, err := scanFila(row)
+		if err != nil {
+			return Fila{}, err
+		}
+		return *f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range filas {
+		if filas[i].Membros, err = r.listMembros(ctx, r.conn(ctx), filas[i].ID, false); err != nil {
+			return nil, err
+		}
+	}
+	return filas, nil
+}
+
+// CreateFila cadastra uma fila na secretaria; a entrada já deve estar normalizada.
+func (r *Repository) CreateFila(ctx context.Context, tenantID uuid.UUID, in FilaInput) (*Fila, error) {
+	row := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO protocolo_filas (tenant_id, secretaria_id, nome, estrategia, ativo)
+        SELECT $1, s.id, $3, $4, $5 FROM secretarias s WHERE s.id = $2 AND s.tenant_id = $1
+        RETURNING `+filaColumns,
+		tenantID, in.SecretariaID, in.Nome, in.Estrategia, in.Ativo)
+	f, err := filaError(scanFila(row))
+	if err != nil {
+		return nil, err
+	}
+	f.Membros = []Membro{}
+	return f, nil
+}
+
+// UpdateFila altera nome, estratégia e situação da fila; a secretaria não muda.
+func (r *Repository) UpdateFila(ctx context.Context, tenantID, id uuid.UUID, in FilaInput) (*Fila, error) {
+	row := r.conn(ctx).QueryRow(ctx, `
+        UPDATE protocolo_filas SET nome = $3, estrategia = $4, ativo = $5, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+        RETURNING `+filaColumns,
+		tenantID, id, in.Nome, in.Estrategia, in.Ativo)
+	f, err := filaError(scanFila(row))
+	if err != nil {
+		return nil, err
+	}
+	if f.Membros, err = r.listMembros(ctx, r.conn(ctx), f.ID, false); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetMembros define os atendentes da fila; todos precisam estar vinculados à secretaria dela.
+// Quem sai da lista fica inativo, preservando o histórico do round-robin.
+func (r *Repository) SetMembros(ctx context.Context, tenantID, filaID uuid.UUID, usuarios []uuid.UUID) ([]Membro, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var secretariaID uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT secretaria_id FROM protocolo_filas WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, filaID).Scan(&secretariaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var linked int
+	if err := tx.QueryRow(ctx, `
+        SELECT count(DISTINCT usuario_id) FROM usuarios_secretarias WHERE secretaria_id = $1 AND usuario_id = ANY($2)
+    `, secretariaID, usuarios).Scan(&linked); err != nil {
+		return nil, err
+	}
+	if linked != len(uniqueIDs(usuarios)) {
+		return nil, ErrNotMember
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE protocolo_fila_membros SET ativo = FALSE WHERE fila_id = $1 AND NOT (usuario_id = ANY($2))`, filaID, usuarios); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO protocolo_fila_membros (fila_id, usuario_id)
+        SELECT $1, unnest($2::uuid[])
+        ON CONFLICT (fila_id, usuario_id) DO UPDATE SET ativo = TRUE
+    `, filaID, usuarios); err != nil {
+		return nil, err
+	}
+	membros, err := r.listMembros(ctx, tx, filaID, false)
+	if err != nil {
+		return nil, err
+	}
+	return membros, tx.Commit(ctx)
+}
+
+// ListRegras lista o roteamento das categorias da prefeitura.
+func (r *Repository) ListRegras(ctx context.Context, tenantID uuid.UUID) ([]Regra, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT categoria_id, secretaria_id, fila_id, updated_at FROM protocolo_regras WHERE tenant_id = $1
+    `, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Regra, error) {
+		var g Regra
+		err := row.Scan(&g.CategoriaID, &g.SecretariaID, &g.FilaID, &g.UpdatedAt)
+		return g, err
+	})
+}
+
+// SaveRegra define para qual secretaria (e fila) vão os protocolos da categoria. A fila, quando
+// informada, precisa ser da mesma secretaria.
+func (r *Repository) SaveRegra(ctx context.Context, tenantID uuid.UUID, regra Regra) (*Regra, error) {
+	row := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO protocolo_regras (categoria_id, tenant_id, secretaria_id, fila_id)
+        SELECT c.id, c.tenant_id, s.id, f.id
+        FROM protocolo_categorias c
+        JOIN secretarias s ON s.id = $3 AND s.tenant_id = c.tenant_id
+        LEFT JOIN protocolo_filas f ON f.id = $4 AND f.secretaria_id = s.id
+        WHERE c.tenant_id = $1 AND c.id = $2 AND ($4::uuid IS NULL OR f.id IS NOT NULL)
+        ON CONFLICT (categoria_id) DO UPDATE
+            SET secretaria_id = EXCLUDED.secretaria_id, fila_id = EXCLUDED.fila_id, updated_at = now()
+        RETURNING categoria_id, secretaria_id, fila_id, updated_at
+    `, tenantID, regra.CategoriaID, regra.SecretariaID, regra.FilaID)
+	var saved Regra
+	if err := row.Scan(&saved.CategoriaID, &saved.SecretariaID, &saved.FilaID, &saved.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteRegra remove o roteamento; a categoria volta a usar a secretaria do seu cadastro.
+func (r *Repository) DeleteRegra(ctx context.Context, tenantID, categoriaID uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM protocolo_regras WHERE tenant_id = $1 AND categoria_id = $2`, tenantID, categoriaID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListProtocolos lista a caixa de protocolos da prefeitura.
+func (r *Repository) ListProtocolos(ctx context.Context, tenantID uuid.UUID, filter ProtocoloFilter) ([]Protocolo, error) {
+	clauses := []string{"p.tenant_id = $1"}
+	args := []any{tenantID}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+	if filter.SecretariaID != nil {
+		add("p.secretaria_id = $%d", *filter.SecretariaID)
+	}
+	if filter.FilaID != nil {
+		add("p.fila_id = $%d", *filter.FilaID)
+	}
+	if filter.ResponsavelID != nil {
+		add("p.responsavel_id = $%d", *filter.ResponsavelID)
+	}
+	if filter.DuplicadoDe != nil {
+		add("p.duplicado_de = $%d", *filter.DuplicadoDe)
+	}
+	if filter.Status != "" {
+		add("p.status = $%d", filter.Status)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args = append(args, limit)
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+protocoloColumns+`
+        FROM protocolos p
+        JOIN protocolo_categorias c ON c.id = p.categoria_id
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY p.created_at DESC
+        LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	protocolos := make([]Protocolo, 0)
+	for rows.Next() {
+		p, err := scanProtocolo(rows)
+		if err != nil {
+			return nil, err
+		}
+		protocolos = append(protocolos, *p)
+	}
+	return protocolos, rows.Err()
+}
+
+// ListEventos devolve o histórico do protocolo em ordem cronológica.
+func (r *Repository) ListEventos(ctx context.Context, protocoloID uuid.UUID) ([]Evento, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT id, tipo, de_secretaria_id, para_secretaria_id, de_fila_id, para_fila_id, responsavel_id, status, motivo, ator_id,
+               delegacao_id, em_nome_de_id, created_at
+        FROM protocolo_eventos
+        WHERE protocolo_id = $1
+        ORDER BY created_at, id
+    `, protocoloID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Evento, error) {
+		var e Evento
+		err := row.Scan(&e.ID, &e.Tipo, &e.DeSecretariaID, &e.ParaSecretariaID, &e.DeFilaID, &e.ParaFilaID, &e.ResponsavelID, &e.Status, &e.Motivo, &e.AtorID,
+			&e.DelegacaoID, &e.EmNomeDeID, &e.CreatedAt)
+		return e, err
+	})
+}
+
+// Transfer move o protocolo para outra secretaria/fila e reatribui segundo a estratégia da fila
+// de destino, registrando a transferência no histórico.
+func (r *Repository) Transfer(ctx context.Context, tenantID, protocoloID uuid.UUID, t Transferencia) (*Protocolo, error) {
+	return r.withProtocolo(ctx, tenantID, protocoloID, func(tx pgx.Tx, p *Protocolo) error {
+		if p.Status == StatusConcluido || p.Status == StatusCancelado {
+			return ErrClosed
+		}
+		motivo := strings.TrimSpace(t.Motivo)
+		return r.route(ctx, tx, p, &t.SecretariaID, t.FilaID, EventoTransferido, &motivo, t.AtorID)
+	})
+}
+
+// Assign atribui manualmente o protocolo a um atendente da fila (ou, sem fila, da secretaria).
+func (r *Repository) Assign(ctx context.Context, tenantID, protocoloID, usuarioID uuid.UUID, actorID *uuid.UUID) (*Protocolo, error) {
+	return r.withProtocolo(ctx, tenantID, protocoloID, func(tx pgx.Tx, p *Protocolo) error {
+		if p.Status == StatusConcluido || p.Status == StatusCancelado {
+			return ErrClosed
+		}
+		var member bool
+		err := tx.QueryRow(ctx, `
+            SELECT CASE
+                WHEN $2::uuid IS NOT NULL THEN EXISTS (SELECT 1 FROM protocolo_fila_membros WHERE fila_id = $2 AND usuario_id = $1 AND ativo)
+                ELSE EXISTS (SELECT 1 FROM usuarios_secretarias WHERE secretaria_id = $3 AND usuario_id = $1)
+            END
+        `, usuarioID, p.FilaID, p.SecretariaID).Scan(&member)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return ErrNotMember
+		}
+		if err := r.setResponsavel(ctx, tx, p, &usuarioID); err != nil {
+			return err
+		}
+		return insertEvento(ctx, tx, p.ID, Evento{Tipo: EventoAtribuido, ParaFilaID: p.FilaID, ResponsavelID: &usuarioID, AtorID: actorID})
+	})
+}
+
+// SetStatus muda o status do protocolo respeitando CanTransition.
+func (r *Repository) SetStatus(ctx context.Context, tenantID, protocoloID uuid.UUID, status string, motivo *string, actorID *uuid.UUID) (*Protocolo, error) {
+	return r.withProtocolo(ctx, tenantID, protocoloID, func(tx pgx.Tx, p *Protocolo) error {
+		if !CanTransition(p.Status, status) {
+			return ErrInvalidTransition
+		}
+		err := tx.QueryRow(ctx, `
+            UPDATE protocolos
+            SET status = $2, concluido_em = CASE WHEN $2 = 'concluido' THEN now() ELSE concluido_em END, updated_at = now()
+            WHERE id = $1
+            RETURNING status, concluido_em, updated_at
+        `, p.ID, status).Scan(&p.Status, &p.ConcluidoEm, &p.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return insertEvento(ctx, tx, p.ID, Evento{Tipo: EventoStatus, Status: &status, Motivo: motivo, ParaFilaID: p.FilaID, ResponsavelID: p.ResponsavelID, AtorID: actorID})
+	})
+}
+
+// QueueMetrics mede a produtividade das filas da prefeitura em [from, to).
+func (r *Repository) QueueMetrics(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]FilaMetrics, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT f.id, f.nome, f.secretaria_id,
+               (SELECT count(*) FROM protocolo_eventos e
+                 WHERE e.para_fila_id = f.id AND e.tipo IN ('roteado', 'transferido') AND e.created_at >= $2 AND e.created_at < $3),
+               (SELECT count(*) FROM protocolo_eventos e
+                 WHERE e.de_fila_id = f.id AND e.tipo = 'transferido' AND e.created_at >= $2 AND e.created_at < $3),
+               count(p.id) FILTER (WHERE p.status = 'concluido' AND p.concluido_em >= $2 AND p.concluido_em < $3),
+               count(p.id) FILTER (WHERE p.status IN ('aberto', 'em_andamento')),
+               count(p.id) FILTER (WHERE p.status IN ('aberto', 'em_andamento') AND p.responsavel_id IS NULL),
+               avg(extract(epoch FROM p.concluido_em - p.created_at) / 3600)
+                   FILTER (WHERE p.status = 'concluido' AND p.concluido_em >= $2 AND p.concluido_em < $3)
+        FROM protocolo_filas f
+        LEFT JOIN protocolos p ON p.fila_id = f.id
+        WHERE f.tenant_id = $1
+        GROUP BY f.id
+        ORDER BY f.nome
+    `, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (FilaMetrics, error) {
+		var m FilaMetrics
+		err := row.Scan(&m.FilaID, &m.Nome, &m.SecretariaID, &m.Recebidos, &m.TransferidosOut, &m.Concluidos, &m.EmAberto, &m.SemResponsavel, &m.HorasMedias)
+		m.Responsaveis = []ResponsavelMetrics{}
+		return m, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[uuid.UUID]int, len(metrics))
+	for i, m := range metrics {
+		index[m.FilaID] = i
+	}
+
+	rows, err = r.conn(ctx).Query(ctx, `
+        SELECT p.fila_id, p.responsavel_id, u.nome,
+               count(*) FILTER (WHERE p.status = 'concluido' AND p.concluido_em >= $2 AND p.concluido_em < $3),
+               count(*) FILTER (WHERE p.status IN ('aberto', 'em_andamento')),
+               avg(extract(epoch FROM p.concluido_em - p.created_at) / 3600)
+                   FILTER (WHERE p.status = 'concluido' AND p.concluido_em >= $2 AND p.concluido_em < $3)
+        FROM protocolos p
+        JOIN usuarios u ON u.id = p.responsavel_id
+        WHERE p.tenant_id = $1 AND p.fila_id IS NOT NULL
+        GROUP BY p.fila_id, p.responsavel_id, u.nome
+        ORDER BY u.nome
+    `, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			filaID uuid.UUID
+			m      ResponsavelMetrics
+		)
+		if err := rows.Scan(&filaID, &m.UsuarioID, &m.Nome, &m.Concluidos, &m.EmAberto, &m.HorasMedias); err != nil {
+			return nil, err
+		}
+		if i, ok := index[filaID]; ok {
+			metrics[i].Responsaveis = append(metrics[i].Responsaveis, m)
+		}
+	}
+	return metrics, rows.Err()
+}
+
+// routeNew aplica o roteamento da categoria a um protocolo recém-criado: a regra da categoria
+// ou, na falta dela, a secretaria do cadastro da categoria.
+func (r *Repository) routeNew(ctx context.Context, tx pgx.Tx, p *Protocolo, categoria *Categoria) error {
+	var (
+		secretariaID *uuid.UUID
+		filaID       *uuid.UUID
+	)
+	err := tx.QueryRow(ctx, `SELECT secretaria_id, fila_id FROM protocolo_regras WHERE categoria_id = $1`, categoria.ID).Scan(&secretariaID, &filaID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		secretariaID = categoria.SecretariaID
+	case err != nil:
+		return err
+	}
+	if secretariaID == nil {
+		return nil
+	}
+	return r.route(ctx, tx, p, secretariaID, filaID, EventoRoteado, nil, nil)
+}
+
+// route coloca o protocolo na secretaria/fila de destino e, se a fila tiver estratégia
+// automática, atribui um atendente. Sem fila informada, usa a fila ativa mais antiga da secretaria.
+func (r *Repository) route(ctx context.Context, tx pgx.Tx, p *Protocolo, secretariaID, filaID *uuid.UUID, tipo string, motivo *string, actorID *uuid.UUID) error {
+	var (
+		target     *uuid.UUID
+		estrategia = StrategyManual
+	)
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+        SELECT f.id, f.estrategia
+        FROM protocolo_filas f
+        JOIN secretarias s ON s.id = f.secretaria_id
+        WHERE s.id = $1 AND s.tenant_id = $2 AND f.ativo AND ($3::uuid IS NULL OR f.id = $3)
+        ORDER BY f.created_at
+        LIMIT 1
+        FOR UPDATE OF f
+    `, secretariaID, p.TenantID, filaID).Scan(&id, &estrategia)
+	switch {
+	case err == nil:
+		target = &id
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	case filaID != nil:
+		// Fila explícita inexistente, inativa ou de outra secretaria.
+		return ErrNotFound
+	default:
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM secretarias WHERE id = $1 AND tenant_id = $2)`, secretariaID, p.TenantID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+	}
+
+	var responsavel *uuid.UUID
+	if target != nil {
+		membros, err := r.listMembros(ctx, tx, *target, true)
+		if err != nil {
+			return err
+		}
+		responsavel = PickAssignee(estrategia, membros)
+		if responsavel != nil {
+			if _, err := tx.Exec(ctx, `UPDATE protocolo_fila_membros SET last_assigned_at = now() WHERE fila_id = $1 AND usuario_id = $2`, *target, *responsavel); err != nil {
+				return err
+			}
+		}
+	}
+
+	evento := Evento{
+		Tipo:             tipo,
+		DeSecretariaID:   p.SecretariaID,
+		ParaSecretariaID: secretariaID,
+		DeFilaID:         p.FilaID,
+		ParaFilaID:       target,
+		ResponsavelID:    responsavel,
+		Motivo:           motivo,
+		AtorID:           actorID,
+	}
+	if _, err := tx.Exec(ctx, `UPDATE protocolos SET secretaria_id = $2, fila_id = $3 WHERE id = $1`, p.ID, secretariaID, target); err != nil {
+		return err
+	}
+	p.SecretariaID, p.FilaID = secretariaID, target
+	if err := r.setResponsavel(ctx, tx, p, responsavel); err != nil {
+		return err
+	}
+	return insertEvento(ctx, tx, p.ID, evento)
+}
+
+func (r *Repository) setResponsavel(ctx context.Context, tx pgx.Tx, p *Protocolo, usuarioID *uuid.UUID) error {
+	return tx.QueryRow(ctx, `
+        UPDATE protocolos
+        SET responsavel_id = $2, atribuido_em = CASE WHEN $2::uuid IS NULL THEN NULL ELSE now() END, updated_at = now()
+        WHERE id = $1
+        RETURNING responsavel_id, atribuido_em, updated_at
+    `, p.ID, usuarioID).Scan(&p.ResponsavelID, &p.AtribuidoEm, &p.UpdatedAt)
+}
+
+// withProtocolo carrega o protocolo com lock, aplica fn e devolve o estado final com o histórico.
+func (r *Repository) withProtocolo(ctx context.Context, tenantID, protocoloID uuid.UUID, fn func(pgx.Tx, *Protocolo) error) (*Protocolo, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := scanProtocolo(tx.QueryRow(ctx, `
+        SELECT `+protocoloColumns+`
+        FROM protocolos p
+        JOIN protocolo_categorias c ON c.id = p.categoria_id
+        WHERE p.tenant_id = $1 AND p.id = $2
+        FOR UPDATE OF p
+    `, tenantID, protocoloID))
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(tx, p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if p.Eventos, err = r.ListEventos(ctx, p.ID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// listMembros devolve os membros da fila com a quantidade de protocolos em aberto de cada um.
+func (r *Repository) listMembros(ctx context.Context, q querier, filaID uuid.UUID, onlyActive bool) ([]Membro, error) {
+	rows, err := q.Query(ctx, `
+        SELECT m.usuario_id, u.nome, m.ativo, m.last_assigned_at,
+               (SELECT count(*) FROM protocolos p
+                 WHERE p.fila_id = m.fila_id AND p.responsavel_id = m.usuario_id AND p.status IN ('aberto', 'em_andamento'))
+        FROM protocolo_fila_membros m
+        JOIN usuarios u ON u.id = m.usuario_id
+        WHERE m.fila_id = $1 AND (NOT $2 OR m.ativo)
+        ORDER BY u.nome
+    `, filaID, onlyActive)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Membro, error) {
+		var m Membro
+		err := row.Scan(&m.UsuarioID, &m.Nome, &m.Ativo, &m.LastAssignedAt, &m.EmAberto)
+		return m, err
+	})
+}
+
+// insertEvento grava o evento no histórico; ações autorizadas por delegação levam a delegação e o
+// secretário em nome de quem foram feitas.
+func insertEvento(ctx context.Context, tx pgx.Tx, protocoloID uuid.UUID, e Evento) error {
+	if d := authz.DelegacaoFrom(ctx); d != nil {
+		e.DelegacaoID, e.EmNomeDeID = &d.ID, &d.DeleganteID
+	}
+	_, err := tx.Exec(ctx, `
+        INSERT INTO protocolo_eventos (protocolo_id, tipo, de_secretaria_id, para_secretaria_id, de_fila_id, para_fila_id, responsavel_id, status, motivo, ator_id,
+                                       delegacao_id, em_nome_de_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9::text, ''), $10, $11, $12)
+    `, protocoloID, e.Tipo, e.DeSecretariaID, e.ParaSecretariaID, e.DeFilaID, e.ParaFilaID, e.ResponsavelID, e.Status, e.Motivo, e.AtorID,
+		e.DelegacaoID, e.EmNomeDeID)
+	return err
+}
+
+func scanFila(row pgx.Row) (*Fila, error) {
+	var f Fila
+	if err := row.Scan(&f.ID, &f.TenantID, &f.SecretariaID, &f.Nome, &f.Estrategia, &f.Ativo, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func filaError(f *Fila, err error) (*Fila, error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrFilaDuplicate
+	}
+	return f, err
+}
+
+func uniqueIDs(ids []uuid.UUID) map[uuid.UUID]struct{} {
+	set := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
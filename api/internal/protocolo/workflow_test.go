@@ -0,0 +1,90 @@
+package protocolo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPickAssigneeRoundRobin(t *testing.T) {
+	now := time.Now()
+	older := now.Add(-time.Hour)
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	membros := []Membro{
+		{UsuarioID: a, Ativo: true, LastAssignedAt: &now},
+		{UsuarioID: b, Ativo: true, LastAssignedAt: &older},
+		{UsuarioID: c, Ativo: false},
+	}
+	if got := PickAssignee(StrategyRoundRobin, membros); got == nil || *got != b {
+		t.Fatalf("esperava %s, veio %v", b, got)
+	}
+
+	d := uuid.New()
+	membros = append(membros, Membro{UsuarioID: d, Ativo: true})
+	if got := PickAssignee(StrategyRoundRobin, membros); got == nil || *got != d {
+		t.Fatalf("membro nunca atribuído deveria receber primeiro, veio %v", got)
+	}
+}
+
+func TestPickAssigneeLoad(t *testing.T) {
+	now := time.Now()
+	older := now.Add(-time.Hour)
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	membros := []Membro{
+		{UsuarioID: a, Ativo: true, EmAberto: 3},
+		{UsuarioID: b, Ativo: true, EmAberto: 1, LastAssignedAt: &now},
+		{UsuarioID: c, Ativo: true, EmAberto: 1, LastAssignedAt: &older},
+	}
+	if got := PickAssignee(StrategyLoad, membros); got == nil || *got != c {
+		t.Fatalf("esperava %s, veio %v", c, got)
+	}
+}
+
+func TestPickAssigneeManualOrEmpty(t *testing.T) {
+	membros := []Membro{{UsuarioID: uuid.New(), Ativo: true}}
+	if got := PickAssignee(StrategyManual, membros); got != nil {
+		t.Fatalf("fila manual não deveria atribuir, veio %v", got)
+	}
+	if got := PickAssignee(StrategyRoundRobin, []Membro{{UsuarioID: uuid.New()}}); got != nil {
+		t.Fatalf("fila sem membros ativos não deveria atribuir, veio %v", got)
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusAberto, StatusEmAndamento, true},
+		{StatusAberto, StatusConcluido, true},
+		{StatusEmAndamento, StatusCancelado, true},
+		{StatusEmAndamento, StatusAberto, false},
+		{StatusConcluido, StatusEmAndamento, false},
+		{StatusCancelado, StatusAberto, false},
+		{StatusAberto, "arquivado", false},
+	}
+	for _, tc := range cases {
+		if got := CanTransition(tc.from, tc.to); got != tc.want {
+			t.Fatalf("%s -> %s: esperava %v", tc.from, tc.to, tc.want)
+		}
+	}
+}
+
+func TestFilaInputNormalize(t *testing.T) {
+	in := FilaInput{Nome: "  Triagem  ", Estrategia: " Round_Robin "}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("entrada válida rejeitada: %v", err)
+	}
+	if in.Nome != "Triagem" || in.Estrategia != StrategyRoundRobin {
+		t.Fatalf("normalização inesperada: %+v", in)
+	}
+	in = FilaInput{Nome: "Triagem"}
+	if err := in.Normalize(); err != nil || in.Estrategia != StrategyManual {
+		t.Fatalf("estratégia padrão deveria ser manual: %+v, %v", in, err)
+	}
+	in = FilaInput{Nome: "Triagem", Estrategia: "aleatoria"}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro para estratégia inválida")
+	}
+}
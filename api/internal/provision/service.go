@@ -156,3 +156,62 @@ func (s *Service) CheckTenant(ctx context.Context, tenantID uuid.UUID) (*tenant.
 	}
 	return s.tenants.GetByID(ctx, tenantID)
 }
+
+// brandingPaths são as rotas do portal que embutem tema e logo do município.
+var brandingPaths = []string{"/", "/index.html", "/manifest.webmanifest", "/tenant"}
+
+// PurgeTenantCache invalida na borda as páginas do município que carregam a identidade visual,
+// tanto no domínio próprio quanto no subdomínio provisionado, além das URLs extras informadas.
+func (s *Service) PurgeTenantCache(ctx context.Context, tenantID uuid.UUID, extra ...string) ([]string, error) {
+	s.mu.RLock()
+	client, baseDomain := s.cloudflare, s.baseDomain
+	s.mu.RUnlock()
+	if client == nil {
+		return nil, fmt.Errorf("cloudflare não configurado")
+	}
+
+	t, err := s.tenants.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, 0, 2)
+	if t.Domain != "" {
+		hosts = append(hosts, t.Domain)
+	}
+	if baseDomain != "" {
+		if fqdn := fmt.Sprintf("%s.%s", t.Slug, baseDomain); fqdn != t.Domain {
+			hosts = append(hosts, fqdn)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	urls := make([]string, 0, len(hosts)*len(brandingPaths)+len(extra))
+	add := func(u string) {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			return
+		}
+		if _, ok := seen[u]; ok {
+			return
+		}
+		seen[u] = struct{}{}
+		urls = append(urls, u)
+	}
+	for _, host := range hosts {
+		for _, path := range brandingPaths {
+			add("https://" + host + path)
+		}
+	}
+	for _, u := range extra {
+		add(u)
+	}
+	if len(urls) == 0 {
+		return urls, nil
+	}
+
+	if err := client.PurgeFiles(ctx, urls); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
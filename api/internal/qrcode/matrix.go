@@ -0,0 +1,240 @@
+package qrcode
+
+// builder monta a matriz: padrões fixos (function) e os módulos de dados.
+type builder struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newBuilder(version int) *builder {
+	size := version*4 + 17
+	b := &builder{version: version, size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range b.modules {
+		b.modules[i] = make([]bool, size)
+		b.function[i] = make([]bool, size)
+	}
+	return b
+}
+
+func (b *builder) set(x, y int, dark bool) {
+	b.modules[y][x] = dark
+	b.function[y][x] = true
+}
+
+func (b *builder) drawFunctionPatterns() {
+	for i := 0; i < b.size; i++ {
+		b.set(6, i, i%2 == 0)
+		b.set(i, 6, i%2 == 0)
+	}
+	b.drawFinder(3, 3)
+	b.drawFinder(b.size-4, 3)
+	b.drawFinder(3, b.size-4)
+
+	positions := alignmentPositions[b.version]
+	last := len(positions) - 1
+	for i, cx := range positions {
+		for j, cy := range positions {
+			// Os cantos com padrão de localização não recebem alinhamento.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			b.drawAlignment(cx, cy)
+		}
+	}
+
+	b.drawFormat(0)
+	if b.version >= 7 {
+		bits := versionBits(b.version)
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, c := b.size-11+i%3, i/3
+			b.set(a, c, dark)
+			b.set(c, a, dark)
+		}
+	}
+}
+
+// drawFinder desenha o padrão de localização centrado em (cx, cy) com o separador claro.
+func (b *builder) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= b.size || y >= b.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			b.set(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (b *builder) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			b.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat grava as duas cópias dos bits de formato e o módulo escuro fixo.
+func (b *builder) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		b.set(8, i, bit(i))
+	}
+	b.set(8, 7, bit(6))
+	b.set(8, 8, bit(7))
+	b.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		b.set(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.set(8, b.size-15+i, bit(i))
+	}
+	b.set(8, b.size-8, true)
+}
+
+// drawCodewords distribui os bits em zigue-zague a partir do canto inferior direito.
+func (b *builder) drawCodewords(data []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < b.size; vert++ {
+			y := vert
+			if upward {
+				y = b.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if b.function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				b.modules[y][x] = (data[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverte os módulos de dados segundo a máscara; aplicar duas vezes desfaz.
+func (b *builder) applyMask(mask int) {
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			if b.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				b.modules[y][x] = !b.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty pontua a matriz pelas quatro regras da norma; a máscara de menor pontuação é a escolhida.
+func (b *builder) penalty() int {
+	score := 0
+	at := func(x, y int, horizontal bool) bool {
+		if horizontal {
+			return b.modules[y][x]
+		}
+		return b.modules[x][y]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, horizontal := range []bool{true, false} {
+		for y := 0; y < b.size; y++ {
+			run := 1
+			for x := 1; x <= b.size; x++ {
+				if x < b.size && at(x, y, horizontal) == at(x-1, y, horizontal) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+7 <= b.size; x++ {
+				match := true
+				for k, dark := range finderLike {
+					if at(x+k, y, horizontal) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (b.lightRun(x-4, x, y, horizontal) || b.lightRun(x+7, x+11, y, horizontal)) {
+					score += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			if b.modules[y][x] {
+				dark++
+			}
+			if x+1 < b.size && y+1 < b.size {
+				c := b.modules[y][x]
+				if b.modules[y][x+1] == c && b.modules[y+1][x] == c && b.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	total := b.size * b.size
+	score += abs(dark*100/total-50) / 5 * 10
+	return score
+}
+
+// lightRun informa se as posições [from, to) da linha são claras; fora da matriz conta como claro.
+func (b *builder) lightRun(from, to, line int, horizontal bool) bool {
+	for i := from; i < to; i++ {
+		if i < 0 || i >= b.size {
+			continue
+		}
+		dark := b.modules[line][i]
+		if !horizontal {
+			dark = b.modules[i][line]
+		}
+		if dark {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,267 @@
+// Package qrcode gera QR codes (modo byte, correção de erro nível M, versões 1 a 10) sem
+// dependências externas, suficientes para URLs de etiquetas e documentos emitidos pela prefeitura.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTooLong indica conteúdo maior que a capacidade da versão 10 no nível M (213 bytes).
+var ErrTooLong = errors.New("qrcode: conteúdo longo demais")
+
+// eccBlocks descreve, por versão, os codewords de correção por bloco e o tamanho dos dados de
+// cada bloco no nível M.
+var eccBlocks = [...]struct {
+	ecc    int
+	blocks []int
+}{
+	1:  {10, []int{16}},
+	2:  {16, []int{28}},
+	3:  {26, []int{44}},
+	4:  {18, []int{32, 32}},
+	5:  {24, []int{43, 43}},
+	6:  {16, []int{27, 27, 27, 27}},
+	7:  {18, []int{31, 31, 31, 31}},
+	8:  {22, []int{38, 38, 39, 39}},
+	9:  {22, []int{36, 36, 36, 37, 37}},
+	10: {26, []int{43, 43, 43, 43, 44}},
+}
+
+var alignmentPositions = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+const maxVersion = 10
+
+// Code é a matriz de módulos do QR code, sem a zona de silêncio.
+type Code struct {
+	Version int
+	Size    int
+	modules [][]bool
+}
+
+// Dark informa se o módulo da coluna x, linha y é escuro.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode gera o QR code do conteúdo na menor versão que o comporta.
+func Encode(content string) (*Code, error) {
+	data := []byte(content)
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if len(data) <= capacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	b := newBuilder(version)
+	b.drawFunctionPatterns()
+	b.drawCodewords(interleave(version, encodeData(version, data)))
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		b.applyMask(mask)
+		b.drawFormat(mask)
+		if p := b.penalty(); best < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		b.applyMask(mask)
+	}
+	b.applyMask(best)
+	b.drawFormat(best)
+	return &Code{Version: version, Size: b.size, modules: b.modules}, nil
+}
+
+// SVG desenha o código com zona de silêncio de 4 módulos, cada módulo com scale unidades.
+func (c *Code) SVG(scale int) string {
+	if scale < 1 {
+		scale = 1
+	}
+	const quiet = 4
+	total := (c.Size + 2*quiet) * scale
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, total, total, c.Size+2*quiet, c.Size+2*quiet)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`, c.PathData(quiet))
+	return sb.String()
+}
+
+// PathData devolve o atributo d de um path SVG com os módulos escuros, deslocados de offset
+// módulos, para embutir o código em outros desenhos.
+func (c *Code) PathData(offset int) string {
+	var sb strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&sb, "M%d %dh1v1h-1z", x+offset, y+offset)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// capacity devolve quantos bytes cabem na versão (modo byte, nível M).
+func capacity(version int) int {
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	return (dataCodewords(version)*8 - 4 - countBits) / 8
+}
+
+func dataCodewords(version int) int {
+	total := 0
+	for _, n := range eccBlocks[version].blocks {
+		total += n
+	}
+	return total
+}
+
+// encodeData monta o fluxo de bits (modo, contagem, dados, terminador e preenchimento).
+func encodeData(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, c := range data {
+		bits.append(int(c), 8)
+	}
+	limit := dataCodewords(version) * 8
+	for i := 0; i < 4 && bits.len() < limit; i++ {
+		bits.append(0, 1)
+	}
+	for bits.len()%8 != 0 {
+		bits.append(0, 1)
+	}
+	out := bits.bytes()
+	for pad := byte(0xEC); len(out) < dataCodewords(version); pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// interleave divide os dados em blocos, calcula a correção de cada um e intercala os codewords.
+func interleave(version int, data []byte) []byte {
+	spec := eccBlocks[version]
+	divisor := rsDivisor(spec.ecc)
+	var blocks, eccs [][]byte
+	offset := 0
+	for _, n := range spec.blocks {
+		block := data[offset : offset+n]
+		offset += n
+		blocks = append(blocks, block)
+		eccs = append(eccs, rsRemainder(block, divisor))
+	}
+	out := make([]byte, 0, len(data)+spec.ecc*len(blocks))
+	longest := spec.blocks[len(spec.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ecc; i++ {
+		for _, ecc := range eccs {
+			out = append(out, ecc[i])
+		}
+	}
+	return out
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>i)&1 == 1)
+	}
+}
+
+func (b *bitBuffer) len() int { return len(b.bits) }
+
+func (b *bitBuffer) bytes() []byte {
+	out := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// rsMultiply multiplica no corpo GF(2^8) com o polinômio 0x11D.
+func rsMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = rsMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = rsMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= rsMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// formatBits devolve os 15 bits de formato para o nível M e a máscara.
+func formatBits(mask int) int {
+	data := 0b00<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits devolve os 18 bits de informação de versão (versões 7 em diante).
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
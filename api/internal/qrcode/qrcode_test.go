@@ -0,0 +1,53 @@
+package qrcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// Exemplo clássico "HELLO WORLD" versão 1-M.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("correção de erro: %v, esperava %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	want := []int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+	for mask, bits := range want {
+		if got := formatBits(mask); got != bits {
+			t.Fatalf("formato M/%d: %015b, esperava %015b", mask, got, bits)
+		}
+	}
+	if got := versionBits(7); got != 0x07C94 {
+		t.Fatalf("versão 7: %x", got)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	code, err := Encode("https://prefeitura.exemplo.gov.br/ativos/abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Version != 4 || code.Size != 33 {
+		t.Fatalf("versão %d tamanho %d", code.Version, code.Size)
+	}
+	// Padrões de localização e módulo escuro fixo.
+	for _, p := range [][2]int{{0, 0}, {32, 0}, {0, 32}, {8, 25}} {
+		if !code.Dark(p[0], p[1]) {
+			t.Fatalf("módulo %v deveria ser escuro", p)
+		}
+	}
+	if !strings.HasPrefix(code.SVG(4), "<svg") {
+		t.Fatal("svg inválido")
+	}
+	if _, err := Encode(strings.Repeat("x", 214)); err != ErrTooLong {
+		t.Fatalf("esperava ErrTooLong, veio %v", err)
+	}
+	if code, err := Encode(strings.Repeat("x", 213)); err != nil || code.Version != 10 {
+		t.Fatalf("213 bytes deveriam caber na versão 10: %v", err)
+	}
+}
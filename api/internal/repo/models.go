@@ -26,6 +26,29 @@ type Cidadao struct {
 	CriadoEm  time.Time
 }
 
+// Responsavel representa familiar com acesso ao portal dos alunos vinculados.
+type Responsavel struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	Nome      string
+	Email     string
+	Telefone  *string
+	SenhaHash *string
+	Ativo     bool
+	CriadoEm  time.Time
+}
+
+// Aluno representa estudante com acesso às avaliações online. TenantID vem da matrícula ativa;
+// sem matrícula ativa o acesso fica bloqueado.
+type Aluno struct {
+	ID        uuid.UUID
+	Nome      string
+	Matricula string
+	SenhaHash *string
+	TenantID  *uuid.UUID
+	CriadoEm  time.Time
+}
+
 // Secretaria representa secretaria municipal.
 type Secretaria struct {
 	ID       uuid.UUID
@@ -51,6 +74,8 @@ type TokenRefresh struct {
 	Expiracao time.Time
 	CriadoEm  time.Time
 	Revogado  bool
+	// Metodo é nulo em sessões anteriores à migração 095 e fora do backoffice.
+	Metodo *string
 }
 
 // SecretariaWithRole agrega secretaria com papel do usuário.
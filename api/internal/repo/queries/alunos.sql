@@ -0,0 +1,27 @@
+-- name: GetAlunoByMatricula :one
+SELECT a.id, a.nome, a.matricula, a.senha_hash, ativa.tenant_id, a.created_at
+FROM alunos a
+LEFT JOIN LATERAL (
+    SELECT e.tenant_id
+    FROM matriculas m
+    JOIN turmas t ON t.id = m.turma_id
+    JOIN escolas e ON e.id = t.escola_id
+    WHERE m.aluno_id = a.id AND m.ativo = TRUE
+    ORDER BY m.ano_letivo DESC NULLS LAST
+    LIMIT 1
+) ativa ON TRUE
+WHERE a.matricula = $1;
+
+-- name: GetAlunoByID :one
+SELECT a.id, a.nome, a.matricula, a.senha_hash, ativa.tenant_id, a.created_at
+FROM alunos a
+LEFT JOIN LATERAL (
+    SELECT e.tenant_id
+    FROM matriculas m
+    JOIN turmas t ON t.id = m.turma_id
+    JOIN escolas e ON e.id = t.escola_id
+    WHERE m.aluno_id = a.id AND m.ativo = TRUE
+    ORDER BY m.ano_letivo DESC NULLS LAST
+    LIMIT 1
+) ativa ON TRUE
+WHERE a.id = $1;
@@ -0,0 +1,9 @@
+-- name: GetResponsavelByEmail :one
+SELECT id, tenant_id, nome, email, telefone, senha_hash, ativo, criado_em
+FROM responsaveis
+WHERE lower(email) = lower($1);
+
+-- name: GetResponsavelByID :one
+SELECT id, tenant_id, nome, email, telefone, senha_hash, ativo, criado_em
+FROM responsaveis
+WHERE id = $1;
@@ -17,3 +17,22 @@ FROM usuarios_secretarias us
 JOIN secretarias s ON s.id = us.secretaria_id
 WHERE us.usuario_id = $1
 ORDER BY s.nome;
+
+-- name: HasTenantAdmin :one
+SELECT EXISTS (SELECT 1 FROM tenant_admins WHERE usuario_id = $1);
+
+-- name: HasBackofficeRole :one
+SELECT EXISTS (SELECT 1 FROM backoffice_role_membros WHERE usuario_id = $1)
+    OR EXISTS (SELECT 1 FROM delegacoes WHERE delegado_id = $1 AND revogada_em IS NULL
+               AND (now() AT TIME ZONE 'America/Sao_Paulo')::date BETWEEN inicio AND fim);
+
+-- name: ListUsuarioAuthPolicies :many
+SELECT t.settings->'auth'
+FROM tenants t
+WHERE t.settings ? 'auth'
+  AND t.id IN (
+      SELECT s.tenant_id FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id WHERE us.usuario_id = $1
+      UNION SELECT tenant_id FROM tenant_admins WHERE usuario_id = $1
+      UNION SELECT e.tenant_id FROM escolas_gestores eg JOIN escolas e ON e.id = eg.escola_id WHERE eg.usuario_id = $1
+      UNION SELECT e.tenant_id FROM professores_turmas pt JOIN turmas tu ON tu.id = pt.turma_id JOIN escolas e ON e.id = tu.escola_id WHERE pt.professor_id = $1
+  );
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
 )
 
 type Queries struct {
@@ -29,6 +31,8 @@ type InsertRefreshTokenParams struct {
 	TokenHash string
 	Expiracao time.Time
 	CriadoEm  time.Time
+	// Metodo é o login que abriu a sessão do backoffice; vazio nas demais audiências.
+	Metodo string
 }
 
 func (q *Queries) GetUsuarioByEmail(ctx context.Context, email string) (Usuario, error) {
@@ -96,6 +100,59 @@ func (q *Queries) HasProfessorTurma(ctx context.Context, professorID uuid.UUID)
 	return exists, nil
 }
 
+func (q *Queries) HasEscolaGestor(ctx context.Context, usuarioID uuid.UUID) (bool, error) {
+	var exists bool
+	if err := q.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM escolas_gestores WHERE usuario_id = $1)`, usuarioID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (q *Queries) HasTenantAdmin(ctx context.Context, usuarioID uuid.UUID) (bool, error) {
+	var exists bool
+	if err := q.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tenant_admins WHERE usuario_id = $1)`, usuarioID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (q *Queries) HasBackofficeRole(ctx context.Context, usuarioID uuid.UUID) (bool, error) {
+	var exists bool
+	if err := q.pool.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM backoffice_role_membros WHERE usuario_id = $1)
+            OR EXISTS (SELECT 1 FROM delegacoes WHERE delegado_id = $1 AND revogada_em IS NULL
+                       AND (now() AT TIME ZONE 'America/Sao_Paulo')::date BETWEEN inicio AND fim)`, usuarioID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (q *Queries) ListUsuarioAuthPolicies(ctx context.Context, usuarioID uuid.UUID) ([][]byte, error) {
+	rows, err := q.pool.Query(ctx, `SELECT t.settings->'auth'
+FROM tenants t
+WHERE t.settings ? 'auth'
+  AND t.id IN (
+      SELECT s.tenant_id FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id WHERE us.usuario_id = $1
+      UNION SELECT tenant_id FROM tenant_admins WHERE usuario_id = $1
+      UNION SELECT e.tenant_id FROM escolas_gestores eg JOIN escolas e ON e.id = eg.escola_id WHERE eg.usuario_id = $1
+      UNION SELECT e.tenant_id FROM professores_turmas pt JOIN turmas tu ON tu.id = pt.turma_id JOIN escolas e ON e.id = tu.escola_id WHERE pt.professor_id = $1
+  )`, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result [][]byte
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		result = append(result, raw)
+	}
+	return result, rows.Err()
+}
+
 func (q *Queries) GetCidadaoByEmail(ctx context.Context, email string) (Cidadao, error) {
 	row := q.pool.QueryRow(ctx, `SELECT id, nome, email, senha_hash, ativo, criado_em FROM cidadaos WHERE email = $1`, email)
 	var c Cidadao
@@ -120,21 +177,83 @@ func (q *Queries) GetCidadaoByID(ctx context.Context, id uuid.UUID) (Cidadao, er
 	return c, nil
 }
 
+const responsavelColumns = `id, tenant_id, nome, email, telefone, senha_hash, ativo, criado_em`
+
+// Responsáveis e alunos são lidos do pool fixado no contexto (db.Conn); dentro de
+// db.Resolver.EachCluster só valem os das prefeituras roteadas para o cluster (db.Tenants), para
+// que a cópia antiga de uma prefeitura movida não autentique ninguém.
+
+func (q *Queries) GetResponsavelByEmail(ctx context.Context, email string) (Responsavel, error) {
+	return scanResponsavel(db.Conn(ctx, q.pool).QueryRow(ctx, `SELECT `+responsavelColumns+` FROM responsaveis
+WHERE lower(email) = lower($1) AND ($2::uuid[] IS NULL OR tenant_id = ANY($2))`, email, db.Tenants(ctx)))
+}
+
+func (q *Queries) GetResponsavelByID(ctx context.Context, id uuid.UUID) (Responsavel, error) {
+	return scanResponsavel(db.Conn(ctx, q.pool).QueryRow(ctx, `SELECT `+responsavelColumns+` FROM responsaveis
+WHERE id = $1 AND ($2::uuid[] IS NULL OR tenant_id = ANY($2))`, id, db.Tenants(ctx)))
+}
+
+func scanResponsavel(row pgx.Row) (Responsavel, error) {
+	var r Responsavel
+	if err := row.Scan(&r.ID, &r.TenantID, &r.Nome, &r.Email, &r.Telefone, &r.SenhaHash, &r.Ativo, &r.CriadoEm); err != nil {
+		if err == pgx.ErrNoRows {
+			return Responsavel{}, ErrNotFound
+		}
+		return Responsavel{}, err
+	}
+	return r, nil
+}
+
+const alunoSelect = `SELECT a.id, a.nome, a.matricula, a.senha_hash, ativa.tenant_id, a.created_at
+FROM alunos a
+LEFT JOIN LATERAL (
+    SELECT e.tenant_id
+    FROM matriculas m
+    JOIN turmas t ON t.id = m.turma_id
+    JOIN escolas e ON e.id = t.escola_id
+    WHERE m.aluno_id = a.id AND m.ativo = TRUE
+    ORDER BY m.ano_letivo DESC NULLS LAST
+    LIMIT 1
+) ativa ON TRUE`
+
+func (q *Queries) GetAlunoByMatricula(ctx context.Context, matricula string) (Aluno, error) {
+	return scanAluno(db.Conn(ctx, q.pool).QueryRow(ctx, alunoSelect+` WHERE a.matricula = $1 AND `+alunoNoCluster, matricula, db.Tenants(ctx)))
+}
+
+func (q *Queries) GetAlunoByID(ctx context.Context, id uuid.UUID) (Aluno, error) {
+	return scanAluno(db.Conn(ctx, q.pool).QueryRow(ctx, alunoSelect+` WHERE a.id = $1 AND `+alunoNoCluster, id, db.Tenants(ctx)))
+}
+
+// alunoNoCluster restringe o aluno à matrícula ativa numa prefeitura do cluster; alunos é tabela
+// compartilhada e continua no primary depois que a prefeitura sai dele.
+const alunoNoCluster = `($2::uuid[] IS NULL OR ativa.tenant_id = ANY($2))`
+
+func scanAluno(row pgx.Row) (Aluno, error) {
+	var a Aluno
+	if err := row.Scan(&a.ID, &a.Nome, &a.Matricula, &a.SenhaHash, &a.TenantID, &a.CriadoEm); err != nil {
+		if err == pgx.ErrNoRows {
+			return Aluno{}, ErrNotFound
+		}
+		return Aluno{}, err
+	}
+	return a, nil
+}
+
 func (q *Queries) InsertRefreshToken(ctx context.Context, arg InsertRefreshTokenParams) (TokenRefresh, error) {
-	row := q.pool.QueryRow(ctx, `INSERT INTO tokens_refresh (id, subject, audience, token_hash, expiracao, criado_em, revogado)
-VALUES ($1, $2, $3, $4, $5, $6, FALSE)
-RETURNING id, subject, audience, token_hash, expiracao, criado_em, revogado`, arg.ID, arg.Subject, arg.Audience, arg.TokenHash, arg.Expiracao, arg.CriadoEm)
+	row := q.pool.QueryRow(ctx, `INSERT INTO tokens_refresh (id, subject, audience, token_hash, expiracao, criado_em, revogado, metodo)
+VALUES ($1, $2, $3, $4, $5, $6, FALSE, NULLIF($7, ''))
+RETURNING id, subject, audience, token_hash, expiracao, criado_em, revogado, metodo`, arg.ID, arg.Subject, arg.Audience, arg.TokenHash, arg.Expiracao, arg.CriadoEm, arg.Metodo)
 	var t TokenRefresh
-	if err := row.Scan(&t.ID, &t.Subject, &t.Audience, &t.TokenHash, &t.Expiracao, &t.CriadoEm, &t.Revogado); err != nil {
+	if err := row.Scan(&t.ID, &t.Subject, &t.Audience, &t.TokenHash, &t.Expiracao, &t.CriadoEm, &t.Revogado, &t.Metodo); err != nil {
 		return TokenRefresh{}, err
 	}
 	return t, nil
 }
 
 func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (TokenRefresh, error) {
-	row := q.pool.QueryRow(ctx, `SELECT id, subject, audience, token_hash, expiracao, criado_em, revogado FROM tokens_refresh WHERE token_hash = $1`, tokenHash)
+	row := q.pool.QueryRow(ctx, `SELECT id, subject, audience, token_hash, expiracao, criado_em, revogado, metodo FROM tokens_refresh WHERE token_hash = $1`, tokenHash)
 	var t TokenRefresh
-	if err := row.Scan(&t.ID, &t.Subject, &t.Audience, &t.TokenHash, &t.Expiracao, &t.CriadoEm, &t.Revogado); err != nil {
+	if err := row.Scan(&t.ID, &t.Subject, &t.Audience, &t.TokenHash, &t.Expiracao, &t.CriadoEm, &t.Revogado, &t.Metodo); err != nil {
 		if err == pgx.ErrNoRows {
 			return TokenRefresh{}, ErrNotFound
 		}
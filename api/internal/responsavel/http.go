@@ -0,0 +1,234 @@
+package responsavel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	httpmiddleware "github.com/gestaozabele/municipio/internal/http/middleware"
+)
+
+type ServiceProvider interface {
+	ListAlunos(ctx context.Context, responsavelID uuid.UUID) ([]Aluno, error)
+	Notas(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) (int, []Nota, error)
+	Frequencia(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int, from, to time.Time) (FrequenciaAluno, error)
+	Boletim(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) (Boletim, error)
+	Materiais(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) ([]Material, error)
+	Comunicados(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) ([]Comunicado, error)
+}
+
+// Handler expõe o portal somente leitura das famílias.
+type Handler struct {
+	service ServiceProvider
+}
+
+func NewHandler(service ServiceProvider) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/alunos", h.listAlunos)
+	r.Get("/alunos/{alunoID}/notas", h.notas)
+	r.Get("/alunos/{alunoID}/frequencia", h.frequencia)
+	r.Get("/alunos/{alunoID}/boletim", h.boletim)
+	r.Get("/alunos/{alunoID}/materiais", h.materiais)
+	r.Get("/alunos/{alunoID}/comunicados", h.comunicados)
+}
+
+func (h *Handler) listAlunos(w http.ResponseWriter, r *http.Request) {
+	responsavelID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return
+	}
+
+	alunos, err := h.service.ListAlunos(r.Context(), responsavelID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "não foi possível listar alunos", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"alunos": alunos})
+}
+
+func (h *Handler) notas(w http.ResponseWriter, r *http.Request) {
+	responsavelID, alunoID, anoLetivo, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	ano, notas, err := h.service.Notas(r.Context(), responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar notas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"ano_letivo": ano, "notas": notas})
+}
+
+func (h *Handler) frequencia(w http.ResponseWriter, r *http.Request) {
+	responsavelID, alunoID, anoLetivo, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, err := parseIntervalo(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", err.Error(), nil)
+		return
+	}
+
+	frequencia, err := h.service.Frequencia(r.Context(), responsavelID, alunoID, anoLetivo, from, to)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar frequência")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, frequencia)
+}
+
+func (h *Handler) boletim(w http.ResponseWriter, r *http.Request) {
+	responsavelID, alunoID, anoLetivo, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	boletim, err := h.service.Boletim(r.Context(), responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível carregar boletim")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, boletim)
+}
+
+func (h *Handler) materiais(w http.ResponseWriter, r *http.Request) {
+	responsavelID, alunoID, anoLetivo, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	materiais, err := h.service.Materiais(r.Context(), responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível listar materiais")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"materiais": materiais})
+}
+
+func (h *Handler) comunicados(w http.ResponseWriter, r *http.Request) {
+	responsavelID, alunoID, anoLetivo, ok := parseScope(w, r)
+	if !ok {
+		return
+	}
+
+	comunicados, err := h.service.Comunicados(r.Context(), responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		writeDomainError(w, err, "não foi possível listar comunicados")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"comunicados": comunicados})
+}
+
+// parseScope lê o responsável do token, o aluno da rota e o ano_letivo opcional da query.
+func parseScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, int, bool) {
+	responsavelID, err := subjectAsUUID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "AUTH", "identificação inválida", nil)
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+	alunoID, err := uuid.Parse(chi.URLParam(r, "alunoID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION", "aluno inválido", nil)
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+	anoLetivo := 0
+	if raw := r.URL.Query().Get("ano_letivo"); raw != "" {
+		anoLetivo, err = strconv.Atoi(raw)
+		if err != nil || anoLetivo < 2000 || anoLetivo > 2100 {
+			writeError(w, http.StatusBadRequest, "VALIDATION", "ano_letivo inválido", nil)
+			return uuid.Nil, uuid.Nil, 0, false
+		}
+	}
+	return responsavelID, alunoID, anoLetivo, true
+}
+
+// parseIntervalo lê from/to (YYYY-MM-DD); o dia final é incluído por completo.
+func parseIntervalo(r *http.Request) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		if from, err = time.Parse("2006-01-02", raw); err != nil {
+			return from, to, errors.New("from inválido")
+		}
+	}
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		if to, err = time.Parse("2006-01-02", raw); err != nil {
+			return from, to, errors.New("to inválido")
+		}
+		to = to.Add(24*time.Hour - time.Nanosecond)
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, errors.New("intervalo inválido")
+	}
+	return from, to, nil
+}
+
+func writeDomainError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "aluno não vinculado ao responsável", nil)
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "registro não encontrado", nil)
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", fallback, nil)
+	}
+}
+
+func subjectAsUUID(r *http.Request) (uuid.UUID, error) {
+	subject := httpmiddleware.GetSubject(r.Context())
+	return uuid.Parse(subject)
+}
+
+type successEnvelope struct {
+	Data  any `json:"data"`
+	Error any `json:"error"`
+}
+
+type errorEnvelope struct {
+	Data  any        `json:"data"`
+	Error *errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(successEnvelope{Data: data})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorEnvelope{
+		Data: nil,
+		Error: &errorBody{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
@@ -0,0 +1,341 @@
+package responsavel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+)
+
+const dbTimeout = 3 * time.Second
+
+// Repository encapsula consultas do portal das famílias. Toda leitura de aluno passa antes por
+// EnsureVinculo.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// conn devolve o pool do cluster da prefeitura da requisição (middleware TenantDatabase) ou o
+// pool padrão fora de requisição.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.db)
+}
+
+type Aluno struct {
+	ID         uuid.UUID  `json:"id"`
+	Nome       string     `json:"nome"`
+	Matricula  *string    `json:"matricula,omitempty"`
+	Parentesco *string    `json:"parentesco,omitempty"`
+	TurmaID    *uuid.UUID `json:"turma_id,omitempty"`
+	Turma      *string    `json:"turma,omitempty"`
+	Turno      *string    `json:"turno,omitempty"`
+	EscolaID   *uuid.UUID `json:"escola_id,omitempty"`
+	Escola     *string    `json:"escola,omitempty"`
+}
+
+type Nota struct {
+	Disciplina string  `json:"disciplina"`
+	Bimestre   int     `json:"bimestre"`
+	Nota       float64 `json:"nota"`
+	Observacao *string `json:"observacao,omitempty"`
+	Turma      string  `json:"turma"`
+}
+
+type FrequenciaDisciplina struct {
+	Disciplina   string  `json:"disciplina"`
+	Aulas        int     `json:"aulas"`
+	Presentes    int     `json:"presentes"`
+	Faltas       int     `json:"faltas"`
+	Justificadas int     `json:"justificadas"`
+	Frequencia   float64 `json:"frequencia"`
+}
+
+type Falta struct {
+	Data       time.Time `json:"data"`
+	Disciplina string    `json:"disciplina"`
+	Status     string    `json:"status"`
+}
+
+type Material struct {
+	ID        uuid.UUID `json:"id"`
+	Titulo    string    `json:"titulo"`
+	Descricao *string   `json:"descricao,omitempty"`
+	URL       *string   `json:"url,omitempty"`
+	Turma     string    `json:"turma"`
+	Professor string    `json:"professor"`
+	CriadoEm  time.Time `json:"criado_em"`
+}
+
+type Comunicado struct {
+	ID          uuid.UUID `json:"id"`
+	Titulo      string    `json:"titulo"`
+	Corpo       string    `json:"corpo"`
+	Escola      string    `json:"escola"`
+	Turma       *string   `json:"turma,omitempty"`
+	PublicadoEm time.Time `json:"publicado_em"`
+}
+
+// turmasDoAluno restringe a coluna às turmas em que o aluno tem matrícula ativa no ano letivo.
+const turmasDoAluno = `SELECT m.turma_id FROM matriculas m WHERE m.aluno_id = $1 AND m.ativo = TRUE AND m.ano_letivo = $2`
+
+// ListAlunos lista os alunos vinculados ao responsável com a matrícula ativa mais recente.
+func (r *Repository) ListAlunos(ctx context.Context, responsavelID uuid.UUID) ([]Aluno, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.id, a.nome, a.matricula, ra.parentesco, mt.turma_id, mt.turma, mt.turno, mt.escola_id, mt.escola
+        FROM responsaveis_alunos ra
+        JOIN responsaveis rs ON rs.id = ra.responsavel_id
+        JOIN alunos a ON a.id = ra.aluno_id
+        LEFT JOIN LATERAL (
+            SELECT t.id AS turma_id, t.nome AS turma, t.turno, e.id AS escola_id, e.nome AS escola
+            FROM matriculas m
+            JOIN turmas t ON t.id = m.turma_id
+            JOIN escolas e ON e.id = t.escola_id
+            WHERE m.aluno_id = a.id AND m.ativo = TRUE AND e.tenant_id = rs.tenant_id
+            ORDER BY m.ano_letivo DESC
+            LIMIT 1
+        ) mt ON TRUE
+        WHERE ra.responsavel_id = $1
+        ORDER BY a.nome
+    `, responsavelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Aluno, 0)
+	for rows.Next() {
+		var a Aluno
+		if err := rows.Scan(&a.ID, &a.Nome, &a.Matricula, &a.Parentesco, &a.TurmaID, &a.Turma, &a.Turno, &a.EscolaID, &a.Escola); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// EnsureVinculo confirma que o aluno está vinculado ao responsável e estuda na prefeitura dele.
+func (r *Repository) EnsureVinculo(ctx context.Context, responsavelID, alunoID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var exists bool
+	if err := r.conn(ctx).QueryRow(ctx, `
+        SELECT EXISTS(
+            SELECT 1
+            FROM responsaveis_alunos ra
+            JOIN responsaveis rs ON rs.id = ra.responsavel_id AND rs.ativo = TRUE
+            JOIN matriculas m ON m.aluno_id = ra.aluno_id
+            JOIN turmas t ON t.id = m.turma_id
+            JOIN escolas e ON e.id = t.escola_id AND e.tenant_id = rs.tenant_id
+            WHERE ra.responsavel_id = $1 AND ra.aluno_id = $2
+        )
+    `, responsavelID, alunoID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AnoLetivo devolve o ano letivo vigente na prefeitura do responsável, ou o ano de referência.
+func (r *Repository) AnoLetivo(ctx context.Context, responsavelID uuid.UUID, ref time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var ano int
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT al.ano
+        FROM anos_letivos al
+        JOIN responsaveis rs ON rs.tenant_id = al.tenant_id
+        WHERE rs.id = $1 AND ($2::date BETWEEN al.inicio AND al.fim OR al.ativo)
+        ORDER BY ($2::date BETWEEN al.inicio AND al.fim) DESC, al.ativo DESC
+        LIMIT 1
+    `, responsavelID, ref).Scan(&ano)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ref.Year(), nil
+		}
+		return 0, err
+	}
+	return ano, nil
+}
+
+// Notas lista as notas lançadas para o aluno no ano letivo, por disciplina e bimestre.
+func (r *Repository) Notas(ctx context.Context, alunoID uuid.UUID, anoLetivo int) ([]Nota, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT n.disciplina, n.bimestre, n.nota::float8, n.obs, t.nome
+        FROM notas n
+        JOIN matriculas m ON m.id = n.matricula_id
+        JOIN turmas t ON t.id = n.turma_id
+        WHERE m.aluno_id = $1 AND n.ano_letivo = $2
+        ORDER BY n.disciplina, n.bimestre
+    `, alunoID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Nota, 0)
+	for rows.Next() {
+		var n Nota
+		if err := rows.Scan(&n.Disciplina, &n.Bimestre, &n.Nota, &n.Observacao, &n.Turma); err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+// Frequencia resume a presença do aluno por disciplina no período. Atraso conta como presença e
+// a frequência é calculada sobre os registros de chamada, como na visão da escola.
+func (r *Repository) Frequencia(ctx context.Context, alunoID uuid.UUID, anoLetivo int, from, to time.Time) ([]FrequenciaDisciplina, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.disciplina,
+            COUNT(DISTINCT a.id),
+            COUNT(*) FILTER (WHERE p.status IN ('PRESENTE', 'ATRASO')),
+            COUNT(*) FILTER (WHERE p.status = 'FALTA'),
+            COUNT(*) FILTER (WHERE p.status = 'JUSTIFICADA'),
+            COUNT(p.status)
+        FROM matriculas m
+        JOIN aulas a ON a.turma_id = m.turma_id AND a.ano_letivo = $2 AND a.inicio BETWEEN $3 AND $4
+        LEFT JOIN presencas p ON p.aula_id = a.id AND p.aula_inicio = a.inicio AND p.matricula_id = m.id
+                             AND p.aula_inicio BETWEEN $3 AND $4
+        WHERE m.aluno_id = $1 AND m.ano_letivo = $2
+        GROUP BY a.disciplina
+        ORDER BY a.disciplina
+    `, alunoID, anoLetivo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]FrequenciaDisciplina, 0)
+	for rows.Next() {
+		var item FrequenciaDisciplina
+		var registros int
+		if err := rows.Scan(&item.Disciplina, &item.Aulas, &item.Presentes, &item.Faltas, &item.Justificadas, &registros); err != nil {
+			return nil, err
+		}
+		if registros > 0 {
+			item.Frequencia = float64(item.Presentes) / float64(registros)
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// Faltas lista as ausências registradas do aluno no período, das mais recentes às mais antigas.
+func (r *Repository) Faltas(ctx context.Context, alunoID uuid.UUID, anoLetivo int, from, to time.Time) ([]Falta, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT a.inicio, a.disciplina, p.status
+        FROM matriculas m
+        JOIN presencas p ON p.matricula_id = m.id AND p.aula_inicio BETWEEN $3 AND $4
+        JOIN aulas a ON a.id = p.aula_id AND a.inicio = p.aula_inicio
+        WHERE m.aluno_id = $1 AND m.ano_letivo = $2 AND p.status IN ('FALTA', 'JUSTIFICADA')
+        ORDER BY a.inicio DESC
+        LIMIT 200
+    `, alunoID, anoLetivo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Falta, 0)
+	for rows.Next() {
+		var f Falta
+		if err := rows.Scan(&f.Data, &f.Disciplina, &f.Status); err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// Materiais lista os materiais publicados nas turmas do aluno no ano letivo.
+func (r *Repository) Materiais(ctx context.Context, alunoID uuid.UUID, anoLetivo, limit int) ([]Material, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT mt.id, mt.titulo, mt.descricao, mt.url, t.nome, COALESCE(u.nome, ''), mt.criado_em
+        FROM materiais mt
+        JOIN turmas t ON t.id = mt.turma_id
+        LEFT JOIN usuarios u ON u.id = mt.professor_id
+        WHERE mt.turma_id IN (`+turmasDoAluno+`)
+        ORDER BY mt.criado_em DESC
+        LIMIT $3
+    `, alunoID, anoLetivo, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Material, 0)
+	for rows.Next() {
+		var m Material
+		if err := rows.Scan(&m.ID, &m.Titulo, &m.Descricao, &m.URL, &m.Turma, &m.Professor, &m.CriadoEm); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// Comunicados lista os comunicados das escolas do aluno: os gerais e os das turmas dele.
+func (r *Repository) Comunicados(ctx context.Context, alunoID uuid.UUID, anoLetivo, limit int) ([]Comunicado, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT c.id, c.titulo, c.corpo, e.nome, t.nome, c.publicado_em
+        FROM comunicados c
+        JOIN escolas e ON e.id = c.escola_id
+        LEFT JOIN turmas t ON t.id = c.turma_id
+        WHERE (c.turma_id IN (`+turmasDoAluno+`))
+           OR (c.turma_id IS NULL AND c.escola_id IN (
+                SELECT tt.escola_id FROM turmas tt WHERE tt.id IN (`+turmasDoAluno+`)))
+        ORDER BY c.publicado_em DESC
+        LIMIT $3
+    `, alunoID, anoLetivo, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Comunicado, 0)
+	for rows.Next() {
+		var c Comunicado
+		if err := rows.Scan(&c.ID, &c.Titulo, &c.Corpo, &c.Escola, &c.Turma, &c.PublicadoEm); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
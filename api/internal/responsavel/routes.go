@@ -0,0 +1,8 @@
+package responsavel
+
+import "github.com/go-chi/chi/v5"
+
+// Mount registra rotas do portal dos responsáveis.
+func Mount(r chi.Router, handler *Handler) {
+	handler.RegisterRoutes(r)
+}
@@ -0,0 +1,209 @@
+package responsavel
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// mediaMinima é a nota de corte da rede; disciplinas abaixo dela aparecem destacadas no boletim.
+const mediaMinima = 6.0
+
+// limiteLista limita materiais e comunicados devolvidos por consulta.
+const limiteLista = 100
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repository *Repository) *Service {
+	return &Service{repo: repository}
+}
+
+// Boletim consolida notas e frequência do aluno no ano letivo.
+type Boletim struct {
+	AnoLetivo   int            `json:"ano_letivo"`
+	Disciplinas []BoletimLinha `json:"disciplinas"`
+	MediaGeral  *float64       `json:"media_geral,omitempty"`
+	Frequencia  *float64       `json:"frequencia,omitempty"`
+}
+
+// BoletimLinha traz as notas de uma disciplina por bimestre (nil quando não lançada).
+type BoletimLinha struct {
+	Disciplina  string      `json:"disciplina"`
+	Bimestres   [4]*float64 `json:"bimestres"`
+	Media       *float64    `json:"media,omitempty"`
+	Frequencia  *float64    `json:"frequencia,omitempty"`
+	Faltas      int         `json:"faltas"`
+	AbaixoMedia bool        `json:"abaixo_media"`
+}
+
+// FrequenciaAluno reúne o resumo por disciplina e as ausências do período.
+type FrequenciaAluno struct {
+	AnoLetivo   int                    `json:"ano_letivo"`
+	Disciplinas []FrequenciaDisciplina `json:"disciplinas"`
+	Faltas      []Falta                `json:"faltas"`
+}
+
+func (s *Service) ListAlunos(ctx context.Context, responsavelID uuid.UUID) ([]Aluno, error) {
+	return s.repo.ListAlunos(ctx, responsavelID)
+}
+
+func (s *Service) Notas(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) (int, []Nota, error) {
+	ano, err := s.escopo(ctx, responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		return 0, nil, err
+	}
+	notas, err := s.repo.Notas(ctx, alunoID, ano)
+	return ano, notas, err
+}
+
+func (s *Service) Frequencia(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int, from, to time.Time) (FrequenciaAluno, error) {
+	ano, err := s.escopo(ctx, responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		return FrequenciaAluno{}, err
+	}
+	from, to = periodoDoAno(ano, from, to)
+	resumo, err := s.repo.Frequencia(ctx, alunoID, ano, from, to)
+	if err != nil {
+		return FrequenciaAluno{}, err
+	}
+	faltas, err := s.repo.Faltas(ctx, alunoID, ano, from, to)
+	if err != nil {
+		return FrequenciaAluno{}, err
+	}
+	return FrequenciaAluno{AnoLetivo: ano, Disciplinas: resumo, Faltas: faltas}, nil
+}
+
+func (s *Service) Boletim(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) (Boletim, error) {
+	ano, err := s.escopo(ctx, responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		return Boletim{}, err
+	}
+	notas, err := s.repo.Notas(ctx, alunoID, ano)
+	if err != nil {
+		return Boletim{}, err
+	}
+	from, to := periodoDoAno(ano, time.Time{}, time.Time{})
+	frequencia, err := s.repo.Frequencia(ctx, alunoID, ano, from, to)
+	if err != nil {
+		return Boletim{}, err
+	}
+	return montarBoletim(ano, notas, frequencia), nil
+}
+
+func (s *Service) Materiais(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) ([]Material, error) {
+	ano, err := s.escopo(ctx, responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Materiais(ctx, alunoID, ano, limiteLista)
+}
+
+func (s *Service) Comunicados(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) ([]Comunicado, error) {
+	ano, err := s.escopo(ctx, responsavelID, alunoID, anoLetivo)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Comunicados(ctx, alunoID, ano, limiteLista)
+}
+
+// escopo confere o vínculo com o aluno e resolve o ano letivo quando não informado.
+func (s *Service) escopo(ctx context.Context, responsavelID, alunoID uuid.UUID, anoLetivo int) (int, error) {
+	if err := s.repo.EnsureVinculo(ctx, responsavelID, alunoID); err != nil {
+		return 0, err
+	}
+	if anoLetivo > 0 {
+		return anoLetivo, nil
+	}
+	return s.repo.AnoLetivo(ctx, responsavelID, util.Now())
+}
+
+// periodoDoAno completa o intervalo ausente com o ano civil do ano letivo.
+func periodoDoAno(ano int, from, to time.Time) (time.Time, time.Time) {
+	if from.IsZero() {
+		from = time.Date(ano, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if to.IsZero() {
+		to = time.Date(ano+1, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	}
+	return from, to
+}
+
+// montarBoletim agrupa as notas por disciplina. A média da disciplina considera só os bimestres
+// lançados; a média geral é a média das disciplinas e a frequência geral pondera pelas aulas.
+func montarBoletim(ano int, notas []Nota, frequencia []FrequenciaDisciplina) Boletim {
+	linhas := map[string]*BoletimLinha{}
+	linha := func(disciplina string) *BoletimLinha {
+		if l, ok := linhas[disciplina]; ok {
+			return l
+		}
+		l := &BoletimLinha{Disciplina: disciplina}
+		linhas[disciplina] = l
+		return l
+	}
+
+	for _, n := range notas {
+		if n.Bimestre < 1 || n.Bimestre > 4 {
+			continue
+		}
+		nota := n.Nota
+		linha(n.Disciplina).Bimestres[n.Bimestre-1] = &nota
+	}
+
+	var presentes, aulas float64
+	for _, f := range frequencia {
+		l := linha(f.Disciplina)
+		l.Faltas = f.Faltas
+		if registros := f.Presentes + f.Faltas + f.Justificadas; registros > 0 {
+			freq := f.Frequencia
+			l.Frequencia = &freq
+			presentes += float64(f.Presentes)
+			aulas += float64(registros)
+		}
+	}
+
+	boletim := Boletim{AnoLetivo: ano, Disciplinas: make([]BoletimLinha, 0, len(linhas))}
+	var soma float64
+	var comMedia int
+	for _, l := range linhas {
+		var total float64
+		var lancadas int
+		for _, nota := range l.Bimestres {
+			if nota != nil {
+				total += *nota
+				lancadas++
+			}
+		}
+		if lancadas > 0 {
+			media := arredondar(total / float64(lancadas))
+			l.Media = &media
+			l.AbaixoMedia = media < mediaMinima
+			soma += media
+			comMedia++
+		}
+		boletim.Disciplinas = append(boletim.Disciplinas, *l)
+	}
+	sort.Slice(boletim.Disciplinas, func(i, j int) bool {
+		return boletim.Disciplinas[i].Disciplina < boletim.Disciplinas[j].Disciplina
+	})
+
+	if comMedia > 0 {
+		media := arredondar(soma / float64(comMedia))
+		boletim.MediaGeral = &media
+	}
+	if aulas > 0 {
+		freq := presentes / aulas
+		boletim.Frequencia = &freq
+	}
+	return boletim
+}
+
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,36 @@
+package responsavel
+
+import "testing"
+
+func TestMontarBoletim(t *testing.T) {
+	notas := []Nota{
+		{Disciplina: "Português", Bimestre: 1, Nota: 7},
+		{Disciplina: "Português", Bimestre: 2, Nota: 8},
+		{Disciplina: "Matemática", Bimestre: 1, Nota: 5},
+	}
+	frequencia := []FrequenciaDisciplina{
+		{Disciplina: "Matemática", Aulas: 10, Presentes: 8, Faltas: 2, Frequencia: 0.8},
+		{Disciplina: "Ciências", Aulas: 10, Presentes: 10, Frequencia: 1},
+	}
+
+	b := montarBoletim(2026, notas, frequencia)
+	if len(b.Disciplinas) != 3 || b.Disciplinas[0].Disciplina != "Ciências" {
+		t.Fatalf("disciplinas = %+v", b.Disciplinas)
+	}
+	mat, port := b.Disciplinas[1], b.Disciplinas[2]
+	if mat.Media == nil || *mat.Media != 5 || !mat.AbaixoMedia || mat.Faltas != 2 {
+		t.Fatalf("matemática = %+v", mat)
+	}
+	if port.Media == nil || *port.Media != 7.5 || port.AbaixoMedia || port.Bimestres[2] != nil {
+		t.Fatalf("português = %+v", port)
+	}
+	if b.Disciplinas[0].Media != nil {
+		t.Fatalf("ciências sem notas não deveria ter média")
+	}
+	if b.MediaGeral == nil || *b.MediaGeral != 6.25 {
+		t.Fatalf("media geral = %v", b.MediaGeral)
+	}
+	if b.Frequencia == nil || *b.Frequencia != 0.9 {
+		t.Fatalf("frequência = %v", b.Frequencia)
+	}
+}
@@ -0,0 +1,342 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/config"
+	"github.com/gestaozabele/municipio/internal/partitions"
+)
+
+const (
+	ClassAccessLogs           = "access_logs"
+	ClassRevokedRefreshTokens = "revoked_refresh_tokens"
+	ClassExpiredRefreshTokens = "expired_refresh_tokens"
+	ClassMonitorReadings      = "monitor_readings"
+	ClassMonitorAlerts        = "monitor_alerts"
+	ClassWebauthnSessions     = "webauthn_sessions"
+
+	deleteBatch = 5000
+)
+
+// Policy descreve a regra de retenção de uma classe de dado, como publicada no relatório.
+type Policy struct {
+	Class       string `json:"class"`
+	Description string `json:"description"`
+	Retention   string `json:"retention"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Purge é o registro de uma execução de expurgo.
+type Purge struct {
+	ID         string          `json:"id"`
+	Class      string          `json:"class"`
+	Cutoff     *time.Time      `json:"cutoff,omitempty"`
+	Removed    int64           `json:"removed"`
+	Detail     json.RawMessage `json:"detail"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Error      *string         `json:"error,omitempty"`
+}
+
+// Total soma o volume expurgado de uma classe no período consultado.
+type Total struct {
+	Class   string     `json:"class"`
+	Runs    int        `json:"runs"`
+	Removed int64      `json:"removed"`
+	Failed  int        `json:"failed"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+}
+
+type result struct {
+	cutoff  *time.Time
+	removed int64
+	detail  map[string]any
+}
+
+type class struct {
+	Policy
+	purge func(ctx context.Context, now time.Time) (result, error)
+}
+
+// Service aplica as políticas de retenção e registra o volume removido em retention_purges.
+type Service struct {
+	pool    *pgxpool.Pool
+	classes []class
+	logger  zerolog.Logger
+	now     func() time.Time
+}
+
+// New monta as classes a partir da configuração. parts e redisClient são opcionais; sem eles
+// os logs de acesso são expurgados só por DELETE e as sessões WebAuthn ficam de fora.
+func New(pool *pgxpool.Pool, redisClient *redis.Client, parts *partitions.Manager, cfg config.RetentionConfig, logger zerolog.Logger) *Service {
+	s := &Service{pool: pool, logger: logger, now: time.Now}
+
+	s.classes = append(s.classes, class{
+		Policy: Policy{
+			Class:       ClassAccessLogs,
+			Description: "Logs de acesso ao backoffice SaaS (usuário, IP, agente)",
+			Retention:   fmt.Sprintf("%d meses", cfg.AccessLogMonths),
+			Enabled:     cfg.AccessLogMonths > 0,
+		},
+		purge: func(ctx context.Context, now time.Time) (result, error) {
+			cutoff := now.AddDate(0, -cfg.AccessLogMonths, 0)
+			res := result{cutoff: &cutoff, detail: map[string]any{}}
+			if parts != nil {
+				dropped, err := parts.DropBefore(ctx, "saas_access_logs", cutoff)
+				names := make([]string, 0, len(dropped))
+				for _, d := range dropped {
+					res.removed += d.Rows
+					names = append(names, d.Name)
+				}
+				res.detail["dropped_partitions"] = names
+				if err != nil {
+					return res, err
+				}
+			}
+			tag, err := s.pool.Exec(ctx, `DELETE FROM saas_access_logs WHERE logged_at < $1`, cutoff)
+			if err != nil {
+				return res, err
+			}
+			res.removed += tag.RowsAffected()
+			return res, nil
+		},
+	})
+
+	s.classes = append(s.classes, class{
+		Policy: Policy{
+			Class:       ClassRevokedRefreshTokens,
+			Description: "Refresh tokens revogados (cidadão e backoffice)",
+			Retention:   fmt.Sprintf("%d dias após emissão", cfg.RevokedRefreshTokenDays),
+			Enabled:     cfg.RevokedRefreshTokenDays > 0,
+		},
+		purge: s.batchPurge(cfg.RevokedRefreshTokenDays, `
+			DELETE FROM tokens_refresh
+			WHERE id IN (SELECT id FROM tokens_refresh WHERE revogado AND criado_em < $1 LIMIT $2)
+		`),
+	})
+
+	s.classes = append(s.classes, class{
+		Policy: Policy{
+			Class:       ClassExpiredRefreshTokens,
+			Description: "Refresh tokens expirados e não renovados",
+			Retention:   fmt.Sprintf("%d dias após expirar", cfg.ExpiredRefreshTokenDays),
+			Enabled:     cfg.ExpiredRefreshTokenDays > 0,
+		},
+		purge: s.batchPurge(cfg.ExpiredRefreshTokenDays, `
+			DELETE FROM tokens_refresh
+			WHERE id IN (SELECT id FROM tokens_refresh WHERE expiracao < $1 LIMIT $2)
+		`),
+	})
+
+	s.classes = append(s.classes, class{
+		Policy: Policy{
+			Class:       ClassMonitorReadings,
+			Description: "Leituras de disponibilidade e latência dos tenants",
+			Retention:   fmt.Sprintf("%d dias, exceto tenants sob retenção legal", cfg.MonitorReadingDays),
+			Enabled:     cfg.MonitorReadingDays > 0,
+		},
+		purge: s.batchPurge(cfg.MonitorReadingDays, `
+			DELETE FROM monitor_check_events
+			WHERE id IN (
+				SELECT e.id FROM monitor_check_events e
+				WHERE e.occurred_at < $1
+				  AND NOT EXISTS (SELECT 1 FROM tenant_legal_holds h WHERE h.tenant_id = e.tenant_id AND h.status IN ('pending','active','releasing'))
+				LIMIT $2
+			)
+		`),
+	})
+
+	s.classes = append(s.classes, class{
+		Policy: Policy{
+			Class:       ClassMonitorAlerts,
+			Description: "Alertas operacionais disparados pelo monitoramento",
+			Retention:   fmt.Sprintf("%d dias, exceto tenants sob retenção legal", cfg.MonitorAlertDays),
+			Enabled:     cfg.MonitorAlertDays > 0,
+		},
+		purge: s.batchPurge(cfg.MonitorAlertDays, `
+			DELETE FROM monitor_alerts
+			WHERE id IN (
+				SELECT e.id FROM monitor_alerts e
+				WHERE e.triggered_at < $1
+				  AND NOT EXISTS (SELECT 1 FROM tenant_legal_holds h WHERE h.tenant_id = e.tenant_id AND h.status IN ('pending','active','releasing'))
+				LIMIT $2
+			)
+		`),
+	})
+
+	s.classes = append(s.classes, class{
+		Policy: Policy{
+			Class:       ClassWebauthnSessions,
+			Description: "Sessões de cerimônia WebAuthn (passkeys) no Redis",
+			Retention:   "expiram pelo TTL do Redis; chaves sem TTL são removidas",
+			Enabled:     redisClient != nil,
+		},
+		purge: func(ctx context.Context, _ time.Time) (result, error) {
+			return purgeWebauthnSessions(ctx, redisClient)
+		},
+	})
+
+	return s
+}
+
+// Policies lista as políticas vigentes, inclusive as desligadas.
+func (s *Service) Policies() []Policy {
+	policies := make([]Policy, 0, len(s.classes))
+	for _, c := range s.classes {
+		policies = append(policies, c.Policy)
+	}
+	return policies
+}
+
+// RunOnce aplica todas as políticas ativas. Uma classe com erro não impede as demais;
+// o erro fica registrado no expurgo e é devolvido agregado.
+func (s *Service) RunOnce(ctx context.Context) error {
+	var errs []error
+	for _, c := range s.classes {
+		if !c.Enabled {
+			continue
+		}
+		started := s.now()
+		res, err := c.purge(ctx, started)
+		if recErr := s.record(ctx, c.Class, started, res, err); recErr != nil {
+			errs = append(errs, fmt.Errorf("retenção: registrar %s: %w", c.Class, recErr))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retenção: %s: %w", c.Class, err))
+			continue
+		}
+		if res.removed > 0 {
+			s.logger.Info().Str("class", c.Class).Int64("removed", res.removed).Msg("retenção: expurgo concluído")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// History devolve os expurgos mais recentes, opcionalmente filtrados por classe.
+func (s *Service) History(ctx context.Context, class string, from, to *time.Time, limit int) ([]Purge, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id::text, data_class, cutoff, removed, detail, started_at, finished_at, error
+		FROM retention_purges
+		WHERE ($1 = '' OR data_class = $1)
+		  AND ($2::timestamptz IS NULL OR started_at >= $2)
+		  AND ($3::timestamptz IS NULL OR started_at < $3)
+		ORDER BY started_at DESC
+		LIMIT $4
+	`, class, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purges := make([]Purge, 0)
+	for rows.Next() {
+		var p Purge
+		if err := rows.Scan(&p.ID, &p.Class, &p.Cutoff, &p.Removed, &p.Detail, &p.StartedAt, &p.FinishedAt, &p.Error); err != nil {
+			return nil, err
+		}
+		purges = append(purges, p)
+	}
+	return purges, rows.Err()
+}
+
+// Totals agrega por classe o volume expurgado no período.
+func (s *Service) Totals(ctx context.Context, from, to *time.Time) ([]Total, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT data_class, COUNT(*), COALESCE(SUM(removed), 0)::bigint, COUNT(*) FILTER (WHERE error IS NOT NULL), MAX(started_at)
+		FROM retention_purges
+		WHERE ($1::timestamptz IS NULL OR started_at >= $1)
+		  AND ($2::timestamptz IS NULL OR started_at < $2)
+		GROUP BY data_class
+		ORDER BY data_class
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]Total, 0)
+	for rows.Next() {
+		var t Total
+		if err := rows.Scan(&t.Class, &t.Runs, &t.Removed, &t.Failed, &t.LastRun); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (s *Service) batchPurge(days int, query string) func(ctx context.Context, now time.Time) (result, error) {
+	return func(ctx context.Context, now time.Time) (result, error) {
+		cutoff := now.AddDate(0, 0, -days)
+		res := result{cutoff: &cutoff}
+		// Lotes curtos evitam locks longos e WAL concentrado em tabelas grandes.
+		for {
+			tag, err := s.pool.Exec(ctx, query, cutoff, deleteBatch)
+			if err != nil {
+				return res, err
+			}
+			res.removed += tag.RowsAffected()
+			if tag.RowsAffected() < deleteBatch {
+				return res, nil
+			}
+		}
+	}
+}
+
+func (s *Service) record(ctx context.Context, class string, started time.Time, res result, purgeErr error) error {
+	detail := res.detail
+	if detail == nil {
+		detail = map[string]any{}
+	}
+	var errMsg *string
+	if purgeErr != nil {
+		msg := purgeErr.Error()
+		errMsg = &msg
+	}
+	// O registro precisa sobreviver a um ctx cancelado no meio do expurgo.
+	recCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	_, err := s.pool.Exec(recCtx, `
+		INSERT INTO retention_purges (data_class, cutoff, removed, detail, started_at, finished_at, error)
+		VALUES ($1, $2, $3, $4, $5, now(), $6)
+	`, class, res.cutoff, res.removed, detail, started, errMsg)
+	return err
+}
+
+func purgeWebauthnSessions(ctx context.Context, client *redis.Client) (result, error) {
+	res := result{detail: map[string]any{}}
+	var scanned int64
+	for _, pattern := range []string{"webauthn:register:*", "webauthn:login:*"} {
+		iter := client.Scan(ctx, 0, pattern, 500).Iterator()
+		for iter.Next(ctx) {
+			scanned++
+			key := iter.Val()
+			ttl, err := client.TTL(ctx, key).Result()
+			if err != nil {
+				return res, err
+			}
+			// -1 indica chave sem expiração; sessões de cerimônia nunca deveriam ficar assim.
+			if ttl != -1 {
+				continue
+			}
+			removed, err := client.Del(ctx, key).Result()
+			if err != nil {
+				return res, err
+			}
+			res.removed += removed
+		}
+		if err := iter.Err(); err != nil {
+			return res, err
+		}
+	}
+	res.detail["scanned"] = scanned
+	return res, nil
+}
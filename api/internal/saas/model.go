@@ -20,6 +20,8 @@ const (
 	RoleAdmin   = "admin"
 	RoleSupport = "support"
 	RoleFinance = "finance"
+	// RoleAuditor lê todas as áreas do SaaS sem poder alterar nada.
+	RoleAuditor = "auditor"
 )
 
 var validRoles = map[string]struct{}{
@@ -27,6 +29,7 @@ var validRoles = map[string]struct{}{
 	RoleAdmin:   {},
 	RoleSupport: {},
 	RoleFinance: {},
+	RoleAuditor: {},
 }
 
 // User representa um administrador do SaaS.
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
 )
 
 // Repository fornece acesso aos dados dos administradores SaaS.
@@ -83,17 +85,22 @@ func (r *Repository) Create(ctx context.Context, input CreateUserInput) (*User,
     `
 
 	id := uuid.New()
-	row := r.pool.QueryRow(ctx, query,
-		id,
-		strings.TrimSpace(input.Name),
-		strings.ToLower(strings.TrimSpace(input.Email)),
-		input.PasswordHash,
-		strings.TrimSpace(strings.ToLower(input.Role)),
-		input.Active,
-		input.CreatedBy,
-	)
-
-	return scanUser(row)
+	var user *User
+	err := db.WithActorTx(ctx, r.pool, input.CreatedBy, func(ctx context.Context, tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, query,
+			id,
+			strings.TrimSpace(input.Name),
+			strings.ToLower(strings.TrimSpace(input.Email)),
+			input.PasswordHash,
+			strings.TrimSpace(strings.ToLower(input.Role)),
+			input.Active,
+			input.CreatedBy,
+		)
+		var err error
+		user, err = scanUser(row)
+		return err
+	})
+	return user, err
 }
 
 // Update altera dados principais do usuário.
@@ -108,14 +115,19 @@ func (r *Repository) Update(ctx context.Context, input UpdateUserInput) (*User,
         RETURNING id, name, email, password_hash, role, active, last_login_at, invited_at, created_at, updated_at, created_by
     `
 
-	row := r.pool.QueryRow(ctx, query,
-		input.ID,
-		strings.TrimSpace(input.Name),
-		strings.TrimSpace(strings.ToLower(input.Role)),
-		input.Active,
-	)
-
-	return scanUser(row)
+	var user *User
+	err := db.WithActorTx(ctx, r.pool, input.UpdatedBy, func(ctx context.Context, tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, query,
+			input.ID,
+			strings.TrimSpace(input.Name),
+			strings.TrimSpace(strings.ToLower(input.Role)),
+			input.Active,
+		)
+		var err error
+		user, err = scanUser(row)
+		return err
+	})
+	return user, err
 }
 
 // UpdatePassword atualiza hash da senha.
@@ -147,17 +159,19 @@ func (r *Repository) RecordLogin(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// Delete remove definitivamente um usuário.
-func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+// Delete remove definitivamente um usuário; actorID identifica quem removeu no log de privilégios.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID, actorID *uuid.UUID) error {
 	const query = `DELETE FROM saas_users WHERE id = $1`
-	tag, err := r.pool.Exec(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	if tag.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return db.WithActorTx(ctx, r.pool, actorID, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
 }
 
 // CreateInvite registra um convite.
@@ -0,0 +1,413 @@
+package saude
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const campanhaColumns = `c.id, c.tenant_id, c.secretaria_id, c.nome, c.vacina, c.descricao, c.inicio, c.fim, c.doses_esquema,
+        c.idade_min_meses, c.idade_max_meses, c.grupos, c.meta_populacao, c.meta_cobertura::float8, c.status,
+        (SELECT count(*) FROM saude_doses d WHERE d.campanha_id = c.id)::int, c.created_at, c.updated_at`
+
+const doseColumns = `id, campanha_id, cidadao_id, paciente_nome, data_nascimento, grupo, bairro, dose, lote, unidade,
+        aplicada_em, agente_id, created_at`
+
+// pessoaChave identifica a pessoa vacinada: o cadastro do cidadão ou, sem ele, nome e nascimento.
+const pessoaChave = `COALESCE(cidadao_id::text, lower(paciente_nome) || '|' || COALESCE(data_nascimento::text, ''))`
+
+// semInformacao rotula bairro ou grupo não informado no painel de cobertura.
+const semInformacao = "não informado"
+
+// Repository provê acesso às campanhas de saúde e às doses aplicadas.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// ListCampanhas lista as campanhas da prefeitura, mais recentes primeiro; status vazio traz todas.
+func (r *Repository) ListCampanhas(ctx context.Context, tenantID uuid.UUID, status string) ([]Campanha, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+campanhaColumns+`
+        FROM saude_campanhas c
+        WHERE c.tenant_id = $1 AND ($2 = '' OR c.status = $2)
+        ORDER BY c.inicio DESC, c.nome
+    `, tenantID, status)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Campanha, error) {
+		c, err := scanCampanha(row)
+		if err != nil {
+			return Campanha{}, err
+		}
+		return *c, nil
+	})
+}
+
+// GetCampanha busca a campanha do tenant.
+func (r *Repository) GetCampanha(ctx context.Context, tenantID, id uuid.UUID) (*Campanha, error) {
+	return scanCampanha(r.conn(ctx).QueryRow(ctx, `SELECT `+campanhaColumns+` FROM saude_campanhas c WHERE c.tenant_id = $1 AND c.id = $2`, tenantID, id))
+}
+
+// CreateCampanha cadastra a campanha como planejada; a entrada já deve estar normalizada.
+func (r *Repository) CreateCampanha(ctx context.Context, tenantID uuid.UUID, in CampanhaInput) (*Campanha, error) {
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO saude_campanhas (tenant_id, secretaria_id, nome, vacina, descricao, inicio, fim, doses_esquema,
+                                     idade_min_meses, idade_max_meses, grupos, meta_populacao, meta_cobertura, created_by)
+        SELECT $1, s.id, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+        FROM secretarias s WHERE s.id = $2 AND s.tenant_id = $1
+        RETURNING id
+    `, tenantID, in.SecretariaID, in.Nome, in.Vacina, in.Descricao, in.Inicio, in.Fim, in.DosesEsquema,
+		in.IdadeMinMeses, in.IdadeMaxMeses, in.Grupos, in.MetaPopulacao, in.MetaCobertura, in.ActorID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSecretaria
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetCampanha(ctx, tenantID, id)
+}
+
+// UpdateCampanha altera a campanha ainda não encerrada; o esquema não pode ficar menor que a maior
+// dose já registrada.
+func (r *Repository) UpdateCampanha(ctx context.Context, tenantID, id uuid.UUID, in CampanhaInput) (*Campanha, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	c, err := scanCampanha(tx.QueryRow(ctx, `SELECT `+campanhaColumns+` FROM saude_campanhas c WHERE c.tenant_id = $1 AND c.id = $2 FOR UPDATE`, tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	if c.Status == StatusEncerrada {
+		return nil, ErrInvalidTransition
+	}
+	var excede bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM saude_doses WHERE campanha_id = $1 AND dose > $2)`, id, in.DosesEsquema).Scan(&excede); err != nil {
+		return nil, err
+	}
+	if excede {
+		return nil, ErrEsquema
+	}
+	if _, err := tx.Exec(ctx, `
+        UPDATE saude_campanhas
+        SET nome = $2, vacina = $3, descricao = $4, inicio = $5, fim = $6, doses_esquema = $7, idade_min_meses = $8,
+            idade_max_meses = $9, grupos = $10, meta_populacao = $11, meta_cobertura = $12, updated_at = now()
+        WHERE id = $1
+    `, id, in.Nome, in.Vacina, in.Descricao, in.Inicio, in.Fim, in.DosesEsquema, in.IdadeMinMeses,
+		in.IdadeMaxMeses, in.Grupos, in.MetaPopulacao, in.MetaCobertura); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetCampanha(ctx, tenantID, id)
+}
+
+// SetStatus ativa ou encerra a campanha.
+func (r *Repository) SetStatus(ctx context.Context, tenantID, id uuid.UUID, status string) (*Campanha, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var atual string
+	err = tx.QueryRow(ctx, `SELECT status FROM saude_campanhas WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, id).Scan(&atual)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(atual, status) {
+		return nil, ErrInvalidTransition
+	}
+	if _, err := tx.Exec(ctx, `UPDATE saude_campanhas SET status = $2, updated_at = now() WHERE id = $1`, id, status); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetCampanha(ctx, tenantID, id)
+}
+
+// RegistrarDose grava a aplicação feita pelo agente de saúde. A campanha precisa estar ativa, o
+// agente vinculado à secretaria dela e o paciente no público-alvo; para cidadãos cadastrados, as
+// doses do esquema seguem em ordem e não se repetem.
+func (r *Repository) RegistrarDose(ctx context.Context, tenantID, campanhaID uuid.UUID, in DoseInput) (*Dose, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	c, err := scanCampanha(tx.QueryRow(ctx, `SELECT `+campanhaColumns+` FROM saude_campanhas c WHERE c.tenant_id = $1 AND c.id = $2 FOR SHARE`, tenantID, campanhaID))
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != StatusAtiva {
+		return nil, ErrInactive
+	}
+	var agente bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM usuarios_secretarias WHERE usuario_id = $1 AND secretaria_id = $2)
+    `, in.AgenteID, c.SecretariaID).Scan(&agente); err != nil {
+		return nil, err
+	}
+	if !agente {
+		return nil, ErrNotAgent
+	}
+	if in.CidadaoID != nil {
+		var nome string
+		err := tx.QueryRow(ctx, `
+            SELECT COALESCE(nome, '') FROM cidadaos WHERE id = $1 AND ativo AND merged_into IS NULL
+        `, *in.CidadaoID).Scan(&nome)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCidadao
+		}
+		if err != nil {
+			return nil, err
+		}
+		if in.PacienteNome == "" {
+			in.PacienteNome = strings.TrimSpace(nome)
+		}
+	}
+	if in.PacienteNome == "" {
+		return nil, &ElegibilidadeError{Motivo: "informe o nome do paciente"}
+	}
+	if err := Elegivel(*c, in); err != nil {
+		return nil, err
+	}
+	if in.CidadaoID != nil && in.Dose > 1 {
+		var anterior bool
+		if err := tx.QueryRow(ctx, `
+            SELECT EXISTS (SELECT 1 FROM saude_doses WHERE campanha_id = $1 AND cidadao_id = $2 AND dose = $3)
+        `, campanhaID, *in.CidadaoID, in.Dose-1).Scan(&anterior); err != nil {
+			return nil, err
+		}
+		if !anterior {
+			return nil, ErrDoseAnterior
+		}
+	}
+	d, err := scanDose(tx.QueryRow(ctx, `
+        INSERT INTO saude_doses (campanha_id, cidadao_id, paciente_nome, data_nascimento, grupo, bairro, dose, lote, unidade, aplicada_em, agente_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING `+doseColumns,
+		campanhaID, in.CidadaoID, in.PacienteNome, in.DataNascimento, in.Grupo, in.Bairro, in.Dose, in.Lote, in.Unidade, in.AplicadaEm, in.AgenteID))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDoses lista as doses da campanha, mais recentes primeiro, opcionalmente filtrando pelo nome.
+func (r *Repository) ListDoses(ctx context.Context, tenantID, campanhaID uuid.UUID, query string, limit int) ([]Dose, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+doseColumns+`
+        FROM saude_doses
+        WHERE campanha_id = (SELECT id FROM saude_campanhas WHERE tenant_id = $1 AND id = $2)
+          AND ($3 = '' OR paciente_nome ILIKE '%' || $3 || '%')
+        ORDER BY aplicada_em DESC, created_at DESC
+        LIMIT $4
+    `, tenantID, campanhaID, strings.TrimSpace(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Dose, error) {
+		d, err := scanDose(row)
+		if err != nil {
+			return Dose{}, err
+		}
+		return *d, nil
+	})
+}
+
+// Cobertura monta o painel da campanha: doses por número e por dia, pessoas alcançadas e esquemas
+// completos no total, por bairro e por grupo prioritário. Bairro e grupo de cada pessoa são os da
+// aplicação mais recente.
+func (r *Repository) Cobertura(ctx context.Context, tenantID, campanhaID uuid.UUID) (*Cobertura, error) {
+	c, err := r.GetCampanha(ctx, tenantID, campanhaID)
+	if err != nil {
+		return nil, err
+	}
+	cob := Cobertura{Campanha: *c, PorDose: []ContagemDose{}, PorBairro: []ContagemGrupo{}, PorGrupo: []ContagemGrupo{}, PorDia: []ContagemDia{}}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+        WITH pessoas AS (
+            SELECT `+pessoaChave+` AS chave, count(*) AS doses, max(dose) AS ultima,
+                   (array_agg(bairro ORDER BY aplicada_em DESC, created_at DESC))[1] AS bairro,
+                   (array_agg(grupo ORDER BY aplicada_em DESC, created_at DESC))[1] AS grupo
+            FROM saude_doses WHERE campanha_id = $1
+            GROUP BY 1
+        )
+        SELECT COALESCE(bairro, ''), COALESCE(grupo, ''), sum(doses)::int, count(*)::int, count(*) FILTER (WHERE ultima >= $2)::int
+        FROM pessoas
+        GROUP BY 1, 2
+    `, campanhaID, c.DosesEsquema)
+	if err != nil {
+		return nil, err
+	}
+	bairros := map[string]*ContagemGrupo{}
+	grupos := map[string]*ContagemGrupo{}
+	for rows.Next() {
+		var (
+			bairro, grupo             string
+			doses, pessoas, completos int
+		)
+		if err := rows.Scan(&bairro, &grupo, &doses, &pessoas, &completos); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		cob.DosesAplicadas += doses
+		cob.Pessoas += pessoas
+		cob.EsquemaCompleto += completos
+		acumular(bairros, bairro, doses, completos)
+		acumular(grupos, grupo, doses, completos)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	cob.PorBairro = ordenar(bairros)
+	cob.PorGrupo = ordenar(grupos)
+	cob.Percentual = Percentual(cob.EsquemaCompleto, c.MetaPopulacao)
+	cob.MetaAtingida = cob.Percentual != nil && *cob.Percentual >= c.MetaCobertura
+
+	rows, err = r.conn(ctx).Query(ctx, `SELECT dose, count(*)::int FROM saude_doses WHERE campanha_id = $1 GROUP BY dose ORDER BY dose`, campanhaID)
+	if err != nil {
+		return nil, err
+	}
+	porDose, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ContagemDose, error) {
+		var d ContagemDose
+		err := row.Scan(&d.Dose, &d.Total)
+		return d, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cob.PorDose = append(cob.PorDose, porDose...)
+
+	rows, err = r.conn(ctx).Query(ctx, `
+        SELECT to_char(aplicada_em, 'YYYY-MM-DD'), count(*)::int FROM saude_doses WHERE campanha_id = $1 GROUP BY 1 ORDER BY 1
+    `, campanhaID)
+	if err != nil {
+		return nil, err
+	}
+	porDia, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ContagemDia, error) {
+		var d ContagemDia
+		err := row.Scan(&d.Data, &d.Total)
+		return d, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cob.PorDia = append(cob.PorDia, porDia...)
+	return &cob, nil
+}
+
+// Carteira lista as doses do cidadão nas campanhas da prefeitura, mais recentes primeiro.
+func (r *Repository) Carteira(ctx context.Context, tenantID, cidadaoID uuid.UUID) ([]RegistroVacina, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT c.id, c.nome, c.vacina, d.dose, c.doses_esquema, d.lote, d.unidade, d.aplicada_em
+        FROM saude_doses d
+        JOIN saude_campanhas c ON c.id = d.campanha_id
+        WHERE c.tenant_id = $1 AND d.cidadao_id = $2
+        ORDER BY d.aplicada_em DESC, c.vacina, d.dose DESC
+    `, tenantID, cidadaoID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (RegistroVacina, error) {
+		var v RegistroVacina
+		err := row.Scan(&v.CampanhaID, &v.Campanha, &v.Vacina, &v.Dose, &v.DosesEsquema, &v.Lote, &v.Unidade, &v.AplicadaEm)
+		return v, err
+	})
+}
+
+func acumular(m map[string]*ContagemGrupo, nome string, doses, completos int) {
+	if nome == "" {
+		nome = semInformacao
+	}
+	c, ok := m[nome]
+	if !ok {
+		c = &ContagemGrupo{Nome: nome}
+		m[nome] = c
+	}
+	c.Doses += doses
+	c.EsquemaCompleto += completos
+}
+
+// ordenar devolve as contagens da maior para a menor, com o não informado por último.
+func ordenar(m map[string]*ContagemGrupo) []ContagemGrupo {
+	out := make([]ContagemGrupo, 0, len(m))
+	for _, c := range m {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Nome == semInformacao) != (out[j].Nome == semInformacao) {
+			return out[j].Nome == semInformacao
+		}
+		if out[i].Doses != out[j].Doses {
+			return out[i].Doses > out[j].Doses
+		}
+		return out[i].Nome < out[j].Nome
+	})
+	return out
+}
+
+func scanCampanha(row pgx.Row) (*Campanha, error) {
+	var c Campanha
+	if err := row.Scan(&c.ID, &c.TenantID, &c.SecretariaID, &c.Nome, &c.Vacina, &c.Descricao, &c.Inicio, &c.Fim, &c.DosesEsquema,
+		&c.IdadeMinMeses, &c.IdadeMaxMeses, &c.Grupos, &c.MetaPopulacao, &c.MetaCobertura, &c.Status,
+		&c.DosesAplicadas, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if c.Grupos == nil {
+		c.Grupos = []string{}
+	}
+	return &c, nil
+}
+
+func scanDose(row pgx.Row) (*Dose, error) {
+	var d Dose
+	if err := row.Scan(&d.ID, &d.CampanhaID, &d.CidadaoID, &d.PacienteNome, &d.DataNascimento, &d.Grupo, &d.Bairro, &d.Dose,
+		&d.Lote, &d.Unidade, &d.AplicadaEm, &d.AgenteID, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,323 @@
+// Package saude acompanha campanhas de vacinação e programas de saúde: público-alvo, doses
+// registradas pelos agentes, cobertura e a carteira de vacinação do cidadão.
+package saude
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica campanha inexistente ou de outro tenant.
+	ErrNotFound = errors.New("saude: campanha não encontrada")
+	// ErrSecretaria indica secretaria que não pertence à prefeitura.
+	ErrSecretaria = errors.New("saude: secretaria não pertence à prefeitura")
+	// ErrInvalidTransition indica mudança de situação não permitida.
+	ErrInvalidTransition = errors.New("saude: mudança de situação inválida")
+	// ErrInactive indica campanha que não está ativa e não aceita doses.
+	ErrInactive = errors.New("saude: campanha não está ativa")
+	// ErrNotAgent indica usuário sem vínculo com a secretaria da campanha.
+	ErrNotAgent = errors.New("saude: usuário não é agente da secretaria da campanha")
+	// ErrCidadao indica cidadão inexistente, inativo ou unificado a outro cadastro.
+	ErrCidadao = errors.New("saude: cidadão inválido")
+	// ErrDuplicate indica dose já registrada para o cidadão na campanha.
+	ErrDuplicate = errors.New("saude: dose já registrada")
+	// ErrDoseAnterior indica dose registrada antes da anterior do esquema.
+	ErrDoseAnterior = errors.New("saude: dose anterior não registrada")
+	// ErrEsquema indica esquema reduzido abaixo de doses já aplicadas.
+	ErrEsquema = errors.New("saude: esquema menor que as doses já registradas")
+)
+
+// ElegibilidadeError indica dose que não cabe na campanha (esquema, período ou público-alvo).
+type ElegibilidadeError struct {
+	Motivo string
+}
+
+func (e *ElegibilidadeError) Error() string {
+	return "saude: " + e.Motivo
+}
+
+// Situações da campanha.
+const (
+	StatusPlanejada = "planejada"
+	StatusAtiva     = "ativa"
+	StatusEncerrada = "encerrada"
+)
+
+// Campanha é uma campanha de vacinação ou programa de saúde de uma secretaria.
+type Campanha struct {
+	ID             uuid.UUID  `json:"id"`
+	TenantID       uuid.UUID  `json:"tenant_id"`
+	SecretariaID   uuid.UUID  `json:"secretaria_id"`
+	Nome           string     `json:"nome"`
+	Vacina         string     `json:"vacina"`
+	Descricao      *string    `json:"descricao,omitempty"`
+	Inicio         time.Time  `json:"inicio"`
+	Fim            *time.Time `json:"fim,omitempty"`
+	DosesEsquema   int        `json:"doses_esquema"`
+	IdadeMinMeses  *int       `json:"idade_min_meses,omitempty"`
+	IdadeMaxMeses  *int       `json:"idade_max_meses,omitempty"`
+	Grupos         []string   `json:"grupos"`
+	MetaPopulacao  *int       `json:"meta_populacao,omitempty"`
+	MetaCobertura  float64    `json:"meta_cobertura"`
+	Status         string     `json:"status"`
+	DosesAplicadas int        `json:"doses_aplicadas"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CampanhaInput contém os campos editáveis da campanha; SecretariaID só vale no cadastro.
+type CampanhaInput struct {
+	SecretariaID  uuid.UUID
+	Nome          string
+	Vacina        string
+	Descricao     *string
+	Inicio        time.Time
+	Fim           *time.Time
+	DosesEsquema  int
+	IdadeMinMeses *int
+	IdadeMaxMeses *int
+	Grupos        []string
+	MetaPopulacao *int
+	MetaCobertura float64
+	ActorID       *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada; sem esquema vale dose única e sem meta, 90% de cobertura.
+func (in *CampanhaInput) Normalize() error {
+	in.Nome = strings.TrimSpace(in.Nome)
+	in.Vacina = strings.TrimSpace(in.Vacina)
+	in.Descricao = trimmed(in.Descricao)
+	if in.DosesEsquema == 0 {
+		in.DosesEsquema = 1
+	}
+	if in.MetaCobertura == 0 {
+		in.MetaCobertura = 90
+	}
+	grupos := make([]string, 0, len(in.Grupos))
+	for _, g := range in.Grupos {
+		if g = normalizeGrupo(g); g != "" && !slices.Contains(grupos, g) {
+			grupos = append(grupos, g)
+		}
+	}
+	in.Grupos = grupos
+	switch {
+	case in.Nome == "":
+		return errors.New("nome obrigatório")
+	case in.Vacina == "":
+		return errors.New("vacina obrigatória")
+	case in.Inicio.IsZero():
+		return errors.New("início obrigatório")
+	case in.Fim != nil && in.Fim.Before(in.Inicio):
+		return errors.New("fim anterior ao início")
+	case in.DosesEsquema < 1 || in.DosesEsquema > 10:
+		return errors.New("esquema deve ter de 1 a 10 doses")
+	case in.IdadeMinMeses != nil && *in.IdadeMinMeses < 0, in.IdadeMaxMeses != nil && *in.IdadeMaxMeses < 0:
+		return errors.New("faixa etária inválida")
+	case in.IdadeMinMeses != nil && in.IdadeMaxMeses != nil && *in.IdadeMaxMeses < *in.IdadeMinMeses:
+		return errors.New("faixa etária inválida")
+	case in.MetaPopulacao != nil && *in.MetaPopulacao <= 0:
+		return errors.New("população-alvo inválida")
+	case in.MetaCobertura <= 0 || in.MetaCobertura > 100:
+		return errors.New("meta de cobertura deve estar entre 0 e 100")
+	}
+	return nil
+}
+
+// CanTransition informa se a campanha pode passar de from para to. Campanha encerrada não reabre.
+func CanTransition(from, to string) bool {
+	switch from {
+	case StatusPlanejada:
+		return to == StatusAtiva || to == StatusEncerrada
+	case StatusAtiva:
+		return to == StatusEncerrada
+	}
+	return false
+}
+
+// Dose é uma aplicação registrada na campanha.
+type Dose struct {
+	ID             uuid.UUID  `json:"id"`
+	CampanhaID     uuid.UUID  `json:"campanha_id"`
+	CidadaoID      *uuid.UUID `json:"cidadao_id,omitempty"`
+	PacienteNome   string     `json:"paciente_nome"`
+	DataNascimento *time.Time `json:"data_nascimento,omitempty"`
+	Grupo          *string    `json:"grupo,omitempty"`
+	Bairro         *string    `json:"bairro,omitempty"`
+	Dose           int        `json:"dose"`
+	Lote           *string    `json:"lote,omitempty"`
+	Unidade        *string    `json:"unidade,omitempty"`
+	AplicadaEm     time.Time  `json:"aplicada_em"`
+	AgenteID       *uuid.UUID `json:"agente_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// DoseInput descreve a aplicação informada pelo agente de saúde.
+type DoseInput struct {
+	CidadaoID      *uuid.UUID
+	PacienteNome   string
+	DataNascimento *time.Time
+	Grupo          *string
+	Bairro         *string
+	Dose           int
+	Lote           *string
+	Unidade        *string
+	AplicadaEm     time.Time
+	AgenteID       uuid.UUID
+}
+
+// Normalize limpa e valida a entrada; sem data de aplicação, vale o dia de hoje.
+func (in *DoseInput) Normalize() error {
+	in.PacienteNome = strings.TrimSpace(in.PacienteNome)
+	in.Bairro = trimmed(in.Bairro)
+	in.Lote = trimmed(in.Lote)
+	in.Unidade = trimmed(in.Unidade)
+	if in.Grupo != nil {
+		if g := normalizeGrupo(*in.Grupo); g != "" {
+			in.Grupo = &g
+		} else {
+			in.Grupo = nil
+		}
+	}
+	if in.Dose == 0 {
+		in.Dose = 1
+	}
+	if in.AplicadaEm.IsZero() {
+		in.AplicadaEm = time.Now()
+	}
+	switch {
+	case in.PacienteNome == "" && in.CidadaoID == nil:
+		return errors.New("informe o cidadão ou o nome do paciente")
+	case in.Dose < 1:
+		return errors.New("dose inválida")
+	case in.DataNascimento != nil && in.DataNascimento.After(in.AplicadaEm):
+		return errors.New("data de nascimento posterior à aplicação")
+	case in.AplicadaEm.After(time.Now().Add(24 * time.Hour)):
+		return errors.New("data de aplicação no futuro")
+	}
+	return nil
+}
+
+// Elegivel confere a dose com a campanha: número dentro do esquema, data no período e paciente no
+// público-alvo, seja pela faixa etária ou por pertencer a um dos grupos prioritários.
+func Elegivel(c Campanha, in DoseInput) error {
+	if in.Dose > c.DosesEsquema {
+		return &ElegibilidadeError{Motivo: fmt.Sprintf("a campanha tem esquema de %d dose(s)", c.DosesEsquema)}
+	}
+	aplicada := dateOnly(in.AplicadaEm)
+	if aplicada.Before(dateOnly(c.Inicio)) || (c.Fim != nil && aplicada.After(dateOnly(*c.Fim))) {
+		return &ElegibilidadeError{Motivo: "aplicação fora do período da campanha"}
+	}
+	faixa := c.IdadeMinMeses != nil || c.IdadeMaxMeses != nil
+	if !faixa && len(c.Grupos) == 0 {
+		return nil
+	}
+	if in.Grupo != nil && slices.Contains(c.Grupos, *in.Grupo) {
+		return nil
+	}
+	if faixa {
+		if in.DataNascimento == nil {
+			return &ElegibilidadeError{Motivo: "data de nascimento obrigatória nesta campanha"}
+		}
+		idade := IdadeMeses(*in.DataNascimento, in.AplicadaEm)
+		if (c.IdadeMinMeses == nil || idade >= *c.IdadeMinMeses) && (c.IdadeMaxMeses == nil || idade <= *c.IdadeMaxMeses) {
+			return nil
+		}
+	}
+	return &ElegibilidadeError{Motivo: "paciente fora do público-alvo da campanha"}
+}
+
+// IdadeMeses devolve a idade em meses completos na data informada.
+func IdadeMeses(nascimento, em time.Time) int {
+	meses := (em.Year()-nascimento.Year())*12 + int(em.Month()) - int(nascimento.Month())
+	if em.Day() < nascimento.Day() {
+		meses--
+	}
+	if meses < 0 {
+		return 0
+	}
+	return meses
+}
+
+// Cobertura resume a campanha para o painel. Pessoas são contadas pelo cadastro do cidadão ou,
+// sem ele, pelo nome e data de nascimento informados no atendimento.
+type Cobertura struct {
+	Campanha        Campanha        `json:"campanha"`
+	DosesAplicadas  int             `json:"doses_aplicadas"`
+	Pessoas         int             `json:"pessoas"`
+	EsquemaCompleto int             `json:"esquema_completo"`
+	Percentual      *float64        `json:"percentual,omitempty"`
+	MetaAtingida    bool            `json:"meta_atingida"`
+	PorDose         []ContagemDose  `json:"por_dose"`
+	PorBairro       []ContagemGrupo `json:"por_bairro"`
+	PorGrupo        []ContagemGrupo `json:"por_grupo"`
+	PorDia          []ContagemDia   `json:"por_dia"`
+}
+
+// ContagemDose conta as aplicações de cada dose do esquema.
+type ContagemDose struct {
+	Dose  int `json:"dose"`
+	Total int `json:"total"`
+}
+
+// ContagemGrupo conta doses e esquemas completos por bairro ou grupo prioritário.
+type ContagemGrupo struct {
+	Nome            string `json:"nome"`
+	Doses           int    `json:"doses"`
+	EsquemaCompleto int    `json:"esquema_completo"`
+}
+
+// ContagemDia conta as aplicações de um dia.
+type ContagemDia struct {
+	Data  string `json:"data"`
+	Total int    `json:"total"`
+}
+
+// Percentual calcula a cobertura (esquemas completos sobre a população-alvo), limitada a 100%.
+func Percentual(completos int, populacao *int) *float64 {
+	if populacao == nil || *populacao <= 0 {
+		return nil
+	}
+	p := float64(completos) * 100 / float64(*populacao)
+	if p > 100 {
+		p = 100
+	}
+	p = math.Round(p*10) / 10
+	return &p
+}
+
+// RegistroVacina é uma linha da carteira de vacinação exibida ao cidadão.
+type RegistroVacina struct {
+	CampanhaID   uuid.UUID `json:"campanha_id"`
+	Campanha     string    `json:"campanha"`
+	Vacina       string    `json:"vacina"`
+	Dose         int       `json:"dose"`
+	DosesEsquema int       `json:"doses_esquema"`
+	Lote         *string   `json:"lote,omitempty"`
+	Unidade      *string   `json:"unidade,omitempty"`
+	AplicadaEm   time.Time `json:"aplicada_em"`
+}
+
+func normalizeGrupo(g string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(g)), " ", "_")
+}
+
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	if v := strings.TrimSpace(*value); v != "" {
+		return &v
+	}
+	return nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,96 @@
+package saude
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestIdadeMeses(t *testing.T) {
+	cases := []struct {
+		nascimento, em string
+		want           int
+	}{
+		{"2024-03-15", "2024-09-15", 6},
+		{"2024-03-15", "2024-09-14", 5},
+		{"1960-01-01", "2025-01-01", 780},
+		{"2025-05-10", "2025-05-01", 0},
+	}
+	for _, c := range cases {
+		if got := IdadeMeses(date(c.nascimento), date(c.em)); got != c.want {
+			t.Errorf("IdadeMeses(%s, %s) = %d, want %d", c.nascimento, c.em, got, c.want)
+		}
+	}
+}
+
+func TestElegivel(t *testing.T) {
+	fim := date("2025-06-30")
+	c := Campanha{
+		Inicio:        date("2025-04-01"),
+		Fim:           &fim,
+		DosesEsquema:  2,
+		IdadeMinMeses: intPtr(720),
+		Grupos:        []string{"gestantes", "profissionais_saude"},
+	}
+	idoso := date("1950-02-01")
+	jovem := date("2000-02-01")
+	gestante := "gestantes"
+	cases := []struct {
+		name string
+		in   DoseInput
+		ok   bool
+	}{
+		{"idoso", DoseInput{Dose: 1, DataNascimento: &idoso, AplicadaEm: date("2025-04-10")}, true},
+		{"fora da faixa", DoseInput{Dose: 1, DataNascimento: &jovem, AplicadaEm: date("2025-04-10")}, false},
+		{"grupo prioritário", DoseInput{Dose: 2, DataNascimento: &jovem, Grupo: &gestante, AplicadaEm: date("2025-04-10")}, true},
+		{"sem nascimento", DoseInput{Dose: 1, AplicadaEm: date("2025-04-10")}, false},
+		{"dose acima do esquema", DoseInput{Dose: 3, DataNascimento: &idoso, AplicadaEm: date("2025-04-10")}, false},
+		{"depois do fim", DoseInput{Dose: 1, DataNascimento: &idoso, AplicadaEm: date("2025-07-01")}, false},
+	}
+	for _, tc := range cases {
+		err := Elegivel(c, tc.in)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+		var eleg *ElegibilidadeError
+		if err != nil && !errors.As(err, &eleg) {
+			t.Errorf("%s: erro de tipo inesperado %T", tc.name, err)
+		}
+	}
+	if err := Elegivel(Campanha{Inicio: date("2025-01-01"), DosesEsquema: 1}, DoseInput{Dose: 1, AplicadaEm: date("2025-02-01")}); err != nil {
+		t.Errorf("campanha sem público restrito: %v", err)
+	}
+}
+
+func TestCampanhaInputNormalize(t *testing.T) {
+	in := CampanhaInput{Nome: " Influenza 2025 ", Vacina: " Influenza ", Inicio: date("2025-04-01"), Grupos: []string{" Gestantes", "gestantes", "Profissionais Saude", ""}}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if in.DosesEsquema != 1 || in.MetaCobertura != 90 || len(in.Grupos) != 2 || in.Grupos[1] != "profissionais_saude" {
+		t.Fatalf("entrada não normalizada: %+v", in)
+	}
+	in = CampanhaInput{Nome: "X", Vacina: "Y", Inicio: date("2025-04-01"), IdadeMinMeses: intPtr(24), IdadeMaxMeses: intPtr(12)}
+	if err := in.Normalize(); err == nil {
+		t.Fatal("esperava erro de faixa etária")
+	}
+}
+
+func TestPercentual(t *testing.T) {
+	if Percentual(10, nil) != nil {
+		t.Fatal("sem população-alvo não há percentual")
+	}
+	if p := Percentual(2, intPtr(3)); p == nil || *p != 66.7 {
+		t.Fatalf("Percentual(2, 3) = %v", p)
+	}
+	if p := Percentual(12, intPtr(10)); *p != 100 {
+		t.Fatalf("Percentual limitado a 100, veio %v", *p)
+	}
+}
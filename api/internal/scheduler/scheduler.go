@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// Locker garante que uma execução periódica aconteça uma única vez por intervalo na frota.
+type Locker interface {
+	RunExclusive(ctx context.Context, job string, interval time.Duration, fn func(ctx context.Context) error) (bool, error)
+}
+
+// Job descreve o estado de coordenação de uma tarefa periódica.
+type Job struct {
+	Name           string     `json:"name"`
+	Holder         *string    `json:"holder,omitempty"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	Runs           int64      `json:"runs"`
+}
+
+// Scheduler coordena réplicas pela tabela scheduler_jobs. Cada réplica tenta reivindicar o job
+// a cada tick; o UPDATE condicional só vence se o último início for anterior ao intervalo e não
+// houver lease ativo, então o job roda uma vez por intervalo mesmo com relógios de tick defasados.
+// Se a réplica dona cair, o lease expira e outra assume no tick seguinte.
+type Scheduler struct {
+	pool     *pgxpool.Pool
+	instance string
+	logger   zerolog.Logger
+}
+
+// New cria o coordenador com um identificador único da instância (host, pid e sufixo aleatório).
+func New(pool *pgxpool.Pool, logger zerolog.Logger) *Scheduler {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return &Scheduler{
+		pool:     pool,
+		instance: fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(suffix)),
+		logger:   logger,
+	}
+}
+
+// Instance identifica esta réplica nos registros de lease.
+func (s *Scheduler) Instance() string {
+	return s.instance
+}
+
+// RunExclusive executa fn se esta réplica vencer a disputa pelo job; devolve false quando outra
+// réplica já executou no intervalo corrente ou ainda detém o lease.
+func (s *Scheduler) RunExclusive(ctx context.Context, job string, interval time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	claimed, err := s.claim(ctx, job, interval)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	runErr := fn(ctx)
+
+	var errMsg *string
+	if runErr != nil {
+		msg := runErr.Error()
+		errMsg = &msg
+	}
+	// O contexto do job pode ter sido cancelado no shutdown; a liberação usa um contexto próprio.
+	releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.pool.Exec(releaseCtx, `
+		UPDATE scheduler_jobs
+		SET locked_until = NULL, last_finished_at = now(), last_error = $3
+		WHERE name = $1 AND holder = $2
+	`, job, s.instance, errMsg); err != nil {
+		s.logger.Warn().Err(err).Str("job", job).Msg("scheduler: falha ao liberar lease")
+	}
+	return true, runErr
+}
+
+func (s *Scheduler) claim(ctx context.Context, job string, interval time.Duration) (bool, error) {
+	// Tolerância de 10% absorve a defasagem entre o tick local e o início registrado.
+	window := interval - interval/10
+	lease := interval
+	if lease < time.Minute {
+		lease = time.Minute
+	}
+
+	var name string
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO scheduler_jobs (name, holder, locked_until, last_started_at, runs)
+		VALUES ($1, $2, now() + $4 * interval '1 second', now(), 1)
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder,
+		    locked_until = EXCLUDED.locked_until,
+		    last_started_at = EXCLUDED.last_started_at,
+		    runs = scheduler_jobs.runs + 1
+		WHERE (scheduler_jobs.last_started_at IS NULL OR scheduler_jobs.last_started_at <= now() - $3 * interval '1 second')
+		  AND (scheduler_jobs.locked_until IS NULL OR scheduler_jobs.locked_until < now())
+		RETURNING name
+	`, job, s.instance, window.Seconds(), lease.Seconds()).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Every roda fn a cada intervalo, coordenando com as demais réplicas, até ctx ser cancelado.
+// A primeira tentativa é imediata.
+func (s *Scheduler) Every(ctx context.Context, job string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ran, err := s.RunExclusive(ctx, job, interval, fn)
+		if err != nil {
+			s.logger.Error().Err(err).Str("job", job).Msg("scheduler: execução falhou")
+		} else if ran {
+			s.logger.Debug().Str("job", job).Msg("scheduler: execução concluída")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Jobs lista o estado de todos os jobs coordenados.
+func (s *Scheduler) Jobs(ctx context.Context) ([]Job, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT name, holder, locked_until, last_started_at, last_finished_at, last_error, runs
+		FROM scheduler_jobs
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(&job.Name, &job.Holder, &job.LockedUntil, &job.LastStartedAt, &job.LastFinishedAt, &job.LastError, &job.Runs); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
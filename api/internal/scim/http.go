@@ -0,0 +1,235 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+type contextKey struct{}
+
+// Handler expõe o endpoint SCIM autenticado por bearer token da prefeitura.
+type Handler struct {
+	service *Service
+	router  TenantRouter
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// TenantRouter fixa no contexto o pool do cluster de banco da prefeitura (db.Resolver).
+type TenantRouter interface {
+	WithTenant(ctx context.Context, tenantID uuid.UUID) (context.Context, error)
+}
+
+// WithRouter faz as requisições autenticadas lerem e gravarem no cluster da prefeitura do token.
+func (h *Handler) WithRouter(router TenantRouter) *Handler {
+	h.router = router
+	return h
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Use(h.authenticate)
+	r.Get("/ServiceProviderConfig", h.serviceProviderConfig)
+	r.Get("/Users", h.listUsers)
+	r.Post("/Users", h.createUser)
+	r.Get("/Users/{userID}", h.getUser)
+	r.Put("/Users/{userID}", h.replaceUser)
+	r.Patch("/Users/{userID}", h.patchUser)
+	r.Delete("/Users/{userID}", h.deleteUser)
+}
+
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+			writeError(w, &scimError{status: http.StatusUnauthorized, detail: "token ausente"})
+			return
+		}
+		tenantID, err := h.service.Autenticar(r.Context(), header[7:])
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, &scimError{status: http.StatusUnauthorized, detail: "token inválido ou revogado"})
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		ctx := r.Context()
+		if h.router != nil {
+			ctx, err = h.router.WithTenant(ctx, tenantID)
+			if errors.Is(err, db.ErrTenantFrozen) {
+				w.Header().Set("Retry-After", "30")
+				writeError(w, &scimError{status: http.StatusServiceUnavailable, detail: "prefeitura em migração de banco; tente novamente em instantes"})
+				return
+			}
+			if err != nil {
+				writeError(w, &scimError{status: http.StatusServiceUnavailable, detail: "banco da prefeitura indisponível"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, contextKey{}, tenantID)))
+	})
+}
+
+func (h *Handler) serviceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{SchemaSPConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxPageSize},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Token emitido no painel SaaS para a prefeitura",
+		}},
+	})
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	startIndex, _ := strconv.Atoi(query.Get("startIndex"))
+	count, _ := strconv.Atoi(query.Get("count"))
+
+	resp, err := h.service.ListUsers(r.Context(), tenantFrom(r), query.Get("filter"), startIndex, count)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	res, err := h.service.GetUser(r.Context(), tenantFrom(r), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var payload UserResource
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, &scimError{status: http.StatusBadRequest, scimType: "invalidSyntax", detail: "JSON inválido"})
+		return
+	}
+	res, err := h.service.CreateUser(r.Context(), tenantFrom(r), payload)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (h *Handler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	var payload UserResource
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, &scimError{status: http.StatusBadRequest, scimType: "invalidSyntax", detail: "JSON inválido"})
+		return
+	}
+	res, err := h.service.ReplaceUser(r.Context(), tenantFrom(r), userID, payload)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	var payload PatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || len(payload.Operations) == 0 {
+		writeError(w, &scimError{status: http.StatusBadRequest, scimType: "invalidSyntax", detail: "PatchOp inválido"})
+		return
+	}
+	res, err := h.service.PatchUser(r.Context(), tenantFrom(r), userID, payload.Operations)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteUser(r.Context(), tenantFrom(r), userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func tenantFrom(r *http.Request) uuid.UUID {
+	tenantID, _ := r.Context().Value(contextKey{}).(uuid.UUID)
+	return tenantID
+}
+
+// parseUserID responde 404, e não 400, para ids malformados: para o IdP o recurso simplesmente não existe.
+func parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "userID")))
+	if err != nil {
+		writeError(w, ErrNotFound)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// writeError responde no formato de erro do SCIM (RFC 7644 §3.12).
+func writeError(w http.ResponseWriter, err error) {
+	resp := &scimError{status: http.StatusInternalServerError, detail: "erro interno"}
+	var se *scimError
+	switch {
+	case errors.As(err, &se):
+		resp = se
+	case errors.Is(err, ErrNotFound):
+		resp = &scimError{status: http.StatusNotFound, detail: "recurso não encontrado"}
+	case errors.Is(err, ErrConflict):
+		resp = &scimError{status: http.StatusConflict, scimType: "uniqueness", detail: "userName ou externalId já provisionado"}
+	default:
+		log.Error().Err(err).Msg("scim: falha ao processar requisição")
+	}
+
+	body := map[string]any{
+		"schemas": []string{SchemaError},
+		"status":  strconv.Itoa(resp.status),
+		"detail":  resp.detail,
+	}
+	if resp.scimType != "" {
+		body["scimType"] = resp.scimType
+	}
+	writeJSON(w, resp.status, body)
+}
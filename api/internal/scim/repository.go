@@ -0,0 +1,482 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
+const dbTimeout = 5 * time.Second
+
+// Repository persiste tokens, regras e identidades provisionadas pelo IdP da prefeitura. Os tokens
+// (scim_tokens) são do plano de controle e ficam sempre no primary, onde a autenticação os procura;
+// regras e identidades seguem o cluster da prefeitura fixado no contexto.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.db)
+}
+
+type Regra struct {
+	ID           uuid.UUID `json:"id"`
+	Atributo     string    `json:"attribute"`
+	Valor        string    `json:"value"`
+	SecretariaID uuid.UUID `json:"secretaria_id"`
+	Secretaria   string    `json:"secretaria,omitempty"`
+	Papel        string    `json:"papel"`
+}
+
+type Atribuicao struct {
+	SecretariaID uuid.UUID
+	Papel        string
+}
+
+type Usuario struct {
+	ID           uuid.UUID
+	ExternalID   *string
+	Email        string
+	Nome         *string
+	Ativo        bool
+	Department   *string
+	Title        *string
+	Roles        []string
+	CriadoEm     time.Time
+	AtualizadoEm time.Time
+}
+
+type UsuarioInput struct {
+	ExternalID *string
+	Email      string
+	Nome       *string
+	Ativo      bool
+	Department *string
+	Title      *string
+	Roles      []string
+}
+
+type TokenStatus struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+const usuarioColumns = `u.id, si.external_id, u.email, u.nome, u.ativo, si.department, si.title, si.roles, si.criado_em, si.atualizado_em`
+
+func scanUsuario(row pgx.Row) (Usuario, error) {
+	var u Usuario
+	err := row.Scan(&u.ID, &u.ExternalID, &u.Email, &u.Nome, &u.Ativo, &u.Department, &u.Title, &u.Roles, &u.CriadoEm, &u.AtualizadoEm)
+	return u, err
+}
+
+// TenantPorToken resolve a prefeitura dona do token, atualizando last_used_at no máximo uma vez por minuto.
+func (r *Repository) TenantPorToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var tenantID uuid.UUID
+	err := r.db.QueryRow(ctx, `
+		UPDATE scim_tokens
+		SET last_used_at = CASE
+			WHEN last_used_at IS NULL OR last_used_at < now() - interval '1 minute' THEN now()
+			ELSE last_used_at
+		END
+		WHERE token_hash = $1 AND revoked_at IS NULL
+		RETURNING tenant_id
+	`, tokenHash).Scan(&tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ErrNotFound
+	}
+	return tenantID, err
+}
+
+// SubstituirToken revoga o token ativo e registra um novo.
+func (r *Repository) SubstituirToken(ctx context.Context, tenantID uuid.UUID, tokenHash string, createdBy *uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE scim_tokens SET revoked_at = now() WHERE tenant_id = $1 AND revoked_at IS NULL`, tenantID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO scim_tokens (tenant_id, token_hash, created_by) VALUES ($1, $2, $3)
+	`, tenantID, tokenHash, createdBy); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) RevogarToken(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tag, err := r.db.Exec(ctx, `UPDATE scim_tokens SET revoked_at = now() WHERE tenant_id = $1 AND revoked_at IS NULL`, tenantID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *Repository) TokenAtivo(ctx context.Context, tenantID uuid.UUID) (*TokenStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var status TokenStatus
+	err := r.db.QueryRow(ctx, `
+		SELECT created_at, last_used_at FROM scim_tokens WHERE tenant_id = $1 AND revoked_at IS NULL
+	`, tenantID).Scan(&status.CreatedAt, &status.LastUsedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (r *Repository) Regras(ctx context.Context, tenantID uuid.UUID) ([]Regra, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT sr.id, sr.atributo, sr.valor, sr.secretaria_id, s.nome, sr.papel
+		FROM scim_regras sr
+		JOIN secretarias s ON s.id = sr.secretaria_id
+		WHERE sr.tenant_id = $1
+		ORDER BY sr.atributo, sr.valor, s.nome
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regras := make([]Regra, 0)
+	for rows.Next() {
+		var regra Regra
+		if err := rows.Scan(&regra.ID, &regra.Atributo, &regra.Valor, &regra.SecretariaID, &regra.Secretaria, &regra.Papel); err != nil {
+			return nil, err
+		}
+		regras = append(regras, regra)
+	}
+	return regras, rows.Err()
+}
+
+// SubstituirRegras troca todas as regras do tenant; secretarias de outra prefeitura são rejeitadas.
+func (r *Repository) SubstituirRegras(ctx context.Context, tenantID uuid.UUID, regras []Regra) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM scim_regras WHERE tenant_id = $1`, tenantID); err != nil {
+		return err
+	}
+	for _, regra := range regras {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO scim_regras (tenant_id, atributo, valor, secretaria_id, papel)
+			SELECT $1, $2, $3, s.id, $5
+			FROM secretarias s
+			WHERE s.id = $4 AND s.tenant_id = $1
+			ON CONFLICT (tenant_id, atributo, valor, secretaria_id) DO UPDATE SET papel = EXCLUDED.papel
+		`, tenantID, regra.Atributo, regra.Valor, regra.SecretariaID, regra.Papel)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) ListUsuarios(ctx context.Context, tenantID uuid.UUID, filtro *Filtro, offset, limit int) ([]Usuario, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where := `si.tenant_id = $1`
+	args := []any{tenantID}
+	if filtro != nil {
+		args = append(args, filtro.Valor)
+		if filtro.Atributo == "externalId" {
+			where += ` AND si.external_id = $2`
+		} else {
+			where += ` AND lower(u.email) = lower($2)`
+		}
+	}
+
+	var total int
+	if err := r.conn(ctx).QueryRow(ctx, `
+		SELECT count(*) FROM scim_identidades si JOIN usuarios u ON u.id = si.usuario_id WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, offset, limit)
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+usuarioColumns+`
+		FROM scim_identidades si
+		JOIN usuarios u ON u.id = si.usuario_id
+		WHERE `+where+`
+		ORDER BY si.criado_em, u.id
+		OFFSET $`+strconv.Itoa(len(args)-1)+` LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	usuarios := make([]Usuario, 0)
+	for rows.Next() {
+		u, err := scanUsuario(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		usuarios = append(usuarios, u)
+	}
+	return usuarios, total, rows.Err()
+}
+
+func (r *Repository) GetUsuario(ctx context.Context, tenantID, usuarioID uuid.UUID) (Usuario, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	u, err := scanUsuario(r.conn(ctx).QueryRow(ctx, `
+		SELECT `+usuarioColumns+`
+		FROM scim_identidades si
+		JOIN usuarios u ON u.id = si.usuario_id
+		WHERE si.tenant_id = $1 AND si.usuario_id = $2
+	`, tenantID, usuarioID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Usuario{}, ErrNotFound
+	}
+	return u, err
+}
+
+// SalvarUsuario cria (usuarioID nil) ou substitui o usuário provisionado e sincroniza seus
+// papéis nas secretarias do tenant. Contas já existentes são adotadas só se não pertencerem
+// a outra prefeitura.
+func (r *Repository) SalvarUsuario(ctx context.Context, tenantID uuid.UUID, usuarioID *uuid.UUID, input UsuarioInput, senhaHash string, atribuicoes []Atribuicao) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	if usuarioID == nil {
+		id, err = adotarOuCriar(ctx, tx, tenantID, input, senhaHash)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	} else {
+		id = *usuarioID
+		tag, err := tx.Exec(ctx, `
+			UPDATE usuarios u SET nome = $3, email = $4, ativo = $5
+			FROM scim_identidades si
+			WHERE si.usuario_id = u.id AND si.tenant_id = $1 AND u.id = $2
+		`, tenantID, id, input.Nome, input.Email, input.Ativo)
+		if err != nil {
+			return uuid.Nil, mapConflict(err)
+		}
+		if tag.RowsAffected() == 0 {
+			return uuid.Nil, ErrNotFound
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO scim_identidades (tenant_id, usuario_id, external_id, department, title, roles)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, usuario_id) DO UPDATE
+		SET external_id = EXCLUDED.external_id, department = EXCLUDED.department, title = EXCLUDED.title,
+		    roles = EXCLUDED.roles, atualizado_em = now()
+	`, tenantID, id, input.ExternalID, input.Department, input.Title, rolesOrEmpty(input.Roles)); err != nil {
+		return uuid.Nil, mapConflict(err)
+	}
+
+	if !input.Ativo {
+		atribuicoes = nil
+	}
+	if err := sincronizarPapeis(ctx, tx, tenantID, id, atribuicoes); err != nil {
+		return uuid.Nil, err
+	}
+	if !input.Ativo {
+		if err := revogarSessoes(ctx, tx, id); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// RemoverUsuario desprovisiona: desativa a conta, remove vínculos do tenant e encerra sessões.
+func (r *Repository) RemoverUsuario(ctx context.Context, tenantID, usuarioID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM scim_identidades WHERE tenant_id = $1 AND usuario_id = $2`, tenantID, usuarioID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.Exec(ctx, `UPDATE usuarios SET ativo = FALSE WHERE id = $1`, usuarioID); err != nil {
+		return err
+	}
+	if err := sincronizarPapeis(ctx, tx, tenantID, usuarioID, nil); err != nil {
+		return err
+	}
+	if err := revogarSessoes(ctx, tx, usuarioID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) CountUsuarios(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var total int
+	err := r.conn(ctx).QueryRow(ctx, `SELECT count(*) FROM scim_identidades WHERE tenant_id = $1`, tenantID).Scan(&total)
+	return total, err
+}
+
+// VinculosDoUsuario junta os tenants a que a conta u já pertence por qualquer caminho que dá
+// acesso ao backoffice, os mesmos de ListUsuarioAuthPolicies mais papéis customizados e convites.
+// A consulta que o usa precisa ter usuarios no FROM com o alias u.
+const VinculosDoUsuario = `
+	SELECT s.tenant_id FROM usuarios_secretarias us JOIN secretarias s ON s.id = us.secretaria_id WHERE us.usuario_id = u.id
+	UNION ALL SELECT ta.tenant_id FROM tenant_admins ta WHERE ta.usuario_id = u.id
+	UNION ALL SELECT e.tenant_id FROM escolas_gestores eg JOIN escolas e ON e.id = eg.escola_id WHERE eg.usuario_id = u.id
+	UNION ALL SELECT e.tenant_id FROM professores_turmas pt JOIN turmas tu ON tu.id = pt.turma_id JOIN escolas e ON e.id = tu.escola_id WHERE pt.professor_id = u.id
+	UNION ALL SELECT br.tenant_id FROM backoffice_role_membros bm JOIN backoffice_roles br ON br.id = bm.role_id WHERE bm.usuario_id = u.id
+	UNION ALL SELECT uc.tenant_id FROM usuarios_convites uc WHERE uc.usuario_id = u.id
+`
+
+// adotarOuCriar reaproveita a conta com o mesmo e-mail só quando ela não tem vínculo com nenhum
+// outro tenant; do contrário o IdP de uma prefeitura assumiria (e poderia desativar) a conta de
+// quem trabalha em outra.
+func adotarOuCriar(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, input UsuarioInput, senhaHash string) (uuid.UUID, error) {
+	var (
+		id           uuid.UUID
+		provisionado bool
+		outroTenant  bool
+	)
+	err := tx.QueryRow(ctx, `
+		SELECT u.id,
+		       EXISTS (SELECT 1 FROM scim_identidades si WHERE si.usuario_id = u.id),
+		       EXISTS (
+		           SELECT 1 FROM (`+VinculosDoUsuario+`) vinculos
+		           WHERE vinculos.tenant_id IS DISTINCT FROM $2
+		       )
+		FROM usuarios u
+		WHERE lower(u.email) = lower($1)
+		FOR UPDATE OF u
+	`, input.Email, tenantID).Scan(&id, &provisionado, &outroTenant)
+	if errors.Is(err, pgx.ErrNoRows) {
+		id = uuid.New()
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO usuarios (id, nome, email, senha_hash, ativo) VALUES ($1, $2, $3, $4, $5)
+		`, id, input.Nome, input.Email, senhaHash, input.Ativo); err != nil {
+			return uuid.Nil, mapConflict(err)
+		}
+		return id, nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if provisionado || outroTenant {
+		return uuid.Nil, ErrConflict
+	}
+	if _, err := tx.Exec(ctx, `UPDATE usuarios SET nome = COALESCE($2, nome), ativo = $3 WHERE id = $1`, id, input.Nome, input.Ativo); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// sincronizarPapeis faz das atribuições a fonte da verdade para as secretarias do tenant,
+// sem tocar vínculos inalterados para não poluir o log de ações privilegiadas.
+func sincronizarPapeis(ctx context.Context, tx pgx.Tx, tenantID, usuarioID uuid.UUID, atribuicoes []Atribuicao) error {
+	secretarias := make([]uuid.UUID, 0, len(atribuicoes))
+	papeis := make([]string, 0, len(atribuicoes))
+	for _, item := range atribuicoes {
+		secretarias = append(secretarias, item.SecretariaID)
+		papeis = append(papeis, item.Papel)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM usuarios_secretarias us
+		USING secretarias s
+		WHERE s.id = us.secretaria_id AND s.tenant_id = $1 AND us.usuario_id = $2
+		  AND NOT (us.secretaria_id = ANY($3::uuid[]))
+	`, tenantID, usuarioID, secretarias); err != nil {
+		return err
+	}
+	if len(secretarias) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO usuarios_secretarias (usuario_id, secretaria_id, papel)
+		SELECT $2, v.secretaria_id, v.papel
+		FROM unnest($3::uuid[], $4::text[]) AS v(secretaria_id, papel)
+		JOIN secretarias s ON s.id = v.secretaria_id AND s.tenant_id = $1
+		ON CONFLICT (usuario_id, secretaria_id) DO UPDATE SET papel = EXCLUDED.papel
+		WHERE usuarios_secretarias.papel <> EXCLUDED.papel
+	`, tenantID, usuarioID, secretarias, papeis)
+	return err
+}
+
+func revogarSessoes(ctx context.Context, tx pgx.Tx, usuarioID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `UPDATE tokens_refresh SET revogado = TRUE WHERE subject = $1 AND audience = 'backoffice' AND NOT revogado`, usuarioID)
+	return err
+}
+
+func mapConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrConflict
+	}
+	return err
+}
+
+func rolesOrEmpty(roles []string) []string {
+	if roles == nil {
+		return []string{}
+	}
+	return roles
+}
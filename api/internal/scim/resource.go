@@ -0,0 +1,460 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaEnterprise   = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaSPConfig     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// Papéis aceitos em usuarios_secretarias, do menor para o maior privilégio.
+var papeis = []string{"ATENDENTE", "SECRETARIO", "PREFEITO", "ADMIN_TEC"}
+
+// Atributos do IdP que podem disparar regras de mapeamento.
+var atributosRegra = []string{"department", "title", "role"}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type EnterpriseUser struct {
+	Department string `json:"department,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// UserResource é o subconjunto do recurso User suportado pelo provisionamento.
+type UserResource struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	UserName    string          `json:"userName"`
+	Name        *Name           `json:"name,omitempty"`
+	DisplayName string          `json:"displayName,omitempty"`
+	Emails      []MultiValue    `json:"emails,omitempty"`
+	Active      *bool           `json:"active,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Roles       []MultiValue    `json:"roles,omitempty"`
+	Enterprise  *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta        *Meta           `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string       `json:"schemas"`
+	TotalResults int            `json:"totalResults"`
+	StartIndex   int            `json:"startIndex"`
+	ItemsPerPage int            `json:"itemsPerPage"`
+	Resources    []UserResource `json:"Resources"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// Filtro representa o único formato aceito em GET /Users: `<atributo> eq "<valor>"`.
+type Filtro struct {
+	Atributo string
+	Valor    string
+}
+
+// scimError carrega status e scimType para a resposta de erro do protocolo.
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+func invalidValue(format string, args ...any) error {
+	return &scimError{status: 400, scimType: "invalidValue", detail: fmt.Sprintf(format, args...)}
+}
+
+// parseFiltro aceita apenas igualdade sobre userName e externalId, que é o que o Azure AD usa para casar contas.
+func parseFiltro(raw string) (*Filtro, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(raw, " ", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[1], "eq") {
+		return nil, &scimError{status: 400, scimType: "invalidFilter", detail: "apenas filtros 'eq' são suportados"}
+	}
+	valor := strings.TrimSpace(parts[2])
+	if len(valor) < 2 || valor[0] != '"' || valor[len(valor)-1] != '"' {
+		return nil, &scimError{status: 400, scimType: "invalidFilter", detail: "valor do filtro deve estar entre aspas"}
+	}
+	valor, err := strconv.Unquote(valor)
+	if err != nil {
+		return nil, &scimError{status: 400, scimType: "invalidFilter", detail: "valor do filtro inválido"}
+	}
+	switch strings.ToLower(parts[0]) {
+	case "username":
+		return &Filtro{Atributo: "userName", Valor: valor}, nil
+	case "externalid":
+		return &Filtro{Atributo: "externalId", Valor: valor}, nil
+	}
+	return nil, &scimError{status: 400, scimType: "invalidFilter", detail: "atributo de filtro não suportado: " + parts[0]}
+}
+
+// toResource converte o usuário persistido para o formato SCIM.
+func toResource(u Usuario) UserResource {
+	active := u.Ativo
+	res := UserResource{
+		Schemas:  []string{SchemaUser, SchemaEnterprise},
+		ID:       u.ID.String(),
+		UserName: u.Email,
+		Emails:   []MultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      u.CriadoEm,
+			LastModified: u.AtualizadoEm,
+			Location:     "/scim/v2/Users/" + u.ID.String(),
+		},
+	}
+	if u.ExternalID != nil {
+		res.ExternalID = *u.ExternalID
+	}
+	if u.Nome != nil {
+		res.DisplayName = *u.Nome
+		res.Name = &Name{Formatted: *u.Nome}
+	}
+	if u.Title != nil {
+		res.Title = *u.Title
+	}
+	if u.Department != nil {
+		res.Enterprise = &EnterpriseUser{Department: *u.Department}
+	}
+	for _, role := range u.Roles {
+		res.Roles = append(res.Roles, MultiValue{Value: role})
+	}
+	return res
+}
+
+// toInput extrai do recurso SCIM os campos persistidos e valida o mínimo necessário.
+func toInput(res UserResource) (UsuarioInput, error) {
+	email := strings.ToLower(strings.TrimSpace(res.UserName))
+	if !strings.Contains(email, "@") {
+		for _, item := range res.Emails {
+			if item.Primary || email == "" || !strings.Contains(email, "@") {
+				email = strings.ToLower(strings.TrimSpace(item.Value))
+			}
+		}
+	}
+	if email == "" || !strings.Contains(email, "@") {
+		return UsuarioInput{}, invalidValue("userName ou emails deve conter um e-mail válido")
+	}
+
+	nome := strings.TrimSpace(res.DisplayName)
+	if nome == "" && res.Name != nil {
+		nome = strings.TrimSpace(res.Name.Formatted)
+		if nome == "" {
+			nome = strings.TrimSpace(res.Name.GivenName + " " + res.Name.FamilyName)
+		}
+	}
+
+	input := UsuarioInput{
+		Email:      email,
+		Nome:       optional(nome),
+		ExternalID: optional(res.ExternalID),
+		Title:      optional(res.Title),
+		Ativo:      res.Active == nil || *res.Active,
+	}
+	if res.Enterprise != nil {
+		input.Department = optional(res.Enterprise.Department)
+	}
+	seen := map[string]struct{}{}
+	for _, role := range res.Roles {
+		value := strings.TrimSpace(role.Value)
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[strings.ToLower(value)]; ok {
+			continue
+		}
+		seen[strings.ToLower(value)] = struct{}{}
+		input.Roles = append(input.Roles, value)
+	}
+	return input, nil
+}
+
+// aplicarPatch aplica as operações no recurso atual. Atributos que não armazenamos são ignorados,
+// pois o Azure AD envia o perfil completo mesmo quando só parte dele é mapeada.
+func aplicarPatch(res *UserResource, ops []PatchOperation) error {
+	for _, op := range ops {
+		kind := strings.ToLower(strings.TrimSpace(op.Op))
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return &scimError{status: 400, scimType: "invalidSyntax", detail: "operação inválida: " + op.Op}
+		}
+		if strings.TrimSpace(op.Path) == "" {
+			if kind == "remove" {
+				return &scimError{status: 400, scimType: "noTarget", detail: "remove exige path"}
+			}
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return invalidValue("value deve ser um objeto quando path é omitido")
+			}
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if key == SchemaEnterprise {
+					var ext map[string]json.RawMessage
+					if err := json.Unmarshal(values[key], &ext); err != nil {
+						return invalidValue("extensão enterprise inválida")
+					}
+					for extKey, extValue := range ext {
+						if err := aplicarAtributo(res, kind, SchemaEnterprise+":"+extKey, extValue); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+				if err := aplicarAtributo(res, kind, key, values[key]); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := aplicarAtributo(res, kind, op.Path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func aplicarAtributo(res *UserResource, kind, path string, value json.RawMessage) error {
+	remove := kind == "remove"
+	lower := strings.ToLower(strings.TrimSpace(path))
+
+	switch {
+	case lower == "active":
+		if remove {
+			return invalidValue("active não pode ser removido")
+		}
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		res.Active = &active
+	case lower == "username":
+		if remove {
+			return invalidValue("userName não pode ser removido")
+		}
+		return setString(&res.UserName, value, false)
+	case lower == "displayname":
+		return setString(&res.DisplayName, value, remove)
+	case lower == "externalid":
+		return setString(&res.ExternalID, value, remove)
+	case lower == "title":
+		return setString(&res.Title, value, remove)
+	case lower == "name.givenname", lower == "name.familyname", lower == "name.formatted":
+		if res.Name == nil {
+			res.Name = &Name{}
+		}
+		// displayName deriva do nome quando o IdP só atualiza as partes.
+		res.DisplayName = ""
+		switch lower {
+		case "name.givenname":
+			return setString(&res.Name.GivenName, value, remove)
+		case "name.familyname":
+			return setString(&res.Name.FamilyName, value, remove)
+		default:
+			return setString(&res.Name.Formatted, value, remove)
+		}
+	case lower == "name":
+		if remove {
+			res.Name = nil
+			return nil
+		}
+		var name Name
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalidValue("name inválido")
+		}
+		res.Name = &name
+		res.DisplayName = ""
+	case strings.HasPrefix(lower, "emails"):
+		if remove {
+			res.Emails = nil
+			return nil
+		}
+		if strings.HasSuffix(lower, ".value") {
+			var email string
+			if err := setString(&email, value, false); err != nil {
+				return err
+			}
+			res.Emails = []MultiValue{{Value: email, Type: "work", Primary: true}}
+			return nil
+		}
+		var emails []MultiValue
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return invalidValue("emails inválido")
+		}
+		res.Emails = emails
+	case lower == strings.ToLower(SchemaEnterprise+":department"):
+		if res.Enterprise == nil {
+			res.Enterprise = &EnterpriseUser{}
+		}
+		return setString(&res.Enterprise.Department, value, remove)
+	case strings.HasPrefix(lower, "roles"):
+		return aplicarRoles(res, kind, path, value)
+	}
+	return nil
+}
+
+func aplicarRoles(res *UserResource, kind, path string, value json.RawMessage) error {
+	if kind == "remove" {
+		// roles[value eq "x"] remove apenas o papel indicado.
+		if start := strings.Index(path, "["); start >= 0 && strings.HasSuffix(path, "]") {
+			parts := strings.SplitN(strings.TrimSpace(path[start+1:len(path)-1]), " ", 3)
+			if len(parts) != 3 || !strings.EqualFold(parts[0], "value") || !strings.EqualFold(parts[1], "eq") {
+				return &scimError{status: 400, scimType: "invalidFilter", detail: "apenas roles[value eq \"...\"] é suportado"}
+			}
+			alvo, err := strconv.Unquote(strings.TrimSpace(parts[2]))
+			if err != nil {
+				return &scimError{status: 400, scimType: "invalidFilter", detail: "valor do filtro inválido"}
+			}
+			kept := res.Roles[:0]
+			for _, role := range res.Roles {
+				if !strings.EqualFold(role.Value, alvo) {
+					kept = append(kept, role)
+				}
+			}
+			res.Roles = kept
+			return nil
+		}
+		res.Roles = nil
+		return nil
+	}
+
+	var roles []MultiValue
+	if err := json.Unmarshal(value, &roles); err != nil {
+		var single MultiValue
+		if err := json.Unmarshal(value, &single); err != nil {
+			return invalidValue("roles inválido")
+		}
+		roles = []MultiValue{single}
+	}
+	if kind == "replace" {
+		res.Roles = roles
+		return nil
+	}
+	res.Roles = append(res.Roles, roles...)
+	return nil
+}
+
+func setString(target *string, value json.RawMessage, remove bool) error {
+	if remove || len(value) == 0 || string(value) == "null" {
+		*target = ""
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err != nil {
+		return invalidValue("valor textual esperado")
+	}
+	*target = strings.TrimSpace(str)
+	return nil
+}
+
+// parseBool aceita booleano JSON ou string ("False"), formato usado pelo Azure AD.
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if parsed, err := strconv.ParseBool(strings.ToLower(strings.TrimSpace(str))); err == nil {
+			return parsed, nil
+		}
+	}
+	return false, invalidValue("active deve ser booleano")
+}
+
+// atribuir resolve as regras do tenant contra os atributos do usuário. Quando várias regras
+// apontam para a mesma secretaria, prevalece o papel de maior privilégio.
+func atribuir(regras []Regra, input UsuarioInput) []Atribuicao {
+	valores := map[string][]string{"role": input.Roles}
+	if input.Department != nil {
+		valores["department"] = []string{*input.Department}
+	}
+	if input.Title != nil {
+		valores["title"] = []string{*input.Title}
+	}
+
+	melhor := map[uuid.UUID]string{}
+	for _, regra := range regras {
+		for _, valor := range valores[regra.Atributo] {
+			if !strings.EqualFold(strings.TrimSpace(valor), strings.TrimSpace(regra.Valor)) {
+				continue
+			}
+			if atual, ok := melhor[regra.SecretariaID]; !ok || papelRank(regra.Papel) > papelRank(atual) {
+				melhor[regra.SecretariaID] = regra.Papel
+			}
+		}
+	}
+
+	atribuicoes := make([]Atribuicao, 0, len(melhor))
+	for secretariaID, papel := range melhor {
+		atribuicoes = append(atribuicoes, Atribuicao{SecretariaID: secretariaID, Papel: papel})
+	}
+	sort.Slice(atribuicoes, func(i, j int) bool {
+		return atribuicoes[i].SecretariaID.String() < atribuicoes[j].SecretariaID.String()
+	})
+	return atribuicoes
+}
+
+func papelRank(papel string) int {
+	for i, p := range papeis {
+		if p == papel {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func optional(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
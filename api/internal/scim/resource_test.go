@@ -0,0 +1,72 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseFiltro(t *testing.T) {
+	filtro, err := parseFiltro(`userName eq "Ana@Prefeitura.gov.br"`)
+	if err != nil || filtro == nil || filtro.Atributo != "userName" || filtro.Valor != "Ana@Prefeitura.gov.br" {
+		t.Fatalf("unexpected filter %+v err %v", filtro, err)
+	}
+	if filtro, err := parseFiltro(""); err != nil || filtro != nil {
+		t.Fatalf("expected empty filter, got %+v err %v", filtro, err)
+	}
+	for _, raw := range []string{`userName co "ana"`, `displayName eq "Ana"`, `externalId eq ana`} {
+		if _, err := parseFiltro(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestAplicarPatch_AzureAD(t *testing.T) {
+	active := true
+	res := UserResource{UserName: "ana@prefeitura.gov.br", DisplayName: "Ana", Active: &active, Roles: []MultiValue{{Value: "Saude"}, {Value: "Gestor"}}}
+
+	var req PatchRequest
+	payload := `{"Operations":[
+		{"op":"Replace","path":"active","value":"False"},
+		{"op":"Add","value":{"title":"Coordenadora","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"department":"Saúde"}}},
+		{"op":"Remove","path":"roles[value eq \"Gestor\"]"},
+		{"op":"Replace","path":"phoneNumbers[type eq \"work\"].value","value":"83 9999-0000"}
+	]}`
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := aplicarPatch(&res, req.Operations); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input, err := toInput(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Ativo {
+		t.Fatalf("expected user deactivated")
+	}
+	if input.Title == nil || *input.Title != "Coordenadora" || input.Department == nil || *input.Department != "Saúde" {
+		t.Fatalf("unexpected attributes %+v", input)
+	}
+	if len(input.Roles) != 1 || input.Roles[0] != "Saude" {
+		t.Fatalf("unexpected roles %v", input.Roles)
+	}
+}
+
+func TestAtribuir_MaiorPapelPrevalece(t *testing.T) {
+	saude, educacao := uuid.New(), uuid.New()
+	regras := []Regra{
+		{Atributo: "department", Valor: "saúde", SecretariaID: saude, Papel: "ATENDENTE"},
+		{Atributo: "role", Valor: "Secretario", SecretariaID: saude, Papel: "SECRETARIO"},
+		{Atributo: "title", Valor: "Diretor", SecretariaID: educacao, Papel: "SECRETARIO"},
+	}
+	department := "Saúde"
+	input := UsuarioInput{Department: &department, Roles: []string{"secretario"}}
+
+	atribuicoes := atribuir(regras, input)
+	if len(atribuicoes) != 1 || atribuicoes[0].SecretariaID != saude || atribuicoes[0].Papel != "SECRETARIO" {
+		t.Fatalf("unexpected assignments %+v", atribuicoes)
+	}
+}
@@ -0,0 +1,8 @@
+package scim
+
+import "github.com/go-chi/chi/v5"
+
+// Mount registra o endpoint SCIM 2.0.
+func Mount(r chi.Router, handler *Handler) {
+	handler.RegisterRoutes(r)
+}
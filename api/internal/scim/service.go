@@ -0,0 +1,253 @@
+package scim
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/gestaozabele/municipio/internal/auth"
+)
+
+// ErrRegraInvalida sinaliza regra de mapeamento rejeitada na configuração.
+var ErrRegraInvalida = errors.New("regra inválida")
+
+const (
+	maxPageSize     = 200
+	defaultPageSize = 100
+)
+
+// Service implementa o subconjunto SCIM 2.0 de Users usado pelo Azure AD.
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+type Configuracao struct {
+	Token         *TokenStatus `json:"token,omitempty"`
+	Regras        []Regra      `json:"rules"`
+	Provisionados int          `json:"provisioned_users"`
+}
+
+// HashToken deriva o valor persistido do bearer token; o token em si nunca é armazenado.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// EmitirToken gera um novo bearer token para o tenant, invalidando o anterior.
+func (s *Service) EmitirToken(ctx context.Context, tenantID uuid.UUID, createdBy *uuid.UUID) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := "scim_" + base64.RawURLEncoding.EncodeToString(buf)
+	if err := s.repo.SubstituirToken(ctx, tenantID, HashToken(token), createdBy); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *Service) RevogarToken(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return s.repo.RevogarToken(ctx, tenantID)
+}
+
+func (s *Service) Autenticar(ctx context.Context, token string) (uuid.UUID, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return uuid.Nil, ErrNotFound
+	}
+	return s.repo.TenantPorToken(ctx, HashToken(token))
+}
+
+func (s *Service) Configuracao(ctx context.Context, tenantID uuid.UUID) (Configuracao, error) {
+	token, err := s.repo.TokenAtivo(ctx, tenantID)
+	if err != nil {
+		return Configuracao{}, err
+	}
+	regras, err := s.repo.Regras(ctx, tenantID)
+	if err != nil {
+		return Configuracao{}, err
+	}
+	total, err := s.repo.CountUsuarios(ctx, tenantID)
+	if err != nil {
+		return Configuracao{}, err
+	}
+	return Configuracao{Token: token, Regras: regras, Provisionados: total}, nil
+}
+
+// DefinirRegras substitui as regras e reaplica o mapeamento aos usuários já provisionados,
+// sem esperar o próximo ciclo do IdP.
+func (s *Service) DefinirRegras(ctx context.Context, tenantID uuid.UUID, regras []Regra) ([]Regra, error) {
+	for i := range regras {
+		regras[i].Atributo = strings.ToLower(strings.TrimSpace(regras[i].Atributo))
+		regras[i].Valor = strings.TrimSpace(regras[i].Valor)
+		regras[i].Papel = strings.ToUpper(strings.TrimSpace(regras[i].Papel))
+		if !contains(atributosRegra, regras[i].Atributo) {
+			return nil, fmt.Errorf("%w: attribute deve ser department, title ou role", ErrRegraInvalida)
+		}
+		if regras[i].Valor == "" {
+			return nil, fmt.Errorf("%w: value obrigatório", ErrRegraInvalida)
+		}
+		if papelRank(regras[i].Papel) == 0 {
+			return nil, fmt.Errorf("%w: papel deve ser %s", ErrRegraInvalida, strings.Join(papeis, ", "))
+		}
+	}
+	if err := s.repo.SubstituirRegras(ctx, tenantID, regras); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: secretaria inexistente ou de outra prefeitura", ErrRegraInvalida)
+		}
+		return nil, err
+	}
+
+	salvas, err := s.repo.Regras(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reaplicar(ctx, tenantID, salvas); err != nil {
+		return nil, err
+	}
+	return salvas, nil
+}
+
+func (s *Service) reaplicar(ctx context.Context, tenantID uuid.UUID, regras []Regra) error {
+	for offset := 0; ; offset += maxPageSize {
+		usuarios, _, err := s.repo.ListUsuarios(ctx, tenantID, nil, offset, maxPageSize)
+		if err != nil {
+			return err
+		}
+		for _, u := range usuarios {
+			input := inputFromUsuario(u)
+			if _, err := s.repo.SalvarUsuario(ctx, tenantID, &u.ID, input, "", atribuir(regras, input)); err != nil {
+				return err
+			}
+		}
+		if len(usuarios) < maxPageSize {
+			return nil
+		}
+	}
+}
+
+// ListUsers implementa GET /Users com paginação 1-based do SCIM.
+func (s *Service) ListUsers(ctx context.Context, tenantID uuid.UUID, filter string, startIndex, count int) (ListResponse, error) {
+	filtro, err := parseFiltro(filter)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count <= 0 {
+		count = defaultPageSize
+	}
+	if count > maxPageSize {
+		count = maxPageSize
+	}
+
+	usuarios, total, err := s.repo.ListUsuarios(ctx, tenantID, filtro, startIndex-1, count)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	resp := ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(usuarios),
+		Resources:    make([]UserResource, 0, len(usuarios)),
+	}
+	for _, u := range usuarios {
+		resp.Resources = append(resp.Resources, toResource(u))
+	}
+	return resp, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, tenantID, usuarioID uuid.UUID) (UserResource, error) {
+	u, err := s.repo.GetUsuario(ctx, tenantID, usuarioID)
+	if err != nil {
+		return UserResource{}, err
+	}
+	return toResource(u), nil
+}
+
+// CreateUser provisiona a conta. Sem senha local: o acesso depende de redefinição ou SSO.
+func (s *Service) CreateUser(ctx context.Context, tenantID uuid.UUID, res UserResource) (UserResource, error) {
+	input, err := toInput(res)
+	if err != nil {
+		return UserResource{}, err
+	}
+	senha := make([]byte, 32)
+	if _, err := rand.Read(senha); err != nil {
+		return UserResource{}, err
+	}
+	senhaHash, err := auth.Hash(hex.EncodeToString(senha))
+	if err != nil {
+		return UserResource{}, err
+	}
+	return s.salvar(ctx, tenantID, nil, input, senhaHash)
+}
+
+func (s *Service) ReplaceUser(ctx context.Context, tenantID, usuarioID uuid.UUID, res UserResource) (UserResource, error) {
+	input, err := toInput(res)
+	if err != nil {
+		return UserResource{}, err
+	}
+	return s.salvar(ctx, tenantID, &usuarioID, input, "")
+}
+
+func (s *Service) PatchUser(ctx context.Context, tenantID, usuarioID uuid.UUID, ops []PatchOperation) (UserResource, error) {
+	atual, err := s.repo.GetUsuario(ctx, tenantID, usuarioID)
+	if err != nil {
+		return UserResource{}, err
+	}
+	res := toResource(atual)
+	if err := aplicarPatch(&res, ops); err != nil {
+		return UserResource{}, err
+	}
+	return s.ReplaceUser(ctx, tenantID, usuarioID, res)
+}
+
+func (s *Service) DeleteUser(ctx context.Context, tenantID, usuarioID uuid.UUID) error {
+	return s.repo.RemoverUsuario(ctx, tenantID, usuarioID)
+}
+
+func (s *Service) salvar(ctx context.Context, tenantID uuid.UUID, usuarioID *uuid.UUID, input UsuarioInput, senhaHash string) (UserResource, error) {
+	regras, err := s.repo.Regras(ctx, tenantID)
+	if err != nil {
+		return UserResource{}, err
+	}
+	id, err := s.repo.SalvarUsuario(ctx, tenantID, usuarioID, input, senhaHash, atribuir(regras, input))
+	if err != nil {
+		return UserResource{}, err
+	}
+	return s.GetUser(ctx, tenantID, id)
+}
+
+func inputFromUsuario(u Usuario) UsuarioInput {
+	return UsuarioInput{
+		ExternalID: u.ExternalID,
+		Email:      u.Email,
+		Nome:       u.Nome,
+		Ativo:      u.Ativo,
+		Department: u.Department,
+		Title:      u.Title,
+		Roles:      u.Roles,
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
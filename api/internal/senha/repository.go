@@ -0,0 +1,530 @@
+package senha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// hoje é a data de Brasília no banco; a numeração e as filas do dia viram à meia-noite local.
+const hoje = `(now() AT TIME ZONE 'America/Sao_Paulo')::date`
+
+const filaColumns = `f.id, f.tenant_id, f.secretaria_id, s.nome, f.nome, f.prefixo, f.local, f.ativa,
+        (SELECT count(*) FROM senhas sn WHERE sn.fila_id = f.id AND sn.dia = ` + hoje + ` AND sn.status = 'aguardando')::int,
+        f.created_at, f.updated_at`
+
+const senhaColumns = `sn.id, sn.tenant_id, sn.fila_id, f.nome, f.local, sn.codigo, sn.numero, sn.preferencial, sn.origem,
+        sn.cidadao_id, sn.status, sn.guiche, sn.atendente_id, sn.chamadas, sn.emitida_em, sn.chamada_em,
+        sn.ultima_chamada_em, sn.finalizada_em`
+
+const totemColumns = `id, tenant_id, nome, created_at, last_used_at, revogado_em, created_by, token_prefix`
+
+// FilaFilter restringe a listagem de filas.
+type FilaFilter struct {
+	SecretariaID  *uuid.UUID
+	SomenteAtivas bool
+}
+
+// Repository persiste filas, senhas e totens.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// ListFilas lista as filas da prefeitura com a quantidade aguardando hoje.
+func (r *Repository) ListFilas(ctx context.Context, tenantID uuid.UUID, filter FilaFilter) ([]Fila, error) {
+	clauses := []string{"f.tenant_id = $1"}
+	args := []any{tenantID}
+	if filter.SecretariaID != nil {
+		args = append(args, *filter.SecretariaID)
+		clauses = append(clauses, fmt.Sprintf("f.secretaria_id = $%d", len(args)))
+	}
+	if filter.SomenteAtivas {
+		clauses = append(clauses, "f.ativa")
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+filaColumns+`
+        FROM senha_filas f
+        JOIN secretarias s ON s.id = f.secretaria_id
+        WHERE `+strings.Join(clauses, " AND ")+`
+        ORDER BY f.nome`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Fila, error) {
+		f, err := scanFila(row)
+		if err != nil {
+			return Fila{}, err
+		}
+		return *f, nil
+	})
+}
+
+// GetFila busca a fila do tenant.
+func (r *Repository) GetFila(ctx context.Context, tenantID, id uuid.UUID) (*Fila, error) {
+	return scanFila(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+filaColumns+`
+        FROM senha_filas f
+        JOIN secretarias s ON s.id = f.secretaria_id
+        WHERE f.tenant_id = $1 AND f.id = $2
+    `, tenantID, id))
+}
+
+// CreateFila cadastra a fila; a entrada já deve estar normalizada.
+func (r *Repository) CreateFila(ctx context.Context, tenantID uuid.UUID, in FilaInput) (*Fila, error) {
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO senha_filas (tenant_id, secretaria_id, nome, prefixo, local, ativa)
+        SELECT $1, s.id, $3, $4, $5, $6 FROM secretarias s WHERE s.id = $2 AND s.tenant_id = $1
+        RETURNING id
+    `, tenantID, in.SecretariaID, in.Nome, in.Prefixo, in.Local, in.Ativa).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSecretaria
+	}
+	if err := filaError(err); err != nil {
+		return nil, err
+	}
+	return r.GetFila(ctx, tenantID, id)
+}
+
+// UpdateFila altera nome, prefixo, local e situação; as senhas já emitidas mantêm o código.
+func (r *Repository) UpdateFila(ctx context.Context, tenantID, id uuid.UUID, in FilaInput) (*Fila, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE senha_filas
+        SET nome = $3, prefixo = $4, local = $5, ativa = $6, updated_at = now()
+        WHERE tenant_id = $1 AND id = $2
+    `, tenantID, id, in.Nome, in.Prefixo, in.Local, in.Ativa)
+	if err := filaError(err); err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrFilaNotFound
+	}
+	return r.GetFila(ctx, tenantID, id)
+}
+
+// Emitir retira a próxima senha do dia na fila. O contador por fila e dia serializa a numeração;
+// o índice único impede o mesmo cidadão de aguardar duas vezes na fila.
+func (r *Repository) Emitir(ctx context.Context, tenantID uuid.UUID, in EmissaoInput) (*Senha, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		prefixo string
+		ativa   bool
+	)
+	err = tx.QueryRow(ctx, `
+        SELECT prefixo, ativa FROM senha_filas WHERE tenant_id = $1 AND id = $2 FOR SHARE
+    `, tenantID, in.FilaID).Scan(&prefixo, &ativa)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFilaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ativa {
+		return nil, ErrFilaFechada
+	}
+
+	var (
+		dia    time.Time
+		numero int
+	)
+	err = tx.QueryRow(ctx, `
+        INSERT INTO senha_contadores (fila_id, dia, ultimo) VALUES ($1, `+hoje+`, 1)
+        ON CONFLICT (fila_id, dia) DO UPDATE SET ultimo = senha_contadores.ultimo + 1
+        RETURNING dia, ultimo
+    `, in.FilaID).Scan(&dia, &numero)
+	if err != nil {
+		return nil, err
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `
+        INSERT INTO senhas (tenant_id, fila_id, dia, numero, codigo, preferencial, origem, cidadao_id, totem_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id
+    `, tenantID, in.FilaID, dia, numero, Codigo(prefixo, numero), in.Preferencial, in.Origem, in.CidadaoID, in.TotemID).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_senhas_cidadao_ativa" {
+		return nil, ErrSenhaAtiva
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Get busca a senha do tenant; aguardando, vem com posição e espera estimada.
+func (r *Repository) Get(ctx context.Context, tenantID, id uuid.UUID) (*Senha, error) {
+	s, err := scanSenha(r.conn(ctx).QueryRow(ctx, `
+        SELECT `+senhaColumns+`
+        FROM senhas sn
+        JOIN senha_filas f ON f.id = sn.fila_id
+        WHERE sn.tenant_id = $1 AND sn.id = $2
+    `, tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.estimar(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetDoCidadao busca a senha retirada pelo próprio cidadão.
+func (r *Repository) GetDoCidadao(ctx context.Context, tenantID, cidadaoID, id uuid.UUID) (*Senha, error) {
+	s, err := r.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.CidadaoID == nil || *s.CidadaoID != cidadaoID {
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+// ListDoCidadao lista as senhas mais recentes do cidadão.
+func (r *Repository) ListDoCidadao(ctx context.Context, tenantID, cidadaoID uuid.UUID) ([]Senha, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+senhaColumns+`
+        FROM senhas sn
+        JOIN senha_filas f ON f.id = sn.fila_id
+        WHERE sn.tenant_id = $1 AND sn.cidadao_id = $2
+        ORDER BY sn.emitida_em DESC
+        LIMIT 20
+    `, tenantID, cidadaoID)
+	if err != nil {
+		return nil, err
+	}
+	senhas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Senha, error) {
+		s, err := scanSenha(row)
+		if err != nil {
+			return Senha{}, err
+		}
+		return *s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range senhas {
+		if err := r.estimar(ctx, &senhas[i]); err != nil {
+			return nil, err
+		}
+	}
+	return senhas, nil
+}
+
+// Cancelar desiste da senha enquanto ela ainda aguarda chamada.
+func (r *Repository) Cancelar(ctx context.Context, tenantID, cidadaoID, id uuid.UUID) (*Senha, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE senhas SET status = 'cancelada', finalizada_em = now()
+        WHERE tenant_id = $1 AND id = $2 AND cidadao_id = $3 AND status = 'aguardando'
+    `, tenantID, id, cidadaoID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.GetDoCidadao(ctx, tenantID, cidadaoID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrSituacao
+	}
+	return r.GetDoCidadao(ctx, tenantID, cidadaoID, id)
+}
+
+// ChamarProxima entrega ao guichê a próxima senha do dia nas filas indicadas: preferenciais
+// primeiro, depois por ordem de emissão. SKIP LOCKED evita que dois guichês chamem a mesma senha.
+func (r *Repository) ChamarProxima(ctx context.Context, tenantID, atendenteID uuid.UUID, filaIDs []uuid.UUID, guiche string) (*Senha, error) {
+	var atendidas int
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT count(*)
+        FROM senha_filas f
+        WHERE f.tenant_id = $1 AND f.id = ANY($2)
+          AND EXISTS (SELECT 1 FROM usuarios_secretarias us WHERE us.secretaria_id = f.secretaria_id AND us.usuario_id = $3)
+    `, tenantID, filaIDs, atendenteID).Scan(&atendidas)
+	if err != nil {
+		return nil, err
+	}
+	if atendidas != len(filaIDs) {
+		return nil, ErrAtendente
+	}
+
+	var id uuid.UUID
+	err = r.conn(ctx).QueryRow(ctx, `
+        UPDATE senhas
+        SET status = 'chamada', guiche = $4, atendente_id = $3, chamadas = 1, chamada_em = now(), ultima_chamada_em = now()
+        WHERE status = 'aguardando' AND id = (
+            SELECT id FROM senhas
+            WHERE tenant_id = $1 AND fila_id = ANY($2) AND dia = `+hoje+` AND status = 'aguardando'
+            ORDER BY preferencial DESC, emitida_em, numero
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id
+    `, tenantID, filaIDs, atendenteID, guiche).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFilaVazia
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Rechamar repete a chamada no painel; só o atendente que chamou pode repetir.
+func (r *Repository) Rechamar(ctx context.Context, tenantID, atendenteID, id uuid.UUID) (*Senha, error) {
+	return r.transicao(ctx, tenantID, id, `
+        UPDATE senhas SET chamadas = chamadas + 1, ultima_chamada_em = now()
+        WHERE tenant_id = $1 AND id = $2 AND atendente_id = $3 AND status = 'chamada'
+    `, atendenteID)
+}
+
+// Finalizar encerra a senha chamada como atendida ou ausente.
+func (r *Repository) Finalizar(ctx context.Context, tenantID, atendenteID, id uuid.UUID, status string) (*Senha, error) {
+	if status != StatusAtendida && status != StatusAusente {
+		return nil, ErrSituacao
+	}
+	return r.transicao(ctx, tenantID, id, `
+        UPDATE senhas SET status = $4, finalizada_em = now()
+        WHERE tenant_id = $1 AND id = $2 AND atendente_id = $3 AND status = 'chamada'
+    `, atendenteID, status)
+}
+
+func (r *Repository) transicao(ctx context.Context, tenantID, id uuid.UUID, query string, args ...any) (*Senha, error) {
+	tag, err := r.conn(ctx).Exec(ctx, query, append([]any{tenantID, id}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.Get(ctx, tenantID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrSituacao
+	}
+	return r.Get(ctx, tenantID, id)
+}
+
+// Painel devolve as últimas chamadas do dia, a mais recente primeiro.
+func (r *Repository) Painel(ctx context.Context, tenantID uuid.UUID, filaID *uuid.UUID) ([]Chamada, error) {
+	args := []any{tenantID}
+	filtro := ""
+	if filaID != nil {
+		args = append(args, *filaID)
+		filtro = "AND sn.fila_id = $2"
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT sn.codigo, f.nome, sn.guiche, sn.preferencial, sn.ultima_chamada_em
+        FROM senhas sn
+        JOIN senha_filas f ON f.id = sn.fila_id
+        WHERE sn.tenant_id = $1 AND sn.dia = `+hoje+` AND sn.ultima_chamada_em IS NOT NULL `+filtro+`
+        ORDER BY sn.ultima_chamada_em DESC
+        LIMIT 10
+    `, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Chamada, error) {
+		var c Chamada
+		err := row.Scan(&c.Codigo, &c.Fila, &c.Guiche, &c.Preferencial, &c.UltimaChamadaEm)
+		return c, err
+	})
+}
+
+// Metricas agrega o atendimento de cada fila entre from (inclusive) e to (exclusive).
+func (r *Repository) Metricas(ctx context.Context, tenantID uuid.UUID, secretariaID *uuid.UUID, from, to time.Time) ([]Metricas, error) {
+	args := []any{tenantID, from, to}
+	filtro := ""
+	if secretariaID != nil {
+		args = append(args, *secretariaID)
+		filtro = "AND f.secretaria_id = $4"
+	}
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT f.id, f.nome,
+               count(sn.id)::int,
+               (count(*) FILTER (WHERE sn.status = 'atendida'))::int,
+               (count(*) FILTER (WHERE sn.status = 'ausente'))::int,
+               (count(*) FILTER (WHERE sn.status = 'cancelada'))::int,
+               (count(*) FILTER (WHERE sn.preferencial))::int,
+               (avg(EXTRACT(EPOCH FROM sn.chamada_em - sn.emitida_em)) / 60)::float8,
+               (percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM sn.chamada_em - sn.emitida_em)) / 60)::float8,
+               (avg(EXTRACT(EPOCH FROM sn.finalizada_em - sn.chamada_em)) FILTER (WHERE sn.status = 'atendida') / 60)::float8
+        FROM senha_filas f
+        LEFT JOIN senhas sn ON sn.fila_id = f.id AND sn.emitida_em >= $2 AND sn.emitida_em < $3
+        WHERE f.tenant_id = $1 `+filtro+`
+        GROUP BY f.id, f.nome
+        ORDER BY f.nome
+    `, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Metricas, error) {
+		var m Metricas
+		err := row.Scan(&m.FilaID, &m.Fila, &m.Emitidas, &m.Atendidas, &m.Ausentes, &m.Canceladas, &m.Preferenciais,
+			&m.EsperaMediaMin, &m.EsperaP90Min, &m.AtendimentoMedioMin)
+		return m, err
+	})
+}
+
+// estimar preenche posição e espera da senha que ainda aguarda, com o tempo médio de atendimento
+// da fila no dia e os guichês ativos na última hora.
+func (r *Repository) estimar(ctx context.Context, s *Senha) error {
+	if s.Status != StatusAguardando {
+		return nil
+	}
+	var (
+		posicao int
+		media   float64
+		guiches int
+	)
+	err := r.conn(ctx).QueryRow(ctx, `
+        WITH alvo AS (SELECT fila_id, dia, preferencial, emitida_em, numero FROM senhas WHERE id = $1)
+        SELECT
+            (SELECT count(*) FROM senhas o, alvo a
+             WHERE o.fila_id = a.fila_id AND o.dia = a.dia AND o.status = 'aguardando'
+               AND ((o.preferencial AND NOT a.preferencial)
+                    OR (o.preferencial = a.preferencial AND (o.emitida_em, o.numero) < (a.emitida_em, a.numero))))::int + 1,
+            (SELECT COALESCE(avg(EXTRACT(EPOCH FROM o.finalizada_em - o.chamada_em)), 0)::float8 FROM senhas o, alvo a
+             WHERE o.fila_id = a.fila_id AND o.dia = a.dia AND o.status = 'atendida'),
+            (SELECT count(DISTINCT o.guiche)::int FROM senhas o, alvo a
+             WHERE o.fila_id = a.fila_id AND o.ultima_chamada_em > now() - interval '1 hour')
+    `, s.ID).Scan(&posicao, &media, &guiches)
+	if err != nil {
+		return err
+	}
+	espera := int(math.Ceil(EsperaEstimada(posicao, time.Duration(media*float64(time.Second)), guiches).Minutes()))
+	s.Posicao = &posicao
+	s.EsperaEstimadaMin = &espera
+	return nil
+}
+
+// ListTotens lista os totens da prefeitura, inclusive os revogados.
+func (r *Repository) ListTotens(ctx context.Context, tenantID uuid.UUID) ([]Totem, error) {
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+totemColumns+` FROM senha_totens WHERE tenant_id = $1 ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Totem, error) {
+		t, err := scanTotem(row)
+		if err != nil {
+			return Totem{}, err
+		}
+		return *t, nil
+	})
+}
+
+// CreateTotem registra o totem; o token em claro só é devolvido ao chamador.
+func (r *Repository) CreateTotem(ctx context.Context, tenantID uuid.UUID, nome, token string, createdBy *uuid.UUID) (*Totem, error) {
+	return scanTotem(r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO senha_totens (tenant_id, nome, token_hash, token_prefix, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING `+totemColumns,
+		tenantID, nome, HashToken(token), token[:min(len(token), 12)], createdBy))
+}
+
+// RevogarTotem desautoriza o totem; as senhas que ele emitiu continuam válidas.
+func (r *Repository) RevogarTotem(ctx context.Context, tenantID, id uuid.UUID) error {
+	tag, err := r.conn(ctx).Exec(ctx, `
+        UPDATE senha_totens SET revogado_em = now() WHERE tenant_id = $1 AND id = $2 AND revogado_em IS NULL
+    `, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TotemPorToken identifica o totem ativo e sua prefeitura, atualizando last_used_at no máximo uma
+// vez por minuto.
+func (r *Repository) TotemPorToken(ctx context.Context, token string) (tenantID, totemID uuid.UUID, err error) {
+	if strings.TrimSpace(token) == "" {
+		return uuid.Nil, uuid.Nil, ErrTotem
+	}
+	err = r.conn(ctx).QueryRow(ctx, `
+        UPDATE senha_totens
+        SET last_used_at = CASE
+            WHEN last_used_at IS NULL OR last_used_at < now() - interval '1 minute' THEN now()
+            ELSE last_used_at
+        END
+        WHERE token_hash = $1 AND revogado_em IS NULL
+        RETURNING tenant_id, id
+    `, HashToken(token)).Scan(&tenantID, &totemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, uuid.Nil, ErrTotem
+	}
+	return tenantID, totemID, err
+}
+
+func filaError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrPrefixo
+	}
+	return err
+}
+
+func scanFila(row pgx.Row) (*Fila, error) {
+	var f Fila
+	err := row.Scan(&f.ID, &f.TenantID, &f.SecretariaID, &f.Secretaria, &f.Nome, &f.Prefixo, &f.Local, &f.Ativa,
+		&f.Aguardando, &f.CreatedAt, &f.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFilaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func scanSenha(row pgx.Row) (*Senha, error) {
+	var s Senha
+	err := row.Scan(&s.ID, &s.TenantID, &s.FilaID, &s.Fila, &s.Local, &s.Codigo, &s.Numero, &s.Preferencial, &s.Origem,
+		&s.CidadaoID, &s.Status, &s.Guiche, &s.AtendenteID, &s.Chamadas, &s.EmitidaEm, &s.ChamadaEm,
+		&s.UltimaChamadaEm, &s.FinalizadaEm)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanTotem(row pgx.Row) (*Totem, error) {
+	var t Totem
+	err := row.Scan(&t.ID, &t.TenantID, &t.Nome, &t.CreatedAt, &t.LastUsedAt, &t.RevogadoEm, &t.CreatedBy, &t.TokenPrefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
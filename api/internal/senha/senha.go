@@ -0,0 +1,218 @@
+// Package senha organiza o atendimento presencial por senhas: o cidadão retira a senha pelo app
+// ou pelo totem da unidade, o atendente chama a próxima do seu guichê e os tempos de espera
+// alimentam os indicadores da cidade.
+package senha
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound indica senha inexistente ou de outro tenant.
+	ErrNotFound = errors.New("senha: senha não encontrada")
+	// ErrFilaNotFound indica fila inexistente ou de outro tenant.
+	ErrFilaNotFound = errors.New("senha: fila não encontrada")
+	// ErrFilaFechada indica fila desativada, que não emite novas senhas.
+	ErrFilaFechada = errors.New("senha: fila fechada para novas senhas")
+	// ErrPrefixo indica prefixo já usado por outra fila da prefeitura.
+	ErrPrefixo = errors.New("senha: prefixo já usado por outra fila")
+	// ErrSecretaria indica secretaria que não pertence à prefeitura.
+	ErrSecretaria = errors.New("senha: secretaria não pertence à prefeitura")
+	// ErrAtendente indica atendente fora da secretaria de alguma das filas do guichê.
+	ErrAtendente = errors.New("senha: usuário não atende a secretaria da fila")
+	// ErrSenhaAtiva indica cidadão que já aguarda nesta fila.
+	ErrSenhaAtiva = errors.New("senha: cidadão já tem senha ativa nesta fila")
+	// ErrFilaVazia indica que não há senha aguardando nas filas do guichê.
+	ErrFilaVazia = errors.New("senha: nenhuma senha aguardando")
+	// ErrSituacao indica operação incompatível com a situação atual da senha.
+	ErrSituacao = errors.New("senha: operação não permitida na situação atual")
+	// ErrTotem indica token de totem inválido ou revogado.
+	ErrTotem = errors.New("senha: totem não autorizado")
+)
+
+// Situações da senha. Aguardando vira chamada no guichê, que termina atendida ou ausente; o
+// cidadão pode cancelar enquanto aguarda.
+const (
+	StatusAguardando = "aguardando"
+	StatusChamada    = "chamada"
+	StatusAtendida   = "atendida"
+	StatusAusente    = "ausente"
+	StatusCancelada  = "cancelada"
+)
+
+// Origens da emissão.
+const (
+	OrigemApp   = "app"
+	OrigemTotem = "totem"
+)
+
+// atendimentoPadrao estima a espera enquanto a fila ainda não tem atendimentos no dia.
+const atendimentoPadrao = 5 * time.Minute
+
+// Fila é um serviço atendido por senha em uma unidade da secretaria.
+type Fila struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	SecretariaID uuid.UUID `json:"secretaria_id"`
+	Secretaria   string    `json:"secretaria"`
+	Nome         string    `json:"nome"`
+	Prefixo      string    `json:"prefixo"`
+	Local        *string   `json:"local,omitempty"`
+	Ativa        bool      `json:"ativa"`
+	Aguardando   int       `json:"aguardando"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FilaInput contém os campos editáveis da fila; SecretariaID só vale no cadastro.
+type FilaInput struct {
+	SecretariaID uuid.UUID
+	Nome         string
+	Prefixo      string
+	Local        *string
+	Ativa        bool
+}
+
+// Normalize limpa e valida a entrada. O prefixo tem de uma a três letras e aparece no painel.
+func (in *FilaInput) Normalize() error {
+	in.Nome = strings.TrimSpace(in.Nome)
+	in.Prefixo = strings.ToUpper(strings.TrimSpace(in.Prefixo))
+	if in.Local != nil {
+		if l := strings.TrimSpace(*in.Local); l != "" {
+			in.Local = &l
+		} else {
+			in.Local = nil
+		}
+	}
+	switch {
+	case in.Nome == "":
+		return errors.New("nome obrigatório")
+	case len(in.Prefixo) < 1 || len(in.Prefixo) > 3 || strings.IndexFunc(in.Prefixo, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0:
+		return errors.New("prefixo deve ter de 1 a 3 letras sem acento")
+	}
+	return nil
+}
+
+// Senha é um lugar na fila do dia.
+type Senha struct {
+	ID                uuid.UUID  `json:"id"`
+	TenantID          uuid.UUID  `json:"tenant_id"`
+	FilaID            uuid.UUID  `json:"fila_id"`
+	Fila              string     `json:"fila"`
+	Local             *string    `json:"local,omitempty"`
+	Codigo            string     `json:"codigo"`
+	Numero            int        `json:"numero"`
+	Preferencial      bool       `json:"preferencial"`
+	Origem            string     `json:"origem"`
+	CidadaoID         *uuid.UUID `json:"cidadao_id,omitempty"`
+	Status            string     `json:"status"`
+	Guiche            *string    `json:"guiche,omitempty"`
+	AtendenteID       *uuid.UUID `json:"atendente_id,omitempty"`
+	Chamadas          int        `json:"chamadas"`
+	EmitidaEm         time.Time  `json:"emitida_em"`
+	ChamadaEm         *time.Time `json:"chamada_em,omitempty"`
+	UltimaChamadaEm   *time.Time `json:"ultima_chamada_em,omitempty"`
+	FinalizadaEm      *time.Time `json:"finalizada_em,omitempty"`
+	Posicao           *int       `json:"posicao,omitempty"`
+	EsperaEstimadaMin *int       `json:"espera_estimada_min,omitempty"`
+}
+
+// EmissaoInput descreve a retirada de senha pelo app (com cidadão) ou pelo totem (com totem).
+type EmissaoInput struct {
+	FilaID       uuid.UUID
+	Preferencial bool
+	Origem       string
+	CidadaoID    *uuid.UUID
+	TotemID      *uuid.UUID
+}
+
+// Chamada é a senha exibida no painel da unidade.
+type Chamada struct {
+	Codigo          string    `json:"codigo"`
+	Fila            string    `json:"fila"`
+	Guiche          string    `json:"guiche"`
+	Preferencial    bool      `json:"preferencial"`
+	UltimaChamadaEm time.Time `json:"ultima_chamada_em"`
+}
+
+// Metricas resume o atendimento de uma fila no período. Espera vai da emissão à primeira chamada;
+// atendimento, da primeira chamada à finalização.
+type Metricas struct {
+	FilaID              uuid.UUID `json:"fila_id"`
+	Fila                string    `json:"fila"`
+	Emitidas            int       `json:"emitidas"`
+	Atendidas           int       `json:"atendidas"`
+	Ausentes            int       `json:"ausentes"`
+	Canceladas          int       `json:"canceladas"`
+	Preferenciais       int       `json:"preferenciais"`
+	EsperaMediaMin      *float64  `json:"espera_media_min"`
+	EsperaP90Min        *float64  `json:"espera_p90_min"`
+	AtendimentoMedioMin *float64  `json:"atendimento_medio_min"`
+}
+
+// Totem é um terminal de autoatendimento autorizado a emitir senhas da prefeitura.
+type Totem struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	Nome        string     `json:"nome"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevogadoEm  *time.Time `json:"revogado_em,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	TokenPrefix string     `json:"token_prefix"`
+}
+
+// Codigo monta o código exibido no painel, como A012; a numeração reinicia a cada dia.
+func Codigo(prefixo string, numero int) string {
+	return fmt.Sprintf("%s%03d", prefixo, numero)
+}
+
+// EsperaEstimada projeta a espera de quem tem posicao-1 senhas à frente, repartidas entre os
+// guichês que chamaram na última hora.
+func EsperaEstimada(posicao int, atendimentoMedio time.Duration, guiches int) time.Duration {
+	if posicao <= 1 {
+		return 0
+	}
+	if atendimentoMedio <= 0 {
+		atendimentoMedio = atendimentoPadrao
+	}
+	if guiches < 1 {
+		guiches = 1
+	}
+	return time.Duration(math.Ceil(float64(posicao-1) / float64(guiches) * float64(atendimentoMedio)))
+}
+
+// NormalizeGuiche valida a identificação do guichê exibida no painel.
+func NormalizeGuiche(raw string) (string, error) {
+	guiche := strings.Join(strings.Fields(raw), " ")
+	if guiche == "" || len([]rune(guiche)) > 40 || strings.IndexFunc(guiche, unicode.IsControl) >= 0 {
+		return "", errors.New("guichê obrigatório, com até 40 caracteres")
+	}
+	return guiche, nil
+}
+
+// NovoTokenTotem gera o token entregue uma única vez ao totem; só o hash é gravado.
+func NovoTokenTotem() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "totem_" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken deriva o valor persistido do token do totem.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(raw)))
+	return hex.EncodeToString(sum[:])
+}
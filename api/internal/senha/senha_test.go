@@ -0,0 +1,68 @@
+package senha
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCodigo(t *testing.T) {
+	cases := map[string]struct {
+		prefixo string
+		numero  int
+	}{
+		"A001":  {"A", 1},
+		"PR042": {"PR", 42},
+		"B1234": {"B", 1234},
+	}
+	for want, c := range cases {
+		if got := Codigo(c.prefixo, c.numero); got != want {
+			t.Errorf("Codigo(%q, %d) = %q, want %q", c.prefixo, c.numero, got, want)
+		}
+	}
+}
+
+func TestEsperaEstimada(t *testing.T) {
+	cases := []struct {
+		posicao int
+		media   time.Duration
+		guiches int
+		want    time.Duration
+	}{
+		{1, 10 * time.Minute, 1, 0},
+		{2, 10 * time.Minute, 1, 10 * time.Minute},
+		{5, 10 * time.Minute, 2, 20 * time.Minute},
+		{3, 0, 0, 10 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := EsperaEstimada(c.posicao, c.media, c.guiches); got != c.want {
+			t.Errorf("EsperaEstimada(%d, %s, %d) = %s, want %s", c.posicao, c.media, c.guiches, got, c.want)
+		}
+	}
+}
+
+func TestFilaInputNormalize(t *testing.T) {
+	local := "  "
+	in := FilaInput{Nome: " Protocolo geral ", Prefixo: " pg ", Local: &local}
+	if err := in.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if in.Nome != "Protocolo geral" || in.Prefixo != "PG" || in.Local != nil {
+		t.Fatalf("entrada normalizada inesperada: %+v", in)
+	}
+	for _, prefixo := range []string{"", "ABCD", "A1", "Ç"} {
+		in := FilaInput{Nome: "Fila", Prefixo: prefixo}
+		if err := in.Normalize(); err == nil {
+			t.Errorf("prefixo %q deveria ser rejeitado", prefixo)
+		}
+	}
+}
+
+func TestNormalizeGuiche(t *testing.T) {
+	got, err := NormalizeGuiche("  Guichê   3 ")
+	if err != nil || got != "Guichê 3" {
+		t.Fatalf("NormalizeGuiche = %q, %v", got, err)
+	}
+	if _, err := NormalizeGuiche("   "); err == nil {
+		t.Fatal("guichê vazio deveria ser rejeitado")
+	}
+}
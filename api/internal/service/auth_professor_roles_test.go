@@ -20,7 +20,12 @@ type stubAuthRepo struct {
 	user         repo.Usuario
 	secretarias  []repo.SecretariaWithRole
 	professor    bool
+	gestor       bool
+	tenantAdmin  bool
 	refreshCalls int
+	policies     [][]byte
+	passkey      bool
+	refresh      *repo.TokenRefresh
 }
 
 func (s *stubAuthRepo) GetUsuarioByEmail(ctx context.Context, email string) (repo.Usuario, error) {
@@ -35,6 +40,9 @@ func (s *stubAuthRepo) ListSecretariasByUsuario(ctx context.Context, usuarioID u
 }
 
 func (s *stubAuthRepo) QueryRowContext(ctx context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "webauthn_credentials") {
+		return stubRow{value: s.passkey}
+	}
 	return stubRow{value: s.professor}
 }
 
@@ -42,11 +50,30 @@ func (s *stubAuthRepo) HasProfessorTurma(ctx context.Context, professorID uuid.U
 	return s.professor, nil
 }
 
+func (s *stubAuthRepo) HasEscolaGestor(ctx context.Context, usuarioID uuid.UUID) (bool, error) {
+	return s.gestor, nil
+}
+
+func (s *stubAuthRepo) HasTenantAdmin(ctx context.Context, usuarioID uuid.UUID) (bool, error) {
+	return s.tenantAdmin, nil
+}
+
+func (s *stubAuthRepo) HasBackofficeRole(ctx context.Context, usuarioID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (s *stubAuthRepo) ListUsuarioAuthPolicies(ctx context.Context, usuarioID uuid.UUID) ([][]byte, error) {
+	return s.policies, nil
+}
+
 func (s *stubAuthRepo) GetCidadaoByEmail(ctx context.Context, email string) (repo.Cidadao, error) {
 	return repo.Cidadao{}, repo.ErrNotFound
 }
 
 func (s *stubAuthRepo) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (repo.TokenRefresh, error) {
+	if s.refresh != nil && s.refresh.TokenHash == tokenHash {
+		return *s.refresh, nil
+	}
 	return repo.TokenRefresh{}, repo.ErrNotFound
 }
 
@@ -70,6 +97,22 @@ func (s *stubAuthRepo) GetCidadaoByID(ctx context.Context, id uuid.UUID) (repo.C
 	return repo.Cidadao{}, repo.ErrNotFound
 }
 
+func (s *stubAuthRepo) GetResponsavelByEmail(ctx context.Context, email string) (repo.Responsavel, error) {
+	return repo.Responsavel{}, repo.ErrNotFound
+}
+
+func (s *stubAuthRepo) GetResponsavelByID(ctx context.Context, id uuid.UUID) (repo.Responsavel, error) {
+	return repo.Responsavel{}, repo.ErrNotFound
+}
+
+func (s *stubAuthRepo) GetAlunoByMatricula(ctx context.Context, matricula string) (repo.Aluno, error) {
+	return repo.Aluno{}, repo.ErrNotFound
+}
+
+func (s *stubAuthRepo) GetAlunoByID(ctx context.Context, id uuid.UUID) (repo.Aluno, error) {
+	return repo.Aluno{}, repo.ErrNotFound
+}
+
 func (s *stubAuthRepo) InsertRefreshToken(ctx context.Context, arg repo.InsertRefreshTokenParams) (repo.TokenRefresh, error) {
 	s.refreshCalls++
 	return repo.TokenRefresh{
@@ -236,3 +279,128 @@ func TestLoginBackofficeRejectsWhenNoEligibleRole(t *testing.T) {
 		t.Fatalf("expected ErrNoEligibleRoles, got result=%v err=%v", result, err)
 	}
 }
+
+func TestLoginBackofficeAddsEscolaGestorRole(t *testing.T) {
+	password := "SenhaForte123!"
+	hash, err := auth.Hash(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	repoStub := &stubAuthRepo{
+		user: repo.Usuario{
+			ID:        uuid.New(),
+			Nome:      "Diretora Teste",
+			Email:     "diretora@example.com",
+			SenhaHash: hash,
+			Ativo:     true,
+		},
+		secretarias: []repo.SecretariaWithRole{{Papel: "ATENDENTE"}},
+		gestor:      true,
+	}
+
+	svc := &AuthService{
+		repo:       repoStub,
+		redis:      &stubRedis{},
+		jwt:        auth.NewJWTManager(strings.Repeat("c", 32), time.Minute),
+		refreshTTL: time.Hour,
+	}
+
+	result, err := svc.LoginBackoffice(context.Background(), "diretora@example.com", password)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if len(result.Roles) != 1 || !containsRole(result.Roles, "ESCOLA_GESTOR") {
+		t.Fatalf("expected roles to be [ESCOLA_GESTOR], got %v", result.Roles)
+	}
+}
+
+func TestLoginBackofficeMFAPendenteRestringeToken(t *testing.T) {
+	password := "SenhaForte123!"
+	hash, err := auth.Hash(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	repoStub := &stubAuthRepo{
+		user: repo.Usuario{
+			ID:        uuid.New(),
+			Nome:      "Gestor Teste",
+			Email:     "gestor@example.com",
+			SenhaHash: hash,
+			Ativo:     true,
+		},
+		gestor:   true,
+		policies: [][]byte{[]byte(`{"mfa_obrigatorio":true}`)},
+	}
+	jwtMgr := auth.NewJWTManager(strings.Repeat("a", 32), time.Minute)
+	svc := &AuthService{repo: repoStub, redis: &stubRedis{}, jwt: jwtMgr, refreshTTL: time.Hour}
+
+	result, err := svc.LoginBackoffice(context.Background(), "gestor@example.com", password)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if !result.MFAPendente {
+		t.Fatalf("expected MFAPendente")
+	}
+	claims, err := jwtMgr.ParseAndValidate(result.AccessToken)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if claims.Scope != auth.ScopeMFACadastro {
+		t.Fatalf("expected scope %q, got %q", auth.ScopeMFACadastro, claims.Scope)
+	}
+
+	repoStub.passkey = true
+	if _, err := svc.LoginBackoffice(context.Background(), "gestor@example.com", password); !errors.Is(err, ErrMFARequired) {
+		t.Fatalf("expected ErrMFARequired with passkey registered, got %v", err)
+	}
+}
+
+func TestRefreshBackofficeReaplicaPolitica(t *testing.T) {
+	userID := uuid.New()
+	raw, hash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("refresh token: %v", err)
+	}
+	repoStub := &stubAuthRepo{
+		user:   repo.Usuario{ID: userID, Nome: "Gestor Teste", Email: "gestor@example.com", Ativo: true},
+		gestor: true,
+		refresh: &repo.TokenRefresh{
+			ID:        uuid.New(),
+			Subject:   userID,
+			Audience:  "backoffice",
+			TokenHash: hash,
+			Expiracao: time.Now().Add(time.Hour),
+		},
+	}
+	redisStub := &stubRedis{}
+	svc := &AuthService{repo: repoStub, redis: redisStub, jwt: auth.NewJWTManager(strings.Repeat("a", 32), time.Minute), refreshTTL: time.Hour}
+	ctx := context.Background()
+	activate := func() {
+		redisStub.Set(ctx, auth.RefreshRedisKey("backoffice", hash), "active", time.Hour)
+	}
+
+	activate()
+	repoStub.policies = [][]byte{[]byte(`{"mfa_obrigatorio":true}`)}
+	repoStub.passkey = true
+	if _, err := svc.Refresh(ctx, "backoffice", raw); !errors.Is(err, ErrMFARequired) {
+		t.Fatalf("expected ErrMFARequired, got %v", err)
+	}
+
+	activate()
+	repoStub.policies = [][]byte{[]byte(`{"sso_obrigatorio":true,"sso_url":"https://sso.example.com"}`)}
+	var sso *SSORequiredError
+	if _, err := svc.Refresh(ctx, "backoffice", raw); !errors.As(err, &sso) {
+		t.Fatalf("expected SSORequiredError, got %v", err)
+	}
+
+	activate()
+	passkey := LoginMethodPasskey
+	repoStub.refresh.Metodo = &passkey
+	repoStub.policies = [][]byte{[]byte(`{"mfa_obrigatorio":true}`)}
+	if _, err := svc.Refresh(ctx, "backoffice", raw); err != nil {
+		t.Fatalf("expected passkey session to refresh, got %v", err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/gestaozabele/municipio/internal/auth"
+	"github.com/gestaozabele/municipio/internal/authz"
 	"github.com/gestaozabele/municipio/internal/repo"
 	"github.com/gestaozabele/municipio/internal/saas"
 	"github.com/gestaozabele/municipio/internal/util"
@@ -27,6 +28,23 @@ var (
 	ErrRefreshInvalid = errors.New("refresh token inválido")
 	// ErrNoEligibleRoles indica ausência de papéis autorizados.
 	ErrNoEligibleRoles = errors.New("usuário sem papel elegível")
+	// ErrMFARequired indica que a prefeitura exige biometria e o usuário tentou entrar só com senha.
+	ErrMFARequired = errors.New("a prefeitura exige login com biometria")
+)
+
+// SSORequiredError indica que a prefeitura só aceita login pelo seu provedor de identidade.
+type SSORequiredError struct {
+	URL string
+}
+
+func (e *SSORequiredError) Error() string {
+	return "a prefeitura exige login pelo provedor de identidade"
+}
+
+// Métodos de login do backoffice, conferidos contra a política das prefeituras.
+const (
+	LoginMethodPassword = "senha"
+	LoginMethodPasskey  = "passkey"
 )
 
 type authRepository interface {
@@ -34,10 +52,18 @@ type authRepository interface {
 	ListSecretariasByUsuario(ctx context.Context, usuarioID uuid.UUID) ([]repo.SecretariaWithRole, error)
 	QueryRowContext(ctx context.Context, sql string, args ...any) pgx.Row
 	HasProfessorTurma(ctx context.Context, professorID uuid.UUID) (bool, error)
+	HasEscolaGestor(ctx context.Context, usuarioID uuid.UUID) (bool, error)
+	HasTenantAdmin(ctx context.Context, usuarioID uuid.UUID) (bool, error)
+	HasBackofficeRole(ctx context.Context, usuarioID uuid.UUID) (bool, error)
+	ListUsuarioAuthPolicies(ctx context.Context, usuarioID uuid.UUID) ([][]byte, error)
 	GetCidadaoByEmail(ctx context.Context, email string) (repo.Cidadao, error)
 	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (repo.TokenRefresh, error)
 	GetUsuarioByID(ctx context.Context, id uuid.UUID) (repo.Usuario, error)
 	GetCidadaoByID(ctx context.Context, id uuid.UUID) (repo.Cidadao, error)
+	GetResponsavelByEmail(ctx context.Context, email string) (repo.Responsavel, error)
+	GetResponsavelByID(ctx context.Context, id uuid.UUID) (repo.Responsavel, error)
+	GetAlunoByMatricula(ctx context.Context, matricula string) (repo.Aluno, error)
+	GetAlunoByID(ctx context.Context, id uuid.UUID) (repo.Aluno, error)
 	InsertRefreshToken(ctx context.Context, arg repo.InsertRefreshTokenParams) (repo.TokenRefresh, error)
 	InvalidateOtherRefreshTokens(ctx context.Context, subject uuid.UUID, audience, keepHash string) error
 	RevokeRefreshToken(ctx context.Context, tokenHash string) error
@@ -58,6 +84,12 @@ type AuthService struct {
 	jwt        *auth.JWTManager
 	refreshTTL time.Duration
 	pool       *pgxpool.Pool
+	clusters   clusterVisitor
+}
+
+// clusterVisitor percorre os clusters de banco das prefeituras (db.Resolver.EachCluster).
+type clusterVisitor interface {
+	EachCluster(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // NewAuthService cria novo serviço.
@@ -65,6 +97,46 @@ func NewAuthService(r *repo.Queries, saasRepo *saas.Repository, pool *pgxpool.Po
 	return &AuthService{repo: r, saasRepo: saasRepo, pool: pool, redis: redisClient, jwt: jwtMgr, refreshTTL: refreshTTL}
 }
 
+// UseClusters faz o login e o refresh de responsáveis e alunos procurá-los em todos os clusters:
+// os de uma prefeitura movida, e os cadastrados depois da mudança, só existem no banco dela.
+func (s *AuthService) UseClusters(clusters clusterVisitor) {
+	s.clusters = clusters
+}
+
+// primeiroNosClusters devolve o primeiro resultado de fn entre os clusters, na ordem de
+// EachCluster; repo.ErrNotFound só sai quando nenhum cluster tem o registro. Sem clusters
+// configurados, fn roda uma vez no pool padrão.
+func primeiroNosClusters[T any](ctx context.Context, clusters clusterVisitor, fn func(ctx context.Context) (T, error)) (T, error) {
+	if clusters == nil {
+		return fn(ctx)
+	}
+	var (
+		found T
+		ok    bool
+	)
+	err := clusters.EachCluster(ctx, func(ctx context.Context) error {
+		if ok {
+			return nil
+		}
+		item, err := fn(ctx)
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found, ok = item, true
+		return nil
+	})
+	if ok {
+		return found, nil
+	}
+	if err != nil {
+		return found, err
+	}
+	return found, repo.ErrNotFound
+}
+
 // JWT expõe gerenciador de JWT (útil em middlewares).
 func (s *AuthService) JWT() *auth.JWTManager {
 	return s.jwt
@@ -80,6 +152,9 @@ type LoginResult struct {
 	Profile       any
 	RefreshHash   string
 	RefreshExpiry time.Time
+	// MFAPendente sinaliza login por senha liberado só para cadastrar a biometria exigida; o token
+	// de acesso sai com auth.ScopeMFACadastro e o middleware MFAEnrollment barra as demais rotas.
+	MFAPendente bool
 }
 
 type PasskeyCredential struct {
@@ -119,6 +194,23 @@ type CidadaoProfile struct {
 	Email *string `json:"email"`
 }
 
+// ResponsavelProfile descreve familiar no portal dos alunos.
+type ResponsavelProfile struct {
+	ID       string  `json:"id"`
+	Nome     string  `json:"nome"`
+	Email    string  `json:"email"`
+	Telefone *string `json:"telefone,omitempty"`
+	TenantID string  `json:"tenant_id"`
+}
+
+// AlunoProfile descreve estudante nas avaliações online.
+type AlunoProfile struct {
+	ID        string `json:"id"`
+	Nome      string `json:"nome"`
+	Matricula string `json:"matricula"`
+	TenantID  string `json:"tenant_id"`
+}
+
 // SaaSProfile descreve administradores do SaaS.
 type SaaSProfile struct {
 	ID    string `json:"id"`
@@ -148,18 +240,24 @@ func (s *AuthService) LoginBackoffice(ctx context.Context, email, password strin
 		return nil, ErrInvalidCredentials
 	}
 
-	return s.loginBackofficeFromUser(ctx, user)
+	return s.loginBackofficeFromUser(ctx, user, LoginMethodPassword)
 }
 
+// LoginBackofficeWithUser conclui o login por biometria, já validada pelo WebAuthn.
 func (s *AuthService) LoginBackofficeWithUser(ctx context.Context, user repo.Usuario) (*LoginResult, error) {
-	return s.loginBackofficeFromUser(ctx, user)
+	return s.loginBackofficeFromUser(ctx, user, LoginMethodPasskey)
 }
 
-func (s *AuthService) loginBackofficeFromUser(ctx context.Context, user repo.Usuario) (*LoginResult, error) {
+func (s *AuthService) loginBackofficeFromUser(ctx context.Context, user repo.Usuario, method string) (*LoginResult, error) {
 	if !user.Ativo {
 		return nil, ErrAccountDisabled
 	}
 
+	policy, mfaPendente, err := s.checkBackofficePolicy(ctx, user.ID, method)
+	if err != nil {
+		return nil, err
+	}
+
 	secretarias, err := s.repo.ListSecretariasByUsuario(ctx, user.ID)
 	if err != nil {
 		return nil, err
@@ -174,15 +272,30 @@ func (s *AuthService) loginBackofficeFromUser(ctx context.Context, user repo.Usu
 	if isProf {
 		roles = appendIfMissing(roles, "PROFESSOR")
 	}
+	if gestor, err := s.repo.HasEscolaGestor(ctx, user.ID); err != nil {
+		return nil, err
+	} else if gestor {
+		roles = appendIfMissing(roles, "ESCOLA_GESTOR")
+	}
+	if tenantAdmin, err := s.repo.HasTenantAdmin(ctx, user.ID); err != nil {
+		return nil, err
+	} else if tenantAdmin {
+		roles = appendIfMissing(roles, "TENANT_ADMIN")
+	}
+	if custom, err := s.repo.HasBackofficeRole(ctx, user.ID); err != nil {
+		return nil, err
+	} else if custom {
+		roles = appendIfMissing(roles, authz.RoleMarker)
+	}
 	roles = normalizeRoles(roles)
-	if hasRole(roles, "PROFESSOR") {
+	if hasRole(roles, "PROFESSOR") || hasRole(roles, "ESCOLA_GESTOR") {
 		roles = removeRole(roles, "ATENDENTE")
 	}
 	if len(roles) == 0 {
 		return nil, ErrNoEligibleRoles
 	}
 
-	token, _, err := s.jwt.GenerateAccessToken(user.ID.String(), "backoffice", roles)
+	token, _, err := s.jwt.GenerateScopedAccessToken(user.ID.String(), "backoffice", roles, backofficeScope(mfaPendente))
 	if err != nil {
 		return nil, err
 	}
@@ -192,8 +305,8 @@ func (s *AuthService) loginBackofficeFromUser(ctx context.Context, user repo.Usu
 		return nil, err
 	}
 
-	expires := util.Now().Add(s.refreshTTL)
-	if err := s.persistRefresh(ctx, user.ID, "backoffice", refreshHash, expires); err != nil {
+	expires := util.Now().Add(policy.SessionTTL(s.refreshTTL))
+	if err := s.persistSession(ctx, user.ID, "backoffice", method, refreshHash, expires); err != nil {
 		return nil, err
 	}
 
@@ -211,6 +324,8 @@ func (s *AuthService) loginBackofficeFromUser(ctx context.Context, user repo.Usu
 		})
 	}
 
+	s.recordBackofficeLogin(ctx, user.ID)
+
 	return &LoginResult{
 		Audience:      "backoffice",
 		AccessToken:   token,
@@ -220,9 +335,60 @@ func (s *AuthService) loginBackofficeFromUser(ctx context.Context, user repo.Usu
 		Profile:       profile,
 		RefreshHash:   refreshHash,
 		RefreshExpiry: expires,
+		MFAPendente:   mfaPendente,
 	}, nil
 }
 
+// checkBackofficePolicy aplica a política das prefeituras a uma sessão aberta por method, no login
+// e em cada renovação. Com MFA exigido, sessão por senha só continua enquanto o usuário não tem
+// passkey, e restrita ao cadastro dela (mfaPendente).
+func (s *AuthService) checkBackofficePolicy(ctx context.Context, userID uuid.UUID, method string) (auth.Policy, bool, error) {
+	policy, err := s.BackofficePolicy(ctx, userID)
+	if err != nil {
+		return policy, false, err
+	}
+	if policy.SSOObrigatorio {
+		return policy, false, &SSORequiredError{URL: policy.SSOURL}
+	}
+	if !policy.MFAObrigatorio || method == LoginMethodPasskey {
+		return policy, false, nil
+	}
+	var hasPasskey bool
+	if err := s.repo.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM webauthn_credentials WHERE usuario_id=$1)`, userID).Scan(&hasPasskey); err != nil {
+		return policy, false, err
+	}
+	if hasPasskey {
+		return policy, false, ErrMFARequired
+	}
+	return policy, true, nil
+}
+
+func backofficeScope(mfaPendente bool) string {
+	if mfaPendente {
+		return auth.ScopeMFACadastro
+	}
+	return ""
+}
+
+// BackofficePolicy combina as políticas de autenticação das prefeituras em que o usuário atua.
+// Política ilegível é ignorada com aviso para não trancar a equipe por um JSON malformado.
+func (s *AuthService) BackofficePolicy(ctx context.Context, userID uuid.UUID) (auth.Policy, error) {
+	raws, err := s.repo.ListUsuarioAuthPolicies(ctx, userID)
+	if err != nil {
+		return auth.Policy{}, err
+	}
+	var policy auth.Policy
+	for _, raw := range raws {
+		p, err := auth.ParsePolicy(raw)
+		if err != nil {
+			log.Warn().Err(err).Str("usuario_id", userID.String()).Msg("auth: política de autenticação inválida")
+			continue
+		}
+		policy = policy.Merge(p)
+	}
+	return policy, nil
+}
+
 func (s *AuthService) GetUsuarioByID(ctx context.Context, id uuid.UUID) (repo.Usuario, error) {
 	return s.repo.GetUsuarioByID(ctx, id)
 }
@@ -398,6 +564,151 @@ func (s *AuthService) LoginCidadao(ctx context.Context, email, password string)
 	}, nil
 }
 
+// LoginResponsavel autentica familiares no portal dos alunos.
+func (s *AuthService) LoginResponsavel(ctx context.Context, email, password string) (*LoginResult, error) {
+	responsavel, err := primeiroNosClusters(ctx, s.clusters, func(ctx context.Context) (repo.Responsavel, error) {
+		return s.repo.GetResponsavelByEmail(ctx, strings.TrimSpace(email))
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			log.Warn().Msg("login responsável: usuário não encontrado")
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !responsavel.Ativo {
+		return nil, ErrAccountDisabled
+	}
+	if responsavel.SenhaHash == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, err := auth.Verify(password, *responsavel.SenhaHash)
+	if err != nil {
+		log.Warn().Err(err).Msg("login responsável: verify password failed")
+		return nil, ErrInvalidCredentials
+	}
+	if !ok {
+		log.Warn().Msg("login responsável: senha inválida")
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.responsavelSession(ctx, responsavel)
+}
+
+// responsavelSession emite tokens de acesso e refresh para o familiar.
+func (s *AuthService) responsavelSession(ctx context.Context, responsavel repo.Responsavel) (*LoginResult, error) {
+	const audience = "responsavel"
+	roles := []string{"RESPONSAVEL"}
+	token, _, err := s.jwt.GenerateAccessToken(responsavel.ID.String(), audience, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expires := util.Now().Add(s.refreshTTL)
+	if err := s.persistRefresh(ctx, responsavel.ID, audience, refreshHash, expires); err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		Audience:      audience,
+		AccessToken:   token,
+		RefreshToken:  rawRefresh,
+		Subject:       responsavel.ID,
+		Roles:         roles,
+		Profile:       responsavelProfile(responsavel),
+		RefreshHash:   refreshHash,
+		RefreshExpiry: expires,
+	}, nil
+}
+
+func responsavelProfile(responsavel repo.Responsavel) *ResponsavelProfile {
+	return &ResponsavelProfile{
+		ID:       responsavel.ID.String(),
+		Nome:     responsavel.Nome,
+		Email:    responsavel.Email,
+		Telefone: responsavel.Telefone,
+		TenantID: responsavel.TenantID.String(),
+	}
+}
+
+// LoginAluno autentica estudantes pela matrícula. Só entra quem tem senha definida pela gestão e
+// matrícula ativa.
+func (s *AuthService) LoginAluno(ctx context.Context, matricula, password string) (*LoginResult, error) {
+	aluno, err := primeiroNosClusters(ctx, s.clusters, func(ctx context.Context) (repo.Aluno, error) {
+		return s.repo.GetAlunoByMatricula(ctx, strings.TrimSpace(matricula))
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			log.Warn().Msg("login aluno: matrícula não encontrada")
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if aluno.SenhaHash == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, err := auth.Verify(password, *aluno.SenhaHash)
+	if err != nil {
+		log.Warn().Err(err).Msg("login aluno: verify password failed")
+		return nil, ErrInvalidCredentials
+	}
+	if !ok {
+		log.Warn().Msg("login aluno: senha inválida")
+		return nil, ErrInvalidCredentials
+	}
+	if aluno.TenantID == nil {
+		return nil, ErrAccountDisabled
+	}
+
+	return s.alunoSession(ctx, aluno)
+}
+
+// alunoSession emite tokens de acesso e refresh para o estudante.
+func (s *AuthService) alunoSession(ctx context.Context, aluno repo.Aluno) (*LoginResult, error) {
+	const audience = "aluno"
+	roles := []string{"ALUNO"}
+	token, _, err := s.jwt.GenerateAccessToken(aluno.ID.String(), audience, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expires := util.Now().Add(s.refreshTTL)
+	if err := s.persistRefresh(ctx, aluno.ID, audience, refreshHash, expires); err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		Audience:      audience,
+		AccessToken:   token,
+		RefreshToken:  rawRefresh,
+		Subject:       aluno.ID,
+		Roles:         roles,
+		Profile:       alunoProfile(aluno),
+		RefreshHash:   refreshHash,
+		RefreshExpiry: expires,
+	}, nil
+}
+
+func alunoProfile(aluno repo.Aluno) *AlunoProfile {
+	profile := &AlunoProfile{ID: aluno.ID.String(), Nome: aluno.Nome, Matricula: aluno.Matricula}
+	if aluno.TenantID != nil {
+		profile.TenantID = aluno.TenantID.String()
+	}
+	return profile
+}
+
 // LoginSaaS autentica administradores da plataforma.
 func (s *AuthService) LoginSaaS(ctx context.Context, email, password string) (*LoginResult, error) {
 	if s.saasRepo == nil {
@@ -476,6 +787,15 @@ func (s *AuthService) recordSaaSLogin(ctx context.Context, userID uuid.UUID) {
 	}
 }
 
+func (s *AuthService) recordBackofficeLogin(ctx context.Context, userID uuid.UUID) {
+	if s.pool == nil {
+		return
+	}
+	if _, err := s.pool.Exec(ctx, `UPDATE usuarios SET ultimo_login_em = now() WHERE id = $1`, userID); err != nil {
+		log.Warn().Err(err).Msg("login backoffice: failed to record last access")
+	}
+}
+
 func saasClaimsFromRole(role string) []string {
 	normalized := saas.NormalizeRole(role)
 	claims := []string{"SAAS_USER"}
@@ -488,6 +808,8 @@ func saasClaimsFromRole(role string) []string {
 		claims = append(claims, "SAAS_SUPPORT")
 	case saas.RoleFinance:
 		claims = append(claims, "SAAS_FINANCE")
+	case saas.RoleAuditor:
+		claims = append(claims, "SAAS_AUDITOR")
 	default:
 		claims = append(claims, "SAAS_ADMIN")
 	}
@@ -532,6 +854,15 @@ func (s *AuthService) Refresh(ctx context.Context, audience, rawToken string) (*
 		if err != nil {
 			return nil, err
 		}
+		// Sessões anteriores ao registro do método contam como senha.
+		method := LoginMethodPassword
+		if record.Metodo != nil {
+			method = *record.Metodo
+		}
+		policy, mfaPendente, err := s.checkBackofficePolicy(ctx, user.ID, method)
+		if err != nil {
+			return nil, err
+		}
 
 		secretarias, err := s.repo.ListSecretariasByUsuario(ctx, user.ID)
 		if err != nil {
@@ -541,8 +872,17 @@ func (s *AuthService) Refresh(ctx context.Context, audience, rawToken string) (*
 		if prof, err := s.repo.HasProfessorTurma(ctx, user.ID); err == nil && prof {
 			roles = appendIfMissing(roles, "PROFESSOR")
 		}
+		if gestor, err := s.repo.HasEscolaGestor(ctx, user.ID); err == nil && gestor {
+			roles = appendIfMissing(roles, "ESCOLA_GESTOR")
+		}
+		if tenantAdmin, err := s.repo.HasTenantAdmin(ctx, user.ID); err == nil && tenantAdmin {
+			roles = appendIfMissing(roles, "TENANT_ADMIN")
+		}
+		if custom, err := s.repo.HasBackofficeRole(ctx, user.ID); err == nil && custom {
+			roles = appendIfMissing(roles, authz.RoleMarker)
+		}
 		roles = normalizeRoles(roles)
-		if hasRole(roles, "PROFESSOR") {
+		if hasRole(roles, "PROFESSOR") || hasRole(roles, "ESCOLA_GESTOR") {
 			roles = removeRole(roles, "ATENDENTE")
 		}
 		if len(roles) == 0 {
@@ -563,7 +903,7 @@ func (s *AuthService) Refresh(ctx context.Context, audience, rawToken string) (*
 			})
 		}
 
-		token, _, err := s.jwt.GenerateAccessToken(user.ID.String(), audience, roles)
+		token, _, err := s.jwt.GenerateScopedAccessToken(user.ID.String(), audience, roles, backofficeScope(mfaPendente))
 		if err != nil {
 			return nil, err
 		}
@@ -573,8 +913,8 @@ func (s *AuthService) Refresh(ctx context.Context, audience, rawToken string) (*
 			return nil, err
 		}
 
-		expires := util.Now().Add(s.refreshTTL)
-		if err := s.persistRefresh(ctx, user.ID, audience, refreshHash, expires); err != nil {
+		expires := util.Now().Add(policy.SessionTTL(s.refreshTTL))
+		if err := s.persistSession(ctx, user.ID, audience, method, refreshHash, expires); err != nil {
 			return nil, err
 		}
 
@@ -587,6 +927,7 @@ func (s *AuthService) Refresh(ctx context.Context, audience, rawToken string) (*
 			Profile:       profile,
 			RefreshHash:   refreshHash,
 			RefreshExpiry: expires,
+			MFAPendente:   mfaPendente,
 		}
 	case "cidadao":
 		cidadao, err := s.repo.GetCidadaoByID(ctx, record.Subject)
@@ -626,6 +967,38 @@ func (s *AuthService) Refresh(ctx context.Context, audience, rawToken string) (*
 			RefreshHash:   refreshHash,
 			RefreshExpiry: expires,
 		}
+	case "responsavel":
+		responsavel, err := primeiroNosClusters(ctx, s.clusters, func(ctx context.Context) (repo.Responsavel, error) {
+			return s.repo.GetResponsavelByID(ctx, record.Subject)
+		})
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, ErrRefreshInvalid
+			}
+			return nil, err
+		}
+		if !responsavel.Ativo {
+			return nil, ErrAccountDisabled
+		}
+		if result, err = s.responsavelSession(ctx, responsavel); err != nil {
+			return nil, err
+		}
+	case "aluno":
+		aluno, err := primeiroNosClusters(ctx, s.clusters, func(ctx context.Context) (repo.Aluno, error) {
+			return s.repo.GetAlunoByID(ctx, record.Subject)
+		})
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, ErrRefreshInvalid
+			}
+			return nil, err
+		}
+		if aluno.SenhaHash == nil || aluno.TenantID == nil {
+			return nil, ErrAccountDisabled
+		}
+		if result, err = s.alunoSession(ctx, aluno); err != nil {
+			return nil, err
+		}
 	case "saas":
 		if s.saasRepo == nil {
 			return nil, ErrRefreshInvalid
@@ -737,8 +1110,17 @@ func (s *AuthService) GetMe(ctx context.Context, audience string, subject uuid.U
 		if prof, err := s.repo.HasProfessorTurma(ctx, subject); err == nil && prof {
 			roles = appendIfMissing(roles, "PROFESSOR")
 		}
+		if gestor, err := s.repo.HasEscolaGestor(ctx, subject); err == nil && gestor {
+			roles = appendIfMissing(roles, "ESCOLA_GESTOR")
+		}
+		if tenantAdmin, err := s.repo.HasTenantAdmin(ctx, subject); err == nil && tenantAdmin {
+			roles = appendIfMissing(roles, "TENANT_ADMIN")
+		}
+		if custom, err := s.repo.HasBackofficeRole(ctx, subject); err == nil && custom {
+			roles = appendIfMissing(roles, authz.RoleMarker)
+		}
 		roles = normalizeRoles(roles)
-		if hasRole(roles, "PROFESSOR") {
+		if hasRole(roles, "PROFESSOR") || hasRole(roles, "ESCOLA_GESTOR") {
 			roles = removeRole(roles, "ATENDENTE")
 		}
 		if len(roles) == 0 {
@@ -757,6 +1139,28 @@ func (s *AuthService) GetMe(ctx context.Context, audience string, subject uuid.U
 			Email: cidadao.Email,
 		}
 		return profile, []string{"CIDADAO"}, nil
+	case "responsavel":
+		responsavel, err := primeiroNosClusters(ctx, s.clusters, func(ctx context.Context) (repo.Responsavel, error) {
+			return s.repo.GetResponsavelByID(ctx, subject)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if !responsavel.Ativo {
+			return nil, nil, ErrNoEligibleRoles
+		}
+		return responsavelProfile(responsavel), []string{"RESPONSAVEL"}, nil
+	case "aluno":
+		aluno, err := primeiroNosClusters(ctx, s.clusters, func(ctx context.Context) (repo.Aluno, error) {
+			return s.repo.GetAlunoByID(ctx, subject)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if aluno.SenhaHash == nil || aluno.TenantID == nil {
+			return nil, nil, ErrNoEligibleRoles
+		}
+		return alunoProfile(aluno), []string{"ALUNO"}, nil
 	case "saas":
 		if s.saasRepo == nil {
 			return nil, nil, errors.New("saas repository não configurado")
@@ -783,6 +1187,12 @@ func (s *AuthService) GetMe(ctx context.Context, audience string, subject uuid.U
 }
 
 func (s *AuthService) persistRefresh(ctx context.Context, subject uuid.UUID, audience, hash string, expires time.Time) error {
+	return s.persistSession(ctx, subject, audience, "", hash, expires)
+}
+
+// persistSession grava o refresh token com o método de login, que a renovação usa para reaplicar
+// a política do backoffice.
+func (s *AuthService) persistSession(ctx context.Context, subject uuid.UUID, audience, method, hash string, expires time.Time) error {
 	_, err := s.repo.InsertRefreshToken(ctx, repo.InsertRefreshTokenParams{
 		ID:        uuid.New(),
 		Subject:   subject,
@@ -790,6 +1200,7 @@ func (s *AuthService) persistRefresh(ctx context.Context, subject uuid.UUID, aud
 		TokenHash: hash,
 		Expiracao: expires,
 		CriadoEm:  util.Now(),
+		Metodo:    method,
 	})
 	if err != nil {
 		return err
@@ -69,8 +69,8 @@ func (s *SaaSUserService) UpdateUser(ctx context.Context, input saas.UpdateUserI
 }
 
 // DeleteUser remove definitivamente o usuário.
-func (s *SaaSUserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+func (s *SaaSUserService) DeleteUser(ctx context.Context, id uuid.UUID, deletedBy *uuid.UUID) error {
+	return s.repo.Delete(ctx, id, deletedBy)
 }
 
 // ListInvites retorna convites com filtro opcional.
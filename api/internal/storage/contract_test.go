@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/fakes"
+)
+
+// objectStore é o que o contrato exige de todo provedor: gravar, ler, remover e assinar links.
+type objectStore interface {
+	Uploader
+	Downloader
+	Deleter
+}
+
+// runUploaderContract exercita o provedor contra o backend informado; fetch baixa uma URL
+// assinada e devolve status e corpo.
+func runUploaderContract(t *testing.T, u objectStore, fetch func(string) (int, []byte)) {
+	t.Helper()
+	ctx := context.Background()
+	key := "contracts/t1/contrato assinado.pdf"
+	body := []byte("%PDF-1.4 conteúdo")
+
+	res, err := u.Upload(ctx, UploadInput{Key: key, Body: body, ContentType: "application/pdf"})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.URL == "" || res.ETag == "" {
+		t.Fatalf("resultado incompleto: %+v", res)
+	}
+	if _, err := u.Upload(ctx, UploadInput{Key: key}); err == nil {
+		t.Fatal("corpo vazio deveria ser recusado")
+	}
+
+	streamKey := "backups/schema/public/dump.dump"
+	sum := sha256.Sum256(body)
+	if _, err := u.Upload(ctx, UploadInput{Key: streamKey, Stream: bytes.NewReader(body), Size: int64(len(body)), SHA256: hex.EncodeToString(sum[:])}); err != nil {
+		t.Fatalf("upload em stream: %v", err)
+	}
+	if got, err := u.Download(ctx, streamKey); err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("download do stream = %q, %v", got, err)
+	}
+	if err := u.Delete(ctx, streamKey); err != nil {
+		t.Fatalf("remoção do stream: %v", err)
+	}
+
+	got, err := u.Download(ctx, key)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("download = %q, %v", got, err)
+	}
+
+	signed, err := u.PresignGet(key, time.Minute)
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	if status, got := fetch(signed); status != http.StatusOK || !bytes.Equal(got, body) {
+		t.Fatalf("link assinado = %d %q", status, got)
+	}
+
+	if err := u.Delete(ctx, key); err != nil {
+		t.Fatalf("remoção: %v", err)
+	}
+	if err := u.Delete(ctx, key); err != nil {
+		t.Fatalf("remoção repetida deveria ser aceita: %v", err)
+	}
+	if _, err := u.Download(ctx, key); err == nil {
+		t.Fatal("download após remoção deveria falhar")
+	}
+}
+
+func httpFetch(target string) (int, []byte) {
+	resp, err := http.Get(target)
+	if err != nil {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+func TestUploaderContractS3(t *testing.T) {
+	fake := fakes.NewObjectStore("anexos", "AKIDTESTE")
+	defer fake.Close()
+	u, err := NewS3Uploader(S3Config{Endpoint: fake.URL, Region: "auto", Bucket: "anexos", AccessKey: "AKIDTESTE", SecretKey: "segredo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runUploaderContract(t, u, httpFetch)
+
+	fake.FailNext(http.StatusServiceUnavailable, 1)
+	if _, err := u.Upload(context.Background(), UploadInput{Key: "x.txt", Body: []byte("x")}); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("esperava falha 503, veio %v", err)
+	}
+	other, _ := NewS3Uploader(S3Config{Endpoint: fake.URL, Region: "auto", Bucket: "anexos", AccessKey: "OUTRA", SecretKey: "segredo"})
+	if _, err := other.Upload(context.Background(), UploadInput{Key: "x.txt", Body: []byte("x")}); err == nil {
+		t.Fatal("chave de acesso desconhecida deveria ser recusada")
+	}
+}
+
+func TestUploaderContractGCS(t *testing.T) {
+	fake := fakes.NewObjectStore("anexos", "")
+	defer fake.Close()
+	creds, err := fake.ServiceAccountJSON("api@projeto.iam.gserviceaccount.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := NewGCSUploader(GCSConfig{Bucket: "anexos", CredentialsJSON: creds, Endpoint: fake.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runUploaderContract(t, u, httpFetch)
+}
+
+func TestUploaderContractLocal(t *testing.T) {
+	u, err := NewLocalUploader(LocalConfig{Root: t.TempDir(), BaseURL: "https://api.exemplo.gov.br/files", SigningSecret: "segredo-de-teste-local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runUploaderContract(t, u, func(target string) (int, []byte) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, strings.TrimPrefix(target, "https://api.exemplo.gov.br"), nil)
+		http.StripPrefix("/files", u).ServeHTTP(rec, req)
+		return rec.Code, rec.Body.Bytes()
+	})
+}
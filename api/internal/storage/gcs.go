@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsDefaultTokenURI = "https://oauth2.googleapis.com/token"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// GCSConfig descreve o bucket do Google Cloud Storage e a conta de serviço que o acessa.
+type GCSConfig struct {
+	Bucket string
+	// CredentialsJSON é o arquivo de chave da conta de serviço, como baixado do console.
+	CredentialsJSON []byte
+	PublicDomain    string
+	// Endpoint troca a API XML padrão (storage.googleapis.com), útil para emuladores.
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+type gcsCredentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// GCSUploader envia objetos pela API XML do GCS autenticando com OAuth da conta de serviço.
+type GCSUploader struct {
+	cfg      GCSConfig
+	client   *http.Client
+	email    string
+	key      *rsa.PrivateKey
+	tokenURI string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpira time.Time
+}
+
+// NewGCSUploader valida bucket e credenciais; o token de acesso só é pedido no primeiro envio.
+func NewGCSUploader(cfg GCSConfig) (*GCSUploader, error) {
+	if strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, errors.New("storage: bucket do GCS ausente")
+	}
+	var creds gcsCredentials
+	if err := json.Unmarshal(cfg.CredentialsJSON, &creds); err != nil {
+		return nil, errors.New("storage: credenciais do GCS inválidas")
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, errors.New("storage: credenciais do GCS sem client_email ou private_key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(creds.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("storage: chave privada do GCS inválida: %w", err)
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = gcsDefaultTokenURI
+	}
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		cfg.Endpoint = gcsDefaultEndpoint
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if !strings.HasPrefix(cfg.Endpoint, "http://") && !strings.HasPrefix(cfg.Endpoint, "https://") {
+		return nil, errors.New("storage: endpoint deve incluir protocolo http/https")
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &GCSUploader{cfg: cfg, client: client, email: creds.ClientEmail, key: key, tokenURI: creds.TokenURI}, nil
+}
+
+func (u *GCSUploader) objectURL(key string) (string, string, error) {
+	if strings.TrimSpace(key) == "" {
+		return "", "", errors.New("storage: chave do objeto obrigatória")
+	}
+	escapedKey := (&url.URL{Path: strings.TrimLeft(key, "/")}).EscapedPath()
+	return fmt.Sprintf("%s/%s/%s", u.cfg.Endpoint, u.cfg.Bucket, escapedKey), escapedKey, nil
+}
+
+// accessToken troca uma asserção JWT assinada pela conta de serviço por um token OAuth, guardado
+// até um minuto antes de expirar.
+func (u *GCSUploader) accessToken(ctx context.Context) (string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.token != "" && time.Now().Before(u.tokenExpira) {
+		return u.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   u.email,
+		"scope": gcsScope,
+		"aud":   u.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(u.key)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("storage: token do GCS recusado (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", errors.New("storage: resposta de token do GCS inválida")
+	}
+	u.token = token.AccessToken
+	u.tokenExpira = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return u.token, nil
+}
+
+// do executa a requisição autenticada e devolve a resposta já conferida quanto ao status.
+func (u *GCSUploader) do(req *http.Request, operacao string) (*http.Response, error) {
+	token, err := u.accessToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp, fmt.Errorf("storage: %s falhou (%d): %s", operacao, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// Upload envia o arquivo ao bucket e retorna a URL pública (se houver domínio configurado).
+func (u *GCSUploader) Upload(ctx context.Context, input UploadInput) (*UploadResult, error) {
+	targetURL, escapedKey, err := u.objectURL(input.Key)
+	if err != nil {
+		return nil, err
+	}
+	reader, size := input.conteudo()
+	if size <= 0 {
+		return nil, errors.New("storage: corpo vazio")
+	}
+	contentType := strings.TrimSpace(input.ContentType)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, targetURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	if strings.TrimSpace(input.CacheControl) != "" {
+		req.Header.Set("Cache-Control", input.CacheControl)
+	}
+	resp, err := u.do(req, "upload")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	publicURL := targetURL
+	if strings.TrimSpace(u.cfg.PublicDomain) != "" {
+		publicURL = fmt.Sprintf("%s/%s", strings.TrimRight(u.cfg.PublicDomain, "/"), escapedKey)
+	}
+	return &UploadResult{URL: publicURL, ETag: strings.Trim(resp.Header.Get("ETag"), "\"")}, nil
+}
+
+// Download baixa o objeto inteiro para a memória.
+func (u *GCSUploader) Download(ctx context.Context, key string) ([]byte, error) {
+	targetURL, _, err := u.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.do(req, "download")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Delete remove o objeto; objeto inexistente não é erro.
+func (u *GCSUploader) Delete(ctx context.Context, key string) error {
+	targetURL, _, err := u.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, targetURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.do(req, "remoção")
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PresignGet devolve uma URL V4 assinada com a chave da conta de serviço, válida por ttl.
+func (u *GCSUploader) PresignGet(key string, ttl time.Duration) (string, error) {
+	targetURL, _, err := u.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return "", err
+	}
+	return presignGCSURL(target, u.email, u.key, ttl, time.Now().UTC())
+}
+
+// presignGCSURL assina target no esquema GOOG4-RSA-SHA256, assinando apenas o host.
+func presignGCSURL(target *url.URL, email string, key *rsa.PrivateKey, ttl time.Duration, now time.Time) (string, error) {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+
+	googDate := now.UTC().Format("20060102T150405Z")
+	dateStamp := now.UTC().Format("20060102")
+	credentialScope := dateStamp + "/auto/storage/goog4_request"
+
+	query := url.Values{}
+	query.Set("X-Goog-Algorithm", "GOOG4-RSA-SHA256")
+	query.Set("X-Goog-Credential", email+"/"+credentialScope)
+	query.Set("X-Goog-Date", googDate)
+	query.Set("X-Goog-Expires", fmt.Sprintf("%d", int(ttl.Seconds())))
+	query.Set("X-Goog-SignedHeaders", "host")
+
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		canonicalURI(target.Path),
+		canonicalQuery,
+		"host:" + target.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hashedCanonical := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"GOOG4-RSA-SHA256",
+		googDate,
+		credentialScope,
+		hex.EncodeToString(hashedCanonical[:]),
+	}, "\n")
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	signed := *target
+	signed.RawQuery = canonicalQuery + "&X-Goog-Signature=" + hex.EncodeToString(signature)
+	return signed.String(), nil
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalConfig descreve o armazenamento em disco usado nos servidores próprios das prefeituras.
+type LocalConfig struct {
+	// Root é o diretório onde os objetos são gravados, um arquivo por chave.
+	Root string
+	// BaseURL é o endereço público em que o uploader é servido (ex.: https://api.exemplo.gov.br/files).
+	BaseURL string
+	// SigningSecret assina os links temporários; precisa ser igual em todas as instâncias da API.
+	SigningSecret string
+	// PublicPrefixes lista as chaves servidas sem assinatura, como logos e dados abertos.
+	PublicPrefixes []string
+}
+
+// LocalUploader grava objetos no sistema de arquivos e os serve como http.Handler.
+type LocalUploader struct {
+	cfg  LocalConfig
+	root string
+	key  []byte
+	now  func() time.Time
+}
+
+// NewLocalUploader valida a configuração e cria o diretório raiz, se preciso.
+func NewLocalUploader(cfg LocalConfig) (*LocalUploader, error) {
+	if strings.TrimSpace(cfg.Root) == "" {
+		return nil, errors.New("storage: diretório local ausente")
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "http://") && !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, errors.New("storage: URL base local deve incluir protocolo http/https")
+	}
+	if len(cfg.SigningSecret) < 16 {
+		return nil, errors.New("storage: segredo de assinatura local deve ter ao menos 16 caracteres")
+	}
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("storage: criar diretório local: %w", err)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	key := sha256.Sum256([]byte("storage-local|" + cfg.SigningSecret))
+	return &LocalUploader{cfg: cfg, root: root, key: key[:], now: time.Now}, nil
+}
+
+// filePath converte a chave em caminho dentro da raiz; chaves com ".." ou absolutas são recusadas
+// para que nenhuma requisição alcance arquivos fora do diretório.
+func (u *LocalUploader) filePath(key string) (string, error) {
+	key = strings.TrimLeft(strings.TrimSpace(key), "/")
+	if key == "" {
+		return "", errors.New("storage: chave do objeto obrigatória")
+	}
+	if strings.Contains(key, "\\") || strings.ContainsRune(key, 0) {
+		return "", errors.New("storage: chave do objeto inválida")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", errors.New("storage: chave do objeto inválida")
+		}
+	}
+	return filepath.Join(u.root, filepath.FromSlash(key)), nil
+}
+
+func (u *LocalUploader) objectURL(key string) string {
+	escapedKey := (&url.URL{Path: strings.TrimLeft(key, "/")}).EscapedPath()
+	return u.cfg.BaseURL + "/" + escapedKey
+}
+
+// Upload grava o arquivo de forma atômica: escreve num temporário ao lado e renomeia.
+func (u *LocalUploader) Upload(ctx context.Context, input UploadInput) (*UploadResult, error) {
+	target, err := u.filePath(input.Key)
+	if err != nil {
+		return nil, err
+	}
+	reader, size := input.conteudo()
+	if size <= 0 {
+		return nil, errors.New("storage: corpo vazio")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return nil, fmt.Errorf("storage: criar diretório: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("storage: upload falhou: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	sum := md5.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, sum), reader); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("storage: upload falhou: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("storage: upload falhou: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("storage: upload falhou: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o640); err != nil {
+		return nil, fmt.Errorf("storage: upload falhou: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, fmt.Errorf("storage: upload falhou: %w", err)
+	}
+
+	return &UploadResult{URL: u.objectURL(input.Key), ETag: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// Download lê o objeto inteiro para a memória.
+func (u *LocalUploader) Download(ctx context.Context, key string) ([]byte, error) {
+	target, err := u.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil, fmt.Errorf("storage: download falhou: %w", err)
+	}
+	return data, nil
+}
+
+// Delete remove o objeto; objeto inexistente não é erro.
+func (u *LocalUploader) Delete(ctx context.Context, key string) error {
+	target, err := u.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: remoção falhou: %w", err)
+	}
+	return nil
+}
+
+// PresignGet devolve um link de ServeHTTP assinado com HMAC e válido por ttl.
+func (u *LocalUploader) PresignGet(key string, ttl time.Duration) (string, error) {
+	if _, err := u.filePath(key); err != nil {
+		return "", err
+	}
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+	key = strings.TrimLeft(strings.TrimSpace(key), "/")
+	expires := strconv.FormatInt(u.now().Add(ttl).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", u.sign(key, expires))
+	return u.objectURL(key) + "?" + query.Encode(), nil
+}
+
+func (u *LocalUploader) sign(key, expires string) string {
+	return hex.EncodeToString(hmacSHA256(u.key, []byte(key+"\n"+expires)))
+}
+
+func (u *LocalUploader) public(key string) bool {
+	for _, prefix := range u.cfg.PublicPrefixes {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serve os objetos; deve ser montado sob o caminho de BaseURL com http.StripPrefix.
+// Fora dos prefixos públicos só atende links gerados por PresignGet dentro da validade.
+func (u *LocalUploader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	key := strings.TrimLeft(r.URL.Path, "/")
+	target, err := u.filePath(key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if u.public(key) {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	} else {
+		expires := r.URL.Query().Get("expires")
+		expiresAt, err := strconv.ParseInt(expires, 10, 64)
+		signature, _ := hex.DecodeString(r.URL.Query().Get("signature"))
+		expected, _ := hex.DecodeString(u.sign(key, expires))
+		if err != nil || !hmac.Equal(signature, expected) {
+			http.Error(w, "assinatura inválida", http.StatusForbidden)
+			return
+		}
+		if u.now().Unix() > expiresAt {
+			http.Error(w, "link expirado", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalUploaderSignedDownload(t *testing.T) {
+	u, err := NewLocalUploader(LocalConfig{
+		Root:           t.TempDir(),
+		BaseURL:        "https://api.exemplo.gov.br/files",
+		SigningSecret:  "segredo-de-teste-local",
+		PublicPrefixes: []string{"tenants/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agora := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	u.now = func() time.Time { return agora }
+
+	ctx := context.Background()
+	for _, key := range []string{"contracts/t1/contrato.pdf", "tenants/cabaceiras/logo.png"} {
+		if _, err := u.Upload(ctx, UploadInput{Key: key, Body: []byte("conteudo")}); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if _, err := u.Upload(ctx, UploadInput{Key: "contracts/../../etc/passwd", Body: []byte("x")}); err == nil {
+		t.Fatal("chave com .. deveria ser recusada")
+	}
+
+	get := func(target string) int {
+		target = strings.TrimPrefix(target, "https://api.exemplo.gov.br/files")
+		rec := httptest.NewRecorder()
+		http.StripPrefix("/files", u).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files"+target, nil))
+		return rec.Code
+	}
+
+	if code := get("/tenants/cabaceiras/logo.png"); code != http.StatusOK {
+		t.Fatalf("público = %d", code)
+	}
+	if code := get("/contracts/t1/contrato.pdf"); code != http.StatusForbidden {
+		t.Fatalf("privado sem assinatura = %d", code)
+	}
+	signed, err := u.PresignGet("contracts/t1/contrato.pdf", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := get(signed); code != http.StatusOK {
+		t.Fatalf("assinado = %d", code)
+	}
+	if code := get(strings.Replace(signed, "contrato.pdf", "outro.pdf", 1)); code != http.StatusForbidden {
+		t.Fatalf("assinatura de outra chave = %d", code)
+	}
+	agora = agora.Add(2 * time.Minute)
+	if code := get(signed); code != http.StatusForbidden {
+		t.Fatalf("expirado = %d", code)
+	}
+}
@@ -3,12 +3,20 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 )
 
+var errNoopUploader = errors.New("storage: uploader não configurado")
+
 // NoopUploader devolve erro indicando que não há backend configurado.
 type NoopUploader struct{}
 
 // Upload sempre retorna erro, sinalizando que o recurso não está disponível.
 func (NoopUploader) Upload(ctx context.Context, input UploadInput) (*UploadResult, error) {
-	return nil, errors.New("storage: uploader não configurado")
+	return nil, errNoopUploader
+}
+
+// PresignGet sempre retorna erro: sem backend não há objeto para assinar.
+func (NoopUploader) PresignGet(key string, ttl time.Duration) (string, error) {
+	return "", errNoopUploader
 }
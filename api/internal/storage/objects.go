@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Downloader lê de volta objetos privados, usado por rotinas internas como o teste de restauração.
+type Downloader interface {
+	Download(ctx context.Context, key string) ([]byte, error)
+}
+
+// Deleter remove objetos, usado pela retenção de backups.
+type Deleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Download baixa o objeto inteiro para a memória.
+func (u *S3Uploader) Download(ctx context.Context, key string) ([]byte, error) {
+	req, err := u.objectRequest(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("storage: download falhou (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Delete remove o objeto; objeto inexistente não é erro.
+func (u *S3Uploader) Delete(ctx context.Context, key string) error {
+	req, err := u.objectRequest(ctx, http.MethodDelete, key)
+	if err != nil {
+		return err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("storage: remoção falhou (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// objectRequest monta uma requisição assinada sem corpo para a chave informada.
+func (u *S3Uploader) objectRequest(ctx context.Context, method, key string) (*http.Request, error) {
+	if strings.TrimSpace(key) == "" {
+		return nil, errors.New("storage: chave do objeto obrigatória")
+	}
+	endpoint := strings.TrimRight(u.cfg.Endpoint, "/")
+	escapedKey := (&url.URL{Path: strings.TrimLeft(key, "/")}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/%s/%s", endpoint, u.cfg.Bucket, escapedKey), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := sha256.Sum256(nil)
+	payloadHex := hex.EncodeToString(empty[:])
+	req.Header.Set("x-amz-content-sha256", payloadHex)
+	if err := signS3Request(req, u.cfg, payloadHex, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxPresignTTL é o limite de validade aceito pelo SigV4 para URLs pré-assinadas.
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// Presigner gera URLs temporárias de leitura para objetos privados.
+type Presigner interface {
+	PresignGet(key string, ttl time.Duration) (string, error)
+}
+
+// PresignGet devolve uma URL de leitura do objeto assinada por ttl, sem depender do bucket público.
+func (u *S3Uploader) PresignGet(key string, ttl time.Duration) (string, error) {
+	if strings.TrimSpace(key) == "" {
+		return "", errors.New("storage: chave do objeto obrigatória")
+	}
+	endpoint := strings.TrimRight(u.cfg.Endpoint, "/")
+	escapedKey := (&url.URL{Path: strings.TrimLeft(key, "/")}).EscapedPath()
+	target, err := url.Parse(fmt.Sprintf("%s/%s/%s", endpoint, u.cfg.Bucket, escapedKey))
+	if err != nil {
+		return "", err
+	}
+	return presignGetURL(target, u.cfg, ttl, time.Now().UTC()), nil
+}
+
+// presignGetURL assina target com SigV4 na query string, assinando apenas o host.
+func presignGetURL(target *url.URL, cfg S3Config, ttl time.Duration, now time.Time) string {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	dateStamp := now.UTC().Format("20060102")
+	credentialScope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, cfg.Region)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", cfg.AccessKey+"/"+credentialScope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		canonicalURI(target.Path),
+		canonicalQuery,
+		"host:" + target.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hashedCanonical := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		hex.EncodeToString(hashedCanonical[:]),
+	}, "\n")
+
+	signingKey := deriveSigningKey(cfg.SecretKey, dateStamp, cfg.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+
+	signed := *target
+	signed.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return signed.String()
+}
@@ -1,7 +1,6 @@
 package storage
 
 import (
-	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -52,7 +51,8 @@ func (u *S3Uploader) Upload(ctx context.Context, input UploadInput) (*UploadResu
 	if strings.TrimSpace(input.Key) == "" {
 		return nil, errors.New("storage: chave do objeto obrigatória")
 	}
-	if len(input.Body) == 0 {
+	reader, size := input.conteudo()
+	if size <= 0 {
 		return nil, errors.New("storage: corpo vazio")
 	}
 
@@ -65,22 +65,24 @@ func (u *S3Uploader) Upload(ctx context.Context, input UploadInput) (*UploadResu
 	escapedKey := (&url.URL{Path: strings.TrimLeft(input.Key, "/")}).EscapedPath()
 	targetURL := fmt.Sprintf("%s/%s/%s", endpoint, u.cfg.Bucket, escapedKey)
 
-	reader := bytes.NewReader(input.Body)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, targetURL, reader)
 	if err != nil {
 		return nil, err
 	}
 
-	payloadHash := sha256.Sum256(input.Body)
-	payloadHex := hex.EncodeToString(payloadHash[:])
+	payloadHex := input.SHA256
+	if input.Stream == nil {
+		payloadHash := sha256.Sum256(input.Body)
+		payloadHex = hex.EncodeToString(payloadHash[:])
+	}
 
 	req.Header.Set("Content-Type", contentType)
-	req.ContentLength = int64(len(input.Body))
+	req.ContentLength = size
 	if strings.TrimSpace(input.CacheControl) != "" {
 		req.Header.Set("Cache-Control", input.CacheControl)
 	}
 	req.Header.Set("x-amz-content-sha256", payloadHex)
-	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(input.Body)))
+	req.Header.Set("Content-Length", fmt.Sprintf("%d", size))
 
 	if err := signS3Request(req, u.cfg, payloadHex, time.Now().UTC()); err != nil {
 		return nil, err
@@ -1,22 +1,41 @@
 package storage
 
-import "context"
+import (
+	"bytes"
+	"context"
+	"io"
+)
 
 // UploadInput representa uma operação de upload simples.
 type UploadInput struct {
-    Key          string
-    Body         []byte
-    ContentType  string
-    CacheControl string
+	Key          string
+	Body         []byte
+	ContentType  string
+	CacheControl string
+	// Stream substitui Body em arquivos grandes, como os dumps de backup, que o provedor lê sem
+	// carregar na memória. Exige Size e o SHA256 em hexadecimal, que a assinatura S3 usa.
+	Stream io.Reader
+	Size   int64
+	SHA256 string
+}
+
+// conteudo devolve o corpo do upload e o tamanho, vindo de Stream ou de Body.
+func (in UploadInput) conteudo() (io.Reader, int64) {
+	if in.Stream != nil {
+		return in.Stream, in.Size
+	}
+	return bytes.NewReader(in.Body), int64(len(in.Body))
 }
 
 // UploadResult descreve o artefato persistido.
 type UploadResult struct {
-    URL string
-    ETag string
+	URL  string
+	ETag string
 }
 
-// Uploader define comportamento básico para armazenar blobs.
+// Uploader define comportamento básico para armazenar blobs. Todo provedor também gera links
+// temporários, para que anexos privados não dependam de URL pública.
 type Uploader interface {
-    Upload(ctx context.Context, input UploadInput) (*UploadResult, error)
+	Upload(ctx context.Context, input UploadInput) (*UploadResult, error)
+	Presigner
 }
@@ -0,0 +1,217 @@
+package support
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const cannedColumns = `id, title, shortcut, category, body, tags, usage_count, last_used_at, created_by, updated_by, created_at, updated_at`
+
+// ListCanned lista respostas prontas, as mais usadas primeiro.
+func (r *Repository) ListCanned(ctx context.Context, filter CannedFilter) ([]CannedResponse, error) {
+	query := `
+        SELECT ` + cannedColumns + `
+        FROM support_canned_responses
+        WHERE ($1 = '' OR category = $1)
+          AND ($2 = '' OR title ILIKE '%' || $2 || '%' OR shortcut ILIKE $2 || '%' OR body ILIKE '%' || $2 || '%')
+        ORDER BY usage_count DESC, title ASC
+    `
+	rows, err := r.pool.Query(ctx, query, strings.TrimSpace(filter.Category), strings.TrimSpace(filter.Query))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := make([]CannedResponse, 0)
+	for rows.Next() {
+		canned, err := scanCanned(rows)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *canned)
+	}
+	return responses, rows.Err()
+}
+
+// GetCanned busca resposta pronta pelo id.
+func (r *Repository) GetCanned(ctx context.Context, id uuid.UUID) (*CannedResponse, error) {
+	return scanCanned(r.pool.QueryRow(ctx, `SELECT `+cannedColumns+` FROM support_canned_responses WHERE id = $1`, id))
+}
+
+// CreateCanned insere resposta pronta.
+func (r *Repository) CreateCanned(ctx context.Context, input CannedResponseInput) (*CannedResponse, error) {
+	const query = `
+        INSERT INTO support_canned_responses (title, shortcut, category, body, tags, created_by, updated_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        RETURNING ` + cannedColumns
+	canned, err := scanCanned(r.pool.QueryRow(ctx, query, input.Title, input.Shortcut, input.Category, input.Body, input.Tags, input.ActorID))
+	return canned, cannedError(err)
+}
+
+// UpdateCanned substitui o conteúdo da resposta pronta.
+func (r *Repository) UpdateCanned(ctx context.Context, id uuid.UUID, input CannedResponseInput) (*CannedResponse, error) {
+	const query = `
+        UPDATE support_canned_responses
+        SET title = $2, shortcut = $3, category = $4, body = $5, tags = $6, updated_by = $7, updated_at = now()
+        WHERE id = $1
+        RETURNING ` + cannedColumns
+	canned, err := scanCanned(r.pool.QueryRow(ctx, query, id, input.Title, input.Shortcut, input.Category, input.Body, input.Tags, input.ActorID))
+	return canned, cannedError(err)
+}
+
+// DeleteCanned remove resposta pronta.
+func (r *Repository) DeleteCanned(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM support_canned_responses WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCannedNotFound
+	}
+	return nil
+}
+
+// MarkCannedUsed contabiliza o uso para ordenar as sugestões.
+func (r *Repository) MarkCannedUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE support_canned_responses SET usage_count = usage_count + 1, last_used_at = now() WHERE id = $1`, id)
+	return err
+}
+
+func scanCanned(row pgx.Row) (*CannedResponse, error) {
+	var c CannedResponse
+	if err := row.Scan(&c.ID, &c.Title, &c.Shortcut, &c.Category, &c.Body, &c.Tags, &c.UsageCount, &c.LastUsedAt, &c.CreatedBy, &c.UpdatedBy, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCannedNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func cannedError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCannedShortcut
+	}
+	return err
+}
+
+// ListCanned lista respostas prontas.
+func (s *Service) ListCanned(ctx context.Context, filter CannedFilter) ([]CannedResponse, error) {
+	return s.repo.ListCanned(ctx, filter)
+}
+
+// GetCanned recupera resposta pronta.
+func (s *Service) GetCanned(ctx context.Context, id uuid.UUID) (*CannedResponse, error) {
+	return s.repo.GetCanned(ctx, id)
+}
+
+// SaveCanned cria (id nulo) ou atualiza resposta pronta.
+func (s *Service) SaveCanned(ctx context.Context, id *uuid.UUID, input CannedResponseInput) (*CannedResponse, error) {
+	input.Title = strings.TrimSpace(input.Title)
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Title == "" {
+		return nil, errors.New("título obrigatório")
+	}
+	if input.Body == "" {
+		return nil, errors.New("texto obrigatório")
+	}
+	input.Shortcut = trimmedOrNil(input.Shortcut)
+	if input.Shortcut != nil {
+		shortcut := strings.ToLower(strings.TrimPrefix(*input.Shortcut, "/"))
+		if strings.ContainsAny(shortcut, " \t\n") {
+			return nil, errors.New("atalho não pode conter espaços")
+		}
+		input.Shortcut = &shortcut
+	}
+	input.Category = trimmedOrNil(input.Category)
+	tags := make([]string, 0, len(input.Tags))
+	for _, tag := range input.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	input.Tags = tags
+
+	if id == nil {
+		return s.repo.CreateCanned(ctx, input)
+	}
+	return s.repo.UpdateCanned(ctx, *id, input)
+}
+
+// DeleteCanned remove resposta pronta.
+func (s *Service) DeleteCanned(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCanned(ctx, id)
+}
+
+// RenderCanned preenche os marcadores da resposta pronta com os dados do chamado.
+// Marcadores sem valor são mantidos para o atendente completar.
+func (s *Service) RenderCanned(ctx context.Context, cannedID, ticketID uuid.UUID, vars map[string]string) (string, error) {
+	canned, err := s.repo.GetCanned(ctx, cannedID)
+	if err != nil {
+		return "", err
+	}
+	ticket, err := s.repo.GetTicket(ctx, ticketID)
+	if err != nil {
+		return "", err
+	}
+	values := map[string]string{
+		"chamado.id":      ticket.ID.String(),
+		"chamado.assunto": ticket.Subject,
+	}
+	if ticket.RequesterEmail != nil {
+		values["solicitante.email"] = *ticket.RequesterEmail
+	}
+	for key, value := range vars {
+		values[key] = value
+	}
+	return RenderTemplate(canned.Body, values), nil
+}
+
+// UseCanned registra o uso da resposta pronta.
+func (s *Service) UseCanned(ctx context.Context, cannedID uuid.UUID) error {
+	return s.repo.MarkCannedUsed(ctx, cannedID)
+}
+
+// RenderTemplate troca marcadores {{chave}} (com espaços opcionais) pelos valores informados.
+func RenderTemplate(body string, values map[string]string) string {
+	var out strings.Builder
+	rest := body
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			out.WriteString(rest)
+			return out.String()
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			out.WriteString(rest)
+			return out.String()
+		}
+		end += start
+		out.WriteString(rest[:start])
+		key := strings.TrimSpace(rest[start+2 : end])
+		if value, ok := values[key]; ok {
+			out.WriteString(value)
+		} else {
+			out.WriteString(rest[start : end+2])
+		}
+		rest = rest[end+2:]
+	}
+}
+
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
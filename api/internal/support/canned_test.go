@@ -0,0 +1,13 @@
+package support
+
+import "testing"
+
+func TestRenderTemplate(t *testing.T) {
+	got := RenderTemplate("Olá {{ solicitante.nome }}, sobre {{chamado.assunto}}: {{desconhecido}} {{", map[string]string{
+		"solicitante.nome": "Ana",
+		"chamado.assunto":  "boletim",
+	})
+	if want := "Olá Ana, sobre boletim: {{desconhecido}} {{"; got != want {
+		t.Fatalf("obtido %q, esperado %q", got, want)
+	}
+}
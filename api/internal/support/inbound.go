@@ -0,0 +1,325 @@
+package support
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MaxInboundAttachmentBytes limita cada anexo recebido por e-mail.
+const MaxInboundAttachmentBytes = 10 << 20
+
+var (
+	// ErrUnroutableEmail indica e-mail sem chamado nem tenant identificável.
+	ErrUnroutableEmail = errors.New("e-mail sem chamado ou tenant correspondente")
+	// ErrDuplicateEmail indica e-mail já processado (reentrega do provedor).
+	ErrDuplicateEmail = errors.New("e-mail já processado")
+)
+
+// InboundEmail é o e-mail recebido já normalizado, independente do provedor.
+type InboundEmail struct {
+	MessageID   string
+	InReplyTo   string
+	References  []string
+	From        string
+	FromName    string
+	To          []string
+	Subject     string
+	Text        string
+	Attachments []InboundAttachment
+}
+
+// InboundAttachment é um arquivo anexado ao e-mail recebido.
+type InboundAttachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// ThreadIDs devolve os Message-IDs que podem ligar o e-mail a um chamado, do mais recente ao mais antigo.
+func (e InboundEmail) ThreadIDs() []string {
+	ids := make([]string, 0, len(e.References)+1)
+	seen := map[string]struct{}{}
+	add := func(id string) {
+		id = normalizeMessageID(id)
+		if id == "" {
+			return
+		}
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	add(e.InReplyTo)
+	for i := len(e.References) - 1; i >= 0; i-- {
+		add(e.References[i])
+	}
+	return ids
+}
+
+// RecipientTag extrai o sufixo após "+" do endereço de suporte (suporte+<tag>@dominio).
+// A tag é o id do chamado nas respostas ou o slug do tenant em chamados novos.
+func RecipientTag(recipients []string, supportAddress string) string {
+	local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(supportAddress)), "@")
+	if !ok {
+		return ""
+	}
+	for _, raw := range recipients {
+		addr := strings.ToLower(strings.TrimSpace(raw))
+		if parsed, err := mail.ParseAddress(raw); err == nil {
+			addr = strings.ToLower(parsed.Address)
+		}
+		rLocal, rDomain, ok := strings.Cut(addr, "@")
+		if !ok || rDomain != domain {
+			continue
+		}
+		base, tag, ok := strings.Cut(rLocal, "+")
+		if ok && base == local && tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// ReplyAddress é o endereço de resposta que leva o e-mail de volta ao chamado.
+func ReplyAddress(supportAddress string, ticketID uuid.UUID) string {
+	local, domain, ok := strings.Cut(strings.TrimSpace(supportAddress), "@")
+	if !ok {
+		return supportAddress
+	}
+	return fmt.Sprintf("%s+%s@%s", local, ticketID.String(), domain)
+}
+
+// StripQuotedReply remove o histórico citado que clientes de e-mail anexam à resposta.
+func StripQuotedReply(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if isQuoteHeader(trimmed) {
+			break
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func isQuoteHeader(line string) bool {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.HasPrefix(lower, "em ") && strings.HasSuffix(lower, "escreveu:"):
+		return true
+	case strings.HasPrefix(lower, "on ") && strings.HasSuffix(lower, "wrote:"):
+		return true
+	case strings.HasPrefix(lower, "-----original message-----"), strings.HasPrefix(lower, "-----mensagem original-----"):
+		return true
+	}
+	return false
+}
+
+// VerifyMailgunSignature confere o HMAC-SHA256 de timestamp+token com a chave de assinatura do webhook.
+func VerifyMailgunSignature(signingKey, timestamp, token, signature string) bool {
+	received, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(received) == 0 || signingKey == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + token))
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+// ParseMailgunForm normaliza os campos da rota de recebimento do Mailgun.
+func ParseMailgunForm(form *multipart.Form) (InboundEmail, error) {
+	value := func(key string) string {
+		if vals := form.Value[key]; len(vals) > 0 {
+			return strings.TrimSpace(vals[0])
+		}
+		return ""
+	}
+
+	email := InboundEmail{
+		MessageID:  value("Message-Id"),
+		InReplyTo:  value("In-Reply-To"),
+		References: strings.Fields(value("References")),
+		Subject:    value("subject"),
+		Text:       value("stripped-text"),
+	}
+	if email.Text == "" {
+		email.Text = StripQuotedReply(value("body-plain"))
+	}
+	from := value("from")
+	if from == "" {
+		from = value("sender")
+	}
+	if addr, err := mail.ParseAddress(from); err == nil {
+		email.From = strings.ToLower(addr.Address)
+		email.FromName = addr.Name
+	} else {
+		email.From = strings.ToLower(from)
+	}
+	for _, rcpt := range strings.Split(value("recipient"), ",") {
+		if rcpt = strings.TrimSpace(rcpt); rcpt != "" {
+			email.To = append(email.To, rcpt)
+		}
+	}
+
+	for field, headers := range form.File {
+		if !strings.HasPrefix(field, "attachment") {
+			continue
+		}
+		for _, fh := range headers {
+			if fh.Size > MaxInboundAttachmentBytes {
+				continue
+			}
+			file, err := fh.Open()
+			if err != nil {
+				return email, err
+			}
+			data, err := io.ReadAll(io.LimitReader(file, MaxInboundAttachmentBytes))
+			file.Close()
+			if err != nil {
+				return email, err
+			}
+			email.Attachments = append(email.Attachments, InboundAttachment{
+				Name:        fh.Filename,
+				ContentType: fh.Header.Get("Content-Type"),
+				Data:        data,
+			})
+		}
+	}
+
+	if email.From == "" {
+		return email, errors.New("remetente ausente")
+	}
+	return email, nil
+}
+
+// ParseMIME normaliza um e-mail bruto (RFC 5322), como o entregue pelo SES.
+func ParseMIME(raw []byte) (InboundEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return InboundEmail{}, fmt.Errorf("e-mail inválido: %w", err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	email := InboundEmail{
+		MessageID:  strings.TrimSpace(msg.Header.Get("Message-Id")),
+		InReplyTo:  strings.TrimSpace(msg.Header.Get("In-Reply-To")),
+		References: strings.Fields(msg.Header.Get("References")),
+		Subject:    strings.TrimSpace(subject),
+	}
+	from, err := msg.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return email, errors.New("remetente ausente")
+	}
+	email.From = strings.ToLower(from[0].Address)
+	email.FromName = from[0].Name
+	for _, key := range []string{"To", "Cc", "Delivered-To"} {
+		if list, err := msg.Header.AddressList(key); err == nil {
+			for _, addr := range list {
+				email.To = append(email.To, addr.Address)
+			}
+		}
+	}
+
+	var text string
+	if err := walkMIME(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body, &text, &email.Attachments); err != nil {
+		return email, err
+	}
+	email.Text = StripQuotedReply(text)
+	return email, nil
+}
+
+func walkMIME(contentType, encoding, disposition string, body io.Reader, text *string, attachments *[]InboundAttachment) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("e-mail: parte inválida: %w", err)
+			}
+			if err := walkMIME(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part.Header.Get("Content-Disposition"), part, text, attachments); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(decodeTransfer(encoding, body), MaxInboundAttachmentBytes+1))
+	if err != nil {
+		return err
+	}
+
+	dispType, dispParams, _ := mime.ParseMediaType(disposition)
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if dispType == "attachment" || (filename != "" && mediaType != "text/plain") {
+		if len(data) > MaxInboundAttachmentBytes {
+			return nil
+		}
+		if filename == "" {
+			filename = "anexo"
+		}
+		*attachments = append(*attachments, InboundAttachment{Name: filename, ContentType: mediaType, Data: data})
+		return nil
+	}
+	if mediaType == "text/plain" && *text == "" {
+		*text = string(data)
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+func normalizeMessageID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ""
+	}
+	if !strings.HasPrefix(id, "<") {
+		id = "<" + id
+	}
+	if !strings.HasSuffix(id, ">") {
+		id += ">"
+	}
+	return id
+}
@@ -0,0 +1,89 @@
+package support
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseMIMEMultipartWithAttachment(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: Ana Souza <ana@prefeitura.gov.br>",
+		"To: suporte+3f0c8a9e-5a8e-4b8e-9a43-8f6f0e2b1c11@gestao.app",
+		"Subject: =?utf-8?q?Boletim_n=C3=A3o_abre?=",
+		"Message-ID: <abc@mail.prefeitura.gov.br>",
+		"In-Reply-To: <resposta-1@gestao.app>",
+		"References: <inicio@gestao.app> <resposta-1@gestao.app>",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b1"`,
+		"",
+		"--b1",
+		`Content-Type: text/plain; charset="utf-8"`,
+		"",
+		"Continua com erro.",
+		"",
+		"Em seg, 6 de out, Suporte escreveu:",
+		"> Pode tentar de novo?",
+		"--b1",
+		"Content-Type: image/png",
+		`Content-Disposition: attachment; filename="tela.png"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		"iVBORw0KGgo=",
+		"--b1--",
+		"",
+	}, "\r\n")
+
+	email, err := ParseMIME([]byte(raw))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if email.From != "ana@prefeitura.gov.br" || email.FromName != "Ana Souza" {
+		t.Fatalf("remetente inesperado: %q %q", email.From, email.FromName)
+	}
+	if email.Subject != "Boletim não abre" {
+		t.Fatalf("assunto inesperado: %q", email.Subject)
+	}
+	if email.Text != "Continua com erro." {
+		t.Fatalf("texto inesperado: %q", email.Text)
+	}
+	if len(email.Attachments) != 1 || email.Attachments[0].Name != "tela.png" || len(email.Attachments[0].Data) != 8 {
+		t.Fatalf("anexos inesperados: %+v", email.Attachments)
+	}
+
+	ids := email.ThreadIDs()
+	if len(ids) != 2 || ids[0] != "<resposta-1@gestao.app>" || ids[1] != "<inicio@gestao.app>" {
+		t.Fatalf("encadeamento inesperado: %v", ids)
+	}
+}
+
+func TestRecipientTag(t *testing.T) {
+	ticketID := uuid.New()
+	reply := ReplyAddress("suporte@gestao.app", ticketID)
+	if got := RecipientTag([]string{"Suporte <" + reply + ">"}, "suporte@gestao.app"); got != ticketID.String() {
+		t.Fatalf("tag do chamado: %q", got)
+	}
+	if got := RecipientTag([]string{"suporte+zabele@gestao.app"}, "suporte@gestao.app"); got != "zabele" {
+		t.Fatalf("tag do tenant: %q", got)
+	}
+	if got := RecipientTag([]string{"suporte+zabele@outro.app", "suporte@gestao.app"}, "suporte@gestao.app"); got != "" {
+		t.Fatalf("tag indevida: %q", got)
+	}
+}
+
+func TestVerifyMailgunSignature(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("chave"))
+	mac.Write([]byte("1700000000" + "tok"))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	if !VerifyMailgunSignature("chave", "1700000000", "tok", signature) {
+		t.Fatal("assinatura válida rejeitada")
+	}
+	if VerifyMailgunSignature("outra", "1700000000", "tok", signature) {
+		t.Fatal("assinatura com chave errada aceita")
+	}
+}
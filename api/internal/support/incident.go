@@ -0,0 +1,95 @@
+package support
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Chamados abertos automaticamente quando uma rota de tenant entra em panic.
+const (
+	CategoryIncident = "incidente"
+	TagIncident      = "panic"
+	tagFingerprint   = "panic:"
+)
+
+// Incident descreve um panic a registrar como chamado interno.
+type Incident struct {
+	TenantID    uuid.UUID
+	Fingerprint string
+	Subject     string
+	Description string
+}
+
+// OpenIncident abre um chamado interno para o panic. Se já existe chamado aberto com a mesma
+// assinatura no tenant, a ocorrência entra como nota interna nele e created volta falso.
+func (s *Service) OpenIncident(ctx context.Context, incident Incident) (*Ticket, bool, error) {
+	tag := tagFingerprint + incident.Fingerprint
+	existing, err := s.repo.FindOpenTicketByTag(ctx, incident.TenantID, tag)
+	switch {
+	case err == nil:
+		_, err = s.AddMessage(ctx, CreateMessageInput{
+			TicketID:   existing.ID,
+			AuthorType: AuthorSystem,
+			Body:       "Nova ocorrência.\n\n" + incident.Description,
+			Channel:    ChannelWeb,
+			Internal:   true,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	case !errors.Is(err, ErrNotFound):
+		return nil, false, err
+	}
+
+	ticket, err := s.CreateTicket(ctx, CreateTicketInput{
+		TenantID:    incident.TenantID,
+		Subject:     incident.Subject,
+		Category:    CategoryIncident,
+		Description: incident.Description,
+		Priority:    PriorityHigh,
+		Status:      StatusOpen,
+		Tags:        []string{TagIncident, tag},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return ticket, true, nil
+}
+
+// PanicFingerprint agrupa panics pela rota e pela função onde o panic ocorreu, ignorando a
+// mensagem (que costuma carregar ids e valores da requisição).
+func PanicFingerprint(route string, stack []byte) string {
+	sum := sha256.Sum256([]byte(route + "|" + PanicSite(stack)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// PanicSite devolve a primeira função após o frame de panic() em um stack de debug.Stack.
+func PanicSite(stack []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(stack))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	afterPanic := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") || line == "" {
+			continue
+		}
+		if afterPanic {
+			if idx := strings.LastIndex(line, "("); idx > 0 {
+				return line[:idx]
+			}
+			return line
+		}
+		if strings.HasPrefix(line, "panic(") {
+			afterPanic = true
+		}
+	}
+	return ""
+}
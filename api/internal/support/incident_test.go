@@ -0,0 +1,40 @@
+package support
+
+import "testing"
+
+const sampleStack = `goroutine 42 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+github.com/gestaozabele/municipio/internal/http/middleware.Recover.func1.1.1()
+	/app/internal/http/middleware/recover.go:55 +0x8a
+panic({0x1234, 0x5678})
+	/usr/local/go/src/runtime/panic.go:770 +0x132
+github.com/gestaozabele/municipio/internal/http.(*Handler).ListAlunos(0xc000, {0x1, 0x2}, 0xc001)
+	/app/internal/http/alunos.go:88 +0x1f
+net/http.HandlerFunc.ServeHTTP(0x1, {0x2, 0x3}, 0x4)
+	/usr/local/go/src/net/http/server.go:2166 +0x29
+`
+
+func TestPanicSite(t *testing.T) {
+	got := PanicSite([]byte(sampleStack))
+	want := "github.com/gestaozabele/municipio/internal/http.(*Handler).ListAlunos"
+	if got != want {
+		t.Fatalf("PanicSite = %q, want %q", got, want)
+	}
+	if PanicSite([]byte("goroutine 1 [running]:\nmain.main()\n")) != "" {
+		t.Fatal("stack sem panic deveria devolver vazio")
+	}
+}
+
+func TestPanicFingerprint(t *testing.T) {
+	a := PanicFingerprint("/api/alunos", []byte(sampleStack))
+	if len(a) != 16 {
+		t.Fatalf("assinatura com tamanho inesperado: %q", a)
+	}
+	if a != PanicFingerprint("/api/alunos", []byte(sampleStack)) {
+		t.Fatal("assinatura deveria ser estável")
+	}
+	if a == PanicFingerprint("/api/turmas", []byte(sampleStack)) {
+		t.Fatal("rotas diferentes deveriam gerar assinaturas diferentes")
+	}
+}
@@ -14,6 +14,8 @@ var (
 	ErrInvalidStatus   = errors.New("invalid status")
 	ErrInvalidPriority = errors.New("invalid priority")
 	ErrInvalidAuthor   = errors.New("invalid author type")
+	ErrCannedNotFound  = errors.New("canned response not found")
+	ErrCannedShortcut  = errors.New("canned response shortcut already in use")
 )
 
 const (
@@ -30,6 +32,9 @@ const (
 	AuthorSaaS   = "saas_user"
 	AuthorTenant = "tenant_user"
 	AuthorSystem = "system"
+
+	ChannelWeb   = "web"
+	ChannelEmail = "email"
 )
 
 var (
@@ -64,9 +69,11 @@ type Ticket struct {
 	Tags        []string   `json:"tags"`
 	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
 	AssignedTo  *uuid.UUID `json:"assigned_to,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	// RequesterEmail recebe as respostas quando o chamado foi aberto por e-mail.
+	RequesterEmail *string    `json:"requester_email,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
 }
 
 // Message representa uma interação no chamado.
@@ -76,7 +83,37 @@ type Message struct {
 	AuthorType string     `json:"author_type"`
 	AuthorID   *uuid.UUID `json:"author_id,omitempty"`
 	Body       string     `json:"body"`
-	CreatedAt  time.Time  `json:"created_at"`
+	Channel    string     `json:"channel"`
+	// Internal marca nota da equipe, nunca enviada nem exibida ao contato do tenant.
+	Internal  bool    `json:"internal"`
+	EmailFrom *string `json:"email_from,omitempty"`
+	// EmailMessageID encadeia respostas por e-mail (In-Reply-To/References).
+	EmailMessageID *string      `json:"email_message_id,omitempty"`
+	Attachments    []Attachment `json:"attachments,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// Attachment é um arquivo recebido junto a uma mensagem.
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	MessageID   uuid.UUID `json:"message_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	ObjectKey   string    `json:"-"`
+	FileURL     string    `json:"-"`
+	DownloadURL string    `json:"download_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateAttachmentInput registra arquivo já enviado ao armazenamento.
+type CreateAttachmentInput struct {
+	MessageID   uuid.UUID
+	FileName    string
+	ContentType string
+	SizeBytes   int64
+	ObjectKey   string
+	FileURL     string
 }
 
 // CreateTicketInput encapsula campos para abertura de ticket.
@@ -90,6 +127,8 @@ type CreateTicketInput struct {
 	Tags        []string
 	CreatedBy   *uuid.UUID
 	AssignedTo  *uuid.UUID
+	// RequesterEmail fica nulo para chamados abertos pelo painel.
+	RequesterEmail *string
 }
 
 // UpdateTicketInput permite atualizar status/atribuições.
@@ -108,14 +147,21 @@ type CreateMessageInput struct {
 	AuthorType string
 	AuthorID   *uuid.UUID
 	Body       string
+	Channel    string
+	Internal   bool
+	EmailFrom  *string
+	// EmailMessageID é único; reentregas do mesmo e-mail devolvem ErrDuplicateEmail.
+	EmailMessageID *string
 }
 
 // TicketFilter permite filtrar listagem de tickets.
 type TicketFilter struct {
 	TenantID *uuid.UUID
-	Status   []string
-	Limit    int
-	Offset   int
+	// TenantIDs restringe a listagem à carteira do atendente; vazio não filtra.
+	TenantIDs []uuid.UUID
+	Status    []string
+	Limit     int
+	Offset    int
 }
 
 // NormalizeStatus garante padrão em letras minúsculas.
@@ -153,3 +199,35 @@ func IsValidAuthor(author string) bool {
 	_, ok := validAuthorTypes[strings.ToLower(strings.TrimSpace(author))]
 	return ok
 }
+
+// CannedResponse é uma resposta pronta reutilizada pela equipe de suporte.
+type CannedResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	Shortcut   *string    `json:"shortcut,omitempty"`
+	Category   *string    `json:"category,omitempty"`
+	Body       string     `json:"body"`
+	Tags       []string   `json:"tags"`
+	UsageCount int        `json:"usage_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	UpdatedBy  *uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CannedResponseInput cria ou substitui uma resposta pronta.
+type CannedResponseInput struct {
+	Title    string
+	Shortcut *string
+	Category *string
+	Body     string
+	Tags     []string
+	ActorID  *uuid.UUID
+}
+
+// CannedFilter filtra respostas prontas por categoria ou texto.
+type CannedFilter struct {
+	Category string
+	Query    string
+}
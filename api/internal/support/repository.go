@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,6 +17,11 @@ type Repository struct {
 	pool *pgxpool.Pool
 }
 
+const (
+	ticketColumns  = `id, tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to, requester_email, created_at, updated_at, closed_at`
+	messageColumns = `id, ticket_id, author_type, author_id, body, channel, internal, email_from, email_message_id, created_at`
+)
+
 // NewRepository cria instância do repositório.
 func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
@@ -24,9 +30,9 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 // CreateTicket insere um novo chamado.
 func (r *Repository) CreateTicket(ctx context.Context, input CreateTicketInput) (*Ticket, error) {
 	const query = `
-        INSERT INTO support_tickets (tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-        RETURNING id, tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to, created_at, updated_at, closed_at
+        INSERT INTO support_tickets (tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to, requester_email)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING ` + ticketColumns + `
     `
 
 	tags := input.Tags
@@ -44,6 +50,7 @@ func (r *Repository) CreateTicket(ctx context.Context, input CreateTicketInput)
 		tags,
 		input.CreatedBy,
 		input.AssignedTo,
+		input.RequesterEmail,
 	)
 
 	return scanTicket(row)
@@ -52,7 +59,7 @@ func (r *Repository) CreateTicket(ctx context.Context, input CreateTicketInput)
 // GetTicket busca um ticket específico.
 func (r *Repository) GetTicket(ctx context.Context, id uuid.UUID) (*Ticket, error) {
 	const query = `
-        SELECT id, tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to, created_at, updated_at, closed_at
+        SELECT ` + ticketColumns + `
         FROM support_tickets
         WHERE id = $1
     `
@@ -64,7 +71,7 @@ func (r *Repository) GetTicket(ctx context.Context, id uuid.UUID) (*Ticket, erro
 // ListTickets lista tickets aplicando filtros simples.
 func (r *Repository) ListTickets(ctx context.Context, filter TicketFilter) ([]Ticket, error) {
 	base := `
-        SELECT id, tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to, created_at, updated_at, closed_at
+        SELECT ` + ticketColumns + `
         FROM support_tickets`
 
 	var (
@@ -79,6 +86,12 @@ func (r *Repository) ListTickets(ctx context.Context, filter TicketFilter) ([]Ti
 		idx++
 	}
 
+	if len(filter.TenantIDs) > 0 {
+		clauses = append(clauses, fmt.Sprintf("tenant_id = ANY($%d)", idx))
+		args = append(args, filter.TenantIDs)
+		idx++
+	}
+
 	if len(filter.Status) > 0 {
 		normalized := make([]string, len(filter.Status))
 		for i, status := range filter.Status {
@@ -172,7 +185,7 @@ func (r *Repository) UpdateTicket(ctx context.Context, input UpdateTicketInput)
         UPDATE support_tickets
         SET %s
         WHERE id = $%d
-        RETURNING id, tenant_id, subject, category, status, priority, description, tags, created_by, assigned_to, created_at, updated_at, closed_at
+        RETURNING `+ticketColumns+`
     `, strings.Join(setParts, ", "), idx)
 
 	row := r.pool.QueryRow(ctx, query, args...)
@@ -182,25 +195,39 @@ func (r *Repository) UpdateTicket(ctx context.Context, input UpdateTicketInput)
 // CreateMessage insere mensagem no ticket.
 func (r *Repository) CreateMessage(ctx context.Context, input CreateMessageInput) (*Message, error) {
 	const query = `
-        INSERT INTO support_ticket_messages (ticket_id, author_type, author_id, body)
-        VALUES ($1, $2, $3, $4)
-        RETURNING id, ticket_id, author_type, author_id, body, created_at
+        INSERT INTO support_ticket_messages (ticket_id, author_type, author_id, body, channel, internal, email_from, email_message_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING ` + messageColumns + `
     `
 
+	channel := input.Channel
+	if channel == "" {
+		channel = ChannelWeb
+	}
+
 	row := r.pool.QueryRow(ctx, query,
 		input.TicketID,
 		strings.ToLower(strings.TrimSpace(input.AuthorType)),
 		input.AuthorID,
 		strings.TrimSpace(input.Body),
+		channel,
+		input.Internal,
+		input.EmailFrom,
+		input.EmailMessageID,
 	)
 
-	return scanMessage(row)
+	msg, err := scanMessage(row)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrDuplicateEmail
+	}
+	return msg, err
 }
 
 // ListMessages lista interações do ticket.
 func (r *Repository) ListMessages(ctx context.Context, ticketID uuid.UUID) ([]Message, error) {
 	const query = `
-        SELECT id, ticket_id, author_type, author_id, body, created_at
+        SELECT ` + messageColumns + `
         FROM support_ticket_messages
         WHERE ticket_id = $1
         ORDER BY created_at ASC
@@ -225,12 +252,146 @@ func (r *Repository) ListMessages(ctx context.Context, ticketID uuid.UUID) ([]Me
 		return nil, rows.Err()
 	}
 
+	attachments, err := r.listAttachments(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range messages {
+		messages[i].Attachments = attachments[messages[i].ID]
+	}
+
 	return messages, nil
 }
 
+// FindTicketByEmailThread devolve o chamado de alguma das mensagens referenciadas pelo e-mail.
+func (r *Repository) FindTicketByEmailThread(ctx context.Context, messageIDs []string) (*Ticket, error) {
+	if len(messageIDs) == 0 {
+		return nil, ErrNotFound
+	}
+	const query = `
+        SELECT ` + ticketColumns + `
+        FROM support_tickets
+        WHERE id = (
+            SELECT ticket_id FROM support_ticket_messages
+            WHERE email_message_id = ANY($1)
+            ORDER BY created_at DESC
+            LIMIT 1
+        )
+    `
+	return scanTicket(r.pool.QueryRow(ctx, query, messageIDs))
+}
+
+// FindOpenTicketByTag busca o chamado ainda aberto do tenant marcado com a etiqueta.
+func (r *Repository) FindOpenTicketByTag(ctx context.Context, tenantID uuid.UUID, tag string) (*Ticket, error) {
+	const query = `
+        SELECT ` + ticketColumns + `
+        FROM support_tickets
+        WHERE tenant_id = $1 AND $2 = ANY(tags) AND status IN ('open', 'in_progress')
+        ORDER BY created_at DESC
+        LIMIT 1
+    `
+
+	row := r.pool.QueryRow(ctx, query, tenantID, tag)
+	return scanTicket(row)
+}
+
+// FindTenantForEmail identifica o tenant de um chamado novo pelo slug da tag do destinatário,
+// pelo e-mail de contato cadastrado ou pelo domínio do remetente.
+func (r *Repository) FindTenantForEmail(ctx context.Context, slug, sender string) (uuid.UUID, error) {
+	const query = `
+        SELECT id FROM tenants
+        WHERE ($1 <> '' AND slug = $1)
+           OR lower(contact->>'email') = $2
+           OR ($3 <> '' AND (domain = $3 OR domain LIKE '%.' || $3))
+        ORDER BY (slug = $1) DESC, (lower(contact->>'email') = $2) DESC
+        LIMIT 1
+    `
+	domain := ""
+	if _, d, ok := strings.Cut(sender, "@"); ok {
+		domain = d
+	}
+	var id uuid.UUID
+	if err := r.pool.QueryRow(ctx, query, strings.ToLower(slug), strings.ToLower(sender), strings.ToLower(domain)).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrUnroutableEmail
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// EmailThread devolve os Message-IDs do chamado em ordem cronológica, para References.
+func (r *Repository) EmailThread(ctx context.Context, ticketID uuid.UUID) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT email_message_id FROM support_ticket_messages
+        WHERE ticket_id = $1 AND email_message_id IS NOT NULL
+        ORDER BY created_at ASC
+    `, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SetMessageEmail grava o Message-ID da resposta enviada por e-mail.
+func (r *Repository) SetMessageEmail(ctx context.Context, messageID uuid.UUID, emailMessageID string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE support_ticket_messages SET email_message_id = $2 WHERE id = $1`, messageID, emailMessageID)
+	return err
+}
+
+// CreateAttachment registra anexo de uma mensagem.
+func (r *Repository) CreateAttachment(ctx context.Context, input CreateAttachmentInput) (*Attachment, error) {
+	const query = `
+        INSERT INTO support_ticket_attachments (message_id, file_name, content_type, size_bytes, object_key, file_url)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, message_id, file_name, content_type, size_bytes, object_key, file_url, created_at
+    `
+	var a Attachment
+	err := r.pool.QueryRow(ctx, query, input.MessageID, input.FileName, input.ContentType, input.SizeBytes, input.ObjectKey, input.FileURL).
+		Scan(&a.ID, &a.MessageID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.ObjectKey, &a.FileURL, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *Repository) listAttachments(ctx context.Context, ticketID uuid.UUID) (map[uuid.UUID][]Attachment, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT a.id, a.message_id, a.file_name, a.content_type, a.size_bytes, a.object_key, a.file_url, a.created_at
+        FROM support_ticket_attachments a
+        JOIN support_ticket_messages m ON m.id = a.message_id
+        WHERE m.ticket_id = $1
+        ORDER BY a.created_at ASC
+    `, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byMessage := make(map[uuid.UUID][]Attachment)
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.MessageID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.ObjectKey, &a.FileURL, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		byMessage[a.MessageID] = append(byMessage[a.MessageID], a)
+	}
+	return byMessage, rows.Err()
+}
+
 func scanTicket(row pgx.Row) (*Ticket, error) {
 	var t Ticket
-	if err := row.Scan(&t.ID, &t.TenantID, &t.Subject, &t.Category, &t.Status, &t.Priority, &t.Description, &t.Tags, &t.CreatedBy, &t.AssignedTo, &t.CreatedAt, &t.UpdatedAt, &t.ClosedAt); err != nil {
+	if err := row.Scan(&t.ID, &t.TenantID, &t.Subject, &t.Category, &t.Status, &t.Priority, &t.Description, &t.Tags, &t.CreatedBy, &t.AssignedTo, &t.RequesterEmail, &t.CreatedAt, &t.UpdatedAt, &t.ClosedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
 		}
@@ -241,7 +402,7 @@ func scanTicket(row pgx.Row) (*Ticket, error) {
 
 func scanMessage(row pgx.Row) (*Message, error) {
 	var m Message
-	if err := row.Scan(&m.ID, &m.TicketID, &m.AuthorType, &m.AuthorID, &m.Body, &m.CreatedAt); err != nil {
+	if err := row.Scan(&m.ID, &m.TicketID, &m.AuthorType, &m.AuthorID, &m.Body, &m.Channel, &m.Internal, &m.EmailFrom, &m.EmailMessageID, &m.CreatedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrMessageNotFound
 		}
@@ -136,3 +136,97 @@ func (s *Service) AddMessage(ctx context.Context, input CreateMessageInput) (*Me
 func (s *Service) ListMessages(ctx context.Context, ticketID uuid.UUID) ([]Message, error) {
 	return s.repo.ListMessages(ctx, ticketID)
 }
+
+// ReceiveEmail encaixa um e-mail recebido no chamado certo: primeiro pelos cabeçalhos de
+// resposta, depois pela tag do destinatário (id do chamado). Sem correspondência, abre um
+// chamado novo no tenant do slug da tag ou do remetente. Chamados encerrados são reabertos.
+func (s *Service) ReceiveEmail(ctx context.Context, supportAddress string, email InboundEmail) (*Ticket, *Message, bool, error) {
+	ownID := normalizeMessageID(email.MessageID)
+	if ownID != "" {
+		if _, err := s.repo.FindTicketByEmailThread(ctx, []string{ownID}); err == nil {
+			return nil, nil, false, ErrDuplicateEmail
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, nil, false, err
+		}
+	}
+
+	body := strings.TrimSpace(email.Text)
+	if body == "" {
+		body = "(e-mail sem texto)"
+	}
+	subject := strings.TrimSpace(email.Subject)
+	if subject == "" {
+		subject = "Chamado por e-mail"
+	}
+
+	ticket, err := s.repo.FindTicketByEmailThread(ctx, email.ThreadIDs())
+	if errors.Is(err, ErrNotFound) {
+		tag := RecipientTag(email.To, supportAddress)
+		if ticketID, parseErr := uuid.Parse(tag); parseErr == nil {
+			ticket, err = s.repo.GetTicket(ctx, ticketID)
+		}
+	}
+
+	created := false
+	switch {
+	case err == nil:
+		if ticket.Status == StatusResolved || ticket.Status == StatusClosed {
+			reopened := StatusOpen
+			if ticket, err = s.UpdateTicket(ctx, ticket.ID, &reopened, nil, nil, false); err != nil {
+				return nil, nil, false, err
+			}
+		}
+	case errors.Is(err, ErrNotFound):
+		tenantID, err := s.repo.FindTenantForEmail(ctx, RecipientTag(email.To, supportAddress), email.From)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		requester := email.From
+		ticket, err = s.CreateTicket(ctx, CreateTicketInput{
+			TenantID:       tenantID,
+			Subject:        subject,
+			Category:       ChannelEmail,
+			Description:    body,
+			Tags:           []string{ChannelEmail},
+			RequesterEmail: &requester,
+		})
+		if err != nil {
+			return nil, nil, false, err
+		}
+		created = true
+	default:
+		return nil, nil, false, err
+	}
+
+	from := email.From
+	input := CreateMessageInput{
+		TicketID:   ticket.ID,
+		AuthorType: AuthorTenant,
+		Body:       body,
+		Channel:    ChannelEmail,
+		EmailFrom:  &from,
+	}
+	if ownID != "" {
+		input.EmailMessageID = &ownID
+	}
+	message, err := s.AddMessage(ctx, input)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return ticket, message, created, nil
+}
+
+// AddAttachment registra anexo já armazenado.
+func (s *Service) AddAttachment(ctx context.Context, input CreateAttachmentInput) (*Attachment, error) {
+	return s.repo.CreateAttachment(ctx, input)
+}
+
+// EmailThread lista os Message-IDs do chamado para montar References.
+func (s *Service) EmailThread(ctx context.Context, ticketID uuid.UUID) ([]string, error) {
+	return s.repo.EmailThread(ctx, ticketID)
+}
+
+// SetMessageEmail associa o Message-ID enviado à mensagem.
+func (s *Service) SetMessageEmail(ctx context.Context, messageID uuid.UUID, emailMessageID string) error {
+	return s.repo.SetMessageEmail(ctx, messageID, emailMessageID)
+}
@@ -12,6 +12,9 @@ var (
 	ErrNotFound      = errors.New("tenant not found")
 	ErrInvalidStatus = errors.New("invalid tenant status")
 	ErrInvalidDNS    = errors.New("invalid tenant dns status")
+	ErrInvalidEnv    = errors.New("invalid tenant environment")
+	ErrNotSandbox    = errors.New("tenant is not a sandbox")
+	ErrWasActivated  = errors.New("activated tenant cannot become a sandbox")
 )
 
 const (
@@ -29,6 +32,18 @@ const (
 	DNSStatusFailed      = "failed"
 )
 
+// Ambientes do tenant. Em sandbox nada sai para os provedores: a fila de e-mail (mail.Outbox), o
+// notify.Dispatcher e os avisos de falta registram as mensagens como suppressed.
+const (
+	EnvironmentProduction = "production"
+	EnvironmentSandbox    = "sandbox"
+)
+
+var validEnvironments = map[string]struct{}{
+	EnvironmentProduction: {},
+	EnvironmentSandbox:    {},
+}
+
 var validTenantStatuses = map[string]struct{}{
 	StatusDraft:     {},
 	StatusReview:    {},
@@ -61,6 +76,9 @@ type Tenant struct {
 	Settings       map[string]any `json:"settings"`
 	CreatedBy      *uuid.UUID     `json:"created_by,omitempty"`
 	ActivatedAt    *time.Time     `json:"activated_at,omitempty"`
+	Environment    string         `json:"environment"`
+	Sandbox        bool           `json:"sandbox"`
+	SandboxResetAt *time.Time     `json:"sandbox_reset_at,omitempty"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 }
@@ -71,6 +89,7 @@ type CreateTenantInput struct {
 	DisplayName string
 	Domain      string
 	Status      string
+	Environment string
 	Contact     map[string]any
 	Theme       map[string]any
 	Settings    map[string]any
@@ -114,3 +133,18 @@ func IsValidDNSStatus(status string) bool {
 	_, ok := validDNSStatuses[strings.ToLower(strings.TrimSpace(status))]
 	return ok
 }
+
+// NormalizeEnvironment padroniza o ambiente informado.
+func NormalizeEnvironment(env string) string {
+	env = strings.ToLower(strings.TrimSpace(env))
+	if env == "" {
+		return EnvironmentProduction
+	}
+	return env
+}
+
+// IsValidEnvironment verifica se o ambiente é aceito.
+func IsValidEnvironment(env string) bool {
+	_, ok := validEnvironments[strings.ToLower(strings.TrimSpace(env))]
+	return ok
+}
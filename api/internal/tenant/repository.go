@@ -24,7 +24,7 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 // GetByDomain busca tenant pelo domínio normalizado.
 func (r *Repository) GetByDomain(ctx context.Context, domain string) (*Tenant, error) {
 	const query = `
-        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, created_at, updated_at
+        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, environment, sandbox_reset_at, created_at, updated_at
         FROM tenants
         WHERE domain = $1
     `
@@ -43,7 +43,7 @@ func (r *Repository) GetByDomain(ctx context.Context, domain string) (*Tenant, e
 // GetByID busca tenant pelo identificador.
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error) {
 	const query = `
-        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, created_at, updated_at
+        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, environment, sandbox_reset_at, created_at, updated_at
         FROM tenants
         WHERE id = $1
     `
@@ -62,7 +62,7 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error)
 // GetBySlug busca tenant pelo slug.
 func (r *Repository) GetBySlug(ctx context.Context, slug string) (*Tenant, error) {
 	const query = `
-        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, created_at, updated_at
+        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, environment, sandbox_reset_at, created_at, updated_at
         FROM tenants
         WHERE slug = $1
     `
@@ -81,7 +81,7 @@ func (r *Repository) GetBySlug(ctx context.Context, slug string) (*Tenant, error
 // List devolve todos os tenants ordenados por criação.
 func (r *Repository) List(ctx context.Context) ([]Tenant, error) {
 	const query = `
-        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, created_at, updated_at
+        SELECT id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, environment, sandbox_reset_at, created_at, updated_at
         FROM tenants
         ORDER BY created_at DESC
     `
@@ -111,9 +111,9 @@ func (r *Repository) List(ctx context.Context) ([]Tenant, error) {
 // Create insere um novo tenant e devolve os dados persistidos.
 func (r *Repository) Create(ctx context.Context, input CreateTenantInput) (*Tenant, error) {
 	const query = `
-        INSERT INTO tenants (slug, display_name, domain, status, contact, theme, settings, logo_url, notes, created_by)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-        RETURNING id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, created_at, updated_at
+        INSERT INTO tenants (slug, display_name, domain, status, contact, theme, settings, logo_url, notes, created_by, environment)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id, slug, display_name, domain, status, dns_status, dns_last_checked_at, dns_error, logo_url, notes, contact, theme, settings, created_by, activated_at, environment, sandbox_reset_at, created_at, updated_at
     `
 
 	contactJSON, err := jsonMarshalMap(input.Contact)
@@ -140,11 +140,32 @@ func (r *Repository) Create(ctx context.Context, input CreateTenantInput) (*Tena
 		input.LogoURL,
 		input.Notes,
 		input.CreatedBy,
+		input.Environment,
 	)
 
 	return scanTenant(row)
 }
 
+// UpdateStatus altera o status; activated_at marca a primeira ativação.
+func (r *Repository) UpdateStatus(ctx context.Context, tenantID uuid.UUID, status string) error {
+	const query = `
+        UPDATE tenants
+        SET status = $2,
+            activated_at = CASE WHEN $2 = 'active' THEN COALESCE(activated_at, now()) ELSE activated_at END,
+            updated_at = now()
+        WHERE id = $1
+    `
+
+	tag, err := r.pool.Exec(ctx, query, tenantID, status)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // UpdateSettings atualiza apenas o campo settings e o timestamp.
 // UpdateDNSStatus atualiza campos de DNS do tenant.
 func (r *Repository) UpdateDNSStatus(ctx context.Context, tenantID uuid.UUID, status string, lastChecked *time.Time, dnsErr *string) error {
@@ -191,6 +212,81 @@ func (r *Repository) UpdateSettings(ctx context.Context, tenantID uuid.UUID, set
 	return nil
 }
 
+// UpdateEnvironment alterna o tenant entre produção e sandbox. Um tenant de produção que já foi
+// ativado não volta para sandbox: lá o reset e a exclusão apagariam dados reais da prefeitura.
+func (r *Repository) UpdateEnvironment(ctx context.Context, tenantID uuid.UUID, environment string) error {
+	const query = `
+        UPDATE tenants
+        SET environment = $2,
+            updated_at = now()
+        WHERE id = $1
+          AND ($2 <> 'sandbox' OR environment = 'sandbox' OR activated_at IS NULL)
+    `
+
+	tag, err := r.pool.Exec(ctx, query, tenantID, environment)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tenants WHERE id = $1)`, tenantID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrWasActivated
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+// sandboxResetStatements apagam dados operacionais de um tenant sandbox.
+// A ordem respeita dependências entre tabelas.
+var sandboxResetStatements = []string{
+	`DELETE FROM support_tickets WHERE tenant_id = $1`,
+	`DELETE FROM saas_push_notifications WHERE tenant_id = $1`,
+	`DELETE FROM saas_access_logs WHERE tenant_id = $1`,
+	`DELETE FROM monitor_check_events WHERE tenant_id = $1`,
+	`DELETE FROM monitor_alerts WHERE tenant_id = $1`,
+}
+
+// ResetSandboxData remove dados operacionais do tenant mantendo cadastro e configuração.
+func (r *Repository) ResetSandboxData(ctx context.Context, tenantID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, stmt := range sandboxResetStatements {
+		if _, err := tx.Exec(ctx, stmt, tenantID); err != nil {
+			return err
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE tenants SET sandbox_reset_at = now(), updated_at = now() WHERE id = $1 AND environment = 'sandbox'`, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotSandbox
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Delete remove definitivamente um tenant sandbox.
+func (r *Repository) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM tenants WHERE id = $1 AND environment = 'sandbox'`, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotSandbox
+	}
+	return nil
+}
+
 func scanTenant(row pgx.Row) (*Tenant, error) {
 	var (
 		t              Tenant
@@ -203,9 +299,10 @@ func scanTenant(row pgx.Row) (*Tenant, error) {
 		settingsRaw    []byte
 		createdBy      *uuid.UUID
 		activatedAt    *time.Time
+		sandboxResetAt *time.Time
 	)
 
-	if err := row.Scan(&t.ID, &t.Slug, &t.DisplayName, &t.Domain, &t.Status, &t.DNSStatus, &dnsLastChecked, &dnsError, &logoURL, &notes, &contactRaw, &themeRaw, &settingsRaw, &createdBy, &activatedAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	if err := row.Scan(&t.ID, &t.Slug, &t.DisplayName, &t.Domain, &t.Status, &t.DNSStatus, &dnsLastChecked, &dnsError, &logoURL, &notes, &contactRaw, &themeRaw, &settingsRaw, &createdBy, &activatedAt, &t.Environment, &sandboxResetAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, ErrNotFound
 		}
@@ -230,6 +327,10 @@ func scanTenant(row pgx.Row) (*Tenant, error) {
 	if activatedAt != nil {
 		t.ActivatedAt = activatedAt
 	}
+	if sandboxResetAt != nil {
+		t.SandboxResetAt = sandboxResetAt
+	}
+	t.Sandbox = t.Environment == EnvironmentSandbox
 
 	contact, err := decodeJSONMap(contactRaw)
 	if err != nil {
@@ -59,10 +59,14 @@ func (s *Service) Create(ctx context.Context, input CreateTenantInput) (*Tenant,
 	input.Slug = normalizeSlug(input.Slug)
 	input.Domain = normalizeDomain(input.Domain)
 	input.Status = NormalizeStatus(input.Status)
+	input.Environment = NormalizeEnvironment(input.Environment)
 
 	if !IsValidStatus(input.Status) {
 		return nil, ErrInvalidStatus
 	}
+	if !IsValidEnvironment(input.Environment) {
+		return nil, ErrInvalidEnv
+	}
 	if input.Contact == nil {
 		input.Contact = map[string]any{}
 	}
@@ -114,14 +118,7 @@ func (s *Service) UpdateDNSStatus(ctx context.Context, tenantID uuid.UUID, statu
 	if err := s.repo.UpdateDNSStatus(ctx, tenantID, status, lastChecked, errMsg); err != nil {
 		return err
 	}
-	s.cache.Range(func(key, value any) bool {
-		entry := value.(cachedTenant)
-		if entry.tenant.ID == tenantID {
-			s.cache.Delete(key)
-			return false
-		}
-		return true
-	})
+	s.invalidate(tenantID)
 	return nil
 }
 
@@ -140,16 +137,64 @@ func (s *Service) UpdateSettings(ctx context.Context, tenantID string, settings
 	}
 
 	// Limpa cache forçando refetch na próxima resolução.
+	s.invalidate(id)
+
+	return nil
+}
+
+// SetStatus muda o ciclo de vida do tenant (draft, active, suspended...).
+func (s *Service) SetStatus(ctx context.Context, tenantID uuid.UUID, status string) (*Tenant, error) {
+	status = NormalizeStatus(status)
+	if !IsValidStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+	if err := s.repo.UpdateStatus(ctx, tenantID, status); err != nil {
+		return nil, err
+	}
+	s.invalidate(tenantID)
+	return s.GetByID(ctx, tenantID)
+}
+
+// SetEnvironment alterna o tenant entre produção e sandbox.
+func (s *Service) SetEnvironment(ctx context.Context, tenantID uuid.UUID, environment string) (*Tenant, error) {
+	environment = NormalizeEnvironment(environment)
+	if !IsValidEnvironment(environment) {
+		return nil, ErrInvalidEnv
+	}
+	if err := s.repo.UpdateEnvironment(ctx, tenantID, environment); err != nil {
+		return nil, err
+	}
+	s.invalidate(tenantID)
+	return s.GetByID(ctx, tenantID)
+}
+
+// ResetSandbox apaga dados operacionais de um tenant sandbox.
+func (s *Service) ResetSandbox(ctx context.Context, tenantID uuid.UUID) (*Tenant, error) {
+	if err := s.repo.ResetSandboxData(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	s.invalidate(tenantID)
+	return s.GetByID(ctx, tenantID)
+}
+
+// Delete remove um tenant; permitido apenas em sandbox.
+func (s *Service) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	if err := s.repo.Delete(ctx, tenantID); err != nil {
+		return err
+	}
+	s.invalidate(tenantID)
+	return nil
+}
+
+func (s *Service) invalidate(tenantID uuid.UUID) {
 	s.cache.Range(func(key, value any) bool {
 		entry := value.(cachedTenant)
-		if entry.tenant.ID == id {
+		if entry.tenant.ID == tenantID {
 			s.cache.Delete(key)
 			return false
 		}
 		return true
 	})
-
-	return nil
 }
 
 // List devolve todos os tenants.
@@ -0,0 +1,517 @@
+package tenantmove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+// Copy copia os dados do tenant da origem para o destino numa única transação no destino, lendo
+// a origem num snapshot consistente. Se o destino já tiver linhas do tenant a cópia é recusada,
+// a menos que reset apague essas linhas antes.
+//
+// Triggers e chaves estrangeiras ficam desligados durante a cópia (session_replication_role =
+// replica), o que exige um usuário com permissão para isso no destino.
+func (m *Mover) Copy(ctx context.Context, id uuid.UUID, reset bool) (Migration, error) {
+	mig, err := m.carregar(ctx, id, StatusPending, StatusCopied, StatusVerified)
+	if err != nil {
+		return mig, err
+	}
+	if mig.Status != StatusPending && !reset {
+		return mig, ErrDestinoOcupado
+	}
+	tabelas, err := m.copiar(ctx, mig, reset)
+	if err != nil {
+		return mig, m.falhar(ctx, id, err)
+	}
+	if _, err := m.control.Exec(ctx, `
+        UPDATE tenant_migrations
+        SET status = 'copied', tabelas = $2, erro = NULL, copied_at = now(), verified_at = NULL, updated_at = now()
+        WHERE id = $1
+    `, id, tabelas); err != nil {
+		return mig, err
+	}
+	m.logger.Info().Str("tenant", mig.TenantSlug).Str("destino", mig.Destino).Int("tabelas", len(tabelas)).Msg("tenantmove: cópia concluída")
+	return Get(ctx, m.control, id)
+}
+
+func (m *Mover) copiar(ctx context.Context, mig Migration, reset bool) ([]Tabela, error) {
+	src, dst, err := m.abrir(ctx, mig, false)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Rollback(ctx)
+	defer dst.Rollback(ctx)
+
+	plano, err := DescobrirPlano(ctx, src, mig.TenantID, foraDaMigracao(mig.Excluir))
+	if err != nil {
+		return nil, err
+	}
+	// No banco principal o registro de tenants é o do plano de controle e já existe.
+	if mig.Destino == db.PrimaryCluster {
+		plano = plano[1:]
+	}
+	if _, err := dst.Exec(ctx, `SET LOCAL session_replication_role = replica`); err != nil {
+		return nil, fmt.Errorf("desligar triggers no destino: %w", err)
+	}
+
+	if !reset {
+		for _, alvo := range plano {
+			if alvo.Compartilhada {
+				continue
+			}
+			var existe bool
+			if err := dst.QueryRow(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s)`, alvo.Nome(), alvo.Filtro)).Scan(&existe); err != nil {
+				return nil, fmt.Errorf("%s: %w", alvo.Nome(), err)
+			}
+			if existe {
+				return nil, fmt.Errorf("%w (%s)", ErrDestinoOcupado, alvo.Nome())
+			}
+		}
+	} else if err := apagar(ctx, dst, plano); err != nil {
+		return nil, err
+	}
+
+	tabelas := make([]Tabela, 0, len(plano))
+	for _, alvo := range plano {
+		cols, err := Colunas(ctx, src, alvo)
+		if err != nil {
+			return nil, err
+		}
+		lidas, gravadas, err := copiarTabela(ctx, src, dst, alvo, cols)
+		if err != nil {
+			return nil, fmt.Errorf("copiar %s: %w", alvo.Nome(), err)
+		}
+		tabelas = append(tabelas, Tabela{Nome: alvo.Nome(), LinhasOrigem: lidas, LinhasDestino: gravadas, OK: lidas == gravadas})
+		if err := ajustarSequencias(ctx, dst, alvo); err != nil {
+			return nil, fmt.Errorf("sequências de %s: %w", alvo.Nome(), err)
+		}
+	}
+	if err := dst.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return tabelas, nil
+}
+
+// abrir inicia as transações de leitura na origem e de trabalho no destino. Com somenteLeitura o
+// destino também fica num snapshot REPEATABLE READ, como na verificação.
+func (m *Mover) abrir(ctx context.Context, mig Migration, somenteLeitura bool) (pgx.Tx, pgx.Tx, error) {
+	origem, err := m.pool(ctx, mig.Origem)
+	if err != nil {
+		return nil, nil, err
+	}
+	destino, err := m.pool(ctx, mig.Destino)
+	if err != nil {
+		return nil, nil, err
+	}
+	leitura := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	src, err := origem.BeginTx(ctx, leitura)
+	if err != nil {
+		return nil, nil, fmt.Errorf("origem %s: %w", mig.Origem, err)
+	}
+	opts := pgx.TxOptions{}
+	if somenteLeitura {
+		opts = leitura
+	}
+	dst, err := destino.BeginTx(ctx, opts)
+	if err != nil {
+		_ = src.Rollback(ctx)
+		return nil, nil, fmt.Errorf("destino %s: %w", mig.Destino, err)
+	}
+	return src, dst, nil
+}
+
+// Colunas lista as colunas copiáveis da tabela, citadas e na ordem física; colunas geradas ficam
+// de fora porque o destino as recalcula.
+func Colunas(ctx context.Context, tx pgx.Tx, alvo Alvo) (string, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT attname FROM pg_attribute
+        WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+        ORDER BY attnum
+    `, alvo.Nome())
+	if err != nil {
+		return "", fmt.Errorf("colunas de %s: %w", alvo.Nome(), err)
+	}
+	nomes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (string, error) {
+		var nome string
+		err := row.Scan(&nome)
+		return pgx.Identifier{nome}.Sanitize(), err
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(nomes, ", "), nil
+}
+
+// copiarTabela liga o COPY TO da origem ao COPY FROM do destino por um pipe, sem materializar a
+// tabela em memória. Devolve as linhas lidas e gravadas. Tabelas compartilhadas passam por uma
+// tabela temporária e só ganham as linhas que o destino ainda não tem.
+func copiarTabela(ctx context.Context, src, dst pgx.Tx, alvo Alvo, cols string) (int64, int64, error) {
+	destino := alvo.Nome()
+	if alvo.Compartilhada {
+		destino = pgx.Identifier{"tenantmove_" + alvo.Tabela[len(alvo.Tabela)-1]}.Sanitize()
+		if _, err := dst.Exec(ctx, fmt.Sprintf(`CREATE TEMP TABLE %s (LIKE %s) ON COMMIT DROP`, destino, alvo.Nome())); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	pr, pw := io.Pipe()
+	var lidas int64
+	errc := make(chan error, 1)
+	go func() {
+		tag, err := src.Conn().PgConn().CopyTo(ctx, pw, fmt.Sprintf("COPY (SELECT %s FROM %s WHERE %s) TO STDOUT", cols, alvo.Nome(), alvo.Filtro))
+		lidas = tag.RowsAffected()
+		pw.CloseWithError(err)
+		errc <- err
+	}()
+
+	tag, err := dst.Conn().PgConn().CopyFrom(ctx, pr, fmt.Sprintf("COPY %s (%s) FROM STDIN", destino, cols))
+	pr.CloseWithError(err)
+	srcErr := <-errc
+	if srcErr != nil && !errors.Is(srcErr, io.ErrClosedPipe) {
+		return 0, 0, srcErr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if alvo.Compartilhada {
+		if _, err := dst.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING`,
+			alvo.Nome(), cols, cols, destino)); err != nil {
+			return 0, 0, err
+		}
+	}
+	return lidas, tag.RowsAffected(), nil
+}
+
+// ajustarSequencias avança as sequências de colunas serial do destino para além do maior valor
+// copiado, para que inserções novas não colidam.
+func ajustarSequencias(ctx context.Context, tx pgx.Tx, alvo Alvo) error {
+	rows, err := tx.Query(ctx, `
+        SELECT a.attname, pg_get_serial_sequence($1, a.attname)
+        FROM pg_attribute a
+        WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+          AND pg_get_serial_sequence($1, a.attname) IS NOT NULL
+    `, alvo.Nome())
+	if err != nil {
+		return err
+	}
+	type sequencia struct{ coluna, nome string }
+	seqs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (sequencia, error) {
+		var s sequencia
+		err := row.Scan(&s.coluna, &s.nome)
+		return s, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, s := range seqs {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+            SELECT setval($1::regclass, GREATEST(
+                COALESCE((SELECT max(%s) FROM %s), 0),
+                COALESCE(pg_sequence_last_value($1::regclass), 0),
+                1))
+        `, pgx.Identifier{s.coluna}.Sanitize(), alvo.Nome()), s.nome); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apagar remove as linhas do tenant na ordem inversa do plano, filhas antes das mães. Tabelas
+// compartilhadas ficam intactas.
+func apagar(ctx context.Context, tx pgx.Tx, plano []Alvo) error {
+	for i := len(plano) - 1; i >= 0; i-- {
+		if plano[i].Compartilhada {
+			continue
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s`, plano[i].Nome(), plano[i].Filtro)); err != nil {
+			return fmt.Errorf("apagar %s: %w", plano[i].Nome(), err)
+		}
+	}
+	return nil
+}
+
+// Verify compara, tabela a tabela, contagem e checksum das linhas do tenant nos dois clusters e
+// procura no destino linhas cujas chaves estrangeiras não encontram a mãe. Divergências ficam registradas em tabelas e a migração continua em copied.
+func (m *Mover) Verify(ctx context.Context, id uuid.UUID) (Migration, error) {
+	mig, err := m.carregar(ctx, id, StatusCopied, StatusVerified)
+	if err != nil {
+		return mig, err
+	}
+	if err := m.verificarERegistrar(ctx, mig); err != nil {
+		return mig, err
+	}
+	if _, err := m.control.Exec(ctx, `
+        UPDATE tenant_migrations SET status = 'verified', verified_at = now(), updated_at = now() WHERE id = $1
+    `, id); err != nil {
+		return mig, err
+	}
+	return Get(ctx, m.control, id)
+}
+
+// verificarERegistrar roda a verificação e grava o resultado por tabela; devolve ErrVerificacao
+// se alguma tabela divergir.
+func (m *Mover) verificarERegistrar(ctx context.Context, mig Migration) error {
+	tabelas, err := m.verificar(ctx, mig)
+	if err != nil {
+		return m.falhar(ctx, mig.ID, err)
+	}
+	var erro *string
+	if divergentes := contarDivergentes(tabelas); divergentes > 0 {
+		msg := fmt.Sprintf("%s: %d tabela(s)", ErrVerificacao.Error(), divergentes)
+		erro = &msg
+	}
+	if _, err := m.control.Exec(ctx, `
+        UPDATE tenant_migrations SET tabelas = $2, erro = $3, updated_at = now() WHERE id = $1
+    `, mig.ID, tabelas, erro); err != nil {
+		return err
+	}
+	if erro != nil {
+		return ErrVerificacao
+	}
+	return nil
+}
+
+func contarDivergentes(tabelas []Tabela) int {
+	var n int
+	for _, t := range tabelas {
+		if !t.OK {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *Mover) verificar(ctx context.Context, mig Migration) ([]Tabela, error) {
+	src, dst, err := m.abrir(ctx, mig, true)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Rollback(ctx)
+	defer dst.Rollback(ctx)
+
+	// A representação em texto das linhas depende dessas configurações; iguais nos dois lados, o
+	// checksum só muda se os dados mudarem.
+	for _, tx := range []pgx.Tx{src, dst} {
+		if _, err := tx.Exec(ctx, `
+            SELECT set_config('TimeZone', 'UTC', true), set_config('DateStyle', 'ISO, YMD', true),
+                   set_config('IntervalStyle', 'postgres', true), set_config('extra_float_digits', '3', true)
+        `); err != nil {
+			return nil, err
+		}
+	}
+
+	plano, err := DescobrirPlano(ctx, src, mig.TenantID, foraDaMigracao(mig.Excluir))
+	if err != nil {
+		return nil, err
+	}
+	tabelas := make([]Tabela, 0, len(plano))
+	for i, alvo := range plano {
+		cols, err := Colunas(ctx, src, alvo)
+		if err != nil {
+			return nil, err
+		}
+		t := Tabela{Nome: alvo.Nome()}
+		if t.LinhasOrigem, t.ChecksumOrigem, err = resumo(ctx, src, alvo, cols); err != nil {
+			return nil, fmt.Errorf("origem %s: %w", alvo.Nome(), err)
+		}
+		if t.LinhasDestino, t.ChecksumDestino, err = resumo(ctx, dst, alvo, cols); err != nil {
+			return nil, fmt.Errorf("destino %s: %w", alvo.Nome(), err)
+		}
+		if i == 0 || alvo.Compartilhada {
+			// O registro de tenants diverge por natureza (db_cluster, settings editados no painel) e
+			// linhas compartilhadas podem ter chegado antes por outro tenant; basta que existam.
+			t.ChecksumOrigem, t.ChecksumDestino = "", ""
+		}
+		if t.Orfas, err = orfas(ctx, dst, alvo); err != nil {
+			return nil, fmt.Errorf("destino %s: %w", alvo.Nome(), err)
+		}
+		t.OK = t.LinhasOrigem == t.LinhasDestino && t.ChecksumOrigem == t.ChecksumDestino && t.Orfas == 0
+		tabelas = append(tabelas, t)
+	}
+	return tabelas, nil
+}
+
+// resumo conta as linhas do tenant e calcula um md5 das linhas ordenadas, independente da ordem
+// física no disco.
+func resumo(ctx context.Context, tx pgx.Tx, alvo Alvo, cols string) (int64, string, error) {
+	var linhas int64
+	var checksum string
+	err := tx.QueryRow(ctx, fmt.Sprintf(`
+        SELECT count(*), COALESCE(md5(string_agg(md5(r::text), '' ORDER BY md5(r::text))), '')
+        FROM (SELECT %s FROM %s WHERE %s) r
+    `, cols, alvo.Nome(), alvo.Filtro)).Scan(&linhas, &checksum)
+	return linhas, checksum, err
+}
+
+// orfas conta, entre as linhas do tenant, as que apontam para uma mãe inexistente. A cópia roda
+// com as chaves estrangeiras desligadas, então só esta consulta garante a integridade no destino.
+func orfas(ctx context.Context, tx pgx.Tx, alvo Alvo) (int64, error) {
+	var total int64
+	for _, ref := range alvo.Referencias {
+		coluna := pgx.Identifier{ref.Coluna}.Sanitize()
+		var n int64
+		err := tx.QueryRow(ctx, fmt.Sprintf(`
+            SELECT count(*) FROM (SELECT %s AS ref FROM %s WHERE %s) f
+            WHERE f.ref IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %s m WHERE m.%s = f.ref)
+        `, coluna, alvo.Nome(), alvo.Filtro, ref.Mae.Sanitize(), pgx.Identifier{ref.ColunaMae}.Sanitize())).Scan(&n)
+		if err != nil {
+			return 0, fmt.Errorf("chave %s: %w", ref.Coluna, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Switch congela o tenant, espera o roteamento em cache expirar, repete a verificação e, se tudo
+// bater, aponta tenants.db_cluster para o destino e descongela. Se a origem mudou desde a última
+// cópia, a cópia é refeita (com reset) ainda congelada e verificada de novo. Em qualquer falha o
+// tenant é descongelado na origem e a migração continua verified.
+func (m *Mover) Switch(ctx context.Context, id uuid.UUID) (Migration, error) {
+	mig, err := m.carregar(ctx, id, StatusVerified)
+	if err != nil {
+		return mig, err
+	}
+	if err := rotasPendentes(mig); err != nil {
+		return mig, err
+	}
+	if err := m.congelar(ctx, mig); err != nil {
+		return mig, m.falhar(ctx, id, err)
+	}
+	if err := m.trocar(ctx, mig); err != nil {
+		m.descongelar(mig)
+		if errors.Is(err, ErrVerificacao) {
+			return mig, err
+		}
+		return mig, m.falhar(ctx, id, err)
+	}
+	m.logger.Info().Str("tenant", mig.TenantSlug).Str("cluster", mig.Destino).Msg("tenantmove: roteamento trocado")
+	return Get(ctx, m.control, id)
+}
+
+// rotasPendentes recusa switch e retire de quem sai do primary enquanto houver RotasPendentes.
+func rotasPendentes(mig Migration) error {
+	if mig.Origem == db.PrimaryCluster && len(RotasPendentes) > 0 {
+		return fmt.Errorf("%w: %s", ErrRotasPendentes, strings.Join(RotasPendentes, "; "))
+	}
+	return nil
+}
+
+// congelar marca tenants.db_frozen; a partir daí o db.Resolver recusa as requisições do tenant e
+// os jobs o pulam.
+func (m *Mover) congelar(ctx context.Context, mig Migration) error {
+	tag, err := m.control.Exec(ctx, `
+        UPDATE tenants SET db_frozen = true WHERE id = $1 AND db_cluster = $2
+    `, mig.TenantID, mig.Origem)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRoteamentoMudou
+	}
+	m.logger.Info().Str("tenant", mig.TenantSlug).Dur("espera", m.espera).Msg("tenantmove: tenant congelado")
+	return nil
+}
+
+// descongelar devolve o tenant à origem depois de um switch que falhou, mesmo com o contexto da
+// etapa cancelado.
+func (m *Mover) descongelar(mig Migration) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := m.control.Exec(ctx, `
+        UPDATE tenants SET db_frozen = false WHERE id = $1 AND db_cluster = $2
+    `, mig.TenantID, mig.Origem); err != nil {
+		m.logger.Error().Err(err).Str("tenant", mig.TenantSlug).Msg("tenantmove: falha ao descongelar o tenant")
+	}
+}
+
+// trocar roda, com o tenant congelado, a espera, a verificação (e a recópia, se preciso) e a troca
+// do roteamento.
+func (m *Mover) trocar(ctx context.Context, mig Migration) error {
+	select {
+	case <-time.After(m.espera):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	err := m.verificarERegistrar(ctx, mig)
+	if errors.Is(err, ErrVerificacao) {
+		m.logger.Info().Str("tenant", mig.TenantSlug).Msg("tenantmove: origem mudou desde a cópia; recopiando congelado")
+		if _, err := m.copiar(ctx, mig, true); err != nil {
+			return err
+		}
+		err = m.verificarERegistrar(ctx, mig)
+	}
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, m.control, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+            UPDATE tenants SET db_cluster = $2, db_frozen = false WHERE id = $1 AND db_cluster = $3 AND db_frozen
+        `, mig.TenantID, mig.Destino, mig.Origem)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrRoteamentoMudou
+		}
+		_, err = tx.Exec(ctx, `
+            UPDATE tenant_migrations SET status = 'switched', erro = NULL, switched_at = now(), updated_at = now()
+            WHERE id = $1
+        `, mig.ID)
+		return err
+	})
+}
+
+// Retire apaga a cópia antiga do tenant na origem depois da troca. No banco principal o registro
+// de tenants permanece, porque é ele que guarda o roteamento. Saindo do primary, recusa enquanto
+// houver RotasPendentes; a migração continua switched e o retire pode ser repetido depois.
+func (m *Mover) Retire(ctx context.Context, id uuid.UUID) (Migration, error) {
+	mig, err := m.carregar(ctx, id, StatusSwitched)
+	if err != nil {
+		return mig, err
+	}
+	if err := rotasPendentes(mig); err != nil {
+		return mig, err
+	}
+	origem, err := m.pool(ctx, mig.Origem)
+	if err != nil {
+		return mig, m.falhar(ctx, id, err)
+	}
+	if err := retirar(ctx, origem, mig); err != nil {
+		return mig, m.falhar(ctx, id, err)
+	}
+	if _, err := m.control.Exec(ctx, `
+        UPDATE tenant_migrations SET status = 'retired', erro = NULL, retired_at = now(), updated_at = now()
+        WHERE id = $1
+    `, id); err != nil {
+		return mig, err
+	}
+	m.logger.Info().Str("tenant", mig.TenantSlug).Str("cluster", mig.Origem).Msg("tenantmove: cópia antiga removida")
+	return Get(ctx, m.control, id)
+}
+
+func retirar(ctx context.Context, pool *pgxpool.Pool, mig Migration) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SET LOCAL session_replication_role = replica`); err != nil {
+			return err
+		}
+		plano, err := DescobrirPlano(ctx, tx, mig.TenantID, foraDaMigracao(mig.Excluir))
+		if err != nil {
+			return err
+		}
+		if mig.Origem == db.PrimaryCluster {
+			plano = plano[1:]
+		}
+		return apagar(ctx, tx, plano)
+	})
+}
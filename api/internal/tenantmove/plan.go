@@ -0,0 +1,273 @@
+package tenantmove
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// profundidadeFK limita quantos níveis de chaves estrangeiras são seguidos a partir das tabelas
+// com tenant_id; cobre cadeias como escola → turma → matrícula → presença.
+const profundidadeFK = 4
+
+// Alvo é uma tabela a mover e o filtro que seleciona as linhas do tenant. COPY não aceita
+// parâmetros, então o UUID do tenant vai interpolado no filtro.
+type Alvo struct {
+	Tabela pgx.Identifier
+	Filtro string
+	// Compartilhada marca tabelas sem tenant_id trazidas por serem mães de linhas do tenant, como
+	// alunos e usuarios: a cópia não sobrescreve linhas que o destino já tenha e nem o reset nem a
+	// retirada as apagam, porque outros tenants podem apontar para as mesmas linhas.
+	Compartilhada bool
+	// Referencias são as chaves estrangeiras da tabela para outras tabelas do plano, conferidas no
+	// destino pela verificação.
+	Referencias []Referencia
+}
+
+// Referencia é uma chave estrangeira de uma coluna para outra tabela do plano.
+type Referencia struct {
+	Coluna    string
+	Mae       pgx.Identifier
+	ColunaMae string
+}
+
+// Nome devolve a tabela qualificada e citada, usada como chave nos relatórios.
+func (a Alvo) Nome() string { return a.Tabela.Sanitize() }
+
+type chaveEstrangeira struct {
+	filha     pgx.Identifier
+	coluna    string
+	mae       pgx.Identifier
+	colunaMae string
+}
+
+// foraDaMigracao devolve o critério de exclusão da migração entre clusters: as tabelas do plano
+// de controle e as pedidas em extras.
+func foraDaMigracao(extras []string) func(pgx.Identifier) bool {
+	return func(t pgx.Identifier) bool { return excluida(t, extras) }
+}
+
+// excluida informa se a tabela pertence ao plano de controle e nunca sai do banco principal:
+// cadastro do SaaS (saas_*), contratos e operações sobre tenants (tenant_*), monitoramento
+// (monitor_*), backups e as tabelas com tenant_id que só o primary lê e grava.
+func excluida(t pgx.Identifier, extras []string) bool {
+	nome := t[len(t)-1]
+	if strings.HasPrefix(nome, "saas_") || strings.HasPrefix(nome, "tenant_") || strings.HasPrefix(nome, "monitor_") {
+		return true
+	}
+	if _, ok := controle[nome]; ok {
+		return true
+	}
+	for _, extra := range extras {
+		if nome == extra || t.Sanitize() == extra {
+			return true
+		}
+	}
+	return false
+}
+
+// controle lista as tabelas do plano de controle fora dos prefixos: os tokens SCIM, que a
+// autenticação procura antes de saber o tenant, a fila de e-mails e o suporte, processados só no
+// primary, e o catálogo de dados abertos e a base de conhecimento, servidos dali.
+var controle = map[string]struct{}{
+	"backup_runs":        {},
+	"schema_migrations":  {},
+	"scim_tokens":        {},
+	"mail_outbox":        {},
+	"support_tickets":    {},
+	"opendata_resources": {},
+	"kb_articles":        {},
+}
+
+// planejar monta o plano de cópia: o registro do tenant, as tabelas com tenant_id, em largura as
+// tabelas que apontam por chave estrangeira para linhas já selecionadas e, por fim, as tabelas mães
+// sem tenant_id que essas linhas referenciam (compartilhadas). Cada tabela entra uma única vez,
+// pelo primeiro caminho encontrado, e o plano sai ordenado com as mães antes das filhas.
+func planejar(tenantID uuid.UUID, comTenant []pgx.Identifier, fks []chaveEstrangeira, fora func(pgx.Identifier) bool) []Alvo {
+	tenants := pgx.Identifier{"public", "tenants"}
+	plano := []Alvo{{Tabela: tenants, Filtro: fmt.Sprintf("id = '%s'", tenantID)}}
+	incluidas := map[string]int{tenants.Sanitize(): 0}
+
+	for _, t := range comTenant {
+		if _, ok := incluidas[t.Sanitize()]; ok || fora(t) {
+			continue
+		}
+		incluidas[t.Sanitize()] = len(plano)
+		plano = append(plano, Alvo{Tabela: t, Filtro: fmt.Sprintf("tenant_id = '%s'", tenantID)})
+	}
+
+	// Cada nível só olha para as tabelas que entraram no nível anterior; o registro de tenants
+	// (índice 0) não é mãe: referências diretas a ele sem tenant_id são do plano de controle.
+	inicio := 1
+	for nivel := 0; nivel < profundidadeFK && inicio < len(plano); nivel++ {
+		fim := len(plano)
+		for _, fk := range fks {
+			idx, ok := incluidas[fk.mae.Sanitize()]
+			if !ok || idx < inicio || idx >= fim {
+				continue
+			}
+			if _, ja := incluidas[fk.filha.Sanitize()]; ja || fora(fk.filha) {
+				continue
+			}
+			mae := plano[idx]
+			incluidas[fk.filha.Sanitize()] = len(plano)
+			plano = append(plano, Alvo{
+				Tabela: fk.filha,
+				Filtro: fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+					pgx.Identifier{fk.coluna}.Sanitize(), pgx.Identifier{fk.colunaMae}.Sanitize(), mae.Nome(), mae.Filtro),
+			})
+		}
+		inicio = fim
+	}
+
+	// Subindo: mães fora do plano entram com as linhas referenciadas por qualquer tabela já
+	// incluída. Referências que só aparecem num nível posterior não ampliam o filtro de uma mãe já
+	// incluída; a verificação de órfãs no destino acusa esse caso.
+	for nivel, fim := 0, 0; nivel < profundidadeFK && fim < len(plano); nivel++ {
+		fim = len(plano)
+		var novas []pgx.Identifier
+		filtros := map[string][]string{}
+		for _, fk := range fks {
+			idx, ok := incluidas[fk.filha.Sanitize()]
+			if !ok || idx >= fim {
+				continue
+			}
+			if _, ja := incluidas[fk.mae.Sanitize()]; ja || fora(fk.mae) {
+				continue
+			}
+			chave := fk.mae.Sanitize()
+			if _, ok := filtros[chave]; !ok {
+				novas = append(novas, fk.mae)
+			}
+			filha := plano[idx]
+			filtros[chave] = append(filtros[chave], fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+				pgx.Identifier{fk.colunaMae}.Sanitize(), pgx.Identifier{fk.coluna}.Sanitize(), filha.Nome(), filha.Filtro))
+		}
+		for _, mae := range novas {
+			incluidas[mae.Sanitize()] = len(plano)
+			plano = append(plano, Alvo{Tabela: mae, Filtro: strings.Join(filtros[mae.Sanitize()], " OR "), Compartilhada: true})
+		}
+		if len(novas) == 0 {
+			break
+		}
+	}
+
+	for _, fk := range fks {
+		filha, ok := incluidas[fk.filha.Sanitize()]
+		if !ok {
+			continue
+		}
+		if _, ok := incluidas[fk.mae.Sanitize()]; !ok {
+			continue
+		}
+		plano[filha].Referencias = append(plano[filha].Referencias, Referencia{Coluna: fk.coluna, Mae: fk.mae, ColunaMae: fk.colunaMae})
+	}
+	return ordenar(plano)
+}
+
+// ordenar devolve o plano com cada mãe antes das filhas, preservando a ordem original entre
+// tabelas independentes. Ciclos (além de autorreferências) seguem a ordem original.
+func ordenar(plano []Alvo) []Alvo {
+	posicao := make(map[string]int, len(plano))
+	for i, alvo := range plano {
+		posicao[alvo.Nome()] = i
+	}
+	pendentes := make([]int, len(plano))
+	filhas := make([][]int, len(plano))
+	for i, alvo := range plano {
+		vistas := map[int]bool{}
+		for _, ref := range alvo.Referencias {
+			mae := posicao[ref.Mae.Sanitize()]
+			if mae == i || vistas[mae] {
+				continue
+			}
+			vistas[mae] = true
+			pendentes[i]++
+			filhas[mae] = append(filhas[mae], i)
+		}
+	}
+
+	ordenado := make([]Alvo, 0, len(plano))
+	feito := make([]bool, len(plano))
+	for len(ordenado) < len(plano) {
+		proximo := -1
+		for i := range plano {
+			if !feito[i] && pendentes[i] == 0 {
+				proximo = i
+				break
+			}
+		}
+		if proximo < 0 {
+			for i := range plano {
+				if !feito[i] {
+					proximo = i
+					break
+				}
+			}
+		}
+		feito[proximo] = true
+		ordenado = append(ordenado, plano[proximo])
+		for _, filha := range filhas[proximo] {
+			pendentes[filha]--
+		}
+	}
+	return ordenado
+}
+
+// DescobrirPlano lê do catálogo as tabelas com tenant_id e as chaves estrangeiras de uma coluna e
+// monta o plano do tenant, com as mães antes das filhas; tabelas para as quais fora devolve
+// verdadeiro ficam de fora. Partições também: a cópia passa pela tabela mãe. O backup por tenant
+// usa o mesmo plano.
+func DescobrirPlano(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, fora func(pgx.Identifier) bool) ([]Alvo, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT n.nspname, c.relname
+        FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        JOIN pg_attribute a ON a.attrelid = c.oid AND a.attname = 'tenant_id' AND NOT a.attisdropped
+        WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition
+          AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+        ORDER BY n.nspname, c.relname
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("listar tabelas: %w", err)
+	}
+	comTenant, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pgx.Identifier, error) {
+		var schema, table string
+		err := row.Scan(&schema, &table)
+		return pgx.Identifier{schema, table}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+        SELECT fn.nspname, f.relname, fa.attname, mn.nspname, m.relname, ma.attname
+        FROM pg_constraint k
+        JOIN pg_class f ON f.oid = k.conrelid
+        JOIN pg_namespace fn ON fn.oid = f.relnamespace
+        JOIN pg_class m ON m.oid = k.confrelid
+        JOIN pg_namespace mn ON mn.oid = m.relnamespace
+        JOIN pg_attribute fa ON fa.attrelid = k.conrelid AND fa.attnum = k.conkey[1]
+        JOIN pg_attribute ma ON ma.attrelid = k.confrelid AND ma.attnum = k.confkey[1]
+        WHERE k.contype = 'f' AND cardinality(k.conkey) = 1 AND NOT f.relispartition AND k.conparentid = 0
+        ORDER BY fn.nspname, f.relname, fa.attname
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("listar chaves estrangeiras: %w", err)
+	}
+	fks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (chaveEstrangeira, error) {
+		var fk chaveEstrangeira
+		var fs, ft, ms, mt string
+		err := row.Scan(&fs, &ft, &fk.coluna, &ms, &mt, &fk.colunaMae)
+		fk.filha = pgx.Identifier{fs, ft}
+		fk.mae = pgx.Identifier{ms, mt}
+		return fk, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return planejar(tenantID, comTenant, fks, fora), nil
+}
@@ -0,0 +1,97 @@
+package tenantmove
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+func TestPlanejar(t *testing.T) {
+	tenantID := uuid.MustParse("6f1c2f4e-4a4f-4b8e-9a51-0d3c1f5a7b21")
+	tabela := func(nome string) pgx.Identifier { return pgx.Identifier{"public", nome} }
+	fk := func(filha, coluna, mae string) chaveEstrangeira {
+		return chaveEstrangeira{filha: tabela(filha), coluna: coluna, mae: tabela(mae), colunaMae: "id"}
+	}
+	// Como no schema: só escolas tem tenant_id; alunos e usuarios são mães sem tenant_id.
+	comTenant := []pgx.Identifier{tabela("escolas"), tabela("saas_invoices"), tabela("tenant_migrations"), tabela("logs_importacao"),
+		tabela("monitor_check_events"), tabela("scim_tokens"), tabela("mail_outbox")}
+	fks := []chaveEstrangeira{
+		fk("turmas", "escola_id", "escolas"),
+		fk("matriculas", "turma_id", "turmas"),
+		fk("matriculas", "aluno_id", "alunos"),
+		fk("presencas", "matricula_id", "matriculas"),
+		fk("professores_turmas", "turma_id", "turmas"),
+		fk("professores_turmas", "professor_id", "usuarios"),
+		fk("escolas_gestores", "escola_id", "escolas"),
+		fk("escolas_gestores", "usuario_id", "usuarios"),
+		fk("usuarios", "criado_por", "saas_users"),
+		fk("dominios", "tenant_id", "tenants"),
+		fk("escolas", "sede_id", "escolas"),
+	}
+
+	plano := planejar(tenantID, comTenant, fks, foraDaMigracao([]string{"logs_importacao"}))
+
+	var nomes []string
+	posicao := map[string]int{}
+	for i, alvo := range plano {
+		nomes = append(nomes, alvo.Tabela[1])
+		posicao[alvo.Nome()] = i
+	}
+	want := "tenants,escolas,turmas,alunos,matriculas,presencas,usuarios,escolas_gestores,professores_turmas"
+	if got := strings.Join(nomes, ","); got != want {
+		t.Fatalf("ordem = %s, want %s", got, want)
+	}
+	for _, alvo := range plano {
+		for _, ref := range alvo.Referencias {
+			if mae := posicao[ref.Mae.Sanitize()]; mae > posicao[alvo.Nome()] {
+				t.Fatalf("%s vem antes da mãe %s", alvo.Nome(), ref.Mae.Sanitize())
+			}
+		}
+	}
+
+	if plano[0].Filtro != "id = '"+tenantID.String()+"'" {
+		t.Fatalf("filtro de tenants = %q", plano[0].Filtro)
+	}
+	porNome := func(nome string) Alvo { return plano[posicao[tabela(nome).Sanitize()]] }
+	wantFiltro := `"turma_id" IN (SELECT "id" FROM "public"."turmas" WHERE "escola_id" IN (SELECT "id" FROM "public"."escolas" WHERE tenant_id = '` + tenantID.String() + `'))`
+	if got := porNome("matriculas").Filtro; got != wantFiltro {
+		t.Fatalf("filtro de matriculas = %s", got)
+	}
+
+	usuarios := porNome("usuarios")
+	if !usuarios.Compartilhada || porNome("escolas").Compartilhada {
+		t.Fatal("só as mães sem tenant_id são compartilhadas")
+	}
+	if !strings.Contains(usuarios.Filtro, `"id" IN (SELECT "professor_id" FROM "public"."professores_turmas"`) ||
+		!strings.Contains(usuarios.Filtro, `OR "id" IN (SELECT "usuario_id" FROM "public"."escolas_gestores"`) {
+		t.Fatalf("filtro de usuarios = %s", usuarios.Filtro)
+	}
+	if len(usuarios.Referencias) != 0 {
+		t.Fatalf("referência ao plano de controle conferida: %+v", usuarios.Referencias)
+	}
+	if refs := porNome("matriculas").Referencias; len(refs) != 2 {
+		t.Fatalf("referências de matriculas = %+v", refs)
+	}
+}
+
+func TestRotasPendentesBloqueiaSaidaDoPrimary(t *testing.T) {
+	pendentes := RotasPendentes
+	t.Cleanup(func() { RotasPendentes = pendentes })
+
+	RotasPendentes = []string{"jobs do scheduler"}
+	if err := rotasPendentes(Migration{Origem: db.PrimaryCluster, Destino: "cluster2"}); !errors.Is(err, ErrRotasPendentes) {
+		t.Fatalf("err = %v, want ErrRotasPendentes", err)
+	}
+	if err := rotasPendentes(Migration{Origem: "cluster2", Destino: db.PrimaryCluster}); err != nil {
+		t.Fatalf("volta ao primary recusada: %v", err)
+	}
+	RotasPendentes = nil
+	if err := rotasPendentes(Migration{Origem: db.PrimaryCluster, Destino: "cluster2"}); err != nil {
+		t.Fatalf("err = %v sem rotas pendentes", err)
+	}
+}
@@ -0,0 +1,272 @@
+// Package tenantmove move os dados de um único tenant entre clusters de banco no modelo
+// blue/green: copia para o destino, confere contagens e checksums, troca o roteamento em
+// tenants.db_cluster e, depois de confirmado, apaga a cópia antiga. Cada migração fica registrada
+// em tenant_migrations e cada etapa pode ser repetida depois de uma falha.
+package tenantmove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusCopied    = "copied"
+	StatusVerified  = "verified"
+	StatusSwitched  = "switched"
+	StatusRetired   = "retired"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrNotFound        = errors.New("tenantmove: migração não encontrada")
+	ErrTenantNotFound  = errors.New("tenantmove: tenant não encontrado")
+	ErrUnknownCluster  = errors.New("tenantmove: cluster desconhecido")
+	ErrMesmoCluster    = errors.New("tenantmove: o tenant já está nesse cluster")
+	ErrMigracaoAtiva   = errors.New("tenantmove: já existe migração em andamento para o tenant")
+	ErrEtapaInvalida   = errors.New("tenantmove: etapa não permitida no status atual")
+	ErrDestinoOcupado  = errors.New("tenantmove: o destino já tem dados do tenant; use reset para apagá-los")
+	ErrVerificacao     = errors.New("tenantmove: contagens, checksums ou chaves estrangeiras divergentes")
+	ErrRoteamentoMudou = errors.New("tenantmove: o roteamento do tenant mudou desde o início da migração")
+	ErrRotasPendentes  = errors.New("tenantmove: ainda há rotas e jobs lendo o tenant direto do primary")
+)
+
+// RotasPendentes lista o que ainda acessa dados de tenant pelo pool do primary, sem passar pelo
+// db.Resolver. Enquanto a lista não estiver vazia nem o switch nem o retire de um tenant que sai
+// do primary são aceitos: esses caminhos continuariam lendo e gravando ali, e as duas cópias
+// divergiriam.
+var RotasPendentes = []string{
+	"login, refresh e convites da equipe (usuarios e senhas lidos no primary)",
+	"rotas do cidadão e públicas (protocolos, senhas, eventos, consultas, câmara e tributos)",
+	"handlers do painel SaaS sobre dados do tenant (equipe, legal hold, onboarding, anos letivos)",
+	"onboarding do tenant admin e unificação de cidadãos",
+	"tenants.settings lido em SQL pelo enfileiramento de faltas",
+}
+
+// Tabela é o resultado da cópia e da verificação de uma tabela do plano.
+type Tabela struct {
+	Nome            string `json:"nome"`
+	LinhasOrigem    int64  `json:"linhas_origem"`
+	LinhasDestino   int64  `json:"linhas_destino"`
+	ChecksumOrigem  string `json:"checksum_origem,omitempty"`
+	ChecksumDestino string `json:"checksum_destino,omitempty"`
+	Orfas           int64  `json:"orfas,omitempty"`
+	OK              bool   `json:"ok"`
+}
+
+// Migration é uma linha de tenant_migrations.
+type Migration struct {
+	ID            uuid.UUID  `json:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	TenantSlug    string     `json:"tenant_slug"`
+	Origem        string     `json:"origem"`
+	Destino       string     `json:"destino"`
+	Status        string     `json:"status"`
+	Excluir       []string   `json:"excluir"`
+	Tabelas       []Tabela   `json:"tabelas"`
+	Erro          *string    `json:"erro,omitempty"`
+	SolicitadoPor *string    `json:"solicitado_por,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	CopiedAt      *time.Time `json:"copied_at,omitempty"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	SwitchedAt    *time.Time `json:"switched_at,omitempty"`
+	RetiredAt     *time.Time `json:"retired_at,omitempty"`
+}
+
+// Mover executa as etapas de migração. O pool de controle é o do banco principal, onde ficam
+// tenants e tenant_migrations; os demais clusters são abertos sob demanda a partir de DB_CLUSTERS.
+type Mover struct {
+	control  *pgxpool.Pool
+	clusters map[string]string
+	espera   time.Duration
+	logger   zerolog.Logger
+
+	mu    sync.Mutex
+	pools map[string]*pgxpool.Pool
+}
+
+// NewMover cria o executor; clusters vem de db.ParseClusters. espera é quanto o switch aguarda
+// depois de congelar o tenant: ao menos DB_ROUTING_TTL mais a duração de uma requisição, para
+// que nenhuma instância continue gravando na origem com o roteamento antigo em cache.
+func NewMover(control *pgxpool.Pool, clusters map[string]string, espera time.Duration, logger zerolog.Logger) *Mover {
+	return &Mover{control: control, clusters: clusters, espera: espera, logger: logger, pools: make(map[string]*pgxpool.Pool)}
+}
+
+// Close fecha os pools abertos para os outros clusters.
+func (m *Mover) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, pool := range m.pools {
+		pool.Close()
+		delete(m.pools, name)
+	}
+}
+
+func (m *Mover) pool(ctx context.Context, cluster string) (*pgxpool.Pool, error) {
+	if cluster == db.PrimaryCluster {
+		return m.control, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if pool, ok := m.pools[cluster]; ok {
+		return pool, nil
+	}
+	dsn, ok := m.clusters[cluster]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCluster, cluster)
+	}
+	pool, err := db.NewPool(ctx, dsn, db.PoolOptions{MaxConns: 4})
+	if err != nil {
+		return nil, fmt.Errorf("conectar ao cluster %s: %w", cluster, err)
+	}
+	m.pools[cluster] = pool
+	return pool, nil
+}
+
+// Start registra uma migração do tenant para o cluster destino. A origem é o cluster atual do
+// tenant; nada é copiado ainda.
+func (m *Mover) Start(ctx context.Context, slug, destino string, excluir []string, solicitadoPor string) (Migration, error) {
+	if _, ok := m.clusters[destino]; !ok && destino != db.PrimaryCluster {
+		return Migration{}, fmt.Errorf("%w: %s", ErrUnknownCluster, destino)
+	}
+	var tenantID uuid.UUID
+	var origem string
+	err := m.control.QueryRow(ctx, `SELECT id, db_cluster FROM tenants WHERE slug = $1`, slug).Scan(&tenantID, &origem)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Migration{}, ErrTenantNotFound
+	}
+	if err != nil {
+		return Migration{}, err
+	}
+	if origem == destino {
+		return Migration{}, ErrMesmoCluster
+	}
+	if excluir == nil {
+		excluir = []string{}
+	}
+
+	var id uuid.UUID
+	err = m.control.QueryRow(ctx, `
+        INSERT INTO tenant_migrations (tenant_id, origem, destino, excluir, solicitado_por)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+        RETURNING id
+    `, tenantID, origem, destino, excluir, solicitadoPor).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return Migration{}, ErrMigracaoAtiva
+		}
+		return Migration{}, err
+	}
+	return Get(ctx, m.control, id)
+}
+
+// Cancel encerra uma migração que ainda não trocou o roteamento. Dados já copiados para o destino
+// ficam lá; uma nova migração com reset os substitui.
+func (m *Mover) Cancel(ctx context.Context, id uuid.UUID) (Migration, error) {
+	tag, err := m.control.Exec(ctx, `
+        UPDATE tenant_migrations SET status = 'cancelled', updated_at = now()
+        WHERE id = $1 AND status IN ('pending', 'copied', 'verified')
+    `, id)
+	if err != nil {
+		return Migration{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := Get(ctx, m.control, id); err != nil {
+			return Migration{}, err
+		}
+		return Migration{}, ErrEtapaInvalida
+	}
+	return Get(ctx, m.control, id)
+}
+
+// carregar lê a migração e confere se o status permite a etapa.
+func (m *Mover) carregar(ctx context.Context, id uuid.UUID, permitidos ...string) (Migration, error) {
+	mig, err := Get(ctx, m.control, id)
+	if err != nil {
+		return mig, err
+	}
+	for _, status := range permitidos {
+		if mig.Status == status {
+			return mig, nil
+		}
+	}
+	return mig, fmt.Errorf("%w: %s", ErrEtapaInvalida, mig.Status)
+}
+
+// falhar grava o erro da etapa sem mudar o status, para que ela possa ser repetida.
+func (m *Mover) falhar(ctx context.Context, id uuid.UUID, cause error) error {
+	if _, err := m.control.Exec(ctx, `
+        UPDATE tenant_migrations SET erro = $2, updated_at = now() WHERE id = $1
+    `, id, cause.Error()); err != nil {
+		m.logger.Warn().Err(err).Str("migracao", id.String()).Msg("tenantmove: falha ao registrar erro")
+	}
+	return cause
+}
+
+const migrationColumns = `
+    m.id, m.tenant_id, t.slug, m.origem, m.destino, m.status, m.excluir, m.tabelas, m.erro,
+    m.solicitado_por, m.created_at, m.updated_at, m.copied_at, m.verified_at, m.switched_at, m.retired_at`
+
+// Get lê uma migração pelo id.
+func Get(ctx context.Context, pool *pgxpool.Pool, id uuid.UUID) (Migration, error) {
+	migs, err := queryMigrations(ctx, pool, `
+        SELECT `+migrationColumns+`
+        FROM tenant_migrations m JOIN tenants t ON t.id = m.tenant_id
+        WHERE m.id = $1
+    `, id)
+	if err != nil {
+		return Migration{}, err
+	}
+	if len(migs) == 0 {
+		return Migration{}, ErrNotFound
+	}
+	return migs[0], nil
+}
+
+// List devolve as migrações mais recentes; com tenantID, só as desse tenant.
+func List(ctx context.Context, pool *pgxpool.Pool, tenantID *uuid.UUID, limit int) ([]Migration, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return queryMigrations(ctx, pool, `
+        SELECT `+migrationColumns+`
+        FROM tenant_migrations m JOIN tenants t ON t.id = m.tenant_id
+        WHERE $1::uuid IS NULL OR m.tenant_id = $1
+        ORDER BY m.created_at DESC
+        LIMIT $2
+    `, tenantID, limit)
+}
+
+func queryMigrations(ctx context.Context, pool *pgxpool.Pool, query string, args ...any) ([]Migration, error) {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	migs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Migration, error) {
+		var mig Migration
+		err := row.Scan(&mig.ID, &mig.TenantID, &mig.TenantSlug, &mig.Origem, &mig.Destino, &mig.Status,
+			&mig.Excluir, &mig.Tabelas, &mig.Erro, &mig.SolicitadoPor, &mig.CreatedAt, &mig.UpdatedAt,
+			&mig.CopiedAt, &mig.VerifiedAt, &mig.SwitchedAt, &mig.RetiredAt)
+		if mig.Tabelas == nil {
+			mig.Tabelas = []Tabela{}
+		}
+		return mig, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return migs, nil
+}
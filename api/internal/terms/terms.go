@@ -0,0 +1,292 @@
+// Package terms versiona os termos de uso e o DPA por público e registra os aceites que
+// liberam o uso dos painéis.
+package terms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Públicos, iguais às audiences dos tokens de acesso.
+const (
+	AudienceCidadao    = "cidadao"
+	AudienceBackoffice = "backoffice"
+	AudienceSaaS       = "saas"
+)
+
+// Tipos de documento.
+const (
+	KindToS = "tos"
+	KindDPA = "dpa"
+)
+
+// cacheTTL define por quanto tempo as versões vigentes ficam em memória.
+const cacheTTL = time.Minute
+
+var (
+	// ErrDuplicate indica versão já registrada para público e tipo.
+	ErrDuplicate = errors.New("terms: versão já registrada")
+	// ErrNotCurrent indica aceite de versão que não é a vigente.
+	ErrNotCurrent = errors.New("terms: versão não vigente")
+)
+
+// Version é uma versão publicada de um documento.
+type Version struct {
+	ID          uuid.UUID `json:"id"`
+	Audience    string    `json:"audience"`
+	Kind        string    `json:"kind"`
+	Version     string    `json:"version"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body,omitempty"`
+	Blocking    bool      `json:"blocking"`
+	EffectiveAt time.Time `json:"effective_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Label identifica a versão em cabeçalhos, ex.: "tos:2026.1".
+func (v Version) Label() string {
+	return v.Kind + ":" + v.Version
+}
+
+// Input contém os campos de uma nova versão.
+type Input struct {
+	Audience    string
+	Kind        string
+	Version     string
+	Title       string
+	Body        string
+	Blocking    bool
+	EffectiveAt *time.Time
+	ActorID     *uuid.UUID
+}
+
+// Normalize limpa e valida a entrada.
+func (in *Input) Normalize() error {
+	in.Audience = strings.ToLower(strings.TrimSpace(in.Audience))
+	in.Kind = strings.ToLower(strings.TrimSpace(in.Kind))
+	in.Version = strings.TrimSpace(in.Version)
+	in.Title = strings.TrimSpace(in.Title)
+	in.Body = strings.TrimSpace(in.Body)
+	switch {
+	case in.Audience != AudienceCidadao && in.Audience != AudienceBackoffice && in.Audience != AudienceSaaS:
+		return errors.New("público inválido")
+	case in.Kind != KindToS && in.Kind != KindDPA:
+		return errors.New("tipo inválido")
+	case in.Version == "":
+		return errors.New("versão obrigatória")
+	case in.Title == "":
+		return errors.New("título obrigatório")
+	case in.Body == "":
+		return errors.New("texto obrigatório")
+	}
+	return nil
+}
+
+// Service consulta versões vigentes e aceites com cache em memória.
+type Service struct {
+	pool *pgxpool.Pool
+
+	mu       sync.Mutex
+	current  map[string][]Version
+	loadedAt map[string]time.Time
+	accepted sync.Map
+}
+
+// NewService cria o serviço de termos.
+func NewService(pool *pgxpool.Pool) *Service {
+	return &Service{
+		pool:     pool,
+		current:  make(map[string][]Version),
+		loadedAt: make(map[string]time.Time),
+	}
+}
+
+// Current devolve as versões vigentes (uma por tipo) do público.
+func (s *Service) Current(ctx context.Context, audience string) ([]Version, error) {
+	s.mu.Lock()
+	if versions, ok := s.current[audience]; ok && time.Since(s.loadedAt[audience]) < cacheTTL {
+		s.mu.Unlock()
+		return versions, nil
+	}
+	s.mu.Unlock()
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT DISTINCT ON (kind) id, audience, kind, version, title, body, blocking, effective_at, created_at
+        FROM terms_versions
+        WHERE audience = $1 AND effective_at <= now()
+        ORDER BY kind, effective_at DESC
+    `, audience)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := collectVersions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.current[audience] = versions
+	s.loadedAt[audience] = time.Now()
+	s.mu.Unlock()
+	return versions, nil
+}
+
+// Pending devolve as versões vigentes ainda não aceitas pelo usuário.
+func (s *Service) Pending(ctx context.Context, audience, subject string) ([]Version, error) {
+	current, err := s.Current(ctx, audience)
+	if err != nil || len(current) == 0 {
+		return nil, err
+	}
+	subjectID, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("terms: subject inválido: %w", err)
+	}
+
+	pending := make([]Version, 0, len(current))
+	missing := make([]uuid.UUID, 0, len(current))
+	for _, v := range current {
+		if _, ok := s.accepted.Load(acceptanceKey(v.ID, subjectID)); ok {
+			continue
+		}
+		missing = append(missing, v.ID)
+	}
+	if len(missing) == 0 {
+		return pending, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT version_id FROM terms_acceptances WHERE subject_id = $1 AND version_id = ANY($2)
+    `, subjectID, missing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		s.accepted.Store(acceptanceKey(id, subjectID), struct{}{})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, v := range current {
+		if _, ok := s.accepted.Load(acceptanceKey(v.ID, subjectID)); !ok {
+			pending = append(pending, v)
+		}
+	}
+	return pending, nil
+}
+
+// Gate resume as pendências para o middleware: rótulos das versões e se alguma bloqueia o uso.
+func (s *Service) Gate(ctx context.Context, audience, subject string) ([]string, bool, error) {
+	pending, err := s.Pending(ctx, audience, subject)
+	if err != nil {
+		return nil, false, err
+	}
+	labels := make([]string, 0, len(pending))
+	blocking := false
+	for _, v := range pending {
+		labels = append(labels, v.Label())
+		blocking = blocking || v.Blocking
+	}
+	return labels, blocking, nil
+}
+
+// Accept registra o aceite das versões informadas; apenas versões vigentes do público são aceitas.
+func (s *Service) Accept(ctx context.Context, audience, subject string, versionIDs []uuid.UUID, ip, userAgent string) error {
+	subjectID, err := uuid.Parse(subject)
+	if err != nil {
+		return fmt.Errorf("terms: subject inválido: %w", err)
+	}
+	current, err := s.Current(ctx, audience)
+	if err != nil {
+		return err
+	}
+	valid := make(map[uuid.UUID]bool, len(current))
+	for _, v := range current {
+		valid[v.ID] = true
+	}
+	for _, id := range versionIDs {
+		if !valid[id] {
+			return ErrNotCurrent
+		}
+	}
+
+	for _, id := range versionIDs {
+		if _, err := s.pool.Exec(ctx, `
+            INSERT INTO terms_acceptances (version_id, subject_id, ip, user_agent)
+            VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+            ON CONFLICT (version_id, subject_id) DO NOTHING
+        `, id, subjectID, ip, userAgent); err != nil {
+			return err
+		}
+		s.accepted.Store(acceptanceKey(id, subjectID), struct{}{})
+	}
+	return nil
+}
+
+// List lista as versões de um público (ou de todos), sem o texto.
+func (s *Service) List(ctx context.Context, audience string) ([]Version, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT id, audience, kind, version, title, '' AS body, blocking, effective_at, created_at
+        FROM terms_versions
+        WHERE ($1 = '' OR audience = $1)
+        ORDER BY audience, kind, effective_at DESC
+    `, strings.ToLower(strings.TrimSpace(audience)))
+	if err != nil {
+		return nil, err
+	}
+	return collectVersions(rows)
+}
+
+// Create publica uma nova versão; a partir de effective_at ela passa a exigir novo aceite.
+func (s *Service) Create(ctx context.Context, in Input) (*Version, error) {
+	var v Version
+	err := s.pool.QueryRow(ctx, `
+        INSERT INTO terms_versions (audience, kind, version, title, body, blocking, effective_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()), $8)
+        RETURNING id, audience, kind, version, title, body, blocking, effective_at, created_at
+    `, in.Audience, in.Kind, in.Version, in.Title, in.Body, in.Blocking, in.EffectiveAt, in.ActorID).
+		Scan(&v.ID, &v.Audience, &v.Kind, &v.Version, &v.Title, &v.Body, &v.Blocking, &v.EffectiveAt, &v.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDuplicate
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.current, in.Audience)
+	s.mu.Unlock()
+	return &v, nil
+}
+
+func collectVersions(rows pgx.Rows) ([]Version, error) {
+	defer rows.Close()
+	versions := make([]Version, 0)
+	for rows.Next() {
+		var v Version
+		if err := rows.Scan(&v.ID, &v.Audience, &v.Kind, &v.Version, &v.Title, &v.Body, &v.Blocking, &v.EffectiveAt, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func acceptanceKey(versionID, subjectID uuid.UUID) string {
+	return versionID.String() + "|" + subjectID.String()
+}
@@ -0,0 +1,123 @@
+package tributos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+// demo simula um sistema tributário para tenants sandbox: os débitos derivam do documento,
+// então a mesma consulta devolve sempre as mesmas parcelas.
+type demo struct {
+	convenio string
+	now      func() time.Time
+}
+
+func newDemo(convenio string, now func() time.Time) *demo {
+	return &demo{convenio: convenio, now: now}
+}
+
+func (d *demo) Name() string { return ProviderDemo }
+
+func (d *demo) Debitos(_ context.Context, consulta Consulta) ([]Debito, error) {
+	seed := sha256.Sum256([]byte(consulta.Documento))
+	hoje := truncateDay(d.now())
+	ano := hoje.Year()
+
+	var debitos []Debito
+	if len(consulta.Documento) == 11 {
+		inscricao := fmt.Sprintf("%02d.%03d.%04d", seed[0]%100, binary.BigEndian.Uint16(seed[1:3])%1000, binary.BigEndian.Uint16(seed[3:5])%10000)
+		parcela := util.RoundMoney(float64(80+int(seed[5])) + float64(seed[6]%100)/100)
+		for n := 1; n <= 10; n++ {
+			debitos = append(debitos, d.debito(TributoIPTU, inscricao, ano, n, "IPTU "+fmt.Sprint(ano), time.Date(ano, time.Month(n+1), 10, 0, 0, 0, 0, time.UTC), parcela, hoje))
+		}
+	} else {
+		inscricao := fmt.Sprintf("%06d", binary.BigEndian.Uint32(seed[0:4])%1000000)
+		valor := util.RoundMoney(float64(150+int(seed[4])*3) + float64(seed[5]%100)/100)
+		for n := 3; n >= 1; n-- {
+			competencia := time.Date(ano, hoje.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -n, 0)
+			vencimento := competencia.AddDate(0, 1, 14)
+			descricao := "ISS competência " + competencia.Format("01/2006")
+			debitos = append(debitos, d.debito(TributoISS, inscricao, competencia.Year(), int(competencia.Month()), descricao, vencimento, valor, hoje))
+		}
+	}
+
+	filtrados := debitos[:0]
+	for _, debito := range debitos {
+		if consulta.Tributo != "" && debito.Tributo != consulta.Tributo {
+			continue
+		}
+		if consulta.Inscricao != "" && debito.Inscricao != consulta.Inscricao {
+			continue
+		}
+		filtrados = append(filtrados, debito)
+	}
+	return filtrados, nil
+}
+
+// debito aplica multa de 2% e juros de 1% ao mês iniciado sobre parcelas vencidas.
+func (d *demo) debito(tributo, inscricao string, exercicio, parcela int, descricao string, vencimento time.Time, valor float64, hoje time.Time) Debito {
+	debito := Debito{
+		ID:         fmt.Sprintf("%s-%s-%d-%02d", tributo, onlyDigits(inscricao), exercicio, parcela),
+		Tributo:    tributo,
+		Inscricao:  inscricao,
+		Exercicio:  exercicio,
+		Parcela:    parcela,
+		Descricao:  descricao,
+		Vencimento: vencimento,
+		Valor:      valor,
+		Vencido:    vencimento.Before(hoje),
+	}
+	if debito.Vencido {
+		meses := (hoje.Year()-vencimento.Year())*12 + int(hoje.Month()-vencimento.Month())
+		if hoje.Day() > vencimento.Day() {
+			meses++
+		}
+		debito.Multa = util.RoundMoney(valor * 0.02)
+		debito.Juros = util.RoundMoney(valor * 0.01 * float64(max(meses, 1)))
+	}
+	debito.Total = util.RoundMoney(debito.Valor + debito.Multa + debito.Juros - debito.Desconto)
+	return debito
+}
+
+func (d *demo) EmitirGuia(ctx context.Context, emissao Emissao) (Guia, error) {
+	abertos, err := d.Debitos(ctx, Consulta{Documento: emissao.Documento})
+	if err != nil {
+		return Guia{}, err
+	}
+	porID := make(map[string]Debito, len(abertos))
+	for _, debito := range abertos {
+		porID[debito.ID] = debito
+	}
+
+	guia := Guia{Vencimento: emissao.Vencimento}
+	hash := sha256.New()
+	hash.Write([]byte(emissao.Documento))
+	for _, id := range emissao.DebitoIDs {
+		debito, ok := porID[id]
+		if !ok {
+			return Guia{}, ErrNotFound
+		}
+		guia.Debitos = append(guia.Debitos, debito)
+		hash.Write([]byte(id))
+	}
+	guia.Valor = Totalizar(guia.Debitos)
+	hash.Write([]byte(emissao.Vencimento.Format("20060102")))
+	guia.NossoNumero = fmt.Sprintf("%017d", binary.BigEndian.Uint64(hash.Sum(nil))%1e17)
+
+	campo, err := CampoLivre(emissao.Vencimento.Format("20060102"), guia.NossoNumero)
+	if err != nil {
+		return Guia{}, err
+	}
+	if guia.CodigoBarras, err = CodigoArrecadacao(d.convenio, guia.Valor, campo); err != nil {
+		return Guia{}, err
+	}
+	if guia.LinhaDigitavel, err = LinhaDigitavel(guia.CodigoBarras); err != nil {
+		return Guia{}, err
+	}
+	return guia, nil
+}
@@ -0,0 +1,143 @@
+package tributos
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Layout FEBRABAN de arrecadação (44 dígitos): produto 8, segmento 1 (prefeituras),
+// identificador de valor 6 (reais, DV módulo 10), DV geral, valor com 11 dígitos, convênio de
+// 4 dígitos e 25 dígitos de campo livre.
+const (
+	produtoArrecadacao = '8'
+	segmentoPrefeitura = '1'
+	valorReaisMod10    = '6'
+)
+
+// CodigoArrecadacao monta o código de barras de uma guia municipal.
+func CodigoArrecadacao(convenio string, valor float64, campoLivre string) (string, error) {
+	if !isDigits(convenio, 4) {
+		return "", fmt.Errorf("%w: convênio deve ter 4 dígitos", ErrInvalid)
+	}
+	if !isDigits(campoLivre, 25) {
+		return "", fmt.Errorf("%w: campo livre deve ter 25 dígitos", ErrInvalid)
+	}
+	centavos := int64(math.Round(valor * 100))
+	if centavos <= 0 || centavos > 99999999999 {
+		return "", fmt.Errorf("%w: valor fora da faixa do código de barras", ErrInvalid)
+	}
+
+	semDV := string([]byte{produtoArrecadacao, segmentoPrefeitura, valorReaisMod10}) +
+		fmt.Sprintf("%011d", centavos) + convenio + campoLivre
+	return semDV[:3] + string(mod10(semDV)) + semDV[3:], nil
+}
+
+// CampoLivre combina vencimento (AAAAMMDD) e nosso número (até 17 dígitos) nos 25 dígitos livres.
+func CampoLivre(vencimento string, nossoNumero string) (string, error) {
+	if !isDigits(vencimento, 8) || nossoNumero == "" || len(nossoNumero) > 17 || !isDigits(nossoNumero, len(nossoNumero)) {
+		return "", fmt.Errorf("%w: nosso número inválido", ErrInvalid)
+	}
+	return vencimento + strings.Repeat("0", 17-len(nossoNumero)) + nossoNumero, nil
+}
+
+// ValidarCodigo confere tamanho, produto, identificador de valor e DV geral do código de barras.
+func ValidarCodigo(codigo string) error {
+	if !isDigits(codigo, 44) || codigo[0] != produtoArrecadacao {
+		return fmt.Errorf("%w: código de barras fora do padrão de arrecadação", ErrInvalid)
+	}
+	semDV := codigo[:3] + codigo[4:]
+	var dv byte
+	switch codigo[2] {
+	case '6', '7':
+		dv = mod10(semDV)
+	case '8', '9':
+		dv = mod11(semDV)
+	default:
+		return fmt.Errorf("%w: identificador de valor desconhecido", ErrInvalid)
+	}
+	if codigo[3] != dv {
+		return fmt.Errorf("%w: dígito verificador do código de barras", ErrInvalid)
+	}
+	return nil
+}
+
+// LinhaDigitavel divide o código em quatro blocos de 11 dígitos, cada um com seu DV.
+func LinhaDigitavel(codigo string) (string, error) {
+	if err := ValidarCodigo(codigo); err != nil {
+		return "", err
+	}
+	dv := mod10
+	if codigo[2] == '8' || codigo[2] == '9' {
+		dv = mod11
+	}
+	blocos := make([]string, 4)
+	for i := range blocos {
+		bloco := codigo[i*11 : (i+1)*11]
+		blocos[i] = bloco + "-" + string(dv(bloco))
+	}
+	return strings.Join(blocos, " "), nil
+}
+
+// mod10 aplica pesos 2,1,2,1... da direita para a esquerda somando os algarismos dos produtos.
+func mod10(digits string) byte {
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		product := int(digits[i]-'0') * weight
+		sum += product/10 + product%10
+		weight = 3 - weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// mod11 aplica pesos 2 a 9 da direita para a esquerda; restos 0 e 1 resultam em DV 0.
+func mod11(digits string) byte {
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		if weight++; weight > 9 {
+			weight = 2
+		}
+	}
+	rest := sum % 11
+	if rest <= 1 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+// Padrões do Interleaved 2 of 5 (1 = barra larga), na ordem dos dígitos 0 a 9.
+var itfPatterns = [10][5]bool{
+	{false, false, true, true, false},
+	{true, false, false, false, true},
+	{false, true, false, false, true},
+	{true, true, false, false, false},
+	{false, false, true, false, true},
+	{true, false, true, false, false},
+	{false, true, true, false, false},
+	{false, false, false, true, true},
+	{true, false, false, true, false},
+	{false, true, false, true, false},
+}
+
+// BarrasITF devolve as larguras (em módulos) alternando barra e espaço, começando por barra,
+// do código em Interleaved 2 of 5 com razão larga/estreita 3:1.
+func BarrasITF(codigo string) ([]int, error) {
+	if len(codigo)%2 != 0 || !isDigits(codigo, len(codigo)) {
+		return nil, fmt.Errorf("%w: ITF exige quantidade par de dígitos", ErrInvalid)
+	}
+	width := func(wide bool) int {
+		if wide {
+			return 3
+		}
+		return 1
+	}
+	widths := []int{1, 1, 1, 1}
+	for i := 0; i < len(codigo); i += 2 {
+		bars, spaces := itfPatterns[codigo[i]-'0'], itfPatterns[codigo[i+1]-'0']
+		for j := 0; j < 5; j++ {
+			widths = append(widths, width(bars[j]), width(spaces[j]))
+		}
+	}
+	return append(widths, 3, 1, 1), nil
+}
@@ -0,0 +1,111 @@
+package tributos
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gestaozabele/municipio/internal/pdf"
+)
+
+// Cabecalho identifica prefeitura e contribuinte impressos na guia.
+type Cabecalho struct {
+	Prefeitura   string
+	Contribuinte string
+}
+
+// RenderPDF desenha a segunda via com discriminação dos débitos, linha digitável e código de
+// barras ITF; serve quando o fornecedor não devolve o PDF oficial.
+func RenderPDF(guia Guia, cab Cabecalho) ([]byte, error) {
+	barras, err := BarrasITF(guia.CodigoBarras)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage()
+	const margin = 40.0
+	width := pdf.A4Width - 2*margin
+	y := page.Height() - 60
+
+	page.Text(margin, y, pdf.Bold, 14, cab.Prefeitura)
+	y -= 18
+	page.Text(margin, y, pdf.Regular, 11, "Documento de Arrecadação Municipal - 2ª via")
+	y -= 26
+	page.Text(margin, y, pdf.Regular, 9, "Contribuinte: "+cab.Contribuinte)
+	page.Text(margin+330, y, pdf.Regular, 9, "Nosso número: "+guia.NossoNumero)
+	y -= 14
+	page.Text(margin, y, pdf.Regular, 9, "Vencimento: "+guia.Vencimento.Format("02/01/2006"))
+	page.Text(margin+330, y, pdf.Bold, 9, "Valor: "+formatBRL(guia.Valor))
+	y -= 22
+
+	page.Line(margin, y+10, margin+width, y+10, 0.5)
+	columns := []struct {
+		title string
+		x     float64
+	}{{"Tributo", 0}, {"Inscrição", 50}, {"Descrição", 130}, {"Venc.", 300}, {"Principal", 355}, {"Encargos", 420}, {"Total", 480}}
+	for _, col := range columns {
+		page.Text(margin+col.x, y, pdf.Bold, 8, col.title)
+	}
+	y -= 14
+	for _, d := range guia.Debitos {
+		values := []string{
+			d.Tributo,
+			d.Inscricao,
+			truncate(d.Descricao, 34),
+			d.Vencimento.Format("02/01/2006"),
+			formatBRL(d.Valor),
+			formatBRL(d.Multa + d.Juros - d.Desconto),
+			formatBRL(d.Total),
+		}
+		for i, col := range columns {
+			page.Text(margin+col.x, y, pdf.Regular, 8, values[i])
+		}
+		y -= 12
+	}
+	page.Line(margin, y+6, margin+width, y+6, 0.5)
+
+	y -= 30
+	page.StrokeRect(margin, y-86, width, 100, 0.8)
+	page.Text(margin+8, y, pdf.Bold, 12, guia.LinhaDigitavel)
+	y -= 72
+
+	// 44 dígitos em ITF somam 405 módulos; 0,72 pt por módulo dá os 103 mm do padrão FEBRABAN.
+	const module = 0.72
+	x := margin + 8
+	for i, w := range barras {
+		if i%2 == 0 {
+			page.Rect(x, y, float64(w)*module, 50)
+		}
+		x += float64(w) * module
+	}
+	page.Text(margin, y-30, pdf.Regular, 7, "Pague em qualquer banco, lotérica ou aplicativo bancário até o vencimento.")
+	return doc.Bytes(), nil
+}
+
+// formatBRL escreve o valor no formato R$ 1.234,56.
+func formatBRL(value float64) string {
+	raw := fmt.Sprintf("%.2f", value)
+	negative := strings.HasPrefix(raw, "-")
+	raw = strings.TrimPrefix(raw, "-")
+	intPart, frac := raw[:len(raw)-3], raw[len(raw)-2:]
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	out := "R$ " + b.String() + "," + frac
+	if negative {
+		out = "-" + out
+	}
+	return out
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-3]) + "..."
+}
@@ -0,0 +1,167 @@
+package tributos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gestaozabele/municipio/internal/db"
+)
+
+const guiaColumns = `id, provider, documento, nosso_numero, valor::float8, vencimento, codigo_barras, linha_digitavel,
+        debitos, pdf IS NOT NULL, created_at`
+
+// Integracao é a configuração gravada da prefeitura.
+type Integracao struct {
+	Config
+	UpdatedBy *uuid.UUID
+	UpdatedAt time.Time
+}
+
+// GuiaEmitida é a segunda via registrada para o cidadão.
+type GuiaEmitida struct {
+	ID         uuid.UUID `json:"id"`
+	Provider   string    `json:"provider"`
+	Documento  string    `json:"documento"`
+	PDFOficial bool      `json:"pdf_oficial"`
+	CreatedAt  time.Time `json:"created_at"`
+	Guia
+}
+
+// Repository persiste a integração e as guias emitidas.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository cria o repositório.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// conn devolve o pool do cluster da prefeitura fixado no contexto (db.Conn) ou o pool padrão.
+func (r *Repository) conn(ctx context.Context) *pgxpool.Pool {
+	return db.Conn(ctx, r.pool)
+}
+
+// GetConfig devolve a integração da prefeitura ou ErrNotConfigured.
+func (r *Repository) GetConfig(ctx context.Context, tenantID uuid.UUID) (*Integracao, error) {
+	var in Integracao
+	var apiBase, apiToken *string
+	err := r.conn(ctx).QueryRow(ctx, `
+        SELECT provider, api_base, api_token, convenio, ativo, updated_by, updated_at
+        FROM tributos_config WHERE tenant_id = $1
+    `, tenantID).Scan(&in.Provider, &apiBase, &apiToken, &in.Convenio, &in.Ativo, &in.UpdatedBy, &in.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotConfigured
+	}
+	if err != nil {
+		return nil, err
+	}
+	if apiBase != nil {
+		in.APIBase = *apiBase
+	}
+	if apiToken != nil {
+		in.APIToken = *apiToken
+	}
+	return &in, nil
+}
+
+// SaveConfig grava a integração; a validação fica com quem chama, via New.
+func (r *Repository) SaveConfig(ctx context.Context, tenantID uuid.UUID, cfg Config, actorID uuid.UUID) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+        INSERT INTO tributos_config (tenant_id, provider, api_base, api_token, convenio, ativo, updated_by)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7)
+        ON CONFLICT (tenant_id) DO UPDATE SET
+            provider = EXCLUDED.provider,
+            api_base = EXCLUDED.api_base,
+            api_token = EXCLUDED.api_token,
+            convenio = EXCLUDED.convenio,
+            ativo = EXCLUDED.ativo,
+            updated_by = EXCLUDED.updated_by,
+            updated_at = now()
+    `, tenantID, cfg.Provider, cfg.APIBase, cfg.APIToken, cfg.Convenio, cfg.Ativo, actorID)
+	return err
+}
+
+// Provider monta o adaptador configurado para a prefeitura.
+func (r *Repository) Provider(ctx context.Context, tenantID uuid.UUID) (Provider, error) {
+	in, err := r.GetConfig(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return New(in.Config)
+}
+
+// CreateGuia registra a guia emitida pelo fornecedor.
+func (r *Repository) CreateGuia(ctx context.Context, tenantID, cidadaoID uuid.UUID, provider, documento string, guia Guia) (*GuiaEmitida, error) {
+	debitos := guia.Debitos
+	if debitos == nil {
+		debitos = []Debito{}
+	}
+	var pdf []byte
+	if len(guia.PDF) > 0 {
+		pdf = guia.PDF
+	}
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRow(ctx, `
+        INSERT INTO tributos_guias (tenant_id, cidadao_id, provider, documento, nosso_numero, valor, vencimento,
+                                    codigo_barras, linha_digitavel, debitos, pdf)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id
+    `, tenantID, cidadaoID, provider, documento, guia.NossoNumero, guia.Valor, guia.Vencimento,
+		guia.CodigoBarras, guia.LinhaDigitavel, debitos, pdf).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	emitida, _, err := r.GetGuia(ctx, tenantID, cidadaoID, id)
+	return emitida, err
+}
+
+// ListGuias lista as segundas vias do cidadão, mais recentes primeiro.
+func (r *Repository) ListGuias(ctx context.Context, tenantID, cidadaoID uuid.UUID) ([]GuiaEmitida, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+        SELECT `+guiaColumns+`
+        FROM tributos_guias
+        WHERE tenant_id = $1 AND cidadao_id = $2
+        ORDER BY created_at DESC
+        LIMIT 100
+    `, tenantID, cidadaoID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (GuiaEmitida, error) {
+		return scanGuia(row)
+	})
+}
+
+// GetGuia busca a guia do cidadão junto do PDF oficial, quando houver.
+func (r *Repository) GetGuia(ctx context.Context, tenantID, cidadaoID, id uuid.UUID) (*GuiaEmitida, []byte, error) {
+	var pdf []byte
+	row := r.conn(ctx).QueryRow(ctx, `
+        SELECT `+guiaColumns+`, pdf
+        FROM tributos_guias
+        WHERE tenant_id = $1 AND cidadao_id = $2 AND id = $3
+    `, tenantID, cidadaoID, id)
+	g, err := scanGuia(row, &pdf)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &g, pdf, nil
+}
+
+func scanGuia(row pgx.Row, extra ...any) (GuiaEmitida, error) {
+	var g GuiaEmitida
+	dest := append([]any{&g.ID, &g.Provider, &g.Documento, &g.NossoNumero, &g.Valor, &g.Vencimento, &g.CodigoBarras,
+		&g.LinhaDigitavel, &g.Debitos, &g.PDFOficial, &g.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return GuiaEmitida{}, err
+	}
+	return g, nil
+}
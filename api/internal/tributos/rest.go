@@ -0,0 +1,232 @@
+package tributos
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// rest fala com sistemas tributários que expõem (diretamente ou via middleware da prefeitura)
+// o contrato genérico abaixo, autenticado por Bearer token:
+//
+//	GET  {base}/debitos?documento=&inscricao=&tributo=  -> {"debitos": [debitoREST...]}
+//	POST {base}/guias {"documento", "debitos": [ids], "vencimento": "AAAA-MM-DD"}
+//	     -> {"nosso_numero", "vencimento", "valor", "codigo_barras", "linha_digitavel", "pdf_base64"}
+//
+// Código de barras e linha digitável são opcionais na resposta: sem eles, o código é montado a
+// partir do convênio e do nosso número.
+type rest struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	convenio   string
+}
+
+func newREST(cfg Config, httpClient *http.Client) *rest {
+	return &rest{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(strings.TrimSpace(cfg.APIBase), "/"),
+		token:      cfg.APIToken,
+		convenio:   cfg.Convenio,
+	}
+}
+
+func (c *rest) Name() string { return ProviderREST }
+
+type debitoREST struct {
+	ID         string  `json:"id"`
+	Documento  string  `json:"documento"`
+	Tributo    string  `json:"tributo"`
+	Inscricao  string  `json:"inscricao"`
+	Exercicio  int     `json:"exercicio"`
+	Parcela    int     `json:"parcela"`
+	Descricao  string  `json:"descricao"`
+	Vencimento string  `json:"vencimento"`
+	Valor      float64 `json:"valor"`
+	Multa      float64 `json:"multa"`
+	Juros      float64 `json:"juros"`
+	Desconto   float64 `json:"desconto"`
+	Total      float64 `json:"total"`
+}
+
+func (d debitoREST) toDebito(now time.Time) (Debito, error) {
+	vencimento, err := time.Parse("2006-01-02", d.Vencimento)
+	if err != nil || strings.TrimSpace(d.ID) == "" {
+		return Debito{}, fmt.Errorf("%w: débito malformado na resposta", ErrUnavailable)
+	}
+	total := d.Total
+	if total == 0 {
+		total = d.Valor + d.Multa + d.Juros - d.Desconto
+	}
+	return Debito{
+		ID:         d.ID,
+		Tributo:    strings.ToUpper(d.Tributo),
+		Inscricao:  d.Inscricao,
+		Exercicio:  d.Exercicio,
+		Parcela:    d.Parcela,
+		Descricao:  d.Descricao,
+		Vencimento: vencimento,
+		Valor:      d.Valor,
+		Multa:      d.Multa,
+		Juros:      d.Juros,
+		Desconto:   d.Desconto,
+		Total:      total,
+		Vencido:    vencimento.Before(truncateDay(now)),
+	}, nil
+}
+
+// Debitos consulta as parcelas em aberto; registros de outro documento são descartados para que
+// um filtro mal implementado no fornecedor não exponha débitos de terceiros.
+func (c *rest) Debitos(ctx context.Context, consulta Consulta) ([]Debito, error) {
+	query := url.Values{"documento": {consulta.Documento}}
+	if consulta.Inscricao != "" {
+		query.Set("inscricao", consulta.Inscricao)
+	}
+	if consulta.Tributo != "" {
+		query.Set("tributo", consulta.Tributo)
+	}
+
+	var resp struct {
+		Debitos []debitoREST `json:"debitos"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/debitos?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	debitos := make([]Debito, 0, len(resp.Debitos))
+	for _, item := range resp.Debitos {
+		if item.Documento != "" && onlyDigits(item.Documento) != consulta.Documento {
+			continue
+		}
+		debito, err := item.toDebito(now)
+		if err != nil {
+			return nil, err
+		}
+		debitos = append(debitos, debito)
+	}
+	return debitos, nil
+}
+
+// EmitirGuia pede a guia ao fornecedor e completa código de barras e linha digitável.
+func (c *rest) EmitirGuia(ctx context.Context, emissao Emissao) (Guia, error) {
+	body := map[string]any{
+		"documento":  emissao.Documento,
+		"debitos":    emissao.DebitoIDs,
+		"vencimento": emissao.Vencimento.Format("2006-01-02"),
+	}
+	var resp struct {
+		NossoNumero    string       `json:"nosso_numero"`
+		Vencimento     string       `json:"vencimento"`
+		Valor          float64      `json:"valor"`
+		CodigoBarras   string       `json:"codigo_barras"`
+		LinhaDigitavel string       `json:"linha_digitavel"`
+		PDFBase64      string       `json:"pdf_base64"`
+		Debitos        []debitoREST `json:"debitos"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/guias", body, &resp); err != nil {
+		return Guia{}, err
+	}
+	if resp.NossoNumero == "" || resp.Valor <= 0 {
+		return Guia{}, fmt.Errorf("%w: guia sem nosso número ou valor", ErrUnavailable)
+	}
+
+	guia := Guia{NossoNumero: resp.NossoNumero, Valor: resp.Valor, Vencimento: emissao.Vencimento}
+	if resp.Vencimento != "" {
+		vencimento, err := time.Parse("2006-01-02", resp.Vencimento)
+		if err != nil {
+			return Guia{}, fmt.Errorf("%w: vencimento malformado na guia", ErrUnavailable)
+		}
+		guia.Vencimento = vencimento
+	}
+	now := time.Now()
+	for _, item := range resp.Debitos {
+		debito, err := item.toDebito(now)
+		if err != nil {
+			return Guia{}, err
+		}
+		guia.Debitos = append(guia.Debitos, debito)
+	}
+
+	guia.CodigoBarras = onlyDigits(resp.CodigoBarras)
+	if guia.CodigoBarras == "" {
+		campo, err := CampoLivre(guia.Vencimento.Format("20060102"), guia.NossoNumero)
+		if err != nil {
+			return Guia{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		if guia.CodigoBarras, err = CodigoArrecadacao(c.convenio, guia.Valor, campo); err != nil {
+			return Guia{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+	}
+	linha, err := LinhaDigitavel(guia.CodigoBarras)
+	if err != nil {
+		return Guia{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	guia.LinhaDigitavel = linha
+
+	if resp.PDFBase64 != "" {
+		pdf, err := base64.StdEncoding.DecodeString(resp.PDFBase64)
+		if err != nil || !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+			return Guia{}, fmt.Errorf("%w: PDF da guia inválido", ErrUnavailable)
+		}
+		guia.PDF = pdf
+	}
+	return guia, nil
+}
+
+func (c *rest) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+		return ErrInvalid
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(out); err != nil {
+		return fmt.Errorf("%w: resposta inválida", ErrUnavailable)
+	}
+	return nil
+}
+
+func onlyDigits(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] >= '0' && value[i] <= '9' {
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
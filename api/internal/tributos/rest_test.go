@@ -0,0 +1,64 @@
+package tributos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/fakes"
+)
+
+func TestRESTContract(t *testing.T) {
+	gw := fakes.NewPaymentGateway("token-teste")
+	defer gw.Close()
+	gw.AddDebito(
+		fakes.Debito{ID: "d1", Documento: "52998224725", Tributo: "iptu", Inscricao: "01.02", Exercicio: 2026, Parcela: 1, Vencimento: "2026-03-10", Valor: 100, Multa: 2, Juros: 1.5},
+		fakes.Debito{ID: "d2", Documento: "52998224725", Tributo: "iss", Exercicio: 2026, Parcela: 1, Vencimento: "2026-04-10", Valor: 50},
+		fakes.Debito{ID: "d3", Documento: "11144477735", Tributo: "iptu", Exercicio: 2026, Parcela: 1, Vencimento: "2026-03-10", Valor: 80},
+	)
+	provider, err := New(Config{Provider: ProviderREST, APIBase: gw.URL, APIToken: "token-teste", Convenio: "0123", Ativo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	debitos, err := provider.Debitos(ctx, Consulta{Documento: "52998224725", Tributo: TributoIPTU})
+	if err != nil || len(debitos) != 1 || debitos[0].ID != "d1" || debitos[0].Total != 103.5 || debitos[0].Tributo != TributoIPTU {
+		t.Fatalf("débitos = %+v, %v", debitos, err)
+	}
+	gw.SetIgnoreFilters(true)
+	debitos, err = provider.Debitos(ctx, Consulta{Documento: "52998224725"})
+	if err != nil || len(debitos) != 2 {
+		t.Fatalf("débitos de outro documento vazaram: %+v, %v", debitos, err)
+	}
+	gw.SetIgnoreFilters(false)
+	if _, err := provider.Debitos(ctx, Consulta{Documento: "39053344705"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("documento desconhecido = %v", err)
+	}
+
+	vencimento := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	guia, err := provider.EmitirGuia(ctx, Emissao{Documento: "52998224725", DebitoIDs: []string{"d1", "d2"}, Vencimento: vencimento})
+	if err != nil {
+		t.Fatalf("EmitirGuia: %v", err)
+	}
+	if guia.Valor != 153.5 || len(guia.Debitos) != 2 || !bytes.HasPrefix(guia.PDF, []byte("%PDF-")) {
+		t.Fatalf("guia inesperada: %+v", guia)
+	}
+	if err := ValidarCodigo(guia.CodigoBarras); err != nil {
+		t.Fatalf("código montado pelo cliente inválido: %v", err)
+	}
+	if emitidas := gw.Guias(); len(emitidas) != 1 || emitidas[0].Vencimento != "2026-03-20" {
+		t.Fatalf("guias no fornecedor: %+v", emitidas)
+	}
+
+	if _, err := provider.EmitirGuia(ctx, Emissao{Documento: "52998224725", DebitoIDs: []string{"d3"}, Vencimento: vencimento}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("débito de terceiro = %v", err)
+	}
+	gw.FailNext(http.StatusBadGateway, 1)
+	if _, err := provider.Debitos(ctx, Consulta{Documento: "52998224725"}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("fornecedor fora = %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Package tributos integra o app ao sistema tributário de cada prefeitura para consulta de
+// débitos de IPTU/ISS e emissão de segunda via de guias, com um adaptador por fornecedor.
+package tributos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gestaozabele/municipio/internal/util"
+)
+
+const (
+	TributoIPTU = "IPTU"
+	TributoISS  = "ISS"
+)
+
+const (
+	// ProviderREST segue o contrato JSON genérico descrito em rest.go.
+	ProviderREST = "rest"
+	// ProviderDemo gera débitos fictícios e só é aceito em tenants sandbox.
+	ProviderDemo = "demo"
+)
+
+// Prazo máximo, em dias, entre a emissão e o vencimento de uma guia.
+const maxDiasVencimento = 30
+
+var (
+	// ErrNotConfigured indica prefeitura sem integração tributária ativa.
+	ErrNotConfigured = errors.New("tributos: integração não configurada")
+	// ErrNotFound indica débito ou guia inexistente para o contribuinte.
+	ErrNotFound = errors.New("tributos: débito não encontrado")
+	// ErrUnavailable indica falha ou indisponibilidade do sistema tributário.
+	ErrUnavailable = errors.New("tributos: sistema tributário indisponível")
+	// ErrInvalid indica consulta ou emissão com dados inválidos.
+	ErrInvalid = errors.New("tributos: dados inválidos")
+)
+
+// Consulta filtra os débitos de um contribuinte.
+type Consulta struct {
+	Documento string
+	Inscricao string
+	Tributo   string
+}
+
+// Debito é uma parcela em aberto, já com encargos calculados pelo sistema tributário.
+type Debito struct {
+	ID         string    `json:"id"`
+	Tributo    string    `json:"tributo"`
+	Inscricao  string    `json:"inscricao"`
+	Exercicio  int       `json:"exercicio"`
+	Parcela    int       `json:"parcela"`
+	Descricao  string    `json:"descricao"`
+	Vencimento time.Time `json:"vencimento"`
+	Valor      float64   `json:"valor"`
+	Multa      float64   `json:"multa"`
+	Juros      float64   `json:"juros"`
+	Desconto   float64   `json:"desconto"`
+	Total      float64   `json:"total"`
+	Vencido    bool      `json:"vencido"`
+}
+
+// Emissao pede a guia de um conjunto de débitos do mesmo contribuinte.
+type Emissao struct {
+	Documento  string
+	DebitoIDs  []string
+	Vencimento time.Time
+}
+
+// Guia é o documento de arrecadação devolvido pelo sistema tributário.
+type Guia struct {
+	NossoNumero    string    `json:"nosso_numero"`
+	Vencimento     time.Time `json:"vencimento"`
+	Valor          float64   `json:"valor"`
+	CodigoBarras   string    `json:"codigo_barras"`
+	LinhaDigitavel string    `json:"linha_digitavel"`
+	Debitos        []Debito  `json:"debitos"`
+	// PDF é o documento oficial quando o fornecedor o devolve; vazio, o app desenha o seu.
+	PDF []byte `json:"-"`
+}
+
+// Provider abstrai o sistema tributário da prefeitura.
+type Provider interface {
+	Name() string
+	Debitos(ctx context.Context, consulta Consulta) ([]Debito, error)
+	EmitirGuia(ctx context.Context, emissao Emissao) (Guia, error)
+}
+
+// Config concentra a integração cadastrada pela prefeitura.
+type Config struct {
+	Provider string
+	APIBase  string
+	APIToken string
+	// Convenio é o código de 4 dígitos do órgão na FEBRABAN, usado para montar códigos de barras.
+	Convenio string
+	Ativo    bool
+}
+
+// New devolve o adaptador configurado ou ErrNotConfigured.
+func New(cfg Config) (Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" || !cfg.Ativo {
+		return nil, ErrNotConfigured
+	}
+	if !isDigits(cfg.Convenio, 4) {
+		return nil, errors.New("tributos: convênio FEBRABAN deve ter 4 dígitos")
+	}
+
+	switch name {
+	case ProviderREST:
+		if strings.TrimSpace(cfg.APIBase) == "" {
+			return nil, errors.New("tributos: endereço da API obrigatório")
+		}
+		if strings.TrimSpace(cfg.APIToken) == "" {
+			return nil, errors.New("tributos: token da API obrigatório")
+		}
+		return newREST(cfg, &http.Client{Timeout: 20 * time.Second}), nil
+	case ProviderDemo:
+		return newDemo(cfg.Convenio, time.Now), nil
+	default:
+		return nil, fmt.Errorf("tributos: provedor %s não suportado", name)
+	}
+}
+
+// NormalizeTributo padroniza o tributo informado; vazio significa todos.
+func NormalizeTributo(tributo string) (string, error) {
+	tributo = strings.ToUpper(strings.TrimSpace(tributo))
+	switch tributo {
+	case "", TributoIPTU, TributoISS:
+		return tributo, nil
+	default:
+		return "", ErrInvalid
+	}
+}
+
+// NormalizeDocumento aceita CPF ou CNPJ, com ou sem máscara, e devolve só os dígitos.
+func NormalizeDocumento(documento string) (string, error) {
+	if cpf, err := util.ValidateCPF(documento); err == nil {
+		return cpf, nil
+	}
+	if cnpj, err := util.ValidateCNPJ(documento); err == nil {
+		return cnpj, nil
+	}
+	return "", ErrInvalid
+}
+
+// MaskDocumento esconde o início e o fim do documento impresso na guia.
+func MaskDocumento(documento string) string {
+	switch len(documento) {
+	case 11:
+		return "***." + documento[3:6] + "." + documento[6:9] + "-**"
+	case 14:
+		return "**." + documento[2:5] + "." + documento[5:8] + "/" + documento[8:12] + "-**"
+	default:
+		return "***"
+	}
+}
+
+// Normalize valida a emissão: débitos sem repetição e vencimento entre hoje e o prazo máximo.
+func (e Emissao) Normalize(now time.Time) (Emissao, error) {
+	hoje := truncateDay(now)
+	seen := make(map[string]struct{}, len(e.DebitoIDs))
+	ids := make([]string, 0, len(e.DebitoIDs))
+	for _, id := range e.DebitoIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 || len(ids) > 24 {
+		return e, ErrInvalid
+	}
+	e.DebitoIDs = ids
+
+	if e.Vencimento.IsZero() {
+		e.Vencimento = hoje.AddDate(0, 0, 5)
+	}
+	e.Vencimento = truncateDay(e.Vencimento)
+	if e.Vencimento.Before(hoje) || e.Vencimento.After(hoje.AddDate(0, 0, maxDiasVencimento)) {
+		return e, ErrInvalid
+	}
+	return e, nil
+}
+
+// Totalizar soma os débitos da guia com arredondamento monetário.
+func Totalizar(debitos []Debito) float64 {
+	total := 0.0
+	for _, d := range debitos {
+		total += d.Total
+	}
+	return util.RoundMoney(total)
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func isDigits(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
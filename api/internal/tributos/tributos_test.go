@@ -0,0 +1,150 @@
+package tributos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodigoArrecadacao_DVELinhaDigitavel(t *testing.T) {
+	campo, err := CampoLivre("20261030", "123456")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codigo, err := CodigoArrecadacao("0123", 1234.56, campo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codigo) != 44 || !strings.HasPrefix(codigo, "816") || codigo[4:15] != "00000123456" || codigo[15:19] != "0123" {
+		t.Fatalf("unexpected layout %s", codigo)
+	}
+	if err := ValidarCodigo(codigo); err != nil {
+		t.Fatalf("generated code should validate: %v", err)
+	}
+
+	tampered := []byte(codigo)
+	tampered[10] = '0' + (tampered[10]-'0'+1)%10
+	if err := ValidarCodigo(string(tampered)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected DV failure, got %v", err)
+	}
+
+	linha, err := LinhaDigitavel(codigo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blocos := strings.Split(linha, " ")
+	if len(blocos) != 4 {
+		t.Fatalf("unexpected linha %s", linha)
+	}
+	for i, bloco := range blocos {
+		if bloco[:11] != codigo[i*11:(i+1)*11] || bloco[12] != mod10(bloco[:11]) {
+			t.Fatalf("unexpected bloco %d: %s", i, bloco)
+		}
+	}
+}
+
+func TestMod10EMod11(t *testing.T) {
+	// Luhn de 7992739871 é 3; em 261533, 3*2+3*3+5*4+1*5+6*6+2*7 = 90, resto 2, DV 11-2 = 9.
+	if got := mod10("7992739871"); got != '3' {
+		t.Fatalf("mod10 = %c", got)
+	}
+	if got := mod11("261533"); got != '9' {
+		t.Fatalf("mod11 = %c", got)
+	}
+}
+
+func TestBarrasITF(t *testing.T) {
+	widths, err := BarrasITF(strings.Repeat("0", 44))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	if len(widths) != 4+22*10+3 || total != 405 {
+		t.Fatalf("unexpected widths: %d elements, %d modules", len(widths), total)
+	}
+	if _, err := BarrasITF("123"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected odd length to fail, got %v", err)
+	}
+}
+
+func TestEmissaoNormalize(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	e, err := Emissao{DebitoIDs: []string{" a ", "a", "b", ""}}.Normalize(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(e.DebitoIDs) != 2 || !e.Vencimento.Equal(time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected emissao %+v", e)
+	}
+	if _, err := (Emissao{DebitoIDs: []string{"a"}, Vencimento: now.AddDate(0, 0, -1)}).Normalize(now); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected past due date to fail, got %v", err)
+	}
+	if _, err := (Emissao{DebitoIDs: []string{"a"}, Vencimento: now.AddDate(0, 0, 31)}).Normalize(now); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected far due date to fail, got %v", err)
+	}
+}
+
+func TestNormalizeDocumento(t *testing.T) {
+	if doc, err := NormalizeDocumento("11.222.333/0001-81"); err != nil || doc != "11222333000181" {
+		t.Fatalf("cnpj: %q %v", doc, err)
+	}
+	if doc, err := NormalizeDocumento("529.982.247-25"); err != nil || doc != "52998224725" {
+		t.Fatalf("cpf: %q %v", doc, err)
+	}
+	if _, err := NormalizeDocumento("11.222.333/0001-80"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid cnpj, got %v", err)
+	}
+	if got := MaskDocumento("52998224725"); got != "***.982.247-**" {
+		t.Fatalf("mask = %s", got)
+	}
+}
+
+func TestDemo_DebitosEGuia(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	provider := newDemo("0123", func() time.Time { return now })
+	ctx := context.Background()
+
+	debitos, err := provider.Debitos(ctx, Consulta{Documento: "52998224725", Tributo: TributoIPTU})
+	if err != nil || len(debitos) != 10 {
+		t.Fatalf("unexpected debitos %d %v", len(debitos), err)
+	}
+	again, _ := provider.Debitos(ctx, Consulta{Documento: "52998224725"})
+	if again[0].ID != debitos[0].ID || again[0].Total != debitos[0].Total {
+		t.Fatal("demo should be deterministic")
+	}
+	if !debitos[0].Vencido || debitos[0].Multa == 0 || debitos[9].Vencido {
+		t.Fatalf("unexpected encargos: %+v / %+v", debitos[0], debitos[9])
+	}
+
+	emissao := Emissao{Documento: "52998224725", DebitoIDs: []string{debitos[0].ID, debitos[1].ID}, Vencimento: now.AddDate(0, 0, 5)}
+	guia, err := provider.EmitirGuia(ctx, emissao)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if guia.Valor != Totalizar(debitos[:2]) || ValidarCodigo(guia.CodigoBarras) != nil {
+		t.Fatalf("unexpected guia %+v", guia)
+	}
+
+	if _, err := provider.EmitirGuia(ctx, Emissao{Documento: "11222333000181", DebitoIDs: []string{debitos[0].ID}, Vencimento: emissao.Vencimento}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("debito de outro contribuinte deveria falhar, got %v", err)
+	}
+
+	content, err := RenderPDF(guia, Cabecalho{Prefeitura: "Prefeitura de Teste", Contribuinte: MaskDocumento(emissao.Documento)})
+	if err != nil || !bytes.HasPrefix(content, []byte("%PDF-1.4")) {
+		t.Fatalf("unexpected pdf: %v", err)
+	}
+}
+
+func TestFormatBRL(t *testing.T) {
+	for value, want := range map[float64]string{0: "R$ 0,00", 1234.5: "R$ 1.234,50", 1234567.891: "R$ 1.234.567,89", -12: "-R$ 12,00"} {
+		if got := formatBRL(value); got != want {
+			t.Fatalf("formatBRL(%v) = %s, want %s", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package util
+
+import "strings"
+
+// Fields é uma seleção de campos em árvore, montada a partir de `?fields=a,b.c`.
+type Fields map[string]Fields
+
+// ParseFields interpreta a lista separada por vírgulas; caminhos com ponto selecionam campos
+// aninhados. Selecionar um pai inteiro prevalece sobre seleções dos filhos.
+func ParseFields(raw string) Fields {
+	fields := Fields{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := fields
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, exists := node[part]
+			if exists && child == nil {
+				break // pai já selecionado por inteiro
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if !exists {
+				child = Fields{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// SelectFields reduz um valor JSON decodificado (map/slice) aos campos selecionados. Listas são
+// filtradas elemento a elemento; campos inexistentes são ignorados.
+func SelectFields(value any, fields Fields) any {
+	if fields == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(fields))
+		for name, sub := range fields {
+			if child, ok := v[name]; ok {
+				out[name] = SelectFields(child, sub)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = SelectFields(item, fields)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	data := map[string]any{
+		"id":   "t1",
+		"name": "Zabelê",
+		"settings": map[string]any{
+			"theme": map[string]any{"primary": "#0a0"},
+			"logo":  "logo.png",
+		},
+		"turmas": []any{
+			map[string]any{"id": "a", "nome": "5A", "alunos": 30},
+			map[string]any{"id": "b", "nome": "5B", "alunos": 28},
+		},
+	}
+
+	got := SelectFields(data, ParseFields("id, settings.theme ,turmas.nome,missing"))
+	want := map[string]any{
+		"id":       "t1",
+		"settings": map[string]any{"theme": map[string]any{"primary": "#0a0"}},
+		"turmas":   []any{map[string]any{"nome": "5A"}, map[string]any{"nome": "5B"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected selection:\n got %#v\nwant %#v", got, want)
+	}
+
+	if fields := ParseFields("settings,settings.theme"); fields["settings"] != nil {
+		t.Fatalf("expected whole parent to win, got %#v", fields)
+	}
+	if ParseFields(" , ") != nil {
+		t.Fatal("expected empty selection to be nil")
+	}
+}
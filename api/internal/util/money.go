@@ -0,0 +1,32 @@
+package util
+
+import (
+	"math"
+	"strings"
+)
+
+// DefaultCurrency é a moeda de referência dos relatórios.
+const DefaultCurrency = "BRL"
+
+// RoundMoney arredonda valores monetários para duas casas (meio para longe do zero),
+// mesmo critério do ROUND(numeric, 2) do Postgres.
+func RoundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// ConvertMoney aplica a taxa de câmbio e arredonda o resultado.
+func ConvertMoney(amount, rate float64) float64 {
+	if rate <= 0 {
+		rate = 1
+	}
+	return RoundMoney(amount * rate)
+}
+
+// NormalizeCurrency padroniza códigos ISO 4217, caindo em BRL quando vazio.
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
@@ -0,0 +1,35 @@
+package util
+
+import "testing"
+
+func TestRoundMoney(t *testing.T) {
+	cases := map[float64]float64{
+		10.005:  10.01,
+		10.004:  10,
+		-2.345:  -2.35,
+		1999.99: 1999.99,
+	}
+	for input, want := range cases {
+		if got := RoundMoney(input); got != want {
+			t.Fatalf("RoundMoney(%v) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestConvertMoney(t *testing.T) {
+	if got := ConvertMoney(120.5, 5.4321); got != 654.57 {
+		t.Fatalf("ConvertMoney = %v, want 654.57", got)
+	}
+	if got := ConvertMoney(10, 0); got != 10 {
+		t.Fatalf("ConvertMoney with zero rate = %v, want 10", got)
+	}
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	if got := NormalizeCurrency(" usd "); got != "USD" {
+		t.Fatalf("NormalizeCurrency = %q", got)
+	}
+	if got := NormalizeCurrency(""); got != DefaultCurrency {
+		t.Fatalf("NormalizeCurrency empty = %q", got)
+	}
+}
@@ -33,3 +33,93 @@ func RequireString(value, field string) error {
 	}
 	return nil
 }
+
+// ValidateCPF confere os dígitos verificadores do CPF, com ou sem máscara, e devolve só os dígitos.
+func ValidateCPF(cpf string) (string, error) {
+	digits := make([]byte, 0, 11)
+	for i := 0; i < len(cpf); i++ {
+		switch c := cpf[i]; {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c-'0')
+		case c == '.' || c == '-' || c == ' ':
+		default:
+			return "", errors.New("cpf inválido")
+		}
+	}
+	if len(digits) != 11 {
+		return "", errors.New("cpf inválido")
+	}
+	repeated := true
+	for _, d := range digits[1:] {
+		if d != digits[0] {
+			repeated = false
+			break
+		}
+	}
+	if repeated {
+		return "", errors.New("cpf inválido")
+	}
+	for _, n := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(digits[i]) * (n + 1 - i)
+		}
+		check := sum * 10 % 11 % 10
+		if check != int(digits[n]) {
+			return "", errors.New("cpf inválido")
+		}
+	}
+	out := make([]byte, len(digits))
+	for i, d := range digits {
+		out[i] = d + '0'
+	}
+	return string(out), nil
+}
+
+// ValidateCNPJ confere os dígitos verificadores do CNPJ, com ou sem máscara, e devolve só os dígitos.
+func ValidateCNPJ(cnpj string) (string, error) {
+	digits := make([]byte, 0, 14)
+	for i := 0; i < len(cnpj); i++ {
+		switch c := cnpj[i]; {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c-'0')
+		case c == '.' || c == '-' || c == '/' || c == ' ':
+		default:
+			return "", errors.New("cnpj inválido")
+		}
+	}
+	if len(digits) != 14 {
+		return "", errors.New("cnpj inválido")
+	}
+	repeated := true
+	for _, d := range digits[1:] {
+		if d != digits[0] {
+			repeated = false
+			break
+		}
+	}
+	if repeated {
+		return "", errors.New("cnpj inválido")
+	}
+	for _, n := range []int{12, 13} {
+		sum, weight := 0, n-7
+		for i := 0; i < n; i++ {
+			sum += int(digits[i]) * weight
+			if weight--; weight < 2 {
+				weight = 9
+			}
+		}
+		check := 0
+		if rest := sum % 11; rest >= 2 {
+			check = 11 - rest
+		}
+		if check != int(digits[n]) {
+			return "", errors.New("cnpj inválido")
+		}
+	}
+	out := make([]byte, len(digits))
+	for i, d := range digits {
+		out[i] = d + '0'
+	}
+	return string(out), nil
+}
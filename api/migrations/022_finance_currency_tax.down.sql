@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_finance_entries_currency;
+
+ALTER TABLE saas_finance_entries
+    DROP CONSTRAINT IF EXISTS saas_finance_entries_retention_check,
+    DROP CONSTRAINT IF EXISTS saas_finance_entries_exchange_rate_check,
+    DROP CONSTRAINT IF EXISTS saas_finance_entries_currency_check;
+
+ALTER TABLE saas_finance_entries
+    DROP COLUMN IF EXISTS net_amount_brl,
+    DROP COLUMN IF EXISTS amount_brl,
+    DROP COLUMN IF EXISTS net_amount;
+
+ALTER TABLE saas_finance_entries
+    DROP COLUMN IF EXISTS ir_retention,
+    DROP COLUMN IF EXISTS iss_retention,
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS currency;
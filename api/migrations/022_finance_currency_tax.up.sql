@@ -0,0 +1,17 @@
+ALTER TABLE saas_finance_entries
+    ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL',
+    ADD COLUMN exchange_rate NUMERIC(14,6) NOT NULL DEFAULT 1,
+    ADD COLUMN iss_retention NUMERIC(14,2) NOT NULL DEFAULT 0,
+    ADD COLUMN ir_retention NUMERIC(14,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE saas_finance_entries
+    ADD COLUMN net_amount NUMERIC(14,2) GENERATED ALWAYS AS (amount - iss_retention - ir_retention) STORED,
+    ADD COLUMN amount_brl NUMERIC(14,2) GENERATED ALWAYS AS (ROUND(amount * exchange_rate, 2)) STORED,
+    ADD COLUMN net_amount_brl NUMERIC(14,2) GENERATED ALWAYS AS (ROUND((amount - iss_retention - ir_retention) * exchange_rate, 2)) STORED;
+
+ALTER TABLE saas_finance_entries
+    ADD CONSTRAINT saas_finance_entries_currency_check CHECK (currency IN ('BRL', 'USD', 'EUR')),
+    ADD CONSTRAINT saas_finance_entries_exchange_rate_check CHECK (exchange_rate > 0 AND (currency <> 'BRL' OR exchange_rate = 1)),
+    ADD CONSTRAINT saas_finance_entries_retention_check CHECK (iss_retention >= 0 AND ir_retention >= 0 AND iss_retention + ir_retention <= amount);
+
+CREATE INDEX idx_finance_entries_currency ON saas_finance_entries (currency);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS saas_finance_entry_approvals;
+
+DROP INDEX IF EXISTS idx_finance_entries_approval;
+
+ALTER TABLE saas_finance_entries
+    DROP COLUMN IF EXISTS decided_at,
+    DROP COLUMN IF EXISTS decided_by,
+    DROP COLUMN IF EXISTS approval_comment,
+    DROP COLUMN IF EXISTS approval_status;
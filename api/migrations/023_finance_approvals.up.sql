@@ -0,0 +1,19 @@
+ALTER TABLE saas_finance_entries
+    ADD COLUMN approval_status TEXT NOT NULL DEFAULT 'approved' CHECK (approval_status IN ('pending','approved','rejected')),
+    ADD COLUMN approval_comment TEXT,
+    ADD COLUMN decided_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    ADD COLUMN decided_at TIMESTAMPTZ;
+
+CREATE INDEX idx_finance_entries_approval ON saas_finance_entries (approval_status);
+
+CREATE TABLE saas_finance_entry_approvals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    finance_entry_id UUID NOT NULL REFERENCES saas_finance_entries(id) ON DELETE CASCADE,
+    decision TEXT NOT NULL CHECK (decision IN ('requested','approved','rejected')),
+    comment TEXT,
+    amount_brl NUMERIC(14,2),
+    actor_id UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_finance_entry_approvals_entry ON saas_finance_entry_approvals (finance_entry_id, created_at DESC);
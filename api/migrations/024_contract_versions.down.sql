@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saas_contract_signature_events;
+DROP TABLE IF EXISTS saas_tenant_contract_versions;
@@ -0,0 +1,43 @@
+CREATE TABLE saas_tenant_contract_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    file_url TEXT NOT NULL,
+    file_key TEXT,
+    file_name TEXT,
+    effective_from DATE NOT NULL DEFAULT CURRENT_DATE,
+    effective_to DATE,
+    notes TEXT,
+    signature_provider TEXT,
+    signature_document_id TEXT,
+    signature_status TEXT NOT NULL DEFAULT 'not_requested' CHECK (signature_status IN ('not_requested','pending','partially_signed','signed','refused','cancelled','expired')),
+    signature_url TEXT,
+    signed_at TIMESTAMPTZ,
+    signature_updated_at TIMESTAMPTZ,
+    uploaded_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    uploaded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (effective_to IS NULL OR effective_to >= effective_from)
+);
+
+CREATE UNIQUE INDEX idx_contract_versions_unique ON saas_tenant_contract_versions (tenant_id, version);
+CREATE UNIQUE INDEX idx_contract_versions_signature ON saas_tenant_contract_versions (signature_provider, signature_document_id) WHERE signature_document_id IS NOT NULL;
+
+CREATE TABLE saas_contract_signature_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    version_id UUID NOT NULL REFERENCES saas_tenant_contract_versions(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    event TEXT NOT NULL,
+    status TEXT NOT NULL,
+    signer_email TEXT,
+    payload JSONB,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_contract_signature_events_version ON saas_contract_signature_events (version_id, received_at DESC);
+
+-- Contratos já enviados viram a versão 1.
+INSERT INTO saas_tenant_contract_versions (tenant_id, version, file_url, file_key, effective_from, uploaded_at)
+SELECT tenant_id, 1, contract_file_url, contract_file_key, COALESCE(start_date, updated_at::date), updated_at
+FROM saas_tenant_contracts
+WHERE contract_file_url IS NOT NULL;
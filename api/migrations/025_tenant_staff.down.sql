@@ -0,0 +1,7 @@
+ALTER TABLE usuarios
+    DROP COLUMN IF EXISTS ultimo_login_em;
+
+DROP INDEX IF EXISTS idx_secretarias_tenant;
+
+ALTER TABLE secretarias
+    DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,7 @@
+ALTER TABLE secretarias
+    ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_secretarias_tenant ON secretarias (tenant_id);
+
+ALTER TABLE usuarios
+    ADD COLUMN IF NOT EXISTS ultimo_login_em TIMESTAMPTZ;
@@ -0,0 +1,14 @@
+UPDATE saas_push_notifications SET status = 'cancelled' WHERE status = 'suppressed';
+
+ALTER TABLE saas_push_notifications
+    DROP CONSTRAINT IF EXISTS saas_push_notifications_status_check;
+
+ALTER TABLE saas_push_notifications
+    ADD CONSTRAINT saas_push_notifications_status_check CHECK (status IN ('pending','approved','rejected','sent','cancelled'));
+
+ALTER TABLE tenants
+    DROP CONSTRAINT IF EXISTS tenants_environment_check;
+
+ALTER TABLE tenants
+    DROP COLUMN IF EXISTS sandbox_reset_at,
+    DROP COLUMN IF EXISTS environment;
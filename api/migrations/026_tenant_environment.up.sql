@@ -0,0 +1,12 @@
+ALTER TABLE tenants
+    ADD COLUMN environment TEXT NOT NULL DEFAULT 'production',
+    ADD COLUMN sandbox_reset_at TIMESTAMPTZ;
+
+ALTER TABLE tenants
+    ADD CONSTRAINT tenants_environment_check CHECK (environment IN ('production', 'sandbox'));
+
+ALTER TABLE saas_push_notifications
+    DROP CONSTRAINT IF EXISTS saas_push_notifications_status_check;
+
+ALTER TABLE saas_push_notifications
+    ADD CONSTRAINT saas_push_notifications_status_check CHECK (status IN ('pending','approved','rejected','sent','cancelled','suppressed'));
@@ -0,0 +1,9 @@
+CREATE TABLE saas_demo_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    table_name TEXT NOT NULL,
+    record_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_demo_records_tenant_table ON saas_demo_records (tenant_id, table_name);
@@ -0,0 +1,31 @@
+DROP INDEX IF EXISTS idx_avaliacoes_ano_turma;
+DROP INDEX IF EXISTS idx_aulas_ano_turma;
+DROP INDEX IF EXISTS idx_matriculas_ano_turma;
+
+ALTER TABLE notas DROP CONSTRAINT IF EXISTS notas_ano_turma_disciplina_bimestre_matricula_key;
+ALTER TABLE matriculas DROP CONSTRAINT IF EXISTS matriculas_aluno_turma_ano_key;
+
+-- Mantém apenas o registro mais recente de cada chave antiga antes de restaurar as restrições.
+DELETE FROM notas n
+USING notas n2
+WHERE n.turma_id = n2.turma_id
+  AND n.disciplina = n2.disciplina
+  AND n.bimestre = n2.bimestre
+  AND n.matricula_id = n2.matricula_id
+  AND n.ano_letivo < n2.ano_letivo;
+
+DELETE FROM matriculas m
+USING matriculas m2
+WHERE m.aluno_id = m2.aluno_id
+  AND m.turma_id = m2.turma_id
+  AND m.ano_letivo < m2.ano_letivo;
+
+ALTER TABLE notas ADD CONSTRAINT notas_turma_id_disciplina_bimestre_matricula_id_key UNIQUE (turma_id, disciplina, bimestre, matricula_id);
+ALTER TABLE matriculas ADD CONSTRAINT matriculas_aluno_id_turma_id_key UNIQUE (aluno_id, turma_id);
+
+ALTER TABLE avaliacoes DROP COLUMN IF EXISTS ano_letivo;
+ALTER TABLE notas DROP COLUMN IF EXISTS ano_letivo;
+ALTER TABLE aulas DROP COLUMN IF EXISTS ano_letivo;
+ALTER TABLE matriculas DROP COLUMN IF EXISTS ano_letivo;
+
+DROP TABLE IF EXISTS anos_letivos;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS anos_letivos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    ano INT NOT NULL CHECK (ano BETWEEN 2000 AND 2100),
+    inicio DATE NOT NULL,
+    fim DATE NOT NULL,
+    ativo BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, ano),
+    CHECK (fim > inicio)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_anos_letivos_ativo ON anos_letivos (tenant_id) WHERE ativo;
+
+ALTER TABLE matriculas ADD COLUMN IF NOT EXISTS ano_letivo INT;
+ALTER TABLE aulas ADD COLUMN IF NOT EXISTS ano_letivo INT;
+ALTER TABLE notas ADD COLUMN IF NOT EXISTS ano_letivo INT;
+ALTER TABLE avaliacoes ADD COLUMN IF NOT EXISTS ano_letivo INT;
+
+-- Dados anteriores à dimensão pertencem ao ano corrente; aulas e avaliações usam a própria data.
+UPDATE matriculas SET ano_letivo = EXTRACT(YEAR FROM now())::int WHERE ano_letivo IS NULL;
+UPDATE notas SET ano_letivo = EXTRACT(YEAR FROM now())::int WHERE ano_letivo IS NULL;
+UPDATE aulas SET ano_letivo = EXTRACT(YEAR FROM inicio)::int WHERE ano_letivo IS NULL;
+UPDATE avaliacoes SET ano_letivo = EXTRACT(YEAR FROM COALESCE(inicio, created_at))::int WHERE ano_letivo IS NULL;
+
+ALTER TABLE matriculas
+    ALTER COLUMN ano_letivo SET DEFAULT EXTRACT(YEAR FROM now())::int,
+    ALTER COLUMN ano_letivo SET NOT NULL;
+ALTER TABLE aulas
+    ALTER COLUMN ano_letivo SET DEFAULT EXTRACT(YEAR FROM now())::int,
+    ALTER COLUMN ano_letivo SET NOT NULL;
+ALTER TABLE notas
+    ALTER COLUMN ano_letivo SET DEFAULT EXTRACT(YEAR FROM now())::int,
+    ALTER COLUMN ano_letivo SET NOT NULL;
+ALTER TABLE avaliacoes
+    ALTER COLUMN ano_letivo SET DEFAULT EXTRACT(YEAR FROM now())::int,
+    ALTER COLUMN ano_letivo SET NOT NULL;
+
+ALTER TABLE matriculas DROP CONSTRAINT IF EXISTS matriculas_aluno_id_turma_id_key;
+ALTER TABLE matriculas ADD CONSTRAINT matriculas_aluno_turma_ano_key UNIQUE (aluno_id, turma_id, ano_letivo);
+
+ALTER TABLE notas DROP CONSTRAINT IF EXISTS notas_turma_id_disciplina_bimestre_matricula_id_key;
+ALTER TABLE notas ADD CONSTRAINT notas_ano_turma_disciplina_bimestre_matricula_key UNIQUE (ano_letivo, turma_id, disciplina, bimestre, matricula_id);
+
+CREATE INDEX IF NOT EXISTS idx_matriculas_ano_turma ON matriculas (ano_letivo, turma_id);
+CREATE INDEX IF NOT EXISTS idx_aulas_ano_turma ON aulas (ano_letivo, turma_id);
+CREATE INDEX IF NOT EXISTS idx_avaliacoes_ano_turma ON avaliacoes (ano_letivo, turma_id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS escolas_gestores (
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    escola_id UUID NOT NULL REFERENCES escolas(id) ON DELETE CASCADE,
+    cargo TEXT NOT NULL DEFAULT 'DIRETOR' CHECK (cargo IN ('DIRETOR', 'COORDENADOR')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (usuario_id, escola_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_escolas_gestores_escola ON escolas_gestores (escola_id);
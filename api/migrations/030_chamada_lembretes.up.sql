@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS professor_notificacoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    professor_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL,
+    titulo TEXT NOT NULL,
+    mensagem TEXT NOT NULL,
+    referencia_id UUID,
+    lida_em TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (professor_id, tipo, referencia_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_professor_notificacoes_pendentes
+    ON professor_notificacoes (professor_id, created_at DESC)
+    WHERE lida_em IS NULL;
+CREATE INDEX IF NOT EXISTS idx_professor_notificacoes_referencia
+    ON professor_notificacoes (tipo, referencia_id);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS justificativas_falta (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aluno_id UUID NOT NULL REFERENCES alunos(id) ON DELETE CASCADE,
+    escola_id UUID NOT NULL REFERENCES escolas(id) ON DELETE CASCADE,
+    data_inicio DATE NOT NULL,
+    data_fim DATE NOT NULL,
+    motivo TEXT NOT NULL,
+    solicitante_tipo TEXT NOT NULL DEFAULT 'RESPONSAVEL' CHECK (solicitante_tipo IN ('RESPONSAVEL', 'SECRETARIA')),
+    solicitante_nome TEXT,
+    anexo_nome TEXT,
+    anexo_url TEXT,
+    anexo_key TEXT,
+    status TEXT NOT NULL DEFAULT 'PENDENTE' CHECK (status IN ('PENDENTE', 'APROVADA', 'REJEITADA')),
+    parecer TEXT,
+    aulas_afetadas INT NOT NULL DEFAULT 0,
+    registrado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    analisado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    analisado_em TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (data_fim >= data_inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_justificativas_escola_status ON justificativas_falta (escola_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_justificativas_aluno_periodo ON justificativas_falta (aluno_id, data_inicio, data_fim) WHERE status = 'APROVADA';
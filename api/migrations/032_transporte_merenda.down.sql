@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS merenda_registros;
+DROP TABLE IF EXISTS transporte_rota_alunos;
+DROP TABLE IF EXISTS transporte_rotas;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS transporte_rotas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    escola_id UUID NOT NULL REFERENCES escolas(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    turno TEXT NOT NULL DEFAULT 'MANHA',
+    zona TEXT NOT NULL DEFAULT 'RURAL' CHECK (zona IN ('RURAL', 'URBANA')),
+    motorista_nome TEXT,
+    motorista_cnh TEXT,
+    motorista_telefone TEXT,
+    veiculo_placa TEXT,
+    veiculo_modelo TEXT,
+    veiculo_capacidade INT CHECK (veiculo_capacidade IS NULL OR veiculo_capacidade > 0),
+    km_diario NUMERIC(8,2) NOT NULL DEFAULT 0 CHECK (km_diario >= 0),
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (escola_id, nome)
+);
+
+CREATE TABLE IF NOT EXISTS transporte_rota_alunos (
+    rota_id UUID NOT NULL REFERENCES transporte_rotas(id) ON DELETE CASCADE,
+    aluno_id UUID NOT NULL REFERENCES alunos(id) ON DELETE CASCADE,
+    ponto_embarque TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (rota_id, aluno_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transporte_rota_alunos_aluno ON transporte_rota_alunos (aluno_id);
+
+-- Refeições efetivamente servidas por dia; a previsão vem da frequência registrada.
+CREATE TABLE IF NOT EXISTS merenda_registros (
+    escola_id UUID NOT NULL REFERENCES escolas(id) ON DELETE CASCADE,
+    data DATE NOT NULL,
+    refeicoes_servidas INT NOT NULL CHECK (refeicoes_servidas >= 0),
+    observacao TEXT,
+    registrado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (escola_id, data)
+);
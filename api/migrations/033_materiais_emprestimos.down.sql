@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS materiais_emprestimos;
+ALTER TABLE materiais DROP COLUMN IF EXISTS quantidade;
@@ -0,0 +1,17 @@
+ALTER TABLE materiais
+    ADD COLUMN IF NOT EXISTS quantidade INT CHECK (quantidade IS NULL OR quantidade >= 0);
+
+CREATE TABLE IF NOT EXISTS materiais_emprestimos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    material_id UUID NOT NULL REFERENCES materiais(id) ON DELETE CASCADE,
+    aluno_id UUID NOT NULL REFERENCES alunos(id) ON DELETE CASCADE,
+    quantidade INT NOT NULL DEFAULT 1 CHECK (quantidade > 0),
+    emprestado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+    devolver_ate DATE NOT NULL,
+    devolvido_em TIMESTAMPTZ,
+    observacao TEXT,
+    registrado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_emprestimos_material_ativos ON materiais_emprestimos (material_id) WHERE devolvido_em IS NULL;
+CREATE INDEX IF NOT EXISTS idx_emprestimos_vencimento ON materiais_emprestimos (devolver_ate) WHERE devolvido_em IS NULL;
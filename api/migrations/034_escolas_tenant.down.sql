@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_escolas_tenant;
+ALTER TABLE escolas DROP COLUMN IF EXISTS tenant_id;
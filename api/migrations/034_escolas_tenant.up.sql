@@ -0,0 +1,27 @@
+ALTER TABLE escolas
+    ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_escolas_tenant ON escolas (tenant_id);
+
+-- Escolas existentes herdam a prefeitura da secretaria de quem as gere ou leciona nelas.
+UPDATE escolas e
+SET tenant_id = vinculo.tenant_id
+FROM (
+    SELECT DISTINCT ON (escola_id) escola_id, tenant_id
+    FROM (
+        SELECT eg.escola_id, s.tenant_id
+        FROM escolas_gestores eg
+        JOIN usuarios_secretarias us ON us.usuario_id = eg.usuario_id
+        JOIN secretarias s ON s.id = us.secretaria_id
+        WHERE s.tenant_id IS NOT NULL
+        UNION ALL
+        SELECT t.escola_id, s.tenant_id
+        FROM turmas t
+        JOIN professores_turmas pt ON pt.turma_id = t.id
+        JOIN usuarios_secretarias us ON us.usuario_id = pt.professor_id
+        JOIN secretarias s ON s.id = us.secretaria_id
+        WHERE s.tenant_id IS NOT NULL AND t.escola_id IS NOT NULL
+    ) candidatos
+    ORDER BY escola_id
+) vinculo
+WHERE e.id = vinculo.escola_id AND e.tenant_id IS NULL;
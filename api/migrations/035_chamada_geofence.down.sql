@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS chamada_excecoes;
+ALTER TABLE escolas
+    DROP COLUMN IF EXISTS geofence_raio_metros,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,21 @@
+ALTER TABLE escolas
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS geofence_raio_metros INT CHECK (geofence_raio_metros IS NULL OR geofence_raio_metros > 0);
+
+-- Chamadas aceitas apesar de violar a política de geofence/atestação do tenant.
+CREATE TABLE IF NOT EXISTS chamada_excecoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aula_id UUID NOT NULL REFERENCES aulas(id) ON DELETE CASCADE,
+    turma_id UUID NOT NULL REFERENCES turmas(id) ON DELETE CASCADE,
+    professor_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL CHECK (tipo IN ('GEOFENCE', 'ATESTACAO')),
+    detalhe TEXT NOT NULL,
+    motivo TEXT NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    distancia_metros DOUBLE PRECISION,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chamada_excecoes_turma ON chamada_excecoes (turma_id, created_at DESC);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS escolas_gestores_privileged_actions ON escolas_gestores;
+DROP TRIGGER IF EXISTS professores_turmas_privileged_actions ON professores_turmas;
+DROP TRIGGER IF EXISTS usuarios_secretarias_privileged_actions ON usuarios_secretarias;
+DROP TRIGGER IF EXISTS saas_users_privileged_actions ON saas_users;
+DROP FUNCTION IF EXISTS log_privileged_action();
+DROP FUNCTION IF EXISTS privilege_rank(TEXT, TEXT);
+DROP TABLE IF EXISTS saas_privileged_actions;
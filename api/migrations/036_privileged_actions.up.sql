@@ -0,0 +1,132 @@
+-- Registro imutável de mudanças de papéis e vínculos que concedem acesso.
+-- Os triggers capturam qualquer escrita; o autor vem de set_config('app.actor_id', ..., true) na transação.
+CREATE TABLE IF NOT EXISTS saas_privileged_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity TEXT NOT NULL CHECK (entity IN ('SAAS_USER', 'SECRETARIA_PAPEL', 'PROFESSOR_TURMA', 'ESCOLA_GESTOR')),
+    action TEXT NOT NULL CHECK (action IN ('GRANT', 'CHANGE', 'REVOKE')),
+    subject_id UUID NOT NULL,
+    scope_id UUID,
+    actor_id UUID,
+    before JSONB,
+    after JSONB,
+    escalation BOOLEAN NOT NULL DEFAULT FALSE,
+    notified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_privileged_actions_created ON saas_privileged_actions (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_privileged_actions_subject ON saas_privileged_actions (subject_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_privileged_actions_owner_pending ON saas_privileged_actions (created_at)
+    WHERE entity = 'SAAS_USER' AND escalation AND notified_at IS NULL;
+
+CREATE OR REPLACE FUNCTION privilege_rank(entity TEXT, role TEXT) RETURNS INT AS $$
+BEGIN
+    IF role IS NULL THEN
+        RETURN 0;
+    END IF;
+    IF entity = 'SAAS_USER' THEN
+        RETURN CASE lower(role) WHEN 'owner' THEN 4 WHEN 'admin' THEN 3 ELSE 1 END;
+    ELSIF entity = 'SECRETARIA_PAPEL' THEN
+        RETURN CASE upper(role) WHEN 'ADMIN_TEC' THEN 4 WHEN 'PREFEITO' THEN 3 WHEN 'SECRETARIO' THEN 2 ELSE 1 END;
+    ELSIF entity = 'ESCOLA_GESTOR' THEN
+        RETURN CASE upper(role) WHEN 'DIRETOR' THEN 2 ELSE 1 END;
+    END IF;
+    RETURN 1;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION log_privileged_action() RETURNS TRIGGER AS $$
+DECLARE
+    v_entity TEXT := TG_ARGV[0];
+    v_before JSONB;
+    v_after JSONB;
+    v_subject UUID;
+    v_scope UUID;
+    v_role_before TEXT;
+    v_role_after TEXT;
+    v_action TEXT;
+BEGIN
+    IF v_entity = 'SAAS_USER' THEN
+        IF TG_OP <> 'INSERT' THEN
+            v_before := jsonb_build_object('role', OLD.role, 'active', OLD.active);
+            v_role_before := CASE WHEN OLD.active THEN OLD.role END;
+            v_subject := OLD.id;
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            v_after := jsonb_build_object('role', NEW.role, 'active', NEW.active);
+            v_role_after := CASE WHEN NEW.active THEN NEW.role END;
+            v_subject := NEW.id;
+        END IF;
+    ELSIF v_entity = 'SECRETARIA_PAPEL' THEN
+        IF TG_OP <> 'INSERT' THEN
+            v_before := jsonb_build_object('papel', OLD.papel);
+            v_role_before := OLD.papel;
+            v_subject := OLD.usuario_id;
+            v_scope := OLD.secretaria_id;
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            v_after := jsonb_build_object('papel', NEW.papel);
+            v_role_after := NEW.papel;
+            v_subject := NEW.usuario_id;
+            v_scope := NEW.secretaria_id;
+        END IF;
+    ELSIF v_entity = 'PROFESSOR_TURMA' THEN
+        IF TG_OP <> 'INSERT' THEN
+            v_before := jsonb_build_object('disciplinas', to_jsonb(OLD.disciplinas));
+            v_role_before := 'PROFESSOR';
+            v_subject := OLD.professor_id;
+            v_scope := OLD.turma_id;
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            v_after := jsonb_build_object('disciplinas', to_jsonb(NEW.disciplinas));
+            v_role_after := 'PROFESSOR';
+            v_subject := NEW.professor_id;
+            v_scope := NEW.turma_id;
+        END IF;
+    ELSIF v_entity = 'ESCOLA_GESTOR' THEN
+        IF TG_OP <> 'INSERT' THEN
+            v_before := jsonb_build_object('cargo', OLD.cargo);
+            v_role_before := OLD.cargo;
+            v_subject := OLD.usuario_id;
+            v_scope := OLD.escola_id;
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            v_after := jsonb_build_object('cargo', NEW.cargo);
+            v_role_after := NEW.cargo;
+            v_subject := NEW.usuario_id;
+            v_scope := NEW.escola_id;
+        END IF;
+    END IF;
+
+    IF TG_OP = 'UPDATE' AND v_before IS NOT DISTINCT FROM v_after THEN
+        RETURN NULL;
+    END IF;
+
+    v_action := CASE TG_OP WHEN 'INSERT' THEN 'GRANT' WHEN 'DELETE' THEN 'REVOKE' ELSE 'CHANGE' END;
+
+    INSERT INTO saas_privileged_actions (entity, action, subject_id, scope_id, actor_id, before, after, escalation)
+    VALUES (
+        v_entity, v_action, v_subject, v_scope,
+        NULLIF(current_setting('app.actor_id', true), '')::uuid,
+        v_before, v_after,
+        privilege_rank(v_entity, v_role_after) > privilege_rank(v_entity, v_role_before)
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER saas_users_privileged_actions
+    AFTER INSERT OR UPDATE OF role, active OR DELETE ON saas_users
+    FOR EACH ROW EXECUTE FUNCTION log_privileged_action('SAAS_USER');
+
+CREATE TRIGGER usuarios_secretarias_privileged_actions
+    AFTER INSERT OR UPDATE OR DELETE ON usuarios_secretarias
+    FOR EACH ROW EXECUTE FUNCTION log_privileged_action('SECRETARIA_PAPEL');
+
+CREATE TRIGGER professores_turmas_privileged_actions
+    AFTER INSERT OR UPDATE OR DELETE ON professores_turmas
+    FOR EACH ROW EXECUTE FUNCTION log_privileged_action('PROFESSOR_TURMA');
+
+CREATE TRIGGER escolas_gestores_privileged_actions
+    AFTER INSERT OR UPDATE OR DELETE ON escolas_gestores
+    FOR EACH ROW EXECUTE FUNCTION log_privileged_action('ESCOLA_GESTOR');
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scim_identidades;
+DROP TABLE IF EXISTS scim_regras;
+DROP TABLE IF EXISTS scim_tokens;
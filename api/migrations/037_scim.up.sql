@@ -0,0 +1,38 @@
+-- Provisionamento SCIM: token por prefeitura, regras de mapeamento e vínculo com o IdP.
+CREATE TABLE IF NOT EXISTS scim_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scim_tokens_tenant_active ON scim_tokens (tenant_id) WHERE revoked_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS scim_regras (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    atributo TEXT NOT NULL CHECK (atributo IN ('department', 'title', 'role')),
+    valor TEXT NOT NULL,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    papel TEXT NOT NULL CHECK (papel IN ('ATENDENTE', 'SECRETARIO', 'PREFEITO', 'ADMIN_TEC')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, atributo, valor, secretaria_id)
+);
+
+CREATE TABLE IF NOT EXISTS scim_identidades (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    external_id TEXT,
+    department TEXT,
+    title TEXT,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+    atualizado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, usuario_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scim_identidades_external ON scim_identidades (tenant_id, external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scim_identidades_usuario ON scim_identidades (usuario_id);
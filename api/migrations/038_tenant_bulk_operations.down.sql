@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saas_tenant_bulk_operation_items;
+DROP TABLE IF EXISTS saas_tenant_bulk_operations;
@@ -0,0 +1,26 @@
+-- Operações em lote sobre prefeituras, executadas em segundo plano com relatório por tenant.
+CREATE TABLE IF NOT EXISTS saas_tenant_bulk_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    operation TEXT NOT NULL CHECK (operation IN ('status', 'modules', 'reprovision')),
+    params JSONB NOT NULL DEFAULT '{}'::jsonb,
+    filter JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    total INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_bulk_operations_created ON saas_tenant_bulk_operations (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS saas_tenant_bulk_operation_items (
+    operation_id UUID NOT NULL REFERENCES saas_tenant_bulk_operations(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed', 'skipped')),
+    error TEXT,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (operation_id, tenant_id)
+);
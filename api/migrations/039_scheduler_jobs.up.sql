@@ -0,0 +1,10 @@
+-- Coordenação de tarefas periódicas entre réplicas: cada job tem um lease e um último início.
+CREATE TABLE IF NOT EXISTS scheduler_jobs (
+    name TEXT PRIMARY KEY,
+    holder TEXT,
+    locked_until TIMESTAMPTZ,
+    last_started_at TIMESTAMPTZ,
+    last_finished_at TIMESTAMPTZ,
+    last_error TEXT,
+    runs BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,49 @@
+DROP TRIGGER IF EXISTS trg_aulas_inicio_presencas ON aulas;
+DROP FUNCTION IF EXISTS presencas_acompanhar_aula();
+
+ALTER TABLE presencas RENAME TO presencas_particionada;
+ALTER TABLE presencas_particionada RENAME CONSTRAINT presencas_pkey TO presencas_particionada_pkey;
+DROP INDEX IF EXISTS idx_presencas_aula;
+DROP INDEX IF EXISTS idx_presencas_matricula;
+
+CREATE TABLE presencas (
+    aula_id UUID NOT NULL REFERENCES aulas(id) ON DELETE CASCADE,
+    matricula_id UUID NOT NULL REFERENCES matriculas(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('PRESENTE','FALTA','ATRASO','JUSTIFICADA')),
+    origem TEXT NOT NULL DEFAULT 'MANUAL',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    justificativa TEXT,
+    PRIMARY KEY (aula_id, matricula_id)
+);
+INSERT INTO presencas (aula_id, matricula_id, status, origem, updated_at, justificativa)
+SELECT aula_id, matricula_id, status, origem, updated_at, justificativa FROM presencas_particionada;
+DROP TABLE presencas_particionada CASCADE;
+CREATE INDEX idx_presencas_aula ON presencas(aula_id);
+
+ALTER TABLE saas_access_logs RENAME TO saas_access_logs_particionada;
+ALTER TABLE saas_access_logs_particionada RENAME CONSTRAINT saas_access_logs_pkey TO saas_access_logs_particionada_pkey;
+DROP INDEX IF EXISTS idx_access_logs_logged_at;
+DROP INDEX IF EXISTS idx_access_logs_tenant;
+DROP INDEX IF EXISTS idx_access_logs_role;
+
+CREATE TABLE saas_access_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,
+    user_name TEXT NOT NULL,
+    email TEXT,
+    role TEXT,
+    tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL,
+    logged_at TIMESTAMPTZ NOT NULL,
+    ip_address TEXT,
+    location TEXT,
+    user_agent TEXT,
+    status TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO saas_access_logs SELECT * FROM saas_access_logs_particionada;
+DROP TABLE saas_access_logs_particionada CASCADE;
+CREATE INDEX idx_access_logs_logged_at ON saas_access_logs (logged_at DESC);
+CREATE INDEX idx_access_logs_tenant ON saas_access_logs (tenant_id);
+CREATE INDEX idx_access_logs_role ON saas_access_logs (role);
+
+DROP FUNCTION IF EXISTS particao_mensal_garantir(TEXT, TEXT, DATE);
@@ -0,0 +1,135 @@
+-- Particionamento mensal nativo de presencas (pela data da aula) e saas_access_logs (pelo acesso).
+-- Cada tabela tem uma partição DEFAULT que recebe linhas fora das faixas criadas; o job de
+-- manutenção cria as partições futuras e move para elas o que tiver caído na DEFAULT.
+
+CREATE OR REPLACE FUNCTION particao_mensal_garantir(tabela TEXT, coluna TEXT, mes DATE)
+RETURNS BOOLEAN AS $$
+DECLARE
+    inicio TIMESTAMPTZ := date_trunc('month', mes::timestamp) AT TIME ZONE 'UTC';
+    fim TIMESTAMPTZ := (date_trunc('month', mes::timestamp) + interval '1 month') AT TIME ZONE 'UTC';
+    nome TEXT := format('%s_p%s', tabela, to_char(mes, 'YYYY_MM'));
+BEGIN
+    IF to_regclass(nome) IS NOT NULL THEN
+        RETURN FALSE;
+    END IF;
+    EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', nome, tabela);
+    EXECUTE format(
+        'WITH movidas AS (DELETE FROM %I WHERE %I >= %L AND %I < %L RETURNING *) INSERT INTO %I SELECT * FROM movidas',
+        tabela || '_default', coluna, inicio, coluna, fim, nome
+    );
+    EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', tabela, nome, inicio, fim);
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- presencas ------------------------------------------------------------------
+
+ALTER TABLE presencas RENAME TO presencas_legado;
+ALTER TABLE presencas_legado RENAME CONSTRAINT presencas_pkey TO presencas_legado_pkey;
+DROP INDEX IF EXISTS idx_presencas_aula;
+
+-- aula_inicio replica aulas.inicio para que a chave de partição acompanhe a linha.
+CREATE TABLE presencas (
+    aula_id UUID NOT NULL REFERENCES aulas(id) ON DELETE CASCADE,
+    matricula_id UUID NOT NULL REFERENCES matriculas(id) ON DELETE CASCADE,
+    aula_inicio TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('PRESENTE','FALTA','ATRASO','JUSTIFICADA')),
+    origem TEXT NOT NULL DEFAULT 'MANUAL',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    justificativa TEXT,
+    PRIMARY KEY (aula_id, matricula_id, aula_inicio)
+) PARTITION BY RANGE (aula_inicio);
+
+CREATE TABLE presencas_default PARTITION OF presencas DEFAULT;
+
+DO $$
+DECLARE
+    mes DATE;
+BEGIN
+    FOR mes IN
+        SELECT generate_series(
+            date_trunc('month', COALESCE((SELECT min(a.inicio) FROM aulas a JOIN presencas_legado p ON p.aula_id = a.id), now()) AT TIME ZONE 'UTC'),
+            date_trunc('month', now() AT TIME ZONE 'UTC') + interval '3 months',
+            interval '1 month'
+        )::date
+    LOOP
+        PERFORM particao_mensal_garantir('presencas', 'aula_inicio', mes);
+    END LOOP;
+END;
+$$;
+
+INSERT INTO presencas (aula_id, matricula_id, aula_inicio, status, origem, updated_at, justificativa)
+SELECT p.aula_id, p.matricula_id, a.inicio, p.status, p.origem, p.updated_at, p.justificativa
+FROM presencas_legado p
+JOIN aulas a ON a.id = p.aula_id;
+
+DROP TABLE presencas_legado;
+
+CREATE INDEX idx_presencas_aula ON presencas (aula_id);
+CREATE INDEX idx_presencas_matricula ON presencas (matricula_id, aula_inicio);
+
+CREATE OR REPLACE FUNCTION presencas_acompanhar_aula() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE presencas SET aula_inicio = NEW.inicio
+    WHERE aula_id = NEW.id AND aula_inicio = OLD.inicio;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_aulas_inicio_presencas
+AFTER UPDATE OF inicio ON aulas
+FOR EACH ROW
+WHEN (OLD.inicio IS DISTINCT FROM NEW.inicio)
+EXECUTE FUNCTION presencas_acompanhar_aula();
+
+-- saas_access_logs -----------------------------------------------------------
+
+ALTER TABLE saas_access_logs RENAME TO saas_access_logs_legado;
+ALTER TABLE saas_access_logs_legado RENAME CONSTRAINT saas_access_logs_pkey TO saas_access_logs_legado_pkey;
+DROP INDEX IF EXISTS idx_access_logs_logged_at;
+DROP INDEX IF EXISTS idx_access_logs_tenant;
+DROP INDEX IF EXISTS idx_access_logs_role;
+
+CREATE TABLE saas_access_logs (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    user_id UUID,
+    user_name TEXT NOT NULL,
+    email TEXT,
+    role TEXT,
+    tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL,
+    logged_at TIMESTAMPTZ NOT NULL,
+    ip_address TEXT,
+    location TEXT,
+    user_agent TEXT,
+    status TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id, logged_at)
+) PARTITION BY RANGE (logged_at);
+
+CREATE TABLE saas_access_logs_default PARTITION OF saas_access_logs DEFAULT;
+
+DO $$
+DECLARE
+    mes DATE;
+BEGIN
+    FOR mes IN
+        SELECT generate_series(
+            date_trunc('month', COALESCE((SELECT min(logged_at) FROM saas_access_logs_legado), now()) AT TIME ZONE 'UTC'),
+            date_trunc('month', now() AT TIME ZONE 'UTC') + interval '3 months',
+            interval '1 month'
+        )::date
+    LOOP
+        PERFORM particao_mensal_garantir('saas_access_logs', 'logged_at', mes);
+    END LOOP;
+END;
+$$;
+
+INSERT INTO saas_access_logs (id, user_id, user_name, email, role, tenant_id, logged_at, ip_address, location, user_agent, status, created_at)
+SELECT id, user_id, user_name, email, role, tenant_id, logged_at, ip_address, location, user_agent, status, created_at
+FROM saas_access_logs_legado;
+
+DROP TABLE saas_access_logs_legado;
+
+CREATE INDEX idx_access_logs_logged_at ON saas_access_logs (logged_at DESC);
+CREATE INDEX idx_access_logs_tenant ON saas_access_logs (tenant_id);
+CREATE INDEX idx_access_logs_role ON saas_access_logs (role);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_monitor_alerts_time;
+DROP INDEX IF EXISTS idx_monitor_check_events_time;
+DROP TABLE IF EXISTS retention_purges;
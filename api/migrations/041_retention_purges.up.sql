@@ -0,0 +1,15 @@
+-- Registro de cada expurgo por classe de dado, base da documentação de retenção (LGPD).
+CREATE TABLE IF NOT EXISTS retention_purges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    data_class TEXT NOT NULL,
+    cutoff TIMESTAMPTZ,
+    removed BIGINT NOT NULL DEFAULT 0,
+    detail JSONB NOT NULL DEFAULT '{}'::jsonb,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_purges_class ON retention_purges (data_class, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_monitor_check_events_time ON monitor_check_events (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_monitor_alerts_time ON monitor_alerts (triggered_at);
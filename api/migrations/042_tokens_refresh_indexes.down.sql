@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tokens_refresh_ativos;
+DROP INDEX IF EXISTS idx_tokens_refresh_revogados;
+DROP INDEX IF EXISTS idx_tokens_refresh_expiracao;
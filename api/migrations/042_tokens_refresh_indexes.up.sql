@@ -0,0 +1,5 @@
+-- Auditoria de índices de tokens_refresh: as buscas por token_hash e por (subject, audience) já
+-- tinham índice; faltavam os usados pelo expurgo e pela contagem de sessões ativas.
+CREATE INDEX IF NOT EXISTS idx_tokens_refresh_expiracao ON tokens_refresh (expiracao);
+CREATE INDEX IF NOT EXISTS idx_tokens_refresh_revogados ON tokens_refresh (criado_em) WHERE revogado;
+CREATE INDEX IF NOT EXISTS idx_tokens_refresh_ativos ON tokens_refresh (audience, expiracao) INCLUDE (subject) WHERE NOT revogado;
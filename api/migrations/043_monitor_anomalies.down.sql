@@ -0,0 +1 @@
+DROP TABLE IF EXISTS monitor_anomaly_state;
@@ -0,0 +1,13 @@
+-- Estado do detector de anomalias (média e variância exponenciais) por tenant e série.
+CREATE TABLE IF NOT EXISTS monitor_anomaly_state (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    mean DOUBLE PRECISION NOT NULL,
+    variance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    samples INTEGER NOT NULL DEFAULT 0,
+    last_value DOUBLE PRECISION,
+    last_score DOUBLE PRECISION,
+    last_anomaly_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, metric)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS saas_file_access_logs;
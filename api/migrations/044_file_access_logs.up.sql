@@ -0,0 +1,14 @@
+-- Registro de cada download de documento privado servido pelo proxy /saas/files/{id}.
+CREATE TABLE IF NOT EXISTS saas_file_access_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('finance_attachment', 'contract_version', 'invoice')),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL,
+    actor_id UUID,
+    ip_address TEXT,
+    user_agent TEXT,
+    accessed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_access_logs_file ON saas_file_access_logs (file_id, accessed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_file_access_logs_accessed ON saas_file_access_logs (accessed_at DESC);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tenant_invoices_quarantine;
+
+ALTER TABLE saas_tenant_invoices
+    DROP COLUMN IF EXISTS released_at,
+    DROP COLUMN IF EXISTS released_by,
+    DROP COLUMN IF EXISTS scanned_at,
+    DROP COLUMN IF EXISTS scan_detail,
+    DROP COLUMN IF EXISTS scan_status;
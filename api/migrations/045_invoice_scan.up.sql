@@ -0,0 +1,11 @@
+-- Resultado da varredura antivírus das notas fiscais e ciclo de quarentena.
+ALTER TABLE saas_tenant_invoices
+    ADD COLUMN IF NOT EXISTS scan_status TEXT NOT NULL DEFAULT 'skipped'
+        CHECK (scan_status IN ('skipped', 'clean', 'quarantined', 'failed', 'released')),
+    ADD COLUMN IF NOT EXISTS scan_detail TEXT,
+    ADD COLUMN IF NOT EXISTS scanned_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS released_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS released_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tenant_invoices_quarantine ON saas_tenant_invoices (uploaded_at DESC)
+    WHERE scan_status IN ('quarantined', 'failed');
@@ -0,0 +1,14 @@
+DELETE FROM saas_file_access_logs WHERE kind = 'support_attachment';
+ALTER TABLE saas_file_access_logs DROP CONSTRAINT IF EXISTS saas_file_access_logs_kind_check;
+ALTER TABLE saas_file_access_logs ADD CONSTRAINT saas_file_access_logs_kind_check
+    CHECK (kind IN ('finance_attachment', 'contract_version', 'invoice'));
+
+DROP TABLE IF EXISTS support_ticket_attachments;
+DROP INDEX IF EXISTS idx_support_messages_email_id;
+
+ALTER TABLE support_ticket_messages
+    DROP COLUMN IF EXISTS email_message_id,
+    DROP COLUMN IF EXISTS email_from,
+    DROP COLUMN IF EXISTS channel;
+
+ALTER TABLE support_tickets DROP COLUMN IF EXISTS requester_email;
@@ -0,0 +1,29 @@
+-- Ponte de e-mail do suporte: remetente do chamado, encadeamento por Message-ID e anexos.
+ALTER TABLE support_tickets ADD COLUMN IF NOT EXISTS requester_email TEXT;
+
+ALTER TABLE support_ticket_messages
+    ADD COLUMN IF NOT EXISTS channel TEXT NOT NULL DEFAULT 'web' CHECK (channel IN ('web', 'email')),
+    ADD COLUMN IF NOT EXISTS email_from TEXT,
+    ADD COLUMN IF NOT EXISTS email_message_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_support_messages_email_id
+    ON support_ticket_messages (email_message_id)
+    WHERE email_message_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS support_ticket_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id UUID NOT NULL REFERENCES support_ticket_messages(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    object_key TEXT NOT NULL,
+    file_url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_support_attachments_message ON support_ticket_attachments (message_id);
+
+-- Anexos do suporte também passam pelo proxy de arquivos privados.
+ALTER TABLE saas_file_access_logs DROP CONSTRAINT IF EXISTS saas_file_access_logs_kind_check;
+ALTER TABLE saas_file_access_logs ADD CONSTRAINT saas_file_access_logs_kind_check
+    CHECK (kind IN ('finance_attachment', 'contract_version', 'invoice', 'support_attachment'));
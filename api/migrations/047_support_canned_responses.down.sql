@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS support_canned_responses;
+ALTER TABLE support_ticket_messages DROP COLUMN IF EXISTS internal;
@@ -0,0 +1,20 @@
+-- Notas internas nos chamados e respostas prontas da equipe de suporte.
+ALTER TABLE support_ticket_messages ADD COLUMN IF NOT EXISTS internal BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS support_canned_responses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title TEXT NOT NULL,
+    shortcut TEXT,
+    category TEXT,
+    body TEXT NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    usage_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_support_canned_shortcut ON support_canned_responses (lower(shortcut)) WHERE shortcut IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_support_canned_category ON support_canned_responses (category);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS kb_articles;
+DROP TABLE IF EXISTS kb_categories;
@@ -0,0 +1,39 @@
+-- Base de conhecimento: artigos de ajuda por área de produto, globais ou exclusivos de um tenant.
+CREATE TABLE IF NOT EXISTS kb_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    product_area TEXT,
+    description TEXT,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS kb_articles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id UUID REFERENCES kb_categories(id) ON DELETE SET NULL,
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE,
+    slug TEXT NOT NULL,
+    title TEXT NOT NULL,
+    summary TEXT,
+    body TEXT NOT NULL,
+    product_area TEXT,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    views INTEGER NOT NULL DEFAULT 0,
+    published_at TIMESTAMPTZ,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    search TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('portuguese', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('portuguese', coalesce(summary, '')), 'B') ||
+        setweight(to_tsvector('portuguese', body), 'C')
+    ) STORED
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kb_articles_slug ON kb_articles (slug, COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::uuid));
+CREATE INDEX IF NOT EXISTS idx_kb_articles_search ON kb_articles USING GIN (search);
+CREATE INDEX IF NOT EXISTS idx_kb_articles_category ON kb_articles (category_id, status);
@@ -0,0 +1,9 @@
+-- Preferências de notificação por usuário: canais habilitados e categorias recusadas.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    audience TEXT NOT NULL CHECK (audience IN ('cidadao', 'backoffice', 'saas')),
+    user_id UUID NOT NULL,
+    channels JSONB NOT NULL DEFAULT '{}'::jsonb,
+    opt_outs TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (audience, user_id)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS opendata_resources;
@@ -0,0 +1,13 @@
+-- Arquivos de dados abertos gerados por tenant, um por conjunto e formato.
+CREATE TABLE IF NOT EXISTS opendata_resources (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    dataset TEXT NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('csv', 'json')),
+    url TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    rows INTEGER NOT NULL,
+    bytes INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    generated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, dataset, format)
+);
@@ -0,0 +1,14 @@
+-- Dados cadastrais do município (IBGE) por tenant e a faixa de cobrança derivada da população.
+CREATE TABLE IF NOT EXISTS saas_tenant_registry (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    ibge_code INTEGER NOT NULL,
+    municipality TEXT NOT NULL,
+    uf CHAR(2) NOT NULL,
+    region TEXT NOT NULL,
+    population BIGINT NOT NULL DEFAULT 0,
+    population_year INTEGER,
+    billing_tier TEXT,
+    synced_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saas_tenant_registry_ibge ON saas_tenant_registry (ibge_code);
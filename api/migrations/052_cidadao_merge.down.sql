@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cidadao_merges;
+ALTER TABLE cidadaos DROP COLUMN IF EXISTS merged_at;
+ALTER TABLE cidadaos DROP COLUMN IF EXISTS merged_into;
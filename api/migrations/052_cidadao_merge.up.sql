@@ -0,0 +1,14 @@
+-- Unificação de cadastros duplicados de cidadãos: o registro absorvido fica inativo apontando para o sobrevivente.
+ALTER TABLE cidadaos ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES cidadaos(id);
+ALTER TABLE cidadaos ADD COLUMN IF NOT EXISTS merged_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS cidadao_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    survivor_id UUID NOT NULL REFERENCES cidadaos(id),
+    merged_id UUID NOT NULL REFERENCES cidadaos(id),
+    actor_id UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    affected JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cidadao_merges_survivor ON cidadao_merges (survivor_id);
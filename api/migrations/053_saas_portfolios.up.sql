@@ -0,0 +1,10 @@
+-- Carteiras de tenants atribuídas a usuários SaaS de suporte/financeiro (gerentes de conta).
+CREATE TABLE IF NOT EXISTS saas_user_portfolios (
+    saas_user_id UUID NOT NULL REFERENCES saas_users(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    assigned_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (saas_user_id, tenant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saas_user_portfolios_tenant ON saas_user_portfolios (tenant_id);
@@ -0,0 +1,10 @@
+-- Administradores delegados: usuários do backoffice que gerenciam a própria prefeitura via /tenant-admin.
+CREATE TABLE IF NOT EXISTS tenant_admins (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, usuario_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_admins_usuario ON tenant_admins (usuario_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS saas_partner_commissions;
+DROP TABLE IF EXISTS saas_partner_referrals;
+DROP TABLE IF EXISTS saas_partners;
@@ -0,0 +1,39 @@
+-- Parceiros comerciais, indicações de tenants e comissões sobre o primeiro ano de contrato.
+CREATE TABLE IF NOT EXISTS saas_partners (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    document TEXT,
+    email TEXT,
+    phone TEXT,
+    default_rate NUMERIC(5,2) NOT NULL DEFAULT 10 CHECK (default_rate >= 0 AND default_rate <= 100),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Cada tenant tem no máximo um parceiro indicador; rate nulo usa a taxa padrão do parceiro.
+CREATE TABLE IF NOT EXISTS saas_partner_referrals (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    partner_id UUID NOT NULL REFERENCES saas_partners(id) ON DELETE CASCADE,
+    rate NUMERIC(5,2) CHECK (rate IS NULL OR (rate >= 0 AND rate <= 100)),
+    starts_at DATE NOT NULL,
+    months INT NOT NULL DEFAULT 12 CHECK (months > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_partner_referrals_partner ON saas_partner_referrals (partner_id);
+
+CREATE TABLE IF NOT EXISTS saas_partner_commissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    partner_id UUID NOT NULL REFERENCES saas_partners(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    invoice_id UUID NOT NULL UNIQUE REFERENCES saas_tenant_invoices(id) ON DELETE CASCADE,
+    reference_month DATE NOT NULL,
+    invoice_amount NUMERIC(14,2) NOT NULL,
+    rate NUMERIC(5,2) NOT NULL,
+    amount NUMERIC(14,2) NOT NULL,
+    finance_entry_id UUID REFERENCES saas_finance_entries(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_partner_commissions_partner ON saas_partner_commissions (partner_id, reference_month);
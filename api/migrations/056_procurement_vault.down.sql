@@ -0,0 +1,6 @@
+DELETE FROM saas_file_access_logs WHERE kind = 'procurement_document';
+ALTER TABLE saas_file_access_logs DROP CONSTRAINT IF EXISTS saas_file_access_logs_kind_check;
+ALTER TABLE saas_file_access_logs ADD CONSTRAINT saas_file_access_logs_kind_check
+    CHECK (kind IN ('finance_attachment', 'contract_version', 'invoice', 'support_attachment'));
+
+DROP TABLE IF EXISTS saas_procurement_documents;
@@ -0,0 +1,27 @@
+-- Cofre de documentos da licitação (edital, ata, contrato, empenhos...) por contrato de tenant.
+CREATE TABLE IF NOT EXISTS saas_procurement_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    category TEXT NOT NULL CHECK (category IN ('edital', 'termo_referencia', 'ata', 'contrato', 'empenho', 'aditivo', 'certidao', 'outros')),
+    title TEXT NOT NULL,
+    number TEXT,
+    amount NUMERIC(14,2),
+    issued_at DATE,
+    expires_at DATE,
+    notes TEXT,
+    file_url TEXT,
+    file_key TEXT,
+    content_type TEXT,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    uploaded_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    uploaded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expiry_warned_at TIMESTAMPTZ,
+    expired_notified_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_procurement_documents_tenant ON saas_procurement_documents (tenant_id, category);
+CREATE INDEX IF NOT EXISTS idx_procurement_documents_expiry ON saas_procurement_documents (expires_at) WHERE expires_at IS NOT NULL;
+
+ALTER TABLE saas_file_access_logs DROP CONSTRAINT IF EXISTS saas_file_access_logs_kind_check;
+ALTER TABLE saas_file_access_logs ADD CONSTRAINT saas_file_access_logs_kind_check
+    CHECK (kind IN ('finance_attachment', 'contract_version', 'invoice', 'support_attachment', 'procurement_document'));
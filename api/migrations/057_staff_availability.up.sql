@@ -0,0 +1,16 @@
+-- Disponibilidade da equipe SaaS: férias, afastamentos e alocações parciais em projetos.
+CREATE TABLE IF NOT EXISTS saas_staff_availability (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    saas_user_id UUID NOT NULL REFERENCES saas_users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('vacation','leave','allocation')),
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    allocation_pct NUMERIC(5,2) NOT NULL DEFAULT 100 CHECK (allocation_pct > 0 AND allocation_pct <= 100),
+    project_id UUID REFERENCES saas_projects(id) ON DELETE CASCADE,
+    notes TEXT,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS idx_staff_availability_user ON saas_staff_availability (saas_user_id, starts_on, ends_on);
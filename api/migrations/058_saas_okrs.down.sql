@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saas_key_results;
+DROP TABLE IF EXISTS saas_objectives;
@@ -0,0 +1,34 @@
+-- Objetivos trimestrais e resultados-chave ligados aos projetos estratégicos.
+CREATE TABLE IF NOT EXISTS saas_objectives (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title TEXT NOT NULL,
+    description TEXT,
+    period TEXT NOT NULL CHECK (period ~ '^[0-9]{4}-Q[1-4]$'),
+    owner_id UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saas_objectives_period ON saas_objectives (period);
+
+-- source = project calcula o progresso pelas tarefas concluídas do projeto vinculado; se o projeto
+-- for removido o resultado-chave fica sem vínculo e com progresso zero.
+CREATE TABLE IF NOT EXISTS saas_key_results (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    objective_id UUID NOT NULL REFERENCES saas_objectives(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual','project')),
+    project_id UUID REFERENCES saas_projects(id) ON DELETE SET NULL,
+    start_value NUMERIC(14,2) NOT NULL DEFAULT 0,
+    target_value NUMERIC(14,2) NOT NULL DEFAULT 100,
+    current_value NUMERIC(14,2) NOT NULL DEFAULT 0,
+    unit TEXT,
+    weight NUMERIC(6,2) NOT NULL DEFAULT 1 CHECK (weight > 0),
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saas_key_results_objective ON saas_key_results (objective_id, position);
+CREATE INDEX IF NOT EXISTS idx_saas_key_results_project ON saas_key_results (project_id);
@@ -0,0 +1,18 @@
+-- Notas de versão publicadas pela equipe SaaS por módulo do produto.
+CREATE TABLE IF NOT EXISTS saas_release_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    module_code TEXT NOT NULL,
+    version TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    audience TEXT NOT NULL DEFAULT 'all' CHECK (audience IN ('all','backoffice','citizen')),
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft','published')),
+    published_at TIMESTAMPTZ,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (module_code, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_release_notes_feed ON saas_release_notes (status, published_at DESC);
@@ -0,0 +1,10 @@
+-- Estado do checklist de implantação exibido ao administrador da prefeitura no primeiro acesso.
+-- A conclusão de cada tarefa é detectada a partir dos dados; aqui ficam apenas dispensas e marcos.
+CREATE TABLE IF NOT EXISTS tenant_onboarding (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    skipped_tasks TEXT[] NOT NULL DEFAULT '{}',
+    dismissed_at TIMESTAMPTZ,
+    dismissed_by UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,30 @@
+-- Versões dos termos de uso (tos) e do acordo de tratamento de dados (dpa) por público.
+-- A versão vigente é a mais recente com effective_at já alcançado.
+CREATE TABLE IF NOT EXISTS terms_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    audience TEXT NOT NULL CHECK (audience IN ('cidadao','backoffice','saas')),
+    kind TEXT NOT NULL CHECK (kind IN ('tos','dpa')),
+    version TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    blocking BOOLEAN NOT NULL DEFAULT TRUE,
+    effective_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (audience, kind, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_terms_versions_current ON terms_versions (audience, kind, effective_at DESC);
+
+-- Aceites são imutáveis; subject_id é o id do usuário no público da versão.
+CREATE TABLE IF NOT EXISTS terms_acceptances (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    version_id UUID NOT NULL REFERENCES terms_versions(id) ON DELETE RESTRICT,
+    subject_id UUID NOT NULL,
+    ip TEXT,
+    user_agent TEXT,
+    accepted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (version_id, subject_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_terms_acceptances_subject ON terms_acceptances (subject_id);
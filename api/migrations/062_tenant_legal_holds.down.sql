@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS tenant_legal_holds_protect ON tenant_legal_holds;
+DROP FUNCTION IF EXISTS protect_tenant_legal_holds();
+DROP TABLE IF EXISTS tenant_legal_holds;
@@ -0,0 +1,43 @@
+-- Retenção legal de tenants em litígio: bloqueia expurgos e limpezas e guarda um retrato
+-- imutável dos dados no momento da ativação. Ativação e liberação exigem dois SAAS_OWNER.
+CREATE TABLE IF NOT EXISTS tenant_legal_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- RESTRICT: o histórico de retenção precisa sobreviver ao tenant.
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE RESTRICT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','active','releasing','released','cancelled')),
+    reason TEXT NOT NULL,
+    case_reference TEXT,
+    requested_by UUID NOT NULL REFERENCES saas_users(id),
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    confirmed_by UUID REFERENCES saas_users(id),
+    confirmed_at TIMESTAMPTZ,
+    release_reason TEXT,
+    release_requested_by UUID REFERENCES saas_users(id),
+    release_requested_at TIMESTAMPTZ,
+    released_by UUID REFERENCES saas_users(id),
+    released_at TIMESTAMPTZ,
+    snapshot JSONB,
+    snapshot_sha256 TEXT,
+    CHECK (confirmed_by IS NULL OR confirmed_by <> requested_by),
+    CHECK (released_by IS NULL OR released_by <> release_requested_by)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_legal_holds_open
+    ON tenant_legal_holds (tenant_id) WHERE status IN ('pending','active','releasing');
+
+-- O retrato, uma vez gravado, não pode ser alterado; registros nunca são apagados.
+CREATE OR REPLACE FUNCTION protect_tenant_legal_holds() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'tenant_legal_holds é somente inclusão';
+    END IF;
+    IF OLD.snapshot IS NOT NULL AND (NEW.snapshot IS DISTINCT FROM OLD.snapshot OR NEW.snapshot_sha256 IS DISTINCT FROM OLD.snapshot_sha256) THEN
+        RAISE EXCEPTION 'retrato da retenção legal é imutável';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tenant_legal_holds_protect
+    BEFORE UPDATE OR DELETE ON tenant_legal_holds
+    FOR EACH ROW EXECUTE FUNCTION protect_tenant_legal_holds();
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS saas_metric_snapshots_protect ON saas_metric_snapshots;
+DROP FUNCTION IF EXISTS protect_saas_metric_snapshots();
+DROP TABLE IF EXISTS saas_metric_snapshots;
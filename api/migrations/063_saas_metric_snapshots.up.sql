@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS saas_metric_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+    version INT NOT NULL CHECK (version > 0),
+    definitions TEXT NOT NULL,
+    metrics JSONB NOT NULL,
+    metrics_sha256 TEXT NOT NULL,
+    reason TEXT,
+    created_by UUID REFERENCES saas_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (month, version)
+);
+
+-- Retratos são congelados: correções geram nova versão em vez de alterar a anterior.
+CREATE OR REPLACE FUNCTION protect_saas_metric_snapshots() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'saas_metric_snapshots é somente inclusão';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER saas_metric_snapshots_protect
+    BEFORE UPDATE OR DELETE ON saas_metric_snapshots
+    FOR EACH ROW EXECUTE FUNCTION protect_saas_metric_snapshots();
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS saas_dashboard_layouts (
+    user_id UUID PRIMARY KEY REFERENCES saas_users(id) ON DELETE CASCADE,
+    widgets JSONB NOT NULL DEFAULT '[]'::jsonb,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS monitor_dependency_health (
+    name TEXT PRIMARY KEY,
+    status TEXT NOT NULL CHECK (status IN ('up', 'down')),
+    latency_ms INTEGER,
+    error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_checked_at TIMESTAMPTZ NOT NULL,
+    last_success_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
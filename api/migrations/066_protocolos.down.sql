@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS protocolo_anexos;
+DROP TABLE IF EXISTS protocolos;
+DROP TABLE IF EXISTS protocolo_sequencias;
+DROP TABLE IF EXISTS protocolo_categorias;
@@ -0,0 +1,58 @@
+-- Categorias de protocolo configuradas pelas secretarias, com o formulário dinâmico (campos,
+-- validação e anexos exigidos). form_version sobe a cada alteração do formulário.
+CREATE TABLE IF NOT EXISTS protocolo_categorias (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID REFERENCES secretarias(id) ON DELETE SET NULL,
+    slug TEXT NOT NULL,
+    nome TEXT NOT NULL,
+    descricao TEXT,
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    form JSONB NOT NULL DEFAULT '{"fields": [], "attachments": []}'::jsonb,
+    form_version INT NOT NULL DEFAULT 1,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, slug)
+);
+
+-- Numeração sequencial por tenant e ano (ex.: 2026-000042).
+CREATE TABLE IF NOT EXISTS protocolo_sequencias (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    ano INT NOT NULL,
+    ultimo INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, ano)
+);
+
+-- Protocolos abertos pelos cidadãos; dados guarda a submissão já validada contra o formulário
+-- na versão form_version.
+CREATE TABLE IF NOT EXISTS protocolos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    categoria_id UUID NOT NULL REFERENCES protocolo_categorias(id) ON DELETE RESTRICT,
+    numero TEXT NOT NULL,
+    cidadao_id UUID NOT NULL REFERENCES cidadaos(id),
+    status TEXT NOT NULL DEFAULT 'aberto' CHECK (status IN ('aberto','em_andamento','concluido','cancelado')),
+    dados JSONB NOT NULL DEFAULT '{}'::jsonb,
+    form_version INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, numero)
+);
+
+CREATE INDEX IF NOT EXISTS idx_protocolos_cidadao ON protocolos (cidadao_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_protocolos_categoria ON protocolos (categoria_id, status);
+
+CREATE TABLE IF NOT EXISTS protocolo_anexos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    protocolo_id UUID NOT NULL REFERENCES protocolos(id) ON DELETE CASCADE,
+    campo TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    object_key TEXT NOT NULL,
+    file_url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_protocolo_anexos_protocolo ON protocolo_anexos (protocolo_id);
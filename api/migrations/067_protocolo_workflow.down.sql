@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS protocolo_eventos;
+DROP INDEX IF EXISTS idx_protocolos_responsavel;
+DROP INDEX IF EXISTS idx_protocolos_fila;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS concluido_em;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS atribuido_em;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS responsavel_id;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS fila_id;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS secretaria_id;
+DROP TABLE IF EXISTS protocolo_regras;
+DROP TABLE IF EXISTS protocolo_fila_membros;
+DROP TABLE IF EXISTS protocolo_filas;
@@ -0,0 +1,60 @@
+-- Filas de atendimento de protocolos por secretaria, com a estratégia de atribuição automática.
+CREATE TABLE IF NOT EXISTS protocolo_filas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    estrategia TEXT NOT NULL DEFAULT 'manual' CHECK (estrategia IN ('manual','round_robin','load')),
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (secretaria_id, nome)
+);
+
+CREATE INDEX IF NOT EXISTS idx_protocolo_filas_tenant ON protocolo_filas (tenant_id);
+
+-- last_assigned_at sustenta o round-robin: recebe o próximo quem está há mais tempo sem receber.
+CREATE TABLE IF NOT EXISTS protocolo_fila_membros (
+    fila_id UUID NOT NULL REFERENCES protocolo_filas(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    last_assigned_at TIMESTAMPTZ,
+    PRIMARY KEY (fila_id, usuario_id)
+);
+
+-- Roteamento: categoria -> secretaria -> fila. Sem fila, vale a fila ativa mais antiga da secretaria.
+CREATE TABLE IF NOT EXISTS protocolo_regras (
+    categoria_id UUID PRIMARY KEY REFERENCES protocolo_categorias(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    fila_id UUID REFERENCES protocolo_filas(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS secretaria_id UUID REFERENCES secretarias(id) ON DELETE SET NULL;
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS fila_id UUID REFERENCES protocolo_filas(id) ON DELETE SET NULL;
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS responsavel_id UUID REFERENCES usuarios(id) ON DELETE SET NULL;
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS atribuido_em TIMESTAMPTZ;
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS concluido_em TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_protocolos_fila ON protocolos (fila_id, status);
+CREATE INDEX IF NOT EXISTS idx_protocolos_responsavel ON protocolos (responsavel_id) WHERE status IN ('aberto','em_andamento');
+
+-- Histórico de roteamento, atribuições, transferências e mudanças de status.
+CREATE TABLE IF NOT EXISTS protocolo_eventos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    protocolo_id UUID NOT NULL REFERENCES protocolos(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL CHECK (tipo IN ('roteado','atribuido','transferido','status')),
+    de_secretaria_id UUID,
+    para_secretaria_id UUID,
+    de_fila_id UUID,
+    para_fila_id UUID,
+    responsavel_id UUID,
+    status TEXT,
+    motivo TEXT,
+    ator_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_protocolo_eventos_protocolo ON protocolo_eventos (protocolo_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_protocolo_eventos_fila ON protocolo_eventos (para_fila_id, created_at);
@@ -0,0 +1,8 @@
+ALTER TABLE protocolo_categorias DROP COLUMN IF EXISTS dedup_janela_dias;
+ALTER TABLE protocolo_categorias DROP COLUMN IF EXISTS dedup_raio_metros;
+DROP INDEX IF EXISTS idx_protocolos_duplicado;
+DROP INDEX IF EXISTS idx_protocolos_bairro;
+DROP INDEX IF EXISTS idx_protocolos_localizacao;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS duplicado_de;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS bairro;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS localizacao;
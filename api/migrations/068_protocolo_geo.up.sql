@@ -0,0 +1,15 @@
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+-- Local informado pelo cidadão (WGS84) e o bairro, usado na agregação do mapa.
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS localizacao geography(Point, 4326);
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS bairro TEXT;
+-- Protocolo original quando outro cidadão já relatou o mesmo problema ali perto.
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS duplicado_de UUID REFERENCES protocolos(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_protocolos_localizacao ON protocolos USING GIST (localizacao);
+CREATE INDEX IF NOT EXISTS idx_protocolos_bairro ON protocolos (tenant_id, lower(bairro)) WHERE bairro IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_protocolos_duplicado ON protocolos (duplicado_de) WHERE duplicado_de IS NOT NULL;
+
+-- Detecção de duplicados por proximidade: raio em metros (NULL desliga) e janela em dias.
+ALTER TABLE protocolo_categorias ADD COLUMN IF NOT EXISTS dedup_raio_metros INT CHECK (dedup_raio_metros IS NULL OR dedup_raio_metros BETWEEN 1 AND 5000);
+ALTER TABLE protocolo_categorias ADD COLUMN IF NOT EXISTS dedup_janela_dias INT NOT NULL DEFAULT 30 CHECK (dedup_janela_dias BETWEEN 1 AND 365);
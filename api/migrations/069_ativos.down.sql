@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_protocolos_ativo;
+ALTER TABLE protocolos DROP COLUMN IF EXISTS ativo_id;
+DROP TABLE IF EXISTS ativo_historico;
+DROP TABLE IF EXISTS ativos;
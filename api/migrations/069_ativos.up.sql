@@ -0,0 +1,43 @@
+-- Cadastro de ativos municipais (praças, escolas, postes, veículos...) que protocolos e ordens de
+-- manutenção podem referenciar. qr_token identifica o ativo na etiqueta pública.
+CREATE TABLE IF NOT EXISTS ativos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID REFERENCES secretarias(id) ON DELETE SET NULL,
+    tipo TEXT NOT NULL CHECK (tipo IN ('praca','escola','poste','veiculo','predio','via','equipamento','outro')),
+    codigo TEXT NOT NULL,
+    nome TEXT NOT NULL,
+    descricao TEXT,
+    endereco TEXT,
+    bairro TEXT,
+    localizacao geography(Point, 4326),
+    atributos JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL DEFAULT 'ativo' CHECK (status IN ('ativo','manutencao','inativo','baixado')),
+    qr_token TEXT NOT NULL UNIQUE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, codigo)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ativos_tenant_tipo ON ativos (tenant_id, tipo);
+CREATE INDEX IF NOT EXISTS idx_ativos_localizacao ON ativos USING GIST (localizacao);
+
+-- Histórico de serviço do ativo registrado pela secretaria: manutenções, notas e mudanças de
+-- status. Os protocolos vinculados entram no histórico pela coluna protocolos.ativo_id.
+CREATE TABLE IF NOT EXISTS ativo_historico (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ativo_id UUID NOT NULL REFERENCES ativos(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL CHECK (tipo IN ('manutencao','status','nota')),
+    descricao TEXT NOT NULL,
+    protocolo_id UUID REFERENCES protocolos(id) ON DELETE SET NULL,
+    custo NUMERIC(12,2),
+    realizado_em DATE,
+    ator_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ativo_historico_ativo ON ativo_historico (ativo_id, created_at DESC);
+
+ALTER TABLE protocolos ADD COLUMN IF NOT EXISTS ativo_id UUID REFERENCES ativos(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_protocolos_ativo ON protocolos (ativo_id) WHERE ativo_id IS NOT NULL;
@@ -0,0 +1,10 @@
+DELETE FROM protocolo_eventos WHERE tipo = 'ordem_servico';
+ALTER TABLE protocolo_eventos DROP CONSTRAINT IF EXISTS protocolo_eventos_tipo_check;
+ALTER TABLE protocolo_eventos ADD CONSTRAINT protocolo_eventos_tipo_check
+    CHECK (tipo IN ('roteado','atribuido','transferido','status'));
+DROP TABLE IF EXISTS ordem_servico_fotos;
+DROP TABLE IF EXISTS ordem_servico_materiais;
+DROP TABLE IF EXISTS ordens_servico;
+DROP TABLE IF EXISTS ordem_servico_sequencias;
+DROP TABLE IF EXISTS equipe_membros;
+DROP TABLE IF EXISTS equipes;
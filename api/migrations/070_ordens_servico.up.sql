@@ -0,0 +1,89 @@
+-- Equipes de campo das secretarias, que executam as ordens de serviço.
+CREATE TABLE IF NOT EXISTS equipes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (secretaria_id, nome)
+);
+
+CREATE INDEX IF NOT EXISTS idx_equipes_tenant ON equipes (tenant_id);
+
+CREATE TABLE IF NOT EXISTS equipe_membros (
+    equipe_id UUID NOT NULL REFERENCES equipes(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    PRIMARY KEY (equipe_id, usuario_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_equipe_membros_usuario ON equipe_membros (usuario_id);
+
+CREATE TABLE IF NOT EXISTS ordem_servico_sequencias (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    ano INT NOT NULL,
+    ultimo INT NOT NULL,
+    PRIMARY KEY (tenant_id, ano)
+);
+
+-- Ordem de serviço gerada a partir de um protocolo; no máximo uma ordem não cancelada por protocolo.
+CREATE TABLE IF NOT EXISTS ordens_servico (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    numero TEXT NOT NULL,
+    protocolo_id UUID NOT NULL REFERENCES protocolos(id) ON DELETE CASCADE,
+    secretaria_id UUID REFERENCES secretarias(id) ON DELETE SET NULL,
+    equipe_id UUID REFERENCES equipes(id) ON DELETE SET NULL,
+    ativo_id UUID REFERENCES ativos(id) ON DELETE SET NULL,
+    descricao TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pendente' CHECK (status IN ('pendente','agendada','em_execucao','concluida','cancelada')),
+    agendada_para TIMESTAMPTZ,
+    iniciada_em TIMESTAMPTZ,
+    concluida_em TIMESTAMPTZ,
+    conclusao_nota TEXT,
+    assinado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    assinatura_nome TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, numero)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ordens_servico_protocolo ON ordens_servico (protocolo_id) WHERE status <> 'cancelada';
+CREATE INDEX IF NOT EXISTS idx_ordens_servico_equipe ON ordens_servico (equipe_id, status, agendada_para);
+CREATE INDEX IF NOT EXISTS idx_ordens_servico_ativo ON ordens_servico (ativo_id) WHERE ativo_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS ordem_servico_materiais (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ordem_id UUID NOT NULL REFERENCES ordens_servico(id) ON DELETE CASCADE,
+    descricao TEXT NOT NULL,
+    quantidade NUMERIC(12,3) NOT NULL CHECK (quantidade > 0),
+    unidade TEXT NOT NULL DEFAULT 'un',
+    custo_unitario NUMERIC(12,2) CHECK (custo_unitario >= 0),
+    posicao INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_ordem_servico_materiais_ordem ON ordem_servico_materiais (ordem_id, posicao);
+
+-- Fotos de antes e depois enviadas pelo aplicativo da equipe em campo.
+CREATE TABLE IF NOT EXISTS ordem_servico_fotos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ordem_id UUID NOT NULL REFERENCES ordens_servico(id) ON DELETE CASCADE,
+    fase TEXT NOT NULL CHECK (fase IN ('antes','depois')),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    object_key TEXT NOT NULL,
+    file_url TEXT NOT NULL,
+    localizacao geography(Point, 4326),
+    tirada_em TIMESTAMPTZ,
+    enviado_por UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ordem_servico_fotos_ordem ON ordem_servico_fotos (ordem_id, fase);
+
+ALTER TABLE protocolo_eventos DROP CONSTRAINT IF EXISTS protocolo_eventos_tipo_check;
+ALTER TABLE protocolo_eventos ADD CONSTRAINT protocolo_eventos_tipo_check
+    CHECK (tipo IN ('roteado','atribuido','transferido','status','ordem_servico'));
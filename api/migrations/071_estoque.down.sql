@@ -0,0 +1,3 @@
+ALTER TABLE ordem_servico_materiais DROP COLUMN IF EXISTS item_id;
+DROP TABLE IF EXISTS estoque_movimentos;
+DROP TABLE IF EXISTS estoque_itens;
@@ -0,0 +1,44 @@
+-- Almoxarifado das secretarias: itens com saldo e estoque mínimo. O saldo é mantido pelos
+-- movimentos e nunca fica negativo.
+CREATE TABLE IF NOT EXISTS estoque_itens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    unidade TEXT NOT NULL,
+    categoria TEXT,
+    estoque_minimo NUMERIC(14,3) NOT NULL DEFAULT 0 CHECK (estoque_minimo >= 0),
+    saldo NUMERIC(14,3) NOT NULL DEFAULT 0 CHECK (saldo >= 0),
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    -- alerta_enviado_em evita repetir o aviso de estoque baixo; volta a NULL quando o saldo se recompõe.
+    alerta_enviado_em TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_estoque_itens_nome ON estoque_itens (secretaria_id, lower(nome));
+CREATE INDEX IF NOT EXISTS idx_estoque_itens_tenant ON estoque_itens (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_estoque_itens_baixo ON estoque_itens (tenant_id) WHERE ativo AND saldo < estoque_minimo;
+
+-- quantidade é a variação do saldo (negativa nas saídas); saída vai para uma ordem de serviço ou
+-- para a merenda de uma escola quando informado.
+CREATE TABLE IF NOT EXISTS estoque_movimentos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id UUID NOT NULL REFERENCES estoque_itens(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL CHECK (tipo IN ('entrada','saida','ajuste')),
+    quantidade NUMERIC(14,3) NOT NULL,
+    saldo_apos NUMERIC(14,3) NOT NULL,
+    custo_unitario NUMERIC(12,2) CHECK (custo_unitario >= 0),
+    data DATE NOT NULL DEFAULT CURRENT_DATE,
+    motivo TEXT,
+    ordem_id UUID REFERENCES ordens_servico(id) ON DELETE SET NULL,
+    escola_id UUID REFERENCES escolas(id) ON DELETE SET NULL,
+    ator_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_estoque_movimentos_item ON estoque_movimentos (item_id, data);
+CREATE INDEX IF NOT EXISTS idx_estoque_movimentos_ordem ON estoque_movimentos (ordem_id) WHERE ordem_id IS NOT NULL;
+
+-- Materiais da ordem de serviço podem sair do almoxarifado na conclusão.
+ALTER TABLE ordem_servico_materiais ADD COLUMN IF NOT EXISTS item_id UUID REFERENCES estoque_itens(id) ON DELETE SET NULL;
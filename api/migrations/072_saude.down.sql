@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saude_doses;
+DROP TABLE IF EXISTS saude_campanhas;
@@ -0,0 +1,48 @@
+-- Campanhas de vacinação e programas de saúde. O público-alvo combina faixa etária (em meses) e
+-- grupos prioritários; meta_populacao é a estimativa de pessoas a vacinar.
+CREATE TABLE IF NOT EXISTS saude_campanhas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    vacina TEXT NOT NULL,
+    descricao TEXT,
+    inicio DATE NOT NULL,
+    fim DATE,
+    doses_esquema INT NOT NULL DEFAULT 1 CHECK (doses_esquema BETWEEN 1 AND 10),
+    idade_min_meses INT CHECK (idade_min_meses >= 0),
+    idade_max_meses INT CHECK (idade_max_meses >= 0),
+    grupos TEXT[] NOT NULL DEFAULT '{}',
+    meta_populacao INT CHECK (meta_populacao > 0),
+    meta_cobertura NUMERIC(5,2) NOT NULL DEFAULT 90 CHECK (meta_cobertura > 0 AND meta_cobertura <= 100),
+    status TEXT NOT NULL DEFAULT 'planejada' CHECK (status IN ('planejada','ativa','encerrada')),
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (fim IS NULL OR fim >= inicio),
+    CHECK (idade_max_meses IS NULL OR idade_min_meses IS NULL OR idade_max_meses >= idade_min_meses)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saude_campanhas_tenant ON saude_campanhas (tenant_id, status, inicio);
+
+-- Dose aplicada por um agente de saúde. Com cidadao_id a dose aparece na carteira de vacinação do
+-- aplicativo; sem ele vale o nome e a data de nascimento informados no atendimento.
+CREATE TABLE IF NOT EXISTS saude_doses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campanha_id UUID NOT NULL REFERENCES saude_campanhas(id) ON DELETE CASCADE,
+    cidadao_id UUID REFERENCES cidadaos(id) ON DELETE SET NULL,
+    paciente_nome TEXT NOT NULL,
+    data_nascimento DATE,
+    grupo TEXT,
+    bairro TEXT,
+    dose INT NOT NULL CHECK (dose >= 1),
+    lote TEXT,
+    unidade TEXT,
+    aplicada_em DATE NOT NULL DEFAULT CURRENT_DATE,
+    agente_id UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saude_doses_cidadao ON saude_doses (campanha_id, cidadao_id, dose) WHERE cidadao_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_saude_doses_campanha ON saude_doses (campanha_id, aplicada_em);
+CREATE INDEX IF NOT EXISTS idx_saude_doses_carteira ON saude_doses (cidadao_id) WHERE cidadao_id IS NOT NULL;
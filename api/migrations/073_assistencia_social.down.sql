@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS assistencia_auditoria;
+DROP FUNCTION IF EXISTS assistencia_auditoria_imutavel();
+DROP TABLE IF EXISTS assistencia_visitas;
+DROP TABLE IF EXISTS assistencia_beneficios;
+DROP TABLE IF EXISTS assistencia_membros;
+DROP TABLE IF EXISTS assistencia_familias;
+DROP TABLE IF EXISTS assistencia_profissionais;
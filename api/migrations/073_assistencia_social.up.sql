@@ -0,0 +1,119 @@
+-- Assistência social (CRAS/CREAS): acesso restrito aos profissionais cadastrados no módulo.
+-- Coordenadores veem todas as famílias; técnicos não veem famílias sigilosas de que não são referência.
+CREATE TABLE IF NOT EXISTS assistencia_profissionais (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    funcao TEXT NOT NULL CHECK (funcao IN ('tecnico','coordenador')),
+    unidade TEXT,
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    concedido_por UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, usuario_id)
+);
+
+CREATE TABLE IF NOT EXISTS assistencia_familias (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    unidade TEXT,
+    codigo_familiar TEXT,
+    responsavel_nome TEXT NOT NULL,
+    responsavel_cpf TEXT,
+    responsavel_nis TEXT,
+    endereco TEXT,
+    bairro TEXT,
+    telefone TEXT,
+    renda_per_capita NUMERIC(12,2) CHECK (renda_per_capita >= 0),
+    tecnico_id UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    sigilosa BOOLEAN NOT NULL DEFAULT FALSE,
+    situacao TEXT NOT NULL DEFAULT 'ativa' CHECK (situacao IN ('ativa','desligada')),
+    observacoes TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- codigo_familiar é o código da família no Cadastro Único.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_assistencia_familias_codigo ON assistencia_familias (tenant_id, codigo_familiar) WHERE codigo_familiar IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_assistencia_familias_cpf ON assistencia_familias (tenant_id, responsavel_cpf) WHERE responsavel_cpf IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_assistencia_familias_tenant ON assistencia_familias (tenant_id, situacao, responsavel_nome);
+
+CREATE TABLE IF NOT EXISTS assistencia_membros (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    familia_id UUID NOT NULL REFERENCES assistencia_familias(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    parentesco TEXT NOT NULL,
+    data_nascimento DATE,
+    nis TEXT,
+    posicao INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistencia_membros_familia ON assistencia_membros (familia_id, posicao);
+
+-- Inserção em programas e benefícios. nis_titular, codigo_familiar e referencia (folha AAAA-MM)
+-- permitem conciliar o Bolsa Família com o Cadastro Único.
+CREATE TABLE IF NOT EXISTS assistencia_beneficios (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    familia_id UUID NOT NULL REFERENCES assistencia_familias(id) ON DELETE CASCADE,
+    programa TEXT NOT NULL CHECK (programa IN ('bolsa_familia','bpc','beneficio_eventual','paif','scfv','outro')),
+    descricao TEXT,
+    situacao TEXT NOT NULL DEFAULT 'ativo' CHECK (situacao IN ('ativo','suspenso','encerrado')),
+    inicio DATE NOT NULL,
+    fim DATE,
+    valor_mensal NUMERIC(12,2) CHECK (valor_mensal >= 0),
+    nis_titular TEXT,
+    codigo_familiar TEXT,
+    referencia TEXT CHECK (referencia ~ '^[0-9]{4}-[0-9]{2}$'),
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (fim IS NULL OR fim >= inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistencia_beneficios_familia ON assistencia_beneficios (familia_id, situacao);
+CREATE INDEX IF NOT EXISTS idx_assistencia_beneficios_programa ON assistencia_beneficios (programa, situacao);
+
+CREATE TABLE IF NOT EXISTS assistencia_visitas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    familia_id UUID NOT NULL REFERENCES assistencia_familias(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL CHECK (tipo IN ('domiciliar','atendimento','acompanhamento')),
+    data DATE NOT NULL,
+    relato TEXT NOT NULL,
+    encaminhamentos TEXT,
+    tecnico_id UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistencia_visitas_familia ON assistencia_visitas (familia_id, data DESC);
+
+-- Trilha de auditoria do módulo: toda leitura e escrita, inclusive acessos negados. É só de
+-- inserção; o trigger recusa alteração e exclusão direta (a cascata da exclusão do tenant passa).
+CREATE TABLE IF NOT EXISTS assistencia_auditoria (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL,
+    familia_id UUID,
+    acao TEXT NOT NULL,
+    detalhe JSONB NOT NULL DEFAULT '{}'::jsonb,
+    ip TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistencia_auditoria_tenant ON assistencia_auditoria (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_assistencia_auditoria_familia ON assistencia_auditoria (familia_id, created_at DESC) WHERE familia_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_assistencia_auditoria_usuario ON assistencia_auditoria (usuario_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION assistencia_auditoria_imutavel() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'assistencia_auditoria é somente de inserção';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS assistencia_auditoria_imutavel ON assistencia_auditoria;
+CREATE TRIGGER assistencia_auditoria_imutavel
+    BEFORE UPDATE OR DELETE ON assistencia_auditoria
+    FOR EACH ROW EXECUTE FUNCTION assistencia_auditoria_imutavel();
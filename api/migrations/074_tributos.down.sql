@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tributos_guias;
+DROP TABLE IF EXISTS tributos_config;
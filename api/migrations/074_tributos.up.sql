@@ -0,0 +1,32 @@
+-- Integração de cada prefeitura com o seu sistema tributário (IPTU/ISS). O token fica restrito ao
+-- backend; o painel do tenant admin só enxerga se ele está preenchido.
+CREATE TABLE IF NOT EXISTS tributos_config (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    api_base TEXT,
+    api_token TEXT,
+    convenio TEXT NOT NULL CHECK (convenio ~ '^[0-9]{4}$'),
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Segunda via emitida pelo cidadão. Os débitos ficam congelados como foram cobrados e o PDF só é
+-- guardado quando o fornecedor devolve o documento oficial; sem ele a guia é redesenhada.
+CREATE TABLE IF NOT EXISTS tributos_guias (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    cidadao_id UUID NOT NULL REFERENCES cidadaos(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    documento TEXT NOT NULL,
+    nosso_numero TEXT NOT NULL,
+    valor NUMERIC(14,2) NOT NULL CHECK (valor > 0),
+    vencimento DATE NOT NULL,
+    codigo_barras TEXT NOT NULL CHECK (codigo_barras ~ '^[0-9]{44}$'),
+    linha_digitavel TEXT NOT NULL,
+    debitos JSONB NOT NULL DEFAULT '[]'::jsonb,
+    pdf BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tributos_guias_cidadao ON tributos_guias (tenant_id, cidadao_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS documentos_emitidos;
+DROP FUNCTION IF EXISTS documentos_emitidos_imutavel();
@@ -0,0 +1,44 @@
+-- Documentos digitais emitidos pelas secretarias. hash e assinatura cobrem os campos da emissão
+-- (ver documento.canonical); depois de gravada a linha só recebe a revogação.
+CREATE TABLE IF NOT EXISTS documentos_emitidos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id),
+    tipo TEXT NOT NULL CHECK (tipo IN ('certidao','declaracao','declaracao_matricula')),
+    titulo TEXT NOT NULL,
+    destinatario_nome TEXT NOT NULL,
+    destinatario_documento TEXT,
+    aluno_id UUID REFERENCES alunos(id) ON DELETE SET NULL,
+    conteudo TEXT NOT NULL,
+    codigo TEXT NOT NULL UNIQUE,
+    hash TEXT NOT NULL,
+    assinatura TEXT NOT NULL,
+    emitido_por UUID NOT NULL,
+    emitido_em TIMESTAMPTZ NOT NULL,
+    valido_ate TIMESTAMPTZ,
+    revogado_em TIMESTAMPTZ,
+    revogado_por UUID,
+    motivo_revogacao TEXT,
+    CHECK ((revogado_em IS NULL) = (motivo_revogacao IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_documentos_emitidos_tenant ON documentos_emitidos (tenant_id, emitido_em DESC);
+CREATE INDEX IF NOT EXISTS idx_documentos_emitidos_aluno ON documentos_emitidos (aluno_id) WHERE aluno_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION documentos_emitidos_imutavel() RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.tenant_id, NEW.secretaria_id, NEW.tipo, NEW.titulo, NEW.destinatario_nome, NEW.destinatario_documento,
+        NEW.conteudo, NEW.codigo, NEW.hash, NEW.assinatura, NEW.emitido_por, NEW.emitido_em, NEW.valido_ate)
+       IS DISTINCT FROM
+       (OLD.tenant_id, OLD.secretaria_id, OLD.tipo, OLD.titulo, OLD.destinatario_nome, OLD.destinatario_documento,
+        OLD.conteudo, OLD.codigo, OLD.hash, OLD.assinatura, OLD.emitido_por, OLD.emitido_em, OLD.valido_ate) THEN
+        RAISE EXCEPTION 'documento emitido não pode ser alterado, apenas revogado';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS documentos_emitidos_imutavel ON documentos_emitidos;
+CREATE TRIGGER documentos_emitidos_imutavel
+    BEFORE UPDATE ON documentos_emitidos
+    FOR EACH ROW EXECUTE FUNCTION documentos_emitidos_imutavel();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS senhas;
+DROP TABLE IF EXISTS senha_contadores;
+DROP TABLE IF EXISTS senha_totens;
+DROP TABLE IF EXISTS senha_filas;
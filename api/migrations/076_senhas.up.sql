@@ -0,0 +1,68 @@
+-- Atendimento presencial por senhas. Cada fila é um serviço de uma secretaria; a numeração
+-- reinicia por dia (horário de Brasília) e vem de senha_contadores, sem corrida entre emissões.
+CREATE TABLE IF NOT EXISTS senha_filas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    prefixo TEXT NOT NULL CHECK (prefixo ~ '^[A-Z]{1,3}$'),
+    local TEXT,
+    ativa BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_senha_filas_prefixo ON senha_filas (tenant_id, prefixo);
+
+-- Totens de autoatendimento; o token só existe no aparelho, aqui fica o hash.
+CREATE TABLE IF NOT EXISTS senha_totens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revogado_em TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_senha_totens_tenant ON senha_totens (tenant_id);
+
+CREATE TABLE IF NOT EXISTS senha_contadores (
+    fila_id UUID NOT NULL REFERENCES senha_filas(id) ON DELETE CASCADE,
+    dia DATE NOT NULL,
+    ultimo INT NOT NULL,
+    PRIMARY KEY (fila_id, dia)
+);
+
+CREATE TABLE IF NOT EXISTS senhas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    fila_id UUID NOT NULL REFERENCES senha_filas(id) ON DELETE CASCADE,
+    dia DATE NOT NULL,
+    numero INT NOT NULL,
+    codigo TEXT NOT NULL,
+    preferencial BOOLEAN NOT NULL DEFAULT FALSE,
+    origem TEXT NOT NULL CHECK (origem IN ('app','totem')),
+    cidadao_id UUID REFERENCES cidadaos(id) ON DELETE SET NULL,
+    totem_id UUID REFERENCES senha_totens(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'aguardando' CHECK (status IN ('aguardando','chamada','atendida','ausente','cancelada')),
+    guiche TEXT,
+    atendente_id UUID,
+    chamadas INT NOT NULL DEFAULT 0,
+    emitida_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- chamada_em é a primeira chamada (fim da espera); ultima_chamada_em ordena o painel.
+    chamada_em TIMESTAMPTZ,
+    ultima_chamada_em TIMESTAMPTZ,
+    finalizada_em TIMESTAMPTZ,
+    UNIQUE (fila_id, dia, numero)
+);
+
+CREATE INDEX IF NOT EXISTS idx_senhas_aguardando ON senhas (fila_id, dia, preferencial DESC, emitida_em) WHERE status = 'aguardando';
+CREATE INDEX IF NOT EXISTS idx_senhas_painel ON senhas (tenant_id, ultima_chamada_em DESC) WHERE ultima_chamada_em IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_senhas_tenant_emitida ON senhas (tenant_id, emitida_em);
+CREATE INDEX IF NOT EXISTS idx_senhas_cidadao ON senhas (cidadao_id, emitida_em DESC) WHERE cidadao_id IS NOT NULL;
+-- Pelo app, uma senha ativa por cidadão e fila no dia.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_senhas_cidadao_ativa ON senhas (fila_id, dia, cidadao_id)
+    WHERE cidadao_id IS NOT NULL AND status IN ('aguardando','chamada');
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS evento_inscricoes;
+DROP TABLE IF EXISTS eventos;
@@ -0,0 +1,50 @@
+-- Eventos com inscrição (cursos, dias de vacinação, palestras). A capacidade conta só as
+-- inscrições confirmadas; as demais aguardam na espera por ordem de chegada.
+CREATE TABLE IF NOT EXISTS eventos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secretaria_id UUID NOT NULL REFERENCES secretarias(id) ON DELETE CASCADE,
+    titulo TEXT NOT NULL,
+    descricao TEXT,
+    tipo TEXT NOT NULL CHECK (tipo IN ('curso','vacinacao','palestra','outro')),
+    local TEXT NOT NULL,
+    inicio TIMESTAMPTZ NOT NULL,
+    fim TIMESTAMPTZ,
+    capacidade INT NOT NULL CHECK (capacidade > 0),
+    inscricoes_ate TIMESTAMPTZ,
+    lembrete_horas INT NOT NULL DEFAULT 24 CHECK (lembrete_horas BETWEEN 0 AND 168),
+    -- lembrete_enviado_em evita repetir o lembrete; volta a NULL quando o início muda.
+    lembrete_enviado_em TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'publicado' CHECK (status IN ('publicado','cancelado')),
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eventos_tenant_inicio ON eventos (tenant_id, inicio);
+CREATE INDEX IF NOT EXISTS idx_eventos_lembrete ON eventos (inicio) WHERE status = 'publicado' AND lembrete_enviado_em IS NULL;
+
+CREATE TABLE IF NOT EXISTS evento_inscricoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    evento_id UUID NOT NULL REFERENCES eventos(id) ON DELETE CASCADE,
+    cidadao_id UUID NOT NULL REFERENCES cidadaos(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    email TEXT,
+    status TEXT NOT NULL CHECK (status IN ('confirmada','espera','cancelada')),
+    codigo TEXT NOT NULL,
+    checkin_em TIMESTAMPTZ,
+    checkin_por UUID,
+    -- promovida_em marca a saída da espera; aviso_promocao_em, o aviso já enviado ao cidadão.
+    promovida_em TIMESTAMPTZ,
+    aviso_promocao_em TIMESTAMPTZ,
+    cancelada_em TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (evento_id, codigo)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_evento_inscricoes_ativa ON evento_inscricoes (evento_id, cidadao_id) WHERE status <> 'cancelada';
+CREATE INDEX IF NOT EXISTS idx_evento_inscricoes_espera ON evento_inscricoes (evento_id, created_at) WHERE status = 'espera';
+CREATE INDEX IF NOT EXISTS idx_evento_inscricoes_cidadao ON evento_inscricoes (cidadao_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_evento_inscricoes_promocao ON evento_inscricoes (promovida_em) WHERE promovida_em IS NOT NULL AND aviso_promocao_em IS NULL;
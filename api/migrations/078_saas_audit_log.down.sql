@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saas_audit_log;
+DROP FUNCTION IF EXISTS saas_audit_log_imutavel();
@@ -0,0 +1,38 @@
+-- Trilha de auditoria das ações de escrita do painel SaaS (cadastro de tenants, contratos,
+-- integrações, usuários). Sem chave estrangeira para tenants e saas_users: o registro sobrevive à
+-- exclusão do que descreve. É só de inserção; o trigger recusa alteração e exclusão.
+CREATE TABLE IF NOT EXISTS saas_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID,
+    actor_roles TEXT[] NOT NULL DEFAULT '{}',
+    action TEXT NOT NULL,
+    entity TEXT NOT NULL DEFAULT '',
+    entity_id TEXT,
+    tenant_id UUID,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INT NOT NULL,
+    before JSONB,
+    after JSONB,
+    diff JSONB,
+    ip TEXT,
+    user_agent TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saas_audit_log_created ON saas_audit_log (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_saas_audit_log_actor ON saas_audit_log (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_saas_audit_log_tenant ON saas_audit_log (tenant_id, created_at DESC) WHERE tenant_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_saas_audit_log_entity ON saas_audit_log (entity, entity_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION saas_audit_log_imutavel() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'saas_audit_log é somente de inserção';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS saas_audit_log_imutavel ON saas_audit_log;
+CREATE TRIGGER saas_audit_log_imutavel
+    BEFORE UPDATE OR DELETE ON saas_audit_log
+    FOR EACH ROW EXECUTE FUNCTION saas_audit_log_imutavel();
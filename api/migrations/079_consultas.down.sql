@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS consulta_votos;
+DROP TABLE IF EXISTS consulta_eleitores;
+DROP TABLE IF EXISTS consulta_propostas;
+DROP TABLE IF EXISTS consultas;
@@ -0,0 +1,57 @@
+-- Consultas públicas e orçamento participativo. O eleitor (HMAC do CPF por consulta) e a cédula
+-- ficam em tabelas separadas: consulta_votos não tem cidadão, origem nem horário, só o dia.
+CREATE TABLE IF NOT EXISTS consultas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    titulo TEXT NOT NULL,
+    descricao TEXT,
+    inicio TIMESTAMPTZ NOT NULL,
+    fim TIMESTAMPTZ NOT NULL,
+    max_escolhas INT NOT NULL DEFAULT 1 CHECK (max_escolhas BETWEEN 1 AND 20),
+    -- limite_por_origem: cédulas aceitas da mesma origem de rede; zero desliga.
+    limite_por_origem INT NOT NULL DEFAULT 30 CHECK (limite_por_origem >= 0),
+    resultados_parciais BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'rascunho' CHECK (status IN ('rascunho','publicada','cancelada')),
+    publicada_em TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (fim > inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consultas_tenant_inicio ON consultas (tenant_id, inicio DESC);
+
+CREATE TABLE IF NOT EXISTS consulta_propostas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    consulta_id UUID NOT NULL REFERENCES consultas(id) ON DELETE CASCADE,
+    titulo TEXT NOT NULL,
+    descricao TEXT,
+    bairro TEXT,
+    valor_estimado NUMERIC(14,2) CHECK (valor_estimado >= 0),
+    ordem INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_consulta_propostas_consulta ON consulta_propostas (consulta_id, ordem);
+
+CREATE TABLE IF NOT EXISTS consulta_eleitores (
+    consulta_id UUID NOT NULL REFERENCES consultas(id) ON DELETE CASCADE,
+    eleitor_hash TEXT NOT NULL,
+    cidadao_id UUID NOT NULL,
+    origem_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (consulta_id, eleitor_hash),
+    UNIQUE (consulta_id, cidadao_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consulta_eleitores_origem ON consulta_eleitores (consulta_id, origem_hash);
+
+CREATE TABLE IF NOT EXISTS consulta_votos (
+    consulta_id UUID NOT NULL REFERENCES consultas(id) ON DELETE CASCADE,
+    cedula UUID NOT NULL,
+    proposta_id UUID NOT NULL REFERENCES consulta_propostas(id) ON DELETE CASCADE,
+    dia DATE NOT NULL,
+    PRIMARY KEY (cedula, proposta_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consulta_votos_consulta ON consulta_votos (consulta_id, proposta_id);
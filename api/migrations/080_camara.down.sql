@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS camara_documentos;
+DROP TABLE IF EXISTS camara_pauta_itens;
+DROP TABLE IF EXISTS camara_sessoes;
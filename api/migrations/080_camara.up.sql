@@ -0,0 +1,55 @@
+-- Agenda legislativa da câmara municipal: sessões, itens de pauta com o resultado da votação e
+-- documentos públicos. Só sessões publicadas aparecem na API pública.
+CREATE TABLE IF NOT EXISTS camara_sessoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL DEFAULT 'ordinaria' CHECK (tipo IN ('ordinaria','extraordinaria','solene','audiencia_publica')),
+    numero INT NOT NULL DEFAULT 0 CHECK (numero >= 0),
+    titulo TEXT NOT NULL,
+    inicio TIMESTAMPTZ NOT NULL,
+    local TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'agendada' CHECK (status IN ('agendada','realizada','adiada','cancelada')),
+    transmissao_url TEXT,
+    publicada BOOLEAN NOT NULL DEFAULT FALSE,
+    publicada_em TIMESTAMPTZ,
+    ata_resumo TEXT,
+    ata_aprovada_em DATE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_camara_sessoes_tenant_inicio ON camara_sessoes (tenant_id, inicio DESC);
+
+CREATE TABLE IF NOT EXISTS camara_pauta_itens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sessao_id UUID NOT NULL REFERENCES camara_sessoes(id) ON DELETE CASCADE,
+    ordem INT NOT NULL DEFAULT 0,
+    tipo TEXT NOT NULL DEFAULT 'outro' CHECK (tipo IN ('projeto_lei','requerimento','indicacao','mocao','veto','outro')),
+    numero TEXT,
+    ementa TEXT NOT NULL,
+    autor TEXT,
+    resultado TEXT CHECK (resultado IN ('aprovado','rejeitado','adiado','retirado')),
+    votos_sim INT CHECK (votos_sim >= 0),
+    votos_nao INT CHECK (votos_nao >= 0),
+    abstencoes INT CHECK (abstencoes >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_camara_pauta_sessao ON camara_pauta_itens (sessao_id, ordem);
+
+CREATE TABLE IF NOT EXISTS camara_documentos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sessao_id UUID NOT NULL REFERENCES camara_sessoes(id) ON DELETE CASCADE,
+    item_id UUID REFERENCES camara_pauta_itens(id) ON DELETE SET NULL,
+    tipo TEXT NOT NULL DEFAULT 'outro' CHECK (tipo IN ('pauta','ata','projeto','parecer','outro')),
+    titulo TEXT NOT NULL,
+    file_url TEXT NOT NULL,
+    file_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    uploaded_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_camara_documentos_sessao ON camara_documentos (sessao_id, created_at);
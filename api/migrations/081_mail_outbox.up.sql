@@ -0,0 +1,26 @@
+-- Fila de e-mails de saída: cada linha é uma mensagem pronta, enviada pelo worker com novas
+-- tentativas e espera crescente; o histórico guarda provedor e identificador da entrega.
+CREATE TABLE IF NOT EXISTS mail_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template TEXT,
+    from_address TEXT,
+    to_addresses TEXT[] NOT NULL,
+    reply_to TEXT,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    message_id TEXT,
+    in_reply_to TEXT,
+    "references" TEXT[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued','sending','sent','failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    provider TEXT,
+    provider_message_id TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_pending ON mail_outbox (next_attempt_at) WHERE status IN ('queued','sending');
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_created ON mail_outbox (created_at DESC);
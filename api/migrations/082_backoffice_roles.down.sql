@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backoffice_role_membros;
+DROP TABLE IF EXISTS backoffice_roles;
@@ -0,0 +1,24 @@
+-- Papéis personalizados do backoffice: cada prefeitura combina permissões granulares do catálogo
+-- (internal/authz) e vincula membros da equipe. Os papéis fixos de usuarios_secretarias continuam
+-- valendo e concedem todas as permissões.
+CREATE TABLE IF NOT EXISTS backoffice_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    codigo TEXT NOT NULL,
+    nome TEXT NOT NULL,
+    descricao TEXT,
+    permissoes TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, codigo)
+);
+
+CREATE TABLE IF NOT EXISTS backoffice_role_membros (
+    role_id UUID NOT NULL REFERENCES backoffice_roles(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (role_id, usuario_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_backoffice_role_membros_usuario ON backoffice_role_membros (usuario_id);
@@ -0,0 +1,5 @@
+ALTER TABLE protocolo_eventos
+    DROP COLUMN IF EXISTS em_nome_de_id,
+    DROP COLUMN IF EXISTS delegacao_id;
+DROP TABLE IF EXISTS delegacao_acoes;
+DROP TABLE IF EXISTS delegacoes;
@@ -0,0 +1,36 @@
+-- Delegação de competência: o secretário transfere parte das permissões do backoffice para outro
+-- usuário da prefeitura durante um período (férias, licenças). As escritas feitas sob a delegação
+-- ficam registradas nela e no histórico dos protocolos.
+CREATE TABLE IF NOT EXISTS delegacoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    delegante_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    delegado_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    permissoes TEXT[] NOT NULL,
+    inicio DATE NOT NULL,
+    fim DATE NOT NULL,
+    motivo TEXT,
+    revogada_em TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (fim >= inicio),
+    CHECK (delegado_id <> delegante_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_delegacoes_delegado ON delegacoes (delegado_id, fim) WHERE revogada_em IS NULL;
+CREATE INDEX IF NOT EXISTS idx_delegacoes_delegante ON delegacoes (tenant_id, delegante_id, inicio DESC);
+
+CREATE TABLE IF NOT EXISTS delegacao_acoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delegacao_id UUID NOT NULL REFERENCES delegacoes(id) ON DELETE CASCADE,
+    usuario_id UUID NOT NULL,
+    metodo TEXT NOT NULL,
+    rota TEXT NOT NULL,
+    status INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delegacao_acoes_delegacao ON delegacao_acoes (delegacao_id, created_at DESC);
+
+ALTER TABLE protocolo_eventos
+    ADD COLUMN IF NOT EXISTS delegacao_id UUID REFERENCES delegacoes(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS em_nome_de_id UUID;
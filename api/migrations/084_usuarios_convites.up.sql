@@ -0,0 +1,14 @@
+-- Convites de primeiro acesso da equipe municipal cadastrada por importação: o usuário nasce com
+-- senha aleatória e define a própria pelo link do e-mail. Só o hash do token é guardado.
+CREATE TABLE IF NOT EXISTS usuarios_convites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    usuario_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usuarios_convites_usuario ON usuarios_convites (usuario_id);
@@ -0,0 +1,14 @@
+DELETE FROM usuarios_secretarias WHERE papel = 'AUDITOR';
+ALTER TABLE usuarios_secretarias DROP CONSTRAINT IF EXISTS usuarios_secretarias_papel_check;
+ALTER TABLE usuarios_secretarias
+    ADD CONSTRAINT usuarios_secretarias_papel_check CHECK (papel IN ('ATENDENTE', 'SECRETARIO', 'PREFEITO', 'ADMIN_TEC'));
+
+DELETE FROM saas_user_invites WHERE role = 'auditor';
+ALTER TABLE saas_user_invites DROP CONSTRAINT IF EXISTS saas_user_invites_role_check;
+ALTER TABLE saas_user_invites
+    ADD CONSTRAINT saas_user_invites_role_check CHECK (role IN ('owner', 'admin', 'support', 'finance'));
+
+UPDATE saas_users SET role = 'support' WHERE role = 'auditor';
+ALTER TABLE saas_users DROP CONSTRAINT IF EXISTS saas_users_role_check;
+ALTER TABLE saas_users
+    ADD CONSTRAINT saas_users_role_check CHECK (role IN ('owner', 'admin', 'support', 'finance'));
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS saas_audit_anchors_imutavel ON saas_audit_anchors;
+DROP FUNCTION IF EXISTS saas_audit_anchors_imutavel();
+DROP TABLE IF EXISTS saas_audit_anchors;
+
+DROP INDEX IF EXISTS idx_saas_audit_log_seq;
+ALTER TABLE saas_audit_log
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,31 @@
+-- Encadeia a trilha do SaaS: cada registro guarda o hash do anterior e o próprio hash, calculados
+-- pela API. Registros anteriores a esta migração ficam fora da cadeia (seq nulo).
+ALTER TABLE saas_audit_log
+    ADD COLUMN IF NOT EXISTS seq BIGINT,
+    ADD COLUMN IF NOT EXISTS prev_hash TEXT,
+    ADD COLUMN IF NOT EXISTS hash TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saas_audit_log_seq ON saas_audit_log (seq) WHERE seq IS NOT NULL;
+
+-- Âncoras publicadas no armazenamento de objetos; a cópia no banco serve à consulta e à verificação.
+CREATE TABLE IF NOT EXISTS saas_audit_anchors (
+    seq BIGINT PRIMARY KEY,
+    hash TEXT NOT NULL,
+    entry_created_at TIMESTAMPTZ NOT NULL,
+    anchored_at TIMESTAMPTZ NOT NULL,
+    object_key TEXT NOT NULL,
+    object_url TEXT,
+    prev_seq BIGINT,
+    prev_hash TEXT
+);
+
+CREATE OR REPLACE FUNCTION saas_audit_anchors_imutavel() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'saas_audit_anchors é somente de inserção';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS saas_audit_anchors_imutavel ON saas_audit_anchors;
+CREATE TRIGGER saas_audit_anchors_imutavel
+    BEFORE UPDATE OR DELETE ON saas_audit_anchors
+    FOR EACH ROW EXECUTE FUNCTION saas_audit_anchors_imutavel();
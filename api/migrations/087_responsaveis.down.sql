@@ -0,0 +1,10 @@
+DELETE FROM tokens_refresh WHERE audience = 'responsavel';
+ALTER TABLE tokens_refresh
+    DROP CONSTRAINT IF EXISTS tokens_refresh_audience_check;
+ALTER TABLE tokens_refresh
+    ADD CONSTRAINT tokens_refresh_audience_check
+    CHECK (audience IN ('backoffice', 'cidadao', 'saas'));
+
+DROP TABLE IF EXISTS comunicados;
+DROP TABLE IF EXISTS responsaveis_alunos;
+DROP TABLE IF EXISTS responsaveis;
@@ -0,0 +1,43 @@
+-- Portal das famílias: responsáveis têm login próprio e enxergam só os alunos vinculados pela
+-- gestão da escola.
+CREATE TABLE IF NOT EXISTS responsaveis (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    nome TEXT NOT NULL,
+    email TEXT NOT NULL,
+    telefone TEXT,
+    senha_hash TEXT,
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_responsaveis_email ON responsaveis (lower(email));
+CREATE INDEX IF NOT EXISTS idx_responsaveis_tenant ON responsaveis (tenant_id);
+
+CREATE TABLE IF NOT EXISTS responsaveis_alunos (
+    responsavel_id UUID NOT NULL REFERENCES responsaveis(id) ON DELETE CASCADE,
+    aluno_id UUID NOT NULL REFERENCES alunos(id) ON DELETE CASCADE,
+    parentesco TEXT,
+    vinculado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (responsavel_id, aluno_id)
+);
+CREATE INDEX IF NOT EXISTS idx_responsaveis_alunos_aluno ON responsaveis_alunos (aluno_id);
+
+-- Comunicados da escola às famílias; sem turma valem para a escola inteira.
+CREATE TABLE IF NOT EXISTS comunicados (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    escola_id UUID NOT NULL REFERENCES escolas(id) ON DELETE CASCADE,
+    turma_id UUID REFERENCES turmas(id) ON DELETE CASCADE,
+    titulo TEXT NOT NULL,
+    corpo TEXT NOT NULL,
+    autor_id UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    publicado_em TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_comunicados_escola ON comunicados (escola_id, publicado_em DESC);
+CREATE INDEX IF NOT EXISTS idx_comunicados_turma ON comunicados (turma_id, publicado_em DESC) WHERE turma_id IS NOT NULL;
+
+ALTER TABLE tokens_refresh
+    DROP CONSTRAINT IF EXISTS tokens_refresh_audience_check;
+ALTER TABLE tokens_refresh
+    ADD CONSTRAINT tokens_refresh_audience_check
+    CHECK (audience IN ('backoffice', 'cidadao', 'saas', 'responsavel'));
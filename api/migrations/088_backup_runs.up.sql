@@ -0,0 +1,19 @@
+-- Execuções do orquestrador de backups (cmd/backup): dumps lógicos por schema ou por tenant e
+-- testes de restauração. O arquivo fica no armazenamento de objetos; aqui só o registro.
+CREATE TABLE IF NOT EXISTS backup_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tipo TEXT NOT NULL CHECK (tipo IN ('dump', 'restore_drill')),
+    escopo TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'success', 'failed', 'expired')),
+    object_key TEXT,
+    bytes BIGINT,
+    sha256 TEXT,
+    backup_id UUID REFERENCES backup_runs(id) ON DELETE SET NULL,
+    erro TEXT,
+    detalhes JSONB NOT NULL DEFAULT '{}'::jsonb,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    expired_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_backup_runs_escopo ON backup_runs (tipo, escopo, started_at DESC);
@@ -0,0 +1,26 @@
+-- Avisos de falta por SMS/WhatsApp aos responsáveis. A chamada enfileira um aviso por aluno,
+-- responsável e dia; o worker entrega após o atraso configurado pela prefeitura e guarda o
+-- resultado de cada tentativa. A adesão fica em tenants.settings->'notificacoes_faltas'.
+CREATE TABLE IF NOT EXISTS falta_notificacoes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    aula_id UUID REFERENCES aulas(id) ON DELETE SET NULL,
+    aluno_id UUID NOT NULL REFERENCES alunos(id) ON DELETE CASCADE,
+    responsavel_id UUID NOT NULL REFERENCES responsaveis(id) ON DELETE CASCADE,
+    data DATE NOT NULL,
+    canal TEXT NOT NULL CHECK (canal IN ('sms', 'whatsapp')),
+    telefone TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'sending', 'sent', 'failed', 'cancelled')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    provider TEXT,
+    provider_message_id TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (responsavel_id, aluno_id, data)
+);
+
+CREATE INDEX IF NOT EXISTS idx_falta_notificacoes_pending ON falta_notificacoes (next_attempt_at) WHERE status IN ('queued', 'sending');
+CREATE INDEX IF NOT EXISTS idx_falta_notificacoes_tenant ON falta_notificacoes (tenant_id, created_at DESC);
@@ -0,0 +1,30 @@
+-- Roteamento de banco por tenant e migrações blue/green entre clusters. db_cluster aponta para
+-- um nome de DB_CLUSTERS ('primary' é o banco de DB_DSN). Cada migração copia os dados do
+-- tenant, confere contagens e checksums, troca o roteamento e por fim apaga a cópia antiga.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS db_cluster TEXT NOT NULL DEFAULT 'primary';
+
+CREATE TABLE IF NOT EXISTS tenant_migrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    origem TEXT NOT NULL,
+    destino TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'copied', 'verified', 'switched', 'retired', 'cancelled')),
+    -- Tabelas extras que ficam fora da cópia, além das do plano de controle.
+    excluir TEXT[] NOT NULL DEFAULT '{}',
+    tabelas JSONB NOT NULL DEFAULT '[]'::jsonb,
+    erro TEXT,
+    solicitado_por TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    copied_at TIMESTAMPTZ,
+    verified_at TIMESTAMPTZ,
+    switched_at TIMESTAMPTZ,
+    retired_at TIMESTAMPTZ,
+    CHECK (origem <> destino)
+);
+
+-- Só uma migração em andamento por tenant.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_migrations_ativa ON tenant_migrations (tenant_id)
+    WHERE status IN ('pending', 'copied', 'verified', 'switched');
+CREATE INDEX IF NOT EXISTS idx_tenant_migrations_created ON tenant_migrations (created_at DESC);
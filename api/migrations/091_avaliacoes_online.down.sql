@@ -0,0 +1,12 @@
+DELETE FROM tokens_refresh WHERE audience = 'aluno';
+ALTER TABLE tokens_refresh
+    DROP CONSTRAINT IF EXISTS tokens_refresh_audience_check;
+ALTER TABLE tokens_refresh
+    ADD CONSTRAINT tokens_refresh_audience_check
+    CHECK (audience IN ('backoffice', 'cidadao', 'saas', 'responsavel'));
+
+DROP TABLE IF EXISTS aval_tentativas;
+ALTER TABLE avaliacoes
+    DROP COLUMN IF EXISTS duracao_minutos,
+    DROP COLUMN IF EXISTS online;
+ALTER TABLE alunos DROP COLUMN IF EXISTS senha_hash;
@@ -0,0 +1,29 @@
+-- Avaliação online: alunos entram com matrícula e senha definida pela gestão da escola, respondem
+-- dentro da janela da avaliação e as questões objetivas são corrigidas na entrega.
+ALTER TABLE alunos ADD COLUMN IF NOT EXISTS senha_hash TEXT;
+
+ALTER TABLE avaliacoes
+    ADD COLUMN IF NOT EXISTS online BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS duracao_minutos INT CHECK (duracao_minutos > 0);
+
+-- Uma tentativa por aluno; prazo é o menor entre o fim da avaliação e o início mais a duração.
+CREATE TABLE IF NOT EXISTS aval_tentativas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    avaliacao_id UUID NOT NULL REFERENCES avaliacoes(id) ON DELETE CASCADE,
+    matricula_id UUID NOT NULL REFERENCES matriculas(id) ON DELETE CASCADE,
+    iniciada_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+    prazo TIMESTAMPTZ,
+    entregue_em TIMESTAMPTZ,
+    acertos INT,
+    objetivas INT,
+    pendentes INT,
+    nota NUMERIC(5,2),
+    UNIQUE (avaliacao_id, matricula_id)
+);
+CREATE INDEX IF NOT EXISTS idx_aval_tentativas_abertas ON aval_tentativas (prazo) WHERE entregue_em IS NULL;
+
+ALTER TABLE tokens_refresh
+    DROP CONSTRAINT IF EXISTS tokens_refresh_audience_check;
+ALTER TABLE tokens_refresh
+    ADD CONSTRAINT tokens_refresh_audience_check
+    CHECK (audience IN ('backoffice', 'cidadao', 'saas', 'responsavel', 'aluno'));
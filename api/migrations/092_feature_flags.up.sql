@@ -0,0 +1,10 @@
+-- Feature flags globais. Além de ligar código novo aos poucos, acoplam as migrações de
+-- contração (cmd/migrate): uma migração com "-- migrate:requires-flag nome" só roda depois que a
+-- flag estiver ligada, ou seja, quando nenhuma versão da API em produção depende mais do schema
+-- antigo.
+CREATE TABLE IF NOT EXISTS feature_flags (
+    nome TEXT PRIMARY KEY,
+    ativa BOOLEAN NOT NULL DEFAULT FALSE,
+    descricao TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
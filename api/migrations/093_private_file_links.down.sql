@@ -0,0 +1,2 @@
+UPDATE saas_tenant_contract_versions SET file_url = '' WHERE file_url IS NULL;
+ALTER TABLE saas_tenant_contract_versions ALTER COLUMN file_url SET NOT NULL;
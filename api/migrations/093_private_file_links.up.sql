@@ -0,0 +1,3 @@
+-- Contratos, notas e anexos financeiros passam a ser servidos só por links assinados de curta
+-- duração; a versão do contrato deixa de exigir a URL permanente do bucket.
+ALTER TABLE saas_tenant_contract_versions ALTER COLUMN file_url DROP NOT NULL;
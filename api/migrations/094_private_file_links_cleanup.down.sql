@@ -0,0 +1,2 @@
+-- As URLs apagadas não são recuperáveis; os arquivos continuam acessíveis pela chave do objeto.
+SELECT 1;
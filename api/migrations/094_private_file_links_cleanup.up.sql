@@ -0,0 +1,8 @@
+-- migrate:phase contract
+-- migrate:requires-flag links_assinados
+-- Apaga as URLs públicas gravadas antes dos links assinados. Só roda depois que nenhuma
+-- instância antiga da API, que ainda lia file_url, estiver em produção.
+UPDATE saas_finance_attachments SET file_url = NULL WHERE object_key IS NOT NULL;
+UPDATE saas_tenant_contract_versions SET file_url = NULL WHERE file_key IS NOT NULL;
+UPDATE saas_tenant_contracts SET contract_file_url = NULL WHERE contract_file_key IS NOT NULL;
+UPDATE saas_tenant_invoices SET file_url = NULL WHERE file_key IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE tokens_refresh DROP COLUMN IF EXISTS metodo;
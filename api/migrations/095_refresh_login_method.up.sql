@@ -0,0 +1,3 @@
+-- Guarda como a sessão do backoffice começou (senha ou passkey) para que a renovação reaplique a
+-- política da prefeitura; linhas antigas ficam nulas e contam como login por senha.
+ALTER TABLE tokens_refresh ADD COLUMN IF NOT EXISTS metodo TEXT;
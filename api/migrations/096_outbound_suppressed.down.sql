@@ -0,0 +1,7 @@
+ALTER TABLE falta_notificacoes DROP CONSTRAINT IF EXISTS falta_notificacoes_status_check;
+ALTER TABLE falta_notificacoes ADD CONSTRAINT falta_notificacoes_status_check
+    CHECK (status IN ('queued', 'sending', 'sent', 'failed', 'cancelled')) NOT VALID;
+ALTER TABLE mail_outbox DROP CONSTRAINT IF EXISTS mail_outbox_status_check;
+ALTER TABLE mail_outbox ADD CONSTRAINT mail_outbox_status_check
+    CHECK (status IN ('queued','sending','sent','failed')) NOT VALID;
+ALTER TABLE mail_outbox DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,10 @@
+-- Mensagens de tenants sandbox não saem para os provedores: a fila de e-mail passa a saber a
+-- prefeitura de origem e e-mails e avisos de falta ganham o status suppressed, registrado no lugar
+-- do envio.
+ALTER TABLE mail_outbox ADD COLUMN IF NOT EXISTS tenant_id UUID;
+ALTER TABLE mail_outbox DROP CONSTRAINT IF EXISTS mail_outbox_status_check;
+ALTER TABLE mail_outbox ADD CONSTRAINT mail_outbox_status_check
+    CHECK (status IN ('queued','sending','sent','failed','suppressed')) NOT VALID;
+ALTER TABLE falta_notificacoes DROP CONSTRAINT IF EXISTS falta_notificacoes_status_check;
+ALTER TABLE falta_notificacoes ADD CONSTRAINT falta_notificacoes_status_check
+    CHECK (status IN ('queued', 'sending', 'sent', 'failed', 'cancelled', 'suppressed')) NOT VALID;
@@ -0,0 +1,3 @@
+-- Congela o tenant durante o switch entre clusters: com db_frozen o roteamento (db.Resolver)
+-- recusa as requisições do tenant até a troca de db_cluster terminar.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS db_frozen BOOLEAN NOT NULL DEFAULT false;
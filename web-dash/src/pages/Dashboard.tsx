@@ -1102,6 +1102,23 @@ export default function DashboardPage() {
     setMessage("Notas fiscais atualizadas.");
   };
 
+  // Contratos e notas são privados: pede um link assinado de curta duração e abre em nova aba.
+  const handleOpenPrivateFile = async (downloadPath: string) => {
+    const popup = window.open("", "_blank");
+    try {
+      const data = await authorizedFetch<{ url: string }>(`${downloadPath}/signed-url`);
+      if (popup) {
+        popup.opener = null;
+        popup.location.href = data.url;
+      } else {
+        window.location.assign(data.url);
+      }
+    } catch (err) {
+      popup?.close();
+      setError(err instanceof Error ? err.message : "Falha ao abrir arquivo");
+    }
+  };
+
   const handleRemoveInvoice = async (tenantId: string, attachmentId: string) => {
     try {
       await authorizedFetch(`/saas/tenants/${tenantId}/contract/invoices/${attachmentId}`, {
@@ -2814,13 +2831,15 @@ export default function DashboardPage() {
                 />
                 {selectedContract.contractFileUrl ? (
                   <p className="upload-item">
-                    <a
-                      href={selectedContract.contractFileUrl ?? "#"}
-                      target="_blank"
-                      rel="noopener noreferrer"
+                    <button
+                      type="button"
+                      onClick={() =>
+                        selectedContract.contractFileUrl &&
+                        handleOpenPrivateFile(selectedContract.contractFileUrl)
+                      }
                     >
                       Abrir contrato vigente
-                    </a>
+                    </button>
                   </p>
                 ) : (
                   <p className="muted">Anexe o PDF do contrato vigente.</p>
@@ -2845,9 +2864,12 @@ export default function DashboardPage() {
                         <span>{invoice.referenceMonth ?? invoice.name}</span>
                         <small>{invoice.uploadedAt}</small>
                         {invoice.url && (
-                          <a href={invoice.url} target="_blank" rel="noopener noreferrer">
+                          <button
+                            type="button"
+                            onClick={() => invoice.url && handleOpenPrivateFile(invoice.url)}
+                          >
                             Abrir
-                          </a>
+                          </button>
                         )}
                         <button
                           type="button"